@@ -0,0 +1,448 @@
+"""
+Public API of a repository and diffs between two of its snapshots.
+
+Exported declarations are extracted while a file is ingested and stored as
+ApiSymbol nodes with their normalized signatures, so the API at a revision is
+read back from its graph, whether ingested for the diff or loaded from a
+snapshot archive.
+"""
+
+import re
+from collections.abc import Iterable
+from dataclasses import asdict, dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+from tree_sitter import Node
+
+from ..language_config import LANGUAGE_CONFIGS
+from ..utils.visibility import is_exported
+
+# A release tag, optionally under a prefix such as a Go submodule's "api/"
+VERSION_TAG = re.compile(r"^(.*?v?)(\d+)\.(\d+)\.(\d+)$")
+
+
+@dataclass
+class ApiSymbol:
+    """An exported declaration and its normalized signature."""
+
+    qualified_name: str  # "<module>.<Name>" or "<module>.<Type>.<Method>"
+    name: str
+    kind: str  # function, method, class, struct, interface, type, const or var
+    signature: str
+    path: str
+    language: str
+    # Exported struct fields or interface methods, for kinds that have them
+    members: list[str] = field(default_factory=list)
+    # Go only: the signature's types without parameter names, e.g. "func(int) error"
+    type_signature: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class ApiChange:
+    """A symbol that was added, removed or changed between two revisions."""
+
+    qualified_name: str
+    kind: str
+    change: str  # added, removed or changed
+    path: str
+    old_signature: str | None = None
+    new_signature: str | None = None
+    removed_members: list[str] = field(default_factory=list)
+    added_members: list[str] = field(default_factory=list)
+    breaking: bool | None = None  # None until classified
+    reason: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class ApiDiff:
+    """Differences between the public API of two revisions."""
+
+    base: str
+    head: str
+    added: list[ApiChange] = field(default_factory=list)
+    removed: list[ApiChange] = field(default_factory=list)
+    changed: list[ApiChange] = field(default_factory=list)
+
+    @property
+    def is_empty(self) -> bool:
+        return not (self.added or self.removed or self.changed)
+
+    @property
+    def breaking_changes(self) -> list[ApiChange]:
+        return [
+            c for c in [*self.removed, *self.changed, *self.added] if c.breaking
+        ]
+
+    @property
+    def bump(self) -> str:
+        """
+        The semantic version increment the changes call for: major when any is
+        breaking, minor when symbols or members were added, otherwise patch.
+        """
+        if self.breaking_changes:
+            return "major"
+        if self.added or any(c.added_members for c in self.changed):
+            return "minor"
+        return "patch"
+
+    @property
+    def next_version(self) -> str | None:
+        return next_version(self.base, self.bump)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "base": self.base,
+            "head": self.head,
+            "summary": {
+                "added": len(self.added),
+                "removed": len(self.removed),
+                "changed": len(self.changed),
+                "breaking": len(self.breaking_changes),
+            },
+            "bump": self.bump,
+            "next_version": self.next_version,
+            "added": [c.to_dict() for c in self.added],
+            "removed": [c.to_dict() for c in self.removed],
+            "changed": [c.to_dict() for c in self.changed],
+        }
+
+
+def extract_api(root: Node, rel_path: str, language: str) -> list[ApiSymbol]:
+    """Extract the exported symbols declared in one parsed file."""
+    path = PurePosixPath(rel_path)
+    if language == "go":
+        # Go symbols belong to the package (directory), not the file
+        module = "" if path.parent == PurePosixPath() else path.parent.as_posix()
+        return _extract_go(root, module, rel_path)
+    module = path.with_suffix("").as_posix()
+    symbols: list[ApiSymbol] = []
+    _extract_generic(root, module, rel_path, language, None, symbols)
+    return symbols
+
+
+def api_snapshot(nodes: Iterable[dict[str, Any]]) -> dict[str, ApiSymbol]:
+    """The API recorded by a graph's ApiSymbol nodes, keyed by qualified name."""
+    snapshot = {}
+    for properties in nodes:
+        symbol = ApiSymbol(
+            qualified_name=properties["symbol"],
+            name=properties["name"],
+            kind=properties["kind"],
+            signature=properties["signature"],
+            path=properties["path"],
+            language=properties["language"],
+            members=list(properties.get("members") or []),
+            type_signature=properties.get("type_signature") or "",
+        )
+        snapshot[symbol.qualified_name] = symbol
+    return snapshot
+
+
+def _extract_generic(
+    node: Node,
+    module: str,
+    rel_path: str,
+    language: str,
+    class_name: str | None,
+    symbols: list[ApiSymbol],
+) -> None:
+    lang_config = LANGUAGE_CONFIGS[language]
+    for child in node.children:
+        if child.type in lang_config.class_node_types:
+            name = _node_name(child)
+            if name and is_exported(name, language):
+                symbols.append(
+                    ApiSymbol(
+                        qualified_name=f"{module}.{name}",
+                        name=name,
+                        kind="class",
+                        signature=_signature(child),
+                        path=rel_path,
+                        language=language,
+                    )
+                )
+            # Rust impl blocks have no name but scope the methods of a type
+            owner = name or _node_text(child.child_by_field_name("type"))
+            if owner and is_exported(owner, language):
+                _extract_generic(child, module, rel_path, language, owner, symbols)
+        elif child.type in lang_config.function_node_types:
+            name = _node_name(child)
+            is_static = language in ("c", "cpp") and _has_static_storage(child)
+            if not name or not is_exported(name, language, is_static):
+                continue
+            prefix = f"{module}.{class_name}" if class_name else module
+            symbols.append(
+                ApiSymbol(
+                    qualified_name=f"{prefix}.{name}",
+                    name=name,
+                    kind="method" if class_name else "function",
+                    signature=_signature(child),
+                    path=rel_path,
+                    language=language,
+                )
+            )
+        else:
+            _extract_generic(child, module, rel_path, language, class_name, symbols)
+
+
+def _extract_go(root: Node, module: str, rel_path: str) -> list[ApiSymbol]:
+    symbols = []
+
+    def add(
+        name: str,
+        kind: str,
+        signature: str,
+        members: list[str],
+        type_signature: str = "",
+    ) -> None:
+        symbols.append(
+            ApiSymbol(
+                qualified_name=f"{module}.{name}" if module else name,
+                name=name.split(".")[-1],
+                kind=kind,
+                signature=signature,
+                path=rel_path,
+                language="go",
+                members=members,
+                type_signature=type_signature,
+            )
+        )
+
+    for child in root.children:
+        if child.type == "function_declaration":
+            name = _node_name(child)
+            if name and is_exported(name, "go"):
+                add(
+                    name,
+                    "function",
+                    _signature(child),
+                    [],
+                    _go_type_signature(child),
+                )
+        elif child.type == "method_declaration":
+            name = _node_name(child)
+            receiver = _go_receiver_type(child)
+            if (
+                name
+                and receiver
+                and is_exported(name, "go")
+                and is_exported(receiver, "go")
+            ):
+                add(
+                    f"{receiver}.{name}",
+                    "method",
+                    _signature(child),
+                    [],
+                    _go_type_signature(child),
+                )
+        elif child.type == "type_declaration":
+            for spec in child.children:
+                if spec.type not in ("type_spec", "type_alias"):
+                    continue
+                name = _node_name(spec)
+                if not name or not is_exported(name, "go"):
+                    continue
+                kind, members = _go_type_members(spec.child_by_field_name("type"))
+                signature = (
+                    f"type {name} {kind}"
+                    if kind in ("struct", "interface")
+                    else f"type {_normalize(_node_text(spec))}"
+                )
+                add(name, kind, signature, members)
+        elif child.type in ("const_declaration", "var_declaration"):
+            kind = "const" if child.type == "const_declaration" else "var"
+            # Grouped declarations may nest their specs in a spec list
+            for spec in _descendants(child, f"{kind}_spec"):
+                spec_type = _normalize(_node_text(spec.child_by_field_name("type")))
+                for name_node in spec.children_by_field_name("name"):
+                    name = _node_text(name_node)
+                    if name and is_exported(name, "go"):
+                        signature = f"{kind} {_normalize(_node_text(spec))}"
+                        add(name, kind, signature, [], spec_type)
+    return symbols
+
+
+def diff_api(
+    old: dict[str, ApiSymbol],
+    new: dict[str, ApiSymbol],
+    base: str = "",
+    head: str = "",
+) -> ApiDiff:
+    """Compare two API snapshots keyed by qualified name."""
+    diff = ApiDiff(base=base, head=head)
+    for qn in sorted(new.keys() - old.keys()):
+        symbol = new[qn]
+        diff.added.append(
+            ApiChange(
+                qn, symbol.kind, "added", symbol.path, new_signature=symbol.signature
+            )
+        )
+    for qn in sorted(old.keys() - new.keys()):
+        symbol = old[qn]
+        diff.removed.append(
+            ApiChange(
+                qn, symbol.kind, "removed", symbol.path, old_signature=symbol.signature
+            )
+        )
+    for qn in sorted(old.keys() & new.keys()):
+        before, after = old[qn], new[qn]
+        removed_members = sorted(set(before.members) - set(after.members))
+        added_members = sorted(set(after.members) - set(before.members))
+        if before.signature == after.signature and not (
+            removed_members or added_members
+        ):
+            continue
+        diff.changed.append(
+            ApiChange(
+                qn,
+                after.kind,
+                "changed",
+                after.path,
+                old_signature=before.signature,
+                new_signature=after.signature,
+                removed_members=removed_members,
+                added_members=added_members,
+            )
+        )
+    return diff
+
+
+def next_version(base: str, bump: str) -> str | None:
+    """
+    The tag following a release tag such as v1.4.2 after a bump, or None when
+    base is not one. Before 1.0.0 a breaking change moves the minor version,
+    as semantic versioning and Go modules allow.
+    """
+    match = VERSION_TAG.match(base)
+    if not match:
+        return None
+    prefix = match[1]
+    major, minor, patch = (int(part) for part in match.groups()[1:])
+    if bump == "major" and major == 0:
+        bump = "minor"
+    if bump == "major":
+        major, minor, patch = major + 1, 0, 0
+    elif bump == "minor":
+        minor, patch = minor + 1, 0
+    else:
+        patch += 1
+    return f"{prefix}{major}.{minor}.{patch}"
+
+
+def _node_text(node: Node | None) -> str:
+    if node is None or node.text is None:
+        return ""
+    return node.text.decode("utf-8", errors="replace")
+
+
+def _normalize(text: str) -> str:
+    return re.sub(r"\s+", " ", text).strip()
+
+
+def _node_name(node: Node) -> str | None:
+    """Return a declaration's name, following C-style declarator chains."""
+    if name_node := node.child_by_field_name("name"):
+        return _node_text(name_node)
+    declarator = node.child_by_field_name("declarator")
+    while declarator is not None:
+        if declarator.type in ("identifier", "field_identifier"):
+            return _node_text(declarator)
+        declarator = declarator.child_by_field_name("declarator")
+    return None
+
+
+def _signature(node: Node) -> str:
+    """Return a declaration's header: its text up to the body, whitespace-normalized."""
+    text = _node_text(node)
+    body = node.child_by_field_name("body")
+    if body is not None:
+        text = text[: body.start_byte - node.start_byte]
+    return _normalize(text).rstrip("{: ")
+
+
+def _has_static_storage(node: Node) -> bool:
+    return any(
+        child.type == "storage_class_specifier" and _node_text(child) == "static"
+        for child in node.children
+    )
+
+
+def _go_receiver_type(node: Node) -> str | None:
+    """Return the receiver type name of a Go method, e.g. Server for (s *Server[T])."""
+    receiver = _node_text(node.child_by_field_name("receiver"))
+    match = re.search(r"\(\s*(?:\w+\s+)?\*?\s*(\w+)", receiver)
+    return match.group(1) if match else None
+
+
+def _go_type_signature(node: Node) -> str:
+    """Return a Go function type without parameter names, e.g. func(int) error."""
+
+    def parameter_types(parameters: Node | None) -> list[str]:
+        types = []
+        for parameter in parameters.named_children if parameters else []:
+            type_text = _normalize(_node_text(parameter.child_by_field_name("type")))
+            if parameter.type == "variadic_parameter_declaration":
+                type_text = f"...{type_text}"
+            elif not type_text:
+                continue
+            # "a, b int" declares two parameters of the same type
+            names = parameter.children_by_field_name("name")
+            types.extend([type_text] * max(len(names), 1))
+        return types
+
+    receiver = parameter_types(node.child_by_field_name("receiver"))
+    params = parameter_types(node.child_by_field_name("parameters"))
+    result_node = node.child_by_field_name("result")
+    if result_node is not None and result_node.type == "parameter_list":
+        result = f"({', '.join(parameter_types(result_node))})"
+    else:
+        result = _normalize(_node_text(result_node))
+
+    prefix = f"({receiver[0]}) " if receiver else ""
+    return f"{prefix}func({', '.join(params)}) {result}".rstrip()
+
+
+def _go_type_members(type_node: Node | None) -> tuple[str, list[str]]:
+    """Classify a Go type and list its exported fields or interface methods."""
+    if type_node is None:
+        return "type", []
+    if type_node.type == "struct_type":
+        members = []
+        for declaration in _descendants(type_node, "field_declaration"):
+            names = [_node_text(n) for n in declaration.children_by_field_name("name")]
+            field_type = _normalize(_node_text(declaration.child_by_field_name("type")))
+            if not names:
+                # Embedded field: exported when the embedded type is
+                embedded = field_type.lstrip("*").split(".")[-1]
+                if is_exported(embedded, "go"):
+                    members.append(field_type)
+            members.extend(f"{n} {field_type}" for n in names if is_exported(n, "go"))
+        return "struct", sorted(members)
+    if type_node.type == "interface_type":
+        # method_elem/method_spec for methods, type_elem/constraint_elem for embedding
+        members = [
+            _normalize(_node_text(child))
+            for child in type_node.named_children
+            if child.type != "comment"
+        ]
+        return "interface", sorted(m for m in members if m)
+    return "type", []
+
+
+def _descendants(node: Node, node_type: str) -> list[Node]:
+    found = []
+    stack = list(node.children)
+    while stack:
+        current = stack.pop()
+        if current.type == node_type:
+            found.append(current)
+        else:
+            stack.extend(current.children)
+    return sorted(found, key=lambda n: n.start_byte)
@@ -0,0 +1,68 @@
+"""Breaking-change classification of API diffs, following Go compatibility rules."""
+
+from .api_surface import ApiChange, ApiDiff, ApiSymbol
+
+
+def classify_diff(
+    diff: ApiDiff, old: dict[str, ApiSymbol], new: dict[str, ApiSymbol]
+) -> ApiDiff:
+    """
+    Mark every change in a diff as breaking or compatible, in place.
+
+    Go symbols follow the Go 1 compatibility rules: removing a symbol,
+    changing a function's parameter or result types, removing or retyping
+    exported struct fields, and adding or removing interface methods all
+    break callers or implementers. Other languages fall back to treating
+    removals and signature changes as breaking.
+    """
+    for change in diff.added:
+        change.breaking, change.reason = False, f"{change.kind} added"
+    for change in diff.removed:
+        change.breaking, change.reason = True, f"{change.kind} removed"
+    for change in diff.changed:
+        before, after = old[change.qualified_name], new[change.qualified_name]
+        if after.language == "go":
+            classification = _classify_go_change(change, before, after)
+            change.breaking, change.reason = classification
+        else:
+            change.breaking, change.reason = True, "signature changed"
+    return diff
+
+
+def _classify_go_change(
+    change: ApiChange, before: ApiSymbol, after: ApiSymbol
+) -> tuple[bool, str]:
+    if before.kind != after.kind:
+        return True, f"changed from {before.kind} to {after.kind}"
+
+    if after.kind in ("function", "method"):
+        if before.type_signature == after.type_signature:
+            return False, "only parameter names changed"
+        return True, (
+            f"type changed from {before.type_signature} to {after.type_signature}"
+        )
+
+    if after.kind == "interface":
+        # Removals break callers; additions break every external implementation
+        if change.removed_members and change.added_members:
+            return True, "interface methods changed"
+        if change.removed_members:
+            return True, "interface narrowed: methods removed"
+        if change.added_members:
+            return True, "methods added to interface"
+        return False, "interface unchanged"
+
+    if after.kind == "struct":
+        if change.removed_members:
+            return True, "exported fields removed or retyped"
+        return False, "exported fields added"
+
+    if after.kind == "const":
+        return True, "constant value or type changed"
+
+    if after.kind == "var":
+        if before.type_signature != after.type_signature:
+            return True, "variable type changed"
+        return False, "variable initializer changed"
+
+    return True, "underlying type changed"
@@ -0,0 +1,239 @@
+"""Call-tree depth and size below entrypoints such as main, HTTP handlers and jobs."""
+
+import fnmatch
+import re
+from collections import defaultdict, deque
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from loguru import logger
+
+# Every function and method with what is needed to recognize entrypoints
+FUNCTIONS_QUERY = """
+MATCH (f)
+WHERE f:Function OR f:Method
+OPTIONAL MATCH (e:Endpoint)-[:HANDLED_BY]->(f)
+RETURN f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       f.name AS name, f.decorators AS decorators, count(e) AS endpoint_count
+"""
+
+CALL_EDGES_QUERY = """
+MATCH (caller)-[:CALLS]->(callee)
+WHERE (caller:Function OR caller:Method) AND (callee:Function OR callee:Method)
+RETURN DISTINCT caller.qualified_name AS caller, callee.qualified_name AS callee
+"""
+
+# Decorators registering CLI commands (click, typer, argh, fire-style groups)
+CLI_DECORATOR = re.compile(r"(?:^|\.)(?:command|group|callback)$|^click\.|^argh\.")
+
+# Decorators registering scheduled or background jobs
+CRON_DECORATOR = re.compile(
+    r"(?:^|\.)(?:scheduled_job|periodic_task|shared_task|task|cron|schedule|every)$"
+)
+
+
+@dataclass
+class EntrypointDepth:
+    """Call-tree metrics of one entrypoint."""
+
+    qualified_name: str
+    label: str  # "Function" or "Method"
+    kind: str  # "main", "http", "cli", "cron" or "custom"
+    max_depth: int  # Longest call chain below the entrypoint; recursion counts once
+    avg_depth: float  # Mean shortest distance to every reachable function
+    tree_size: int  # Distinct functions reachable from the entrypoint
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class CallDepthAnalyzer:
+    """Measures how deep and wide the call trees below entrypoints are."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(
+        self, extra_patterns: list[str] | None = None
+    ) -> list[EntrypointDepth]:
+        """Return entrypoint metrics, deepest call trees first."""
+        entrypoints = find_entrypoints(
+            self.ingestor.fetch_all(FUNCTIONS_QUERY), extra_patterns or []
+        )
+        edges = [
+            (row["caller"], row["callee"])
+            for row in self.ingestor.fetch_all(CALL_EDGES_QUERY)
+        ]
+        return compute_call_depths(entrypoints, edges)
+
+    def store_depths(self, depths: list[EntrypointDepth]) -> None:
+        """Persist the metrics as properties on the entrypoint nodes."""
+        for depth in depths:
+            self.ingestor.ensure_node_batch(
+                depth.label,
+                {
+                    "qualified_name": depth.qualified_name,
+                    "entrypoint_kind": depth.kind,
+                    "call_depth_max": depth.max_depth,
+                    "call_depth_avg": round(depth.avg_depth, 2),
+                    "call_tree_size": depth.tree_size,
+                },
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Stored call-depth metrics on {len(depths)} entrypoints")
+
+
+def classify_entrypoint(row: dict[str, Any], extra_patterns: list[str]) -> str | None:
+    """Return the entrypoint kind of a function row, or None if it is not one."""
+    if row.get("endpoint_count"):
+        return "http"
+    decorators = row.get("decorators") or []
+    if any(CLI_DECORATOR.search(d) for d in decorators):
+        return "cli"
+    if any(CRON_DECORATOR.search(d) for d in decorators):
+        return "cron"
+    if row.get("name") == "main" and row.get("label", "Function") == "Function":
+        return "main"
+    qualified_name = row.get("qualified_name") or ""
+    if any(fnmatch.fnmatchcase(qualified_name, p) for p in extra_patterns):
+        return "custom"
+    return None
+
+
+def find_entrypoints(
+    rows: list[dict[str, Any]], extra_patterns: list[str]
+) -> list[tuple[str, str, str]]:
+    """Return (qualified_name, label, kind) for every entrypoint row."""
+    entrypoints = []
+    for row in rows:
+        kind = classify_entrypoint(row, extra_patterns)
+        if kind:
+            label = row.get("label") or "Function"
+            entrypoints.append((row["qualified_name"], label, kind))
+    return entrypoints
+
+
+def compute_call_depths(
+    entrypoints: list[tuple[str, str, str]], edges: list[tuple[str, str]]
+) -> list[EntrypointDepth]:
+    """
+    Compute call-tree metrics for each entrypoint.
+
+    Mutually recursive functions are collapsed into a single level so that
+    cycles do not make the longest chain infinite.
+    """
+    graph: dict[str, set[str]] = defaultdict(set)
+    for caller, callee in edges:
+        if caller != callee:
+            graph[caller].add(callee)
+
+    component = strongly_connected_components(graph)
+    dag: dict[int, set[int]] = defaultdict(set)
+    for caller, callees in graph.items():
+        for callee in callees:
+            if component[caller] != component[callee]:
+                dag[component[caller]].add(component[callee])
+    longest = _longest_paths(dag)
+
+    results = []
+    for qualified_name, label, kind in entrypoints:
+        distances = _bfs_distances(graph, qualified_name)
+        reachable = [d for name, d in distances.items() if name != qualified_name]
+        results.append(
+            EntrypointDepth(
+                qualified_name=qualified_name,
+                label=label,
+                kind=kind,
+                max_depth=longest.get(component.get(qualified_name, -1), 0),
+                avg_depth=sum(reachable) / len(reachable) if reachable else 0.0,
+                tree_size=len(reachable),
+            )
+        )
+    return sorted(
+        results, key=lambda r: (-r.max_depth, -r.tree_size, r.qualified_name)
+    )
+
+
+def _bfs_distances(graph: dict[str, set[str]], root: str) -> dict[str, int]:
+    distances = {root: 0}
+    queue = deque([root])
+    while queue:
+        node = queue.popleft()
+        for callee in graph.get(node, ()):
+            if callee not in distances:
+                distances[callee] = distances[node] + 1
+                queue.append(callee)
+    return distances
+
+
+def strongly_connected_components(graph: dict[str, set[str]]) -> dict[str, int]:
+    """Iterative Tarjan's algorithm; returns a component id per node."""
+    nodes = set(graph)
+    for callees in graph.values():
+        nodes.update(callees)
+
+    index: dict[str, int] = {}
+    lowlink: dict[str, int] = {}
+    on_stack: set[str] = set()
+    stack: list[str] = []
+    component: dict[str, int] = {}
+    counter = 0
+    component_count = 0
+
+    for start in sorted(nodes):
+        if start in index:
+            continue
+        work = [(start, iter(sorted(graph.get(start, ()))))]
+        index[start] = lowlink[start] = counter
+        counter += 1
+        stack.append(start)
+        on_stack.add(start)
+        while work:
+            node, callees = work[-1]
+            advanced = False
+            for callee in callees:
+                if callee not in index:
+                    index[callee] = lowlink[callee] = counter
+                    counter += 1
+                    stack.append(callee)
+                    on_stack.add(callee)
+                    work.append((callee, iter(sorted(graph.get(callee, ())))))
+                    advanced = True
+                    break
+                if callee in on_stack:
+                    lowlink[node] = min(lowlink[node], index[callee])
+            if advanced:
+                continue
+            work.pop()
+            if work:
+                parent = work[-1][0]
+                lowlink[parent] = min(lowlink[parent], lowlink[node])
+            if lowlink[node] == index[node]:
+                while True:
+                    member = stack.pop()
+                    on_stack.discard(member)
+                    component[member] = component_count
+                    if member == node:
+                        break
+                component_count += 1
+    return component
+
+
+def _longest_paths(dag: dict[int, set[int]]) -> dict[int, int]:
+    """Longest number of edges from each component to a leaf of the DAG."""
+    longest: dict[int, int] = {}
+    for start in dag:
+        if start in longest:
+            continue
+        work = [(start, False)]
+        while work:
+            node, expanded = work.pop()
+            if node in longest:
+                continue
+            children = dag.get(node, set())
+            if expanded or not children:
+                longest[node] = max((longest[c] + 1 for c in children), default=0)
+                continue
+            work.append((node, True))
+            work.extend((child, False) for child in children if child not in longest)
+    return longest
@@ -0,0 +1,122 @@
+"""cgo: the C preamble of Go files that `import "C"`, and their calls into C.
+
+The comment right before `import "C"` is C code compiled with the file, and
+C.name in Go refers to what it or the package's .c files declare. Calls such
+as C.add(a, b) cross into C; C.int(x) and C.CString(s) are conversions and
+helpers cgo provides, not C functions.
+"""
+
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Conversions to C numeric types, and the helpers cgo generates
+CGO_TYPES = {
+    "char",
+    "schar",
+    "uchar",
+    "short",
+    "ushort",
+    "int",
+    "uint",
+    "long",
+    "ulong",
+    "longlong",
+    "ulonglong",
+    "float",
+    "double",
+    "complexfloat",
+    "complexdouble",
+    "size_t",
+    "uintptr_t",
+}
+CGO_HELPERS = {"CString", "CBytes", "GoString", "GoStringN", "GoBytes"}
+CGO_TYPE_PREFIXES = ("struct_", "union_", "enum_")
+
+
+@dataclass
+class CgoPreamble:
+    source: str
+    start_line: int  # Line of the Go file the C source starts on
+
+
+@dataclass
+class NativeCall:
+    name: str
+    line_number: int
+
+
+def cgo_preamble(root_node: Node) -> CgoPreamble | None:
+    """The C source in the comments directly above `import "C"`, if any."""
+    for declaration in root_node.named_children:
+        if declaration.type != "import_declaration" or not _imports_c(declaration):
+            continue
+        comments: list[Node] = []
+        previous = declaration.prev_named_sibling
+        next_line = declaration.start_point[0]
+        # A blank line between the comment and the import ends the preamble
+        while (
+            previous is not None
+            and previous.type == "comment"
+            and previous.end_point[0] == next_line - 1
+        ):
+            comments.insert(0, previous)
+            next_line = previous.start_point[0]
+            previous = previous.prev_named_sibling
+        if not comments:
+            return None
+        lines = []
+        for comment in comments:
+            text = node_text(comment)
+            if text.startswith("/*"):
+                lines.extend(text[2:-2].split("\n"))
+            else:
+                lines.append(text[2:])
+        return CgoPreamble("\n".join(lines), comments[0].start_point[0] + 1)
+    return None
+
+
+def native_calls(func_node: Node) -> list[NativeCall]:
+    """Calls to C functions through the C pseudo-package, in source order."""
+    calls = []
+    stack = list(func_node.named_children)
+    while stack:
+        node = stack.pop()
+        if node.type == "call_expression":
+            function = node.child_by_field_name("function")
+            name = _c_name(function) if function is not None else ""
+            if name and not is_cgo_builtin(name):
+                calls.append(NativeCall(name, node.start_point[0] + 1))
+        stack.extend(node.named_children)
+    return sorted(calls, key=lambda call: call.line_number)
+
+
+def is_cgo_builtin(name: str) -> bool:
+    return (
+        name in CGO_TYPES or name in CGO_HELPERS or name.startswith(CGO_TYPE_PREFIXES)
+    )
+
+
+def _imports_c(declaration: Node) -> bool:
+    stack = list(declaration.named_children)
+    while stack:
+        node = stack.pop()
+        if node.type == "import_spec":
+            path = node.child_by_field_name("path")
+            if path is not None and node_text(path) == '"C"':
+                return True
+        stack.extend(node.named_children)
+    return False
+
+
+def _c_name(function: Node) -> str:
+    if function.type != "selector_expression":
+        return ""
+    operand = function.child_by_field_name("operand")
+    field = function.child_by_field_name("field")
+    if operand is None or field is None or node_text(operand) != "C":
+        return ""
+    return node_text(field)
+
@@ -0,0 +1,212 @@
+"""Changelogs between two revisions from their commits and public API diff."""
+
+import re
+import subprocess
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+from ..parsers.issue_references import extract_issue_references
+from .api_surface import ApiChange, ApiDiff
+
+# "type(scope)!: description" as in the Conventional Commits specification
+CONVENTIONAL_HEADER = re.compile(
+    r"^(?P<type>[a-zA-Z]+)(?:\((?P<scope>[^)]*)\))?(?P<bang>!)?:\s*(?P<text>.+)$"
+)
+BREAKING_FOOTER = re.compile(r"^BREAKING[ -]CHANGE:\s*(?P<text>.+)$", re.MULTILINE)
+
+# Words that mark a commit without a conventional header as a fix or feature
+FIX_WORDS = re.compile(r"^(fix|fixes|fixed|resolve|resolves|resolved|correct)\b", re.I)
+FEATURE_WORDS = re.compile(r"^(add|adds|added|implement|introduce|support)\b", re.I)
+
+FEATURE_TYPES = {"feat", "feature"}
+FIX_TYPES = {"fix", "bugfix", "hotfix"}
+# Maintenance that a changelog reader does not need to see
+HIDDEN_TYPES = {"chore", "ci", "build", "test", "tests", "style"}
+
+# Field and record separators for `git log`, unlikely to appear in messages
+FIELD_SEPARATOR = "\x1f"
+RECORD_SEPARATOR = "\x1e"
+
+
+@dataclass
+class ChangelogEntry:
+    """One commit as it appears in the changelog."""
+
+    sha: str
+    section: str  # features, fixes or other
+    description: str
+    scope: str = ""
+    author: str = ""
+    issues: list[str] = field(default_factory=list)
+    breaking: str = ""  # What breaks, from a "!" header or BREAKING CHANGE footer
+
+
+@dataclass
+class Changelog:
+    """The changes between two revisions, grouped for release notes."""
+
+    base: str
+    head: str
+    features: list[ChangelogEntry] = field(default_factory=list)
+    fixes: list[ChangelogEntry] = field(default_factory=list)
+    other: list[ChangelogEntry] = field(default_factory=list)
+    # Commits announcing breaking changes, and breaking API changes by symbol
+    breaking_commits: list[ChangelogEntry] = field(default_factory=list)
+    breaking_api: list[ApiChange] = field(default_factory=list)
+    added_api: list[ApiChange] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "base": self.base,
+            "head": self.head,
+            "breaking": {
+                "commits": [asdict(e) for e in self.breaking_commits],
+                "api": [c.to_dict() for c in self.breaking_api],
+            },
+            "features": [asdict(e) for e in self.features],
+            "added_api": [c.to_dict() for c in self.added_api],
+            "fixes": [asdict(e) for e in self.fixes],
+            "other": [asdict(e) for e in self.other],
+        }
+
+    def to_markdown(self) -> str:
+        lines = [f"## {self.head}", "", f"Changes since {self.base}."]
+        if self.breaking_commits or self.breaking_api:
+            lines += ["", "### Breaking changes", ""]
+            lines += [f"- {_entry_line(e, e.breaking)}" for e in self.breaking_commits]
+            for change in self.breaking_api:
+                lines.append(
+                    f"- `{change.qualified_name}` ({change.kind}): {change.reason}"
+                )
+                if change.old_signature and change.new_signature:
+                    lines += [
+                        f"  - before: `{change.old_signature}`",
+                        f"  - after: `{change.new_signature}`",
+                    ]
+        if self.features or self.added_api:
+            lines += ["", "### Features", ""]
+            lines += [f"- {_entry_line(e)}" for e in self.features]
+            if self.added_api:
+                names = ", ".join(f"`{c.qualified_name}`" for c in self.added_api)
+                lines.append(f"- New API: {names}")
+        for title, entries in (("Fixes", self.fixes), ("Other changes", self.other)):
+            if entries:
+                lines += ["", f"### {title}", ""]
+                lines += [f"- {_entry_line(e)}" for e in entries]
+        if len(lines) == 3:
+            lines += ["", "No notable changes."]
+        return "\n".join(lines) + "\n"
+
+
+def _entry_line(entry: ChangelogEntry, text: str = "") -> str:
+    scope = f"**{entry.scope}:** " if entry.scope else ""
+    issues = f" ({', '.join(entry.issues)})" if entry.issues else ""
+    return f"{scope}{text or entry.description}{issues} ({entry.sha[:8]})"
+
+
+@dataclass
+class RawCommit:
+    """A commit as read from `git log`, with its full message."""
+
+    sha: str
+    author: str
+    subject: str
+    body: str = ""
+    parents: list[str] = field(default_factory=list)
+
+
+def commits_between(repo_path: Path, base: str, head: str) -> list[RawCommit]:
+    """Commits reachable from head but not base, oldest first."""
+    result = subprocess.run(
+        [
+            "git",
+            "-C",
+            str(repo_path),
+            "log",
+            "--reverse",
+            f"--format=%H{FIELD_SEPARATOR}%an{FIELD_SEPARATOR}%P{FIELD_SEPARATOR}"
+            f"%s{FIELD_SEPARATOR}%b{RECORD_SEPARATOR}",
+            f"{base}..{head}",
+        ],
+        capture_output=True,
+        text=True,
+        check=True,
+    )
+    commits = []
+    for record in result.stdout.split(RECORD_SEPARATOR):
+        fields = record.strip("\n").split(FIELD_SEPARATOR)
+        if len(fields) != 5:
+            continue
+        sha, author, parents, subject, body = fields
+        commits.append(RawCommit(sha, author, subject, body.strip(), parents.split()))
+    return commits
+
+
+def classify_commit(commit: RawCommit) -> ChangelogEntry | None:
+    """
+    The changelog entry for a commit, or None for merges and maintenance.
+    Conventional headers decide the section; other subjects are matched
+    against a few leading verbs and otherwise listed as other changes.
+    """
+    if len(commit.parents) > 1:
+        return None
+    issues = [
+        ref.key
+        for ref in extract_issue_references(f"{commit.subject}\n{commit.body}")
+    ]
+    footer = BREAKING_FOOTER.search(commit.body)
+    breaking = footer["text"].strip() if footer else ""
+
+    header = CONVENTIONAL_HEADER.match(commit.subject)
+    if header:
+        commit_type = header["type"].lower()
+        if header["bang"] and not breaking:
+            breaking = header["text"]
+        if commit_type in HIDDEN_TYPES and not breaking:
+            return None
+        if commit_type in FEATURE_TYPES:
+            section = "features"
+        elif commit_type in FIX_TYPES:
+            section = "fixes"
+        else:
+            section = "other"
+        return ChangelogEntry(
+            commit.sha,
+            section,
+            header["text"],
+            header["scope"] or "",
+            commit.author,
+            issues,
+            breaking,
+        )
+
+    if FIX_WORDS.match(commit.subject):
+        section = "fixes"
+    elif FEATURE_WORDS.match(commit.subject):
+        section = "features"
+    else:
+        section = "other"
+    return ChangelogEntry(
+        commit.sha,
+        section,
+        commit.subject,
+        author=commit.author,
+        issues=issues,
+        breaking=breaking,
+    )
+
+
+def build_changelog(commits: list[RawCommit], api_diff: ApiDiff) -> Changelog:
+    """Group classified commits and attach the classified API diff."""
+    changelog = Changelog(base=api_diff.base, head=api_diff.head)
+    for commit in commits:
+        entry = classify_commit(commit)
+        if entry is None:
+            continue
+        if entry.breaking:
+            changelog.breaking_commits.append(entry)
+        getattr(changelog, entry.section).append(entry)
+    changelog.breaking_api = api_diff.breaking_changes
+    changelog.added_api = [c for c in api_diff.added if not c.breaking]
+    return changelog
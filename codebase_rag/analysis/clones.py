@@ -0,0 +1,296 @@
+"""Copy-pasted functions, found from fingerprints of their normalized bodies.
+
+At parse time each function body is reduced to its tokens with identifiers
+and literals replaced by placeholders, so renaming variables or changing
+constants leaves the fingerprint alone. The fingerprint is a hash of those
+tokens, equal for exact clones, and a MinHash signature of their 5-token
+shingles, whose matching positions estimate how much two bodies share.
+Signatures are bucketed by bands, so only functions sharing a band are
+compared.
+"""
+
+import hashlib
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+from loguru import logger
+from tree_sitter import Node
+
+SHINGLE_SIZE = 5
+SIGNATURE_SIZE = 32
+BANDS = 8  # Of SIGNATURE_SIZE / BANDS rows each
+# Bodies shorter than this are getters, delegations and other boilerplate
+# that look alike without having been copied
+MIN_TOKENS = 40
+
+_PRIME = (1 << 61) - 1
+# Fixed, so signatures from separate ingestions stay comparable
+_PERMUTATIONS = [
+    (
+        int.from_bytes(hashlib.blake2b(f"a{i}".encode(), digest_size=8).digest())
+        % _PRIME
+        | 1,
+        int.from_bytes(hashlib.blake2b(f"b{i}".encode(), digest_size=8).digest())
+        % _PRIME,
+    )
+    for i in range(SIGNATURE_SIZE)
+]
+
+FINGERPRINTS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f)
+WHERE (f:Function OR f:Method) AND f.body_minhash IS NOT NULL
+RETURN DISTINCT f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       m.path AS path, f.start_line AS start_line, f.end_line AS end_line,
+       f.lines_of_code AS lines_of_code, f.body_hash AS body_hash,
+       f.body_minhash AS body_minhash
+"""
+
+CLEAR_CLONES = "MATCH ()-[r:CLONE_OF]->() DELETE r"
+
+
+def body_fingerprint(func_node: Node) -> dict[str, Any]:
+    """
+    body_hash, body_minhash and body_tokens of a function, or nothing for
+    bodies too short to be worth comparing.
+    """
+    body = func_node.child_by_field_name("body") or func_node
+    tokens = normalized_tokens(body)
+    if len(tokens) < MIN_TOKENS:
+        return {}
+    return {
+        "body_hash": hashlib.sha1(" ".join(tokens).encode()).hexdigest(),
+        "body_minhash": minhash(tokens),
+        "body_tokens": len(tokens),
+    }
+
+
+def normalized_tokens(node: Node) -> list[str]:
+    """The leaf tokens under a node, identifiers and literals as placeholders."""
+    tokens = []
+    stack = [node]
+    while stack:
+        current = stack.pop()
+        if "comment" in current.type:
+            continue
+        if current.child_count == 0 or _is_literal(current):
+            tokens.append(_token(current))
+            continue
+        stack.extend(reversed(current.children))
+    return tokens
+
+
+def _is_literal(node: Node) -> bool:
+    # Strings have children (quotes, escapes) but are one token for cloning
+    return node.is_named and (
+        "string" in node.type or node.type.endswith("literal")
+    )
+
+
+def _token(node: Node) -> str:
+    if not node.is_named:
+        return node.type  # Keywords, operators and punctuation
+    if node.type.endswith("identifier"):
+        return "$id"
+    if _is_literal(node) or node.type in ("integer", "float", "number"):
+        return "$lit"
+    return node.type
+
+
+def minhash(tokens: list[str]) -> list[int]:
+    shingles = {
+        int.from_bytes(
+            hashlib.blake2b(
+                "\x1f".join(tokens[i : i + SHINGLE_SIZE]).encode(), digest_size=8
+            ).digest()
+        )
+        for i in range(max(1, len(tokens) - SHINGLE_SIZE + 1))
+    }
+    return [
+        min((a * shingle + b) % _PRIME for shingle in shingles)
+        for a, b in _PERMUTATIONS
+    ]
+
+
+def similarity(first: list[int], second: list[int]) -> float:
+    """Estimated share of shingles two signatures' bodies have in common."""
+    if not first or len(first) != len(second):
+        return 0.0
+    return sum(1 for x, y in zip(first, second) if x == y) / len(first)
+
+
+@dataclass
+class ClonePair:
+    source: str  # The later of the two, by path and line
+    target: str
+    similarity: float
+    exact: bool
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class CloneMember:
+    qualified_name: str
+    path: str
+    start_line: int
+    end_line: int
+    label: str = "Function"
+
+    @property
+    def location(self) -> str:
+        return f"{self.path}:{self.start_line}-{self.end_line}"
+
+
+@dataclass
+class CloneGroup:
+    """Functions connected by clone pairs, e.g. one body pasted in three places."""
+
+    members: list[CloneMember]
+    min_similarity: float
+    pairs: list[ClonePair] = field(default_factory=list)
+
+    @property
+    def duplicated_lines(self) -> int:
+        """Lines that would go away by keeping one copy."""
+        sizes = sorted(m.end_line - m.start_line + 1 for m in self.members)
+        return sum(sizes[:-1])
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "members": [asdict(m) for m in self.members],
+            "min_similarity": self.min_similarity,
+            "duplicated_lines": self.duplicated_lines,
+            "pairs": [p.to_dict() for p in self.pairs],
+        }
+
+
+class CloneAnalyzer:
+    """Groups functions whose fingerprints show near-identical bodies."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(self, threshold: float = 0.9, min_lines: int = 5) -> list[CloneGroup]:
+        rows = [
+            row
+            for row in self.ingestor.fetch_all(FINGERPRINTS_QUERY)
+            if (row.get("lines_of_code") or 0) >= min_lines
+        ]
+        return group_clones(rows, find_clone_pairs(rows, threshold))
+
+    def store_pairs(self, groups: list[CloneGroup]) -> int:
+        """Replace the CLONE_OF edges with those of the groups."""
+        self.ingestor.execute_write(CLEAR_CLONES)
+        labels = {
+            member.qualified_name: member.label
+            for group in groups
+            for member in group.members
+        }
+        stored = 0
+        for group in groups:
+            for pair in group.pairs:
+                self.ingestor.ensure_relationship_batch(
+                    (labels[pair.source], "qualified_name", pair.source),
+                    "CLONE_OF",
+                    (labels[pair.target], "qualified_name", pair.target),
+                    {"similarity": pair.similarity, "exact": pair.exact},
+                )
+                stored += 1
+        self.ingestor.flush_all()
+        logger.info(f"Stored {stored} CLONE_OF edges in {len(groups)} groups")
+        return stored
+
+
+def find_clone_pairs(rows: list[dict[str, Any]], threshold: float) -> list[ClonePair]:
+    """Pairs of functions at or above a similarity, compared within LSH buckets."""
+    rows = sorted(rows, key=lambda r: (r["path"], r["start_line"] or 0))
+    buckets: dict[tuple, list[int]] = defaultdict(list)
+    rows_per_band = SIGNATURE_SIZE // BANDS
+    for index, row in enumerate(rows):
+        signature = row["body_minhash"]
+        for band in range(BANDS):
+            start = band * rows_per_band
+            key = (band, *signature[start : start + rows_per_band])
+            buckets[key].append(index)
+
+    candidates = {
+        (first, second)
+        for members in buckets.values()
+        for i, first in enumerate(members)
+        for second in members[i + 1 :]
+    }
+    pairs = []
+    for first, second in sorted(candidates):
+        earlier, later = rows[first], rows[second]
+        if _nested(earlier, later):
+            continue
+        exact = bool(earlier["body_hash"]) and (
+            earlier["body_hash"] == later["body_hash"]
+        )
+        score = (
+            1.0
+            if exact
+            else similarity(earlier["body_minhash"], later["body_minhash"])
+        )
+        if score >= threshold:
+            pairs.append(
+                ClonePair(
+                    later["qualified_name"],
+                    earlier["qualified_name"],
+                    round(score, 3),
+                    exact,
+                )
+            )
+    return pairs
+
+
+def _nested(first: dict[str, Any], second: dict[str, Any]) -> bool:
+    """Whether one function encloses the other, e.g. a closure in its parent."""
+    return first["path"] == second["path"] and (
+        (first["start_line"] or 0) <= (second["start_line"] or 0)
+        and (second["end_line"] or 0) <= (first["end_line"] or 0)
+    )
+
+
+def group_clones(
+    rows: list[dict[str, Any]], pairs: list[ClonePair]
+) -> list[CloneGroup]:
+    """Connected components of the pairs, the most duplicated lines first."""
+    parent: dict[str, str] = {}
+
+    def find(name: str) -> str:
+        while parent.setdefault(name, name) != name:
+            parent[name] = parent[parent[name]]
+            name = parent[name]
+        return name
+
+    for pair in pairs:
+        parent[find(pair.source)] = find(pair.target)
+
+    by_name = {row["qualified_name"]: row for row in rows}
+    grouped: dict[str, list[ClonePair]] = defaultdict(list)
+    for pair in pairs:
+        grouped[find(pair.source)].append(pair)
+
+    groups = []
+    for group_pairs in grouped.values():
+        names = {p.source for p in group_pairs} | {p.target for p in group_pairs}
+        members = [
+            CloneMember(
+                name,
+                by_name[name]["path"],
+                by_name[name]["start_line"],
+                by_name[name]["end_line"],
+                by_name[name].get("label") or "Function",
+            )
+            for name in names
+        ]
+        members.sort(key=lambda m: (m.path, m.start_line))
+        groups.append(
+            CloneGroup(members, min(p.similarity for p in group_pairs), group_pairs)
+        )
+    return sorted(
+        groups, key=lambda g: (-g.duplicated_lines, g.members[0].qualified_name)
+    )
@@ -0,0 +1,81 @@
+"""Find the function nodes behind runtime locations: file and line, or a name."""
+
+import re
+from collections import defaultdict
+from typing import Any
+
+# Functions and methods with the file and lines they occupy
+FUNCTIONS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f)
+WHERE f:Function OR f:Method
+RETURN f.qualified_name AS qualified_name, f.name AS name, labels(f)[0] AS label,
+       m.path AS path, f.start_line AS start_line, f.end_line AS end_line
+"""
+
+
+class CodeLocator:
+    """
+    Resolves locations reported by running programs (trace spans, stack
+    frames) to Function and Method rows of FUNCTIONS_QUERY.
+    """
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self._by_path: dict[str, list[dict[str, Any]]] = defaultdict(list)
+        self._by_name: dict[str, list[dict[str, Any]]] = defaultdict(list)
+        self._loaded = False
+
+    def at_line(self, file_path: str, line: int) -> dict[str, Any] | None:
+        """The innermost function spanning a line of a file."""
+        self._load()
+        file_path = file_path.replace("\\", "/")
+        candidates = [
+            function
+            for path, functions in self._by_path.items()
+            # Absolute or build-relative paths at runtime; JVM frames only
+            # know the package directory and file name
+            if file_path == path
+            or file_path.endswith(f"/{path}")
+            or path.endswith(f"/{file_path}")
+            for function in functions
+            if (function["start_line"] or 0) <= line <= (function["end_line"] or 0)
+        ]
+        if not candidates:
+            return None
+        # Nested functions and closures start after their enclosing function
+        return max(candidates, key=lambda f: f["start_line"])
+
+    def by_name(
+        self, function: str, namespace: str = "", unique_only: bool = False
+    ) -> dict[str, Any] | None:
+        """
+        A function by its runtime name, e.g. "Cart.total", "pkg.(*Cart).Total"
+        or "Cart::total"; the qualifying parts pick between same-named ones.
+        """
+        self._load()
+        parts = [p for p in re.split(r"[^\w]+", f"{namespace}.{function}") if p]
+        if not parts:
+            return None
+        candidates = self._by_name.get(parts[-1], [])
+        if not candidates or (unique_only and len(candidates) > 1):
+            return None
+
+        context = set(parts[:-1])
+
+        def overlap(function: dict[str, Any]) -> int:
+            return len(context & set(function["qualified_name"].split(".")))
+
+        best = min(candidates, key=lambda f: (-overlap(f), f["qualified_name"]))
+        if len(candidates) > 1 and context and overlap(best) == 0:
+            return None
+        return best
+
+    def _load(self) -> None:
+        if self._loaded:
+            return
+        for row in self.ingestor.fetch_all(FUNCTIONS_QUERY):
+            if row.get("path"):
+                self._by_path[row["path"].replace("\\", "/")].append(row)
+            if row.get("name"):
+                self._by_name[row["name"]].append(row)
+        self._loaded = True
@@ -0,0 +1,231 @@
+"""Size metrics for functions and classes: lines, parameters, nesting, fields."""
+
+from tree_sitter import Node
+
+from .complexity import NESTED_FUNCTION_TYPES
+
+# Statements that open a nested block of control flow, per language
+NESTING_NODE_TYPES: dict[str, set[str]] = {
+    "python": {
+        "if_statement",
+        "for_statement",
+        "while_statement",
+        "try_statement",
+        "with_statement",
+        "match_statement",
+    },
+    "javascript": {
+        "if_statement",
+        "for_statement",
+        "for_in_statement",
+        "while_statement",
+        "do_statement",
+        "try_statement",
+        "switch_statement",
+    },
+    "typescript": {
+        "if_statement",
+        "for_statement",
+        "for_in_statement",
+        "while_statement",
+        "do_statement",
+        "try_statement",
+        "switch_statement",
+    },
+    "go": {
+        "if_statement",
+        "for_statement",
+        "expression_switch_statement",
+        "type_switch_statement",
+        "select_statement",
+    },
+    "rust": {
+        "if_expression",
+        "for_expression",
+        "while_expression",
+        "loop_expression",
+        "match_expression",
+    },
+    "java": {
+        "if_statement",
+        "for_statement",
+        "enhanced_for_statement",
+        "while_statement",
+        "do_statement",
+        "try_statement",
+        "switch_expression",
+    },
+    "scala": {
+        "if_expression",
+        "for_expression",
+        "while_expression",
+        "match_expression",
+        "try_expression",
+    },
+    "cpp": {
+        "if_statement",
+        "for_statement",
+        "for_range_loop",
+        "while_statement",
+        "do_statement",
+        "switch_statement",
+        "try_statement",
+    },
+    "c": {
+        "if_statement",
+        "for_statement",
+        "while_statement",
+        "do_statement",
+        "switch_statement",
+    },
+}
+
+# Member declarations counted as fields when they appear directly in a class body
+FIELD_NODE_TYPES: dict[str, set[str]] = {
+    "python": {"expression_statement"},
+    "javascript": {"field_definition"},
+    "typescript": {"public_field_definition"},
+    "java": {"field_declaration"},
+    "scala": {"val_definition", "var_definition"},
+    "rust": {"field_declaration"},
+    "cpp": {"field_declaration"},
+    "c": {"field_declaration"},
+}
+
+# Receiver parameters that are not part of a method's real parameter list
+IMPLICIT_PARAMETERS = {"self", "cls", "this"}
+
+
+def calculate_max_nesting_depth(func_node: Node, language: str) -> int:
+    """
+    Return the deepest level of nested control-flow blocks in a function.
+
+    An ``else if`` chain counts as a single level, and nested functions are
+    skipped because they are measured on their own.
+    """
+    nesting_types = NESTING_NODE_TYPES.get(language, set())
+    nested_types = NESTED_FUNCTION_TYPES.get(language, set())
+
+    max_depth = 0
+    stack = [(child, 0) for child in func_node.children]
+    while stack:
+        node, depth = stack.pop()
+        if node.type in nested_types:
+            continue
+        if node.type in nesting_types and not _is_else_if(node):
+            depth += 1
+            max_depth = max(max_depth, depth)
+        stack.extend((child, depth) for child in node.children)
+    return max_depth
+
+
+def count_parameters(func_node: Node) -> int:
+    """Count the declared parameters of a function, excluding self/cls/this."""
+    parameters = _find_parameter_list(func_node)
+    if parameters is None:
+        # Single-parameter arrow functions: `x => x * 2`
+        return 1 if func_node.child_by_field_name("parameter") else 0
+
+    count = 0
+    for parameter in parameters.named_children:
+        if parameter.type == "self_parameter" or parameter.type.endswith(
+            ("comment", "_separator")
+        ):
+            continue
+        text = (parameter.text or b"").decode("utf-8", errors="replace")
+        if text in IMPLICIT_PARAMETERS or text == "void":
+            continue
+        # Go declares several names with one type: `a, b int`
+        names = parameter.children_by_field_name("name")
+        count += max(len(names), 1) if parameter.type.endswith("declaration") else 1
+    return count
+
+
+def count_lines_of_code(func_node: Node) -> int:
+    """Count the lines of a function holding code, not just blanks or comments."""
+    lines: set[int] = set()
+    stack = [func_node]
+    while stack:
+        node = stack.pop()
+        if "comment" in node.type:
+            continue
+        if node.child_count == 0:
+            # Multi-line strings hold code on every line they span
+            lines.update(range(node.start_point[0], node.end_point[0] + 1))
+        stack.extend(node.children)
+    return len(lines)
+
+
+def count_class_fields(class_node: Node, language: str) -> int:
+    """Count the fields a class declares in its body (and via self.x in Python)."""
+    body = class_node.child_by_field_name("body")
+    if body is None:
+        return 0
+    field_types = FIELD_NODE_TYPES.get(language, set())
+
+    if language == "python":
+        names = {
+            target
+            for child in body.named_children
+            if child.type in field_types
+            for target in _python_assignment_targets(child, prefix="")
+        }
+        # Instance attributes assigned in methods: self.total = 0
+        for node in _walk(body):
+            if node.type == "expression_statement":
+                names.update(_python_assignment_targets(node, prefix="self."))
+        return len(names)
+
+    count = 0
+    for child in body.named_children:
+        if child.type not in field_types:
+            continue
+        if any(d.type == "function_declarator" for d in _walk(child)):
+            continue  # C++ method prototypes are not fields
+        # `int x, y;` declares two fields
+        declarators = child.children_by_field_name("declarator")
+        count += max(len(declarators), 1)
+    return count
+
+
+def _is_else_if(node: Node) -> bool:
+    parent = node.parent
+    if parent is None or not node.type.startswith("if_"):
+        return False
+    return parent.type == "else_clause" or (
+        parent.type == node.type and parent.child_by_field_name("alternative") == node
+    )
+
+
+def _find_parameter_list(func_node: Node) -> Node | None:
+    parameters = func_node.child_by_field_name("parameters")
+    # C/C++ keep the parameter list inside the function declarator
+    declarator = func_node.child_by_field_name("declarator")
+    while parameters is None and declarator is not None:
+        parameters = declarator.child_by_field_name("parameters")
+        declarator = declarator.child_by_field_name("declarator")
+    return parameters
+
+
+def _python_assignment_targets(statement: Node, prefix: str) -> set[str]:
+    targets = set()
+    for child in statement.named_children:
+        if child.type not in ("assignment", "augmented_assignment"):
+            continue
+        left = child.child_by_field_name("left")
+        text = (left.text or b"").decode("utf-8", errors="replace") if left else ""
+        if prefix and text.startswith(prefix) and "." not in text[len(prefix) :]:
+            targets.add(text[len(prefix) :])
+        elif not prefix and text.isidentifier():
+            targets.add(text)
+    return targets
+
+
+def _walk(node: Node) -> list[Node]:
+    nodes = []
+    stack = list(node.children)
+    while stack:
+        current = stack.pop()
+        nodes.append(current)
+        stack.extend(current.children)
+    return nodes
@@ -0,0 +1,242 @@
+"""Conventional commit messages proposed from a diff and its graph context."""
+
+import textwrap
+from dataclasses import dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+from .review import ChangedSymbol, FileDiff, ReviewAssistant, parse_unified_diff
+
+SUBJECT_LIMIT = 72
+BODY_WIDTH = 72
+
+DOC_SUFFIXES = (".md", ".rst", ".adoc", ".txt")
+BUILD_FILES = {
+    "pyproject.toml",
+    "setup.py",
+    "setup.cfg",
+    "requirements.txt",
+    "package.json",
+    "package-lock.json",
+    "go.mod",
+    "go.sum",
+    "Cargo.toml",
+    "Cargo.lock",
+    "pom.xml",
+    "build.gradle",
+    "Makefile",
+    "Dockerfile",
+}
+TEST_DIRECTORIES = {"test", "tests", "__tests__", "spec"}
+TEST_PREFIX = "test_"
+TEST_MARKERS = ("_test.", ".test.", "_spec.", ".spec.")
+
+# Commit types for changes that only touch one kind of non-code file
+FILE_KIND_TYPES = {"test": "test", "docs": "docs", "ci": "ci", "build": "build"}
+
+
+@dataclass
+class CommitMessage:
+    """A proposed conventional commit message."""
+
+    type: str
+    scope: str
+    subject: str
+    body: list[str] = field(default_factory=list)  # Paragraphs
+
+    @property
+    def header(self) -> str:
+        scope = f"({self.scope})" if self.scope else ""
+        return f"{self.type}{scope}: {self.subject}"
+
+    def render(self) -> str:
+        paragraphs = [self.header]
+        paragraphs += [textwrap.fill(p, BODY_WIDTH) for p in self.body]
+        return "\n\n".join(paragraphs) + "\n"
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "type": self.type,
+            "scope": self.scope,
+            "subject": self.subject,
+            "body": self.body,
+            "message": self.render(),
+        }
+
+
+def classify_file(path: str) -> str:
+    """The kind of a changed file by path: test, docs, ci, build or code."""
+    parts = PurePosixPath(path).parts
+    name = parts[-1] if parts else ""
+    if parts and parts[0] in (".github", ".gitlab-ci.yml", ".circleci"):
+        return "ci"
+    if name in BUILD_FILES:
+        return "build"
+    if TEST_DIRECTORIES & set(parts[:-1]) or name.startswith(TEST_PREFIX):
+        return "test"
+    if any(marker in name for marker in TEST_MARKERS):
+        return "test"
+    if name.endswith(DOC_SUFFIXES) or (parts and parts[0] == "docs"):
+        return "docs"
+    return "code"
+
+
+class CommitMessageGenerator:
+    """Proposes a commit message for a diff from what the graph knows about it."""
+
+    def __init__(self, ingestor: Any):
+        self.assistant = ReviewAssistant(ingestor)
+
+    def generate(self, diff_text: str) -> CommitMessage:
+        diffs = parse_unified_diff(diff_text)
+        report = self.assistant.review(diffs)
+        return propose_message(diffs, report.symbols)
+
+
+def new_symbols(diffs: list[FileDiff], symbols: list[ChangedSymbol]) -> set[str]:
+    """Changed symbols whose definition line is itself added by the diff."""
+    added = {d.path: d.added_lines for d in diffs if not d.is_deleted}
+    return {
+        s.qualified_name for s in symbols if s.start_line in added.get(s.path, ())
+    }
+
+
+def propose_message(
+    diffs: list[FileDiff], symbols: list[ChangedSymbol]
+) -> CommitMessage:
+    """
+    Infer the type from the kinds of files touched and the graph context: new
+    functions or files are a feature, touching code with crash reports is a
+    fix, pure removals are a refactor and other code changes default to fix.
+    """
+    kinds = {classify_file(d.path) for d in diffs}
+    code_diffs = [d for d in diffs if classify_file(d.path) == "code"]
+    added = new_symbols(diffs, symbols)
+    code_symbols = [s for s in symbols if classify_file(s.path) == "code"]
+
+    if not code_diffs:
+        kind = kinds.pop() if len(kinds) == 1 else "chore"
+        commit_type = FILE_KIND_TYPES.get(kind, "chore")
+        verb = "cover" if commit_type == "test" and symbols else "update"
+        targets = _names(symbols) or _file_names([d.path for d in diffs])
+    elif added & {s.qualified_name for s in code_symbols} or any(
+        d.old_path is None for d in code_diffs
+    ):
+        commit_type, verb = "feat", "add"
+        new = [s for s in code_symbols if s.qualified_name in added]
+        targets = _names(new) or _file_names(
+            [d.path for d in code_diffs if d.old_path is None]
+        )
+    elif any(s.crashes for s in code_symbols):
+        commit_type, verb = "fix", "fix crashes in"
+        targets = _names([s for s in code_symbols if s.crashes])
+    elif all(not d.added_lines for d in code_diffs):
+        commit_type, verb = "refactor", "remove code from"
+        targets = _names(code_symbols) or _file_names([d.path for d in code_diffs])
+    else:
+        commit_type, verb = "fix", "update"
+        targets = _names(code_symbols) or _file_names([d.path for d in code_diffs])
+
+    message = CommitMessage(
+        type=commit_type,
+        scope=_scope([d.path for d in code_diffs or diffs]),
+        subject="",
+        body=_body(diffs, code_symbols or symbols),
+    )
+    message.subject = _fit_subject(verb, targets, len(message.header))
+    return message
+
+
+def _names(symbols: list[ChangedSymbol]) -> list[str]:
+    names = []
+    for symbol in symbols:
+        parts = symbol.qualified_name.split(".")
+        name = ".".join(parts[-2:]) if symbol.label == "Method" else parts[-1]
+        if name not in names:
+            names.append(name)
+    return names
+
+
+def _file_names(paths: list[str]) -> list[str]:
+    return list(dict.fromkeys(PurePosixPath(p).name for p in paths))
+
+
+def _scope(paths: list[str]) -> str:
+    """The directory all paths share, or the file when only one is touched."""
+    directories = {str(PurePosixPath(p).parent) for p in paths}
+    if len(directories) == 1:
+        directory = PurePosixPath(directories.pop())
+        if directory.name:
+            return directory.name
+        return PurePosixPath(paths[0]).stem if len(set(paths)) == 1 else ""
+    common = _common_prefix([PurePosixPath(d).parts for d in directories])
+    return common[-1] if common else ""
+
+
+def _common_prefix(parts: list[tuple[str, ...]]) -> list[str]:
+    prefix = []
+    for segment in zip(*parts, strict=False):
+        if len(set(segment)) != 1:
+            break
+        prefix.append(segment[0])
+    return prefix
+
+
+def _fit_subject(verb: str, targets: list[str], header_length: int) -> str:
+    """Name as many targets as fit in the subject line, summarising the rest."""
+    for shown in range(len(targets), 0, -1):
+        names = targets[:shown]
+        rest = len(targets) - shown
+        if rest:
+            listed = f"{', '.join(names)} and {rest} more"
+        elif shown > 1:
+            listed = f"{', '.join(names[:-1])} and {names[-1]}"
+        else:
+            listed = names[0]
+        subject = f"{verb} {listed}"
+        if header_length + len(subject) <= SUBJECT_LIMIT:
+            return subject
+    return f"{verb} {len(targets)} symbols" if targets else verb
+
+
+def _body(diffs: list[FileDiff], symbols: list[ChangedSymbol]) -> list[str]:
+    """Paragraphs on the areas a change affects, from the symbols' graph context."""
+    body = []
+    files = sorted(d.path for d in diffs)
+    areas = sorted({str(PurePosixPath(p).parent) for p in files} - {"."})
+    if symbols:
+        body.append(
+            f"Changes {_list(_names(symbols))} in {len(files)} file(s)"
+            + (f" under {_list(areas)}." if areas else ".")
+        )
+    else:
+        body.append(f"Changes {_list(files)}.")
+
+    changed = {s.qualified_name for s in symbols}
+    endpoints = sorted({e for s in symbols for e in s.endpoints})
+    callers = sorted({c for s in symbols for c in s.callers} - changed)
+    tests = sorted({t for s in symbols for t in s.tests})
+    untested = [s for s in symbols if not s.tests]
+    crashes = sorted({c["short_id"] for s in symbols for c in s.crashes})
+    owners = sorted({o for s in symbols for o in s.owners})
+
+    if endpoints:
+        body.append(f"Affects endpoints {_list(endpoints)}.")
+    if callers:
+        body.append(f"Reached from {len(callers)} caller(s), e.g. {_list(callers)}.")
+    if tests:
+        body.append(f"Covered by {_list(tests)}.")
+    if untested:
+        body.append(f"No tests cover {_list(_names(untested))}.")
+    if crashes:
+        body.append(f"Related crash reports: {', '.join(crashes)}.")
+    if owners:
+        body.append(f"Owners: {', '.join(owners)}.")
+    return body
+
+
+def _list(names: list[str], limit: int = 3) -> str:
+    shown = ", ".join(names[:limit])
+    if len(names) > limit:
+        shown += f" and {len(names) - limit} more"
+    return shown
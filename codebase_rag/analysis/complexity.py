@@ -0,0 +1,138 @@
+"""Cyclomatic complexity calculation for functions and methods."""
+
+from tree_sitter import Node
+
+# AST node types that introduce an additional execution path, per language
+DECISION_NODE_TYPES: dict[str, set[str]] = {
+    "python": {
+        "if_statement",
+        "elif_clause",
+        "for_statement",
+        "while_statement",
+        "except_clause",
+        "conditional_expression",
+        "case_clause",
+        "for_in_clause",
+        "if_clause",
+        "boolean_operator",
+    },
+    "javascript": {
+        "if_statement",
+        "for_statement",
+        "for_in_statement",
+        "while_statement",
+        "do_statement",
+        "catch_clause",
+        "ternary_expression",
+        "switch_case",
+    },
+    "typescript": {
+        "if_statement",
+        "for_statement",
+        "for_in_statement",
+        "while_statement",
+        "do_statement",
+        "catch_clause",
+        "ternary_expression",
+        "switch_case",
+    },
+    "go": {
+        "if_statement",
+        "for_statement",
+        "expression_case",
+        "type_case",
+        "communication_case",
+    },
+    "rust": {
+        "if_expression",
+        "for_expression",
+        "while_expression",
+        "loop_expression",
+        "match_arm",
+    },
+    "java": {
+        "if_statement",
+        "for_statement",
+        "enhanced_for_statement",
+        "while_statement",
+        "do_statement",
+        "catch_clause",
+        "ternary_expression",
+        "switch_label",
+    },
+    "scala": {
+        "if_expression",
+        "for_expression",
+        "while_expression",
+        "case_clause",
+        "catch_clause",
+    },
+    "cpp": {
+        "if_statement",
+        "for_statement",
+        "for_range_loop",
+        "while_statement",
+        "do_statement",
+        "case_statement",
+        "catch_clause",
+        "conditional_expression",
+    },
+    "c": {
+        "if_statement",
+        "for_statement",
+        "while_statement",
+        "do_statement",
+        "case_statement",
+        "conditional_expression",
+    },
+}
+
+# Short-circuit operators that add a branch when found in a binary expression
+BOOLEAN_OPERATORS = {"&&", "||", "??", "and", "or"}
+
+# Nested definitions are scored separately, so they are not descended into
+NESTED_FUNCTION_TYPES: dict[str, set[str]] = {
+    "python": {"function_definition", "lambda"},
+    "javascript": {"function_declaration", "arrow_function", "method_definition"},
+    "typescript": {"function_declaration", "arrow_function", "method_definition"},
+    "go": {"function_declaration", "method_declaration", "func_literal"},
+    "rust": {"function_item", "closure_expression"},
+    "java": {"method_declaration", "constructor_declaration", "lambda_expression"},
+    "scala": {"function_definition"},
+    "cpp": {"function_definition", "lambda_expression"},
+    "c": {"function_definition"},
+}
+
+
+def calculate_cyclomatic_complexity(func_node: Node, language: str) -> int:
+    """
+    Calculate McCabe cyclomatic complexity for a function node.
+
+    The result is 1 plus the number of decision points found in the
+    function body. Nested functions and lambdas are excluded because they
+    are reported as separate graph nodes.
+    """
+    decision_types = DECISION_NODE_TYPES.get(language, set())
+    nested_types = NESTED_FUNCTION_TYPES.get(language, set())
+
+    complexity = 1
+    stack = list(func_node.children)
+    while stack:
+        node = stack.pop()
+        if node.type in nested_types:
+            continue
+        if node.type in decision_types:
+            complexity += 1
+        elif node.type == "binary_expression" and _is_boolean_operator(node):
+            complexity += 1
+        stack.extend(node.children)
+
+    return complexity
+
+
+def _is_boolean_operator(node: Node) -> bool:
+    """Check whether a binary expression uses a short-circuit operator."""
+    operator = node.child_by_field_name("operator")
+    if operator is None or operator.text is None:
+        return False
+    return operator.text.decode("utf-8") in BOOLEAN_OPERATORS
@@ -0,0 +1,492 @@
+"""
+Heuristic shared-state hazards: package variables written from goroutines.
+
+Also collects the channels of Go code and the sends and receives on them, so
+the graph can show which functions talk to each other through which channel.
+"""
+
+from collections import defaultdict, deque
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+SPAWN_EDGES_QUERY = """
+MATCH (caller)-[:SPAWNS]->(callee)
+RETURN DISTINCT caller.qualified_name AS caller, callee.qualified_name AS callee
+"""
+
+CALL_EDGES_QUERY = """
+MATCH (caller)-[:CALLS]->(callee)
+WHERE (caller:Function OR caller:Method) AND (callee:Function OR callee:Method)
+RETURN DISTINCT caller.qualified_name AS caller, callee.qualified_name AS callee
+"""
+
+WRITES_QUERY = """
+MATCH (f)-[w:WRITES]->(v:GlobalVariable)
+OPTIONAL MATCH (m:Module)-[:DEFINES_VARIABLE]->(v)
+RETURN f.qualified_name AS writer, v.qualified_name AS variable, m.path AS path,
+       w.in_goroutine AS in_goroutine, w.guarded AS guarded
+"""
+
+# Methods on sync.Mutex/RWMutex and sync.Once that serialize access
+LOCK_METHODS = {"Lock", "RLock", "TryLock", "TryRLock", "Do"}
+
+# Packages whose calls are treated as synchronized access
+SYNC_PACKAGES = {"atomic"}
+
+
+@dataclass
+class GoChannel:
+    """A channel declared by a package variable, struct field, local or parameter."""
+
+    name: str
+    scope: str  # package, field, local or parameter
+    element_type: str
+    direction: str  # both, send (chan<- T) or receive (<-chan T)
+    buffered: bool
+    line_number: int
+    owner: str = ""  # Struct type of a field channel
+    parameter_index: int | None = None
+
+
+@dataclass
+class ChannelOperation:
+    """A send (`ch <- v`) or receive (`<-ch`, `range ch`) on a channel expression."""
+
+    channel: str  # As written: ch, s.results
+    line_number: int
+    in_goroutine: bool
+    ranged: bool = False  # `for v := range ch`, a receive only if ch is a channel
+
+
+@dataclass
+class GoFunctionConcurrency:
+    """Goroutine launches and package-variable writes inside one Go function."""
+
+    spawned: list[str] = field(default_factory=list)  # Names started with `go f()`
+    # Package variable name -> whether any write happens in a `go func() {...}()`
+    writes: dict[str, bool] = field(default_factory=dict)
+    guarded: bool = False  # Takes a lock or uses sync/atomic somewhere
+    sends: list[ChannelOperation] = field(default_factory=list)
+    receives: list[ChannelOperation] = field(default_factory=list)
+    # (callee name, argument index, argument) for identifiers passed to calls,
+    # so channels handed to a function or goroutine can be followed into it
+    arguments: list[tuple[str, int, str]] = field(default_factory=list)
+
+
+@dataclass
+class SharedStateHazard:
+    """A package variable written from goroutine-reachable code without locking."""
+
+    variable: str
+    path: str
+    goroutine_writers: list[str]  # Unguarded writers reachable from a goroutine
+    other_writers: list[str]  # Unguarded writers outside goroutines that race them
+    spawned_from: list[str]  # Functions whose `go` statements lead to the writers
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class ConcurrencyHazardAnalyzer:
+    """Reports package-level state that goroutines may write concurrently."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(self) -> list[SharedStateHazard]:
+        spawns = _edges(self.ingestor.fetch_all(SPAWN_EDGES_QUERY))
+        calls = _edges(self.ingestor.fetch_all(CALL_EDGES_QUERY))
+        writes = self.ingestor.fetch_all(WRITES_QUERY)
+        return find_shared_state_hazards(spawns, calls, writes)
+
+
+def find_shared_state_hazards(
+    spawns: list[tuple[str, str]],
+    calls: list[tuple[str, str]],
+    writes: list[dict[str, Any]],
+) -> list[SharedStateHazard]:
+    """
+    Flag variables with unguarded writes from code that may run in a goroutine.
+
+    Code runs in a goroutine when it is the target of a SPAWNS edge, is called
+    transitively from one, or is the body of a `go func() {...}()` literal.
+    Guarding is judged per function, so a lock taken anywhere in the writer
+    is assumed to protect its writes.
+    """
+    callees: dict[str, set[str]] = defaultdict(set)
+    for caller, callee in calls:
+        callees[caller].add(callee)
+
+    # Goroutine-reachable function -> spawning functions that lead to it
+    spawners: dict[str, set[str]] = defaultdict(set)
+    for spawner, target in spawns:
+        queue = deque([target])
+        seen = {target}
+        while queue:
+            current = queue.popleft()
+            spawners[current].add(spawner)
+            for callee in callees.get(current, ()):
+                if callee not in seen:
+                    seen.add(callee)
+                    queue.append(callee)
+
+    by_variable: dict[str, list[dict[str, Any]]] = defaultdict(list)
+    for write in writes:
+        if not write.get("guarded"):
+            by_variable[write["variable"]].append(write)
+
+    hazards = []
+    for variable, unguarded in by_variable.items():
+        goroutine_writers, other_writers, spawned_from = set(), set(), set()
+        for write in unguarded:
+            writer = write["writer"]
+            if write.get("in_goroutine"):
+                goroutine_writers.add(writer)
+                spawned_from.add(writer)
+            if writer in spawners:
+                goroutine_writers.add(writer)
+                spawned_from.update(spawners[writer])
+            if not write.get("in_goroutine") and writer not in spawners:
+                other_writers.add(writer)
+        if goroutine_writers:
+            hazards.append(
+                SharedStateHazard(
+                    variable=variable,
+                    path=unguarded[0].get("path") or "",
+                    goroutine_writers=sorted(goroutine_writers),
+                    other_writers=sorted(other_writers - goroutine_writers),
+                    spawned_from=sorted(spawned_from),
+                )
+            )
+    return sorted(
+        hazards,
+        key=lambda h: (
+            -(len(h.goroutine_writers) + len(h.other_writers)),
+            h.variable,
+        ),
+    )
+
+
+def collect_package_variables(root_node: Node) -> list[tuple[str, str]]:
+    """Return (name, type) for every package-level `var` in a Go file."""
+    variables = []
+    for declaration in root_node.children:
+        if declaration.type != "var_declaration":
+            continue
+        for spec in _var_specs(declaration):
+            type_node = spec.child_by_field_name("type")
+            type_text = node_text(type_node) if type_node else ""
+            for name_node in spec.children_by_field_name("name"):
+                name = node_text(name_node)
+                if name and name != "_":
+                    variables.append((name, type_text))
+    return variables
+
+
+def analyze_go_function(
+    func_node: Node, package_variables: set[str]
+) -> GoFunctionConcurrency:
+    """Collect goroutine launches, package-variable writes and lock usage."""
+    facts = GoFunctionConcurrency()
+    shadowed = _local_names(func_node)
+    tracked = package_variables - shadowed
+
+    stack: list[tuple[Node, bool]] = [(func_node, False)]
+    while stack:
+        node, in_goroutine = stack.pop()
+        if node.type == "go_statement":
+            call = next(
+                (c for c in node.named_children if c.type == "call_expression"), None
+            )
+            function = call.child_by_field_name("function") if call else None
+            if function is not None and function.type == "func_literal":
+                stack.append((function, True))
+                stack.extend((arg, in_goroutine) for arg in call.named_children[1:])
+                continue
+            if function is not None and (name := _callee_name(function)):
+                facts.spawned.append(name)
+        elif node.type == "call_expression":
+            function = node.child_by_field_name("function")
+            if function is not None and _is_synchronizing(function):
+                facts.guarded = True
+            arguments = node.child_by_field_name("arguments")
+            if function is not None and arguments is not None:
+                callee = _callee_name(function)
+                facts.arguments.extend(
+                    (callee, index, node_text(argument))
+                    for index, argument in enumerate(arguments.named_children)
+                    if callee and argument.type == "identifier"
+                )
+        elif node.type == "send_statement":
+            channel = node.child_by_field_name("channel")
+            if channel is not None:
+                facts.sends.append(
+                    ChannelOperation(
+                        node_text(channel), node.start_point[0] + 1, in_goroutine
+                    )
+                )
+        elif node.type == "unary_expression" and _is_receive(node):
+            operand = node.child_by_field_name("operand")
+            if operand is not None:
+                facts.receives.append(
+                    ChannelOperation(
+                        node_text(operand), node.start_point[0] + 1, in_goroutine
+                    )
+                )
+        elif node.type == "range_clause":
+            right = node.child_by_field_name("right")
+            if right is not None and right.type in (
+                "identifier",
+                "selector_expression",
+            ):
+                facts.receives.append(
+                    ChannelOperation(
+                        node_text(right), node.start_point[0] + 1, in_goroutine, True
+                    )
+                )
+        elif node.type in ("assignment_statement", "inc_statement", "dec_statement"):
+            targets = node.child_by_field_name("left") or node.named_children[0]
+            for target in _assigned_names(targets):
+                if target in tracked:
+                    in_any_goroutine = facts.writes.get(target, False)
+                    facts.writes[target] = in_any_goroutine or in_goroutine
+        stack.extend((child, in_goroutine) for child in node.children)
+    # The stack walks backwards; keep channel operations in source order
+    facts.sends.sort(key=lambda operation: operation.line_number)
+    facts.receives.sort(key=lambda operation: operation.line_number)
+    return facts
+
+
+def collect_package_channels(root_node: Node) -> list[GoChannel]:
+    """Channels held in package-level variables and in struct fields."""
+    channels = []
+    for declaration in root_node.children:
+        if declaration.type == "var_declaration":
+            for spec in _var_specs(declaration):
+                channels.extend(_declared_channels(spec, "package"))
+        elif declaration.type == "type_declaration":
+            for spec in declaration.named_children:
+                name = spec.child_by_field_name("name")
+                struct = spec.child_by_field_name("type")
+                if name is None or struct is None or struct.type != "struct_type":
+                    continue
+                for field_node in _descendants_of_type(struct, "field_declaration"):
+                    type_node = field_node.child_by_field_name("type")
+                    if type_node is None or type_node.type != "channel_type":
+                        continue
+                    for field_name in field_node.children_by_field_name("name"):
+                        channels.append(
+                            _channel(
+                                node_text(field_name),
+                                "field",
+                                type_node,
+                                False,
+                                field_node,
+                                owner=node_text(name),
+                            )
+                        )
+    return channels
+
+
+def collect_function_channels(func_node: Node) -> list[GoChannel]:
+    """Channel parameters of a Go function and the channels it makes locally."""
+    channels = []
+    parameters = func_node.child_by_field_name("parameters")
+    index = 0
+    for parameter in parameters.named_children if parameters else []:
+        names = parameter.children_by_field_name("name")
+        type_node = parameter.child_by_field_name("type")
+        if type_node is not None and type_node.type == "channel_type":
+            channels.extend(
+                _channel(
+                    node_text(name),
+                    "parameter",
+                    type_node,
+                    False,
+                    parameter,
+                    parameter_index=index + offset,
+                )
+                for offset, name in enumerate(names)
+            )
+        # `a, b chan int` declares two parameters, `chan int` alone one
+        index += max(len(names), 1)
+    body = func_node.child_by_field_name("body")
+    if body is None:
+        return channels
+    for node in _descendants_of_type(body, "short_var_declaration", "var_spec"):
+        channels.extend(_declared_channels(node, "local"))
+    return channels
+
+
+def receiver_of(func_node: Node) -> tuple[str, str]:
+    """Receiver name and type of a Go method, (s, Store) for (s *Store)."""
+    receiver = func_node.child_by_field_name("receiver")
+    if receiver is None or not receiver.named_children:
+        return "", ""
+    parameter = receiver.named_children[0]
+    name = parameter.child_by_field_name("name")
+    type_node = parameter.child_by_field_name("type")
+    type_name = node_text(type_node).lstrip("*").split("[")[0] if type_node else ""
+    return (node_text(name) if name else ""), type_name.strip()
+
+
+def _declared_channels(node: Node, scope: str) -> list[GoChannel]:
+    """Channels of `var ch chan T`, `var ch = make(chan T)` or `ch := make(...)`."""
+    if node.type == "short_var_declaration":
+        left = node.child_by_field_name("left")
+        names = left.named_children if left else []
+        right = node.child_by_field_name("right")
+        values = right.named_children if right else []
+        type_node = None
+    else:
+        names = node.children_by_field_name("name")
+        value = node.child_by_field_name("value")
+        values = value.named_children if value else []
+        type_node = node.child_by_field_name("type")
+    channels = []
+    for position, name in enumerate(names):
+        made = _made_channel(values[position]) if position < len(values) else None
+        channel_type = made[0] if made else type_node
+        if channel_type is None or channel_type.type != "channel_type":
+            continue
+        buffered = made[1] if made else False
+        channels.append(_channel(node_text(name), scope, channel_type, buffered, node))
+    return channels
+
+
+def _made_channel(value: Node) -> tuple[Node, bool] | None:
+    """The channel type and whether a capacity is given for make(chan T, n)."""
+    if value.type != "call_expression":
+        return None
+    function = value.child_by_field_name("function")
+    arguments = value.child_by_field_name("arguments")
+    if function is None or node_text(function) != "make" or arguments is None:
+        return None
+    arguments_list = arguments.named_children
+    if not arguments_list or arguments_list[0].type != "channel_type":
+        return None
+    capacity = arguments_list[1:2]
+    return arguments_list[0], bool(capacity) and node_text(capacity[0]) != "0"
+
+
+def _channel(
+    name: str,
+    scope: str,
+    type_node: Node,
+    buffered: bool,
+    declaration: Node,
+    owner: str = "",
+    parameter_index: int | None = None,
+) -> GoChannel:
+    text = "".join(node_text(type_node).split())
+    if text.startswith("<-chan"):
+        direction, element = "receive", text[len("<-chan") :]
+    elif text.startswith("chan<-"):
+        direction, element = "send", text[len("chan<-") :]
+    else:
+        direction, element = "both", text[len("chan") :]
+    value = type_node.child_by_field_name("value")
+    return GoChannel(
+        name=name,
+        scope=scope,
+        element_type=node_text(value) if value is not None else element,
+        direction=direction,
+        buffered=buffered,
+        line_number=declaration.start_point[0] + 1,
+        owner=owner,
+        parameter_index=parameter_index,
+    )
+
+
+def _is_receive(node: Node) -> bool:
+    operator = node.child_by_field_name("operator")
+    if operator is not None:
+        return operator.type == "<-"
+    return bool(node.children) and node.children[0].type == "<-"
+
+
+def _descendants_of_type(node: Node, *types: str) -> list[Node]:
+    found = []
+    stack = list(node.named_children)
+    while stack:
+        child = stack.pop()
+        if child.type in types:
+            found.append(child)
+        stack.extend(child.named_children)
+    return sorted(found, key=lambda n: n.start_point)
+
+
+def _edges(rows: list[dict[str, Any]]) -> list[tuple[str, str]]:
+    return [(row["caller"], row["callee"]) for row in rows]
+
+
+def _var_specs(declaration: Node) -> list[Node]:
+    specs = []
+    for child in declaration.named_children:
+        if child.type == "var_spec":
+            specs.append(child)
+        elif child.type == "var_spec_list":
+            specs.extend(c for c in child.named_children if c.type == "var_spec")
+    return specs
+
+
+def _local_names(func_node: Node) -> set[str]:
+    """Names declared inside a function, which shadow package variables."""
+    names = set()
+    stack = list(func_node.children)
+    while stack:
+        node = stack.pop()
+        if node.type == "short_var_declaration":
+            left = node.child_by_field_name("left")
+            if left is not None:
+                names.update(
+                    node_text(c) for c in left.named_children if c.type == "identifier"
+                )
+        elif node.type in ("var_spec", "parameter_declaration", "const_spec"):
+            names.update(node_text(c) for c in node.children_by_field_name("name"))
+        elif node.type == "range_clause":
+            left = node.child_by_field_name("left")
+            if left is not None and any(c.type == ":=" for c in node.children):
+                names.update(
+                    node_text(c) for c in left.named_children if c.type == "identifier"
+                )
+        stack.extend(node.children)
+    return names
+
+
+def _assigned_names(targets: Node) -> list[str]:
+    """Root identifiers written by `x = `, `x[k] = `, `x.f = ` or `x++`."""
+    nodes = targets.named_children if targets.type == "expression_list" else [targets]
+    names = []
+    for target in nodes:
+        while target.type in ("index_expression", "selector_expression"):
+            operand = target.child_by_field_name("operand")
+            if operand is None:
+                break
+            target = operand
+        if target.type == "identifier":
+            names.append(node_text(target))
+    return names
+
+
+def _is_synchronizing(function: Node) -> bool:
+    if function.type != "selector_expression":
+        return False
+    operand = function.child_by_field_name("operand")
+    field_node = function.child_by_field_name("field")
+    if operand is not None and node_text(operand) in SYNC_PACKAGES:
+        return True
+    return field_node is not None and node_text(field_node) in LOCK_METHODS
+
+
+def _callee_name(function: Node) -> str:
+    if function.type == "selector_expression":
+        function = function.child_by_field_name("field") or function
+    if function.type not in ("identifier", "field_identifier"):
+        return ""
+    return node_text(function)
+
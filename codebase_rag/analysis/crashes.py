@@ -0,0 +1,206 @@
+"""Sentry crash report ingestion: issues linked to the functions they crash in."""
+
+import json
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .code_locator import CodeLocator
+from .stack_traces import StackFrame
+
+# Functions with crash reports and how often those crashes happened
+CRASHING_FUNCTIONS_QUERY = """
+MATCH (c:Crash)-[r:CRASHED_IN]->(f)
+WITH f, count(c) AS crashes, sum(c.count) AS events,
+     sum(CASE WHEN r.is_top THEN c.count ELSE 0 END) AS top_frame_events,
+     collect(c.short_id)[..5] AS examples
+WHERE events >= $min_events
+RETURN f.qualified_name AS qualified_name, crashes, events, top_frame_events,
+       examples
+ORDER BY events DESC
+LIMIT $limit
+"""
+
+
+@dataclass
+class CrashIssue:
+    """A Sentry issue: one grouped crash with its occurrence counts."""
+
+    issue_id: str
+    short_id: str
+    title: str
+    culprit: str = ""
+    level: str = ""
+    status: str = ""
+    project: str = ""
+    count: int = 0  # Events
+    user_count: int = 0
+    first_seen: str = ""
+    last_seen: str = ""
+    url: str = ""
+    # In-app frames of the latest event, outermost call first
+    frames: list[StackFrame] = field(default_factory=list)
+
+
+def load_sentry_export(data: Any) -> list[CrashIssue]:
+    """
+    Issues from a Sentry export: the issue list API response (optionally with
+    each issue's latest event under "latestEvent"), or a single event as
+    downloaded from an issue page.
+    """
+    if isinstance(data, dict) and "issues" in data:
+        data = data["issues"]
+    if isinstance(data, dict):
+        data = [data]
+    issues = []
+    for item in data if isinstance(data, list) else []:
+        if not isinstance(item, dict):
+            continue
+        if "entries" in item or "exception" in item:
+            issues.append(_from_event(item))
+        elif item.get("id"):
+            issues.append(_from_issue(item))
+    return issues
+
+
+def _from_issue(issue: dict[str, Any]) -> CrashIssue:
+    event = issue.get("latestEvent") or issue.get("event") or {}
+    project = issue.get("project")
+    return CrashIssue(
+        issue_id=str(issue["id"]),
+        short_id=issue.get("shortId") or str(issue["id"]),
+        title=issue.get("title") or "",
+        culprit=issue.get("culprit") or "",
+        level=issue.get("level") or "",
+        status=issue.get("status") or "",
+        project=project.get("slug", "") if isinstance(project, dict) else "",
+        # Counts are strings in the issues API
+        count=int(issue.get("count") or 0),
+        user_count=int(issue.get("userCount") or 0),
+        first_seen=issue.get("firstSeen") or "",
+        last_seen=issue.get("lastSeen") or "",
+        url=issue.get("permalink") or "",
+        frames=_event_frames(event),
+    )
+
+
+def _from_event(event: dict[str, Any]) -> CrashIssue:
+    issue_id = str(event.get("groupID") or event.get("eventID") or "")
+    return CrashIssue(
+        issue_id=issue_id,
+        short_id=issue_id,
+        title=event.get("title") or "",
+        culprit=event.get("culprit") or "",
+        level=event.get("level") or "",
+        count=1,
+        first_seen=event.get("dateCreated") or "",
+        last_seen=event.get("dateCreated") or "",
+        frames=_event_frames(event),
+    )
+
+
+def _event_frames(event: dict[str, Any]) -> list[StackFrame]:
+    # Processed events keep the exception among "entries"; raw payloads at the top
+    exception = event.get("exception") or next(
+        (
+            entry.get("data")
+            for entry in event.get("entries") or []
+            if entry.get("type") == "exception"
+        ),
+        None,
+    )
+    values = (exception or {}).get("values") or []
+    if not values:
+        return []
+    # The last value is the exception that was raised; earlier ones caused it
+    frames = (values[-1].get("stacktrace") or {}).get("frames") or []
+    in_app = [frame for frame in frames if frame.get("inApp") or frame.get("in_app")]
+    return [
+        StackFrame(
+            function=".".join(
+                part for part in (frame.get("module"), frame.get("function")) if part
+            ),
+            file=frame.get("filename") or frame.get("absPath") or "",
+            line=int(frame.get("lineNo") or frame.get("lineno") or 0),
+        )
+        for frame in in_app or frames
+    ]
+
+
+def collect_exports(paths: list[Path]) -> list[Path]:
+    """Expand directories into the JSON files they contain."""
+    files = []
+    for path in paths:
+        if path.is_dir():
+            files.extend(sorted(path.rglob("*.json")))
+        elif path.is_file():
+            files.append(path)
+    return files
+
+
+def load_export_file(path: Path) -> list[CrashIssue]:
+    return load_sentry_export(json.loads(path.read_text(encoding="utf-8")))
+
+
+class CrashMapper:
+    """Stores Crash nodes with CRASHED_IN edges to the functions in their frames."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self.locator = CodeLocator(ingestor)
+
+    def ingest(self, issues: list[CrashIssue]) -> dict[str, int]:
+        frames = resolved = 0
+        for issue in issues:
+            self.ingestor.ensure_node_batch(
+                "Crash",
+                {
+                    "issue_id": issue.issue_id,
+                    "short_id": issue.short_id,
+                    "title": issue.title,
+                    "culprit": issue.culprit,
+                    "level": issue.level,
+                    "status": issue.status,
+                    "project": issue.project,
+                    "count": issue.count,
+                    "user_count": issue.user_count,
+                    "first_seen": issue.first_seen,
+                    "last_seen": issue.last_seen,
+                    "url": issue.url,
+                },
+            )
+            linked: set[str] = set()
+            # Innermost first, so a function recursing keeps its top position
+            for depth, frame in enumerate(reversed(issue.frames)):
+                frames += 1
+                match = self._resolve(frame)
+                if match is None or match["qualified_name"] in linked:
+                    continue
+                linked.add(match["qualified_name"])
+                resolved += 1
+                self.ingestor.ensure_relationship_batch(
+                    ("Crash", "issue_id", issue.issue_id),
+                    "CRASHED_IN",
+                    (match["label"], "qualified_name", match["qualified_name"]),
+                    {"depth": depth, "is_top": depth == 0, "line": frame.line},
+                )
+        self.ingestor.flush_all()
+        stats = {"crashes": len(issues), "frames": frames, "resolved_frames": resolved}
+        logger.info(f"Ingested crash reports: {stats}")
+        return stats
+
+    def find_crashing_functions(
+        self, min_events: int = 1, limit: int = 50
+    ) -> list[dict[str, Any]]:
+        return self.ingestor.fetch_all(  # type: ignore[no-any-return]
+            CRASHING_FUNCTIONS_QUERY, {"min_events": min_events, "limit": limit}
+        )
+
+    def _resolve(self, frame: StackFrame) -> dict[str, Any] | None:
+        if frame.file and frame.line:
+            match = self.locator.at_line(frame.file, frame.line)
+            if match:
+                return match
+        return self.locator.by_name(frame.function) if frame.function else None
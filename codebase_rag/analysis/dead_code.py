@@ -0,0 +1,199 @@
+"""Dead code: functions and types nothing reachable from an entrypoint uses."""
+
+import fnmatch
+from collections import defaultdict, deque
+from dataclasses import asdict, dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+from loguru import logger
+
+from ..parsers.test_detector import TestDetector
+from ..utils.visibility import is_exported, language_for_path
+from .call_depth import classify_entrypoint
+
+# Functions, methods and types with what is needed to recognize roots
+SYMBOLS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(n)
+WHERE n:Function OR n:Method OR n:Class OR n:Interface
+WITH DISTINCT m, n
+OPTIONAL MATCH (e:Endpoint)-[:HANDLED_BY]->(n)
+RETURN n.qualified_name AS qualified_name, labels(n)[0] AS label,
+       n.name AS name, n.decorators AS decorators, n.is_static AS is_static,
+       m.path AS path, n.start_line AS line_number, count(e) AS endpoint_count
+"""
+
+# Edges through which code uses other code, with the label of their source
+USES_QUERY = """
+MATCH (a)-[r]->(b)
+WHERE type(r) IN $types AND (b:Function OR b:Method OR b:Class OR b:Interface)
+RETURN DISTINCT a.qualified_name AS source, labels(a)[0] AS source_label,
+       b.qualified_name AS target, type(r) AS type
+"""
+
+# Edges meaning the target is used when the source is; the others run backwards
+FORWARD_EDGES = {
+    "CALLS",
+    "SPAWNS",
+    "DEFERS",
+    "INSTANTIATES",
+    "TESTS",
+    "EXERCISES",
+    "DOCUMENTS",
+    "INHERITS_FROM",
+    "IMPLEMENTS",
+}
+# A live method makes the overriding methods live, as calls dispatch to them,
+# and the class defining it
+BACKWARD_EDGES = {"OVERRIDES", "DEFINES_METHOD"}
+TEST_LABELS = {"TestFunction", "TestCase", "TestSuite"}
+
+
+@dataclass
+class DeadSymbol:
+    """A function or type no entrypoint, exported symbol or test reaches."""
+
+    qualified_name: str
+    name: str
+    label: str  # "Function", "Method", "Class" or "Interface"
+    path: str
+    package: str  # Directory containing the file
+    line_number: int | None = None
+    # Dead code using it, which goes away with it
+    dead_users: list[str] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class DeadCodeAnalyzer:
+    """Walks the call graph from the roots and reports what it never reaches."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(
+        self,
+        exported_roots: bool = True,
+        exclude: list[str] | None = None,
+        entrypoints: list[str] | None = None,
+    ) -> list[DeadSymbol]:
+        return find_dead_code(
+            self.ingestor.fetch_all(SYMBOLS_QUERY),
+            self.ingestor.fetch_all(
+                USES_QUERY, {"types": sorted(FORWARD_EDGES | BACKWARD_EDGES)}
+            ),
+            exported_roots,
+            exclude or [],
+            entrypoints or [],
+        )
+
+    def store_results(self, dead: list[DeadSymbol]) -> None:
+        """Tag dead symbols with is_dead_code, clearing stale tags first."""
+        self.ingestor.execute_write(
+            "MATCH (n) WHERE (n:Function OR n:Method OR n:Class OR n:Interface) "
+            "AND n.is_dead_code = true SET n.is_dead_code = false"
+        )
+        for symbol in dead:
+            self.ingestor.ensure_node_batch(
+                symbol.label,
+                {"qualified_name": symbol.qualified_name, "is_dead_code": True},
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Tagged {len(dead)} symbols as dead code")
+
+
+def find_dead_code(
+    symbols: list[dict[str, Any]],
+    uses: list[dict[str, Any]],
+    exported_roots: bool = True,
+    exclude: list[str] | None = None,
+    entrypoints: list[str] | None = None,
+) -> list[DeadSymbol]:
+    """
+    Symbols unreachable from the roots: entrypoints (main, HTTP handlers, CLI
+    commands, jobs), tests, code in test files, Go init functions, decorated
+    functions and special methods, which are called by their frameworks and
+    the language, exported symbols unless exported_roots is off, and anything
+    in an excluded package, such as one whose code is called through
+    reflection.
+    """
+    detector = TestDetector()
+    graph: dict[str, set[str]] = defaultdict(set)
+    roots: set[str] = set()
+    for use in uses:
+        if use["type"] in BACKWARD_EDGES:
+            graph[use["target"]].add(use["source"])
+        else:
+            graph[use["source"]].add(use["target"])
+        if use.get("source_label") in TEST_LABELS:
+            roots.add(use["source"])
+
+    by_name: dict[str, dict[str, Any]] = {}
+    for row in symbols:
+        by_name[row["qualified_name"]] = row
+        if _is_root(row, detector, exported_roots, exclude or [], entrypoints or []):
+            roots.add(row["qualified_name"])
+
+    live = set(roots)
+    queue = deque(roots)
+    while queue:
+        for used in graph.get(queue.popleft(), ()):
+            if used not in live:
+                live.add(used)
+                queue.append(used)
+
+    dead_users: dict[str, set[str]] = defaultdict(set)
+    for use in uses:
+        if use["type"] in FORWARD_EDGES and use["source"] not in live:
+            dead_users[use["target"]].add(use["source"])
+
+    dead = []
+    for qualified_name, row in by_name.items():
+        if qualified_name in live:
+            continue
+        path = row.get("path") or ""
+        dead.append(
+            DeadSymbol(
+                qualified_name=qualified_name,
+                name=row.get("name") or qualified_name,
+                label=row.get("label") or "Function",
+                path=path,
+                package=str(PurePosixPath(path).parent) if path else "",
+                line_number=row.get("line_number"),
+                dead_users=sorted(dead_users[qualified_name] - {qualified_name}),
+            )
+        )
+    return sorted(dead, key=lambda d: (d.path, d.line_number or 0, d.qualified_name))
+
+
+def is_excluded(path: str, patterns: list[str]) -> bool:
+    """Whether a file is in one of the packages given as directories or globs."""
+    return any(
+        path.startswith(pattern.rstrip("/") + "/") or fnmatch.fnmatchcase(path, pattern)
+        for pattern in patterns
+    )
+
+
+def _is_root(
+    row: dict[str, Any],
+    detector: TestDetector,
+    exported_roots: bool,
+    exclude: list[str],
+    entrypoints: list[str],
+) -> bool:
+    path = row.get("path") or ""
+    name = row.get("name") or ""
+    language = language_for_path(path)
+    if is_excluded(path, exclude):
+        return True
+    if language and detector.is_test_file(path, language):
+        return True
+    if row.get("label") in ("Function", "Method"):
+        if classify_entrypoint(row, entrypoints):
+            return True
+        if row.get("decorators") or (name.startswith("__") and name.endswith("__")):
+            return True
+        if language == "go" and name == "init":
+            return True
+    return exported_roots and is_exported(name, language, bool(row.get("is_static")))
@@ -282,35 +282,45 @@ class DependencyAnalyzer:
         exports = []
         imports = []
 
-        # ES6 imports and exports
-        # TODO: Implement import_export_query when JavaScript analysis is added
-        # import_export_query = """
-        # [
-        #     (import_statement) @import
-        #     (export_statement) @export
-        # ]
-        # """
-
-        # CommonJS patterns
-        # TODO: Implement commonjs_query when JavaScript analysis is added
-        # commonjs_query = """
-        # [
-        #     (call_expression
-        #         function: (identifier) @func
-        #         arguments: (arguments (string) @module)
-        #     ) @require
-        #     (assignment_expression
-        #         left: (member_expression
-        #             object: (identifier) @obj
-        #             property: (property_identifier) @prop
-        #         )
-        #         right: (_) @value
-        #     ) @exports_assign
-        # ]
-        # """
+        # ES6 imports, re-exports, require() and import() calls
+        stack = [root_node]
+        while stack:
+            node = stack.pop()
+            stack.extend(reversed(node.children))
+            if node.type in ("import_statement", "export_statement"):
+                source = node.child_by_field_name("source")
+                if source is None:
+                    continue
+                has_clause = any(
+                    child.type in ("import_clause", "export_clause", "*")
+                    for child in node.children
+                )
+                imports.append(Import(
+                    symbol="*",
+                    source_module=self._get_node_text(source).strip("'\"`"),
+                    import_type="namespace" if has_clause else "side_effect",
+                    line_number=node.start_point[0] + 1,
+                    is_type_only=any(child.type == "type" for child in node.children)
+                ))
+            elif node.type == "call_expression":
+                function = node.child_by_field_name("function")
+                arguments = node.child_by_field_name("arguments")
+                if function is None or arguments is None or not (
+                    function.type == "import"
+                    or (function.type == "identifier"
+                        and self._get_node_text(function) == "require")
+                ):
+                    continue
+                argument = next(iter(arguments.named_children), None)
+                if argument is not None and argument.type == "string":
+                    imports.append(Import(
+                        symbol="*",
+                        source_module=self._get_node_text(argument).strip("'\"`"),
+                        import_type="namespace",
+                        line_number=node.start_point[0] + 1
+                    ))
 
-        # TODO: Implement JavaScript/TypeScript dependency analysis
-        logger.info("JavaScript/TypeScript dependency analysis not yet implemented")
+        # TODO: Implement JavaScript/TypeScript export analysis
 
         return exports, imports
 
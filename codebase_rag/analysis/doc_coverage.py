@@ -0,0 +1,108 @@
+"""Undocumented public API report, ranked by how widely each symbol is used."""
+
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from ..parsers.test_detector import TestDetector
+from ..utils.visibility import is_exported, language_for_path
+
+# Functions, methods and classes with their doc comment and inbound usage count
+DOCUMENTATION_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(s)
+WHERE s:Function OR s:Method OR s:Class
+OPTIONAL MATCH (user)-[:CALLS|INHERITS_FROM]->(s)
+WITH m, s, count(DISTINCT user) AS fan_in
+RETURN DISTINCT s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.path AS path, s.docstring AS docstring,
+       s.is_static AS is_static, fan_in
+"""
+
+
+@dataclass
+class UndocumentedSymbol:
+    """An exported symbol without a doc comment."""
+
+    qualified_name: str
+    name: str
+    label: str  # "Function", "Method" or "Class"
+    path: str
+    fan_in: int  # Distinct callers/subclasses; the weight for prioritizing docs
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class DocCoverageReport:
+    """Documentation coverage of the public API."""
+
+    total_exported: int
+    documented: int
+    undocumented: list[UndocumentedSymbol]
+
+    @property
+    def coverage_percent(self) -> float:
+        if not self.total_exported:
+            return 100.0
+        return self.documented * 100.0 / self.total_exported
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "total_exported": self.total_exported,
+            "documented": self.documented,
+            "coverage_percent": round(self.coverage_percent, 2),
+            "undocumented": [symbol.to_dict() for symbol in self.undocumented],
+        }
+
+
+class DocCoverageAnalyzer:
+    """Finds exported symbols lacking documentation in the code graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(self, min_fan_in: int = 0) -> DocCoverageReport:
+        """Return the coverage report, most depended-upon symbols first."""
+        rows = self.ingestor.fetch_all(DOCUMENTATION_QUERY)
+        return build_doc_coverage_report(rows, min_fan_in)
+
+
+def build_doc_coverage_report(
+    rows: list[dict[str, Any]],
+    min_fan_in: int = 0,
+    test_detector: TestDetector | None = None,
+) -> DocCoverageReport:
+    """Filter rows to the public, non-test API and rank undocumented symbols by fan-in."""
+    test_detector = test_detector or TestDetector()
+    total = documented = 0
+    undocumented = []
+    for row in rows:
+        path = row.get("path") or ""
+        language = language_for_path(path)
+        if language and test_detector.is_test_file(path, language):
+            continue
+        if not is_exported(row.get("name") or "", language, bool(row.get("is_static"))):
+            continue
+
+        total += 1
+        if (row.get("docstring") or "").strip():
+            documented += 1
+            continue
+
+        fan_in = row.get("fan_in") or 0
+        if fan_in < min_fan_in:
+            continue
+        undocumented.append(
+            UndocumentedSymbol(
+                qualified_name=row["qualified_name"],
+                name=row["name"],
+                label=row.get("label") or "Function",
+                path=path,
+                fan_in=fan_in,
+            )
+        )
+
+    undocumented.sort(key=lambda s: (-s.fan_in, s.qualified_name))
+    return DocCoverageReport(
+        total_exported=total, documented=documented, undocumented=undocumented
+    )
@@ -0,0 +1,256 @@
+"""
+Go functions used as values: passed as arguments, assigned, kept in struct
+fields or returned, rather than called where they are named.
+
+A comparator handed to sort.Slice, a handler registered on a mux or a
+function wrapped by middleware runs when whatever received it calls it, so
+direct calls alone leave it without callers. The uses collected here become
+REFERENCES edges, and CALLS edges where the function that calls the value is
+known: a standard library function that calls it before returning, a
+function of the repository calling the parameter it was passed as, or the
+function itself calling the local it was stored in.
+"""
+
+from dataclasses import dataclass, field
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Standard library functions calling a function argument before they return,
+# by (import path, name): the position of that argument
+SYNCHRONOUS_CALLBACKS: dict[tuple[str, str], int] = {
+    ("sort", "Slice"): 1,
+    ("sort", "SliceStable"): 1,
+    ("sort", "Search"): 1,
+    ("slices", "SortFunc"): 1,
+    ("slices", "SortStableFunc"): 1,
+    ("slices", "IsSortedFunc"): 1,
+    ("slices", "BinarySearchFunc"): 2,
+    ("slices", "IndexFunc"): 1,
+    ("slices", "ContainsFunc"): 1,
+    ("slices", "DeleteFunc"): 1,
+    ("slices", "CompactFunc"): 1,
+    ("slices", "EqualFunc"): 2,
+    ("slices", "CompareFunc"): 2,
+    ("slices", "MaxFunc"): 1,
+    ("slices", "MinFunc"): 1,
+    ("maps", "DeleteFunc"): 1,
+    ("maps", "EqualFunc"): 2,
+    ("strings", "Map"): 0,
+    ("strings", "FieldsFunc"): 1,
+    ("strings", "IndexFunc"): 1,
+    ("strings", "LastIndexFunc"): 1,
+    ("strings", "ContainsFunc"): 1,
+    ("strings", "TrimFunc"): 1,
+    ("strings", "TrimLeftFunc"): 1,
+    ("strings", "TrimRightFunc"): 1,
+    ("bytes", "Map"): 0,
+    ("bytes", "FieldsFunc"): 1,
+    ("bytes", "IndexFunc"): 1,
+    ("bytes", "TrimFunc"): 1,
+    ("path/filepath", "Walk"): 1,
+    ("path/filepath", "WalkDir"): 1,
+    ("io/fs", "WalkDir"): 2,
+}
+
+# Names that are never functions of the repository
+PREDECLARED = {"nil", "true", "false", "iota", "_"}
+
+
+@dataclass
+class FunctionValue:
+    """A name used as a value: `handler`, `auth.Wrap` or a method value `s.serve`."""
+
+    name: str  # As written
+    line_number: int
+    use: str  # argument, assignment, field or return
+    callee: str = ""  # For arguments, the call receiving it as written: sort.Slice
+    position: int = 0  # For arguments, its index among them
+    target: str = ""  # For assignments and fields, what it is stored in
+
+
+@dataclass
+class GoFunctionValues:
+    """The function values of one Go function and what it calls by variable."""
+
+    values: list[FunctionValue] = field(default_factory=list)
+    # Parameters the function calls, by position: {index: name}
+    called_parameters: dict[int, str] = field(default_factory=dict)
+    # Names called as `f()`, which a local holding a function may be
+    called_names: set[str] = field(default_factory=set)
+    # Parameters and locals, which shadow functions of the package
+    local_names: set[str] = field(default_factory=set)
+
+
+def collect_function_values(func_node: Node) -> GoFunctionValues:
+    """Names a Go function uses as values, and the parameters it calls."""
+    facts = GoFunctionValues()
+    parameters = _parameter_names(func_node)
+    facts.local_names = {*parameters, *_local_names(func_node)}
+    receiver = func_node.child_by_field_name("receiver")
+    facts.local_names.update(_parameter_names_of(receiver))
+
+    stack = list(func_node.named_children)
+    while stack:
+        node = stack.pop()
+        if node.type == "call_expression":
+            function = node.child_by_field_name("function")
+            arguments = node.child_by_field_name("arguments")
+            if function is not None and function.type == "identifier":
+                facts.called_names.add(node_text(function))
+            for position, argument in enumerate(
+                arguments.named_children if arguments is not None else []
+            ):
+                if _is_value(argument):
+                    facts.values.append(
+                        FunctionValue(
+                            node_text(argument),
+                            argument.start_point[0] + 1,
+                            "argument",
+                            callee=node_text(function) if function is not None else "",
+                            position=position,
+                        )
+                    )
+        elif node.type in ("assignment_statement", "short_var_declaration"):
+            left = node.child_by_field_name("left")
+            right = node.child_by_field_name("right")
+            facts.values.extend(_assigned(left, right))
+        elif node.type == "var_spec":
+            names = node.children_by_field_name("name")
+            value = node.child_by_field_name("value")
+            values = value.named_children if value is not None else []
+            facts.values.extend(
+                FunctionValue(
+                    node_text(value_node),
+                    value_node.start_point[0] + 1,
+                    "assignment",
+                    target=node_text(name),
+                )
+                for name, value_node in zip(names, values)
+                if _is_value(value_node)
+            )
+        elif node.type == "keyed_element":
+            key, value_node = _key_and_value(node)
+            if key is not None and value_node is not None and _is_value(value_node):
+                facts.values.append(
+                    FunctionValue(
+                        node_text(value_node),
+                        value_node.start_point[0] + 1,
+                        "field",
+                        target=node_text(key),
+                    )
+                )
+        elif node.type == "return_statement":
+            results = node.named_children
+            if results and results[0].type == "expression_list":
+                results = results[0].named_children
+            facts.values.extend(
+                FunctionValue(node_text(result), result.start_point[0] + 1, "return")
+                for result in results
+                if _is_value(result)
+            )
+        stack.extend(node.named_children)
+
+    facts.values.sort(key=lambda value: (value.line_number, value.position))
+    facts.called_parameters = {
+        index: name
+        for index, name in enumerate(parameters)
+        if name in facts.called_names
+    }
+    return facts
+
+
+def _is_value(node: Node) -> bool:
+    """An identifier or `x.y` selector that could name a function."""
+    if node.type == "identifier":
+        return node_text(node) not in PREDECLARED
+    if node.type == "selector_expression":
+        operand = node.child_by_field_name("operand")
+        return operand is not None and operand.type == "identifier"
+    return False
+
+
+def _assigned(left: Node | None, right: Node | None) -> list[FunctionValue]:
+    """Values of `a, b = f, g` paired with what they are assigned to."""
+    if left is None or right is None:
+        return []
+    targets = left.named_children if left.type == "expression_list" else [left]
+    values = right.named_children if right.type == "expression_list" else [right]
+    if len(targets) != len(values):
+        # `a, b := f()` assigns results, not the function
+        return []
+    return [
+        FunctionValue(
+            node_text(value),
+            value.start_point[0] + 1,
+            "assignment",
+            target=node_text(target),
+        )
+        for target, value in zip(targets, values)
+        if _is_value(value)
+    ]
+
+
+def _key_and_value(node: Node) -> tuple[Node | None, Node | None]:
+    """The key and value of `Key: value` in a composite literal."""
+    key = node.child_by_field_name("key")
+    value = node.child_by_field_name("value")
+    if key is None or value is None:
+        # Grammars without field names wrap both in literal_element nodes
+        elements = node.named_children
+        if len(elements) != 2:
+            return None, None
+        key, value = elements
+    if key.type == "literal_element" and key.named_children:
+        key = key.named_children[0]
+    if value.type == "literal_element" and value.named_children:
+        value = value.named_children[0]
+    if key.type not in ("field_identifier", "identifier"):
+        # Map and slice literals are keyed by values rather than fields
+        return None, None
+    return key, value
+
+
+def _parameter_names(func_node: Node) -> list[str]:
+    """The parameter names of a function in order, `_` for unnamed ones."""
+    return _parameter_names_of(func_node.child_by_field_name("parameters"))
+
+
+def _parameter_names_of(parameters: Node | None) -> list[str]:
+    names = []
+    for parameter in parameters.named_children if parameters is not None else []:
+        if parameter.type != "parameter_declaration":
+            # A variadic parameter is called with a slice, never as one
+            continue
+        declared = parameter.children_by_field_name("name")
+        names.extend(node_text(name) for name in declared)
+        if not declared:
+            names.append("_")
+    return names
+
+
+def _local_names(func_node: Node) -> set[str]:
+    """Names declared inside a function's body."""
+    names = set()
+    body = func_node.child_by_field_name("body")
+    stack = list(body.named_children) if body is not None else []
+    while stack:
+        node = stack.pop()
+        if node.type == "short_var_declaration":
+            left = node.child_by_field_name("left")
+            if left is not None:
+                names.update(
+                    node_text(c) for c in left.named_children if c.type == "identifier"
+                )
+        elif node.type in ("var_spec", "const_spec", "parameter_declaration"):
+            names.update(node_text(c) for c in node.children_by_field_name("name"))
+        elif node.type == "range_clause":
+            left = node.child_by_field_name("left")
+            if left is not None and any(c.type == ":=" for c in node.children):
+                names.update(
+                    node_text(c) for c in left.named_children if c.type == "identifier"
+                )
+        stack.extend(node.named_children)
+    return names
+
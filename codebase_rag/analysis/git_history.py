@@ -0,0 +1,142 @@
+"""Commit history in the graph: commits, their authors and the code they changed.
+
+Files are linked to every loaded commit that touched them. Functions and
+methods are linked through git blame of their current lines, so a commit is
+recorded as modifying a function only while some of its lines survive; that
+is what "who last touched this function" asks, and commits whose lines were
+all rewritten since no longer describe the code as it is.
+"""
+
+from collections import defaultdict
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from ..version_control.git_analyzer import BlameInfo, CommitInfo, GitAnalyzer
+from .review import SYMBOLS_IN_PATHS_QUERY
+
+# Drop edges of an earlier load, so a re-run reflects the current blame
+CLEAR_MODIFIED = "MATCH (:Commit)-[r:MODIFIED]->() DELETE r"
+
+
+class GitHistoryLoader:
+    """Creates Commit and Author nodes and MODIFIED edges to files and functions."""
+
+    def __init__(self, ingestor: Any, git_analyzer: GitAnalyzer):
+        self.ingestor = ingestor
+        self.git_analyzer = git_analyzer
+
+    def load(self, commits: list[CommitInfo]) -> dict[str, int]:
+        self.ingestor.execute_write(CLEAR_MODIFIED)
+        authors: set[str] = set()
+        paths: set[str] = set()
+        file_links = 0
+        for commit in commits:
+            self.ingestor.ensure_node_batch(
+                "Commit",
+                {
+                    "sha": commit.sha,
+                    "short_sha": commit.sha[:8],
+                    "author": commit.author,
+                    "author_email": commit.author_email,
+                    "message": commit.message[:500],
+                    "date": commit.date.isoformat(),
+                    "files_changed": len(commit.files_changed),
+                },
+            )
+            self.ingestor.ensure_node_batch(
+                "Author", {"email": commit.author_email, "name": commit.author}
+            )
+            authors.add(commit.author_email)
+            self.ingestor.ensure_relationship_batch(
+                ("Commit", "sha", commit.sha),
+                "AUTHORED_BY",
+                ("Author", "email", commit.author_email),
+                {"date": commit.date.isoformat()},
+            )
+            for path in commit.files_changed:
+                paths.add(path)
+                self.ingestor.ensure_relationship_batch(
+                    ("Commit", "sha", commit.sha),
+                    "MODIFIED",
+                    ("File", "path", path),
+                    {"date": commit.date.isoformat()},
+                )
+                file_links += 1
+
+        function_links, touched = self._link_functions(
+            {commit.sha for commit in commits}, sorted(paths)
+        )
+        self.ingestor.flush_all()
+        logger.info(f"Loaded {len(commits)} commits by {len(authors)} authors")
+        return {
+            "commits": len(commits),
+            "authors": len(authors),
+            "file_links": file_links,
+            "function_links": function_links,
+            "functions": touched,
+        }
+
+    def _link_functions(self, shas: set[str], paths: list[str]) -> tuple[int, int]:
+        """
+        Link functions in the touched files to the loaded commits blamed for
+        their lines, and record on each who changed it last.
+        """
+        if not paths:
+            return 0, 0
+        symbols = self.ingestor.fetch_all(SYMBOLS_IN_PATHS_QUERY, {"paths": paths})
+        by_path: dict[str, list[dict[str, Any]]] = defaultdict(list)
+        for symbol in symbols:
+            by_path[symbol["path"]].append(symbol)
+
+        links = touched = 0
+        for path, path_symbols in by_path.items():
+            blame = self.git_analyzer.get_blame_info(
+                str(Path(self.git_analyzer.repo_path) / path)
+            )
+            if not blame:
+                continue
+            for symbol in path_symbols:
+                start, end = symbol.get("start_line"), symbol.get("end_line")
+                if start is None or end is None:
+                    continue
+                lines = [b for b in blame if start <= b.line_number <= end]
+                if not lines:
+                    continue
+                node = (symbol["label"], "qualified_name", symbol["qualified_name"])
+                links += self._link_function(node, lines, shas)
+                touched += 1
+        return links, touched
+
+    def _link_function(
+        self, node: tuple[str, str, str], lines: list[BlameInfo], shas: set[str]
+    ) -> int:
+        by_commit: dict[str, list[BlameInfo]] = defaultdict(list)
+        for line in lines:
+            by_commit[line.commit_sha].append(line)
+
+        last = max(lines, key=lambda line: line.date)
+        self.ingestor.ensure_node_batch(
+            node[0],
+            {
+                node[1]: node[2],
+                "last_modified_by": last.author,
+                "last_modified_email": last.author_email,
+                "last_modified_at": last.date.isoformat(),
+                "last_commit_sha": last.commit_sha,
+                "author_count": len({line.author_email for line in lines}),
+            },
+        )
+        links = 0
+        for sha, commit_lines in by_commit.items():
+            if sha not in shas:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("Commit", "sha", sha),
+                "MODIFIED",
+                node,
+                {"lines": len(commit_lines)},
+            )
+            links += 1
+        return links
@@ -0,0 +1,218 @@
+"""Go build constraints: `//go:build` lines and GOOS/GOARCH file name suffixes.
+
+A file builds only for the configurations its constraints allow. Both kinds
+are combined into one `//go:build` style expression (`linux && !cgo`), which
+is evaluated the way the go command does: GOOS, GOARCH, `unix` for Unix-like
+systems, the gc compiler, every go1.N release tag and the tags given.
+"""
+
+import re
+from dataclasses import dataclass, field
+
+# From go/build/syslist.go
+KNOWN_OS = {
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "js",
+    "linux",
+    "nacl",
+    "netbsd",
+    "openbsd",
+    "plan9",
+    "solaris",
+    "wasip1",
+    "windows",
+    "zos",
+}
+UNIX_OS = KNOWN_OS - {"js", "nacl", "plan9", "wasip1", "windows"}
+KNOWN_ARCH = {
+    "386",
+    "amd64",
+    "arm",
+    "arm64",
+    "loong64",
+    "mips",
+    "mips64",
+    "mips64le",
+    "mipsle",
+    "ppc64",
+    "ppc64le",
+    "riscv64",
+    "s390x",
+    "wasm",
+}
+# First-class ports and other common targets, evaluated for every constraint
+# so the graph can be filtered by platform without evaluating expressions
+COMMON_PLATFORMS = [
+    "linux/amd64",
+    "linux/arm64",
+    "linux/386",
+    "linux/arm",
+    "darwin/amd64",
+    "darwin/arm64",
+    "windows/amd64",
+    "windows/arm64",
+    "windows/386",
+    "freebsd/amd64",
+    "js/wasm",
+    "wasip1/wasm",
+]
+
+GO_BUILD_LINE = re.compile(r"^//go:build\s+(.+?)\s*$")
+PLUS_BUILD_LINE = re.compile(r"^//\s*\+build\s+(.+?)\s*$")
+TOKEN = re.compile(r"\s*(\(|\)|!|&&|\|\||[\w.]+)")
+
+
+@dataclass(frozen=True)
+class BuildConfig:
+    """A build configuration: GOOS, GOARCH and extra build tags."""
+
+    goos: str = "linux"
+    goarch: str = "amd64"
+    tags: frozenset[str] = field(default_factory=frozenset)
+
+    def __str__(self) -> str:
+        tags = f" tags={','.join(sorted(self.tags))}" if self.tags else ""
+        return f"{self.goos}/{self.goarch}{tags}"
+
+    def satisfies(self, expression: str) -> bool:
+        if not expression:
+            return True
+        satisfied = {self.goos, self.goarch, "gc", *self.tags}
+        if self.goos in UNIX_OS:
+            satisfied.add("unix")
+        # android implies linux, ios implies darwin, illumos implies solaris
+        implied = {"android": "linux", "ios": "darwin", "illumos": "solaris"}
+        if self.goos in implied:
+            satisfied.add(implied[self.goos])
+        return evaluate(expression, satisfied)
+
+
+def build_constraint(file_name: str, source: str) -> str:
+    """The constraints of a Go file as one expression, empty when it has none."""
+    parts = [
+        part
+        for part in (file_name_constraint(file_name), header_constraint(source))
+        if part
+    ]
+    if len(parts) == 2 and "||" in parts[1]:
+        parts[1] = f"({parts[1]})"
+    return " && ".join(parts)
+
+
+def file_name_constraint(file_name: str) -> str:
+    """`x_linux.go`, `x_amd64.go` or `x_linux_amd64.go`, as go/build reads them."""
+    name = file_name.removesuffix(".go").removesuffix("_test")
+    # Everything up to the first underscore is the file's own name
+    _, underscore, rest = name.partition("_")
+    if not underscore:
+        return ""
+    elements = rest.split("_")
+    if len(elements) >= 2 and elements[-2] in KNOWN_OS and elements[-1] in KNOWN_ARCH:
+        return f"{elements[-2]} && {elements[-1]}"
+    if elements[-1] in KNOWN_OS or elements[-1] in KNOWN_ARCH:
+        return elements[-1]
+    return ""
+
+
+def header_constraint(source: str) -> str:
+    """
+    The `//go:build` line before the package clause, or else the older
+    `// +build` lines: spaces are ORs, commas ANDs, and the lines ANDed.
+    """
+    plus_build = []
+    for raw_line in source.splitlines():
+        line = raw_line.strip()
+        if line.startswith("package ") or line.startswith("/*"):
+            break
+        if match := GO_BUILD_LINE.match(line):
+            return match.group(1)
+        if match := PLUS_BUILD_LINE.match(line):
+            options = [
+                " && ".join(option.split(","))
+                for option in match.group(1).split()
+            ]
+            plus_build.append(
+                options[0] if len(options) == 1 else f"({' || '.join(options)})"
+            )
+    if len(plus_build) == 1 and plus_build[0].startswith("("):
+        return plus_build[0][1:-1]
+    return " && ".join(plus_build)
+
+
+def evaluate(expression: str, satisfied: set[str]) -> bool:
+    """Evaluate a build expression; release tags (go1.N) are always satisfied."""
+    tokens = TOKEN.findall(expression)
+    if "".join(tokens) != "".join(expression.split()):
+        raise ValueError(f"invalid build constraint: {expression}")
+    position = 0
+
+    def peek() -> str | None:
+        return tokens[position] if position < len(tokens) else None
+
+    def take(expected: str | None = None) -> str:
+        nonlocal position
+        token = peek()
+        if token is None or (expected is not None and token != expected):
+            raise ValueError(f"invalid build constraint: {expression}")
+        position += 1
+        return token
+
+    def parse_or() -> bool:
+        value = parse_and()
+        while peek() == "||":
+            take()
+            value = parse_and() or value
+        return value
+
+    def parse_and() -> bool:
+        value = parse_not()
+        while peek() == "&&":
+            take()
+            value = parse_not() and value
+        return value
+
+    def parse_not() -> bool:
+        if peek() == "!":
+            take()
+            return not parse_not()
+        if peek() == "(":
+            take()
+            value = parse_or()
+            take(")")
+            return value
+        tag = take()
+        if tag in ("&&", "||", ")"):
+            raise ValueError(f"invalid build constraint: {expression}")
+        return tag in satisfied or tag.startswith("go1.")
+
+    result = parse_or()
+    if position != len(tokens):
+        raise ValueError(f"invalid build constraint: {expression}")
+    return result
+
+
+def constraint_terms(expression: str) -> tuple[list[str], list[str], list[str]]:
+    """The operating systems, architectures and other tags an expression names."""
+    names = {token for token in TOKEN.findall(expression) if token[0].isalnum()}
+    return (
+        sorted(names & KNOWN_OS),
+        sorted(names & KNOWN_ARCH),
+        sorted(names - KNOWN_OS - KNOWN_ARCH),
+    )
+
+
+def platforms(expression: str) -> list[str]:
+    """The common GOOS/GOARCH pairs a constraint allows without extra tags."""
+    allowed = []
+    for platform in COMMON_PLATFORMS:
+        goos, goarch = platform.split("/")
+        if BuildConfig(goos, goarch).satisfies(expression):
+            allowed.append(platform)
+    return allowed
@@ -0,0 +1,214 @@
+"""Go coverage profiles (`go test -coverprofile`) mapped onto the graph.
+
+A profile lists blocks of statements as `file:line.col,line.col statements
+count`, with files named by import path, which ends with the file's path in
+the repository; each block belongs to the innermost function spanning it.
+Every function gets the share of its statements that ran; a profile of a
+single test, e.g. from `go test -run '^TestAdd$' -coverprofile=TestAdd.out`,
+also links that test to the functions it reached with COVERS edges.
+"""
+
+import re
+from collections import defaultdict
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .code_locator import CodeLocator
+
+BLOCK_LINE = re.compile(r"^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$")
+PROFILE_SUFFIXES = {".out", ".cov", ".cover", ".coverprofile"}
+
+GO_TEST_FUNCTIONS_QUERY = """
+MATCH (m:Module)-[:CONTAINS_TEST]->(t:TestFunction)
+WHERE m.path ENDS WITH '_test.go'
+RETURN t.qualified_name AS qualified_name, t.name AS name
+"""
+
+CLEAR_TEST_COVERAGE = """
+MATCH (:TestFunction {qualified_name: $qualified_name})-[r:COVERS]->()
+DELETE r
+"""
+
+
+@dataclass(frozen=True)
+class CoverageBlock:
+    file: str  # As the profile names it, e.g. example.com/shop/calc/calc.go
+    start_line: int
+    start_column: int
+    end_line: int
+    end_column: int
+    statements: int
+    count: int
+
+
+@dataclass
+class CoverageProfile:
+    source: str
+    mode: str = "set"
+    # The test the profile is of, when it is of a single test
+    test: str | None = None
+    blocks: list[CoverageBlock] = field(default_factory=list)
+
+
+@dataclass
+class FunctionCoverage:
+    statements: int = 0
+    covered: int = 0
+
+    @property
+    def percent(self) -> float:
+        if not self.statements:
+            return 0.0
+        return round(100.0 * self.covered / self.statements, 1)
+
+
+def parse_cover_profile(
+    content: str, source: str = "", test: str | None = None
+) -> CoverageProfile:
+    """
+    Parse a cover profile. Profiles merged from several packages (-coverpkg)
+    list a block once per test binary; the block is kept with its highest count.
+    """
+    profile = CoverageProfile(source, test=test)
+    blocks: dict[tuple[str, int, int, int, int], CoverageBlock] = {}
+    for line in content.splitlines():
+        line = line.strip()
+        if line.startswith("mode:"):
+            profile.mode = line.removeprefix("mode:").strip()
+            continue
+        match = BLOCK_LINE.match(line)
+        if not match:
+            continue
+        file = match[1]
+        start_line, start_column, end_line, end_column = map(int, match.groups()[1:5])
+        block = CoverageBlock(
+            file,
+            start_line,
+            start_column,
+            end_line,
+            end_column,
+            int(match[6]),
+            int(match[7]),
+        )
+        key = (file, start_line, start_column, end_line, end_column)
+        if key not in blocks or blocks[key].count < block.count:
+            blocks[key] = block
+    profile.blocks = list(blocks.values())
+    return profile
+
+
+def collect_profiles(paths: list[Path]) -> list[Path]:
+    """Expand directories into the cover profiles they contain."""
+    profiles = []
+    for path in paths:
+        if path.is_dir():
+            profiles.extend(
+                sorted(p for p in path.rglob("*") if p.suffix in PROFILE_SUFFIXES)
+            )
+        elif path.is_file():
+            profiles.append(path)
+    return profiles
+
+
+class CoverageLoader:
+    """Records Go coverage on function nodes and COVERS edges from tests."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self.locator = CodeLocator(ingestor)
+        self.tests: dict[str, list[str]] = defaultdict(list)
+
+    def load(self, profiles: list[CoverageProfile]) -> dict[str, int]:
+        for row in self.ingestor.fetch_all(GO_TEST_FUNCTIONS_QUERY):
+            self.tests[row["name"]].append(row["qualified_name"])
+
+        labels: dict[str, str] = {}
+        # Blocks of each function across profiles: one covered by any of them
+        # counts as covered, once
+        blocks: dict[str, dict[tuple, CoverageBlock]] = defaultdict(dict)
+        unmatched_blocks = 0
+        covers = 0
+        unmatched_tests = 0
+        for profile in profiles:
+            by_function: dict[str, list[CoverageBlock]] = defaultdict(list)
+            for block in profile.blocks:
+                function = self.locator.at_line(block.file, block.start_line)
+                if function is None:
+                    unmatched_blocks += 1
+                    continue
+                qn = function["qualified_name"]
+                labels[qn] = function["label"]
+                by_function[qn].append(block)
+                key = (block.file, block.start_line, block.start_column)
+                if key not in blocks[qn] or blocks[qn][key].count < block.count:
+                    blocks[qn][key] = block
+            if profile.test is None:
+                continue
+            test_qn = self._test_function(profile.test, by_function)
+            if test_qn is None:
+                logger.warning(
+                    f"No Go test function {profile.test} for {profile.source}"
+                )
+                unmatched_tests += 1
+                continue
+            # A test's new profile replaces what it covered before
+            self.ingestor.execute_write(
+                CLEAR_TEST_COVERAGE, {"qualified_name": test_qn}
+            )
+            for qn, function_blocks in sorted(by_function.items()):
+                coverage = _coverage(function_blocks)
+                if not coverage.covered:
+                    continue
+                self.ingestor.ensure_relationship_batch(
+                    ("TestFunction", "qualified_name", test_qn),
+                    "COVERS",
+                    (labels[qn], "qualified_name", qn),
+                    {
+                        "covered_statements": coverage.covered,
+                        "coverage_percent": coverage.percent,
+                    },
+                )
+                covers += 1
+
+        for qn, function_blocks in sorted(blocks.items()):
+            coverage = _coverage(list(function_blocks.values()))
+            self.ingestor.ensure_node_batch(
+                labels[qn],
+                {
+                    "qualified_name": qn,
+                    "coverage_percent": coverage.percent,
+                    "covered_statements": coverage.covered,
+                    "total_statements": coverage.statements,
+                },
+            )
+        self.ingestor.flush_all()
+
+        return {
+            "profiles": len(profiles),
+            "functions": len(blocks),
+            "unmatched_blocks": unmatched_blocks,
+            "covers": covers,
+            "unmatched_tests": unmatched_tests,
+        }
+
+    def _test_function(
+        self, name: str, by_function: dict[str, list[CoverageBlock]]
+    ) -> str | None:
+        candidates = self.tests.get(name, [])
+        if len(candidates) > 1:
+            # Tests of the same name in several packages: the one in a package
+            # the profile covers
+            packages = {qn.rsplit(".", 2)[0] for qn in by_function}
+            candidates = [qn for qn in candidates if qn.rsplit(".", 2)[0] in packages]
+        return candidates[0] if len(candidates) == 1 else None
+
+
+def _coverage(blocks: list[CoverageBlock]) -> FunctionCoverage:
+    return FunctionCoverage(
+        sum(block.statements for block in blocks),
+        sum(block.statements for block in blocks if block.count > 0),
+    )
+
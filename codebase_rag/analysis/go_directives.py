@@ -0,0 +1,105 @@
+"""Go directives: //go:generate commands and //go:embed patterns.
+
+`go generate` runs the command of each //go:generate line in the file's
+directory; what it writes is recognized by the standard header
+`// Code generated ... DO NOT EDIT.`, which usually names the generator.
+A //go:embed line embeds the files its patterns match, relative to the file's
+directory, into the variable declared below it. A directory embeds its files
+recursively, except those starting with . or _ unless the pattern has `all:`.
+"""
+
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+
+GENERATE_LINE = re.compile(r"^//go:generate\s+(.+?)\s*$")
+EMBED_LINE = re.compile(r"^//go:embed\s+(.+?)\s*$")
+GENERATED_HEADER = re.compile(r"^// Code generated (.*) DO NOT EDIT\.$")
+EMBED_PATTERN = re.compile(r'"([^"]*)"|`([^`]*)`|(\S+)')
+VAR_DECLARATION = re.compile(r"^var\s+(\w+)")
+
+
+@dataclass
+class GoDirective:
+    kind: str  # generate or embed
+    line_number: int
+    command: str = ""  # generate: the command line
+    patterns: list[str] = field(default_factory=list)  # embed
+    variable: str = ""  # embed: the variable the files are embedded into
+
+
+def collect_directives(source: str) -> list[GoDirective]:
+    """The //go:generate and //go:embed lines of a Go file, in order."""
+    directives = []
+    lines = source.splitlines()
+    for index, line in enumerate(lines):
+        if match := GENERATE_LINE.match(line):
+            directives.append(GoDirective("generate", index + 1, command=match[1]))
+        elif match := EMBED_LINE.match(line):
+            patterns = [
+                next(group for group in pattern if group)
+                for pattern in EMBED_PATTERN.findall(match[1])
+            ]
+            directives.append(
+                GoDirective(
+                    "embed",
+                    index + 1,
+                    patterns=patterns,
+                    variable=_next_variable(lines, index + 1),
+                )
+            )
+    return directives
+
+
+def generated_by(source: str) -> str | None:
+    """
+    What the generated-code header before the package clause says about the
+    generator, e.g. "by stringer -type=Pill;", or None for hand-written code.
+    """
+    for line in source.splitlines():
+        if line.startswith("package "):
+            break
+        if match := GENERATED_HEADER.match(line.strip()):
+            return match[1]
+    return None
+
+
+def generator_program(command: str) -> str:
+    """The program a go:generate command runs: stringer, or gen for go run ./gen."""
+    words = command.split()
+    if len(words) >= 3 and words[0] == "go" and words[1] in ("run", "tool"):
+        program = next((w for w in words[2:] if not w.startswith("-")), words[2])
+    else:
+        program = words[0] if words else ""
+    name = program.rstrip("/").rsplit("/", 1)[-1]
+    # go run gen.go, or a versioned module path: go run golang.org/x/tools@v0.1
+    return name.removesuffix(".go").split("@")[0]
+
+
+def embedded_files(directory: Path, patterns: list[str]) -> list[Path]:
+    """Files of a directory the patterns of a //go:embed line embed."""
+    found: set[Path] = set()
+    for pattern in patterns:
+        include_hidden = pattern.startswith("all:")
+        for match in directory.glob(pattern.removeprefix("all:")):
+            if match.is_file():
+                found.add(match)
+                continue
+            for path in match.rglob("*"):
+                hidden = any(
+                    part.startswith((".", "_"))
+                    for part in path.relative_to(match).parts
+                )
+                if path.is_file() and (include_hidden or not hidden):
+                    found.add(path)
+    return sorted(found)
+
+
+def _next_variable(lines: list[str], start: int) -> str:
+    for line in lines[start:]:
+        stripped = line.strip()
+        if not stripped or stripped.startswith("//"):
+            continue
+        match = VAR_DECLARATION.match(stripped)
+        return match[1] if match else ""
+    return ""
@@ -0,0 +1,114 @@
+"""Doc comments of Go packages and declarations, read as go doc reads them.
+
+A doc comment is the block of comments directly above a top-level
+declaration, with no blank line between them; a package's is the one above
+its package clause, by convention in doc.go and starting "Package name".
+Declarations grouped in `type ( ... )` have theirs above each spec, or share
+the group's when it declares one. Directives such as //go:generate and
+//go:build are not part of the text.
+"""
+
+import re
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+DIRECTIVE = re.compile(r"^//(go:|line |export |extern |nolint)")
+
+
+@dataclass
+class GoDoc:
+    """The doc comment of a Go package or top-level declaration."""
+
+    kind: str  # package, function, method or type
+    name: str  # The declared name, or the package name
+    text: str
+    start_line: int
+    end_line: int
+    is_interface: bool = False
+
+
+def collect_go_docs(root_node: Node) -> list[GoDoc]:
+    """The doc comments of a Go file's package and top-level declarations."""
+    docs = []
+    for node in root_node.named_children:
+        if node.type == "package_clause":
+            name = next(
+                (c for c in node.named_children if c.type == "package_identifier"),
+                None,
+            )
+            doc = _doc_above(node, "package", node_text(name) if name else "")
+        elif node.type in ("function_declaration", "method_declaration"):
+            name = node.child_by_field_name("name")
+            kind = "function" if node.type == "function_declaration" else "method"
+            doc = _doc_above(node, kind, node_text(name) if name else "")
+        elif node.type == "type_declaration":
+            docs.extend(_type_docs(node))
+            continue
+        else:
+            continue
+        if doc is not None:
+            docs.append(doc)
+    return docs
+
+
+def _type_docs(declaration: Node) -> list[GoDoc]:
+    specs = [c for c in declaration.named_children if c.type == "type_spec"]
+    docs = []
+    for spec in specs:
+        name = spec.child_by_field_name("name")
+        doc = _doc_above(spec, "type", node_text(name) if name else "")
+        if doc is None and len(specs) == 1:
+            doc = _doc_above(declaration, "type", node_text(name) if name else "")
+        if doc is None:
+            continue
+        type_node = spec.child_by_field_name("type")
+        doc.is_interface = type_node is not None and type_node.type == "interface_type"
+        docs.append(doc)
+    return docs
+
+
+def _doc_above(node: Node, kind: str, name: str) -> GoDoc | None:
+    """The comment block ending on the line above a node, as a GoDoc."""
+    comments: list[Node] = []
+    expected_end = node.start_point[0] - 1
+    sibling = node.prev_named_sibling
+    while (
+        sibling is not None
+        and sibling.type == "comment"
+        and sibling.end_point[0] == expected_end
+    ):
+        comments.insert(0, sibling)
+        expected_end = sibling.start_point[0] - 1
+        sibling = sibling.prev_named_sibling
+    if not comments or not name:
+        return None
+    text = "\n".join(
+        line for comment in comments for line in _comment_lines(node_text(comment))
+    ).strip()
+    if not text:
+        return None
+    return GoDoc(
+        kind,
+        name,
+        text,
+        comments[0].start_point[0] + 1,
+        comments[-1].end_point[0] + 1,
+    )
+
+
+def _comment_lines(comment: str) -> list[str]:
+    """The text lines of a // or /* */ comment, without their markers."""
+    if comment.startswith("//"):
+        if DIRECTIVE.match(comment):
+            return []
+        line = comment[2:]
+        return [line[1:] if line.startswith(" ") else line]
+    body = comment.removeprefix("/*").removesuffix("*/")
+    return [
+        line.strip().removeprefix("* ").removeprefix("*")
+        for line in body.splitlines()
+    ]
+
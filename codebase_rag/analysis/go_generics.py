@@ -0,0 +1,160 @@
+"""Go generics: the type parameters of generic declarations and their uses.
+
+A generic function or type declares type parameters, each with a constraint
+(`[K comparable, V any]`). Its uses are instantiations: explicit ones give the
+type arguments (`Map[string, int](m)`, `Stack[Order]{}`), inferred ones are
+plain calls of a generic function whose arguments Go works out from the call.
+"""
+
+from collections.abc import Iterator
+from dataclasses import dataclass, field
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Constraints every package can name without declaring them
+BUILTIN_CONSTRAINTS = {"any", "comparable"}
+
+
+@dataclass
+class GoTypeParameter:
+    name: str
+    constraint: str  # As written: comparable, ~int | ~float64, constraints.Ordered
+    position: int
+
+    @property
+    def constraint_name(self) -> str | None:
+        """The named interface the constraint refers to, if it is just one."""
+        text = self.constraint.strip()
+        if not text or any(symbol in text for symbol in "~|{[*"):
+            return None
+        return text
+
+
+@dataclass
+class GenericDefinition:
+    """A generic function or named type."""
+
+    name: str
+    is_function: bool
+    is_interface: bool
+    parameters: list[GoTypeParameter] = field(default_factory=list)
+
+
+@dataclass
+class GoInstantiation:
+    name: str  # As written: Map, slices.Map
+    type_arguments: list[str]
+    line_number: int
+
+    @property
+    def inferred(self) -> bool:
+        return not self.type_arguments
+
+
+def collect_generic_definitions(root_node: Node) -> list[GenericDefinition]:
+    """Functions and types of a Go file that declare type parameters."""
+    definitions = []
+    for declaration in root_node.named_children:
+        if declaration.type == "function_declaration":
+            specs = [declaration]
+        elif declaration.type == "type_declaration":
+            specs = [c for c in declaration.named_children if c.type == "type_spec"]
+        else:
+            continue
+        for spec in specs:
+            name = spec.child_by_field_name("name")
+            parameters = _type_parameters(spec)
+            if name is None or not parameters:
+                continue
+            definition = spec.child_by_field_name("type")
+            definitions.append(
+                GenericDefinition(
+                    name=node_text(name),
+                    is_function=spec.type == "function_declaration",
+                    is_interface=(
+                        definition is not None and definition.type == "interface_type"
+                    ),
+                    parameters=parameters,
+                )
+            )
+    return definitions
+
+
+def collect_instantiations(func_node: Node) -> list[GoInstantiation]:
+    """
+    Candidate instantiations in a function's signature and body: generic
+    types with type arguments, calls with explicit type arguments and plain
+    calls that may infer them. Which names are generic is only known once
+    every file is parsed.
+    """
+    instantiations = []
+    for node in _walk(func_node):
+        if node.type == "generic_type":
+            type_node = node.child_by_field_name("type")
+            arguments = node.child_by_field_name("type_arguments")
+            if type_node is not None and arguments is not None:
+                instantiations.append(
+                    GoInstantiation(
+                        node_text(type_node), _arguments(arguments), _line(node)
+                    )
+                )
+        elif node.type == "call_expression":
+            function = node.child_by_field_name("function")
+            arguments = node.child_by_field_name("type_arguments")
+            if function is None:
+                continue
+            if function.type == "index_expression":
+                # Older grammars read Map[int](x) as indexing, then a call
+                operand = function.child_by_field_name("operand")
+                index = function.child_by_field_name("index")
+                if operand is not None and index is not None:
+                    instantiations.append(
+                        GoInstantiation(
+                            node_text(operand), [node_text(index)], _line(node)
+                        )
+                    )
+            elif function.type in ("identifier", "selector_expression"):
+                type_arguments = _arguments(arguments) if arguments else []
+                instantiations.append(
+                    GoInstantiation(node_text(function), type_arguments, _line(node))
+                )
+    return sorted(instantiations, key=lambda i: i.line_number)
+
+
+def _type_parameters(node: Node) -> list[GoTypeParameter]:
+    parameter_list = node.child_by_field_name("type_parameters")
+    if parameter_list is None:
+        return []
+    parameters = []
+    for declaration in parameter_list.named_children:
+        if declaration.type != "type_parameter_declaration":
+            continue
+        constraint = declaration.child_by_field_name("type")
+        constraint_text = " ".join(node_text(constraint).split()) if constraint else ""
+        # `[K, V any]` gives both parameters the one constraint
+        for name in declaration.children_by_field_name("name"):
+            parameters.append(
+                GoTypeParameter(node_text(name), constraint_text, len(parameters))
+            )
+    return parameters
+
+
+def _walk(func_node: Node) -> Iterator[Node]:
+    """A function's nodes but its receiver, where Stack[T] names its own T."""
+    receiver = func_node.child_by_field_name("receiver")
+    stack = [c for c in func_node.named_children if c != receiver]
+    while stack:
+        node = stack.pop()
+        yield node
+        stack.extend(node.named_children)
+
+
+def _arguments(type_arguments: Node) -> list[str]:
+    return ["".join(node_text(a).split()) for a in type_arguments.named_children]
+
+
+def _line(node: Node) -> int:
+    return node.start_point[0] + 1
+
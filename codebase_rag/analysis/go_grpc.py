@@ -0,0 +1,80 @@
+"""gRPC services in generated Go code, and calls made through their clients.
+
+protoc-gen-go-grpc writes a grpc.ServiceDesc for each service, naming it and
+its RPCs, with a <Service>Client interface that callers use and a
+<Service>Server interface that handlers implement. Calls through the client
+interface and implementations of the server interface both point at an
+RpcMethod named like the RPC on the wire, "/users.v1.UserService/GetUser",
+so a caller and its handler meet even when their services are ingested
+separately into one graph.
+"""
+
+import re
+from dataclasses import dataclass, field
+
+SERVICE_DESC = re.compile(r"\bgrpc\.ServiceDesc\s*\{")
+SERVICE_NAME = re.compile(r'\bServiceName:\s*"([^"]+)"')
+HANDLER_TYPE = re.compile(r"\bHandlerType:\s*\(\*(\w+)\)\(nil\)")
+METHOD_NAME = re.compile(r'\bMethodName:\s*"(\w+)"')
+STREAM_NAME = re.compile(r'\bStreamName:\s*"(\w+)"')
+
+# Joins both sides of every RPC once they are in the graph
+LINK_RPC_CALLS = """
+MATCH (caller)-[i:INVOKES_RPC]->(r:RpcMethod)<-[:HANDLES_RPC]-(handler)
+MERGE (caller)-[c:CALLS_RPC]->(handler)
+SET c.rpc = r.qualified_name, c.line_number = i.line_number
+"""
+
+
+@dataclass
+class GrpcService:
+    """A service registered by generated code."""
+
+    name: str  # Full protobuf name, e.g. "users.v1.UserService"
+    server: str  # Interface handlers implement, e.g. "UserServiceServer"
+    methods: list[str] = field(default_factory=list)  # Unary RPCs
+    streams: list[str] = field(default_factory=list)  # Streaming RPCs
+
+    @property
+    def client(self) -> str:
+        """The client interface generated next to the server interface."""
+        return self.server.removesuffix("Server") + "Client"
+
+    def rpc_name(self, method: str) -> str:
+        return f"/{self.name}/{method}"
+
+    def has_rpc(self, method: str) -> bool:
+        return method in self.methods or method in self.streams
+
+
+def collect_grpc_services(source: str) -> list[GrpcService]:
+    """The services whose ServiceDesc a Go file declares."""
+    services = []
+    for match in SERVICE_DESC.finditer(source):
+        body = source[match.end() : _closing_brace(source, match.end())]
+        name = SERVICE_NAME.search(body)
+        handler = HANDLER_TYPE.search(body)
+        if name is None or handler is None:
+            continue
+        services.append(
+            GrpcService(
+                name=name.group(1),
+                server=handler.group(1),
+                methods=METHOD_NAME.findall(body),
+                streams=STREAM_NAME.findall(body),
+            )
+        )
+    return services
+
+
+def _closing_brace(source: str, start: int) -> int:
+    """Index of the brace closing the literal opened just before start."""
+    depth = 1
+    for index in range(start, len(source)):
+        if source[index] == "{":
+            depth += 1
+        elif source[index] == "}":
+            depth -= 1
+            if depth == 0:
+                return index
+    return len(source)
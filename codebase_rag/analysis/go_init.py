@@ -0,0 +1,79 @@
+"""Go package initialization: init() functions and the order packages run them.
+
+A package is initialized after every package it imports, blank imports
+included, and runs its init() functions in the order the go command hands
+its files to the compiler, sorted by name, then in the order of each file.
+Among packages whose imports are all initialized, the one with the lowest
+import path goes first (the rule of the Go 1.21 specification).
+"""
+
+from collections import deque
+from dataclasses import dataclass, field
+
+from tree_sitter import Node
+
+
+@dataclass
+class GoPackageFacts:
+    """What initialization needs to know about one Go package."""
+
+    directory: str  # Relative to the repository root
+    imports: set[str] = field(default_factory=set)
+    blank_imports: set[str] = field(default_factory=set)
+    # (file name, module qn, number of init functions) of files with init()
+    init_files: list[tuple[str, str, int]] = field(default_factory=list)
+
+
+def count_init_functions(root_node: Node) -> int:
+    """Number of `func init()` declarations, a file may have several."""
+    count = 0
+    for declaration in root_node.named_children:
+        if declaration.type != "function_declaration":
+            continue
+        name = declaration.child_by_field_name("name")
+        if name is not None and name.text == b"init":
+            count += 1
+    return count
+
+
+def initialization_order(imports: dict[str, set[str]]) -> list[str]:
+    """
+    Packages in the order they are initialized, given each one's imports by
+    import path. Imports outside the mapping are taken as initialized; an
+    import cycle, which Go rejects, leaves its packages out.
+    """
+    pending = {
+        package: set(deps) & imports.keys() for package, deps in imports.items()
+    }
+    order = []
+    while True:
+        ready = sorted(package for package, deps in pending.items() if not deps)
+        if not ready:
+            return order
+        package = ready[0]
+        order.append(package)
+        del pending[package]
+        for deps in pending.values():
+            deps.discard(package)
+
+
+def nearest_initializers(
+    package: str, imports: dict[str, set[str]], initializers: set[str]
+) -> set[str]:
+    """
+    The packages with init functions a package waits for directly: those it
+    imports, through packages without any, but not through another one.
+    """
+    found = set()
+    seen = {package}
+    queue = deque(imports.get(package, ()))
+    while queue:
+        current = queue.popleft()
+        if current in seen:
+            continue
+        seen.add(current)
+        if current in initializers:
+            found.add(current)
+        else:
+            queue.extend(imports.get(current, ()))
+    return found
@@ -0,0 +1,484 @@
+"""Structural interface satisfaction for Go: which types implement which interfaces.
+
+Go types implement interfaces implicitly, by having the methods. Method sets
+are collected from declarations across the files of a package, including
+methods promoted from embedded fields, and compared with each interface's
+methods by name and signature. Without type checking, types in signatures are
+compared without their package qualifier, so io.Reader and a local Reader
+read the same.
+
+The same method sets resolve method calls: x.M() goes to the method M of the
+type of x, its own or promoted from the shallowest embedded field declaring
+it, or to the interface declaring M when x has an interface type.
+"""
+
+import re
+from collections.abc import Callable, Iterator
+from dataclasses import dataclass, field
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Interfaces of the standard library worth linking repository types to, keyed
+# by how Go code refers to them, with method signatures normalized as below
+STDLIB_INTERFACES: dict[str, dict[str, str]] = {
+    "error": {"Error": "()string"},
+    "fmt.Stringer": {"String": "()string"},
+    "io.Reader": {"Read": "([]byte)int,error"},
+    "io.Writer": {"Write": "([]byte)int,error"},
+    "io.Closer": {"Close": "()error"},
+    "io.ReadWriter": {"Read": "([]byte)int,error", "Write": "([]byte)int,error"},
+    "io.ReadCloser": {"Read": "([]byte)int,error", "Close": "()error"},
+    "io.WriteCloser": {"Write": "([]byte)int,error", "Close": "()error"},
+    "io.StringWriter": {"WriteString": "(string)int,error"},
+    "sort.Interface": {"Len": "()int", "Less": "(int,int)bool", "Swap": "(int,int)"},
+    "http.Handler": {"ServeHTTP": "(ResponseWriter,*Request)"},
+    "json.Marshaler": {"MarshalJSON": "()[]byte,error"},
+    "json.Unmarshaler": {"UnmarshalJSON": "([]byte)error"},
+    "encoding.TextMarshaler": {"MarshalText": "()[]byte,error"},
+    "encoding.TextUnmarshaler": {"UnmarshalText": "([]byte)error"},
+}
+
+QUALIFIER = re.compile(r"\b\w+\.")
+TYPE_ARGUMENTS = re.compile(r"\[.*\]$")
+# Embedded fields followed when promoting methods, against embedding cycles
+MAX_EMBEDDING_DEPTH = 5
+
+
+@dataclass
+class GoType:
+    """A named type declared in a Go file."""
+
+    name: str
+    is_interface: bool
+    start_line: int
+    end_line: int
+    # Interface methods: name -> normalized signature
+    methods: dict[str, str] = field(default_factory=dict)
+    # Embedded interfaces, or struct fields as (type name, by pointer)
+    embedded: list[tuple[str, bool]] = field(default_factory=list)
+    field_count: int = 0
+    # Struct fields, embedded ones by their type's name: name -> type, no *
+    fields: dict[str, str] = field(default_factory=dict)
+
+
+@dataclass
+class GoMethod:
+    """A method declaration, by receiver type name."""
+
+    receiver: str
+    name: str
+    signature: str
+    pointer_receiver: bool
+
+
+@dataclass
+class Implementation:
+    type_qn: str
+    interface_qn: str
+    # Only *T has all the methods, so a T value does not satisfy the interface
+    pointer_receiver: bool
+
+
+def collect_go_types(root_node: Node) -> list[GoType]:
+    """Named struct, interface and other defined types of a Go file."""
+    types = []
+    for spec in _type_specs(root_node):
+        name = spec.child_by_field_name("name")
+        definition = spec.child_by_field_name("type")
+        if name is None or definition is None:
+            continue
+        go_type = GoType(
+            name=node_text(name),
+            is_interface=definition.type == "interface_type",
+            start_line=spec.start_point[0] + 1,
+            end_line=spec.end_point[0] + 1,
+        )
+        if definition.type == "interface_type":
+            _read_interface(definition, go_type)
+        elif definition.type == "struct_type":
+            _read_struct(definition, go_type)
+        types.append(go_type)
+    return types
+
+
+def collect_go_methods(root_node: Node) -> list[GoMethod]:
+    methods = []
+    for declaration in root_node.named_children:
+        if declaration.type != "method_declaration":
+            continue
+        name = declaration.child_by_field_name("name")
+        receiver = declaration.child_by_field_name("receiver")
+        if name is None or receiver is None or not receiver.named_children:
+            continue
+        receiver_type = receiver.named_children[0].child_by_field_name("type")
+        if receiver_type is None:
+            continue
+        type_text = node_text(receiver_type)
+        methods.append(
+            GoMethod(
+                receiver=TYPE_ARGUMENTS.sub("", type_text.lstrip("*").strip()),
+                name=node_text(name),
+                signature=signature(declaration),
+                pointer_receiver=type_text.startswith("*"),
+            )
+        )
+    return methods
+
+
+def variable_types(func_node: Node) -> dict[str, str]:
+    """
+    Named types of a function's receiver, parameters and the locals declared
+    with a type (`var x T`) or a composite literal (`x := &T{}`), without *.
+    """
+    types = {}
+    for field_name in ("receiver", "parameters"):
+        parameters = func_node.child_by_field_name(field_name)
+        for parameter in parameters.named_children if parameters else []:
+            type_node = parameter.child_by_field_name("type")
+            for name in parameter.children_by_field_name("name"):
+                if type_node is not None:
+                    types[node_text(name)] = _type_name(type_node)
+    stack = list(func_node.named_children)
+    while stack:
+        node = stack.pop()
+        if node.type == "var_spec":
+            type_node = node.child_by_field_name("type")
+            for name in node.children_by_field_name("name"):
+                if type_node is not None:
+                    types[node_text(name)] = _type_name(type_node)
+        elif node.type == "short_var_declaration":
+            left = node.child_by_field_name("left")
+            right = node.child_by_field_name("right")
+            for name, value in zip(
+                left.named_children if left else [],
+                right.named_children if right else [],
+            ):
+                if value.type == "unary_expression" and value.named_children:
+                    value = value.named_children[0]
+                value_type = (
+                    value.child_by_field_name("type")
+                    if value.type == "composite_literal"
+                    else None
+                )
+                if value_type is not None and name.type == "identifier":
+                    types[node_text(name)] = _type_name(value_type)
+        if node.type != "func_literal":
+            stack.extend(node.named_children)
+    return types
+
+
+def signature(node: Node) -> str:
+    """Parameter and result types of a method, without names or qualifiers."""
+    parameters = node.child_by_field_name("parameters")
+    result = node.child_by_field_name("result")
+    params = ",".join(_parameter_types(parameters)) if parameters else ""
+    if result is None:
+        results = ""
+    elif result.type == "parameter_list":
+        results = ",".join(_parameter_types(result))
+    else:
+        results = _normalize(node_text(result))
+    return f"({params}){results}"
+
+
+class MethodSets:
+    """
+    Types and methods of every package seen, resolved into method sets.
+
+    Packages are keyed by qualified name; a qualified type reference such as
+    store.Repository is looked up in the packages whose last name part is
+    store, then in the standard library table.
+    """
+
+    def __init__(self) -> None:
+        self.types: dict[str, dict[str, tuple[str, GoType]]] = {}
+        self.methods: dict[str, list[GoMethod]] = {}
+        # Qualified name of each method by (package qn, receiver type, name)
+        self.method_qns: dict[tuple[str, str, str], str] = {}
+        # The same qualified names, to tell methods from functions
+        self.declared_methods: set[str] = set()
+
+    def add_file(
+        self,
+        package_qn: str,
+        module_qn: str,
+        types: list[GoType],
+        methods: list[GoMethod],
+    ) -> None:
+        package = self.types.setdefault(package_qn, {})
+        for go_type in types:
+            package[go_type.name] = (f"{module_qn}.{go_type.name}", go_type)
+        self.methods.setdefault(package_qn, []).extend(methods)
+        for method in methods:
+            self.method_qns[(package_qn, method.receiver, method.name)] = (
+                f"{module_qn}.{method.name}"
+            )
+            self.declared_methods.add(f"{module_qn}.{method.name}")
+
+    def lookup(self, package_qn: str, name: str) -> str | None:
+        """Qualified name of a repository type referenced from a package."""
+        found = self._resolve(package_qn, name)
+        return found[0] if isinstance(found, tuple) else None
+
+    def resolve_call(
+        self, package_qn: str, call_node: Node, variables: dict[str, str]
+    ) -> tuple[str, str] | None:
+        """
+        The method an `x.M()` or `x.f.M()` call in a package runs, as (label,
+        qn), given the types of the caller's variables (see variable_types).
+        """
+        function = call_node.child_by_field_name("function")
+        if function is None or function.type != "selector_expression":
+            return None
+        method = function.child_by_field_name("field")
+        receiver = self._expression_type(
+            package_qn, function.child_by_field_name("operand"), variables
+        )
+        if method is None or receiver is None:
+            return None
+        return self.method_owner(*receiver, node_text(method))
+
+    def method_owner(
+        self, package_qn: str, type_name: str, method: str
+    ) -> tuple[str, str] | None:
+        """Where a method of a type is declared, following embedding."""
+
+        def own_method(
+            type_qn: str, go_type: GoType, owner: str
+        ) -> tuple[str, str] | None:
+            if go_type.is_interface:
+                return ("Interface", type_qn) if method in go_type.methods else None
+            method_qn = self.method_qns.get((owner, go_type.name, method))
+            return ("Function", method_qn) if method_qn else None
+
+        return self._promoted(package_qn, type_name, own_method)
+
+    def field_type(
+        self, package_qn: str, type_name: str, name: str
+    ) -> tuple[str, str] | None:
+        """(package qn, type) of a struct field, promoted fields included."""
+
+        def own_field(
+            _: str, go_type: GoType, owner: str
+        ) -> tuple[str, str] | None:
+            field_type = go_type.fields.get(name)
+            return (owner, field_type) if field_type else None
+
+        return self._promoted(package_qn, type_name, own_field)
+
+    def _promoted(
+        self,
+        package_qn: str,
+        type_name: str,
+        lookup: Callable[[str, GoType, str], tuple[str, str] | None],
+    ) -> tuple[str, str] | None:
+        """
+        The first lookup to succeed on a type, then on its embedded types one
+        depth at a time. Two matches at one depth are ambiguous, Go rejects
+        the selector, so nothing is returned.
+        """
+        found = self._resolve(package_qn, type_name)
+        level = [found] if isinstance(found, tuple) else []
+        seen = set()
+        for _ in range(MAX_EMBEDDING_DEPTH + 1):
+            matches = set()
+            embedded = []
+            for type_qn, go_type, owner in level:
+                if type_qn in seen:
+                    continue
+                seen.add(type_qn)
+                if match := lookup(type_qn, go_type, owner):
+                    matches.add(match)
+                else:
+                    embedded.extend(
+                        self._resolve(owner, name) for name, _ in go_type.embedded
+                    )
+            if matches:
+                return matches.pop() if len(matches) == 1 else None
+            level = [e for e in embedded if isinstance(e, tuple)]
+            if not level:
+                return None
+        return None
+
+    def _expression_type(
+        self, package_qn: str, node: Node | None, variables: dict[str, str]
+    ) -> tuple[str, str] | None:
+        if node is None:
+            return None
+        if node.type == "identifier":
+            type_name = variables.get(node_text(node))
+            return (package_qn, type_name) if type_name else None
+        if node.type == "selector_expression":
+            owner = self._expression_type(
+                package_qn, node.child_by_field_name("operand"), variables
+            )
+            name = node.child_by_field_name("field")
+            if owner is None or name is None:
+                return None
+            return self.field_type(*owner, node_text(name))
+        return None
+
+    def implementations(self) -> Iterator[Implementation]:
+        interfaces = {
+            qn: methods
+            for qn, methods in self._interfaces()
+            if methods  # everything satisfies the empty interface
+        }
+        for package_qn, package in self.types.items():
+            for type_qn, go_type in package.values():
+                if go_type.is_interface:
+                    continue
+                value_set, pointer_set = self._method_sets(package_qn, go_type.name)
+                for interface_qn, wanted in interfaces.items():
+                    if not _satisfies(pointer_set, wanted):
+                        continue
+                    yield Implementation(
+                        type_qn, interface_qn, not _satisfies(value_set, wanted)
+                    )
+
+    def _interfaces(self) -> Iterator[tuple[str, dict[str, str]]]:
+        yield from STDLIB_INTERFACES.items()
+        for package_qn, package in self.types.items():
+            for type_qn, go_type in package.values():
+                if go_type.is_interface:
+                    yield type_qn, self._interface_methods(package_qn, go_type, set())
+
+    def _interface_methods(
+        self, package_qn: str, go_type: GoType, seen: set[str]
+    ) -> dict[str, str]:
+        methods = {}
+        for name, _ in go_type.embedded:
+            found = self._resolve(package_qn, name)
+            if isinstance(found, dict):
+                methods.update(found)
+            elif found and found[1].is_interface and found[0] not in seen:
+                seen.add(found[0])
+                methods.update(self._interface_methods(found[2], found[1], seen))
+        methods.update(go_type.methods)
+        return methods
+
+    def _method_sets(
+        self, package_qn: str, type_name: str, depth: int = 0
+    ) -> tuple[dict[str, str], dict[str, str]]:
+        """Methods callable on a T value and on a *T, with promoted ones."""
+        value_set: dict[str, str] = {}
+        pointer_set: dict[str, str] = {}
+        _, go_type = self.types[package_qn][type_name]
+        embedded = go_type.embedded if depth < MAX_EMBEDDING_DEPTH else []
+        # Promoted methods first, so the type's own methods shadow them
+        for name, by_pointer in embedded:
+            found = self._resolve(package_qn, name)
+            if isinstance(found, dict):
+                value_set.update(found)
+                pointer_set.update(found)
+            elif found and found[1].is_interface:
+                methods = self._interface_methods(found[2], found[1], set())
+                value_set.update(methods)
+                pointer_set.update(methods)
+            elif found:
+                inner_value, inner_pointer = self._method_sets(
+                    found[2], found[1].name, depth + 1
+                )
+                value_set.update(inner_pointer if by_pointer else inner_value)
+                pointer_set.update(inner_pointer)
+        for method in self.methods.get(package_qn, []):
+            if method.receiver != type_name:
+                continue
+            pointer_set[method.name] = method.signature
+            if not method.pointer_receiver:
+                value_set[method.name] = method.signature
+        return value_set, pointer_set
+
+    def _resolve(
+        self, package_qn: str, name: str
+    ) -> tuple[str, GoType, str] | dict[str, str] | None:
+        """A referenced type as (qn, type, package) or stdlib interface methods."""
+        name = TYPE_ARGUMENTS.sub("", name)
+        if name in STDLIB_INTERFACES:
+            return STDLIB_INTERFACES[name]
+        qualifier, _, simple = name.rpartition(".")
+        if not qualifier:
+            found = self.types.get(package_qn, {}).get(name)
+            return (*found, package_qn) if found else None
+        for other_qn, package in self.types.items():
+            if other_qn.rsplit(".", 1)[-1] == qualifier and simple in package:
+                return (*package[simple], other_qn)
+        return None
+
+
+def _satisfies(method_set: dict[str, str], wanted: dict[str, str]) -> bool:
+    return all(method_set.get(name) == sig for name, sig in wanted.items())
+
+
+def _type_specs(root_node: Node) -> Iterator[Node]:
+    for declaration in root_node.named_children:
+        if declaration.type == "type_declaration":
+            yield from (c for c in declaration.named_children if c.type == "type_spec")
+
+
+def _read_interface(interface: Node, go_type: GoType) -> None:
+    for element in interface.named_children:
+        if element.type in ("method_elem", "method_spec"):
+            name = element.child_by_field_name("name")
+            if name is not None:
+                go_type.methods[node_text(name)] = signature(element)
+        elif element.type != "comment":
+            text = node_text(element)
+            # Type sets (~int | float64) constrain generics, they add no methods
+            if not any(symbol in text for symbol in "~|"):
+                go_type.embedded.append((text.strip(), False))
+
+
+def _read_struct(struct: Node, go_type: GoType) -> None:
+    fields = next(
+        (c for c in struct.named_children if c.type == "field_declaration_list"), None
+    )
+    for declaration in fields.named_children if fields else []:
+        if declaration.type != "field_declaration":
+            continue
+        names = declaration.children_by_field_name("name")
+        field_type = declaration.child_by_field_name("type")
+        if names:
+            go_type.field_count += len(names)
+            for name in names:
+                if field_type is not None:
+                    go_type.fields[node_text(name)] = _type_name(field_type)
+            continue
+        if field_type is None:
+            continue
+        # The grammar keeps the * of an embedded *T outside the type field
+        by_pointer = any(child.type == "*" for child in declaration.children)
+        text = node_text(field_type)
+        go_type.embedded.append(
+            (text.lstrip("*").strip(), by_pointer or text.startswith("*"))
+        )
+        # An embedded field is named after its type: Store for *store.Store
+        embedded_type = _type_name(field_type)
+        go_type.fields[embedded_type.rsplit(".", 1)[-1]] = embedded_type
+        go_type.field_count += 1
+
+
+def _parameter_types(parameters: Node) -> list[str]:
+    types = []
+    for parameter in parameters.named_children:
+        type_node = parameter.child_by_field_name("type")
+        if type_node is None:
+            continue
+        type_text = _normalize(node_text(type_node))
+        if parameter.type == "variadic_parameter_declaration":
+            type_text = f"...{type_text}"
+        # `a, b int` declares two parameters of one type
+        names = parameter.children_by_field_name("name")
+        types.extend([type_text] * max(len(names), 1))
+    return types
+
+
+def _type_name(type_node: Node) -> str:
+    return TYPE_ARGUMENTS.sub("", node_text(type_node).lstrip("*").strip())
+
+
+def _normalize(type_text: str) -> str:
+    return QUALIFIER.sub("", "".join(type_text.split()))
+
@@ -0,0 +1,265 @@
+"""Go module resolution: go.mod, go.work and go.sum, and imports to modules.
+
+Every go.mod declares a module and the module versions it requires, possibly
+replaced by other versions or by local directories. A go.work file groups
+local modules into a workspace. An import path belongs to the required module
+with the longest path that is a prefix of it, as the go command resolves it.
+"""
+
+import re
+from collections.abc import Iterable, Iterator
+from dataclasses import dataclass, field
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# A major version suffix, as in github.com/go-chi/chi/v5 or gopkg.in/yaml.v3
+MAJOR_VERSION = re.compile(r"^v\d+$")
+GOPKG_VERSION = re.compile(r"\.v\d+$")
+# Predeclared functions, never from a dot-imported package
+GO_BUILTINS = {
+    "append",
+    "cap",
+    "clear",
+    "close",
+    "complex",
+    "copy",
+    "delete",
+    "imag",
+    "len",
+    "make",
+    "max",
+    "min",
+    "new",
+    "panic",
+    "print",
+    "println",
+    "real",
+    "recover",
+}
+
+
+@dataclass
+class GoRequirement:
+    path: str
+    version: str
+    indirect: bool = False
+
+
+@dataclass
+class GoReplacement:
+    """`old [version] => new [version]`; new is a directory when local."""
+
+    old_path: str
+    old_version: str
+    new_path: str
+    new_version: str
+
+    @property
+    def is_local(self) -> bool:
+        # Module paths never start with a dot or a slash, directories do
+        return self.new_path.startswith((".", "/"))
+
+
+@dataclass
+class GoModFile:
+    module: str
+    go_version: str = ""
+    requires: list[GoRequirement] = field(default_factory=list)
+    replaces: list[GoReplacement] = field(default_factory=list)
+
+
+@dataclass
+class GoWorkFile:
+    go_version: str = ""
+    uses: list[str] = field(default_factory=list)  # Module directories
+    replaces: list[GoReplacement] = field(default_factory=list)
+
+
+@dataclass
+class WorkspaceModules:
+    """
+    The Go modules declared by the other repositories of a workspace, and
+    their exported functions, so an import of one resolves into the graph
+    of the repository declaring it instead of to an external module.
+    """
+
+    # Module path -> name of the repository declaring it
+    repositories: dict[str, str] = field(default_factory=dict)
+    # Package import path -> exported function name -> qualified name
+    functions: dict[str, dict[str, str]] = field(default_factory=dict)
+
+    def repository(self, import_path: str) -> str | None:
+        module = module_for_import(import_path, self.repositories)
+        return self.repositories[module] if module else None
+
+    def function(self, import_path: str, name: str) -> str | None:
+        return self.functions.get(import_path, {}).get(name)
+
+    def add_functions(self, functions: dict[str, dict[str, str]]) -> None:
+        """Offer the exports of an ingested repository to those after it."""
+        for import_path, names in functions.items():
+            self.functions.setdefault(import_path, {}).update(names)
+
+
+def parse_go_mod_file(content: str) -> GoModFile:
+    """Parse the module path, requirements and replacements of a go.mod file."""
+    go_mod = GoModFile(module="")
+    for verb, line, comment in _directives(content):
+        words = [word.strip('"') for word in line.split()]
+        if verb == "module" and words:
+            go_mod.module = words[0]
+        elif verb == "go" and words:
+            go_mod.go_version = words[0]
+        elif verb == "require" and len(words) >= 2:
+            go_mod.requires.append(
+                GoRequirement(words[0], words[1], comment.strip() == "indirect")
+            )
+        elif verb == "replace" and (replacement := _replacement(words)):
+            go_mod.replaces.append(replacement)
+    return go_mod
+
+
+def parse_go_work(content: str) -> GoWorkFile:
+    """Parse the module directories and replacements of a go.work file."""
+    go_work = GoWorkFile()
+    for verb, line, _ in _directives(content):
+        words = [word.strip('"') for word in line.split()]
+        if verb == "go" and words:
+            go_work.go_version = words[0]
+        elif verb == "use" and words:
+            go_work.uses.append(words[0])
+        elif verb == "replace" and (replacement := _replacement(words)):
+            go_work.replaces.append(replacement)
+    return go_work
+
+
+def parse_go_sum(content: str) -> dict[tuple[str, str], str]:
+    """Checksums of module contents by (path, version), without go.mod hashes."""
+    checksums = {}
+    for line in content.splitlines():
+        words = line.split()
+        if len(words) == 3 and not words[1].endswith("/go.mod"):
+            checksums[(words[0], words[1])] = words[2]
+    return checksums
+
+
+def module_for_import(import_path: str, module_paths: Iterable[str]) -> str | None:
+    """The module an import path belongs to, the longest matching prefix."""
+    matches = [
+        module
+        for module in module_paths
+        if import_path == module or import_path.startswith(f"{module}/")
+    ]
+    return max(matches, key=len, default=None)
+
+
+def is_standard_library(import_path: str) -> bool:
+    # Module paths start with a domain name; the standard library's never do
+    return "." not in import_path.split("/", 1)[0]
+
+
+def import_specs(root_node: Node) -> list[tuple[str, str]]:
+    """(name as written, import path) of each import, name empty if none."""
+    specs = []
+    for declaration in root_node.named_children:
+        if declaration.type != "import_declaration":
+            continue
+        for spec in _descendants(declaration, "import_spec"):
+            path_node = spec.child_by_field_name("path")
+            if path_node is None:
+                continue
+            name_node = spec.child_by_field_name("name")
+            specs.append(
+                (
+                    node_text(name_node) if name_node is not None else "",
+                    node_text(path_node).strip('"`'),
+                )
+            )
+    return specs
+
+
+def collect_go_imports(root_node: Node) -> dict[str, str]:
+    """
+    Imported packages of a Go file by the name the file uses for them.
+    Without reading the package, its name is guessed from the import path:
+    the last element, skipping a major version (chi/v5 is chi, yaml.v3 yaml).
+    Blank and dot imports are left out, nothing refers to them by name.
+    """
+    return {
+        name or _package_name(import_path): import_path
+        for name, import_path in import_specs(root_node)
+        if name not in ("_", ".")
+    }
+
+
+def referenced_packages(func_node: Node, names: set[str]) -> set[str]:
+    """Which of the imported package names a function uses, as pkg.Name."""
+    used = set()
+    for node in _descendants(func_node, "selector_expression", "qualified_type"):
+        field_name = "operand" if node.type == "selector_expression" else "package"
+        qualifier = node.child_by_field_name(field_name)
+        if qualifier is not None and qualifier.type in (
+            "identifier",
+            "package_identifier",
+        ):
+            name = node_text(qualifier)
+            if name in names:
+                used.add(name)
+    return used
+
+
+def _directives(content: str) -> Iterator[tuple[str, str, str]]:
+    """(verb, arguments, trailing comment) of each line, blocks unfolded."""
+    block = ""
+    for raw_line in content.splitlines():
+        line, _, comment = raw_line.partition("//")
+        line = line.strip()
+        if not line:
+            continue
+        if block:
+            if line == ")":
+                block = ""
+            else:
+                yield block, line, comment
+            continue
+        verb, _, rest = line.partition(" ")
+        rest = rest.strip()
+        if rest == "(":
+            block = verb
+        elif rest:
+            yield verb, rest, comment
+
+
+def _replacement(words: list[str]) -> GoReplacement | None:
+    if "=>" not in words:
+        return None
+    arrow = words.index("=>")
+    old, new = words[:arrow], words[arrow + 1 :]
+    if not old or not new:
+        return None
+    return GoReplacement(
+        old[0],
+        old[1] if len(old) > 1 else "",
+        new[0],
+        new[1] if len(new) > 1 else "",
+    )
+
+
+def _package_name(import_path: str) -> str:
+    elements = import_path.split("/")
+    name = elements[-1]
+    if MAJOR_VERSION.match(name) and len(elements) > 1:
+        name = elements[-2]
+    return GOPKG_VERSION.sub("", name)
+
+
+def _descendants(node: Node, *types: str) -> Iterator[Node]:
+    stack = list(node.named_children)
+    while stack:
+        child = stack.pop()
+        if child.type in types:
+            yield child
+        stack.extend(child.named_children)
+
@@ -0,0 +1,190 @@
+"""Lexical scopes of a Go function: which declaration a name refers to where.
+
+Calls were resolved by name against the whole function, so a local `m :=
+&Meter{}` in one closure turned an `m.Inc()` elsewhere, where m is the
+imported metrics package, into a method call, and a local func variable
+`format` called as `format()` linked to the package's own format function.
+
+Go scopes are blocks: the function's with its receiver, parameters and
+results, each `{}` block and closure, the implicit blocks of if, for and
+switch statements holding their initializers, and each case clause. A local
+is visible from the end of its declaration to the end of its block, and the
+innermost declaration of a name hides the others, package-level names and
+imported package names included.
+"""
+
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+from .go_interfaces import TYPE_ARGUMENTS
+
+# Nodes opening a block that locals declared in them belong to
+SCOPE_TYPES = frozenset(
+    {
+        "function_declaration",
+        "method_declaration",
+        "func_literal",
+        "block",
+        "if_statement",
+        "for_statement",
+        "expression_switch_statement",
+        "type_switch_statement",
+        "select_statement",
+        "expression_case",
+        "type_case",
+        "default_case",
+        "communication_case",
+    }
+)
+
+FUNCTION_TYPES = ("function_declaration", "method_declaration", "func_literal")
+
+
+@dataclass
+class GoBinding:
+    """A local of a Go function: a parameter, variable or constant."""
+
+    name: str
+    type_name: str  # Named type without *, "" when not known
+    scope_start: int  # Byte range of the block it is declared in
+    scope_end: int
+    visible_from: int  # Byte where its declaration ends
+
+
+class GoScopes:
+    """The locals declared in a Go function and its closures, by block."""
+
+    def __init__(self, func_node: Node):
+        self.bindings: dict[str, list[GoBinding]] = {}
+        stack = [func_node]
+        while stack:
+            node = stack.pop()
+            self._declare(node)
+            stack.extend(node.named_children)
+
+    def lookup(self, node: Node, name: str) -> GoBinding | None:
+        """The local a name refers to at a node, None for package-level names."""
+        position = node.start_byte
+        visible = [
+            binding
+            for binding in self.bindings.get(name, ())
+            if binding.scope_start <= position < binding.scope_end
+            and binding.visible_from <= position
+        ]
+        # Enclosing blocks nest, so the innermost starts last
+        return max(
+            visible,
+            key=lambda b: (b.scope_start, b.visible_from),
+            default=None,
+        )
+
+    def variables_at(self, node: Node) -> dict[str, str]:
+        """Named types of the locals visible at a node, as variable_types gives."""
+        types = {}
+        for name in self.bindings:
+            binding = self.lookup(node, name)
+            if binding is not None and binding.type_name:
+                types[name] = binding.type_name
+        return types
+
+    def _declare(self, node: Node) -> None:
+        if node.type in FUNCTION_TYPES:
+            body = node.child_by_field_name("body")
+            visible_from = body.start_byte if body is not None else node.end_byte
+            for field_name in ("receiver", "parameters", "result"):
+                parameters = node.child_by_field_name(field_name)
+                if parameters is None or parameters.type != "parameter_list":
+                    continue
+                for parameter in parameters.named_children:
+                    type_node = parameter.child_by_field_name("type")
+                    type_name = (
+                        _type_name(type_node)
+                        if type_node is not None
+                        and parameter.type == "parameter_declaration"
+                        else ""
+                    )
+                    for name in parameter.children_by_field_name("name"):
+                        self._bind(name, type_name, node, visible_from)
+        elif node.type == "short_var_declaration":
+            left = node.child_by_field_name("left")
+            right = node.child_by_field_name("right")
+            names = left.named_children if left is not None else []
+            values = right.named_children if right is not None else []
+            if len(values) != len(names):
+                values = []
+            for index, name in enumerate(names):
+                value = values[index] if values else None
+                self._bind(name, _literal_type(value), _scope_of(node), node.end_byte)
+        elif node.type in ("var_spec", "const_spec"):
+            type_node = node.child_by_field_name("type")
+            type_name = _type_name(type_node) if type_node is not None else ""
+            values = node.child_by_field_name("value")
+            names = node.children_by_field_name("name")
+            literals = values.named_children if values is not None else []
+            for index, name in enumerate(names):
+                if not type_name and len(literals) == len(names):
+                    declared = _literal_type(literals[index])
+                else:
+                    declared = type_name
+                self._bind(name, declared, _scope_of(node), node.end_byte)
+        elif node.type in ("range_clause", "receive_statement"):
+            # `for k, v := range m` and `case v, ok := <-ch:` declare with :=
+            left = node.child_by_field_name("left")
+            if left is not None and any(c.type == ":=" for c in node.children):
+                for name in left.named_children:
+                    self._bind(name, "", _scope_of(node), node.end_byte)
+        elif node.type == "type_switch_statement":
+            self._declare_type_switch(node)
+
+    def _declare_type_switch(self, node: Node) -> None:
+        """`switch v := x.(type)`: v has the case's type in each clause."""
+        alias = node.child_by_field_name("alias")
+        if alias is None:
+            return
+        names = alias.named_children if alias.type == "expression_list" else [alias]
+        for clause in node.named_children:
+            if clause.type not in ("type_case", "default_case"):
+                continue
+            types = clause.children_by_field_name("type")
+            type_name = _type_name(types[0]) if len(types) == 1 else ""
+            if type_name == "nil":
+                type_name = ""
+            for name in names:
+                self._bind(name, type_name, clause, clause.start_byte)
+
+    def _bind(self, name: Node, type_name: str, scope: Node, visible_from: int) -> None:
+        if name.type != "identifier":
+            return
+        text = node_text(name)
+        if text == "_":
+            return
+        self.bindings.setdefault(text, []).append(
+            GoBinding(text, type_name, scope.start_byte, scope.end_byte, visible_from)
+        )
+
+
+def _scope_of(node: Node) -> Node:
+    """The innermost block a declaration belongs to."""
+    parent = node.parent
+    while parent is not None and parent.type not in SCOPE_TYPES:
+        parent = parent.parent
+    return parent if parent is not None else node
+
+
+def _literal_type(value: Node | None) -> str:
+    """The type of a composite literal value, `T{}` or `&T{}`."""
+    if value is None:
+        return ""
+    if value.type == "unary_expression" and value.named_children:
+        value = value.named_children[0]
+    if value.type != "composite_literal":
+        return ""
+    type_node = value.child_by_field_name("type")
+    return _type_name(type_node) if type_node is not None else ""
+
+
+def _type_name(type_node: Node) -> str:
+    return TYPE_ARGUMENTS.sub("", node_text(type_node).lstrip("*").strip())
+
@@ -0,0 +1,240 @@
+"""govulncheck findings mapped onto the graph.
+
+`govulncheck -json ./...` streams JSON messages: "osv" entries describing
+vulnerabilities of the Go vulnerability database and "finding" messages, each
+with a trace from the vulnerable symbol (first frame) back to the code of the
+module being checked (last frame). A finding without a function in its first
+frame only means the vulnerable package is imported, or without a package that
+the module is required; only the symbol level says the code calls it.
+"""
+
+import json
+import subprocess
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .code_locator import CodeLocator
+
+# How far govulncheck saw the vulnerable code reached, weakest first
+REQUIRED = "required"
+IMPORTED = "imported"
+CALLED = "called"
+REACHABILITY_RANK = {REQUIRED: 1, IMPORTED: 2, CALLED: 3}
+# The Go vulnerability database has no severity; reachability stands in for it
+REACHABILITY_SEVERITY = {REQUIRED: "low", IMPORTED: "medium", CALLED: "high"}
+
+
+@dataclass
+class VulnFrame:
+    module: str
+    version: str = ""
+    package: str = ""
+    function: str = ""
+    receiver: str = ""
+    filename: str = ""
+    line: int = 0
+
+    @property
+    def symbol(self) -> str:
+        """The frame as Go names it, e.g. golang.org/x/net/http2.Server.ServeConn."""
+        if not self.function:
+            return self.package or self.module
+        receiver = self.receiver.lstrip("*")
+        name = f"{receiver}.{self.function}" if receiver else self.function
+        return f"{self.package}.{name}" if self.package else name
+
+
+@dataclass
+class VulnFinding:
+    osv: str
+    fixed_version: str = ""
+    trace: list[VulnFrame] = field(default_factory=list)
+
+    @property
+    def reachability(self) -> str:
+        vulnerable = self.trace[0] if self.trace else None
+        if vulnerable is not None and vulnerable.function:
+            return CALLED
+        if vulnerable is not None and vulnerable.package:
+            return IMPORTED
+        return REQUIRED
+
+
+@dataclass
+class GovulncheckReport:
+    osvs: dict[str, dict[str, Any]] = field(default_factory=dict)
+    findings: list[VulnFinding] = field(default_factory=list)
+
+
+def parse_govulncheck_json(content: str) -> GovulncheckReport:
+    """Read the stream of indented JSON messages `govulncheck -json` writes."""
+    report = GovulncheckReport()
+    decoder = json.JSONDecoder()
+    position = 0
+    content = content.strip()
+    while position < len(content):
+        message, position = decoder.raw_decode(content, position)
+        while position < len(content) and content[position].isspace():
+            position += 1
+        if osv := message.get("osv"):
+            report.osvs[osv["id"]] = osv
+        elif finding := message.get("finding"):
+            report.findings.append(
+                VulnFinding(
+                    finding["osv"],
+                    finding.get("fixed_version", ""),
+                    [_frame(frame) for frame in finding.get("trace") or []],
+                )
+            )
+    return report
+
+
+def run_govulncheck(repo_path: Path) -> str:
+    """Output of `govulncheck -json ./...` run in a repository."""
+    result = subprocess.run(
+        ["govulncheck", "-json", "./..."],
+        cwd=repo_path,
+        capture_output=True,
+        text=True,
+        check=True,
+    )
+    return result.stdout
+
+
+class GovulncheckLoader:
+    """
+    Records govulncheck findings as Vulnerability nodes on the module versions
+    they affect, with CALLS_VULNERABLE edges from the repository's functions
+    that reach a vulnerable symbol.
+    """
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self.locator = CodeLocator(ingestor)
+
+    def load(self, report: GovulncheckReport) -> dict[str, int]:
+        # A vulnerability is as reachable as its most reachable finding
+        reachability: dict[str, str] = {}
+        fixes: dict[str, tuple[str, str]] = {}
+        for finding in report.findings:
+            current = reachability.get(finding.osv, REQUIRED)
+            if REACHABILITY_RANK[finding.reachability] >= REACHABILITY_RANK[current]:
+                reachability[finding.osv] = finding.reachability
+            if finding.trace and finding.fixed_version:
+                fixes[finding.osv] = (finding.trace[0].module, finding.fixed_version)
+
+        for osv_id, reached in sorted(reachability.items()):
+            osv = report.osvs.get(osv_id, {})
+            module, fixed_version = fixes.get(osv_id, ("", ""))
+            self.ingestor.ensure_node_batch(
+                "Vulnerability",
+                {
+                    "id": osv_id,
+                    "type": "go_vulnerability",
+                    "severity": REACHABILITY_SEVERITY[reached],
+                    "description": osv.get("summary") or osv.get("details", ""),
+                    "recommendation": (
+                        f"Upgrade {module} to {fixed_version}" if fixed_version else ""
+                    ),
+                    "aliases": osv.get("aliases") or [],
+                    "reachability": reached,
+                    "url": (osv.get("database_specific") or {}).get("url", ""),
+                },
+            )
+
+        module_versions: set[tuple[str, str]] = set()
+        calls = 0
+        unresolved_traces = 0
+        for finding in report.findings:
+            if not finding.trace:
+                continue
+            vulnerable = finding.trace[0]
+            if vulnerable.version:
+                module_versions.add((vulnerable.module, vulnerable.version))
+                self.ingestor.ensure_relationship_batch(
+                    (
+                        "ModuleVersion",
+                        "qualified_name",
+                        f"{vulnerable.module}@{vulnerable.version}",
+                    ),
+                    "HAS_KNOWN_VULNERABILITY",
+                    ("Vulnerability", "id", finding.osv),
+                    {
+                        "package": vulnerable.package,
+                        "fixed_version": finding.fixed_version,
+                    },
+                )
+            if finding.reachability != CALLED:
+                continue
+            # The last frame is in the checked module; so are the repository's
+            # other frames of the trace
+            checked_module = finding.trace[-1].module
+            callers = [
+                (frame, function)
+                for frame in finding.trace[1:]
+                if frame.module == checked_module
+                and (function := self._function(frame)) is not None
+            ]
+            if not callers:
+                logger.debug(f"No function of the graph calls {vulnerable.symbol}")
+                unresolved_traces += 1
+                continue
+            # The function closest to the vulnerable symbol makes the call; the
+            # outermost one is where the path enters the repository
+            frame, caller = callers[0]
+            self.ingestor.ensure_relationship_batch(
+                (caller["label"], "qualified_name", caller["qualified_name"]),
+                "CALLS_VULNERABLE",
+                ("Vulnerability", "id", finding.osv),
+                {
+                    "symbol": vulnerable.symbol,
+                    "line_number": frame.line,
+                    "entry": callers[-1][1]["qualified_name"],
+                    "trace": [step.symbol for step in finding.trace],
+                },
+            )
+            calls += 1
+
+        for module, version in sorted(module_versions):
+            self.ingestor.ensure_node_batch(
+                "ModuleVersion",
+                {
+                    "qualified_name": f"{module}@{version}",
+                    "path": module,
+                    "version": version,
+                },
+            )
+        self.ingestor.flush_all()
+
+        return {
+            "vulnerabilities": len(reachability),
+            "called": sum(1 for reached in reachability.values() if reached == CALLED),
+            "module_versions": len(module_versions),
+            "calls": calls,
+            "unresolved_traces": unresolved_traces,
+        }
+
+    def _function(self, frame: VulnFrame) -> dict[str, Any] | None:
+        if not frame.function:
+            return None
+        if frame.filename and frame.line:
+            if function := self.locator.at_line(frame.filename, frame.line):
+                return function
+        name = f"{frame.receiver.lstrip('*')}.{frame.function}".lstrip(".")
+        return self.locator.by_name(name, frame.package)
+
+
+def _frame(frame: dict[str, Any]) -> VulnFrame:
+    position = frame.get("position") or {}
+    return VulnFrame(
+        frame.get("module", ""),
+        frame.get("version", ""),
+        frame.get("package", ""),
+        frame.get("function", ""),
+        frame.get("receiver", ""),
+        position.get("filename", ""),
+        position.get("line", 0),
+    )
@@ -0,0 +1,199 @@
+"""Churn/complexity hotspot analysis combining Git history with code metrics."""
+
+from collections import defaultdict
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from loguru import logger
+
+from ..version_control.git_analyzer import DiffHunk
+
+# Functions and methods together with the path of the module that defines them
+FUNCTION_METRICS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(f)
+WHERE f:Function OR f:Method
+RETURN DISTINCT f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       m.path AS path, f.cyclomatic_complexity AS complexity,
+       f.start_line AS start_line, f.end_line AS end_line
+"""
+
+
+@dataclass
+class Hotspot:
+    """A function or file ranked by churn multiplied by complexity."""
+
+    qualified_name: str  # Qualified name for functions, path for files
+    label: str  # "Function", "Method" or "File"
+    path: str
+    churn: int  # Commits changing the function's lines, or the file
+    complexity: int
+    score: float  # churn * complexity
+    normalized_score: float = 0.0  # score relative to the top hotspot (0.0 - 1.0)
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class HotspotAnalyzer:
+    """Ranks hotspots (high churn x high complexity) using the code graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def fetch_function_metrics(self) -> list[dict[str, Any]]:
+        """Fetch complexity and location for every function and method."""
+        return self.ingestor.fetch_all(FUNCTION_METRICS_QUERY)  # type: ignore[no-any-return]
+
+    def rank_functions(
+        self, history: list[dict[str, list[DiffHunk]]], limit: int | None = None
+    ) -> list[Hotspot]:
+        """
+        Rank functions and methods by the commits changing their lines times
+        their complexity; history is GitAnalyzer.get_diff_hunks().
+        """
+        rows = self.fetch_function_metrics()
+        hotspots = score_function_hotspots(rows, function_churn(rows, history))
+        return hotspots[:limit] if limit else hotspots
+
+    def rank_files(
+        self, churn: dict[str, int], limit: int | None = None
+    ) -> list[Hotspot]:
+        """Rank files by their churn times the summed complexity of their functions."""
+        hotspots = score_file_hotspots(self.fetch_function_metrics(), churn)
+        return hotspots[:limit] if limit else hotspots
+
+    def store_scores(self, hotspots: list[Hotspot]) -> None:
+        """Persist churn and normalized hotspot scores on the graph nodes."""
+        for hotspot in hotspots:
+            if hotspot.label == "File":
+                key, value = "path", hotspot.path
+            else:
+                key, value = "qualified_name", hotspot.qualified_name
+            self.ingestor.ensure_node_batch(
+                hotspot.label,
+                {
+                    key: value,
+                    "churn": hotspot.churn,
+                    "hotspot_score": round(hotspot.normalized_score, 4),
+                },
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Stored hotspot scores on {len(hotspots)} nodes")
+
+
+def function_churn(
+    rows: list[dict[str, Any]], history: list[dict[str, list[DiffHunk]]]
+) -> dict[str, int]:
+    """
+    Count the commits whose diff hunks overlap each function's lines. Going
+    back from the newest commit, each function's line span is carried over
+    to the lines it had before the commit, until the commit adding it.
+    """
+    spans: dict[str, dict[str, tuple[int, int]]] = defaultdict(dict)
+    for row in rows:
+        if row.get("path") and row.get("start_line") and row.get("end_line"):
+            spans[row["path"]][row["qualified_name"]] = (
+                row["start_line"],
+                row["end_line"],
+            )
+
+    churn: dict[str, int] = defaultdict(int)
+    for commit in history:
+        for path, hunks in commit.items():
+            functions = spans.get(path)
+            if not functions:
+                continue
+            for qualified_name, (start, end) in list(functions.items()):
+                if any(_overlaps(hunk, start, end) for hunk in hunks):
+                    churn[qualified_name] += 1
+                start = _line_before(hunks, start, first=True)
+                end = _line_before(hunks, end, first=False)
+                if start > end:
+                    # Added by this commit
+                    del functions[qualified_name]
+                else:
+                    functions[qualified_name] = (start, end)
+    return dict(churn)
+
+
+def _overlaps(hunk: DiffHunk, start: int, end: int) -> bool:
+    if hunk.new_count == 0:
+        # Lines removed after new_start, inside the function if both its
+        # neighbours are
+        return start <= hunk.new_start < end
+    return hunk.new_start <= end and start <= hunk.new_start + hunk.new_count - 1
+
+
+def _line_before(hunks: list[DiffHunk], line: int, first: bool) -> int:
+    """
+    The line a line of a commit's version had in its parent: shifted by the
+    hunks above it, or the first or last of the old lines of a hunk it is in.
+    """
+    shift = 0
+    for hunk in hunks:
+        new_end = hunk.new_start + hunk.new_count - 1
+        if hunk.new_count and hunk.new_start <= line <= new_end:
+            if hunk.old_count == 0:
+                # Only lines added; old_start is the line above them
+                return hunk.old_start + 1 if first else hunk.old_start
+            if first:
+                return hunk.old_start
+            return hunk.old_start + hunk.old_count - 1
+        if new_end < line if hunk.new_count else hunk.new_start < line:
+            shift += hunk.old_count - hunk.new_count
+    return line + shift
+
+
+def score_function_hotspots(
+    rows: list[dict[str, Any]], churn: dict[str, int]
+) -> list[Hotspot]:
+    """
+    Score function rows returned by FUNCTION_METRICS_QUERY against the churn
+    of each function, by qualified name.
+    """
+    hotspots = []
+    for row in rows:
+        changes = churn.get(row["qualified_name"], 0)
+        complexity = row.get("complexity") or 1
+        hotspots.append(
+            Hotspot(
+                qualified_name=row["qualified_name"],
+                label=row.get("label") or "Function",
+                path=row.get("path") or "",
+                churn=changes,
+                complexity=complexity,
+                score=float(changes * complexity),
+            )
+        )
+    return _normalize_and_sort(hotspots)
+
+
+def score_file_hotspots(
+    rows: list[dict[str, Any]], churn: dict[str, int]
+) -> list[Hotspot]:
+    """Aggregate function complexity per file and score it against file churn."""
+    complexity_by_path: dict[str, int] = defaultdict(int)
+    for row in rows:
+        if row.get("path"):
+            complexity_by_path[row["path"]] += row.get("complexity") or 1
+
+    hotspots = [
+        Hotspot(
+            qualified_name=path,
+            label="File",
+            path=path,
+            churn=churn.get(path, 0),
+            complexity=complexity,
+            score=float(churn.get(path, 0) * complexity),
+        )
+        for path, complexity in complexity_by_path.items()
+    ]
+    return _normalize_and_sort(hotspots)
+
+
+def _normalize_and_sort(hotspots: list[Hotspot]) -> list[Hotspot]:
+    """Sort hotspots by score and attach a score relative to the maximum."""
+    max_score = max((h.score for h in hotspots), default=0.0)
+    for hotspot in hotspots:
+        hotspot.normalized_score = hotspot.score / max_score if max_score else 0.0
+    return sorted(hotspots, key=lambda h: (-h.score, h.qualified_name))
@@ -0,0 +1,320 @@
+"""Tests to run for a change: those reaching the changed code through the graph.
+
+Changed lines are matched to the functions and methods containing them, as
+for review. Their callers are followed up the CALLS edges, and every test
+with a TESTS, COVERS or COVERED_BY edge to a changed or calling function is
+selected, with the tests whose own lines changed. Lines outside any function
+(imports, constants, type declarations) count as a change to every function
+of their file, since the graph cannot tell which of them depend on it.
+
+Like review, this expects the graph to reflect the head of the change.
+"""
+
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+from .review import (
+    SYMBOLS_IN_PATHS_QUERY,
+    ChangedSymbol,
+    FileDiff,
+    find_changed_symbols,
+)
+
+# Functions and methods calling any of a set, one level up
+CALLERS_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (caller)-[:CALLS]->(f {qualified_name: qn})
+WHERE (caller:Function OR caller:Method) AND (f:Function OR f:Method)
+RETURN DISTINCT qn AS callee, caller.qualified_name AS caller
+"""
+
+# Tests linked to any of a set of functions, with the file defining them
+TESTS_OF_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (f {qualified_name: qn})
+WHERE f:Function OR f:Method
+MATCH (t)-[:TESTS|COVERS]->(f)
+WHERE t:TestFunction OR t:TestCase
+OPTIONAL MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(t)
+RETURN DISTINCT qn AS target, t.qualified_name AS qualified_name,
+       labels(t)[0] AS label, t.name AS name, m.path AS path
+UNION
+UNWIND $qualified_names AS qn
+MATCH (f {qualified_name: qn})-[:COVERED_BY]->(t)
+WHERE (f:Function OR f:Method) AND (t:TestFunction OR t:TestCase)
+OPTIONAL MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(t)
+RETURN DISTINCT qn AS target, t.qualified_name AS qualified_name,
+       labels(t)[0] AS label, t.name AS name, m.path AS path
+"""
+
+# Tests declared in the touched files, with their line ranges
+TESTS_IN_PATHS_QUERY = """
+MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(t)
+WHERE (t:TestFunction OR t:TestCase) AND m.path IN $paths
+RETURN DISTINCT t.qualified_name AS qualified_name, labels(t)[0] AS label,
+       t.name AS name, m.path AS path, t.start_line AS start_line,
+       t.end_line AS end_line
+"""
+
+# Levels of callers followed up from a changed function
+DEFAULT_MAX_DEPTH = 10
+# Opens the impact comment on a pull request, so later pushes edit it in place
+IMPACT_COMMENT_MARKER = "<!-- graph-code:impact -->"
+
+
+@dataclass
+class ImpactedTest:
+    """A test to run, and the changed function it reaches."""
+
+    qualified_name: str
+    label: str
+    name: str
+    path: str | None
+    changed: str  # The changed function, or the test itself when edited
+    via: str  # The function the test is linked to, the changed one or a caller
+    distance: int  # Fewest calls from via down to changed code
+
+    @property
+    def package(self) -> str | None:
+        return str(PurePosixPath(self.path).parent) if self.path else None
+
+
+@dataclass
+class ImpactReport:
+    changed: list[ChangedSymbol]
+    tests: list[ImpactedTest] = field(default_factory=list)
+    # Changed functions no test reaches
+    untested: list[str] = field(default_factory=list)
+
+    @property
+    def packages(self) -> list[str]:
+        """Directories of the selected tests, e.g. for `go test ./<dir>`."""
+        return sorted({t.package for t in self.tests if t.package})
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "changed": [s.qualified_name for s in self.changed],
+            "tests": [asdict(t) for t in self.tests],
+            "packages": self.packages,
+            "untested": self.untested,
+        }
+
+
+def go_test_runs(report: ImpactReport) -> list[str]:
+    """
+    `go test` arguments per package: the package and a -run of its tests, or
+    the package alone when specs (Ginkgo) run inside a test function.
+    """
+    by_package: dict[str, set[str]] = defaultdict(set)
+    whole: set[str] = set()
+    for test in report.tests:
+        if not test.package or not (test.path or "").endswith("_test.go"):
+            continue
+        # Subtests run through their top-level test
+        name = test.name.split("/")[0]
+        if name.startswith(("Test", "Example", "Fuzz")):
+            by_package[test.package].add(name)
+        else:
+            whole.add(test.package)
+    return [
+        f"./{package}"
+        if package in whole
+        else f"./{package} -run '^({'|'.join(sorted(names))})$'"
+        for package, names in sorted(
+            {**by_package, **{p: set() for p in whole}}.items()
+        )
+    ]
+
+
+def impact_comment(report: ImpactReport, limit: int = 30) -> str:
+    """A pull request comment: how many tests cover the diff, and which."""
+    if not report.changed and not report.tests:
+        return f"{IMPACT_COMMENT_MARKER}\nNo changed function or test is in the graph."
+    count = len(report.tests)
+    lines = [
+        IMPACT_COMMENT_MARKER,
+        f"**{count} test{'' if count == 1 else 's'} cover your diff**, across "
+        f"{len(report.packages)} package(s), reaching "
+        f"{len(report.changed)} changed function(s).",
+    ]
+    if report.tests:
+        lines += [
+            "",
+            "| Test | File | Reaches | Calls away |",
+            "| --- | --- | --- | ---: |",
+        ]
+        for test in report.tests[:limit]:
+            lines.append(
+                f"| `{test.qualified_name}` | {test.path or ''} | "
+                f"`{test.changed}` | {test.distance} |"
+            )
+        if count > limit:
+            lines.append(f"\n…and {count - limit} more.")
+    if report.untested:
+        names = ", ".join(f"`{name}`" for name in report.untested)
+        lines += ["", f"No test reaches: {names}"]
+    return "\n".join(lines)
+
+
+class ImpactAnalyzer:
+    """Selects the tests affected by a diff from the call graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(
+        self, diffs: list[FileDiff], max_depth: int = DEFAULT_MAX_DEPTH
+    ) -> ImpactReport:
+        paths = sorted({d.path for d in diffs if not d.is_deleted})
+        if not paths:
+            return ImpactReport([])
+        test_rows = self.ingestor.fetch_all(TESTS_IN_PATHS_QUERY, {"paths": paths})
+        edited_tests = _edited_tests(diffs, test_rows)
+        # Test files are handled through their tests alone
+        test_paths = {row["path"] for row in test_rows}
+        symbol_rows = [
+            row
+            for row in self.ingestor.fetch_all(SYMBOLS_IN_PATHS_QUERY, {"paths": paths})
+            if row["path"] not in test_paths
+        ]
+        changed = _with_module_level_changes(
+            diffs, symbol_rows, find_changed_symbols(diffs, symbol_rows)
+        )
+
+        # Breadth-first up the callers, for the distance to each, then the
+        # changed functions each caller leads to over all the edges seen
+        distance = {s.qualified_name: 0 for s in changed}
+        origins = {s.qualified_name: {s.qualified_name} for s in changed}
+        edges: list[tuple[str, str]] = []
+        frontier = list(distance)
+        for depth in range(1, max_depth + 1):
+            if not frontier:
+                break
+            rows = self.ingestor.fetch_all(
+                CALLERS_QUERY, {"qualified_names": frontier}
+            )
+            frontier = []
+            for row in rows:
+                edges.append((row["callee"], row["caller"]))
+                if row["caller"] not in distance:
+                    distance[row["caller"]] = depth
+                    origins[row["caller"]] = set()
+                    frontier.append(row["caller"])
+        spreading = True
+        while spreading:
+            spreading = False
+            for callee, caller in edges:
+                if not origins[callee] <= origins[caller]:
+                    origins[caller] |= origins[callee]
+                    spreading = True
+
+        tests: dict[str, ImpactedTest] = {}
+        for qn, row in edited_tests.items():
+            tests[qn] = ImpactedTest(
+                qn, row["label"], row["name"], row["path"], qn, qn, 0
+            )
+        reaching: set[str] = set()
+        if distance:
+            rows = self.ingestor.fetch_all(
+                TESTS_OF_QUERY, {"qualified_names": list(distance)}
+            )
+            # Closest link first, so each test records its most direct reason
+            rows.sort(key=lambda r: (distance[r["target"]], r["target"]))
+            for row in rows:
+                reaching |= origins[row["target"]]
+                if row["qualified_name"] in tests:
+                    continue
+                tests[row["qualified_name"]] = ImpactedTest(
+                    row["qualified_name"],
+                    row["label"],
+                    row["name"] or row["qualified_name"].rsplit(".", 1)[-1],
+                    row["path"],
+                    min(origins[row["target"]]),
+                    row["target"],
+                    distance[row["target"]],
+                )
+        return ImpactReport(
+            changed=changed,
+            tests=sorted(
+                tests.values(), key=lambda t: (t.path or "", t.qualified_name)
+            ),
+            untested=sorted(
+                s.qualified_name for s in changed if s.qualified_name not in reaching
+            ),
+        )
+
+
+def _edited_tests(
+    diffs: list[FileDiff], test_rows: list[dict[str, Any]]
+) -> dict[str, dict[str, Any]]:
+    """
+    Tests whose lines changed, and every test of a file changed outside its
+    tests, e.g. in a shared fixture.
+    """
+    changed_lines = {d.path: d.added_lines | d.removed_at for d in diffs}
+    by_path: dict[str, list[dict[str, Any]]] = defaultdict(list)
+    for row in test_rows:
+        by_path[row["path"]].append(row)
+
+    edited = {}
+    for path, rows in by_path.items():
+        spans = [
+            (row["start_line"], row["end_line"])
+            for row in rows
+            if row.get("start_line") is not None and row.get("end_line") is not None
+        ]
+        lines = changed_lines.get(path, set())
+        outside = any(not any(s <= n <= e for s, e in spans) for n in lines)
+        for row in rows:
+            start, end = row.get("start_line"), row.get("end_line")
+            if outside or (
+                start is not None
+                and end is not None
+                and any(start <= n <= end for n in lines)
+            ):
+                edited[row["qualified_name"]] = row
+    return edited
+
+
+def _with_module_level_changes(
+    diffs: list[FileDiff],
+    symbol_rows: list[dict[str, Any]],
+    changed: list[ChangedSymbol],
+) -> list[ChangedSymbol]:
+    """Add every function of a file changed outside its functions."""
+    by_path: dict[str, list[dict[str, Any]]] = defaultdict(list)
+    for row in symbol_rows:
+        if row.get("start_line") is not None and row.get("end_line") is not None:
+            by_path[row["path"]].append(row)
+    seen = {s.qualified_name for s in changed}
+    result = list(changed)
+    for diff in diffs:
+        rows = by_path.get(diff.path)
+        if diff.is_deleted or not rows:
+            continue
+        outside = [
+            line
+            for line in sorted(diff.added_lines | diff.removed_at)
+            if not any(r["start_line"] <= line <= r["end_line"] for r in rows)
+        ]
+        if not outside:
+            continue
+        for row in rows:
+            if row["qualified_name"] in seen:
+                continue
+            seen.add(row["qualified_name"])
+            result.append(
+                ChangedSymbol(
+                    qualified_name=row["qualified_name"],
+                    label=row["label"],
+                    path=row["path"],
+                    start_line=row["start_line"],
+                    end_line=row["end_line"],
+                    first_changed_line=outside[0],
+                    complexity=row.get("complexity") or 0,
+                )
+            )
+    result.sort(key=lambda s: (s.path, s.start_line))
+    return result
@@ -0,0 +1,110 @@
+"""Import cycles between packages, found from the Module IMPORTS edges."""
+
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+from .call_depth import strongly_connected_components
+
+IMPORT_EDGES_QUERY = """
+MATCH (a:Module)-[r:IMPORTS]->(b:Module)
+RETURN a.qualified_name AS source, a.path AS source_path,
+       b.qualified_name AS target, b.path AS target_path,
+       r.line_number AS line_number, r.symbol AS symbol
+"""
+
+
+@dataclass
+class ImportSite:
+    """An import statement making one package depend on another."""
+
+    path: str
+    line_number: int | None
+    target_module: str
+    symbol: str | None = None
+
+    @property
+    def location(self) -> str:
+        return f"{self.path}:{self.line_number or '?'}"
+
+
+@dataclass
+class PackageDependency:
+    """The imports from one package of a cycle to another."""
+
+    source: str
+    target: str
+    imports: list[ImportSite] = field(default_factory=list)
+
+
+@dataclass
+class PackageCycle:
+    """Packages that all import each other, directly or transitively."""
+
+    packages: list[str]
+    # Weakest dependencies first, as those are the cheapest to break
+    dependencies: list[PackageDependency]
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class ImportCycleAnalyzer:
+    """Reports strongly connected components of the package import graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(self) -> list[PackageCycle]:
+        return find_package_cycles(self.ingestor.fetch_all(IMPORT_EDGES_QUERY))
+
+
+def package_of(path: str) -> str:
+    """The directory of a module, which is its package."""
+    return str(PurePosixPath(path).parent)
+
+
+def find_package_cycles(edges: list[dict[str, Any]]) -> list[PackageCycle]:
+    """Group module imports by package and return the cyclic components."""
+    dependencies: dict[tuple[str, str], PackageDependency] = {}
+    graph: dict[str, set[str]] = defaultdict(set)
+    for edge in edges:
+        if not edge.get("source_path") or not edge.get("target_path"):
+            continue
+        source = package_of(edge["source_path"])
+        target = package_of(edge["target_path"])
+        if source == target:
+            continue
+        graph[source].add(target)
+        dependency = dependencies.setdefault(
+            (source, target), PackageDependency(source, target)
+        )
+        dependency.imports.append(
+            ImportSite(
+                edge["source_path"],
+                edge.get("line_number"),
+                edge["target"],
+                edge.get("symbol"),
+            )
+        )
+
+    members: dict[int, list[str]] = defaultdict(list)
+    for package, component in strongly_connected_components(graph).items():
+        members[component].append(package)
+
+    cycles = []
+    for packages in members.values():
+        if len(packages) < 2:
+            continue
+        inside = set(packages)
+        cyclic = [
+            dependency
+            for (source, target), dependency in dependencies.items()
+            if source in inside and target in inside
+        ]
+        for dependency in cyclic:
+            dependency.imports.sort(key=lambda i: (i.path, i.line_number or 0))
+        cyclic.sort(key=lambda d: (len(d.imports), d.source, d.target))
+        cycles.append(PackageCycle(sorted(packages), cyclic))
+    return sorted(cycles, key=lambda c: (-len(c.packages), c.packages))
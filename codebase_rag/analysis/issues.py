@@ -0,0 +1,238 @@
+"""Links issue tracker references in commit messages to commits and code.
+
+Comments are scanned for references during ingestion; this pass covers
+commit history. Each commit that mentions an issue is linked to it, and so
+is every function or method whose current lines git blame attributes to
+that commit, which answers "what code was changed for #123" for the code
+as it is now rather than as it was when the commit landed.
+"""
+
+from collections import defaultdict
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from ..parsers.issue_references import IssueReference, extract_issue_references
+from ..services.issue_trackers import IssueTracker
+from ..version_control.git_analyzer import CommitInfo, GitAnalyzer
+from .review import SYMBOLS_IN_PATHS_QUERY
+
+# Issues not fetched from their tracker yet, or all of them on refresh
+UNHYDRATED_ISSUES_QUERY = """
+MATCH (i:Issue)
+WHERE (i.hydrated_at IS NULL OR $refresh) AND i.tracker IN $trackers
+RETURN i.key AS key, i.tracker AS tracker, i.number AS number,
+       i.project AS project
+"""
+
+HYDRATE_ISSUE_QUERY = """
+MATCH (i:Issue {key: $key})
+SET i += $properties
+"""
+
+# Sub-task or epic membership; the parent is fetched on a later hydration
+LINK_PARENT_ISSUE_QUERY = """
+MATCH (i:Issue {key: $key})
+MERGE (p:Issue {key: $parent})
+ON CREATE SET p.tracker = i.tracker, p.number = $number, p.project = $project
+MERGE (i)-[:CHILD_OF]->(p)
+"""
+
+# Rebuild code -> Jira ticket edges from blamed commits and code comments
+SYNC_TICKET_EDGES_QUERY = """
+OPTIONAL MATCH ()-[old:IMPLEMENTS_TICKET]->(:Issue)
+DELETE old
+WITH count(*) AS cleared
+MATCH (code)-[link:CHANGED_FOR|REFERENCES_ISSUE]->(i:Issue {tracker: 'jira'})
+WHERE NOT code:Commit
+WITH code, i,
+     collect(DISTINCT CASE type(link) WHEN 'CHANGED_FOR' THEN 'commit'
+                                      ELSE 'comment' END) AS via,
+     collect(DISTINCT link.commit_sha) AS commits
+MERGE (code)-[t:IMPLEMENTS_TICKET]->(i)
+SET t.via = via, t.commits = commits
+RETURN count(t) AS linked
+"""
+
+# Code implementing a ticket or its sub-tasks and epic children
+TICKET_CODE_QUERY = """
+MATCH (ticket:Issue {key: $key})
+OPTIONAL MATCH (child:Issue)-[:CHILD_OF*1..2]->(ticket)
+WITH [ticket] + collect(DISTINCT child) AS tickets
+UNWIND tickets AS t
+MATCH (code)-[r:IMPLEMENTS_TICKET]->(t)
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(code)
+RETURN DISTINCT code.qualified_name AS qualified_name, labels(code)[0] AS label,
+       coalesce(m.path, code.path) AS path, t.key AS ticket, r.via AS via,
+       r.commits AS commits
+ORDER BY path, qualified_name
+"""
+
+ISSUE_QUERY = """
+MATCH (i:Issue {key: $key})
+RETURN i.key AS key, i.title AS title, i.state AS state, i.url AS url,
+       i.issue_type AS issue_type, i.assignee AS assignee
+"""
+
+
+class IssueLinker:
+    """Records which commits and functions were changed for which issues."""
+
+    def __init__(
+        self,
+        ingestor: Any,
+        git_analyzer: GitAnalyzer | None,
+        jira_projects: set[str] | None = None,
+    ):
+        self.ingestor = ingestor
+        self.git_analyzer = git_analyzer
+        self.jira_projects = jira_projects
+
+    def link_commits(self, commits: list[CommitInfo]) -> dict[str, int]:
+        """Link commits to the issues their messages reference."""
+        referencing: dict[str, list[IssueReference]] = {}
+        issues: set[str] = set()
+        touched_paths: set[str] = set()
+        for commit in commits:
+            references = extract_issue_references(commit.message, self.jira_projects)
+            if not references:
+                continue
+            referencing[commit.sha] = references
+            touched_paths.update(commit.files_changed)
+            self.ingestor.ensure_node_batch(
+                "Commit",
+                {
+                    "sha": commit.sha,
+                    "short_sha": commit.sha[:8],
+                    "author": commit.author,
+                    "author_email": commit.author_email,
+                    "message": commit.message[:500],
+                    "date": commit.date.isoformat(),
+                },
+            )
+            for reference in references:
+                self._ensure_issue(reference)
+                issues.add(reference.key)
+                self.ingestor.ensure_relationship_batch(
+                    ("Commit", "sha", commit.sha),
+                    "REFERENCES_ISSUE",
+                    ("Issue", "key", reference.key),
+                    {"closes": reference.closes},
+                )
+
+        function_links = self._link_functions(referencing, sorted(touched_paths))
+        self.ingestor.flush_all()
+        return {
+            "commits": len(referencing),
+            "issues": len(issues),
+            "function_links": function_links,
+        }
+
+    def hydrate_issues(
+        self, trackers: list[IssueTracker], refresh: bool = False
+    ) -> int:
+        """
+        Fetch title, state and labels of Issue nodes not fetched before, or
+        of all of them with refresh, e.g. to pick up closed tickets.
+        """
+        by_name = {tracker.tracker: tracker for tracker in trackers}
+        rows = self.ingestor.fetch_all(
+            UNHYDRATED_ISSUES_QUERY,
+            {"trackers": sorted(by_name), "refresh": refresh},
+        )
+        hydrated = 0
+        for row in rows:
+            try:
+                properties = by_name[row["tracker"]].fetch(row)
+            except Exception as e:
+                logger.warning(f"Could not fetch issue {row['key']}: {e}")
+                continue
+            if properties is None:
+                continue
+            parent = properties.pop("parent", "")
+            properties["hydrated_at"] = datetime.now(UTC).isoformat()
+            self.ingestor.execute_write(
+                HYDRATE_ISSUE_QUERY, {"key": row["key"], "properties": properties}
+            )
+            if parent:
+                project, _, number = parent.rpartition("-")
+                self.ingestor.execute_write(
+                    LINK_PARENT_ISSUE_QUERY,
+                    {
+                        "key": row["key"],
+                        "parent": parent,
+                        "project": project,
+                        "number": int(number) if number.isdigit() else 0,
+                    },
+                )
+            hydrated += 1
+        return hydrated
+
+    def sync_ticket_edges(self) -> int:
+        """
+        Recompute IMPLEMENTS_TICKET edges from code to the Jira tickets its
+        blamed commits or its comments reference; returns the edge count.
+        """
+        rows = self.ingestor.fetch_all(SYNC_TICKET_EDGES_QUERY)
+        return rows[0]["linked"] if rows else 0
+
+    def find_ticket_code(
+        self, key: str
+    ) -> tuple[dict[str, Any] | None, list[dict[str, Any]]]:
+        """A ticket and the code implementing it, including its sub-tasks."""
+        issues = self.ingestor.fetch_all(ISSUE_QUERY, {"key": key})
+        if not issues:
+            return None, []
+        return issues[0], self.ingestor.fetch_all(TICKET_CODE_QUERY, {"key": key})
+
+    def _ensure_issue(self, reference: IssueReference) -> None:
+        # Same properties as the Issue nodes created for comments at ingestion
+        self.ingestor.ensure_node_batch(
+            "Issue",
+            {
+                "key": reference.key,
+                "tracker": reference.tracker,
+                "number": reference.number,
+                "project": reference.project,
+            },
+        )
+
+    def _link_functions(
+        self, referencing: dict[str, list[IssueReference]], paths: list[str]
+    ) -> int:
+        """Link functions whose blamed commits reference issues."""
+        if not referencing or not paths or self.git_analyzer is None:
+            return 0
+        symbols = self.ingestor.fetch_all(SYMBOLS_IN_PATHS_QUERY, {"paths": paths})
+        by_path: dict[str, list[dict[str, Any]]] = defaultdict(list)
+        for symbol in symbols:
+            by_path[symbol["path"]].append(symbol)
+
+        links = 0
+        for path, path_symbols in by_path.items():
+            file_path = Path(self.git_analyzer.repo_path) / path
+            blamed: dict[int, str] = {
+                blame.line_number: blame.commit_sha
+                for blame in self.git_analyzer.get_blame_info(str(file_path))
+                if blame.commit_sha in referencing
+            }
+            if not blamed:
+                continue
+            for symbol in path_symbols:
+                start, end = symbol.get("start_line"), symbol.get("end_line")
+                if start is None or end is None:
+                    continue
+                node = (symbol["label"], "qualified_name", symbol["qualified_name"])
+                shas = {sha for line, sha in blamed.items() if start <= line <= end}
+                for sha in sorted(shas):
+                    for reference in referencing[sha]:
+                        self.ingestor.ensure_relationship_batch(
+                            node,
+                            "CHANGED_FOR",
+                            ("Issue", "key", reference.key),
+                            {"commit_sha": sha},
+                        )
+                        links += 1
+        return links
@@ -0,0 +1,220 @@
+"""Architectural layers declared in config, and the dependencies that break them.
+
+A repository's .cgr.toml lists its layers from the outermost in:
+
+    [[layers]]
+    name = "handlers"
+    packages = ["internal/handlers", "cmd/**"]
+
+    [[layers]]
+    name = "services"
+    packages = ["internal/services"]
+
+    [[layers]]
+    name = "repos"
+    packages = ["internal/store"]
+
+A layer may depend on the layers declared after it, or only on those listed
+in its may_use. An import or call from a module of one layer into a module
+of a layer it may not use is a violation; modules in no layer are unchecked.
+"""
+
+from dataclasses import asdict, dataclass
+from fnmatch import fnmatchcase
+from typing import Any
+
+from loguru import logger
+
+from .import_cycles import IMPORT_EDGES_QUERY
+
+MODULES_QUERY = """
+MATCH (m:Module) WHERE m.path IS NOT NULL
+RETURN m.qualified_name AS qualified_name, m.path AS path
+"""
+
+# Calls between functions and methods of different modules
+CALL_EDGES_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(a)-[c:CALLS]->(b),
+      (n:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(b)
+WHERE (a:Function OR a:Method) AND (b:Function OR b:Method) AND m <> n
+RETURN DISTINCT a.qualified_name AS source, m.path AS source_path,
+       b.qualified_name AS target, n.path AS target_path,
+       c.line_number AS line_number
+"""
+
+# Drop the layers and flags of an earlier run, so removed layers and fixed
+# dependencies disappear
+CLEAR_LAYERS = [
+    "MATCH (l:Layer) DETACH DELETE l",
+    "MATCH ()-[r:IMPORTS|CALLS]->() WHERE r.layer_violation IS NOT NULL "
+    "REMOVE r.layer_violation",
+]
+
+FLAG_IMPORTS = """
+UNWIND $edges AS edge
+MATCH (a:Module {qualified_name: edge.source})-[r:IMPORTS]->
+      (b:Module {qualified_name: edge.target})
+SET r.layer_violation = edge.rule
+"""
+
+FLAG_CALLS = """
+UNWIND $edges AS edge
+MATCH (a {qualified_name: edge.source})-[r:CALLS]->(b {qualified_name: edge.target})
+WHERE (a:Function OR a:Method) AND (b:Function OR b:Method)
+SET r.layer_violation = edge.rule
+"""
+
+
+@dataclass
+class Layer:
+    name: str
+    rank: int  # Position in the config, outermost first
+    packages: list[str]
+    may_use: list[str] | None = None
+
+    def contains(self, path: str) -> bool:
+        """Whether a module path is in one of the layer's directories or globs."""
+        return any(
+            fnmatchcase(path, pattern) or path.startswith(pattern.rstrip("/") + "/")
+            for pattern in self.packages
+        )
+
+    def may_depend_on(self, other: "Layer") -> bool:
+        if other.name == self.name:
+            return True
+        if self.may_use is not None:
+            return other.name in self.may_use
+        return other.rank > self.rank
+
+
+def load_layers(document: dict[str, Any]) -> list[Layer]:
+    """The layers in a config file's [[layers]] entries."""
+    entries = document.get("layers") or []
+    if not isinstance(entries, list):
+        raise ValueError("layers must be a list of [[layers]] entries")
+    layers: list[Layer] = []
+    for rank, entry in enumerate(entries):
+        if not isinstance(entry, dict) or not isinstance(entry.get("name"), str):
+            raise ValueError(f"Layer {rank + 1} needs a name")
+        name = entry["name"]
+        packages = entry.get("packages") or []
+        if isinstance(packages, str):
+            packages = [packages]
+        if not packages or not all(isinstance(p, str) for p in packages):
+            raise ValueError(f"Layer '{name}' needs a list of package globs")
+        may_use = entry.get("may_use")
+        if isinstance(may_use, str):
+            may_use = [may_use]
+        layers.append(Layer(name, rank, list(packages), may_use))
+
+    names = [layer.name for layer in layers]
+    for layer in layers:
+        if names.count(layer.name) > 1:
+            raise ValueError(f"Layer '{layer.name}' is declared more than once")
+        unknown = set(layer.may_use or []) - set(names)
+        if unknown:
+            raise ValueError(
+                f"Layer '{layer.name}' may_use names unknown layers: "
+                f"{', '.join(sorted(unknown))}"
+            )
+    return layers
+
+
+@dataclass
+class LayerViolation:
+    """An import or call from one layer into a layer it may not use."""
+
+    kind: str  # "import" or "call"
+    source_layer: str
+    target_layer: str
+    source: str
+    target: str
+    path: str
+    line_number: int | None
+
+    @property
+    def rule(self) -> str:
+        return f"{self.source_layer} -> {self.target_layer}"
+
+    @property
+    def location(self) -> str:
+        return f"{self.path}:{self.line_number or '?'}"
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class LayeringAnalyzer:
+    """Assigns modules to layers and checks IMPORTS and CALLS edges against them."""
+
+    def __init__(self, ingestor: Any, layers: list[Layer]):
+        self.ingestor = ingestor
+        self.layers = layers
+
+    def layer_of(self, path: str | None) -> Layer | None:
+        """The first declared layer containing a module path."""
+        if not path:
+            return None
+        return next((layer for layer in self.layers if layer.contains(path)), None)
+
+    def analyze(self) -> list[LayerViolation]:
+        violations = []
+        for kind, query in (("import", IMPORT_EDGES_QUERY), ("call", CALL_EDGES_QUERY)):
+            for edge in self.ingestor.fetch_all(query):
+                source = self.layer_of(edge.get("source_path"))
+                target = self.layer_of(edge.get("target_path"))
+                if source is None or target is None or source.may_depend_on(target):
+                    continue
+                violations.append(
+                    LayerViolation(
+                        kind,
+                        source.name,
+                        target.name,
+                        edge["source"],
+                        edge["target"],
+                        edge["source_path"],
+                        edge.get("line_number"),
+                    )
+                )
+        return sorted(
+            violations, key=lambda v: (v.rule, v.path, v.line_number or 0, v.kind)
+        )
+
+    def store(self, violations: list[LayerViolation]) -> dict[str, int]:
+        """
+        Write a Layer node per layer with IN_LAYER edges from its modules, and
+        mark violating edges with layer_violation.
+        """
+        for query in CLEAR_LAYERS:
+            self.ingestor.execute_write(query)
+        for layer in self.layers:
+            self.ingestor.ensure_node_batch(
+                "Layer",
+                {"name": layer.name, "rank": layer.rank, "packages": layer.packages},
+            )
+        assigned = 0
+        for module in self.ingestor.fetch_all(MODULES_QUERY):
+            layer = self.layer_of(module["path"])
+            if layer is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module["qualified_name"]),
+                "IN_LAYER",
+                ("Layer", "name", layer.name),
+            )
+            assigned += 1
+        self.ingestor.flush_all()
+
+        for kind, query in (("import", FLAG_IMPORTS), ("call", FLAG_CALLS)):
+            edges = [
+                {"source": v.source, "target": v.target, "rule": v.rule}
+                for v in violations
+                if v.kind == kind
+            ]
+            if edges:
+                self.ingestor.execute_write(query, {"edges": edges})
+        logger.info(
+            f"Stored {len(self.layers)} layers over {assigned} modules and "
+            f"{len(violations)} violations"
+        )
+        return {"layers": len(self.layers), "modules": assigned}
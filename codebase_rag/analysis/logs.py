@@ -0,0 +1,49 @@
+"""Queries over LogStatement nodes extracted during ingestion."""
+
+from typing import Any
+
+# Logging calls with their enclosing function (or module), matched on message text
+LOGS_QUERY = """
+MATCH (owner)-[:LOGS]->(l:LogStatement)
+WHERE ($text = '' OR toLower(l.message) CONTAINS toLower($text))
+  AND ($levels IS NULL OR l.level IN $levels)
+  AND ($library = '' OR l.library = $library)
+  AND ($path_prefix = '' OR l.path STARTS WITH $path_prefix)
+RETURN l.qualified_name AS qualified_name, l.library AS library, l.level AS level,
+       l.message AS message, l.call AS call, l.path AS path,
+       l.line_number AS line_number, owner.qualified_name AS owner
+ORDER BY l.path, l.line_number
+LIMIT $limit
+"""
+
+
+class LogAnalyzer:
+    """Answers "where is this message logged?" from extracted logging calls."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def find_log_statements(
+        self,
+        text: str = "",
+        levels: list[str] | None = None,
+        library: str = "",
+        path_prefix: str = "",
+        limit: int = 50,
+    ) -> list[dict[str, Any]]:
+        """
+        Return logging calls whose message template contains text
+        (case-insensitive), optionally filtered by level, library and path.
+        """
+        if path_prefix and not path_prefix.endswith("/"):
+            path_prefix += "/"
+        return self.ingestor.fetch_all(  # type: ignore[no-any-return]
+            LOGS_QUERY,
+            {
+                "text": text,
+                "levels": [level.lower() for level in levels] if levels else None,
+                "library": library,
+                "path_prefix": path_prefix,
+                "limit": limit,
+            },
+        )
@@ -0,0 +1,343 @@
+"""Calls the static pass could not resolve, asked of a language server.
+
+Interface methods, callbacks and values whose type is only known to the
+compiler leave the tree-sitter passes without a callee. A language server
+knows: for each function and method of the graph, the resolver asks for its
+outgoing calls through the call hierarchy, and adds the CALLS edges the
+graph lacks between definitions it has. A call whose target is not in the
+graph, such as an interface method, is followed to the implementations the
+server reports, and those edges are marked dynamic.
+
+Positions in the graph are lines; the server wants the character of the
+name too, found on the definition's first line. Like precise index imports
+this runs after ingestion and only adds edges, marked with the server that
+proved them.
+"""
+
+import queue
+import shlex
+import subprocess
+import threading
+from collections import defaultdict
+from dataclasses import dataclass
+from pathlib import Path
+from typing import IO, Any
+from urllib.parse import unquote, urlparse
+
+from loguru import logger
+
+from ..lsp.protocol import read_message, write_message
+
+# Language server command and LSP language id per language, by extension
+LANGUAGE_SERVERS = {
+    "go": ("gopls", "go", (".go",)),
+    "python": ("pyright-langserver --stdio", "python", (".py",)),
+    "typescript": (
+        "typescript-language-server --stdio",
+        "typescript",
+        (".ts", ".tsx"),
+    ),
+    "javascript": (
+        "typescript-language-server --stdio",
+        "javascript",
+        (".js", ".jsx"),
+    ),
+    "rust": ("rust-analyzer", "rust", (".rs",)),
+    "c": ("clangd", "c", (".c", ".h")),
+    "cpp": ("clangd", "cpp", (".cc", ".cpp", ".hpp")),
+    "java": ("jdtls", "java", (".java",)),
+}
+# Seconds to wait for one answer; indexing a large module can take a while
+REQUEST_TIMEOUT = 60.0
+
+DEFINITIONS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(d)
+WHERE (d:Function OR d:Method) AND m.path STARTS WITH $prefix
+RETURN DISTINCT d.qualified_name AS qualified_name, labels(d)[0] AS label,
+       d.name AS name, m.path AS path, d.start_line AS start_line
+"""
+
+EXISTING_CALLS_QUERY = """
+MATCH (a)-[:CALLS]->(b)
+WHERE a.qualified_name IN $callers
+RETURN a.qualified_name AS caller, b.qualified_name AS callee
+"""
+
+
+class LanguageServerError(Exception):
+    """The server failed, answered with an error, or took too long."""
+
+
+class LanguageServerClient:
+    """A language server run as a subprocess, spoken to over stdio."""
+
+    def __init__(self, command: str, root: Path):
+        self.command = command
+        self.root = root
+        self._process: subprocess.Popen | None = None
+        self._messages: queue.Queue[dict[str, Any] | None] = queue.Queue()
+        self._next_id = 0
+
+    def __enter__(self) -> "LanguageServerClient":
+        try:
+            self._process = subprocess.Popen(
+                shlex.split(self.command),
+                cwd=self.root,
+                stdin=subprocess.PIPE,
+                stdout=subprocess.PIPE,
+                stderr=subprocess.DEVNULL,
+            )
+        except OSError as e:
+            raise LanguageServerError(f"cannot start {self.command}: {e}") from e
+        assert self._process.stdout is not None
+        threading.Thread(
+            target=self._read, args=(self._process.stdout,), daemon=True
+        ).start()
+        self.request(
+            "initialize",
+            {
+                "processId": None,
+                "rootUri": self.root.as_uri(),
+                "capabilities": {
+                    "textDocument": {
+                        "callHierarchy": {},
+                        "implementation": {},
+                    }
+                },
+            },
+        )
+        self.notify("initialized", {})
+        return self
+
+    def __exit__(self, *exc_info: Any) -> None:
+        if self._process is None:
+            return
+        try:
+            self.request("shutdown", None, timeout=5.0)
+            self.notify("exit", None)
+        except (LanguageServerError, OSError):
+            pass
+        try:
+            self._process.wait(timeout=5.0)
+        except subprocess.TimeoutExpired:
+            self._process.kill()
+        self._process = None
+
+    def request(
+        self, method: str, params: Any, timeout: float = REQUEST_TIMEOUT
+    ) -> Any:
+        self._next_id += 1
+        request_id = self._next_id
+        self._send(
+            {"jsonrpc": "2.0", "id": request_id, "method": method, "params": params}
+        )
+        while True:
+            try:
+                message = self._messages.get(timeout=timeout)
+            except queue.Empty as e:
+                raise LanguageServerError(f"{method} timed out") from e
+            if message is None:
+                raise LanguageServerError(f"{self.command} exited")
+            if "method" in message:
+                self._answer(message)
+            elif message.get("id") == request_id:
+                if "error" in message:
+                    raise LanguageServerError(
+                        f"{method}: {message['error'].get('message')}"
+                    )
+                return message.get("result")
+
+    def notify(self, method: str, params: Any) -> None:
+        self._send({"jsonrpc": "2.0", "method": method, "params": params})
+
+    def _answer(self, message: dict[str, Any]) -> None:
+        """Reply to what a server asks of the client, with nothing to offer."""
+        if "id" not in message:
+            return
+        result: Any = None
+        if message["method"] == "workspace/configuration":
+            result = [None] * len(message.get("params", {}).get("items", []))
+        self._send({"jsonrpc": "2.0", "id": message["id"], "result": result})
+
+    def _send(self, message: dict[str, Any]) -> None:
+        if self._process is None or self._process.stdin is None:
+            raise LanguageServerError(f"{self.command} is not running")
+        write_message(self._process.stdin, message)
+
+    def _read(self, stream: IO[bytes]) -> None:
+        try:
+            while (message := read_message(stream)) is not None:
+                self._messages.put(message)
+        except Exception as e:
+            logger.warning(f"Unreadable message from {self.command}: {e}")
+        self._messages.put(None)
+
+
+@dataclass
+class _Definition:
+    qualified_name: str
+    label: str
+    name: str
+    path: str
+    start_line: int
+
+
+class CallResolver:
+    """Adds the CALLS edges a language server finds between graph functions."""
+
+    def __init__(self, ingestor: Any, client: Any, repo_path: Path, language: str):
+        self.ingestor = ingestor
+        self.client = client
+        self.repo_path = repo_path
+        self.server = client.command.split()[0]
+        _, self.language_id, self.extensions = LANGUAGE_SERVERS[language]
+
+    def resolve(self, path_prefix: str = "") -> dict[str, int]:
+        definitions = [
+            _Definition(**row)
+            for row in self.ingestor.fetch_all(
+                DEFINITIONS_QUERY, {"prefix": path_prefix.strip("/")}
+            )
+            if row["path"].endswith(self.extensions) and row["start_line"]
+        ]
+        by_line = {(d.path, d.start_line): d for d in definitions}
+        existing = {
+            (row["caller"], row["callee"])
+            for row in self.ingestor.fetch_all(
+                EXISTING_CALLS_QUERY,
+                {"callers": [d.qualified_name for d in definitions]},
+            )
+        }
+        by_path: dict[str, list[_Definition]] = defaultdict(list)
+        for definition in definitions:
+            by_path[definition.path].append(definition)
+
+        stats = {"functions": 0, "calls": 0, "dynamic_calls": 0, "failed": 0}
+        for path, file_definitions in sorted(by_path.items()):
+            try:
+                lines = (self.repo_path / path).read_text(encoding="utf-8").splitlines()
+            except (OSError, UnicodeDecodeError) as e:
+                logger.warning(f"Skipping {path}: {e}")
+                continue
+            uri = (self.repo_path / path).as_uri()
+            self.client.notify(
+                "textDocument/didOpen",
+                {
+                    "textDocument": {
+                        "uri": uri,
+                        "languageId": self.language_id,
+                        "version": 1,
+                        "text": "\n".join(lines) + "\n",
+                    }
+                },
+            )
+            for caller in file_definitions:
+                try:
+                    callees = self._callees(uri, lines, caller, by_line)
+                except Exception as e:
+                    logger.debug(f"No call hierarchy for {caller.qualified_name}: {e}")
+                    stats["failed"] += 1
+                    continue
+                stats["functions"] += 1
+                for callee, dynamic in callees:
+                    key = (caller.qualified_name, callee.qualified_name)
+                    if key in existing or key[0] == key[1]:
+                        continue
+                    existing.add(key)
+                    properties = {"resolved_by": "lsp", "server": self.server}
+                    if dynamic:
+                        properties["dynamic"] = True
+                    self.ingestor.ensure_relationship_batch(
+                        (caller.label, "qualified_name", caller.qualified_name),
+                        "CALLS",
+                        (callee.label, "qualified_name", callee.qualified_name),
+                        properties,
+                    )
+                    stats["dynamic_calls" if dynamic else "calls"] += 1
+            self.client.notify("textDocument/didClose", {"textDocument": {"uri": uri}})
+        self.ingestor.flush_all()
+        return stats
+
+    def _callees(
+        self,
+        uri: str,
+        lines: list[str],
+        caller: _Definition,
+        by_line: dict[tuple[str, int], _Definition],
+    ) -> list[tuple[_Definition, bool]]:
+        """Graph definitions caller calls, each with whether it is dynamic."""
+        position = _name_position(lines, caller)
+        if position is None:
+            return []
+        items = self.client.request(
+            "textDocument/prepareCallHierarchy",
+            {"textDocument": {"uri": uri}, "position": position},
+        )
+        if not items:
+            return []
+        outgoing = self.client.request(
+            "callHierarchy/outgoingCalls", {"item": items[0]}
+        )
+        callees = []
+        for call in outgoing or []:
+            target = call["to"]
+            definition = self._definition_at(
+                target["uri"], target["selectionRange"]["start"]["line"], by_line
+            )
+            if definition is not None:
+                callees.append((definition, False))
+                continue
+            # Not a graph definition, e.g. an interface method: its
+            # implementations are what the call can reach
+            locations = self.client.request(
+                "textDocument/implementation",
+                {
+                    "textDocument": {"uri": target["uri"]},
+                    "position": target["selectionRange"]["start"],
+                },
+            )
+            for location in _locations(locations):
+                definition = self._definition_at(
+                    location["uri"], location["range"]["start"]["line"], by_line
+                )
+                if definition is not None:
+                    callees.append((definition, True))
+        return callees
+
+    def _definition_at(
+        self, uri: str, line: int, by_line: dict[tuple[str, int], _Definition]
+    ) -> _Definition | None:
+        path = Path(unquote(urlparse(uri).path))
+        if not path.is_relative_to(self.repo_path):
+            return None
+        relative = path.relative_to(self.repo_path).as_posix()
+        return by_line.get((relative, line + 1))
+
+
+def _name_position(lines: list[str], definition: _Definition) -> dict[str, int] | None:
+    """Where the name is on the definition's first line, in UTF-16 units."""
+    line_index = definition.start_line - 1
+    if not definition.name or not 0 <= line_index < len(lines):
+        return None
+    text = lines[line_index]
+    column = text.find(f"{definition.name}(")
+    if column < 0:
+        column = text.find(definition.name)
+    if column < 0:
+        return None
+    character = len(text[:column].encode("utf-16-le")) // 2
+    return {"line": line_index, "character": character}
+
+
+def _locations(result: Any) -> list[dict[str, Any]]:
+    """Locations from a Location, a list of them, or LocationLinks."""
+    if not result:
+        return []
+    if isinstance(result, dict):
+        result = [result]
+    return [
+        {"uri": r["targetUri"], "range": r["targetSelectionRange"]}
+        if "targetUri" in r
+        else r
+        for r in result
+    ]
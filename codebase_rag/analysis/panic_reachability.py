@@ -0,0 +1,228 @@
+"""Reachability of Go panics from exported functions, stopping at deferred recovers."""
+
+from collections import deque
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from loguru import logger
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+from ..utils.visibility import is_exported
+
+# Go functions with their panic/recover flags recorded during ingestion
+GO_FUNCTIONS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(f)
+WHERE (f:Function OR f:Method) AND m.path ENDS WITH '.go'
+RETURN DISTINCT f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       f.name AS name, m.path AS path, f.calls_panic AS calls_panic,
+       f.has_recover AS has_recover
+"""
+
+GO_CALL_EDGES_QUERY = """
+MATCH (caller)-[:CALLS]->(callee)
+WHERE (caller:Function OR caller:Method) AND (callee:Function OR callee:Method)
+RETURN DISTINCT caller.qualified_name AS caller, callee.qualified_name AS callee
+"""
+
+
+@dataclass
+class PanicPath:
+    """An exported function that can panic, with one call chain to a panic site."""
+
+    qualified_name: str
+    label: str
+    path: str
+    chain: list[str]  # From the exported function to the function calling panic
+
+    @property
+    def panics_directly(self) -> bool:
+        return len(self.chain) == 1
+
+    def to_dict(self) -> dict[str, Any]:
+        return {**asdict(self), "panics_directly": self.panics_directly}
+
+
+@dataclass
+class PanicCall:
+    line_number: int
+    value: str  # The argument as written, e.g. `"negative input"` or `err`
+
+
+@dataclass
+class DeferredCall:
+    """A `defer` statement; callee is empty when it defers a function literal."""
+
+    line_number: int
+    callee: str
+    recovers: bool
+
+
+class PanicReachabilityAnalyzer:
+    """Finds exported Go functions that can transitively reach an unrecovered panic."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def analyze(self, include_unexported: bool = False) -> list[PanicPath]:
+        rows = self.ingestor.fetch_all(GO_FUNCTIONS_QUERY)
+        edges = [
+            (row["caller"], row["callee"])
+            for row in self.ingestor.fetch_all(GO_CALL_EDGES_QUERY)
+        ]
+        return find_panic_paths(rows, edges, include_unexported)
+
+    def store_results(self, paths: list[PanicPath]) -> None:
+        """Mark functions with a panic path as may_panic, clearing stale marks first."""
+        self.ingestor.execute_write(
+            "MATCH (f) WHERE (f:Function OR f:Method) AND f.may_panic = true "
+            "SET f.may_panic = false"
+        )
+        for panic_path in paths:
+            self.ingestor.ensure_node_batch(
+                panic_path.label,
+                {"qualified_name": panic_path.qualified_name, "may_panic": True},
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Marked {len(paths)} functions as may_panic")
+
+
+def find_panic_paths(
+    rows: list[dict[str, Any]],
+    edges: list[tuple[str, str]],
+    include_unexported: bool = False,
+) -> list[PanicPath]:
+    """
+    Return exported functions that reach a panic without an intervening recover.
+
+    A function with a deferred recover absorbs panics raised by itself and by
+    everything it calls, so the search does not continue through it. Calls to
+    functions outside the graph (other modules, the standard library) are not
+    followed.
+    """
+    functions = {row["qualified_name"]: row for row in rows}
+    callees: dict[str, list[str]] = {}
+    for caller, callee in edges:
+        if caller in functions and callee in functions and caller != callee:
+            callees.setdefault(caller, []).append(callee)
+
+    results = []
+    for qualified_name, row in sorted(functions.items()):
+        if not include_unexported and not _is_exported_go(row):
+            continue
+        chain = _shortest_chain_to_panic(qualified_name, functions, callees)
+        if chain:
+            results.append(
+                PanicPath(
+                    qualified_name=qualified_name,
+                    label=row.get("label") or "Function",
+                    path=row.get("path") or "",
+                    chain=chain,
+                )
+            )
+    return sorted(results, key=lambda p: (len(p.chain), p.qualified_name))
+
+
+def detect_panic_and_recover(func_node: Node) -> tuple[bool, bool]:
+    """
+    Return whether a Go function calls panic and whether it defers a recover.
+
+    Only recover calls inside deferred function literals are counted, since
+    recover has no effect unless called directly by a deferred function.
+    """
+    calls_panic = has_recover = False
+    stack = list(func_node.children)
+    while stack:
+        node = stack.pop()
+        if node.type == "call_expression" and _callee_name(node) == "panic":
+            calls_panic = True
+        elif node.type == "defer_statement" and _defers_recover(node):
+            has_recover = True
+        stack.extend(node.children)
+    return calls_panic, has_recover
+
+
+def collect_panic_flow(func_node: Node) -> tuple[list[PanicCall], list[DeferredCall]]:
+    """The panic calls and defer statements of a Go function, in source order."""
+    panics, defers = [], []
+    stack = list(func_node.children)
+    while stack:
+        node = stack.pop()
+        line_number = node.start_point[0] + 1
+        if node.type == "call_expression" and _callee_name(node) == "panic":
+            arguments = node.child_by_field_name("arguments")
+            value = node_text(arguments)[1:-1].strip() if arguments is not None else ""
+            panics.append(PanicCall(line_number, value))
+        elif node.type == "defer_statement":
+            call = next(
+                (c for c in node.named_children if c.type == "call_expression"), None
+            )
+            function = call.child_by_field_name("function") if call else None
+            callee = (
+                node_text(function)
+                if function is not None and function.type != "func_literal"
+                else ""
+            )
+            defers.append(DeferredCall(line_number, callee, _defers_recover(node)))
+        stack.extend(node.children)
+    panics.sort(key=lambda panic: panic.line_number)
+    defers.sort(key=lambda defer: defer.line_number)
+    return panics, defers
+
+
+def _shortest_chain_to_panic(
+    start: str, functions: dict[str, dict[str, Any]], callees: dict[str, list[str]]
+) -> list[str] | None:
+    if functions[start].get("has_recover"):
+        return None
+    parents: dict[str, str | None] = {start: None}
+    queue = deque([start])
+    while queue:
+        current = queue.popleft()
+        if functions[current].get("calls_panic"):
+            chain = [current]
+            while parents[chain[-1]] is not None:
+                chain.append(parents[chain[-1]])  # type: ignore[arg-type]
+            return chain[::-1]
+        for callee in sorted(callees.get(current, [])):
+            if callee in parents or functions[callee].get("has_recover"):
+                continue
+            parents[callee] = current
+            queue.append(callee)
+    return None
+
+
+def _is_exported_go(row: dict[str, Any]) -> bool:
+    if not is_exported(row.get("name") or "", "go"):
+        return False
+    # Methods are only reachable from outside if their receiver type is exported
+    if row.get("label") == "Method":
+        parts = (row.get("qualified_name") or "").split(".")
+        return len(parts) < 2 or is_exported(parts[-2], "go")
+    return True
+
+
+def _callee_name(call: Node) -> str:
+    function = call.child_by_field_name("function")
+    if function is None or function.type != "identifier" or function.text is None:
+        return ""
+    return function.text.decode("utf-8")
+
+
+
+def _defers_recover(defer_node: Node) -> bool:
+    for call in defer_node.named_children:
+        if call.type != "call_expression":
+            continue
+        function = call.child_by_field_name("function")
+        if function is None or function.type != "func_literal":
+            continue
+        stack = list(function.children)
+        while stack:
+            node = stack.pop()
+            if node.type == "call_expression" and _callee_name(node) == "recover":
+                return True
+            # A recover in a further nested literal is not called by the deferred one
+            if node.type != "func_literal":
+                stack.extend(node.children)
+    return False
@@ -0,0 +1,352 @@
+"""Import of precise code-intelligence indexes (SCIP and LSIF).
+
+Compiler-backed indexers (scip-go, scip-typescript, scip-python, lsif-go,
+...) know exactly which definition every reference resolves to, where the
+tree-sitter passes have to guess from names and imports. Importing an index
+adds the CALLS, IMPLEMENTS and OVERRIDES edges it proves between functions,
+methods and classes already in the graph, and can prune CALLS edges it
+contradicts.
+
+SCIP indexes are protobuf messages; only the handful of fields used here are
+decoded, so no protobuf runtime is needed. LSIF dumps are JSON lines.
+"""
+
+import json
+from collections import defaultdict
+from collections.abc import Iterable
+from dataclasses import dataclass, field, replace
+from pathlib import Path, PurePosixPath
+from typing import Any
+from urllib.parse import unquote, urlparse
+
+from loguru import logger
+
+from ..utils.protobuf_wire import fields, int_field, packed_varints, string_field
+
+# SCIP SymbolRole bit marking the definition of a symbol
+SCIP_DEFINITION_ROLE = 0x1
+
+# Definitions in the indexed files, to anchor index positions to graph nodes
+DEFINITIONS_IN_PATHS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(d)
+WHERE (d:Function OR d:Method OR d:Class) AND m.path IN $paths
+RETURN DISTINCT d.qualified_name AS qualified_name, labels(d)[0] AS label,
+       m.path AS path, d.start_line AS start_line, d.end_line AS end_line
+"""
+
+# Drop CALLS edges between indexed files that the index does not confirm
+PRUNE_CALLS_QUERY = """
+UNWIND $callers AS row
+MATCH (caller {qualified_name: row.caller})-[r:CALLS]->(callee)
+WHERE (callee:Function OR callee:Method)
+  AND NOT callee.qualified_name IN row.confirmed
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(callee)
+WHERE m.path IN $paths
+DELETE r
+RETURN count(*) AS pruned
+"""
+
+
+@dataclass
+class Occurrence:
+    """A symbol's name appearing at a position of a file."""
+
+    symbol: str
+    path: str  # Relative to the indexed project root
+    line: int  # 1-based, as graph line numbers are
+    is_definition: bool = False
+
+
+@dataclass
+class PreciseIndex:
+    """What an index says about symbols, independent of its format."""
+
+    tool: str
+    occurrences: list[Occurrence] = field(default_factory=list)
+    # (implementing symbol, implemented symbol)
+    implementations: list[tuple[str, str]] = field(default_factory=list)
+
+    @property
+    def paths(self) -> list[str]:
+        return sorted({o.path for o in self.occurrences})
+
+
+@dataclass
+class _Definition:
+    qualified_name: str
+    label: str
+    start_line: int
+    end_line: int
+
+
+class PreciseIndexImporter:
+    """Adds the relationships proven by an index to the graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def import_index(
+        self, index: PreciseIndex, path_prefix: str = "", prune: bool = False
+    ) -> dict[str, int]:
+        """
+        Link index symbols to graph nodes and write the edges they prove.
+        path_prefix locates the indexed project inside the repository, for
+        indexes built in a subdirectory of a monorepo.
+        """
+        prefix = path_prefix.strip("/")
+        if prefix:
+            occurrences = [
+                replace(o, path=f"{prefix}/{o.path}") for o in index.occurrences
+            ]
+            index = replace(index, occurrences=occurrences)
+        paths = index.paths
+        rows = self.ingestor.fetch_all(DEFINITIONS_IN_PATHS_QUERY, {"paths": paths})
+        by_path: dict[str, list[_Definition]] = defaultdict(list)
+        for row in rows:
+            by_path[row["path"]].append(
+                _Definition(
+                    row["qualified_name"],
+                    row["label"],
+                    row["start_line"],
+                    row["end_line"],
+                )
+            )
+
+        # Index symbols defined on the first line of a graph definition
+        nodes: dict[str, _Definition] = {}
+        for occurrence in index.occurrences:
+            if occurrence.is_definition and occurrence.symbol not in nodes:
+                node = _innermost(by_path[occurrence.path], occurrence.line)
+                if node is not None and node.start_line == occurrence.line:
+                    nodes[occurrence.symbol] = node
+
+        calls: dict[str, set[str]] = defaultdict(set)
+        labels: dict[str, str] = {}
+        for occurrence in index.occurrences:
+            callee = nodes.get(occurrence.symbol)
+            if occurrence.is_definition or callee is None:
+                continue
+            if callee.label not in ("Function", "Method"):
+                continue
+            caller = _innermost(
+                by_path[occurrence.path], occurrence.line, ("Function", "Method")
+            )
+            if caller is None or caller.qualified_name == callee.qualified_name:
+                continue
+            calls[caller.qualified_name].add(callee.qualified_name)
+            labels[caller.qualified_name] = caller.label
+            labels[callee.qualified_name] = callee.label
+
+        properties = {"precise": True, "indexer": index.tool}
+        call_count = 0
+        for caller_qn, callee_qns in calls.items():
+            for callee_qn in sorted(callee_qns):
+                self.ingestor.ensure_relationship_batch(
+                    (labels[caller_qn], "qualified_name", caller_qn),
+                    "CALLS",
+                    (labels[callee_qn], "qualified_name", callee_qn),
+                    properties,
+                )
+                call_count += 1
+
+        implementation_count = 0
+        for implementing, implemented in index.implementations:
+            child, parent = nodes.get(implementing), nodes.get(implemented)
+            if child is None or parent is None or child.label != parent.label:
+                continue
+            rel_type = "IMPLEMENTS" if child.label == "Class" else "OVERRIDES"
+            self.ingestor.ensure_relationship_batch(
+                (child.label, "qualified_name", child.qualified_name),
+                rel_type,
+                (parent.label, "qualified_name", parent.qualified_name),
+                properties,
+            )
+            implementation_count += 1
+        self.ingestor.flush_all()
+
+        pruned = 0
+        if prune:
+            # Every function in the indexed files is a caller the index covers
+            callers = []
+            for definitions in by_path.values():
+                for d in definitions:
+                    if d.label != "Class":
+                        qn = d.qualified_name
+                        confirmed = sorted(calls.get(qn, ()))
+                        callers.append({"caller": qn, "confirmed": confirmed})
+            result = self.ingestor.fetch_all(
+                PRUNE_CALLS_QUERY, {"callers": callers, "paths": paths}
+            )
+            pruned = result[0]["pruned"] if result else 0
+
+        return {
+            "documents": len(paths),
+            "linked_symbols": len(nodes),
+            "calls": call_count,
+            "implementations": implementation_count,
+            "pruned_calls": pruned,
+        }
+
+
+def load_index(path: Path, index_format: str = "auto") -> PreciseIndex:
+    """Read a SCIP (.scip) or LSIF (.lsif, .json, .jsonl) index file."""
+    if index_format == "auto":
+        index_format = "scip" if path.suffix == ".scip" else "lsif"
+    if index_format == "scip":
+        return parse_scip(path.read_bytes())
+    if index_format == "lsif":
+        with open(path, encoding="utf-8") as f:
+            return parse_lsif(f)
+    raise ValueError(f"Unknown index format '{index_format}'")
+
+
+def parse_scip(data: bytes) -> PreciseIndex:
+    """Decode the documents, occurrences and relationships of a SCIP Index."""
+    index = PreciseIndex(tool="scip")
+    for number, value in fields(data):
+        if number == 1 and isinstance(value, bytes):  # Index.metadata
+            for meta_number, meta_value in fields(value):
+                if meta_number == 2 and isinstance(meta_value, bytes):  # tool_info
+                    name = string_field(meta_value, 1)
+                    index.tool = name or index.tool
+        elif number == 2 and isinstance(value, bytes):  # Index.documents
+            _parse_scip_document(value, index)
+    return index
+
+
+def _parse_scip_document(data: bytes, index: PreciseIndex) -> None:
+    path = string_field(data, 1)  # Document.relative_path
+    for number, value in fields(data):
+        if not isinstance(value, bytes):
+            continue
+        if number == 2:  # Document.occurrences
+            symbol, roles, start_line = "", 0, None
+            for occ_number, occ_value in fields(value):
+                if occ_number == 1:  # range: [start_line, start_char, ...]
+                    if isinstance(occ_value, bytes):
+                        start_line = next(iter(packed_varints(occ_value)), None)
+                    elif start_line is None:
+                        start_line = occ_value
+                elif occ_number == 2 and isinstance(occ_value, bytes):
+                    symbol = occ_value.decode("utf-8")
+                elif occ_number == 3 and isinstance(occ_value, int):
+                    roles = occ_value
+            # Locals are scoped to one document and never graph nodes
+            if symbol and not symbol.startswith("local ") and start_line is not None:
+                index.occurrences.append(
+                    Occurrence(
+                        symbol,
+                        path,
+                        start_line + 1,
+                        bool(roles & SCIP_DEFINITION_ROLE),
+                    )
+                )
+        elif number == 3:  # Document.symbols (SymbolInformation)
+            symbol = string_field(value, 1)
+            for info_number, info_value in fields(value):
+                if info_number == 4 and isinstance(info_value, bytes):
+                    related = string_field(info_value, 1)
+                    # Relationship.is_implementation
+                    if int_field(info_value, 3) and related:
+                        index.implementations.append((symbol, related))
+
+
+def parse_lsif(lines: Iterable[str]) -> PreciseIndex:
+    """Resolve an LSIF graph dump into occurrences of result sets."""
+    index = PreciseIndex(tool="lsif")
+    project_root = ""
+    documents: dict[int, str] = {}
+    range_lines: dict[int, int] = {}
+    range_documents: dict[int, int] = {}
+    next_of: dict[int, int] = {}
+    # resultSet -> definitionResult / implementationResult
+    definition_results: dict[int, int] = {}
+    implementation_results: dict[int, int] = {}
+    result_items: dict[int, list[int]] = defaultdict(list)
+
+    for line in lines:
+        line = line.strip()
+        if not line:
+            continue
+        element = json.loads(line)
+        label = element.get("label")
+        if element.get("type") == "vertex":
+            if label == "metaData":
+                project_root = element.get("projectRoot", "")
+                tool = element.get("toolInfo", {}).get("name")
+                index.tool = tool or index.tool
+            elif label == "document":
+                documents[element["id"]] = element["uri"]
+            elif label == "range":
+                range_lines[element["id"]] = element["start"]["line"] + 1
+            continue
+
+        in_vertices = element.get("inVs") or [element.get("inV")]
+        if label == "contains":
+            for vertex in in_vertices:
+                range_documents[vertex] = element["outV"]
+        elif label == "next":
+            next_of[element["outV"]] = element["inV"]
+        elif label == "textDocument/definition":
+            definition_results[element["outV"]] = element["inV"]
+        elif label == "textDocument/implementation":
+            implementation_results[element["outV"]] = element["inV"]
+        elif label == "item":
+            result_items[element["outV"]].extend(in_vertices)
+
+    def result_set(vertex: int) -> int:
+        seen = set()
+        while vertex in next_of and vertex not in seen:
+            seen.add(vertex)
+            vertex = next_of[vertex]
+        return vertex
+
+    definitions = {
+        range_id
+        for result in definition_results.values()
+        for range_id in result_items[result]
+    }
+    for range_id, line_number in range_lines.items():
+        document = range_documents.get(range_id)
+        symbol = result_set(range_id)
+        if document is None or symbol == range_id:
+            continue  # Not part of any result set, e.g. a bare range
+        index.occurrences.append(
+            Occurrence(
+                f"lsif:{symbol}",
+                _relative_path(documents[document], project_root),
+                line_number,
+                range_id in definitions,
+            )
+        )
+    for symbol, result in implementation_results.items():
+        for range_id in result_items[result]:
+            if range_id in range_lines:
+                index.implementations.append(
+                    (f"lsif:{result_set(range_id)}", f"lsif:{result_set(symbol)}")
+                )
+    return index
+
+
+def _innermost(
+    definitions: list[_Definition],
+    line: int,
+    labels: tuple[str, ...] = ("Function", "Method", "Class"),
+) -> _Definition | None:
+    enclosing = [
+        d
+        for d in definitions
+        if d.label in labels and d.start_line <= line <= d.end_line
+    ]
+    if not enclosing:
+        return None
+    return min(enclosing, key=lambda d: d.end_line - d.start_line)
+
+
+def _relative_path(uri: str, project_root: str) -> str:
+    path = PurePosixPath(unquote(urlparse(uri).path))
+    root = PurePosixPath(unquote(urlparse(project_root).path))
+    if project_root and path.is_relative_to(root):
+        return path.relative_to(root).as_posix()
+    logger.debug(f"LSIF document {uri} is outside the project root")
+    return path.as_posix().lstrip("/")
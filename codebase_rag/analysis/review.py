@@ -0,0 +1,352 @@
+"""Pull request review: graph context for changed symbols and review comments.
+
+The graph is expected to reflect the head of the change, so line ranges of
+functions and methods line up with the new side of the diff.
+"""
+
+import re
+import subprocess
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+# Functions and methods defined in the touched modules, with their line ranges
+SYMBOLS_IN_PATHS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f)
+WHERE (f:Function OR f:Method) AND m.path IN $paths
+RETURN DISTINCT f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       m.path AS path, f.start_line AS start_line, f.end_line AS end_line,
+       f.cyclomatic_complexity AS complexity
+"""
+
+# Callers, tests and endpoints around each changed symbol
+SYMBOL_CONTEXT_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (f {qualified_name: qn})
+WHERE f:Function OR f:Method
+OPTIONAL MATCH (caller)-[:CALLS]->(f)
+WITH f, collect(DISTINCT caller.qualified_name) AS callers
+OPTIONAL MATCH (test)-[:TESTS]->(f)
+WITH f, callers, collect(DISTINCT test.qualified_name) AS tests
+OPTIONAL MATCH (f)-[:COVERED_BY]->(covering)
+WITH f, callers, tests + collect(DISTINCT covering.qualified_name) AS tests
+OPTIONAL MATCH (e:Endpoint)-[:HANDLED_BY]->(f)
+WITH f, callers, tests, collect(DISTINCT e.method + ' ' + e.route) AS endpoints
+OPTIONAL MATCH (c:Crash)-[:CRASHED_IN]->(f)
+WITH f, callers, tests, endpoints, collect(DISTINCT c) AS crash_nodes
+RETURN f.qualified_name AS qualified_name, callers, tests, endpoints,
+       [c IN crash_nodes | {short_id: c.short_id, title: c.title,
+                            count: c.count, url: c.url}] AS crashes
+"""
+
+# CODEOWNERS owners of the touched files
+OWNERS_QUERY = """
+MATCH (file:File)-[:OWNED_BY]->(owner:Team|User)
+WHERE file.path IN $paths
+RETURN file.path AS path, collect(DISTINCT owner.name) AS owners
+"""
+
+HUNK_HEADER = re.compile(r"^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@")
+
+# Callers beyond this many make a change worth a blast-radius note
+WIDE_IMPACT_CALLERS = 5
+COMPLEX_FUNCTION = 10
+# Crash reports seen this often make a change to the crashing code a warning
+HIGH_TRAFFIC_CRASH_EVENTS = 100
+
+
+@dataclass
+class FileDiff:
+    """The lines one file gains or loses in a unified diff."""
+
+    path: str  # New path; the old path for deleted files
+    old_path: str | None = None
+    added_lines: set[int] = field(default_factory=set)
+    # New-side line numbers that removed lines used to precede
+    removed_at: set[int] = field(default_factory=set)
+    is_deleted: bool = False
+
+
+@dataclass
+class ChangedSymbol:
+    """A function or method whose body overlaps the changed lines."""
+
+    qualified_name: str
+    label: str
+    path: str
+    start_line: int
+    end_line: int
+    first_changed_line: int  # Where comments on the symbol are anchored
+    complexity: int = 0
+    callers: list[str] = field(default_factory=list)
+    tests: list[str] = field(default_factory=list)
+    endpoints: list[str] = field(default_factory=list)
+    owners: list[str] = field(default_factory=list)
+    # Sentry issues with a frame in the symbol: short_id, title, count, url
+    crashes: list[dict[str, Any]] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class ReviewComment:
+    """A comment to leave on one line of the new side of the diff."""
+
+    path: str
+    line: int
+    severity: str  # "warning" or "info"
+    body: str
+    symbol: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class ReviewReport:
+    """Changed symbols with their graph context, and the comments they raise."""
+
+    files: list[str]
+    symbols: list[ChangedSymbol]
+    comments: list[ReviewComment]
+    reviewers: list[str]
+
+    def summary(self) -> str:
+        """A short overview suitable for the body of a posted review."""
+        untested = sum(1 for s in self.symbols if not s.tests)
+        lines = [
+            f"Changed {len(self.symbols)} function(s) in {len(self.files)} file(s); "
+            f"{untested} without tests.",
+        ]
+        if self.reviewers:
+            lines.append(f"Suggested reviewers: {', '.join(self.reviewers)}")
+        return "\n\n".join(lines)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "files": self.files,
+            "reviewers": self.reviewers,
+            "summary": self.summary(),
+            "symbols": [s.to_dict() for s in self.symbols],
+            "comments": [c.to_dict() for c in self.comments],
+        }
+
+
+class ReviewAssistant:
+    """Builds review context and comments for a diff from the code graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def review(self, diffs: list[FileDiff]) -> ReviewReport:
+        paths = sorted({d.path for d in diffs if not d.is_deleted})
+        owners = {
+            row["path"]: sorted(row["owners"])
+            for row in self.ingestor.fetch_all(OWNERS_QUERY, {"paths": paths})
+        }
+        symbol_rows = self.ingestor.fetch_all(SYMBOLS_IN_PATHS_QUERY, {"paths": paths})
+        symbols = find_changed_symbols(diffs, symbol_rows)
+        if symbols:
+            context_rows = self.ingestor.fetch_all(
+                SYMBOL_CONTEXT_QUERY,
+                {"qualified_names": [s.qualified_name for s in symbols]},
+            )
+            context = {row["qualified_name"]: row for row in context_rows}
+            for symbol in symbols:
+                row = context.get(symbol.qualified_name, {})
+                symbol.callers = sorted(row.get("callers") or [])
+                symbol.tests = sorted(set(row.get("tests") or []))
+                symbol.endpoints = sorted(row.get("endpoints") or [])
+                symbol.crashes = sorted(
+                    row.get("crashes") or [], key=lambda c: -(c["count"] or 0)
+                )
+                symbol.owners = owners.get(symbol.path, [])
+
+        reviewers = sorted({owner for names in owners.values() for owner in names})
+        return ReviewReport(
+            files=sorted(d.path for d in diffs),
+            symbols=symbols,
+            comments=generate_comments(symbols),
+            reviewers=reviewers,
+        )
+
+
+def parse_unified_diff(text: str) -> list[FileDiff]:
+    """Parse `git diff` output into per-file added and removed line numbers."""
+    diffs: list[FileDiff] = []
+    current: FileDiff | None = None
+    new_line = old_left = new_left = 0
+    for line in text.splitlines():
+        if current is not None and (old_left > 0 or new_left > 0):
+            # Inside a hunk; its header says how many lines belong to it
+            if line.startswith("+"):
+                current.added_lines.add(new_line)
+                new_line += 1
+                new_left -= 1
+            elif line.startswith("-"):
+                current.removed_at.add(new_line)
+                old_left -= 1
+            elif not line.startswith("\\"):
+                new_line += 1
+                old_left -= 1
+                new_left -= 1
+        elif line.startswith("--- "):
+            old_path = _strip_prefix(line[4:])
+            current = FileDiff(path=old_path or "", old_path=old_path)
+            diffs.append(current)
+        elif line.startswith("+++ ") and current is not None:
+            new_path = _strip_prefix(line[4:])
+            if new_path is None:
+                current.is_deleted = True
+            else:
+                current.path = new_path
+        elif (match := HUNK_HEADER.match(line)) and current is not None:
+            old_left = int(match.group(2) or 1)
+            new_line = int(match.group(3))
+            new_left = int(match.group(4) or 1)
+    return diffs
+
+
+def diff_between_refs(repo_path: Path, base: str, head: str) -> str:
+    """Run `git diff` for the changes a branch at `head` makes on top of `base`."""
+    result = subprocess.run(
+        ["git", "-C", str(repo_path), "diff", "--no-color", f"{base}...{head}"],
+        capture_output=True,
+        text=True,
+        check=True,
+    )
+    return result.stdout
+
+
+def staged_diff(repo_path: Path) -> str:
+    """Run `git diff` for the changes staged for the next commit."""
+    result = subprocess.run(
+        ["git", "-C", str(repo_path), "diff", "--cached", "--no-color"],
+        capture_output=True,
+        text=True,
+        check=True,
+    )
+    return result.stdout
+
+
+def find_changed_symbols(
+    diffs: list[FileDiff], symbol_rows: list[dict[str, Any]]
+) -> list[ChangedSymbol]:
+    """Match changed lines to the functions and methods that contain them.
+
+    Removed lines count against the symbol spanning the line that now sits
+    where they were.
+    """
+    changed_lines: dict[str, set[int]] = defaultdict(set)
+    for diff in diffs:
+        if not diff.is_deleted:
+            changed_lines[diff.path] |= diff.added_lines | diff.removed_at
+
+    symbols = []
+    for row in symbol_rows:
+        start, end = row.get("start_line"), row.get("end_line")
+        if start is None or end is None:
+            continue
+        touched = [n for n in changed_lines.get(row["path"], ()) if start <= n <= end]
+        if not touched:
+            continue
+        symbols.append(
+            ChangedSymbol(
+                qualified_name=row["qualified_name"],
+                label=row["label"],
+                path=row["path"],
+                start_line=start,
+                end_line=end,
+                first_changed_line=min(touched),
+                complexity=row.get("complexity") or 0,
+            )
+        )
+    symbols.sort(key=lambda s: (s.path, s.start_line))
+    return symbols
+
+
+def generate_comments(symbols: list[ChangedSymbol]) -> list[ReviewComment]:
+    """Turn graph context into review comments, anchored at each symbol's change."""
+    comments = []
+    for symbol in symbols:
+        name = symbol.qualified_name.rsplit(".", 1)[-1]
+        findings = []
+        if not symbol.tests and (symbol.callers or symbol.endpoints):
+            findings.append(
+                (
+                    "warning",
+                    f"`{name}` changed but no tests exercise it; "
+                    f"{_dependents_phrase(symbol)} rely on it.",
+                )
+            )
+        if len(symbol.callers) >= WIDE_IMPACT_CALLERS:
+            findings.append(
+                (
+                    "info",
+                    f"`{name}` has {len(symbol.callers)} callers; check that "
+                    f"{sample_names(symbol.callers)} still behave as expected.",
+                )
+            )
+        if symbol.endpoints:
+            findings.append(
+                (
+                    "info",
+                    f"`{name}` handles {sample_names(symbol.endpoints)}; "
+                    "API clients see this change.",
+                )
+            )
+        if symbol.crashes:
+            events = sum(crash["count"] or 0 for crash in symbol.crashes)
+            findings.append(
+                (
+                    "warning" if events >= HIGH_TRAFFIC_CRASH_EVENTS else "info",
+                    f"`{name}` appears in {len(symbol.crashes)} crash report(s) "
+                    f"with {events} events, e.g. "
+                    f"{sample_names([c['short_id'] for c in symbol.crashes])}; check "
+                    "whether this change affects them.",
+                )
+            )
+        if symbol.complexity >= COMPLEX_FUNCTION:
+            findings.append(
+                (
+                    "info",
+                    f"`{name}` has cyclomatic complexity {symbol.complexity}; "
+                    "consider splitting it while it is being changed.",
+                )
+            )
+        comments.extend(
+            ReviewComment(
+                path=symbol.path,
+                line=symbol.first_changed_line,
+                severity=severity,
+                body=body,
+                symbol=symbol.qualified_name,
+            )
+            for severity, body in findings
+        )
+    return comments
+
+
+def _strip_prefix(path: str) -> str | None:
+    path = path.split("\t", 1)[0]
+    if path == "/dev/null":
+        return None
+    return path[2:] if path[:2] in ("a/", "b/") else path
+
+
+def _dependents_phrase(symbol: ChangedSymbol) -> str:
+    parts = []
+    if symbol.callers:
+        parts.append(f"{len(symbol.callers)} caller(s)")
+    if symbol.endpoints:
+        parts.append(f"{len(symbol.endpoints)} endpoint(s)")
+    return " and ".join(parts)
+
+
+def sample_names(names: list[str], limit: int = 3) -> str:
+    shown = ", ".join(f"`{n}`" for n in names[:limit])
+    if len(names) > limit:
+        shown += f" and {len(names) - limit} more"
+    return shown
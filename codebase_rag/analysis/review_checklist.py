@@ -0,0 +1,203 @@
+"""Reviewer checklists tailored to a diff from what the graph knows about it."""
+
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+from .review import (
+    HIGH_TRAFFIC_CRASH_EVENTS,
+    WIDE_IMPACT_CALLERS,
+    ReviewReport,
+    sample_names,
+)
+
+# Hotspot scores are relative to the top hotspot; above this a change is risky
+HOTSPOT_THRESHOLD = 0.5
+
+# Categories in the order reviewers should work through them
+CATEGORIES = (
+    "API compatibility",
+    "Tests",
+    "Hotspots",
+    "Architecture",
+    "Crashes",
+    "Blast radius",
+    "Ownership",
+)
+
+# Hotspot scores and flaky tests of the changed symbols
+SYMBOL_FACTS_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (f {qualified_name: qn})
+WHERE f:Function OR f:Method
+OPTIONAL MATCH (t)-[:TESTS]->(f)
+WHERE t.is_flaky = true
+RETURN f.qualified_name AS qualified_name, f.hotspot_score AS hotspot_score,
+       f.churn AS churn, collect(DISTINCT t.qualified_name) AS flaky_tests
+"""
+
+# Import cycles the touched modules take part in
+MODULE_CYCLES_QUERY = """
+MATCH (m:Module)-[:CIRCULAR_DEPENDENCY]-(other:Module)
+WHERE m.path IN $paths
+RETURN m.path AS path, collect(DISTINCT other.qualified_name) AS cycle_with
+"""
+
+
+@dataclass
+class ChecklistItem:
+    """One thing for the reviewer to check off."""
+
+    category: str
+    text: str
+    priority: str = "normal"  # "high" or "normal"
+    refs: list[str] = field(default_factory=list)  # Symbols, routes or crash ids
+
+
+@dataclass
+class Checklist:
+    """Checklist items for a change, grouped by category when rendered."""
+
+    items: list[ChecklistItem]
+    files: list[str]
+
+    def to_dict(self) -> dict[str, Any]:
+        return {"files": self.files, "items": [asdict(item) for item in self.items]}
+
+    def to_markdown(self) -> str:
+        lines = [
+            "## Review checklist",
+            "",
+            f"{len(self.items)} item(s) for {len(self.files)} changed file(s).",
+        ]
+        if not self.items:
+            lines += ["", "The graph has nothing specific to check for this change."]
+        for category in CATEGORIES:
+            items = [item for item in self.items if item.category == category]
+            if not items:
+                continue
+            lines += ["", f"### {category}", ""]
+            for item in items:
+                marker = "**(high)** " if item.priority == "high" else ""
+                lines.append(f"- [ ] {marker}{item.text}")
+        return "\n".join(lines) + "\n"
+
+
+class ReviewChecklistBuilder:
+    """Derives checklist items from a review report plus hotspot and cycle data."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def build(self, report: ReviewReport) -> Checklist:
+        facts: dict[str, dict[str, Any]] = {}
+        if report.symbols:
+            rows = self.ingestor.fetch_all(
+                SYMBOL_FACTS_QUERY,
+                {"qualified_names": [s.qualified_name for s in report.symbols]},
+            )
+            facts = {row["qualified_name"]: row for row in rows}
+        cycles = {
+            row["path"]: sorted(row["cycle_with"])
+            for row in self.ingestor.fetch_all(
+                MODULE_CYCLES_QUERY, {"paths": report.files}
+            )
+            if row["cycle_with"]
+        }
+        return build_checklist(report, facts, cycles)
+
+
+def build_checklist(
+    report: ReviewReport,
+    facts: dict[str, dict[str, Any]],
+    cycles: dict[str, list[str]],
+) -> Checklist:
+    """Turn review context and graph facts into checklist items."""
+    items = []
+    for symbol in report.symbols:
+        name = f"`{symbol.qualified_name.rsplit('.', 1)[-1]}`"
+        fact = facts.get(symbol.qualified_name, {})
+        if symbol.endpoints:
+            items.append(
+                ChecklistItem(
+                    "API compatibility",
+                    f"{name} serves {sample_names(symbol.endpoints)}: confirm request "
+                    "and response contracts still hold for existing clients.",
+                    "high",
+                    symbol.endpoints,
+                )
+            )
+        if not symbol.tests:
+            dependents = bool(symbol.callers or symbol.endpoints)
+            items.append(
+                ChecklistItem(
+                    "Tests",
+                    f"{name} has no tests linked in the graph: ask for a test or "
+                    "verify it manually.",
+                    "high" if dependents else "normal",
+                    [symbol.qualified_name],
+                )
+            )
+        if fact.get("flaky_tests"):
+            items.append(
+                ChecklistItem(
+                    "Tests",
+                    f"{name} is exercised by flaky tests "
+                    f"{sample_names(fact['flaky_tests'])}: do not rely on a single "
+                    "green run.",
+                    refs=fact["flaky_tests"],
+                )
+            )
+        if (fact.get("hotspot_score") or 0) >= HOTSPOT_THRESHOLD:
+            items.append(
+                ChecklistItem(
+                    "Hotspots",
+                    f"{name} is a hotspot (score {fact['hotspot_score']:.2f}, "
+                    f"{fact.get('churn') or 0} commits to its file): review edge "
+                    "cases closely and consider whether it should be split.",
+                    refs=[symbol.qualified_name],
+                )
+            )
+        if symbol.crashes:
+            events = sum(crash["count"] or 0 for crash in symbol.crashes)
+            crash_ids = [crash["short_id"] for crash in symbol.crashes]
+            items.append(
+                ChecklistItem(
+                    "Crashes",
+                    f"{name} appears in crash reports {sample_names(crash_ids)} "
+                    f"({events} events): check whether the change fixes them or "
+                    "adds new failure modes.",
+                    "high" if events >= HIGH_TRAFFIC_CRASH_EVENTS else "normal",
+                    crash_ids,
+                )
+            )
+        if len(symbol.callers) >= WIDE_IMPACT_CALLERS:
+            items.append(
+                ChecklistItem(
+                    "Blast radius",
+                    f"{name} has {len(symbol.callers)} callers: spot-check "
+                    f"{sample_names(symbol.callers)}.",
+                    refs=symbol.callers,
+                )
+            )
+
+    for path, modules in sorted(cycles.items()):
+        items.append(
+            ChecklistItem(
+                "Architecture",
+                f"`{path}` is in an import cycle with {sample_names(modules)}: make "
+                "sure the change does not add to it.",
+                refs=modules,
+            )
+        )
+    if report.reviewers:
+        items.append(
+            ChecklistItem(
+                "Ownership",
+                f"Get a review from the code owners: {', '.join(report.reviewers)}.",
+                refs=report.reviewers,
+            )
+        )
+
+    order = {category: index for index, category in enumerate(CATEGORIES)}
+    items.sort(key=lambda item: (order[item.category], item.priority != "high"))
+    return Checklist(items=items, files=report.files)
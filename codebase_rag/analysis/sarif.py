@@ -0,0 +1,392 @@
+"""SARIF 2.1.0 output for analysis findings.
+
+Code-scanning dashboards and editors read SARIF natively, so findings can be
+uploaded with existing tooling (e.g. github/codeql-action/upload-sarif) and
+show up as pull request annotations.
+"""
+
+import hashlib
+from dataclasses import dataclass, field
+from typing import Any
+
+from .dead_code import DeadSymbol
+from .import_cycles import PackageCycle
+from .layering import LayerViolation
+from .smells import (
+    DEEP_NESTING,
+    GOD_CLASS,
+    LONG_FUNCTION,
+    LONG_PARAMETER_LIST,
+    CodeSmell,
+)
+from .taint import CWE_BY_SINK, TaintPath
+
+SARIF_SCHEMA = "https://json.schemastore.org/sarif-2.1.0.json"
+SARIF_VERSION = "2.1.0"
+TOOL_NAME = "graph-code"
+TOOL_URI = "https://github.com/vitali87/code-graph-rag"
+
+# Vulnerability severities mapped to SARIF result levels
+SEVERITY_LEVELS = {
+    "critical": "error",
+    "high": "error",
+    "medium": "warning",
+    "low": "note",
+}
+
+
+@dataclass(frozen=True)
+class SarifRule:
+    """A kind of finding, listed once in the tool's rule table."""
+
+    id: str
+    name: str  # PascalCase, as SARIF viewers expect
+    description: str
+    level: str = "warning"  # "error", "warning" or "note"
+    tags: tuple[str, ...] = ()
+
+
+@dataclass
+class SarifLocation:
+    """A place a finding points to besides its own, with what happens there."""
+
+    path: str
+    line: int | None = None
+    message: str = ""
+
+
+@dataclass
+class SarifFinding:
+    """One result: a rule violated at a location in the repository."""
+
+    rule: SarifRule
+    message: str
+    path: str  # Repository-relative
+    line: int | None = None
+    level: str | None = None  # Overrides the rule's default level
+    symbol: str = ""  # Identifies the finding regardless of line, for fingerprints
+    properties: dict[str, Any] = field(default_factory=dict)
+    related: list[SarifLocation] = field(default_factory=list)
+    # Steps from where data enters to the finding, shown as a code flow
+    flow: list[SarifLocation] = field(default_factory=list)
+
+
+SMELL_RULES = {
+    GOD_CLASS: SarifRule(
+        "smells/god-class",
+        "GodClass",
+        "Class has too many methods or fields.",
+        tags=("maintainability",),
+    ),
+    LONG_FUNCTION: SarifRule(
+        "smells/long-function",
+        "LongFunction",
+        "Function or method body is longer than the configured limit.",
+        tags=("maintainability",),
+    ),
+    LONG_PARAMETER_LIST: SarifRule(
+        "smells/long-parameter-list",
+        "LongParameterList",
+        "Function or method takes more parameters than the configured limit.",
+        tags=("maintainability",),
+    ),
+    DEEP_NESTING: SarifRule(
+        "smells/deep-nesting",
+        "DeepNesting",
+        "Control flow is nested deeper than the configured limit.",
+        tags=("maintainability",),
+    ),
+}
+
+UNCHECKED_ERROR_RULE = SarifRule(
+    "go/unchecked-error",
+    "UncheckedError",
+    "Error returned by a call is discarded.",
+    tags=("reliability", "go"),
+)
+
+DEAD_CODE_RULE = SarifRule(
+    "dead-code/unreachable",
+    "UnreachableCode",
+    "Function or type is not reachable from entrypoints, exports or tests.",
+    level="note",
+    tags=("maintainability",),
+)
+
+LAYERING_RULE = SarifRule(
+    "architecture/layering",
+    "LayeringViolation",
+    "Import or call into a layer the caller's layer may not use.",
+    tags=("architecture",),
+)
+
+IMPORT_CYCLE_RULE = SarifRule(
+    "architecture/import-cycle",
+    "ImportCycle",
+    "Packages import each other, directly or transitively.",
+    tags=("architecture",),
+)
+
+
+def build_sarif_log(findings: list[SarifFinding]) -> dict[str, Any]:
+    """Assemble a SARIF log with a single run covering all findings."""
+    rules: list[SarifRule] = []
+    rule_index: dict[str, int] = {}
+    results = []
+    for finding in findings:
+        if finding.rule.id not in rule_index:
+            rule_index[finding.rule.id] = len(rules)
+            rules.append(finding.rule)
+        result: dict[str, Any] = {
+            "ruleId": finding.rule.id,
+            "ruleIndex": rule_index[finding.rule.id],
+            "level": finding.level or finding.rule.level,
+            "message": {"text": finding.message},
+            "locations": [
+                {"physicalLocation": _physical_location(finding.path, finding.line)}
+            ],
+            # Stable across line shifts so dashboards can track a finding
+            "partialFingerprints": {"graphCodeFinding/v1": _fingerprint(finding)},
+        }
+        if finding.related:
+            result["relatedLocations"] = [
+                {"id": number, **_location(related)}
+                for number, related in enumerate(finding.related, 1)
+            ]
+        if finding.flow:
+            steps = [{"location": _location(step)} for step in finding.flow]
+            result["codeFlows"] = [{"threadFlows": [{"locations": steps}]}]
+        if finding.properties:
+            result["properties"] = finding.properties
+        results.append(result)
+
+    driver = {
+        "name": TOOL_NAME,
+        "informationUri": TOOL_URI,
+        "rules": [_rule_descriptor(rule) for rule in rules],
+    }
+    return {
+        "$schema": SARIF_SCHEMA,
+        "version": SARIF_VERSION,
+        "runs": [{"tool": {"driver": driver}, "results": results}],
+    }
+
+
+def smell_findings(smells: list[CodeSmell]) -> list[SarifFinding]:
+    """Findings for smells detected by SmellAnalyzer."""
+    return [
+        SarifFinding(
+            rule=SMELL_RULES[smell.smell],
+            message=(
+                f"{smell.label} '{smell.qualified_name}' has {smell.metric} "
+                f"{smell.value} (limit {smell.threshold})."
+            ),
+            path=smell.path,
+            line=smell.start_line,
+            symbol=smell.qualified_name,
+            properties={"metric": smell.metric, "value": smell.value},
+        )
+        for smell in smells
+    ]
+
+
+def unchecked_error_findings(rows: list[dict[str, Any]]) -> list[SarifFinding]:
+    """Findings for rows returned by UncheckedErrorAnalyzer."""
+    return [
+        SarifFinding(
+            rule=UNCHECKED_ERROR_RULE,
+            message=(
+                f"Error from {row['call']} is discarded ({row['kind']}) "
+                f"in {row['owner']}."
+            ),
+            path=row["path"],
+            line=row["line_number"],
+            # Likely failures (I/O, network, database) deserve attention first
+            level="warning" if row["likelihood"] >= 3 else "note",
+            symbol=f"{row['owner']}:{row['call']}",
+            properties={"category": row["category"]},
+        )
+        for row in rows
+    ]
+
+
+def vulnerability_findings(rows: list[dict[str, Any]]) -> list[SarifFinding]:
+    """Findings for rows returned by VulnerabilityAnalyzer."""
+    findings = []
+    for row in rows:
+        tags = ["security"]
+        if row.get("cwe_id"):
+            tags.append(f"external/cwe/{row['cwe_id'].lower()}")
+        rule = SarifRule(
+            f"security/{row['type']}",
+            "".join(part.title() for part in row["type"].split("_")),
+            row["description"],
+            level=SEVERITY_LEVELS.get(row["severity"], "warning"),
+            tags=tuple(tags),
+        )
+        message = row["description"].rstrip(".") + "."
+        if row.get("recommendation"):
+            message += f" {row['recommendation']}"
+        findings.append(
+            SarifFinding(
+                rule=rule,
+                message=message,
+                path=row["path"],
+                line=row["line_number"],
+                symbol=row.get("code_snippet") or row["id"],
+                properties={
+                    "severity": row["severity"],
+                    "confidence": row["confidence"],
+                },
+            )
+        )
+    return findings
+
+
+def dead_code_findings(dead: list[DeadSymbol]) -> list[SarifFinding]:
+    """Findings for symbols reported by DeadCodeAnalyzer."""
+    findings = []
+    for symbol in dead:
+        message = (
+            f"{symbol.label} '{symbol.qualified_name}' is not reachable from any "
+            "entrypoint, exported symbol or test."
+        )
+        if symbol.dead_users:
+            message += f" Only dead code uses it: {', '.join(symbol.dead_users)}."
+        findings.append(
+            SarifFinding(
+                rule=DEAD_CODE_RULE,
+                message=message,
+                path=symbol.path,
+                line=symbol.line_number,
+                symbol=symbol.qualified_name,
+            )
+        )
+    return findings
+
+
+def layering_findings(violations: list[LayerViolation]) -> list[SarifFinding]:
+    """Findings for violations reported by LayeringAnalyzer."""
+    return [
+        SarifFinding(
+            rule=LAYERING_RULE,
+            message=(
+                f"{violation.kind.capitalize()} of {violation.target} from "
+                f"{violation.source}: layer '{violation.source_layer}' may not "
+                f"use layer '{violation.target_layer}'."
+            ),
+            path=violation.path,
+            line=violation.line_number,
+            symbol=f"{violation.kind}:{violation.source}->{violation.target}",
+            properties={"rule": violation.rule},
+        )
+        for violation in violations
+    ]
+
+
+def import_cycle_findings(cycles: list[PackageCycle]) -> list[SarifFinding]:
+    """
+    One finding per cycle reported by ImportCycleAnalyzer, on an import of
+    its weakest dependency, the cheapest to break; the dependency's other
+    imports are related locations.
+    """
+    findings = []
+    for cycle in cycles:
+        weakest = next((d for d in cycle.dependencies if d.imports), None)
+        if weakest is None:
+            continue
+        first, *others = weakest.imports
+        findings.append(
+            SarifFinding(
+                rule=IMPORT_CYCLE_RULE,
+                message=(
+                    f"Import cycle between {', '.join(cycle.packages)}. "
+                    f"{weakest.source} -> {weakest.target} takes the fewest "
+                    f"imports to break ({len(weakest.imports)})."
+                ),
+                path=first.path,
+                line=first.line_number,
+                symbol=",".join(sorted(cycle.packages)),
+                properties={"packages": cycle.packages},
+                related=[
+                    SarifLocation(site.path, site.line_number, site.target_module)
+                    for site in others
+                ],
+            )
+        )
+    return findings
+
+
+def taint_findings(paths: list[TaintPath]) -> list[SarifFinding]:
+    """Findings for paths found by TaintAnalyzer, with the path as a code flow."""
+    findings = []
+    for path in paths:
+        cwe = CWE_BY_SINK.get(path.sink_kind, "CWE-20")
+        rule = SarifRule(
+            f"taint/{path.vuln_type}",
+            "".join(part.title() for part in path.vuln_type.split("_")),
+            f"Untrusted {path.source.kind} reaches a {path.sink_kind} sink "
+            "without a sanitizer.",
+            level="error",
+            tags=("security", f"external/cwe/{cwe.lower()}"),
+        )
+        source = SarifLocation(
+            path.source_path, path.source.line, f"{path.source.text} is read"
+        )
+        sink = SarifLocation(
+            path.sink_path, path.sink_line, f"{path.sink_call} receives it"
+        )
+        findings.append(
+            SarifFinding(
+                rule=rule,
+                message=(
+                    f"{path.source.text} ({path.source.kind}) reaches "
+                    f"{path.sink_call} without a sanitizer, through "
+                    f"{' -> '.join(path.functions)}."
+                ),
+                path=path.sink_path,
+                line=path.sink_line,
+                symbol=(
+                    f"{path.source.function}:{path.source.text}->"
+                    f"{path.sink_function}:{path.sink_call}"
+                ),
+                related=[source],
+                flow=[source, sink],
+            )
+        )
+    return findings
+
+
+def _location(location: SarifLocation) -> dict[str, Any]:
+    result: dict[str, Any] = {
+        "physicalLocation": _physical_location(location.path, location.line)
+    }
+    if location.message:
+        result["message"] = {"text": location.message}
+    return result
+
+
+def _physical_location(path: str, line: int | None) -> dict[str, Any]:
+    location: dict[str, Any] = {
+        "artifactLocation": {"uri": path, "uriBaseId": "%SRCROOT%"}
+    }
+    if line:
+        location["region"] = {"startLine": line}
+    return location
+
+
+def _rule_descriptor(rule: SarifRule) -> dict[str, Any]:
+    descriptor: dict[str, Any] = {
+        "id": rule.id,
+        "name": rule.name,
+        "shortDescription": {"text": rule.description},
+        "defaultConfiguration": {"level": rule.level},
+    }
+    if rule.tags:
+        descriptor["properties"] = {"tags": list(rule.tags)}
+    return descriptor
+
+
+def _fingerprint(finding: SarifFinding) -> str:
+    key = "\0".join([finding.rule.id, finding.path, finding.symbol or finding.message])
+    return hashlib.sha256(key.encode("utf-8")).hexdigest()[:32]
@@ -0,0 +1,326 @@
+"""Software bill of materials (SBOM) in CycloneDX and SPDX JSON formats.
+
+Components come from the same manifests ingestion reads (go.mod,
+package.json, requirements files and pyproject.toml). Lockfiles next to a
+manifest (package-lock.json, poetry.lock, uv.lock) pin resolved versions and
+add hashes and, for npm, declared licenses.
+"""
+
+import base64
+import json
+import re
+import uuid
+from dataclasses import asdict, dataclass, field
+from datetime import UTC, datetime
+from pathlib import Path, PurePosixPath
+from typing import Any
+from urllib.parse import quote
+
+import toml
+from loguru import logger
+
+from .unused_dependencies import Manifest, find_manifests, parse_go_mod
+
+TOOL_NAME = "graph-code"
+
+# Package URL types per manifest ecosystem
+PURL_TYPES = {"go": "golang", "npm": "npm", "python": "pypi"}
+
+# Lockfiles that pin the dependencies of a manifest in the same directory
+LOCKFILES = {
+    "npm": ("package-lock.json",),
+    "python": ("poetry.lock", "uv.lock"),
+}
+
+# CycloneDX hash algorithm names, keyed by the prefixes lockfiles use
+HASH_ALGORITHMS = {"sha1": "SHA-1", "sha256": "SHA-256", "sha512": "SHA-512"}
+
+
+@dataclass
+class Component:
+    """A third-party package a manifest depends on."""
+
+    name: str
+    ecosystem: str
+    version: str  # Resolved version, or the declared constraint if unpinned
+    manifest: str  # Manifest that declares it, relative to the repository
+    dev: bool = False
+    pinned: bool = False  # True when the version is exact
+    license: str = ""
+    hashes: dict[str, str] = field(default_factory=dict)  # CycloneDX alg -> hex
+
+    @property
+    def purl(self) -> str:
+        name = quote(self.name, safe="/")
+        if self.ecosystem == "python":
+            name = _normalize_python_name(self.name)
+        purl = f"pkg:{PURL_TYPES[self.ecosystem]}/{name}"
+        return f"{purl}@{quote(self.version, safe='')}" if self.pinned else purl
+
+    def to_dict(self) -> dict[str, Any]:
+        return {**asdict(self), "purl": self.purl}
+
+
+class SbomBuilder:
+    """Collects components per manifest and renders SBOM documents."""
+
+    def __init__(self, repo_path: Path, include_dev: bool = False):
+        self.repo_path = repo_path
+        self.include_dev = include_dev
+
+    def collect(self, manifest_path: str | None = None) -> list[Component]:
+        """
+        Return components of every manifest in the repository, or of one
+        manifest (e.g. "services/api/go.mod") to scope the SBOM to a module.
+        """
+        manifests = find_manifests(self.repo_path)
+        if manifest_path:
+            wanted = PurePosixPath(manifest_path).as_posix()
+            manifests = [m for m in manifests if m.path == wanted]
+
+        components: dict[tuple[str, str, str], Component] = {}
+        for manifest in manifests:
+            for component in self._manifest_components(manifest):
+                if component.dev and not self.include_dev:
+                    continue
+                key = (component.ecosystem, component.name, component.version)
+                components.setdefault(key, component)
+        return sorted(components.values(), key=lambda c: (c.ecosystem, c.name))
+
+    def _manifest_components(self, manifest: Manifest) -> list[Component]:
+        manifest_file = self.repo_path / manifest.path
+        if manifest.ecosystem == "go":
+            # Indirect requirements ship in the binary too
+            content = manifest_file.read_text(encoding="utf-8", errors="replace")
+            manifest = Manifest(
+                manifest.path, "go", parse_go_mod(content, True).dependencies
+            )
+
+        locked: dict[str, dict[str, Any]] = {}
+        for lockfile in LOCKFILES.get(manifest.ecosystem, ()):
+            lock_path = manifest_file.parent / lockfile
+            if lock_path.is_file():
+                try:
+                    locked = parse_lockfile(lock_path)
+                except (ValueError, toml.TomlDecodeError) as e:
+                    logger.warning(f"Could not parse {lock_path}: {e}")
+                break
+
+        components = []
+        for dep in manifest.dependencies:
+            key = dep.name
+            if manifest.ecosystem == "python":
+                key = _normalize_python_name(dep.name)
+            lock = locked.get(key, {})
+            # go.mod requirements are always exact versions
+            pinned = bool(lock) or manifest.ecosystem == "go" or _is_exact(dep.version)
+            components.append(
+                Component(
+                    name=dep.name,
+                    ecosystem=manifest.ecosystem,
+                    version=lock.get("version") or dep.version,
+                    manifest=manifest.path,
+                    dev=dep.dev,
+                    pinned=pinned,
+                    license=lock.get("license", ""),
+                    hashes=lock.get("hashes", {}),
+                )
+            )
+        return components
+
+
+def parse_lockfile(path: Path) -> dict[str, dict[str, Any]]:
+    """Map package names to the version, hashes and license a lockfile pins."""
+    content = path.read_text(encoding="utf-8", errors="replace")
+    if path.name == "package-lock.json":
+        return parse_package_lock(content)
+    return parse_python_lock(content)
+
+
+def parse_package_lock(content: str) -> dict[str, dict[str, Any]]:
+    """Parse an npm lockfile (v1, or v2/v3 with a "packages" table)."""
+    data = json.loads(content)
+    locked: dict[str, dict[str, Any]] = {}
+    packages = data.get("packages")
+    if packages is not None:
+        entries = [
+            (path.rsplit("node_modules/", 1)[-1], info)
+            for path, info in packages.items()
+            # Only top-level installs; nested copies are other versions
+            if path.startswith("node_modules/") and path.count("node_modules/") == 1
+        ]
+    else:
+        entries = list((data.get("dependencies") or {}).items())
+    for name, info in entries:
+        locked[name] = {
+            "version": info.get("version", ""),
+            "license": _npm_license(info.get("license")),
+            "hashes": _integrity_hashes(info.get("integrity", "")),
+        }
+    return locked
+
+
+def parse_python_lock(content: str) -> dict[str, dict[str, Any]]:
+    """Parse poetry.lock or uv.lock into versions and distribution hashes."""
+    data = toml.loads(content)
+    # Poetry 1.0 kept file hashes in a separate metadata table
+    legacy_files = data.get("metadata", {}).get("files", {})
+    locked = {}
+    for package in data.get("package", []):
+        name = _normalize_python_name(package["name"])
+        files = package.get("files") or legacy_files.get(package["name"], [])
+        if "sdist" in package:  # uv.lock
+            files = [package["sdist"], *files]
+        # Component hashes describe one artifact; prefer the source distribution
+        files = sorted(files, key=lambda f: not f.get("file", "").endswith(".tar.gz"))
+        hashes: dict[str, str] = {}
+        if files:
+            algorithm, _, digest = files[0].get("hash", "").partition(":")
+            if algorithm in HASH_ALGORITHMS and digest:
+                hashes[HASH_ALGORITHMS[algorithm]] = digest
+        locked[name] = {"version": package.get("version", ""), "hashes": hashes}
+    return locked
+
+
+def to_cyclonedx(components: list[Component], name: str) -> dict[str, Any]:
+    """Render components as a CycloneDX 1.5 JSON document."""
+    entries = []
+    for component in components:
+        entry: dict[str, Any] = {
+            "type": "library",
+            "bom-ref": f"{component.purl}#{component.version}",
+            "name": component.name,
+            "version": component.version,
+            "purl": component.purl,
+            "scope": "optional" if component.dev else "required",
+        }
+        if component.hashes:
+            entry["hashes"] = [
+                {"alg": alg, "content": digest}
+                for alg, digest in sorted(component.hashes.items())
+            ]
+        if component.license:
+            entry["licenses"] = [{"expression": component.license}]
+        entry["properties"] = [
+            {"name": "graph-code:manifest", "value": component.manifest}
+        ]
+        entries.append(entry)
+
+    return {
+        "bomFormat": "CycloneDX",
+        "specVersion": "1.5",
+        "serialNumber": f"urn:uuid:{uuid.uuid4()}",
+        "version": 1,
+        "metadata": {
+            "timestamp": _timestamp(),
+            "tools": [{"name": TOOL_NAME}],
+            "component": {"type": "application", "name": name},
+        },
+        "components": entries,
+    }
+
+
+def to_spdx(components: list[Component], name: str) -> dict[str, Any]:
+    """Render components as an SPDX 2.3 JSON document."""
+    root_id = "SPDXRef-Application"
+    packages: list[dict[str, Any]] = [
+        {
+            "SPDXID": root_id,
+            "name": name,
+            "downloadLocation": "NOASSERTION",
+            "filesAnalyzed": False,
+        }
+    ]
+    relationships = [
+        {
+            "spdxElementId": "SPDXRef-DOCUMENT",
+            "relationshipType": "DESCRIBES",
+            "relatedSpdxElement": root_id,
+        }
+    ]
+    for index, component in enumerate(components, start=1):
+        spdx_id = f"SPDXRef-Package-{index}"
+        package: dict[str, Any] = {
+            "SPDXID": spdx_id,
+            "name": component.name,
+            "versionInfo": component.version,
+            "downloadLocation": "NOASSERTION",
+            "filesAnalyzed": False,
+            "licenseDeclared": component.license or "NOASSERTION",
+            "licenseConcluded": "NOASSERTION",
+            "copyrightText": "NOASSERTION",
+            "externalRefs": [
+                {
+                    "referenceCategory": "PACKAGE-MANAGER",
+                    "referenceType": "purl",
+                    "referenceLocator": component.purl,
+                }
+            ],
+        }
+        if component.hashes:
+            package["checksums"] = [
+                {"algorithm": alg.replace("-", ""), "checksumValue": digest}
+                for alg, digest in sorted(component.hashes.items())
+            ]
+        packages.append(package)
+        if component.dev:
+            relationships.append(
+                {
+                    "spdxElementId": spdx_id,
+                    "relationshipType": "DEV_DEPENDENCY_OF",
+                    "relatedSpdxElement": root_id,
+                }
+            )
+        else:
+            relationships.append(
+                {
+                    "spdxElementId": root_id,
+                    "relationshipType": "DEPENDS_ON",
+                    "relatedSpdxElement": spdx_id,
+                }
+            )
+
+    return {
+        "spdxVersion": "SPDX-2.3",
+        "dataLicense": "CC0-1.0",
+        "SPDXID": "SPDXRef-DOCUMENT",
+        "name": name,
+        "documentNamespace": f"https://spdx.org/spdxdocs/{quote(name)}-{uuid.uuid4()}",
+        "creationInfo": {"created": _timestamp(), "creators": [f"Tool: {TOOL_NAME}"]},
+        "packages": packages,
+        "relationships": relationships,
+    }
+
+
+def _is_exact(version: str) -> bool:
+    version = version.strip()
+    if version.startswith("=="):
+        version = version[2:]
+    return bool(version) and version[0].isdigit() and not any(
+        c in version for c in "<>=~^*|, "
+    )
+
+
+def _normalize_python_name(name: str) -> str:
+    # PEP 503: lockfiles and package URLs use the normalized project name
+    return re.sub(r"[-_.]+", "-", name).lower()
+
+
+def _npm_license(value: Any) -> str:
+    if isinstance(value, dict):  # Legacy {"type": "MIT", "url": ...}
+        return str(value.get("type", ""))
+    return str(value or "")
+
+
+def _integrity_hashes(integrity: str) -> dict[str, str]:
+    """Convert Subresource Integrity strings ("sha512-<base64>") to hex digests."""
+    hashes = {}
+    for token in integrity.split():
+        algorithm, _, digest = token.partition("-")
+        if algorithm in HASH_ALGORITHMS and digest:
+            hashes[HASH_ALGORITHMS[algorithm]] = base64.b64decode(digest).hex()
+    return hashes
+
+
+def _timestamp() -> str:
+    return datetime.now(UTC).strftime("%Y-%m-%dT%H:%M:%SZ")
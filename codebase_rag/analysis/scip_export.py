@@ -0,0 +1,273 @@
+"""Export of the graph as a SCIP index for Sourcegraph-compatible tools.
+
+Language indexers already cover plain definitions and references; what the
+graph adds are relationships they cannot see, which SCIP carries as symbol
+relationships:
+
+- tests reference the code they exercise (TESTS edges),
+- handlers implement the HTTP endpoints they serve (HANDLED_BY edges, with
+  each endpoint exported as a symbol defined where its route is registered),
+- subclasses and overriding methods implement what they inherit.
+
+Calls between functions become reference occurrences. The graph records no
+call positions, so each is placed on the first mention of the callee's name
+in the caller's body, read from the checkout.
+"""
+
+import re
+from collections import defaultdict
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from ..utils.protobuf_wire import encode_field, encode_packed
+from ..utils.visibility import language_for_path
+
+TOOL_NAME = "graph-code"
+
+# SCIP enum values (scip.proto)
+UTF8_ENCODING = 1
+DEFINITION_ROLE = 0x1
+SYMBOL_KINDS = {"Class": 7, "Function": 17, "Method": 26}
+
+DEFINITIONS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(d)
+WHERE (d:Function OR d:Method OR d:Class)
+  AND (m.qualified_name = $project OR m.qualified_name STARTS WITH $project + '.')
+RETURN DISTINCT m.qualified_name AS module, m.path AS path,
+       d.qualified_name AS qualified_name, d.name AS name,
+       labels(d)[0] AS label, d.start_line AS start_line,
+       d.end_line AS end_line, d.docstring AS docstring
+"""
+
+CALLS_QUERY = """
+MATCH (a)-[:CALLS]->(b)
+WHERE (a:Function OR a:Method) AND (b:Function OR b:Method)
+RETURN DISTINCT a.qualified_name AS source, b.qualified_name AS target
+"""
+
+IMPLEMENTATIONS_QUERY = """
+MATCH (a)-[:IMPLEMENTS|INHERITS_FROM|OVERRIDES]->(b)
+RETURN DISTINCT a.qualified_name AS source, b.qualified_name AS target
+"""
+
+TESTS_QUERY = """
+MATCH (t)-[:TESTS]->(code)
+RETURN DISTINCT t.qualified_name AS source, code.qualified_name AS target
+"""
+
+ENDPOINTS_QUERY = """
+MATCH (m:Module)-[:DEFINES_ENDPOINT]->(e:Endpoint)
+WHERE m.qualified_name = $project OR m.qualified_name STARTS WITH $project + '.'
+OPTIONAL MATCH (e)-[:HANDLED_BY]->(handler)
+RETURN e.qualified_name AS qualified_name, e.method AS method, e.route AS route,
+       e.framework AS framework, e.line_number AS line_number,
+       m.qualified_name AS module, m.path AS path,
+       collect(DISTINCT handler.qualified_name) AS handlers
+"""
+
+SIMPLE_DESCRIPTOR = re.compile(r"[\w+$-]+")
+
+
+@dataclass
+class _Relationship:
+    symbol: str
+    is_reference: bool = False
+    is_implementation: bool = False
+
+    def encode(self) -> bytes:
+        message = encode_field(1, self.symbol)
+        if self.is_reference:
+            message += encode_field(2, True)
+        if self.is_implementation:
+            message += encode_field(3, True)
+        return message
+
+
+@dataclass
+class _Symbol:
+    symbol: str
+    display_name: str
+    kind: int = 0
+    documentation: list[str] = field(default_factory=list)
+    relationships: list[_Relationship] = field(default_factory=list)
+
+    def encode(self) -> bytes:
+        message = encode_field(1, self.symbol)
+        for text in self.documentation:
+            message += encode_field(3, text)
+        for relationship in self.relationships:
+            message += encode_field(4, relationship.encode())
+        if self.kind:
+            message += encode_field(5, self.kind)
+        return message + encode_field(6, self.display_name)
+
+
+@dataclass
+class _Document:
+    path: str
+    occurrences: list[bytes] = field(default_factory=list)
+    symbols: list[_Symbol] = field(default_factory=list)
+
+    def add_occurrence(self, symbol: str, span: list[int], roles: int = 0) -> None:
+        occurrence = encode_packed(1, span) + encode_field(2, symbol)
+        if roles:
+            occurrence += encode_field(3, roles)
+        self.occurrences.append(occurrence)
+
+    def encode(self) -> bytes:
+        message = encode_field(1, self.path)
+        message += b"".join(encode_field(2, o) for o in self.occurrences)
+        message += b"".join(encode_field(3, s.encode()) for s in self.symbols)
+        language = language_for_path(self.path)
+        return message + encode_field(4, language) if language else message
+
+
+class ScipExporter:
+    """Builds a SCIP index of one project's modules from the graph."""
+
+    def __init__(self, ingestor: Any, repo_path: Path, project: str | None = None):
+        self.ingestor = ingestor
+        self.repo_path = repo_path.resolve()
+        # Module qualified names start with the repository directory name
+        self.project = project or self.repo_path.name
+        self._lines: dict[str, list[str]] = {}
+
+    def export(self) -> tuple[bytes, dict[str, int]]:
+        """Return the encoded index and counts of what it contains."""
+        params = {"project": self.project}
+        rows = self.ingestor.fetch_all(DEFINITIONS_QUERY, params)
+        labels = {row["qualified_name"]: row["label"] for row in rows}
+        definitions = {row["qualified_name"]: row for row in rows}
+        symbols = {
+            row["qualified_name"]: self._symbol(
+                row["module"], row["qualified_name"], labels
+            )
+            for row in rows
+        }
+
+        documents: dict[str, _Document] = {}
+        infos: dict[str, _Symbol] = {}
+        for row in rows:
+            document = documents.setdefault(row["path"], _Document(row["path"]))
+            symbol = symbols[row["qualified_name"]]
+            line = row["start_line"]
+            # Without the source, definitions keep their line but no columns
+            span = self._span(row["path"], line, line, row["name"]) or [line - 1, 0, 0]
+            document.add_occurrence(symbol, span, DEFINITION_ROLE)
+            info = _Symbol(symbol, row["name"], SYMBOL_KINDS.get(row["label"], 0))
+            if row.get("docstring"):
+                info.documentation.append(row["docstring"])
+            document.symbols.append(info)
+            infos[row["qualified_name"]] = info
+
+        stats: dict[str, int] = defaultdict(int)
+        for call in self.ingestor.fetch_all(CALLS_QUERY):
+            caller = definitions.get(call["source"])
+            if caller is None or call["target"] not in symbols:
+                continue
+            callee_name = definitions[call["target"]]["name"]
+            # Search the body, below the caller's own definition line
+            path = caller["path"]
+            span = self._span(
+                path, caller["start_line"] + 1, caller["end_line"], callee_name
+            )
+            if span:
+                documents[path].add_occurrence(symbols[call["target"]], span)
+                stats["references"] += 1
+
+        for relation, query in (
+            ("implementations", IMPLEMENTATIONS_QUERY),
+            ("tests", TESTS_QUERY),
+        ):
+            for edge in self.ingestor.fetch_all(query):
+                source, target = infos.get(edge["source"]), symbols.get(edge["target"])
+                if source is None or target is None:
+                    continue
+                source.relationships.append(
+                    _Relationship(
+                        target,
+                        is_reference=relation == "tests",
+                        is_implementation=relation == "implementations",
+                    )
+                )
+                stats[relation] += 1
+
+        for endpoint in self.ingestor.fetch_all(ENDPOINTS_QUERY, params):
+            name = f"{endpoint['method']} {endpoint['route']}"
+            symbol = self._symbol(endpoint["module"], None, labels, endpoint=name)
+            document = documents.setdefault(
+                endpoint["path"], _Document(endpoint["path"])
+            )
+            line = endpoint.get("line_number") or 1
+            span = self._span(endpoint["path"], line, line, endpoint["route"])
+            span = span or [line - 1, 0, 0]
+            document.add_occurrence(symbol, span, DEFINITION_ROLE)
+            framework = endpoint.get("framework") or "unknown framework"
+            documentation = [f"HTTP endpoint `{name}` ({framework})"]
+            document.symbols.append(_Symbol(symbol, name, documentation=documentation))
+            for handler in endpoint["handlers"]:
+                if handler in infos:
+                    infos[handler].relationships.append(
+                        _Relationship(symbol, is_implementation=True)
+                    )
+            stats["endpoints"] += 1
+
+        metadata = (
+            encode_field(2, encode_field(1, TOOL_NAME))
+            + encode_field(3, self.repo_path.as_uri())
+            + encode_field(4, UTF8_ENCODING)
+        )
+        index = encode_field(1, metadata) + b"".join(
+            encode_field(2, documents[path].encode()) for path in sorted(documents)
+        )
+        stats["documents"] = len(documents)
+        stats["symbols"] = len(rows)
+        return index, dict(stats)
+
+    def _symbol(
+        self,
+        module: str,
+        qualified_name: str | None,
+        labels: dict[str, str],
+        endpoint: str | None = None,
+    ) -> str:
+        """
+        A global SCIP symbol: the module path as namespaces, then classes as
+        types, functions and methods as methods, and endpoints as terms.
+        """
+        descriptors = [f"{_escape(part)}/" for part in module.split(".")[1:]]
+        if qualified_name is not None:
+            prefix = module
+            for part in qualified_name[len(module) + 1 :].split("."):
+                prefix = f"{prefix}.{part}"
+                suffix = "#" if labels.get(prefix) == "Class" else "()."
+                descriptors.append(f"{_escape(part)}{suffix}")
+        if endpoint is not None:
+            descriptors.append(f"{_escape(endpoint)}.")
+        return f"{TOOL_NAME} . {self.project} . {''.join(descriptors)}"
+
+    def _span(self, path: str, first_line: int, last_line: int, name: str) -> list[int]:
+        """[line, start, end] (0-based) of the first mention of name, if any."""
+        lines = self._file_lines(path)
+        pattern = re.compile(rf"(?<!\w){re.escape(name)}(?!\w)")
+        for index in range(first_line - 1, min(last_line, len(lines))):
+            match = pattern.search(lines[index])
+            if match:
+                return [index, match.start(), match.end()]
+        return []
+
+    def _file_lines(self, path: str) -> list[str]:
+        if path not in self._lines:
+            try:
+                text = (self.repo_path / path).read_text(encoding="utf-8")
+                self._lines[path] = text.splitlines()
+            except (OSError, UnicodeDecodeError):
+                self._lines[path] = []
+        return self._lines[path]
+
+
+def _escape(name: str) -> str:
+    if SIMPLE_DESCRIPTOR.fullmatch(name):
+        return name
+    return "`" + name.replace("`", "``") + "`"
@@ -0,0 +1,160 @@
+"""Code-smell detection: god classes, long functions and deeply nested code."""
+
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from loguru import logger
+
+# Functions and methods with the size metrics recorded during ingestion
+FUNCTION_SIZE_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(f)
+WHERE f:Function OR f:Method
+RETURN DISTINCT f.qualified_name AS qualified_name, labels(f)[0] AS label,
+       m.path AS path, f.start_line AS start_line, f.end_line AS end_line,
+       f.parameter_count AS parameter_count,
+       f.max_nesting_depth AS max_nesting_depth
+"""
+
+# Classes with their field count and number of methods
+CLASS_SIZE_QUERY = """
+MATCH (m:Module)-[:DEFINES]->(c:Class)
+OPTIONAL MATCH (c)-[:DEFINES_METHOD]->(method)
+WITH m, c, count(DISTINCT method) AS method_count
+RETURN c.qualified_name AS qualified_name, m.path AS path,
+       c.start_line AS start_line, c.field_count AS field_count, method_count
+"""
+
+# Smell names, stored in the `smells` property of the affected nodes
+GOD_CLASS = "god_class"
+LONG_FUNCTION = "long_function"
+LONG_PARAMETER_LIST = "long_parameter_list"
+DEEP_NESTING = "deep_nesting"
+
+
+@dataclass
+class SmellThresholds:
+    """Limits above which a class or function is reported as smelly."""
+
+    max_methods: int = 20
+    max_fields: int = 15
+    max_function_lines: int = 60
+    max_parameters: int = 5
+    max_nesting_depth: int = 4
+
+
+@dataclass
+class CodeSmell:
+    """A single threshold violation on a class, function or method."""
+
+    qualified_name: str
+    label: str  # "Class", "Function" or "Method"
+    path: str
+    smell: str  # One of the smell name constants
+    metric: str  # The measured property, e.g. "method_count"
+    value: int
+    threshold: int
+    start_line: int | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class SmellAnalyzer:
+    """Detects code smells from size metrics stored in the code graph."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def detect(self, thresholds: SmellThresholds | None = None) -> list[CodeSmell]:
+        """Return all smells, worst violations (relative to their threshold) first."""
+        thresholds = thresholds or SmellThresholds()
+        smells = detect_class_smells(
+            self.ingestor.fetch_all(CLASS_SIZE_QUERY), thresholds
+        ) + detect_function_smells(
+            self.ingestor.fetch_all(FUNCTION_SIZE_QUERY), thresholds
+        )
+        return sorted(
+            smells, key=lambda s: (-s.value / max(s.threshold, 1), s.qualified_name)
+        )
+
+    def store_smells(self, smells: list[CodeSmell]) -> None:
+        """Tag smelly nodes with their smell names and clear stale tags on the rest."""
+        tags: dict[tuple[str, str], set[str]] = {}
+        for smell in smells:
+            tags.setdefault((smell.label, smell.qualified_name), set()).add(smell.smell)
+
+        self.ingestor.execute_write(
+            "MATCH (n) WHERE (n:Class OR n:Function OR n:Method) "
+            "AND n.smells IS NOT NULL SET n.smells = []"
+        )
+        for (label, qualified_name), names in tags.items():
+            self.ingestor.ensure_node_batch(
+                label, {"qualified_name": qualified_name, "smells": sorted(names)}
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Tagged {len(tags)} nodes with code smells")
+
+
+def detect_class_smells(
+    rows: list[dict[str, Any]], thresholds: SmellThresholds
+) -> list[CodeSmell]:
+    """Flag classes with too many methods or fields as god classes."""
+    smells = []
+    for row in rows:
+        for metric, limit in (
+            ("method_count", thresholds.max_methods),
+            ("field_count", thresholds.max_fields),
+        ):
+            value = row.get(metric) or 0
+            if value > limit:
+                smells.append(_smell(row, "Class", GOD_CLASS, metric, value, limit))
+    return smells
+
+
+def detect_function_smells(
+    rows: list[dict[str, Any]], thresholds: SmellThresholds
+) -> list[CodeSmell]:
+    """Flag functions that are too long, take many parameters or nest deeply."""
+    smells = []
+    for row in rows:
+        label = row.get("label") or "Function"
+        start, end = row.get("start_line"), row.get("end_line")
+        checks = [
+            (
+                LONG_FUNCTION,
+                "line_count",
+                end - start + 1 if start and end else 0,
+                thresholds.max_function_lines,
+            ),
+            (
+                LONG_PARAMETER_LIST,
+                "parameter_count",
+                row.get("parameter_count") or 0,
+                thresholds.max_parameters,
+            ),
+            (
+                DEEP_NESTING,
+                "max_nesting_depth",
+                row.get("max_nesting_depth") or 0,
+                thresholds.max_nesting_depth,
+            ),
+        ]
+        for smell, metric, value, limit in checks:
+            if value > limit:
+                smells.append(_smell(row, label, smell, metric, value, limit))
+    return smells
+
+
+def _smell(
+    row: dict[str, Any], label: str, smell: str, metric: str, value: int, limit: int
+) -> CodeSmell:
+    return CodeSmell(
+        qualified_name=row["qualified_name"],
+        label=label,
+        path=row.get("path") or "",
+        smell=smell,
+        metric=metric,
+        value=value,
+        threshold=limit,
+        start_line=row.get("start_line"),
+    )
@@ -0,0 +1,243 @@
+"""Resolve pasted stack traces (Go panics, Python tracebacks, JVM) to graph nodes."""
+
+import re
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from ..version_control.git_analyzer import BlameInfo, GitAnalyzer
+from .code_locator import CodeLocator
+
+GO = "go"
+PYTHON = "python"
+JVM = "jvm"
+
+PYTHON_FRAME = re.compile(
+    r'^\s*File "(?P<file>[^"]+)", line (?P<line>\d+), in (?P<fn>\S+)'
+)
+# "at com.acme.Cart.total(Cart.java:42)", optionally with a "java.base/" module
+JVM_FRAME = re.compile(
+    r"^\s*at\s+(?:[\w.$-]+(?:@[\w.-]+)?/)?(?P<cls>[\w$.]+)\.(?P<fn>[\w$<>]+)"
+    r"\((?P<file>[^:)]+)(?::(?P<line>\d+))?\)"
+)
+# "main.(*Cart).Total(0xc000012345)"; generic functions print "Map[...]"
+GO_FUNCTION = re.compile(r"^(?P<fn>[\w./*()\[\]-]*[\w\]])\(.*\)$")
+GO_LOCATION = re.compile(r"^\s+(?P<file>\S+\.go):(?P<line>\d+)")
+
+# How many blamed authors to show per frame
+MAX_AUTHORS = 3
+
+
+@dataclass
+class StackFrame:
+    """One frame as printed in the trace."""
+
+    function: str
+    file: str
+    line: int
+
+
+@dataclass
+class ResolvedFrame:
+    """A frame with the graph node it ran, source around the line and authors."""
+
+    frame: StackFrame
+    qualified_name: str = ""
+    label: str = ""
+    path: str = ""  # Repository-relative, from the graph
+    snippet: list[tuple[int, str]] = field(default_factory=list)
+    authors: list[dict[str, str]] = field(default_factory=list)
+
+    @property
+    def resolved(self) -> bool:
+        return bool(self.qualified_name)
+
+
+@dataclass
+class ResolvedTrace:
+    """A parsed trace, frames ordered from the entry point to the failure."""
+
+    kind: str
+    message: str
+    frames: list[ResolvedFrame]
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+def detect_kind(text: str) -> str | None:
+    if "Traceback (most recent call last)" in text:
+        return PYTHON
+    if re.search(r"^goroutine \d+ \[", text, re.MULTILINE):
+        return GO
+    if re.search(r"^\s*at\s+[\w$.]+\(", text, re.MULTILINE):
+        return JVM
+    return None
+
+
+def parse_stack_trace(text: str) -> tuple[str, str, list[StackFrame]]:
+    """
+    The kind, the error message and the frames of a trace, outermost call
+    first. Raises ValueError for text that is not a recognised trace.
+    """
+    kind = detect_kind(text)
+    if kind == PYTHON:
+        message, frames = _parse_python(text)
+    elif kind == GO:
+        message, frames = _parse_go(text)
+    elif kind == JVM:
+        message, frames = _parse_jvm(text)
+    else:
+        raise ValueError("not a Go panic, Python traceback or JVM stack trace")
+    return kind, message, frames
+
+
+def _parse_python(text: str) -> tuple[str, list[StackFrame]]:
+    # With chained exceptions the last traceback is the one that escaped
+    block = text.split("Traceback (most recent call last)")[-1]
+    frames = []
+    message = ""
+    for line in block.splitlines()[1:]:
+        match = PYTHON_FRAME.match(line)
+        if match:
+            frames.append(StackFrame(match["fn"], match["file"], int(match["line"])))
+        elif line and not line[0].isspace():
+            message = line.strip()
+            break
+    return message, frames
+
+
+def _parse_go(text: str) -> tuple[str, list[StackFrame]]:
+    lines = text.splitlines()
+    message = next(
+        (line for line in lines if line.startswith(("panic:", "fatal error:"))), ""
+    ).strip()
+    frames: list[StackFrame] = []
+    in_goroutine = False
+    function = ""
+    for line in lines:
+        if line.startswith("goroutine "):
+            if in_goroutine:
+                break  # Only the goroutine that panicked, which is printed first
+            in_goroutine = True
+            continue
+        if not in_goroutine:
+            continue
+        location = GO_LOCATION.match(line)
+        if location and function:
+            frames.append(StackFrame(function, location["file"], int(location["line"])))
+            function = ""
+            continue
+        match = GO_FUNCTION.match(line.strip())
+        if match and not line.startswith("created by"):
+            function = match["fn"]
+        else:
+            function = ""
+    frames.reverse()
+    return message, frames
+
+
+def _parse_jvm(text: str) -> tuple[str, list[StackFrame]]:
+    message = ""
+    frames = []
+    for line in text.splitlines():
+        if line.startswith("Caused by:"):
+            break  # Frames of causes repeat the outer trace up to "... N more"
+        match = JVM_FRAME.match(line)
+        if match:
+            # Frames name the file only; its directory follows the package
+            package = match["cls"].rpartition(".")[0]
+            file = "/".join([*package.split("."), match["file"]]).lstrip("/")
+            function = f"{match['cls'].split('$')[0]}.{match['fn']}"
+            frames.append(StackFrame(function, file, int(match["line"] or 0)))
+        elif not message and line.strip():
+            message = line.strip()
+    frames.reverse()
+    return message, frames
+
+
+class StackTraceResolver:
+    """Maps every frame of a stack trace to the function it ran in the graph."""
+
+    def __init__(
+        self,
+        ingestor: Any,
+        repo_path: Path,
+        git_analyzer: GitAnalyzer | None = None,
+        context_lines: int = 2,
+    ):
+        self.locator = CodeLocator(ingestor)
+        self.repo_path = repo_path
+        self.git_analyzer = git_analyzer
+        self.context_lines = context_lines
+        self._blame: dict[str, list[BlameInfo]] = {}
+
+    def resolve(self, text: str) -> ResolvedTrace:
+        kind, message, frames = parse_stack_trace(text)
+        return ResolvedTrace(kind, message, [self._resolve_frame(f) for f in frames])
+
+    def _resolve_frame(self, frame: StackFrame) -> ResolvedFrame:
+        resolved = ResolvedFrame(frame)
+        match = None
+        if frame.line:
+            match = self.locator.at_line(frame.file, frame.line)
+        if match is None:
+            match = self.locator.by_name(frame.function)
+            # A name alone is only trusted when it is defined in the frame's file
+            if match and Path(match["path"]).name != Path(frame.file).name:
+                match = None
+        if match is None:
+            return resolved  # Standard library, dependencies or generated code
+
+        resolved.qualified_name = match["qualified_name"]
+        resolved.label = match["label"]
+        resolved.path = match["path"]
+        resolved.snippet = self._snippet(match["path"], frame.line)
+        resolved.authors = self._authors(
+            match["path"], match["start_line"], match["end_line"]
+        )
+        return resolved
+
+    def _snippet(self, path: str, line: int) -> list[tuple[int, str]]:
+        if not line:
+            return []
+        try:
+            lines = (self.repo_path / path).read_text(
+                encoding="utf-8", errors="replace"
+            ).splitlines()
+        except OSError:
+            return []
+        first = max(line - self.context_lines, 1)
+        last = min(line + self.context_lines, len(lines))
+        return [(number, lines[number - 1]) for number in range(first, last + 1)]
+
+    def _authors(self, path: str, start: int, end: int) -> list[dict[str, str]]:
+        """Authors of the function's lines, most recently active first."""
+        if self.git_analyzer is None or not start or not end:
+            return []
+        if path not in self._blame:
+            try:
+                self._blame[path] = self.git_analyzer.get_blame_info(
+                    str(self.repo_path / path)
+                )
+            except Exception as e:
+                logger.debug(f"Blame failed for {path}: {e}")
+                self._blame[path] = []
+
+        latest: dict[str, BlameInfo] = {}
+        for blame in self._blame[path]:
+            if start <= blame.line_number <= end:
+                current = latest.get(blame.author)
+                if current is None or blame.date > current.date:
+                    latest[blame.author] = blame
+        ordered = sorted(latest.values(), key=lambda b: b.date, reverse=True)
+        return [
+            {
+                "author": blame.author,
+                "date": blame.date.date().isoformat(),
+                "commit": blame.commit_sha[:8],
+            }
+            for blame in ordered[:MAX_AUTHORS]
+        ]
@@ -0,0 +1,793 @@
+"""Taint tracking from configured sources to sinks, within and across functions.
+
+Sources are reads of untrusted data (request parameters, environment
+variables), sinks are calls that must not receive it unchecked (SQL
+execution, process spawning) and sanitizers are calls whose result is safe
+whatever went in. Each is a glob over the expression as written, e.g.
+"*.FormValue" or "os.Getenv", so they can be declared in the [taint] section
+of a repository's .cgr.toml:
+
+    [taint.sources]
+    user_input = ["*.Param"]
+
+    [taint.sinks]
+    sql = ["*.Raw"]
+
+    [taint]
+    sanitizers = ["validate.*"]
+
+Taint moves through assignments and expressions inside a function, into
+callees through their arguments and back through return values, following
+the CALLS edges already in the graph. Control flow is ignored, so a value
+checked on one branch and used on another is still reported; a sanitizer
+call is the only validation the engine recognises.
+"""
+
+import re
+from collections import defaultdict, deque
+from dataclasses import dataclass, field
+from fnmatch import fnmatchcase
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+from tree_sitter import Node, Parser
+
+from ..language_config import get_language_config
+from ..utils.ast_helpers import node_text
+from .code_locator import CodeLocator
+
+IGNORED_DIRS = {".git", "vendor", "node_modules", "venv", ".venv", "build", "dist"}
+
+DEFAULT_SOURCES = {
+    "user_input": [
+        "*.FormValue",
+        "*.PostFormValue",
+        "*.URL.Query",
+        "*.URL.RawQuery",
+        "*.URL.Path",
+        "*.PathValue",
+        "*.Header.Get",
+        "*.Cookie",
+        "*.Body",
+        "mux.Vars",
+        "request.args",
+        "request.form",
+        "request.values",
+        "request.json",
+        "request.get_json",
+        "request.cookies",
+        "request.headers",
+        "input",
+    ],
+    "env": ["os.Getenv", "os.LookupEnv", "os.Environ", "os.getenv", "os.environ"],
+    "cli_args": ["os.Args", "flag.Arg", "flag.Args", "sys.argv"],
+}
+DEFAULT_SINKS = {
+    "sql": [
+        "*.Exec",
+        "*.ExecContext",
+        "*.Query",
+        "*.QueryContext",
+        "*.QueryRow",
+        "*.QueryRowContext",
+        "*.execute",
+        "*.executemany",
+        "*.executescript",
+    ],
+    "exec": [
+        "exec.Command",
+        "exec.CommandContext",
+        "syscall.Exec",
+        "os.system",
+        "os.popen",
+        "subprocess.*",
+        "eval",
+        "exec",
+    ],
+}
+DEFAULT_SANITIZERS = [
+    "strconv.Atoi",
+    "strconv.Parse*",
+    "strconv.Quote",
+    "html.EscapeString",
+    "url.QueryEscape",
+    "url.PathEscape",
+    "filepath.Base",
+    "uuid.Parse",
+    "int",
+    "float",
+    "bool",
+    "shlex.quote",
+    "html.escape",
+]
+
+CWE_BY_SINK = {"sql": "CWE-89", "exec": "CWE-78"}
+
+# Callees whose name a call's last segment matches
+CALL_EDGES_QUERY = """
+MATCH (caller)-[:CALLS]->(callee)
+WHERE (caller:Function OR caller:Method) AND (callee:Function OR callee:Method)
+RETURN caller.qualified_name AS caller, callee.qualified_name AS callee
+"""
+
+# Drop findings of an earlier run, so fixed flows disappear
+CLEAR_TAINT_PATHS = [
+    "MATCH (v:Vulnerability) WHERE v.id STARTS WITH 'taint:' DETACH DELETE v",
+    "MATCH ()-[r:FLOWS_TO]->() WHERE r.taint_id IS NOT NULL DELETE r",
+]
+
+# Passes over a function body, so values assigned late in a loop reach
+# uses earlier in it
+BODY_PASSES = 2
+# Rounds of recomputing what functions return before giving up on a fixpoint
+SUMMARY_ROUNDS = 5
+# Longest chain of calls a parameter is followed back through
+MAX_CALL_DEPTH = 8
+
+GO_PARAMETERS = ("parameter_declaration", "variadic_parameter_declaration")
+
+
+@dataclass
+class TaintRules:
+    """Globs over expression text, sources and sinks grouped by kind."""
+
+    sources: dict[str, list[str]] = field(
+        default_factory=lambda: {k: list(v) for k, v in DEFAULT_SOURCES.items()}
+    )
+    sinks: dict[str, list[str]] = field(
+        default_factory=lambda: {k: list(v) for k, v in DEFAULT_SINKS.items()}
+    )
+    sanitizers: list[str] = field(default_factory=lambda: list(DEFAULT_SANITIZERS))
+
+    @classmethod
+    def from_config(cls, document: dict[str, Any]) -> "TaintRules":
+        """
+        The defaults extended by a config file's [taint] section; with
+        defaults = false the section replaces them.
+        """
+        section = document.get("taint") or {}
+        if not isinstance(section, dict):
+            raise ValueError("The taint section must be a mapping")
+        rules = cls() if section.get("defaults", True) else cls({}, {}, [])
+        for name in ("sources", "sinks"):
+            groups = section.get(name) or {}
+            if not isinstance(groups, dict):
+                raise ValueError(f"taint.{name} must map kinds to lists of patterns")
+            for kind, patterns in groups.items():
+                getattr(rules, name).setdefault(kind, []).extend(
+                    _patterns(f"taint.{name}.{kind}", patterns)
+                )
+        rules.sanitizers.extend(
+            _patterns("taint.sanitizers", section.get("sanitizers") or [])
+        )
+        return rules
+
+    def source_kind(self, text: str) -> str | None:
+        return _match(self.sources, text)
+
+    def sink_kind(self, text: str) -> str | None:
+        return _match(self.sinks, text)
+
+    def is_sanitizer(self, text: str) -> bool:
+        return any(fnmatchcase(text, pattern) for pattern in self.sanitizers)
+
+
+def _patterns(name: str, patterns: Any) -> list[str]:
+    if isinstance(patterns, str):
+        patterns = [patterns]
+    if not isinstance(patterns, list) or not all(
+        isinstance(p, str) for p in patterns
+    ):
+        raise ValueError(f"{name} must be a list of patterns")
+    return patterns
+
+
+def _match(groups: dict[str, list[str]], text: str) -> str | None:
+    for kind, patterns in groups.items():
+        if any(fnmatchcase(text, pattern) for pattern in patterns):
+            return kind
+    return None
+
+
+@dataclass
+class CallSite:
+    callee: str  # As written, e.g. "db.Query" or "r.URL.Query().Get"
+    line: int
+    args: list["Value"] = field(default_factory=list)
+    receiver: "Value | None" = None  # What a method is called on
+
+
+@dataclass
+class Value:
+    """What an expression is computed from."""
+
+    line: int = 0
+    variables: list[str] = field(default_factory=list)
+    reads: list[str] = field(default_factory=list)  # Member chains, e.g. "r.Body"
+    calls: list[CallSite] = field(default_factory=list)
+
+    def extend(self, other: "Value") -> "Value":
+        self.variables += other.variables
+        self.reads += other.reads
+        self.calls += other.calls
+        return self
+
+
+@dataclass
+class Step:
+    """An assignment, a return or an expression evaluated for its effect."""
+
+    targets: list[str]
+    values: list[Value]
+    returns: bool = False
+
+
+@dataclass
+class FunctionFlow:
+    qualified_name: str
+    label: str
+    path: str
+    params: list[str]
+    steps: list[Step]
+    receiver: str | None = None
+
+
+# Labels a tainted value carries: the source it came from, or the index of
+# the parameter it came through (RECEIVER for the receiver)
+RECEIVER = -1
+
+
+@dataclass(frozen=True)
+class TaintSource:
+    kind: str
+    text: str
+    function: str
+    line: int
+
+
+@dataclass
+class SinkHit:
+    function: str
+    kind: str
+    call: str
+    line: int
+    labels: frozenset
+
+
+@dataclass
+class CallEdge:
+    caller: str
+    callee: str
+    line: int
+    args: list[frozenset]
+    receiver: frozenset
+
+
+@dataclass
+class TaintPath:
+    """Untrusted data reaching a sink with no sanitizer on the way."""
+
+    source: TaintSource
+    sink_kind: str
+    sink_call: str
+    sink_function: str
+    sink_path: str
+    sink_line: int
+    source_path: str
+    functions: list[str]  # From the function reading the source to the sink's
+
+    @property
+    def vuln_type(self) -> str:
+        return f"{self.source.kind}_to_{self.sink_kind}"
+
+    @property
+    def id(self) -> str:
+        return (
+            f"taint:{self.sink_function}:{self.sink_line}:"
+            f"{self.source.function}:{self.source.line}"
+        )
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "type": self.vuln_type,
+            "source_kind": self.source.kind,
+            "source": self.source.text,
+            "source_location": f"{self.source_path}:{self.source.line}",
+            "sink_kind": self.sink_kind,
+            "sink": self.sink_call,
+            "sink_location": f"{self.sink_path}:{self.sink_line}",
+            "functions": self.functions,
+        }
+
+
+class TaintEngine:
+    """Propagates taint through function flows and collects source-to-sink paths."""
+
+    def __init__(
+        self,
+        rules: TaintRules,
+        flows: list[FunctionFlow],
+        call_edges: list[tuple[str, str]],
+    ):
+        self.rules = rules
+        self.flows = {flow.qualified_name: flow for flow in flows}
+        self.callees: dict[str, list[str]] = defaultdict(list)
+        for caller, callee in call_edges:
+            if callee in self.flows:
+                self.callees[caller].append(callee)
+        self.returns: dict[str, frozenset] = {qn: frozenset() for qn in self.flows}
+        self._hits: list[SinkHit] = []
+        self._edges: list[CallEdge] = []
+
+    def run(self) -> list[TaintPath]:
+        for _ in range(SUMMARY_ROUNDS):
+            changed = False
+            for flow in self.flows.values():
+                returned = self._run_function(flow, record=False)
+                if returned != self.returns[flow.qualified_name]:
+                    self.returns[flow.qualified_name] = returned
+                    changed = True
+            if not changed:
+                break
+        for flow in self.flows.values():
+            self._run_function(flow, record=True)
+        return self._paths()
+
+    def _run_function(self, flow: FunctionFlow, record: bool) -> frozenset:
+        env: dict[str, frozenset] = {
+            name: frozenset({index}) for index, name in enumerate(flow.params)
+        }
+        if flow.receiver:
+            env[flow.receiver] = frozenset({RECEIVER})
+        returned: frozenset = frozenset()
+        for number in range(BODY_PASSES):
+            last = record and number == BODY_PASSES - 1
+            for step in flow.steps:
+                values = [self._evaluate(v, env, flow, last) for v in step.values]
+                if step.returns:
+                    returned = returned.union(*values)
+                if len(step.targets) != len(values):
+                    values = [frozenset().union(*values)] * len(step.targets)
+                for target, labels in zip(step.targets, values):
+                    if target != "_":
+                        env[target] = labels
+        return returned
+
+    def _evaluate(
+        self, value: Value, env: dict[str, frozenset], flow: FunctionFlow, record: bool
+    ) -> frozenset:
+        labels: set = set()
+        for name in value.variables:
+            labels |= env.get(name, frozenset())
+        for read in value.reads:
+            kind = self.rules.source_kind(read)
+            if kind:
+                labels.add(TaintSource(kind, read, flow.qualified_name, value.line))
+        for call in value.calls:
+            labels |= self._call(call, env, flow, record)
+        return frozenset(labels)
+
+    def _call(
+        self,
+        call: CallSite,
+        env: dict[str, frozenset],
+        flow: FunctionFlow,
+        record: bool,
+    ) -> frozenset:
+        args = [self._evaluate(arg, env, flow, record) for arg in call.args]
+        receiver = (
+            self._evaluate(call.receiver, env, flow, record)
+            if call.receiver
+            else frozenset()
+        )
+        callee = self._resolve(flow.qualified_name, call.callee)
+        if record:
+            sink = self.rules.sink_kind(call.callee)
+            tainted = frozenset().union(*args)
+            if sink and tainted:
+                self._hits.append(
+                    SinkHit(flow.qualified_name, sink, call.callee, call.line, tainted)
+                )
+            if callee:
+                self._edges.append(
+                    CallEdge(flow.qualified_name, callee, call.line, args, receiver)
+                )
+
+        if self.rules.is_sanitizer(call.callee):
+            return frozenset()
+        kind = self.rules.source_kind(call.callee)
+        if kind:
+            return frozenset(
+                {TaintSource(kind, call.callee, flow.qualified_name, call.line)}
+            )
+        if callee is None:
+            # Library code: assume the result derives from everything passed in
+            return receiver.union(*args)
+        labels = set()
+        for label in self.returns[callee]:
+            if isinstance(label, TaintSource):
+                labels.add(label)
+            elif label == RECEIVER:
+                labels |= receiver
+            elif label < len(args):
+                labels |= args[label]
+        return frozenset(labels)
+
+    def _resolve(self, caller: str, callee: str) -> str | None:
+        """The repository function a call goes to, by the CALLS edges of its caller."""
+        name = re.split(r"[.:]", callee)[-1]
+        matches = sorted(
+            qn for qn in self.callees.get(caller, []) if qn.rsplit(".", 1)[-1] == name
+        )
+        return matches[0] if matches else None
+
+    def _paths(self) -> list[TaintPath]:
+        """
+        Sources reaching a sink directly, then callers passing sources into
+        parameters that reach one, followed back through the call graph.
+        """
+        calls_into: dict[str, list[CallEdge]] = defaultdict(list)
+        for edge in self._edges:
+            calls_into[edge.callee].append(edge)
+
+        paths: dict[tuple, TaintPath] = {}
+        pending: deque[tuple[SinkHit, str, int, list[str]]] = deque()
+        for hit in self._hits:
+            for label in hit.labels:
+                if isinstance(label, TaintSource):
+                    self._add_path(paths, hit, label, [hit.function])
+                else:
+                    pending.append((hit, hit.function, label, [hit.function]))
+
+        seen = set()
+        while pending:
+            hit, function, index, chain = pending.popleft()
+            for edge in calls_into[function]:
+                if index == RECEIVER:
+                    labels = edge.receiver
+                elif index < len(edge.args):
+                    labels = edge.args[index]
+                else:
+                    continue
+                for label in labels:
+                    if isinstance(label, TaintSource):
+                        self._add_path(paths, hit, label, [edge.caller, *chain])
+                        continue
+                    key = (id(hit), edge.caller, label)
+                    if key in seen or len(chain) >= MAX_CALL_DEPTH:
+                        continue
+                    seen.add(key)
+                    pending.append((hit, edge.caller, label, [edge.caller, *chain]))
+        return sorted(
+            paths.values(),
+            key=lambda p: (p.sink_path, p.sink_line, p.source_path, p.source.line),
+        )
+
+    def _add_path(
+        self,
+        paths: dict[tuple, TaintPath],
+        hit: SinkHit,
+        source: TaintSource,
+        chain: list[str],
+    ) -> None:
+        if chain[0] != source.function:
+            # Read in a helper whose return value carried it
+            chain = [source.function, *chain]
+        key = (source, hit.function, hit.line, hit.kind)
+        if key in paths and len(paths[key].functions) <= len(chain):
+            return
+        paths[key] = TaintPath(
+            source=source,
+            sink_kind=hit.kind,
+            sink_call=hit.call,
+            sink_function=hit.function,
+            sink_path=self.flows[hit.function].path,
+            sink_line=hit.line,
+            source_path=self.flows[source.function].path,
+            functions=chain,
+        )
+
+
+class FlowExtractor:
+    """Reads the steps of each Go and Python function of a file with Tree-sitter."""
+
+    def __init__(self, language: str):
+        self.language = language
+        if language == "go":
+            self.function_types = ("function_declaration", "method_declaration")
+            self.member, self.member_object = "selector_expression", "operand"
+            self.call = "call_expression"
+            self.inline_functions = ("func_literal",)
+        else:
+            self.function_types = ("function_definition",)
+            self.member, self.member_object = "attribute", "object"
+            self.call = "call"
+            self.inline_functions = ("lambda",)
+
+    def functions(self, root: Node) -> list[Node]:
+        found = []
+        stack = [root]
+        while stack:
+            node = stack.pop()
+            if node.type in self.function_types:
+                found.append(node)
+            stack.extend(reversed(node.named_children))
+        return found
+
+    def flow(self, node: Node) -> tuple[list[str], str | None, list[Step]]:
+        """The parameters, receiver and steps of a function node."""
+        params = self._params(node.child_by_field_name("parameters"))
+        receiver = None
+        if self.language == "go" and node.type == "method_declaration":
+            receivers = self._params(node.child_by_field_name("receiver"))
+            receiver = receivers[0] if receivers else None
+        elif params and params[0] in ("self", "cls") and self._in_class(node):
+            receiver = params.pop(0)
+        steps: list[Step] = []
+        body = node.child_by_field_name("body")
+        if body is not None:
+            self._statements(body, steps)
+        return params, receiver, steps
+
+    def _params(self, node: Node | None) -> list[str]:
+        if node is None:
+            return []
+        names = []
+        for child in node.named_children:
+            if child.type in GO_PARAMETERS:
+                # Unnamed Go parameters still take an argument position
+                declared = child.children_by_field_name("name")
+                names += [_compact_text(n) for n in declared] or ["_"]
+            elif child.type == "identifier":
+                names.append(_compact_text(child))
+            elif child.type not in ("keyword_separator", "positional_separator"):
+                name = child.child_by_field_name("name") or _first_identifier(child)
+                if name is not None:
+                    names.append(_compact_text(name))
+        return names
+
+    def _in_class(self, node: Node) -> bool:
+        parent = node.parent
+        while parent is not None and parent.type in ("block", "decorated_definition"):
+            parent = parent.parent
+        return parent is not None and parent.type == "class_definition"
+
+    def _statements(self, node: Node, steps: list[Step]) -> None:
+        for child in node.named_children:
+            self._statement(child, steps)
+
+    def _statement(self, node: Node, steps: list[Step]) -> None:
+        kind = node.type
+        if kind in self.function_types or kind in ("class_definition", "comment"):
+            return
+        assignment = self._assignment(node)
+        if assignment is not None:
+            targets, value_nodes, compound = assignment
+            values = [self.value(n, steps) for n in value_nodes]
+            if compound:
+                merged = Value(node.start_point[0] + 1, list(targets))
+                for value in values:
+                    merged.extend(value)
+                values = [merged]
+            steps.append(Step(targets, values))
+            body = node.child_by_field_name("body")
+            if body is not None:
+                self._statements(body, steps)
+            return
+        if kind == "return_statement":
+            values = [self.value(child, steps) for child in node.named_children]
+            steps.append(Step([], values, returns=True))
+        elif kind in ("block", "statement_list", "var_declaration") or kind.endswith(
+            ("_statement", "_clause", "_case")
+        ):
+            self._statements(node, steps)
+        else:
+            steps.append(Step([], [self.value(node, steps)]))
+
+    def _assignment(self, node: Node) -> tuple[list[str], list[Node], bool] | None:
+        """Targets, value expressions and whether the targets' old values remain."""
+        kind = node.type
+        left = node.child_by_field_name("left")
+        right = node.child_by_field_name("right")
+        if kind in ("short_var_declaration", "assignment_statement"):
+            operator = node.child_by_field_name("operator")
+            compound = operator is not None and _compact_text(operator) not in (
+                "=",
+                ":=",
+            )
+            return self._targets(left), list(right.named_children), compound
+        if kind == "var_spec":
+            value = node.child_by_field_name("value")
+            names = [_compact_text(n) for n in node.children_by_field_name("name")]
+            return names, list(value.named_children) if value else [], False
+        if kind in ("range_clause", "for_statement") and right is not None:
+            return self._targets(left), [right], False
+        if kind in ("assignment", "augmented_assignment") and right is not None:
+            targets = self._targets(left)
+            unpacked = left.type in ("pattern_list", "tuple_pattern", "list_pattern")
+            if unpacked and right.type in ("expression_list", "tuple", "list"):
+                return targets, list(right.named_children), False
+            return targets, [right], kind == "augmented_assignment"
+        return None
+
+    def _targets(self, node: Node | None) -> list[str]:
+        if node is None:
+            return []
+        if node.type in ("identifier", self.member):
+            return [_compact_text(node)]
+        if node.type in ("index_expression", "subscript"):
+            # Storing into a map or list taints the whole of it
+            return self._targets(node.named_children[0])
+        targets = []
+        for child in node.named_children:
+            targets += self._targets(child)
+        return targets
+
+    def value(self, node: Node, steps: list[Step]) -> Value:
+        line = node.start_point[0] + 1
+        if node.type == "identifier":
+            return Value(line, [_compact_text(node)])
+        if node.type == self.member:
+            text = _compact_text(node)
+            inner = self.value(node.child_by_field_name(self.member_object), steps)
+            # A field can be assigned to and read back like a variable
+            return Value(line, [text], [text]).extend(inner)
+        if node.type == self.call:
+            return Value(line, calls=[self._call_site(node, steps)])
+        if node.type in self.inline_functions:
+            body = node.child_by_field_name("body")
+            if body is not None and self.language == "go":
+                self._statements(body, steps)
+            return Value(line)
+        if node.type == "keyword_argument":
+            return self.value(node.child_by_field_name("value"), steps)
+        if node.type in self.function_types or node.type == "field_identifier":
+            return Value(line)
+        merged = Value(line)
+        for child in node.named_children:
+            merged.extend(self.value(child, steps))
+        return merged
+
+    def _call_site(self, node: Node, steps: list[Step]) -> CallSite:
+        function = node.child_by_field_name("function")
+        receiver = None
+        if function.type == self.member:
+            operand = function.child_by_field_name(self.member_object)
+            receiver = self.value(operand, steps)
+        elif function.type != "identifier":
+            receiver = self.value(function, steps)
+        arguments = node.child_by_field_name("arguments")
+        args = [
+            self.value(arg, steps)
+            for arg in (arguments.named_children if arguments else [])
+            if arg.type != "comment"
+        ]
+        return CallSite(
+            _compact_text(function), node.start_point[0] + 1, args, receiver
+        )
+
+
+def _compact_text(node: Node) -> str:
+    return re.sub(r"\s+", "", node_text(node))
+
+
+def _first_identifier(node: Node) -> Node | None:
+    for child in node.named_children:
+        if child.type == "identifier":
+            return child
+    return None
+
+
+class TaintAnalyzer:
+    """Finds taint paths in a repository's Go and Python code and stores them."""
+
+    def __init__(self, ingestor: Any, parsers: dict[str, Parser], rules: TaintRules):
+        self.ingestor = ingestor
+        self.parsers = parsers
+        self.rules = rules
+        self.labels: dict[str, str] = {}  # Function or Method, by qualified name
+
+    def analyze(self, repo_path: Path) -> list[TaintPath]:
+        locator = CodeLocator(self.ingestor)
+        flows = []
+        for file_path in sorted(repo_path.rglob("*")):
+            relative = file_path.relative_to(repo_path)
+            if not file_path.is_file() or IGNORED_DIRS.intersection(relative.parts):
+                continue
+            lang_config = get_language_config(file_path.suffix)
+            if not lang_config or lang_config.name not in ("go", "python"):
+                continue
+            if lang_config.name not in self.parsers:
+                continue
+            try:
+                source = file_path.read_bytes()
+            except OSError as e:
+                logger.warning(f"Could not read {file_path}: {e}")
+                continue
+            flows += self.extract_flows(
+                source, relative.as_posix(), lang_config.name, locator
+            )
+        edges = [
+            (row["caller"], row["callee"])
+            for row in self.ingestor.fetch_all(CALL_EDGES_QUERY)
+        ]
+        self.labels = {flow.qualified_name: flow.label for flow in flows}
+        paths = TaintEngine(self.rules, flows, edges).run()
+        logger.info(f"Found {len(paths)} taint paths in {len(flows)} functions")
+        return paths
+
+    def extract_flows(
+        self, source: bytes, rel_path: str, language: str, locator: CodeLocator
+    ) -> list[FunctionFlow]:
+        """Flows of the functions in one file, named as in the graph."""
+        extractor = FlowExtractor(language)
+        root = self.parsers[language].parse(source).root_node
+        flows = []
+        for node in extractor.functions(root):
+            name = node.child_by_field_name("name")
+            line = node.start_point[0] + 1
+            function = locator.at_line(rel_path, line)
+            if (
+                name is None
+                or function is None
+                or function["name"] != _compact_text(name)
+            ):
+                continue
+            params, receiver, steps = extractor.flow(node)
+            flows.append(
+                FunctionFlow(
+                    function["qualified_name"],
+                    function["label"],
+                    rel_path,
+                    params,
+                    steps,
+                    receiver,
+                )
+            )
+        return flows
+
+    def store_paths(self, paths: list[TaintPath]) -> None:
+        """
+        Record each path as a Vulnerability of the sink's module and link the
+        functions it passes through with FLOWS_TO.
+        """
+        for query in CLEAR_TAINT_PATHS:
+            self.ingestor.execute_write(query)
+        for path in paths:
+            self.ingestor.ensure_node_batch(
+                "Vulnerability",
+                {
+                    "id": path.id,
+                    "type": path.vuln_type,
+                    "severity": "high",
+                    "description": (
+                        f"{path.source.text} ({path.source.kind}) reaches "
+                        f"{path.sink_call} ({path.sink_kind}) without a sanitizer"
+                    ),
+                    "line_number": path.sink_line,
+                    "code_snippet": " -> ".join(path.functions),
+                    "cwe_id": CWE_BY_SINK.get(path.sink_kind, "CWE-20"),
+                    "recommendation": "Validate or sanitize the value before use",
+                    "confidence": 0.7,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "path", path.sink_path),
+                "HAS_VULNERABILITY",
+                ("Vulnerability", "id", path.id),
+                {"file_path": path.sink_path},
+            )
+            for source, target in zip(path.functions, path.functions[1:]):
+                self.ingestor.ensure_relationship_batch(
+                    (self.labels.get(source, "Function"), "qualified_name", source),
+                    "FLOWS_TO",
+                    (self.labels.get(target, "Function"), "qualified_name", target),
+                    {
+                        "taint_id": path.id,
+                        "source_kind": path.source.kind,
+                        "sink_kind": path.sink_kind,
+                    },
+                )
+        self.ingestor.flush_all()
+        logger.info(f"Stored {len(paths)} taint paths")
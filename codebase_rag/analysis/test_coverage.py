@@ -119,11 +119,13 @@ class TestCodeAnalyzer:
                 )
                 tested_items.extend(content_matches)
 
-            # Create relationships for the best matches
+            # Create relationships for the best matches, naming the test by
+            # its key where names repeat within a file (Ginkgo specs)
+            test_key = getattr(test_node, "key", test_node.name)
             best_matches = self._select_best_matches(tested_items)
             for match in best_matches:
                 relationships.append(
-                    (test_node.name, "TESTS", match.tested_type, match.tested_function)
+                    (test_key, "TESTS", match.tested_type, match.tested_function)
                 )
                 self.links.append(match)
 
@@ -284,9 +286,14 @@ class TestCodeAnalyzer:
         test_code = ""
         if hasattr(test_node, "start_line") and hasattr(test_node, "end_line"):
             lines = test_content.split("\n")
-            start = max(0, test_node.start_line - 1)
-            end = min(len(lines), test_node.end_line)
-            test_code = "\n".join(lines[start:end])
+            ranges = [(test_node.start_line, test_node.end_line)]
+            # Setup blocks that run with the test, e.g. Ginkgo BeforeEach
+            properties = getattr(test_node, "properties", None) or {}
+            ranges.extend(properties.get("setup_lines", []))
+            test_code = "\n".join(
+                "\n".join(lines[max(0, start - 1) : min(len(lines), end)])
+                for start, end in ranges
+            )
 
         if not test_code:
             return matches
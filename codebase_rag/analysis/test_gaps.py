@@ -0,0 +1,120 @@
+"""Test gap analysis: public functions and HTTP endpoints that nothing tests."""
+
+from collections import defaultdict
+from dataclasses import asdict, dataclass
+from pathlib import PurePosixPath
+from typing import Any
+
+from ..parsers.test_detector import TestDetector
+from ..utils.visibility import is_exported, language_for_path
+
+# Functions and methods with no inbound TESTS edge and no coverage data
+UNTESTED_FUNCTIONS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..3]->(f)
+WHERE (f:Function OR f:Method)
+  AND NOT (f)<-[:TESTS]-()
+  AND NOT (f)-[:COVERED_BY]->()
+  AND (f.coverage_percent IS NULL OR f.coverage_percent = 0)
+RETURN DISTINCT f.qualified_name AS qualified_name, f.name AS name,
+       labels(f)[0] AS label, m.path AS path,
+       f.cyclomatic_complexity AS complexity, f.start_line AS line_number,
+       f.is_static AS is_static
+"""
+
+# Endpoints that are not tested directly and whose handler is not tested either
+UNTESTED_ENDPOINTS_QUERY = """
+MATCH (m:Module)-[:DEFINES_ENDPOINT]->(e:Endpoint)
+WHERE NOT (e)<-[:TESTS]-()
+OPTIONAL MATCH (e)-[:HANDLED_BY]->(h)
+WITH m, e, h
+WHERE h IS NULL
+   OR (NOT (h)<-[:TESTS]-() AND NOT (h)-[:COVERED_BY]->()
+       AND (h.coverage_percent IS NULL OR h.coverage_percent = 0))
+RETURN DISTINCT e.qualified_name AS qualified_name,
+       e.method + ' ' + e.route AS name, 'Endpoint' AS label, m.path AS path,
+       h.cyclomatic_complexity AS complexity, e.line_number AS line_number,
+       false AS is_static
+"""
+
+
+@dataclass
+class TestGap:
+    """An exported function or endpoint with no tests and no coverage."""
+
+    __test__ = False  # Not a pytest test class
+
+    qualified_name: str
+    name: str
+    label: str  # "Function", "Method" or "Endpoint"
+    path: str
+    package: str  # Directory containing the file
+    complexity: int
+    line_number: int | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class TestGapAnalyzer:
+    """Finds untested public API in the code graph."""
+
+    __test__ = False
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self.test_detector = TestDetector()
+
+    def find_gaps(self, include_endpoints: bool = True) -> list[TestGap]:
+        """Return untested exported functions and endpoints, most complex first."""
+        rows = list(self.ingestor.fetch_all(UNTESTED_FUNCTIONS_QUERY))
+        if include_endpoints:
+            rows.extend(self.ingestor.fetch_all(UNTESTED_ENDPOINTS_QUERY))
+        return collect_test_gaps(rows, self.test_detector)
+
+    def find_gaps_by_package(
+        self, include_endpoints: bool = True
+    ) -> dict[str, list[TestGap]]:
+        """Return test gaps grouped by package, riskiest packages first."""
+        return group_by_package(self.find_gaps(include_endpoints))
+
+
+def collect_test_gaps(
+    rows: list[dict[str, Any]], test_detector: TestDetector | None = None
+) -> list[TestGap]:
+    """Filter graph rows down to public, non-test symbols and sort by complexity."""
+    test_detector = test_detector or TestDetector()
+    gaps = []
+    for row in rows:
+        path = row.get("path") or ""
+        language = language_for_path(path)
+        if language and test_detector.is_test_file(path, language):
+            continue
+        if row.get("label") != "Endpoint" and not is_exported(
+            row.get("name") or "", language, bool(row.get("is_static"))
+        ):
+            continue
+        gaps.append(
+            TestGap(
+                qualified_name=row["qualified_name"],
+                name=row.get("name") or row["qualified_name"],
+                label=row.get("label") or "Function",
+                path=path,
+                package=str(PurePosixPath(path).parent) if path else "",
+                complexity=row.get("complexity") or 1,
+                line_number=row.get("line_number"),
+            )
+        )
+    return sorted(gaps, key=lambda g: (-g.complexity, g.qualified_name))
+
+
+def group_by_package(gaps: list[TestGap]) -> dict[str, list[TestGap]]:
+    """
+    Group complexity-sorted gaps by package.
+
+    Packages appear in order of their most complex gap, and gaps keep their
+    complexity ordering within each package.
+    """
+    grouped: dict[str, list[TestGap]] = defaultdict(list)
+    for gap in gaps:
+        grouped[gap.package].append(gap)
+    return dict(grouped)
@@ -0,0 +1,340 @@
+"""JUnit XML test-result ingestion and flaky test detection."""
+
+import hashlib
+import re
+import xml.etree.ElementTree as ET
+from collections import defaultdict
+from dataclasses import asdict, dataclass, field
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+PASSED = "passed"
+FAILED = "failed"
+ERROR = "error"
+SKIPPED = "skipped"
+# Passed only after a retry within the same run (Surefire flakyFailure/flakyError)
+RETRIED = "retried"
+
+# Single-character codes used for the compact status history on test nodes
+STATUS_CODES = {PASSED: "P", FAILED: "F", ERROR: "E", SKIPPED: "S", RETRIED: "R"}
+
+# Test nodes the results can be attached to, with their simple names
+TEST_NODES_QUERY = """
+MATCH (t)
+WHERE t:TestCase OR t:TestFunction
+RETURN t.qualified_name AS qualified_name, t.name AS name, labels(t)[0] AS label
+"""
+
+# Full ordered result history for a set of test nodes
+TEST_HISTORY_QUERY = """
+MATCH (r:TestResult)-[:RESULT_OF]->(t)
+WHERE t.qualified_name IN $qualified_names
+RETURN t.qualified_name AS qualified_name, labels(t)[0] AS label,
+       r.status AS status, r.timestamp AS timestamp
+ORDER BY r.timestamp
+"""
+
+# Flaky tests together with the production code they exercise
+FLAKY_TESTS_QUERY = """
+MATCH (t)
+WHERE (t:TestCase OR t:TestFunction) AND t.is_flaky = true
+OPTIONAL MATCH (t)-[:TESTS]->(code)
+RETURN t.qualified_name AS qualified_name, t.run_count AS run_count,
+       t.fail_count AS fail_count, t.flakiness_score AS flakiness_score,
+       t.status_history AS status_history,
+       collect(DISTINCT code.qualified_name) AS exercises
+ORDER BY t.flakiness_score DESC, t.fail_count DESC
+"""
+
+
+@dataclass
+class TestResult:
+    """The outcome of one test case in one run."""
+
+    __test__ = False  # Not a pytest test class
+
+    classname: str
+    name: str
+    status: str
+    duration: float = 0.0
+    message: str = ""
+
+
+@dataclass
+class TestRun:
+    """A single JUnit XML report, treated as one CI run."""
+
+    __test__ = False
+
+    run_id: str
+    timestamp: str  # ISO 8601
+    source: str
+    results: list[TestResult] = field(default_factory=list)
+
+    def count(self, status: str) -> int:
+        return sum(1 for result in self.results if result.status == status)
+
+
+@dataclass
+class TestHistory:
+    """Aggregated pass/fail history of a single test across runs."""
+
+    __test__ = False
+
+    qualified_name: str
+    label: str
+    statuses: list[str]
+    run_count: int = 0
+    fail_count: int = 0
+    pass_rate: float = 0.0
+    flakiness_score: float = 0.0  # Fraction of consecutive runs that flipped outcome
+    is_flaky: bool = False
+    last_status: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+def parse_junit_xml(path: Path) -> TestRun:
+    """
+    Parse a JUnit XML report into a TestRun.
+
+    Both ``<testsuites>`` and bare ``<testsuite>`` roots are accepted. The
+    run id is a hash of the file contents, so re-ingesting the same report
+    is idempotent.
+    """
+    data = path.read_bytes()
+    root = ET.fromstring(data)
+    suites = [root] if root.tag == "testsuite" else root.iter("testsuite")
+
+    timestamp = root.get("timestamp") or ""
+    results = []
+    for suite in suites:
+        timestamp = timestamp or suite.get("timestamp") or ""
+        for case in suite.findall("testcase"):
+            results.append(_parse_testcase(case, suite.get("name", "")))
+
+    if not timestamp:
+        timestamp = datetime.fromtimestamp(path.stat().st_mtime, tz=UTC).isoformat()
+
+    return TestRun(
+        run_id=hashlib.sha1(data).hexdigest()[:16],
+        timestamp=timestamp,
+        source=str(path),
+        results=results,
+    )
+
+
+def _parse_testcase(case: ET.Element, suite_name: str) -> TestResult:
+    status, message = PASSED, ""
+    for child in case:
+        tag = child.tag
+        if tag in ("failure", "error"):
+            status = FAILED if tag == "failure" else ERROR
+            message = child.get("message") or (child.text or "").strip()
+            break
+        if tag == "skipped":
+            status = SKIPPED
+            break
+        if tag in ("flakyFailure", "flakyError", "rerunFailure", "rerunError"):
+            status = RETRIED
+            message = child.get("message") or ""
+
+    try:
+        duration = float(case.get("time") or 0)
+    except ValueError:
+        duration = 0.0
+
+    return TestResult(
+        classname=case.get("classname") or suite_name,
+        name=case.get("name", ""),
+        status=status,
+        duration=duration,
+        message=message[:500],
+    )
+
+
+def collect_reports(paths: list[Path]) -> list[Path]:
+    """Expand directories into the XML reports they contain."""
+    reports = []
+    for path in paths:
+        if path.is_dir():
+            reports.extend(sorted(path.rglob("*.xml")))
+        elif path.is_file():
+            reports.append(path)
+    return reports
+
+
+def summarize_history(
+    qualified_name: str, label: str, statuses: list[str], min_runs: int = 3
+) -> TestHistory:
+    """
+    Summarize a chronological list of statuses for one test.
+
+    Skipped runs are ignored. A test is flaky when it has at least
+    ``min_runs`` executed runs and either flipped between passing and
+    failing more than once or passed only after a retry.
+    """
+    executed = [s for s in statuses if s != SKIPPED]
+    # A retried pass is an intermittent failure within a single run
+    outcomes = [s in (FAILED, ERROR) for s in executed]
+    failures = sum(outcomes)
+    flips = sum(1 for a, b in zip(outcomes, outcomes[1:], strict=False) if a != b)
+
+    history = TestHistory(
+        qualified_name=qualified_name,
+        label=label,
+        statuses=statuses,
+        run_count=len(executed),
+        fail_count=failures,
+        last_status=statuses[-1] if statuses else "",
+    )
+    if executed:
+        history.pass_rate = 1 - failures / len(executed)
+    if len(executed) > 1:
+        history.flakiness_score = flips / (len(executed) - 1)
+    history.is_flaky = len(executed) >= min_runs and (flips > 1 or RETRIED in executed)
+    return history
+
+
+class TestResultAnalyzer:
+    """Attaches CI test results to test nodes and surfaces flaky tests."""
+
+    __test__ = False
+
+    def __init__(self, ingestor: Any, min_runs: int = 3):
+        self.ingestor = ingestor
+        self.min_runs = min_runs
+
+    def ingest_runs(self, runs: list[TestRun]) -> dict[str, int]:
+        """Store runs and results, then refresh the history of affected tests."""
+        test_nodes = self._index_test_nodes()
+        touched: set[str] = set()
+        unmatched = 0
+
+        for run in runs:
+            self.ingestor.ensure_node_batch(
+                "TestRun",
+                {
+                    "run_id": run.run_id,
+                    "timestamp": run.timestamp,
+                    "source": run.source,
+                    "total": len(run.results),
+                    "failures": run.count(FAILED) + run.count(ERROR),
+                    "skipped": run.count(SKIPPED),
+                },
+            )
+            for result in run.results:
+                match = match_test_node(result, test_nodes)
+                result_id = f"{run.run_id}:{result.classname}.{result.name}"
+                self.ingestor.ensure_node_batch(
+                    "TestResult",
+                    {
+                        "result_id": result_id,
+                        "classname": result.classname,
+                        "name": result.name,
+                        "status": result.status,
+                        "duration": result.duration,
+                        "message": result.message,
+                        "timestamp": run.timestamp,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("TestRun", "run_id", run.run_id),
+                    "HAS_RESULT",
+                    ("TestResult", "result_id", result_id),
+                )
+                if match is None:
+                    unmatched += 1
+                    continue
+                label, qualified_name = match
+                self.ingestor.ensure_relationship_batch(
+                    ("TestResult", "result_id", result_id),
+                    "RESULT_OF",
+                    (label, "qualified_name", qualified_name),
+                )
+                touched.add(qualified_name)
+
+        self.ingestor.flush_all()
+        histories = self.refresh_histories(sorted(touched))
+        return {
+            "runs": len(runs),
+            "results": sum(len(run.results) for run in runs),
+            "matched_tests": len(touched),
+            "unmatched_results": unmatched,
+            "flaky_tests": sum(1 for h in histories if h.is_flaky),
+        }
+
+    def refresh_histories(self, qualified_names: list[str]) -> list[TestHistory]:
+        """Recompute pass/fail summaries on test nodes from all stored results."""
+        if not qualified_names:
+            return []
+        rows = self.ingestor.fetch_all(
+            TEST_HISTORY_QUERY, {"qualified_names": qualified_names}
+        )
+        statuses: dict[tuple[str, str], list[str]] = defaultdict(list)
+        for row in rows:
+            statuses[(row["qualified_name"], row["label"])].append(row["status"])
+
+        histories = [
+            summarize_history(qn, label, history, self.min_runs)
+            for (qn, label), history in statuses.items()
+        ]
+        for history in histories:
+            self.ingestor.ensure_node_batch(
+                history.label,
+                {
+                    "qualified_name": history.qualified_name,
+                    "run_count": history.run_count,
+                    "fail_count": history.fail_count,
+                    "pass_rate": round(history.pass_rate, 4),
+                    "flakiness_score": round(history.flakiness_score, 4),
+                    "is_flaky": history.is_flaky,
+                    "last_status": history.last_status,
+                    "status_history": "".join(
+                        STATUS_CODES.get(s, "?") for s in history.statuses[-50:]
+                    ),
+                },
+            )
+        self.ingestor.flush_all()
+        logger.info(f"Updated result history on {len(histories)} test nodes")
+        return histories
+
+    def find_flaky_tests(self) -> list[dict[str, Any]]:
+        """Return flaky tests with the production code they exercise."""
+        return self.ingestor.fetch_all(FLAKY_TESTS_QUERY)  # type: ignore[no-any-return]
+
+    def _index_test_nodes(self) -> dict[str, list[tuple[str, str]]]:
+        index: dict[str, list[tuple[str, str]]] = defaultdict(list)
+        for row in self.ingestor.fetch_all(TEST_NODES_QUERY):
+            if row.get("name"):
+                index[row["name"]].append((row["label"], row["qualified_name"]))
+        return index
+
+
+def match_test_node(
+    result: TestResult, test_nodes: dict[str, list[tuple[str, str]]]
+) -> tuple[str, str] | None:
+    """
+    Find the graph test node for a JUnit result.
+
+    Candidates share the test's simple name; ties are broken by how many
+    components of the JUnit classname appear in the node's qualified name.
+    """
+    # Parametrized tests report as "test_x[param]"; subtests as "TestX/case"
+    name = re.sub(r"\[.*\]$", "", result.name).split("/")[0]
+    candidates = test_nodes.get(name, [])
+    if not candidates:
+        return None
+    if len(candidates) == 1:
+        return candidates[0]
+
+    class_parts = {p for p in re.split(r"[./:]+", result.classname) if p}
+
+    def overlap(candidate: tuple[str, str]) -> int:
+        return len(class_parts & set(candidate[1].split(".")))
+
+    return min(candidates, key=lambda c: (-overlap(c), c[1]))
@@ -0,0 +1,47 @@
+"""Queries over TODO/FIXME/HACK nodes extracted during ingestion."""
+
+from typing import Any
+
+# Todo nodes with their owning function (if any), oldest first
+TODOS_QUERY = """
+MATCH (owner)-[:HAS_TODO]->(t:Todo)
+WHERE ($path_prefix = '' OR t.path STARTS WITH $path_prefix)
+  AND ($kinds IS NULL OR t.kind IN $kinds)
+  AND ($author = '' OR t.author = $author OR t.author_email = $author)
+RETURN t.qualified_name AS qualified_name, t.kind AS kind, t.text AS text,
+       t.tag AS tag, t.path AS path, t.line_number AS line_number,
+       t.author AS author, t.created_at AS created_at,
+       owner.qualified_name AS owner
+ORDER BY t.created_at = '', t.created_at ASC, t.path, t.line_number
+LIMIT $limit
+"""
+
+
+class TodoAnalyzer:
+    """Lists tracked TODO comments, e.g. the oldest TODOs in one package."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def find_todos(
+        self,
+        path_prefix: str = "",
+        kinds: list[str] | None = None,
+        author: str = "",
+        limit: int = 50,
+    ) -> list[dict[str, Any]]:
+        """
+        Return TODOs oldest first, optionally filtered by package path, kind
+        and author. TODOs without blame information sort last.
+        """
+        if path_prefix and not path_prefix.endswith("/"):
+            path_prefix += "/"
+        return self.ingestor.fetch_all(  # type: ignore[no-any-return]
+            TODOS_QUERY,
+            {
+                "path_prefix": path_prefix,
+                "kinds": [k.upper() for k in kinds] if kinds else None,
+                "author": author,
+                "limit": limit,
+            },
+        )
@@ -0,0 +1,306 @@
+"""Map exported OpenTelemetry traces onto function nodes as observed calls."""
+
+import json
+import math
+import re
+from collections import defaultdict
+from dataclasses import dataclass, field
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .code_locator import CodeLocator
+
+# Error status codes: numeric in OTLP/JSON, by name in some exporters
+STATUS_ERROR = (2, "STATUS_CODE_ERROR")
+
+ENDPOINT_HANDLERS_QUERY = """
+MATCH (e:Endpoint)-[:HANDLED_BY]->(f)
+RETURN e.method AS method, e.route AS route, f.qualified_name AS qualified_name,
+       labels(f)[0] AS label
+"""
+
+# Counts and totals accumulate across ingests; percentiles describe the latest
+RECORD_OBSERVED_CALLS_QUERY = """
+UNWIND $edges AS e
+MATCH (a:{caller_label} {{qualified_name: e.caller}})
+MATCH (b:{callee_label} {{qualified_name: e.callee}})
+MERGE (a)-[r:OBSERVED_CALL]->(b)
+SET r.count = coalesce(r.count, 0) + e.count,
+    r.error_count = coalesce(r.error_count, 0) + e.error_count,
+    r.total_ms = coalesce(r.total_ms, 0.0) + e.total_ms,
+    r.max_ms = CASE WHEN coalesce(r.max_ms, 0.0) > e.max_ms
+                    THEN r.max_ms ELSE e.max_ms END,
+    r.p50_ms = e.p50_ms, r.p95_ms = e.p95_ms,
+    r.services = e.services, r.last_seen = e.last_seen
+SET r.avg_ms = r.total_ms / r.count
+"""
+
+# Semantic convention attribute names, current ones first
+FUNCTION_ATTRIBUTES = ("code.function.name", "code.function")
+NAMESPACE_ATTRIBUTES = ("code.namespace",)
+FILE_ATTRIBUTES = ("code.file.path", "code.filepath")
+LINE_ATTRIBUTES = ("code.line.number", "code.lineno")
+ROUTE_ATTRIBUTES = ("http.route",)
+METHOD_ATTRIBUTES = ("http.request.method", "http.method")
+
+# Route parameters as written by different frameworks: {id}, :id, <id>, <int:id>
+ROUTE_PARAMETER = re.compile(r"\{[^}]*\}|:\w+|<[^>]*>")
+
+
+@dataclass
+class Span:
+    """One span of an exported trace."""
+
+    trace_id: str
+    span_id: str
+    parent_span_id: str
+    name: str
+    service: str
+    start_ns: int
+    end_ns: int
+    is_error: bool = False
+    attributes: dict[str, Any] = field(default_factory=dict)
+
+    @property
+    def key(self) -> tuple[str, str]:
+        return self.trace_id, self.span_id
+
+    @property
+    def duration_ms(self) -> float:
+        return max(self.end_ns - self.start_ns, 0) / 1_000_000
+
+    def attribute(self, names: tuple[str, ...]) -> Any:
+        for name in names:
+            if name in self.attributes:
+                return self.attributes[name]
+        return None
+
+
+@dataclass
+class ObservedCall:
+    """Aggregated runtime calls from one function to another."""
+
+    caller: tuple[str, str]  # (label, qualified_name)
+    callee: tuple[str, str]
+    durations: list[float] = field(default_factory=list)
+    error_count: int = 0
+    services: set[str] = field(default_factory=set)
+    last_seen_ns: int = 0
+
+    def to_row(self) -> dict[str, Any]:
+        durations = sorted(self.durations)
+        return {
+            "caller": self.caller[1],
+            "callee": self.callee[1],
+            "count": len(durations),
+            "error_count": self.error_count,
+            "total_ms": round(sum(durations), 3),
+            "max_ms": round(durations[-1], 3),
+            "p50_ms": round(percentile(durations, 50), 3),
+            "p95_ms": round(percentile(durations, 95), 3),
+            "services": sorted(self.services),
+            "last_seen": datetime.fromtimestamp(
+                self.last_seen_ns / 1e9, tz=UTC
+            ).isoformat(),
+        }
+
+
+def percentile(sorted_values: list[float], p: float) -> float:
+    """Nearest-rank percentile of an ascending list."""
+    if not sorted_values:
+        return 0.0
+    rank = max(math.ceil(p / 100 * len(sorted_values)), 1)
+    return sorted_values[rank - 1]
+
+
+def parse_otlp_json(content: str) -> list[Span]:
+    """
+    Spans of an OTLP/JSON export: a single ExportTraceServiceRequest, or one
+    per line as written by the collector's file exporter.
+    """
+    content = content.strip()
+    if not content:
+        return []
+    try:
+        documents = [json.loads(content)]
+    except json.JSONDecodeError:
+        documents = [json.loads(line) for line in content.splitlines() if line.strip()]
+
+    spans = []
+    for document in documents:
+        for resource_spans in document.get("resourceSpans") or []:
+            resource = _attributes(
+                (resource_spans.get("resource") or {}).get("attributes")
+            )
+            service = str(resource.get("service.name") or "")
+            # Exports before OTLP 0.15 call scope spans instrumentationLibrarySpans
+            scopes = resource_spans.get("scopeSpans") or resource_spans.get(
+                "instrumentationLibrarySpans"
+            )
+            for scope_spans in scopes or []:
+                for span in scope_spans.get("spans") or []:
+                    spans.append(_span(span, service))
+    return spans
+
+
+def _span(span: dict[str, Any], service: str) -> Span:
+    return Span(
+        trace_id=span.get("traceId") or "",
+        span_id=span.get("spanId") or "",
+        parent_span_id=span.get("parentSpanId") or "",
+        name=span.get("name") or "",
+        service=service,
+        start_ns=int(span.get("startTimeUnixNano") or 0),
+        end_ns=int(span.get("endTimeUnixNano") or 0),
+        is_error=(span.get("status") or {}).get("code") in STATUS_ERROR,
+        attributes=_attributes(span.get("attributes")),
+    )
+
+
+def _attributes(attributes: list[dict[str, Any]] | None) -> dict[str, Any]:
+    values = {}
+    for attribute in attributes or []:
+        value = attribute.get("value") or {}
+        if "stringValue" in value:
+            values[attribute["key"]] = value["stringValue"]
+        elif "intValue" in value:
+            # int64 values are strings in OTLP/JSON
+            values[attribute["key"]] = int(value["intValue"])
+        elif "doubleValue" in value:
+            values[attribute["key"]] = float(value["doubleValue"])
+        elif "boolValue" in value:
+            values[attribute["key"]] = bool(value["boolValue"])
+    return values
+
+
+def collect_trace_files(paths: list[Path]) -> list[Path]:
+    """Expand directories into the JSON exports they contain."""
+    files = []
+    for path in paths:
+        if path.is_dir():
+            files.extend(
+                sorted(p for p in path.rglob("*") if p.suffix in (".json", ".jsonl"))
+            )
+        elif path.is_file():
+            files.append(path)
+    return files
+
+
+class TraceMapper:
+    """Resolves spans to function nodes and records OBSERVED_CALL edges."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+        self.locator = CodeLocator(ingestor)
+        self._handlers: dict[tuple[str, str], tuple[str, str]] = {}
+        self._loaded = False
+
+    def ingest(self, spans: list[Span]) -> dict[str, int]:
+        """Aggregate parent-to-child calls between resolved spans and store them."""
+        self._load()
+        resolved = {span.key: self.resolve(span) for span in spans}
+        by_key = {span.key: span for span in spans}
+
+        calls: dict[tuple[tuple[str, str], tuple[str, str]], ObservedCall] = {}
+        for span in spans:
+            callee = resolved[span.key]
+            if callee is None:
+                continue
+            caller = self._resolved_ancestor(span, by_key, resolved)
+            # Nested spans of one function, e.g. manual and automatic, are not calls
+            if caller is None or caller == callee:
+                continue
+            call = calls.setdefault((caller, callee), ObservedCall(caller, callee))
+            call.durations.append(span.duration_ms)
+            call.error_count += span.is_error
+            if span.service:
+                call.services.add(span.service)
+            call.last_seen_ns = max(call.last_seen_ns, span.end_ns)
+
+        by_labels: dict[tuple[str, str], list[dict[str, Any]]] = defaultdict(list)
+        for call in calls.values():
+            by_labels[(call.caller[0], call.callee[0])].append(call.to_row())
+        for (caller_label, callee_label), rows in by_labels.items():
+            self.ingestor.execute_write(
+                RECORD_OBSERVED_CALLS_QUERY.format(
+                    caller_label=caller_label, callee_label=callee_label
+                ),
+                {"edges": rows},
+            )
+        stats = {
+            "spans": len(spans),
+            "resolved_spans": sum(1 for r in resolved.values() if r is not None),
+            "observed_calls": len(calls),
+        }
+        logger.info(f"Mapped traces to code: {stats}")
+        return stats
+
+    def resolve(self, span: Span) -> tuple[str, str] | None:
+        """
+        The (label, qualified_name) of the function a span ran, from code.*
+        attributes, the HTTP route of server spans, or the span name.
+        """
+        self._load()
+        file_path = span.attribute(FILE_ATTRIBUTES)
+        line = span.attribute(LINE_ATTRIBUTES)
+        if file_path and line:
+            match = self.locator.at_line(str(file_path), int(line))
+            if match:
+                return match["label"], match["qualified_name"]
+
+        function = span.attribute(FUNCTION_ATTRIBUTES)
+        if function:
+            namespace = span.attribute(NAMESPACE_ATTRIBUTES) or ""
+            match = self.locator.by_name(str(function), str(namespace))
+            if match:
+                return match["label"], match["qualified_name"]
+
+        route = span.attribute(ROUTE_ATTRIBUTES)
+        if route:
+            method = str(span.attribute(METHOD_ATTRIBUTES) or "").upper()
+            normalized = _normalize_route(str(route))
+            handler = self._handlers.get((method, normalized)) or self._handlers.get(
+                ("ANY", normalized)
+            )
+            if not method:
+                handler = handler or next(
+                    (h for (_, r), h in self._handlers.items() if r == normalized),
+                    None,
+                )
+            if handler:
+                return handler
+
+        # Manually created spans are often named after the function
+        match = self.locator.by_name(span.name, unique_only=True)
+        return (match["label"], match["qualified_name"]) if match else None
+
+    def _resolved_ancestor(
+        self,
+        span: Span,
+        by_key: dict[tuple[str, str], Span],
+        resolved: dict[tuple[str, str], tuple[str, str] | None],
+    ) -> tuple[str, str] | None:
+        # Skip unresolved spans in between, such as middleware or client spans
+        seen = {span.key}
+        parent = (span.trace_id, span.parent_span_id)
+        while parent[1] and parent in by_key and parent not in seen:
+            seen.add(parent)
+            if resolved.get(parent):
+                return resolved[parent]
+            parent = (span.trace_id, by_key[parent].parent_span_id)
+        return None
+
+    def _load(self) -> None:
+        if self._loaded:
+            return
+        for row in self.ingestor.fetch_all(ENDPOINT_HANDLERS_QUERY):
+            key = ((row["method"] or "").upper(), _normalize_route(row["route"] or ""))
+            self._handlers[key] = (row["label"], row["qualified_name"])
+        self._loaded = True
+
+
+def _normalize_route(route: str) -> str:
+    return ROUTE_PARAMETER.sub("{}", route.rstrip("/") or "/")
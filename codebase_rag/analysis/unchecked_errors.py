@@ -0,0 +1,228 @@
+"""Audit of Go call sites whose returned error is discarded."""
+
+import re
+from dataclasses import asdict, dataclass
+from typing import Any
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Unchecked call sites with their enclosing function, most failure-prone first
+UNCHECKED_ERRORS_QUERY = """
+MATCH (owner)-[:HAS_UNCHECKED_ERROR]->(u:UncheckedError)
+WHERE u.likelihood >= $min_likelihood
+  AND ($path_prefix = '' OR u.path STARTS WITH $path_prefix)
+RETURN u.qualified_name AS qualified_name, u.call AS call, u.kind AS kind,
+       u.category AS category, u.likelihood AS likelihood, u.path AS path,
+       u.line_number AS line_number, owner.qualified_name AS owner
+ORDER BY u.likelihood DESC, u.path, u.line_number
+LIMIT $limit
+"""
+
+# Failure likelihood per callee category; I/O and remote calls fail routinely
+FAILURE_LIKELIHOOD = {
+    "io": 3,
+    "network": 3,
+    "database": 3,
+    "encoding": 2,
+    "parse": 2,
+    "other": 1,
+}
+
+CATEGORY_PACKAGES = {
+    "io": {"os", "io", "ioutil", "bufio", "fs", "filepath"},
+    "network": {"http", "net", "grpc", "rpc", "client", "conn", "srv", "server"},
+    "database": {"sql", "db", "tx", "rows", "stmt"},
+    "encoding": {"json", "xml", "yaml", "gob", "proto", "csv", "base64"},
+    "parse": {"strconv", "url", "regexp"},
+}
+
+CATEGORY_METHODS = {
+    "io": re.compile(
+        r"^(?:Close|Write\w*|Read\w*|Flush|Sync|Remove\w*|Rename|Mkdir\w*|Chmod|"
+        r"Chown|Truncate|Seek|Copy\w*|Create\w*|Open\w*)$"
+    ),
+    "network": re.compile(r"^(?:Dial\w*|Listen\w*|Serve\w*|Shutdown)$"),
+    "database": re.compile(
+        r"^(?:Exec\w*|Query\w*|Scan|Commit|Rollback|Ping\w*|Prepare\w*|Begin\w*)$"
+    ),
+    "encoding": re.compile(r"^(?:Unmarshal|Marshal\w*|Encode|Decode)$"),
+    "parse": re.compile(r"^(?:Atoi|Parse\w*|Compile)$"),
+}
+
+# Calls whose errors are idiomatically ignored
+IGNORED_PACKAGES = {"fmt"}
+
+
+@dataclass
+class UncheckedError:
+    """A call site whose error result is dropped."""
+
+    call: str  # Dotted callee as written, e.g. "os.Remove" or "f.Close"
+    kind: str  # "blank" (`_ = f()`), "ignored" (bare call), "deferred" or "goroutine"
+    category: str  # io, network, database, encoding, parse or other
+    line_number: int
+    column: int
+
+    @property
+    def likelihood(self) -> int:
+        return FAILURE_LIKELIHOOD[self.category]
+
+    def to_dict(self) -> dict[str, Any]:
+        return {**asdict(self), "likelihood": self.likelihood}
+
+
+class UncheckedErrorAnalyzer:
+    """Lists discarded errors, ranked by how likely the callee is to fail."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def find_unchecked_errors(
+        self, min_likelihood: int = 1, path_prefix: str = "", limit: int = 50
+    ) -> list[dict[str, Any]]:
+        if path_prefix and not path_prefix.endswith("/"):
+            path_prefix += "/"
+        return self.ingestor.fetch_all(  # type: ignore[no-any-return]
+            UNCHECKED_ERRORS_QUERY,
+            {
+                "min_likelihood": min_likelihood,
+                "path_prefix": path_prefix,
+                "limit": limit,
+            },
+        )
+
+
+def collect_error_returning_functions(root_node: Node) -> set[str]:
+    """Names of Go functions and methods declared with an error result."""
+    names = set()
+    for declaration in root_node.children:
+        if declaration.type not in ("function_declaration", "method_declaration"):
+            continue
+        name = declaration.child_by_field_name("name")
+        result = declaration.child_by_field_name("result")
+        if name is not None and result is not None and _returns_error(result):
+            names.add(node_text(name))
+    return names
+
+
+def find_unchecked_errors(
+    root_node: Node, error_functions: set[str]
+) -> list[UncheckedError]:
+    """
+    Find `_ = f()` assignments and bare, deferred or `go` calls that drop errors.
+
+    Without type information, a bare call only counts when its callee is known
+    to return an error: a function declared in the repository with an error
+    result, or a call matching one of the failure-prone categories. An
+    explicit `_ =` discard is always reported.
+    """
+    findings = []
+    stack = [root_node]
+    while stack:
+        node = stack.pop()
+        stack.extend(node.children)
+        call, kind = _discarded_call(node)
+        if call is None:
+            continue
+        parts = _callee_parts(call)
+        if not parts or parts[0] in IGNORED_PACKAGES:
+            continue
+        category = categorize_call(parts)
+        if kind == "blank" or category != "other" or parts[-1] in error_functions:
+            findings.append(
+                UncheckedError(
+                    call=".".join(parts),
+                    kind=kind,
+                    category=category,
+                    line_number=call.start_point[0] + 1,
+                    column=call.start_point[1],
+                )
+            )
+    return sorted(findings, key=lambda f: (f.line_number, f.column))
+
+
+def categorize_call(parts: list[str]) -> str:
+    """Categorize a dotted callee by its package or receiver and method name."""
+    if not parts:
+        return "other"
+    method = parts[-1]
+    receivers = {p.lower() for p in parts[:-1]}
+    for category, packages in CATEGORY_PACKAGES.items():
+        if receivers & packages:
+            return category
+    for category, pattern in CATEGORY_METHODS.items():
+        if pattern.match(method):
+            return category
+    return "other"
+
+
+def _discarded_call(node: Node) -> tuple[Node | None, str]:
+    if node.type == "assignment_statement":
+        left = node.child_by_field_name("left")
+        right = node.child_by_field_name("right")
+        if left is None or right is None or len(right.named_children) != 1:
+            return None, ""
+        # The error is conventionally the last result: `_ = f()`, `v, _ = f()`
+        targets = left.named_children
+        value = right.named_children[0]
+        if (
+            targets
+            and node_text(targets[-1]) == "_"
+            and value.type == "call_expression"
+        ):
+            return value, "blank"
+    elif node.type == "short_var_declaration":
+        left = node.child_by_field_name("left")
+        right = node.child_by_field_name("right")
+        if left is None or right is None or len(right.named_children) != 1:
+            return None, ""
+        targets = left.named_children
+        value = right.named_children[0]
+        if (
+            len(targets) > 1
+            and node_text(targets[-1]) == "_"
+            and value.type == "call_expression"
+        ):
+            return value, "blank"
+    elif node.type == "expression_statement":
+        expression = node.named_children[0] if node.named_children else None
+        if expression is not None and expression.type == "call_expression":
+            return expression, "ignored"
+    elif node.type in ("defer_statement", "go_statement"):
+        call = next(
+            (c for c in node.named_children if c.type == "call_expression"), None
+        )
+        if call is not None:
+            return call, "deferred" if node.type == "defer_statement" else "goroutine"
+    return None, ""
+
+
+def _callee_parts(call: Node) -> list[str]:
+    function = call.child_by_field_name("function")
+    parts: list[str] = []
+    while function is not None:
+        if function.type == "selector_expression":
+            field_node = function.child_by_field_name("field")
+            parts.insert(0, node_text(field_node) if field_node is not None else "")
+            function = function.child_by_field_name("operand")
+        elif function.type == "call_expression":
+            function = function.child_by_field_name("function")
+        elif function.type == "identifier":
+            parts.insert(0, node_text(function))
+            break
+        else:
+            break
+    return [p for p in parts if p]
+
+
+def _returns_error(result: Node) -> bool:
+    if result.type == "type_identifier":
+        return node_text(result) == "error"
+    # (T, error) or (n int, err error)
+    return any(
+        node_text(child.child_by_field_name("type") or child) == "error"
+        for child in result.named_children
+    )
+
@@ -0,0 +1,374 @@
+"""
+Detection of declared dependencies that no source file imports, by checking
+the manifests of a repository against the imports ingested into its graph.
+"""
+
+import json
+import re
+from dataclasses import asdict, dataclass, field
+from pathlib import Path, PurePosixPath
+from typing import Any
+
+import toml
+from loguru import logger
+
+IGNORED_DIRS = {".git", "vendor", "node_modules", "venv", ".venv", "build", "dist"}
+
+# The ecosystem of a module importing an ExternalPackage, by file extension
+SOURCE_EXTENSIONS = {
+    "npm": {".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"},
+    "python": {".py"},
+}
+
+# Node's own modules, imported without the "node:" prefix too
+NODE_BUILTINS = frozenset(
+    "assert async_hooks buffer child_process cluster console crypto dgram dns "
+    "events fs http http2 https module net os path perf_hooks process "
+    "querystring readline stream string_decoder timers tls tty url util v8 vm "
+    "worker_threads zlib".split()
+)
+
+# Modules with their third-party imports (IMPORTS edges to ExternalPackage
+# nodes) and the Go modules their imports come from (IMPORTS_MODULE edges)
+IMPORTED_PACKAGES_QUERY = """
+MATCH (m:Module)-[:IMPORTS]->(p:ExternalPackage)
+WHERE m.qualified_name STARTS WITH $project + '.'
+RETURN DISTINCT m.path AS path, p.name AS package, false AS go
+UNION
+MATCH (m:Module)-[:IMPORTS_MODULE]->(g:GoModule)
+WHERE m.qualified_name STARTS WITH $project + '.'
+RETURN DISTINCT m.path AS path, g.path AS package, true AS go
+"""
+
+# Distributions whose import name cannot be derived from the project name
+PYTHON_IMPORT_NAMES = {
+    "beautifulsoup4": {"bs4"},
+    "pillow": {"PIL"},
+    "pyyaml": {"yaml"},
+    "scikit-learn": {"sklearn"},
+    "python-dateutil": {"dateutil"},
+    "python-dotenv": {"dotenv"},
+    "protobuf": {"google"},
+    "opencv-python": {"cv2"},
+    "pyjwt": {"jwt"},
+    "attrs": {"attr", "attrs"},
+    "typing-extensions": {"typing_extensions"},
+    "google-genai": {"google"},
+    "pydantic-settings": {"pydantic_settings"},
+}
+
+REQUIREMENT_NAME = re.compile(r"^([A-Za-z0-9][A-Za-z0-9._-]*)")
+
+
+@dataclass
+class DeclaredDependency:
+    """A dependency listed in a manifest."""
+
+    name: str
+    version: str = ""
+    dev: bool = False  # devDependencies, optional or dev dependency groups
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class Manifest:
+    """A dependency manifest and the source tree it governs."""
+
+    path: str  # Relative to the repository root
+    ecosystem: str  # "go", "npm" or "python"
+    dependencies: list[DeclaredDependency]
+    module_name: str = ""  # Go module path or npm package name
+
+
+@dataclass
+class UnusedDependencyReport:
+    """Declared-but-unimported dependencies of one manifest."""
+
+    manifest: str
+    ecosystem: str
+    module_name: str
+    declared: int
+    unused: list[DeclaredDependency] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "manifest": self.manifest,
+            "ecosystem": self.ecosystem,
+            "module_name": self.module_name,
+            "declared": self.declared,
+            "unused": [dep.to_dict() for dep in self.unused],
+        }
+
+
+class UnusedDependencyAnalyzer:
+    """Cross-checks manifests in a repository against the imports in its graph."""
+
+    def __init__(self, ingestor: Any, repo_path: Path):
+        self.ingestor = ingestor
+        self.repo_path = repo_path
+
+    def analyze(self, include_dev: bool = False) -> list[UnusedDependencyReport]:
+        """Return one report per manifest, including manifests with no unused deps."""
+        manifests = find_manifests(self.repo_path)
+        imports = self.collect_imports(manifests)
+        reports = []
+        for manifest in manifests:
+            unused = [
+                dep
+                for dep in manifest.dependencies
+                if (include_dev or not dep.dev)
+                and not is_dependency_imported(
+                    dep, manifest.ecosystem, imports.get(manifest.path, set())
+                )
+            ]
+            reports.append(
+                UnusedDependencyReport(
+                    manifest=manifest.path,
+                    ecosystem=manifest.ecosystem,
+                    module_name=manifest.module_name,
+                    declared=len(manifest.dependencies),
+                    unused=unused,
+                )
+            )
+        return reports
+
+    def collect_imports(self, manifests: list[Manifest]) -> dict[str, set[str]]:
+        """
+        The packages imported by the modules each manifest governs, by manifest
+        path: those of the graph's modules below it, save modules of nested
+        manifests of the same ecosystem, which own their own subtrees.
+        """
+        imports: dict[str, set[str]] = {manifest.path: set() for manifest in manifests}
+        rows = self.ingestor.fetch_all(
+            IMPORTED_PACKAGES_QUERY, {"project": self.repo_path.name}
+        )
+        for row in rows:
+            if not row.get("path") or not row.get("package"):
+                continue
+            path = PurePosixPath(row["path"])
+            ecosystem = "go" if row["go"] else _ecosystem_of(path)
+            manifest = governing_manifest(path, ecosystem, manifests)
+            if manifest is not None:
+                imports[manifest.path].add(row["package"])
+        return imports
+
+
+def find_manifests(repo_path: Path) -> list[Manifest]:
+    """Parse every go.mod, package.json, requirements file and pyproject.toml."""
+    manifests = []
+    for file_path in sorted(repo_path.rglob("*")):
+        relative = file_path.relative_to(repo_path)
+        if not file_path.is_file() or IGNORED_DIRS.intersection(relative.parts):
+            continue
+        name = file_path.name
+        try:
+            content = file_path.read_text(encoding="utf-8", errors="replace")
+            if name == "go.mod":
+                manifest = parse_go_mod(content)
+            elif name == "package.json":
+                manifest = parse_package_json(content)
+            elif name.startswith("requirements") and name.endswith(".txt"):
+                manifest = parse_requirements(content, dev="dev" in name)
+            elif name == "pyproject.toml":
+                manifest = parse_pyproject(content)
+            else:
+                continue
+        except (ValueError, toml.TomlDecodeError) as e:
+            logger.warning(f"Could not parse {relative}: {e}")
+            continue
+        manifest.path = relative.as_posix()
+        manifests.append(manifest)
+    return manifests
+
+
+def governing_manifest(
+    path: PurePosixPath, ecosystem: str, manifests: list[Manifest]
+) -> Manifest | None:
+    """The innermost manifest of an ecosystem whose directory holds a file."""
+    candidates = [
+        manifest
+        for manifest in manifests
+        if manifest.ecosystem == ecosystem
+        and _is_within(path, PurePosixPath(manifest.path).parent)
+    ]
+    return max(
+        candidates,
+        key=lambda manifest: len(PurePosixPath(manifest.path).parent.parts),
+        default=None,
+    )
+
+
+def parse_go_mod(content: str, include_indirect: bool = False) -> Manifest:
+    """Parse the module path and requirements of a go.mod file.
+
+    Indirect requirements are needed by dependencies, not by the module
+    itself, so they are skipped unless asked for.
+    """
+    module_match = re.search(r"^module\s+(\S+)", content, re.MULTILINE)
+    dependencies = []
+    in_block = False
+    for raw_line in content.splitlines():
+        line = raw_line.strip()
+        if line.startswith("require ("):
+            in_block = True
+            continue
+        if in_block and line == ")":
+            in_block = False
+            continue
+        if line.startswith("require "):
+            line = line[len("require ") :]
+        elif not in_block:
+            continue
+
+        if not line or line.startswith("//"):
+            continue
+        if "// indirect" in line and not include_indirect:
+            continue
+        parts = line.split()
+        if len(parts) >= 2:
+            dependencies.append(DeclaredDependency(name=parts[0], version=parts[1]))
+    return Manifest(
+        path="go.mod",
+        ecosystem="go",
+        dependencies=dependencies,
+        module_name=module_match.group(1) if module_match else "",
+    )
+
+
+def parse_package_json(content: str) -> Manifest:
+    """Parse runtime and development dependencies of a package.json file."""
+    data = json.loads(content)
+    dependencies = []
+    for key, dev in (
+        ("dependencies", False),
+        ("peerDependencies", False),
+        ("optionalDependencies", False),
+        ("devDependencies", True),
+    ):
+        for name, version in (data.get(key) or {}).items():
+            dependencies.append(DeclaredDependency(name, str(version), dev))
+    return Manifest(
+        path="package.json",
+        ecosystem="npm",
+        dependencies=dependencies,
+        module_name=data.get("name", ""),
+    )
+
+
+def parse_requirements(content: str, dev: bool = False) -> Manifest:
+    """Parse a pip requirements file, ignoring options and includes."""
+    dependencies = []
+    for raw_line in content.splitlines():
+        line = raw_line.split("#", 1)[0].strip()
+        # Options, includes and direct URLs do not name an index package
+        if not line or line.startswith("-") or "://" in line:
+            continue
+        match = REQUIREMENT_NAME.match(line)
+        if match:
+            name = match.group(1)
+            version = line[len(name) :].split(";", 1)[0].strip()
+            dependencies.append(DeclaredDependency(name, version, dev))
+    return Manifest(
+        path="requirements.txt", ecosystem="python", dependencies=dependencies
+    )
+
+
+def parse_pyproject(content: str) -> Manifest:
+    """Parse PEP 621 and Poetry dependencies from a pyproject.toml file."""
+    data = toml.loads(content)
+    project = data.get("project", {})
+    dependencies = []
+
+    def add_requirement(requirement: str, dev: bool) -> None:
+        match = REQUIREMENT_NAME.match(requirement.strip())
+        if match:
+            version = requirement.strip()[len(match.group(1)) :].strip()
+            dependencies.append(DeclaredDependency(match.group(1), version, dev))
+
+    for requirement in project.get("dependencies", []):
+        add_requirement(requirement, dev=False)
+    for group in project.get("optional-dependencies", {}).values():
+        for requirement in group:
+            add_requirement(requirement, dev=True)
+
+    poetry = data.get("tool", {}).get("poetry", {})
+    for key, dev in (("dependencies", False), ("dev-dependencies", True)):
+        for name, spec in poetry.get(key, {}).items():
+            if name.lower() != "python":
+                dependencies.append(DeclaredDependency(name, str(spec), dev))
+    for group in poetry.get("group", {}).values():
+        for name, spec in group.get("dependencies", {}).items():
+            dependencies.append(DeclaredDependency(name, str(spec), True))
+
+    return Manifest(
+        path="pyproject.toml",
+        ecosystem="python",
+        dependencies=dependencies,
+        module_name=project.get("name") or poetry.get("name", ""),
+    )
+
+
+def is_dependency_imported(
+    dep: DeclaredDependency, ecosystem: str, imports: set[str]
+) -> bool:
+    """
+    Check whether any import refers to a declared dependency. Imports are Go
+    module or package paths, npm package names or specifiers, and top-level
+    Python modules.
+    """
+    if ecosystem == "go":
+        # A module provides every package below its path
+        prefix = f"{dep.name}/"
+        return any(imp == dep.name or imp.startswith(prefix) for imp in imports)
+    if ecosystem == "npm":
+        packages = {npm_package_name(imp) for imp in imports}
+        if dep.name.startswith("@types/"):
+            # Type packages are used when the package they describe is imported
+            described = dep.name[len("@types/") :]
+            if "__" in described:
+                described = "@" + described.replace("__", "/", 1)
+            return described in packages or dep.name in packages
+        return dep.name in packages
+    return bool(python_import_names(dep.name) & imports)
+
+
+def python_import_names(distribution: str) -> set[str]:
+    """Guess the top-level modules a Python distribution provides."""
+    normalized = re.sub(r"[-_.]+", "-", distribution).lower()
+    if normalized in PYTHON_IMPORT_NAMES:
+        return PYTHON_IMPORT_NAMES[normalized]
+    candidate = normalized.replace("-", "_")
+    names = {candidate}
+    if candidate.startswith("python_"):
+        names.add(candidate[len("python_") :])
+    if candidate.startswith("py") and len(candidate) > 2:
+        names.add(candidate[2:])
+    return names
+
+
+def npm_package_name(specifier: str) -> str:
+    """The package an import specifier is from, scoped ones included."""
+    if specifier.startswith("@"):
+        return "/".join(specifier.split("/")[:2])
+    return specifier.split("/")[0]
+
+
+def _ecosystem_of(path: PurePosixPath) -> str:
+    return next(
+        (
+            ecosystem
+            for ecosystem, extensions in SOURCE_EXTENSIONS.items()
+            if path.suffix in extensions
+        ),
+        "",
+    )
+
+
+def _is_within(path: PurePosixPath, directory: PurePosixPath) -> bool:
+    return (
+        directory == PurePosixPath(".")
+        or path == directory
+        or directory in path.parents
+    )
@@ -0,0 +1,53 @@
+"""Queries over Vulnerability nodes recorded by security analysis at ingestion."""
+
+from typing import Any
+
+# Severity ranks used to filter and order findings
+SEVERITY_RANK = {"critical": 4, "high": 3, "medium": 2, "low": 1}
+
+# Vulnerabilities (pattern matches, hardcoded secrets and unvalidated taint
+# flows) with the module that contains them
+VULNERABILITIES_QUERY = """
+MATCH (m:Module)-[:HAS_VULNERABILITY]->(v:Vulnerability)
+WHERE v.severity IN $severities
+  AND ($types IS NULL OR v.type IN $types)
+  AND ($path_prefix = '' OR m.path STARTS WITH $path_prefix)
+RETURN DISTINCT v.id AS id, v.type AS type, v.severity AS severity,
+       v.description AS description, v.cwe_id AS cwe_id,
+       v.recommendation AS recommendation, v.confidence AS confidence,
+       v.code_snippet AS code_snippet, m.path AS path,
+       v.line_number AS line_number
+ORDER BY m.path, v.line_number
+"""
+
+
+class VulnerabilityAnalyzer:
+    """Lists vulnerabilities found during ingestion, most severe first."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def find_vulnerabilities(
+        self,
+        min_severity: str = "low",
+        types: list[str] | None = None,
+        path_prefix: str = "",
+        limit: int = 50,
+    ) -> list[dict[str, Any]]:
+        """
+        Return vulnerabilities at or above a severity, optionally filtered by
+        type (e.g. "hardcoded_secret") and package path.
+        """
+        if path_prefix and not path_prefix.endswith("/"):
+            path_prefix += "/"
+        threshold = SEVERITY_RANK[min_severity]
+        rows = self.ingestor.fetch_all(
+            VULNERABILITIES_QUERY,
+            {
+                "severities": [s for s, r in SEVERITY_RANK.items() if r >= threshold],
+                "types": types or None,
+                "path_prefix": path_prefix,
+            },
+        )
+        rows.sort(key=lambda row: -SEVERITY_RANK.get(row["severity"], 0))
+        return rows[:limit]  # type: ignore[no-any-return]
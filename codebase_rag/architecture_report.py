@@ -0,0 +1,441 @@
+"""An architecture report of an ingested repository, as Markdown or HTML.
+
+`report` gathers what a quarterly review of a codebase asks about into one
+document: how packages depend on each other, as a diagram and with the
+import cycles among them, the functions most worth simplifying, public code
+nothing tests, and who owns what. Markdown diagrams are Mermaid, which code
+hosts render; the HTML page draws its diagram as inline SVG and needs no
+scripts or network access to be read.
+"""
+
+import html
+from collections import Counter, defaultdict
+from dataclasses import asdict, dataclass, field
+from datetime import UTC, datetime
+from pathlib import PurePosixPath
+from typing import Any
+
+from .analysis.call_depth import strongly_connected_components
+from .analysis.hotspots import Hotspot, HotspotAnalyzer
+from .analysis.import_cycles import IMPORT_EDGES_QUERY, find_package_cycles
+from .analysis.test_gaps import TestGapAnalyzer
+from .version_control.git_analyzer import DiffHunk
+
+# Packages drawn at most, those with the most imports between them first
+MAX_DIAGRAM_PACKAGES = 25
+
+OWNERSHIP_QUERY = """
+MATCH (f:File)-[:OWNED_BY]->(owner)
+WHERE owner:Team OR owner:User
+RETURN DISTINCT owner.name AS owner, labels(owner)[0] AS kind, f.path AS path
+"""
+
+FILES_QUERY = """
+MATCH (f:File)
+RETURN f.path AS path
+"""
+
+
+@dataclass
+class PackageEdge:
+    source: str
+    target: str
+    imports: int  # Import statements from the source package into the target
+
+
+@dataclass
+class GapSummary:
+    package: str
+    count: int
+    # The most complex untested symbols of the package, most complex first
+    examples: list[str] = field(default_factory=list)
+
+
+@dataclass
+class OwnerSummary:
+    owner: str
+    kind: str  # "Team" or "User"
+    files: int
+    directories: list[str] = field(default_factory=list)
+
+
+@dataclass
+class ArchitectureReport:
+    """What the report shows, gathered from the graph."""
+
+    project: str
+    generated_at: str
+    dependencies: list[PackageEdge] = field(default_factory=list)
+    cycles: list[list[str]] = field(default_factory=list)
+    hotspots: list[Hotspot] = field(default_factory=list)
+    # Whether hotspots are ranked by churn times complexity or complexity alone
+    has_churn: bool = True
+    test_gaps: list[GapSummary] = field(default_factory=list)
+    owners: list[OwnerSummary] = field(default_factory=list)
+    unowned_files: int = 0
+    total_files: int = 0
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+    def diagram_edges(self) -> list[PackageEdge]:
+        """The dependencies among the packages with the most imports."""
+        weight: Counter[str] = Counter()
+        for edge in self.dependencies:
+            weight[edge.source] += edge.imports
+            weight[edge.target] += edge.imports
+        shown = {package for package, _ in weight.most_common(MAX_DIAGRAM_PACKAGES)}
+        return [
+            edge
+            for edge in self.dependencies
+            if edge.source in shown and edge.target in shown
+        ]
+
+    def to_markdown(self) -> str:
+        lines = [
+            f"# Architecture report: {self.project}",
+            "",
+            f"Generated {self.generated_at} from the code graph.",
+            "",
+            "## Package dependencies",
+            "",
+        ]
+        edges = self.diagram_edges()
+        if edges:
+            ids = _diagram_ids(edges)
+            lines += ["```mermaid", "graph LR"]
+            lines += [f'    {ids[p]}["{p}"]' for p in sorted(ids)]
+            lines += [
+                f"    {ids[e.source]} -->|{e.imports}| {ids[e.target]}" for e in edges
+            ]
+            lines += ["```", ""]
+            if len(edges) < len(self.dependencies):
+                lines += [
+                    f"The {MAX_DIAGRAM_PACKAGES} packages with the most imports "
+                    f"are shown; {len(self.dependencies)} dependencies in all.",
+                    "",
+                ]
+        else:
+            lines += ["No imports between packages in the graph.", ""]
+        if self.cycles:
+            lines += ["### Import cycles", ""]
+            lines += [f"- {' -> '.join(c)} -> {c[0]}" for c in self.cycles]
+            lines.append("")
+
+        lines += ["## Complexity hotspots", ""]
+        if self.hotspots:
+            lines += _markdown_table(
+                ["Function", "Path", "Churn", "Complexity"],
+                [
+                    [f"`{h.qualified_name}`", h.path, str(h.churn), str(h.complexity)]
+                    for h in self.hotspots
+                ],
+            )
+            if not self.has_churn:
+                lines += ["", "No Git history was found; ranked by complexity."]
+        else:
+            lines.append("No functions in the graph.")
+
+        lines += ["", "## Test coverage gaps", ""]
+        if self.test_gaps:
+            lines += _markdown_table(
+                ["Package", "Untested", "Most complex"],
+                [
+                    [
+                        g.package or ".",
+                        str(g.count),
+                        ", ".join(f"`{example}`" for example in g.examples),
+                    ]
+                    for g in self.test_gaps
+                ],
+            )
+        else:
+            lines.append("Every public function and endpoint is tested.")
+
+        lines += ["", "## Ownership", ""]
+        if self.owners:
+            lines += _markdown_table(
+                ["Owner", "Kind", "Files", "Directories"],
+                [
+                    [o.owner, o.kind, str(o.files), ", ".join(o.directories)]
+                    for o in self.owners
+                ],
+            )
+            lines += ["", _unowned_sentence(self)]
+        else:
+            lines.append("No CODEOWNERS, OWNERS or catalog owners in the graph.")
+        return "\n".join(lines) + "\n"
+
+    def to_html(self) -> str:
+        title = f"Architecture report: {self.project}"
+        parts = [
+            "<!DOCTYPE html>",
+            '<html lang="en"><head><meta charset="utf-8">',
+            f"<title>{html.escape(title)}</title>",
+            f"<style>{_STYLE}</style></head><body>",
+            f"<h1>{html.escape(title)}</h1>",
+            f"<p>Generated {html.escape(self.generated_at)} from the code graph.</p>",
+            "<h2>Package dependencies</h2>",
+        ]
+        edges = self.diagram_edges()
+        parts.append(
+            dependency_svg(edges)
+            if edges
+            else "<p>No imports between packages in the graph.</p>"
+        )
+        if self.cycles:
+            parts.append("<h3>Import cycles</h3><ul>")
+            parts += [
+                f"<li>{html.escape(' -> '.join(c + c[:1]))}</li>" for c in self.cycles
+            ]
+            parts.append("</ul>")
+
+        parts.append("<h2>Complexity hotspots</h2>")
+        parts.append(
+            _html_table(
+                ["Function", "Path", "Churn", "Complexity"],
+                [
+                    [h.qualified_name, h.path, str(h.churn), str(h.complexity)]
+                    for h in self.hotspots
+                ],
+            )
+            if self.hotspots
+            else "<p>No functions in the graph.</p>"
+        )
+        if self.hotspots and not self.has_churn:
+            parts.append("<p>No Git history was found; ranked by complexity.</p>")
+
+        parts.append("<h2>Test coverage gaps</h2>")
+        parts.append(
+            _html_table(
+                ["Package", "Untested", "Most complex"],
+                [
+                    [g.package or ".", str(g.count), ", ".join(g.examples)]
+                    for g in self.test_gaps
+                ],
+            )
+            if self.test_gaps
+            else "<p>Every public function and endpoint is tested.</p>"
+        )
+
+        parts.append("<h2>Ownership</h2>")
+        if self.owners:
+            parts.append(
+                _html_table(
+                    ["Owner", "Kind", "Files", "Directories"],
+                    [
+                        [o.owner, o.kind, str(o.files), ", ".join(o.directories)]
+                        for o in self.owners
+                    ],
+                )
+            )
+            parts.append(f"<p>{html.escape(_unowned_sentence(self))}</p>")
+        else:
+            parts.append("<p>No CODEOWNERS, OWNERS or catalog owners in the graph.</p>")
+        parts.append("</body></html>")
+        return "\n".join(parts) + "\n"
+
+
+def build_report(
+    ingestor: Any,
+    project: str,
+    history: list[dict[str, list[DiffHunk]]],
+    limit: int = 10,
+    depth: int = 2,
+) -> ArchitectureReport:
+    """
+    Gather the report from the graph. Packages are directories cut to their
+    first `depth` parts, so services/billing/internal counts as
+    services/billing; history is the lines each commit changed per file, from
+    GitAnalyzer.get_diff_hunks(), empty without Git history.
+    """
+    report = ArchitectureReport(
+        project=project,
+        generated_at=datetime.now(UTC).strftime("%Y-%m-%d %H:%M UTC"),
+        has_churn=bool(history),
+    )
+    import_edges = list(ingestor.fetch_all(IMPORT_EDGES_QUERY))
+    report.dependencies = package_dependencies(import_edges, depth)
+    report.cycles = [cycle.packages for cycle in find_package_cycles(import_edges)]
+
+    analyzer = HotspotAnalyzer(ingestor)
+    if history:
+        report.hotspots = analyzer.rank_functions(history, limit)
+    else:
+        ranked = sorted(
+            analyzer.rank_functions([]),
+            key=lambda h: (-h.complexity, h.qualified_name),
+        )
+        report.hotspots = ranked[:limit]
+
+    gaps = TestGapAnalyzer(ingestor).find_gaps_by_package()
+    report.test_gaps = [
+        GapSummary(package, len(found), [g.qualified_name for g in found[:3]])
+        for package, found in sorted(gaps.items(), key=lambda item: -len(item[1]))
+    ][:limit]
+
+    files = {row["path"] for row in ingestor.fetch_all(FILES_QUERY) if row["path"]}
+    ownership = list(ingestor.fetch_all(OWNERSHIP_QUERY))
+    report.owners = summarize_owners(ownership, depth)
+    owned = {row["path"] for row in ownership}
+    report.total_files = len(files)
+    report.unowned_files = len(files - owned)
+    return report
+
+
+def package_dependencies(
+    edges: list[dict[str, Any]], depth: int = 2
+) -> list[PackageEdge]:
+    """Module imports counted per pair of packages, most imports first."""
+    counts: Counter[tuple[str, str]] = Counter()
+    for edge in edges:
+        if not edge.get("source_path") or not edge.get("target_path"):
+            continue
+        source = _package(edge["source_path"], depth)
+        target = _package(edge["target_path"], depth)
+        if source != target:
+            counts[(source, target)] += 1
+    return [
+        PackageEdge(source, target, imports)
+        for (source, target), imports in sorted(
+            counts.items(), key=lambda item: (-item[1], item[0])
+        )
+    ]
+
+
+def summarize_owners(rows: list[dict[str, Any]], depth: int = 2) -> list[OwnerSummary]:
+    """Files and top directories per owner, owners of the most files first."""
+    files: dict[tuple[str, str], set[str]] = defaultdict(set)
+    for row in rows:
+        if row.get("owner") and row.get("path"):
+            files[(row["owner"], row.get("kind") or "Team")].add(row["path"])
+    summaries = []
+    for (owner, kind), paths in files.items():
+        directories = Counter(_package(path, depth) for path in paths)
+        summaries.append(
+            OwnerSummary(
+                owner,
+                kind,
+                len(paths),
+                [directory for directory, _ in directories.most_common(3)],
+            )
+        )
+    return sorted(summaries, key=lambda s: (-s.files, s.owner))
+
+
+def dependency_svg(edges: list[PackageEdge]) -> str:
+    """
+    The packages as boxes in columns, each package right of the packages
+    importing it, with an arrow per dependency. Packages in an import cycle
+    share a column.
+    """
+    graph: dict[str, set[str]] = defaultdict(set)
+    for edge in edges:
+        graph[edge.source].add(edge.target)
+    components = strongly_connected_components(graph)
+    importers: dict[int, set[int]] = defaultdict(set)
+    for edge in edges:
+        if components[edge.source] != components[edge.target]:
+            importers[components[edge.target]].add(components[edge.source])
+    column: dict[int, int] = {}
+
+    def place(component: int) -> int:
+        # Components importing each other are condensed, so this terminates
+        if component not in column:
+            column[component] = max(
+                (place(i) + 1 for i in importers[component]), default=0
+            )
+        return column[component]
+
+    packages = sorted(components)
+    rows: dict[int, list[str]] = defaultdict(list)
+    for package in packages:
+        rows[place(components[package])].append(package)
+
+    box_width, box_height, gap_x, gap_y, margin = 200, 28, 80, 14, 10
+    position = {
+        package: (
+            margin + c * (box_width + gap_x),
+            margin + r * (box_height + gap_y),
+        )
+        for c, members in rows.items()
+        for r, package in enumerate(members)
+    }
+    width = margin * 2 + (max(rows) + 1) * (box_width + gap_x) - gap_x
+    height = margin * 2 + max(len(m) for m in rows.values()) * (box_height + gap_y)
+    parts = [
+        f'<svg xmlns="http://www.w3.org/2000/svg" width="{width}" '
+        f'height="{height}" class="dependencies">',
+        '<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" '
+        'markerWidth="6" markerHeight="6" orient="auto">'
+        '<path d="M 0 0 L 10 5 L 0 10 z"/></marker></defs>',
+    ]
+    for edge in edges:
+        (x1, y1), (x2, y2) = position[edge.source], position[edge.target]
+        # Between the facing sides of the boxes, along the left within a column
+        start_x = x1 + box_width if x2 > x1 else x1
+        end_x = x2 + box_width if x2 < x1 else x2
+        parts.append(
+            f'<line x1="{start_x}" y1="{y1 + box_height // 2}" x2="{end_x}" '
+            f'y2="{y2 + box_height // 2}" marker-end="url(#arrow)">'
+            f"<title>{html.escape(edge.source)} imports {html.escape(edge.target)} "
+            f"{edge.imports} times</title></line>"
+        )
+    for package, (x, y) in position.items():
+        parts.append(
+            f'<g><rect x="{x}" y="{y}" width="{box_width}" height="{box_height}"/>'
+            f'<text x="{x + 8}" y="{y + 18}">{html.escape(_fit(package))}</text>'
+            f"<title>{html.escape(package)}</title></g>"
+        )
+    parts.append("</svg>")
+    return "".join(parts)
+
+
+_STYLE = (
+    "body{font-family:system-ui,sans-serif;margin:2rem auto;max-width:72rem;"
+    "color:#1f2328}"
+    "table{border-collapse:collapse;margin:1rem 0}"
+    "th,td{border:1px solid #d0d7de;padding:.3rem .6rem;text-align:left}"
+    "th{background:#f6f8fa}"
+    "svg.dependencies{overflow:visible}"
+    "svg rect{fill:#f6f8fa;stroke:#57606a;rx:4}"
+    "svg text{font-size:12px;font-family:ui-monospace,monospace}"
+    "svg line{stroke:#8c959f}"
+)
+
+
+def _package(path: str, depth: int) -> str:
+    parts = PurePosixPath(path).parent.parts
+    return "/".join(parts[:depth]) or "."
+
+
+def _diagram_ids(edges: list[PackageEdge]) -> dict[str, str]:
+    # Mermaid node ids may not contain slashes or dots, so packages are numbered
+    packages = sorted({p for e in edges for p in (e.source, e.target)})
+    return {package: f"p{index}" for index, package in enumerate(packages)}
+
+
+def _fit(text: str, width: int = 26) -> str:
+    return text if len(text) <= width else "…" + text[-(width - 1) :]
+
+
+def _markdown_table(headers: list[str], rows: list[list[str]]) -> list[str]:
+    lines = ["| " + " | ".join(headers) + " |", "|" + "---|" * len(headers)]
+    lines += [
+        "| " + " | ".join(cell.replace("|", "\\|") for cell in row) + " |"
+        for row in rows
+    ]
+    return lines
+
+
+def _html_table(headers: list[str], rows: list[list[str]]) -> str:
+    head = "".join(f"<th>{html.escape(h)}</th>" for h in headers)
+    body = "".join(
+        "<tr>" + "".join(f"<td>{html.escape(cell)}</td>" for cell in row) + "</tr>"
+        for row in rows
+    )
+    return f"<table><tr>{head}</tr>{body}</table>"
+
+
+def _unowned_sentence(report: ArchitectureReport) -> str:
+    return f"{report.unowned_files} of {report.total_files} files have no owner."
@@ -0,0 +1,84 @@
+"""Splitting oversized functions and files into chunks at statement boundaries.
+
+An embedding of a long function sees only its first lines, and a snippet
+shown to a model is cut at a size limit, so whatever a 400-line handler does
+past its opening is lost to search. Bodies longer than MAX_CHUNK_LINES are
+split into Chunk nodes, linked from their function, method or module with
+HAS_CHUNK. Cuts fall before a statement: the statements of the body, or of
+a statement too long to fit a chunk on its own, e.g. a long loop. Each chunk
+repeats the last CHUNK_OVERLAP lines of the one before, so code near a cut
+is seen whole by one of them. Chunks carry line ranges only; their source is
+read from the file, and a search matching a chunk reports its parent.
+"""
+
+from typing import Any
+
+from tree_sitter import Node
+
+MAX_CHUNK_LINES = 80
+CHUNK_OVERLAP = 8
+
+
+def statement_starts(node: Node, max_lines: int = MAX_CHUNK_LINES) -> set[int]:
+    """
+    The first lines (1-based) of the statements in a node's body, and of the
+    statements inside those longer than max_lines.
+    """
+    starts = set()
+    body = node.child_by_field_name("body") or node
+    for child in body.named_children:
+        starts.add(child.start_point[0] + 1)
+        if child.end_point[0] - child.start_point[0] + 1 > max_lines:
+            starts |= statement_starts(child, max_lines)
+    return starts
+
+
+def chunk_spans(
+    start_line: int,
+    end_line: int,
+    boundaries: set[int],
+    max_lines: int = MAX_CHUNK_LINES,
+    overlap: int = CHUNK_OVERLAP,
+) -> list[tuple[int, int]]:
+    """
+    Line ranges of at most max_lines covering start_line to end_line, each
+    ending before the furthest boundary it can reach; a stretch without a
+    boundary is cut at max_lines. Nothing when the whole fits in one chunk.
+    """
+    if end_line - start_line + 1 <= max_lines:
+        return []
+    cuts = sorted(b for b in boundaries if start_line < b <= end_line)
+    spans = []
+    chunk_start = start_line
+    while end_line - chunk_start + 1 > max_lines:
+        limit = chunk_start + max_lines
+        # The next chunk starts overlap lines before the cut; it must advance
+        reachable = [b for b in cuts if chunk_start + overlap < b <= limit]
+        cut = reachable[-1] if reachable else limit
+        spans.append((chunk_start, cut - 1))
+        chunk_start = cut - overlap
+    spans.append((chunk_start, end_line))
+    return spans
+
+
+def node_chunks(
+    node: Node, parent_qn: str, max_lines: int = MAX_CHUNK_LINES
+) -> list[dict[str, Any]]:
+    """Chunk node properties for a function, method or module node."""
+    spans = chunk_spans(
+        node.start_point[0] + 1,
+        node.end_point[0] + 1,
+        statement_starts(node, max_lines),
+        max_lines,
+    )
+    return [
+        {
+            "qualified_name": f"{parent_qn}:chunk{index}",
+            "parent": parent_qn,
+            "index": index,
+            "chunk_count": len(spans),
+            "start_line": start,
+            "end_line": end,
+        }
+        for index, (start, end) in enumerate(spans)
+    ]
@@ -0,0 +1,161 @@
+"""Citations for the agent's answers: the code each claim rests on.
+
+The agent is asked to name the code behind every claim, as a qualified name
+in backticks or a `path:line` location. Each sentence of the answer is a
+claim; its names are resolved to definitions in the graph and its locations
+to the modules holding them, giving the file, line range and node id an
+answer can be audited against. Names matching nothing in the graph are kept
+as unresolved, so a reviewer sees what the agent cited but cannot be found.
+Given a project, only its code is cited.
+"""
+
+import re
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+# Definitions named in an answer; the shortest qualified name wins
+NAMES_QUERY = """
+UNWIND $names AS name
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+WHERE (s:Function OR s:Method OR s:Class OR s:Interface)
+  AND ($project IS NULL OR m.qualified_name STARTS WITH $project + '.')
+  AND (s.qualified_name = name OR s.qualified_name ENDS WITH '.' + name)
+RETURN name, s.qualified_name AS qualified_name, labels(s)[0] AS label,
+       id(s) AS node_id, m.path AS path, s.start_line AS start_line,
+       s.end_line AS end_line
+ORDER BY size(s.qualified_name)
+"""
+
+# Modules of the files an answer points into
+PATHS_QUERY = """
+MATCH (m:Module)
+WHERE m.path IN $paths
+  AND ($project IS NULL OR m.qualified_name STARTS WITH $project + '.')
+RETURN m.path AS path, m.qualified_name AS qualified_name, id(m) AS node_id
+"""
+
+# `name`, `pkg.Class.method` or `helper()`
+CODE_SPAN = re.compile(r"`([A-Za-z_][\w.]*)(?:\(\))?`")
+# shop/cart.py:12 or shop/cart.py:12-20, with or without backticks
+LOCATION = re.compile(r"([\w./-]+\.\w+):(\d+)(?:-(\d+))?")
+SENTENCE_END = re.compile(r"(?<=[.!?])\s+|\n+")
+
+
+@dataclass
+class Citation:
+    """A definition or location a claim of an answer refers to."""
+
+    claim: str
+    reference: str  # As written in the answer
+    qualified_name: str | None = None
+    label: str | None = None
+    path: str | None = None
+    start_line: int | None = None
+    end_line: int | None = None
+    node_id: int | None = None
+
+    @property
+    def resolved(self) -> bool:
+        return self.path is not None
+
+    @property
+    def location(self) -> str:
+        if self.start_line is None:
+            return self.path or ""
+        if self.end_line is None or self.end_line == self.start_line:
+            return f"{self.path}:{self.start_line}"
+        return f"{self.path}:{self.start_line}-{self.end_line}"
+
+    def url(self, repo_path: Path, base_url: str = "") -> str | None:
+        """A web link under base_url, or a file:// link into the checkout."""
+        if self.path is None:
+            return None
+        if base_url:
+            link = f"{base_url.rstrip('/')}/{self.path}"
+            if self.start_line is not None:
+                link += f"#L{self.start_line}"
+                if self.end_line and self.end_line != self.start_line:
+                    link += f"-L{self.end_line}"
+            return link
+        return (repo_path / self.path).resolve().as_uri()
+
+    def to_dict(self) -> dict[str, Any]:
+        return {**asdict(self), "location": self.location}
+
+
+@dataclass
+class CitedAnswer:
+    text: str
+    citations: list[Citation] = field(default_factory=list)
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "answer": self.text,
+            "citations": [c.to_dict() for c in self.citations],
+        }
+
+
+def claims(text: str) -> list[str]:
+    """The sentences of an answer, with code blocks left out."""
+    prose = re.sub(r"```.*?```", "\n", text, flags=re.S)
+    return [s.strip() for s in SENTENCE_END.split(prose) if s.strip()]
+
+
+class CitationResolver:
+    """Ties the names and locations in an answer to the graph."""
+
+    def __init__(self, ingestor: Any, project: str | None = None):
+        self.ingestor = ingestor
+        self.project = project  # None cites code of every project
+
+    def cite(self, text: str) -> CitedAnswer:
+        references: list[tuple[str, str, re.Match[str] | None]] = []
+        for claim in claims(text):
+            for match in LOCATION.finditer(claim):
+                references.append((claim, match.group(0), match))
+            for name in CODE_SPAN.findall(claim):
+                references.append((claim, name, None))
+
+        names = list(dict.fromkeys(ref for _, ref, match in references if not match))
+        paths = list(
+            dict.fromkeys(match.group(1) for _, _, match in references if match)
+        )
+        definitions: dict[str, dict[str, Any]] = {}
+        if names:
+            for row in self.ingestor.fetch_all(
+                NAMES_QUERY, {"names": names, "project": self.project}
+            ):
+                definitions.setdefault(row["name"], row)
+        modules: dict[str, dict[str, Any]] = {}
+        if paths:
+            for row in self.ingestor.fetch_all(
+                PATHS_QUERY, {"paths": paths, "project": self.project}
+            ):
+                modules[row["path"]] = row
+
+        citations = []
+        seen = set()
+        for claim, reference, match in references:
+            if (claim, reference) in seen:
+                continue
+            seen.add((claim, reference))
+            citation = Citation(claim, reference)
+            if match is None and reference in definitions:
+                row = definitions[reference]
+                citation.qualified_name = row["qualified_name"]
+                citation.label = row["label"]
+                citation.path = row["path"]
+                citation.start_line = row.get("start_line")
+                citation.end_line = row.get("end_line")
+                citation.node_id = row["node_id"]
+            elif match is not None and match.group(1) in modules:
+                row = modules[match.group(1)]
+                citation.qualified_name = row["qualified_name"]
+                citation.label = "Module"
+                citation.path = row["path"]
+                citation.start_line = int(match.group(2))
+                citation.end_line = int(match.group(3) or match.group(2))
+                citation.node_id = row["node_id"]
+            citations.append(citation)
+        return CitedAnswer(text, citations)
@@ -0,0 +1,81 @@
+"""Shell completion values that come from the graph and the config file.
+
+Completion runs on every Tab press, so symbol names are not queried from
+Memgraph there: ingestion writes them to a plain-text index instead, one
+"qualified_name<TAB>label" line per function, method and class.
+"""
+
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .config import ConfigFileError, active_config_file, read_config_file, settings
+from .language_config import LANGUAGE_CONFIGS
+
+MAX_COMPLETIONS = 50
+
+
+def symbol_index_path() -> Path:
+    return Path(settings.COMPLETION_INDEX_PATH).expanduser()
+
+
+def write_symbol_index(ingestor: Any, path: Path | None = None) -> int:
+    """Store the graph's symbol names for completion; returns how many."""
+    path = path or symbol_index_path()
+    rows = ingestor.fetch_all(
+        "MATCH (n) WHERE n:Function OR n:Method OR n:Class "
+        "RETURN n.qualified_name AS name, labels(n)[0] AS label"
+    )
+    symbols = sorted({(row["name"], row["label"]) for row in rows if row.get("name")})
+    path.parent.mkdir(parents=True, exist_ok=True)
+    path.write_text(
+        "".join(f"{name}\t{label}\n" for name, label in symbols), encoding="utf-8"
+    )
+    logger.debug(f"Wrote {len(symbols)} symbols for completion to {path}")
+    return len(symbols)
+
+
+def complete_symbol(incomplete: str) -> list[tuple[str, str]]:
+    """
+    Qualified names starting with what was typed, or whose last part does,
+    so `total<Tab>` offers shop.cart.total as well as typing it in full.
+    """
+    path = symbol_index_path()
+    if not path.is_file():
+        return []
+    matches = []
+    with path.open(encoding="utf-8") as index:
+        for line in index:
+            name, _, label = line.rstrip("\n").partition("\t")
+            short_name = name.rsplit(".", 1)[-1]
+            if name.startswith(incomplete) or short_name.startswith(incomplete):
+                matches.append((name, label))
+                if len(matches) == MAX_COMPLETIONS:
+                    break
+    return matches
+
+
+def complete_profile(incomplete: str) -> list[str]:
+    """Profile names from the config file that would be loaded."""
+    path = active_config_file()
+    if path is None:
+        return []
+    try:
+        profiles = read_config_file(path).get("profiles") or {}
+    except ConfigFileError:
+        return []
+    return [name for name in profiles if name.startswith(incomplete)]
+
+
+def complete_language(incomplete: str) -> list[str]:
+    return [name for name in LANGUAGE_CONFIGS if name.startswith(incomplete)]
+
+
+def choices(*values: str) -> Any:
+    """A completion callback offering a fixed set of values."""
+
+    def complete(incomplete: str) -> list[str]:
+        return [value for value in values if value.startswith(incomplete)]
+
+    return complete
@@ -1,30 +1,222 @@
 from __future__ import annotations
 
-from typing import Literal
+import os
+import tomllib
+from pathlib import Path
+from typing import Any, Literal
 
+import yaml
 from dotenv import load_dotenv
-from pydantic import AnyHttpUrl
-from pydantic_settings import BaseSettings, SettingsConfigDict
+from loguru import logger
+from pydantic import AnyHttpUrl, ValidationError
+from pydantic.fields import FieldInfo
+from pydantic_settings import (
+    BaseSettings,
+    PydanticBaseSettingsSource,
+    SettingsConfigDict,
+)
 
 load_dotenv()
 
+# Looked up in the working directory and its parents, then in the user config
+CONFIG_FILE_NAMES = (".cgr.toml", ".cgr.yaml", ".cgr.yml")
+USER_CONFIG_FILE = Path("~/.config/cgr/config.toml")
+CONFIG_PATH_ENV = "CGR_CONFIG"
+PROFILE_ENV = "CGR_PROFILE"
+CONFIG_SECTIONS = {
+    "default_profile",
+    "settings",
+    "profiles",
+    "taint",
+    "layers",
+    "repositories",
+    "queries",
+}
 
-def detect_provider_from_model(model_name: str) -> Literal["gemini", "openai", "anthropic", "local"]:
-    """Detect the provider based on model name patterns."""
-    if model_name.startswith("gemini-"):
-        return "gemini"
-    elif model_name.startswith("gpt-") or model_name.startswith("o1-"):
-        return "openai"
-    elif model_name.startswith("claude-"):
-        return "anthropic"
+# Analyses made while ingesting that DISABLED_ANALYSES turns off, by name
+INGESTION_ANALYSES = {
+    "data-flow": "variable definitions and the flow of values between them",
+    "dependencies": "imports and exports of modules",
+    "security": "vulnerable patterns and taint flows",
+    "inheritance": "class hierarchies and method overrides",
+    "endpoints": "HTTP routes and their handlers",
+    "cycles": "circular dependencies between modules",
+    "git": "history, authors and churn of files",
+    "api": "exported symbols and their signatures, which api-diff compares",
+}
+
+# The file and profile settings are loaded from, when not found automatically
+_config_selection: dict[str, Any] = {"path": None, "profile": None}
+
+
+class ConfigFileError(ValueError):
+    """A config file that cannot be read or names a profile it lacks."""
+
+
+Provider = Literal["gemini", "openai", "anthropic", "local"]
+
+# Prefixes naming a model's provider, as in "ollama:qwen2.5-coder:7b"; local
+# models are served by Ollama or any other OpenAI-compatible server
+PROVIDER_PREFIXES: dict[str, Provider] = {
+    "gemini": "gemini",
+    "openai": "openai",
+    "anthropic": "anthropic",
+    "local": "local",
+    "ollama": "local",
+    "openai-compatible": "local",
+}
+
+
+def split_model_id(model_id: str) -> tuple[Provider, str]:
+    """
+    The provider of a model and its name as the provider knows it. A provider
+    prefix wins over the name, so a local "gpt-oss" is not sent to OpenAI.
+    """
+    prefix, _, name = model_id.partition(":")
+    if name and prefix in PROVIDER_PREFIXES:
+        return PROVIDER_PREFIXES[prefix], name
+    provider: Provider
+    if model_id.startswith("gemini-"):
+        provider = "gemini"
+    elif model_id.startswith("gpt-") or model_id.startswith("o1-"):
+        provider = "openai"
+    elif model_id.startswith("claude-"):
+        provider = "anthropic"
     else:
-        return "local"
+        provider = "local"
+    return provider, model_id
+
+
+def detect_provider_from_model(model_name: str) -> Provider:
+    """Detect the provider from a provider prefix or model name patterns."""
+    return split_model_id(model_name)[0]
+
+
+def local_endpoint(config: Any, role: str) -> str:
+    """The OpenAI-compatible server of a role's local model."""
+    endpoint = getattr(config, f"LOCAL_{role.upper()}_ENDPOINT", None)
+    return str(endpoint or config.LOCAL_MODEL_ENDPOINT)
+
+
+def find_config_file(start: Path | None = None) -> Path | None:
+    """The config file named by CGR_CONFIG, the nearest project one, or the user's."""
+    if os.environ.get(CONFIG_PATH_ENV):
+        return Path(os.environ[CONFIG_PATH_ENV]).expanduser()
+    directory = (start or Path.cwd()).resolve()
+    for candidate in (directory, *directory.parents):
+        for name in CONFIG_FILE_NAMES:
+            if (candidate / name).is_file():
+                return candidate / name
+    user_config = USER_CONFIG_FILE.expanduser()
+    return user_config if user_config.is_file() else None
+
+
+def read_config_file(path: Path) -> dict[str, Any]:
+    """Parse a TOML or YAML config file into its top-level mapping."""
+    try:
+        content = path.read_text(encoding="utf-8")
+        if path.suffix == ".toml":
+            document = tomllib.loads(content)
+        else:
+            document = yaml.safe_load(content) or {}
+    except (OSError, tomllib.TOMLDecodeError, yaml.YAMLError) as e:
+        raise ConfigFileError(f"Cannot read config file {path}: {e}") from e
+    if not isinstance(document, dict):
+        raise ConfigFileError(f"Config file {path} must contain a mapping")
+    return document
+
+
+def resolve_profile(document: dict[str, Any], profile: str | None) -> dict[str, Any]:
+    """
+    Merge the base settings with a profile and the profiles it extends, later
+    ones winning. Keys are returned upper-cased to match the setting names.
+    """
+    profiles = document.get("profiles") or {}
+    chain: list[dict[str, Any]] = []
+    name = profile
+    while name is not None:
+        if name not in profiles:
+            known = ", ".join(sorted(profiles)) or "none"
+            raise ConfigFileError(f"Unknown profile '{name}' (profiles: {known})")
+        values = profiles[name] or {}
+        if not isinstance(values, dict):
+            raise ConfigFileError(f"Profile '{name}' must be a mapping")
+        if any(values is seen for seen in chain):
+            raise ConfigFileError(f"Profile '{profile}' has circular extends")
+        chain.append(values)
+        name = values.get("extends")
+
+    base = document.get("settings") or {}
+    if not isinstance(base, dict):
+        raise ConfigFileError("The settings section must be a mapping")
+    merged: dict[str, Any] = {}
+    for values in [base, *reversed(chain)]:
+        merged.update(
+            {key.upper(): value for key, value in values.items() if key != "extends"}
+        )
+    return merged
+
+
+def profile_values(
+    path: Path | None = None, profile: str | None = None, strict: bool = False
+) -> dict[str, Any]:
+    """
+    Settings from the config file for the selected profile: the one given,
+    else CGR_PROFILE, else the file's default_profile. Unless strict, a broken
+    file is logged and ignored so commands that do not need it still run.
+    """
+    path = path or find_config_file()
+    if path is None:
+        return {}
+    try:
+        document = read_config_file(path)
+        name = (
+            profile or os.environ.get(PROFILE_ENV) or document.get("default_profile")
+        )
+        return resolve_profile(document, name)
+    except ConfigFileError as e:
+        if strict:
+            raise
+        logger.warning(f"Ignoring config file: {e}")
+        return {}
+
+
+class ConfigFileSettingsSource(PydanticBaseSettingsSource):
+    """Settings from the selected profile of a config file."""
+
+    def __init__(self, settings_cls: type[BaseSettings], values: dict[str, Any]):
+        super().__init__(settings_cls)
+        self.values = values
+
+    def get_field_value(
+        self, field: FieldInfo, field_name: str
+    ) -> tuple[Any, str, bool]:
+        return self.values.get(field_name), field_name, False
+
+    def __call__(self) -> dict[str, Any]:
+        # Unknown keys are reported by `config validate` rather than failing here
+        return {
+            key: _comma_separated(value)
+            for key, value in self.values.items()
+            if key in self.settings_cls.model_fields
+        }
+
+
+def _comma_separated(value: Any) -> Any:
+    """
+    A list from a config file as the comma separated text settings such as
+    DISABLED_LANGUAGES take, so `exclude_paths = ["docs/**"]` can be written.
+    """
+    if isinstance(value, list):
+        return ",".join(str(item) for item in value)
+    return value
 
 
 class AppConfig(BaseSettings):
     """
     Application Configuration using Pydantic for robust validation and type-safety.
-    All settings are loaded from environment variables or a .env file.
+    Settings are loaded from environment variables, a .env file and the selected
+    profile of a config file, in that order of precedence.
     """
 
     model_config = SettingsConfigDict(
@@ -33,9 +225,18 @@ class AppConfig(BaseSettings):
         case_sensitive=False,
     )
 
+    # The graph database: memgraph, neo4j for an existing Neo4j 5 server, or
+    # embedded for a file-backed graph needing no server
+    GRAPH_BACKEND: Literal["memgraph", "neo4j", "embedded"] = "memgraph"
     MEMGRAPH_HOST: str = "localhost"
     MEMGRAPH_PORT: int = 7687
     MEMGRAPH_HTTP_PORT: int = 7444
+    NEO4J_URI: str = "bolt://localhost:7687"
+    NEO4J_USERNAME: str = "neo4j"
+    NEO4J_PASSWORD: str | None = None
+    # The server's default database unless set
+    NEO4J_DATABASE: str | None = None
+    EMBEDDED_GRAPH_PATH: str = "~/.local/share/cgr/graph.db"
     LAB_PORT: int = 3000
 
     GEMINI_PROVIDER: Literal["gla", "vertex"] = "gla"
@@ -54,6 +255,10 @@ class AppConfig(BaseSettings):
     LOCAL_ORCHESTRATOR_MODEL_ID: str = "llama3"
     LOCAL_CYPHER_MODEL_ID: str = "llama3"
     LOCAL_MODEL_API_KEY: str = "ollama"
+    # Servers of the orchestrator and Cypher models when they differ from
+    # LOCAL_MODEL_ENDPOINT, e.g. a large model on a GPU host next to Ollama
+    LOCAL_ORCHESTRATOR_ENDPOINT: AnyHttpUrl | None = None
+    LOCAL_CYPHER_ENDPOINT: AnyHttpUrl | None = None
 
     OPENAI_API_KEY: str | None = None
     OPENAI_ORCHESTRATOR_MODEL_ID: str = "gpt-4o-mini"
@@ -64,12 +269,171 @@ class AppConfig(BaseSettings):
     ANTHROPIC_CYPHER_MODEL_ID: str = "claude-3-5-haiku-20241022"
 
     TARGET_REPO_PATH: str = "."
+    # Graph sinks receiving ingested nodes and relationships, comma separated:
+    # built-in names ("jsonl"), entry point names or "module:attribute"
+    GRAPH_SINKS: str = ""
+    GRAPH_SINK_JSONL_PATH: str = "graph-stream.jsonl"
+    # Nodes or relationships buffered per batched write, and the retries of a
+    # batch that conflicts with another transaction
+    GRAPH_BATCH_SIZE: int = 1000
+    GRAPH_WRITE_RETRIES: int = 3
+    # Results of read-only queries kept per chat session until the graph
+    # changes; 0 disables caching
+    QUERY_CACHE_SIZE: int = 256
+    # Directories with language plugins besides ~/.config/cgr/plugins,
+    # separated like PATH
+    LANGUAGE_PLUGIN_DIRS: str = ""
+    # Languages not to parse, comma separated, e.g. "java,scala"
+    DISABLED_LANGUAGES: str = ""
+    # Extensions parsed as another language, or not at all with an empty
+    # name: ".pyi=python,.gotmpl=,.h=cpp"
+    LANGUAGE_EXTENSIONS: str = ""
+    # Globs of repository paths to ingest and to leave out, comma separated,
+    # e.g. "services/**" and "**/generated/**,*.pb.go"; a glob without a
+    # slash matches file names at any depth. Empty includes everything
+    INCLUDE_PATHS: str = ""
+    EXCLUDE_PATHS: str = ""
+    # Leave out what the repository's .gitignore files ignore
+    RESPECT_GITIGNORE: bool = True
+    # Analyses not to make while ingesting, comma separated names from
+    # INGESTION_ANALYSES, e.g. "data-flow,security"
+    DISABLED_ANALYSES: str = ""
     SHELL_COMMAND_TIMEOUT: int = 30
+    # Store hashes instead of the code's text, keeping its structure (privacy.py)
+    PRIVATE_INGESTION: bool = False
+    PRIVACY_HASH_KEY: str | None = None
+    # JSON reports of ingestion runs, unless `start --report` names a file
+    INGESTION_REPORT_DIR: str = "~/.cache/cgr/reports"
+    # Symbol names offered by shell completion, refreshed after each ingestion
+    COMPLETION_INDEX_PATH: str = "~/.cache/cgr/symbols.tsv"
+    # Questions asked in chat sessions, recalled with Up and Ctrl+R; empty to
+    # keep them for the session only
+    REPL_HISTORY_PATH: str = "~/.cache/cgr/history"
+    # What parsing each file added to the graph, replayed for files whose
+    # contents have not changed; empty to parse every file on each ingestion
+    PARSE_CACHE_DIR: str = "~/.cache/cgr/parse-cache"
+    # Progress of full ingestions, saved every CHECKPOINT_INTERVAL files for
+    # `start --update-graph --resume`; empty to save none
+    CHECKPOINT_DIR: str = "~/.cache/cgr/checkpoints"
+    CHECKPOINT_INTERVAL: int = 500
+    # Source files above LARGE_FILE_BYTES, mostly generated code and bundles:
+    # "declarations" parses them for what they define only, "summarize" stores
+    # their size and line count unparsed, "skip" leaves them out. Files above
+    # MAX_PARSE_BYTES are never parsed (see large_files.py)
+    LARGE_FILE_BYTES: int = 1_000_000
+    LARGE_FILE_MODE: Literal["skip", "summarize", "declarations"] = "declarations"
+    MAX_PARSE_BYTES: int = 20_000_000
+    # Generated code, by its `Code generated ... DO NOT EDIT.` or @generated
+    # header or a path matching GENERATED_PATHS or the usual names (*.pb.go,
+    # mocks/): "full" ingests it, "declarations" parses it for what it
+    # defines only and "skip" leaves it out; its nodes get generated: true
+    # (see generated_code.py)
+    GENERATED_CODE_MODE: Literal["full", "declarations", "skip"] = "full"
+    GENERATED_PATHS: str = ""
+    # Embeddings behind semantic search (`embed`): "hashing" needs no model,
+    # "openai", "voyage" and "local" (LOCAL_MODEL_ENDPOINT) call an embeddings
+    # API and "sentence-transformers" runs the model in-process; the model
+    # defaults per provider
+    EMBEDDING_PROVIDER: Literal[
+        "hashing", "openai", "voyage", "sentence-transformers", "local"
+    ] = "hashing"
+    EMBEDDING_MODEL_ID: str | None = None
+    VOYAGE_API_KEY: str | None = None
+    # Reranking of semantic search results before they reach the prompt:
+    # "cross-encoder" (sentence-transformers) or "llm"; the model defaults to
+    # ms-marco-MiniLM or the Cypher model, and RERANK_TOP_K results are kept
+    RERANKER: Literal["none", "cross-encoder", "llm"] = "none"
+    RERANKER_MODEL_ID: str | None = None
+    RERANK_TOP_K: int = 5
+    # Code returned with a retrieved symbol: hops of each kind of edge
+    # followed (callers, callees, types, tests; empty for none), and the
+    # nearest related symbols kept per kind
+    CONTEXT_EXPANSION: str = "callers=1,callees=1,types=1,tests=1"
+    CONTEXT_EXPANSION_LIMIT: int = 5
+    # Web prefix of citation links, e.g. https://github.com/acme/shop/blob/main;
+    # file:// links into the checkout when empty
+    CITATION_BASE_URL: str = ""
+    # Share of the orchestrator model's context window one tool result may
+    # fill; CONTEXT_WINDOW_TOKENS overrides the window known for the model,
+    # and TOKENIZER_MODEL_ID names a Hugging Face tokenizer for local models
+    CONTEXT_WINDOW_TOKENS: int | None = None
+    RETRIEVAL_BUDGET_SHARE: float = 0.25
+    TOKENIZER_MODEL_ID: str | None = None
+    # Keep chat sessions in the graph as Conversation, Question and Answer
+    # nodes linked to the code the answers cited
+    CONVERSATION_MEMORY: bool = True
+
+    # Logging: level name, "text" or "json" lines, and an optional extra file
+    LOG_LEVEL: str = "INFO"
+    LOG_FORMAT: Literal["text", "json"] = "text"
+    LOG_FILE: str | None = None
+
+    # Server mode: webhook-driven updates of a shared graph; set SERVER_HOST
+    # to 0.0.0.0 (or pass --host) to accept connections from other machines
+    SERVER_HOST: str = "127.0.0.1"
+    SERVER_PORT: int = 8080
+    GITHUB_WEBHOOK_SECRET: str | None = None
+    GITHUB_TOKEN: str | None = None
+    JIRA_URL: str | None = None
+    # Jira Cloud authenticates with an API token plus the account email;
+    # leave JIRA_EMAIL unset to use a Server/Data Center personal access token
+    JIRA_EMAIL: str | None = None
+    JIRA_API_TOKEN: str | None = None
+    GITLAB_WEBHOOK_SECRET: str | None = None
+    GITLAB_TOKEN: str | None = None
+    # Set for GitLab deploy tokens; personal and project tokens use "oauth2"
+    GITLAB_TOKEN_USERNAME: str | None = None
+    BITBUCKET_WEBHOOK_SECRET: str | None = None
+    BITBUCKET_TOKEN: str | None = None
+    # Set for app passwords and Bitbucket Server tokens; access tokens need none
+    BITBUCKET_TOKEN_USERNAME: str | None = None
+    # Bearer token editor extensions must send to the /rpc endpoint
+    EDITOR_RPC_TOKEN: str | None = None
+    # Hashed API tokens with roles and repo scopes; replaces EDITOR_RPC_TOKEN
+    # on /rpc and enables /query once it exists (see `api-token`)
+    API_TOKENS_FILE: str = "~/.config/cgr/api-tokens.toml"
+    # Runs of the jobs in a repository's [schedule] config, kept across restarts
+    SCHEDULE_HISTORY_FILE: str = "~/.cache/cgr/schedule-runs.jsonl"
+    # Chat bots: Slack app credentials and Discord application public key
+    SLACK_BOT_TOKEN: str | None = None
+    SLACK_SIGNING_SECRET: str | None = None
+    DISCORD_PUBLIC_KEY: str | None = None
+
+    # Models to use when none is given on the command line, e.g. per profile
+    ORCHESTRATOR_MODEL: str | None = None
+    CYPHER_MODEL: str | None = None
+    # Provider whose *_ORCHESTRATOR_MODEL_ID and *_CYPHER_MODEL_ID are used
+    # when no model is given at all
+    MODEL_PROVIDER: Provider = "gemini"
+    # Refuse cloud models and embeddings, for code that must not leave the
+    # building
+    LOCAL_ONLY: bool = False
 
     # Active models (set via CLI or defaults)
     _active_orchestrator_model: str | None = None
     _active_cypher_model: str | None = None
 
+    @classmethod
+    def settings_customise_sources(
+        cls,
+        settings_cls: type[BaseSettings],
+        init_settings: PydanticBaseSettingsSource,
+        env_settings: PydanticBaseSettingsSource,
+        dotenv_settings: PydanticBaseSettingsSource,
+        file_secret_settings: PydanticBaseSettingsSource,
+    ) -> tuple[PydanticBaseSettingsSource, ...]:
+        config_file = ConfigFileSettingsSource(
+            settings_cls,
+            profile_values(_config_selection["path"], _config_selection["profile"]),
+        )
+        return (
+            init_settings,
+            env_settings,
+            dotenv_settings,
+            config_file,
+            file_secret_settings,
+        )
+
     def validate_for_usage(self) -> None:
         """Validate that required API keys are set for the providers being used."""
         # Get the providers for active models
@@ -102,6 +466,22 @@ class AppConfig(BaseSettings):
                 raise ValueError(
                     "Configuration Error: ANTHROPIC_API_KEY is required when using Anthropic models."
                 )
+
+        if self.LOCAL_ONLY:
+            if self.RERANKER == "llm" and self.RERANKER_MODEL_ID:
+                providers_in_use.add(detect_provider_from_model(self.RERANKER_MODEL_ID))
+            cloud = sorted(providers_in_use - {"local"})
+            if cloud:
+                raise ValueError(
+                    f"Configuration Error: LOCAL_ONLY is set but {', '.join(cloud)} "
+                    "models are configured; use local models, e.g. MODEL_PROVIDER=local."
+                )
+            if self.EMBEDDING_PROVIDER in ("openai", "voyage"):
+                raise ValueError(
+                    "Configuration Error: LOCAL_ONLY is set but EMBEDDING_PROVIDER is "
+                    f"{self.EMBEDDING_PROVIDER}; use hashing, sentence-transformers "
+                    "or local embeddings."
+                )
         return
 
     @property
@@ -109,16 +489,29 @@ class AppConfig(BaseSettings):
         """Determines the active orchestrator model ID."""
         if self._active_orchestrator_model:
             return self._active_orchestrator_model
-        # Default fallback to Gemini
-        return self.GEMINI_MODEL_ID
+        if self.ORCHESTRATOR_MODEL:
+            return self.ORCHESTRATOR_MODEL
+        return self.default_model("orchestrator")
 
     @property
     def active_cypher_model(self) -> str:
         """Determines the active cypher model ID."""
         if self._active_cypher_model:
             return self._active_cypher_model
-        # Default fallback to Gemini
-        return self.MODEL_CYPHER_ID
+        if self.CYPHER_MODEL:
+            return self.CYPHER_MODEL
+        return self.default_model("cypher")
+
+    def default_model(self, role: str) -> str:
+        """A role's model of MODEL_PROVIDER, prefixed with the provider."""
+        if self.MODEL_PROVIDER == "gemini":
+            # Gemini model names predate the per-provider ones
+            if role == "orchestrator":
+                return self.GEMINI_MODEL_ID
+            return self.MODEL_CYPHER_ID
+        provider = self.MODEL_PROVIDER
+        model_id = getattr(self, f"{provider.upper()}_{role.upper()}_MODEL_ID")
+        return f"{provider}:{model_id}"
 
     def set_orchestrator_model(self, model: str) -> None:
         """Set the active orchestrator model."""
@@ -129,4 +522,81 @@ class AppConfig(BaseSettings):
         self._active_cypher_model = model
 
 
+def load_settings(path: Path | None = None, profile: str | None = None) -> AppConfig:
+    """
+    Reload the shared settings from a config file and profile. The settings
+    object is updated in place because modules hold on to it since import.
+    """
+    # Fail loudly on an explicit selection instead of silently ignoring it
+    if profile and (path or find_config_file()) is None:
+        raise ConfigFileError(f"Profile '{profile}' given but no config file found")
+    profile_values(path, profile, strict=True)
+    _config_selection.update(path=path, profile=profile)
+    reloaded = AppConfig()
+    for name in AppConfig.model_fields:
+        setattr(settings, name, getattr(reloaded, name))
+    return settings
+
+
+def split_names(value: str) -> list[str]:
+    """The entries of a comma separated setting, stripped, empty ones dropped."""
+    return [entry.strip() for entry in value.split(",") if entry.strip()]
+
+
+def active_config_file() -> Path | None:
+    """The config file the shared settings were loaded from, if any."""
+    return _config_selection["path"] or find_config_file()
+
+
+def validate_config_file(path: Path) -> list[str]:
+    """Problems with a config file and each of its profiles; empty if valid."""
+    document = read_config_file(path)
+    problems = [
+        f"Unknown section '{key}'" for key in sorted(set(document) - CONFIG_SECTIONS)
+    ]
+    known = {name.lower() for name in AppConfig.model_fields} | {"extends"}
+    profiles = document.get("profiles") or {}
+    sections = {"settings": document.get("settings") or {}}
+    sections.update({f"profile '{name}'": v or {} for name, v in profiles.items()})
+    for section, values in sections.items():
+        if not isinstance(values, dict):
+            problems.append(f"{section} must be a mapping")
+            continue
+        problems += [
+            f"{section}: unknown setting '{key}'"
+            for key in sorted(values)
+            if key.lower() not in known
+        ]
+
+    default = document.get("default_profile")
+    if default is not None and default not in profiles:
+        problems.append(f"default_profile '{default}' is not a profile")
+    # Build each profile the way commands would, so env overrides apply too
+    previous = dict(_config_selection)
+    for name in list(profiles) or [None]:
+        label = f"profile '{name}'" if name else "settings"
+        _config_selection.update(path=path, profile=name)
+        try:
+            profile_values(path, name, strict=True)
+            profile_config = AppConfig()
+            problems += [
+                f"{label}: unknown analysis '{name}' in disabled_analyses"
+                for name in split_names(profile_config.DISABLED_ANALYSES)
+                if name not in INGESTION_ANALYSES
+            ]
+            # API keys only matter for profiles that pick the models to use
+            if profile_config.ORCHESTRATOR_MODEL or profile_config.CYPHER_MODEL:
+                profile_config.validate_for_usage()
+        except ValidationError as e:
+            problems += [
+                f"{label}: {'.'.join(map(str, error['loc']))}: {error['msg']}"
+                for error in e.errors()
+            ]
+        except ValueError as e:  # Includes ConfigFileError
+            problems.append(f"{label}: {e}")
+        finally:
+            _config_selection.update(previous)
+    return problems
+
+
 settings = AppConfig()
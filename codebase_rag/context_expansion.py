@@ -0,0 +1,197 @@
+"""Code shown alongside a retrieved function: its callers, callees, types and tests.
+
+A function's source alone rarely answers a question about it: what calls it
+with which arguments, what it hands its results to, the class it belongs to
+and what that class implements, and the tests pinning its behaviour are all
+one or two edges away. An expansion policy sets how many hops of each kind
+of edge to follow, e.g. "callers=2,callees=1,types=1,tests=1"; the nearest
+related symbols of each kind are returned with the start of their source.
+"""
+
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+# Hops followed for any one kind; longer call chains fan out to most of a graph
+MAX_HOPS = 4
+# Source lines returned per related symbol
+MAX_RELATED_LINES = 30
+
+_LOCATION = """
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(x)
+RETURN x.qualified_name AS qualified_name, labels(x)[0] AS label, hops,
+       m.path AS path, x.start_line AS start_line, x.end_line AS end_line
+ORDER BY hops, qualified_name
+LIMIT $limit
+"""
+
+
+def callers_query(hops: int) -> str:
+    return f"""
+MATCH p = (x)-[:CALLS*1..{hops}]->(n {{qualified_name: $qualified_name}})
+WHERE (x:Function OR x:Method) AND x <> n
+WITH x, min(size(relationships(p))) AS hops
+{_LOCATION}"""
+
+
+def callees_query(hops: int) -> str:
+    return f"""
+MATCH p = (n {{qualified_name: $qualified_name}})-[:CALLS*1..{hops}]->(x)
+WHERE (x:Function OR x:Method OR x:Interface) AND x <> n
+WITH x, min(size(relationships(p))) AS hops
+{_LOCATION}"""
+
+
+def types_query(hops: int) -> str:
+    # The class defining a method is one hop away; its bases, interfaces and
+    # the generic types used are followed from it and from the symbol itself
+    return f"""
+MATCH (n {{qualified_name: $qualified_name}})
+OPTIONAL MATCH (owner)-[:DEFINES_METHOD]->(n)
+WITH n, owner
+UNWIND [s IN [n, owner] WHERE s IS NOT NULL] AS start
+MATCH p = (start)-[:INHERITS_FROM|IMPLEMENTS|INSTANTIATES*0..{hops}]->(x)
+WHERE (x:Class OR x:Interface OR x:Struct OR x:Typedef) AND x <> n
+WITH x, min(size(relationships(p)) + CASE WHEN start = n THEN 0 ELSE 1 END)
+     AS hops
+WHERE hops >= 1 AND hops <= {hops}
+{_LOCATION}"""
+
+
+def tests_query(hops: int) -> str:
+    # A test of the symbol is one hop away, a test of a caller two
+    return f"""
+MATCH p = (f)-[:CALLS*0..{hops - 1}]->(n {{qualified_name: $qualified_name}})
+WHERE f:Function OR f:Method
+MATCH (f)-[:TESTS|COVERS|COVERED_BY]-(x)
+WHERE x:TestFunction OR x:TestCase
+WITH x, min(size(relationships(p)) + 1) AS hops
+OPTIONAL MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(x)
+RETURN x.qualified_name AS qualified_name, labels(x)[0] AS label, hops,
+       m.path AS path, x.start_line AS start_line, x.end_line AS end_line
+ORDER BY hops, qualified_name
+LIMIT $limit
+"""
+
+
+QUERIES = {
+    "callers": callers_query,
+    "callees": callees_query,
+    "types": types_query,
+    "tests": tests_query,
+}
+RELATIONS = {"callers": "caller", "callees": "callee", "types": "type", "tests": "test"}
+
+
+@dataclass(frozen=True)
+class ExpansionPolicy:
+    """Hops followed per kind of edge, 0 to leave a kind out."""
+
+    callers: int = 1
+    callees: int = 1
+    types: int = 1
+    tests: int = 1
+    # Related symbols kept per kind, nearest first
+    limit: int = 5
+
+    @classmethod
+    def parse(cls, spec: str, limit: int = 5) -> "ExpansionPolicy":
+        """
+        A policy from "kind=hops" pairs separated by commas; kinds not named
+        are not expanded, and an empty spec expands nothing.
+        """
+        hops = dict.fromkeys(QUERIES, 0)
+        for entry in (e.strip() for e in spec.split(",")):
+            if not entry:
+                continue
+            kind, _, value = entry.partition("=")
+            kind = kind.strip().lower()
+            if kind not in QUERIES:
+                raise ValueError(
+                    f"Unknown context expansion '{kind}'; "
+                    f"choose from {', '.join(QUERIES)}"
+                )
+            try:
+                hops[kind] = int(value)
+            except ValueError as e:
+                raise ValueError(f"Expected {kind}=<hops>, got '{entry}'") from e
+            if not 0 <= hops[kind] <= MAX_HOPS:
+                raise ValueError(f"{kind} hops must be between 0 and {MAX_HOPS}")
+        return cls(**hops, limit=limit)
+
+    @property
+    def enabled(self) -> bool:
+        return any(getattr(self, kind) for kind in QUERIES)
+
+
+@dataclass
+class RelatedCode:
+    qualified_name: str
+    label: str
+    relation: str  # caller, callee, type or test
+    hops: int
+    path: str | None
+    start_line: int | None
+    source: str = ""
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+class ContextExpander:
+    """Finds the code related to a symbol as far as a policy allows."""
+
+    def __init__(
+        self, ingestor: Any, policy: ExpansionPolicy, repo_path: Path | None = None
+    ):
+        self.ingestor = ingestor
+        self.policy = policy
+        self.repo_path = repo_path
+
+    def expand(self, qualified_name: str) -> list[RelatedCode]:
+        related = []
+        files: dict[str, list[str]] = {}
+        for kind, query in QUERIES.items():
+            hops = getattr(self.policy, kind)
+            if not hops:
+                continue
+            try:
+                rows = self.ingestor.fetch_all(
+                    query(hops),
+                    {"qualified_name": qualified_name, "limit": self.policy.limit},
+                )
+            except Exception as e:
+                logger.warning(f"Could not expand {kind} of {qualified_name}: {e}")
+                continue
+            for row in rows:
+                related.append(
+                    RelatedCode(
+                        row["qualified_name"],
+                        row["label"],
+                        RELATIONS[kind],
+                        row["hops"],
+                        row.get("path"),
+                        row.get("start_line"),
+                        self._source(row, files),
+                    )
+                )
+        return related
+
+    def _source(self, row: dict[str, Any], files: dict[str, list[str]]) -> str:
+        path = row.get("path")
+        if not self.repo_path or not path or not row.get("start_line"):
+            return ""
+        if path not in files:
+            try:
+                files[path] = (
+                    (self.repo_path / path)
+                    .read_text(encoding="utf-8", errors="replace")
+                    .splitlines()
+                )
+            except OSError:
+                files[path] = []
+        start = row["start_line"] - 1
+        end = min(row.get("end_line") or start + 1, start + MAX_RELATED_LINES)
+        return "\n".join(files[path][start:end])
@@ -0,0 +1,217 @@
+"""Chat sessions kept in the graph, next to the code they discussed.
+
+A session is a Conversation node; each question asked in it is a Question
+node, in order (HAS_QUESTION, and FOLLOWS to the question before), answered
+by an Answer node that REFERENCES the functions, classes and modules the
+answer cited. A follow-up such as "why does it panic?" is sent along with
+the code discussed last, so the agent knows what "it" is, and a session can
+be resumed later with its exchanges as history. The investigations of a
+function are the questions whose answers referenced it.
+"""
+
+import re
+import uuid
+from dataclasses import asdict, dataclass
+from datetime import UTC, datetime
+from typing import Any
+
+from pydantic_ai.messages import (
+    ModelMessage,
+    ModelRequest,
+    ModelResponse,
+    TextPart,
+    UserPromptPart,
+)
+
+from .citations import CitedAnswer
+
+# Words that refer back to code discussed earlier
+PRONOUN = re.compile(r"\b(it|its|this|that|these|those|they|them|their)\b", re.I)
+# Symbols of the latest answers sent with a question referring back to them
+RECENT_SYMBOLS = 5
+
+TRANSCRIPT_QUERY = """
+MATCH (:Conversation {conversation_id: $conversation_id})-[:HAS_QUESTION]->(q)
+OPTIONAL MATCH (q)-[:ANSWERED_BY]->(a:Answer)
+RETURN q.index AS index, q.text AS question, a.text AS answer,
+       q.asked_at AS asked_at
+ORDER BY index
+"""
+
+RECENT_SYMBOLS_QUERY = """
+MATCH (:Conversation {conversation_id: $conversation_id})-[:HAS_QUESTION]->(q)
+MATCH (q)-[:ANSWERED_BY]->(:Answer)-[:REFERENCES]->(s)
+RETURN s.qualified_name AS qualified_name, max(q.index) AS turn
+ORDER BY turn DESC, qualified_name
+LIMIT $limit
+"""
+
+CONVERSATIONS_QUERY = """
+MATCH (c:Conversation)
+OPTIONAL MATCH (c)-[:HAS_QUESTION]->(q:Question)
+WITH c, q ORDER BY q.index
+WITH c, collect(q.text) AS questions
+RETURN c.conversation_id AS conversation_id, c.repo AS repo, c.user AS user,
+       c.started_at AS started_at, size(questions) AS question_count,
+       questions[0] AS first_question
+ORDER BY started_at DESC
+LIMIT $limit
+"""
+
+INVESTIGATIONS_QUERY = """
+MATCH (c:Conversation)-[:HAS_QUESTION]->(q:Question)-[:ANSWERED_BY]->(a:Answer)
+MATCH (a)-[:REFERENCES]->(s {qualified_name: $qualified_name})
+RETURN q.index AS index, q.text AS question, a.text AS answer,
+       q.asked_at AS asked_at, c.conversation_id AS conversation_id,
+       c.user AS user
+ORDER BY asked_at DESC
+LIMIT $limit
+"""
+
+
+@dataclass
+class Exchange:
+    """A question of a conversation and the answer it got."""
+
+    index: int
+    question: str
+    answer: str | None
+    asked_at: str | None = None
+    conversation_id: str | None = None
+    user: str | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+def history_messages(exchanges: list[Exchange]) -> list[ModelMessage]:
+    """Agent message history replaying the exchanges of a resumed session."""
+    messages: list[ModelMessage] = []
+    for exchange in exchanges:
+        messages.append(ModelRequest(parts=[UserPromptPart(exchange.question)]))
+        if exchange.answer is not None:
+            messages.append(ModelResponse(parts=[TextPart(exchange.answer)]))
+    return messages
+
+
+def conversations(ingestor: Any, limit: int = 20) -> list[dict[str, Any]]:
+    """The latest conversations with their first question."""
+    return ingestor.fetch_all(CONVERSATIONS_QUERY, {"limit": limit})
+
+
+def investigations(
+    ingestor: Any, qualified_name: str, limit: int = 20
+) -> list[Exchange]:
+    """Questions, latest first, whose answers referenced a symbol."""
+    return [
+        Exchange(**row)
+        for row in ingestor.fetch_all(
+            INVESTIGATIONS_QUERY, {"qualified_name": qualified_name, "limit": limit}
+        )
+    ]
+
+
+class ConversationStore:
+    """Records one conversation's exchanges and the code they referenced."""
+
+    def __init__(
+        self,
+        ingestor: Any,
+        conversation_id: str | None = None,
+        repo: str = "",
+        user: str = "",
+    ):
+        self.ingestor = ingestor
+        self.conversation_id = conversation_id or uuid.uuid4().hex[:12]
+        self.repo = repo
+        self.user = user
+        # Questions recorded so far; the Conversation node is written with
+        # the first of them, so a session asking nothing leaves no trace
+        self.turns = 0
+
+    def resume(self) -> list[Exchange]:
+        """The exchanges recorded so far, for a session continuing this one."""
+        exchanges = [
+            Exchange(**row, conversation_id=self.conversation_id)
+            for row in self.ingestor.fetch_all(
+                TRANSCRIPT_QUERY, {"conversation_id": self.conversation_id}
+            )
+        ]
+        if not exchanges:
+            raise ValueError(f"No conversation '{self.conversation_id}' in the graph")
+        self.turns = exchanges[-1].index + 1
+        return exchanges
+
+    def with_context(self, question: str) -> str:
+        """
+        A question that refers back to earlier code, with the symbols the
+        latest answers referenced; other questions are returned unchanged.
+        """
+        if not self.turns or not PRONOUN.search(question):
+            return question
+        rows = self.ingestor.fetch_all(
+            RECENT_SYMBOLS_QUERY,
+            {"conversation_id": self.conversation_id, "limit": RECENT_SYMBOLS},
+        )
+        if not rows:
+            return question
+        symbols = ", ".join(f"`{row['qualified_name']}`" for row in rows)
+        return f"{question}\n\n(Code discussed most recently: {symbols})"
+
+    def record(self, question: str, answer: CitedAnswer) -> None:
+        """Store a question and its answer, linked to the code it cited."""
+        index = self.turns
+        now = datetime.now(UTC).isoformat()
+        if index == 0:
+            self.ingestor.ensure_node_batch(
+                "Conversation",
+                {
+                    "conversation_id": self.conversation_id,
+                    "repo": self.repo,
+                    "user": self.user,
+                    "started_at": now,
+                },
+            )
+        question_id = f"{self.conversation_id}:{index}"
+        self.ingestor.ensure_node_batch(
+            "Question",
+            {
+                "question_id": question_id,
+                "conversation_id": self.conversation_id,
+                "index": index,
+                "text": question,
+                "asked_at": now,
+            },
+        )
+        self.ingestor.ensure_node_batch(
+            "Answer", {"answer_id": question_id, "text": answer.text}
+        )
+        self.ingestor.ensure_relationship_batch(
+            ("Conversation", "conversation_id", self.conversation_id),
+            "HAS_QUESTION",
+            ("Question", "question_id", question_id),
+        )
+        if index:
+            self.ingestor.ensure_relationship_batch(
+                ("Question", "question_id", question_id),
+                "FOLLOWS",
+                ("Question", "question_id", f"{self.conversation_id}:{index - 1}"),
+            )
+        self.ingestor.ensure_relationship_batch(
+            ("Question", "question_id", question_id),
+            "ANSWERED_BY",
+            ("Answer", "answer_id", question_id),
+        )
+        referenced = {
+            (citation.label, citation.qualified_name)
+            for citation in answer.citations
+            if citation.resolved and citation.qualified_name and citation.label
+        }
+        for label, qualified_name in sorted(referenced):
+            self.ingestor.ensure_relationship_batch(
+                ("Answer", "answer_id", question_id),
+                "REFERENCES",
+                (label, "qualified_name", qualified_name),
+            )
+        self.ingestor.flush_all()
+        self.turns += 1
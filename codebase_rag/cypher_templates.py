@@ -0,0 +1,289 @@
+"""Parameterized Cypher for common questions, and checks on generated Cypher.
+
+The query model writes Cypher for any question, but sometimes invents labels
+or relationship types, or writes syntax Memgraph rejects. The templates here
+answer the most frequent questions (who calls a function, what implements an
+interface, which tests reach a function, how one function reaches another)
+with fixed queries whose labels and relationships are checked against the
+schema the model is given. Symbols are resolved to qualified names with the
+fuzzy symbol index, so "calculator.divde" finds shop.Calculator.divide.
+
+Generated queries are checked the same way before they run; a query with
+problems, or one Memgraph rejects, is sent back to the model with the
+reason, and after the last attempt a template matching the question is used
+instead.
+"""
+
+import re
+from dataclasses import dataclass
+from functools import cache
+from typing import Any
+
+from loguru import logger
+
+from .query_templates import ENHANCED_GRAPH_SCHEMA
+from .symbol_search import SymbolIndex
+
+# Generated queries tried before falling back to a template
+MAX_ATTEMPTS = 3
+# Calls followed between two symbols by path_between_symbols
+MAX_PATH_LENGTH = 8
+DEFAULT_LIMIT = 50
+
+# Where each definition lives, for the path column of the results
+_DEFINED_IN = "OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->({var})"
+
+
+@dataclass(frozen=True)
+class QueryTemplate:
+    name: str
+    description: str
+    # Symbol parameters, resolved to qualified names before the query runs
+    parameters: tuple[str, ...]
+    query: str
+
+
+TEMPLATES = {
+    template.name: template
+    for template in [
+        QueryTemplate(
+            "find_callers",
+            "Functions and methods calling a function or method",
+            ("symbol",),
+            f"""
+MATCH (caller)-[c:CALLS]->(f {{qualified_name: $symbol}})
+WHERE caller:Function OR caller:Method
+{_DEFINED_IN.format(var="caller")}
+RETURN DISTINCT caller.qualified_name AS caller, labels(caller)[0] AS kind,
+       m.path AS path, c.line_number AS line_number
+ORDER BY path, line_number
+LIMIT $limit
+""",
+        ),
+        QueryTemplate(
+            "find_callees",
+            "Functions and methods a function or method calls",
+            ("symbol",),
+            f"""
+MATCH (f {{qualified_name: $symbol}})-[c:CALLS]->(callee)
+WHERE callee:Function OR callee:Method OR callee:Interface
+{_DEFINED_IN.format(var="callee")}
+RETURN DISTINCT callee.qualified_name AS callee, labels(callee)[0] AS kind,
+       m.path AS path, c.line_number AS line_number
+ORDER BY line_number
+LIMIT $limit
+""",
+        ),
+        QueryTemplate(
+            "find_implementations",
+            "Classes implementing an interface or inheriting from a class",
+            ("symbol",),
+            f"""
+MATCH (impl)-[r:IMPLEMENTS|INHERITS_FROM]->(base {{qualified_name: $symbol}})
+{_DEFINED_IN.format(var="impl")}
+RETURN DISTINCT impl.qualified_name AS implementation, labels(impl)[0] AS kind,
+       type(r) AS relationship, m.path AS path
+ORDER BY implementation
+LIMIT $limit
+""",
+        ),
+        QueryTemplate(
+            "tests_for_function",
+            "Tests linked to a function or method, by name or coverage",
+            ("symbol",),
+            """
+MATCH (t)-[r:TESTS|COVERS]->(f {qualified_name: $symbol})
+WHERE t:TestFunction OR t:TestCase
+OPTIONAL MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(t)
+RETURN DISTINCT t.qualified_name AS test, labels(t)[0] AS kind,
+       type(r) AS link, m.path AS path
+UNION
+MATCH (f {qualified_name: $symbol})-[r:COVERED_BY]->(t)
+WHERE t:TestFunction OR t:TestCase
+OPTIONAL MATCH (m:Module)-[:CONTAINS_TEST*1..4]->(t)
+RETURN DISTINCT t.qualified_name AS test, labels(t)[0] AS kind,
+       type(r) AS link, m.path AS path
+""",
+        ),
+        QueryTemplate(
+            "path_between_symbols",
+            "The shortest call chain from one function or method to another",
+            ("symbol", "target"),
+            f"""
+MATCH (a {{qualified_name: $symbol}}), (b {{qualified_name: $target}})
+MATCH p = (a)-[:CALLS|CALLS_RPC *BFS ..{MAX_PATH_LENGTH}]->(b)
+RETURN [n IN nodes(p) | n.qualified_name] AS chain, size(relationships(p)) AS hops
+LIMIT 1
+""",
+        ),
+    ]
+}
+
+# Question shapes answered by each template, for the fallback
+_SYMBOL = r"[`'\"]?([\w.:/*]+)[`'\"]?"
+QUESTION_PATTERNS = [
+    (
+        "path_between_symbols",
+        rf"(?:path|chain|get)\s+from\s+{_SYMBOL}\s+to\s+{_SYMBOL}",
+    ),
+    (
+        "path_between_symbols",
+        rf"how\s+does\s+{_SYMBOL}\s+(?:reach|call|get\s+to)\s+{_SYMBOL}",
+    ),
+    ("find_callers", rf"(?:who|what|which\s+\w+)\s+calls?\s+{_SYMBOL}"),
+    ("find_callers", rf"callers\s+of\s+{_SYMBOL}"),
+    ("find_callees", rf"what\s+does\s+{_SYMBOL}\s+call"),
+    ("find_callees", rf"(?:callees\s+of|calls\s+made\s+by)\s+{_SYMBOL}"),
+    (
+        "find_implementations",
+        rf"(?:implement(?:s|ations?|ers?)|subclass(?:es)?)\s+(?:of\s+)?{_SYMBOL}",
+    ),
+    ("tests_for_function", rf"tests?\s+(?:for|of|covering)\s+{_SYMBOL}"),
+    (
+        "tests_for_function",
+        rf"(?:which|what)\s+tests\s+(?:cover|exercise|reach)\s+{_SYMBOL}",
+    ),
+]
+_WRITE = re.compile(r"\b(CREATE|MERGE|DELETE|DETACH|SET|REMOVE|DROP|LOAD)\b", re.I)
+_STRING = re.compile(r"'(?:[^'\\]|\\.)*'|\"(?:[^\"\\]|\\.)*\"")
+_NODE_LABELS = re.compile(r"\(\s*\w*\s*((?::\s*\w+\s*(?:\|\s*\w+\s*)*)+)")
+_LABEL_PREDICATE = re.compile(r"\b\w+\s*:\s*([A-Z]\w*)\b")
+_RELATIONSHIP_TYPES = re.compile(r"\[\s*\w*\s*:\s*(\w+(?:\s*\|\s*:?\s*\w+)*)")
+
+
+@dataclass(frozen=True)
+class GraphSchema:
+    labels: frozenset[str]
+    relationship_types: frozenset[str]
+    # From entries such as CONTAINS_*, covering CONTAINS_FILE and the rest
+    relationship_prefixes: tuple[str, ...]
+
+    def knows_relationship(self, name: str) -> bool:
+        return name in self.relationship_types or name.startswith(
+            self.relationship_prefixes
+        )
+
+
+@cache
+def graph_schema(schema_text: str = ENHANCED_GRAPH_SCHEMA) -> GraphSchema:
+    """The node labels and relationship types a schema description lists."""
+    labels = set(re.findall(r"^- (\w+): \{", schema_text, re.M))
+    types = set()
+    for line in schema_text.splitlines():
+        if not re.match(r"^- [A-Z][A-Z_*]+", line):
+            continue
+        # "- SENDS_TO / RECEIVES_FROM (...)" or "- A (...); B (...)"
+        for entry in line[2:].split(";"):
+            types.update(re.findall(r"\b[A-Z][A-Z_]*[A-Z*]", entry.split("(")[0]))
+    prefixes = tuple(sorted(t[:-1] for t in types if t.endswith("*")))
+    return GraphSchema(
+        frozenset(labels),
+        frozenset(t for t in types if not t.endswith("*")),
+        prefixes,
+    )
+
+
+def schema_problems(query: str, schema: GraphSchema | None = None) -> list[str]:
+    """
+    What is wrong with a read query before it runs: writes, and labels or
+    relationship types the schema does not have.
+    """
+    schema = schema or graph_schema()
+    code = _STRING.sub("''", query)
+    problems = []
+    if write := _WRITE.search(code):
+        problems.append(f"{write.group(1).upper()} writes to the graph; only read")
+    labels = {
+        label.strip()
+        for match in _NODE_LABELS.finditer(code)
+        for label in re.split(r"[:|]", match.group(1))
+        if label.strip()
+    }
+    # Outside relationship patterns, where r:TYPE is not a label
+    labels |= set(_LABEL_PREDICATE.findall(re.sub(r"\[[^\]]*\]", "[]", code)))
+    for label in sorted(labels - schema.labels):
+        problems.append(f"Unknown node label :{label}")
+    for match in _RELATIONSHIP_TYPES.finditer(code):
+        for name in re.split(r"\s*\|\s*:?\s*", match.group(1)):
+            if name and not schema.knows_relationship(name):
+                problems.append(f"Unknown relationship type :{name}")
+    return problems
+
+
+def retry_prompt(question: str, query: str, problems: list[str]) -> str:
+    """The question again, with the rejected query and why it failed."""
+    reasons = "\n".join(f"- {problem}" for problem in problems)
+    return (
+        f"{question}\n\nThis Cypher query was rejected:\n{query}\n\nBecause:\n"
+        f"{reasons}\n\nWrite a corrected query using only the labels, "
+        "relationship types and properties of the schema."
+    )
+
+
+def match_question(question: str) -> tuple[str, list[str]] | None:
+    """The template answering a question, and its symbol arguments, if any."""
+    for name, pattern in QUESTION_PATTERNS:
+        match = re.search(pattern, question, re.I)
+        if match:
+            return name, [arg.rstrip(".:") for arg in match.groups()]
+    return None
+
+
+@dataclass
+class TemplateResult:
+    template: str
+    query: str
+    parameters: dict[str, Any]
+    results: list[dict[str, Any]]
+    # Symbols as given, for those that matched no qualified name
+    unresolved: list[str]
+
+
+class TemplateRunner:
+    """Runs templates with symbols resolved against the graph's names."""
+
+    def __init__(self, ingestor: Any, min_score: float = 0.8):
+        self.ingestor = ingestor
+        self.min_score = min_score
+        self._symbols: SymbolIndex | None = None
+
+    @property
+    def symbols(self) -> SymbolIndex:
+        """Graph symbol names, loaded on first use."""
+        if self._symbols is None:
+            try:
+                self._symbols = SymbolIndex.from_graph(self.ingestor)
+            except Exception as e:
+                logger.warning(f"Symbol names unavailable: {e}")
+                self._symbols = SymbolIndex([])
+        return self._symbols
+
+    def resolve(self, symbol: str) -> str | None:
+        """The qualified name best matching a symbol as written."""
+        found = self.symbols.search(symbol, limit=1)
+        if found and found[0].score >= self.min_score:
+            return found[0].qualified_name
+        return None
+
+    def run(
+        self, name: str, symbols: list[str], limit: int = DEFAULT_LIMIT
+    ) -> TemplateResult:
+        template = TEMPLATES.get(name)
+        if template is None:
+            raise ValueError(
+                f"Unknown template '{name}'; choose from {', '.join(TEMPLATES)}"
+            )
+        if len(symbols) != len(template.parameters):
+            raise ValueError(
+                f"{name} takes {len(template.parameters)} symbol(s): "
+                f"{', '.join(template.parameters)}"
+            )
+        parameters: dict[str, Any] = {"limit": limit}
+        unresolved = []
+        for parameter, symbol in zip(template.parameters, symbols):
+            qualified_name = self.resolve(symbol)
+            if qualified_name is None:
+                unresolved.append(symbol)
+            parameters[parameter] = qualified_name or symbol
+        results = self.ingestor.fetch_all(template.query, parameters)
+        return TemplateResult(name, template.query, parameters, results, unresolved)
@@ -0,0 +1,386 @@
+"""Environment checks behind `doctor`, each with a fix for what it finds."""
+
+import shutil
+import urllib.request
+from collections.abc import Callable
+from dataclasses import asdict, dataclass
+from pathlib import Path
+from typing import Any
+
+import psutil
+from tree_sitter import Language
+
+from .config import AppConfig, detect_provider_from_model, local_endpoint
+from .language_config import LANGUAGE_CONFIGS
+from .language_plugins import REGISTERED_PLUGINS
+from .services.graph_backends import EmbeddedBackend, Neo4jBackend
+
+OK = "ok"
+WARN = "warn"
+FAIL = "fail"
+
+# Index syntax and SHOW STORAGE INFO used by the ingestor need Memgraph 2.x
+MIN_MEMGRAPH_VERSION = (2, 0)
+# IF NOT EXISTS on named indexes and CALL ... IN TRANSACTIONS need Neo4j 5
+MIN_NEO4J_VERSION = (5, 0)
+MIN_FREE_DISK_BYTES = 2 * 1024**3
+MIN_AVAILABLE_MEMORY_BYTES = 2 * 1024**3
+
+# Lookups every query tool relies on; created by GraphIndexManager on ingest
+REQUIRED_INDEXES = (
+    ("File", "path"),
+    ("Module", "qualified_name"),
+    ("Class", "qualified_name"),
+    ("Function", "qualified_name"),
+    ("Method", "qualified_name"),
+)
+
+# Built-in grammars whose PyPI package is not tree-sitter-<language>
+GRAMMAR_PACKAGES = {"typescript": "tree-sitter-typescript"}
+
+API_KEY_SETTINGS = {
+    "openai": "OPENAI_API_KEY",
+    "anthropic": "ANTHROPIC_API_KEY",
+}
+
+
+@dataclass
+class Check:
+    """The outcome of one check; fix says what to do unless it passed."""
+
+    name: str
+    status: str
+    detail: str
+    fix: str = ""
+
+
+def check_database(ingestor: Any, host: str, port: int) -> list[Check]:
+    """Connectivity and version, then storage usage and the lookup indexes."""
+    if isinstance(ingestor.backend, Neo4jBackend | EmbeddedBackend):
+        return _check_other_backend(ingestor)
+    try:
+        ingestor.__enter__()
+    except Exception as e:
+        return [
+            Check(
+                "Memgraph connection",
+                FAIL,
+                f"cannot connect to {host}:{port}: {e}",
+                "Start Memgraph with `docker compose up -d` or point "
+                "MEMGRAPH_HOST/MEMGRAPH_PORT at a running instance",
+            )
+        ]
+    try:
+        return [
+            _version_check(ingestor, host, port),
+            _storage_check(ingestor),
+            _index_check(ingestor),
+        ]
+    finally:
+        ingestor.__exit__(None, None, None)
+
+
+def _version_check(ingestor: Any, host: str, port: int) -> Check:
+    rows = ingestor.fetch_all("SHOW VERSION")
+    version = str(rows[0].get("version", "")) if rows else ""
+    numbers = tuple(int(p) for p in version.split(".")[:2] if p.isdigit())
+    if numbers and numbers < MIN_MEMGRAPH_VERSION:
+        minimum = ".".join(map(str, MIN_MEMGRAPH_VERSION))
+        return Check(
+            "Memgraph connection",
+            FAIL,
+            f"{host}:{port} runs Memgraph {version}",
+            f"Upgrade to Memgraph {minimum} or newer, e.g. the "
+            "memgraph/memgraph-mage image in docker-compose.yaml",
+        )
+    return Check(
+        "Memgraph connection", OK, f"{host}:{port}, version {version or 'unknown'}"
+    )
+
+
+def _storage_check(ingestor: Any) -> Check:
+    info = {
+        row.get("storage info"): row.get("value")
+        for row in ingestor.fetch_all("SHOW STORAGE INFO")
+    }
+    nodes = int(info.get("vertex_count") or 0)
+    edges = int(info.get("edge_count") or 0)
+    if not nodes:
+        return Check(
+            "Graph contents",
+            WARN,
+            "the database is empty",
+            "Ingest a repository with `start --repo-path PATH --update-graph`",
+        )
+    usage = info.get("memory_res") or info.get("memory_usage")
+    detail = f"{nodes} nodes, {edges} relationships"
+    if usage:
+        detail += f", {usage} in memory"
+    return Check("Graph contents", OK, detail)
+
+
+def _index_check(ingestor: Any) -> Check:
+    existing = {
+        (row.get("label"), row.get("property"))
+        for row in ingestor.fetch_all("SHOW INDEX INFO")
+    }
+    return _missing_indexes_check(existing)
+
+
+def _check_other_backend(ingestor: Any) -> list[Check]:
+    """
+    check_database for Neo4j and the embedded backend, whose statements for
+    versions, storage and indexes differ from Memgraph's.
+    """
+    backend = ingestor.backend
+    neo4j = isinstance(backend, Neo4jBackend)
+    name = "Neo4j connection" if neo4j else "Embedded graph"
+    try:
+        ingestor.__enter__()
+    except Exception as e:
+        fix = (
+            "Start Neo4j or point NEO4J_URI, NEO4J_USERNAME and "
+            "NEO4J_PASSWORD at a running server"
+            if neo4j
+            else "Install it with `pip install 'graph-code[embedded]'` and "
+            "check that EMBEDDED_GRAPH_PATH is writable"
+        )
+        return [Check(name, FAIL, f"cannot open {backend}: {e}", fix)]
+    try:
+        if neo4j:
+            connection = _neo4j_version_check(ingestor)
+            index_rows = ingestor.fetch_all(
+                "SHOW INDEXES YIELD labelsOrTypes, properties "
+                "RETURN labelsOrTypes AS labels, properties"
+            )
+        else:
+            connection = Check(name, OK, str(backend))
+            index_rows = ingestor.fetch_all(
+                "CALL db.indexes() YIELD label, properties "
+                "RETURN [label] AS labels, properties"
+            )
+
+        nodes = ingestor.fetch_all("MATCH (n) RETURN count(n) AS count")[0]["count"]
+        edges = ingestor.fetch_all("MATCH ()-[r]->() RETURN count(r) AS count")
+        if nodes:
+            contents = Check(
+                "Graph contents",
+                OK,
+                f"{nodes} nodes, {edges[0]['count']} relationships",
+            )
+        else:
+            contents = Check(
+                "Graph contents",
+                WARN,
+                "the database is empty",
+                "Ingest a repository with `start --repo-path PATH --update-graph`",
+            )
+
+        existing = {
+            (labels[0], prop)
+            for row in index_rows
+            if (labels := row.get("labels"))
+            for prop in row.get("properties") or []
+        }
+        return [connection, contents, _missing_indexes_check(existing)]
+    finally:
+        ingestor.__exit__(None, None, None)
+
+
+def _neo4j_version_check(ingestor: Any) -> Check:
+    rows = ingestor.fetch_all(
+        "CALL dbms.components() YIELD name, versions RETURN versions[0] AS version"
+    )
+    version = str(rows[0].get("version", "")) if rows else ""
+    numbers = tuple(int(p) for p in version.split(".")[:2] if p.isdigit())
+    if numbers and numbers < MIN_NEO4J_VERSION:
+        minimum = ".".join(map(str, MIN_NEO4J_VERSION))
+        return Check(
+            "Neo4j connection",
+            FAIL,
+            f"{ingestor.backend} runs Neo4j {version}",
+            f"Upgrade to Neo4j {minimum} or newer",
+        )
+    return Check(
+        "Neo4j connection",
+        OK,
+        f"{ingestor.backend}, version {version or 'unknown'}",
+    )
+
+
+def _missing_indexes_check(existing: set[tuple[Any, Any]]) -> Check:
+    missing = [
+        f":{label}({prop})"
+        for label, prop in REQUIRED_INDEXES
+        if (label, prop) not in existing
+    ]
+    if missing:
+        return Check(
+            "Graph indexes",
+            WARN,
+            f"missing {', '.join(missing)}; lookups fall back to label scans",
+            "Re-run `start --update-graph`, which creates the indexes",
+        )
+    return Check("Graph indexes", OK, f"{len(existing)} indexes")
+
+
+def check_grammars(loaders: dict[str, Callable[[], object] | None]) -> list[Check]:
+    """Whether each language's grammar is installed and loads."""
+    missing, broken = [], []
+    for name in LANGUAGE_CONFIGS:
+        plugin = REGISTERED_PLUGINS.get(name)
+        loader = plugin.language if plugin else loaders.get(name)
+        if loader is None:
+            missing.append(name)
+            continue
+        try:
+            Language(loader())
+        except Exception as e:
+            broken.append(f"{name} ({e})")
+
+    loaded = len(LANGUAGE_CONFIGS) - len(missing) - len(broken)
+    checks = []
+    if not loaded:
+        checks.append(
+            Check(
+                "Grammars",
+                FAIL,
+                "no Tree-sitter grammar could be loaded",
+                "Reinstall the project dependencies, e.g. `uv sync`",
+            )
+        )
+    else:
+        checks.append(Check("Grammars", OK, f"{loaded} languages load"))
+    if missing:
+        packages = " ".join(
+            GRAMMAR_PACKAGES.get(name, f"tree-sitter-{name}") for name in missing
+        )
+        checks.append(
+            Check(
+                "Missing grammars",
+                WARN,
+                f"{', '.join(missing)}: files in these languages are skipped",
+                f"pip install {packages}",
+            )
+        )
+    if broken:
+        checks.append(
+            Check(
+                "Broken grammars",
+                FAIL,
+                "; ".join(broken),
+                "Reinstall the grammar package or rebuild the plugin's library "
+                "against the installed tree-sitter version",
+            )
+        )
+    return checks
+
+
+def check_providers(
+    config: AppConfig, probe: Callable[[str], None] | None = None
+) -> list[Check]:
+    """The configured models, their providers' credentials and local endpoints."""
+    probe = probe or _probe_endpoint
+    checks = []
+    for role, name, model in (
+        ("orchestrator", "Orchestrator model", config.active_orchestrator_model),
+        ("cypher", "Cypher model", config.active_cypher_model),
+    ):
+        provider = detect_provider_from_model(model)
+        problem = _credential_problem(config, provider)
+        if problem:
+            detail, fix = problem
+            checks.append(Check(name, FAIL, f"{model} ({provider}): {detail}", fix))
+            continue
+        if provider == "local":
+            endpoint = local_endpoint(config, role).rstrip("/")
+            try:
+                probe(f"{endpoint}/models")
+            except Exception as e:
+                checks.append(
+                    Check(
+                        name,
+                        FAIL,
+                        f"{model}: {endpoint} is unreachable ({e})",
+                        "Start the local model server (e.g. `ollama serve`) or "
+                        f"set LOCAL_{role.upper()}_ENDPOINT or LOCAL_MODEL_ENDPOINT",
+                    )
+                )
+                continue
+            checks.append(Check(name, OK, f"{model} (local at {endpoint})"))
+            continue
+        checks.append(Check(name, OK, f"{model} ({provider})"))
+    return checks
+
+
+def _credential_problem(config: AppConfig, provider: str) -> tuple[str, str] | None:
+    if provider != "local" and getattr(config, "LOCAL_ONLY", False):
+        return (
+            "LOCAL_ONLY refuses cloud models",
+            "Use a local model, e.g. MODEL_PROVIDER=local or an ollama: model",
+        )
+    if provider == "gemini":
+        if config.GEMINI_PROVIDER == "vertex" and not config.GCP_PROJECT_ID:
+            return (
+                "GCP_PROJECT_ID is not set",
+                "Set GCP_PROJECT_ID, or GEMINI_PROVIDER=gla with GEMINI_API_KEY",
+            )
+        if config.GEMINI_PROVIDER == "gla" and not config.GEMINI_API_KEY:
+            return (
+                "GEMINI_API_KEY is not set",
+                "Set GEMINI_API_KEY in .env or pick another model with "
+                "ORCHESTRATOR_MODEL/CYPHER_MODEL",
+            )
+    key = API_KEY_SETTINGS.get(provider)
+    if key and not getattr(config, key):
+        return f"{key} is not set", f"Set {key} in .env or the config file"
+    return None
+
+
+def _probe_endpoint(url: str) -> None:
+    with urllib.request.urlopen(url, timeout=3):
+        pass
+
+
+def check_resources(path: Path) -> list[Check]:
+    """Free disk space where the graph and caches live, and available memory."""
+    checks = []
+    free = shutil.disk_usage(path).free
+    if free < MIN_FREE_DISK_BYTES:
+        checks.append(
+            Check(
+                "Disk space",
+                WARN,
+                f"{_gib(free)} free on {path}",
+                f"Free up space; ingesting large repositories needs at least "
+                f"{_gib(MIN_FREE_DISK_BYTES)}",
+            )
+        )
+    else:
+        checks.append(Check("Disk space", OK, f"{_gib(free)} free"))
+
+    available = psutil.virtual_memory().available
+    if available < MIN_AVAILABLE_MEMORY_BYTES:
+        checks.append(
+            Check(
+                "Memory",
+                WARN,
+                f"{_gib(available)} available",
+                "Close other programs or ingest with --parallel --workers 2 "
+                "and --folder-filter to bound memory use",
+            )
+        )
+    else:
+        checks.append(Check("Memory", OK, f"{_gib(available)} available"))
+    return checks
+
+
+def _gib(size: int) -> str:
+    return f"{size / 1024**3:.1f} GiB"
+
+
+def checks_to_dict(checks: list[Check]) -> dict[str, Any]:
+    return {
+        "healthy": all(c.status != FAIL for c in checks),
+        "checks": [asdict(c) for c in checks],
+    }
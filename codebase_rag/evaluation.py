@@ -0,0 +1,222 @@
+"""Retrieval evaluation against a golden set of questions.
+
+A golden set is a YAML file of questions, each with the graph nodes a good
+answer draws on and, optionally, phrases the answer must contain:
+
+    questions:
+      - id: retries
+        question: Where do we retry failed calls?
+        expected_nodes: [billing.jobs.wait_and_repeat]
+        expected_answer: [exponential, wait_and_repeat]
+
+Each question is run through a retrieval pipeline, the chat agent with its
+read-only tools or semantic search alone, and the nodes it retrieved, in the
+order they first came back, are scored against the expected ones: precision
+of the first k, recall and reciprocal rank, averaged over the set into mean
+precision, recall and MRR. An answer is correct when it contains every
+expected phrase, ignoring case. Comparing reports before and after changing
+chunking or context expansion settings shows what the change did.
+"""
+
+import re
+from collections.abc import Awaitable, Callable
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+import yaml
+
+# Chunks of a function count as the function; searches report them that way
+CHUNK_SUFFIX = re.compile(r":chunk\d+$")
+
+
+@dataclass
+class GoldenQuestion:
+    id: str
+    question: str
+    expected_nodes: list[str]
+    expected_answer: list[str] = field(default_factory=list)
+
+
+@dataclass
+class Retrieval:
+    """What a pipeline retrieved for a question, and its answer if any."""
+
+    nodes: list[str]
+    answer: str | None = None
+
+
+@dataclass
+class QuestionResult:
+    id: str
+    question: str
+    retrieved: list[str]
+    # None for questions expecting an answer but no particular nodes
+    precision: float | None
+    recall: float | None
+    reciprocal_rank: float | None
+    answer: str | None = None
+    answer_correct: bool | None = None  # None without expected phrases or answer
+    missing_phrases: list[str] = field(default_factory=list)
+    error: str | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class EvaluationReport:
+    k: int
+    results: list[QuestionResult]
+
+    def summary(self) -> dict[str, Any]:
+        """Mean precision@k, recall and MRR, and the share of correct answers."""
+        judged = [
+            float(r.answer_correct)
+            for r in self.results
+            if r.answer_correct is not None
+        ]
+        return {
+            "questions": len(self.results),
+            "k": self.k,
+            f"precision@{self.k}": _mean([r.precision for r in self.results]),
+            "recall": _mean([r.recall for r in self.results]),
+            "mrr": _mean([r.reciprocal_rank for r in self.results]),
+            "answer_accuracy": _mean(judged),
+            "errors": sum(1 for r in self.results if r.error),
+        }
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "summary": self.summary(),
+            "results": [r.to_dict() for r in self.results],
+        }
+
+
+def _mean(values: list[float | None]) -> float | None:
+    scored = [value for value in values if value is not None]
+    return round(sum(scored) / len(scored), 4) if scored else None
+
+
+def load_golden_set(path: Path) -> list[GoldenQuestion]:
+    """The questions of a golden set file; ValueError if it is malformed."""
+    try:
+        document = yaml.safe_load(path.read_text(encoding="utf-8")) or {}
+    except (OSError, yaml.YAMLError) as e:
+        raise ValueError(f"Cannot read golden set {path}: {e}") from e
+    entries = document.get("questions") if isinstance(document, dict) else None
+    if not isinstance(entries, list) or not entries:
+        raise ValueError(f"Golden set {path} has no list of questions")
+
+    questions = []
+    for number, entry in enumerate(entries, 1):
+        if not isinstance(entry, dict) or not entry.get("question"):
+            raise ValueError(f"Question {number} of {path} has no question text")
+        nodes = entry.get("expected_nodes") or []
+        answer = entry.get("expected_answer") or []
+        if isinstance(answer, str):
+            answer = [answer]
+        if not isinstance(nodes, list) or not isinstance(answer, list):
+            raise ValueError(
+                f"Question {number} of {path}: expected_nodes and expected_answer "
+                "must be lists"
+            )
+        if not nodes and not answer:
+            raise ValueError(
+                f"Question {number} of {path} expects neither nodes nor an answer"
+            )
+        questions.append(
+            GoldenQuestion(
+                str(entry.get("id", number)),
+                str(entry["question"]),
+                [str(node) for node in nodes],
+                [str(phrase) for phrase in answer],
+            )
+        )
+    return questions
+
+
+def score_retrieval(
+    expected: list[str], retrieved: list[str], k: int
+) -> tuple[float, float, float]:
+    """Precision of the first k retrieved nodes, recall, and reciprocal rank."""
+    wanted = set(expected)
+    top = retrieved[:k]
+    hits = [node for node in top if node in wanted]
+    precision = len(hits) / len(top) if top else 0.0
+    recall = len(wanted & set(retrieved)) / len(wanted)
+    rank = next((i for i, node in enumerate(retrieved, 1) if node in wanted), None)
+    return round(precision, 4), round(recall, 4), round(1 / rank, 4) if rank else 0.0
+
+
+def score_answer(answer: str, phrases: list[str]) -> tuple[bool, list[str]]:
+    """Whether an answer contains every expected phrase, and those it lacks."""
+    text = answer.lower()
+    missing = [phrase for phrase in phrases if phrase.lower() not in text]
+    return not missing, missing
+
+
+def qualified_names(value: Any) -> list[str]:
+    """Qualified names in a tool result, in order, at any depth."""
+    names: list[str] = []
+    if hasattr(value, "model_dump"):
+        value = value.model_dump()
+    if isinstance(value, dict):
+        for key, item in value.items():
+            if key.endswith("qualified_name") and isinstance(item, str):
+                names.append(item)
+            else:
+                names += qualified_names(item)
+    elif isinstance(value, list | tuple):
+        for item in value:
+            names += qualified_names(item)
+    return names
+
+
+def retrieved_nodes(messages: list[Any]) -> list[str]:
+    """The nodes the agent's tools returned, in the order they first came back."""
+    nodes: list[str] = []
+    for message in messages:
+        for part in getattr(message, "parts", []):
+            if getattr(part, "part_kind", None) == "tool-return":
+                nodes += qualified_names(part.content)
+    return _unique(nodes)
+
+
+def _unique(nodes: list[str]) -> list[str]:
+    return list(dict.fromkeys(CHUNK_SUFFIX.sub("", node) for node in nodes))
+
+
+async def evaluate(
+    questions: list[GoldenQuestion],
+    retrieve: Callable[[str], Awaitable[Retrieval]],
+    k: int = 10,
+) -> EvaluationReport:
+    """Run each question through a pipeline and score what it retrieved."""
+    results = []
+    for golden in questions:
+        try:
+            retrieval = await retrieve(golden.question)
+        except Exception as e:
+            # A failed question retrieved nothing it was expected to
+            score = 0.0 if golden.expected_nodes else None
+            results.append(
+                QuestionResult(
+                    golden.id, golden.question, [], score, score, score, error=str(e)
+                )
+            )
+            continue
+        retrieved = _unique(retrieval.nodes)
+        result = QuestionResult(
+            golden.id, golden.question, retrieved, None, None, None, retrieval.answer
+        )
+        if golden.expected_nodes:
+            result.precision, result.recall, result.reciprocal_rank = (
+                score_retrieval(golden.expected_nodes, retrieved, k)
+            )
+        if golden.expected_answer and retrieval.answer is not None:
+            result.answer_correct, result.missing_phrases = score_answer(
+                retrieval.answer, golden.expected_answer
+            )
+        results.append(result)
+    return EvaluationReport(k, results)
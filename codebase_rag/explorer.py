@@ -0,0 +1,315 @@
+"""A full-screen terminal explorer of the graph around a symbol.
+
+`explore`, or /explore at the chat prompt, finds a function, method or
+class by name and lists what is one edge away from it: its callers and
+callees, the types implementing or methods overriding it, and its tests.
+The arrow keys move through that list while the source of the selected
+symbol is previewed beside it; Enter moves to the selected symbol and Left
+returns to the previous one, so a call chain can be walked without asking
+about each step. From the chat prompt, c ends the explorer with the symbol
+added to the context of the next question.
+"""
+
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+from prompt_toolkit import Application
+from prompt_toolkit.filters import Condition
+from prompt_toolkit.formatted_text import StyleAndTextTuples
+from prompt_toolkit.key_binding import KeyBindings
+from prompt_toolkit.layout import HSplit, Layout, VSplit, Window
+from prompt_toolkit.layout.controls import FormattedTextControl
+from prompt_toolkit.widgets import Frame, TextArea
+
+from .context_expansion import _LOCATION, callees_query, callers_query, tests_query
+from .symbol_search import SymbolIndex
+
+# Related symbols listed per relation, and search matches listed
+MAX_RELATED = 20
+MAX_MATCHES = 20
+MAX_PREVIEW_LINES = 80
+
+NODE_QUERY = f"""
+MATCH (x {{qualified_name: $qualified_name}})
+WITH x, 0 AS hops
+{_LOCATION}"""
+
+IMPLEMENTATIONS_QUERY = f"""
+MATCH (x)-[:IMPLEMENTS|INHERITS_FROM|OVERRIDES]->(n {{qualified_name: $qualified_name}})
+WITH x, 1 AS hops
+{_LOCATION}"""
+
+RELATION_QUERIES = {
+    "Callers": callers_query(1),
+    "Callees": callees_query(1),
+    "Implementations": IMPLEMENTATIONS_QUERY,
+    "Tests": tests_query(1),
+}
+# The relation of the symbols a search found
+MATCHES = "Matches"
+
+
+@dataclass
+class ExplorerNode:
+    qualified_name: str
+    label: str
+    path: str | None = None
+    start_line: int | None = None
+    end_line: int | None = None
+
+    @property
+    def location(self) -> str:
+        if not self.path:
+            return ""
+        if not self.start_line:
+            return self.path
+        return f"{self.path}:{self.start_line}-{self.end_line or self.start_line}"
+
+
+@dataclass
+class Entry:
+    relation: str  # A key of RELATION_QUERIES, or MATCHES
+    node: ExplorerNode
+
+
+class GraphExplorer:
+    """The graph queries behind the explorer, and the source it previews."""
+
+    def __init__(self, ingestor: Any, repo_path: Path):
+        self.ingestor = ingestor
+        self.repo_path = repo_path
+        self._index: SymbolIndex | None = None
+        self._nodes: dict[str, ExplorerNode | None] = {}
+
+    def search(self, text: str) -> list[ExplorerNode]:
+        """Symbols named like the text, best first, as `search` finds them."""
+        if self._index is None:
+            self._index = SymbolIndex.from_graph(self.ingestor)
+        return [
+            ExplorerNode(match.qualified_name, match.label)
+            for match in self._index.search(text, MAX_MATCHES)
+        ]
+
+    def node(self, qualified_name: str) -> ExplorerNode | None:
+        """A symbol with the file and lines of its source, None if unknown."""
+        if qualified_name not in self._nodes:
+            rows = self.ingestor.fetch_all(
+                NODE_QUERY, {"qualified_name": qualified_name, "limit": 1}
+            )
+            self._nodes[qualified_name] = _node(rows[0]) if rows else None
+        return self._nodes[qualified_name]
+
+    def related(self, qualified_name: str) -> list[Entry]:
+        """The symbols one edge away, grouped by relation in a fixed order."""
+        entries = []
+        for relation, query in RELATION_QUERIES.items():
+            try:
+                rows = self.ingestor.fetch_all(
+                    query, {"qualified_name": qualified_name, "limit": MAX_RELATED}
+                )
+            except Exception as e:
+                logger.warning(
+                    f"Could not find {relation.lower()} of {qualified_name}: {e}"
+                )
+                continue
+            for row in rows:
+                node = _node(row)
+                self._nodes.setdefault(node.qualified_name, node)
+                entries.append(Entry(relation, node))
+        return entries
+
+    def preview(self, node: ExplorerNode) -> str:
+        """The numbered source lines of a symbol."""
+        if node.path is None:
+            node = self.node(node.qualified_name) or node
+        if not node.path or not node.start_line:
+            return f"No source of {node.qualified_name} in the graph."
+        try:
+            lines = (
+                (self.repo_path / node.path)
+                .read_text(encoding="utf-8", errors="replace")
+                .splitlines()
+            )
+        except OSError as e:
+            return f"Cannot read {node.path}: {e}"
+        start = node.start_line - 1
+        end = min(node.end_line or node.start_line, start + MAX_PREVIEW_LINES)
+        width = len(str(end))
+        return "\n".join(
+            f"{number:>{width}}  {line}"
+            for number, line in enumerate(lines[start:end], start=start + 1)
+        )
+
+
+def context_note(node: ExplorerNode) -> str:
+    """How a symbol picked in the explorer is put before the next question."""
+    where = f" at {node.location}" if node.location else ""
+    return f"(Context: {node.label} {node.qualified_name}{where}.)"
+
+
+def _node(row: dict[str, Any]) -> ExplorerNode:
+    return ExplorerNode(
+        row["qualified_name"],
+        row["label"],
+        row.get("path"),
+        row.get("start_line"),
+        row.get("end_line"),
+    )
+
+
+class ExplorerState:
+    """Where the explorer is: its symbol, the listed entries and the selection."""
+
+    def __init__(self, explorer: GraphExplorer):
+        self.explorer = explorer
+        self.current: ExplorerNode | None = None
+        self.entries: list[Entry] = []
+        self.selected = 0
+        self.message = ""
+        self._back: list[str] = []
+
+    @property
+    def selected_node(self) -> ExplorerNode | None:
+        if self.entries:
+            return self.entries[self.selected].node
+        return self.current
+
+    def search(self, text: str) -> None:
+        matches = self.explorer.search(text)
+        if len(matches) == 1:
+            self.open(matches[0].qualified_name)
+            return
+        self.entries = [Entry(MATCHES, node) for node in matches]
+        self.selected = 0
+        self.message = "" if matches else f"No symbols match '{text}'."
+
+    def open(self, qualified_name: str, remember: bool = True) -> None:
+        node = self.explorer.node(qualified_name)
+        if node is None:
+            self.message = f"{qualified_name} is not in the graph."
+            return
+        if remember and self.current is not None:
+            self._back.append(self.current.qualified_name)
+        self.current = node
+        self.entries = self.explorer.related(qualified_name)
+        self.selected = 0
+        self.message = "" if self.entries else "Nothing is related to it."
+
+    def move(self, delta: int) -> None:
+        if self.entries:
+            self.selected = max(0, min(len(self.entries) - 1, self.selected + delta))
+
+    def enter(self) -> None:
+        if self.entries:
+            self.open(self.entries[self.selected].node.qualified_name)
+
+    def back(self) -> None:
+        if self._back:
+            self.open(self._back.pop(), remember=False)
+
+    def lines(self) -> StyleAndTextTuples:
+        """The list pane: the symbol, then its related symbols by relation."""
+        fragments: StyleAndTextTuples = []
+        if self.current is not None:
+            fragments.append(
+                ("bold", f"{self.current.label} {self.current.qualified_name}\n")
+            )
+        relation = None
+        for index, entry in enumerate(self.entries):
+            if entry.relation != relation:
+                relation = entry.relation
+                fragments.append(("bold ansicyan", f"\n{relation}\n"))
+            style = "reverse" if index == self.selected else ""
+            fragments.append((style, f"  {entry.node.qualified_name}"))
+            fragments.append(("ansibrightblack", f"  {entry.node.label}\n"))
+        if self.message:
+            fragments.append(("ansiyellow", f"\n{self.message}\n"))
+        return fragments
+
+
+def run_explorer(
+    explorer: GraphExplorer, start: str | None = None, pick: bool = False
+) -> ExplorerNode | None:
+    """
+    Explore from a symbol, or a search for one; with pick, c returns the
+    selected symbol. Returns None when the explorer is quit.
+    """
+    state = ExplorerState(explorer)
+    if start:
+        state.search(start)
+
+    search = TextArea(height=1, prompt="Search: ", multiline=False)
+    listing = Window(FormattedTextControl(state.lines, focusable=True))
+    def source() -> str:
+        node = state.selected_node
+        return explorer.preview(node) if node is not None else ""
+
+    preview = Window(FormattedTextControl(source), wrap_lines=False)
+    keys = "↑↓ move  Enter open  ← back  / search  q quit"
+    status = Window(
+        FormattedTextControl(keys + ("  c add to chat" if pick else "")), height=1
+    )
+
+    def accept(buffer: Any) -> bool:
+        state.search(buffer.text)
+        application.layout.focus(listing)
+        return False
+
+    search.accept_handler = accept
+    bindings = KeyBindings()
+    in_list = Condition(lambda: application.layout.has_focus(listing))
+
+    @bindings.add("up", filter=in_list)
+    def _up(event: Any) -> None:
+        state.move(-1)
+
+    @bindings.add("down", filter=in_list)
+    def _down(event: Any) -> None:
+        state.move(1)
+
+    @bindings.add("enter", filter=in_list)
+    @bindings.add("right", filter=in_list)
+    def _open(event: Any) -> None:
+        state.enter()
+
+    @bindings.add("left", filter=in_list)
+    @bindings.add("backspace", filter=in_list)
+    def _back(event: Any) -> None:
+        state.back()
+
+    @bindings.add("/", filter=in_list)
+    @bindings.add("tab")
+    def _search(event: Any) -> None:
+        layout = event.app.layout
+        layout.focus(listing if layout.has_focus(search) else search)
+
+    @bindings.add("c", filter=in_list)
+    def _pick(event: Any) -> None:
+        if pick and state.selected_node is not None:
+            event.app.exit(result=explorer.node(state.selected_node.qualified_name))
+
+    @bindings.add("q", filter=in_list)
+    @bindings.add("escape")
+    @bindings.add("c-c")
+    def _quit(event: Any) -> None:
+        event.app.exit(result=None)
+
+    application: Application[ExplorerNode | None] = Application(
+        layout=Layout(
+            HSplit(
+                [
+                    search,
+                    VSplit(
+                        [Frame(listing, title="Graph"), Frame(preview, title="Source")]
+                    ),
+                    status,
+                ]
+            ),
+            focused_element=listing if start else search,
+        ),
+        key_bindings=bindings,
+        full_screen=True,
+    )
+    return application.run()
@@ -0,0 +1,152 @@
+"""Graph consistency checks behind `fsck`, and the repairs that are safe to make.
+
+Incremental updates delete File nodes for removed files but leave what was
+parsed from them, so the usual damage is a stale module: a Module whose file
+is gone, still defining functions and classes that live code points at.
+Those are removed by --repair. Duplicated keys and definitions nothing
+defines are only reported, since deciding which copy is right needs a fresh
+ingestion. The graph stores no embeddings, so there are none to check.
+"""
+
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+# Nodes MERGEd on these keys; more than one node per key means a bad write
+UNIQUE_KEYS = {
+    "Project": "name",
+    "Package": "qualified_name",
+    "Folder": "path",
+    "File": "path",
+    "Module": "qualified_name",
+    "Class": "qualified_name",
+    "Function": "qualified_name",
+    "Method": "qualified_name",
+}
+
+STALE_MODULES_QUERY = """
+MATCH (m:Module) WHERE m.path IS NOT NULL
+OPTIONAL MATCH (f:File {path: m.path})
+WITH m, f WHERE f IS NULL
+RETURN m.qualified_name AS name, m.path AS path
+ORDER BY name
+"""
+
+DANGLING_EDGES_QUERY = """
+MATCH (m:Module) WHERE m.path IS NOT NULL
+OPTIONAL MATCH (f:File {path: m.path})
+WITH m, f WHERE f IS NULL
+MATCH (m)-[:DEFINES|DEFINES_METHOD*1..2]->(d)<-[r]-(source)
+WHERE NOT type(r) IN ['DEFINES', 'DEFINES_METHOD']
+RETURN DISTINCT source.qualified_name AS source, type(r) AS type,
+       d.qualified_name AS target
+ORDER BY target, source
+"""
+
+MISSING_PROVENANCE_QUERY = """
+MATCH (n)
+WHERE (n:Module AND n.path IS NULL)
+   OR ((n:Function OR n:Class) AND NOT ()-[:DEFINES]->(n))
+   OR (n:Method AND NOT ()-[:DEFINES_METHOD]->(n))
+RETURN labels(n)[0] AS label, n.qualified_name AS name
+ORDER BY label, name
+"""
+
+DELETE_DEFINITIONS_QUERY = """
+MATCH (:Module {qualified_name: $name})-[:DEFINES|DEFINES_METHOD*1..2]->(d)
+WITH collect(DISTINCT d) AS defined
+UNWIND defined AS d
+DETACH DELETE d
+"""
+
+DELETE_MODULE_QUERY = "MATCH (m:Module {qualified_name: $name}) DETACH DELETE m"
+
+
+@dataclass
+class Finding:
+    """One kind of inconsistency, how often it occurs and a few examples."""
+
+    name: str
+    count: int
+    samples: list[str] = field(default_factory=list)
+    fix: str = ""
+    repairable: bool = False
+
+
+def check_graph(ingestor: Any, sample_size: int = 10) -> list[Finding]:
+    """Every check, including those that found nothing (count 0)."""
+    return [
+        _duplicate_keys(ingestor, sample_size),
+        _stale_modules(ingestor, sample_size),
+        _dangling_edges(ingestor, sample_size),
+        _missing_provenance(ingestor, sample_size),
+    ]
+
+
+def _duplicate_keys(ingestor: Any, sample_size: int) -> Finding:
+    samples = []
+    for label, key in UNIQUE_KEYS.items():
+        rows = ingestor.fetch_all(
+            f"MATCH (n:{label}) WHERE n.{key} IS NOT NULL "
+            f"WITH n.{key} AS key, count(n) AS copies WHERE copies > 1 "
+            "RETURN key, copies ORDER BY copies DESC"
+        )
+        samples += [f"{label} {row['key']} ({row['copies']} nodes)" for row in rows]
+    return Finding(
+        "Duplicate keys",
+        len(samples),
+        samples[:sample_size],
+        "Re-ingest with `start --update-graph --clean`",
+    )
+
+
+def _stale_modules(ingestor: Any, sample_size: int) -> Finding:
+    rows = ingestor.fetch_all(STALE_MODULES_QUERY)
+    return Finding(
+        "Modules without a file",
+        len(rows),
+        [f"{row['name']} ({row['path']})" for row in rows[:sample_size]],
+        "`fsck --repair` removes them with what they define",
+        repairable=True,
+    )
+
+
+def _dangling_edges(ingestor: Any, sample_size: int) -> Finding:
+    rows = ingestor.fetch_all(DANGLING_EDGES_QUERY)
+    return Finding(
+        "Edges into deleted files",
+        len(rows),
+        [
+            f"{row['source']} -[{row['type']}]-> {row['target']}"
+            for row in rows[:sample_size]
+        ],
+        "Removed along with the modules without a file by `fsck --repair`",
+        repairable=True,
+    )
+
+
+def _missing_provenance(ingestor: Any, sample_size: int) -> Finding:
+    rows = ingestor.fetch_all(MISSING_PROVENANCE_QUERY)
+    return Finding(
+        "Definitions without a source",
+        len(rows),
+        [f"{row['label']} {row['name']}" for row in rows[:sample_size]],
+        "Re-ingest the repository these came from",
+    )
+
+
+def repair(ingestor: Any) -> int:
+    """Delete modules whose file is gone and their definitions; returns how many."""
+    stale = ingestor.fetch_all(STALE_MODULES_QUERY)
+    for row in stale:
+        ingestor.execute_write(DELETE_DEFINITIONS_QUERY, {"name": row["name"]})
+        ingestor.execute_write(DELETE_MODULE_QUERY, {"name": row["name"]})
+    if stale:
+        ingestor.mark_graph_changed()
+    return len(stale)
+
+
+def findings_to_dict(findings: list[Finding]) -> dict[str, Any]:
+    return {
+        "consistent": not any(f.count for f in findings),
+        "findings": [asdict(f) for f in findings],
+    }
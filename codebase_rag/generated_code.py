@@ -0,0 +1,67 @@
+"""Generated code: files that say so in their header, or where generators write.
+
+Protobuf and gRPC stubs, mocks, deep-copy functions and stringers are
+committed next to the code they serve and can outnumber it, so questions
+about the repository get answered from boilerplate. A file is generated when
+a comment before its code reads as Go's `// Code generated ... DO NOT EDIT.`
+convention or carries an `@generated` marker, or when its path matches
+GENERATED_PATHS besides the usual names of generated files below.
+
+GENERATED_CODE_MODE decides what becomes of them: "full" ingests them like
+the rest, "declarations" parses them for their functions, classes and types
+only, as large files are, and "skip" leaves them out. Their modules and
+definitions get `generated: true`, which semantic search ranks below
+handwritten code.
+"""
+
+import re
+from pathlib import Path
+
+from .large_files import HEADER_BYTES
+from .workspace import matches_glob
+
+GENERATED_CODE_MODES = ("full", "declarations", "skip")
+
+# Files named as generators name them, as INCLUDE_PATHS globs
+DEFAULT_GENERATED_PATHS = (
+    "*.pb.go",
+    "*.pb.gw.go",
+    "*_pb2.py",
+    "*_pb2_grpc.py",
+    "*_pb.js",
+    "*_pb.d.ts",
+    "*_grpc_pb.js",
+    "zz_generated*.go",
+    "mock_*.go",
+    "*_mock.go",
+    "**/mocks/",
+)
+
+# A comment line before the code: the Go convention (golang.org/s/generatedcode)
+# also followed by other generators, or Meta's @generated
+GENERATED_HEADER = re.compile(
+    rb"^\s*(?://|#|/?\*+|--)\s*(?:Code generated .* DO NOT EDIT\.|.*@generated\b)",
+    re.MULTILINE,
+)
+COMMENT = re.compile(rb"^\s*(?://|#|/?\*|--|$)")
+
+
+def has_generated_header(header: bytes) -> bool:
+    """Whether the comments at the top of a file say it is generated."""
+    for line in header.splitlines():
+        if not COMMENT.match(line):
+            return False  # Past the leading comments
+        if GENERATED_HEADER.match(line):
+            return True
+    return False
+
+
+def is_generated(path: Path, relative_path: str, patterns: list[str]) -> bool:
+    """Whether a file is generated, by its path or the header of its contents."""
+    if any(matches_glob(relative_path, pattern) for pattern in patterns):
+        return True
+    try:
+        with path.open("rb") as f:
+            return has_generated_header(f.read(HEADER_BYTES))
+    except OSError:
+        return False  # Reported when the file is parsed
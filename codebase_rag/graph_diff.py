@@ -0,0 +1,363 @@
+"""Differences between the graphs of two revisions or snapshots.
+
+Each side of a diff is a Git revision, ingested from `git archive` without
+touching the database, or an archive written by `snapshot`. Only what
+describes the architecture is compared: functions, methods, classes and
+interfaces by qualified name, the external packages and Go modules the
+code depends on, and the CALLS and IMPORTS edges between them. A function
+has changed when its parameters, size, complexity, decorators or the
+fingerprint of its body did; moving it within its file does not count.
+"""
+
+import io
+import subprocess
+import tarfile
+import tempfile
+from collections.abc import Iterator
+from contextlib import contextmanager
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any
+
+from .graph_updater import GraphUpdater
+from .services.dry_run import DryRunIngestor, _hashable
+from .snapshots import NODES_FILE, RELATIONSHIPS_FILE, snapshot_rows
+
+# The labels recorded, with the property their nodes are known by; ApiSymbol
+# nodes are compared by `api-diff`, which reads its snapshots from records
+IDENTITIES = {
+    "Module": "qualified_name",
+    "Class": "qualified_name",
+    "Interface": "qualified_name",
+    "Function": "qualified_name",
+    "Method": "qualified_name",
+    "ExternalPackage": "name",
+    "GoModule": "path",
+    "ModuleVersion": "qualified_name",
+    "ApiSymbol": "qualified_name",
+}
+SYMBOL_LABELS = ("Class", "Interface", "Function", "Method")
+EDGE_TYPES = ("CALLS", "IMPORTS")
+# DEPENDS_ON from a GoModule to the ModuleVersion its go.mod requires, and
+# from config files to ExternalPackages, as DEPENDS_ON_EXTERNAL from the Project
+DEPENDENCY_TYPES = ("DEPENDS_ON", "DEPENDS_ON_EXTERNAL")
+CHANGE_PROPERTIES = (
+    "parameter_count",
+    "lines_of_code",
+    "cyclomatic_complexity",
+    "decorators",
+    "base_classes",
+    "method_count",
+    "body_hash",
+)
+
+# A node of a recorded graph: its label and the value identifying it
+NodeKey = tuple[str, Any]
+
+
+@dataclass
+class GraphRecord:
+    """The nodes and relationships of a graph that a diff compares."""
+
+    nodes: dict[NodeKey, dict[str, Any]] = field(default_factory=dict)
+    relationships: set[tuple[NodeKey, str, NodeKey]] = field(default_factory=set)
+
+    def add_node(self, label: str, properties: dict[str, Any]) -> None:
+        key = IDENTITIES.get(label)
+        if key and properties.get(key) is not None:
+            self.nodes[(label, _hashable(properties[key]))] = properties
+
+    def add_relationship(
+        self, source: NodeKey, rel_type: str, target: NodeKey
+    ) -> None:
+        if rel_type in EDGE_TYPES + DEPENDENCY_TYPES:
+            self.relationships.add((source, rel_type, target))
+
+    def labelled(self, label: str) -> list[dict[str, Any]]:
+        """The properties of the recorded nodes with a label."""
+        return [properties for key, properties in self.nodes.items() if key[0] == label]
+
+    def edges(self, rel_type: str) -> set[tuple[NodeKey, NodeKey]]:
+        """Edges of a type whose ends were both ingested, as MATCH would find."""
+        return {
+            (source, target)
+            for source, kind, target in self.relationships
+            if kind == rel_type and source in self.nodes and target in self.nodes
+        }
+
+    def dependencies(self) -> dict[str, str]:
+        """The version of each external package and required Go module."""
+        versions: dict[str, set[str]] = {}
+        # Packages only imported, with no manifest declaring them, are left
+        # out; the Project and ConfigFile nodes declaring packages are not
+        # compared, so their edges are read whichever nodes were ingested
+        declared = {
+            target
+            for _, rel_type, target in self.relationships
+            if rel_type in DEPENDENCY_TYPES
+        }
+        undeclared = {target for _, target in self.edges("IMPORTS")} - declared
+        for key, properties in self.nodes.items():
+            if key[0] == "ExternalPackage" and key not in undeclared:
+                versions.setdefault(properties["name"], set()).add(
+                    properties.get("version_spec") or ""
+                )
+        for _, target in self.edges("DEPENDS_ON"):
+            if target[0] == "ModuleVersion":
+                properties = self.nodes[target]
+                versions.setdefault(properties.get("path", target[1]), set()).add(
+                    properties.get("version") or ""
+                )
+        return {
+            name: ", ".join(sorted(v for v in found if v))
+            for name, found in versions.items()
+        }
+
+
+class RecordingIngestor(DryRunIngestor):
+    """A dry-run ingestor keeping the nodes and relationships a diff compares."""
+
+    def __init__(self, batch_size: int | None = None):
+        super().__init__(batch_size=batch_size)
+        self.record = GraphRecord()
+
+    def flush_nodes(self) -> None:
+        for label, properties in self.node_buffer:
+            self.record.add_node(label, properties)
+        super().flush_nodes()
+
+    def flush_relationships(self) -> None:
+        for from_node, rel_type, to_node, _ in self.relationship_buffer:
+            self.record.add_relationship(
+                (from_node[0], _hashable(from_node[2])),
+                rel_type,
+                (to_node[0], _hashable(to_node[2])),
+            )
+        super().flush_relationships()
+
+
+@contextmanager
+def revision_tree(repo_path: Path, revision: str) -> Iterator[Path]:
+    """
+    The tree at a Git revision, without a checkout, in a temporary directory
+    named like the repository so that qualified names match its own.
+    """
+    archive = subprocess.run(
+        ["git", "-C", str(repo_path), "archive", "--format=tar", revision],
+        capture_output=True,
+        check=True,
+    )
+    with tempfile.TemporaryDirectory() as temp_dir:
+        tree = Path(temp_dir) / repo_path.name
+        tree.mkdir()
+        with tarfile.open(fileobj=io.BytesIO(archive.stdout)) as tar:
+            tar.extractall(tree, filter="data")
+        yield tree
+
+
+def graph_at_revision(
+    repo_path: Path, revision: str, parsers: dict[str, Any], queries: dict[str, Any]
+) -> GraphRecord:
+    """Ingest the tree at a Git revision into a record, without a database."""
+    with revision_tree(repo_path, revision) as tree, RecordingIngestor() as ingestor:
+        GraphUpdater(ingestor, tree, parsers, queries).run()
+        ingestor.flush_all()
+        return ingestor.record
+
+
+def graph_from_snapshot(path: Path) -> GraphRecord:
+    """Read the compared part of a graph from a snapshot archive."""
+    record = GraphRecord()
+    keys: dict[int, NodeKey] = {}
+    for row in snapshot_rows(path, NODES_FILE):
+        label = next((lb for lb in row["labels"] if lb in IDENTITIES), None)
+        if label is None:
+            continue
+        record.add_node(label, row["properties"])
+        value = row["properties"].get(IDENTITIES[label])
+        if value is not None:
+            keys[row["id"]] = (label, _hashable(value))
+    for row in snapshot_rows(path, RELATIONSHIPS_FILE):
+        if row["from_id"] in keys and row["to_id"] in keys:
+            record.add_relationship(
+                keys[row["from_id"]], row["type"], keys[row["to_id"]]
+            )
+    return record
+
+
+@dataclass
+class SymbolChange:
+    qualified_name: str
+    label: str
+    change: str  # added, removed or changed
+    details: list[str] = field(default_factory=list)
+
+
+@dataclass
+class DependencyChange:
+    name: str
+    change: str
+    old_version: str = ""
+    new_version: str = ""
+
+
+@dataclass
+class EdgeChange:
+    type: str  # CALLS or IMPORTS
+    source: str
+    target: str
+    change: str  # added or removed
+
+
+@dataclass
+class GraphDiff:
+    """What changed in the architecture between two graphs."""
+
+    base: str
+    head: str
+    symbols: list[SymbolChange] = field(default_factory=list)
+    dependencies: list[DependencyChange] = field(default_factory=list)
+    edges: list[EdgeChange] = field(default_factory=list)
+
+    @property
+    def is_empty(self) -> bool:
+        return not (self.symbols or self.dependencies or self.edges)
+
+    def counts(self) -> dict[str, int]:
+        """Changes per kind and change, e.g. {"Function added": 3}."""
+        counts: dict[str, int] = {}
+        for name in [
+            *(f"{s.label} {s.change}" for s in self.symbols),
+            *(f"dependency {d.change}" for d in self.dependencies),
+            *(f"{e.type} edge {e.change}" for e in self.edges),
+        ]:
+            counts[name] = counts.get(name, 0) + 1
+        return counts
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "base": self.base,
+            "head": self.head,
+            "summary": self.counts(),
+            "symbols": [asdict(s) for s in self.symbols],
+            "dependencies": [asdict(d) for d in self.dependencies],
+            "edges": [asdict(e) for e in self.edges],
+        }
+
+    def to_markdown(self) -> str:
+        lines = [f"## Architecture changes {self.base}..{self.head}"]
+        for change, title in (
+            ("added", "Added"),
+            ("removed", "Removed"),
+            ("changed", "Changed"),
+        ):
+            symbols = [s for s in self.symbols if s.change == change]
+            if symbols:
+                lines += ["", f"### {title}", ""]
+                for s in symbols:
+                    details = f": {'; '.join(s.details)}" if s.details else ""
+                    lines.append(f"- `{s.qualified_name}` ({s.label}){details}")
+        if self.dependencies:
+            lines += ["", "### Dependencies", ""]
+            lines += [f"- {_dependency_line(d)}" for d in self.dependencies]
+        for rel_type, title in (("IMPORTS", "Imports"), ("CALLS", "Calls")):
+            edges = [e for e in self.edges if e.type == rel_type]
+            if edges:
+                lines += ["", f"### {title}", ""]
+                lines += [
+                    f"- {'+' if e.change == 'added' else '-'} "
+                    f"`{e.source}` -> `{e.target}`"
+                    for e in edges
+                ]
+        if len(lines) == 1:
+            lines += ["", "No architectural changes."]
+        return "\n".join(lines) + "\n"
+
+
+def diff_graphs(old: GraphRecord, new: GraphRecord, base: str, head: str) -> GraphDiff:
+    """Compare two recorded graphs; every list is sorted for stable output."""
+    diff = GraphDiff(base=base, head=head)
+    old_symbols = _symbols(old.nodes)
+    new_symbols = _symbols(new.nodes)
+    for key in sorted(new_symbols.keys() - old_symbols.keys()):
+        diff.symbols.append(SymbolChange(str(key[1]), key[0], "added"))
+    for key in sorted(old_symbols.keys() - new_symbols.keys()):
+        diff.symbols.append(SymbolChange(str(key[1]), key[0], "removed"))
+    for key in sorted(old_symbols.keys() & new_symbols.keys()):
+        details = _property_changes(old_symbols[key], new_symbols[key])
+        if details:
+            diff.symbols.append(SymbolChange(str(key[1]), key[0], "changed", details))
+
+    before, after = old.dependencies(), new.dependencies()
+    for name in sorted(before.keys() | after.keys()):
+        if name not in before:
+            diff.dependencies.append(
+                DependencyChange(name, "added", new_version=after[name])
+            )
+        elif name not in after:
+            diff.dependencies.append(
+                DependencyChange(name, "removed", old_version=before[name])
+            )
+        elif before[name] != after[name]:
+            diff.dependencies.append(
+                DependencyChange(name, "changed", before[name], after[name])
+            )
+
+    for rel_type in EDGE_TYPES:
+        old_edges, new_edges = old.edges(rel_type), new.edges(rel_type)
+        for change, edges in (
+            ("added", new_edges - old_edges),
+            ("removed", old_edges - new_edges),
+        ):
+            diff.edges.extend(
+                EdgeChange(rel_type, str(source[1]), str(target[1]), change)
+                for source, target in sorted(edges, key=_edge_order)
+            )
+    return diff
+
+
+def _symbols(nodes: dict[NodeKey, dict[str, Any]]) -> dict[NodeKey, dict[str, Any]]:
+    # External interfaces (io.Writer) are referred to, not declared, by the code
+    return {
+        key: properties
+        for key, properties in nodes.items()
+        if key[0] in SYMBOL_LABELS and not properties.get("is_external")
+    }
+
+
+def _property_changes(before: dict[str, Any], after: dict[str, Any]) -> list[str]:
+    details = []
+    for name in CHANGE_PROPERTIES:
+        old, new = before.get(name), after.get(name)
+        if _normalized(old) == _normalized(new):
+            continue
+        if name == "body_hash":
+            details.append("body changed")
+        else:
+            details.append(f"{name} {_shown(old)} -> {_shown(new)}")
+    return details
+
+
+def _normalized(value: Any) -> Any:
+    # Snapshots hold JSON, where tuples and empty values come back as lists
+    if isinstance(value, list | tuple):
+        return tuple(value) or None
+    return value
+
+
+def _shown(value: Any) -> str:
+    if isinstance(value, list | tuple):
+        return "[" + ", ".join(map(str, value)) + "]"
+    return "none" if value is None else str(value)
+
+
+def _edge_order(edge: tuple[NodeKey, NodeKey]) -> tuple[str, str]:
+    return (str(edge[0][1]), str(edge[1][1]))
+
+
+def _dependency_line(change: DependencyChange) -> str:
+    if change.change == "changed":
+        old, new = change.old_version or "?", change.new_version or "?"
+        return f"`{change.name}` {old} -> {new}"
+    version = change.new_version or change.old_version
+    return f"{change.change} `{change.name}`" + (f" {version}" if version else "")
@@ -0,0 +1,243 @@
+"""The graph, or a slice of it, as JSON, GraphML, DOT or JSON Lines.
+
+GraphML loads into Gephi, yEd and NetworkX, DOT into Graphviz, and JSON Lines
+streams one node or relationship per line into downstream tooling; JSON is
+the document `graph_loader` reads. A slice keeps the nodes matching every
+filter given: under one of some packages (by qualified name), with one of
+some labels, or returned by a read-only Cypher query. Relationships are kept
+when both their ends are.
+"""
+
+import json
+from dataclasses import dataclass, field
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any, TextIO
+from xml.sax.saxutils import escape, quoteattr
+
+from .server.query import is_read_only
+
+EXPORT_FORMATS = ("json", "graphml", "dot", "jsonl")
+# Formats guessed from the output file when none is given
+SUFFIX_FORMATS = {
+    ".graphml": "graphml",
+    ".dot": "dot",
+    ".gv": "dot",
+    ".jsonl": "jsonl",
+    ".ndjson": "jsonl",
+}
+
+NODES_QUERY = """
+MATCH (n)
+{where}
+RETURN id(n) AS node_id, labels(n) AS labels, properties(n) AS properties
+"""
+
+RELATIONSHIPS_QUERY = """
+MATCH (a)-[r]->(b)
+WHERE id(a) IN $ids AND id(b) IN $ids
+RETURN id(a) AS from_id, id(b) AS to_id, type(r) AS type,
+       properties(r) AS properties
+"""
+
+
+@dataclass
+class GraphFilter:
+    """Which nodes to export; an empty filter exports the whole graph."""
+
+    packages: list[str] = field(default_factory=list)
+    labels: list[str] = field(default_factory=list)
+    query: str | None = None
+
+    def __bool__(self) -> bool:
+        return bool(self.packages or self.labels or self.query)
+
+
+def format_for(output: Path, requested: str | None = None) -> str:
+    """The requested format, else the one the output's suffix implies."""
+    if requested:
+        if requested not in EXPORT_FORMATS:
+            raise ValueError(
+                f"Unknown format '{requested}', expected one of "
+                f"{', '.join(EXPORT_FORMATS)}"
+            )
+        return requested
+    return SUFFIX_FORMATS.get(output.suffix.lower(), "json")
+
+
+def select_graph(ingestor: Any, graph_filter: GraphFilter) -> dict[str, Any]:
+    """Nodes and relationships of the slice, shaped like the JSON export."""
+    if not graph_filter:
+        return ingestor.export_graph_to_dict()
+    conditions = []
+    params: dict[str, Any] = {}
+    if graph_filter.packages:
+        conditions.append(
+            "any(p IN $packages WHERE n.qualified_name = p "
+            "OR n.qualified_name STARTS WITH p + '.')"
+        )
+        params["packages"] = graph_filter.packages
+    if graph_filter.labels:
+        conditions.append("any(l IN labels(n) WHERE l IN $labels)")
+        params["labels"] = graph_filter.labels
+    if graph_filter.query:
+        if not is_read_only(graph_filter.query):
+            raise ValueError("The export query must be read-only")
+        conditions.append("id(n) IN $query_ids")
+        params["query_ids"] = sorted(
+            _node_ids(ingestor.fetch_all(graph_filter.query))
+        )
+
+    nodes = ingestor.fetch_all(
+        NODES_QUERY.format(where=f"WHERE {' AND '.join(conditions)}"), params
+    )
+    relationships = ingestor.fetch_all(
+        RELATIONSHIPS_QUERY, {"ids": [node["node_id"] for node in nodes]}
+    )
+    return {
+        "nodes": nodes,
+        "relationships": relationships,
+        "metadata": {
+            "total_nodes": len(nodes),
+            "total_relationships": len(relationships),
+            "exported_at": datetime.now(UTC).isoformat(),
+        },
+    }
+
+
+def write_graph(graph: dict[str, Any], output: Path, export_format: str) -> None:
+    output.parent.mkdir(parents=True, exist_ok=True)
+    with output.open("w", encoding="utf-8") as f:
+        if export_format == "json":
+            json.dump(graph, f, indent=2, ensure_ascii=False, default=str)
+        elif export_format == "jsonl":
+            write_jsonl(graph, f)
+        elif export_format == "graphml":
+            write_graphml(graph, f)
+        else:
+            write_dot(graph, f)
+
+
+def write_jsonl(graph: dict[str, Any], f: TextIO) -> None:
+    """
+    Every node, then every relationship, one JSON object per line. `kind`
+    tells them apart, as `type` is already the relationship's type.
+    """
+    for node in graph["nodes"]:
+        f.write(json.dumps({"kind": "node", **node}, default=str) + "\n")
+    for rel in graph["relationships"]:
+        f.write(json.dumps({"kind": "relationship", **rel}, default=str) + "\n")
+
+
+def write_graphml(graph: dict[str, Any], f: TextIO) -> None:
+    """
+    GraphML with every property as a string attribute; lists and maps are
+    written as JSON. Nodes get a `label` for Gephi and a `labels` attribute.
+    """
+    node_keys = sorted(
+        {key for node in graph["nodes"] for key in node["properties"]}
+        - {"label", "labels"}
+    )
+    edge_keys = sorted(
+        {key for rel in graph["relationships"] for key in rel["properties"]}
+        - {"label"}
+    )
+    f.write('<?xml version="1.0" encoding="UTF-8"?>\n')
+    f.write('<graphml xmlns="http://graphml.graphdrawing.org/xmlns">\n')
+    for key in ("label", "labels", *node_keys):
+        f.write(_graphml_key(f"n_{key}", "node", key))
+    for key in ("label", *edge_keys):
+        f.write(_graphml_key(f"e_{key}", "edge", key))
+    f.write('  <graph id="code-graph" edgedefault="directed">\n')
+    for node in graph["nodes"]:
+        f.write(f'    <node id="n{node["node_id"]}">\n')
+        data = {
+            "label": _display_name(node),
+            "labels": ":".join(node["labels"]),
+            **{
+                key: value
+                for key, value in node["properties"].items()
+                if key in node_keys
+            },
+        }
+        for key, value in data.items():
+            f.write(f'      <data key="n_{key}">{escape(_text(value))}</data>\n')
+        f.write("    </node>\n")
+    for i, rel in enumerate(graph["relationships"]):
+        f.write(
+            f'    <edge id="e{i}" source="n{rel["from_id"]}" '
+            f'target="n{rel["to_id"]}">\n'
+        )
+        data = {
+            "label": rel["type"],
+            **{
+                key: value
+                for key, value in rel["properties"].items()
+                if key in edge_keys
+            },
+        }
+        for key, value in data.items():
+            f.write(f'      <data key="e_{key}">{escape(_text(value))}</data>\n')
+        f.write("    </edge>\n")
+    f.write("  </graph>\n</graphml>\n")
+
+
+def write_dot(graph: dict[str, Any], f: TextIO) -> None:
+    """A Graphviz digraph of node names and relationship types."""
+    f.write("digraph code_graph {\n  node [shape=box];\n")
+    for node in graph["nodes"]:
+        label = f"{_display_name(node)}\\n{':'.join(node['labels'])}"
+        f.write(f"  n{node['node_id']} [label={_dot_string(label)}];\n")
+    for rel in graph["relationships"]:
+        f.write(
+            f"  n{rel['from_id']} -> n{rel['to_id']} "
+            f"[label={_dot_string(rel['type'])}];\n"
+        )
+    f.write("}\n")
+
+
+def _node_ids(rows: list[dict[str, Any]]) -> set[int]:
+    """Ids of the nodes a query returned, alone, in lists or in paths."""
+    ids: set[int] = set()
+
+    def collect(value: Any) -> None:
+        if isinstance(value, list):
+            for item in value:
+                collect(item)
+        elif hasattr(value, "labels") and hasattr(value, "id"):
+            ids.add(value.id)
+        elif hasattr(value, "nodes"):
+            collect(list(value.nodes))
+
+    for row in rows:
+        for value in row.values():
+            collect(value)
+    return ids
+
+
+def _display_name(node: dict[str, Any]) -> str:
+    properties = node["properties"]
+    return str(
+        properties.get("name")
+        or properties.get("qualified_name")
+        or properties.get("path")
+        or node["node_id"]
+    )
+
+
+def _graphml_key(key_id: str, domain: str, name: str) -> str:
+    return (
+        f"  <key id={quoteattr(key_id)} for={quoteattr(domain)} "
+        f'attr.name={quoteattr(name)} attr.type="string"/>\n'
+    )
+
+
+def _text(value: Any) -> str:
+    if isinstance(value, list | dict):
+        return json.dumps(value, default=str)
+    return str(value)
+
+
+def _dot_string(text: str) -> str:
+    # Backslashes are kept: \n in a label is Graphviz's line break
+    return '"' + text.replace('"', '\\"') + '"'
@@ -4,14 +4,25 @@ from typing import Any
 
 from loguru import logger
 
+from .config import settings
+from .services.graph_backends import GraphBackend, create_backend
 from .services.graph_service import MemgraphIngestor
 
 
 class GraphIndexManager:
-    """Manages graph indexes for optimized query performance."""
+    """Manages graph indexes for optimized query performance.
 
-    def __init__(self, ingestor: MemgraphIngestor):
+    Index Cypher comes from the ingestor's backend when given, otherwise from
+    the one GRAPH_BACKEND names.
+    """
+
+    def __init__(
+        self, ingestor: MemgraphIngestor, backend: GraphBackend | None = None
+    ):
         self.ingestor = ingestor
+        self.backend = backend or create_backend(
+            settings.MEMGRAPH_HOST, settings.MEMGRAPH_PORT
+        )
 
     def create_indexes(self) -> None:
         """Create all necessary indexes for the knowledge graph."""
@@ -42,6 +53,9 @@ class GraphIndexManager:
         self._create_index("Class", "name")
         self._create_index("Method", "qualified_name")
         self._create_index("Method", "name")
+        self._create_index("Function", "body_hash")
+        self._create_index("Method", "body_hash")
+        self._create_index("Chunk", "qualified_name")
         
         # Module and package nodes
         self._create_index("Module", "qualified_name")
@@ -69,6 +83,22 @@ class GraphIndexManager:
         # Version control nodes
         self._create_index("Commit", "hash")
         self._create_index("Contributor", "email")
+        self._create_index("Author", "email")
+        self._create_index("Issue", "key")
+        self._create_index("Crash", "issue_id")
+
+        # Backstage catalog nodes
+        self._create_index("Component", "ref")
+        self._create_index("System", "ref")
+        self._create_index("API", "ref")
+
+        # Architecture
+        self._create_index("Layer", "name")
+
+        # Conversations kept by `start`
+        self._create_index("Conversation", "conversation_id")
+        self._create_index("Question", "question_id")
+        self._create_index("Answer", "answer_id")
 
     def _create_relationship_indexes(self) -> None:
         """Create indexes on relationship types."""
@@ -78,6 +108,8 @@ class GraphIndexManager:
             "CONTAINS",
             "IMPORTS",
             "CALLS",
+            "HAS_CHUNK",
+            "REFERENCES",
             "INHERITS_FROM",
             "IMPLEMENTS",
             "OVERRIDES",
@@ -88,6 +120,7 @@ class GraphIndexManager:
             "ASSERTS",
             "FLOWS_TO",
             "MODIFIES",
+            "MODIFIED",
             "POINTS_TO",
             "ASSIGNS_FP",
             "INVOKES_FP",
@@ -95,6 +128,19 @@ class GraphIndexManager:
             "INCLUDES",
             "LOCKS",
             "UNLOCKS",
+            "REFERENCES_ISSUE",
+            "CHANGED_FOR",
+            "IMPLEMENTS_TICKET",
+            "CHILD_OF",
+            "PART_OF",
+            "PROVIDES_API",
+            "CONSUMES_API",
+            "IMPLEMENTED_IN",
+            "DEFINED_IN",
+            "OBSERVED_CALL",
+            "CRASHED_IN",
+            "IN_LAYER",
+            "CLONE_OF",
         ]
         
         logger.debug(f"Relationship types to be indexed: {relationship_types}")
@@ -117,7 +163,7 @@ class GraphIndexManager:
     def _create_index(self, label: str, property_name: str) -> None:
         """Create a single property index."""
         try:
-            query = f"CREATE INDEX ON :{label}({property_name})"
+            query = self.backend.index_query(label, property_name)
             self.ingestor.execute_write(query)
             logger.debug(f"Created index on {label}.{property_name}")
         except Exception as e:
@@ -126,6 +126,13 @@ class GraphLoader:
         assert self._data is not None, "Data should be loaded"
         return self._data["metadata"]  # type: ignore
 
+    def to_dict(self) -> dict[str, Any]:
+        """Get the exported graph as loaded from the file."""
+        if self._data is None:
+            self.load()
+        assert self._data is not None, "Data should be loaded"
+        return self._data
+
     def find_nodes_by_label(self, label: str) -> list[GraphNode]:
         """Find all nodes with a specific label. O(1) lookup."""
         if self._nodes is None:
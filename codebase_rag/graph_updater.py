@@ -1,5 +1,15 @@
+import fnmatch
+import hashlib
 import os
+import re
+import sys
+import uuid
 from collections import defaultdict
+from collections.abc import Iterable, Iterator
+from concurrent.futures import Future
+from contextlib import contextmanager, nullcontext
+from datetime import UTC, datetime
+from itertools import repeat
 from pathlib import Path
 from typing import Any
 
@@ -9,19 +19,212 @@ from tree_sitter import Node, Parser
 
 from codebase_rag.services.graph_service import MemgraphIngestor
 
+from .analysis.api_surface import extract_api
+from .analysis.cgo import cgo_preamble, native_calls
+from .analysis.clones import body_fingerprint
+from .analysis.code_metrics import (
+    calculate_max_nesting_depth,
+    count_class_fields,
+    count_lines_of_code,
+    count_parameters,
+)
+from .analysis.complexity import calculate_cyclomatic_complexity
+from .analysis.concurrency import (
+    GoChannel,
+    GoFunctionConcurrency,
+    analyze_go_function,
+    collect_function_channels,
+    collect_package_channels,
+    collect_package_variables,
+    receiver_of,
+)
 from .analysis.data_flow import DataFlowAnalyzer
 from .analysis.dependencies import DependencyAnalyzer
+from .analysis.function_values import (
+    SYNCHRONOUS_CALLBACKS,
+    FunctionValue,
+    GoFunctionValues,
+    collect_function_values,
+)
+from .analysis.go_build import (
+    BuildConfig,
+    build_constraint,
+    constraint_terms,
+    platforms,
+)
+from .analysis.go_directives import (
+    collect_directives,
+    embedded_files,
+    generated_by,
+    generator_program,
+)
+from .analysis.go_docs import collect_go_docs
+from .analysis.go_generics import (
+    BUILTIN_CONSTRAINTS,
+    GoInstantiation,
+    collect_generic_definitions,
+    collect_instantiations,
+)
+from .analysis.go_grpc import (
+    LINK_RPC_CALLS,
+    GrpcService,
+    collect_grpc_services,
+)
+from .analysis.go_init import (
+    GoPackageFacts,
+    count_init_functions,
+    initialization_order,
+    nearest_initializers,
+)
+from .analysis.go_interfaces import (
+    STDLIB_INTERFACES,
+    MethodSets,
+    collect_go_methods,
+    collect_go_types,
+    variable_types,
+)
+from .analysis.go_modules import (
+    GO_BUILTINS,
+    GoModFile,
+    GoReplacement,
+    WorkspaceModules,
+    collect_go_imports,
+    import_specs,
+    is_standard_library,
+    module_for_import,
+    parse_go_mod_file,
+    parse_go_sum,
+    parse_go_work,
+    referenced_packages,
+)
+from .analysis.go_scopes import GoScopes
 from .analysis.inheritance import InheritanceAnalyzer
+from .analysis.panic_reachability import collect_panic_flow, detect_panic_and_recover
 from .analysis.security import SecurityAnalyzer
 from .analysis.test_coverage import TestCodeAnalyzer
-from .language_config import LanguageConfig, get_language_config
+from .analysis.unchecked_errors import (
+    collect_error_returning_functions,
+    find_unchecked_errors,
+)
+from .analysis.unused_dependencies import NODE_BUILTINS, npm_package_name
+from .chunking import node_chunks
+from .config import settings, split_names
+from .generated_code import DEFAULT_GENERATED_PATHS, is_generated
+from .ingest_checkpoint import IngestCheckpoint
+from .ingest_progress import FileProgress
+from .ingestion_report import CountingSink, IngestionReport, collect_warnings
+from .language_config import (
+    DISABLED_LANGUAGES,
+    EXTENSION_OVERRIDES,
+    LanguageConfig,
+    get_language_config,
+)
+from .large_files import summarize_file
+from .parse_cache import (
+    UNCACHED_LANGUAGES,
+    CachedFile,
+    ExtractionRecorder,
+    ParseCache,
+)
+from .parse_pool import ParsedSource, ParsePool
+from .parsers.backstage_parser import (
+    CATALOG_FILE_NAMES,
+    CATALOG_LABELS,
+    BackstageCatalogParser,
+    CatalogEntity,
+)
 from .parsers.bdd_parser import BDDParser
 from .parsers.c_parser import CParser
+from .parsers.codeowners_parser import CodeOwnersParser, OwnersTree
 from .parsers.config_parser import ConfigParser
+from .parsers.container_parser import (
+    ContainerSyntaxError,
+    Dockerfile,
+    context_path,
+    is_compose_file,
+    is_dockerfile,
+    parse_compose,
+    parse_dockerfile,
+)
+from .parsers.endpoint_detector import EndpointDetector, HttpEndpoint
+from .parsers.env_detector import EnvReadDetector
+from .parsers.hcl_parser import HclSyntaxError
+from .parsers.issue_references import IssueReferenceExtractor
+from .parsers.log_extractor import LogExtractor
+from .parsers.markdown_parser import is_readme, parse_markdown_sections
+from .parsers.openapi_parser import (
+    SPEC_SUFFIXES,
+    ApiOperation,
+    normalize_route,
+    parse_openapi,
+)
+from .parsers.proto_parser import ProtoSyntaxError, parse_proto, resolve_type
+from .parsers.sql_detector import SqlQueryDetector
+from .parsers.sql_parser import (
+    SqlSchema,
+    SqlStatement,
+    is_migration,
+    is_query,
+    migration_order,
+    query_tables,
+    split_statements,
+)
+from .parsers.terraform_parser import (
+    META_ARGUMENTS,
+    TERRAFORM_LABELS,
+    TerraformBlock,
+    parse_terraform,
+    provides_env,
+)
 from .parsers.test_detector import TestDetector
-from .parsers.test_parser import TestParser
-from .processing import FileTask, ParallelProcessor, ThreadSafeIngestor
+from .parsers.test_parser import TestParser, go_test_target
+from .parsers.todo_extractor import TodoExtractor
+from .parsers.workflow_parser import (
+    MAKEFILE_NAMES,
+    Invocation,
+    Makefile,
+    Workflow,
+    WorkflowSyntaxError,
+    invocations,
+    is_makefile,
+    is_workflow_file,
+    parse_makefile,
+    parse_workflow,
+)
 from .version_control.git_analyzer import GitAnalyzer
+from .workspace import GitIgnore, matches_glob, read_ragignore
+
+# Calls in the body of a Go example or benchmark, by function or method name
+GO_CALL = re.compile(r"(\w+)\s*\(")
+
+# Files of other modules with edges into the given modules or what they
+# define: calls, imports, inheritance. Deleting a changed module drops those
+# edges, so the files are parsed again to restore them.
+DEPENDENT_FILES_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*0..2]->(target)<-[r]-(source)
+WHERE m.path IN $paths AND NOT type(r) IN ['DEFINES', 'DEFINES_METHOD']
+MATCH (dependent:Module)-[:DEFINES|DEFINES_METHOD*0..2]->(source)
+WHERE dependent.path IS NOT NULL AND NOT dependent.path IN $paths
+RETURN DISTINCT dependent.path AS path
+ORDER BY path
+"""
+
+# .proto files with messages or RPCs naming a message of the given files
+PROTO_DEPENDENT_FILES_QUERY = """
+MATCH (f:File)-[:DEFINES]->(:ProtoMessage)<-[:REFERENCES|ACCEPTS|RETURNS]-(source)
+WHERE f.path IN $paths
+MATCH (dependent:File)-[:DEFINES|HAS_RPC*1..2]->(source)
+WHERE NOT dependent.path IN $paths
+RETURN DISTINCT dependent.path AS path
+ORDER BY path
+"""
+
+# Environment variables code reads, for Terraform resources that provide them
+ENV_VARS_READ_QUERY = """
+MATCH (e:EnvVar)<-[:READS_ENV]-()
+RETURN DISTINCT e.name AS name
+ORDER BY name
+"""
 
 
 class GraphUpdater:
@@ -38,6 +241,14 @@ class GraphUpdater:
         folder_filter: str | None = None,
         file_pattern: str | None = None,
         skip_tests: bool = False,
+        build_config: BuildConfig | None = None,
+        progress: FileProgress | None = None,
+        parse_cache: ParseCache | None = None,
+        large_file_mode: str | None = None,
+        generated_code_mode: str | None = None,
+        workspace: WorkspaceModules | None = None,
+        checkpoint: IngestCheckpoint | None = None,
+        resume: bool = False,
     ):
         self.ingestor = ingestor
         self.repo_path = repo_path
@@ -52,6 +263,107 @@ class GraphUpdater:
             set
         )  # Track module dependencies
         self.module_exports: dict[str, list] = defaultdict(list)  # Track module exports
+        # HTTP endpoints awaiting handler resolution: (endpoint_qn, module_qn, endpoint)
+        self.pending_endpoints: list[tuple[str, str, HttpEndpoint]] = []
+        # Linked routes by (method, normalized route): [(endpoint qn, handler qn)]
+        self.http_routes: dict[tuple[str, str], list[tuple[str, str]]] = (
+            defaultdict(list)
+        )
+        # Operations of OpenAPI specs to match to those routes: (endpoint qn,
+        # operation, normalized full route, normalized route without base path)
+        self.pending_api_operations: list[tuple[str, ApiOperation, str, str]] = []
+        # Dockerfiles by path, and the Compose services building them:
+        # (service qn, Dockerfile path, build context path, target stage)
+        self.dockerfiles: dict[str, Dockerfile] = {}
+        self.pending_compose_builds: list[tuple[str, str, str, str | None]] = []
+        # Blocks of the .tf files of each directory, a Terraform module
+        self.terraform_modules: dict[str, list[TerraformBlock]] = defaultdict(list)
+        # Statements of the migrations among .sql files by path, applied in
+        # order once every file is read
+        self.sql_migrations: dict[str, list[SqlStatement]] = {}
+        # GitHub Actions workflows and Makefiles by path, whose commands are
+        # linked to what they run once every file is read
+        self.workflows: dict[str, Workflow] = {}
+        self.makefiles: dict[str, Makefile] = {}
+        # Go Example and Benchmark functions: (test qn, module qn, kind, calls)
+        self.pending_go_targets: list[tuple[str, str, str, list[str]]] = []
+        # Line spans of functions/methods per module: (start, end, label, qn)
+        self.function_spans: dict[str, list[tuple[int, int, str, str]]] = defaultdict(
+            list
+        )
+        # Go package-level variables: {package_qn: {name: variable_qn}}
+        self.go_package_variables: dict[str, dict[str, str]] = defaultdict(dict)
+        # Names of Go functions and methods declared with an error result
+        self.go_error_functions: set[str] = set()
+        # Go types and methods of every package, for implicit IMPLEMENTS edges
+        self.go_method_sets = MethodSets()
+        # Go channels in package variables by package, and in struct fields by
+        # (package, struct type), by name: {name: channel qn}
+        self.go_package_channels: dict[str, dict[str, str]] = defaultdict(dict)
+        self.go_field_channels: dict[tuple[str, str], dict[str, str]] = defaultdict(
+            dict
+        )
+        # Channel parameters by function and position, and the sends and
+        # receives on each, to follow channels passed into functions
+        self.go_parameter_channels: dict[str, dict[int, str]] = defaultdict(dict)
+        self.go_channel_uses: dict[str, list[tuple[str, str, str, int, bool]]] = (
+            defaultdict(list)
+        )
+        # (module qn, callee name, argument index, channel qn)
+        self.pending_channel_arguments: list[tuple[str, str, int, str]] = []
+        # Parameters each Go function calls, by position, and functions passed
+        # to functions of the repository: (callee, position, value, line)
+        self.go_called_parameters: dict[str, dict[int, str]] = {}
+        self.pending_callback_arguments: list[
+            tuple[tuple[str, str], int, tuple[str, str], int]
+        ] = []
+        # Generic Go functions and types by package, by name: {name: (label, qn)}
+        self.go_generic_definitions: dict[str, dict[str, tuple[str, str]]] = (
+            defaultdict(dict)
+        )
+        # Type parameters with a named constraint: (parameter qn, package qn,
+        # constraint), and possible instantiations: (label, qn, package qn, use)
+        self.pending_type_constraints: list[tuple[str, str, str]] = []
+        self.pending_instantiations: list[tuple[str, str, str, GoInstantiation]] = []
+        # go.mod files by directory, and the module each imported package of a
+        # Go file comes from: {module qn: {name: (import path, module path)}}
+        self.go_mod_files: dict[Path, GoModFile] = {}
+        self.go_imports: dict[str, dict[str, tuple[str, str]]] = {}
+        # Every import of each Go module by the name it is used under:
+        # {module qn: {name: import path}}, and Go packages by import path
+        self.go_file_imports: dict[str, dict[str, str]] = {}
+        self._go_packages_by_import: dict[str, str] = {}
+        # Go modules of the other repositories of a workspace ingestion
+        self.workspace = workspace
+        # Dot imports of each Go module: (import path, module path, empty for
+        # the standard library and packages of the repository)
+        self.go_dot_imports: dict[str, list[tuple[str, str]]] = {}
+        # Build constraint expression of each constrained Go module
+        self.go_build_constraints: dict[str, str] = {}
+        # C functions of each cgo preamble by Go module, {module qn: {name: qn}},
+        # and of the repository's C files by name, for CALLS_NATIVE edges
+        self.cgo_functions: dict[str, dict[str, str]] = {}
+        self.c_functions: dict[str, set[str]] = defaultdict(set)
+        # gRPC services of generated Go code by their client interface qn,
+        # and by their server interface qn for linking handlers
+        self.grpc_clients: dict[str, GrpcService] = {}
+        self.grpc_servers: dict[str, GrpcService] = {}
+        # Full names of the protobuf messages and enums of .proto files, and
+        # the type references to resolve against them once all are read:
+        # (source node, type as written, scope, relationship, properties)
+        self.proto_messages: set[str] = set()
+        self.proto_enums: set[str] = set()
+        self.pending_proto_types: list[
+            tuple[tuple[str, str, str], str, str, str, dict[str, Any]]
+        ] = []
+        # Imports and init() functions of each Go package, by package qn
+        self.go_packages: dict[str, GoPackageFacts] = {}
+        # Files and ignored directories not parsed, with the reason why
+        self.skipped_files: dict[str, str] = {}
+        # Set per run or incremental update and bound to its log records
+        self.run_id = ""
+        # What the last run() wrote, skipped and how long each pass took
+        self.report: IngestionReport | None = None
 
         # Parallel processing configuration
         self.parallel = parallel
@@ -59,14 +371,52 @@ class GraphUpdater:
         self.folder_filter = folder_filter
         self.file_pattern = file_pattern
         self.skip_tests = skip_tests
+        self.include_paths = split_names(settings.INCLUDE_PATHS)
+        self.exclude_paths = split_names(settings.EXCLUDE_PATHS)
+        self.disabled_analyses = set(split_names(settings.DISABLED_ANALYSES))
+        # Go files excluded by their build constraints are skipped when set
+        self.build_config = build_config
+        self.progress = progress
+        # Unchanged files are replayed from the cache rather than parsed when set
+        self.parse_cache = parse_cache
+        # Cached files found while queueing parses, replayed when reached
+        self.cached_files: dict[Path, CachedFile] = {}
+        # Extractions being recorded, stored once their calls are collected
+        self.recorded_files: dict[Path, CachedFile] = {}
+        self.recorded_calls: list[tuple[str, str, str]] | None = None
+        # Calls of replayed files to resolve with the others: (module qn, calls)
+        self.cached_calls: list[tuple[str, list[tuple[str, str, str]]]] = []
+        # Set when building a shard: calls left for the merge to resolve,
+        # (module qn, caller label, caller qn, called name)
+        self.unresolved_calls: list[tuple[str, str, str, str]] | None = None
+        self._layout_hash: str | None = None
+        # Source files above LARGE_FILE_BYTES: "skip", "summarize" or
+        # "declarations" (see large_files.py)
+        self.large_file_mode = large_file_mode or settings.LARGE_FILE_MODE
+        # Generated code: "full", "declarations" or "skip" (see generated_code.py)
+        self.generated_code_mode = generated_code_mode or settings.GENERATED_CODE_MODE
+        self.generated_paths = [
+            *DEFAULT_GENERATED_PATHS,
+            *split_names(settings.GENERATED_PATHS),
+        ]
+        self._generated: dict[Path, bool] = {}
+        # Progress saved per file while run() writes, resumed from when set
+        self.checkpoint = checkpoint
+        self.resume = resume
+        self._failed_batches = 0
 
         # Initialize Git analyzer if repo is a git repository
         self.git_analyzer = None
-        try:
-            self.git_analyzer = GitAnalyzer(repo_path)
-            logger.info("Git repository detected, version control analysis enabled")
-        except Exception:
-            logger.info("Not a git repository or git analysis unavailable")
+        if "git" in self.disabled_analyses:
+            logger.info("Version control analysis disabled in DISABLED_ANALYSES")
+        else:
+            try:
+                self.git_analyzer = GitAnalyzer(repo_path)
+                logger.info(
+                    "Git repository detected, version control analysis enabled"
+                )
+            except Exception:
+                logger.info("Not a git repository or git analysis unavailable")
         self.ignore_dirs = {
             ".git",
             "venv",
@@ -81,48 +431,237 @@ class GraphUpdater:
             ".ruff_cache",
             ".claude",
         }
+        self.ignore_dirs |= read_ragignore(self.repo_path)
+        self.gitignore = (
+            GitIgnore(self.repo_path) if settings.RESPECT_GITIGNORE else None
+        )
 
     def run(self) -> None:
         """Orchestrates the parsing and ingestion process."""
-        self.ingestor.ensure_node_batch("Project", {"name": self.project_name})
-        logger.info(f"Ensuring Project: {self.project_name}")
+        self.run_id = uuid.uuid4().hex[:12]
+        report = IngestionReport(
+            run_id=self.run_id,
+            project=self.project_name,
+            repo_path=str(self.repo_path),
+            started_at=datetime.now(UTC).isoformat(),
+        )
+        counter = CountingSink()
+        with (
+            self._sink(counter),
+            logger.contextualize(run_id=self.run_id),
+            collect_warnings(self.run_id) as warnings,
+        ):
+            if self.checkpoint:
+                resumed = self.checkpoint.start(
+                    self._checkpoint_fingerprint(), self.resume
+                )
+                if resumed:
+                    logger.info(
+                        f"--- Resuming: {resumed} files stored by the interrupted "
+                        "run are read again without being written ---"
+                    )
+                self._failed_batches = self.ingestor.failed_batches
+            self.ingestor.ensure_node_batch("Project", {"name": self.project_name})
+            logger.info(f"Ensuring Project: {self.project_name}")
 
-        logger.info("--- Pass 1: Identifying Packages and Folders ---")
-        self._identify_structure()
+            logger.info("--- Pass 1: Identifying Packages and Folders ---")
+            with report.stage("structure"):
+                self._identify_structure()
+                self._ingest_go_modules()
 
-        if self.parallel:
-            logger.info(
-                f"\n--- Pass 2: Processing Files in Parallel ({self.num_workers or 'auto'} workers) ---"
-            )
-            self._process_files_parallel()
-        else:
             logger.info(
                 "\n--- Pass 2: Processing Files, Caching ASTs, and Collecting Definitions ---"
             )
-            self._process_files()
+            with report.stage("files"):
+                self._process_files()
+            if self.parse_cache:
+                logger.info(
+                    f"--- Reused {self.parse_cache.hits} unchanged files from the "
+                    "parse cache ---"
+                )
 
-        logger.info(
-            f"\n--- Found {len(self.function_registry)} functions/methods in codebase ---"
+            logger.info(
+                f"\n--- Found {len(self.function_registry)} functions/methods in codebase ---"
+            )
+            logger.info("--- Pass 3: Processing Function Calls from AST Cache ---")
+            with report.stage("calls"):
+                self._process_function_calls()
+            with report.stage("links"):
+                self._link_http_endpoints()
+                self._link_api_operations()
+                self._link_go_test_targets()
+                self._link_go_implementations()
+                self._link_proto_types()
+                self._link_channel_arguments()
+                self._link_callback_arguments()
+                self._link_go_generics()
+                self._link_build_variants()
+                self._link_go_initialization()
+                self._link_containers()
+                self._link_terraform()
+                self._ingest_sql_schema()
+                self._link_workflows()
+                self._link_makefiles()
+                self._ingest_code_owners()
+                self._ingest_backstage_catalog()
+
+            if "cycles" not in self.disabled_analyses:
+                logger.info("--- Pass 4: Detecting Circular Dependencies ---")
+                with report.stage("cycles"):
+                    self._detect_and_report_circular_dependencies()
+
+            # Analyze repository-level Git information
+            if self.git_analyzer:
+                with report.stage("git"):
+                    self._analyze_repository_git_info()
+
+            logger.info("\n--- Analysis complete. Flushing all data to database... ---")
+            with report.stage("flush"):
+                self.ingestor.flush_all()
+                if self.grpc_clients:
+                    self.ingestor.execute_write(LINK_RPC_CALLS)
+                if self.terraform_modules:
+                    # Reads of files taken from the parse cache are only in
+                    # the graph once flushed
+                    self._link_env_providers()
+                    self.ingestor.flush_all()
+                self.ingestor.mark_graph_changed()
+            if self.checkpoint:
+                self.checkpoint.clear()
+        report.finish(counter, self.skipped_files, warnings)
+        self.report = report
+
+    def load_function_registry(self) -> None:
+        """Seed call resolution with the functions already stored in the graph."""
+        rows = self.ingestor.fetch_all(
+            "MATCH (f) WHERE f:Function OR f:Method "
+            "RETURN f.qualified_name AS qualified_name, labels(f)[0] AS label"
         )
-        logger.info("--- Pass 3: Processing Function Calls from AST Cache ---")
-        self._process_function_calls()
-
-        logger.info("--- Pass 4: Detecting Circular Dependencies ---")
-        self._detect_and_report_circular_dependencies()
+        for row in rows:
+            qualified_name = row["qualified_name"]
+            self.function_registry[qualified_name] = row["label"]
+            self.simple_name_lookup[qualified_name.split(".")[-1]].add(qualified_name)
+        logger.info(f"Loaded {len(rows)} functions/methods from the graph")
 
-        # Analyze repository-level Git information
-        if self.git_analyzer:
-            self._analyze_repository_git_info()
+    def update_files(self, changed: list[str], removed: list[str]) -> None:
+        """
+        Re-ingest changed files and drop removed ones without a full re-parse.
 
-        logger.info("\n--- Analysis complete. Flushing all data to database... ---")
-        self.ingestor.flush_all()
+        Paths are relative to the repository root. Nodes defined by a changed
+        module are deleted before it is parsed again, so symbols that no longer
+        exist disappear with their edges. Unchanged files with calls or other
+        edges into those modules are parsed again too, without deleting
+        anything, so their edges to the new definitions are restored.
+        """
+        self.run_id = uuid.uuid4().hex[:12]
+        with logger.contextualize(run_id=self.run_id):
+            dependents = [
+                row["path"]
+                for row in self.ingestor.fetch_all(
+                    DEPENDENT_FILES_QUERY, {"paths": [*changed, *removed]}
+                )
+            ]
+            proto_paths = [p for p in [*changed, *removed] if p.endswith(".proto")]
+            if proto_paths:
+                dependents += [
+                    row["path"]
+                    for row in self.ingestor.fetch_all(
+                        PROTO_DEPENDENT_FILES_QUERY, {"paths": proto_paths}
+                    )
+                    if row["path"] not in dependents
+                ]
+                # Services and RPCs stay, as generated Go code links to them too
+                self.ingestor.execute_write(
+                    "MATCH (m:ProtoMessage) WHERE m.path IN $paths DETACH DELETE m",
+                    {"paths": proto_paths},
+                )
+            for relative_path in [*changed, *removed]:
+                self.ingestor.execute_write(
+                    "MATCH (m:Module {path: $path}) "
+                    "OPTIONAL MATCH (m)-[:DEFINES|DEFINES_METHOD|DEFINES_VARIABLE|"
+                    "DEFINES_ENDPOINT|DEFINES_CHANNEL|HAS_TYPE_PARAMETER|HAS_TODO|"
+                    "LOGS|HAS_UNCHECKED_ERROR|HAS_DOCUMENTATION|EXPOSES|"
+                    "HAS_CHUNK*1..4]->(c) "
+                    "DETACH DELETE m, c",
+                    {"path": relative_path},
+                )
+            for relative_path in [*changed, *removed]:
+                self.ingestor.execute_write(
+                    "MATCH (:File {path: $path})-[:HAS_DOCUMENTATION]->(d) "
+                    "DETACH DELETE d",
+                    {"path": relative_path},
+                )
+            for relative_path in removed:
+                self.ingestor.execute_write(
+                    "MATCH (f:File {path: $path}) DETACH DELETE f", {"path": relative_path}
+                )
+            for relative_path in [*changed, *removed]:
+                # A header may have been added or removed
+                self._generated.pop(self.repo_path / relative_path, None)
+
+            if not self.structural_elements:
+                # Needed to attach modules to their packages, and Go imports
+                # to the modules they come from
+                self._identify_structure()
+                self._ingest_go_modules()
+
+            parsed = []
+            for relative_path in [*changed, *dependents]:
+                file_path = self.repo_path / relative_path
+                if (
+                    not file_path.is_file()
+                    or self.ignore_dirs.intersection(Path(relative_path).parts)
+                    or self._path_skip_reason(relative_path)
+                ):
+                    continue
+                lang_config = get_language_config(file_path.suffix)
+                if lang_config and lang_config.name in self.parsers:
+                    with logger.contextualize(file=relative_path):
+                        self.parse_and_ingest_file(file_path, lang_config.name)
+                    if file_path in self.ast_cache:
+                        parsed.append(file_path)
+                elif file_path.suffix == ".proto":
+                    self._parse_proto_file(file_path)
+                elif is_readme(file_path.name):
+                    self._parse_readme(file_path)
+
+            for file_path in parsed:
+                root_node, language = self.ast_cache.pop(file_path)
+                with logger.contextualize(file=self._relative_posix(file_path)):
+                    self._process_calls_in_file(file_path, root_node, language)
+            self._resolve_cached_calls()
+            self._link_go_test_targets()
+            self._link_channel_arguments()
+            self._link_callback_arguments()
+            self._link_go_generics()
+            self._link_build_variants()
+            if self.pending_proto_types:
+                # Messages of the .proto files left alone are only in the graph
+                self.proto_messages.update(
+                    row["qualified_name"]
+                    for row in self.ingestor.fetch_all(
+                        "MATCH (m:ProtoMessage) RETURN m.qualified_name AS "
+                        "qualified_name"
+                    )
+                )
+                self._link_proto_types()
+            # Initialization order needs every file of a package, so the
+            # PackageInit nodes are left as the last full run made them
+            self.ingestor.flush_all()
+            if self.grpc_clients:
+                self.ingestor.execute_write(LINK_RPC_CALLS)
+            self.ingestor.mark_graph_changed()
+            logger.info(
+                f"Updated {len(parsed)} files, removed {len(removed)}; "
+                f"{len(dependents)} unchanged files re-linked to the changes"
+            )
 
     def _identify_structure(self) -> None:
         """First pass: Walks the directory to find all packages and folders."""
         for root_str, dirs, _ in os.walk(self.repo_path, topdown=True):
-            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
             root = Path(root_str)
             relative_root = root.relative_to(self.repo_path)
+            dirs[:] = self._prune_ignored_dirs(relative_root, dirs)
 
             parent_rel_path = relative_root.parent
             parent_container_qn = self.structural_elements.get(parent_rel_path)
@@ -187,13 +726,114 @@ class GraphUpdater:
 
     def _process_files(self) -> None:
         """Second pass: Walks the directory, parses files, and caches their ASTs."""
-        for root_str, dirs, files in os.walk(self.repo_path, topdown=True):
-            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
+        files = self._walk_files()
+        pool = ParsePool(self.parsers, self.num_workers) if self.parallel else None
+        if self.progress:
+            self.progress.start(len(files))
+        try:
+            with pool or nullcontext():
+                parsed: Iterable[Future[ParsedSource] | None] = repeat(None)
+                if pool:
+                    logger.info(f"Parsing {len(files)} files on {pool.workers} threads")
+                    parsed = pool.parse_in_order(self._parse_jobs(files))
+                # In walk order even when parsed in parallel, so each
+                # directory's files are written together, as sequentially
+                for (filepath, parent), source in zip(files, parsed):
+                    with self._checkpointed("files", filepath):
+                        self._process_file(filepath, parent, source)
+                    if self.progress:
+                        self.progress.advance()
+        finally:
+            if self.progress:
+                self.progress.finish()
+
+    @contextmanager
+    def _sink(self, sink: Any) -> Iterator[None]:
+        """
+        Pass what the ingestor writes to a sink as well while in the block;
+        ingestors without sinks, like test doubles, are left as they are.
+        """
+        sinks = getattr(self.ingestor, "sinks", None)
+        if sinks is None:
+            yield
+            return
+        sinks.add(sink)
+        try:
+            yield
+        finally:
+            sinks.remove(sink)
+
+    @contextmanager
+    def _checkpointed(self, stage: str, file_path: Path) -> Iterator[None]:
+        """
+        A file's part in a pass, not written again when a resumed run stored
+        it, and otherwise recorded in the next checkpoint.
+        """
+        if self.checkpoint is None:
+            yield
+            return
+        relative_path = self._relative_posix(file_path)
+        if self.checkpoint.is_stored(stage, relative_path):
+            with self.ingestor.skipping_writes():
+                yield
+            return
+        yield
+        self.checkpoint.mark(stage, relative_path)
+        if self.checkpoint.due:
+            self._save_checkpoint(self.checkpoint)
+
+    def _save_checkpoint(self, checkpoint: IngestCheckpoint) -> None:
+        """Write what is buffered, then record the files it came from as stored."""
+        self.ingestor.flush_all()
+        failed = self.ingestor.failed_batches - self._failed_batches
+        self._failed_batches = self.ingestor.failed_batches
+        if failed:
+            # Some of their writes are lost, so a resumed run makes them again
+            logger.warning(
+                f"{failed} batches failed since the last checkpoint; "
+                "their files are not recorded as stored"
+            )
+            checkpoint.discard()
+            return
+        checkpoint.save()
+
+    def _checkpoint_fingerprint(self) -> str:
+        """The options that decide what a run writes, which a resume must share."""
+        options = [
+            self.project_name,
+            self.folder_filter or "",
+            self.file_pattern or "",
+            str(self.skip_tests),
+            str(self.build_config or ""),
+            ",".join(self.include_paths),
+            ",".join(self.exclude_paths),
+            ",".join(sorted(self.disabled_analyses)),
+            self.large_file_mode,
+            self.generated_code_mode,
+            ",".join(self.generated_paths),
+            self._redaction(),
+        ]
+        return hashlib.sha256("\0".join(options).encode()).hexdigest()
+
+    def _walk_files(self) -> list[tuple[Path, tuple[str, str, str]]]:
+        """Files to ingest with their parent container, in walk order."""
+        folders = _option_list(self.folder_filter)
+        patterns = _option_list(self.file_pattern)
+        files = []
+        for root_str, dirs, file_names in os.walk(self.repo_path, topdown=True):
             root = Path(root_str)
             relative_root = root.relative_to(self.repo_path)
-            parent_container_qn = self.structural_elements.get(relative_root)
+            dirs[:] = self._prune_ignored_dirs(relative_root, dirs)
+
+            if folders and not str(relative_root).startswith(tuple(folders)):
+                for file_name in file_names:
+                    self.skipped_files[str(relative_root / file_name)] = (
+                        "outside --folder-filter"
+                    )
+                continue
 
-            parent_label, parent_key, parent_val = (
+            parent_container_qn = self.structural_elements.get(relative_root)
+            parent = (
                 ("Package", "qualified_name", parent_container_qn)
                 if parent_container_qn
                 else (
@@ -203,40 +843,165 @@ class GraphUpdater:
                 )
             )
 
-            for file_name in files:
-                filepath = root / file_name
-                relative_filepath = str(filepath.relative_to(self.repo_path))
+            for file_name in file_names:
+                relative_filepath = str(relative_root / file_name)
+                if patterns and not any(
+                    fnmatch.fnmatch(file_name, pattern) for pattern in patterns
+                ):
+                    self.skipped_files[relative_filepath] = (
+                        "does not match --file-pattern"
+                    )
+                    continue
+                if self.skip_tests and any(
+                    marker in file_name.lower()
+                    for marker in ("test_", "_test.", ".test.", ".spec.")
+                ):
+                    self.skipped_files[relative_filepath] = "test file (--skip-tests)"
+                    continue
+                reason = self._path_skip_reason(relative_filepath)
+                if reason:
+                    self.skipped_files[relative_filepath] = reason
+                    continue
+                files.append((root / file_name, parent))
+        return files
 
-                # Create generic File node for all files
-                self.ingestor.ensure_node_batch(
-                    "File",
-                    {
-                        "path": relative_filepath,
-                        "name": file_name,
-                        "extension": filepath.suffix,
-                    },
-                )
-                self.ingestor.ensure_relationship_batch(
-                    (parent_label, parent_key, parent_val),
-                    "CONTAINS_FILE",
-                    ("File", "path", relative_filepath),
-                )
+    def _path_skip_reason(self, relative_path: str) -> str | None:
+        """
+        Why INCLUDE_PATHS, EXCLUDE_PATHS or a .gitignore leave a file out, if
+        they do.
+        """
+        if self.include_paths and not any(
+            matches_glob(relative_path, pattern) for pattern in self.include_paths
+        ):
+            return "not in INCLUDE_PATHS"
+        if any(matches_glob(relative_path, pattern) for pattern in self.exclude_paths):
+            return "excluded by EXCLUDE_PATHS"
+        if self.gitignore and self.gitignore.ignored(relative_path):
+            return "ignored by .gitignore"
+        return None
 
-                # Check if this file type is supported for parsing
-                lang_config = get_language_config(filepath.suffix)
-                if lang_config and lang_config.name in self.parsers:
-                    self.parse_and_ingest_file(filepath, lang_config.name)
-                elif file_name == "pyproject.toml":
-                    self._parse_dependencies(filepath)
-                elif filepath.suffix == ".feature":
-                    # Parse BDD feature files
-                    self._parse_bdd_file(filepath)
-                elif self._is_config_file(filepath):
-                    # Parse configuration files
-                    self._parse_config_file(filepath)
-
-    def _get_docstring(self, node: Node) -> str | None:
-        """Extracts the docstring from a function or class node's body."""
+    def _parse_jobs(
+        self, files: list[tuple[Path, tuple[str, str, str]]]
+    ) -> Iterator[tuple[Path, str | None]]:
+        """Files for a ParsePool, without a language for those in the cache."""
+        for filepath, _ in files:
+            language = self._parser_language(filepath)
+            if language and self._large_file_mode(filepath) in ("skip", "summarize"):
+                language = None
+            if (
+                language
+                and self.generated_code_mode == "skip"
+                and self._is_generated(filepath)
+            ):
+                language = None
+            if language:
+                _, cached = self._lookup_cached(filepath, language)
+                if cached:
+                    self.cached_files[filepath] = cached
+                    language = None
+            yield filepath, language
+
+    def _parser_language(self, filepath: Path) -> str | None:
+        lang_config = get_language_config(filepath.suffix)
+        if lang_config and lang_config.name in self.parsers:
+            return lang_config.name
+        return None
+
+    def _process_file(
+        self,
+        filepath: Path,
+        parent: tuple[str, str, str],
+        parsed: Future[ParsedSource] | None = None,
+    ) -> None:
+        relative_filepath = str(filepath.relative_to(self.repo_path))
+
+        # Create generic File node for all files
+        self.ingestor.ensure_node_batch(
+            "File",
+            {
+                "path": relative_filepath,
+                "name": filepath.name,
+                "extension": filepath.suffix,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            parent,
+            "CONTAINS_FILE",
+            ("File", "path", relative_filepath),
+        )
+
+        # Check if this file type is supported for parsing
+        language = self._parser_language(filepath)
+        if language:
+            with logger.contextualize(file=relative_filepath):
+                self.parse_and_ingest_file(filepath, language, parsed)
+        elif filepath.name == "pyproject.toml":
+            self._parse_dependencies(filepath)
+        elif filepath.suffix == ".feature":
+            # Parse BDD feature files
+            self._parse_bdd_file(filepath)
+        elif filepath.suffix == ".proto":
+            self._parse_proto_file(filepath)
+        elif filepath.suffix == ".tf":
+            self._parse_terraform_file(filepath)
+        elif filepath.suffix == ".sql":
+            self._parse_sql_file(filepath)
+        elif is_dockerfile(filepath.name):
+            self._parse_dockerfile(filepath)
+        elif is_makefile(filepath.name):
+            self._parse_makefile(filepath)
+            if self._is_config_file(filepath):
+                self._parse_config_file(filepath)
+        elif is_readme(filepath.name):
+            self._parse_readme(filepath)
+        elif self._is_config_file(filepath):
+            # Compose files, OpenAPI specs and workflows stay config files
+            # too, with services, endpoints or jobs besides their settings
+            if is_compose_file(filepath.name):
+                self._parse_compose_file(filepath)
+            elif is_workflow_file(relative_filepath):
+                self._parse_workflow_file(filepath)
+            elif filepath.suffix in SPEC_SUFFIXES:
+                self._parse_openapi_file(filepath)
+            # Parse configuration files
+            self._parse_config_file(filepath)
+        else:
+            self.skipped_files[relative_filepath] = self._skip_reason(filepath)
+
+    def _prune_ignored_dirs(self, relative_root: Path, dirs: list[str]) -> list[str]:
+        """The subdirectories to walk into, recording the ignored ones as skipped."""
+        kept = []
+        for name in dirs:
+            relative_dir = relative_root / name
+            if name in self.ignore_dirs:
+                self.skipped_files[f"{relative_dir}/"] = "ignored directory"
+            elif self.gitignore and self.gitignore.ignored(
+                relative_dir.as_posix(), is_dir=True
+            ):
+                self.skipped_files[f"{relative_dir}/"] = "ignored by .gitignore"
+            else:
+                kept.append(name)
+        return kept
+
+    def _skip_reason(self, filepath: Path) -> str:
+        lang_config = get_language_config(filepath.suffix, include_disabled=True)
+        if lang_config and lang_config.name in DISABLED_LANGUAGES:
+            return f"{lang_config.name} is disabled"
+        if lang_config:
+            return f"no {lang_config.name} grammar installed"
+        if filepath.suffix in EXTENSION_OVERRIDES:
+            return f"{filepath.suffix} files are excluded in LANGUAGE_EXTENSIONS"
+        if filepath.suffix:
+            return f"no parser for {filepath.suffix} files"
+        return "no parser for files without an extension"
+
+    def _get_docstring(self, node: Node, language: str = "python") -> str | None:
+        """
+        Extracts the docstring from a function or class node's body, or for
+        languages without docstrings, the doc comment directly above it.
+        """
+        if language != "python":
+            return self._get_leading_comment(node)
         body_node = node.child_by_field_name("body")
         if not body_node or not body_node.children:
             return None
@@ -250,13 +1015,204 @@ class GraphUpdater:
                 return text.decode("utf-8").strip("'\" \n")  # type: ignore[no-any-return]
         return None
 
-    def parse_and_ingest_file(self, file_path: Path, language: str) -> None:
+    def _get_leading_comment(self, node: Node) -> str | None:
+        """Collects the contiguous comment block that ends on the line above a node."""
+        # Doc comments sit above `export function ...` rather than the function
+        if node.parent and node.parent.type == "export_statement":
+            node = node.parent
+
+        lines: list[str] = []
+        expected_end = node.start_point[0] - 1
+        sibling = node.prev_sibling
+        while (
+            sibling is not None
+            and sibling.type.endswith("comment")
+            and sibling.end_point[0] == expected_end
+        ):
+            if sibling.text is not None:
+                lines[:0] = sibling.text.decode("utf-8", errors="replace").split("\n")
+            expected_end = sibling.start_point[0] - 1
+            sibling = sibling.prev_sibling
+
+        cleaned = [
+            re.sub(r"^\s*(?:/\*+|\*+/|\*|//+!?|#+)\s?|\s*\*+/\s*$", "", line).rstrip()
+            for line in lines
+        ]
+        doc = "\n".join(cleaned).strip()
+        return doc or None
+
+    def _get_decorators(self, node: Node) -> list[str]:
+        """Returns decorator and annotation names, e.g. ["app.command", "Override"]."""
+        candidates = list(node.children)
+        # Python wraps decorated functions and classes in a decorated_definition
+        if node.parent and node.parent.type == "decorated_definition":
+            candidates.extend(node.parent.children)
+        for child in node.children:
+            if child.type == "modifiers":  # Java annotations
+                candidates.extend(child.children)
+
+        decorators = []
+        for child in candidates:
+            if child.type not in ("decorator", "annotation", "marker_annotation"):
+                continue
+            text = (child.text or b"").decode("utf-8", errors="replace")
+            # Drop the "@" and any call arguments
+            name = text.lstrip("@").split("(", 1)[0].strip()
+            if name:
+                decorators.append(name)
+        return decorators
+
+    def parse_and_ingest_file(
+        self,
+        file_path: Path,
+        language: str,
+        parsed: Future[ParsedSource] | None = None,
+    ) -> None:
         """
         Parses a file, ingests its structure and definitions,
-        and caches the AST for the next pass.
+        and caches the AST for the next pass. A file already being parsed
+        by a ParsePool is passed as the future of its parse. A file whose
+        contents are in the parse cache is replayed from it instead.
         """
         if isinstance(file_path, str):
             file_path = Path(file_path)
+        if not self._is_generated(file_path):
+            self._ingest_source_file(file_path, language, parsed)
+            return
+        relative_path = file_path.relative_to(self.repo_path)
+        if self.generated_code_mode == "skip":
+            logger.info(f"Not parsing generated {relative_path}")
+            self.skipped_files[str(relative_path)] = "generated code"
+            return
+        module_qn = self._module_qualified_name(relative_path)
+        with self.ingestor.tagging_module(module_qn, {"generated": True}):
+            self._ingest_source_file(
+                file_path,
+                language,
+                parsed,
+                declarations_only=self.generated_code_mode == "declarations",
+            )
+
+    def _ingest_source_file(
+        self,
+        file_path: Path,
+        language: str,
+        parsed: Future[ParsedSource] | None = None,
+        declarations_only: bool = False,
+    ) -> None:
+        large_file_mode = self._large_file_mode(file_path)
+        if large_file_mode in ("skip", "summarize"):
+            self._ingest_large_file(file_path, large_file_mode)
+            return
+        if declarations_only or large_file_mode == "declarations":
+            self._parse_and_ingest_file(
+                file_path, language, parsed, declarations_only=True
+            )
+            return
+        cache_key, cached = None, self.cached_files.pop(file_path, None)
+        if cached is None:
+            cache_key, cached = self._lookup_cached(file_path, language, parsed)
+        relative_path = file_path.relative_to(self.repo_path)
+        module_qn = self._module_qualified_name(relative_path)
+        if cached:
+            logger.info(f"Replaying cached extraction of {relative_path}")
+            if language == "go" and not self._restore_go_file(
+                file_path, parsed, module_qn
+            ):
+                return
+            self._replay_cached_file(cached, module_qn, language)
+            self._ingest_uncached_facts(file_path, language, parsed, module_qn)
+            return
+        if cache_key is None:
+            self._parse_and_ingest_file(file_path, language, parsed)
+            if self._parsed(file_path):
+                self._ingest_uncached_facts(file_path, language, parsed, module_qn)
+            return
+
+        entry = CachedFile(cache_key)
+        spans = len(self.function_spans[module_qn])
+        endpoints = len(self.pending_endpoints)
+        recorder = ExtractionRecorder(entry)
+        with self._sink(recorder):
+            self._parse_and_ingest_file(file_path, language, parsed)
+        if not self._parsed(file_path):
+            return  # Failed, so parsed again next time
+        self._ingest_uncached_facts(file_path, language, parsed, module_qn)
+        entry.spans = self.function_spans[module_qn][spans:]
+        entry.endpoints = self.pending_endpoints[endpoints:]
+        entry.dependencies = set(self.module_dependencies.get(module_qn, ()))
+        self.recorded_files[file_path] = entry
+
+    def _parsed(self, file_path: Path) -> bool:
+        """Whether a file was parsed, not skipped or failed."""
+        relative_path = str(file_path.relative_to(self.repo_path))
+        return file_path in self.ast_cache and relative_path not in self.skipped_files
+
+    def _ingest_uncached_facts(
+        self,
+        file_path: Path,
+        language: str,
+        parsed: Future[ParsedSource] | None,
+        module_qn: str,
+    ) -> None:
+        """
+        What a file adds that its contents alone do not decide, left out of
+        the parse cache: its Git history, and for Go the files its directives
+        embed and generate.
+        """
+        if language == "go":
+            try:
+                source = parsed.result().source if parsed else file_path.read_bytes()
+                self._ingest_go_directives(file_path, source, module_qn)
+            except Exception as e:
+                logger.error(f"Failed to ingest the directives of {file_path}: {e}")
+        if self.git_analyzer:
+            self._analyze_git_info(file_path, module_qn)
+
+    def _restore_go_file(
+        self, file_path: Path, parsed: Future[ParsedSource] | None, module_qn: str
+    ) -> bool:
+        """
+        Parse a Go file replayed from the parse cache again for what the
+        passes after it need: its AST, and the method sets, imports, channels
+        and other package facts Go calls are resolved with. What it writes is
+        dropped, the replay writing it. False when the build configuration
+        leaves the file out.
+        """
+        relative_path = file_path.relative_to(self.repo_path)
+        if parsed is not None:
+            source_bytes, tree = parsed.result()
+        else:
+            source_bytes, tree = file_path.read_bytes(), None
+        constraint = self._go_build_constraint(file_path, source_bytes)
+        if constraint is None:
+            return False
+        if tree is None:
+            tree = self.parsers["go"].parse(source_bytes)
+        root_node = tree.root_node
+        self.ast_cache[file_path] = (root_node, "go")
+        with self.ingestor.discarding_writes():
+            if constraint:
+                self._ingest_build_constraint(module_qn, constraint)
+            self._ingest_top_level_functions(root_node, module_qn, "go")
+            self._ingest_classes_and_methods(root_node, module_qn, "go")
+            self._ingest_go_package_variables(root_node, module_qn)
+            self._ingest_go_channels(root_node, module_qn)
+            self._ingest_go_generics(root_node, module_qn)
+            self._collect_go_package_facts(root_node, module_qn, relative_path)
+            self._ingest_cgo_preamble(file_path, root_node, module_qn)
+            self.go_error_functions.update(collect_error_returning_functions(root_node))
+            self._ingest_go_types(root_node, module_qn)
+            self._ingest_go_imports(root_node, module_qn, relative_path)
+        return True
+
+    def _parse_and_ingest_file(
+        self,
+        file_path: Path,
+        language: str,
+        parsed: Future[ParsedSource] | None = None,
+        declarations_only: bool = False,
+    ) -> None:
         relative_path = file_path.relative_to(self.repo_path)
         relative_path_str = str(relative_path)
         logger.info(f"Parsing and Caching AST for {language}: {relative_path_str}")
@@ -265,50 +1221,58 @@ class GraphUpdater:
             # Check if language is supported
             if language not in self.parsers or language not in self.queries:
                 logger.warning(f"Unsupported language '{language}' for {file_path}")
+                self.skipped_files[relative_path_str] = f"no {language} queries"
                 return
 
-            source_bytes = file_path.read_bytes()
-            parser = self.parsers[language]
-            tree = parser.parse(source_bytes)
+            if parsed is not None:
+                source_bytes, tree = parsed.result()
+            else:
+                source_bytes, tree = file_path.read_bytes(), None
+            constraint = ""
+            if language == "go":
+                constraint = self._go_build_constraint(file_path, source_bytes)
+                if constraint is None:
+                    return
+            if tree is None:
+                tree = self.parsers[language].parse(source_bytes)
             root_node = tree.root_node
 
             # Cache the parsed AST for the function call pass
-            self.ast_cache[file_path] = (root_node, language)
+            if not declarations_only:
+                self.ast_cache[file_path] = (root_node, language)
 
-            module_qn = ".".join(
-                [self.project_name] + list(relative_path.with_suffix("").parts)
-            )
-            if file_path.name == "__init__.py":
-                module_qn = ".".join(
-                    [self.project_name] + list(relative_path.parent.parts)
-                )
+            module_qn = self._module_qualified_name(relative_path)
 
-            self.ingestor.ensure_node_batch(
-                "Module",
-                {
-                    "qualified_name": module_qn,
-                    "name": file_path.name,
-                    "path": relative_path_str,
-                },
-            )
+            module_props: dict[str, Any] = {
+                "qualified_name": module_qn,
+                "name": file_path.name,
+                "path": relative_path_str,
+            }
+            if declarations_only:
+                module_props["declarations_only"] = True
+            self.ingestor.ensure_node_batch("Module", module_props)
+            if constraint:
+                self._ingest_build_constraint(module_qn, constraint)
+            if not declarations_only:
+                self._ingest_chunks("Module", module_qn, root_node)
 
             # Link Module to its parent Package/Folder
-            parent_rel_path = relative_path.parent
-            parent_container_qn = self.structural_elements.get(parent_rel_path)
-            parent_label, parent_key, parent_val = (
-                ("Package", "qualified_name", parent_container_qn)
-                if parent_container_qn
-                else (
-                    ("Folder", "path", str(parent_rel_path))
-                    if parent_rel_path != Path()
-                    else ("Project", "name", self.project_name)
-                )
-            )
             self.ingestor.ensure_relationship_batch(
-                (parent_label, parent_key, parent_val),
+                self._module_parent(relative_path),
                 "CONTAINS_MODULE",
                 ("Module", "qualified_name", module_qn),
             )
+            self._ingest_api_surface(
+                root_node, module_qn, relative_path.as_posix(), language
+            )
+
+            if declarations_only:
+                # What a large file declares, without the passes over bodies
+                self._ingest_top_level_functions(root_node, module_qn, language)
+                self._ingest_classes_and_methods(root_node, module_qn, language)
+                if language == "go":
+                    self._ingest_go_types(root_node, module_qn)
+                return
 
             # Check if this is a test file
             test_detector = TestDetector()
@@ -326,37 +1290,274 @@ class GraphUpdater:
                 # Use regular parsing for other files
                 self._ingest_top_level_functions(root_node, module_qn, language)
                 self._ingest_classes_and_methods(root_node, module_qn, language)
+                if language == "go":
+                    self._ingest_go_package_variables(root_node, module_qn)
+                    self._ingest_go_channels(root_node, module_qn)
+                    self._ingest_go_generics(root_node, module_qn)
+                    self._collect_go_package_facts(root_node, module_qn, relative_path)
+                    self._ingest_cgo_preamble(file_path, root_node, module_qn)
+                    self._ingest_go_documentation(root_node, module_qn, relative_path)
+                    self.go_error_functions.update(
+                        collect_error_returning_functions(root_node)
+                    )
+
+            if language == "go":
+                # Test files too: their mocks and fakes implement interfaces
+                self._ingest_go_types(root_node, module_qn)
+                self._ingest_go_imports(root_node, module_qn, relative_path)
+
+            # Track TODO/FIXME/HACK comments
+            self._ingest_todos(file_path, root_node, module_qn, relative_path_str)
+            self._ingest_issue_references(root_node, module_qn, relative_path_str)
+
+            # Track logging calls so log messages can be traced back to code
+            if not is_test:
+                self._ingest_log_statements(
+                    root_node, module_qn, relative_path_str, language
+                )
+
+            # Detect HTTP endpoints exposed by non-test code
+            if (
+                not is_test
+                and "endpoints" not in self.disabled_analyses
+                and language in ["python", "javascript", "typescript", "go", "java"]
+            ):
+                self._ingest_http_endpoints(
+                    relative_path_str, source_bytes.decode("utf-8"), module_qn, language
+                )
+
+            # Environment variables read, to trace infrastructure setting them
+            if not is_test:
+                self._ingest_env_reads(
+                    source_bytes.decode("utf-8"), module_qn, language
+                )
+                # And the tables its SQL queries read and write
+                self._ingest_sql_queries(source_bytes.decode("utf-8"), module_qn)
 
             # Perform data flow analysis if enabled
-            if language in ["python", "javascript", "typescript", "c"]:
+            if (
+                language in ["python", "javascript", "typescript", "c"]
+                and "data-flow" not in self.disabled_analyses
+            ):
                 self._analyze_data_flow(
                     file_path, source_bytes.decode("utf-8"), module_qn, language
                 )
 
             # Perform dependency analysis
-            if language in ["python", "javascript", "typescript", "c"]:
+            if (
+                language in ["python", "javascript", "typescript", "c"]
+                and "dependencies" not in self.disabled_analyses
+            ):
                 self._analyze_dependencies(
                     file_path, source_bytes.decode("utf-8"), module_qn, language
                 )
 
             # Perform security analysis
-            if language in ["python", "javascript", "typescript", "c"]:
+            if (
+                language in ["python", "javascript", "typescript", "c"]
+                and "security" not in self.disabled_analyses
+            ):
                 self._analyze_security(
                     file_path, source_bytes.decode("utf-8"), module_qn, language
                 )
 
             # Perform inheritance analysis
-            if language in ["python", "javascript", "typescript", "java", "cpp"]:
+            if (
+                language in ["python", "javascript", "typescript", "java", "cpp"]
+                and "inheritance" not in self.disabled_analyses
+            ):
                 self._analyze_inheritance(
                     file_path, source_bytes.decode("utf-8"), module_qn, language
                 )
 
-            # Perform Git analysis if available
-            if self.git_analyzer:
-                self._analyze_git_info(file_path, module_qn)
-
         except Exception as e:
             logger.error(f"Failed to parse or ingest {file_path}: {e}")
+            self.skipped_files[relative_path_str] = f"parse error: {e}"
+
+    def _is_generated(self, file_path: Path) -> bool:
+        """Whether a file is generated code (see generated_code.py)."""
+        if file_path not in self._generated:
+            relative_path = file_path.relative_to(self.repo_path).as_posix()
+            self._generated[file_path] = is_generated(
+                file_path, relative_path, self.generated_paths
+            )
+        return self._generated[file_path]
+
+    def _large_file_mode(self, file_path: Path) -> str | None:
+        """How a file above LARGE_FILE_BYTES is ingested, None for other files."""
+        try:
+            size = file_path.stat().st_size
+        except OSError:
+            return None  # Reported when the file is parsed
+        if size <= settings.LARGE_FILE_BYTES:
+            return None
+        if size > settings.MAX_PARSE_BYTES and self.large_file_mode == "declarations":
+            return "summarize"
+        return self.large_file_mode
+
+    def _ingest_large_file(self, file_path: Path, mode: str) -> None:
+        relative_path = file_path.relative_to(self.repo_path)
+        try:
+            summary = summarize_file(file_path)
+        except OSError as e:
+            logger.error(f"Failed to read {file_path}: {e}")
+            self.skipped_files[str(relative_path)] = f"read error: {e}"
+            return
+        logger.info(
+            f"Not parsing {relative_path} ({summary.size_bytes} bytes, "
+            f"LARGE_FILE_MODE={mode})"
+        )
+        self.skipped_files[str(relative_path)] = (
+            f"larger than {settings.LARGE_FILE_BYTES} bytes"
+            + (", summarized" if mode == "summarize" else "")
+        )
+        if mode == "skip":
+            return
+        module_qn = self._module_qualified_name(relative_path)
+        self.ingestor.ensure_node_batch(
+            "Module",
+            {
+                "qualified_name": module_qn,
+                "name": file_path.name,
+                "path": str(relative_path),
+                "size_bytes": summary.size_bytes,
+                "line_count": summary.line_count,
+                "generated": summary.generated,
+                "summarized": True,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            self._module_parent(relative_path),
+            "CONTAINS_MODULE",
+            ("Module", "qualified_name", module_qn),
+        )
+
+    def _module_parent(self, relative_path: Path) -> tuple[str, str, str]:
+        """The Package, Folder or Project containing a module."""
+        parent_rel_path = relative_path.parent
+        parent_container_qn = self.structural_elements.get(parent_rel_path)
+        if parent_container_qn:
+            return ("Package", "qualified_name", parent_container_qn)
+        if parent_rel_path != Path():
+            return ("Folder", "path", str(parent_rel_path))
+        return ("Project", "name", self.project_name)
+
+    def _lookup_cached(
+        self,
+        file_path: Path,
+        language: str,
+        parsed: Future[ParsedSource] | None = None,
+    ) -> tuple[str | None, CachedFile | None]:
+        """
+        The parse cache key of a file and its cached extraction, if any. The
+        key is None for files that are always parsed, and when there is no
+        cache. Private runs are not cached: replaying an extraction would
+        redact it a second time.
+        """
+        if (
+            self.parse_cache is None
+            or self.ingestor.redactor is not None
+            or language in UNCACHED_LANGUAGES
+            or language not in self.queries
+            or TestDetector().is_test_file(str(file_path), language)
+        ):
+            return None, None
+        try:
+            source = parsed.result().source if parsed else file_path.read_bytes()
+        except Exception:
+            return None, None  # Reported when the file is parsed
+        relative_path = str(file_path.relative_to(self.repo_path))
+        parent = self.structural_elements.get(Path(relative_path).parent)
+        key = ParseCache.key(
+            source,
+            relative_path,
+            language,
+            str(parent),
+            self._layout(),
+            self._go_modules() if language == "go" else "",
+            ",".join(sorted(self.disabled_analyses)),
+            ",".join(self.generated_paths),
+            self._redaction(),
+        )
+        return key, self.parse_cache.load(relative_path, key)
+
+    def _redaction(self) -> str:
+        """How stored text is redacted: not at all, hashed, or keyed."""
+        redactor = self.ingestor.redactor
+        return redactor.fingerprint if redactor is not None else ""
+
+    def _layout(self) -> str:
+        """
+        A hash of the packages, folders and top-level names of the repository,
+        which a file's parents and imports are resolved against.
+        """
+        if self._layout_hash is None:
+            entries = [f"{path}:{qn}" for path, qn in self.structural_elements.items()]
+            entries += os.listdir(self.repo_path)
+            self._layout_hash = hashlib.sha256(
+                "\n".join(sorted(entries)).encode()
+            ).hexdigest()
+        return self._layout_hash
+
+    def _go_modules(self) -> str:
+        """The Go modules of the repository and what they require."""
+        return "\n".join(
+            f"{directory}:{go_mod.module}:"
+            + ",".join(sorted(r.path for r in go_mod.requires))
+            for directory, go_mod in sorted(self.go_mod_files.items())
+        )
+
+    def _replay_cached_file(
+        self, cached: CachedFile, module_qn: str, language: str
+    ) -> None:
+        for label, properties in cached.nodes:
+            self.ingestor.ensure_node_batch(label, properties)
+            if label in ("Function", "Method"):
+                qualified_name = properties["qualified_name"]
+                self.function_registry[qualified_name] = label
+                self.simple_name_lookup[qualified_name.split(".")[-1]].add(
+                    qualified_name
+                )
+        for from_node, rel_type, to_node, properties in cached.relationships:
+            self.ingestor.ensure_relationship_batch(
+                from_node, rel_type, to_node, properties
+            )
+        self.pending_endpoints.extend(cached.endpoints)
+        if cached.dependencies:
+            self.module_dependencies[module_qn].update(cached.dependencies)
+        if language == "go":
+            return  # Spans and calls come from parsing it again
+        self.function_spans[module_qn].extend(cached.spans)
+        self.cached_calls.append((module_qn, cached.calls))
+
+    def _resolve_cached_calls(self) -> None:
+        """CALLS edges of replayed files, resolved like those of parsed ones."""
+        for module_qn, calls in self.cached_calls:
+            self.link_calls(module_qn, calls)
+        self.cached_calls.clear()
+
+    def link_calls(self, module_qn: str, calls: list[tuple[str, str, str]]) -> int:
+        """
+        CALLS edges for calls made in a module, given as (caller label,
+        caller qn, called name); returns how many were resolved.
+        """
+        linked = 0
+        for caller_type, caller_qn, call_name in calls:
+            callee_info = self._resolve_function_call(call_name, module_qn)
+            if callee_info is None:
+                if self.unresolved_calls is not None:
+                    self.unresolved_calls.append(
+                        (module_qn, caller_type, caller_qn, call_name)
+                    )
+                continue
+            callee_type, callee_qn = callee_info
+            self.ingestor.ensure_relationship_batch(
+                (caller_type, "qualified_name", caller_qn),
+                "CALLS",
+                (callee_type, "qualified_name", callee_qn),
+            )
+            linked += 1
+        return linked
 
     def _ingest_top_level_functions(
         self, root_node: Node, module_qn: str, language: str
@@ -392,13 +1593,29 @@ class GraphUpdater:
             props: dict[str, Any] = {
                 "qualified_name": func_qn,
                 "name": func_name,
-                "decorators": [],
+                "decorators": self._get_decorators(func_node),
                 "start_line": func_node.start_point[0] + 1,
                 "end_line": func_node.end_point[0] + 1,
-                "docstring": self._get_docstring(func_node),
+                "docstring": self._get_docstring(func_node, language),
+                "cyclomatic_complexity": calculate_cyclomatic_complexity(
+                    func_node, language
+                ),
+                "parameter_count": count_parameters(func_node),
+                "max_nesting_depth": calculate_max_nesting_depth(func_node, language),
+                "lines_of_code": count_lines_of_code(func_node),
+                **body_fingerprint(func_node),
             }
+            props["calls_panic"], props["has_recover"] = (
+                detect_panic_and_recover(func_node)
+                if language == "go"
+                else (False, False)
+            )
             logger.info(f"  Found Function: {func_name} (qn: {func_qn})")
             self.ingestor.ensure_node_batch("Function", props)
+            self._ingest_chunks("Function", func_qn, func_node)
+            self.function_spans[module_qn].append(
+                (props["start_line"], props["end_line"], "Function", func_qn)
+            )
 
             self.function_registry[func_qn] = "Function"
             self.simple_name_lookup[func_name].add(func_qn)
@@ -500,10 +1717,11 @@ class GraphUpdater:
             class_props: dict[str, Any] = {
                 "qualified_name": class_qn,
                 "name": class_name,
-                "decorators": [],
+                "decorators": self._get_decorators(class_node),
                 "start_line": class_node.start_point[0] + 1,
                 "end_line": class_node.end_point[0] + 1,
-                "docstring": self._get_docstring(class_node),
+                "docstring": self._get_docstring(class_node, language),
+                "field_count": count_class_fields(class_node, language),
             }
             logger.info(f"  Found Class: {class_name} (qn: {class_qn})")
             self.ingestor.ensure_node_batch("Class", class_props)
@@ -533,13 +1751,36 @@ class GraphUpdater:
                 method_props: dict[str, Any] = {
                     "qualified_name": method_qn,
                     "name": method_name,
-                    "decorators": [],
+                    "decorators": self._get_decorators(method_node),
                     "start_line": method_node.start_point[0] + 1,
                     "end_line": method_node.end_point[0] + 1,
-                    "docstring": self._get_docstring(method_node),
+                    "docstring": self._get_docstring(method_node, language),
+                    "cyclomatic_complexity": calculate_cyclomatic_complexity(
+                        method_node, language
+                    ),
+                    "parameter_count": count_parameters(method_node),
+                    "max_nesting_depth": calculate_max_nesting_depth(
+                        method_node, language
+                    ),
+                    "lines_of_code": count_lines_of_code(method_node),
+                    **body_fingerprint(method_node),
                 }
+                method_props["calls_panic"], method_props["has_recover"] = (
+                    detect_panic_and_recover(method_node)
+                    if language == "go"
+                    else (False, False)
+                )
                 logger.info(f"    Found Method: {method_name} (qn: {method_qn})")
                 self.ingestor.ensure_node_batch("Method", method_props)
+                self._ingest_chunks("Method", method_qn, method_node)
+                self.function_spans[module_qn].append(
+                    (
+                        method_props["start_line"],
+                        method_props["end_line"],
+                        "Method",
+                        method_qn,
+                    )
+                )
 
                 self.function_registry[method_qn] = "Method"
                 self.simple_name_lookup[method_name].add(method_qn)
@@ -550,27 +1791,1495 @@ class GraphUpdater:
                     ("Method", "qualified_name", method_qn),
                 )
 
-    def _parse_dependencies(self, filepath: Path) -> None:
-        logger.info(f"  Parsing pyproject.toml: {filepath}")
-        try:
-            data = toml.load(filepath)
-            deps = (data.get("tool", {}).get("poetry", {}).get("dependencies", {})) or {
-                dep.split(">=")[0].split("==")[0].strip(): dep
-                for dep in data.get("project", {}).get("dependencies", [])
-            }
-            for dep_name, dep_spec in deps.items():
-                if dep_name.lower() == "python":
+    def _ingest_go_types(self, root_node: Node, module_qn: str) -> None:
+        """Ingest Go named types: interfaces as Interface nodes, others as Class."""
+        types = collect_go_types(root_node)
+        for go_type in types:
+            type_qn = f"{module_qn}.{go_type.name}"
+            if go_type.is_interface:
+                label = "Interface"
+                props: dict[str, Any] = {
+                    "qualified_name": type_qn,
+                    "name": go_type.name,
+                    "start_line": go_type.start_line,
+                    "end_line": go_type.end_line,
+                    "method_count": len(go_type.methods),
+                    "is_external": False,
+                }
+            else:
+                # The same properties as the classes of other languages
+                label = "Class"
+                props = {
+                    "qualified_name": type_qn,
+                    "name": go_type.name,
+                    "decorators": [],
+                    "start_line": go_type.start_line,
+                    "end_line": go_type.end_line,
+                    "docstring": None,
+                    "field_count": go_type.field_count,
+                }
+            self.ingestor.ensure_node_batch(label, props)
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "DEFINES",
+                (label, "qualified_name", type_qn),
+            )
+        self.go_method_sets.add_file(
+            module_qn.rsplit(".", 1)[0],
+            module_qn,
+            types,
+            collect_go_methods(root_node),
+        )
+        source = root_node.text.decode("utf-8", errors="replace")
+        if "grpc.ServiceDesc" in source:
+            self._ingest_grpc_services(collect_grpc_services(source), module_qn)
+
+    def _ingest_go_documentation(
+        self, root_node: Node, module_qn: str, relative_path: Path
+    ) -> None:
+        """
+        Create a Documentation node per doc comment of a Go file, with a
+        DOCUMENTS edge to the function, method or type it describes, or to the
+        package's directory for the package doc.
+        """
+        for doc in collect_go_docs(root_node):
+            if doc.kind == "package":
+                doc_qn = f"{module_qn}:doc"
+                target = self._module_parent(relative_path)
+            else:
+                symbol_qn = f"{module_qn}.{doc.name}"
+                if doc.kind == "type":
+                    label = "Interface" if doc.is_interface else "Class"
+                elif (label := self.function_registry.get(symbol_qn)) is None:
                     continue
-                logger.info(f"    Found dependency: {dep_name} (spec: {dep_spec})")
-                self.ingestor.ensure_node_batch("ExternalPackage", {"name": dep_name})
-                self.ingestor.ensure_relationship_batch(
-                    ("Project", "name", self.project_name),
-                    "DEPENDS_ON_EXTERNAL",
-                    ("ExternalPackage", "name", dep_name),
-                    properties={"version_spec": str(dep_spec)},
-                )
-        except Exception as e:
-            logger.error(f"    Error parsing {filepath}: {e}")
+                doc_qn = f"{symbol_qn}:doc"
+                target = (label, "qualified_name", symbol_qn)
+            self.ingestor.ensure_node_batch(
+                "Documentation",
+                {
+                    "qualified_name": doc_qn,
+                    "kind": doc.kind,
+                    "name": doc.name,
+                    "text": doc.text,
+                    "path": relative_path.as_posix(),
+                    "start_line": doc.start_line,
+                    "end_line": doc.end_line,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "HAS_DOCUMENTATION",
+                ("Documentation", "qualified_name", doc_qn),
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Documentation", "qualified_name", doc_qn), "DOCUMENTS", target
+            )
+
+    def _ingest_grpc_services(
+        self, services: list[GrpcService], module_qn: str
+    ) -> None:
+        """
+        Create RpcMethod nodes for the services of a generated gRPC file, with
+        DECLARES_RPC edges from the client and server interfaces declaring them
+        and the ProtoService a .proto file, when ingested, defines too.
+        """
+        for service in services:
+            self.grpc_clients[f"{module_qn}.{service.client}"] = service
+            self.grpc_servers[f"{module_qn}.{service.server}"] = service
+            package, _, name = service.name.rpartition(".")
+            self.ingestor.ensure_node_batch(
+                "ProtoService",
+                {"qualified_name": service.name, "name": name, "package": package},
+            )
+            for method in [*service.methods, *service.streams]:
+                rpc = ("RpcMethod", "qualified_name", service.rpc_name(method))
+                self.ingestor.ensure_node_batch(
+                    "RpcMethod",
+                    {
+                        "qualified_name": service.rpc_name(method),
+                        "name": method,
+                        "service": service.name,
+                        "streaming": method in service.streams,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("ProtoService", "qualified_name", service.name), "HAS_RPC", rpc
+                )
+                for interface in (service.server, service.client):
+                    self.ingestor.ensure_relationship_batch(
+                        ("Interface", "qualified_name", f"{module_qn}.{interface}"),
+                        "DECLARES_RPC",
+                        rpc,
+                        {"method": method},
+                    )
+        logger.info(f"  Found {len(services)} gRPC services")
+
+    def _go_build_constraint(self, file_path: Path, source: bytes) -> str | None:
+        """A Go file's build constraint, or None when the build config excludes it."""
+        constraint = build_constraint(
+            file_path.name, source.decode("utf-8", errors="replace")
+        )
+        if not constraint or self.build_config is None:
+            return constraint
+        try:
+            if self.build_config.satisfies(constraint):
+                return constraint
+        except ValueError as e:
+            logger.warning(f"{e} in {file_path}, parsing the file anyway")
+            return constraint
+        relative_path = str(file_path.relative_to(self.repo_path))
+        self.skipped_files[relative_path] = f"not built for {self.build_config}"
+        return None
+
+    def _ingest_chunks(self, label: str, qualified_name: str, node: Node) -> None:
+        """Split a body too long to embed whole into HAS_CHUNK-linked chunks."""
+        for chunk in node_chunks(node, qualified_name):
+            self.ingestor.ensure_node_batch("Chunk", chunk)
+            self.ingestor.ensure_relationship_batch(
+                (label, "qualified_name", qualified_name),
+                "HAS_CHUNK",
+                ("Chunk", "qualified_name", chunk["qualified_name"]),
+            )
+
+    def _ingest_build_constraint(self, module_qn: str, constraint: str) -> None:
+        goos, goarch, tags = constraint_terms(constraint)
+        try:
+            allowed = platforms(constraint)
+        except ValueError as e:
+            logger.warning(f"{e} in {module_qn}")
+            allowed = []
+        self.ingestor.ensure_node_batch(
+            "BuildConstraint",
+            {
+                "expression": constraint,
+                "goos": goos,
+                "goarch": goarch,
+                "tags": tags,
+                "platforms": allowed,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            ("Module", "qualified_name", module_qn),
+            "HAS_BUILD_CONSTRAINT",
+            ("BuildConstraint", "expression", constraint),
+        )
+        self.go_build_constraints[module_qn] = constraint
+
+    def _ingest_go_directives(
+        self, file_path: Path, source: bytes, module_qn: str
+    ) -> None:
+        """
+        Create Directive nodes for //go:generate and //go:embed lines, with
+        EMBEDS edges to the files embedded and GENERATES edges to the files of
+        the directory whose generated-code header names the command's program.
+        """
+        directives = collect_directives(source.decode("utf-8", errors="replace"))
+        generated: dict[str, str] = {}
+        if any(directive.kind == "generate" for directive in directives):
+            for sibling in sorted(file_path.parent.glob("*.go")):
+                if sibling == file_path:
+                    continue
+                try:
+                    header = generated_by(sibling.read_text(encoding="utf-8"))
+                except (OSError, UnicodeDecodeError):
+                    continue
+                if header is not None:
+                    generated[self._relative_posix(sibling)] = header.lower()
+
+        for directive in directives:
+            directive_qn = f"{module_qn}:go:{directive.kind}:{directive.line_number}"
+            self.ingestor.ensure_node_batch(
+                "Directive",
+                {
+                    "qualified_name": directive_qn,
+                    "kind": directive.kind,
+                    "line_number": directive.line_number,
+                    "command": directive.command,
+                    "patterns": directive.patterns,
+                    "variable": directive.variable,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "HAS_DIRECTIVE",
+                ("Directive", "qualified_name", directive_qn),
+            )
+            if directive.kind == "embed":
+                targets = [
+                    (self._relative_posix(path), "EMBEDS")
+                    for path in embedded_files(file_path.parent, directive.patterns)
+                ]
+            else:
+                program = generator_program(directive.command).lower()
+                targets = [
+                    (path, "GENERATES")
+                    for path, header in generated.items()
+                    if program and program in header
+                ]
+            for path, rel_type in targets:
+                self.ingestor.ensure_relationship_batch(
+                    ("Directive", "qualified_name", directive_qn),
+                    rel_type,
+                    ("File", "path", path),
+                )
+
+    def _link_build_variants(self) -> None:
+        """
+        Create VARIANT_OF edges between Go functions of one package that share
+        a name but live in files built for different configurations, such as
+        open in file_linux.go and in file_windows.go.
+        """
+        variants: dict[tuple[str, str], list[str]] = defaultdict(list)
+        for qn in self.function_registry:
+            module_qn, _, name = qn.rpartition(".")
+            if module_qn in self.go_build_constraints:
+                variants[(module_qn.rsplit(".", 1)[0], name)].append(qn)
+        for qns in variants.values():
+            qns.sort()
+            for index, qn in enumerate(qns):
+                for other_qn in qns[index + 1 :]:
+                    self.ingestor.ensure_relationship_batch(
+                        (self.function_registry[qn], "qualified_name", qn),
+                        "VARIANT_OF",
+                        (self.function_registry[other_qn], "qualified_name", other_qn),
+                    )
+
+    def _collect_go_package_facts(
+        self, root_node: Node, module_qn: str, relative_path: Path
+    ) -> None:
+        facts = self.go_packages.setdefault(
+            module_qn.rsplit(".", 1)[0], GoPackageFacts(relative_path.parent.as_posix())
+        )
+        for name, import_path in import_specs(root_node):
+            if name == "_":
+                facts.blank_imports.add(import_path)
+            else:
+                facts.imports.add(import_path)
+        if count := count_init_functions(root_node):
+            facts.init_files.append((relative_path.name, module_qn, count))
+
+    def _go_import_path(self, directory: str) -> str:
+        """The import path of a package directory, from its module's go.mod."""
+        path = Path(directory)
+        for module_dir in (path, *path.parents):
+            if module_dir in self.go_mod_files:
+                rest = path.relative_to(module_dir).as_posix()
+                module = self.go_mod_files[module_dir].module
+                return module if rest == "." else f"{module}/{rest}"
+        # Outside any module, as in a GOPATH tree, the directory is the path
+        return directory
+
+    def _link_go_initialization(self) -> None:
+        """
+        Create a PackageInit node for each Go package with init() functions,
+        RUNS edges to them in the order they run, and INIT_BEFORE edges from
+        the package inits that must complete before it, following imports.
+        """
+        packages = {
+            self._go_import_path(facts.directory): package_qn
+            for package_qn, facts in self.go_packages.items()
+        }
+        imports = {
+            import_path: (
+                self.go_packages[package_qn].imports
+                | self.go_packages[package_qn].blank_imports
+            )
+            & packages.keys()
+            for import_path, package_qn in packages.items()
+        }
+        order = {
+            path: index for index, path in enumerate(initialization_order(imports))
+        }
+        initializers = {
+            path for path, qn in packages.items() if self.go_packages[qn].init_files
+        }
+
+        for import_path in sorted(initializers):
+            package_qn = packages[import_path]
+            facts = self.go_packages[package_qn]
+            self.ingestor.ensure_node_batch(
+                "PackageInit",
+                {
+                    "qualified_name": f"{package_qn}:init",
+                    "package": import_path,
+                    "directory": facts.directory,
+                    "init_count": sum(count for _, _, count in facts.init_files),
+                    # -1 when the package is part of an import cycle
+                    "order": order.get(import_path, -1),
+                    "blank_imports": sorted(facts.blank_imports),
+                },
+            )
+            directory = Path(facts.directory)
+            container_qn = self.structural_elements.get(directory)
+            container = (
+                ("Package", "qualified_name", container_qn)
+                if container_qn
+                else (
+                    ("Folder", "path", str(directory))
+                    if directory != Path()
+                    else ("Project", "name", self.project_name)
+                )
+            )
+            self.ingestor.ensure_relationship_batch(
+                container,
+                "HAS_INIT",
+                ("PackageInit", "qualified_name", f"{package_qn}:init"),
+            )
+            # The go command compiles a package's files sorted by name
+            for index, (file_name, module_qn, count) in enumerate(
+                sorted(facts.init_files)
+            ):
+                self.ingestor.ensure_relationship_batch(
+                    ("PackageInit", "qualified_name", f"{package_qn}:init"),
+                    "RUNS",
+                    ("Function", "qualified_name", f"{module_qn}.init"),
+                    {"order": index, "file": file_name, "count": count},
+                )
+            for dependency in sorted(
+                nearest_initializers(import_path, imports, initializers)
+            ):
+                self.ingestor.ensure_relationship_batch(
+                    ("PackageInit", "qualified_name", f"{packages[dependency]}:init"),
+                    "INIT_BEFORE",
+                    ("PackageInit", "qualified_name", f"{package_qn}:init"),
+                )
+
+    def _ingest_go_modules(self) -> None:
+        """
+        Ingest the go.mod and go.work files of the repository: local modules,
+        the module versions they require (DEPENDS_ON), with go.sum checksums,
+        replacements and workspace members.
+        """
+        manifests = []
+        for root_str, dirs, files in os.walk(self.repo_path, topdown=True):
+            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
+            for name in ("go.mod", "go.work"):
+                if name in files:
+                    manifests.append(Path(root_str) / name)
+        for manifest in manifests:
+            if manifest.name != "go.mod":
+                continue
+            try:
+                go_mod = parse_go_mod_file(manifest.read_text(encoding="utf-8"))
+            except OSError as e:
+                logger.warning(f"Could not read {manifest}: {e}")
+                continue
+            if go_mod.module:
+                directory = manifest.parent.relative_to(self.repo_path)
+                self.go_mod_files[directory] = go_mod
+
+        for directory, go_mod in self.go_mod_files.items():
+            manifest_path = (directory / "go.mod").as_posix()
+            logger.info(f"  Found Go module {go_mod.module} in {manifest_path}")
+            self._ensure_go_module(go_mod.module, manifest_path, go_mod.go_version)
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", str(directory / "go.mod")),
+                "DECLARES_MODULE",
+                ("GoModule", "path", go_mod.module),
+            )
+            go_sum = self.repo_path / directory / "go.sum"
+            checksums = (
+                parse_go_sum(go_sum.read_text(encoding="utf-8"))
+                if go_sum.is_file()
+                else {}
+            )
+            for requirement in go_mod.requires:
+                version_qn = self._ensure_go_module_version(
+                    requirement.path,
+                    requirement.version,
+                    checksums.get((requirement.path, requirement.version), ""),
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("GoModule", "path", go_mod.module),
+                    "DEPENDS_ON",
+                    ("ModuleVersion", "qualified_name", version_qn),
+                    {"indirect": requirement.indirect},
+                )
+            for replacement in go_mod.replaces:
+                self._ingest_go_replacement(replacement, directory, manifest_path)
+
+        for manifest in manifests:
+            if manifest.name != "go.work":
+                continue
+            directory = manifest.parent.relative_to(self.repo_path)
+            try:
+                go_work = parse_go_work(manifest.read_text(encoding="utf-8"))
+            except OSError as e:
+                logger.warning(f"Could not read {manifest}: {e}")
+                continue
+            for use in go_work.uses:
+                member = self.go_mod_files.get(
+                    Path(os.path.normpath(directory / use))
+                )
+                if member is None:
+                    continue
+                self.ingestor.ensure_relationship_batch(
+                    ("File", "path", str(directory / "go.work")),
+                    "INCLUDES_MODULE",
+                    ("GoModule", "path", member.module),
+                )
+            for replacement in go_work.replaces:
+                self._ingest_go_replacement(
+                    replacement, directory, (directory / "go.work").as_posix()
+                )
+
+    def _ensure_go_module(
+        self, module_path: str, manifest: str = "", go_version: str = ""
+    ) -> None:
+        # Every node of a label needs the same keys to be batched together
+        self.ingestor.ensure_node_batch(
+            "GoModule",
+            {
+                "path": module_path,
+                "is_local": bool(manifest),
+                "manifest": manifest,
+                "go_version": go_version,
+            },
+        )
+
+    def _ensure_go_module_version(
+        self, module_path: str, version: str, checksum: str = ""
+    ) -> str:
+        version_qn = f"{module_path}@{version}"
+        if not self._is_local_go_module(module_path):
+            self._ensure_go_module(module_path)
+        self.ingestor.ensure_node_batch(
+            "ModuleVersion",
+            {
+                "qualified_name": version_qn,
+                "path": module_path,
+                "version": version,
+                "checksum": checksum,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            ("GoModule", "path", module_path),
+            "HAS_VERSION",
+            ("ModuleVersion", "qualified_name", version_qn),
+        )
+        return version_qn
+
+    def _is_local_go_module(self, module_path: str) -> bool:
+        # A module of another repository of the workspace has its node written
+        # by that repository's ingestion, which must not be overwritten here
+        if self.workspace and module_path in self.workspace.repositories:
+            return True
+        return any(module_path == m.module for m in self.go_mod_files.values())
+
+    def go_exports(self) -> dict[str, dict[str, str]]:
+        """
+        The exported functions of the repository's Go packages by import path,
+        {import path: {name: qn}}, for the repositories of a workspace after it.
+        """
+        packages = {
+            package_qn: self._go_import_path(facts.directory)
+            for package_qn, facts in self.go_packages.items()
+        }
+        exports: dict[str, dict[str, str]] = defaultdict(dict)
+        for qn, label in self.function_registry.items():
+            module_qn, _, name = qn.rpartition(".")
+            package_qn = module_qn.rpartition(".")[0]
+            if (
+                label == "Function"
+                and package_qn in packages
+                and name[:1].isupper()
+                and not module_qn.endswith("_test")
+            ):
+                exports[packages[package_qn]][name] = qn
+        return dict(exports)
+
+    def _ingest_go_replacement(
+        self, replacement: GoReplacement, directory: Path, manifest: str
+    ) -> None:
+        """A REPLACED_BY edge to the replacing version or local module."""
+        if replacement.is_local:
+            local = self.go_mod_files.get(
+                Path(os.path.normpath(directory / replacement.new_path))
+            )
+            if local is None:
+                return
+            target = ("GoModule", "path", local.module)
+        else:
+            target = (
+                "ModuleVersion",
+                "qualified_name",
+                self._ensure_go_module_version(
+                    replacement.new_path, replacement.new_version
+                ),
+            )
+        if not self._is_local_go_module(replacement.old_path):
+            self._ensure_go_module(replacement.old_path)
+        self.ingestor.ensure_relationship_batch(
+            ("GoModule", "path", replacement.old_path),
+            "REPLACED_BY",
+            target,
+            # Without a version, every version of the module is replaced
+            {"version": replacement.old_version, "manifest": manifest},
+        )
+
+    def _ingest_api_surface(
+        self, root_node: Node, module_qn: str, relative_path: str, language: str
+    ) -> None:
+        """
+        ApiSymbol nodes for the exported declarations of a non-test file, with
+        the normalized signatures `api-diff` compares between revisions.
+        """
+        if "api" in self.disabled_analyses or TestDetector().is_test_file(
+            relative_path, language
+        ):
+            return
+        for symbol in extract_api(root_node, relative_path, language):
+            # The symbol's own name is only unique within its repository
+            symbol_qn = f"{self.project_name}:{symbol.qualified_name}"
+            self.ingestor.ensure_node_batch(
+                "ApiSymbol",
+                {
+                    "qualified_name": symbol_qn,
+                    "symbol": symbol.qualified_name,
+                    "name": symbol.name,
+                    "kind": symbol.kind,
+                    "signature": symbol.signature,
+                    "path": symbol.path,
+                    "language": symbol.language,
+                    "members": symbol.members,
+                    "type_signature": symbol.type_signature,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "EXPOSES",
+                ("ApiSymbol", "qualified_name", symbol_qn),
+            )
+
+    def _ingest_go_imports(
+        self, root_node: Node, module_qn: str, relative_path: Path
+    ) -> None:
+        """
+        Create IMPORTS_MODULE edges from a Go file to the modules it uses, blank
+        imports, which run a package's init() only, included.
+        """
+        specs = import_specs(root_node)
+        go_mod = next(
+            (
+                self.go_mod_files[directory]
+                for directory in relative_path.parents
+                if directory in self.go_mod_files
+            ),
+            None,
+        )
+        local_modules = [m.module for m in self.go_mod_files.values()]
+        required = [r.path for r in go_mod.requires] if go_mod else []
+        dependencies = {
+            import_path: module_for_import(import_path, required) or ""
+            for _, import_path in specs
+            if not is_standard_library(import_path)
+            and not module_for_import(import_path, local_modules)
+        }
+        dot_imports = [
+            (import_path, dependencies.get(import_path, ""))
+            for name, import_path in specs
+            if name == "."
+        ]
+        if dot_imports:
+            self.go_dot_imports[module_qn] = dot_imports
+        else:
+            self.go_dot_imports.pop(module_qn, None)
+        self.go_file_imports[module_qn] = collect_go_imports(root_node)
+        if go_mod is None:
+            return
+        imports: dict[str, tuple[str, str]] = {}
+        for name, import_path in collect_go_imports(root_node).items():
+            if dependencies.get(import_path):
+                imports[name] = (import_path, dependencies[import_path])
+        self.go_imports[module_qn] = imports
+        packages: dict[str, list[str]] = defaultdict(list)
+        blank: dict[str, list[str]] = defaultdict(list)
+        for name, import_path in specs:
+            if dependency := dependencies.get(import_path):
+                packages[dependency].append(import_path)
+                if name == "_":
+                    blank[dependency].append(import_path)
+        for dependency, import_paths in packages.items():
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "IMPORTS_MODULE",
+                ("GoModule", "path", dependency),
+                {
+                    "packages": sorted(set(import_paths)),
+                    "blank": sorted(blank[dependency]),
+                },
+            )
+
+    def _ingest_go_module_uses(
+        self,
+        func_node: Node,
+        func_qn: str,
+        func_type: str,
+        module_qn: str,
+        calls_dot_imports: bool = False,
+    ) -> None:
+        """
+        Create USES_MODULE edges from a Go function to the modules it refers to,
+        and to those dot-imported when it calls names not declared locally.
+        """
+        imports = self.go_imports.get(module_qn, {})
+        packages: dict[str, list[str]] = defaultdict(list)
+        for name in referenced_packages(func_node, set(imports)):
+            import_path, dependency = imports[name]
+            packages[dependency].append(import_path)
+        if calls_dot_imports:
+            for import_path, dependency in self.go_dot_imports.get(module_qn, []):
+                if dependency:
+                    packages[dependency].append(import_path)
+        for dependency, import_paths in packages.items():
+            self.ingestor.ensure_relationship_batch(
+                (func_type, "qualified_name", func_qn),
+                "USES_MODULE",
+                ("GoModule", "path", dependency),
+                {"packages": sorted(import_paths)},
+            )
+
+    def _ingest_go_generics(self, root_node: Node, module_qn: str) -> None:
+        """Ingest the type parameters of generic Go functions and types."""
+        package_qn = module_qn.rsplit(".", 1)[0]
+        for definition in collect_generic_definitions(root_node):
+            definition_qn = f"{module_qn}.{definition.name}"
+            if definition.is_function:
+                label = "Function"
+            else:
+                label = "Interface" if definition.is_interface else "Class"
+            self.go_generic_definitions[package_qn][definition.name] = (
+                label,
+                definition_qn,
+            )
+            for parameter in definition.parameters:
+                parameter_qn = f"{definition_qn}.{parameter.name}"
+                self.ingestor.ensure_node_batch(
+                    "TypeParameter",
+                    {
+                        "qualified_name": parameter_qn,
+                        "name": parameter.name,
+                        "constraint": parameter.constraint,
+                        "position": parameter.position,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    (label, "qualified_name", definition_qn),
+                    "HAS_TYPE_PARAMETER",
+                    ("TypeParameter", "qualified_name", parameter_qn),
+                )
+                if parameter.constraint_name:
+                    self.pending_type_constraints.append(
+                        (parameter_qn, package_qn, parameter.constraint_name)
+                    )
+
+    def _ingest_go_package_variables(self, root_node: Node, module_qn: str) -> None:
+        """Ingest package-level `var` declarations of a Go file."""
+        package_qn = module_qn.rsplit(".", 1)[0]
+        for name, type_text in collect_package_variables(root_node):
+            var_qn = f"{module_qn}.{name}"
+            self.ingestor.ensure_node_batch(
+                "GlobalVariable",
+                {
+                    "qualified_name": var_qn,
+                    "name": name,
+                    "type": type_text,
+                    "is_static": False,
+                    "is_extern": False,
+                    "is_const": False,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "DEFINES_VARIABLE",
+                ("GlobalVariable", "qualified_name", var_qn),
+            )
+            self.go_package_variables[package_qn][name] = var_qn
+
+    def _ingest_todos(
+        self, file_path: Path, root_node: Node, module_qn: str, relative_path: str
+    ) -> None:
+        """Create Todo nodes linked to their enclosing function and blamed author."""
+        todos = TodoExtractor().extract(root_node)
+        if not todos:
+            return
+
+        blame_by_line = {}
+        if self.git_analyzer:
+            blame_by_line = {
+                blame.line_number: blame
+                for blame in self.git_analyzer.get_blame_info(str(file_path))
+            }
+
+        for todo in todos:
+            todo_qn = f"{module_qn}:{todo.line_number}"
+            blame = blame_by_line.get(todo.line_number)
+            self.ingestor.ensure_node_batch(
+                "Todo",
+                {
+                    "qualified_name": todo_qn,
+                    "kind": todo.kind,
+                    "text": todo.text[:500],
+                    "tag": todo.tag,
+                    "path": relative_path,
+                    "line_number": todo.line_number,
+                    "author": blame.author if blame else "",
+                    "author_email": blame.author_email if blame else "",
+                    "commit_sha": blame.commit_sha if blame else "",
+                    "created_at": blame.date.isoformat() if blame else "",
+                },
+            )
+
+            self.ingestor.ensure_relationship_batch(
+                self._enclosing_owner(module_qn, todo.line_number),
+                "HAS_TODO",
+                ("Todo", "qualified_name", todo_qn),
+            )
+
+            if blame:
+                # Same id scheme as the repository-level Contributor nodes
+                contributor_id = f"{blame.author}_{blame.author_email}".replace(" ", "_")
+                self.ingestor.ensure_node_batch(
+                    "Contributor",
+                    {
+                        "id": contributor_id,
+                        "name": blame.author,
+                        "email": blame.author_email,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("Todo", "qualified_name", todo_qn),
+                    "AUTHORED_BY",
+                    ("Contributor", "id", contributor_id),
+                )
+
+        logger.info(f"  Found {len(todos)} TODO comments")
+
+    def _ingest_issue_references(
+        self, root_node: Node, module_qn: str, relative_path: str
+    ) -> None:
+        """Create Issue nodes for tracker references in comments, e.g. "see #123"."""
+        references = IssueReferenceExtractor().extract(root_node)
+        for reference in references:
+            self.ingestor.ensure_node_batch(
+                "Issue",
+                {
+                    "key": reference.key,
+                    "tracker": reference.tracker,
+                    "number": reference.number,
+                    "project": reference.project,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                self._enclosing_owner(module_qn, reference.line_number),
+                "REFERENCES_ISSUE",
+                ("Issue", "key", reference.key),
+                {"path": relative_path, "line_number": reference.line_number},
+            )
+        if references:
+            logger.info(f"  Found {len(references)} issue references in comments")
+
+    def _enclosing_owner(
+        self, module_qn: str, line_number: int
+    ) -> tuple[str, str, str]:
+        """Return the innermost function or method containing a line, or the module."""
+        enclosing = [
+            span
+            for span in self.function_spans.get(module_qn, [])
+            if span[0] <= line_number <= span[1]
+        ]
+        if enclosing:
+            _, _, label, owner_qn = min(enclosing, key=lambda s: s[1] - s[0])
+            return (label, "qualified_name", owner_qn)
+        return ("Module", "qualified_name", module_qn)
+
+    def _ingest_log_statements(
+        self, root_node: Node, module_qn: str, relative_path: str, language: str
+    ) -> None:
+        """Create LogStatement nodes linked to their enclosing function."""
+        statements = LogExtractor(language).extract(root_node)
+        for statement in statements:
+            log_qn = f"{module_qn}:{statement.line_number}:{statement.column}"
+            self.ingestor.ensure_node_batch(
+                "LogStatement",
+                {
+                    "qualified_name": log_qn,
+                    "library": statement.library,
+                    "level": statement.level,
+                    "message": statement.message[:500],
+                    "call": statement.call,
+                    "path": relative_path,
+                    "line_number": statement.line_number,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                self._enclosing_owner(module_qn, statement.line_number),
+                "LOGS",
+                ("LogStatement", "qualified_name", log_qn),
+            )
+
+        if statements:
+            logger.info(f"  Found {len(statements)} logging calls")
+
+    def _ingest_http_endpoints(
+        self, relative_path: str, content: str, module_qn: str, language: str
+    ) -> None:
+        """Create Endpoint nodes for HTTP routes registered in a file."""
+        endpoints = EndpointDetector().detect(content, language)
+        for endpoint in endpoints:
+            endpoint_qn = f"{module_qn}:{endpoint.method} {endpoint.route}"
+            self.ingestor.ensure_node_batch(
+                "Endpoint",
+                {
+                    "qualified_name": endpoint_qn,
+                    "method": endpoint.method,
+                    "route": endpoint.route,
+                    "framework": endpoint.framework,
+                    "handler": endpoint.handler or "",
+                    "path": relative_path,
+                    "line_number": endpoint.line_number,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "DEFINES_ENDPOINT",
+                ("Endpoint", "qualified_name", endpoint_qn),
+            )
+            if endpoint.handler:
+                self.pending_endpoints.append((endpoint_qn, module_qn, endpoint))
+
+        if endpoints:
+            logger.info(f"  Found {len(endpoints)} HTTP endpoints")
+
+    def _ingest_env_reads(self, content: str, module_qn: str, language: str) -> None:
+        """Create READS_ENV edges to the environment variables a file reads."""
+        reads = EnvReadDetector().detect(content, language)
+        for read in reads:
+            self.ingestor.ensure_node_batch("EnvVar", {"name": read.name})
+            self.ingestor.ensure_relationship_batch(
+                self._enclosing_owner(module_qn, read.line_number),
+                "READS_ENV",
+                ("EnvVar", "name", read.name),
+                {"line_number": read.line_number},
+            )
+
+    def _ingest_sql_queries(self, content: str, module_qn: str) -> None:
+        """Create READS_FROM and WRITES_TO edges to the tables a file queries."""
+        queries = SqlQueryDetector().detect(content)
+        for query in queries:
+            self._ingest_table_uses(
+                self._enclosing_owner(module_qn, query.line_number),
+                query.reads,
+                query.writes,
+                query.line_number,
+            )
+
+        if queries:
+            logger.info(f"  Found {len(queries)} SQL queries")
+
+    def _ingest_table_uses(
+        self,
+        owner: tuple[str, str, str],
+        reads: list[str],
+        writes: list[str],
+        line_number: int,
+    ) -> None:
+        # Tables no migration defines, managed by an ORM or another
+        # repository, are still tables the code shares with others
+        for rel_type, tables in (("READS_FROM", reads), ("WRITES_TO", writes)):
+            for table in tables:
+                self.ingestor.ensure_node_batch(
+                    "Table",
+                    {"qualified_name": table, "name": table.rpartition(".")[2]},
+                )
+                self.ingestor.ensure_relationship_batch(
+                    owner,
+                    rel_type,
+                    ("Table", "qualified_name", table),
+                    {"line_number": line_number},
+                )
+
+    def _link_http_endpoints(self) -> None:
+        """Link Endpoint nodes to their handler functions once all are registered."""
+        for endpoint_qn, module_qn, endpoint in self.pending_endpoints:
+            candidates = self.simple_name_lookup.get(endpoint.handler or "", set())
+            if not candidates:
+                continue
+            # Prefer a handler defined in the registering module
+            local = sorted(qn for qn in candidates if qn.startswith(f"{module_qn}."))
+            handler_qn = local[0] if local else sorted(candidates)[0]
+            self.ingestor.ensure_relationship_batch(
+                ("Endpoint", "qualified_name", endpoint_qn),
+                "HANDLED_BY",
+                (self.function_registry[handler_qn], "qualified_name", handler_qn),
+            )
+            self.http_routes[
+                (endpoint.method, normalize_route(endpoint.route))
+            ].append((endpoint_qn, handler_qn))
+        self.pending_endpoints.clear()
+
+    def _parse_openapi_file(self, file_path: Path) -> None:
+        """Create an Endpoint node for each operation of an OpenAPI spec."""
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            content = file_path.read_text(encoding="utf-8")
+        except (OSError, UnicodeDecodeError) as e:
+            logger.warning(f"Could not read {relative_path}: {e}")
+            return
+        spec = parse_openapi(content, file_path.suffix)
+        if spec is None:
+            return
+        for operation in spec.operations:
+            route = spec.route(operation)
+            endpoint_qn = f"{relative_path}:{operation.method} {route}"
+            self.ingestor.ensure_node_batch(
+                "Endpoint",
+                {
+                    "qualified_name": endpoint_qn,
+                    "method": operation.method,
+                    "route": route,
+                    "framework": "openapi",
+                    "handler": operation.operation_id or "",
+                    "path": relative_path,
+                    "line_number": operation.line_number,
+                    "summary": operation.summary,
+                    "tags": operation.tags,
+                    "deprecated": operation.deprecated,
+                    "api_title": spec.title,
+                    "api_version": spec.version,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", relative_path),
+                "DEFINES_ENDPOINT",
+                ("Endpoint", "qualified_name", endpoint_qn),
+            )
+            self.pending_api_operations.append(
+                (
+                    endpoint_qn,
+                    operation,
+                    normalize_route(route),
+                    normalize_route(operation.path),
+                )
+            )
+        logger.info(
+            f"  Found {len(spec.operations)} operations in the {spec.title or 'API'} "
+            f"spec {relative_path}"
+        )
+
+    def _link_api_operations(self) -> None:
+        """
+        Link the operations of OpenAPI specs to the endpoints registered in
+        code for the same method and route (SPECIFIES) and to their handlers
+        (HANDLED_BY). Routes are tried with and without the spec's base path,
+        then as a suffix of it, for routers mounted under a prefix; a spec
+        operation with no matching route falls back to the function named by
+        its operationId, when exactly one is.
+        """
+        linked = 0
+        for endpoint_qn, operation, route, relative in self.pending_api_operations:
+            source = ("Endpoint", "qualified_name", endpoint_qn)
+            matches = self._registered_route(operation.method, route, relative)
+            handlers = [(handler_qn, "route") for _, handler_qn in matches]
+            for code_endpoint_qn, _ in matches:
+                self.ingestor.ensure_relationship_batch(
+                    source,
+                    "SPECIFIES",
+                    ("Endpoint", "qualified_name", code_endpoint_qn),
+                )
+            if not matches and operation.operation_id:
+                named = self._operation_handlers(operation.operation_id)
+                if len(named) == 1:
+                    handlers = [(named[0], "operation_id")]
+            for handler_qn, matched_by in handlers:
+                self.ingestor.ensure_relationship_batch(
+                    source,
+                    "HANDLED_BY",
+                    (self.function_registry[handler_qn], "qualified_name", handler_qn),
+                    {"matched_by": matched_by},
+                )
+            linked += bool(handlers)
+        self.pending_api_operations.clear()
+        if linked:
+            logger.info(f"  Linked {linked} API operations to their handlers")
+
+    def _registered_route(
+        self, method: str, route: str, relative: str
+    ) -> list[tuple[str, str]]:
+        """The code endpoints and handlers serving a spec operation's route."""
+        for candidate in (route, relative):
+            for verb in (method, "ANY"):
+                if matches := self.http_routes.get((verb, candidate)):
+                    return matches
+        # A router group registers its routes without the group's prefix
+        suffixes = sorted(
+            (
+                (len(registered), registered, verb)
+                for verb, registered in self.http_routes
+                if verb in (method, "ANY")
+                and registered != "/"
+                and route.endswith(registered)
+            ),
+            reverse=True,
+        )
+        if not suffixes or (len(suffixes) > 1 and suffixes[0][0] == suffixes[1][0]):
+            return []
+        _, registered, verb = suffixes[0]
+        return self.http_routes[(verb, registered)]
+
+    def _operation_handlers(self, operation_id: str) -> list[str]:
+        """Functions named by an operationId: createOrder, CreateOrder, create_order."""
+        snake = re.sub(r"(?<=[a-z0-9])(?=[A-Z])", "_", operation_id).lower()
+        names = {operation_id, operation_id[:1].upper() + operation_id[1:], snake}
+        return sorted(
+            qn for name in names for qn in self.simple_name_lookup.get(name, set())
+        )
+
+    def _link_go_test_targets(self) -> None:
+        """
+        Link Go benchmarks (EXERCISES) and examples (DOCUMENTS) to the function
+        they are named after, or else to the first package function they call.
+        """
+        # Functions outside _test files, by the package (directory) defining them
+        by_package: dict[str, list[str]] = defaultdict(list)
+        if self.pending_go_targets:
+            for qn in self.function_registry:
+                module = qn.rsplit(".", 1)[0]
+                if not module.endswith("_test"):
+                    by_package[module.rsplit(".", 1)[0]].append(qn)
+        for test_qn, module_qn, kind, calls in self.pending_go_targets:
+            local = by_package.get(module_qn.rsplit(".", 1)[0], [])
+            # Go methods are qualified by module, not receiver, so T_M matches M
+            named = go_test_target(test_qn.rsplit(".", 1)[1])[-1:]
+            target_qn = None
+            for name in [*named, *calls]:
+                matches = sorted(qn for qn in local if qn.endswith(f".{name}"))
+                if matches:
+                    target_qn = matches[0]
+                    break
+            if target_qn is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("TestFunction", "qualified_name", test_qn),
+                "EXERCISES" if kind == "benchmark" else "DOCUMENTS",
+                (self.function_registry[target_qn], "qualified_name", target_qn),
+            )
+        self.pending_go_targets.clear()
+
+    def _link_go_implementations(self) -> None:
+        """
+        Create IMPLEMENTS edges from Go types to the interfaces, in the
+        repository or common standard library ones, whose methods they have.
+        """
+        implementations = list(self.go_method_sets.implementations())
+        for interface_qn in sorted(
+            {i.interface_qn for i in implementations} & STDLIB_INTERFACES.keys()
+        ):
+            self.ingestor.ensure_node_batch(
+                "Interface",
+                {
+                    "qualified_name": interface_qn,
+                    "name": interface_qn.rsplit(".", 1)[-1],
+                    "start_line": 0,
+                    "end_line": 0,
+                    "method_count": len(STDLIB_INTERFACES[interface_qn]),
+                    "is_external": True,
+                },
+            )
+        for implementation in implementations:
+            self.ingestor.ensure_relationship_batch(
+                ("Class", "qualified_name", implementation.type_qn),
+                "IMPLEMENTS",
+                ("Interface", "qualified_name", implementation.interface_qn),
+                {"pointer_receiver": implementation.pointer_receiver},
+            )
+            if implementation.interface_qn in self.grpc_servers:
+                self._link_grpc_handlers(
+                    implementation.type_qn,
+                    self.grpc_servers[implementation.interface_qn],
+                )
+        if implementations:
+            logger.info(f"  Found {len(implementations)} Go interface implementations")
+
+    def _link_grpc_handlers(self, type_qn: str, service: GrpcService) -> None:
+        """
+        Create HANDLES_RPC edges from the methods a gRPC server type declares
+        itself, and an IMPLEMENTS_SERVICE edge from the type; methods promoted
+        from the embedded Unimplemented server only return an error.
+        """
+        module_qn, type_name = type_qn.rsplit(".", 1)
+        package_qn = module_qn.rsplit(".", 1)[0]
+        if type_name.startswith("Unimplemented"):
+            return
+        self.ingestor.ensure_relationship_batch(
+            ("Class", "qualified_name", type_qn),
+            "IMPLEMENTS_SERVICE",
+            ("ProtoService", "qualified_name", service.name),
+        )
+        for method in [*service.methods, *service.streams]:
+            method_qn = self.go_method_sets.method_qns.get(
+                (package_qn, type_name, method)
+            )
+            if method_qn is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("Function", "qualified_name", method_qn),
+                "HANDLES_RPC",
+                ("RpcMethod", "qualified_name", service.rpc_name(method)),
+            )
+
+    def _link_proto_types(self) -> None:
+        """
+        Create the ACCEPTS, RETURNS and REFERENCES edges to the messages named
+        by RPCs and message fields, resolved as protoc resolves them.
+        """
+        known = self.proto_messages | self.proto_enums
+        linked = 0
+        for source, type_name, scope, rel_type, properties in self.pending_proto_types:
+            message_qn = resolve_type(type_name, scope, known)
+            # Enums, and messages of .proto files outside the repository
+            if message_qn not in self.proto_messages:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                source,
+                rel_type,
+                ("ProtoMessage", "qualified_name", message_qn),
+                properties or None,
+            )
+            linked += 1
+        self.pending_proto_types.clear()
+        if linked:
+            logger.info(f"  Linked {linked} protobuf message types")
+
+    def _link_go_generics(self) -> None:
+        """
+        Create CONSTRAINED_BY edges from type parameters to the interfaces
+        constraining them, and INSTANTIATES edges from the functions using
+        generic functions and types to their definitions.
+        """
+        external: set[str] = set()
+        for parameter_qn, package_qn, constraint in self.pending_type_constraints:
+            interface_qn = self.go_method_sets.lookup(package_qn, constraint)
+            if interface_qn is None:
+                # comparable, any, or one from another module (cmp.Ordered)
+                if constraint not in BUILTIN_CONSTRAINTS and "." not in constraint:
+                    continue
+                interface_qn = constraint
+                if constraint not in external:
+                    external.add(constraint)
+                    self.ingestor.ensure_node_batch(
+                        "Interface",
+                        {
+                            "qualified_name": constraint,
+                            "name": constraint.rsplit(".", 1)[-1],
+                            "start_line": 0,
+                            "end_line": 0,
+                            "method_count": 0,
+                            "is_external": True,
+                        },
+                    )
+            self.ingestor.ensure_relationship_batch(
+                ("TypeParameter", "qualified_name", parameter_qn),
+                "CONSTRAINED_BY",
+                ("Interface", "qualified_name", interface_qn),
+            )
+        self.pending_type_constraints.clear()
+
+        linked = 0
+        for label, qn, package_qn, use in self.pending_instantiations:
+            definition = self._resolve_generic(package_qn, use.name)
+            if definition is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                (label, "qualified_name", qn),
+                "INSTANTIATES",
+                (definition[0], "qualified_name", definition[1]),
+                {
+                    "type_arguments": ", ".join(use.type_arguments),
+                    "inferred": use.inferred,
+                    "line_number": use.line_number,
+                },
+            )
+            linked += 1
+        self.pending_instantiations.clear()
+        if linked:
+            logger.info(f"  Found {linked} Go generic instantiations")
+
+    def _resolve_generic(self, package_qn: str, name: str) -> tuple[str, str] | None:
+        """A generic definition named from a package, Map or slices.Map."""
+        qualifier, _, simple = name.rpartition(".")
+        if not qualifier:
+            return self.go_generic_definitions.get(package_qn, {}).get(name)
+        for other_qn, definitions in self.go_generic_definitions.items():
+            if other_qn.rsplit(".", 1)[-1] == qualifier and simple in definitions:
+                return definitions[simple]
+        return None
+
+    def _ingest_code_owners(self) -> None:
+        """
+        Create OWNED_BY edges from files and packages to the users and teams
+        owning them in the CODEOWNERS file and in per-directory OWNERS files.
+        """
+        codeowners_path = CodeOwnersParser.find(self.repo_path)
+        owners_tree = OwnersTree.find(self.repo_path, self.ignore_dirs)
+        if not codeowners_path and not owners_tree.files:
+            return
+        codeowners = CodeOwnersParser()
+        source = ""
+        if codeowners_path:
+            codeowners = CodeOwnersParser.from_file(codeowners_path)
+            source = str(codeowners_path.relative_to(self.repo_path))
+            logger.info(f"--- Ingesting code owners from {source} ---")
+        if owners_tree.files:
+            logger.info(
+                f"--- Ingesting code owners from {len(owners_tree.files)} "
+                "OWNERS files ---"
+            )
+
+        targets = [
+            (("Package", "qualified_name", package_qn), str(rel_path), True)
+            for rel_path, package_qn in self.structural_elements.items()
+            if package_qn and rel_path != Path()
+        ]
+        for root_str, dirs, files in os.walk(self.repo_path, topdown=True):
+            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
+            for file_name in files:
+                relative_filepath = str(
+                    (Path(root_str) / file_name).relative_to(self.repo_path)
+                )
+                file_node = ("File", "path", relative_filepath)
+                targets.append((file_node, relative_filepath, False))
+
+        edge_count = 0
+        for target, path, is_dir in targets:
+            posix_path = Path(path).as_posix()
+            rules = [
+                (source, rule) for rule in codeowners.rules_for(posix_path, is_dir)
+            ]
+            rules += [
+                (owners_file.path, rule)
+                for owners_file, rule in owners_tree.rules_for(posix_path, is_dir)
+            ]
+            for rule_source, rule in rules:
+                for owner in rule.owners:
+                    if owner.kind == "Team":
+                        self.ingestor.ensure_node_batch("Team", {"name": owner.name})
+                    else:
+                        self.ingestor.ensure_node_batch(
+                            "User", {"name": owner.name, "email": owner.email}
+                        )
+                    self.ingestor.ensure_relationship_batch(
+                        target,
+                        "OWNED_BY",
+                        (owner.kind, "name", owner.name),
+                        {
+                            "pattern": rule.pattern,
+                            "section": rule.section,
+                            "source": rule_source,
+                            "line_number": rule.line_number,
+                        },
+                    )
+                    edge_count += 1
+        logger.info(f"  Created {edge_count} ownership edges")
+
+    def _ingest_backstage_catalog(self) -> None:
+        """
+        Create Component, System and API nodes from Backstage catalog-info.yaml
+        files, linked to their owners, to each other and to the code below the
+        descriptor's directory.
+        """
+        parser = BackstageCatalogParser()
+        entities: list[CatalogEntity] = []
+        for root_str, dirs, files in os.walk(self.repo_path, topdown=True):
+            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
+            for file_name in files:
+                if file_name in CATALOG_FILE_NAMES:
+                    entities.extend(
+                        parser.parse_file(Path(root_str) / file_name, self.repo_path)
+                    )
+        if not entities:
+            return
+        logger.info(f"--- Ingesting {len(entities)} Backstage catalog entities ---")
+
+        declared = {entity.ref.ref for entity in entities}
+        for entity in entities:
+            self._ensure_catalog_node(entity.label, entity.ref.ref, entity)
+            node = (entity.label, "ref", entity.ref.ref)
+            if entity.owner:
+                owner_label = "User" if entity.owner.kind == "user" else "Team"
+                if owner_label == "Team":
+                    self.ingestor.ensure_node_batch("Team", {"name": entity.owner.ref})
+                else:
+                    self.ingestor.ensure_node_batch(
+                        "User", {"name": entity.owner.ref, "email": ""}
+                    )
+                self.ingestor.ensure_relationship_batch(
+                    (owner_label, "name", entity.owner.ref),
+                    "OWNS",
+                    node,
+                    {"source": entity.path},
+                )
+
+            related = [
+                ("PART_OF", entity.system),
+                *(("PROVIDES_API", api) for api in entity.provides_apis),
+                *(("CONSUMES_API", api) for api in entity.consumes_apis),
+                *(("DEPENDS_ON", dependency) for dependency in entity.depends_on),
+            ]
+            for rel_type, target in related:
+                if target is None or target.kind not in CATALOG_LABELS:
+                    continue  # Resources and other kinds are not modelled
+                target_label = CATALOG_LABELS[target.kind]
+                if target.ref not in declared:
+                    # Declared in another repository's catalog
+                    self._ensure_catalog_node(target_label, target.ref)
+                    declared.add(target.ref)
+                self.ingestor.ensure_relationship_batch(
+                    node, rel_type, (target_label, "ref", target.ref)
+                )
+
+            if entity.label == "Component":
+                directory = Path(entity.directory)
+                package_qn = self.structural_elements.get(directory)
+                code_node = (
+                    ("Project", "name", self.project_name)
+                    if directory == Path()
+                    else (
+                        ("Package", "qualified_name", package_qn)
+                        if package_qn
+                        else ("Folder", "path", str(directory))
+                    )
+                )
+                self.ingestor.ensure_relationship_batch(
+                    node, "IMPLEMENTED_IN", code_node
+                )
+            if entity.definition_path:
+                self.ingestor.ensure_relationship_batch(
+                    node, "DEFINED_IN", ("File", "path", entity.definition_path)
+                )
+
+    def _ensure_catalog_node(
+        self, label: str, ref: str, entity: CatalogEntity | None = None
+    ) -> None:
+        # Every node of a label needs the same keys to be batched together
+        self.ingestor.ensure_node_batch(
+            label,
+            {
+                "ref": ref,
+                "name": ref.rpartition("/")[2],
+                "title": entity.title if entity else "",
+                "description": entity.description if entity else "",
+                "type": entity.type if entity else "",
+                "lifecycle": entity.lifecycle if entity else "",
+                "tags": entity.tags if entity else [],
+                "path": entity.path if entity else "",
+            },
+        )
+
+    def _parse_dependencies(self, filepath: Path) -> None:
+        logger.info(f"  Parsing pyproject.toml: {filepath}")
+        try:
+            data = toml.load(filepath)
+            deps = (data.get("tool", {}).get("poetry", {}).get("dependencies", {})) or {
+                dep.split(">=")[0].split("==")[0].strip(): dep
+                for dep in data.get("project", {}).get("dependencies", [])
+            }
+            for dep_name, dep_spec in deps.items():
+                if dep_name.lower() == "python":
+                    continue
+                logger.info(f"    Found dependency: {dep_name} (spec: {dep_spec})")
+                self.ingestor.ensure_node_batch("ExternalPackage", {"name": dep_name})
+                self.ingestor.ensure_relationship_batch(
+                    ("Project", "name", self.project_name),
+                    "DEPENDS_ON_EXTERNAL",
+                    ("ExternalPackage", "name", dep_name),
+                    properties={"version_spec": str(dep_spec)},
+                )
+        except Exception as e:
+            logger.error(f"    Error parsing {filepath}: {e}")
+
+    def _ingest_cgo_preamble(
+        self, file_path: Path, root_node: Node, module_qn: str
+    ) -> None:
+        """
+        Parse the C preamble of a cgo file with the C parser and ingest its
+        functions as module_qn.C.name, on the lines of the Go file they are on.
+        """
+        self.cgo_functions.pop(module_qn, None)
+        preamble = cgo_preamble(root_node)
+        if preamble is None or "c" not in self.parsers:
+            return
+        c_parser = CParser(self.parsers["c"], self.queries["c"])
+        nodes, relationships = c_parser.parse_file(str(file_path), preamble.source)
+        offset = preamble.start_line - 1
+        functions: dict[str, str] = {}
+        for node in nodes:
+            if node.node_type != "function":
+                continue
+            func_qn = f"{module_qn}.C.{node.name}"
+            functions[node.name] = func_qn
+            start_line, end_line = node.start_line + offset, node.end_line + offset
+            self.ingestor.ensure_node_batch(
+                "Function",
+                {
+                    "qualified_name": func_qn,
+                    "name": node.name,
+                    "start_line": start_line,
+                    "end_line": end_line,
+                    "return_type": node.properties.get("return_type", "void"),
+                    "is_static": node.properties.get("is_static", False),
+                    "is_inline": node.properties.get("is_inline", False),
+                    "parameter_count": len(node.properties.get("parameters", [])),
+                },
+            )
+            # Not in simple_name_lookup: Go reaches them only as C.name
+            self.function_registry[func_qn] = "Function"
+            self.function_spans[module_qn].append(
+                (start_line, end_line, "Function", func_qn)
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", module_qn),
+                "DEFINES",
+                ("Function", "qualified_name", func_qn),
+            )
+        for source, rel_type, _, target in relationships:
+            if rel_type == "CALLS" and source in functions and target in functions:
+                self.ingestor.ensure_relationship_batch(
+                    ("Function", "qualified_name", functions[source]),
+                    "CALLS",
+                    ("Function", "qualified_name", functions[target]),
+                )
+        if functions:
+            self.cgo_functions[module_qn] = functions
+
+    def _ingest_cgo_calls(
+        self, func_node: Node, func_qn: str, func_type: str, module_qn: str
+    ) -> None:
+        """
+        Create CALLS_NATIVE edges from a Go function to the C functions it calls
+        as C.name: the file's preamble first, then the C files of its package
+        directory, which cgo compiles with it, then any unique C function.
+        """
+        calls = native_calls(func_node)
+        if not calls:
+            return
+        package_qn = module_qn.rsplit(".", 1)[0]
+        preamble = self.cgo_functions.get(module_qn, {})
+        for call in calls:
+            target_qn = preamble.get(call.name)
+            if target_qn is None:
+                candidates = sorted(self.c_functions.get(call.name, ()))
+                local = [qn for qn in candidates if qn.rsplit(".", 2)[0] == package_qn]
+                if local or len(candidates) == 1:
+                    target_qn = (local or candidates)[0]
+            if target_qn is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                (func_type, "qualified_name", func_qn),
+                "CALLS_NATIVE",
+                ("Function", "qualified_name", target_qn),
+                {"line_number": call.line_number},
+            )
 
     def _ingest_c_file(self, file_path: Path, content: str, module_qn: str) -> None:
         """Ingest C-specific nodes and relationships."""
@@ -580,6 +3289,25 @@ class GraphUpdater:
         c_parser = CParser(self.parsers["c"], self.queries["c"])
         nodes, relationships = c_parser.parse_file(str(file_path), content)
 
+        # Map function start lines to metrics and doc comments using the cached AST
+        metrics_by_line: dict[int, dict[str, Any]] = {}
+        docstring_by_line: dict[int, str | None] = {}
+        if file_path in self.ast_cache:
+            root_node = self.ast_cache[file_path][0]
+            captures = self.queries["c"]["functions"].captures(root_node)
+            for func_node in captures.get("function", []):
+                start_line = func_node.start_point[0] + 1
+                metrics_by_line[start_line] = {
+                    "cyclomatic_complexity": calculate_cyclomatic_complexity(
+                        func_node, "c"
+                    ),
+                    "parameter_count": count_parameters(func_node),
+                    "max_nesting_depth": calculate_max_nesting_depth(func_node, "c"),
+                    "lines_of_code": count_lines_of_code(func_node),
+                    **body_fingerprint(func_node),
+                }
+                docstring_by_line[start_line] = self._get_docstring(func_node, "c")
+
         # Ingest nodes
         for node in nodes:
             if node.node_type == "function":
@@ -594,10 +3322,22 @@ class GraphUpdater:
                         "return_type": node.properties.get("return_type", "void"),
                         "is_static": node.properties.get("is_static", False),
                         "is_inline": node.properties.get("is_inline", False),
+                        "cyclomatic_complexity": 1,
+                        "parameter_count": len(node.properties.get("parameters", [])),
+                        "max_nesting_depth": 0,
+                        "lines_of_code": node.end_line - node.start_line + 1,
+                        "calls_panic": False,
+                        "has_recover": False,
+                        **metrics_by_line.get(node.start_line, {}),
+                        "docstring": docstring_by_line.get(node.start_line),
                     },
                 )
                 self.function_registry[func_qn] = "Function"
                 self.simple_name_lookup[node.name].add(func_qn)
+                self.c_functions[node.name].add(func_qn)
+                self.function_spans[module_qn].append(
+                    (node.start_line, node.end_line, "Function", func_qn)
+                )
                 self.ingestor.ensure_relationship_batch(
                     ("Module", "qualified_name", module_qn),
                     "DEFINES",
@@ -852,7 +3592,28 @@ class GraphUpdater:
     def _process_function_calls(self) -> None:
         """Third pass: Process function calls using the cached ASTs."""
         for file_path, (root_node, language) in self.ast_cache.items():
-            self._process_calls_in_file(file_path, root_node, language)
+            with (
+                logger.contextualize(file=self._relative_posix(file_path)),
+                self._checkpointed("calls", file_path),
+            ):
+                self._process_calls_in_file(file_path, root_node, language)
+        self._resolve_cached_calls()
+
+    def _relative_posix(self, file_path: Path) -> str:
+        return file_path.relative_to(self.repo_path).as_posix()
+
+    def _module_qualified_name(self, relative_path: Path) -> str:
+        """
+        The project name, directories and file stem, or the package for an
+        __init__.py. Go package names are taken by dropping the last part, so
+        a Go stem stays one part: users_grpc.pb.go is the module users_grpc_pb.
+        """
+        if relative_path.name == "__init__.py":
+            return ".".join([self.project_name, *relative_path.parent.parts])
+        stem = relative_path.with_suffix("")
+        if relative_path.suffix == ".go":
+            stem = stem.with_name(stem.name.replace(".", "_"))
+        return ".".join([self.project_name, *stem.parts])
 
     def _process_calls_in_file(
         self, file_path: Path, root_node: Node, language: str
@@ -860,21 +3621,53 @@ class GraphUpdater:
         """Process function calls in a specific file using its cached AST."""
         relative_path = file_path.relative_to(self.repo_path)
         logger.debug(f"Processing calls in cached AST for: {relative_path}")
+        recorded = self.recorded_files.pop(file_path, None)
+        self.recorded_calls = recorded.calls if recorded else None
 
         try:
-            module_qn = ".".join(
-                [self.project_name] + list(relative_path.with_suffix("").parts)
-            )
-            if file_path.name == "__init__.py":
-                module_qn = ".".join(
-                    [self.project_name] + list(relative_path.parent.parts)
-                )
+            module_qn = self._module_qualified_name(relative_path)
 
             self._process_calls_in_functions(root_node, module_qn, language)
             self._process_calls_in_classes(root_node, module_qn, language)
+            if language == "go" and not file_path.name.endswith("_test.go"):
+                self._ingest_unchecked_errors(
+                    root_node, module_qn, relative_path.as_posix()
+                )
 
         except Exception as e:
             logger.error(f"Failed to process calls in {file_path}: {e}")
+            recorded = None
+        self.recorded_calls = None
+        if recorded and self.parse_cache:
+            self.parse_cache.store(str(relative_path), recorded)
+
+    def _ingest_unchecked_errors(
+        self, root_node: Node, module_qn: str, relative_path: str
+    ) -> None:
+        """Create UncheckedError nodes for Go calls whose error result is dropped."""
+        findings = find_unchecked_errors(root_node, self.go_error_functions)
+        for finding in findings:
+            finding_qn = f"{module_qn}:{finding.line_number}:{finding.column}"
+            self.ingestor.ensure_node_batch(
+                "UncheckedError",
+                {
+                    "qualified_name": finding_qn,
+                    "call": finding.call,
+                    "kind": finding.kind,
+                    "category": finding.category,
+                    "likelihood": finding.likelihood,
+                    "path": relative_path,
+                    "line_number": finding.line_number,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                self._enclosing_owner(module_qn, finding.line_number),
+                "HAS_UNCHECKED_ERROR",
+                ("UncheckedError", "qualified_name", finding_qn),
+            )
+
+        if findings:
+            logger.info(f"  Found {len(findings)} unchecked errors in {relative_path}")
 
     def _process_calls_in_functions(
         self, root_node: Node, module_qn: str, language: str
@@ -990,18 +3783,55 @@ class GraphUpdater:
 
         call_captures = calls_query.captures(caller_node)
         call_nodes = call_captures.get("call", [])
+        callees: set[tuple[str, str]] = set()
+        go_variables = variable_types(caller_node) if language == "go" else {}
+        go_scopes = GoScopes(caller_node) if language == "go" else None
+        calls_dot_imports = False
         for call_node in call_nodes:
             if not isinstance(call_node, Node):
                 continue
             call_name = self._get_call_target_name(call_node)
-            if not call_name:
+            if (
+                call_name
+                and go_scopes is not None
+                and go_scopes.lookup(call_node, call_name)
+            ):
+                # A local holding a function, not the package's of that name
+                continue
+            if call_name and module_qn in self.go_dot_imports:
+                callee_info = self._resolve_go_dot_import(call_name, module_qn)
+                if callee_info is None and call_name not in GO_BUILTINS:
+                    calls_dot_imports = True
+            elif call_name:
+                if self.recorded_calls is not None:
+                    self.recorded_calls.append((caller_type, caller_qn, call_name))
+                callee_info = None
+                if language == "go":
+                    callee_info = self._go_package_function(
+                        module_qn.rsplit(".", 1)[0], call_name
+                    )
+                callee_info = callee_info or self._resolve_function_call(
+                    call_name, module_qn
+                )
+                if callee_info is None and self.unresolved_calls is not None:
+                    self.unresolved_calls.append(
+                        (module_qn, caller_type, caller_qn, call_name)
+                    )
+            elif go_scopes is not None:
+                callee_info = self._resolve_go_selector_call(
+                    call_node, module_qn, go_scopes
+                )
+            else:
                 continue
-
-            callee_info = self._resolve_function_call(call_name, module_qn)
             if not callee_info:
                 continue
 
             callee_type, callee_qn = callee_info
+            callees.add(callee_info)
+            if callee_type == "Interface" and callee_qn in self.grpc_clients:
+                self._ingest_rpc_call(
+                    call_node, caller_qn, caller_type, self.grpc_clients[callee_qn]
+                )
             logger.debug(
                 f"      Found call from {caller_qn} to {call_name} (resolved as {callee_type}:{callee_qn})"
             )
@@ -1012,6 +3842,443 @@ class GraphUpdater:
                 (callee_type, "qualified_name", callee_qn),
             )
 
+        if language == "go":
+            self._ingest_go_concurrency(caller_node, caller_qn, caller_type, module_qn)
+            package_qn = module_qn.rsplit(".", 1)[0]
+            self.pending_instantiations.extend(
+                (caller_type, caller_qn, package_qn, instantiation)
+                for instantiation in collect_instantiations(caller_node)
+            )
+            self._ingest_go_module_uses(
+                caller_node, caller_qn, caller_type, module_qn, calls_dot_imports
+            )
+            self._ingest_go_function_values(
+                caller_node, caller_qn, caller_type, module_qn, go_variables
+            )
+            self._ingest_go_panic_flow(
+                caller_node, caller_qn, caller_type, module_qn, callees
+            )
+            self._ingest_cgo_calls(caller_node, caller_qn, caller_type, module_qn)
+
+    def _ingest_rpc_call(
+        self, call_node: Node, caller_qn: str, caller_type: str, service: GrpcService
+    ) -> None:
+        """Link a call through a generated gRPC client to the RPC it makes."""
+        function = call_node.child_by_field_name("function")
+        method = function.child_by_field_name("field") if function else None
+        if method is None or method.text is None:
+            return
+        name = method.text.decode("utf8")
+        if not service.has_rpc(name):
+            return
+        self.ingestor.ensure_relationship_batch(
+            (caller_type, "qualified_name", caller_qn),
+            "INVOKES_RPC",
+            ("RpcMethod", "qualified_name", service.rpc_name(name)),
+            {"line_number": call_node.start_point[0] + 1},
+        )
+
+    def _ingest_go_panic_flow(
+        self,
+        func_node: Node,
+        func_qn: str,
+        func_type: str,
+        module_qn: str,
+        callees: set[tuple[str, str]],
+    ) -> None:
+        """
+        Create PANICS edges to a PanicSite for each panic call, DEFERS edges to
+        deferred functions, and, when a deferred literal calls recover, RECOVERS
+        edges to the function's own panic sites and to every function it calls.
+        """
+        panics, defers = collect_panic_flow(func_node)
+        panic_qns = []
+        for panic in panics:
+            panic_qn = f"{func_qn}.panic:{panic.line_number}"
+            panic_qns.append(panic_qn)
+            self.ingestor.ensure_node_batch(
+                "PanicSite",
+                {
+                    "qualified_name": panic_qn,
+                    "line_number": panic.line_number,
+                    "value": panic.value,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                (func_type, "qualified_name", func_qn),
+                "PANICS",
+                ("PanicSite", "qualified_name", panic_qn),
+            )
+
+        for deferred in defers:
+            callee_info = (
+                self._resolve_function_call(deferred.callee, module_qn)
+                if deferred.callee
+                else None
+            )
+            if callee_info:
+                self.ingestor.ensure_relationship_batch(
+                    (func_type, "qualified_name", func_qn),
+                    "DEFERS",
+                    (callee_info[0], "qualified_name", callee_info[1]),
+                    {"line_number": deferred.line_number},
+                )
+
+        recover = next((deferred for deferred in defers if deferred.recovers), None)
+        if recover is None:
+            return
+        # A deferred recover absorbs panics of the function and of its callees
+        targets = [("PanicSite", panic_qn) for panic_qn in panic_qns]
+        targets.extend(sorted(callee for callee in callees if callee[1] != func_qn))
+        for target_type, target_qn in targets:
+            self.ingestor.ensure_relationship_batch(
+                (func_type, "qualified_name", func_qn),
+                "RECOVERS",
+                (target_type, "qualified_name", target_qn),
+                {"line_number": recover.line_number},
+            )
+
+    def _ingest_go_concurrency(
+        self, func_node: Node, func_qn: str, func_type: str, module_qn: str
+    ) -> None:
+        """
+        Create SPAWNS edges for `go` statements, WRITES edges to package vars
+        and SENDS_TO/RECEIVES_FROM edges to channels.
+        """
+        package_vars = self.go_package_variables.get(module_qn.rsplit(".", 1)[0], {})
+        facts = analyze_go_function(func_node, set(package_vars))
+        self._ingest_go_channel_operations(
+            func_node, func_qn, func_type, module_qn, facts
+        )
+
+        for name in facts.spawned:
+            callee_info = self._resolve_function_call(name, module_qn)
+            if callee_info:
+                callee_type, callee_qn = callee_info
+                self.ingestor.ensure_relationship_batch(
+                    (func_type, "qualified_name", func_qn),
+                    "SPAWNS",
+                    (callee_type, "qualified_name", callee_qn),
+                )
+
+        for name, in_goroutine in facts.writes.items():
+            self.ingestor.ensure_relationship_batch(
+                (func_type, "qualified_name", func_qn),
+                "WRITES",
+                ("GlobalVariable", "qualified_name", package_vars[name]),
+                {"in_goroutine": in_goroutine, "guarded": facts.guarded},
+            )
+            if func_type == "Function" and func_qn.endswith(".init"):
+                self.ingestor.ensure_relationship_batch(
+                    (func_type, "qualified_name", func_qn),
+                    "INITIALIZES",
+                    ("GlobalVariable", "qualified_name", package_vars[name]),
+                )
+
+    def _ingest_go_channels(self, root_node: Node, module_qn: str) -> None:
+        """Ingest channels held in package variables and struct fields."""
+        package_qn = module_qn.rsplit(".", 1)[0]
+        for channel in collect_package_channels(root_node):
+            if channel.scope == "field":
+                channel_qn = f"{module_qn}.{channel.owner}.{channel.name}"
+                self.go_field_channels[(package_qn, channel.owner)][
+                    channel.name
+                ] = channel_qn
+            else:
+                channel_qn = f"{module_qn}.{channel.name}"
+                self.go_package_channels[package_qn][channel.name] = channel_qn
+            self._ingest_channel(channel, channel_qn, ("Module", module_qn))
+
+    def _ingest_channel(
+        self, channel: GoChannel, channel_qn: str, owner: tuple[str, str]
+    ) -> None:
+        self.ingestor.ensure_node_batch(
+            "Channel",
+            {
+                "qualified_name": channel_qn,
+                "name": channel.name,
+                "scope": channel.scope,
+                "element_type": channel.element_type,
+                "direction": channel.direction,
+                "buffered": channel.buffered,
+                "line_number": channel.line_number,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            (owner[0], "qualified_name", owner[1]),
+            "DEFINES_CHANNEL",
+            ("Channel", "qualified_name", channel_qn),
+        )
+
+    def _ingest_go_channel_operations(
+        self,
+        func_node: Node,
+        func_qn: str,
+        func_type: str,
+        module_qn: str,
+        facts: GoFunctionConcurrency,
+    ) -> None:
+        """
+        Create SENDS_TO and RECEIVES_FROM edges from a Go function to the
+        channels it uses: its own locals and parameters, its receiver's
+        fields and package variables.
+        """
+        package_qn = module_qn.rsplit(".", 1)[0]
+        local: dict[str, str] = {}
+        for channel in collect_function_channels(func_node):
+            channel_qn = f"{func_qn}.{channel.name}"
+            local[channel.name] = channel_qn
+            if channel.parameter_index is not None:
+                self.go_parameter_channels[func_qn][channel.parameter_index] = (
+                    channel_qn
+                )
+            self._ingest_channel(channel, channel_qn, (func_type, func_qn))
+        receiver, receiver_type = receiver_of(func_node)
+        fields = self.go_field_channels.get((package_qn, receiver_type), {})
+        package_channels = self.go_package_channels.get(package_qn, {})
+
+        def resolve(expression: str, ranged: bool, line_number: int) -> str | None:
+            owner, _, name = expression.rpartition(".")
+            if owner:
+                return fields.get(name) if receiver and owner == receiver else None
+            if name in local:
+                return local[name]
+            if name in package_channels:
+                return package_channels[name]
+            if ranged:
+                # Ranging over a slice or map is not a receive
+                return None
+            # Only channels are sent to and received from, so a name used that
+            # way is a channel even when its declaration was not recognised
+            local[name] = f"{func_qn}.{name}"
+            self._ingest_channel(
+                GoChannel(name, "local", "", "both", False, line_number),
+                local[name],
+                (func_type, func_qn),
+            )
+            return local[name]
+
+        for rel_type, operations in (
+            ("SENDS_TO", facts.sends),
+            ("RECEIVES_FROM", facts.receives),
+        ):
+            for operation in operations:
+                channel_qn = resolve(
+                    operation.channel, operation.ranged, operation.line_number
+                )
+                if channel_qn is None:
+                    continue
+                self.ingestor.ensure_relationship_batch(
+                    (func_type, "qualified_name", func_qn),
+                    rel_type,
+                    ("Channel", "qualified_name", channel_qn),
+                    {
+                        "line_number": operation.line_number,
+                        "in_goroutine": operation.in_goroutine,
+                    },
+                )
+                self.go_channel_uses[channel_qn].append(
+                    (
+                        func_type,
+                        func_qn,
+                        rel_type,
+                        operation.line_number,
+                        operation.in_goroutine,
+                    )
+                )
+
+        for callee, index, argument in facts.arguments:
+            channel_qn = local.get(argument) or package_channels.get(argument)
+            if channel_qn:
+                self.pending_channel_arguments.append(
+                    (module_qn, callee, index, channel_qn)
+                )
+
+    def _link_channel_arguments(self) -> None:
+        """
+        Follow channels passed to functions and goroutines: a send or receive
+        on the callee's channel parameter also uses the caller's channel.
+        """
+        for module_qn, callee, index, channel_qn in self.pending_channel_arguments:
+            callee_info = self._resolve_function_call(callee, module_qn)
+            if not callee_info:
+                continue
+            parameter_qn = self.go_parameter_channels.get(callee_info[1], {}).get(
+                index
+            )
+            if parameter_qn is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("Channel", "qualified_name", channel_qn),
+                "PASSED_AS",
+                ("Channel", "qualified_name", parameter_qn),
+            )
+            for func_type, func_qn, rel_type, line_number, in_goroutine in (
+                self.go_channel_uses.get(parameter_qn, [])
+            ):
+                self.ingestor.ensure_relationship_batch(
+                    (func_type, "qualified_name", func_qn),
+                    rel_type,
+                    ("Channel", "qualified_name", channel_qn),
+                    {
+                        "line_number": line_number,
+                        "in_goroutine": in_goroutine,
+                        "via": parameter_qn.rsplit(".", 1)[1],
+                    },
+                )
+        self.pending_channel_arguments.clear()
+
+    def _ingest_go_function_values(
+        self,
+        func_node: Node,
+        func_qn: str,
+        func_type: str,
+        module_qn: str,
+        variables: dict[str, str],
+    ) -> None:
+        """
+        Create REFERENCES edges to the functions a Go function passes, stores
+        or returns as values, and CALLS edges where what calls them is known:
+        a standard library function such as sort.Slice, or the function itself
+        through a local. Values passed to functions of the repository wait for
+        _link_callback_arguments, once every function's parameters are read.
+        """
+        facts = collect_function_values(func_node)
+        if facts.called_parameters:
+            self.go_called_parameters[func_qn] = facts.called_parameters
+        source = (func_type, "qualified_name", func_qn)
+        for value in facts.values:
+            target = self._resolve_go_function_value(
+                value.name, module_qn, facts.local_names, variables
+            )
+            if target is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                source,
+                "REFERENCES",
+                (target[0], "qualified_name", target[1]),
+                {"line_number": value.line_number, "use": value.use},
+            )
+            via = self._go_value_caller(value, module_qn, facts, variables, target)
+            if via:
+                self.ingestor.ensure_relationship_batch(
+                    source,
+                    "CALLS",
+                    (target[0], "qualified_name", target[1]),
+                    {"synthetic": True, "via": via},
+                )
+
+    def _go_value_caller(
+        self,
+        value: FunctionValue,
+        module_qn: str,
+        facts: GoFunctionValues,
+        variables: dict[str, str],
+        target: tuple[str, str],
+    ) -> str | None:
+        """
+        What makes the function holding a value call it, if that is known now:
+        the standard library function it is passed to, or the local it is
+        assigned to and called through. A function of the repository it is
+        passed to is recorded for _link_callback_arguments instead.
+        """
+        if value.use == "assignment":
+            if value.target in facts.local_names and value.target in facts.called_names:
+                return value.target
+            return None
+        if value.use != "argument":
+            return None
+        package, _, name = value.callee.rpartition(".")
+        if package and package not in facts.local_names:
+            import_path = self.go_file_imports.get(module_qn, {}).get(package)
+            if SYNCHRONOUS_CALLBACKS.get((import_path or "", name)) == value.position:
+                return value.callee
+        callee = self._resolve_go_function_value(
+            value.callee, module_qn, facts.local_names, variables
+        )
+        if callee is not None:
+            self.pending_callback_arguments.append(
+                (callee, value.position, target, value.line_number)
+            )
+        return None
+
+    def _link_callback_arguments(self) -> None:
+        """
+        CALLS edges from functions of the repository to the functions passed
+        to them, where they call the parameter the function was passed as.
+        """
+        for callee, position, target, _ in self.pending_callback_arguments:
+            parameter = self.go_called_parameters.get(callee[1], {}).get(position)
+            if parameter is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                (callee[0], "qualified_name", callee[1]),
+                "CALLS",
+                (target[0], "qualified_name", target[1]),
+                {"synthetic": True, "via": parameter},
+            )
+        self.pending_callback_arguments.clear()
+
+    def _resolve_go_function_value(
+        self,
+        expression: str,
+        module_qn: str,
+        local_names: set[str],
+        variables: dict[str, str],
+    ) -> tuple[str, str] | None:
+        """
+        The function or method a Go expression names: a function of the
+        package, `pkg.F` of an imported package of the repository or the
+        workspace, or the method value `x.M` of a variable of known type.
+        Locals and parameters shadow the package's functions.
+        """
+        package_qn = module_qn.rsplit(".", 1)[0]
+        operand, _, name = expression.rpartition(".")
+        if not operand:
+            if name in local_names:
+                return None
+            return self._go_package_function(package_qn, name)
+        if "." in operand:
+            return None
+        if operand in variables:
+            owner = self.go_method_sets.method_owner(
+                package_qn, variables[operand], name
+            )
+            if owner is None or owner[1] not in self.function_registry:
+                return None
+            return self.function_registry[owner[1]], owner[1]
+        if operand in local_names:
+            return None
+        import_path = self.go_file_imports.get(module_qn, {}).get(operand)
+        if import_path is None:
+            return None
+        imported_qn = self._go_package_for_import(import_path)
+        if imported_qn is not None:
+            return self._go_package_function(imported_qn, name)
+        if self.workspace is not None:
+            callee_qn = self.workspace.function(import_path, name)
+            return ("Function", callee_qn) if callee_qn else None
+        return None
+
+    def _go_package_function(self, package_qn: str, name: str) -> tuple[str, str] | None:
+        """A function, not a method, that a Go package of the repository declares."""
+        matches = sorted(
+            qn
+            for qn in self.simple_name_lookup.get(name, ())
+            if qn.rsplit(".", 2)[0] == package_qn
+            and qn not in self.go_method_sets.declared_methods
+        )
+        return (self.function_registry[matches[0]], matches[0]) if matches else None
+
+    def _go_package_for_import(self, import_path: str) -> str | None:
+        """The qualified name of the repository's Go package with an import path."""
+        if len(self._go_packages_by_import) != len(self.go_packages):
+            self._go_packages_by_import = {
+                self._go_import_path(facts.directory): package_qn
+                for package_qn, facts in self.go_packages.items()
+            }
+        return self._go_packages_by_import.get(import_path)
+
     def _resolve_function_call(
         self, call_name: str, module_qn: str
     ) -> tuple[str, str] | None:
@@ -1040,6 +4307,120 @@ class GraphUpdater:
 
         return None
 
+    def _resolve_go_dot_import(
+        self, call_name: str, module_qn: str
+    ) -> tuple[str, str] | None:
+        """
+        Resolve a bare call in a Go file with dot imports: to a function of the
+        file's own package, else of a dot-imported package of the repository.
+        Names from other dot-imported packages are not guessed at elsewhere.
+        """
+        packages = [module_qn.rsplit(".", 1)[0]]
+        dot_imported = {path for path, _ in self.go_dot_imports[module_qn]}
+        packages.extend(
+            package_qn
+            for package_qn, facts in self.go_packages.items()
+            if self._go_import_path(facts.directory) in dot_imported
+        )
+        for package_qn in packages:
+            matches = sorted(
+                qn
+                for qn in self.simple_name_lookup.get(call_name, ())
+                if qn.rsplit(".", 2)[0] == package_qn
+            )
+            if matches:
+                return self.function_registry[matches[0]], matches[0]
+        return None
+
+    def _resolve_go_selector_call(
+        self, call_node: Node, module_qn: str, scopes: GoScopes
+    ) -> tuple[str, str] | None:
+        """
+        Resolve a Go `x.M()` call: a method call when x is a local in scope
+        there, else a call into the package the file imports as x, under its
+        alias if it has one, in the repository or another of the workspace.
+        """
+        variables = scopes.variables_at(call_node)
+        function = call_node.child_by_field_name("function")
+        operand = (
+            function.child_by_field_name("operand")
+            if function is not None and function.type == "selector_expression"
+            else None
+        )
+        if (
+            operand is not None
+            and operand.type == "identifier"
+            and operand.text is not None
+            and not scopes.lookup(call_node, operand.text.decode("utf8"))
+        ):
+            return self._resolve_go_package_call(
+                call_node, module_qn
+            ) or self._resolve_workspace_call(call_node, module_qn)
+        return self._resolve_go_method_call(call_node, module_qn, variables)
+
+    def _resolve_go_package_call(
+        self, call_node: Node, module_qn: str
+    ) -> tuple[str, str] | None:
+        """
+        Resolve a Go `pkg.F()` call to a function of the repository's package
+        that the file imports as pkg.
+        """
+        function = call_node.child_by_field_name("function")
+        operand = function.child_by_field_name("operand") if function else None
+        field = function.child_by_field_name("field") if function else None
+        if operand is None or field is None or operand.text is None:
+            return None
+        imports = self.go_file_imports.get(module_qn, {})
+        import_path = imports.get(operand.text.decode("utf8"))
+        package_qn = self._go_package_for_import(import_path) if import_path else None
+        if package_qn is None or field.text is None:
+            return None
+        return self._go_package_function(package_qn, field.text.decode("utf8"))
+
+    def _resolve_go_method_call(
+        self, call_node: Node, module_qn: str, variables: dict[str, str]
+    ) -> tuple[str, str] | None:
+        """
+        Resolve a Go `x.M()` call through the type of x to the method that
+        declares M, promoted ones included, or to the interface declaring it.
+        """
+        callee_info = self.go_method_sets.resolve_call(
+            module_qn.rsplit(".", 1)[0], call_node, variables
+        )
+        if callee_info is None:
+            return None
+        callee_type, callee_qn = callee_info
+        if callee_type == "Interface":
+            return callee_info
+        # Methods declared in files that were skipped have no node
+        if callee_qn not in self.function_registry:
+            return None
+        return self.function_registry[callee_qn], callee_qn
+
+    def _resolve_workspace_call(
+        self, call_node: Node, module_qn: str
+    ) -> tuple[str, str] | None:
+        """
+        Resolve a Go `pkg.F()` call to an exported function of a package that
+        another repository of the workspace declares.
+        """
+        if self.workspace is None:
+            return None
+        function = call_node.child_by_field_name("function")
+        if function is None or function.type != "selector_expression":
+            return None
+        operand = function.child_by_field_name("operand")
+        field = function.child_by_field_name("field")
+        if operand is None or field is None or operand.type != "identifier":
+            return None
+        if operand.text is None or field.text is None:
+            return None
+        imported = self.go_imports.get(module_qn, {}).get(operand.text.decode("utf8"))
+        if imported is None:
+            return None
+        callee_qn = self.workspace.function(imported[0], field.text.decode("utf8"))
+        return ("Function", callee_qn) if callee_qn else None
+
     # TODO: (VA) This is a hack to resolve function calls. We need to improve this.
     def _is_likely_same_function(
         self, call_name: str, registered_qn: str, caller_module_qn: str
@@ -1068,10 +4449,11 @@ class GraphUpdater:
         )
         nodes, relationships = test_parser.parse_test_file(str(file_path), content)
 
+        case_keys = {node.key for node in nodes if node.node_type == "test_case"}
         # Ingest test nodes
         for node in nodes:
             if node.node_type == "test_suite":
-                suite_qn = f"{module_qn}.{node.name}"
+                suite_qn = f"{module_qn}.{node.key}"
                 self.ingestor.ensure_node_batch(
                     "TestSuite",
                     {
@@ -1080,16 +4462,26 @@ class GraphUpdater:
                         "framework": node.properties.get("framework", ""),
                         "start_line": node.start_line,
                         "end_line": node.end_line,
+                        **node.stored_properties(),
                     },
                 )
-                self.ingestor.ensure_relationship_batch(
-                    ("Module", "qualified_name", module_qn),
-                    "CONTAINS_TEST_SUITE",
-                    ("TestSuite", "qualified_name", suite_qn),
-                )
+                parent_suite = node.properties.get("parent_suite")
+                if parent_suite:
+                    # Nested suites (Ginkgo Context in Describe) hang off their parent
+                    self.ingestor.ensure_relationship_batch(
+                        ("TestSuite", "qualified_name", f"{module_qn}.{parent_suite}"),
+                        "CONTAINS_SUITE",
+                        ("TestSuite", "qualified_name", suite_qn),
+                    )
+                else:
+                    self.ingestor.ensure_relationship_batch(
+                        ("Module", "qualified_name", module_qn),
+                        "CONTAINS_TEST_SUITE",
+                        ("TestSuite", "qualified_name", suite_qn),
+                    )
 
             elif node.node_type == "test_case":
-                test_qn = f"{module_qn}.{node.name}"
+                test_qn = f"{module_qn}.{node.key}"
                 self.ingestor.ensure_node_batch(
                     "TestCase",
                     {
@@ -1098,9 +4490,11 @@ class GraphUpdater:
                         "framework": node.properties.get("framework", ""),
                         "start_line": node.start_line,
                         "end_line": node.end_line,
+                        **node.stored_properties(),
                     },
                 )
                 parent_suite = node.properties.get("parent_suite")
+                parent_test = node.properties.get("parent_test")
                 if parent_suite:
                     parent_qn = f"{module_qn}.{parent_suite}"
                     self.ingestor.ensure_relationship_batch(
@@ -1108,6 +4502,17 @@ class GraphUpdater:
                         "CONTAINS_TEST",
                         ("TestCase", "qualified_name", test_qn),
                     )
+                elif parent_test:
+                    # Go subtests belong to their test function, suite method
+                    # or outer subtest
+                    parent_label = (
+                        "TestCase" if parent_test in case_keys else "TestFunction"
+                    )
+                    self.ingestor.ensure_relationship_batch(
+                        (parent_label, "qualified_name", f"{module_qn}.{parent_test}"),
+                        "CONTAINS_TEST",
+                        ("TestCase", "qualified_name", test_qn),
+                    )
                 else:
                     self.ingestor.ensure_relationship_batch(
                         ("Module", "qualified_name", module_qn),
@@ -1117,6 +4522,7 @@ class GraphUpdater:
 
             elif node.node_type == "test_function":
                 test_qn = f"{module_qn}.{node.name}"
+                kind = node.properties.get("test_type", "test")
                 self.ingestor.ensure_node_batch(
                     "TestFunction",
                     {
@@ -1125,8 +4531,16 @@ class GraphUpdater:
                         "framework": node.properties.get("framework", ""),
                         "start_line": node.start_line,
                         "end_line": node.end_line,
+                        "kind": kind,
                     },
                 )
+                if language == "go" and kind in ("benchmark", "example"):
+                    body = "\n".join(
+                        content.splitlines()[node.start_line : node.end_line]
+                    )
+                    self.pending_go_targets.append(
+                        (test_qn, module_qn, kind, GO_CALL.findall(body))
+                    )
                 self.ingestor.ensure_relationship_batch(
                     ("Module", "qualified_name", module_qn),
                     "CONTAINS_TEST",
@@ -1161,8 +4575,9 @@ class GraphUpdater:
                         "module": module_qn,
                     },
                 )
+                source_label = "TestCase" if source in case_keys else "TestFunction"
                 self.ingestor.ensure_relationship_batch(
-                    ("TestCase", "qualified_name", source_qn),
+                    (source_label, "qualified_name", source_qn),
                     "ASSERTS",
                     ("Assertion", "text", target),
                 )
@@ -1251,6 +4666,97 @@ class GraphUpdater:
         except Exception as e:
             logger.error(f"Failed to parse BDD file {file_path}: {e}")
 
+    def _parse_proto_file(self, file_path: Path) -> None:
+        """
+        Create ProtoService, RpcMethod and ProtoMessage nodes for a .proto
+        file. RPCs are named as on the wire, so they are the RpcMethods that
+        generated Go code declares and handlers implement.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            proto = parse_proto(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError, ProtoSyntaxError) as e:
+            logger.warning(f"Could not parse {relative_path}: {e}")
+            self.skipped_files[relative_path] = f"invalid protobuf: {e}"
+            return
+        file_node = ("File", "path", relative_path)
+        self.proto_enums.update(proto.full_name(enum) for enum in proto.enums)
+
+        for message in proto.messages:
+            message_qn = proto.full_name(message.name)
+            self.proto_messages.add(message_qn)
+            self.ingestor.ensure_node_batch(
+                "ProtoMessage",
+                {
+                    "qualified_name": message_qn,
+                    "name": message.name.rsplit(".", 1)[-1],
+                    "package": proto.package,
+                    "path": relative_path,
+                    "start_line": message.line_number,
+                    "field_count": len(message.fields),
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                file_node, "DEFINES", ("ProtoMessage", "qualified_name", message_qn)
+            )
+            for proto_field in message.fields:
+                if proto_field.type_name:
+                    self.pending_proto_types.append(
+                        (
+                            ("ProtoMessage", "qualified_name", message_qn),
+                            proto_field.type_name,
+                            message_qn,
+                            "REFERENCES",
+                            {
+                                "field": proto_field.name,
+                                "repeated": proto_field.repeated,
+                            },
+                        )
+                    )
+
+        for service in proto.services:
+            service_qn = proto.full_name(service.name)
+            service_node = ("ProtoService", "qualified_name", service_qn)
+            self.ingestor.ensure_node_batch(
+                "ProtoService",
+                {
+                    "qualified_name": service_qn,
+                    "name": service.name,
+                    "package": proto.package,
+                    "path": relative_path,
+                    "start_line": service.line_number,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(file_node, "DEFINES", service_node)
+            for rpc in service.rpcs:
+                rpc_node = ("RpcMethod", "qualified_name", f"/{service_qn}/{rpc.name}")
+                self.ingestor.ensure_node_batch(
+                    "RpcMethod",
+                    {
+                        "qualified_name": rpc_node[2],
+                        "name": rpc.name,
+                        "service": service_qn,
+                        "streaming": rpc.client_streaming or rpc.server_streaming,
+                        "client_streaming": rpc.client_streaming,
+                        "server_streaming": rpc.server_streaming,
+                        "request_type": rpc.request,
+                        "response_type": rpc.response,
+                        "path": relative_path,
+                        "start_line": rpc.line_number,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    service_node, "HAS_RPC", rpc_node
+                )
+                self.pending_proto_types += [
+                    (rpc_node, rpc.request, proto.package, "ACCEPTS", {}),
+                    (rpc_node, rpc.response, proto.package, "RETURNS", {}),
+                ]
+        logger.info(
+            f"  Found {len(proto.services)} services and {len(proto.messages)} "
+            f"messages in {relative_path}"
+        )
+
     def _analyze_data_flow(
         self, file_path: Path, content: str, module_qn: str, language: str
     ) -> None:
@@ -1352,6 +4858,21 @@ class GraphUpdater:
 
             # Track dependencies
             for imp in imports:
+                package = self._external_package(imp.source_module, language)
+                if package:
+                    # Third-party imports, which `analyze unused-deps` checks
+                    # the manifests against
+                    self.ingestor.ensure_node_batch(
+                        "ExternalPackage", {"name": package}
+                    )
+                    self.ingestor.ensure_relationship_batch(
+                        ("Module", "qualified_name", module_qn),
+                        "IMPORTS",
+                        ("ExternalPackage", "name", package),
+                        {"symbol": imp.symbol, "line_number": imp.line_number},
+                    )
+                    continue
+
                 # Resolve the import to a module qualified name
                 dep_module = self._resolve_import_to_module(
                     imp.source_module, module_qn, language
@@ -1361,12 +4882,10 @@ class GraphUpdater:
 
                     # Create IMPORTS relationship
                     self.ingestor.ensure_relationship_batch(
-                        (
-                            ("Module", "qualified_name", module_qn),
-                            "IMPORTS",
-                            ("Module", "qualified_name", dep_module),
-                            {"symbol": imp.symbol, "line_number": imp.line_number},
-                        )
+                        ("Module", "qualified_name", module_qn),
+                        "IMPORTS",
+                        ("Module", "qualified_name", dep_module),
+                        {"symbol": imp.symbol, "line_number": imp.line_number},
                     )
 
                     # If importing specific symbol, create REQUIRES relationship
@@ -1428,12 +4947,44 @@ class GraphUpdater:
                         return f"{base}.{relative_path}"
                     return base
             else:
-                # Absolute import - return as is
+                # Absolute imports of the repository's own packages, which are
+                # qualified under the project name
+                top_level = import_path.split(".")[0]
+                if (self.repo_path / top_level).is_dir() or (
+                    self.repo_path / f"{top_level}.py"
+                ).is_file():
+                    return f"{self.project_name}.{import_path}"
                 return import_path
 
         # For other languages, return the import path as is
         return import_path
 
+    def _external_package(self, import_path: str, language: str) -> str | None:
+        """
+        The third-party package an import is from: the top-level module in
+        Python, the npm package in JavaScript. None for the repository's own
+        modules and the standard library.
+        """
+        if language == "python":
+            top_level = import_path.split(".")[0]
+            if (
+                not top_level
+                or top_level in sys.stdlib_module_names
+                or (self.repo_path / top_level).is_dir()
+                or (self.repo_path / f"{top_level}.py").is_file()
+            ):
+                return None
+            return top_level
+        if language in ("javascript", "typescript"):
+            # Relative and absolute paths, and aliases such as "@/components"
+            if not import_path or import_path.startswith((".", "/", "@/", "~")):
+                return None
+            package = npm_package_name(import_path)
+            if package.startswith("node:") or package in NODE_BUILTINS:
+                return None
+            return package
+        return None
+
     def _determine_export_node_type(self, export_type: str) -> str:
         """Determine the graph node label based on export type."""
         type_mapping = {
@@ -1471,12 +5022,10 @@ class GraphUpdater:
                     # Create CIRCULAR_DEPENDENCY relationships in the graph
                     for j in range(len(cycle) - 1):
                         self.ingestor.ensure_relationship_batch(
-                            (
-                                ("Module", "qualified_name", cycle[j]),
-                                "CIRCULAR_DEPENDENCY",
-                                ("Module", "qualified_name", cycle[j + 1]),
-                                {"cycle_id": i},
-                            )
+                            ("Module", "qualified_name", cycle[j]),
+                            "CIRCULAR_DEPENDENCY",
+                            ("Module", "qualified_name", cycle[j + 1]),
+                            {"cycle_id": i},
                         )
             else:
                 logger.info("  No circular dependencies detected")
@@ -1894,8 +5443,6 @@ class GraphUpdater:
                 self._parse_package_json_specifics(config_file, config_qn)
             elif filepath.name in ["requirements.txt", "setup.py", "setup.cfg"]:
                 self._parse_python_dependencies(config_file, config_qn)
-            elif filepath.name == "Dockerfile":
-                self._parse_dockerfile_specifics(config_file, config_qn)
 
             logger.info(
                 f"    Successfully parsed {config_file.format} file with {len(config_file.settings)} settings"
@@ -1927,11 +5474,722 @@ class GraphUpdater:
         # This is for any Python-specific handling
         pass
 
-    def _parse_dockerfile_specifics(self, config_file, config_qn: str) -> None:
-        """Parse Dockerfile specific information."""
-        # Could extract base images, exposed ports, etc.
-        # For now, basic parsing is sufficient
-        pass
+    def _parse_dockerfile(self, file_path: Path) -> None:
+        """
+        Create an Image node per build stage of a Dockerfile, with BUILDS_FROM
+        and COPIES_FROM edges to the stages or registry images they use. What
+        they copy from the build context is linked once Compose files have
+        said which context that is.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            dockerfile = parse_dockerfile(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError, ContainerSyntaxError) as e:
+            logger.warning(f"Could not parse {relative_path}: {e}")
+            self.skipped_files[relative_path] = f"invalid Dockerfile: {e}"
+            return
+        self.dockerfiles[relative_path] = dockerfile
+        for index, stage in enumerate(dockerfile.stages):
+            stage_node = ("Image", "qualified_name", f"{relative_path}:{stage.name}")
+            self.ingestor.ensure_node_batch(
+                "Image",
+                {
+                    "qualified_name": stage_node[2],
+                    "name": stage.name,
+                    "path": relative_path,
+                    "line_number": stage.line_number,
+                    "exposed_ports": stage.exposed_ports,
+                    "is_final": index == len(dockerfile.stages) - 1,
+                    "is_external": False,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", relative_path), "DEFINES", stage_node
+            )
+            earlier = Dockerfile(dockerfile.stages[:index])
+            self.ingestor.ensure_relationship_batch(
+                stage_node,
+                "BUILDS_FROM",
+                self._image_node(relative_path, earlier, stage.base),
+            )
+            for copy in stage.copies:
+                if copy.from_stage is not None:
+                    self.ingestor.ensure_relationship_batch(
+                        stage_node,
+                        "COPIES_FROM",
+                        self._image_node(relative_path, earlier, copy.from_stage),
+                        {"line_number": copy.line_number},
+                    )
+        logger.info(f"  Found {len(dockerfile.stages)} build stages in {relative_path}")
+
+    def _image_node(
+        self, dockerfile_path: str, earlier: Dockerfile, reference: str
+    ) -> tuple[str, str, str]:
+        """An earlier stage of a Dockerfile, or else an image of a registry."""
+        if stage := earlier.stage(reference):
+            return ("Image", "qualified_name", f"{dockerfile_path}:{stage.name}")
+        name, _, tag = reference.partition("@")[0].rpartition(":")
+        if "/" in tag or not name:
+            name, tag = reference.partition("@")[0], ""
+        self.ingestor.ensure_node_batch(
+            "Image",
+            {
+                "qualified_name": reference,
+                "name": name,
+                "tag": tag,
+                "is_external": True,
+            },
+        )
+        return ("Image", "qualified_name", reference)
+
+    def _parse_compose_file(self, file_path: Path) -> None:
+        """
+        Create a Service node per Compose service, with DEPENDS_ON edges
+        between them and RUNS_IMAGE edges to the images they run. BUILDS
+        edges to the Dockerfile stages they build are linked once every
+        Dockerfile is read.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            services = parse_compose(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError, ContainerSyntaxError) as e:
+            logger.warning(f"Could not parse {relative_path}: {e}")
+            return
+        directory = Path(relative_path).parent.as_posix()
+        for service in services:
+            service_qn = f"{relative_path}:{service.name}"
+            service_node = ("Service", "qualified_name", service_qn)
+            self.ingestor.ensure_node_batch(
+                "Service",
+                {
+                    "qualified_name": service_qn,
+                    "name": service.name,
+                    "path": relative_path,
+                    "line_number": service.line_number,
+                    "image": service.image or "",
+                    "ports": service.ports,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", relative_path), "DEFINES", service_node
+            )
+            for dependency in service.depends_on:
+                self.ingestor.ensure_relationship_batch(
+                    service_node,
+                    "DEPENDS_ON",
+                    ("Service", "qualified_name", f"{relative_path}:{dependency}"),
+                )
+            context = (
+                context_path(directory, service.build_context)
+                if service.build_context is not None
+                else None
+            )
+            if context is not None:
+                dockerfile_path = context_path(context, service.dockerfile)
+                if dockerfile_path is not None:
+                    self.pending_compose_builds.append(
+                        (service_qn, dockerfile_path, context, service.target)
+                    )
+            elif service.image:
+                # Built elsewhere, or pulled from a registry
+                self.ingestor.ensure_relationship_batch(
+                    service_node,
+                    "RUNS_IMAGE",
+                    self._image_node(relative_path, Dockerfile(), service.image),
+                )
+        logger.info(f"  Found {len(services)} Compose services in {relative_path}")
+
+    def _link_containers(self) -> None:
+        """
+        Create BUILDS edges from Compose services to the Dockerfile stages
+        they build, and COPIES edges from stages to the directories and files
+        they copy in. A copied path is looked up in the build contexts Compose
+        gives the Dockerfile, then in its own directory and the repository
+        root, the contexts `docker build` is usually run with.
+        """
+        contexts: dict[str, list[str]] = defaultdict(list)
+        for service_qn, dockerfile_path, context, target in self.pending_compose_builds:
+            contexts[dockerfile_path].append(context)
+            dockerfile = self.dockerfiles.get(dockerfile_path)
+            stage = dockerfile.stage(target) if dockerfile else None
+            if stage is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                ("Service", "qualified_name", service_qn),
+                "BUILDS",
+                ("Image", "qualified_name", f"{dockerfile_path}:{stage.name}"),
+            )
+        self.pending_compose_builds.clear()
+
+        for dockerfile_path, dockerfile in self.dockerfiles.items():
+            candidates = [
+                *contexts.get(dockerfile_path, []),
+                Path(dockerfile_path).parent.as_posix(),
+                "",
+            ]
+            for stage in dockerfile.stages:
+                stage_node = (
+                    "Image",
+                    "qualified_name",
+                    f"{dockerfile_path}:{stage.name}",
+                )
+                for copy in stage.copies:
+                    if copy.from_stage is not None:
+                        continue
+                    for source in copy.sources:
+                        target = self._context_path_node(candidates, source)
+                        if target:
+                            self.ingestor.ensure_relationship_batch(
+                                stage_node,
+                                "COPIES",
+                                target,
+                                {
+                                    "destination": copy.destination,
+                                    "line_number": copy.line_number,
+                                },
+                            )
+
+    def _context_path_node(
+        self, contexts: list[str], source: str
+    ) -> tuple[str, str, str] | None:
+        """The node of a path in the first of some directories that has it."""
+        # Globs stand for the directory they match in
+        parts = source.split("/")
+        glob = next((i for i, p in enumerate(parts) if re.search(r"[*?\[]", p)), None)
+        if glob is not None:
+            source = "/".join(parts[:glob]) or "."
+        for context in dict.fromkeys(contexts):
+            relative = context_path(context or ".", source)
+            if relative is None:
+                continue
+            if relative == "":
+                return ("Project", "name", self.project_name)
+            if Path(relative) in self.structural_elements:
+                package_qn = self.structural_elements[Path(relative)]
+                if package_qn:
+                    return ("Package", "qualified_name", package_qn)
+                return ("Folder", "path", relative)
+            if (self.repo_path / relative).is_file():
+                return ("File", "path", relative)
+        return None
+
+    def _parse_terraform_file(self, file_path: Path) -> None:
+        """
+        Create TerraformResource, TerraformModule and TerraformVariable nodes
+        for the blocks of a .tf file, with SETS_ENV edges to the environment
+        variables its resources set. References between blocks are linked
+        once every file of their directory, their Terraform module, is read.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            blocks = parse_terraform(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError, HclSyntaxError) as e:
+            logger.warning(f"Could not parse {relative_path}: {e}")
+            self.skipped_files[relative_path] = f"invalid Terraform: {e}"
+            return
+        directory = Path(relative_path).parent.as_posix()
+        for block in blocks:
+            self.terraform_modules[directory].append(block)
+            node = self._terraform_node(directory, block)
+            properties = {
+                "qualified_name": node[2],
+                "name": block.name,
+                "address": block.address,
+                "path": relative_path,
+                "line_number": block.line_number,
+            }
+            if block.kind in ("resource", "data"):
+                properties["type"] = block.type
+                properties["provider"] = block.type.split("_")[0]
+                properties["is_data"] = block.kind == "data"
+            elif block.kind == "module":
+                properties["source"] = block.source or ""
+            else:
+                kinds = {"variable": "input", "local": "local", "output": "output"}
+                properties["kind"] = kinds[block.kind]
+                description = block.attributes.get("description")
+                literal = description.literal if description else None
+                properties["description"] = literal or ""
+            self.ingestor.ensure_node_batch(node[0], properties)
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", relative_path), "DEFINES", node
+            )
+            for assignment in block.env:
+                self.ingestor.ensure_node_batch("EnvVar", {"name": assignment.name})
+                self.ingestor.ensure_relationship_batch(
+                    node,
+                    "SETS_ENV",
+                    ("EnvVar", "name", assignment.name),
+                    {"line_number": assignment.line_number},
+                )
+        logger.info(f"  Found {len(blocks)} Terraform blocks in {relative_path}")
+
+    def _terraform_node(
+        self, directory: str, block: TerraformBlock
+    ) -> tuple[str, str, str]:
+        return (
+            TERRAFORM_LABELS[block.kind],
+            "qualified_name",
+            f"{directory}:{block.address}",
+        )
+
+    def _link_terraform(self) -> None:
+        """
+        Create REFERENCES edges between the blocks of each Terraform module,
+        PROVIDES_ENV edges from the resources and module calls whose values
+        a resource puts in an environment variable, and, for module calls of
+        a module in the repository, a SOURCED_FROM edge to its directory and
+        SETS edges to the input variables their arguments set.
+        """
+        for directory, blocks in self.terraform_modules.items():
+            declared = {block.address: block for block in blocks}
+            for block in blocks:
+                node = self._terraform_node(directory, block)
+                for reference in block.references:
+                    if target := declared.get(reference):
+                        self.ingestor.ensure_relationship_batch(
+                            node,
+                            "REFERENCES",
+                            self._terraform_node(directory, target),
+                        )
+                for assignment in block.env:
+                    for reference in assignment.references:
+                        target = declared.get(reference)
+                        if target and target.kind in ("resource", "data", "module"):
+                            self.ingestor.ensure_relationship_batch(
+                                self._terraform_node(directory, target),
+                                "PROVIDES_ENV",
+                                ("EnvVar", "name", assignment.name),
+                                {
+                                    "matched_by": "reference",
+                                    "set_by": block.address,
+                                    "line_number": assignment.line_number,
+                                },
+                            )
+                if block.kind == "module":
+                    self._link_terraform_module_call(directory, block)
+
+    def _link_terraform_module_call(
+        self, directory: str, block: TerraformBlock
+    ) -> None:
+        source = block.source or ""
+        if not source.startswith(("./", "../")):
+            return  # From a registry or a repository elsewhere
+        node = self._terraform_node(directory, block)
+        if target := self._context_path_node([directory], source):
+            self.ingestor.ensure_relationship_batch(node, "SOURCED_FROM", target)
+        child = context_path(directory, source)
+        if child is None:
+            return
+        variables = {
+            child_block.address: child_block
+            for child_block in self.terraform_modules.get(child or ".", [])
+        }
+        for argument, attribute in block.attributes.items():
+            variable = variables.get(f"var.{argument}")
+            if argument in META_ARGUMENTS or variable is None:
+                continue
+            self.ingestor.ensure_relationship_batch(
+                node,
+                "SETS",
+                self._terraform_node(child or ".", variable),
+                {"line_number": attribute.line_number},
+            )
+
+    def _link_env_providers(self) -> None:
+        """
+        Create PROVIDES_ENV edges from Terraform resources to the environment
+        variables code reads whose names look like theirs (see provides_env).
+        """
+        names = [row["name"] for row in self.ingestor.fetch_all(ENV_VARS_READ_QUERY)]
+        for directory, blocks in self.terraform_modules.items():
+            for block in blocks:
+                for name in names:
+                    if provides_env(block, name):
+                        self.ingestor.ensure_relationship_batch(
+                            self._terraform_node(directory, block),
+                            "PROVIDES_ENV",
+                            ("EnvVar", "name", name),
+                            {"matched_by": "name"},
+                        )
+
+    def _parse_sql_file(self, file_path: Path) -> None:
+        """
+        Link a .sql file to the tables its queries read and write, and keep
+        its statements if it is a migration, to be applied in order with the
+        others once every .sql file is read.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            statements = split_statements(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError) as e:
+            logger.warning(f"Could not read {relative_path}: {e}")
+            self.skipped_files[relative_path] = f"unreadable SQL: {e}"
+            return
+        if is_migration(relative_path):
+            self.sql_migrations[relative_path] = statements
+        for statement in statements:
+            if is_query(statement.text):
+                self._ingest_table_uses(
+                    ("File", "path", relative_path),
+                    *query_tables(statement.text),
+                    statement.line_number,
+                )
+
+    def _ingest_sql_schema(self) -> None:
+        """
+        Apply the migrations in order, and create Table and Column nodes for
+        the schema they leave: DEFINES and ALTERS edges from the migrations
+        creating and changing each table, HAS_COLUMN edges, REFERENCES edges
+        for foreign keys between tables and between their columns, and
+        READS_FROM edges from views to the tables they select from.
+        """
+        schema = SqlSchema()
+        for path in sorted(self.sql_migrations, key=migration_order):
+            for statement in self.sql_migrations[path]:
+                schema.apply(statement, path)
+        for table in schema.tables.values():
+            table_node = ("Table", "qualified_name", table.name)
+            table_schema, _, name = table.name.rpartition(".")
+            self.ingestor.ensure_node_batch(
+                "Table",
+                {
+                    "qualified_name": table.name,
+                    "name": name,
+                    "schema": table_schema,
+                    "kind": table.kind,
+                    "path": table.path,
+                    "line_number": table.line_number,
+                    "primary_key": table.primary_key,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", table.path), "DEFINES", table_node
+            )
+            for path, line_number in table.alterations:
+                if path != table.path:
+                    self.ingestor.ensure_relationship_batch(
+                        ("File", "path", path),
+                        "ALTERS",
+                        table_node,
+                        {"line_number": line_number},
+                    )
+            for column in table.columns.values():
+                column_qn = f"{table.name}.{column.name}"
+                self.ingestor.ensure_node_batch(
+                    "Column",
+                    {
+                        "qualified_name": column_qn,
+                        "name": column.name,
+                        "data_type": column.data_type,
+                        "nullable": column.nullable,
+                        "primary_key": column.primary_key,
+                        "default": column.default or "",
+                        "path": column.path,
+                        "line_number": column.line_number,
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    table_node, "HAS_COLUMN", ("Column", "qualified_name", column_qn)
+                )
+            for key in table.foreign_keys:
+                target = schema.tables.get(key.table)
+                if target is None:
+                    continue
+                self.ingestor.ensure_relationship_batch(
+                    table_node,
+                    "REFERENCES",
+                    ("Table", "qualified_name", target.name),
+                    {"columns": key.columns},
+                )
+                referenced = key.referenced_columns or target.primary_key
+                for column, target_column in zip(key.columns, referenced):
+                    self.ingestor.ensure_relationship_batch(
+                        ("Column", "qualified_name", f"{table.name}.{column}"),
+                        "REFERENCES",
+                        ("Column", "qualified_name", f"{target.name}.{target_column}"),
+                    )
+            for read in table.reads:
+                self.ingestor.ensure_relationship_batch(
+                    table_node, "READS_FROM", ("Table", "qualified_name", read)
+                )
+        if schema.tables:
+            logger.info(
+                f"--- Found {len(schema.tables)} tables in "
+                f"{len(self.sql_migrations)} migrations ---"
+            )
+
+    def _parse_workflow_file(self, file_path: Path) -> None:
+        """
+        Create Workflow, Job and Step nodes for a GitHub Actions workflow,
+        with NEEDS edges between its jobs. What the steps run is linked once
+        every Makefile is read.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            workflow = parse_workflow(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError, WorkflowSyntaxError) as e:
+            logger.warning(f"Could not parse {relative_path}: {e}")
+            self.skipped_files[relative_path] = f"invalid workflow: {e}"
+            return
+        self.workflows[relative_path] = workflow
+        workflow_node = ("Workflow", "qualified_name", relative_path)
+        self.ingestor.ensure_node_batch(
+            "Workflow",
+            {
+                "qualified_name": relative_path,
+                "name": workflow.name or file_path.stem,
+                "path": relative_path,
+                "triggers": workflow.triggers,
+            },
+        )
+        self.ingestor.ensure_relationship_batch(
+            ("File", "path", relative_path), "DEFINES", workflow_node
+        )
+        for job in workflow.jobs:
+            job_qn = f"{relative_path}:{job.id}"
+            self.ingestor.ensure_node_batch(
+                "Job",
+                {
+                    "qualified_name": job_qn,
+                    "name": job.name,
+                    "path": relative_path,
+                    "line_number": job.line_number,
+                    "runs_on": job.runs_on,
+                    "uses": job.uses or "",
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                workflow_node, "HAS_JOB", ("Job", "qualified_name", job_qn)
+            )
+            for index, step in enumerate(job.steps):
+                step_qn = f"{job_qn}:{index}"
+                name = step.name
+                redactor = getattr(self.ingestor, "redactor", None)
+                if redactor is not None and not step.named:
+                    # Private runs keep no script text, not even as a name
+                    name = redactor.hash(name)
+                self.ingestor.ensure_node_batch(
+                    "Step",
+                    {
+                        "qualified_name": step_qn,
+                        "name": name,
+                        "path": relative_path,
+                        "line_number": step.line_number,
+                        "uses": step.uses or "",
+                        "run": step.run or "",
+                    },
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("Job", "qualified_name", job_qn),
+                    "HAS_STEP",
+                    ("Step", "qualified_name", step_qn),
+                    {"order": index},
+                )
+        # Once every job has its node, as a job may need one after it
+        for job in workflow.jobs:
+            for needed in job.needs:
+                self.ingestor.ensure_relationship_batch(
+                    ("Job", "qualified_name", f"{relative_path}:{job.id}"),
+                    "NEEDS",
+                    ("Job", "qualified_name", f"{relative_path}:{needed}"),
+                )
+        logger.info(f"  Found {len(workflow.jobs)} jobs in {relative_path}")
+
+    def _parse_readme(self, file_path: Path) -> None:
+        """
+        Create a Documentation node per section of a README, with a DOCUMENTS
+        edge to the package or folder the README is in.
+        """
+        relative_path = file_path.relative_to(self.repo_path)
+        path = relative_path.as_posix()
+        try:
+            text = file_path.read_text(encoding="utf-8")
+        except (OSError, UnicodeDecodeError) as e:
+            logger.warning(f"Could not read {path}: {e}")
+            return
+        parent = self._module_parent(relative_path)
+        for section in parse_markdown_sections(text, file_path.name):
+            doc_qn = f"{path}:{section.start_line}"
+            self.ingestor.ensure_node_batch(
+                "Documentation",
+                {
+                    "qualified_name": doc_qn,
+                    "kind": "readme",
+                    "name": section.title,
+                    "text": section.text,
+                    "path": path,
+                    "start_line": section.start_line,
+                    "end_line": section.end_line,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", path),
+                "HAS_DOCUMENTATION",
+                ("Documentation", "qualified_name", doc_qn),
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("Documentation", "qualified_name", doc_qn), "DOCUMENTS", parent
+            )
+
+    def _parse_makefile(self, file_path: Path) -> None:
+        """
+        Create a MakeTarget node per target of a Makefile, with DEPENDS_ON
+        edges to the targets it has as prerequisites.
+        """
+        relative_path = str(file_path.relative_to(self.repo_path))
+        try:
+            makefile = parse_makefile(file_path.read_text(encoding="utf-8"))
+        except (OSError, UnicodeDecodeError) as e:
+            logger.warning(f"Could not read {relative_path}: {e}")
+            return
+        self.makefiles[relative_path] = makefile
+        for target in makefile.targets.values():
+            target_qn = f"{relative_path}:{target.name}"
+            self.ingestor.ensure_node_batch(
+                "MakeTarget",
+                {
+                    "qualified_name": target_qn,
+                    "name": target.name,
+                    "path": relative_path,
+                    "line_number": target.line_number,
+                    "commands": [command for _, command in target.commands],
+                    "is_default": target.name == makefile.default_goal,
+                    "is_phony": target.is_phony,
+                    "outputs": target.outputs,
+                },
+            )
+            self.ingestor.ensure_relationship_batch(
+                ("File", "path", relative_path),
+                "DEFINES",
+                ("MakeTarget", "qualified_name", target_qn),
+            )
+        for target in makefile.targets.values():
+            target_qn = f"{relative_path}:{target.name}"
+            for prerequisite in target.prerequisites:
+                if prerequisite in makefile.targets:
+                    prerequisite_qn = f"{relative_path}:{prerequisite}"
+                    self.ingestor.ensure_relationship_batch(
+                        ("MakeTarget", "qualified_name", target_qn),
+                        "DEPENDS_ON",
+                        ("MakeTarget", "qualified_name", prerequisite_qn),
+                    )
+
+    def _link_workflows(self) -> None:
+        """
+        Link workflow steps to what their commands run: RUNS_TARGET edges to
+        Makefile targets, RUNS_SCRIPT edges to scripts and RUNS_PACKAGE edges
+        to the directories of Go packages. Steps run in the repository root
+        or their working-directory. Jobs calling a reusable workflow of the
+        repository get a CALLS_WORKFLOW edge to it.
+        """
+        for path, workflow in self.workflows.items():
+            for job in workflow.jobs:
+                job_node = ("Job", "qualified_name", f"{path}:{job.id}")
+                called = context_path("", job.uses) if job.uses else None
+                if called in self.workflows:
+                    self.ingestor.ensure_relationship_batch(
+                        job_node,
+                        "CALLS_WORKFLOW",
+                        ("Workflow", "qualified_name", called),
+                    )
+                for index, step in enumerate(job.steps):
+                    directory = context_path(
+                        "", step.working_directory or job.working_directory or "."
+                    )
+                    if step.run is None or directory is None:
+                        continue
+                    step_node = ("Step", "qualified_name", f"{path}:{job.id}:{index}")
+                    for invocation in invocations(step.run):
+                        line_number = step.line_number
+                        if step.run_line_number:
+                            line_number = (
+                                step.run_line_number + invocation.line_number - 1
+                            )
+                        self._link_invocation(
+                            step_node, invocation, directory, line_number
+                        )
+
+    def _link_makefiles(self) -> None:
+        """
+        Link Makefile targets to what their recipes run, as steps are, and
+        with BUILDS_FROM edges to the source files they are built from.
+        Recipes run in the directory of their Makefile, which their paths
+        are relative to.
+        """
+        for path, makefile in self.makefiles.items():
+            directory = Path(path).parent.as_posix()
+
+            def is_file(name: str, directory: str = directory) -> bool:
+                relative = context_path(directory, name)
+                return bool(relative) and (self.repo_path / relative).is_file()
+
+            for target in makefile.targets.values():
+                target_node = ("MakeTarget", "qualified_name", f"{path}:{target.name}")
+                for line_number, command in target.commands:
+                    for invocation in invocations(command):
+                        self._link_invocation(
+                            target_node, invocation, directory, line_number
+                        )
+                for source, built in makefile.sources(target, is_file):
+                    self.ingestor.ensure_relationship_batch(
+                        target_node,
+                        "BUILDS_FROM",
+                        ("File", "path", context_path(directory, source)),
+                        {"via": built},
+                    )
+
+    def _link_invocation(
+        self,
+        node: tuple[str, str, str],
+        invocation: Invocation,
+        directory: str,
+        line_number: int,
+    ) -> None:
+        """Link a step or Makefile target to what one of its commands runs."""
+        properties: dict[str, Any] = {
+            "command": invocation.command,
+            "line_number": line_number,
+        }
+        if invocation.kind == "make":
+            make_directory = context_path(directory, invocation.directory)
+            if make_directory is None:
+                return
+            names = [invocation.makefile] if invocation.makefile else MAKEFILE_NAMES
+            makefile_path = next(
+                (
+                    path
+                    for name in names
+                    if (path := context_path(make_directory, name)) in self.makefiles
+                ),
+                None,
+            )
+            if makefile_path is None:
+                return
+            makefile = self.makefiles[makefile_path]
+            name = invocation.target or makefile.default_goal
+            if name in makefile.targets:
+                self.ingestor.ensure_relationship_batch(
+                    node,
+                    "RUNS_TARGET",
+                    ("MakeTarget", "qualified_name", f"{makefile_path}:{name}"),
+                    properties,
+                )
+        elif invocation.kind == "script":
+            target = self._context_path_node([directory], invocation.target)
+            if target and target[0] == "File":
+                self.ingestor.ensure_relationship_batch(
+                    node, "RUNS_SCRIPT", target, properties
+                )
+        else:
+            # ./... stands for the packages under a directory, linked to it
+            path = invocation.target.removesuffix("...").rstrip("/") or "."
+            target = self._context_path_node([directory], path)
+            if target:
+                properties["pattern"] = invocation.target
+                self.ingestor.ensure_relationship_batch(
+                    node, "RUNS_PACKAGE", target, properties
+                )
 
     def _analyze_repository_git_info(self) -> None:
         """Analyze repository-level Git information."""
@@ -2038,7 +6296,7 @@ class GraphUpdater:
                 # Find the test node's qualified name
                 test_qn = None
                 for node in test_nodes:
-                    if node.name == test_name:
+                    if node.key == test_name:
                         test_qn = f"{module_qn}.{test_name}"
                         break
 
@@ -2058,7 +6316,7 @@ class GraphUpdater:
                 # Determine the correct test node type
                 test_node_type = None
                 for node in test_nodes:
-                    if node.name == test_name:
+                    if node.key == test_name:
                         if node.node_type == "test_suite":
                             test_node_type = "TestSuite"
                         elif node.node_type == "test_case":
@@ -2109,128 +6367,7 @@ class GraphUpdater:
         except Exception as e:
             logger.error(f"Failed to analyze test-code relationships: {e}")
 
-    def _process_files_parallel(self) -> None:
-        """Process files in parallel for improved performance."""
-        # Collect all file tasks first
-        file_tasks = []
-
-        for root_str, dirs, files in os.walk(self.repo_path, topdown=True):
-            dirs[:] = [d for d in dirs if d not in self.ignore_dirs]
-            root = Path(root_str)
-            relative_root = root.relative_to(self.repo_path)
-
-            # Apply folder filter if specified
-            if self.folder_filter:
-                relative_path_str = str(relative_root)
-                if not relative_path_str.startswith(self.folder_filter):
-                    continue
-
-            parent_container_qn = self.structural_elements.get(relative_root)
-            parent_label, parent_key, parent_val = (
-                ("Package", "qualified_name", parent_container_qn)
-                if parent_container_qn
-                else (
-                    ("Folder", "path", str(relative_root))
-                    if relative_root != Path()
-                    else ("Project", "name", self.project_name)
-                )
-            )
-
-            for file_name in files:
-                filepath = root / file_name
-
-                # Apply file pattern filter if specified
-                if self.file_pattern:
-                    import fnmatch
 
-                    if not fnmatch.fnmatch(file_name, self.file_pattern):
-                        continue
-
-                # Skip test files if requested
-                if self.skip_tests:
-                    test_patterns = ["test_", "_test.py", ".test.", ".spec."]
-                    if any(pattern in file_name.lower() for pattern in test_patterns):
-                        continue
-
-                relative_filepath = str(filepath.relative_to(self.repo_path))
-
-                # Create generic File node for all files
-                self.ingestor.ensure_node_batch(
-                    "File",
-                    {
-                        "path": relative_filepath,
-                        "name": file_name,
-                        "extension": filepath.suffix,
-                    },
-                )
-                self.ingestor.ensure_relationship_batch(
-                    (parent_label, parent_key, parent_val),
-                    "CONTAINS_FILE",
-                    ("File", "path", relative_filepath),
-                )
-
-                # Check if this file type is supported for parsing
-                lang_config = get_language_config(filepath.suffix)
-                if lang_config and lang_config.name in self.parsers:
-                    task = FileTask(
-                        filepath=filepath,
-                        relative_filepath=relative_filepath,
-                        parent_label=parent_label,
-                        parent_key=parent_key,
-                        parent_val=parent_val,
-                        language_config=lang_config,
-                    )
-                    file_tasks.append(task)
-
-        if not file_tasks:
-            logger.warning("No files found to process")
-            return
-
-        logger.info(f"Found {len(file_tasks)} files to process")
-
-        # Create thread-safe ingestor wrapper
-        thread_safe_ingestor = ThreadSafeIngestor(self.ingestor)
-
-        # Create parallel processor
-        processor = ParallelProcessor(
-            repo_path=self.repo_path,
-            parsers=self.parsers,
-            queries=self.queries,
-            project_name=self.project_name,
-            num_workers=self.num_workers,
-        )
-
-        # Process files in parallel
-        results, elapsed_time = processor.process_files_parallel(file_tasks)
-
-        # Process results
-        logger.info("Processing results and updating graph...")
-        for result in results:
-            if result.error:
-                logger.error(f"Error processing {result.filepath}: {result.error}")
-                continue
-
-            # Add nodes and relationships to graph
-            thread_safe_ingestor.add_nodes(result.nodes)
-            thread_safe_ingestor.add_relationships(result.relationships)
-
-            # Update internal registries
-            self.function_registry.update(result.functions)
-            for simple_name, qnames in result.simple_names.items():
-                self.simple_name_lookup[simple_name].update(qnames)
-
-            # Cache AST data
-            if result.ast_data:
-                self.ast_cache[result.filepath] = result.ast_data
-
-            # Track module dependencies and exports
-            if result.module_qn:
-                self.module_dependencies[result.module_qn].update(result.dependencies)
-                self.module_exports[result.module_qn].extend(result.exports)
-
-        # Flush all buffered data
-        thread_safe_ingestor.flush_all()
-
-        logger.info(
-            f"Parallel processing complete: {len(results)} files in {elapsed_time:.2f}s"
-        )
+def _option_list(value: str | None) -> list[str]:
+    """The entries of a comma-separated --folder-filter or --file-pattern."""
+    return [entry.strip() for entry in (value or "").split(",") if entry.strip()]
@@ -0,0 +1,129 @@
+"""Checkpoints of a full ingestion, to resume one that was interrupted.
+
+Every CHECKPOINT_INTERVAL files the ingestion writes what it has buffered and
+records the files it came from, per pass: the files whose definitions are
+stored and those whose calls are. A run that dies on a lost connection or is
+cancelled leaves its last checkpoint behind, and `start --update-graph
+--resume` picks it up: the recorded files are read again, but what they add
+is not written a second time.
+
+Reading them again is how the work left across files is recovered: resolving
+calls needs every file's definitions and the syntax trees of the files whose
+calls remain, which is parsing, minutes where writing took hours, and mostly
+replayed from the parse cache. The passes after the calls are made again.
+
+A checkpoint holds for the options of the run that made it; a run with other
+filters, build constraints or analyses starts from zero instead. A file
+changed since it was recorded is written again.
+"""
+
+import hashlib
+import json
+import os
+from pathlib import Path
+
+from loguru import logger
+
+# Bump when what a checkpoint records changes, to ignore older ones
+CHECKPOINT_VERSION = 1
+
+# The passes checkpointed per file, in the order they run
+STAGES = ("files", "calls")
+
+
+class IngestCheckpoint:
+    """The stored progress of a repository's full ingestion, by pass and file."""
+
+    def __init__(self, directory: Path, repo_path: Path, interval: int = 500):
+        self.repo_path = repo_path
+        repo_id = hashlib.sha256(str(repo_path.resolve()).encode()).hexdigest()
+        self.path = directory / f"{repo_id[:16]}.json"
+        self.interval = max(interval, 1)
+        self.fingerprint = ""
+        # {stage: {relative path: size and mtime when recorded}}
+        self.stored: dict[str, dict[str, str]] = {stage: {} for stage in STAGES}
+        # Handled since the last checkpoint, stored once their writes are
+        self.pending: dict[str, dict[str, str]] = {stage: {} for stage in STAGES}
+
+    def start(self, fingerprint: str, resume: bool = False) -> int:
+        """
+        Begin a run with the given options, from the stored checkpoint when
+        resuming and it was made with the same options; returns how many
+        files it had recorded.
+        """
+        self.fingerprint = fingerprint
+        self.stored = {stage: {} for stage in STAGES}
+        self.pending = {stage: {} for stage in STAGES}
+        if not resume:
+            # Stale once this run writes, which may follow a --clean
+            self.clear()
+            return 0
+        try:
+            document = json.loads(self.path.read_text(encoding="utf-8"))
+        except FileNotFoundError:
+            logger.warning(f"No checkpoint of {self.repo_path} to resume from")
+            return 0
+        except (OSError, ValueError) as e:
+            logger.warning(f"Ignoring unreadable checkpoint {self.path}: {e}")
+            return 0
+        if document.get("version") != CHECKPOINT_VERSION:
+            logger.warning(f"Ignoring checkpoint {self.path} of an older version")
+            return 0
+        if document.get("fingerprint") != fingerprint:
+            logger.warning(
+                "Ignoring the checkpoint of a run with other options; "
+                "ingesting from the start"
+            )
+            return 0
+        for stage in STAGES:
+            self.stored[stage] = dict(document.get("stages", {}).get(stage, {}))
+        return len(self.stored[STAGES[0]])
+
+    def is_stored(self, stage: str, relative_path: str) -> bool:
+        """Whether the pass stored what a file adds, and the file is unchanged."""
+        recorded = self.stored[stage].get(relative_path)
+        return recorded is not None and recorded == self._signature(relative_path)
+
+    def mark(self, stage: str, relative_path: str) -> None:
+        """Record a file as handled, stored with the next checkpoint."""
+        self.pending[stage][relative_path] = self._signature(relative_path)
+
+    @property
+    def due(self) -> bool:
+        return sum(len(files) for files in self.pending.values()) >= self.interval
+
+    def save(self) -> None:
+        """Count the pending files as stored, once their writes were flushed."""
+        for stage in STAGES:
+            self.stored[stage].update(self.pending[stage])
+        self.pending = {stage: {} for stage in STAGES}
+        document = {
+            "version": CHECKPOINT_VERSION,
+            "repo_path": str(self.repo_path),
+            "fingerprint": self.fingerprint,
+            "stages": self.stored,
+        }
+        temporary = self.path.with_suffix(f".{os.getpid()}.tmp")
+        try:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            temporary.write_text(json.dumps(document), encoding="utf-8")
+            # Replaced whole, so an interruption never leaves half a checkpoint
+            temporary.replace(self.path)
+        except OSError as e:
+            logger.warning(f"Could not save the ingestion checkpoint: {e}")
+            temporary.unlink(missing_ok=True)
+
+    def discard(self) -> None:
+        """Forget the pending files, whose writes did not all succeed."""
+        self.pending = {stage: {} for stage in STAGES}
+
+    def clear(self) -> None:
+        """Remove the stored checkpoint, once a run completes or starts over."""
+        self.path.unlink(missing_ok=True)
+
+    def _signature(self, relative_path: str) -> str:
+        try:
+            stat = (self.repo_path / relative_path).stat()
+        except OSError:
+            return ""
+        return f"{stat.st_size}:{stat.st_mtime_ns}"
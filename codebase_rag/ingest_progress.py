@@ -0,0 +1,68 @@
+"""Progress through the files of an ingestion run.
+
+Ingesting a large repository takes long enough that a bar with the files
+done, the rate and the time left is worth more than the log scrolling past.
+"""
+
+from typing import Protocol
+
+from rich.console import Console
+from rich.progress import (
+    BarColumn,
+    MofNCompleteColumn,
+    Progress,
+    ProgressColumn,
+    Task,
+    TaskID,
+    TextColumn,
+    TimeElapsedColumn,
+    TimeRemainingColumn,
+)
+from rich.text import Text
+
+
+class FileProgress(Protocol):
+    def start(self, total: int) -> None: ...
+
+    def advance(self) -> None: ...
+
+    def finish(self) -> None: ...
+
+
+class FilesPerSecondColumn(ProgressColumn):
+    """Files done per second, averaged over the last half minute."""
+
+    def render(self, task: Task) -> Text:
+        return Text(f"{task.speed or 0:.1f} files/s", style="progress.data.speed")
+
+
+class ConsoleProgress:
+    """A progress bar of files processed, their rate and an ETA."""
+
+    def __init__(self, console: Console):
+        self.console = console
+        self._progress: Progress | None = None
+        self._task: TaskID | None = None
+
+    def start(self, total: int) -> None:
+        self._progress = Progress(
+            TextColumn("[bold green]Ingesting files[/bold green]"),
+            BarColumn(),
+            MofNCompleteColumn(),
+            FilesPerSecondColumn(),
+            TimeElapsedColumn(),
+            TextColumn("ETA"),
+            TimeRemainingColumn(),
+            console=self.console,
+        )
+        self._task = self._progress.add_task("files", total=total)
+        self._progress.start()
+
+    def advance(self) -> None:
+        if self._progress is not None and self._task is not None:
+            self._progress.advance(self._task)
+
+    def finish(self) -> None:
+        if self._progress is not None:
+            self._progress.stop()
+            self._progress = None
@@ -0,0 +1,164 @@
+"""The report of an ingestion run: what was written, skipped and how long it took."""
+
+import json
+import time
+from collections import Counter
+from collections.abc import Iterator
+from contextlib import contextmanager
+from dataclasses import asdict, dataclass, field
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .config import settings
+from .services.graph_sinks import GraphSink, NodeRef
+
+# Warnings kept verbatim; the count covers all of them
+MAX_REPORTED_WARNINGS = 1000
+
+
+class CountingSink(GraphSink):
+    """Counts nodes and relationships as MERGE stores them, once per key."""
+
+    name = "counting"
+
+    def __init__(self) -> None:
+        self.nodes: dict[str, set[Any]] = {}
+        self.relationships: set[tuple] = set()
+
+    def on_node(self, label: str, properties: dict[str, Any]) -> None:
+        if properties:
+            key = next(iter(properties.values()))
+            self.nodes.setdefault(label, set()).add(_hashable(key))
+
+    def on_relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        self.relationships.add(
+            (tuple(map(_hashable, from_node)), rel_type, tuple(map(_hashable, to_node)))
+        )
+
+    def node_counts(self) -> dict[str, int]:
+        return {label: len(keys) for label, keys in sorted(self.nodes.items())}
+
+    def relationship_counts(self) -> dict[str, int]:
+        return dict(sorted(Counter(r[1] for r in self.relationships).items()))
+
+
+def _hashable(value: Any) -> Any:
+    return tuple(value) if isinstance(value, list) else value
+
+
+@dataclass
+class IngestionReport:
+    """Counts, problems and timings of one GraphUpdater.run()."""
+
+    run_id: str
+    project: str
+    repo_path: str
+    started_at: str = ""
+    finished_at: str = ""
+    duration_seconds: float = 0.0
+    # Seconds per pass, in the order they ran
+    stages: dict[str, float] = field(default_factory=dict)
+    node_counts: dict[str, int] = field(default_factory=dict)
+    relationship_counts: dict[str, int] = field(default_factory=dict)
+    parse_errors: dict[str, str] = field(default_factory=dict)
+    skipped_files: dict[str, str] = field(default_factory=dict)
+    warning_count: int = 0
+    warnings: list[str] = field(default_factory=list)
+
+    @contextmanager
+    def stage(self, name: str) -> Iterator[None]:
+        started = time.perf_counter()
+        try:
+            yield
+        finally:
+            self.stages[name] = round(time.perf_counter() - started, 3)
+
+    def finish(
+        self, counter: CountingSink, skipped_files: dict[str, str], warnings: list[str]
+    ) -> None:
+        finished = datetime.now(UTC)
+        self.finished_at = finished.isoformat()
+        if self.started_at:
+            elapsed = finished - datetime.fromisoformat(self.started_at)
+            self.duration_seconds = round(elapsed.total_seconds(), 3)
+        self.node_counts = counter.node_counts()
+        self.relationship_counts = counter.relationship_counts()
+        for path, reason in sorted(skipped_files.items()):
+            if reason.startswith("parse error"):
+                self.parse_errors[path] = reason
+            else:
+                self.skipped_files[path] = reason
+        self.warning_count = len(warnings)
+        self.warnings = warnings[:MAX_REPORTED_WARNINGS]
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            **asdict(self),
+            "total_nodes": sum(self.node_counts.values()),
+            "total_relationships": sum(self.relationship_counts.values()),
+        }
+
+    def summary_properties(self) -> dict[str, Any]:
+        """Properties of the IngestionRun node; run_id first as the merge key."""
+        return {
+            "run_id": self.run_id,
+            "project": self.project,
+            "started_at": self.started_at,
+            "finished_at": self.finished_at,
+            "duration_seconds": self.duration_seconds,
+            "nodes": sum(self.node_counts.values()),
+            "relationships": sum(self.relationship_counts.values()),
+            "parse_errors": len(self.parse_errors),
+            "skipped_files": len(self.skipped_files),
+            "warnings": self.warning_count,
+        }
+
+
+@contextmanager
+def collect_warnings(run_id: str) -> Iterator[list[str]]:
+    """Messages of WARNING and above logged while the run is bound to run_id."""
+    messages: list[str] = []
+    handler_id = logger.add(
+        lambda message: messages.append(message.record["message"]),
+        level="WARNING",
+        filter=lambda record: record["extra"].get("run_id") == run_id,
+        format="{message}",
+    )
+    try:
+        yield messages
+    finally:
+        logger.remove(handler_id)
+
+
+def default_report_path(report: IngestionReport) -> Path:
+    started = report.started_at[:19].replace(":", "").replace("-", "")
+    name = f"{report.project}-{started}-{report.run_id}.json"
+    return Path(settings.INGESTION_REPORT_DIR).expanduser() / name
+
+
+def write_report(report: IngestionReport, path: Path | None = None) -> Path:
+    """Write the report as JSON, by default under INGESTION_REPORT_DIR."""
+    path = path or default_report_path(report)
+    path.parent.mkdir(parents=True, exist_ok=True)
+    path.write_text(json.dumps(report.to_dict(), indent=2) + "\n", encoding="utf-8")
+    return path
+
+
+def store_run_summary(ingestor: Any, report: IngestionReport) -> None:
+    """Record the run as an IngestionRun node attached to its project."""
+    ingestor.ensure_node_batch("IngestionRun", report.summary_properties())
+    ingestor.ensure_relationship_batch(
+        ("Project", "name", report.project),
+        "HAS_INGESTION_RUN",
+        ("IngestionRun", "run_id", report.run_id),
+    )
+    ingestor.flush_all()
@@ -142,14 +142,93 @@ LANGUAGE_CONFIGS = {
 }
 
 
-def get_language_config(file_extension: str) -> LanguageConfig | None:
+# Set from the DISABLED_LANGUAGES and LANGUAGE_EXTENSIONS settings
+DISABLED_LANGUAGES: set[str] = set()
+# Extension -> language name, taking precedence over file_extensions above;
+# an empty name leaves files with that extension unparsed
+EXTENSION_OVERRIDES: dict[str, str] = {}
+
+
+def get_language_config(
+    file_extension: str, include_disabled: bool = False
+) -> LanguageConfig | None:
     """Get language configuration based on file extension."""
-    for config in LANGUAGE_CONFIGS.values():
-        if file_extension in config.file_extensions:
-            return config
-    return None
+    if file_extension in EXTENSION_OVERRIDES:
+        config = LANGUAGE_CONFIGS.get(EXTENSION_OVERRIDES[file_extension])
+    else:
+        config = next(
+            (
+                c
+                for c in LANGUAGE_CONFIGS.values()
+                if file_extension in c.file_extensions
+            ),
+            None,
+        )
+    if config and config.name in DISABLED_LANGUAGES and not include_disabled:
+        return None
+    return config
 
 
 def get_language_config_by_name(language_name: str) -> LanguageConfig | None:
     """Get language configuration by language name."""
     return LANGUAGE_CONFIGS.get(language_name.lower())
+
+
+def language_extensions(language_name: str) -> list[str]:
+    """The extensions parsed as a language once overrides are applied."""
+    config = LANGUAGE_CONFIGS.get(language_name)
+    extensions = [
+        extension
+        for extension in (config.file_extensions if config else [])
+        if extension not in EXTENSION_OVERRIDES
+    ]
+    return extensions + [
+        extension
+        for extension, name in EXTENSION_OVERRIDES.items()
+        if name == language_name
+    ]
+
+
+def parse_extension_map(value: str) -> dict[str, str]:
+    """Parse ".pyi=python,.gotmpl=gotmpl" into {".pyi": "python", ...}."""
+    mapping = {}
+    for item in value.split(","):
+        if not item.strip():
+            continue
+        extension, separator, name = item.partition("=")
+        extension = extension.strip()
+        if not separator or not extension:
+            raise ValueError(f"Expected '.extension=language', got '{item.strip()}'")
+        if not extension.startswith("."):
+            extension = f".{extension}"
+        mapping[extension] = name.strip().lower()
+    return mapping
+
+
+def configure_languages(disabled: str, extensions: str) -> list[str]:
+    """
+    Apply the disabled languages (comma separated) and extension overrides,
+    replacing earlier ones. Returns problems such as unknown language names;
+    those entries are ignored rather than applied.
+    """
+    problems = []
+    DISABLED_LANGUAGES.clear()
+    for name in (n.strip().lower() for n in disabled.split(",")):
+        if not name:
+            continue
+        if name in LANGUAGE_CONFIGS:
+            DISABLED_LANGUAGES.add(name)
+        else:
+            problems.append(f"Cannot disable unknown language '{name}'")
+
+    EXTENSION_OVERRIDES.clear()
+    try:
+        mapping = parse_extension_map(extensions)
+    except ValueError as e:
+        return problems + [str(e)]
+    for extension, name in mapping.items():
+        if name and name not in LANGUAGE_CONFIGS:
+            problems.append(f"Cannot map {extension} to unknown language '{name}'")
+        else:
+            EXTENSION_OVERRIDES[extension] = name
+    return problems
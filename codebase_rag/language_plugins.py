@@ -0,0 +1,214 @@
+"""Language plugins: extra Tree-sitter languages registered without code changes.
+
+A plugin bundles a grammar, the mapping of its node types onto functions,
+classes, modules and calls (a LanguageConfig), and optional named queries.
+Plugins are found through the "code_graph_rag.languages" entry point group
+and in plugin directories, where each plugin is either a Python file that
+defines PLUGIN (or PLUGINS) or a folder with a declarative plugin.toml
+pointing at a compiled grammar library.
+"""
+
+import ctypes
+import importlib.util
+import os
+import tomllib
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from importlib.metadata import entry_points
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .language_config import LANGUAGE_CONFIGS, LanguageConfig
+
+# Bumped on incompatible changes; plugins declare the version they target
+PLUGIN_API_VERSION = 1
+ENTRY_POINT_GROUP = "code_graph_rag.languages"
+PLUGIN_MANIFEST = "plugin.toml"
+USER_PLUGIN_DIR = Path("~/.config/cgr/plugins")
+
+# LanguageConfig fields a plugin.toml may set, besides name and extensions
+MANIFEST_CONFIG_FIELDS = (
+    "function_node_types",
+    "class_node_types",
+    "module_node_types",
+    "call_node_types",
+    "name_field",
+    "body_field",
+    "package_indicators",
+)
+
+
+class PluginError(ValueError):
+    """A plugin that cannot be loaded or conflicts with registered languages."""
+
+
+@dataclass
+class LanguagePlugin:
+    """A language contributed by a plugin."""
+
+    config: LanguageConfig
+    # Returns the grammar's language pointer, like tree_sitter_python.language
+    language: Callable[[], object]
+    # Query sources compiled alongside the built-in ones, e.g. "assignments"
+    queries: dict[str, str] = field(default_factory=dict)
+    api_version: int = PLUGIN_API_VERSION
+    source: str = ""  # Where the plugin was found, for `languages`
+
+
+# Plugins registered so far, by language name
+REGISTERED_PLUGINS: dict[str, LanguagePlugin] = {}
+
+
+def register_language_plugin(plugin: LanguagePlugin) -> LanguageConfig:
+    """
+    Add a plugin's language to the registry. Built-in languages cannot be
+    replaced, and file extensions another language already claims stay with it.
+    """
+    name = plugin.config.name.lower()
+    if plugin.api_version != PLUGIN_API_VERSION:
+        raise PluginError(
+            f"Plugin for {name} targets plugin API {plugin.api_version}, "
+            f"this version supports {PLUGIN_API_VERSION}"
+        )
+    if name in LANGUAGE_CONFIGS and name not in REGISTERED_PLUGINS:
+        raise PluginError(f"'{name}' is a built-in language")
+
+    claimed = {
+        extension: other.name
+        for other in LANGUAGE_CONFIGS.values()
+        if other.name != name
+        for extension in other.file_extensions
+    }
+    extensions = []
+    for extension in plugin.config.file_extensions:
+        if extension in claimed:
+            logger.warning(
+                f"Plugin for {name}: {extension} already belongs to "
+                f"{claimed[extension]}, skipping it"
+            )
+        else:
+            extensions.append(extension)
+    if not extensions:
+        raise PluginError(f"Plugin for {name} has no file extensions of its own")
+
+    plugin.config.name = name
+    plugin.config.file_extensions = extensions
+    LANGUAGE_CONFIGS[name] = plugin.config
+    REGISTERED_PLUGINS[name] = plugin
+    return plugin.config
+
+
+def plugin_directories(extra: str = "") -> list[Path]:
+    """The user plugin directory plus any listed in extra (os.pathsep separated)."""
+    directories = [USER_PLUGIN_DIR.expanduser()]
+    directories += [Path(d).expanduser() for d in extra.split(os.pathsep) if d]
+    return [d for d in directories if d.is_dir()]
+
+
+def discover_plugins(directories: list[Path]) -> list[LanguagePlugin]:
+    """Plugins from entry points and directories; broken ones are logged."""
+    plugins: list[LanguagePlugin] = []
+    for entry_point in entry_points(group=ENTRY_POINT_GROUP):
+        try:
+            found = _as_plugins(entry_point.load())
+        except Exception as e:
+            logger.warning(f"Failed to load language plugin {entry_point.name}: {e}")
+            continue
+        for plugin in found:
+            plugin.source = plugin.source or f"entry point {entry_point.value}"
+        plugins += found
+
+    for directory in directories:
+        for path in sorted(directory.iterdir()):
+            try:
+                if path.suffix == ".py":
+                    found = _load_python_plugin(path)
+                elif (path / PLUGIN_MANIFEST).is_file():
+                    found = [load_manifest_plugin(path / PLUGIN_MANIFEST)]
+                else:
+                    continue
+            except Exception as e:
+                logger.warning(f"Failed to load language plugin {path}: {e}")
+                continue
+            for plugin in found:
+                plugin.source = plugin.source or str(path)
+            plugins += found
+    return plugins
+
+
+def load_language_plugins(extra_directories: str = "") -> list[str]:
+    """Discover and register plugins once; returns the newly added languages."""
+    added = []
+    for plugin in discover_plugins(plugin_directories(extra_directories)):
+        if plugin.config.name.lower() in REGISTERED_PLUGINS:
+            continue
+        try:
+            added.append(register_language_plugin(plugin).name)
+        except PluginError as e:
+            logger.warning(f"Skipping language plugin from {plugin.source}: {e}")
+    return added
+
+
+def load_manifest_plugin(manifest_path: Path) -> LanguagePlugin:
+    """A plugin described by plugin.toml next to its compiled grammar library."""
+    manifest = tomllib.loads(manifest_path.read_text(encoding="utf-8"))
+    for key in ("name", "file_extensions", "grammar", "function_node_types"):
+        if key not in manifest:
+            raise PluginError(f"{manifest_path} is missing '{key}'")
+    name = str(manifest["name"])
+    grammar = manifest_path.parent / manifest["grammar"]
+    # Grammars compiled by the tree-sitter CLI export tree_sitter_<name>()
+    symbol = manifest.get("symbol") or f"tree_sitter_{name.replace('-', '_')}"
+    config = LanguageConfig(
+        name=name,
+        file_extensions=list(manifest["file_extensions"]),
+        function_node_types=[],
+        class_node_types=[],
+        module_node_types=[],
+    )
+    for key in MANIFEST_CONFIG_FIELDS:
+        if key in manifest:
+            setattr(config, key, manifest[key])
+    return LanguagePlugin(
+        config=config,
+        language=_shared_library_language(grammar, symbol),
+        queries={str(k): str(v) for k, v in manifest.get("queries", {}).items()},
+        api_version=int(manifest.get("api_version", PLUGIN_API_VERSION)),
+        source=str(manifest_path),
+    )
+
+
+def _shared_library_language(library: Path, symbol: str) -> Callable[[], object]:
+    def language() -> object:
+        function = getattr(ctypes.cdll.LoadLibrary(str(library)), symbol)
+        function.restype = ctypes.c_void_p
+        return function()
+
+    return language
+
+
+def _load_python_plugin(path: Path) -> list[LanguagePlugin]:
+    spec = importlib.util.spec_from_file_location(f"cgr_plugin_{path.stem}", path)
+    if spec is None or spec.loader is None:
+        raise PluginError(f"Cannot import {path}")
+    module = importlib.util.module_from_spec(spec)
+    spec.loader.exec_module(module)
+    exported = getattr(module, "PLUGINS", None) or getattr(module, "PLUGIN", None)
+    if exported is None:
+        raise PluginError(f"{path} defines neither PLUGIN nor PLUGINS")
+    return _as_plugins(exported)
+
+
+def _as_plugins(exported: Any) -> list[LanguagePlugin]:
+    """Accept a plugin, a list of them, or a callable returning either."""
+    if callable(exported):
+        exported = exported()
+    plugins = exported if isinstance(exported, list | tuple) else [exported]
+    for plugin in plugins:
+        if not isinstance(plugin, LanguagePlugin):
+            raise PluginError(
+                f"Expected a LanguagePlugin, got {type(plugin).__name__}"
+            )
+    return list(plugins)
@@ -0,0 +1,50 @@
+"""Source files too large to parse like the rest.
+
+Generated protobuf code and bundled JavaScript run to tens of megabytes, and
+their syntax trees, source text and the chunks of their bodies take many
+times that in memory, while what matters in them is mostly what they
+declare. Files above LARGE_FILE_BYTES are handled per LARGE_FILE_MODE:
+"declarations" parses them for their functions, classes and types only and
+keeps no tree for the call pass, "summarize" records their size and line
+count from a streamed read without parsing, and "skip" leaves them out.
+Files above MAX_PARSE_BYTES are summarized rather than parsed in any mode
+but "skip".
+"""
+
+import re
+from dataclasses import dataclass
+from pathlib import Path
+
+LARGE_FILE_MODES = ("skip", "summarize", "declarations")
+
+# Headers of generated code: Go's "Code generated ... DO NOT EDIT.",
+# @generated (Meta, protoc plugins) and the common autogenerated notices
+GENERATED_MARKER = re.compile(
+    rb"code generated|@generated|auto-?generated|do not edit", re.IGNORECASE
+)
+HEADER_BYTES = 2048
+READ_CHUNK_BYTES = 1 << 20
+
+
+@dataclass
+class FileSummary:
+    size_bytes: int
+    line_count: int
+    generated: bool
+
+
+def summarize_file(path: Path) -> FileSummary:
+    """Size, lines and whether a file says it is generated, read in chunks."""
+    size = lines = 0
+    header = b""
+    last = b"\n"
+    with path.open("rb") as f:
+        while chunk := f.read(READ_CHUNK_BYTES):
+            if len(header) < HEADER_BYTES:
+                header += chunk[: HEADER_BYTES - len(header)]
+            size += len(chunk)
+            lines += chunk.count(b"\n")
+            last = chunk[-1:]
+    if last != b"\n":
+        lines += 1  # A last line without a newline
+    return FileSummary(size, lines, bool(GENERATED_MARKER.search(header)))
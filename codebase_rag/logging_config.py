@@ -0,0 +1,83 @@
+"""Log output for the CLI: level, text or JSON lines, and an optional file.
+
+Ingestion binds a run ID for the whole run and the file being processed, via
+loguru's contextualize(); both appear in every record logged meanwhile, so a
+failure deep inside a large run can be traced back to its file.
+"""
+
+import json
+import sys
+from typing import Any, TextIO
+
+from loguru import logger
+
+LOG_FORMATS = ("text", "json")
+
+TEXT_FORMAT = (
+    "<green>{time:YYYY-MM-DD HH:mm:ss.SSS}</green> | <level>{level: <8}</level> | "
+    "{extra[context]}<level>{message}</level>\n{exception}"
+)
+
+
+def configure_logging(
+    level: str = "INFO",
+    log_format: str = "text",
+    log_file: str | None = None,
+    stream: TextIO | None = None,
+) -> None:
+    """Replace loguru's handlers with one for the stream and one for log_file."""
+    if log_format not in LOG_FORMATS:
+        raise ValueError(
+            f"Unknown log format '{log_format}', expected one of {LOG_FORMATS}"
+        )
+    level = level.upper()
+    logger.remove()
+    logger.configure(patcher=_add_context_prefix)
+    destinations: list[Any] = [stream or sys.stderr]
+    if log_file:
+        destinations.append(log_file)
+    for destination in destinations:
+        if log_format == "json":
+            logger.add(destination, level=level, format=_json_format)
+        else:
+            # Colors only on the terminal, never in files
+            colorize = None if destination is not log_file else False
+            logger.add(
+                destination, level=level, format=TEXT_FORMAT, colorize=colorize
+            )
+
+
+def _add_context_prefix(record: dict[str, Any]) -> None:
+    extra = record["extra"]
+    parts = [extra[key] for key in ("run_id", "file") if extra.get(key)]
+    extra["context"] = f"[{' '.join(parts)}] " if parts else ""
+
+
+def json_record(record: dict[str, Any]) -> dict[str, Any]:
+    """A loguru record flattened to the fields worth shipping to a log store."""
+    entry = {
+        "time": record["time"].isoformat(),
+        "level": record["level"].name,
+        "message": record["message"],
+        "logger": record["name"],
+        "function": record["function"],
+        "line": record["line"],
+    }
+    entry.update(
+        (key, value)
+        for key, value in record["extra"].items()
+        if key not in ("context", "json")
+    )
+    if record["exception"] is not None:
+        exc_type, exc_value, _ = record["exception"]
+        entry["exception"] = {
+            "type": exc_type.__name__ if exc_type else None,
+            "value": str(exc_value),
+        }
+    return entry
+
+
+def _json_format(record: dict[str, Any]) -> str:
+    # loguru formats the returned template, so the JSON goes through extra
+    record["extra"]["json"] = json.dumps(json_record(record), default=str)
+    return "{extra[json]}\n"
@@ -0,0 +1,5 @@
+"""Language Server Protocol front end for editors, backed by the code graph."""
+
+from .server import GraphLanguageServer
+
+__all__ = ["GraphLanguageServer"]
@@ -0,0 +1,66 @@
+"""JSON-RPC 2.0 framing used by the Language Server Protocol.
+
+Each message is a JSON body preceded by HTTP-style headers, of which only
+Content-Length is required.
+"""
+
+import json
+from typing import Any, BinaryIO
+
+# Error codes defined by JSON-RPC and the LSP specification
+PARSE_ERROR = -32700
+INVALID_REQUEST = -32600
+METHOD_NOT_FOUND = -32601
+INVALID_PARAMS = -32602
+INTERNAL_ERROR = -32603
+SERVER_NOT_INITIALIZED = -32002
+
+
+class JsonRpcError(Exception):
+    """An error reported back to the client in a response."""
+
+    def __init__(self, code: int, message: str):
+        super().__init__(message)
+        self.code = code
+        self.message = message
+
+
+def read_message(stream: BinaryIO) -> dict[str, Any] | None:
+    """Read one message, or return None when the stream is closed."""
+    headers: dict[str, str] = {}
+    while True:
+        line = stream.readline()
+        if not line:
+            return None
+        line = line.strip()
+        if not line:
+            break
+        name, _, value = line.decode("ascii").partition(":")
+        headers[name.strip().lower()] = value.strip()
+
+    try:
+        length = int(headers["content-length"])
+    except (KeyError, ValueError) as e:
+        raise JsonRpcError(INVALID_REQUEST, "Missing Content-Length header") from e
+    body = stream.read(length)
+    try:
+        message = json.loads(body)
+    except json.JSONDecodeError as e:
+        raise JsonRpcError(PARSE_ERROR, f"Invalid JSON: {e}") from e
+    if not isinstance(message, dict):
+        raise JsonRpcError(INVALID_REQUEST, "Expected a JSON object")
+    return message
+
+
+def write_message(stream: BinaryIO, message: dict[str, Any]) -> None:
+    body = json.dumps(message, separators=(",", ":")).encode("utf-8")
+    stream.write(f"Content-Length: {len(body)}\r\n\r\n".encode("ascii") + body)
+    stream.flush()
+
+
+def error_response(request_id: Any, error: JsonRpcError) -> dict[str, Any]:
+    return {
+        "jsonrpc": "2.0",
+        "id": request_id,
+        "error": {"code": error.code, "message": error.message},
+    }
@@ -0,0 +1,394 @@
+"""Language server answering navigation requests from the code graph.
+
+Definitions, references, implementations and hovers are looked up in the
+graph instead of a local index, so an editor gets navigation across every
+repository ingested into it. Module paths in the graph are relative to their
+repository and module qualified names start with the project name (the
+repository directory name); each project that should be navigable is mapped
+to a local checkout.
+"""
+
+import re
+import sys
+from collections.abc import Callable
+from pathlib import Path
+from typing import Any, BinaryIO
+from urllib.parse import unquote, urlparse
+
+from loguru import logger
+
+from ..services.graph_service import MemgraphIngestor
+from .protocol import (
+    INTERNAL_ERROR,
+    METHOD_NOT_FOUND,
+    SERVER_NOT_INITIALIZED,
+    JsonRpcError,
+    error_response,
+    read_message,
+    write_message,
+)
+
+# Definitions enclosing a line of a file, innermost first
+ENCLOSING_SYMBOLS_QUERY = """
+MATCH (m:Module {path: $path})-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+WHERE (s:Function OR s:Method OR s:Class)
+  AND (m.qualified_name = $project OR m.qualified_name STARTS WITH $project + '.')
+  AND s.start_line <= $line AND s.end_line >= $line
+RETURN s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.qualified_name AS module, m.path AS path,
+       s.start_line AS start_line, s.end_line AS end_line
+ORDER BY s.start_line DESC
+"""
+
+# Definitions with a given name, flagging those the enclosing symbol calls
+NAMED_SYMBOLS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+WHERE (s:Function OR s:Method OR s:Class) AND s.name = $name
+OPTIONAL MATCH (caller {qualified_name: $caller})-[c:CALLS]->(s)
+RETURN s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.qualified_name AS module, m.path AS path,
+       s.start_line AS start_line, s.end_line AS end_line,
+       count(c) > 0 AS called
+LIMIT 50
+"""
+
+# Functions and methods that call a symbol
+REFERENCES_QUERY = """
+MATCH (s)-[:CALLS]->({qualified_name: $qualified_name})
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+RETURN DISTINCT s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.qualified_name AS module, m.path AS path,
+       s.start_line AS start_line, s.end_line AS end_line
+"""
+
+# Subclasses, interface implementations and overriding methods
+IMPLEMENTATIONS_QUERY = """
+MATCH (s)-[:IMPLEMENTS|INHERITS_FROM|OVERRIDES*1..5]->
+      ({qualified_name: $qualified_name})
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+RETURN DISTINCT s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.qualified_name AS module, m.path AS path,
+       s.start_line AS start_line, s.end_line AS end_line
+"""
+
+# Context shown when hovering a symbol
+EXPLAIN_QUERY = """
+MATCH (s {qualified_name: $qualified_name})
+OPTIONAL MATCH (caller)-[:CALLS]->(s)
+WITH s, collect(DISTINCT caller.qualified_name) AS callers
+OPTIONAL MATCH (s)-[:CALLS]->(callee)
+WITH s, callers, collect(DISTINCT callee.qualified_name) AS callees
+OPTIONAL MATCH (test)-[:TESTS]->(s)
+WITH s, callers, callees, collect(DISTINCT test.qualified_name) AS tests
+OPTIONAL MATCH (e:Endpoint)-[:HANDLED_BY]->(s)
+RETURN labels(s)[0] AS label, s.docstring AS docstring,
+       s.cyclomatic_complexity AS complexity, callers, callees, tests,
+       collect(DISTINCT e.method + ' ' + e.route) AS endpoints
+"""
+
+IDENTIFIER = re.compile(r"[A-Za-z_][A-Za-z0-9_]*")
+
+# Names listed in a hover before the rest are summarized as a count
+HOVER_LIST_LIMIT = 5
+
+# Text documents are synchronized by sending their full content
+TEXT_DOCUMENT_SYNC_FULL = 1
+
+
+class GraphLanguageServer:
+    """Serves LSP requests over a pair of byte streams, usually stdio."""
+
+    def __init__(self, ingestor: MemgraphIngestor, projects: dict[str, Path]):
+        self.ingestor = ingestor
+        self.projects = {name: path.resolve() for name, path in projects.items()}
+        self.documents: dict[str, str] = {}  # Open document text by URI
+        self.initialized = False
+        self.shutdown_requested = False
+        self.exit_code: int | None = None
+        self.handlers: dict[str, Callable[[dict[str, Any]], Any]] = {
+            "initialize": self.initialize,
+            "initialized": lambda params: None,
+            "shutdown": self.shutdown,
+            "exit": self.exit,
+            "textDocument/didOpen": self.did_open,
+            "textDocument/didChange": self.did_change,
+            "textDocument/didClose": self.did_close,
+            "textDocument/definition": self.definition,
+            "textDocument/references": self.references,
+            "textDocument/implementation": self.implementation,
+            "textDocument/hover": self.hover,
+        }
+
+    def serve(
+        self, reader: BinaryIO | None = None, writer: BinaryIO | None = None
+    ) -> int:
+        """Handle messages (from stdio by default) until the client exits."""
+        reader = reader or sys.stdin.buffer
+        writer = writer or sys.stdout.buffer
+        while self.exit_code is None:
+            try:
+                message = read_message(reader)
+            except JsonRpcError as e:
+                write_message(writer, error_response(None, e))
+                continue
+            if message is None:  # Client went away without shutting down
+                return 1
+            response = self.handle(message)
+            if response is not None:
+                write_message(writer, response)
+        return self.exit_code
+
+    def handle(self, message: dict[str, Any]) -> dict[str, Any] | None:
+        """Handle one request or notification; return the response, if any."""
+        method = message.get("method")
+        is_request = "id" in message
+        if method is None:  # A response; this server sends no requests
+            return None
+
+        handler = self.handlers.get(method)
+        try:
+            if handler is None:
+                # Unknown notifications, e.g. $/cancelRequest, are ignored
+                if not is_request:
+                    return None
+                raise JsonRpcError(METHOD_NOT_FOUND, f"Unsupported method {method}")
+            if not self.initialized and method not in ("initialize", "exit"):
+                if not is_request:
+                    return None
+                raise JsonRpcError(SERVER_NOT_INITIALIZED, "Server not initialized")
+            result = handler(message.get("params") or {})
+        except JsonRpcError as e:
+            return error_response(message.get("id"), e) if is_request else None
+        except Exception as e:
+            logger.exception(f"LSP handler for {method} failed")
+            error = JsonRpcError(INTERNAL_ERROR, str(e))
+            return error_response(message.get("id"), error) if is_request else None
+
+        if not is_request:
+            return None
+        return {"jsonrpc": "2.0", "id": message["id"], "result": result}
+
+    # Lifecycle
+
+    def initialize(self, params: dict[str, Any]) -> dict[str, Any]:
+        roots = [folder["uri"] for folder in params.get("workspaceFolders") or []]
+        if not roots and params.get("rootUri"):
+            roots = [params["rootUri"]]
+        for uri in roots:
+            root = _uri_to_path(uri)
+            # Projects mapped explicitly take precedence over workspace folders
+            self.projects.setdefault(root.name, root)
+        logger.info(f"Navigating projects: {', '.join(sorted(self.projects))}")
+
+        self.initialized = True
+        return {
+            "capabilities": {
+                "textDocumentSync": TEXT_DOCUMENT_SYNC_FULL,
+                "definitionProvider": True,
+                "referencesProvider": True,
+                "implementationProvider": True,
+                "hoverProvider": True,
+            },
+            "serverInfo": {"name": "graph-code"},
+        }
+
+    def shutdown(self, params: dict[str, Any]) -> None:
+        self.shutdown_requested = True
+
+    def exit(self, params: dict[str, Any]) -> None:
+        self.exit_code = 0 if self.shutdown_requested else 1
+
+    # Document synchronization
+
+    def did_open(self, params: dict[str, Any]) -> None:
+        document = params["textDocument"]
+        self.documents[document["uri"]] = document["text"]
+
+    def did_change(self, params: dict[str, Any]) -> None:
+        changes = params["contentChanges"]
+        if changes:
+            self.documents[params["textDocument"]["uri"]] = changes[-1]["text"]
+
+    def did_close(self, params: dict[str, Any]) -> None:
+        self.documents.pop(params["textDocument"]["uri"], None)
+
+    # Navigation
+
+    def definition(self, params: dict[str, Any]) -> list[dict[str, Any]]:
+        symbols = self._resolve(params)
+        return self._locations(symbols)
+
+    def references(self, params: dict[str, Any]) -> list[dict[str, Any]]:
+        symbols = self._resolve(params)
+        include_declaration = params.get("context", {}).get("includeDeclaration")
+        locations = self._locations(symbols) if include_declaration else []
+        for symbol in symbols:
+            callers = self.ingestor.fetch_all(
+                REFERENCES_QUERY, {"qualified_name": symbol["qualified_name"]}
+            )
+            # CALLS edges carry no position, so find the call inside each caller
+            locations += self._locations(callers, mention=symbol["name"])
+        return locations
+
+    def implementation(self, params: dict[str, Any]) -> list[dict[str, Any]]:
+        locations = []
+        for symbol in self._resolve(params):
+            implementations = self.ingestor.fetch_all(
+                IMPLEMENTATIONS_QUERY, {"qualified_name": symbol["qualified_name"]}
+            )
+            locations += self._locations(implementations)
+        return locations
+
+    def hover(self, params: dict[str, Any]) -> dict[str, Any] | None:
+        symbols = self._resolve(params)
+        if not symbols:
+            return None
+        symbol = symbols[0]
+        rows = self.ingestor.fetch_all(
+            EXPLAIN_QUERY, {"qualified_name": symbol["qualified_name"]}
+        )
+        if not rows:
+            return None
+        return {
+            "contents": {
+                "kind": "markdown",
+                "value": explain_symbol(symbol["qualified_name"], rows[0]),
+            }
+        }
+
+    def _resolve(self, params: dict[str, Any]) -> list[dict[str, Any]]:
+        """Graph symbols the identifier under the cursor may refer to."""
+        uri = params["textDocument"]["uri"]
+        line = params["position"]["line"]
+        lines = self._document_lines(uri)
+        if line >= len(lines):
+            return []
+        name = _identifier_at(lines[line], params["position"]["character"])
+        if not name:
+            return []
+
+        enclosing: list[dict[str, Any]] = []
+        located = self._project_path(uri)
+        if located:
+            project, path = located
+            enclosing = self.ingestor.fetch_all(
+                ENCLOSING_SYMBOLS_QUERY,
+                {"path": path, "project": project, "line": line + 1},
+            )
+        for symbol in enclosing:
+            # The cursor is on the name of the definition itself
+            if symbol["name"] == name and symbol["start_line"] == line + 1:
+                return [symbol]
+
+        caller = enclosing[0]["qualified_name"] if enclosing else ""
+        candidates = self.ingestor.fetch_all(
+            NAMED_SYMBOLS_QUERY, {"name": name, "caller": caller}
+        )
+        # Prefer what the graph says is called here, then same-file definitions
+        called = [c for c in candidates if c["called"]]
+        if called:
+            return called
+        local = [c for c in candidates if located and c["path"] == located[1]]
+        return local or candidates
+
+    def _locations(
+        self, symbols: list[dict[str, Any]], mention: str | None = None
+    ) -> list[dict[str, Any]]:
+        """
+        LSP locations of symbols' names, or of the first mention of another
+        name in their bodies (falling back to the definition line).
+        """
+        locations = []
+        for symbol in symbols:
+            file_path = self._file_path(symbol)
+            if file_path is None:
+                continue
+            uri = file_path.as_uri()
+            lines = self._document_lines(uri)
+            line, column = symbol["start_line"] - 1, 0
+            name = mention or symbol["name"]
+            # Mentions are searched in the body, below the definition line
+            first, last = (line, line + 1)
+            if mention:
+                first, last = line + 1, symbol["end_line"]
+            for index in range(first, min(last, len(lines))):
+                match = re.search(rf"\b{re.escape(name)}\b", lines[index])
+                if match:
+                    line, column = index, match.start()
+                    break
+            locations.append(
+                {
+                    "uri": uri,
+                    "range": {
+                        "start": {"line": line, "character": column},
+                        "end": {"line": line, "character": column + len(name)},
+                    },
+                }
+            )
+        return locations
+
+    def _project_path(self, uri: str) -> tuple[str, str] | None:
+        """The project and repository-relative path of a document."""
+        path = _uri_to_path(uri)
+        for project, root in self.projects.items():
+            if path.is_relative_to(root):
+                return project, path.relative_to(root).as_posix()
+        return None
+
+    def _file_path(self, symbol: dict[str, Any]) -> Path | None:
+        project = symbol["module"].split(".", 1)[0]
+        root = self.projects.get(project)
+        if root is None or not symbol.get("path"):
+            return None
+        return root / symbol["path"]
+
+    def _document_lines(self, uri: str) -> list[str]:
+        if uri in self.documents:
+            return self.documents[uri].splitlines()
+        try:
+            return _uri_to_path(uri).read_text(encoding="utf-8").splitlines()
+        except (OSError, UnicodeDecodeError):
+            return []
+
+
+def explain_symbol(qualified_name: str, context: dict[str, Any]) -> str:
+    """Markdown describing a symbol and its surroundings in the graph."""
+    parts = [f"**{context['label']}** `{qualified_name}`"]
+    if context.get("docstring"):
+        parts.append(context["docstring"].strip())
+
+    facts = []
+    for title, key in (
+        ("Called by", "callers"),
+        ("Calls", "callees"),
+        ("Endpoints", "endpoints"),
+    ):
+        if context.get(key):
+            facts.append(f"- {title}: {_summarize(context[key])}")
+    if context["label"] in ("Function", "Method"):
+        tests = context.get("tests") or []
+        facts.append(f"- Tests: {_summarize(tests)}" if tests else "- Tests: none")
+    if context.get("complexity"):
+        facts.append(f"- Cyclomatic complexity: {context['complexity']}")
+    if facts:
+        parts.append("\n".join(facts))
+    return "\n\n".join(parts)
+
+
+def _summarize(names: list[str]) -> str:
+    shown = ", ".join(f"`{name}`" for name in sorted(names)[:HOVER_LIST_LIMIT])
+    if len(names) > HOVER_LIST_LIMIT:
+        shown += f" and {len(names) - HOVER_LIST_LIMIT} more"
+    return shown
+
+
+def _identifier_at(line: str, character: int) -> str | None:
+    for match in IDENTIFIER.finditer(line):
+        if match.start() <= character <= match.end():
+            return match.group()
+    return None
+
+
+def _uri_to_path(uri: str) -> Path:
+    return Path(unquote(urlparse(uri).path)).resolve()
+
@@ -1,15 +1,22 @@
 import asyncio
+import getpass
 import json
 import shlex
 import shutil
+import subprocess
 import sys
+import tarfile
+import threading
 import uuid
+from collections.abc import Awaitable, Callable, Iterator
+from contextlib import contextmanager
+from dataclasses import asdict
 from pathlib import Path
-from typing import Any
+from typing import Any, TextIO
 
 import typer
 from loguru import logger
-from prompt_toolkit import prompt
+from prompt_toolkit import PromptSession, prompt
 from prompt_toolkit.formatted_text import HTML
 from prompt_toolkit.key_binding import KeyBindings
 from prompt_toolkit.shortcuts import print_formatted_text
@@ -19,19 +26,262 @@ from rich.panel import Panel
 from rich.prompt import Confirm
 from rich.table import Table
 from rich.text import Text
+from tree_sitter import Language
 
-from .config import detect_provider_from_model, settings
+from .analysis.api_surface import VERSION_TAG, ApiDiff, api_snapshot, diff_api
+from .analysis.breaking_changes import classify_diff
+from .analysis.call_depth import CallDepthAnalyzer
+from .analysis.changelog import build_changelog, commits_between
+from .analysis.clones import CloneAnalyzer
+from .analysis.commit_message import CommitMessageGenerator
+from .analysis.concurrency import ConcurrencyHazardAnalyzer
+from .analysis.crashes import CrashMapper, collect_exports, load_export_file
+from .analysis.dead_code import DeadCodeAnalyzer
+from .analysis.doc_coverage import DocCoverageAnalyzer
+from .analysis.git_history import GitHistoryLoader
+from .analysis.go_build import KNOWN_ARCH, KNOWN_OS, BuildConfig
+from .analysis.go_coverage import (
+    CoverageLoader,
+    collect_profiles,
+    parse_cover_profile,
+)
+from .analysis.govulncheck import (
+    GovulncheckLoader,
+    parse_govulncheck_json,
+    run_govulncheck,
+)
+from .analysis.hotspots import HotspotAnalyzer
+from .analysis.impact import (
+    DEFAULT_MAX_DEPTH,
+    IMPACT_COMMENT_MARKER,
+    ImpactAnalyzer,
+    ImpactReport,
+    go_test_runs,
+    impact_comment,
+)
+from .analysis.import_cycles import ImportCycleAnalyzer
+from .analysis.issues import IssueLinker
+from .analysis.layering import LayeringAnalyzer, load_layers
+from .analysis.logs import LogAnalyzer
+from .analysis.lsp_resolution import (
+    LANGUAGE_SERVERS,
+    CallResolver,
+    LanguageServerClient,
+    LanguageServerError,
+)
+from .analysis.panic_reachability import PanicReachabilityAnalyzer
+from .analysis.precise_index import PreciseIndexImporter, load_index
+from .analysis.review import (
+    ReviewAssistant,
+    ReviewReport,
+    diff_between_refs,
+    parse_unified_diff,
+    staged_diff,
+)
+from .analysis.review_checklist import ReviewChecklistBuilder
+from .analysis.sarif import (
+    build_sarif_log,
+    dead_code_findings,
+    import_cycle_findings,
+    layering_findings,
+    smell_findings,
+    taint_findings,
+    unchecked_error_findings,
+    vulnerability_findings,
+)
+from .analysis.sbom import SbomBuilder, to_cyclonedx, to_spdx
+from .analysis.scip_export import ScipExporter
+from .analysis.smells import SmellAnalyzer, SmellThresholds
+from .analysis.stack_traces import StackTraceResolver
+from .analysis.taint import TaintAnalyzer, TaintRules
+from .analysis.test_gaps import TestGapAnalyzer
+from .analysis.test_results import (
+    TestResultAnalyzer,
+    collect_reports,
+    parse_junit_xml,
+)
+from .analysis.todos import TodoAnalyzer
+from .analysis.traces import TraceMapper, collect_trace_files, parse_otlp_json
+from .analysis.unchecked_errors import UncheckedErrorAnalyzer
+from .analysis.unused_dependencies import UnusedDependencyAnalyzer
+from .analysis.vulnerabilities import SEVERITY_RANK, VulnerabilityAnalyzer
+from .architecture_report import build_report
+from .citations import CitationResolver, CitedAnswer
+from .completion import (
+    choices,
+    complete_language,
+    complete_profile,
+    complete_symbol,
+    write_symbol_index,
+)
+from .config import (
+    CONFIG_FILE_NAMES,
+    ConfigFileError,
+    active_config_file,
+    detect_provider_from_model,
+    find_config_file,
+    load_settings,
+    local_endpoint,
+    read_config_file,
+    settings,
+    split_model_id,
+    split_names,
+    validate_config_file,
+)
+from .context_expansion import ExpansionPolicy
+from .conversations import (
+    ConversationStore,
+    conversations,
+    history_messages,
+    investigations,
+)
+from .cypher_templates import TemplateRunner
+from .doctor import (
+    FAIL,
+    OK,
+    WARN,
+    check_database,
+    check_grammars,
+    check_providers,
+    check_resources,
+    checks_to_dict,
+)
+from .evaluation import Retrieval, evaluate, load_golden_set, retrieved_nodes
+from .explorer import GraphExplorer, run_explorer
+from .fsck import check_graph, findings_to_dict, repair
+from .graph_export import (
+    EXPORT_FORMATS,
+    GraphFilter,
+    format_for,
+    select_graph,
+    write_graph,
+)
+from .graph_diff import (
+    GraphDiff,
+    GraphRecord,
+    diff_graphs,
+    graph_at_revision,
+    graph_from_snapshot,
+)
 from .graph_updater import GraphUpdater, MemgraphIngestor
-from .parser_loader import load_parsers
-from .services.llm import CypherGenerator, create_rag_orchestrator
+from .ingest_checkpoint import IngestCheckpoint
+from .ingest_progress import ConsoleProgress
+from .ingestion_report import IngestionReport, store_run_summary, write_report
+from .logging_config import configure_logging
+from .lsp import GraphLanguageServer
+from .multi_repo import (
+    WorkspaceRepository,
+    cross_repository_callers,
+    ingest_workspace,
+    load_repositories,
+    repository_dependencies,
+    repository_entry,
+)
+from .language_config import (
+    DISABLED_LANGUAGES,
+    LANGUAGE_CONFIGS,
+    configure_languages,
+    language_extensions,
+)
+from .language_plugins import REGISTERED_PLUGINS, load_language_plugins
+from .generated_code import GENERATED_CODE_MODES
+from .large_files import LARGE_FILE_MODES
+from .parse_cache import ParseCache
+from .parser_loader import LANGUAGE_LIBRARIES, load_parsers
+from .query_cache import GraphQueryCache
+from .repl import (
+    create_session,
+    parse_slash_command,
+    rows_table,
+    run_slash_command,
+)
+from .saved_queries import (
+    SavedQuery,
+    parse_arguments,
+    save_query,
+    saved_queries,
+)
+from .server import GraphServer
+from .server.api import create_api_routes
+from .server.auth import ROLE_NAMES, Role, TokenRegistry, add_token
+from .server.bots import (
+    BotProject,
+    ChannelScopes,
+    CodeQuestionBot,
+    create_bot_routes,
+    scoped_question,
+)
+from .server.editor import create_editor_routes
+from .server.query import create_query_routes
+from .server.repositories import TOKEN_USERNAMES, RepositoryMirror
+from .server.scheduler import (
+    RunHistory,
+    Scheduler,
+    create_schedule_routes,
+    load_jobs,
+)
+from .server.webhooks import (
+    PullRequestEvent,
+    PushSynchronizer,
+    create_webhook_routes,
+)
+from .semantic_search import EmbeddingMismatchError, SemanticIndex
+from .services.dry_run import DryRunIngestor, DryRunReport
+from .services.embeddings import create_embedder
+from .services.issue_trackers import (
+    GitHubIssueTracker,
+    IssueTracker,
+    JiraIssueTracker,
+)
+from .services.llm import (
+    CypherGenerator,
+    LLMGenerationError,
+    create_rag_orchestrator,
+)
+from .services.rerankers import create_reranker
+from .services.review_publishers import (
+    GitHubReviewPublisher,
+    GitLabReviewPublisher,
+    ReviewPublisher,
+)
+from .shards import merge_shards as load_shards
+from .shards import write_shard
+from .snapshots import restore_snapshot, save_snapshot
+from .streaming import ConsoleStream, stream_run
+from .symbol_search import SymbolIndex
+from .token_budget import TokenBudget
 from .tools.code_retrieval import CodeRetriever, create_code_retrieval_tool
-from .tools.codebase_query import create_query_tool
+from .tools.codebase_query import (
+    create_query_tool,
+    create_saved_query_tool,
+    create_template_tool,
+)
 from .tools.directory_lister import DirectoryLister, create_directory_lister_tool
 from .tools.document_analyzer import DocumentAnalyzer, create_document_analyzer_tool
 from .tools.file_editor import FileEditor, create_file_editor_tool
 from .tools.file_reader import FileReader, create_file_reader_tool
 from .tools.file_writer import FileWriter, create_file_writer_tool
+from .tools.semantic_search import create_semantic_search_tool
 from .tools.shell_command import ShellCommander, create_shell_command_tool
+from .version_control.changes import changed_paths
+from .version_control.git_analyzer import GitAnalyzer
+from .watcher import DEFAULT_DEBOUNCE_SECONDS, watch_repository
+from .workspace import (
+    CONFIG_FILE_NAME,
+    RAGIGNORE,
+    detect_languages,
+    ignore_candidates,
+    provision_schema,
+    render_config,
+    render_ragignore,
+)
+
+# Sections of `--help`, so the command list reads by task
+GRAPH_PANEL = "Build the graph"
+INSIGHT_PANEL = "Analyze the code"
+REVIEW_PANEL = "Review changes"
+INTEGRATIONS_PANEL = "Servers and editors"
+SETUP_PANEL = "Setup"
 
 app = typer.Typer(
     name="graph-code",
@@ -40,10 +290,90 @@ app = typer.Typer(
     "graphs, and enables natural language querying of codebase structure and "
     "relationships.",
     no_args_is_help=True,
-    add_completion=False,
+    rich_markup_mode="rich",
+)
+analyze_app = typer.Typer(
+    help="Run graph-backed analyses over an ingested codebase.",
+    no_args_is_help=True,
+)
+app.add_typer(analyze_app, name="analyze", rich_help_panel=INSIGHT_PANEL)
+config_app = typer.Typer(
+    help="Inspect and validate the config file and its profiles.",
+    no_args_is_help=True,
+)
+app.add_typer(config_app, name="config", rich_help_panel=SETUP_PANEL)
+graph_app = typer.Typer(
+    help="Compare the graphs of two revisions or snapshots.",
+    no_args_is_help=True,
 )
+app.add_typer(graph_app, name="graph", rich_help_panel=INSIGHT_PANEL)
+workspace_app = typer.Typer(
+    help="Ingest several repositories into one graph and query across them.",
+    no_args_is_help=True,
+)
+app.add_typer(workspace_app, name="workspace", rich_help_panel=GRAPH_PANEL)
+query_app = typer.Typer(
+    help="Run and save named queries kept in the config file.",
+    no_args_is_help=True,
+)
+app.add_typer(query_app, name="query", rich_help_panel=INSIGHT_PANEL)
 console = Console(width=None, force_terminal=True)
 
+# Settings whose values `config show` masks unless asked not to
+SECRET_SETTING_MARKERS = ("KEY", "TOKEN", "SECRET", "PASSWORD")
+
+
+@app.callback()
+def main_options(
+    config_file: str | None = typer.Option(
+        None,
+        "--config",
+        help="Config file with settings and profiles (default: .cgr.toml or "
+        ".cgr.yaml in this or a parent directory, then ~/.config/cgr/config.toml)",
+    ),
+    profile: str | None = typer.Option(
+        None,
+        "--profile",
+        help="Config profile to use (default: CGR_PROFILE or the file's "
+        "default_profile)",
+        autocompletion=complete_profile,
+    ),
+    log_level: str | None = typer.Option(
+        None,
+        "--log-level",
+        help="DEBUG, INFO, WARNING or ERROR (default: INFO)",
+        autocompletion=choices("DEBUG", "INFO", "WARNING", "ERROR"),
+    ),
+    log_json: bool = typer.Option(False, "--log-json", help="Write logs as JSON lines"),
+    log_file: str | None = typer.Option(
+        None, "--log-file", help="Also append logs to this file"
+    ),
+) -> None:
+    # Without --config or --profile, settings were loaded from the defaults
+    if config_file is not None or profile is not None:
+        try:
+            load_settings(Path(config_file) if config_file else None, profile)
+        except ConfigFileError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+    if log_level:
+        settings.LOG_LEVEL = log_level
+    if log_json:
+        settings.LOG_FORMAT = "json"
+    if log_file:
+        settings.LOG_FILE = log_file
+    _configure_logging()
+
+
+def _configure_logging(stream: TextIO | None = None) -> None:
+    try:
+        configure_logging(
+            settings.LOG_LEVEL, settings.LOG_FORMAT, settings.LOG_FILE, stream
+        )
+    except ValueError as e:  # Unknown level or format
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+
 
 def _handle_chat_images(question: str, project_root: Path) -> str:
     """
@@ -119,8 +449,13 @@ def _handle_chat_images(question: str, project_root: Path) -> str:
     return updated_question
 
 
-def get_multiline_input(prompt_text: str = "Ask a question") -> str:
-    """Get multiline input from user with Ctrl+J to submit."""
+def get_multiline_input(
+    prompt_text: str = "Ask a question", session: PromptSession | None = None
+) -> str:
+    """
+    Get multiline input from user with Ctrl+J to submit, through a session
+    for its history and completion when given one.
+    """
     bindings = KeyBindings()
 
     @bindings.add("c-j")
@@ -149,7 +484,7 @@ def get_multiline_input(prompt_text: str = "Ask a question") -> str:
     )
 
     # Use simple prompt without formatting to avoid alignment issues
-    result = prompt(
+    result = (session.prompt if session else prompt)(
         "",
         multiline=True,
         key_bindings=bindings,
@@ -161,10 +496,20 @@ def get_multiline_input(prompt_text: str = "Ask a question") -> str:
 
 
 async def run_chat_loop(
-    rag_agent: Any, message_history: list[Any], project_root: Path
+    rag_agent: Any,
+    message_history: list[Any],
+    project_root: Path,
+    citations: CitationResolver | None = None,
+    citation_style: str = "links",
+    memory: ConversationStore | None = None,
+    stream: bool = False,
+    ingestor: Any = None,
 ) -> None:
     """Runs the main chat loop."""
     question = ""
+    session = create_session()
+    # Symbols picked in /explore, put before the next question
+    pinned: list[str] = []
     while True:
         try:
             # If the last response was a confirmation request, use a confirm prompt
@@ -176,33 +521,67 @@ async def run_chat_loop(
                     console.print("[bold yellow]Operation cancelled.[/bold yellow]")
             else:
                 question = await asyncio.to_thread(
-                    get_multiline_input, "[bold cyan]Ask a question[/bold cyan]"
+                    get_multiline_input,
+                    "[bold cyan]Ask a question[/bold cyan]",
+                    session,
                 )
 
             if question.lower() in ["exit", "quit"]:
                 break
             if not question.strip():
                 continue
+            command = parse_slash_command(question)
+            if command is not None:
+                if command.name == "/exit":
+                    break
+                note = await asyncio.to_thread(
+                    run_slash_command, command, ingestor, console, project_root
+                )
+                if note:
+                    pinned.append(note)
+                question = ""
+                continue
 
             # Handle images in the question
             question = _handle_chat_images(question, project_root)
+            asked = question
+            if pinned:
+                question = "\n".join([*pinned, question])
+                pinned.clear()
+            if memory is not None:
+                question = memory.with_context(question)
 
-            with console.status("[bold green]Thinking...[/bold green]"):
-                response = await rag_agent.run(
-                    question, message_history=message_history
+            if stream:
+                response = await stream_run(
+                    rag_agent, question, message_history, ConsoleStream(console)
                 )
+            else:
+                with console.status("[bold green]Thinking...[/bold green]"):
+                    response = await rag_agent.run(
+                        question, message_history=message_history
+                    )
 
             # Store the agent's raw output to check for confirmation requests
             question = response.output
-            markdown_response = Markdown(question)
-            console.print(
-                Panel(
-                    markdown_response,
-                    title="[bold green]Final Answer[/bold green]",
-                    border_style="green",
+            if not stream:
+                console.print(
+                    Panel(
+                        Markdown(question),
+                        title="[bold green]Final Answer[/bold green]",
+                        border_style="green",
+                    )
                 )
-            )
+            cited = CitedAnswer(question)
+            if citations is not None and "[y/n]" not in question:
+                cited = citations.cite(question)
+                if citation_style != "none":
+                    _print_citations(cited, citation_style, project_root)
             message_history.extend(response.new_messages())
+            if memory is not None:
+                try:
+                    memory.record(asked, cited)
+                except Exception as e:
+                    logger.warning(f"Could not save the exchange: {e}")
 
         except KeyboardInterrupt:
             break
@@ -211,6 +590,34 @@ async def run_chat_loop(
             console.print(f"[bold red]An unexpected error occurred: {e}[/bold red]")
 
 
+def _print_citations(answer: CitedAnswer, style: str, project_root: Path) -> None:
+    """The sources of an answer, as a table of links or as JSON."""
+    if style == "json":
+        print(json.dumps(answer.to_dict(), indent=2))
+        return
+    if not answer.citations:
+        return
+    table = Table(title="Sources", show_lines=False)
+    table.add_column("Claim", style="dim", overflow="fold")
+    table.add_column("Cites", style="cyan")
+    table.add_column("Location", style="magenta")
+    table.add_column("Node", justify="right")
+    for citation in answer.citations:
+        url = citation.url(project_root, settings.CITATION_BASE_URL)
+        location = (
+            f"[link={url}]{citation.location}[/link]"
+            if url
+            else "[red]not in the graph[/red]"
+        )
+        table.add_row(
+            citation.claim,
+            citation.qualified_name or citation.reference,
+            location,
+            "" if citation.node_id is None else str(citation.node_id),
+        )
+    console.print(table)
+
+
 def _update_model_settings(
     orchestrator_model: str | None,
     cypher_model: str | None,
@@ -225,6 +632,18 @@ def _update_model_settings(
         settings.set_cypher_model(cypher_model)
 
 
+def _add_model_rows(table: Table) -> None:
+    """The active models, with the server of each local one."""
+    for role, model in (
+        ("Orchestrator", settings.active_orchestrator_model),
+        ("Cypher", settings.active_cypher_model),
+    ):
+        provider = detect_provider_from_model(model)
+        table.add_row(f"{role} Model", f"{model} ({provider})")
+        if provider == "local":
+            table.add_row(f"{role} Endpoint", local_endpoint(settings, role))
+
+
 def _export_graph_to_file(ingestor: MemgraphIngestor, output: str) -> bool:
     """
     Export graph data to a JSON file.
@@ -268,7 +687,12 @@ def _initialize_services_and_agent(repo_path: str, ingestor: MemgraphIngestor) -
     settings.validate_for_usage()
 
     cypher_generator = CypherGenerator()
-    code_retriever = CodeRetriever(project_root=repo_path, ingestor=ingestor)
+    code_retriever = CodeRetriever(
+        project_root=repo_path,
+        ingestor=ingestor,
+        expansion=_expansion_policy(),
+        budget=_token_budget(),
+    )
     file_reader = FileReader(project_root=repo_path)
     file_writer = FileWriter(project_root=repo_path)
     file_editor = FileEditor(project_root=repo_path)
@@ -278,7 +702,6 @@ def _initialize_services_and_agent(repo_path: str, ingestor: MemgraphIngestor) -
     directory_lister = DirectoryLister(project_root=repo_path)
     document_analyzer = DocumentAnalyzer(project_root=repo_path)
 
-    query_tool = create_query_tool(ingestor, cypher_generator, console)
     code_tool = create_code_retrieval_tool(code_retriever)
     file_reader_tool = create_file_reader_tool(file_reader)
     file_writer_tool = create_file_writer_tool(file_writer)
@@ -289,7 +712,8 @@ def _initialize_services_and_agent(repo_path: str, ingestor: MemgraphIngestor) -
 
     rag_agent = create_rag_orchestrator(
         tools=[
-            query_tool,
+            *_graph_query_tools(ingestor, cypher_generator),
+            *_semantic_search_tools(ingestor, repo_path),
             code_tool,
             file_reader_tool,
             file_writer_tool,
@@ -302,10 +726,77 @@ def _initialize_services_and_agent(repo_path: str, ingestor: MemgraphIngestor) -
     return rag_agent
 
 
-async def main_async(repo_path: str) -> None:
+def _expansion_policy() -> ExpansionPolicy | None:
+    """The CONTEXT_EXPANSION policy for retrieved code, or None if invalid."""
+    try:
+        return ExpansionPolicy.parse(
+            settings.CONTEXT_EXPANSION, settings.CONTEXT_EXPANSION_LIMIT
+        )
+    except ValueError as e:
+        logger.warning(f"Retrieved code is not expanded: {e}")
+        return None
+
+
+def _token_budget() -> TokenBudget:
+    """The tokens a tool result may use in the orchestrator model's context."""
+    return TokenBudget.for_model(
+        split_model_id(settings.active_orchestrator_model)[1],
+        settings.CONTEXT_WINDOW_TOKENS,
+        settings.RETRIEVAL_BUDGET_SHARE,
+        settings.TOKENIZER_MODEL_ID,
+    )
+
+
+def _graph_query_tools(
+    ingestor: MemgraphIngestor, cypher_generator: CypherGenerator
+) -> list[Any]:
+    """
+    The template tool, the free-form query tool and, when the config file
+    has any, the saved query tool, sharing symbol names.
+    """
+    runner = TemplateRunner(ingestor)
+    budget = _token_budget()
+    tools = [
+        create_template_tool(runner, budget),
+        create_query_tool(ingestor, cypher_generator, console, runner, budget),
+    ]
+    try:
+        queries = saved_queries()
+    except ValueError as e:
+        logger.warning(f"Saved queries unavailable: {e}")
+        queries = {}
+    if queries:
+        tools.append(create_saved_query_tool(runner, queries, budget))
+    return tools
+
+
+def _semantic_search_tools(ingestor: MemgraphIngestor, repo_path: str) -> list[Any]:
+    """The semantic search tool, or none when the embedder cannot be created."""
+    try:
+        embedder = create_embedder()
+    except (ValueError, ImportError) as e:
+        logger.warning(f"Semantic code search unavailable: {e}")
+        return []
+    try:
+        reranker = create_reranker()
+    except Exception as e:
+        logger.warning(f"Semantic search results are not reranked: {e}")
+        reranker = None
+    index = SemanticIndex(
+        ingestor, embedder, Path(repo_path), reranker, settings.RERANK_TOP_K
+    )
+    return [create_semantic_search_tool(index, _token_budget())]
+
+
+async def main_async(
+    repo_path: str,
+    citation_style: str = "links",
+    memory: bool = True,
+    conversation_id: str | None = None,
+    stream: bool = False,
+) -> None:
     """Initializes services and runs the main application loop."""
-    logger.remove()
-    logger.add(sys.stdout, format="{time:YYYY-MM-DD HH:mm:ss.SSS} | {message}")
+    _configure_logging(sys.stdout)
 
     # Clean up temp directory on startup
     project_root = Path(repo_path).resolve()
@@ -321,38 +812,61 @@ async def main_async(repo_path: str) -> None:
     table.add_column("Configuration", style="cyan")
     table.add_column("Value", style="magenta")
 
-    orchestrator_model = settings.active_orchestrator_model
-    orchestrator_provider = detect_provider_from_model(orchestrator_model)
-    table.add_row(
-        "Orchestrator Model", f"{orchestrator_model} ({orchestrator_provider})"
-    )
-
-    cypher_model = settings.active_cypher_model
-    cypher_provider = detect_provider_from_model(cypher_model)
-    table.add_row("Cypher Model", f"{cypher_model} ({cypher_provider})")
-
-    # Show local endpoint if any model is using local provider
-    if orchestrator_provider == "local" or cypher_provider == "local":
-        table.add_row("Local Model Endpoint", str(settings.LOCAL_MODEL_ENDPOINT))
+    _add_model_rows(table)
     table.add_row("Target Repository", repo_path)
     console.print(table)
 
+    query_cache = None
+    if settings.QUERY_CACHE_SIZE:
+        query_cache = GraphQueryCache(settings.QUERY_CACHE_SIZE)
     with MemgraphIngestor(
-        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+        host=settings.MEMGRAPH_HOST,
+        port=settings.MEMGRAPH_PORT,
+        query_cache=query_cache,
     ) as ingestor:
         console.print("[bold green]Successfully connected to Memgraph.[/bold green]")
         console.print(
             Panel(
-                "[bold yellow]Ask questions about your codebase graph. Type 'exit' or 'quit' to end.[/bold yellow]",
+                "[bold yellow]Ask questions about your codebase graph. Tab completes "
+                "symbol names, /help lists commands such as /cypher and /explore, "
+                "and 'exit' or 'quit' ends the session.[/bold yellow]",
                 border_style="yellow",
             )
         )
 
         rag_agent = _initialize_services_and_agent(repo_path, ingestor)
-        await run_chat_loop(rag_agent, [], project_root)
+        store = None
+        history: list[Any] = []
+        if memory:
+            store = ConversationStore(
+                ingestor, conversation_id, project_root.name, getpass.getuser()
+            )
+            if conversation_id:
+                history = history_messages(store.resume())
+            console.print(
+                f"[dim]Conversation {store.conversation_id}"
+                + (f", resumed after {store.turns} questions" if history else "")
+                + "[/dim]"
+            )
+        # Answers are cited for the conversation's references even unshown
+        citations = (
+            CitationResolver(ingestor)
+            if citation_style != "none" or store is not None
+            else None
+        )
+        await run_chat_loop(
+            rag_agent,
+            history,
+            project_root,
+            citations,
+            citation_style,
+            store,
+            stream,
+            ingestor,
+        )
 
 
-@app.command()
+@app.command(rich_help_panel=GRAPH_PANEL)
 def start(
     repo_path: str | None = typer.Option(
         None, "--repo-path", help="Path to the target repository for code retrieval"
@@ -382,12 +896,44 @@ def start(
     parallel: bool = typer.Option(
         False,
         "--parallel",
-        help="Use parallel processing for faster ingestion (REQ-SCL-2)",
+        help="Parse files on a pool of worker threads for faster ingestion",
     ),
     workers: int | None = typer.Option(
         None,
         "--workers",
-        help="Number of parallel workers (default: 80% of CPU cores)",
+        help="Number of parsing threads with --parallel (default: 80% of CPU cores)",
+    ),
+    batch_size: int | None = typer.Option(
+        None,
+        "--batch-size",
+        help="Nodes or relationships per batched graph write (default: "
+        "GRAPH_BATCH_SIZE)",
+    ),
+    parse_cache: bool = typer.Option(
+        True,
+        "--parse-cache/--no-parse-cache",
+        help="Replay files unchanged since the last ingestion from "
+        "PARSE_CACHE_DIR instead of parsing them",
+    ),
+    large_files: str | None = typer.Option(
+        None,
+        "--large-files",
+        help="Files above LARGE_FILE_BYTES: parse their declarations only, "
+        "summarize them unparsed or skip them (default: LARGE_FILE_MODE)",
+        autocompletion=choices(*LARGE_FILE_MODES),
+    ),
+    generated: str | None = typer.Option(
+        None,
+        "--generated",
+        help="Generated code: ingest it fully, parse its declarations only or "
+        "skip it (default: GENERATED_CODE_MODE)",
+        autocompletion=choices(*GENERATED_CODE_MODES),
+    ),
+    resume: bool = typer.Option(
+        False,
+        "--resume",
+        help="Continue an interrupted --update-graph from its last checkpoint "
+        "in CHECKPOINT_DIR, without writing again the files it stored",
     ),
     folder_filter: str | None = typer.Option(
         None,
@@ -404,9 +950,106 @@ def start(
         "--skip-tests",
         help="Skip test files during ingestion (REQ-SCL-1)",
     ),
+    goos: str | None = typer.Option(
+        None,
+        "--goos",
+        help="Only ingest Go files built for this GOOS (default linux when "
+        "--goarch or --build-tags is given)",
+    ),
+    goarch: str | None = typer.Option(
+        None, "--goarch", help="Only ingest Go files built for this GOARCH"
+    ),
+    build_tags: str | None = typer.Option(
+        None,
+        "--build-tags",
+        help="Comma-separated Go build tags to set with --goos/--goarch, "
+        "e.g. integration,cgo",
+    ),
+    report_file: str | None = typer.Option(
+        None,
+        "--report",
+        help="Write the ingestion report to this JSON file "
+        "(default: a new file in INGESTION_REPORT_DIR)",
+    ),
+    private: bool = typer.Option(
+        False,
+        "--private",
+        help="Store hashes instead of docstrings, comments and literal values "
+        "(also PRIVATE_INGESTION)",
+    ),
+    dry_run: bool = typer.Option(
+        False,
+        "--dry-run",
+        help="Parse the repository and report what would be written, "
+        "without connecting to the database (requires --update-graph)",
+    ),
+    sample: int = typer.Option(
+        0,
+        "--sample",
+        min=0,
+        help="With --dry-run, show this many example nodes per label and "
+        "relationships per type",
+    ),
+    verbose: bool = typer.Option(
+        False,
+        "--verbose",
+        "-v",
+        help="With --dry-run, list every parsed file with the nodes found in it",
+    ),
+    citations: str = typer.Option(
+        "links",
+        "--citations",
+        help="After each answer, list the file, lines and graph node of the "
+        "code each claim cites, as clickable links or JSON",
+        autocompletion=choices("links", "json", "none"),
+    ),
+    memory: bool = typer.Option(
+        True,
+        "--memory/--no-memory",
+        help="Keep the session in the graph, linked to the code the answers "
+        "cite (off when CONVERSATION_MEMORY is false)",
+    ),
+    conversation: str | None = typer.Option(
+        None,
+        "--conversation",
+        help="Resume a conversation kept in the graph, by its id",
+    ),
+    stream: bool = typer.Option(
+        True,
+        "--stream/--no-stream",
+        help="Show tool calls and the answer as they are made, rather than the "
+        "answer once complete (off when output is not a terminal)",
+    ),
+    watch_files: bool = typer.Option(
+        False,
+        "--watch",
+        help="Update the graph while chatting as files of the repository change",
+    ),
 ) -> None:
     """Starts the Codebase RAG CLI."""
     target_repo_path = repo_path or settings.TARGET_REPO_PATH
+    if citations not in ("links", "json", "none"):
+        console.print(f"[bold red]Error: unknown citations '{citations}'[/bold red]")
+        raise typer.Exit(1)
+    if large_files and large_files not in LARGE_FILE_MODES:
+        console.print(
+            f"[bold red]Error: --large-files is one of "
+            f"{', '.join(LARGE_FILE_MODES)}[/bold red]"
+        )
+        raise typer.Exit(1)
+    if generated and generated not in GENERATED_CODE_MODES:
+        console.print(
+            f"[bold red]Error: --generated is one of "
+            f"{', '.join(GENERATED_CODE_MODES)}[/bold red]"
+        )
+        raise typer.Exit(1)
+    memory = memory and settings.CONVERSATION_MEMORY
+    if conversation and not memory:
+        console.print(
+            "[bold red]Error: --conversation needs conversation memory, "
+            "turned off by --no-memory or CONVERSATION_MEMORY.[/bold red]"
+        )
+        raise typer.Exit(1)
 
     # Validate output option usage
     if output and not update_graph:
@@ -414,36 +1057,42 @@ def start(
             "[bold red]Error: --output/-o option requires --update-graph to be specified.[/bold red]"
         )
         raise typer.Exit(1)
-
-    _update_model_settings(orchestrator_model, cypher_model)
-
-    if update_graph:
-        repo_to_update = Path(target_repo_path)
+    if dry_run and (not update_graph or output or clean):
         console.print(
-            f"[bold green]Updating knowledge graph for: {repo_to_update}[/bold green]"
+            "[bold red]Error: --dry-run requires --update-graph and cannot be "
+            "combined with --output or --clean.[/bold red]"
         )
+        raise typer.Exit(1)
+    if (sample or verbose) and not dry_run:
+        console.print(
+            "[bold red]Error: --sample and --verbose describe a --dry-run."
+            "[/bold red]"
+        )
+        raise typer.Exit(1)
+    if resume and (not update_graph or dry_run or clean):
+        console.print(
+            "[bold red]Error: --resume requires --update-graph and cannot be "
+            "combined with --dry-run or --clean.[/bold red]"
+        )
+        raise typer.Exit(1)
+    if resume and not settings.CHECKPOINT_DIR:
+        console.print(
+            "[bold red]Error: --resume needs CHECKPOINT_DIR, which is empty."
+            "[/bold red]"
+        )
+        raise typer.Exit(1)
 
-        with MemgraphIngestor(
-            host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
-        ) as ingestor:
-            if clean:
-                console.print("[bold yellow]Cleaning database...[/bold yellow]")
-                ingestor.clean_database()
-            ingestor.ensure_constraints()
-
-            # Create indexes for better query performance
-            from .graph_indexing import GraphIndexManager
-
-            index_manager = GraphIndexManager(ingestor)
-            index_manager.create_indexes()
-
-            # Load parsers and queries
-            parsers, queries = load_parsers()
+    _update_model_settings(orchestrator_model, cypher_model)
+    build_config = _build_config(goos, goarch, build_tags)
 
-            # Create graph updater with parallel processing options
+    if dry_run:
+        repo_to_scan = Path(target_repo_path)
+        console.print(f"[bold green]Dry run for: {repo_to_scan}[/bold green]")
+        parsers, queries = load_parsers()
+        with DryRunIngestor(sample_size=sample) as dry_ingestor:
             updater = GraphUpdater(
-                ingestor,
-                repo_to_update,
+                dry_ingestor,
+                repo_to_scan,
                 parsers,
                 queries,
                 parallel=parallel,
@@ -451,8 +1100,62 @@ def start(
                 folder_filter=folder_filter,
                 file_pattern=file_pattern,
                 skip_tests=skip_tests,
+                build_config=build_config,
+                progress=_file_progress(),
+                parse_cache=_parse_cache(repo_to_scan, parse_cache and not private),
+                large_file_mode=large_files,
+                generated_code_mode=generated,
             )
             updater.run()
+            _print_dry_run(
+                dry_ingestor.report(updater.skipped_files), verbose=verbose
+            )
+        return
+
+    if update_graph:
+        repo_to_update = Path(target_repo_path)
+        console.print(
+            f"[bold green]Updating knowledge graph for: {repo_to_update}[/bold green]"
+        )
+
+        with MemgraphIngestor(
+            host=settings.MEMGRAPH_HOST,
+            port=settings.MEMGRAPH_PORT,
+            batch_size=batch_size,
+            private=private or None,
+        ) as ingestor:
+            if clean:
+                console.print("[bold yellow]Cleaning database...[/bold yellow]")
+                ingestor.clean_database()
+            provision_schema(ingestor)
+            checkpoint = _ingest_checkpoint(repo_to_update)
+            try:
+                _ingest_repository(
+                    ingestor,
+                    repo_to_update,
+                    Path(report_file) if report_file else None,
+                    parallel=parallel,
+                    num_workers=workers,
+                    folder_filter=folder_filter,
+                    file_pattern=file_pattern,
+                    skip_tests=skip_tests,
+                    build_config=build_config,
+                    progress=_file_progress(),
+                    parse_cache=_parse_cache(
+                        repo_to_update, parse_cache and not private
+                    ),
+                    large_file_mode=large_files,
+                    generated_code_mode=generated,
+                    checkpoint=checkpoint,
+                    resume=resume,
+                )
+            except BaseException:
+                if checkpoint and checkpoint.path.exists():
+                    console.print(
+                        "[bold yellow]Ingestion interrupted; run it again with "
+                        "--resume to continue from its last checkpoint.[/bold yellow]"
+                    )
+                raise
 
             # Export graph if output file specified
             if output:
@@ -464,79 +1167,4111 @@ def start(
         return
 
     try:
-        asyncio.run(main_async(target_repo_path))
+        with _watching(Path(target_repo_path) if watch_files else None):
+            asyncio.run(
+                main_async(
+                    target_repo_path,
+                    citations,
+                    memory,
+                    conversation,
+                    stream and console.is_terminal,
+                )
+            )
     except KeyboardInterrupt:
         console.print("\n[bold red]Application terminated by user.[/bold red]")
     except ValueError as e:
         console.print(f"[bold red]Startup Error: {e}[/bold red]")
 
 
-@app.command()
-def export(
-    output: str = typer.Option(
-        ..., "-o", "--output", help="Output file path for the exported graph"
-    ),
-    format_json: bool = typer.Option(
-        True, "--json/--no-json", help="Export in JSON format"
-    ),
-) -> None:
-    """Export the current knowledge graph to a file."""
-    if not format_json:
+def _build_config(
+    goos: str | None, goarch: str | None, build_tags: str | None
+) -> BuildConfig | None:
+    """The Go build configuration to ingest for, None to ingest every file."""
+    if not (goos or goarch or build_tags):
+        return None
+    if (goos and goos not in KNOWN_OS) or (goarch and goarch not in KNOWN_ARCH):
         console.print(
-            "[bold red]Error: Currently only JSON format is supported.[/bold red]"
+            f"[bold red]Error: unknown GOOS/GOARCH {goos or ''}/{goarch or ''}; "
+            f"GOOS is one of {', '.join(sorted(KNOWN_OS))} and GOARCH one of "
+            f"{', '.join(sorted(KNOWN_ARCH))}.[/bold red]"
         )
         raise typer.Exit(1)
+    tags = frozenset(t.strip() for t in (build_tags or "").split(",") if t.strip())
+    return BuildConfig(goos or "linux", goarch or "amd64", tags)
 
-    console.print("[bold cyan]Connecting to Memgraph to export graph...[/bold cyan]")
-
-    try:
-        with MemgraphIngestor(
-            host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
-        ) as ingestor:
-            console.print("[bold cyan]Exporting graph data...[/bold cyan]")
-            if not _export_graph_to_file(ingestor, output):
-                raise typer.Exit(1)
 
-    except Exception as e:
-        console.print(f"[bold red]Failed to export graph: {e}[/bold red]")
-        logger.error(f"Export error: {e}", exc_info=True)
-        raise typer.Exit(1) from e
+def _format_bytes(size: float) -> str:
+    for unit in ("B", "KB", "MB", "GB"):
+        if size < 1024:
+            return f"{size:.0f} {unit}" if unit == "B" else f"{size:.1f} {unit}"
+        size /= 1024
+    return f"{size:.1f} TB"
 
 
-async def run_optimization_loop(
-    rag_agent: Any,
-    message_history: list[Any],
-    project_root: Path,
-    language: str,
-    reference_document: str | None = None,
+def _print_dry_run(
+    report: DryRunReport, shown_skips: int = 20, verbose: bool = False
 ) -> None:
-    """Runs the optimization loop with the RAG agent."""
-    console.print(
-        f"[bold green]Starting {language} optimization session...[/bold green]"
-    )
-    document_info = (
-        f" using the reference document: {reference_document}"
-        if reference_document
-        else ""
-    )
-    console.print(
-        Panel(
-            f"[bold yellow]The agent will analyze your {language} codebase{document_info} and propose specific optimizations."
-            f"You'll be asked to approve each suggestion before implementation."
-            f"Type 'exit' or 'quit' to end the session.[/bold yellow]",
-            border_style="yellow",
-        )
-    )
+    """
+    Counts per node label, relationship type and language, samples, skipped
+    files and size; verbose adds what was found in each file.
+    """
+    for title, counts in (
+        ("Nodes", report.node_counts),
+        ("Relationships", report.relationship_counts),
+    ):
+        table = Table(title=f"[bold green]{title} That Would Be Written[/bold green]")
+        table.add_column("Type", style="cyan")
+        table.add_column("Count", justify="right")
+        for name, count in sorted(counts.items(), key=lambda item: -item[1]):
+            table.add_row(name, str(count))
+        table.add_row("[bold]Total[/bold]", f"[bold]{sum(counts.values())}[/bold]")
+        console.print(table)
 
-    # Initial optimization analysis
+    if report.language_counts:
+        table = Table(title="[bold green]Per Language[/bold green]")
+        table.add_column("Language", style="cyan")
+        table.add_column("Nodes", justify="right")
+        table.add_column("Relationships", justify="right")
+        table.add_column("Most common labels")
+        for language, labels in report.language_counts.items():
+            table.add_row(
+                language,
+                str(sum(labels.values())),
+                str(report.language_relationships.get(language, 0)),
+                ", ".join(f"{label} {n}" for label, n in list(labels.items())[:5]),
+            )
+        console.print(table)
 
-    instructions = [
-        "Use your code retrieval and graph querying tools to understand the codebase structure",
-        "Read relevant source files to identify optimization opportunities",
-    ]
-    if reference_document:
-        instructions.append(
-            f"Use the analyze_document tool to reference best practices from {reference_document}"
+    if verbose and report.file_counts:
+        table = Table(title="[bold green]Per File[/bold green]")
+        table.add_column("File", style="magenta")
+        table.add_column("Nodes")
+        for path, labels in report.file_counts.items():
+            found = ", ".join(
+                f"{label} {n}"
+                for label, n in labels.items()
+                if label not in ("File", "Module")
+            )
+            table.add_row(path, found or "[yellow]no definitions[/yellow]")
+        console.print(table)
+
+    for label, samples in report.node_samples.items():
+        console.print(f"[bold cyan]{label}[/bold cyan] sample:")
+        for properties in samples:
+            console.print(f"  {_sample_text(properties)}", markup=False)
+    for rel_type, samples in report.relationship_samples.items():
+        console.print(f"[bold cyan]{rel_type}[/bold cyan] sample:")
+        for from_node, _, to_node, properties in samples:
+            line = f"  ({from_node[0]} {from_node[2]})-[{rel_type}]->"
+            line += f"({to_node[0]} {to_node[2]})"
+            if properties:
+                line += f" {_sample_text(properties)}"
+            console.print(line, markup=False)
+
+    if report.unresolved_relationships:
+        dropped = ", ".join(
+            f"{rel_type} ({count})"
+            for rel_type, count in report.unresolved_relationships.items()
+        )
+        console.print(
+            "[yellow]Relationships to nodes outside this ingestion, created only "
+            f"if those nodes are already in the graph: {dropped}[/yellow]"
+        )
+
+    if report.skipped_files:
+        table = Table(
+            title=f"[bold green]Skipped ({len(report.skipped_files)})[/bold green]"
+        )
+        table.add_column("Reason", style="yellow")
+        table.add_column("Paths", justify="right")
+        for reason, count in report.skip_reasons().items():
+            table.add_row(reason, str(count))
+        console.print(table)
+        errors = {p: r for p, r in report.skipped_files.items() if "error" in r}
+        for path, reason in list(errors.items())[:shown_skips]:
+            console.print(f"  [red]{path}[/red]: {reason}")
+        if len(errors) > shown_skips:
+            console.print(f"  ... and {len(errors) - shown_skips} more parse errors")
+
+    console.print(
+        f"[bold]Estimated database size:[/bold] "
+        f"{_format_bytes(report.estimated_bytes)} before indexes"
+    )
+
+
+def _sample_text(properties: dict[str, Any], width: int = 160) -> str:
+    # Long docstrings and source snippets would push everything else away
+    text = json.dumps(properties, default=str, ensure_ascii=False)
+    return text if len(text) <= width else text[: width - 3] + "..."
+
+
+@app.command(rich_help_panel=INTEGRATIONS_PANEL)
+def serve(
+    repo_path: str = typer.Option(
+        ..., "--repo-path", help="Working copy to keep in sync (cloned if missing)"
+    ),
+    clone_url: str = typer.Option(
+        "", "--clone-url", help="HTTPS URL to clone from and fetch pushed commits"
+    ),
+    branch: str | None = typer.Option(
+        None, "--branch", help="Branch to follow (default: the repository default)"
+    ),
+    host: str = typer.Option(settings.SERVER_HOST, "--host", help="Address to bind"),
+    port: int = typer.Option(settings.SERVER_PORT, "--port", help="Port to listen on"),
+    provider: str = typer.Option(
+        "github",
+        "--provider",
+        help="Repository host, selecting the clone credentials: "
+        "github, gitlab, bitbucket",
+        autocompletion=choices("github", "gitlab", "bitbucket"),
+    ),
+    editor: bool = typer.Option(
+        False, "--editor", help="Also serve the editor extension endpoint (POST /rpc)"
+    ),
+    query: bool = typer.Option(
+        False,
+        "--query",
+        help="Also serve POST /query for Cypher, to API tokens allowed to use it",
+    ),
+    api: bool = typer.Option(
+        False,
+        "--api",
+        help="Also serve the REST API (/api/ask, /api/symbols, /api/ingest and "
+        "/query) to API tokens",
+    ),
+    schedule: bool = typer.Option(
+        True,
+        "--schedule/--no-schedule",
+        help="Run the jobs in the [schedule] section of the repository's config",
+    ),
+    pr_comments: bool = typer.Option(
+        False,
+        "--pr-comments",
+        help="Comment on GitHub pull requests with the tests reaching their diff",
+    ),
+    insecure: bool = typer.Option(
+        False,
+        "--insecure",
+        help="Serve every provider's webhook, unverified where no secret is set",
+    ),
+) -> None:
+    """Run the server mode: re-ingest changed files on every push webhook."""
+    # Token, token username override and webhook secret per provider
+    credentials = {
+        "github": (settings.GITHUB_TOKEN, None, settings.GITHUB_WEBHOOK_SECRET),
+        "gitlab": (
+            settings.GITLAB_TOKEN,
+            settings.GITLAB_TOKEN_USERNAME,
+            settings.GITLAB_WEBHOOK_SECRET,
+        ),
+        "bitbucket": (
+            settings.BITBUCKET_TOKEN,
+            settings.BITBUCKET_TOKEN_USERNAME,
+            settings.BITBUCKET_WEBHOOK_SECRET,
+        ),
+    }
+    if provider not in credentials:
+        console.print(f"[bold red]Error: unknown provider '{provider}'[/bold red]")
+        raise typer.Exit(1)
+    token, username, secret = credentials[provider]
+    if pr_comments and (provider != "github" or not token):
+        console.print(
+            "[bold red]Error: --pr-comments needs the github provider and "
+            "GITHUB_TOKEN[/bold red]"
+        )
+        raise typer.Exit(1)
+    if not secret and not insecure:
+        # Anyone reaching the port could make the mirror fetch what they like
+        console.print(
+            f"[bold red]Error: {provider.upper()}_WEBHOOK_SECRET is not set; set "
+            "it, or pass --insecure to accept unverified payloads[/bold red]"
+        )
+        raise typer.Exit(1)
+    if not secret:
+        logger.warning(
+            f"{provider.upper()}_WEBHOOK_SECRET is not set; "
+            "webhook payloads are not verified"
+        )
+
+    try:
+        registry = _api_token_registry()
+    except (OSError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if (query or api) and registry is None:
+        flag = "--api" if api else "--query"
+        console.print(
+            f"[bold red]Error: {flag} needs API tokens; create one with "
+            "`api-token`[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    repo = Path(repo_path).resolve()
+    mirror = RepositoryMirror(
+        repo, clone_url, token, username or TOKEN_USERNAMES[provider]
+    )
+    mirror.ensure_clone()
+    if branch is None and provider == "bitbucket":
+        # Bitbucket payloads do not name the default branch
+        branch = mirror.default_branch()
+    parsers, queries = load_parsers()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        server = GraphServer()
+        updater = GraphUpdater(ingestor, repo, parsers, queries)
+        updater.load_function_registry()
+
+        def comment(event: PullRequestEvent, body: str) -> None:
+            GitHubReviewPublisher(event.repository, event.number, token).upsert_comment(
+                body, IMPACT_COMMENT_MARKER
+            )
+
+        synchronizer = create_webhook_routes(
+            server,
+            mirror,
+            updater,
+            settings.GITHUB_WEBHOOK_SECRET,
+            branch,
+            gitlab_secret=settings.GITLAB_WEBHOOK_SECRET,
+            bitbucket_secret=settings.BITBUCKET_WEBHOOK_SECRET,
+            impact=ImpactAnalyzer(ingestor) if pr_comments else None,
+            comment=comment if pr_comments else None,
+            insecure=insecure,
+        )
+        if editor:
+            _add_editor_routes(server, str(repo), ingestor, registry)
+        if api and registry:
+            create_api_routes(
+                server,
+                ingestor,
+                registry,
+                repo.name,
+                synchronizer.poll,
+                _read_only_answerer(ingestor, str(repo), "API questions"),
+            )
+        elif query and registry:
+            create_query_routes(server, ingestor, registry)
+        if schedule:
+            _start_scheduler(server, repo, synchronizer, ingestor, registry)
+        console.print(
+            f"[bold green]Receiving {provider} webhooks at "
+            f"http://{host}:{port}/webhooks/{provider} for {repo}[/bold green]"
+        )
+        server.serve(host, port)
+
+
+def _start_scheduler(
+    server: GraphServer,
+    repo: Path,
+    synchronizer: PushSynchronizer,
+    ingestor: MemgraphIngestor,
+    registry: TokenRegistry | None,
+) -> None:
+    """Run the repository's scheduled jobs and serve their history on /schedule."""
+
+    def hotspots() -> dict[str, Any]:
+        analyzer = HotspotAnalyzer(ingestor)
+        ranked = analyzer.rank_functions(GitAnalyzer(repo).get_diff_hunks())
+        analyzer.store_scores(ranked)
+        return {"functions": len(ranked)}
+
+    def call_depth() -> dict[str, Any]:
+        analyzer = CallDepthAnalyzer(ingestor)
+        depths = analyzer.analyze()
+        analyzer.store_depths(depths)
+        return {"entrypoints": len(depths)}
+
+    def smells() -> dict[str, Any]:
+        analyzer = SmellAnalyzer(ingestor)
+        found = analyzer.detect(SmellThresholds())
+        analyzer.store_smells(found)
+        return {"smells": len(found)}
+
+    def consistency() -> dict[str, Any]:
+        findings = check_graph(ingestor)
+        return {finding.name: finding.count for finding in findings if finding.count}
+
+    tasks = {
+        "ingest": synchronizer.poll,
+        "hotspots": hotspots,
+        "call-depth": call_depth,
+        "smells": smells,
+        "fsck": consistency,
+    }
+    try:
+        jobs = load_jobs(_repo_config(repo), list(tasks))
+    except (ConfigFileError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if not jobs:
+        return
+
+    history = RunHistory(Path(settings.SCHEDULE_HISTORY_FILE).expanduser())
+    scheduler = Scheduler(jobs, tasks, history)
+    create_schedule_routes(server, scheduler, registry, repo.name)
+    scheduler.start()
+    for job in scheduler.describe():
+        logger.info(
+            f"Scheduled {job['name']} ({', '.join(job['tasks'])}) "
+            f"next at {job['next_run']}"
+        )
+
+
+def _repo_config(repo: Path) -> dict[str, Any]:
+    """The config file at the top of a repository, or an empty one."""
+    config_file = next(
+        (repo / name for name in CONFIG_FILE_NAMES if (repo / name).is_file()), None
+    )
+    return read_config_file(config_file) if config_file else {}
+
+
+def _api_token_registry() -> TokenRegistry | None:
+    """The API tokens in API_TOKENS_FILE, or None if there is no such file."""
+    path = Path(settings.API_TOKENS_FILE).expanduser()
+    return TokenRegistry.from_file(path) if path.is_file() else None
+
+
+def _read_only_tools(ingestor: MemgraphIngestor, repo_path: str) -> list[Any]:
+    """The agent's tools for answering questions, without writing or running."""
+    return [
+        *_graph_query_tools(ingestor, CypherGenerator()),
+        *_semantic_search_tools(ingestor, repo_path),
+        create_code_retrieval_tool(
+            CodeRetriever(
+                project_root=repo_path,
+                ingestor=ingestor,
+                expansion=_expansion_policy(),
+                budget=_token_budget(),
+            )
+        ),
+        create_file_reader_tool(FileReader(project_root=repo_path)),
+    ]
+
+
+def _add_editor_routes(
+    server: GraphServer,
+    repo_path: str,
+    ingestor: MemgraphIngestor,
+    registry: TokenRegistry | None = None,
+) -> None:
+    """Register /rpc, answering questions with a read-only agent if possible."""
+    if not settings.EDITOR_RPC_TOKEN and registry is None:
+        logger.warning(
+            "Neither EDITOR_RPC_TOKEN nor API tokens are set; /rpc accepts any caller"
+        )
+    answer = _read_only_answerer(ingestor, repo_path, "Editor questions")
+    if answer is None:
+        create_editor_routes(
+            server, ingestor, token=settings.EDITOR_RPC_TOKEN, registry=registry
+        )
+        return
+    create_editor_routes(
+        server, ingestor, answer, settings.EDITOR_RPC_TOKEN, registry
+    )
+
+
+def _read_only_answerer(
+    ingestor: MemgraphIngestor, repo_path: str, askers: str
+) -> Callable[[str], str] | None:
+    """
+    Answers from an agent that may not write files or run commands, or None
+    when no model is configured.
+    """
+    try:
+        settings.validate_for_usage()
+    except ValueError as e:
+        logger.warning(f"{askers} get graph context only: {e}")
+        return None
+    rag_agent = create_rag_orchestrator(tools=_read_only_tools(ingestor, repo_path))
+
+    def answer(prompt: str) -> str:
+        return str(asyncio.run(rag_agent.run(prompt)).output)
+
+    return answer
+
+
+@app.command("api-token", rich_help_panel=INTEGRATIONS_PANEL)
+def api_token(
+    name: str = typer.Argument(..., help="Who or what the token is for"),
+    role: str = typer.Option(
+        "read-only",
+        "--role",
+        help=f"One of {', '.join(ROLE_NAMES)}",
+        autocompletion=choices(*ROLE_NAMES),
+    ),
+    repos: list[str] = typer.Option(
+        [],
+        "--repo",
+        help="Graph project the token may access (repeatable; default: all)",
+    ),
+) -> None:
+    """Create a server API token; only its hash is stored in API_TOKENS_FILE."""
+    try:
+        parsed_role = Role.parse(role)
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    path = Path(settings.API_TOKENS_FILE).expanduser()
+    secret = add_token(path, name, parsed_role, repos)
+    console.print(
+        f"[green]Token '{name}' ({parsed_role.label}, "
+        f"{', '.join(repos) or 'all repos'}) saved to {path}[/green]"
+    )
+    console.print("Copy it now, it is not shown again:")
+    print(secret)
+
+
+@app.command(rich_help_panel=INTEGRATIONS_PANEL)
+def bot(
+    config: Path = typer.Option(
+        ..., "--config", help="YAML file mapping chat channels to projects"
+    ),
+    host: str = typer.Option(settings.SERVER_HOST, "--host", help="Address to bind"),
+    port: int = typer.Option(settings.SERVER_PORT, "--port", help="Port to listen on"),
+) -> None:
+    """Answer code questions in Slack threads and Discord /ask commands."""
+    try:
+        scopes = ChannelScopes.load(config)
+        settings.validate_for_usage()
+    except (OSError, KeyError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if not settings.SLACK_BOT_TOKEN and not settings.DISCORD_PUBLIC_KEY:
+        console.print(
+            "[bold red]Error: set SLACK_BOT_TOKEN and/or DISCORD_PUBLIC_KEY[/bold red]"
+        )
+        raise typer.Exit(1)
+    if settings.SLACK_BOT_TOKEN and not settings.SLACK_SIGNING_SECRET:
+        logger.warning("SLACK_SIGNING_SECRET is not set; Slack events are not verified")
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        agents: dict[str, Any] = {}
+
+        def answer(question: str, project: BotProject) -> str:
+            if project.name not in agents:
+                # Chat users ask questions; the bot must not change any code
+                agents[project.name] = create_rag_orchestrator(
+                    tools=[
+                        *_graph_query_tools(ingestor, CypherGenerator()),
+                        *_semantic_search_tools(ingestor, str(project.path)),
+                        create_code_retrieval_tool(
+                            CodeRetriever(
+                                project_root=str(project.path),
+                                ingestor=ingestor,
+                                expansion=_expansion_policy(),
+                                budget=_token_budget(),
+                            )
+                        ),
+                        create_file_reader_tool(
+                            FileReader(project_root=str(project.path))
+                        ),
+                    ]
+                )
+            prompt = scoped_question(question, project)
+            return str(asyncio.run(agents[project.name].run(prompt)).output)
+
+        server = GraphServer()
+        paths = create_bot_routes(
+            server,
+            CodeQuestionBot(ingestor, scopes, answer),
+            settings.SLACK_BOT_TOKEN,
+            settings.SLACK_SIGNING_SECRET,
+            settings.DISCORD_PUBLIC_KEY,
+        )
+        for path in paths:
+            console.print(
+                f"[bold green]Listening at http://{host}:{port}{path}[/bold green]"
+            )
+        server.serve(host, port)
+
+
+@app.command(rich_help_panel=INTEGRATIONS_PANEL)
+def lsp(
+    repo_path: str | None = typer.Option(
+        None,
+        "--repo-path",
+        help="Checkout to navigate (default: the editor's workspace folders)",
+    ),
+    project: list[str] = typer.Option(
+        [],
+        "--project",
+        help="Map another ingested project to a checkout, as NAME=PATH (repeatable)",
+    ),
+) -> None:
+    """Run a language server on stdio answering navigation from the graph."""
+    projects = {}
+    if repo_path:
+        root = Path(repo_path).resolve()
+        projects[root.name] = root
+    for mapping in project:
+        name, separator, path = mapping.partition("=")
+        if not separator or not name or not path:
+            # stdout carries the protocol, so errors go to stderr
+            logger.error(f"Invalid --project '{mapping}', expected NAME=PATH")
+            raise typer.Exit(1)
+        projects[name] = Path(path).expanduser()
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        exit_code = GraphLanguageServer(ingestor, projects).serve()
+    raise typer.Exit(exit_code)
+
+
+@app.command(rich_help_panel=INTEGRATIONS_PANEL)
+def mcp() -> None:
+    """Run the MCP server of mcp_server on stdio, connected to the graph."""
+    try:
+        # mcp_server imports this module, and needs the optional MCP SDK
+        from mcp_server.server import CodeGraphMCPServer  # noqa: PLC0415
+    except ImportError as e:
+        console.print(
+            "[bold red]The MCP server needs the MCP SDK: "
+            f"pip install 'graph-code[mcp-server]' ({e})[/bold red]"
+        )
+        raise typer.Exit(1) from e
+    query_cache = None
+    if settings.QUERY_CACHE_SIZE:
+        query_cache = GraphQueryCache(settings.QUERY_CACHE_SIZE)
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST,
+        port=settings.MEMGRAPH_PORT,
+        query_cache=query_cache,
+    ) as ingestor:
+        asyncio.run(CodeGraphMCPServer(ingestor).run())
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def export(
+    output: str = typer.Option(
+        ..., "-o", "--output", help="Output file path for the exported graph"
+    ),
+    export_format: str | None = typer.Option(
+        None,
+        "--format",
+        help="json, graphml, dot or jsonl (default: from the output's extension, "
+        "else json)",
+        autocompletion=choices(*EXPORT_FORMATS),
+    ),
+    format_json: bool = typer.Option(
+        True, "--json/--no-json", hidden=True, help="Export in JSON format"
+    ),
+    packages: list[str] = typer.Option(
+        [],
+        "--package",
+        help="Export only nodes under this qualified name prefix (repeatable)",
+    ),
+    labels: list[str] = typer.Option(
+        [], "--label", help="Export only nodes with this label (repeatable)"
+    ),
+    query: str | None = typer.Option(
+        None, "--query", help="Export only nodes returned by this read-only Cypher"
+    ),
+) -> None:
+    """Export the knowledge graph, or a slice of it, to a file."""
+    output_path = Path(output)
+    try:
+        resolved_format = format_for(output_path, export_format)
+        if not format_json and resolved_format == "json":
+            raise ValueError("--no-json needs another --format")
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    graph_filter = GraphFilter(packages, labels, query)
+
+    console.print("[bold cyan]Connecting to Memgraph to export graph...[/bold cyan]")
+
+    try:
+        with MemgraphIngestor(
+            host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+        ) as ingestor:
+            console.print("[bold cyan]Exporting graph data...[/bold cyan]")
+            if graph_filter or resolved_format != "json":
+                graph_data = select_graph(ingestor, graph_filter)
+                write_graph(graph_data, output_path, resolved_format)
+                console.print(
+                    f"[bold green]Graph exported as {resolved_format} to: "
+                    f"{output_path.absolute()}[/bold green]"
+                )
+                console.print(
+                    f"[bold cyan]Export contains {len(graph_data['nodes'])} nodes "
+                    f"and {len(graph_data['relationships'])} "
+                    "relationships[/bold cyan]"
+                )
+            elif not _export_graph_to_file(ingestor, output):
+                raise typer.Exit(1)
+
+    except typer.Exit:
+        raise
+    except Exception as e:
+        console.print(f"[bold red]Failed to export graph: {e}[/bold red]")
+        logger.error(f"Export error: {e}", exc_info=True)
+        raise typer.Exit(1) from e
+
+
+@app.command("ingest-test-results", rich_help_panel=GRAPH_PANEL)
+def ingest_test_results(
+    paths: list[Path] = typer.Argument(
+        ..., help="JUnit XML reports, or directories to search for them"
+    ),
+    min_runs: int = typer.Option(
+        3, "--min-runs", help="Runs required before a test can be flagged flaky"
+    ),
+) -> None:
+    """Attach CI test results to test nodes and update their pass/fail history."""
+    reports = collect_reports(paths)
+    if not reports:
+        console.print("[bold red]Error: No JUnit XML reports found.[/bold red]")
+        raise typer.Exit(1)
+
+    runs = []
+    for report in reports:
+        try:
+            runs.append(parse_junit_xml(report))
+        except Exception as e:
+            console.print(f"[yellow]Skipping {report}: {e}[/yellow]")
+    if not runs:
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = TestResultAnalyzer(ingestor, min_runs=min_runs).ingest_runs(runs)
+
+    console.print(
+        f"[bold green]Ingested {stats['results']} results from {stats['runs']} runs "
+        f"({stats['matched_tests']} tests matched, "
+        f"{stats['unmatched_results']} results unmatched).[/bold green]"
+    )
+    if stats["flaky_tests"]:
+        console.print(
+            f"[bold yellow]{stats['flaky_tests']} flaky tests detected. "
+            "Run 'analyze flaky-tests' for details.[/bold yellow]"
+        )
+
+
+@app.command("load-coverage", rich_help_panel=GRAPH_PANEL)
+def load_coverage(
+    paths: list[Path] = typer.Argument(
+        ..., help="Go cover profiles (go test -coverprofile), or directories of them"
+    ),
+    per_test: bool = typer.Option(
+        False,
+        "--per-test",
+        help="Each profile is of one test, named by the file: TestAdd.out",
+    ),
+) -> None:
+    """Record Go test coverage on functions and link tests to the code they run."""
+    files = collect_profiles(paths)
+    if not files:
+        console.print("[bold red]Error: No cover profiles found.[/bold red]")
+        raise typer.Exit(1)
+
+    profiles = [
+        parse_cover_profile(
+            file_path.read_text(encoding="utf-8"),
+            str(file_path),
+            test=file_path.stem if per_test else None,
+        )
+        for file_path in files
+    ]
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = CoverageLoader(ingestor).load(profiles)
+
+    console.print(
+        f"[bold green]Recorded coverage of {stats['functions']} functions from "
+        f"{stats['profiles']} profiles; recorded {stats['covers']} COVERS edges "
+        "from tests.[/bold green]"
+    )
+    if stats["unmatched_blocks"] or stats["unmatched_tests"]:
+        console.print(
+            f"[yellow]{stats['unmatched_blocks']} profiled blocks and "
+            f"{stats['unmatched_tests']} tests did not match the graph.[/yellow]"
+        )
+
+
+@app.command("ingest-govulncheck", rich_help_panel=GRAPH_PANEL)
+def ingest_govulncheck(
+    paths: list[Path] | None = typer.Argument(
+        None, help="Output of govulncheck -json; runs govulncheck when omitted"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Go module to run govulncheck in"
+    ),
+) -> None:
+    """Attach Go vulnerabilities to module versions and the code that calls them."""
+    outputs = []
+    if paths:
+        outputs = [path.read_text(encoding="utf-8") for path in paths]
+    else:
+        target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+        console.print(f"Running govulncheck in {target_repo_path}...")
+        try:
+            outputs = [run_govulncheck(target_repo_path)]
+        except FileNotFoundError as e:
+            console.print(
+                "[bold red]Error: govulncheck not found. Install it with "
+                "go install golang.org/x/vuln/cmd/govulncheck@latest[/bold red]"
+            )
+            raise typer.Exit(1) from e
+        except subprocess.CalledProcessError as e:
+            console.print(f"[bold red]govulncheck failed: {e.stderr}[/bold red]")
+            raise typer.Exit(1) from e
+
+    reports = []
+    for output in outputs:
+        try:
+            reports.append(parse_govulncheck_json(output))
+        except (ValueError, KeyError) as e:
+            console.print(f"[yellow]Skipping unreadable govulncheck JSON: {e}[/yellow]")
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        loader = GovulncheckLoader(ingestor)
+        stats = [loader.load(report) for report in reports]
+
+    console.print(
+        f"[bold green]Recorded {sum(s['vulnerabilities'] for s in stats)} "
+        f"vulnerabilities, {sum(s['called'] for s in stats)} of them called, "
+        f"with {sum(s['calls'] for s in stats)} vulnerable call paths."
+        "[/bold green]"
+    )
+
+
+@app.command("ingest-traces", rich_help_panel=GRAPH_PANEL)
+def ingest_traces(
+    paths: list[Path] = typer.Argument(
+        ..., help="OTLP/JSON trace exports, or directories to search for them"
+    ),
+    service: list[str] = typer.Option(
+        [],
+        "--service",
+        help="Only map spans of this service.name (repeatable)",
+    ),
+) -> None:
+    """Map OpenTelemetry spans to functions and record OBSERVED_CALL edges."""
+    files = collect_trace_files(paths)
+    if not files:
+        console.print("[bold red]Error: No trace exports found.[/bold red]")
+        raise typer.Exit(1)
+
+    spans = []
+    for file_path in files:
+        try:
+            spans.extend(parse_otlp_json(file_path.read_text(encoding="utf-8")))
+        except (ValueError, AttributeError) as e:
+            console.print(f"[yellow]Skipping {file_path}: {e}[/yellow]")
+    if service:
+        spans = [span for span in spans if span.service in service]
+    if not spans:
+        console.print("[bold red]Error: No spans to map.[/bold red]")
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = TraceMapper(ingestor).ingest(spans)
+
+    console.print(
+        f"[bold green]Resolved {stats['resolved_spans']} of {stats['spans']} spans "
+        f"to functions; recorded {stats['observed_calls']} observed calls."
+        "[/bold green]"
+    )
+
+
+@app.command("ingest-crashes", rich_help_panel=GRAPH_PANEL)
+def ingest_crashes(
+    paths: list[Path] = typer.Argument(
+        ..., help="Sentry issue or event JSON exports, or directories of them"
+    ),
+) -> None:
+    """Attach Sentry crash reports to the functions in their stack frames."""
+    files = collect_exports(paths)
+    issues = []
+    for file_path in files:
+        try:
+            issues.extend(load_export_file(file_path))
+        except (ValueError, KeyError) as e:
+            console.print(f"[yellow]Skipping {file_path}: {e}[/yellow]")
+    if not issues:
+        console.print("[bold red]Error: No Sentry issues found.[/bold red]")
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = CrashMapper(ingestor).ingest(issues)
+
+    console.print(
+        f"[bold green]Ingested {stats['crashes']} crash reports; "
+        f"{stats['resolved_frames']} of {stats['frames']} frames linked to "
+        "functions.[/bold green]"
+    )
+
+
+@app.command("resolve-trace", rich_help_panel=INSIGHT_PANEL)
+def resolve_trace(
+    trace_file: str = typer.Argument(
+        "-", help="File holding the stack trace, or - to read it from stdin"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Checkout the graph was ingested from"
+    ),
+    context: int = typer.Option(
+        2, "--context", help="Source lines to show around each frame's line"
+    ),
+    as_json: bool = typer.Option(
+        False, "--json", help="Print the resolved trace as JSON"
+    ),
+) -> None:
+    """Map a Go panic, Python traceback or JVM stack trace onto the graph."""
+    if trace_file == "-":
+        text = sys.stdin.read()
+    else:
+        text = Path(trace_file).read_text(encoding="utf-8", errors="replace")
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        resolver = StackTraceResolver(
+            ingestor, target_repo_path, GitAnalyzer(target_repo_path), context
+        )
+        try:
+            trace = resolver.resolve(text)
+        except ValueError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+
+    if as_json:
+        print(json.dumps(trace.to_dict(), indent=2))
+        return
+
+    console.print(f"[bold]{trace.message or trace.kind + ' stack trace'}[/bold]")
+    for number, frame in enumerate(trace.frames, start=1):
+        if not frame.resolved:
+            console.print(
+                f"[dim]{number:>3}. {frame.frame.function} "
+                f"({frame.frame.file}:{frame.frame.line})[/dim]"
+            )
+            continue
+        console.print(
+            f"{number:>3}. [bold cyan]{frame.qualified_name}[/bold cyan] "
+            f"({frame.path}:{frame.frame.line})"
+        )
+        for line_number, line in frame.snippet:
+            marker = ">" if line_number == frame.frame.line else " "
+            console.print(
+                Text(f"      {marker} {line_number:>5} | {line}"),
+                style="bold" if marker == ">" else "dim",
+            )
+        if frame.authors:
+            authors = ", ".join(
+                f"{a['author']} ({a['date']}, {a['commit']})" for a in frame.authors
+            )
+            console.print(f"      [yellow]Recent authors:[/yellow] {authors}")
+    resolved = sum(1 for frame in trace.frames if frame.resolved)
+    console.print(
+        f"\n[bold green]{resolved} of {len(trace.frames)} frames resolved to "
+        "the graph.[/bold green]"
+    )
+
+
+@app.command("import-index", rich_help_panel=GRAPH_PANEL)
+def import_index(
+    index_path: Path = typer.Argument(
+        ..., help="SCIP index (index.scip) or LSIF dump (dump.lsif)"
+    ),
+    index_format: str = typer.Option(
+        "auto",
+        "--format",
+        help="auto (by file extension), scip or lsif",
+        autocompletion=choices("auto", "scip", "lsif"),
+    ),
+    path_prefix: str = typer.Option(
+        "",
+        "--path-prefix",
+        help="Directory of the indexed project within the repository",
+    ),
+    prune: bool = typer.Option(
+        False, "--prune", help="Remove CALLS edges between indexed files it contradicts"
+    ),
+) -> None:
+    """Refine call and implementation edges with a SCIP or LSIF index."""
+    try:
+        index = load_index(index_path, index_format)
+    except (OSError, ValueError) as e:
+        console.print(f"[bold red]Error: could not read {index_path}: {e}[/bold red]")
+        raise typer.Exit(1) from e
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = PreciseIndexImporter(ingestor).import_index(index, path_prefix, prune)
+
+    console.print(
+        f"[bold green]Imported {index.tool} index: {stats['linked_symbols']} symbols "
+        f"linked across {stats['documents']} files, {stats['calls']} calls and "
+        f"{stats['implementations']} implementations recorded.[/bold green]"
+    )
+    if prune:
+        console.print(f"Pruned {stats['pruned_calls']} unconfirmed CALLS edges.")
+
+
+@app.command("resolve-calls", rich_help_panel=GRAPH_PANEL)
+def resolve_calls(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Checkout the graph was ingested from"
+    ),
+    language: str = typer.Option(
+        "go",
+        "--language",
+        help="Language whose calls to resolve",
+        autocompletion=choices(*LANGUAGE_SERVERS),
+    ),
+    server: str | None = typer.Option(
+        None,
+        "--server",
+        help="Language server command (default: gopls, pyright-langserver, ...)",
+    ),
+    path_prefix: str = typer.Option(
+        "", "--path-prefix", help="Only resolve calls from files under this path"
+    ),
+) -> None:
+    """Add calls the static pass missed, asking a language server's call hierarchy."""
+    if language not in LANGUAGE_SERVERS:
+        console.print(
+            f"[bold red]Error: unknown language '{language}', expected one of "
+            f"{', '.join(LANGUAGE_SERVERS)}[/bold red]"
+        )
+        raise typer.Exit(1)
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    command = server or LANGUAGE_SERVERS[language][0]
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        try:
+            with LanguageServerClient(command, target_repo_path) as client:
+                stats = CallResolver(
+                    ingestor, client, target_repo_path, language
+                ).resolve(path_prefix)
+        except LanguageServerError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+
+    console.print(
+        f"[bold green]Asked {command.split()[0]} about {stats['functions']} "
+        f"functions: {stats['calls']} calls and {stats['dynamic_calls']} dynamic "
+        f"calls added.[/bold green]"
+    )
+    if stats["failed"]:
+        console.print(
+            f"[yellow]{stats['failed']} functions had no call hierarchy.[/yellow]"
+        )
+
+
+@app.command("export-scip", rich_help_panel=GRAPH_PANEL)
+def export_scip(
+    output: Path = typer.Option(
+        Path("index.scip"), "-o", "--output", help="Where to write the SCIP index"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Checkout the graph was ingested from"
+    ),
+    project: str | None = typer.Option(
+        None, "--project", help="Graph project to export (default: checkout name)"
+    ),
+) -> None:
+    """Export definitions and graph relationships as a SCIP index."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        index, stats = ScipExporter(ingestor, target_repo_path, project).export()
+
+    if not stats["documents"]:
+        console.print(
+            f"[bold red]Error: no modules of project "
+            f"'{project or target_repo_path.name}' in the graph.[/bold red]"
+        )
+        raise typer.Exit(1)
+    output.write_bytes(index)
+    console.print(
+        f"[bold green]Wrote {output}: {stats['symbols']} symbols in "
+        f"{stats['documents']} files, {stats.get('references', 0)} call references, "
+        f"{stats.get('tests', 0)} test relationships and "
+        f"{stats.get('endpoints', 0)} endpoints.[/bold green]"
+    )
+
+
+@app.command("ingest-history", rich_help_panel=GRAPH_PANEL)
+def ingest_history(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Git repository the graph was ingested from"
+    ),
+    max_commits: int = typer.Option(
+        500, "--max-commits", help="How many recent commits to load"
+    ),
+) -> None:
+    """
+    Load recent commits and their authors, linked to the files they changed
+    and, through git blame, to the functions whose lines they wrote.
+    """
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    git_analyzer = GitAnalyzer(target_repo_path)
+    commits = git_analyzer.get_recent_commits(max_commits)
+    if not commits:
+        console.print(
+            f"[bold red]Error: no commits found in {target_repo_path}[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        stats = GitHistoryLoader(ingestor, git_analyzer).load(commits)
+
+    console.print(
+        f"[bold green]Loaded {stats['commits']} commits by {stats['authors']} "
+        f"authors, with {stats['file_links']} file and {stats['function_links']} "
+        f"function MODIFIED edges.[/bold green]"
+    )
+
+
+@app.command("link-issues", rich_help_panel=GRAPH_PANEL)
+def link_issues(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Git repository the graph was ingested from"
+    ),
+    max_commits: int = typer.Option(
+        500, "--max-commits", help="How many recent commits to scan"
+    ),
+    jira_project: list[str] = typer.Option(
+        [],
+        "--jira-project",
+        help="Only count Jira keys of this project (repeatable)",
+    ),
+    github: str | None = typer.Option(
+        None,
+        "--github",
+        help="GitHub repository (owner/name) to fetch issue titles and states from",
+    ),
+    refresh_issues: bool = typer.Option(
+        False,
+        "--refresh-issues",
+        help="Fetch details of issues fetched before again, e.g. to update states",
+    ),
+) -> None:
+    """
+    Link commits and the functions they changed to referenced issues.
+
+    Jira tickets are fetched from JIRA_URL when it is set, and code changed
+    for or mentioning a ticket gets an IMPLEMENTS_TICKET edge to it.
+    """
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    git_analyzer = GitAnalyzer(target_repo_path)
+    commits = git_analyzer.get_recent_commits(max_commits)
+    if not commits:
+        console.print(
+            f"[bold red]Error: no commits found in {target_repo_path}[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        linker = IssueLinker(ingestor, git_analyzer, set(jira_project) or None)
+        stats = linker.link_commits(commits)
+        trackers: list[IssueTracker] = []
+        if github:
+            trackers.append(GitHubIssueTracker(github, settings.GITHUB_TOKEN))
+        if settings.JIRA_URL:
+            trackers.append(
+                JiraIssueTracker(
+                    settings.JIRA_URL, settings.JIRA_API_TOKEN, settings.JIRA_EMAIL
+                )
+            )
+        hydrated = linker.hydrate_issues(trackers, refresh_issues) if trackers else 0
+        ticket_links = linker.sync_ticket_edges()
+
+    console.print(
+        f"[bold green]{stats['commits']} of {len(commits)} commits reference "
+        f"{stats['issues']} issues; {stats['function_links']} links from changed "
+        f"functions recorded.[/bold green]"
+    )
+    if trackers:
+        console.print(f"Fetched details of {hydrated} issues.")
+    if ticket_links:
+        console.print(f"Linked code to Jira tickets with {ticket_links} edges.")
+
+
+@app.command("ticket", rich_help_panel=INSIGHT_PANEL)
+def ticket(
+    key: str = typer.Argument(..., help="Jira ticket key, e.g. PROJ-1234"),
+) -> None:
+    """Show the code that implemented a Jira ticket and its sub-tasks."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        issue, rows = IssueLinker(ingestor, None).find_ticket_code(key)
+
+    if issue is None:
+        console.print(
+            f"[bold red]Error: {key} is not in the graph; run link-issues first"
+            "[/bold red]"
+        )
+        raise typer.Exit(1)
+    summary = " ".join(
+        part for part in (issue.get("issue_type"), issue.get("title")) if part
+    )
+    console.print(f"[bold]{key}[/bold] {summary} [{issue.get('state') or 'unknown'}]")
+    if issue.get("url"):
+        console.print(issue["url"])
+    if not rows:
+        console.print("No code linked to this ticket.")
+        return
+
+    table = Table(title=f"Code implementing {key}")
+    table.add_column("Symbol")
+    table.add_column("Path")
+    table.add_column("Ticket")
+    table.add_column("Via")
+    for row in rows:
+        table.add_row(
+            row["qualified_name"],
+            row["path"] or "",
+            row["ticket"],
+            ", ".join(row["via"] or []),
+        )
+    console.print(table)
+
+
+@app.command("search", rich_help_panel=INSIGHT_PANEL)
+def search_symbols(
+    query: str = typer.Argument(
+        ..., help="Name to look for, e.g. getUserName or calculator.divide"
+    ),
+    limit: int = typer.Option(10, "--limit", help="Most matches to show"),
+    semantic: bool = typer.Option(
+        False,
+        "--semantic",
+        help="Search by meaning, e.g. 'where do we debounce retries', using the "
+        "embeddings from `embed`",
+    ),
+    expand: bool = typer.Option(
+        True,
+        "--expand/--no-expand",
+        help="With --semantic, also rank the callers and callees of the best matches",
+    ),
+    rerank: bool = typer.Option(
+        True,
+        "--rerank/--no-rerank",
+        help="With --semantic and RERANKER set, keep the RERANK_TOP_K results "
+        "whose source the reranker finds most relevant",
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose source the reranker reads"
+    ),
+    json_output: bool = typer.Option(False, "--json", help="Print JSON"),
+) -> None:
+    """Find functions, methods and classes by name, or by meaning with --semantic."""
+    if semantic:
+        _semantic_search(query, limit, expand, rerank, repo_path, json_output)
+        return
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        matches = SymbolIndex.from_graph(ingestor).search(query, limit)
+
+    if json_output:
+        print(json.dumps([asdict(match) for match in matches], indent=2))
+        return
+    if not matches:
+        console.print(f"No symbols match '{query}'.")
+        raise typer.Exit(1)
+    table = Table(title=f"Symbols matching '{query}'")
+    table.add_column("Symbol", style="cyan")
+    table.add_column("Kind")
+    table.add_column("Score", justify="right")
+    for match in matches:
+        table.add_row(match.qualified_name, match.label, f"{match.score:.2f}")
+    console.print(table)
+
+
+@app.command(rich_help_panel=INSIGHT_PANEL)
+def explore(
+    symbol: str | None = typer.Argument(
+        None, help="Symbol to start from, or a name to search for"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose source is previewed"
+    ),
+) -> None:
+    """Browse the callers, callees, implementations and tests of symbols."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        run_explorer(GraphExplorer(ingestor, target_repo_path), symbol)
+
+
+def _semantic_search(
+    query: str,
+    limit: int,
+    expand: bool,
+    rerank: bool,
+    repo_path: str | None,
+    json_output: bool,
+) -> None:
+    try:
+        embedder = create_embedder()
+        reranker = create_reranker() if rerank else None
+    except Exception as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        index = SemanticIndex(
+            ingestor, embedder, target_repo_path, reranker, settings.RERANK_TOP_K
+        )
+        try:
+            hits = index.search(query, limit, expand)
+        except EmbeddingMismatchError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+
+    if json_output:
+        print(json.dumps([hit.to_dict() for hit in hits], indent=2))
+        return
+    if not hits:
+        console.print(
+            f"No symbols match '{query}'. Run `embed` first if the graph has no "
+            f"{embedder.name} embeddings."
+        )
+        raise typer.Exit(1)
+    table = Table(title=f"Code related to '{query}'")
+    table.add_column("Symbol", style="cyan")
+    table.add_column("Kind")
+    table.add_column("Location", style="magenta")
+    table.add_column("Found as")
+    table.add_column("Score", justify="right")
+    if reranker:
+        table.add_column("Relevance", justify="right")
+    for hit in hits:
+        found = "match" if hit.relation == "match" else f"{hit.relation} of {hit.via}"
+        if hit.chunk_start:
+            found += f" at lines {hit.chunk_start}-{hit.chunk_end}"
+        row = [
+            hit.qualified_name,
+            hit.label,
+            f"{hit.path}:{hit.start_line}" if hit.start_line else hit.path or "",
+            found,
+            f"{hit.score:.2f}",
+        ]
+        if reranker:
+            row.append("" if hit.rerank_score is None else f"{hit.rerank_score:.2f}")
+        table.add_row(*row)
+    console.print(table)
+
+
+@app.command("conversations", rich_help_panel=INSIGHT_PANEL)
+def list_conversations(
+    symbol: str | None = typer.Option(
+        None,
+        "--symbol",
+        help="List the questions whose answers referenced this qualified name",
+        autocompletion=complete_symbol,
+    ),
+    show: str | None = typer.Option(
+        None, "--show", help="Print the questions and answers of a conversation"
+    ),
+    limit: int = typer.Option(20, "--limit", help="Conversations or questions"),
+    json_output: bool = typer.Option(False, "--json", help="Print JSON"),
+) -> None:
+    """Browse past chat sessions, or the investigations of a function."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        if show:
+            try:
+                exchanges = ConversationStore(ingestor, show).resume()
+            except ValueError as e:
+                console.print(f"[bold red]Error: {e}[/bold red]")
+                raise typer.Exit(1) from e
+        elif symbol:
+            exchanges = investigations(ingestor, symbol, limit)
+        else:
+            sessions = conversations(ingestor, limit)
+
+    if show or symbol:
+        if json_output:
+            print(json.dumps([e.to_dict() for e in exchanges], indent=2))
+            return
+        if not exchanges:
+            console.print(f"No answers have referenced {symbol}.")
+            raise typer.Exit(1)
+        for exchange in exchanges:
+            where = "" if show else f" in {exchange.conversation_id}"
+            console.print(
+                Panel(
+                    Markdown(exchange.answer or "_No answer_"),
+                    title=f"[bold cyan]{exchange.question}[/bold cyan]",
+                    subtitle=f"{exchange.asked_at or ''}{where}",
+                    border_style="green",
+                )
+            )
+        return
+
+    if json_output:
+        print(json.dumps(sessions, indent=2))
+        return
+    if not sessions:
+        console.print("No conversations in the graph yet.")
+        return
+    table = Table(title="Conversations")
+    table.add_column("Id", style="cyan")
+    table.add_column("Started")
+    table.add_column("User")
+    table.add_column("Questions", justify="right")
+    table.add_column("First question", overflow="fold")
+    for session in sessions:
+        table.add_row(
+            session["conversation_id"],
+            (session["started_at"] or "")[:16].replace("T", " "),
+            session["user"] or "",
+            str(session["question_count"]),
+            session["first_question"] or "",
+        )
+    console.print(table)
+    console.print("Resume one with `start --conversation <id>`.")
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def embed(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose source is embedded with its symbols"
+    ),
+    rebuild: bool = typer.Option(
+        False,
+        "--rebuild",
+        help="Embed every symbol again, replacing an index built with another "
+        "embedding provider or model",
+    ),
+) -> None:
+    """Store embeddings of functions, methods and classes for semantic search."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    try:
+        embedder = create_embedder()
+    except (ValueError, ImportError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        try:
+            stats = SemanticIndex(ingestor, embedder, target_repo_path).build(rebuild)
+        except EmbeddingMismatchError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+    console.print(
+        f"[bold green]Embedded {stats['embedded']} symbols with {embedder.name}"
+        f"[/bold green] ({embedder.provider}, {embedder.dimension} dimensions; "
+        f"{stats['unchanged']} unchanged)."
+    )
+
+
+@app.command("eval", rich_help_panel=INSIGHT_PANEL)
+def evaluate_retrieval(
+    golden_set: Path = typer.Argument(
+        ..., help="YAML file of questions with their expected nodes and answers"
+    ),
+    mode: str = typer.Option(
+        "agent",
+        "--mode",
+        help="agent: the chat agent with its read-only tools; semantic: semantic "
+        "search alone, without a language model",
+        autocompletion=choices("agent", "semantic"),
+    ),
+    k: int = typer.Option(
+        10, "--k", min=1, help="Retrieved nodes scored for precision"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository the golden set is about"
+    ),
+    output: Path | None = typer.Option(
+        None, "--output", "-o", help="Also write the JSON report to this file"
+    ),
+    json_output: bool = typer.Option(False, "--json", help="Print JSON"),
+) -> None:
+    """Score retrieval and answers against a golden set of questions."""
+    if mode not in ("agent", "semantic"):
+        console.print(f"[bold red]Error: unknown mode '{mode}'[/bold red]")
+        raise typer.Exit(1)
+    try:
+        questions = load_golden_set(golden_set)
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    target_repo_path = str(Path(repo_path or settings.TARGET_REPO_PATH).resolve())
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        try:
+            retrieve = _eval_pipeline(mode, ingestor, target_repo_path, k)
+        except (ValueError, ImportError, LLMGenerationError) as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+        with console.status(f"[bold green]Asking {len(questions)} questions..."):
+            report = asyncio.run(evaluate(questions, retrieve, k))
+
+    if output:
+        output.write_text(json.dumps(report.to_dict(), indent=2), encoding="utf-8")
+    if json_output:
+        print(json.dumps(report.to_dict(), indent=2))
+        return
+    table = Table(title=f"Retrieval against {golden_set.name} ({mode})")
+    table.add_column("Question", style="cyan")
+    table.add_column(f"P@{k}", justify="right")
+    table.add_column("Recall", justify="right")
+    table.add_column("RR", justify="right")
+    table.add_column("Answer")
+    table.add_column("Missed", style="dim", overflow="fold")
+    for result in report.results:
+        if result.error:
+            answer = f"[red]error: {result.error}[/red]"
+        elif result.answer_correct is None:
+            answer = ""
+        elif result.answer_correct:
+            answer = "[green]correct[/green]"
+        else:
+            answer = "[red]wrong[/red]"
+        table.add_row(
+            result.id,
+            _score(result.precision),
+            _score(result.recall),
+            _score(result.reciprocal_rank),
+            answer,
+            ", ".join(result.missing_phrases),
+        )
+    console.print(table)
+    summary = report.summary()
+    console.print(
+        f"Mean precision@{k} {_score(summary[f'precision@{k}'])}, "
+        f"recall {_score(summary['recall'])}, MRR {_score(summary['mrr'])}, "
+        f"answer accuracy {_score(summary['answer_accuracy'])} "
+        f"over {summary['questions']} questions ({summary['errors']} failed)."
+    )
+
+
+def _score(value: float | None) -> str:
+    return "-" if value is None else f"{value:.2f}"
+
+
+def _eval_pipeline(
+    mode: str, ingestor: MemgraphIngestor, repo_path: str, k: int
+) -> Callable[[str], Awaitable[Retrieval]]:
+    """The retrieval pipeline golden questions are run through."""
+    if mode == "semantic":
+        index = SemanticIndex(
+            ingestor,
+            create_embedder(),
+            Path(repo_path),
+            create_reranker(),
+            settings.RERANK_TOP_K,
+        )
+
+        async def search(question: str) -> Retrieval:
+            hits = await asyncio.to_thread(index.search, question, k)
+            return Retrieval([hit.qualified_name for hit in hits])
+
+        return search
+
+    settings.validate_for_usage()
+    rag_agent = create_rag_orchestrator(tools=_read_only_tools(ingestor, repo_path))
+
+    async def ask(question: str) -> Retrieval:
+        response = await rag_agent.run(question)
+        nodes = retrieved_nodes(response.all_messages())
+        return Retrieval(nodes, str(response.output))
+
+    return ask
+
+
+@app.command(rich_help_panel=SETUP_PANEL)
+def doctor(
+    path: str = typer.Option(
+        ".", "--path", help="Directory whose free disk space is checked"
+    ),
+    json_output: bool = typer.Option(False, "--json", help="Print JSON"),
+) -> None:
+    """Check the database, grammars, model providers and system resources."""
+    load_language_plugins(settings.LANGUAGE_PLUGIN_DIRS)
+    checks = check_database(
+        MemgraphIngestor(host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT),
+        settings.MEMGRAPH_HOST,
+        settings.MEMGRAPH_PORT,
+    )
+    checks += check_grammars(LANGUAGE_LIBRARIES)
+    checks += check_providers(settings)
+    checks += check_resources(Path(path))
+    failed = any(c.status == FAIL for c in checks)
+
+    if json_output:
+        print(json.dumps(checks_to_dict(checks), indent=2))
+        if failed:
+            raise typer.Exit(1)
+        return
+
+    styles = {
+        OK: "[green]ok[/green]",
+        WARN: "[yellow]warn[/yellow]",
+        FAIL: "[red]fail[/red]",
+    }
+    table = Table(title="[bold green]Doctor[/bold green]")
+    table.add_column("Check", style="cyan")
+    table.add_column("Status")
+    table.add_column("Details")
+    for check in checks:
+        table.add_row(check.name, styles[check.status], check.detail)
+    console.print(table)
+    for check in checks:
+        if check.fix:
+            console.print(f"[bold]{check.name}:[/bold] {check.fix}")
+    if failed:
+        raise typer.Exit(1)
+    console.print("[bold green]No problems that block using the tool.[/bold green]")
+
+
+@app.command(rich_help_panel=SETUP_PANEL)
+def init(
+    path: str = typer.Argument(".", help="Repository to set up"),
+    force: bool = typer.Option(
+        False, "--force", help="Overwrite an existing config file and .ragignore"
+    ),
+    schema: bool = typer.Option(
+        True,
+        "--schema/--no-schema",
+        help="Create the database constraints and indexes",
+    ),
+    ingest: bool = typer.Option(
+        False, "--ingest", help="Run the first ingestion once set up"
+    ),
+) -> None:
+    """Write a starter config and .ragignore, and prepare the database."""
+    repo_path = Path(path).resolve()
+    if not repo_path.is_dir():
+        console.print(f"[bold red]Error: {repo_path} is not a directory[/bold red]")
+        raise typer.Exit(1)
+
+    languages = detect_languages(repo_path)
+    if languages:
+        found = ", ".join(f"{name} ({count})" for name, count in languages.items())
+        console.print(f"[bold]Languages:[/bold] {found}")
+    else:
+        console.print("[yellow]No files in a supported language found.[/yellow]")
+
+    ignored = ignore_candidates(repo_path)
+    for name, content in (
+        (
+            CONFIG_FILE_NAME,
+            render_config(languages, settings.MEMGRAPH_HOST, settings.MEMGRAPH_PORT),
+        ),
+        (RAGIGNORE, render_ragignore(ignored)),
+    ):
+        target = repo_path / name
+        if target.exists() and not force:
+            console.print(
+                f"[yellow]Kept existing {target} (--force replaces it)[/yellow]"
+            )
+            continue
+        target.write_text(content, encoding="utf-8")
+        console.print(f"[green]Wrote {target}[/green]")
+
+    if not schema and not ingest:
+        return
+    try:
+        with MemgraphIngestor(
+            host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+        ) as ingestor:
+            provision_schema(ingestor)
+            console.print("[green]Created database constraints and indexes[/green]")
+            if ingest:
+                _ingest_repository(
+                    ingestor,
+                    repo_path,
+                    progress=_file_progress(),
+                    parse_cache=_parse_cache(repo_path),
+                )
+    except Exception as e:
+        console.print(
+            f"[bold red]Database setup failed: {e}[/bold red]\n"
+            "Run `doctor` to check the connection, then `init` again."
+        )
+        raise typer.Exit(1) from e
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def update(
+    base: str = typer.Argument("HEAD", help="Commit the graph was last updated to"),
+    head: str | None = typer.Argument(
+        None, help="Commit to update to, checked out (default: the working tree)"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose graph is updated"
+    ),
+) -> None:
+    """Re-ingest only the files changed between two commits, or since one."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    try:
+        changes = changed_paths(target_repo_path, base, head)
+    except subprocess.CalledProcessError as e:
+        console.print(
+            f"[bold red]Error: git diff failed: {e.stderr.strip()}[/bold red]"
+        )
+        raise typer.Exit(1) from e
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if not changes.changed and not changes.removed:
+        console.print("[green]No files changed; the graph is up to date.[/green]")
+        return
+
+    parsers, queries = load_parsers()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        updater = GraphUpdater(
+            ingestor,
+            target_repo_path,
+            parsers,
+            queries,
+            parse_cache=_parse_cache(target_repo_path),
+        )
+        updater.load_function_registry()
+        updater.update_files(changes.changed, changes.removed)
+        _refresh_symbol_index(ingestor)
+    console.print(
+        f"[bold green]Updated the graph for {base}..{head or 'working tree'}:"
+        f"[/bold green] {len(changes.changed)} changed and "
+        f"{len(changes.removed)} removed files."
+    )
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def watch(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose graph is kept current"
+    ),
+    debounce: float = typer.Option(
+        DEFAULT_DEBOUNCE_SECONDS,
+        "--debounce",
+        help="Seconds without changes before an update is made",
+    ),
+) -> None:
+    """Keep the graph current with the working copy as files are saved."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+
+    def report(changed: list[str], removed: list[str]) -> None:
+        console.print(
+            f"[green]Graph updated:[/green] {', '.join(changed + removed)}",
+            highlight=False,
+        )
+
+    console.print(
+        f"[bold green]Watching {target_repo_path}[/bold green] (Ctrl+C to stop)"
+    )
+    try:
+        _watch(target_repo_path, threading.Event(), debounce, report)
+    except KeyboardInterrupt:
+        console.print("\n[bold]Stopped watching.[/bold]")
+
+
+def _watch(
+    repo_path: Path,
+    stop: threading.Event,
+    debounce: float = DEFAULT_DEBOUNCE_SECONDS,
+    report: Callable[[list[str], list[str]], None] | None = None,
+) -> None:
+    parsers, queries = load_parsers()
+    # A connection of its own: the watcher may run beside the chat agent's
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        updater = GraphUpdater(
+            ingestor, repo_path, parsers, queries, parse_cache=_parse_cache(repo_path)
+        )
+        updater.load_function_registry()
+
+        def on_update(changed: list[str], removed: list[str]) -> None:
+            _refresh_symbol_index(ingestor)
+            if report:
+                report(changed, removed)
+
+        watch_repository(
+            repo_path, updater, updater.ignore_dirs, stop, debounce, on_update
+        )
+
+
+@contextmanager
+def _watching(repo_path: Path | None) -> Iterator[None]:
+    """Keep the graph of repo_path current in the background, if given."""
+    if repo_path is None:
+        yield
+        return
+    stop = threading.Event()
+    watcher = threading.Thread(
+        target=_watch, args=(repo_path.resolve(), stop), daemon=True
+    )
+    watcher.start()
+    try:
+        yield
+    finally:
+        stop.set()
+        watcher.join(timeout=5)
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def shard(
+    directory: str = typer.Argument(
+        ..., help="Top-level directory of the repository the shard covers"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository the directory is in"
+    ),
+    output: str | None = typer.Option(
+        None,
+        "--output",
+        "-o",
+        help="Shard file to write (default: <directory>.shard.jsonl)",
+    ),
+    parallel: bool = typer.Option(
+        False, "--parallel", help="Parse files on a pool of worker threads"
+    ),
+    workers: int | None = typer.Option(
+        None, "--workers", help="Number of parsing threads with --parallel"
+    ),
+) -> None:
+    """Parse one directory of a monorepo into a shard file, without Memgraph."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    name = Path(directory).as_posix().strip("/")
+    if not (target_repo_path / name).is_dir():
+        console.print(
+            f"[bold red]Error: {name} is not a directory of "
+            f"{target_repo_path}[/bold red]"
+        )
+        raise typer.Exit(1)
+    shard_file = Path(output or f"{name.replace('/', '_')}.shard.jsonl")
+
+    parsers, queries = load_parsers()
+    with DryRunIngestor() as ingestor:
+        updater = GraphUpdater(
+            ingestor,
+            target_repo_path,
+            parsers,
+            queries,
+            parallel=parallel,
+            num_workers=workers,
+            folder_filter=name,
+            progress=_file_progress(),
+            parse_cache=_parse_cache(target_repo_path),
+        )
+        writer = write_shard(updater, name, shard_file)
+    console.print(
+        f"[bold green]Wrote shard {name} to {shard_file}:[/bold green] "
+        f"{writer.nodes} nodes, {writer.relationships} relationships, "
+        f"{len(updater.unresolved_calls or [])} calls left for the merge"
+    )
+
+
+@app.command("merge-shards", rich_help_panel=GRAPH_PANEL)
+def merge_shards(
+    shard_files: list[str] = typer.Argument(..., help="Shard files to merge"),
+    repo_path: str | None = typer.Option(
+        None,
+        "--repo-path",
+        help="Repository the shards were built from, which names the project",
+    ),
+) -> None:
+    """Load shard files into the graph and resolve calls between them."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    # Shards built with --private or PRIVATE_INGESTION hold hashes already
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT, private=False
+    ) as ingestor:
+        updater = GraphUpdater(ingestor, target_repo_path, {}, {})
+        try:
+            result = load_shards(ingestor, updater, [Path(f) for f in shard_files])
+        except (OSError, ValueError) as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+        _refresh_symbol_index(ingestor)
+    console.print(
+        f"[bold green]Merged {len(result.shards)} shards:[/bold green] "
+        f"{result.nodes} nodes, {result.relationships} relationships; "
+        f"{result.calls_linked} calls across shards linked, "
+        f"{result.calls_unresolved} unresolved"
+    )
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def snapshot(
+    output: str = typer.Option(
+        "cgr-snapshot.tar.gz", "-o", "--output", help="Archive to write"
+    ),
+) -> None:
+    """Save the whole graph, embeddings included, to a portable archive."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        try:
+            result = save_snapshot(ingestor, Path(output))
+        except OSError as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+    embeddings = (
+        f"embeddings from {result.embedding_model}"
+        if result.embedding_model
+        else "no embeddings"
+    )
+    console.print(
+        f"[bold green]Saved snapshot to {result.path}:[/bold green] "
+        f"{result.nodes} nodes, {result.relationships} relationships, {embeddings}"
+    )
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def restore(
+    archive: str = typer.Argument(..., help="Archive written by snapshot"),
+    replace: bool = typer.Option(
+        False, "--replace", help="Delete the graph first if it is not empty"
+    ),
+) -> None:
+    """Load a graph snapshot instead of ingesting from scratch."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        try:
+            result = restore_snapshot(ingestor, Path(archive), replace)
+        except (OSError, tarfile.TarError, ValueError) as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+        _refresh_symbol_index(ingestor)
+    console.print(
+        f"[bold green]Restored {', '.join(result.projects) or 'snapshot'}:"
+        f"[/bold green] {result.nodes} nodes, {result.relationships} relationships"
+    )
+
+
+@app.command(rich_help_panel=GRAPH_PANEL)
+def fsck(
+    repair_graph: bool = typer.Option(
+        False, "--repair", help="Remove modules whose file was deleted"
+    ),
+    samples: int = typer.Option(10, "--samples", help="Examples shown per problem"),
+    json_output: bool = typer.Option(False, "--json", help="Print JSON"),
+) -> None:
+    """Check the graph for duplicates, stale modules and orphaned definitions."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        findings = check_graph(ingestor, samples)
+        if repair_graph and any(f.count and f.repairable for f in findings):
+            removed = repair(ingestor)
+            if not json_output:
+                console.print(f"[green]Removed {removed} stale modules[/green]")
+            findings = check_graph(ingestor, samples)
+    inconsistent = any(f.count for f in findings)
+
+    if json_output:
+        print(json.dumps(findings_to_dict(findings), indent=2))
+    else:
+        table = Table(title="[bold green]Graph Consistency[/bold green]")
+        table.add_column("Check", style="cyan")
+        table.add_column("Found", justify="right")
+        for finding in findings:
+            style = "red" if finding.count else "green"
+            table.add_row(finding.name, f"[{style}]{finding.count}[/{style}]")
+        console.print(table)
+        for finding in findings:
+            if not finding.count:
+                continue
+            console.print(f"[bold]{finding.name}:[/bold] {finding.fix}")
+            for sample in finding.samples:
+                console.print(f"  {sample}")
+            if finding.count > len(finding.samples):
+                console.print(f"  ... and {finding.count - len(finding.samples)} more")
+    if inconsistent:
+        raise typer.Exit(1)
+
+
+@app.command("completion-index", rich_help_panel=SETUP_PANEL)
+def completion_index() -> None:
+    """Rebuild the symbol names shell completion offers from the graph."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        count = write_symbol_index(ingestor)
+    console.print(f"[bold green]Indexed {count} symbols for completion[/bold green]")
+
+
+def _ingest_repository(
+    ingestor: MemgraphIngestor,
+    repo_path: Path,
+    report_file: Path | None = None,
+    **options: Any,
+) -> None:
+    """Parse the repository into the graph, then refresh completion and reports."""
+    parsers, queries = load_parsers()
+    updater = GraphUpdater(ingestor, repo_path, parsers, queries, **options)
+    updater.run()
+    _refresh_symbol_index(ingestor)
+    if updater.report:
+        _record_ingestion_report(ingestor, updater.report, report_file)
+
+
+def _parse_cache(repo_path: Path, enabled: bool = True) -> ParseCache | None:
+    # Private ingestion keeps no plain code on disk, so nothing is cached
+    if not enabled or not settings.PARSE_CACHE_DIR or settings.PRIVATE_INGESTION:
+        return None
+    return ParseCache(Path(settings.PARSE_CACHE_DIR).expanduser(), repo_path)
+
+
+def _ingest_checkpoint(repo_path: Path) -> IngestCheckpoint | None:
+    if not settings.CHECKPOINT_DIR:
+        return None
+    return IngestCheckpoint(
+        Path(settings.CHECKPOINT_DIR).expanduser(),
+        repo_path,
+        settings.CHECKPOINT_INTERVAL,
+    )
+
+
+def _file_progress() -> ConsoleProgress | None:
+    # A bar redrawn in place only makes sense on a terminal
+    return ConsoleProgress(console) if console.is_terminal else None
+
+
+def _record_ingestion_report(
+    ingestor: MemgraphIngestor, report: IngestionReport, path: Path | None
+) -> None:
+    store_run_summary(ingestor, report)
+    written = write_report(report, path)
+    console.print(
+        f"[bold]Run {report.run_id}:[/bold] "
+        f"{sum(report.node_counts.values())} nodes, "
+        f"{sum(report.relationship_counts.values())} relationships, "
+        f"{len(report.parse_errors)} parse errors, "
+        f"{len(report.skipped_files)} skipped, {report.warning_count} warnings "
+        f"in {report.duration_seconds:.1f}s; report: {written}"
+    )
+
+
+def _refresh_symbol_index(ingestor: MemgraphIngestor) -> None:
+    # A stale completion list is no reason to fail an ingestion
+    try:
+        write_symbol_index(ingestor)
+    except OSError as e:
+        logger.warning(f"Could not update the completion index: {e}")
+
+
+@app.command("languages", rich_help_panel=SETUP_PANEL)
+def languages() -> None:
+    """List built-in and plugin languages and whether their grammars load."""
+    load_language_plugins(settings.LANGUAGE_PLUGIN_DIRS)
+    for problem in configure_languages(
+        settings.DISABLED_LANGUAGES, settings.LANGUAGE_EXTENSIONS
+    ):
+        console.print(f"[yellow]Warning: {problem}[/yellow]")
+    table = Table(title="[bold green]Languages[/bold green]")
+    table.add_column("Language", style="cyan")
+    table.add_column("Extensions")
+    table.add_column("Source")
+    table.add_column("Grammar")
+    for name in LANGUAGE_CONFIGS:
+        plugin = REGISTERED_PLUGINS.get(name)
+        loader = plugin.language if plugin else LANGUAGE_LIBRARIES.get(name)
+        table.add_row(
+            name,
+            " ".join(language_extensions(name)),
+            plugin.source if plugin else "built-in",
+            "[dim]disabled[/dim]"
+            if name in DISABLED_LANGUAGES
+            else _grammar_status(loader),
+        )
+    console.print(table)
+
+
+def _grammar_status(loader: Any) -> str:
+    if loader is None:
+        return "[yellow]not installed[/yellow]"
+    try:
+        Language(loader())
+    except Exception as e:
+        return f"[red]failed: {e}[/red]"
+    return "[green]ok[/green]"
+
+
+@config_app.command("validate")
+def config_validate(
+    path: str | None = typer.Argument(
+        None, help="Config file (default: the one found automatically)"
+    ),
+) -> None:
+    """Check a config file's sections, setting names and every profile."""
+    config_path = Path(path) if path else find_config_file()
+    if config_path is None:
+        console.print("[bold red]Error: no config file found[/bold red]")
+        raise typer.Exit(1)
+    try:
+        problems = validate_config_file(config_path)
+    except ConfigFileError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if problems:
+        for problem in problems:
+            console.print(f"[red]- {problem}[/red]")
+        console.print(
+            f"[bold red]{len(problems)} problem(s) in {config_path}[/bold red]"
+        )
+        raise typer.Exit(1)
+    console.print(f"[bold green]{config_path} is valid.[/bold green]")
+
+
+@config_app.command("show")
+def config_show(
+    show_secrets: bool = typer.Option(
+        False, "--show-secrets", help="Print API keys and tokens unmasked"
+    ),
+) -> None:
+    """Show the effective settings after profile and environment overrides."""
+    table = Table(title="[bold green]Settings[/bold green]")
+    table.add_column("Setting", style="cyan")
+    table.add_column("Value")
+    for name in type(settings).model_fields:
+        value = getattr(settings, name)
+        secret = any(marker in name for marker in SECRET_SETTING_MARKERS)
+        if value is not None and secret and not show_secrets:
+            value = "********"
+        table.add_row(name, "" if value is None else str(value))
+    console.print(table)
+    console.print(f"Config file: {active_config_file() or 'none'}")
+
+
+@app.command("api-diff", rich_help_panel=INSIGHT_PANEL)
+def api_diff(
+    base: str = typer.Argument(
+        ..., help="Base commit, tag or branch, or an archive written by snapshot"
+    ),
+    head: str = typer.Argument(
+        ..., help="Head commit, tag or branch, or an archive written by snapshot"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository"
+    ),
+    as_json: bool = typer.Option(
+        False, "--json", help="Print the diff as JSON instead of a table"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the diff to a JSON file"
+    ),
+    fail_on_breaking: bool = typer.Option(
+        False,
+        "--fail-on-breaking",
+        help="Exit with status 1 if any change is breaking",
+    ),
+) -> None:
+    """Compare exported API between two revisions and the version bump it needs."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    diff = _classified_api_diff(target_repo_path, base, head)
+    if as_json:
+        print(json.dumps(diff.to_dict(), indent=2))
+    else:
+        _print_api_diff(diff)
+    if output:
+        _write_json_report(diff.to_dict(), output)
+    if fail_on_breaking and diff.breaking_changes:
+        if not as_json:
+            console.print(
+                f"[bold red]{len(diff.breaking_changes)} breaking change(s) "
+                f"between {base} and {head}.[/bold red]"
+            )
+        raise typer.Exit(1)
+
+
+def _classified_api_diff(repo_path: Path, base: str, head: str) -> ApiDiff:
+    """
+    Read the public API from the graphs of both revisions or snapshots and
+    classify the differences.
+    """
+    old = api_snapshot(_graph_at(repo_path, base).labelled("ApiSymbol"))
+    new = api_snapshot(_graph_at(repo_path, head).labelled("ApiSymbol"))
+    return classify_diff(diff_api(old, new, base=base, head=head), old, new)
+
+
+@app.command("changelog", rich_help_panel=INSIGHT_PANEL)
+def changelog(
+    base: str = typer.Argument(..., help="Previous release tag or commit"),
+    head: str = typer.Argument(..., help="New release tag or commit"),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository"
+    ),
+    as_json: bool = typer.Option(
+        False, "--json", help="Print the changelog as JSON instead of Markdown"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the changelog to this file"
+    ),
+) -> None:
+    """Generate a changelog between two tags from commits and the API diff."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    try:
+        commits = commits_between(target_repo_path, base, head)
+    except subprocess.CalledProcessError as e:
+        console.print(f"[bold red]Error: git log failed: {e.stderr.strip()}[/bold red]")
+        raise typer.Exit(1) from e
+    result = build_changelog(
+        commits, _classified_api_diff(target_repo_path, base, head)
+    )
+
+    if as_json:
+        rendered = json.dumps(result.to_dict(), indent=2) + "\n"
+    else:
+        rendered = result.to_markdown()
+    if output:
+        Path(output).write_text(rendered, encoding="utf-8")
+        console.print(f"[bold green]Changelog written to {output}[/bold green]")
+    else:
+        print(rendered, end="")
+
+
+@app.command("report", rich_help_panel=INSIGHT_PANEL)
+def architecture_report(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the repository that was ingested"
+    ),
+    output: str | None = typer.Option(
+        None,
+        "-o",
+        "--output",
+        help="Write the report to this file; .html files get the HTML report",
+    ),
+    report_format: str | None = typer.Option(
+        None,
+        "--format",
+        help="'markdown' or 'html'; by default from the output file's suffix",
+        autocompletion=choices("markdown", "html"),
+    ),
+    limit: int = typer.Option(
+        10, "--limit", help="Hotspots and packages with test gaps to list"
+    ),
+    depth: int = typer.Option(
+        2, "--depth", help="Directory levels that make up a package in the diagram"
+    ),
+    since: str | None = typer.Option(
+        None,
+        "--since",
+        help="Only count commits after this date for hotspots (e.g. '6 months ago')",
+    ),
+) -> None:
+    """Write an architecture report of dependencies, hotspots, gaps and owners."""
+    if report_format is None:
+        suffix = Path(output).suffix.lower() if output else ""
+        report_format = "html" if suffix in (".html", ".htm") else "markdown"
+    if report_format not in ("markdown", "html"):
+        console.print(
+            "[bold red]Error: --format must be 'markdown' or 'html'.[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    history = GitAnalyzer(target_repo_path).get_diff_hunks(since=since)
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        report = build_report(
+            ingestor, target_repo_path.name, history, limit=limit, depth=depth
+        )
+
+    rendered = report.to_html() if report_format == "html" else report.to_markdown()
+    if output:
+        output_path = Path(output)
+        output_path.parent.mkdir(parents=True, exist_ok=True)
+        output_path.write_text(rendered, encoding="utf-8")
+        console.print(
+            f"[bold green]Report written to: {output_path.absolute()}[/bold green]"
+        )
+    else:
+        print(rendered, end="")
+
+
+def _print_api_diff(diff: ApiDiff) -> None:
+    """Render an API diff as a table."""
+    if diff.is_empty:
+        console.print(
+            f"[bold green]No public API changes between {diff.base} and {diff.head}."
+            "[/bold green]"
+        )
+        return
+
+    table = Table(
+        title=f"[bold green]API changes {diff.base}..{diff.head}[/bold green]"
+    )
+    table.add_column("Change", style="bold")
+    table.add_column("Symbol", style="cyan")
+    table.add_column("Kind", style="magenta")
+    table.add_column("Details")
+    table.add_column("Breaking")
+    styles = {"added": "green", "removed": "red", "changed": "yellow"}
+    for change in [*diff.removed, *diff.changed, *diff.added]:
+        if change.change == "changed":
+            details = []
+            if change.old_signature != change.new_signature:
+                details += [f"- {change.old_signature}", f"+ {change.new_signature}"]
+            details += [f"- {m}" for m in change.removed_members]
+            details += [f"+ {m}" for m in change.added_members]
+        else:
+            details = [change.new_signature or change.old_signature or ""]
+        table.add_row(
+            f"[{styles[change.change]}]{change.change}[/{styles[change.change]}]",
+            change.qualified_name,
+            change.kind,
+            "\n".join(details),
+            f"[red]yes[/red]: {change.reason}" if change.breaking else change.reason,
+        )
+    console.print(table)
+
+    bump = f"Semantic version: [bold]{diff.bump}[/bold] release"
+    if diff.next_version:
+        bump += f", tag [bold cyan]{diff.next_version}[/bold cyan] after {diff.base}"
+    console.print(bump)
+    version = VERSION_TAG.match(diff.next_version or "")
+    if (
+        diff.bump == "major"
+        and version
+        and int(version[2]) >= 2
+        and any(c.path.endswith(".go") for c in diff.breaking_changes)
+    ):
+        console.print(
+            f"[dim]Go modules from v2 on carry the major version in their path; "
+            f"add /v{version[2]} to the module line of go.mod.[/dim]"
+        )
+
+
+@graph_app.command("diff")
+def graph_diff(
+    base: str = typer.Argument(
+        ..., help="Base commit, tag or branch, or an archive written by snapshot"
+    ),
+    head: str = typer.Argument(
+        ..., help="Head commit, tag or branch, or an archive written by snapshot"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Git repository the revisions belong to"
+    ),
+    as_json: bool = typer.Option(False, "--json", help="Print the diff as JSON"),
+    markdown: bool = typer.Option(
+        False, "--markdown", help="Print the diff as Markdown release notes"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the diff to a JSON file"
+    ),
+) -> None:
+    """Report the functions, dependencies and call edges two graphs differ in."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    diff = diff_graphs(
+        _graph_at(target_repo_path, base), _graph_at(target_repo_path, head), base, head
+    )
+    if as_json:
+        print(json.dumps(diff.to_dict(), indent=2))
+    elif markdown:
+        print(diff.to_markdown(), end="")
+    else:
+        _print_graph_diff(diff)
+    if output:
+        _write_json_report(diff.to_dict(), output)
+
+
+def _graph_at(repo_path: Path, revision: str) -> GraphRecord:
+    """The graph of a snapshot archive, or else of a Git revision."""
+    if Path(revision).is_file():
+        try:
+            return graph_from_snapshot(Path(revision))
+        except (OSError, ValueError, tarfile.TarError) as e:
+            console.print(f"[bold red]Error: {e}[/bold red]")
+            raise typer.Exit(1) from e
+    parsers, queries = load_parsers()
+    try:
+        with console.status(f"[bold green]Ingesting {revision}...[/bold green]"):
+            return graph_at_revision(repo_path, revision, parsers, queries)
+    except subprocess.CalledProcessError as e:
+        stderr = e.stderr.decode("utf-8", errors="replace").strip()
+        console.print(f"[bold red]Error: git archive failed: {stderr}[/bold red]")
+        raise typer.Exit(1) from e
+
+
+def _print_graph_diff(diff: GraphDiff) -> None:
+    """Render a graph diff as a table per kind of change."""
+    if diff.is_empty:
+        console.print(
+            f"[bold green]No architectural changes between {diff.base} and "
+            f"{diff.head}.[/bold green]"
+        )
+        return
+    styles = {"added": "green", "removed": "red", "changed": "yellow"}
+
+    def change(text: str) -> str:
+        return f"[{styles[text]}]{text}[/{styles[text]}]"
+
+    if diff.symbols:
+        table = Table(
+            title=f"[bold green]Symbols {diff.base}..{diff.head}[/bold green]"
+        )
+        table.add_column("Change", style="bold")
+        table.add_column("Symbol", style="cyan")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Details")
+        for symbol in diff.symbols:
+            table.add_row(
+                change(symbol.change),
+                symbol.qualified_name,
+                symbol.label,
+                "\n".join(symbol.details),
+            )
+        console.print(table)
+    if diff.dependencies:
+        table = Table(title="[bold green]Dependencies[/bold green]")
+        table.add_column("Change", style="bold")
+        table.add_column("Dependency", style="cyan")
+        table.add_column("Before")
+        table.add_column("After")
+        for dependency in diff.dependencies:
+            table.add_row(
+                change(dependency.change),
+                dependency.name,
+                dependency.old_version,
+                dependency.new_version,
+            )
+        console.print(table)
+    if diff.edges:
+        table = Table(title="[bold green]Calls and imports[/bold green]")
+        table.add_column("Change", style="bold")
+        table.add_column("Edge", style="magenta")
+        table.add_column("From", style="cyan")
+        table.add_column("To", style="cyan")
+        for edge in diff.edges:
+            table.add_row(change(edge.change), edge.type, edge.source, edge.target)
+        console.print(table)
+    summary = ", ".join(f"{count} {name}" for name, count in diff.counts().items())
+    console.print(f"[dim]{summary}[/dim]")
+
+
+@workspace_app.command("add")
+def workspace_add(
+    path: str = typer.Argument(..., help="Repository to add to the workspace"),
+) -> None:
+    """Register a repository as a [[repositories]] entry of the config file."""
+    config_file = active_config_file() or Path.cwd() / CONFIG_FILE_NAME
+    if config_file.suffix != ".toml":
+        console.print(
+            f"[bold red]Error: add the repository to the repositories list of "
+            f"{config_file} by hand.[/bold red]"
+        )
+        raise typer.Exit(1)
+    repository = Path(path).expanduser()
+    if not repository.is_dir():
+        console.print(f"[bold red]Error: {repository} is not a directory.[/bold red]")
+        raise typer.Exit(1)
+    registered = (
+        _workspace_repositories(config_file, required=False)
+        if config_file.is_file()
+        else []
+    )
+    if any(r.path == repository.resolve() for r in registered):
+        console.print(f"{repository} is already in the workspace of {config_file}.")
+        return
+    added = WorkspaceRepository(repository.resolve())
+    if any(r.name == added.name for r in registered):
+        console.print(
+            f"[bold red]Error: a repository named '{added.name}' is already in "
+            f"the workspace.[/bold red]"
+        )
+        raise typer.Exit(1)
+    with config_file.open("a", encoding="utf-8") as f:
+        f.write(repository_entry(repository, config_file.parent))
+    console.print(f"[bold green]Added {added.name} to {config_file}.[/bold green]")
+
+
+@workspace_app.command("list")
+def workspace_list() -> None:
+    """List the repositories of the workspace and those they depend on."""
+    repositories = _workspace_repositories()
+    dependencies = repository_dependencies(repositories)
+    table = Table(title="[bold green]Workspace Repositories[/bold green]")
+    table.add_column("Repository", style="cyan")
+    table.add_column("Path")
+    table.add_column("Go modules", style="magenta")
+    table.add_column("Depends on", style="yellow")
+    for repository in repositories:
+        table.add_row(
+            repository.name,
+            str(repository.path),
+            "\n".join(repository.modules),
+            ", ".join(sorted(dependencies.get(repository.name, {}))),
+        )
+    console.print(table)
+
+
+@workspace_app.command("ingest")
+def workspace_ingest(
+    clean: bool = typer.Option(
+        False, "--clean", help="Clean the database before ingesting"
+    ),
+    batch_size: int | None = typer.Option(
+        None,
+        "--batch-size",
+        min=1,
+        help="Nodes or relationships per batched write (default: GRAPH_BATCH_SIZE)",
+    ),
+    skip_tests: bool = typer.Option(
+        False, "--skip-tests", help="Skip test files during ingestion"
+    ),
+) -> None:
+    """Ingest every repository of the workspace into one graph."""
+    repositories = _workspace_repositories()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST,
+        port=settings.MEMGRAPH_PORT,
+        batch_size=batch_size,
+    ) as ingestor:
+        if clean:
+            console.print("[bold yellow]Cleaning database...[/bold yellow]")
+            ingestor.clean_database()
+        provision_schema(ingestor)
+        parsers, queries = load_parsers()
+        updaters = ingest_workspace(
+            ingestor,
+            repositories,
+            parsers,
+            queries,
+            skip_tests=skip_tests,
+            progress=_file_progress(),
+        )
+        _refresh_symbol_index(ingestor)
+        for updater in updaters:
+            if updater.report:
+                _record_ingestion_report(ingestor, updater.report, None)
+    console.print(
+        f"[bold green]Ingested {len(updaters)} repositories into one graph."
+        "[/bold green]"
+    )
+
+
+@workspace_app.command("callers")
+def workspace_callers(
+    repo: str = typer.Argument(..., help="Repository whose callers to list"),
+    limit: int = typer.Option(100, "--limit", min=1, help="Calls listed at most"),
+    as_json: bool = typer.Option(False, "--json", help="Print the calls as JSON"),
+) -> None:
+    """List calls into a repository's functions from the other repositories."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        rows = cross_repository_callers(ingestor, repo, limit)
+    if as_json:
+        print(json.dumps(rows, indent=2))
+        return
+    if not rows:
+        console.print(
+            f"[bold green]Nothing outside {repo} calls into it.[/bold green]"
+        )
+        return
+    table = Table(title=f"[bold green]Callers of {repo} elsewhere[/bold green]")
+    table.add_column("Function", style="cyan")
+    table.add_column("Repository", style="magenta")
+    table.add_column("Caller", style="yellow")
+    for row in rows:
+        table.add_row(row["callee"], row["caller_repo"], row["caller"])
+    console.print(table)
+
+
+def _workspace_repositories(
+    config_file: Path | None = None, required: bool = True
+) -> list[WorkspaceRepository]:
+    """
+    The [[repositories]] of the config file; exits when it is broken, or
+    when required and there are none.
+    """
+    config_file = config_file or active_config_file()
+    try:
+        repositories = (
+            load_repositories(read_config_file(config_file), config_file.parent)
+            if config_file
+            else []
+        )
+    except (ConfigFileError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if required and not repositories:
+        console.print(
+            "[bold yellow]No repositories in the workspace; add them with "
+            "`workspace add PATH`.[/bold yellow]"
+        )
+        raise typer.Exit(1)
+    return repositories
+
+
+@query_app.command("list")
+def query_list() -> None:
+    """List the saved queries and the parameters they take."""
+    queries = _saved_queries()
+    if not queries:
+        console.print(
+            "[bold yellow]No saved queries; add [queries.NAME] tables to the "
+            "config file or use `query save`.[/bold yellow]"
+        )
+        return
+    table = Table(title="[bold green]Saved queries[/bold green]")
+    table.add_column("Name", style="cyan")
+    table.add_column("Parameters", style="magenta")
+    table.add_column("Description")
+    for saved in queries.values():
+        table.add_row(
+            saved.name,
+            " ".join(
+                f"--{p.replace('_', '-')}"
+                + (f"={saved.defaults[p]}" if p in saved.defaults else "")
+                for p in saved.parameters
+            ),
+            saved.description,
+        )
+    console.print(table)
+
+
+@query_app.command(
+    "run",
+    context_settings={"allow_extra_args": True, "ignore_unknown_options": True},
+)
+def query_run(
+    ctx: typer.Context,
+    name: str = typer.Argument(..., help="Name of the saved query"),
+    as_json: bool = typer.Option(False, "--json", help="Print the rows as JSON"),
+) -> None:
+    """Run a saved query, giving its parameters as --name value options."""
+    saved = _saved_queries().get(name)
+    if saved is None:
+        console.print(
+            f"[bold red]Error: no query is saved as '{name}'; `query list` "
+            "shows them.[/bold red]"
+        )
+        raise typer.Exit(1)
+    try:
+        arguments = parse_arguments(ctx.args)
+        with MemgraphIngestor(
+            host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+        ) as ingestor:
+            result = saved.run(TemplateRunner(ingestor), arguments)
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    for symbol in result.unresolved:
+        console.print(f"[yellow]No symbol in the graph matches {symbol}.[/yellow]")
+    if as_json:
+        print(json.dumps(result.results, indent=2, default=str))
+    else:
+        console.print(rows_table(result.results))
+
+
+@query_app.command("save")
+def query_save(
+    name: str = typer.Argument(..., help="Name to run the query by"),
+    query: str = typer.Argument(..., help="The Cypher query, with $parameters"),
+    description: str = typer.Option(
+        "", "--description", help="What the query answers, shown to the agent too"
+    ),
+    symbols: str = typer.Option(
+        "",
+        "--symbols",
+        help="Comma separated parameters matched to qualified names, e.g. symbol",
+    ),
+) -> None:
+    """Save a query as a [queries.NAME] table of the config file."""
+    config_file = active_config_file() or Path.cwd() / CONFIG_FILE_NAME
+    saved = SavedQuery(name, query.strip(), description, split_names(symbols))
+    unknown = [s for s in saved.symbols if s not in saved.parameters]
+    try:
+        if unknown:
+            raise ValueError(f"The query has no parameter ${unknown[0]}")
+        save_query(config_file, saved)
+    except ValueError as e:
+        console.print(f"[bold red]Error: {e}.[/bold red]")
+        raise typer.Exit(1) from e
+    console.print(f"[bold green]Saved {name} to {config_file}.[/bold green]")
+
+
+def _saved_queries() -> dict[str, SavedQuery]:
+    """The saved queries of the config file; exits when they are broken."""
+    try:
+        return saved_queries()
+    except ValueError as e:  # Includes ConfigFileError
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+
+
+@app.command(rich_help_panel=INSIGHT_PANEL)
+def sbom(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the repository to describe"
+    ),
+    sbom_format: str = typer.Option(
+        "cyclonedx",
+        "--format",
+        help="Document format: cyclonedx or spdx",
+        autocompletion=choices("cyclonedx", "spdx"),
+    ),
+    manifest: str | None = typer.Option(
+        None,
+        "--manifest",
+        help="Scope the SBOM to one module's manifest, e.g. services/api/go.mod",
+    ),
+    include_dev: bool = typer.Option(
+        False, "--include-dev", help="Include development and optional dependencies"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the SBOM to a file instead of stdout"
+    ),
+) -> None:
+    """Generate a CycloneDX or SPDX SBOM from the repository's dependencies."""
+    renderers = {"cyclonedx": to_cyclonedx, "spdx": to_spdx}
+    if sbom_format not in renderers:
+        console.print(f"[bold red]Error: unknown format '{sbom_format}'[/bold red]")
+        raise typer.Exit(1)
+
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    components = SbomBuilder(target_repo_path, include_dev).collect(manifest)
+    if manifest and not components:
+        console.print(
+            f"[bold yellow]No dependencies found for manifest {manifest}[/bold yellow]"
+        )
+    name = target_repo_path.name
+    if manifest:
+        name = f"{name}/{Path(manifest).parent.as_posix()}".removesuffix("/.")
+    document = renderers[sbom_format](components, name)
+    if output:
+        _write_json_report(document, output)
+    else:
+        print(json.dumps(document, indent=2))
+
+
+@app.command(rich_help_panel=REVIEW_PANEL)
+def review(
+    base: str | None = typer.Argument(None, help="Base commit, tag or branch"),
+    head: str | None = typer.Argument(None, help="Head commit, tag or branch"),
+    diff_file: str | None = typer.Option(
+        None, "--diff", help="Read a unified diff from this file ('-' for stdin)"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository, for BASE and HEAD"
+    ),
+    github: str | None = typer.Option(
+        None, "--github", help="GitHub repository (owner/name) of the pull request"
+    ),
+    gitlab: str | None = typer.Option(
+        None, "--gitlab", help="GitLab project (group/name) of the merge request"
+    ),
+    pr: int | None = typer.Option(
+        None, "--pr", help="Pull or merge request number; its diff is fetched"
+    ),
+    api_url: str | None = typer.Option(
+        None,
+        "--api-url",
+        help="API base URL, for GitHub Enterprise or a self-hosted GitLab",
+    ),
+    post: bool = typer.Option(
+        False, "--post", help="Post the comments to the pull or merge request"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the review to a JSON file"
+    ),
+) -> None:
+    """Review a change using graph context: callers, tests, owners and endpoints."""
+    publisher: ReviewPublisher | None = None
+    if (github or gitlab) and pr is None:
+        console.print(
+            "[bold red]Error: --pr is required with --github/--gitlab[/bold red]"
+        )
+        raise typer.Exit(1)
+    if github and pr is not None:
+        publisher = GitHubReviewPublisher(
+            github, pr, settings.GITHUB_TOKEN, api_url or "https://api.github.com"
+        )
+    elif gitlab and pr is not None:
+        publisher = GitLabReviewPublisher(
+            gitlab, pr, settings.GITLAB_TOKEN, api_url or "https://gitlab.com/api/v4"
+        )
+
+    diff_text = _read_diff(diff_file, base, head, repo_path)
+    if diff_text is None and publisher:
+        diff_text = publisher.fetch_diff()
+    elif diff_text is None:
+        console.print(
+            "[bold red]Error: give BASE and HEAD, --diff, or a pull request "
+            "with --github/--gitlab and --pr[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        report = ReviewAssistant(ingestor).review(parse_unified_diff(diff_text))
+
+    _print_review(report)
+    if output:
+        _write_json_report(report.to_dict(), output)
+    if post:
+        if publisher is None:
+            console.print(
+                "[bold red]Error: --post needs --github or --gitlab[/bold red]"
+            )
+            raise typer.Exit(1)
+        posted = publisher.publish(report)
+        console.print(
+            f"[bold green]Posted review with {posted} comment(s).[/bold green]"
+        )
+
+
+@app.command("impact", rich_help_panel=REVIEW_PANEL)
+def impact(
+    base: str | None = typer.Argument(None, help="Base commit, tag or branch"),
+    head: str | None = typer.Argument(None, help="Head commit, tag or branch"),
+    diff_file: str | None = typer.Option(
+        None, "--diff", help="Read a unified diff from this file ('-' for stdin)"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository, for BASE and HEAD"
+    ),
+    depth: int = typer.Option(
+        DEFAULT_MAX_DEPTH, "--depth", help="Levels of callers to follow up"
+    ),
+    list_format: str = typer.Option(
+        "table",
+        "--list",
+        help="Print a table, or one line per test, package or `go test` run",
+        autocompletion=choices("table", "tests", "packages", "go"),
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the selection to a JSON file"
+    ),
+    github: str | None = typer.Option(
+        None, "--github", help="GitHub repository (owner/name) of the pull request"
+    ),
+    pr: int | None = typer.Option(
+        None, "--pr", help="Pull request number; its diff is fetched if not given"
+    ),
+    api_url: str = typer.Option(
+        "https://api.github.com",
+        "--api-url",
+        help="API base URL, for GitHub Enterprise",
+    ),
+    post: bool = typer.Option(
+        False,
+        "--post",
+        help="Comment on the pull request with the tests covering the diff, "
+        "editing the earlier comment on later runs",
+    ),
+) -> None:
+    """Select the tests to run for a change: those reaching the changed code."""
+    if list_format not in ("table", "tests", "packages", "go"):
+        console.print(f"[bold red]Error: unknown list '{list_format}'[/bold red]")
+        raise typer.Exit(1)
+    publisher = None
+    if github and pr is not None:
+        publisher = GitHubReviewPublisher(github, pr, settings.GITHUB_TOKEN, api_url)
+    elif github or pr is not None or post:
+        console.print(
+            "[bold red]Error: --github and --pr go together, and --post needs "
+            "both[/bold red]"
+        )
+        raise typer.Exit(1)
+    diff_text = _read_diff(diff_file, base, head, repo_path)
+    if diff_text is None and publisher:
+        diff_text = publisher.fetch_diff()
+    elif diff_text is None:
+        console.print(
+            "[bold red]Error: give BASE and HEAD, --diff, or a pull request "
+            "with --github and --pr[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        report = ImpactAnalyzer(ingestor).analyze(
+            parse_unified_diff(diff_text), depth
+        )
+
+    if output:
+        _write_json_report(report.to_dict(), output)
+    if post and publisher:
+        publisher.upsert_comment(impact_comment(report), IMPACT_COMMENT_MARKER)
+        console.print(f"[bold green]Commented on {github}#{pr}.[/bold green]")
+    if list_format == "table":
+        _print_impact(report)
+        return
+    # Plain lines for scripts, e.g. `impact main HEAD --list go | xargs -L1 go test`
+    if list_format == "tests":
+        lines = [test.qualified_name for test in report.tests]
+    elif list_format == "packages":
+        lines = report.packages
+    else:
+        lines = go_test_runs(report)
+    for line in lines:
+        print(line)
+
+
+def _print_impact(report: ImpactReport) -> None:
+    console.print(
+        f"[bold]{len(report.changed)} changed function(s) reach "
+        f"{len(report.tests)} test(s) in {len(report.packages)} package(s).[/bold]"
+    )
+    if report.tests:
+        table = Table(title="[bold green]Tests to Run[/bold green]")
+        table.add_column("Test", style="cyan")
+        table.add_column("File")
+        table.add_column("Reaches", style="magenta")
+        table.add_column("Calls away", justify="right")
+        for test in report.tests:
+            table.add_row(
+                test.qualified_name,
+                test.path or "",
+                test.changed,
+                str(test.distance),
+            )
+        console.print(table)
+    if report.untested:
+        console.print(
+            "[bold yellow]No test reaches: "
+            f"{', '.join(report.untested)}[/bold yellow]"
+        )
+
+
+@app.command("review-checklist", rich_help_panel=REVIEW_PANEL)
+def review_checklist(
+    base: str | None = typer.Argument(None, help="Base commit, tag or branch"),
+    head: str | None = typer.Argument(None, help="Head commit, tag or branch"),
+    diff_file: str | None = typer.Option(
+        None, "--diff", help="Read a unified diff from this file ('-' for stdin)"
+    ),
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository, for BASE and HEAD"
+    ),
+    output_format: str = typer.Option(
+        "markdown",
+        "--format",
+        help="markdown or json",
+        autocompletion=choices("markdown", "json"),
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the checklist to this file"
+    ),
+) -> None:
+    """Generate a reviewer checklist for a diff from graph facts."""
+    if output_format not in ("markdown", "json"):
+        console.print("[bold red]Error: --format must be markdown or json[/bold red]")
+        raise typer.Exit(1)
+    diff_text = _read_diff(diff_file, base, head, repo_path)
+    if diff_text is None:
+        console.print("[bold red]Error: give BASE and HEAD, or --diff[/bold red]")
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        report = ReviewAssistant(ingestor).review(parse_unified_diff(diff_text))
+        checklist = ReviewChecklistBuilder(ingestor).build(report)
+
+    if output_format == "json":
+        rendered = json.dumps(checklist.to_dict(), indent=2) + "\n"
+    else:
+        rendered = checklist.to_markdown()
+    if output:
+        Path(output).write_text(rendered, encoding="utf-8")
+        console.print(f"[bold green]Checklist written to {output}[/bold green]")
+    else:
+        print(rendered, end="")
+
+
+@app.command("commit-message", rich_help_panel=REVIEW_PANEL)
+def commit_message(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository with staged changes"
+    ),
+    diff_file: str | None = typer.Option(
+        None, "--diff", help="Use this diff instead of the staged one ('-' for stdin)"
+    ),
+    output_json: bool = typer.Option(False, "--json", help="Output as JSON"),
+) -> None:
+    """Propose a conventional commit message for the staged changes."""
+    if diff_file:
+        diff_text = _read_diff(diff_file, None, None, None) or ""
+    else:
+        target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+        try:
+            diff_text = staged_diff(target_repo_path)
+        except subprocess.CalledProcessError as e:
+            console.print(
+                f"[bold red]Error: git diff failed: {e.stderr.strip()}[/bold red]"
+            )
+            raise typer.Exit(1) from e
+    if not diff_text.strip():
+        console.print("[bold red]Error: nothing is staged for commit[/bold red]")
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        message = CommitMessageGenerator(ingestor).generate(diff_text)
+
+    if output_json:
+        print(json.dumps(message.to_dict(), indent=2))
+    else:
+        print(message.render(), end="")
+
+
+def _read_diff(
+    diff_file: str | None, base: str | None, head: str | None, repo_path: str | None
+) -> str | None:
+    """The diff to review from --diff or BASE and HEAD; None if neither is given."""
+    if diff_file:
+        if diff_file == "-":
+            return sys.stdin.read()
+        return Path(diff_file).read_text(encoding="utf-8")
+    if base and head:
+        target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+        try:
+            return diff_between_refs(target_repo_path, base, head)
+        except subprocess.CalledProcessError as e:
+            console.print(
+                f"[bold red]Error: git diff failed: {e.stderr.strip()}[/bold red]"
+            )
+            raise typer.Exit(1) from e
+    return None
+
+
+def _print_review(report: ReviewReport) -> None:
+    """Render changed symbols and review comments as tables."""
+    console.print(f"[bold]{report.summary()}[/bold]")
+    if not report.symbols:
+        return
+
+    table = Table(title="[bold green]Changed Symbols[/bold green]")
+    table.add_column("Symbol", style="cyan")
+    table.add_column("Location")
+    table.add_column("Callers", justify="right")
+    table.add_column("Tests", justify="right")
+    table.add_column("Endpoints")
+    table.add_column("Owners", style="magenta")
+    for symbol in report.symbols:
+        table.add_row(
+            symbol.qualified_name,
+            f"{symbol.path}:{symbol.start_line}-{symbol.end_line}",
+            str(len(symbol.callers)),
+            str(len(symbol.tests)) if symbol.tests else "[red]0[/red]",
+            "\n".join(symbol.endpoints),
+            ", ".join(symbol.owners),
+        )
+    console.print(table)
+
+    styles = {"warning": "yellow", "info": "blue"}
+    for comment in report.comments:
+        style = styles.get(comment.severity, "white")
+        console.print(
+            f"[{style}]{comment.severity}[/{style}] "
+            f"{comment.path}:{comment.line}: {comment.body}"
+        )
+
+
+def _write_json_report(data: Any, output: str) -> None:
+    """Write an analysis report to a JSON file."""
+    output_path = Path(output)
+    output_path.parent.mkdir(parents=True, exist_ok=True)
+    with open(output_path, "w", encoding="utf-8") as f:
+        json.dump(data, f, indent=2, ensure_ascii=False)
+    console.print(
+        f"[bold green]Report written to: {output_path.absolute()}[/bold green]"
+    )
+
+
+@analyze_app.command("hotspots")
+def analyze_hotspots(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the Git repository that was ingested"
+    ),
+    level: str = typer.Option(
+        "function",
+        "--level",
+        help="Rank 'function' or 'file' hotspots",
+        autocompletion=choices("function", "file"),
+    ),
+    limit: int = typer.Option(20, "--limit", help="Number of hotspots to display"),
+    since: str | None = typer.Option(
+        None,
+        "--since",
+        help="Only count commits after this date (e.g. '6 months ago')",
+    ),
+    store_scores: bool = typer.Option(
+        True,
+        "--store-scores/--no-store-scores",
+        help="Write churn and hotspot_score properties back to the graph",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the full ranking to a JSON file"
+    ),
+) -> None:
+    """Rank hotspots by Git churn multiplied by cyclomatic complexity."""
+    if level not in ("function", "file"):
+        console.print("[bold red]Error: --level must be 'function' or 'file'.[/bold red]")
+        raise typer.Exit(1)
+
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    git = GitAnalyzer(target_repo_path)
+    # Functions count the commits changing their own lines, files any commit
+    history = git.get_diff_hunks(since=since) if level == "function" else []
+    churn = git.get_file_churn(since=since) if level == "file" else {}
+    if not history and not churn:
+        console.print(
+            f"[bold red]Error: No Git history found for '{target_repo_path}'.[/bold red]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = HotspotAnalyzer(ingestor)
+        hotspots = (
+            analyzer.rank_functions(history)
+            if level == "function"
+            else analyzer.rank_files(churn)
+        )
+        if store_scores:
+            analyzer.store_scores(hotspots)
+
+    table = Table(title=f"[bold green]Top {level.capitalize()} Hotspots[/bold green]")
+    table.add_column("Name", style="cyan")
+    table.add_column("Path", style="magenta")
+    table.add_column("Churn", justify="right")
+    table.add_column("Complexity", justify="right")
+    table.add_column("Score", justify="right", style="bold yellow")
+    for hotspot in hotspots[:limit]:
+        table.add_row(
+            hotspot.qualified_name,
+            hotspot.path,
+            str(hotspot.churn),
+            str(hotspot.complexity),
+            f"{hotspot.score:.0f}",
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report([h.to_dict() for h in hotspots], output)
+
+
+@analyze_app.command("call-depth")
+def analyze_call_depth(
+    entrypoints: list[str] | None = typer.Option(
+        None,
+        "--entrypoint",
+        help="Extra entrypoint qualified-name pattern (glob); may be repeated",
+        autocompletion=complete_symbol,
+    ),
+    min_depth: int = typer.Option(
+        0, "--min-depth", help="Only display entrypoints at least this deep"
+    ),
+    limit: int = typer.Option(20, "--limit", help="Number of entrypoints to display"),
+    store: bool = typer.Option(
+        True,
+        "--store/--no-store",
+        help="Write call_depth_max, call_depth_avg and call_tree_size to the graph",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all entrypoint metrics to a JSON file"
+    ),
+) -> None:
+    """Measure call-tree depth and size below main, HTTP, CLI and job entrypoints."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = CallDepthAnalyzer(ingestor)
+        depths = analyzer.analyze(entrypoints)
+        if store:
+            analyzer.store_depths(depths)
+
+    shown = [d for d in depths if d.max_depth >= min_depth][:limit]
+    if not shown:
+        console.print("[bold yellow]No matching entrypoints found.[/bold yellow]")
+    else:
+        table = Table(title="[bold green]Entrypoint Call Depth[/bold green]")
+        table.add_column("Entrypoint", style="cyan")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Max depth", justify="right", style="bold yellow")
+        table.add_column("Avg depth", justify="right")
+        table.add_column("Reachable", justify="right")
+        for depth in shown:
+            table.add_row(
+                depth.qualified_name,
+                depth.kind,
+                str(depth.max_depth),
+                f"{depth.avg_depth:.1f}",
+                str(depth.tree_size),
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report([d.to_dict() for d in depths], output)
+
+
+@analyze_app.command("dead-code")
+def analyze_dead_code(
+    exclude: list[str] | None = typer.Option(
+        None,
+        "--exclude",
+        help="Package directory or path glob whose code counts as used, e.g. one "
+        "called through reflection; may be repeated",
+    ),
+    entrypoints: list[str] | None = typer.Option(
+        None,
+        "--entrypoint",
+        help="Extra entrypoint qualified-name pattern (glob); may be repeated",
+        autocompletion=complete_symbol,
+    ),
+    exported_roots: bool = typer.Option(
+        True,
+        "--exported-roots/--no-exported-roots",
+        help="Count exported functions and types as used, as a library's are",
+    ),
+    limit: int = typer.Option(50, "--limit", help="Number of symbols to display"),
+    store: bool = typer.Option(
+        True, "--store/--no-store", help="Write is_dead_code to the graph"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all dead symbols to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write all dead symbols to a SARIF file for code scanning"
+    ),
+) -> None:
+    """Find functions and types unreachable from entrypoints, exports and tests."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = DeadCodeAnalyzer(ingestor)
+        dead = analyzer.analyze(exported_roots, exclude, entrypoints)
+        if store:
+            analyzer.store_results(dead)
+
+    if not dead:
+        console.print("[bold green]No dead code found.[/bold green]")
+    else:
+        table = Table(title=f"[bold green]Dead Code ({len(dead)})[/bold green]")
+        table.add_column("Symbol", style="cyan")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Location")
+        table.add_column("Only used by", style="yellow")
+        for symbol in dead[:limit]:
+            location = f"{symbol.path}:{symbol.line_number or '?'}"
+            table.add_row(
+                symbol.qualified_name,
+                symbol.label,
+                location,
+                ", ".join(symbol.dead_users),
+            )
+        console.print(table)
+        console.print(
+            "[dim]Uses inside function bodies that are not calls, such as a type "
+            "in a variable declaration, are not in the graph; check before "
+            "deleting types.[/dim]"
+        )
+
+    if output:
+        _write_json_report([d.to_dict() for d in dead], output)
+    if sarif:
+        _write_json_report(build_sarif_log(dead_code_findings(dead)), sarif)
+
+
+@analyze_app.command("cycles")
+def analyze_cycles(
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all cycles to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write the cycles to a SARIF file for code scanning"
+    ),
+) -> None:
+    """Find import cycles between packages and the imports that close them."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        cycles = ImportCycleAnalyzer(ingestor).analyze()
+
+    if not cycles:
+        console.print("[bold green]No import cycles between packages.[/bold green]")
+    for number, cycle in enumerate(cycles, 1):
+        table = Table(
+            title=f"[bold green]Cycle {number}: "
+            f"{', '.join(cycle.packages)}[/bold green]"
+        )
+        table.add_column("Dependency", style="cyan")
+        table.add_column("Imports", justify="right")
+        table.add_column("Import statements", style="yellow")
+        for dependency in cycle.dependencies:
+            table.add_row(
+                f"{dependency.source} -> {dependency.target}",
+                str(len(dependency.imports)),
+                "\n".join(site.location for site in dependency.imports),
+            )
+        console.print(table)
+    if cycles:
+        console.print(
+            "[dim]Dependencies with the fewest imports are listed first; they "
+            "are usually the cheapest to break.[/dim]"
+        )
+
+    if output:
+        _write_json_report([c.to_dict() for c in cycles], output)
+    if sarif:
+        _write_json_report(build_sarif_log(import_cycle_findings(cycles)), sarif)
+
+
+@analyze_app.command("layering")
+def analyze_layering(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Repository whose .cgr.toml declares the layers"
+    ),
+    store: bool = typer.Option(
+        True,
+        "--store/--no-store",
+        help="Write Layer nodes, IN_LAYER edges and layer_violation on edges",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all violations to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write the violations to a SARIF file for code scanning"
+    ),
+) -> None:
+    """Check imports and calls against the layers declared in .cgr.toml."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    try:
+        layers = load_layers(_repo_config(target_repo_path))
+    except (ConfigFileError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+    if not layers:
+        console.print(
+            f"[bold yellow]No layers declared in the config of "
+            f"{target_repo_path}.[/bold yellow]"
+        )
+        raise typer.Exit(1)
+
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = LayeringAnalyzer(ingestor, layers)
+        violations = analyzer.analyze()
+        if store:
+            analyzer.store(violations)
+
+    console.print(
+        "Layers, outermost first: "
+        + " -> ".join(f"[cyan]{layer.name}[/cyan]" for layer in layers)
+    )
+    if not violations:
+        console.print("[bold green]No layering violations.[/bold green]")
+    else:
+        table = Table(title="[bold green]Layering Violations[/bold green]")
+        table.add_column("Rule broken", style="bold red")
+        table.add_column("Kind", style="magenta")
+        table.add_column("From", style="cyan")
+        table.add_column("To", style="cyan")
+        table.add_column("Location", style="yellow")
+        for violation in violations:
+            table.add_row(
+                violation.rule,
+                violation.kind,
+                violation.source,
+                violation.target,
+                violation.location,
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report([v.to_dict() for v in violations], output)
+    if sarif:
+        _write_json_report(build_sarif_log(layering_findings(violations)), sarif)
+
+
+@analyze_app.command("panics")
+def analyze_panics(
+    include_unexported: bool = typer.Option(
+        False,
+        "--include-unexported",
+        help="Also report unexported functions that can reach a panic",
+    ),
+    limit: int = typer.Option(20, "--limit", help="Number of functions to display"),
+    store: bool = typer.Option(
+        True, "--store/--no-store", help="Write may_panic to the graph"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all panic paths to a JSON file"
+    ),
+) -> None:
+    """List exported Go functions that can reach a panic without a recover."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = PanicReachabilityAnalyzer(ingestor)
+        paths = analyzer.analyze(include_unexported)
+        if store:
+            analyzer.store_results(paths)
+
+    if not paths:
+        console.print("[bold green]No unrecovered panic paths found.[/bold green]")
+    else:
+        table = Table(title="[bold green]Panic Reachability[/bold green]")
+        table.add_column("Function", style="cyan")
+        table.add_column("Path")
+        table.add_column("Call chain", style="bold yellow")
+        table.add_column("Direct", justify="center")
+        for panic_path in paths[:limit]:
+            table.add_row(
+                panic_path.qualified_name,
+                panic_path.path,
+                " -> ".join(panic_path.chain),
+                "yes" if panic_path.panics_directly else "",
+            )
+        console.print(table)
+        console.print(f"[bold]{len(paths)} functions may panic[/bold]")
+
+    if output:
+        _write_json_report([p.to_dict() for p in paths], output)
+
+
+@analyze_app.command("concurrency")
+def analyze_concurrency(
+    limit: int = typer.Option(20, "--limit", help="Number of variables to display"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all hazards to a JSON file"
+    ),
+) -> None:
+    """List Go package variables written from goroutines without a mutex or atomic."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        hazards = ConcurrencyHazardAnalyzer(ingestor).analyze()
+
+    if not hazards:
+        console.print("[bold green]No shared-state hazards found.[/bold green]")
+    else:
+        table = Table(title="[bold green]Shared-State Hazards[/bold green]")
+        table.add_column("Variable", style="cyan")
+        table.add_column("Path")
+        table.add_column("Goroutine writers", style="bold yellow")
+        table.add_column("Other writers")
+        table.add_column("Spawned from", style="magenta")
+        for hazard in hazards[:limit]:
+            table.add_row(
+                hazard.variable,
+                hazard.path,
+                "\n".join(hazard.goroutine_writers),
+                "\n".join(hazard.other_writers),
+                "\n".join(hazard.spawned_from),
+            )
+        console.print(table)
+        console.print(
+            f"[bold]{len(hazards)} variables to check with the race detector[/bold]"
+        )
+
+    if output:
+        _write_json_report([h.to_dict() for h in hazards], output)
+
+
+@analyze_app.command("test-gaps")
+def analyze_test_gaps(
+    include_endpoints: bool = typer.Option(
+        True,
+        "--endpoints/--no-endpoints",
+        help="Include HTTP endpoints whose handlers are untested",
+    ),
+    limit: int = typer.Option(
+        10, "--limit", help="Maximum number of gaps to display per package"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the full report to a JSON file"
+    ),
+) -> None:
+    """List exported functions and endpoints with no tests and no coverage."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        gaps_by_package = TestGapAnalyzer(ingestor).find_gaps_by_package(
+            include_endpoints
+        )
+
+    if not gaps_by_package:
+        console.print("[bold green]No untested public API found.[/bold green]")
+        return
+
+    for package, gaps in gaps_by_package.items():
+        table = Table(
+            title=f"[bold green]{package or '.'}[/bold green] ({len(gaps)} gaps)"
+        )
+        table.add_column("Name", style="cyan")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Location")
+        table.add_column("Complexity", justify="right", style="bold yellow")
+        for gap in gaps[:limit]:
+            location = f"{gap.path}:{gap.line_number}" if gap.line_number else gap.path
+            table.add_row(gap.qualified_name, gap.label, location, str(gap.complexity))
+        console.print(table)
+
+    total = sum(len(gaps) for gaps in gaps_by_package.values())
+    console.print(
+        f"[bold]{total} untested symbols across {len(gaps_by_package)} packages[/bold]"
+    )
+
+    if output:
+        _write_json_report(
+            {
+                package: [gap.to_dict() for gap in gaps]
+                for package, gaps in gaps_by_package.items()
+            },
+            output,
+        )
+
+
+@analyze_app.command("flaky-tests")
+def analyze_flaky_tests(
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the report to a JSON file"
+    ),
+) -> None:
+    """List tests with intermittent failures and the code they exercise."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        flaky_tests = TestResultAnalyzer(ingestor).find_flaky_tests()
+
+    if not flaky_tests:
+        console.print("[bold green]No flaky tests found.[/bold green]")
+        return
+
+    table = Table(title="[bold green]Flaky Tests[/bold green]")
+    table.add_column("Test", style="cyan")
+    table.add_column("Failures", justify="right")
+    table.add_column("Flakiness", justify="right", style="bold yellow")
+    table.add_column("History")
+    table.add_column("Exercises", style="magenta")
+    for test in flaky_tests:
+        table.add_row(
+            test["qualified_name"],
+            f"{test['fail_count']}/{test['run_count']}",
+            f"{test['flakiness_score']:.2f}",
+            test["status_history"],
+            "\n".join(test["exercises"]) or "-",
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report(flaky_tests, output)
+
+
+@analyze_app.command("crashes")
+def analyze_crashes(
+    min_events: int = typer.Option(
+        1, "--min-events", help="Only list functions whose crashes occurred this often"
+    ),
+    limit: int = typer.Option(50, "--limit", help="Maximum functions to list"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the report to a JSON file"
+    ),
+) -> None:
+    """List functions appearing in Sentry crash reports, by crash events."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        rows = CrashMapper(ingestor).find_crashing_functions(min_events, limit)
+
+    if not rows:
+        console.print("[bold green]No crashing functions found.[/bold green]")
+        return
+
+    table = Table(title="[bold green]Crashing Functions[/bold green]")
+    table.add_column("Function", style="cyan")
+    table.add_column("Crashes", justify="right")
+    table.add_column("Events", justify="right", style="bold yellow")
+    table.add_column("As Top Frame", justify="right")
+    table.add_column("Examples", style="magenta")
+    for row in rows:
+        table.add_row(
+            row["qualified_name"],
+            str(row["crashes"]),
+            str(row["events"]),
+            str(row["top_frame_events"]),
+            ", ".join(row["examples"]),
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report(rows, output)
+
+
+@analyze_app.command("todos")
+def analyze_todos(
+    path: str = typer.Option(
+        "", "--path", help="Only show TODOs under this directory (e.g. 'payments')"
+    ),
+    kind: list[str] | None = typer.Option(
+        None,
+        "--kind",
+        help="Filter by marker: TODO, FIXME, HACK or XXX",
+        autocompletion=choices("TODO", "FIXME", "HACK", "XXX"),
+    ),
+    author: str = typer.Option("", "--author", help="Filter by author name or email"),
+    limit: int = typer.Option(50, "--limit", help="Maximum number of TODOs to list"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the list to a JSON file"
+    ),
+) -> None:
+    """List tracked TODO/FIXME/HACK comments, oldest first."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        todos = TodoAnalyzer(ingestor).find_todos(path, kind, author, limit)
+
+    if not todos:
+        console.print("[bold green]No matching TODOs found.[/bold green]")
+        return
+
+    table = Table(title="[bold green]Oldest TODOs[/bold green]")
+    table.add_column("Kind", style="bold yellow")
+    table.add_column("Text")
+    table.add_column("Location", style="magenta")
+    table.add_column("Owner", style="cyan")
+    table.add_column("Author")
+    table.add_column("Since")
+    for todo in todos:
+        table.add_row(
+            todo["kind"],
+            todo["text"],
+            f"{todo['path']}:{todo['line_number']}",
+            todo["owner"],
+            todo["author"] or "-",
+            (todo["created_at"] or "-")[:10],
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report(todos, output)
+
+
+@analyze_app.command("logs")
+def analyze_logs(
+    text: str = typer.Argument(
+        "", help="Text to search for in log messages (e.g. 'payment failed')"
+    ),
+    level: list[str] | None = typer.Option(
+        None, "--level", help="Filter by level: debug, info, warn, error, fatal, ..."
+    ),
+    library: str = typer.Option(
+        "", "--library", help="Filter by library: slog, zap, logrus, logging, print"
+    ),
+    path: str = typer.Option("", "--path", help="Only search under this directory"),
+    limit: int = typer.Option(50, "--limit", help="Maximum number of calls to list"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the list to a JSON file"
+    ),
+) -> None:
+    """Find where a message is logged, with its level and enclosing function."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        statements = LogAnalyzer(ingestor).find_log_statements(
+            text, level, library, path, limit
+        )
+
+    if not statements:
+        console.print("[bold yellow]No matching log statements found.[/bold yellow]")
+        return
+
+    table = Table(title="[bold green]Log Statements[/bold green]")
+    table.add_column("Level", style="bold yellow")
+    table.add_column("Message")
+    table.add_column("Location", style="magenta")
+    table.add_column("Function", style="cyan")
+    table.add_column("Call")
+    for statement in statements:
+        table.add_row(
+            statement["level"],
+            statement["message"],
+            f"{statement['path']}:{statement['line_number']}",
+            statement["owner"],
+            statement["call"],
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report(statements, output)
+
+
+@analyze_app.command("unchecked-errors")
+def analyze_unchecked_errors(
+    min_likelihood: int = typer.Option(
+        1,
+        "--min-likelihood",
+        help="Only list callees at least this failure-prone (1 other, 2 parsing "
+        "and encoding, 3 I/O, network and database)",
+    ),
+    path: str = typer.Option("", "--path", help="Only list calls under this directory"),
+    limit: int = typer.Option(50, "--limit", help="Maximum number of calls to list"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the list to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write the list to a SARIF file for code scanning"
+    ),
+) -> None:
+    """List Go calls whose returned error is discarded, most failure-prone first."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        findings = UncheckedErrorAnalyzer(ingestor).find_unchecked_errors(
+            min_likelihood, path, limit
+        )
+
+    if not findings:
+        console.print("[bold green]No unchecked errors found.[/bold green]")
+        if sarif:
+            _write_json_report(build_sarif_log([]), sarif)
+        return
+
+    table = Table(title="[bold green]Unchecked Errors[/bold green]")
+    table.add_column("Call", style="cyan")
+    table.add_column("Kind")
+    table.add_column("Category", style="magenta")
+    table.add_column("Likelihood", justify="right", style="bold yellow")
+    table.add_column("Location")
+    table.add_column("Function")
+    for finding in findings:
+        table.add_row(
+            finding["call"],
+            finding["kind"],
+            finding["category"],
+            str(finding["likelihood"]),
+            f"{finding['path']}:{finding['line_number']}",
+            finding["owner"],
+        )
+    console.print(table)
+
+    if output:
+        _write_json_report(findings, output)
+    if sarif:
+        _write_json_report(build_sarif_log(unchecked_error_findings(findings)), sarif)
+
+
+@analyze_app.command("vulnerabilities")
+def analyze_vulnerabilities(
+    severity: str = typer.Option(
+        "low", "--severity", help="Minimum severity: low, medium, high or critical"
+    ),
+    vuln_types: list[str] | None = typer.Option(
+        None,
+        "--type",
+        help="Only list this type, e.g. hardcoded_secret or user_input_to_exec "
+        "(repeatable)",
+    ),
+    path: str = typer.Option(
+        "", "--path", help="Only list findings under this directory"
+    ),
+    limit: int = typer.Option(50, "--limit", help="Maximum number of findings"),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the list to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write the list to a SARIF file for code scanning"
+    ),
+) -> None:
+    """List vulnerabilities, hardcoded secrets and unvalidated taint flows."""
+    if severity not in SEVERITY_RANK:
+        console.print(f"[bold red]Error: unknown severity '{severity}'[/bold red]")
+        raise typer.Exit(1)
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        findings = VulnerabilityAnalyzer(ingestor).find_vulnerabilities(
+            severity, vuln_types, path, limit
+        )
+
+    if not findings:
+        console.print("[bold green]No vulnerabilities found.[/bold green]")
+    else:
+        table = Table(title="[bold green]Vulnerabilities[/bold green]")
+        table.add_column("Severity", style="bold red")
+        table.add_column("Type", style="cyan")
+        table.add_column("CWE", style="magenta")
+        table.add_column("Location")
+        table.add_column("Description")
+        for finding in findings:
+            table.add_row(
+                finding["severity"],
+                finding["type"],
+                finding["cwe_id"] or "",
+                f"{finding['path']}:{finding['line_number']}",
+                finding["description"],
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report(findings, output)
+    if sarif:
+        _write_json_report(build_sarif_log(vulnerability_findings(findings)), sarif)
+
+
+@analyze_app.command("taint")
+def analyze_taint(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Checkout the graph was ingested from"
+    ),
+    sources: list[str] | None = typer.Option(
+        None,
+        "--source",
+        help="Only list paths from this source kind, e.g. user_input or env "
+        "(repeatable)",
+    ),
+    sinks: list[str] | None = typer.Option(
+        None,
+        "--sink",
+        help="Only list paths into this sink kind, e.g. sql or exec (repeatable)",
+    ),
+    store: bool = typer.Option(
+        True,
+        "--store/--no-store",
+        help="Write the paths as Vulnerability nodes and FLOWS_TO edges",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all paths to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write the paths to a SARIF file for code scanning"
+    ),
+) -> None:
+    """Trace untrusted input to SQL, command and other sinks without a sanitizer."""
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    try:
+        rules = TaintRules.from_config(_repo_config(target_repo_path))
+    except (ConfigFileError, ValueError) as e:
+        console.print(f"[bold red]Error: {e}[/bold red]")
+        raise typer.Exit(1) from e
+
+    parsers, _ = load_parsers()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = TaintAnalyzer(ingestor, parsers, rules)
+        paths = analyzer.analyze(target_repo_path)
+        if store:
+            analyzer.store_paths(paths)
+
+    shown = [
+        path
+        for path in paths
+        if (not sources or path.source.kind in sources)
+        and (not sinks or path.sink_kind in sinks)
+    ]
+    if not shown:
+        console.print("[bold green]No unsanitized taint paths found.[/bold green]")
+    else:
+        table = Table(title="[bold green]Taint Paths[/bold green]")
+        table.add_column("Type", style="bold red")
+        table.add_column("Source", style="cyan")
+        table.add_column("Sink", style="magenta")
+        table.add_column("Through")
+        for path in shown:
+            found = path.to_dict()
+            table.add_row(
+                path.vuln_type,
+                f"{path.source.text}\n{found['source_location']}",
+                f"{path.sink_call}\n{found['sink_location']}",
+                "\n".join(path.functions),
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report([path.to_dict() for path in shown], output)
+    if sarif:
+        _write_json_report(build_sarif_log(taint_findings(shown)), sarif)
+
+
+@analyze_app.command("undocumented")
+def analyze_undocumented(
+    limit: int = typer.Option(30, "--limit", help="Number of symbols to display"),
+    min_fan_in: int = typer.Option(
+        0, "--min-fan-in", help="Only report symbols with at least this many callers"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the full report to a JSON file"
+    ),
+) -> None:
+    """List exported symbols without documentation, weighted by fan-in."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        report = DocCoverageAnalyzer(ingestor).analyze(min_fan_in=min_fan_in)
+
+    console.print(
+        f"[bold]Documented {report.documented}/{report.total_exported} exported "
+        f"symbols ({report.coverage_percent:.1f}%)[/bold]"
+    )
+    if report.undocumented:
+        table = Table(title="[bold green]Undocumented Public API[/bold green]")
+        table.add_column("Symbol", style="cyan")
+        table.add_column("Kind", style="magenta")
+        table.add_column("Path")
+        table.add_column("Fan-in", justify="right", style="bold yellow")
+        for symbol in report.undocumented[:limit]:
+            table.add_row(
+                symbol.qualified_name, symbol.label, symbol.path, str(symbol.fan_in)
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report(report.to_dict(), output)
+
+
+@analyze_app.command("smells")
+def analyze_smells(
+    max_methods: int = typer.Option(
+        SmellThresholds.max_methods, "--max-methods", help="Methods per class"
+    ),
+    max_fields: int = typer.Option(
+        SmellThresholds.max_fields, "--max-fields", help="Fields per class"
+    ),
+    max_lines: int = typer.Option(
+        SmellThresholds.max_function_lines, "--max-lines", help="Lines per function"
+    ),
+    max_parameters: int = typer.Option(
+        SmellThresholds.max_parameters, "--max-params", help="Parameters per function"
+    ),
+    max_nesting: int = typer.Option(
+        SmellThresholds.max_nesting_depth,
+        "--max-nesting",
+        help="Nested control-flow blocks per function",
+    ),
+    limit: int = typer.Option(30, "--limit", help="Number of smells to display"),
+    store_smells: bool = typer.Option(
+        True,
+        "--store-smells/--no-store-smells",
+        help="Write the smells property back to the graph",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all smells to a JSON file"
+    ),
+    sarif: str | None = typer.Option(
+        None, "--sarif", help="Write all smells to a SARIF file for code scanning"
+    ),
+) -> None:
+    """Report god classes, long functions, long parameter lists and deep nesting."""
+    thresholds = SmellThresholds(
+        max_methods=max_methods,
+        max_fields=max_fields,
+        max_function_lines=max_lines,
+        max_parameters=max_parameters,
+        max_nesting_depth=max_nesting,
+    )
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = SmellAnalyzer(ingestor)
+        smells = analyzer.detect(thresholds)
+        if store_smells:
+            analyzer.store_smells(smells)
+
+    if not smells:
+        console.print("[bold green]No code smells above the thresholds.[/bold green]")
+    else:
+        table = Table(title=f"[bold green]Code Smells ({len(smells)})[/bold green]")
+        table.add_column("Smell", style="bold yellow")
+        table.add_column("Symbol", style="cyan")
+        table.add_column("Path", style="magenta")
+        table.add_column("Metric")
+        table.add_column("Value", justify="right")
+        table.add_column("Limit", justify="right")
+        for smell in smells[:limit]:
+            table.add_row(
+                smell.smell,
+                smell.qualified_name,
+                f"{smell.path}:{smell.start_line}" if smell.start_line else smell.path,
+                smell.metric,
+                str(smell.value),
+                str(smell.threshold),
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report([s.to_dict() for s in smells], output)
+    if sarif:
+        _write_json_report(build_sarif_log(smell_findings(smells)), sarif)
+
+
+@analyze_app.command("clones")
+def analyze_clones(
+    threshold: float = typer.Option(
+        0.9,
+        "--threshold",
+        min=0.0,
+        max=1.0,
+        help="Share of a body two functions must have in common to be clones",
+    ),
+    min_lines: int = typer.Option(
+        5, "--min-lines", help="Ignore functions shorter than this many lines"
+    ),
+    limit: int = typer.Option(20, "--limit", help="Number of groups to display"),
+    store: bool = typer.Option(
+        True, "--store/--no-store", help="Write CLONE_OF edges to the graph"
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write all clone groups to a JSON file"
+    ),
+) -> None:
+    """Find functions whose bodies were copied, renamed variables and all."""
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        analyzer = CloneAnalyzer(ingestor)
+        groups = analyzer.analyze(threshold, min_lines)
+        if store:
+            analyzer.store_pairs(groups)
+
+    if not groups:
+        console.print(
+            "[bold green]No clones found.[/bold green] [dim]Functions ingested "
+            "before fingerprints existed need a re-index.[/dim]"
+        )
+    else:
+        table = Table(title=f"[bold green]Clone Groups ({len(groups)})[/bold green]")
+        table.add_column("#", justify="right")
+        table.add_column("Functions", style="cyan")
+        table.add_column("Locations", style="magenta")
+        table.add_column("Similarity", justify="right")
+        table.add_column("Duplicated lines", justify="right", style="yellow")
+        for number, group in enumerate(groups[:limit], 1):
+            table.add_row(
+                str(number),
+                "\n".join(m.qualified_name for m in group.members),
+                "\n".join(m.location for m in group.members),
+                f"{group.min_similarity:.0%}",
+                str(group.duplicated_lines),
+            )
+        console.print(table)
+
+    if output:
+        _write_json_report([g.to_dict() for g in groups], output)
+
+
+@analyze_app.command("unused-deps")
+def analyze_unused_deps(
+    repo_path: str | None = typer.Option(
+        None, "--repo-path", help="Path to the repository to check"
+    ),
+    include_dev: bool = typer.Option(
+        False,
+        "--include-dev",
+        help="Also report unused devDependencies and optional/dev groups",
+    ),
+    output: str | None = typer.Option(
+        None, "-o", "--output", help="Write the report to a JSON file"
+    ),
+) -> None:
+    """
+    List dependencies declared in manifests but never imported by the sources,
+    as ingested into the graph.
+    """
+    target_repo_path = Path(repo_path or settings.TARGET_REPO_PATH).resolve()
+    with MemgraphIngestor(
+        host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT
+    ) as ingestor:
+        reports = UnusedDependencyAnalyzer(ingestor, target_repo_path).analyze(
+            include_dev
+        )
+    if not reports:
+        console.print("[bold yellow]No dependency manifests found.[/bold yellow]")
+        return
+
+    table = Table(title="[bold green]Unused Dependencies[/bold green]")
+    table.add_column("Manifest", style="magenta")
+    table.add_column("Dependency", style="cyan")
+    table.add_column("Version")
+    table.add_column("Dev", justify="center")
+    for report in reports:
+        for dep in report.unused:
+            table.add_row(
+                report.manifest, dep.name, dep.version, "yes" if dep.dev else ""
+            )
+    unused_count = sum(len(report.unused) for report in reports)
+    if unused_count:
+        console.print(table)
+    console.print(
+        f"[bold]{unused_count} unused of "
+        f"{sum(report.declared for report in reports)} declared dependencies "
+        f"across {len(reports)} manifests[/bold]"
+    )
+
+    if output:
+        _write_json_report([report.to_dict() for report in reports], output)
+
+
+async def run_optimization_loop(
+    rag_agent: Any,
+    message_history: list[Any],
+    project_root: Path,
+    language: str,
+    reference_document: str | None = None,
+) -> None:
+    """Runs the optimization loop with the RAG agent."""
+    console.print(
+        f"[bold green]Starting {language} optimization session...[/bold green]"
+    )
+    document_info = (
+        f" using the reference document: {reference_document}"
+        if reference_document
+        else ""
+    )
+    console.print(
+        Panel(
+            f"[bold yellow]The agent will analyze your {language} codebase{document_info} and propose specific optimizations."
+            f"You'll be asked to approve each suggestion before implementation."
+            f"Type 'exit' or 'quit' to end the session.[/bold yellow]",
+            border_style="yellow",
+        )
+    )
+
+    # Initial optimization analysis
+
+    instructions = [
+        "Use your code retrieval and graph querying tools to understand the codebase structure",
+        "Read relevant source files to identify optimization opportunities",
+    ]
+    if reference_document:
+        instructions.append(
+            f"Use the analyze_document tool to reference best practices from {reference_document}"
         )
 
     instructions.extend(
@@ -646,15 +5381,7 @@ async def main_optimize_async(
     table.add_row("Target Language", language)
     table.add_row("Repository Path", str(project_root))
 
-    orchestrator_model = settings.active_orchestrator_model
-    orchestrator_provider = detect_provider_from_model(orchestrator_model)
-    table.add_row(
-        "Orchestrator Model", f"{orchestrator_model} ({orchestrator_provider})"
-    )
-
-    cypher_model = settings.active_cypher_model
-    cypher_provider = detect_provider_from_model(cypher_model)
-    table.add_row("Cypher Model", f"{cypher_model} ({cypher_provider})")
+    _add_model_rows(table)
     console.print(table)
 
     with MemgraphIngestor(
@@ -668,11 +5395,12 @@ async def main_optimize_async(
         )
 
 
-@app.command()
+@app.command(rich_help_panel=INSIGHT_PANEL)
 def optimize(
     language: str = typer.Argument(
         ...,
         help="Programming language to optimize for (e.g., python, java, javascript, cpp)",
+        autocompletion=complete_language,
     ),
     repo_path: str | None = typer.Option(
         None, "--repo-path", help="Path to the repository to optimize"
@@ -0,0 +1,5 @@
+"""Graph queries behind the code graph tools of the MCP server in mcp_server."""
+
+from .tools import GraphTools
+
+__all__ = ["GraphTools"]
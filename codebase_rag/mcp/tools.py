@@ -0,0 +1,137 @@
+"""The graph queries behind the MCP tools.
+
+Each returns JSON-ready data: symbols matched by name, callers followed up
+the CALLS edges, tests reaching a function, and rows of read-only Cypher.
+"""
+
+from typing import Any
+
+from ..analysis.impact import CALLERS_QUERY, TESTS_OF_QUERY
+from ..server.query import MAX_ROWS, is_read_only, jsonable
+from ..symbol_search import SymbolIndex
+
+# Levels of callers followed when none is asked for, and at most
+DEFAULT_CALLER_DEPTH = 1
+MAX_CALLER_DEPTH = 10
+# Levels of callers whose tests are found for a function by default
+DEFAULT_TEST_DEPTH = 3
+
+SYMBOL_DETAILS_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (n {qualified_name: qn})
+WHERE n:Function OR n:Method OR n:Class
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(n)
+RETURN n.qualified_name AS qualified_name, n.start_line AS start_line,
+       n.end_line AS end_line, n.docstring AS docstring, m.path AS path
+"""
+
+
+class GraphTools:
+    """Answers to tool calls from the graph an ingestor is connected to."""
+
+    def __init__(self, ingestor: Any):
+        self.ingestor = ingestor
+
+    def find_symbol(self, name: str, limit: int = 10) -> dict[str, Any]:
+        """Functions, methods and classes best matching a name, fuzzily."""
+        matches = SymbolIndex.from_graph(self.ingestor).search(name, limit=limit)
+        details = self._details([m.qualified_name for m in matches])
+        return {
+            "query": name,
+            "matches": [
+                {
+                    "qualified_name": m.qualified_name,
+                    "label": m.label,
+                    "score": round(m.score, 3),
+                    **details.get(m.qualified_name, {}),
+                }
+                for m in matches
+            ],
+        }
+
+    def get_callers(
+        self, qualified_name: str, depth: int = DEFAULT_CALLER_DEPTH
+    ) -> dict[str, Any]:
+        """Functions and methods calling one, up to depth levels up."""
+        distance = self._callers(qualified_name, depth)
+        callers = sorted(
+            (qn for qn in distance if qn != qualified_name),
+            key=lambda qn: (distance[qn], qn),
+        )
+        details = self._details(callers)
+        return {
+            "qualified_name": qualified_name,
+            "callers": [
+                {"qualified_name": qn, "depth": distance[qn], **details.get(qn, {})}
+                for qn in callers
+            ],
+        }
+
+    def get_tests_for(
+        self, qualified_name: str, depth: int = DEFAULT_TEST_DEPTH
+    ) -> dict[str, Any]:
+        """
+        Tests linked to a function or method, or to its callers up to depth
+        levels up, closest first.
+        """
+        distance = self._callers(qualified_name, depth)
+        rows = self.ingestor.fetch_all(
+            TESTS_OF_QUERY, {"qualified_names": list(distance)}
+        )
+        rows.sort(key=lambda r: (distance[r["target"]], r["qualified_name"]))
+        tests: dict[str, dict[str, Any]] = {}
+        for row in rows:
+            tests.setdefault(
+                row["qualified_name"],
+                {
+                    "qualified_name": row["qualified_name"],
+                    "label": row["label"],
+                    "path": row["path"],
+                    "via": row["target"],
+                    "depth": distance[row["target"]],
+                },
+            )
+        return {"qualified_name": qualified_name, "tests": list(tests.values())}
+
+    def run_cypher(
+        self, cypher: str, params: dict[str, Any] | None = None
+    ) -> dict[str, Any]:
+        """Rows of a read-only Cypher query; writing queries are refused."""
+        if not is_read_only(cypher):
+            raise ValueError("Only read-only Cypher queries can be run")
+        rows = self.ingestor.fetch_all(cypher, params or {})
+        return {
+            "rows": [
+                {key: jsonable(value) for key, value in row.items()}
+                for row in rows[:MAX_ROWS]
+            ],
+            "truncated": len(rows) > MAX_ROWS,
+        }
+
+    def _callers(self, qualified_name: str, depth: int) -> dict[str, int]:
+        """Distance up the CALLS edges to each caller, the function at 0."""
+        distance = {qualified_name: 0}
+        frontier = [qualified_name]
+        for level in range(1, max(0, min(depth, MAX_CALLER_DEPTH)) + 1):
+            if not frontier:
+                break
+            rows = self.ingestor.fetch_all(CALLERS_QUERY, {"qualified_names": frontier})
+            frontier = []
+            for row in rows:
+                if row["caller"] not in distance:
+                    distance[row["caller"]] = level
+                    frontier.append(row["caller"])
+        return distance
+
+    def _details(self, qualified_names: list[str]) -> dict[str, dict[str, Any]]:
+        if not qualified_names:
+            return {}
+        rows = self.ingestor.fetch_all(
+            SYMBOL_DETAILS_QUERY, {"qualified_names": qualified_names}
+        )
+        return {
+            row["qualified_name"]: {
+                key: value for key, value in row.items() if key != "qualified_name"
+            }
+            for row in rows
+        }
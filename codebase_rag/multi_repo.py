@@ -0,0 +1,228 @@
+"""Several repositories ingested into one graph as a workspace.
+
+The repositories of a workspace are the [[repositories]] entries of a config
+file. They are ingested one after another into the same graph, those
+declaring Go modules before the ones requiring them, so that a call into a
+package of another repository resolves to the function there and imports of
+its module lead to the module's own node rather than to an external one.
+Every node qualified under a repository's name is tagged with it as `repo`,
+and a Project DEPENDS_ON each project whose Go modules it requires.
+"""
+
+import os
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .analysis.go_modules import (
+    GoModFile,
+    WorkspaceModules,
+    module_for_import,
+    parse_go_mod_file,
+)
+from .graph_updater import GraphUpdater
+from .workspace import SCAN_SKIP_DIRS, read_ragignore
+
+TAG_REPOSITORY_QUERY = """
+MATCH (n)
+WHERE n.qualified_name STARTS WITH $prefix OR (n:Project AND n.name = $repo)
+SET n.repo = $repo
+"""
+
+LINK_REPOSITORIES_QUERY = """
+MATCH (a:Project {name: $source}), (b:Project {name: $target})
+MERGE (a)-[r:DEPENDS_ON]->(b)
+SET r.modules = $modules
+"""
+
+CROSS_REPOSITORY_CALLERS_QUERY = """
+MATCH (caller)-[:CALLS]->(callee)
+WHERE callee.repo = $repo AND caller.repo IS NOT NULL AND caller.repo <> $repo
+RETURN callee.qualified_name AS callee, caller.repo AS caller_repo,
+       caller.qualified_name AS caller, labels(caller)[0] AS caller_label
+ORDER BY callee, caller_repo, caller
+LIMIT $limit
+"""
+
+
+@dataclass
+class WorkspaceRepository:
+    """A repository of a workspace and the Go modules it declares and requires."""
+
+    path: Path
+    modules: list[str] = field(default_factory=list)
+    requires: list[str] = field(default_factory=list)
+
+    @property
+    def name(self) -> str:
+        # The project name ingestion qualifies the repository's nodes under
+        return self.path.name
+
+
+def load_repositories(
+    document: dict[str, Any], base: Path
+) -> list[WorkspaceRepository]:
+    """
+    The repositories in a config file's [[repositories]] entries, with paths
+    relative to the directory of the file.
+    """
+    entries = document.get("repositories") or []
+    if not isinstance(entries, list):
+        raise ValueError("repositories must be a list of [[repositories]] entries")
+    repositories: list[WorkspaceRepository] = []
+    for number, entry in enumerate(entries, start=1):
+        if not isinstance(entry, dict) or not isinstance(entry.get("path"), str):
+            raise ValueError(f"Repository {number} needs a path")
+        path = (base / Path(entry["path"]).expanduser()).resolve()
+        if not path.is_dir():
+            raise ValueError(f"Repository {number}: {path} is not a directory")
+        repositories.append(_with_go_modules(path))
+
+    names = [repository.name for repository in repositories]
+    for name in names:
+        if names.count(name) > 1:
+            raise ValueError(
+                f"Repository name '{name}' is used more than once; qualified "
+                "names of its nodes would collide"
+            )
+    return repositories
+
+
+def repository_entry(path: Path, base: Path) -> str:
+    """The [[repositories]] entry `workspace add` appends to a TOML config file."""
+    try:
+        shown = Path(os.path.relpath(path.resolve(), base.resolve())).as_posix()
+    except ValueError:  # Another drive, on Windows
+        shown = path.resolve().as_posix()
+    return f'\n[[repositories]]\npath = "{shown}"\n'
+
+
+def ingestion_order(
+    repositories: list[WorkspaceRepository],
+) -> list[WorkspaceRepository]:
+    """
+    The repositories in the order to ingest them: each after those declaring
+    the modules it requires, otherwise as listed. Repositories requiring
+    each other keep their listed order.
+    """
+    dependencies = repository_dependencies(repositories)
+    ordered: list[WorkspaceRepository] = []
+    remaining = list(repositories)
+    while remaining:
+        placed = {repository.name for repository in ordered}
+        ready = next(
+            (r for r in remaining if set(dependencies.get(r.name, {})) <= placed),
+            remaining[0],
+        )
+        ordered.append(ready)
+        remaining.remove(ready)
+    return ordered
+
+
+def repository_dependencies(
+    repositories: list[WorkspaceRepository],
+) -> dict[str, dict[str, list[str]]]:
+    """{repository: {repository it depends on: [modules it requires from it]}}."""
+    declared = {
+        module: repository.name
+        for repository in repositories
+        for module in repository.modules
+    }
+    dependencies: dict[str, dict[str, list[str]]] = {}
+    for repository in repositories:
+        for required in repository.requires:
+            module = module_for_import(required, declared)
+            if module is None or declared[module] == repository.name:
+                continue
+            dependencies.setdefault(repository.name, {}).setdefault(
+                declared[module], []
+            ).append(required)
+    return dependencies
+
+
+def ingest_workspace(
+    ingestor: Any,
+    repositories: list[WorkspaceRepository],
+    parsers: dict[str, Any],
+    queries: dict[str, Any],
+    **options: Any,
+) -> list[GraphUpdater]:
+    """
+    Ingest the repositories of a workspace into one graph, tag their nodes
+    and link their projects; options are passed on to each GraphUpdater.
+    Returns the updaters in the order they ran, for their reports.
+    """
+    workspace = WorkspaceModules(
+        repositories={
+            module: repository.name
+            for repository in repositories
+            for module in repository.modules
+        }
+    )
+    updaters = []
+    for repository in ingestion_order(repositories):
+        logger.info(f"--- Ingesting {repository.name} from {repository.path} ---")
+        updater = GraphUpdater(
+            ingestor,
+            repository.path,
+            parsers,
+            queries,
+            workspace=workspace,
+            **options,
+        )
+        updater.run()
+        ingestor.execute_write(
+            TAG_REPOSITORY_QUERY,
+            {"repo": repository.name, "prefix": f"{repository.name}."},
+        )
+        workspace.add_functions(updater.go_exports())
+        updaters.append(updater)
+
+    for source, targets in repository_dependencies(repositories).items():
+        for target, modules in targets.items():
+            ingestor.execute_write(
+                LINK_REPOSITORIES_QUERY,
+                {"source": source, "target": target, "modules": sorted(modules)},
+            )
+    ingestor.mark_graph_changed()
+    return updaters
+
+
+def cross_repository_callers(
+    ingestor: Any, repo: str, limit: int = 100
+) -> list[dict[str, Any]]:
+    """Calls into a repository's functions and methods from the other ones."""
+    return list(
+        ingestor.fetch_all(
+            CROSS_REPOSITORY_CALLERS_QUERY, {"repo": repo, "limit": limit}
+        )
+    )
+
+
+def _with_go_modules(path: Path) -> WorkspaceRepository:
+    repository = WorkspaceRepository(path)
+    for go_mod in _go_mod_files(path):
+        repository.modules.append(go_mod.module)
+        repository.requires.extend(r.path for r in go_mod.requires)
+    return repository
+
+
+def _go_mod_files(repo_path: Path) -> list[GoModFile]:
+    skipped = SCAN_SKIP_DIRS | read_ragignore(repo_path)
+    found = []
+    for root, dirs, files in os.walk(repo_path):
+        dirs[:] = sorted(d for d in dirs if d not in skipped)
+        if "go.mod" not in files:
+            continue
+        try:
+            go_mod = parse_go_mod_file(
+                (Path(root) / "go.mod").read_text(encoding="utf-8")
+            )
+        except OSError as e:
+            logger.warning(f"Could not read {Path(root) / 'go.mod'}: {e}")
+            continue
+        if go_mod.module:
+            found.append(go_mod)
+    return found
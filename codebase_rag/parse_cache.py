@@ -0,0 +1,120 @@
+"""What parsing a file added to the graph, kept to skip unchanged files.
+
+For most languages a file's nodes and relationships, the functions it
+registers for call resolution and the names it calls depend on its contents
+alone. They are stored per file under PARSE_CACHE_DIR, keyed by a hash of the
+contents, so the next ingestion replays them instead of parsing the file
+again, with or without Git to say what changed. Calls are kept as names and
+resolved again on replay, since what a name refers to depends on the files
+around it. Go files are parsed again on replay for what resolves their calls,
+method sets and imports among it, with what that writes dropped. A file's Git
+history and the files its Go directives embed or generate are not cached, as
+they change without the file changing.
+
+C and test files are always parsed: what they add depends on the other files
+of their package or on the code under test.
+"""
+
+import hashlib
+import os
+import pickle
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .parsers.endpoint_detector import HttpEndpoint
+from .services.graph_sinks import GraphSink, NodeRef
+
+# Bump when what is extracted from a file changes, to drop older entries
+CACHE_VERSION = 5
+
+UNCACHED_LANGUAGES = frozenset({"c"})
+
+
+@dataclass
+class CachedFile:
+    """The extraction of one file, valid for the contents its key was made from."""
+
+    key: str
+    nodes: list[tuple[str, dict[str, Any]]] = field(default_factory=list)
+    relationships: list[tuple[NodeRef, str, NodeRef, dict[str, Any] | None]] = (
+        field(default_factory=list)
+    )
+    # Line spans of its functions and methods: (start, end, label, qn)
+    spans: list[tuple[int, int, str, str]] = field(default_factory=list)
+    endpoints: list[tuple[str, str, HttpEndpoint]] = field(default_factory=list)
+    dependencies: set[str] = field(default_factory=set)
+    # Unresolved calls: (caller label, caller qn, called name)
+    calls: list[tuple[str, str, str]] = field(default_factory=list)
+
+
+class ExtractionRecorder(GraphSink):
+    """Collects what the ingestor is given while a file is parsed."""
+
+    name = "parse-cache"
+
+    def __init__(self, entry: CachedFile):
+        self.entry = entry
+
+    def on_node(self, label: str, properties: dict[str, Any]) -> None:
+        self.entry.nodes.append((label, properties))
+
+    def on_relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        self.entry.relationships.append((from_node, rel_type, to_node, properties))
+
+
+class ParseCache:
+    """Cached extractions of one repository's files, one entry per path."""
+
+    def __init__(self, directory: Path, repo_path: Path):
+        repo_id = hashlib.sha256(str(repo_path.resolve()).encode()).hexdigest()
+        self.directory = directory / repo_id[:16]
+        self.hits = 0
+
+    @staticmethod
+    def key(source: bytes, *context: str) -> str:
+        """A key for a file's contents and whatever else its extraction used."""
+        digest = hashlib.sha256("\0".join([str(CACHE_VERSION), *context]).encode())
+        digest.update(b"\0")
+        digest.update(source)
+        return digest.hexdigest()
+
+    def load(self, relative_path: str, key: str) -> CachedFile | None:
+        """The entry of a path if it was made from the same contents."""
+        try:
+            with self._entry_path(relative_path).open("rb") as f:
+                entry = pickle.load(f)
+        except FileNotFoundError:
+            return None
+        except Exception as e:
+            logger.warning(f"Ignoring unreadable cache entry of {relative_path}: {e}")
+            return None
+        if not isinstance(entry, CachedFile) or entry.key != key:
+            return None
+        self.hits += 1
+        return entry
+
+    def store(self, relative_path: str, entry: CachedFile) -> None:
+        path = self._entry_path(relative_path)
+        temporary = path.with_suffix(f".{os.getpid()}.tmp")
+        try:
+            path.parent.mkdir(parents=True, exist_ok=True)
+            with temporary.open("wb") as f:
+                pickle.dump(entry, f, protocol=pickle.HIGHEST_PROTOCOL)
+            # Replaced whole, so a concurrent ingestion never reads half an entry
+            temporary.replace(path)
+        except Exception as e:
+            logger.warning(f"Could not cache the extraction of {relative_path}: {e}")
+            temporary.unlink(missing_ok=True)
+
+    def _entry_path(self, relative_path: str) -> Path:
+        name = hashlib.sha256(relative_path.encode()).hexdigest()[:32]
+        return self.directory / f"{name}.pickle"
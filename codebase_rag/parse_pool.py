@@ -0,0 +1,78 @@
+"""Files parsed on a pool of threads, handed back in the order they were walked.
+
+Reading a file and parsing it with tree-sitter is most of what ingesting it
+costs, and depends on no other file; writing its nodes updates registries
+every file shares. So only the parse is spread over worker threads, each
+with parsers of its own since a tree-sitter parser is not thread-safe, and
+tree-sitter releases the GIL while it parses. The ingestion loop takes the
+parsed files in walk order, so a package's files are still written together
+and one after another, as in a sequential run, and the graph is the same.
+"""
+
+import os
+import threading
+from collections import deque
+from collections.abc import Iterable, Iterator
+from concurrent.futures import Future, ThreadPoolExecutor
+from pathlib import Path
+from typing import Any, NamedTuple
+
+from tree_sitter import Parser, Tree
+
+# Files parsed ahead of the one being written, per worker; bounds the trees
+# held waiting while a slow file is written
+PARSE_AHEAD_PER_WORKER = 8
+
+
+class ParsedSource(NamedTuple):
+    source: bytes
+    tree: Tree
+
+
+def default_workers() -> int:
+    """80% of the CPU cores, at least one."""
+    return max(1, int((os.cpu_count() or 1) * 0.8))
+
+
+class ParsePool:
+    """Worker threads reading and parsing files for the ingestion loop."""
+
+    def __init__(self, parsers: dict[str, Parser], workers: int | None = None):
+        self.languages = {name: parser.language for name, parser in parsers.items()}
+        self.workers = max(1, workers) if workers else default_workers()
+        self._local = threading.local()
+        self._executor = ThreadPoolExecutor(self.workers, thread_name_prefix="parse")
+
+    def __enter__(self) -> "ParsePool":
+        return self
+
+    def __exit__(self, *exc_info: Any) -> None:
+        # Nothing left to write the rest for if ingestion stopped early
+        self._executor.shutdown(cancel_futures=True)
+
+    def parse(self, path: Path, language: str) -> ParsedSource:
+        """Read and parse a file, with this thread's parser for its language."""
+        parsers: dict[str, Parser] = self._local.__dict__.setdefault("parsers", {})
+        if language not in parsers:
+            parsers[language] = Parser(self.languages[language])
+        source = path.read_bytes()
+        return ParsedSource(source, parsers[language].parse(source))
+
+    def parse_in_order(
+        self, files: Iterable[tuple[Path, str | None]]
+    ) -> Iterator[Future[ParsedSource] | None]:
+        """A future per file, in the order given; None for files not parsed.
+
+        Parsing runs ahead of the caller by a few files per worker; errors
+        reading or parsing a file are raised by its future's result().
+        """
+        ahead = self.workers * PARSE_AHEAD_PER_WORKER
+        pending: deque[Future[ParsedSource] | None] = deque()
+        for path, language in files:
+            pending.append(
+                self._executor.submit(self.parse, path, language) if language else None
+            )
+            if len(pending) > ahead:
+                yield pending.popleft()
+        while pending:
+            yield pending.popleft()
@@ -4,7 +4,9 @@ from typing import Any
 from loguru import logger
 from tree_sitter import Language, Parser
 
-from .language_config import LANGUAGE_CONFIGS
+from .config import settings
+from .language_config import DISABLED_LANGUAGES, LANGUAGE_CONFIGS, configure_languages
+from .language_plugins import REGISTERED_PLUGINS, load_language_plugins
 
 # Define a type for the language library loaders
 LanguageLoader = Callable[[], object] | None
@@ -93,7 +95,19 @@ def load_parsers() -> tuple[dict[str, Parser], dict[str, Any]]:
     queries: dict[str, Any] = {}
     available_languages = []
 
+    load_language_plugins(settings.LANGUAGE_PLUGIN_DIRS)
+    for name, plugin in REGISTERED_PLUGINS.items():
+        LANGUAGE_LIBRARIES.setdefault(name, plugin.language)
+    # After plugins, so overrides can name plugin languages
+    for problem in configure_languages(
+        settings.DISABLED_LANGUAGES, settings.LANGUAGE_EXTENSIONS
+    ):
+        logger.warning(problem)
+
     for lang_name, lang_config in LANGUAGE_CONFIGS.items():
+        if lang_name in DISABLED_LANGUAGES:
+            logger.debug(f"Skipping {lang_name}, disabled in DISABLED_LANGUAGES.")
+            continue
         lang_lib = LANGUAGE_LIBRARIES.get(lang_name)
         if lang_lib:
             try:
@@ -147,6 +161,10 @@ def load_parsers() -> tuple[dict[str, Parser], dict[str, Any]]:
                         (assignment_expression) @assignment
                     """)
 
+                plugin = REGISTERED_PLUGINS.get(lang_name)
+                for query_name, source in (plugin.queries if plugin else {}).items():
+                    lang_queries[query_name] = language.query(source)
+
                 queries[lang_name] = lang_queries
 
                 available_languages.append(lang_name)
@@ -0,0 +1,147 @@
+"""Parsing of Backstage software catalog descriptor files (catalog-info.yaml)."""
+
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+import yaml
+from loguru import logger
+
+CATALOG_FILE_NAMES = ("catalog-info.yaml", "catalog-info.yml")
+
+# Entity kinds that become graph nodes, mapped to their labels; Group and User
+# entities only matter as owners and map onto the CODEOWNERS Team/User nodes
+CATALOG_LABELS = {"component": "Component", "system": "System", "api": "API"}
+
+
+@dataclass
+class EntityRef:
+    """A Backstage entity reference, e.g. "component:default/payments"."""
+
+    kind: str
+    namespace: str
+    name: str
+
+    @property
+    def ref(self) -> str:
+        return f"{self.kind}:{self.namespace}/{self.name}"
+
+
+@dataclass
+class CatalogEntity:
+    """A catalog entity with the relations the graph cares about."""
+
+    ref: EntityRef
+    path: str  # Descriptor file, relative to the repository root
+    title: str = ""
+    description: str = ""
+    type: str = ""  # spec.type, e.g. "service", "website" or "openapi"
+    lifecycle: str = ""
+    owner: EntityRef | None = None
+    system: EntityRef | None = None
+    provides_apis: list[EntityRef] = field(default_factory=list)
+    consumes_apis: list[EntityRef] = field(default_factory=list)
+    depends_on: list[EntityRef] = field(default_factory=list)
+    tags: list[str] = field(default_factory=list)
+    definition_path: str = ""  # API spec file referenced with $text/$yaml/$json
+
+    @property
+    def label(self) -> str:
+        return CATALOG_LABELS[self.ref.kind]
+
+    @property
+    def directory(self) -> str:
+        """Directory holding the descriptor, taken as the entity's code root."""
+        return str(Path(self.path).parent)
+
+
+def parse_entity_ref(
+    value: str, default_kind: str, default_namespace: str = "default"
+) -> EntityRef:
+    """Parse "[kind:][namespace/]name" with Backstage's defaulting rules."""
+    kind, _, rest = value.rpartition(":") if ":" in value else ("", "", value)
+    namespace, _, name = rest.rpartition("/") if "/" in rest else ("", "", rest)
+    return EntityRef(
+        (kind or default_kind).lower(),
+        (namespace or default_namespace).lower(),
+        name.lower(),
+    )
+
+
+class BackstageCatalogParser:
+    """Reads Component, System and API entities from catalog descriptor files."""
+
+    def parse(self, content: str, path: str) -> list[CatalogEntity]:
+        """Entities in one multi-document descriptor; path is repo-relative."""
+        try:
+            documents = list(yaml.safe_load_all(content))
+        except yaml.YAMLError as e:
+            logger.warning(f"Invalid catalog descriptor {path}: {e}")
+            return []
+        entities = []
+        for document in documents:
+            if not isinstance(document, dict):
+                continue
+            entity = self._entity(document, path)
+            if entity:
+                entities.append(entity)
+        return entities
+
+    def parse_file(self, file_path: Path, repo_path: Path) -> list[CatalogEntity]:
+        content = file_path.read_text(encoding="utf-8", errors="replace")
+        return self.parse(content, str(file_path.relative_to(repo_path)))
+
+    def _entity(self, document: dict[str, Any], path: str) -> CatalogEntity | None:
+        kind = str(document.get("kind") or "").lower()
+        metadata = document.get("metadata") or {}
+        if kind not in CATALOG_LABELS or not metadata.get("name"):
+            return None
+        namespace = str(metadata.get("namespace") or "default")
+        spec = document.get("spec") or {}
+
+        def ref(value: Any, default_kind: str) -> EntityRef | None:
+            if not value:
+                return None
+            return parse_entity_ref(str(value), default_kind, namespace)
+
+        def refs(values: Any, default_kind: str) -> list[EntityRef]:
+            return [
+                parse_entity_ref(str(value), default_kind, namespace)
+                for value in values or []
+            ]
+
+        return CatalogEntity(
+            ref=parse_entity_ref(str(metadata["name"]), kind, namespace),
+            path=path,
+            title=str(metadata.get("title") or ""),
+            description=str(metadata.get("description") or ""),
+            type=str(spec.get("type") or ""),
+            lifecycle=str(spec.get("lifecycle") or ""),
+            owner=ref(spec.get("owner"), "group"),
+            system=ref(spec.get("system"), "system"),
+            provides_apis=refs(spec.get("providesApis"), "api"),
+            consumes_apis=refs(spec.get("consumesApis"), "api"),
+            depends_on=refs(spec.get("dependsOn"), "component"),
+            tags=[str(tag) for tag in metadata.get("tags") or []],
+            definition_path=_definition_path(spec.get("definition"), path),
+        )
+
+
+def _definition_path(definition: Any, descriptor_path: str) -> str:
+    """Repo-relative path of an API spec given as {"$text": "./openapi.yaml"}."""
+    if not isinstance(definition, dict):
+        return ""
+    for key in ("$text", "$yaml", "$json", "$openapi"):
+        target = definition.get(key)
+        if isinstance(target, str) and "://" not in target:
+            resolved = Path(descriptor_path).parent / target
+            # Normalise "./" and "../" without touching the filesystem
+            parts: list[str] = []
+            for part in resolved.parts:
+                if part == "..":
+                    if parts:
+                        parts.pop()
+                elif part != ".":
+                    parts.append(part)
+            return "/".join(parts)
+    return ""
@@ -0,0 +1,317 @@
+"""Parsing of GitHub and GitLab CODEOWNERS files and of per-directory OWNERS files."""
+
+import os
+import re
+from dataclasses import dataclass, field
+from pathlib import Path, PurePosixPath
+
+import yaml
+from loguru import logger
+
+# Locations searched by GitHub and GitLab, in their order of precedence
+CODEOWNERS_LOCATIONS = (
+    ".github/CODEOWNERS",
+    ".gitlab/CODEOWNERS",
+    "CODEOWNERS",
+    "docs/CODEOWNERS",
+)
+
+# GitLab section headers: "[Backend]", "^[Docs][2] @docs-team"
+SECTION_PATTERN = re.compile(r"^\^?\[([^\]]+)\](?:\[\d+\])?\s*(.*)$")
+
+# Chromium and Kubernetes per-directory owners, and Kubernetes team aliases
+OWNERS_FILE_NAME = "OWNERS"
+OWNERS_ALIASES_FILE_NAME = "OWNERS_ALIASES"
+
+# Top-level keys of a Kubernetes (Prow) OWNERS file; others are Chromium's format
+_YAML_OWNERS_KEY = re.compile(
+    r"^(approvers|reviewers|options|filters|labels)\s*:", re.MULTILINE
+)
+
+# Placeholder file name used to ask whether a rule covers a directory's contents
+_DIRECTORY_PROBE = "\x00probe"
+
+
+@dataclass
+class CodeOwner:
+    """An owner reference: a user, a team or an email address."""
+
+    name: str  # As written, e.g. "@alice", "@org/team" or "alice@example.com"
+    kind: str  # "User" or "Team"
+    email: str = ""
+
+
+@dataclass
+class CodeOwnersRule:
+    """A path pattern and the owners responsible for matching files."""
+
+    pattern: str
+    owners: list[CodeOwner]
+    line_number: int
+    section: str = ""  # GitLab section name; empty for GitHub files
+    regex: re.Pattern = field(init=False, repr=False)
+
+    def __post_init__(self) -> None:
+        self.regex = _compile_pattern(self.pattern)
+
+    def matches(self, path: str) -> bool:
+        return bool(self.regex.match(path.strip("/")))
+
+
+def parse_owner(token: str) -> CodeOwner | None:
+    """Classify an owner token, or return None if it is not an owner."""
+    if token.startswith("@"):
+        # "@org/team" for GitHub teams, "@group/subgroup" for GitLab groups
+        return CodeOwner(name=token, kind="Team" if "/" in token else "User")
+    if "@" in token:
+        return CodeOwner(name=token, kind="User", email=token)
+    return None
+
+
+class CodeOwnersParser:
+    """Parses CODEOWNERS rules and resolves the owners of repository paths."""
+
+    def __init__(self, rules: list[CodeOwnersRule] | None = None):
+        self.rules = rules or []
+
+    @classmethod
+    def find(cls, repo_path: Path) -> Path | None:
+        """Return the CODEOWNERS file that applies to a repository, if any."""
+        for location in CODEOWNERS_LOCATIONS:
+            candidate = repo_path / location
+            if candidate.is_file():
+                return candidate
+        return None
+
+    @classmethod
+    def from_file(cls, path: Path) -> "CodeOwnersParser":
+        return cls.parse(path.read_text(encoding="utf-8", errors="replace"))
+
+    @classmethod
+    def parse(cls, content: str) -> "CodeOwnersParser":
+        rules = []
+        section = ""
+        section_owners: list[CodeOwner] = []
+        for line_number, raw_line in enumerate(content.splitlines(), start=1):
+            line = raw_line.strip()
+            if not line or line.startswith("#"):
+                continue
+
+            section_match = SECTION_PATTERN.match(line)
+            if section_match:
+                section = section_match.group(1).strip()
+                section_owners = _parse_owners(section_match.group(2).split())
+                continue
+
+            # Escaped spaces belong to the pattern, e.g. "docs/My\ Guide.md"
+            tokens = re.split(r"(?<!\\)\s+", line)
+            pattern = tokens[0].replace("\\ ", " ")
+            owners = _parse_owners(tokens[1:])
+            # GitLab rules without owners inherit their section's default owners
+            if not owners and section:
+                owners = section_owners
+            rules.append(CodeOwnersRule(pattern, owners, line_number, section))
+        return cls(rules)
+
+    def rules_for(self, path: str, is_dir: bool = False) -> list[CodeOwnersRule]:
+        """
+        Return the rules deciding ownership of a file, or of new files in a directory.
+
+        The last matching rule wins, as on GitHub. GitLab sections are
+        evaluated independently, so a path can be owned by several sections
+        at once and one winning rule is returned per section.
+        """
+        if is_dir:
+            path = f"{path.strip('/')}/{_DIRECTORY_PROBE}".lstrip("/")
+
+        winners: dict[str, CodeOwnersRule] = {}
+        for rule in self.rules:
+            if rule.matches(path):
+                winners[rule.section] = rule
+        return list(winners.values())
+
+    def owners_for(self, path: str, is_dir: bool = False) -> list[CodeOwner]:
+        """Return the distinct owners of a path across all sections."""
+        owners: dict[str, CodeOwner] = {}
+        for rule in self.rules_for(path, is_dir):
+            for owner in rule.owners:
+                owners.setdefault(owner.name, owner)
+        return list(owners.values())
+
+
+def _parse_owners(tokens: list[str]) -> list[CodeOwner]:
+    owners = []
+    for token in tokens:
+        if token.startswith("#"):
+            break  # Trailing comment
+        owner = parse_owner(token)
+        if owner:
+            owners.append(owner)
+    return owners
+
+
+def _compile_pattern(pattern: str) -> re.Pattern:
+    """Translate a gitignore-style CODEOWNERS pattern into a regex over paths."""
+    anchored = pattern.startswith("/") or "/" in pattern.rstrip("/")
+    directory_only = pattern.endswith("/")
+    body = pattern.strip("/")
+
+    regex = ""
+    i = 0
+    while i < len(body):
+        if body.startswith("**/", i):
+            regex += "(?:.*/)?"
+            i += 3
+        elif body.startswith("**", i):
+            regex += ".*"
+            i += 2
+        elif body[i] == "*":
+            regex += "[^/]*"
+            i += 1
+        elif body[i] == "?":
+            regex += "[^/]"
+            i += 1
+        else:
+            regex += re.escape(body[i])
+            i += 1
+
+    if not anchored:
+        regex = "(?:.*/)?" + regex
+    # A pattern naming a directory also owns everything below it, but
+    # "docs/*" only owns the files directly inside docs/
+    if directory_only:
+        suffix = "/.*"
+    elif body.endswith("/*"):
+        suffix = ""
+    else:
+        suffix = "(?:/.*)?"
+    return re.compile(f"^{regex}{suffix}$", re.DOTALL)
+
+
+@dataclass
+class OwnersFile:
+    """An OWNERS file, owning its directory and everything below it."""
+
+    path: str  # Relative to the repository root, e.g. "payments/OWNERS"
+    rules: list[CodeOwnersRule]  # Anchored to the file's directory
+    no_parent: bool = False  # Owners of parent directories do not apply
+
+    @property
+    def directory(self) -> str:
+        parent = str(PurePosixPath(self.path).parent)
+        return "" if parent == "." else parent
+
+
+class OwnersTree:
+    """
+    Resolves owners from OWNERS files, which apply to their directory and
+    its subdirectories together with the owners of parent directories,
+    unless a file opts out with "set noparent" or no_parent_owners.
+    """
+
+    def __init__(self, files: list[OwnersFile] | None = None):
+        self.files = {owners.directory: owners for owners in files or []}
+
+    @classmethod
+    def find(cls, repo_path: Path, ignore_dirs: set[str]) -> "OwnersTree":
+        aliases = _load_aliases(repo_path / OWNERS_ALIASES_FILE_NAME)
+        files = []
+        for root_str, dirs, file_names in os.walk(repo_path, topdown=True):
+            dirs[:] = [d for d in dirs if d not in ignore_dirs]
+            if OWNERS_FILE_NAME not in file_names:
+                continue
+            owners_path = Path(root_str) / OWNERS_FILE_NAME
+            relative = owners_path.relative_to(repo_path).as_posix()
+            content = owners_path.read_text(encoding="utf-8", errors="replace")
+            files.append(parse_owners_file(content, relative, aliases))
+        return cls(files)
+
+    def rules_for(
+        self, path: str, is_dir: bool = False
+    ) -> list[tuple[OwnersFile, CodeOwnersRule]]:
+        """The rules of the OWNERS files governing a path, nearest first."""
+        path = path.strip("/")
+        probe = f"{path}/{_DIRECTORY_PROBE}" if is_dir else path
+        directory = PurePosixPath(probe).parent
+        matched = []
+        for parent in [directory, *directory.parents]:
+            owners = self.files.get("" if str(parent) == "." else str(parent))
+            if owners is None:
+                continue
+            matched.extend(
+                (owners, rule) for rule in owners.rules if rule.matches(probe)
+            )
+            if owners.no_parent:
+                break
+        return matched
+
+
+def parse_owners_file(
+    content: str, path: str, aliases: set[str] | None = None
+) -> OwnersFile:
+    """Parse a Kubernetes (YAML) or Chromium (line-based) OWNERS file."""
+    directory = str(PurePosixPath(path).parent)
+    prefix = "/" if directory == "." else f"/{directory}/"
+    if _YAML_OWNERS_KEY.search(content):
+        return _parse_yaml_owners(content, path, prefix, aliases or set())
+    return _parse_chromium_owners(content, path, prefix)
+
+
+def _parse_chromium_owners(content: str, path: str, prefix: str) -> OwnersFile:
+    owners_file = OwnersFile(path, [])
+    for line_number, raw_line in enumerate(content.splitlines(), start=1):
+        line = raw_line.split("#", 1)[0].strip()
+        if not line:
+            continue
+        if line == "set noparent":
+            owners_file.no_parent = True
+        elif line.startswith("per-file "):
+            # "per-file *.gn,BUILD=build@example.com,other@example.com"
+            patterns, _, emails = line[len("per-file ") :].partition("=")
+            owners = _parse_owners(emails.replace(",", " ").split())
+            for pattern in patterns.split(","):
+                rule = CodeOwnersRule(prefix + pattern.strip(), owners, line_number)
+                owners_file.rules.append(rule)
+        else:
+            # Includes ("file://...", "include ...") and "*" grant nothing here
+            owners = _parse_owners(line.split())
+            if owners:
+                rule = CodeOwnersRule(prefix + "**", owners, line_number)
+                owners_file.rules.append(rule)
+    return owners_file
+
+
+def _parse_yaml_owners(
+    content: str, path: str, prefix: str, aliases: set[str]
+) -> OwnersFile:
+    try:
+        data = yaml.safe_load(content) or {}
+    except yaml.YAMLError as e:
+        logger.warning(f"Could not parse {path}: {e}")
+        return OwnersFile(path, [])
+    options = data.get("options") or {}
+    owners_file = OwnersFile(path, [], bool(options.get("no_parent_owners")))
+    lines = content.splitlines()
+    for name in data.get("approvers") or []:
+        name = str(name)
+        # GitHub handles without "@", or aliases for teams
+        if name in aliases:
+            owner = CodeOwner(name=name, kind="Team")
+        else:
+            owner = parse_owner(name) or CodeOwner(name=f"@{name}", kind="User")
+        line_number = next(
+            (i for i, line in enumerate(lines, start=1) if name in line), 0
+        )
+        owners_file.rules.append(CodeOwnersRule(prefix + "**", [owner], line_number))
+    return owners_file
+
+
+def _load_aliases(path: Path) -> set[str]:
+    if not path.is_file():
+        return set()
+    try:
+        data = yaml.safe_load(path.read_text(encoding="utf-8")) or {}
+    except yaml.YAMLError as e:
+        logger.warning(f"Could not parse {path}: {e}")
+        return set()
+    return set((data.get("aliases") or {}).keys())
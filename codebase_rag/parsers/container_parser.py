@@ -0,0 +1,245 @@
+"""Parsing of Dockerfiles and Compose files into images, stages and services.
+
+A Dockerfile is read as its build stages: the image each starts FROM (an
+earlier stage or an image from a registry), the files it COPYs or ADDs from
+the build context or from another stage, and the ports it exposes. A Compose
+file is read as its services: the image each runs or the Dockerfile it
+builds, with its build context, and the services it depends on.
+"""
+
+import json
+import re
+import shlex
+from dataclasses import dataclass, field
+from pathlib import PurePosixPath
+from typing import Any
+
+import yaml
+
+COMPOSE_FILE = re.compile(r"^(?:docker-)?compose(?:\.[\w-]+)?\.ya?ml$")
+VARIABLE = re.compile(r"\$\{(\w+)(?::?-([^}]*))?\}|\$(\w+)")
+
+
+@dataclass
+class DockerCopy:
+    """A COPY or ADD instruction."""
+
+    sources: list[str]  # Relative to the build context, or to from_stage
+    destination: str
+    line_number: int
+    from_stage: str | None = None  # --from: a stage name or index, or an image
+
+
+@dataclass
+class DockerStage:
+    name: str  # The AS alias, or the stage's index when it has none
+    base: str  # Image reference or name of an earlier stage
+    line_number: int
+    copies: list[DockerCopy] = field(default_factory=list)
+    exposed_ports: list[str] = field(default_factory=list)
+
+
+@dataclass
+class Dockerfile:
+    stages: list[DockerStage] = field(default_factory=list)
+
+    def stage(self, name: str | None) -> DockerStage | None:
+        """A stage by alias or index; the last one, which is built, for None."""
+        if name is None:
+            return self.stages[-1] if self.stages else None
+        return next(
+            (s for i, s in enumerate(self.stages) if name in (s.name, str(i))), None
+        )
+
+
+@dataclass
+class ComposeService:
+    name: str
+    line_number: int
+    image: str | None = None
+    # Build context and Dockerfile, relative to the Compose file's directory
+    build_context: str | None = None
+    dockerfile: str = "Dockerfile"
+    target: str | None = None  # Stage the build stops at
+    depends_on: list[str] = field(default_factory=list)
+    ports: list[str] = field(default_factory=list)
+
+
+class ContainerSyntaxError(ValueError):
+    pass
+
+
+def is_dockerfile(name: str) -> bool:
+    """Dockerfile or Containerfile, or variants like Dockerfile.dev, api.Dockerfile."""
+    lowered = name.lower()
+    return any(
+        lowered == base
+        or lowered.startswith(f"{base}.")
+        or lowered.endswith(f".{base}")
+        for base in ("dockerfile", "containerfile")
+    )
+
+
+def is_compose_file(name: str) -> bool:
+    """compose.yaml, docker-compose.yml and overrides such as compose.prod.yaml."""
+    return bool(COMPOSE_FILE.match(name))
+
+
+def parse_dockerfile(content: str) -> Dockerfile:
+    """Read the stages of a Dockerfile."""
+    dockerfile = Dockerfile()
+    # ARGs declared before the first FROM may be used in FROM lines
+    arguments: dict[str, str] = {}
+    for line_number, instruction, arguments_text in _instructions(content):
+        stage = dockerfile.stages[-1] if dockerfile.stages else None
+        if instruction == "ARG" and stage is None:
+            name, _, default = arguments_text.partition("=")
+            arguments[name.strip()] = default.strip().strip("\"'")
+        elif instruction == "FROM":
+            words = [w for w in arguments_text.split() if not w.startswith("--")]
+            if not words:
+                raise ContainerSyntaxError(f"line {line_number}: FROM without image")
+            name = str(len(dockerfile.stages))
+            if len(words) >= 3 and words[1].upper() == "AS":
+                name = words[2]
+            dockerfile.stages.append(
+                DockerStage(name, _substitute(words[0], arguments), line_number)
+            )
+        elif stage is None:
+            continue
+        elif instruction in ("COPY", "ADD"):
+            copy = _copy(line_number, arguments_text)
+            if copy:
+                stage.copies.append(copy)
+        elif instruction == "EXPOSE":
+            stage.exposed_ports += arguments_text.split()
+    return dockerfile
+
+
+def parse_compose(content: str) -> list[ComposeService]:
+    """Read the services of a Compose file."""
+    try:
+        data = yaml.safe_load(content)
+    except yaml.YAMLError as e:
+        raise ContainerSyntaxError(str(e)) from e
+    services = data.get("services") if isinstance(data, dict) else None
+    if not isinstance(services, dict):
+        return []
+    lines = content.split("\n")
+    result = []
+    for name, definition in services.items():
+        definition = definition or {}
+        service = ComposeService(
+            name=str(name),
+            line_number=_key_line(lines, str(name)),
+            image=definition.get("image"),
+            depends_on=_names(definition.get("depends_on")),
+            ports=[str(port) for port in definition.get("ports") or []],
+        )
+        build = definition.get("build")
+        if isinstance(build, str):
+            service.build_context = build
+        elif isinstance(build, dict):
+            service.build_context = str(build.get("context") or ".")
+            service.dockerfile = str(build.get("dockerfile") or "Dockerfile")
+            service.target = build.get("target")
+        result.append(service)
+    return result
+
+
+def context_path(base: str, relative: str) -> str | None:
+    """
+    A repository path for a path relative to a directory of the repository,
+    or None when it leaves the repository or is a URL (a remote context).
+    """
+    if "://" in relative or relative.startswith("git@"):
+        return None
+    parts: list[str] = []
+    for part in PurePosixPath(base, relative).parts:
+        if part == "..":
+            if not parts:
+                return None
+            parts.pop()
+        elif part not in (".", "/"):
+            parts.append(part)
+    return "/".join(parts)
+
+
+def _instructions(content: str) -> list[tuple[int, str, str]]:
+    """(line, INSTRUCTION, arguments) with continuation lines joined."""
+    instructions = []
+    pending = ""
+    start = 0
+    for number, line in enumerate(content.split("\n"), start=1):
+        stripped = line.strip()
+        if not pending and (not stripped or stripped.startswith("#")):
+            continue
+        if pending and stripped.startswith("#"):
+            continue  # Comments between continuation lines
+        if not pending:
+            start = number
+        if stripped.endswith("\\"):
+            pending += stripped[:-1] + " "
+            continue
+        text = pending + stripped
+        pending = ""
+        instruction, _, arguments = text.partition(" ")
+        instructions.append((start, instruction.upper(), arguments.strip()))
+    return instructions
+
+
+def _copy(line_number: int, text: str) -> DockerCopy | None:
+    from_stage = None
+    rest = text.strip()
+    while rest.startswith("--"):
+        flag, _, rest = rest.partition(" ")
+        rest = rest.lstrip()
+        if flag.startswith("--from="):
+            from_stage = flag.removeprefix("--from=")
+    if rest.startswith("<<"):
+        return None  # A heredoc: the file is written inline
+    try:
+        words = json.loads(rest) if rest.startswith("[") else shlex.split(rest)
+    except ValueError:
+        return None
+    if len(words) < 2:
+        return None
+    return DockerCopy(words[:-1], words[-1], line_number, from_stage)
+
+
+def _substitute(text: str, arguments: dict[str, str]) -> str:
+    def replace(match: re.Match) -> str:
+        name = match.group(1) or match.group(3)
+        value = arguments.get(name) or match.group(2)
+        return value if value is not None else match.group(0)
+
+    return VARIABLE.sub(replace, text)
+
+
+def _names(depends_on: Any) -> list[str]:
+    """Service names of depends_on, a list or a map with conditions."""
+    if isinstance(depends_on, dict):
+        return [str(name) for name in depends_on]
+    if isinstance(depends_on, list):
+        return [str(name) for name in depends_on]
+    return []
+
+
+def _key_line(lines: list[str], name: str) -> int:
+    """Line of a service's key under services:, 0 when not found."""
+    key = re.compile(rf"""["']?{re.escape(name)}["']?\s*:""")
+    in_services = False
+    indent = None
+    for number, line in enumerate(lines, start=1):
+        stripped = line.lstrip()
+        if not stripped or stripped.startswith("#"):
+            continue
+        depth = len(line) - len(stripped)
+        if depth == 0:
+            in_services = re.match(r"services\s*:", stripped) is not None
+        elif in_services:
+            # Service keys are the first level under services:
+            indent = depth if indent is None else indent
+            if depth == indent and key.match(stripped):
+                return number
+    return 0
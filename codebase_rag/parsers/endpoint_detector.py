@@ -0,0 +1,242 @@
+"""Detection of HTTP endpoint registrations across common web frameworks."""
+
+import re
+from dataclasses import dataclass
+
+HTTP_METHODS = ("GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS")
+
+
+@dataclass
+class HttpEndpoint:
+    """An HTTP route registration and the function that handles it."""
+
+    method: str  # Upper-case HTTP verb, or "ANY" when not restricted
+    route: str
+    handler: str | None  # Simple name of the handler function, None for inline handlers
+    line_number: int
+    framework: str
+
+
+class EndpointDetector:
+    """Detects HTTP endpoints declared with decorators or router registrations."""
+
+    # @app.get("/users"), @router.post('/items')  (FastAPI, Flask 2, Starlette)
+    PY_VERB_DECORATOR = re.compile(
+        r"^\s*@\w+(?:\.\w+)*\.(get|post|put|delete|patch|head|options)\(\s*[rf]?[\"']([^\"']*)[\"']",
+        re.IGNORECASE,
+    )
+    # @app.route("/users", methods=["GET", "POST"])  (Flask)
+    PY_ROUTE_DECORATOR = re.compile(
+        r"^\s*@\w+(?:\.\w+)*\.(?:route|api_route)\(\s*[rf]?[\"']([^\"']*)[\"'](.*)"
+    )
+    # path("users/", views.user_list)  (Django)
+    PY_DJANGO_PATH = re.compile(
+        r"\b(?:re_)?path\(\s*[rf]?[\"']([^\"']*)[\"']\s*,\s*([\w.]+)"
+    )
+    PY_DEF = re.compile(r"^\s*(?:async\s+)?def\s+(\w+)")
+
+    # app.get('/users', auth, listUsers)  (Express, Koa router, Fastify)
+    JS_ROUTE = re.compile(
+        r"\b(?:app|api|server|router|routes|fastify|\w+Router)"
+        r"\.(get|post|put|delete|patch|head|options|all)"
+        r"\(\s*[\"'`](/[^\"'`]*)[\"'`]\s*,(.*)",
+        re.IGNORECASE,
+    )
+
+    # http.HandleFunc("/users", listUsers), mux.Handle("GET /users", h)
+    GO_HANDLE = re.compile(r"\.(?:HandleFunc|Handle)\(\s*\"([^\"]*)\"\s*,\s*([\w.]+)")
+    # r.GET("/users", listUsers) (gin/echo), r.Get("/users", listUsers) (chi)
+    GO_VERB = re.compile(
+        r"\.(GET|POST|PUT|DELETE|PATCH|HEAD|OPTIONS|Get|Post|Put|Delete|Patch|Head|Options)"
+        r"\(\s*\"(/[^\"]*)\"\s*,(.*)"
+    )
+    GO_METHODS_SUFFIX = re.compile(r"\.Methods\(([^)]*)\)")
+
+    # @GetMapping("/users"), @RequestMapping(value = "/users", method = RequestMethod.GET)
+    JAVA_MAPPING = re.compile(
+        r"^\s*@(Get|Post|Put|Delete|Patch|Request)Mapping\s*(?:\((.*)\))?"
+    )
+    JAVA_METHOD_DECL = re.compile(
+        r"^\s*(?:(?:public|protected|private|static|final|synchronized|abstract)\s+)*"
+        r"[\w<>\[\],.?\s]+?\s+(\w+)\s*\("
+    )
+
+    def detect(self, content: str, language: str) -> list[HttpEndpoint]:
+        """Detect endpoints in source code for the given language."""
+        lines = content.split("\n")
+        if language == "python":
+            return self._detect_python(lines)
+        if language in ("javascript", "typescript"):
+            return self._detect_javascript(lines)
+        if language == "go":
+            return self._detect_go(lines)
+        if language == "java":
+            return self._detect_java(lines)
+        return []
+
+    def _detect_python(self, lines: list[str]) -> list[HttpEndpoint]:
+        endpoints = []
+        for i, line in enumerate(lines):
+            if match := self.PY_VERB_DECORATOR.match(line):
+                endpoints.append(
+                    HttpEndpoint(
+                        method=match.group(1).upper(),
+                        route=match.group(2),
+                        handler=self._next_python_def(lines, i),
+                        line_number=i + 1,
+                        framework="fastapi",
+                    )
+                )
+            elif match := self.PY_ROUTE_DECORATOR.match(line):
+                methods = [
+                    verb.upper()
+                    for verb in re.findall(r"[\"'](\w+)[\"']", match.group(2))
+                    if verb.upper() in HTTP_METHODS
+                ] or ["GET"]
+                handler = self._next_python_def(lines, i)
+                endpoints.extend(
+                    HttpEndpoint(
+                        method=method,
+                        route=match.group(1),
+                        handler=handler,
+                        line_number=i + 1,
+                        framework="flask",
+                    )
+                    for method in methods
+                )
+            elif match := self.PY_DJANGO_PATH.search(line):
+                endpoints.append(
+                    HttpEndpoint(
+                        method="ANY",
+                        route=match.group(1),
+                        handler=match.group(2).split(".")[-1],
+                        line_number=i + 1,
+                        framework="django",
+                    )
+                )
+        return endpoints
+
+    def _next_python_def(self, lines: list[str], decorator_index: int) -> str | None:
+        """Find the function defined below a decorator, skipping other decorators."""
+        for line in lines[decorator_index + 1 : decorator_index + 16]:
+            if match := self.PY_DEF.match(line):
+                return match.group(1)
+            if line.lstrip().startswith("class "):
+                return None
+        return None
+
+    def _detect_javascript(self, lines: list[str]) -> list[HttpEndpoint]:
+        endpoints = []
+        for i, line in enumerate(lines):
+            match = self.JS_ROUTE.search(line)
+            if not match:
+                continue
+            method = match.group(1).upper()
+            endpoints.append(
+                HttpEndpoint(
+                    method="ANY" if method == "ALL" else method,
+                    route=match.group(2),
+                    handler=self._last_identifier_argument(match.group(3)),
+                    line_number=i + 1,
+                    framework="express",
+                )
+            )
+        return endpoints
+
+    def _detect_go(self, lines: list[str]) -> list[HttpEndpoint]:
+        endpoints = []
+        for i, line in enumerate(lines):
+            if match := self.GO_HANDLE.search(line):
+                pattern = match.group(1)
+                method = "ANY"
+                # Go 1.22 patterns embed the method: "GET /users/{id}"
+                if " " in pattern and pattern.split(" ", 1)[0] in HTTP_METHODS:
+                    method, pattern = pattern.split(" ", 1)
+                elif methods := self.GO_METHODS_SUFFIX.search(line):
+                    verbs = re.findall(r"\"(\w+)\"", methods.group(1))
+                    method = verbs[0].upper() if verbs else "ANY"
+                endpoints.append(
+                    HttpEndpoint(
+                        method=method,
+                        route=pattern,
+                        handler=match.group(2).split(".")[-1],
+                        line_number=i + 1,
+                        framework="net/http",
+                    )
+                )
+            elif match := self.GO_VERB.search(line):
+                endpoints.append(
+                    HttpEndpoint(
+                        method=match.group(1).upper(),
+                        route=match.group(2),
+                        handler=self._last_identifier_argument(match.group(3)),
+                        line_number=i + 1,
+                        framework="gin",
+                    )
+                )
+        return endpoints
+
+    def _detect_java(self, lines: list[str]) -> list[HttpEndpoint]:
+        endpoints = []
+        class_prefix = ""
+        for i, line in enumerate(lines):
+            match = self.JAVA_MAPPING.match(line)
+            if not match:
+                continue
+            kind, args = match.group(1), match.group(2) or ""
+            route_match = re.search(r"\"([^\"]*)\"", args)
+            route = route_match.group(1) if route_match else ""
+            handler = self._next_java_method(lines, i)
+
+            if kind == "Request":
+                # A class-level @RequestMapping sets the prefix for its methods
+                if handler is None:
+                    class_prefix = route.rstrip("/")
+                    continue
+                verb = re.search(r"RequestMethod\.(\w+)", args)
+                method = verb.group(1).upper() if verb else "ANY"
+            else:
+                method = kind.upper()
+
+            endpoints.append(
+                HttpEndpoint(
+                    method=method,
+                    route=f"{class_prefix}{route}" if class_prefix else route,
+                    handler=handler,
+                    line_number=i + 1,
+                    framework="spring",
+                )
+            )
+        return endpoints
+
+    def _next_java_method(self, lines: list[str], annotation_index: int) -> str | None:
+        """Find the method declared below an annotation, None if it is a class."""
+        for line in lines[annotation_index + 1 :]:
+            stripped = line.strip()
+            if not stripped or stripped.startswith(("@", "//", "*", "/*")):
+                continue
+            if re.search(r"\b(class|interface|record)\b", stripped):
+                return None
+            if match := self.JAVA_METHOD_DECL.match(line):
+                return match.group(1)
+            return None
+        return None
+
+    @staticmethod
+    def _last_identifier_argument(arguments: str) -> str | None:
+        """Return the final argument of a call when it is a plain identifier."""
+        depth = 0
+        closing = len(arguments)
+        for index, char in enumerate(arguments):
+            if char in "([{":
+                depth += 1
+            elif char in ")]}":
+                if depth == 0:
+                    closing = index
+                    break
+                depth -= 1
+        parts = [part.strip() for part in arguments[:closing].split(",")]
+        last = parts[-1] if parts else ""
+        if re.fullmatch(r"[\w$.]+", last):
+            return last.split(".")[-1]
+        return None
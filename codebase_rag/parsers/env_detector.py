@@ -0,0 +1,52 @@
+"""Detection of environment variables read by code, by name."""
+
+import re
+from dataclasses import dataclass
+
+NAME = r"[\"'`]([A-Za-z_][A-Za-z0-9_]*)[\"'`]"
+
+# Per language, patterns whose first group is the variable's name
+PATTERNS = {
+    # os.getenv("X"), os.environ["X"], os.environ.get("X"), environ.get("X")
+    "python": [
+        re.compile(rf"\bgetenv\(\s*{NAME}"),
+        re.compile(rf"\benviron(?:\.get\(|\.pop\(|\.setdefault\(|\[)\s*{NAME}"),
+    ],
+    # process.env.X, process.env["X"], import.meta.env.X, Deno.env.get("X")
+    "javascript": [
+        re.compile(r"\b(?:process|meta)\.env\.([A-Za-z_][A-Za-z0-9_]*)"),
+        re.compile(rf"\b(?:process|meta)\.env\[\s*{NAME}\s*\]"),
+        re.compile(rf"\bDeno\.env\.get\(\s*{NAME}"),
+    ],
+    # os.Getenv("X"), os.LookupEnv("X")
+    "go": [re.compile(rf"\bos\.(?:Getenv|LookupEnv)\(\s*{NAME}")],
+    "java": [re.compile(rf"\bSystem\.getenv\(\s*{NAME}")],
+    "scala": [
+        re.compile(rf"\bSystem\.getenv\(\s*{NAME}"),
+        re.compile(rf"\bsys\.env(?:\.get\(|\.getOrElse\(|\()\s*{NAME}"),
+    ],
+    "rust": [re.compile(rf"\b(?:env::var|env::var_os|env!|option_env!)\(\s*{NAME}")],
+    "c": [re.compile(rf"\b(?:secure_)?getenv\(\s*{NAME}")],
+    "cpp": [re.compile(rf"\b(?:std::)?(?:secure_)?getenv\(\s*{NAME}")],
+}
+PATTERNS["typescript"] = PATTERNS["javascript"]
+
+
+@dataclass
+class EnvRead:
+    name: str
+    line_number: int
+
+
+class EnvReadDetector:
+    """Detects reads of environment variables named by string literals."""
+
+    def detect(self, content: str, language: str) -> list[EnvRead]:
+        patterns = PATTERNS.get(language)
+        if not patterns:
+            return []
+        reads = []
+        for number, line in enumerate(content.split("\n"), start=1):
+            for pattern in patterns:
+                reads += [EnvRead(name, number) for name in pattern.findall(line)]
+        return reads
@@ -0,0 +1,283 @@
+"""Parsing of HCL (HashiCorp Configuration Language) files, as Terraform writes.
+
+No tree-sitter grammar for HCL is installed, so the file is scanned into its
+blocks and attributes directly. Expressions are kept as source text; what is
+read from them is their literal string value when they have one, the
+traversals they make (var.region, aws_sqs_queue.orders.url) and the entries
+of object constructors ({ KEY = value }).
+"""
+
+import json
+import re
+from collections.abc import Iterator
+from dataclasses import dataclass, field
+
+IDENTIFIER = re.compile(r"[A-Za-z_][\w-]*")
+HEREDOC = re.compile(r"<<-?([A-Za-z_]\w*)[ \t]*\n")
+# A traversal's root and its attribute steps; index steps are dropped first
+TRAVERSAL = re.compile(r"(?<![\w.\"-])([A-Za-z_][\w-]*)((?:\.[A-Za-z_][\w-]*)+)")
+INDEX = re.compile(r"(?<=[\w\])])\[[^\[\]]*\]")
+
+
+@dataclass
+class HclAttribute:
+    name: str
+    expression: str  # Source text
+    line_number: int
+
+    @property
+    def literal(self) -> str | None:
+        """The value of a plain quoted string, without interpolations."""
+        text = self.expression.strip()
+        if len(text) < 2 or text[0] != '"' or text[-1] != '"' or "${" in text:
+            return None
+        try:
+            value = json.loads(text)
+        except ValueError:
+            return None
+        return value if isinstance(value, str) else None
+
+    @property
+    def traversals(self) -> list[str]:
+        return traversals(self.expression)
+
+
+@dataclass
+class HclBlock:
+    type: str  # "" for a file's body
+    labels: list[str]
+    line_number: int
+    attributes: dict[str, HclAttribute] = field(default_factory=dict)
+    blocks: list["HclBlock"] = field(default_factory=list)
+
+    def walk(self) -> Iterator["HclBlock"]:
+        """This block and every block nested in it."""
+        yield self
+        for block in self.blocks:
+            yield from block.walk()
+
+
+class HclSyntaxError(ValueError):
+    pass
+
+
+def parse_hcl(content: str) -> HclBlock:
+    """A file's body: its top-level attributes and blocks."""
+    scanner = _Scanner(content)
+    body = HclBlock("", [], 1)
+    scanner.body(body, closing=None)
+    return body
+
+
+def traversals(expression: str) -> list[str]:
+    """Dotted traversals made by an expression, outside string literals."""
+    code = _without_strings(expression)
+    while INDEX.search(code):
+        code = INDEX.sub("", code)
+    return list(
+        dict.fromkeys(root + steps for root, steps in TRAVERSAL.findall(code))
+    )
+
+
+def object_entries(expression: str) -> list[tuple[str, str, int]]:
+    """
+    The (key, value expression, line offset) items of an object constructor,
+    the offset counting the lines from the expression's first.
+    """
+    text = expression.strip()
+    if not text.startswith("{"):
+        return []
+    entries = []
+    scanner = _Scanner(text)
+    scanner.position = 1
+    while True:
+        scanner.skip_space(newlines=True, commas=True)
+        if scanner.at_end() or scanner.peek() == "}":
+            return entries
+        key = scanner.key()
+        if key is None:
+            return entries
+        scanner.skip_space()
+        if scanner.peek() not in ("=", ":"):
+            return entries
+        scanner.position += 1
+        offset = scanner.line() - 1
+        entries.append((key, scanner.expression(stop=",").strip(), offset))
+
+
+class _Scanner:
+    def __init__(self, text: str):
+        self.text = text
+        self.position = 0
+
+    def body(self, block: HclBlock, closing: str | None) -> None:
+        while True:
+            self.skip_space(newlines=True)
+            if self.at_end():
+                if closing:
+                    raise self.error(f"{block.type} block is not closed")
+                return
+            if self.peek() == closing:
+                self.position += 1
+                return
+            line = self.line()
+            name = self.identifier()
+            self.skip_space()
+            if self.peek() == "=" and self.peek(1) != "=":
+                self.position += 1
+                block.attributes[name] = HclAttribute(
+                    name, self.expression().strip(), line
+                )
+                continue
+            labels = []
+            while self.peek() != "{":
+                if self.peek() == '"':
+                    labels.append(json.loads(self.string()))
+                elif IDENTIFIER.match(self.text, self.position):
+                    labels.append(self.identifier())
+                else:
+                    raise self.error(f"expected a block after '{name}'")
+                self.skip_space()
+            self.position += 1
+            nested = HclBlock(name, labels, line)
+            block.blocks.append(nested)
+            self.body(nested, closing="}")
+
+    def expression(self, stop: str = "") -> str:
+        """Source text up to the end of the line, or a closing brace or stop."""
+        start = self.position
+        depth = 0
+        while not self.at_end():
+            char = self.peek()
+            if char == '"':
+                self.string()
+                continue
+            if char == "<" and (heredoc := HEREDOC.match(self.text, self.position)):
+                end = re.compile(rf"^[ \t]*{heredoc.group(1)}[ \t]*$", re.MULTILINE)
+                closing = end.search(self.text, heredoc.end())
+                self.position = closing.end() if closing else len(self.text)
+                continue
+            before = self.position
+            if self.comment():
+                if depth > 0 or self.text.startswith("/*", before):
+                    continue
+                self.position = before  # A line comment ends the expression
+                break
+            if char in "([{":
+                depth += 1
+            elif char in ")]}":
+                if depth == 0:
+                    break
+                depth -= 1
+            elif depth == 0 and (char == "\n" or char in stop):
+                break
+            self.position += 1
+        return self.text[start : self.position]
+
+    def string(self) -> str:
+        """A quoted string with any interpolations in it, as source text."""
+        start = self.position
+        self.position += 1
+        while not self.at_end():
+            char = self.peek()
+            if char == "\\":
+                self.position += 2
+            elif char == '"':
+                self.position += 1
+                return self.text[start : self.position]
+            elif char in "$%" and self.peek(1) == "{":
+                self.position += 2
+                self.expression()
+                if self.peek() == "}":
+                    self.position += 1
+            elif char == "\n":
+                break
+            else:
+                self.position += 1
+        raise self.error("string is not closed")
+
+    def key(self) -> str | None:
+        """An object key: an identifier or a quoted string."""
+        if self.peek() == '"':
+            value = json.loads(self.string())
+            return value if isinstance(value, str) else None
+        if IDENTIFIER.match(self.text, self.position):
+            return self.identifier()
+        return None
+
+    def identifier(self) -> str:
+        match = IDENTIFIER.match(self.text, self.position)
+        if not match:
+            raise self.error(f"unexpected '{self.peek()}'")
+        self.position = match.end()
+        return match.group()
+
+    def skip_space(self, newlines: bool = False, commas: bool = False) -> None:
+        while not self.at_end():
+            char = self.peek()
+            if (
+                char in " \t\r"
+                or (newlines and char == "\n")
+                or (commas and char == ",")
+            ):
+                self.position += 1
+            elif not self.comment():
+                return
+            elif not newlines and self.text[self.position - 1] == "\n":
+                self.position -= 1  # Leave the line's end to the caller
+                return
+
+    def comment(self) -> bool:
+        """Skip a comment starting here, up to and including its line's end."""
+        if self.peek() == "#" or (self.peek() == "/" and self.peek(1) == "/"):
+            end = self.text.find("\n", self.position)
+            self.position = len(self.text) if end < 0 else end + 1
+            return True
+        if self.peek() == "/" and self.peek(1) == "*":
+            end = self.text.find("*/", self.position + 2)
+            self.position = len(self.text) if end < 0 else end + 2
+            return True
+        return False
+
+    def peek(self, ahead: int = 0) -> str:
+        index = self.position + ahead
+        return self.text[index] if index < len(self.text) else ""
+
+    def at_end(self) -> bool:
+        return self.position >= len(self.text)
+
+    def line(self) -> int:
+        return self.text.count("\n", 0, self.position) + 1
+
+    def error(self, message: str) -> HclSyntaxError:
+        return HclSyntaxError(f"line {self.line()}: {message}")
+
+
+def _without_strings(expression: str) -> str:
+    """An expression with string literals blanked, keeping their interpolations."""
+    result = []
+    in_string = False
+    depth = 0  # Of braces inside an interpolation
+    index = 0
+    while index < len(expression):
+        char = expression[index]
+        if in_string and depth == 0:
+            if char == "\\":
+                index += 2
+                continue
+            if char == '"':
+                in_string = False
+            elif char in "$%" and expression[index + 1 : index + 2] == "{":
+                depth = 1
+                index += 2
+                result.append(" ")
+                continue
+            result.append(" ")
+        elif in_string:
+            depth += {"{": 1, "}": -1}.get(char, 0)
+            result.append(" " if depth == 0 else char)
+        else:
+            in_string = char == '"'
+            result.append(" " if in_string else char)
+        index += 1
+    return "".join(result)
@@ -0,0 +1,127 @@
+"""Extraction of issue tracker references from commit messages and comments.
+
+Recognized forms:
+
+- GitHub/GitLab style: "#123", "GH-123" and "owner/repo#123"
+- Jira style keys: "PROJ-456"
+
+A reference preceded by a closing keyword ("fixes #123", "Closes PROJ-4")
+is marked as closing the issue.
+"""
+
+import re
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+from .todo_extractor import COMMENT_DECORATION
+
+CLOSING_KEYWORD = (
+    r"(?:(?i:(?P<keyword>close[sd]?|fix(?:e[sd])?|resolve[sd]?)):?\s+)?"
+)
+
+GITHUB_REFERENCE = re.compile(
+    CLOSING_KEYWORD
+    + r"(?<![\w/#&])(?P<repository>[\w.-]+/[\w.-]+)?"
+    + r"(?:#|(?i:\bGH-))(?P<number>\d+)\b"
+)
+
+JIRA_REFERENCE = re.compile(
+    CLOSING_KEYWORD + r"\b(?P<project>[A-Z][A-Z0-9_]+)-(?P<number>\d+)\b"
+)
+
+# Uppercase prefixes of things that look like Jira keys but are not tickets
+NOT_JIRA_PROJECTS = frozenset(
+    "AES AGPL BSD CP CVE CWE ECMA ES GH GPL HTTP IEEE ISO LGPL MD PEP RFC RSA "
+    "SHA SSL TLS UTC UTF WCAG".split()
+)
+
+
+@dataclass
+class IssueReference:
+    """A mention of an issue, identified by its tracker key."""
+
+    key: str  # "#123", "owner/repo#123" or "PROJ-456"
+    tracker: str  # github or jira
+    number: int
+    project: str = ""  # Jira project key, or the other repository of a GitHub issue
+    closes: bool = False
+    line_number: int = 0  # For references found in source comments
+
+
+def extract_issue_references(
+    text: str, jira_projects: set[str] | None = None
+) -> list[IssueReference]:
+    """
+    Return the distinct issues a text mentions, in order of appearance. When
+    jira_projects is given, only keys of those projects count as Jira issues.
+    """
+    found: list[tuple[int, IssueReference]] = []
+    for match in GITHUB_REFERENCE.finditer(text):
+        repository = match.group("repository") or ""
+        number = int(match.group("number"))
+        key = f"{repository}#{number}"
+        found.append(
+            (
+                match.start(),
+                IssueReference(
+                    key, "github", number, repository, bool(match.group("keyword"))
+                ),
+            )
+        )
+    for match in JIRA_REFERENCE.finditer(text):
+        project = match.group("project")
+        if jira_projects is not None:
+            if project not in jira_projects:
+                continue
+        elif project in NOT_JIRA_PROJECTS:
+            continue
+        number = int(match.group("number"))
+        found.append(
+            (
+                match.start(),
+                IssueReference(
+                    f"{project}-{number}",
+                    "jira",
+                    number,
+                    project,
+                    bool(match.group("keyword")),
+                ),
+            )
+        )
+
+    references: dict[str, IssueReference] = {}
+    for _, reference in sorted(found, key=lambda item: item[0]):
+        if reference.key in references:
+            references[reference.key].closes |= reference.closes
+        else:
+            references[reference.key] = reference
+    return list(references.values())
+
+
+class IssueReferenceExtractor:
+    """Finds issue references in the comment nodes of a syntax tree."""
+
+    def extract(self, root_node: Node) -> list[IssueReference]:
+        """Return references in source order, one per issue and comment line."""
+        references = []
+        stack = [root_node]
+        while stack:
+            node = stack.pop()
+            if node.type.endswith("comment"):
+                references.extend(self._extract_from_comment(node))
+                continue
+            stack.extend(node.children)
+        return sorted(references, key=lambda r: r.line_number)
+
+    def _extract_from_comment(self, node: Node) -> list[IssueReference]:
+        if node.text is None:
+            return []
+        references = []
+        lines = node.text.decode("utf-8", errors="replace").split("\n")
+        for offset, line in enumerate(lines):
+            text = COMMENT_DECORATION.sub("", line)
+            for reference in extract_issue_references(text):
+                reference.line_number = node.start_point[0] + offset + 1
+                references.append(reference)
+        return references
@@ -0,0 +1,202 @@
+"""Extraction of logging and print calls with their message templates and levels."""
+
+import re
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+from ..utils.ast_helpers import node_text
+
+# Call node types per language, and the field holding the called expression
+CALL_NODE_TYPES = {
+    "python": "call",
+    "javascript": "call_expression",
+    "typescript": "call_expression",
+    "go": "call_expression",
+    "java": "method_invocation",
+}
+
+STRING_NODE_TYPES = {
+    "string",
+    "template_string",
+    "interpreted_string_literal",
+    "raw_string_literal",
+    "string_literal",
+}
+
+# Method name (lowercased, without format suffixes) -> normalized level
+LEVELS = {
+    "trace": "trace",
+    "debug": "debug",
+    "info": "info",
+    "log": "info",
+    "print": "info",
+    "fprint": "info",
+    "warn": "warn",
+    "warning": "warn",
+    "error": "error",
+    "exception": "error",
+    "fatal": "fatal",
+    "critical": "fatal",
+    "panic": "panic",
+    "dpanic": "panic",
+}
+
+# Receivers that conventionally hold a logger: log, logger, l, sugar, auditLog, ...
+LOGGER_NAME = re.compile(r"^(?:l|lg|sugar|\w*log|\w*logger)$", re.IGNORECASE)
+
+# logrus entries built with WithField(s)/WithError before the level call
+LOGRUS_BUILDERS = {"WithField", "WithFields", "WithError", "WithContext"}
+
+STRING_QUOTES = re.compile(r'^[A-Za-z]*("""|\'\'\'|"|\'|`)(.*)\1$', re.DOTALL)
+
+
+@dataclass
+class LogStatement:
+    """A single logging or print call."""
+
+    library: str  # slog, zap, logrus, log, logging, console, print or logger
+    level: str  # trace, debug, info, warn, error, fatal or panic
+    message: str  # First string literal argument, placeholders kept verbatim
+    line_number: int
+    column: int
+    call: str  # Dotted callee, e.g. "slog.ErrorContext"
+
+
+class LogExtractor:
+    """Finds logging calls in a syntax tree for one language."""
+
+    def __init__(self, language: str):
+        self.language = language
+        self.call_type = CALL_NODE_TYPES.get(language)
+
+    def extract(self, root_node: Node) -> list[LogStatement]:
+        """Return logging calls in source order."""
+        if self.call_type is None:
+            return []
+        statements = []
+        stack = [root_node]
+        while stack:
+            node = stack.pop()
+            if node.type == self.call_type:
+                statement = self._classify(node)
+                if statement:
+                    statements.append(statement)
+            stack.extend(node.children)
+        return sorted(statements, key=lambda s: (s.line_number, s.column))
+
+    def _classify(self, call: Node) -> LogStatement | None:
+        parts = _callee_parts(call)
+        if not parts:
+            return None
+        method, receiver = parts[-1], parts[:-1]
+        library = self._library(method, receiver)
+        level = _level(method)
+        if library is None or level is None:
+            return None
+        return LogStatement(
+            library=library,
+            level=level,
+            message=_first_string_argument(call),
+            line_number=call.start_point[0] + 1,
+            column=call.start_point[1],
+            call=".".join(parts),
+        )
+
+    def _library(self, method: str, receiver: list[str]) -> str | None:
+        if not receiver:
+            # Only the print builtin counts as a bare logging call
+            return "print" if self.language == "python" and method == "print" else None
+        root, immediate = receiver[0], receiver[-1]
+        if self.language == "go":
+            if root == "fmt":
+                return "print" if method.startswith(("Print", "Fprint")) else None
+            if root == "slog":
+                return "slog"
+            if "zap" in receiver or "Sugar" in receiver or method.endswith("w"):
+                return "zap"
+            if root == "logrus" or LOGRUS_BUILDERS.intersection(receiver):
+                return "logrus"
+            if root == "log" and len(receiver) == 1:
+                # The standard library logger only has Print, Fatal and Panic
+                if method.startswith(("Print", "Fatal", "Panic")):
+                    return "log"
+        elif self.language == "python" and root == "logging":
+            return "logging"
+        elif self.language in ("javascript", "typescript") and root == "console":
+            return "console"
+        elif self.language == "java" and receiver[:2] in (
+            ["System", "out"],
+            ["System", "err"],
+        ):
+            return "print"
+        return "logger" if LOGGER_NAME.match(immediate) else None
+
+
+def _level(method: str) -> str | None:
+    name = method.removesuffix("Context").lower()
+    candidates = [name]
+    if name.endswith("ln"):
+        candidates.append(name[:-2])
+    if name.endswith(("f", "w", "s")):
+        candidates.append(name[:-1])
+    return next((LEVELS[c] for c in candidates if c in LEVELS), None)
+
+
+def _callee_parts(call: Node) -> list[str]:
+    """Dotted callee names, flattening chained calls: a.With(x).Info -> a.With.Info."""
+    if call.type == "method_invocation":
+        name = call.child_by_field_name("name")
+        receiver = call.child_by_field_name("object")
+        prefix = _expression_parts(receiver) if receiver is not None else []
+        return prefix + [node_text(name)] if name is not None else []
+    function = call.child_by_field_name("function")
+    return _expression_parts(function) if function is not None else []
+
+
+def _expression_parts(node: Node) -> list[str]:
+    if node.type in ("call", "call_expression", "method_invocation"):
+        return _callee_parts(node)
+    if node.type in ("selector_expression", "attribute", "member_expression"):
+        operand = (
+            node.child_by_field_name("operand")
+            or node.child_by_field_name("object")
+        )
+        member = (
+            node.child_by_field_name("field")
+            or node.child_by_field_name("attribute")
+            or node.child_by_field_name("property")
+        )
+        if operand is None or member is None:
+            return []
+        return _expression_parts(operand) + [node_text(member)]
+    if node.type == "field_access":
+        operand = node.child_by_field_name("object")
+        member = node.child_by_field_name("field")
+        if operand is None or member is None:
+            return []
+        return _expression_parts(operand) + [node_text(member)]
+    if node.type in ("identifier", "field_identifier", "property_identifier", "this"):
+        return [node_text(node)]
+    return []
+
+
+def _first_string_argument(call: Node) -> str:
+    arguments = call.child_by_field_name("arguments")
+    if arguments is None:
+        return ""
+    for argument in arguments.named_children:
+        stack = [argument]
+        while stack:
+            node = stack.pop()
+            if node.type in STRING_NODE_TYPES:
+                return _unquote(node_text(node))
+            # Later children first so the leftmost literal is found first
+            stack.extend(reversed(node.named_children))
+    return ""
+
+
+def _unquote(literal: str) -> str:
+    match = STRING_QUOTES.match(literal)
+    return match.group(2) if match else literal
+
@@ -0,0 +1,63 @@
+"""Sections of README files, the prose a directory's authors wrote about it.
+
+A README is split at its ATX headings (`#` to `######`) into sections, each
+holding its heading and the text up to the next heading of any level. What
+precedes the first heading, usually a badge line and a summary, is a section
+titled after the file. Lines of fenced code blocks are never headings, so a
+`# comment` in a shell example does not start a section.
+"""
+
+import re
+from dataclasses import dataclass
+
+README_SUFFIXES = ("", ".md", ".markdown", ".txt")
+
+HEADING = re.compile(r"^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$")
+FENCE = re.compile(r"^ {0,3}(```|~~~)")
+
+
+@dataclass
+class MarkdownSection:
+    title: str
+    level: int  # 1 to 6, 0 for the text before the first heading
+    text: str  # The heading line included
+    start_line: int
+    end_line: int
+
+
+def is_readme(name: str) -> bool:
+    """README, README.md and the like, in any case."""
+    stem, dot, suffix = name.partition(".")
+    return stem.lower() == "readme" and f"{dot}{suffix}".lower() in README_SUFFIXES
+
+
+def parse_markdown_sections(text: str, title: str = "") -> list[MarkdownSection]:
+    """The sections of a Markdown document with any text, in order."""
+    sections: list[MarkdownSection] = []
+    current = MarkdownSection(title, 0, "", 1, 1)
+    lines: list[str] = []
+    fence = None
+
+    def close(end_line: int) -> None:
+        # A heading with nothing below it says too little on its own
+        body = lines[1:] if current.level else lines
+        if "\n".join(body).strip():
+            current.text = "\n".join(lines).strip()
+            current.end_line = end_line
+            sections.append(current)
+
+    for number, line in enumerate(text.splitlines(), start=1):
+        if match := FENCE.match(line):
+            if fence is None:
+                fence = match[1]
+            elif match[1] == fence:
+                fence = None
+        elif fence is None and (heading := HEADING.match(line)):
+            close(number - 1)
+            current = MarkdownSection(
+                heading[2], len(heading[1]), "", number, number
+            )
+            lines = []
+        lines.append(line)
+    close(len(text.splitlines()))
+    return sections
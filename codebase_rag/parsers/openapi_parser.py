@@ -0,0 +1,123 @@
+"""Parsing of OpenAPI 3 and Swagger 2 specifications into their operations.
+
+A spec is a YAML or JSON file with a top-level "openapi" or "swagger" key.
+Its operations are compared with the routes registered in code (see
+endpoint_detector.py) after normalize_route, which blanks path parameters so
+that "/orders/{id}" (OpenAPI, chi, net/http), "/orders/:id" (gin, echo) and
+"/orders/{id:[0-9]+}" (chi, gorilla/mux) name the same route.
+"""
+
+import json
+import re
+from dataclasses import dataclass, field
+from urllib.parse import urlparse
+
+import yaml
+
+SPEC_SUFFIXES = (".yaml", ".yml", ".json")
+OPERATION_METHODS = ("get", "put", "post", "delete", "options", "head", "patch")
+
+SPEC_KEY = re.compile(r"""^["']?(?:openapi|swagger)["']?\s*:""", re.MULTILINE)
+# A path item's key, e.g. `  /orders/{id}:` or `"/orders/{id}": {`
+PATH_KEY = re.compile(r"""^\s*["']?(/[^"':]*)["']?\s*:""")
+METHOD_KEY = re.compile(
+    rf"""^\s*["']?({"|".join(OPERATION_METHODS)})["']?\s*:""", re.IGNORECASE
+)
+PATH_PARAMETER = re.compile(r"\{[^}/]*\}|(?<=/)[:*][^/]*")
+
+
+@dataclass
+class ApiOperation:
+    """An operation of a spec: a method on one of its paths."""
+
+    method: str  # Upper-case HTTP verb
+    path: str  # As the spec writes it, relative to the base path
+    line_number: int  # 0 when the spec is on one line
+    operation_id: str | None = None
+    summary: str = ""
+    tags: list[str] = field(default_factory=list)
+    deprecated: bool = False
+
+
+@dataclass
+class ApiSpec:
+    title: str
+    version: str  # Of the API, from info.version
+    spec_version: str  # Of OpenAPI or Swagger, e.g. "3.1.0" or "2.0"
+    base_path: str  # From the first server URL or basePath, "" at the root
+    operations: list[ApiOperation] = field(default_factory=list)
+
+    def route(self, operation: ApiOperation) -> str:
+        """The full route of an operation, under the base path."""
+        return f"{self.base_path}{operation.path}"
+
+
+def parse_openapi(content: str, suffix: str) -> ApiSpec | None:
+    """The spec a YAML or JSON file holds, or None when it is not one."""
+    if not SPEC_KEY.search(content) and not (
+        suffix == ".json" and re.search(r'"(?:openapi|swagger)"\s*:', content)
+    ):
+        return None
+    try:
+        data = json.loads(content) if suffix == ".json" else yaml.safe_load(content)
+    except (ValueError, yaml.YAMLError):
+        return None
+    if not isinstance(data, dict) or not ("openapi" in data or "swagger" in data):
+        return None
+
+    info = data.get("info") or {}
+    spec = ApiSpec(
+        title=str(info.get("title") or ""),
+        version=str(info.get("version") or ""),
+        spec_version=str(data.get("openapi") or data.get("swagger")),
+        base_path=_base_path(data),
+    )
+    lines = _operation_lines(content)
+    for path, item in (data.get("paths") or {}).items():
+        if not isinstance(item, dict):
+            continue
+        for method in OPERATION_METHODS:
+            operation = item.get(method)
+            if not isinstance(operation, dict):
+                continue
+            spec.operations.append(
+                ApiOperation(
+                    method=method.upper(),
+                    path=path,
+                    line_number=lines.get((path, method), 0),
+                    operation_id=operation.get("operationId"),
+                    summary=str(operation.get("summary") or ""),
+                    tags=[str(tag) for tag in operation.get("tags") or []],
+                    deprecated=bool(operation.get("deprecated")),
+                )
+            )
+    return spec
+
+
+def normalize_route(route: str) -> str:
+    """A route with its parameters blanked: "/orders/:id/" -> "/orders/{}"."""
+    normalized = PATH_PARAMETER.sub("{}", route.split("?")[0]).rstrip("/")
+    return normalized or "/"
+
+
+def _base_path(data: dict) -> str:
+    if "swagger" in data:
+        base = str(data.get("basePath") or "")
+    else:
+        servers = data.get("servers") or [{}]
+        url = str(servers[0].get("url") or "") if isinstance(servers[0], dict) else ""
+        # Server variables may stand for the scheme and host, or be in the path
+        base = urlparse(url).path if "://" in url else url
+    return base.rstrip("/")
+
+
+def _operation_lines(content: str) -> dict[tuple[str, str], int]:
+    """Line of each (path, method) key, for specs written over several lines."""
+    lines: dict[tuple[str, str], int] = {}
+    path = None
+    for number, line in enumerate(content.split("\n"), start=1):
+        if match := PATH_KEY.match(line):
+            path = match.group(1)
+        elif path and (match := METHOD_KEY.match(line)):
+            lines.setdefault((path, match.group(1).lower()), number)
+    return lines
@@ -0,0 +1,361 @@
+"""Parsing of Protocol Buffers (.proto) files into services, RPCs and messages.
+
+There is no tree-sitter grammar for protobuf among the installed ones, and
+the language is small enough to read with a tokenizer and a recursive
+descent over its declarations: proto2, proto3 and editions files alike.
+Options, enums, extensions and reserved ranges are skipped; what is kept is
+what links a service to its RPCs and those to the messages they exchange.
+"""
+
+import re
+from dataclasses import dataclass, field
+
+TOKEN = re.compile(
+    r"""
+    (?P<space>\s+)
+    | (?P<comment>//[^\n]*|/\*.*?\*/)
+    | (?P<string>"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*')
+    | (?P<word>\.?[A-Za-z_][\w.]*)
+    | (?P<number>[-+]?(?:0[xX][0-9A-Fa-f]+|\d+(?:\.\d*)?(?:[eE][-+]?\d+)?))
+    | (?P<symbol>.)
+    """,
+    re.VERBOSE | re.DOTALL,
+)
+
+# Scalar field types, which name no message
+SCALAR_TYPES = {
+    "double",
+    "float",
+    "int32",
+    "int64",
+    "uint32",
+    "uint64",
+    "sint32",
+    "sint64",
+    "fixed32",
+    "fixed64",
+    "sfixed32",
+    "sfixed64",
+    "bool",
+    "string",
+    "bytes",
+}
+FIELD_LABELS = {"repeated", "optional", "required"}
+
+
+@dataclass
+class ProtoField:
+    name: str
+    type: str  # As written, e.g. "Money", ".shop.v1.Money" or "map<string, Item>"
+    number: int
+    repeated: bool = False
+    # Message or enum type the field holds, the value's for a map
+    type_name: str | None = None
+
+
+@dataclass
+class ProtoMessage:
+    name: str  # Nested messages are named through their parents: "Order.Line"
+    line_number: int
+    fields: list[ProtoField] = field(default_factory=list)
+
+
+@dataclass
+class ProtoRpc:
+    name: str
+    request: str  # As written
+    response: str
+    line_number: int
+    client_streaming: bool = False
+    server_streaming: bool = False
+
+
+@dataclass
+class ProtoService:
+    name: str
+    line_number: int
+    rpcs: list[ProtoRpc] = field(default_factory=list)
+
+
+@dataclass
+class ProtoFile:
+    package: str = ""
+    imports: list[str] = field(default_factory=list)
+    options: dict[str, str] = field(default_factory=dict)  # Top-level only
+    services: list[ProtoService] = field(default_factory=list)
+    messages: list[ProtoMessage] = field(default_factory=list)
+    enums: list[str] = field(default_factory=list)  # Named like messages
+
+    def full_name(self, name: str) -> str:
+        """A declaration's name qualified with the file's package."""
+        return f"{self.package}.{name}" if self.package else name
+
+    @property
+    def go_package(self) -> str | None:
+        """The import path of the generated Go package, without an alias."""
+        option = self.options.get("go_package")
+        return option.split(";")[0] if option else None
+
+
+class ProtoSyntaxError(ValueError):
+    pass
+
+
+def parse_proto(source: str) -> ProtoFile:
+    """Read the declarations of a .proto file."""
+    return _Parser(source).parse()
+
+
+def resolve_type(name: str, scope: str, known: set[str]) -> str | None:
+    """
+    The full name a type reference means from within scope (a package or
+    message's full name), among the known message and enum names: a leading
+    dot is absolute, otherwise the innermost enclosing scope declaring it
+    wins.
+    """
+    if name.startswith("."):
+        return name[1:] if name[1:] in known else None
+    parts = scope.split(".") if scope else []
+    for end in range(len(parts), -1, -1):
+        prefix = ".".join(parts[:end])
+        candidate = f"{prefix}.{name}" if prefix else name
+        if candidate in known:
+            return candidate
+    return None
+
+
+class _Parser:
+    def __init__(self, source: str):
+        self.tokens: list[tuple[str, str, int]] = []
+        line = 1
+        for match in TOKEN.finditer(source):
+            kind = match.lastgroup or "symbol"
+            text = match.group()
+            if kind not in ("space", "comment"):
+                self.tokens.append((kind, text, line))
+            line += text.count("\n")
+        self.position = 0
+        self.file = ProtoFile()
+
+    def parse(self) -> ProtoFile:
+        while not self._at_end():
+            word = self._next()
+            if word == "package":
+                self.file.package = self._next().lstrip(".")
+                self._expect(";")
+            elif word == "import":
+                if self._peek() in ("public", "weak"):
+                    self._next()
+                self.file.imports.append(self._string())
+                self._expect(";")
+            elif word == "option":
+                name, value = self._option()
+                self.file.options[name] = value
+            elif word == "message":
+                self._message("")
+            elif word == "enum":
+                self.file.enums.append(self._next())
+                self._skip_block()
+            elif word == "service":
+                self._service()
+            elif word in ("syntax", "edition"):
+                self._skip_statement()
+            elif word == "extend":
+                self._next()
+                self._skip_block()
+            elif word != ";":
+                raise self._error(f"unexpected '{word}'")
+        return self.file
+
+    def _message(self, parent: str) -> None:
+        line = self._line()
+        name = self._next()
+        full = f"{parent}.{name}" if parent else name
+        message = ProtoMessage(full, line)
+        self.file.messages.append(message)
+        self._expect("{")
+        while (word := self._peek()) != "}":
+            if word is None:
+                raise self._error(f"message {full} is not closed")
+            if word == "message":
+                self._next()
+                self._message(full)
+            elif word == "enum":
+                self._next()
+                self.file.enums.append(f"{full}.{self._next()}")
+                self._skip_block()
+            elif word == "oneof":
+                self._next()
+                self._next()
+                self._expect("{")
+                while self._peek() != "}":
+                    if self._peek() == "option":
+                        self._skip_statement()
+                    else:
+                        self._field(message)
+                self._expect("}")
+            elif word in ("option", "reserved", "extensions"):
+                self._skip_statement()
+            elif word == "extend":
+                self._next()
+                self._next()
+                self._skip_block()
+            elif word == ";":
+                self._next()
+            else:
+                self._field(message)
+        self._expect("}")
+
+    def _field(self, message: ProtoMessage) -> None:
+        repeated = False
+        if self._peek() in FIELD_LABELS:
+            repeated = self._next() == "repeated"
+        if self._peek() == "group":
+            # proto2 groups declare a message inline
+            self._next()
+            name = self._next()
+            self._skip_block()
+            message.fields.append(ProtoField(name.lower(), name, 0, repeated))
+            return
+        if self._peek() == "map":
+            self._next()
+            self._expect("<")
+            key = self._next()
+            self._expect(",")
+            value = self._next()
+            self._expect(">")
+            type_text, type_name = f"map<{key}, {value}>", value
+        else:
+            type_text = type_name = self._next()
+        name = self._next()
+        self._expect("=")
+        number = int(self._next(), 0)
+        self._skip_statement()
+        message.fields.append(
+            ProtoField(
+                name,
+                type_text,
+                number,
+                repeated,
+                None if type_name in SCALAR_TYPES else type_name,
+            )
+        )
+
+    def _service(self) -> None:
+        service = ProtoService(self._next(), self._line())
+        self.file.services.append(service)
+        self._expect("{")
+        while (word := self._peek()) != "}":
+            if word is None:
+                raise self._error(f"service {service.name} is not closed")
+            if word == "rpc":
+                self._next()
+                line = self._line()
+                name = self._next()
+                client_streaming, request = self._rpc_type()
+                self._expect("returns")
+                server_streaming, response = self._rpc_type()
+                service.rpcs.append(
+                    ProtoRpc(
+                        name,
+                        request,
+                        response,
+                        line,
+                        client_streaming,
+                        server_streaming,
+                    )
+                )
+                if self._peek() == "{":
+                    self._skip_block()
+                else:
+                    self._expect(";")
+            else:
+                self._skip_statement()
+        self._expect("}")
+
+    def _rpc_type(self) -> tuple[bool, str]:
+        self._expect("(")
+        streaming = self._peek() == "stream" and self._peek(1) != ")"
+        if streaming:
+            self._next()
+        name = self._next()
+        self._expect(")")
+        return streaming, name
+
+    def _option(self) -> tuple[str, str]:
+        name = ""
+        while (word := self._next()) != "=":
+            name += word
+        value = self._peek() or ""
+        self._skip_statement()
+        if value[:1] in ('"', "'"):
+            value = value[1:-1]
+        return name, value
+
+    def _string(self) -> str:
+        kind, text, _ = self._token()
+        if kind != "string":
+            raise self._error(f"expected a string, not '{text}'")
+        self.position += 1
+        return text[1:-1]
+
+    def _skip_statement(self) -> None:
+        """Skip to the end of a statement, past any block or brackets it has."""
+        self._skip_statement_until(";")
+
+    def _skip_statement_until(self, end: str) -> None:
+        depth = 0
+        while not self._at_end():
+            word = self._next()
+            if word == end and depth == 0:
+                return
+            if word in ("{", "[", "("):
+                depth += 1
+            elif word in ("}", "]", ")"):
+                depth -= 1
+                # A statement may end with a block instead, e.g. an option
+                # with an aggregate value
+                if depth == 0 and word == "}":
+                    return
+        raise self._error(f"expected '{end}'")
+
+    def _skip_block(self) -> None:
+        """Skip past the next brace-delimited block and anything before it."""
+        self._skip_statement_until("{")
+        depth = 1
+        while depth:
+            if self._at_end():
+                raise self._error("block is not closed")
+            word = self._next()
+            depth += {"{": 1, "}": -1}.get(word, 0)
+
+    def _expect(self, text: str) -> None:
+        word = self._next()
+        if word != text:
+            raise self._error(f"expected '{text}', not '{word}'")
+
+    def _token(self) -> tuple[str, str, int]:
+        if self._at_end():
+            raise self._error("unexpected end of file")
+        return self.tokens[self.position]
+
+    def _next(self) -> str:
+        text = self._token()[1]
+        self.position += 1
+        return text
+
+    def _peek(self, ahead: int = 0) -> str | None:
+        index = self.position + ahead
+        return self.tokens[index][1] if index < len(self.tokens) else None
+
+    def _line(self) -> int:
+        return self._token()[2] if not self._at_end() else 0
+
+    def _at_end(self) -> bool:
+        return self.position >= len(self.tokens)
+
+    def _error(self, message: str) -> ProtoSyntaxError:
+        line = 1
+        if self.tokens:
+            line = self.tokens[min(self.position, len(self.tokens) - 1)][2]
+        return ProtoSyntaxError(f"line {line}: {message}")
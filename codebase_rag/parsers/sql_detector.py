@@ -0,0 +1,58 @@
+"""Detection of SQL queries written as string literals in code."""
+
+import re
+from dataclasses import dataclass
+
+from .sql_parser import is_query, query_tables
+
+# String literals of the languages we parse: Python and Java text blocks,
+# backquoted Go raw strings and JavaScript templates, and quoted strings
+STRING = re.compile(
+    r'"""(.*?)"""|\'\'\'(.*?)\'\'\'|`([^`]*)`|"((?:[^"\\\n]|\\.)*)"|'
+    r"'((?:[^'\\\n]|\\.)*)'",
+    re.S,
+)
+# What may stand between literals joined into one string: "a " + "b",
+# Python's adjacent literals and line continuations
+CONCATENATION = re.compile(r"^[\s+\\]*$")
+SQL_KEYWORD = re.compile(
+    r"\b(?:select|insert|update|delete|merge|replace|upsert|truncate)\b", re.I
+)
+
+
+@dataclass
+class SqlQuery:
+    text: str
+    line_number: int  # Of the literal it starts in
+    reads: list[str]  # Tables, named as sql_parser.normalize_name does
+    writes: list[str]
+
+
+class SqlQueryDetector:
+    """Detects SQL queries in string literals and the tables they use."""
+
+    def detect(self, content: str) -> list[SqlQuery]:
+        if not SQL_KEYWORD.search(content):
+            return []
+        queries = []
+        pieces: list[str] = []
+        start = end = 0
+        for match in STRING.finditer(content):
+            if pieces and not CONCATENATION.match(content[end : match.start()]):
+                queries += self._query(content, start, pieces)
+                pieces = []
+            if not pieces:
+                start = match.start()
+            pieces.append(next(group for group in match.groups() if group is not None))
+            end = match.end()
+        if pieces:
+            queries += self._query(content, start, pieces)
+        return queries
+
+    def _query(self, content: str, start: int, pieces: list[str]) -> list[SqlQuery]:
+        text = "".join(pieces)
+        if not is_query(text):
+            return []
+        reads, writes = query_tables(text)
+        line_number = content.count("\n", 0, start) + 1
+        return [SqlQuery(text, line_number, reads, writes)]
@@ -0,0 +1,485 @@
+"""Parsing of SQL schemas and migrations into tables, and of queries into the
+tables they read and write.
+
+Migrations are applied in order to one SqlSchema, so that the tables and
+columns left are those of the database the migrations build: a column added
+by a later migration belongs to its table, a dropped or renamed one is gone.
+Only the "up" half of migration files is read: the `-- +goose Down`,
+`-- +migrate Down` and `-- migrate:down` sections, and .down.sql and Flyway
+undo (U1__...) files, revert the schema instead.
+
+Names are compared as the database folds them: unquoted names lowercased,
+and the default schema (public, dbo, main) left out.
+"""
+
+import bisect
+import re
+from dataclasses import dataclass, field
+from pathlib import PurePosixPath
+
+IDENTIFIER = r'(?:"[^"]+"|`[^`]+`|\[[^\]]+\]|[A-Za-z_][\w$]*)'
+NAME = rf"{IDENTIFIER}(?:\s*\.\s*{IDENTIFIER})*"
+DEFAULT_SCHEMAS = {"public", "dbo", "main"}
+
+DIRECTION = re.compile(r"--\s*(?:\+goose|\+migrate|migrate:)\s*(up|down)\b", re.I)
+DOLLAR_QUOTE = re.compile(r"\$(\w*)\$")
+
+CREATE_TABLE = re.compile(
+    r"^CREATE\s+(?:OR\s+REPLACE\s+)?TABLE\s+"
+    rf"(?:IF\s+NOT\s+EXISTS\s+)?({NAME})\s*\(",
+    re.I,
+)
+CREATE_VIEW = re.compile(
+    r"^CREATE\s+(?:OR\s+REPLACE\s+)?(?:MATERIALIZED\s+)?VIEW\s+"
+    rf"(?:IF\s+NOT\s+EXISTS\s+)?({NAME})(?:\s*\([^)]*\))?\s+AS\s+(.*)$",
+    re.I | re.S,
+)
+ALTER_TABLE = re.compile(
+    rf"^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?({NAME})\s+(.*)$", re.I | re.S
+)
+RENAME_TABLE = re.compile(rf"^RENAME\s+TABLE\s+({NAME})\s+TO\s+({NAME})", re.I)
+DROP_TABLE = re.compile(
+    r"^DROP\s+(?:TABLE|VIEW|MATERIALIZED\s+VIEW)\s+(?:IF\s+EXISTS\s+)?(.*)$",
+    re.I | re.S,
+)
+# Where a column's type ends and its constraints start
+COLUMN_CONSTRAINT = re.compile(
+    r"\s(?:CONSTRAINT|NOT\s+NULL|NULL|PRIMARY\s+KEY|REFERENCES|DEFAULT|UNIQUE|"
+    r"CHECK|GENERATED|COLLATE|AUTO_?INCREMENT|COMMENT|ON\s+UPDATE)\b",
+    re.I,
+)
+REFERENCES = re.compile(rf"REFERENCES\s+({NAME})\s*(?:\(([^)]*)\))?", re.I)
+DEFAULT = re.compile(r"\bDEFAULT\s+('(?:[^']|'')*'|\([^)]*\)|[^\s,]+(?:\(\))?)", re.I)
+TABLE_CONSTRAINT = re.compile(
+    r"^(?:CONSTRAINT\s+\S+\s+)?(PRIMARY\s+KEY|FOREIGN\s+KEY|UNIQUE|CHECK|EXCLUDE|"
+    r"INDEX|KEY|FULLTEXT|SPATIAL|LIKE)\b",
+    re.I,
+)
+KEY_COLUMNS = re.compile(r"\(([^)]*)\)")
+NEW_TYPE = re.compile(r"^(?:SET\s+DATA\s+)?TYPE\s+(.+?)(?:\s+USING\s.*)?$", re.I | re.S)
+
+# A query's statement, as it starts
+QUERY = re.compile(
+    r"^\s*\(?\s*(?=(\w+))(?:SELECT\b.*?\bFROM\b|INSERT\s+(?:IGNORE\s+)?INTO\b|"
+    rf"UPDATE\s+(?:ONLY\s+)?{NAME}(?:\s+(?:AS\s+)?\w+)?\s+SET\b|DELETE\s+FROM\b|"
+    r"WITH\s+(?:RECURSIVE\s+)?\w+(?:\s*\([^)]*\))?\s+AS\s*"
+    r"(?:NOT\s+)?(?:MATERIALIZED\s+)?\(|"
+    r"MERGE\s+INTO\b|(?:REPLACE|UPSERT)\s+INTO\b|TRUNCATE\b)",
+    re.I | re.S,
+)
+WRITES = re.compile(
+    r"\b(?:INSERT\s+(?:IGNORE\s+)?INTO|(?:REPLACE|UPSERT|MERGE)\s+INTO|"
+    rf"UPDATE(?:\s+ONLY)?|DELETE\s+FROM|TRUNCATE(?:\s+TABLE)?)\s+({NAME})",
+    re.I,
+)
+# Names of tables, not of functions such as unnest(...)
+READS = re.compile(rf"\b(FROM|JOIN|USING)\s+({NAME})(?![\w$])(?!\s*\()", re.I)
+# A table after a comma in a FROM list, e.g. FROM orders o, customers c
+MORE_FROM = re.compile(
+    rf"\s*(?:(?:AS\s+)?(\w+))?\s*,\s*({NAME})(?![\w$])(?!\s*\()", re.I
+)
+CTE = re.compile(
+    r"(?:\bWITH(?:\s+RECURSIVE)?|,)\s*(\w+)(?:\s*\([^)]*\))?\s+AS\s*\(", re.I
+)
+SQL_STRING = re.compile(r"'(?:[^']|'')*'")
+SQL_COMMENT = re.compile(r"--[^\n]*|/\*.*?\*/", re.S)
+FROM_LIST_END = {
+    "where",
+    "group",
+    "order",
+    "having",
+    "limit",
+    "offset",
+    "join",
+    "inner",
+    "left",
+    "right",
+    "full",
+    "cross",
+    "natural",
+    "union",
+    "returning",
+    "on",
+    "set",
+    "window",
+    "for",
+}
+
+
+@dataclass
+class SqlStatement:
+    text: str  # Without comments
+    line_number: int
+
+
+@dataclass
+class SqlColumn:
+    name: str
+    data_type: str
+    line_number: int
+    path: str = ""  # Of the migration adding it
+    nullable: bool = True
+    primary_key: bool = False
+    default: str | None = None
+
+
+@dataclass
+class SqlForeignKey:
+    columns: list[str]
+    table: str
+    referenced_columns: list[str]  # Empty for the referenced table's key
+
+
+@dataclass
+class SqlTable:
+    name: str
+    line_number: int
+    path: str = ""  # Of the migration creating it
+    kind: str = "table"  # Or view
+    columns: dict[str, SqlColumn] = field(default_factory=dict)
+    foreign_keys: list[SqlForeignKey] = field(default_factory=list)
+    reads: list[str] = field(default_factory=list)  # Tables a view selects from
+    # Later migrations changing the table: (path, line_number)
+    alterations: list[tuple[str, int]] = field(default_factory=list)
+
+    @property
+    def primary_key(self) -> list[str]:
+        return [c.name for c in self.columns.values() if c.primary_key]
+
+
+@dataclass
+class SqlSchema:
+    tables: dict[str, SqlTable] = field(default_factory=dict)
+
+    def apply(self, statement: SqlStatement, path: str) -> None:
+        """Change the schema as a DDL statement of a migration does."""
+        text = statement.text
+        if match := CREATE_TABLE.match(text):
+            name = normalize_name(match.group(1))
+            table = SqlTable(name, statement.line_number, path)
+            body = _parenthesized(text, match.end() - 1)
+            for offset, definition in _split_top_level(body):
+                line = _line(statement, text, match.end() + offset)
+                self._define(table, definition, line, path)
+            self.tables[name] = table
+        elif match := CREATE_VIEW.match(text):
+            name = normalize_name(match.group(1))
+            view = SqlTable(name, statement.line_number, path, kind="view")
+            view.reads = query_tables(match.group(2))[0]
+            self.tables[name] = view
+        elif match := ALTER_TABLE.match(text):
+            table = self.tables.get(normalize_name(match.group(1)))
+            if table is None:
+                return
+            table.alterations.append((path, statement.line_number))
+            for offset, action in _split_top_level(match.group(2)):
+                line = _line(statement, text, match.start(2) + offset)
+                self._alter(table, action, line, path)
+        elif match := RENAME_TABLE.match(text):
+            self._rename(normalize_name(match.group(1)), normalize_name(match.group(2)))
+        elif match := DROP_TABLE.match(text):
+            names = re.sub(
+                r"\s+(?:CASCADE|RESTRICT)\s*$", "", match.group(1), flags=re.I
+            )
+            for name in names.split(","):
+                self.tables.pop(normalize_name(name), None)
+
+    def _define(self, table: SqlTable, definition: str, line: int, path: str) -> None:
+        """Add a column or table constraint of a CREATE TABLE or ADD."""
+        constraint = TABLE_CONSTRAINT.match(definition)
+        if constraint is None:
+            column = _column(definition, line, path)
+            table.columns[column.name] = column
+            if match := REFERENCES.search(definition):
+                table.foreign_keys.append(
+                    SqlForeignKey(
+                        [column.name],
+                        normalize_name(match.group(1)),
+                        _column_names(match.group(2) or ""),
+                    )
+                )
+            return
+        kind = constraint.group(1).upper().split()[0]
+        columns = KEY_COLUMNS.search(definition, constraint.end())
+        names = _column_names(columns.group(1)) if columns else []
+        if kind == "PRIMARY":
+            for name in names:
+                if name in table.columns:
+                    table.columns[name].primary_key = True
+                    table.columns[name].nullable = False
+        elif kind == "FOREIGN" and (match := REFERENCES.search(definition)):
+            table.foreign_keys.append(
+                SqlForeignKey(
+                    names,
+                    normalize_name(match.group(1)),
+                    _column_names(match.group(2) or ""),
+                )
+            )
+
+    def _alter(self, table: SqlTable, action: str, line: int, path: str) -> None:
+        words = action.split()
+        if not words:
+            return
+        verb = words[0].upper()
+        rest = re.sub(r"^\w+\s+(?:COLUMN\s+)?", "", action, flags=re.I)
+        if verb == "ADD":
+            rest = re.sub(r"^IF\s+NOT\s+EXISTS\s+", "", rest, flags=re.I)
+            self._define(table, rest, line, path)
+        elif verb == "DROP" and not TABLE_CONSTRAINT.match(rest):
+            if re.match(r"CONSTRAINT\b", rest, re.I):
+                return
+            rest = re.sub(r"^IF\s+EXISTS\s+", "", rest, flags=re.I)
+            name = normalize_name(rest.split()[0]) if rest.split() else ""
+            table.columns.pop(name, None)
+            table.foreign_keys = [
+                key for key in table.foreign_keys if name not in key.columns
+            ]
+        elif verb == "RENAME":
+            match = re.match(rf"^(?:TO\s+)?({NAME})(?:\s+TO\s+({NAME}))?", rest, re.I)
+            if not match or re.match(r"CONSTRAINT\b", rest, re.I):
+                return
+            if match.group(2) is None:
+                self._rename(table.name, normalize_name(match.group(1)))
+            elif (old := normalize_name(match.group(1))) in table.columns:
+                new = normalize_name(match.group(2))
+                table.columns[old].name = new
+                table.columns = {
+                    (new if name == old else name): column
+                    for name, column in table.columns.items()
+                }
+                for key in table.foreign_keys:
+                    key.columns = [new if c == old else c for c in key.columns]
+        elif verb in ("ALTER", "MODIFY", "CHANGE"):
+            self._change_column(table, verb, rest, line, path)
+
+    def _change_column(
+        self, table: SqlTable, verb: str, rest: str, line: int, path: str
+    ) -> None:
+        words = rest.split()
+        if not words:
+            return
+        name = normalize_name(words[0])
+        column = table.columns.get(name)
+        if column is None:
+            return
+        if verb in ("MODIFY", "CHANGE"):
+            # MySQL: the whole definition again, after the old name for CHANGE
+            definition = rest if verb == "MODIFY" else rest[len(words[0]) :].strip()
+            changed = _column(definition, column.line_number, column.path)
+            changed.primary_key = changed.primary_key or column.primary_key
+            del table.columns[name]
+            table.columns[changed.name] = changed
+            return
+        change = " ".join(words[1:])
+        if match := NEW_TYPE.match(change):
+            column.data_type = match.group(1)
+        elif re.match(r"^SET\s+NOT\s+NULL\b", change, re.I):
+            column.nullable = False
+        elif re.match(r"^DROP\s+NOT\s+NULL\b", change, re.I):
+            column.nullable = True
+        elif match := DEFAULT.search(change):
+            column.default = match.group(1)
+        elif re.match(r"^DROP\s+DEFAULT\b", change, re.I):
+            column.default = None
+
+    def _rename(self, old: str, new: str) -> None:
+        table = self.tables.pop(old, None)
+        if table is None:
+            return
+        table.name = new
+        self.tables[new] = table
+        for other in self.tables.values():
+            for key in other.foreign_keys:
+                if key.table == old:
+                    key.table = new
+            other.reads = [new if name == old else name for name in other.reads]
+
+
+def is_migration(path: str) -> bool:
+    """Whether a .sql file applies its changes, rather than reverting them."""
+    name = PurePosixPath(path).name.lower()
+    return not name.endswith(".down.sql") and not re.match(r"u\d+(?:[._]\d+)*__", name)
+
+
+def migration_order(path: str) -> list:
+    """Sort key of migrations: by directory, then with numbers compared as such."""
+    return [int(part) if part.isdigit() else part for part in re.split(r"(\d+)", path)]
+
+
+def split_statements(content: str) -> list[SqlStatement]:
+    """
+    The statements of a SQL file, without comments, leaving out those in
+    the down sections of migrations.
+    """
+    masked: list[str] = []
+    boundaries = [0]
+    applying = [True]  # Whether the statement starting at each boundary applies
+    index = 0
+    length = len(content)
+    while index < length:
+        char = content[index]
+        end = index + 1
+        if content.startswith("--", index):
+            end = content.find("\n", index)
+            end = length if end < 0 else end
+            if direction := DIRECTION.match(content, index, end):
+                boundaries.append(index)
+                applying.append(direction.group(1).lower() == "up")
+            masked.append(" " * (end - index))
+        elif content.startswith("/*", index):
+            end = content.find("*/", index + 2)
+            end = length if end < 0 else end + 2
+            masked.append(re.sub(r"[^\n]", " ", content[index:end]))
+        else:
+            if char in "'\"`":
+                end = index + 1
+                while end < length and content[end] != char:
+                    end += 1
+                end = min(end + 1, length)
+            elif char == "$" and (quote := DOLLAR_QUOTE.match(content, index)):
+                close = content.find(quote.group(), quote.end())
+                end = length if close < 0 else close + len(quote.group())
+            masked.append(content[index:end])
+            if char == ";":
+                boundaries.append(index + 1)
+                applying.append(applying[-1])
+        index = end
+    text = "".join(masked)
+    newlines = [i for i, c in enumerate(text) if c == "\n"]
+    statements = []
+    boundaries.append(length)
+    for start, stop, applies in zip(boundaries, boundaries[1:], applying):
+        statement = text[start:stop].rstrip().rstrip(";").strip()
+        if not applies or not statement:
+            continue
+        first = start + len(text[start:stop]) - len(text[start:stop].lstrip())
+        statements.append(
+            SqlStatement(statement, bisect.bisect_left(newlines, first) + 1)
+        )
+    return statements
+
+
+def is_query(text: str) -> bool:
+    """
+    Whether text is a SQL query: SELECT ... FROM, INSERT INTO, ... written
+    as SQL is, with its first keyword in upper or lower case, unlike the
+    sentence "Select a file from the list".
+    """
+    match = QUERY.match(text)
+    keyword = match.group(1) if match else ""
+    return keyword.isupper() or keyword.islower()
+
+
+def query_tables(sql: str) -> tuple[list[str], list[str]]:
+    """The tables a query reads from and writes to, common table expressions aside."""
+    text = SQL_STRING.sub("''", SQL_COMMENT.sub(" ", sql))
+    ctes = {name.lower() for name in CTE.findall(text)}
+    writes = []
+    written_at = set()
+    for match in WRITES.finditer(text):
+        writes.append(normalize_name(match.group(1)))
+        written_at.add(match.start(1))
+    reads = []
+    for match in READS.finditer(text):
+        if match.start(2) in written_at or _in_function_call(text, match.start()):
+            continue  # DELETE FROM writes, EXTRACT(YEAR FROM x) reads no table
+        reads.append(normalize_name(match.group(2)))
+        if match.group(1).upper() != "FROM":
+            continue
+        position = match.end()
+        while more := MORE_FROM.match(text, position):
+            if (more.group(1) or "").lower() in FROM_LIST_END:
+                break
+            reads.append(normalize_name(more.group(2)))
+            position = more.end()
+    return (
+        _tables(name for name in reads if name not in ctes),
+        _tables(name for name in writes if name not in ctes),
+    )
+
+
+def normalize_name(name: str) -> str:
+    """A table or column name as the database compares it: "Public"."Users" -> users."""
+    parts = [
+        part[1:-1] if part[:1] in "\"`[" else part.lower()
+        for part in re.findall(IDENTIFIER, name.strip())
+    ]
+    if len(parts) > 1 and parts[0].lower() in DEFAULT_SCHEMAS:
+        parts = parts[1:]
+    return ".".join(parts)
+
+
+def _in_function_call(text: str, index: int) -> bool:
+    """Whether index is in the arguments of a call, not in a subquery."""
+    depth = 0
+    for position in range(index - 1, -1, -1):
+        if text[position] == ")":
+            depth += 1
+        elif text[position] == "(":
+            if depth == 0:
+                called = re.search(r"\w\s*$", text[:position]) is not None
+                inner = text[position + 1 : index].lstrip()
+                return called and not re.match(r"SELECT\b", inner, re.I)
+            depth -= 1
+    return False
+
+
+def _column(definition: str, line: int, path: str) -> SqlColumn:
+    match = re.match(rf"\s*({IDENTIFIER})\s*(.*)$", definition, re.S)
+    name, rest = (match.group(1), match.group(2)) if match else (definition, "")
+    constraint = COLUMN_CONSTRAINT.search(f" {rest}")
+    data_type = rest[: constraint.start()] if constraint else rest
+    primary_key = re.search(r"\bPRIMARY\s+KEY\b", rest, re.I) is not None
+    default = DEFAULT.search(rest)
+    return SqlColumn(
+        name=normalize_name(name),
+        data_type=" ".join(data_type.split()),
+        line_number=line,
+        path=path,
+        nullable=not primary_key and not re.search(r"\bNOT\s+NULL\b", rest, re.I),
+        primary_key=primary_key,
+        default=default.group(1) if default else None,
+    )
+
+
+def _column_names(text: str) -> list[str]:
+    return [normalize_name(name) for name in text.split(",") if name.strip()]
+
+
+def _tables(names) -> list[str]:
+    return [name for name in dict.fromkeys(names) if name]
+
+
+def _parenthesized(text: str, start: int) -> str:
+    """The text inside the parentheses opening at start."""
+    depth = 0
+    for index in range(start, len(text)):
+        if text[index] == "(":
+            depth += 1
+        elif text[index] == ")":
+            depth -= 1
+            if depth == 0:
+                return text[start + 1 : index]
+    return text[start + 1 :]
+
+
+def _split_top_level(text: str) -> list[tuple[int, str]]:
+    """Comma-separated parts outside parentheses, with their offsets in text."""
+    parts = []
+    depth = 0
+    start = 0
+    for index, char in enumerate(f"{text},"):
+        if char == "(":
+            depth += 1
+        elif char == ")":
+            depth -= 1
+        elif char == "," and depth == 0:
+            part = text[start:index]
+            if part.strip():
+                parts.append((start + len(part) - len(part.lstrip()), part.strip()))
+            start = index + 1
+    return parts
+
+
+def _line(statement: SqlStatement, text: str, offset: int) -> int:
+    return statement.line_number + text.count("\n", 0, offset)
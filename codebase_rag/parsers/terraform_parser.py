@@ -0,0 +1,268 @@
+"""Terraform configuration read from the HCL of its .tf files.
+
+A Terraform module is a directory: the resources, data sources, module
+calls, input variables, locals and outputs declared in its files refer to
+each other by address (aws_sqs_queue.orders, data.aws_iam_policy.x,
+module.db, var.region, local.prefix). Besides those references, what is
+read is the environment a resource gives the code it runs (a Lambda's
+environment variables, a container's env), so that infrastructure can be
+traced to the code reading it.
+"""
+
+import re
+from dataclasses import dataclass, field
+
+from .hcl_parser import HclAttribute, HclBlock, object_entries, parse_hcl, traversals
+
+ENV_NAME = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+# Attributes holding environment variables as an object: Lambda's variables,
+# Cloud Functions' environment_variables, App Service's app_settings, ...
+ENV_OBJECTS = {
+    "variables",
+    "environment",
+    "environment_variables",
+    "app_settings",
+    "env_vars",
+}
+# Blocks with one variable each, as Kubernetes and Cloud Run write them
+ENV_BLOCKS = {"env"}
+# {name = "QUEUE_URL", value = ...} items, as ECS container definitions have
+ENV_ITEM = re.compile(
+    r"""["']?name["']?\s*[=:]\s*"([A-Za-z_][A-Za-z0-9_]*)"\s*,?\s*"""
+    r"""["']?value["']?\s*[=:]\s*([^,}\n]+)"""
+)
+# Roots of traversals that are not addresses of blocks
+NON_ADDRESSES = {"each", "count", "self", "path", "terraform"}
+# Node labels of the kinds of blocks
+TERRAFORM_LABELS = {
+    "resource": "TerraformResource",
+    "data": "TerraformResource",
+    "module": "TerraformModule",
+    "variable": "TerraformVariable",
+    "local": "TerraformVariable",
+    "output": "TerraformVariable",
+}
+# Module arguments that are not input variables of the module
+META_ARGUMENTS = {"source", "version", "providers", "count", "for_each", "depends_on"}
+
+# Attributes naming what a resource provisions, as the cloud knows it
+NAME_ATTRIBUTES = (
+    "name",
+    "bucket",
+    "queue_name",
+    "table_name",
+    "topic_name",
+    "function_name",
+    "identifier",
+    "cluster_identifier",
+    "db_name",
+    "database_name",
+    "repository_name",
+)
+# Words for the kind of thing a resource type provisions, besides the
+# words of the type itself
+KIND_WORDS = {
+    "sqs": {"queue"},
+    "s3": {"bucket"},
+    "dynamodb": {"table"},
+    "sns": {"topic"},
+    "kinesis": {"stream"},
+    "db": {"database"},
+    "rds": {"db", "database"},
+    "sql": {"db", "database"},
+    "elasticache": {"cache", "redis"},
+    "redis": {"cache"},
+    "memcache": {"cache"},
+    "pubsub": {"topic", "subscription"},
+    "storage": {"bucket"},
+    "secretsmanager": {"secret"},
+}
+# Words of variable names for how a resource is reached, not which it is
+ACCESS_WORDS = {
+    "url",
+    "uri",
+    "arn",
+    "name",
+    "id",
+    "host",
+    "hostname",
+    "endpoint",
+    "port",
+    "dsn",
+    "address",
+    "addr",
+    "region",
+}
+
+
+@dataclass
+class EnvAssignment:
+    """An environment variable a resource sets."""
+
+    name: str
+    line_number: int
+    references: list[str]  # Addresses of the blocks its value uses
+
+
+@dataclass
+class TerraformBlock:
+    kind: str  # resource, data, module, variable, local or output
+    address: str  # As Terraform refers to it, e.g. var.region
+    name: str
+    line_number: int
+    type: str = ""  # Of a resource or data source
+    references: list[str] = field(default_factory=list)  # Addresses
+    attributes: dict[str, HclAttribute] = field(default_factory=dict)
+    env: list[EnvAssignment] = field(default_factory=list)
+
+    @property
+    def source(self) -> str | None:
+        """Where a module call's module is, for module blocks."""
+        attribute = self.attributes.get("source")
+        return attribute.literal if attribute else None
+
+    @property
+    def provisioned_names(self) -> list[str]:
+        """
+        The names a resource gives what it provisions, with any
+        interpolations left out: "${var.env}-orders" names "-orders".
+        """
+        names = []
+        for key in NAME_ATTRIBUTES:
+            attribute = self.attributes.get(key)
+            text = attribute.expression.strip() if attribute else ""
+            if len(text) > 2 and text[0] == text[-1] == '"':
+                names.append(re.sub(r"\$\{[^}]*\}", " ", text[1:-1]))
+        return names
+
+
+def parse_terraform(content: str) -> list[TerraformBlock]:
+    """The blocks a .tf file declares, in order."""
+    blocks = []
+    for block in parse_hcl(content).blocks:
+        labels = block.labels
+        if block.type in ("resource", "data") and len(labels) == 2:
+            prefix = "data." if block.type == "data" else ""
+            declared = TerraformBlock(
+                kind=block.type,
+                address=f"{prefix}{labels[0]}.{labels[1]}",
+                name=labels[1],
+                line_number=block.line_number,
+                type=labels[0],
+            )
+            if block.type == "resource":
+                declared.env = _env_assignments(block)
+        elif block.type in ("module", "variable", "output") and len(labels) == 1:
+            root = {"module": "module", "variable": "var", "output": "output"}
+            declared = TerraformBlock(
+                kind=block.type,
+                address=f"{root[block.type]}.{labels[0]}",
+                name=labels[0],
+                line_number=block.line_number,
+            )
+        elif block.type == "locals":
+            for attribute in block.attributes.values():
+                blocks.append(
+                    TerraformBlock(
+                        kind="local",
+                        address=f"local.{attribute.name}",
+                        name=attribute.name,
+                        line_number=attribute.line_number,
+                        references=_addresses(attribute.traversals),
+                        attributes={"value": attribute},
+                    )
+                )
+            continue
+        else:
+            continue  # terraform, provider and moved blocks, ...
+        declared.attributes = block.attributes
+        declared.references = _addresses(
+            traversal
+            for nested in block.walk()
+            for attribute in nested.attributes.values()
+            for traversal in attribute.traversals
+        )
+        blocks.append(declared)
+    return blocks
+
+
+def address(traversal: str) -> str | None:
+    """The address of the block a traversal refers to, None for others."""
+    parts = traversal.split(".")
+    root = parts[0]
+    if root in ("var", "local", "module"):
+        return ".".join(parts[:2])
+    if root == "data":
+        return ".".join(parts[:3]) if len(parts) >= 3 else None
+    if root in NON_ADDRESSES or "_" not in root:
+        return None  # Resource types are prefixed by their provider
+    return ".".join(parts[:2])
+
+
+def provides_env(block: TerraformBlock, env_name: str) -> bool:
+    """
+    Whether a resource looks like what an environment variable names: the
+    variable names its kind, and the rest of its words end the resource's
+    name or a name it provisions, as ORDERS_QUEUE_URL does for
+    aws_sqs_queue.orders or a queue named "${var.env}-orders".
+    """
+    if block.kind != "resource":
+        return False
+    type_words = block.type.split("_")[1:]
+    kinds = set(type_words)
+    for word in type_words:
+        kinds |= KIND_WORDS.get(word, set())
+    words = _words(env_name)
+    if not kinds & set(words):
+        return False
+    rest = [w for w in words if w not in kinds and w not in ACCESS_WORDS]
+    if not rest:
+        return False
+    names = [block.name, *block.provisioned_names]
+    return any(_words(name)[-len(rest) :] == rest for name in names)
+
+
+def _env_assignments(block: HclBlock) -> list[EnvAssignment]:
+    assignments = []
+    for nested in block.walk():
+        if nested.type in ENV_BLOCKS and (name := nested.attributes.get("name")):
+            if name.literal and ENV_NAME.match(name.literal):
+                values = [
+                    traversal
+                    for inner in nested.walk()
+                    for attribute in inner.attributes.values()
+                    if attribute is not name
+                    for traversal in attribute.traversals
+                ]
+                assignments.append(
+                    EnvAssignment(name.literal, name.line_number, _addresses(values))
+                )
+        for attribute in nested.attributes.values():
+            if attribute.name in ENV_OBJECTS:
+                for key, value, offset in object_entries(attribute.expression):
+                    if ENV_NAME.match(key):
+                        assignments.append(
+                            EnvAssignment(
+                                key,
+                                attribute.line_number + offset,
+                                _addresses(traversals(value)),
+                            )
+                        )
+            for match in ENV_ITEM.finditer(attribute.expression):
+                offset = attribute.expression.count("\n", 0, match.start())
+                assignments.append(
+                    EnvAssignment(
+                        match.group(1),
+                        attribute.line_number + offset,
+                        _addresses(traversals(match.group(2))),
+                    )
+                )
+    return assignments
+
+
+def _addresses(traversed) -> list[str]:
+    return list(dict.fromkeys(a for t in traversed if (a := address(t))))
+
+
+def _words(name: str) -> list[str]:
+    return [w for w in re.split(r"[^a-z0-9]+", name.lower()) if w]
@@ -158,6 +158,22 @@ class TestDetector:
             },
         },
         "go": {
+            # Ahead of "testing", which testify files import as well
+            "testify": {
+                "imports": [r"github\.com/stretchr/testify"],
+                "decorators": [],
+                "functions": [r"suite\.Suite", r"func\s+Test"],
+                "assertions": [
+                    r"\b(assert|require)\.\w+\s*\(",
+                    # Suite methods: s.Equal(...), s.Require().NoError(...)
+                    r"\.(Assert|Require)\(\)\.\w+\s*\(",
+                    r"\b(?!bytes\.|reflect\.|strings\.|errors\.)\w+\."
+                    r"(Equal|NotEqual|EqualValues|NoError|Error|ErrorIs|True|False"
+                    r"|Nil|NotNil|Empty|NotEmpty|Len|Contains|Panics)\(\s*[^)\s]",
+                    r"t\.Error",
+                    r"t\.Fatal",
+                ],
+            },
             "testing": {
                 "imports": [
                     r"import\s+.*\"testing\"",
@@ -1,5 +1,7 @@
 """Unified test parser for multiple languages and frameworks."""
 
+import re
+from collections.abc import Iterator
 from dataclasses import dataclass, field
 from typing import Any
 
@@ -9,6 +11,47 @@ from tree_sitter import Node, Parser
 from .bdd_parser import BDDFeature, BDDParser
 from .test_detector import TestDetector, TestFrameworkInfo
 
+GINKGO_IMPORT = re.compile(r"github\.com/onsi/ginkgo")
+GINKGO_CONTAINERS = {"Describe", "Context", "When", "DescribeTable"}
+GINKGO_SPECS = {"It", "Specify", "Entry"}
+# Setup nodes whose code runs as part of every spec in their container
+GINKGO_SETUP = {"BeforeEach", "JustBeforeEach", "BeforeAll"}
+# Prefixes of the focused and pending variants, e.g. FDescribe, XIt
+GINKGO_VARIANTS = {"F": "focused", "P": "pending", "X": "pending"}
+TESTIFY_IMPORT = re.compile(r"github\.com/stretchr/testify")
+# Lifecycle methods of a testify suite; the setup ones run around every test
+TESTIFY_SETUP = {"SetupSuite", "SetupTest", "BeforeTest", "SetupSubTest"}
+TESTIFY_TEARDOWN = {"TearDownSuite", "TearDownTest", "AfterTest", "TearDownSubTest"}
+# The suite passed to suite.Run: new(S), &S{...} or S{...}
+TESTIFY_SUITE_ARG = re.compile(r"^(?:new\(\s*(\w+)\s*\)|&?(\w+)\s*\{)")
+# Parser properties that are also stored on the graph node
+STORED_PROPERTIES = (
+    "ginkgo_type",
+    "lifecycle",  # testify suite lifecycle methods
+    "full_text",
+    "focused",
+    "pending",
+    "test_type",
+    "case_line",  # table row of a Go subtest
+)
+GO_NAMED_TESTS = ("Example", "Benchmark")
+
+
+def go_test_target(name: str) -> list[str]:
+    """
+    Identifiers a Go Example or Benchmark function is named after, following
+    the Example convention: ExampleCalculator_Add gives Calculator and Add,
+    ExampleAdd_second gives Add and a package-level Example gives nothing.
+    """
+    prefix = next((p for p in GO_NAMED_TESTS if name.startswith(p)), None)
+    if prefix is None:
+        return []
+    parts = name[len(prefix) :].split("_")
+    # A trailing lower-case suffix only tells several examples apart
+    while parts and not parts[-1][:1].isupper():
+        parts.pop()
+    return parts
+
 
 @dataclass
 class TestNode:
@@ -24,6 +67,18 @@ class TestNode:
         default_factory=list
     )  # (rel_type, target_type, target_name)
 
+    @property
+    def key(self) -> str:
+        """Name unique within the file, appended to the module's qualified name."""
+        return self.properties.get("full_text", self.name)
+
+    def stored_properties(self) -> dict[str, Any]:
+        return {
+            name: self.properties[name]
+            for name in STORED_PROPERTIES
+            if name in self.properties
+        }
+
 
 class TestParser:
     """Parse test files and extract test-related nodes and relationships."""
@@ -66,7 +121,7 @@ class TestParser:
             self._parse_c_tests(content, framework_info)
         elif framework_info.framework == "cargo":
             self._parse_rust_tests(content, framework_info)
-        elif framework_info.framework in ["testing", "ginkgo"]:
+        elif framework_info.framework in ["testing", "ginkgo", "testify"]:
             self._parse_go_tests(content, framework_info)
 
         # Extract assertions
@@ -355,21 +410,29 @@ class TestParser:
         self, assertions: list[tuple[int, str]]
     ) -> None:
         """Create relationships for assertions to their containing tests."""
+        tests = [
+            node
+            for node in self.nodes
+            if node.node_type in ["test_case", "test_function"]
+        ]
         # Find which test each assertion belongs to
         for line_num, assertion_text in assertions:
-            # Find the test that contains this line
-            for node in self.nodes:
-                if node.node_type in ["test_case", "test_function"]:
-                    if node.start_line <= line_num <= node.end_line:
-                        self.relationships.append(
-                            (
-                                node.name,
-                                "ASSERTS",
-                                "assertion",
-                                assertion_text[:50],
-                            )  # Truncate long assertions
-                        )
-                        break
+            # The innermost test containing this line, so subtests and
+            # nested specs own their assertions rather than the outer test
+            containing = [
+                node for node in tests if node.start_line <= line_num <= node.end_line
+            ]
+            if not containing:
+                continue
+            node = min(containing, key=lambda n: n.end_line - n.start_line)
+            self.relationships.append(
+                (
+                    node.key,
+                    "ASSERTS",
+                    "assertion",
+                    assertion_text[:50],
+                )  # Truncate long assertions
+            )
 
     def _get_node_name(self, node: Node) -> str | None:
         """Extract name from various node types."""
@@ -476,6 +539,9 @@ class TestParser:
         if function_query:
             function_captures = function_query.captures(tree.root_node)
             for func_node in function_captures.get("function", []):
+                if func_node.type == "method_declaration":
+                    # Tests are plain functions; suite methods are handled below
+                    continue
                 func_name = self._get_node_name(func_node)
                 if func_name and (
                     func_name.startswith("Test")
@@ -499,9 +565,91 @@ class TestParser:
                     )
                     self.nodes.append(test_func)
 
-                    # For Ginkgo framework, look for Describe/It blocks
-                    if framework_info.framework == "ginkgo":
-                        self._parse_ginkgo_structure(func_node, framework_info)
+                    body = func_node.child_by_field_name("body")
+                    if body is not None:
+                        self._walk_go_subtests(body, test_func, body, [])
+
+        # Spec files often import "testing" too, so Ginkgo is checked either way
+        if framework_info.framework == "ginkgo" or GINKGO_IMPORT.search(content):
+            self._walk_ginkgo_tree(tree.root_node, None, [])
+        if framework_info.framework == "testify" or TESTIFY_IMPORT.search(content):
+            self._parse_testify_suites(tree.root_node)
+
+    def _parse_testify_suites(self, root: Node) -> None:
+        """
+        Build a test suite for each struct embedding testify's suite.Suite and
+        a test case for each of its TestXxx methods, keyed as `go test` names
+        them (TestRunner/TestMethod) when a TestXxx function runs the suite.
+        The suite's setup methods count as setup of every case.
+        """
+        suites = {
+            name: spec for name, spec in _go_type_specs(root) if _embeds_suite(spec)
+        }
+        if not suites:
+            return
+        runners: dict[str, str] = {}
+        methods: dict[str, list[tuple[str, Node]]] = {name: [] for name in suites}
+        for declaration in root.named_children:
+            name_node = declaration.child_by_field_name("name")
+            if name_node is None:
+                continue
+            name = name_node.text.decode("utf-8")
+            if declaration.type == "function_declaration" and name.startswith("Test"):
+                for suite_name in _suite_runs(declaration):
+                    runners.setdefault(suite_name, name)
+            elif declaration.type == "method_declaration":
+                receiver = _receiver_type(declaration)
+                if receiver in methods:
+                    methods[receiver].append((name, declaration))
+
+        for suite_name, spec in suites.items():
+            lifecycle = sorted(
+                name
+                for name, _ in methods[suite_name]
+                if name in TESTIFY_SETUP | TESTIFY_TEARDOWN
+            )
+            properties: dict[str, Any] = {"framework": "testify"}
+            if lifecycle:
+                properties["lifecycle"] = lifecycle
+            suite = TestNode(
+                node_type="test_suite",
+                name=suite_name,
+                file_path=self.current_file,
+                start_line=spec.start_point[0] + 1,
+                end_line=spec.end_point[0] + 1,
+                properties=properties,
+            )
+            self.nodes.append(suite)
+            setup = [
+                [method.start_point[0] + 1, method.end_point[0] + 1]
+                for name, method in methods[suite_name]
+                if name in TESTIFY_SETUP
+            ]
+            prefix = runners.get(suite_name, suite_name)
+            for name, method in methods[suite_name]:
+                if not name.startswith("Test"):
+                    continue
+                case_properties: dict[str, Any] = {
+                    "framework": "testify",
+                    "test_type": "suite_method",
+                    "full_text": f"{prefix}/{name}",
+                    "parent_suite": suite.key,
+                }
+                if setup:
+                    case_properties["setup_lines"] = setup
+                case = TestNode(
+                    node_type="test_case",
+                    name=name,
+                    file_path=self.current_file,
+                    start_line=method.start_point[0] + 1,
+                    end_line=method.end_point[0] + 1,
+                    properties=case_properties,
+                )
+                self.nodes.append(case)
+                body = method.child_by_field_name("body")
+                if body is not None:
+                    # s.Run("name", func() {...}) subtests
+                    self._walk_go_subtests(body, case, body, setup)
 
     def _extract_java_test_methods(
         self, class_node: Node, class_name: str, framework_info: TestFrameworkInfo
@@ -668,95 +816,438 @@ class TestParser:
         for child in node.named_children:
             self._find_rust_test_functions(child, framework_info, parent_suite)
 
-    def _parse_ginkgo_structure(
-        self, node: Node, framework_info: TestFrameworkInfo
+    def _walk_ginkgo_tree(
+        self,
+        node: Node,
+        parent: TestNode | None,
+        setup: list[list[int]],
+        table_body: list[int] | None = None,
     ) -> None:
-        """Parse Ginkgo BDD-style test structure in Go."""
-        # Look for Describe/Context/It blocks within the test function
-        self._walk_ginkgo_tree(node, framework_info)
+        """
+        Build the nested suites and cases of a Ginkgo spec file, wherever the
+        specs are declared (`var _ = Describe(...)` or inside a TestXxx func).
+
+        Containers become test suites and specs test cases, keyed by their
+        Ginkgo full text: the texts of the enclosing containers and their own,
+        joined by spaces. Cases record the line ranges of the setup blocks
+        around them, and table entries the table body, so the code those run
+        counts towards what the spec tests.
+        """
+        call = self._ginkgo_call(node)
+        if call is None:
+            for child in node.named_children:
+                self._walk_ginkgo_tree(child, parent, setup, table_body)
+            return
+
+        kind, variant, args = call
+        text = self._ginkgo_text(args)
+        if kind in GINKGO_SETUP or text is None:
+            return
+        properties: dict[str, Any] = {
+            "framework": "ginkgo",
+            "ginkgo_type": kind.lower(),
+            "full_text": f"{parent.key} {text}" if parent else text,
+        }
+        if parent:
+            properties["parent_suite"] = parent.key
+        if variant:
+            properties[variant] = True
+        elif parent:
+            # Focus and pending apply to everything inside the container
+            for inherited in ("focused", "pending"):
+                if parent.properties.get(inherited):
+                    properties[inherited] = True
+
+        is_suite = kind in GINKGO_CONTAINERS
+        body = next(
+            (arg for arg in args.named_children if arg.type == "func_literal"), None
+        )
+        if not is_suite:
+            ranges = [*setup, table_body] if table_body else setup
+            if ranges:
+                properties["setup_lines"] = ranges
+        test_node = TestNode(
+            node_type="test_suite" if is_suite else "test_case",
+            name=text,
+            file_path=self.current_file,
+            start_line=node.start_point[0] + 1,
+            end_line=node.end_point[0] + 1,
+            properties=properties,
+        )
+        self.nodes.append(test_node)
+        if not is_suite or body is None:
+            return
+
+        inner_setup = setup + self._ginkgo_setup_ranges(body)
+        self._walk_ginkgo_tree(body, test_node, inner_setup)
+        if kind == "DescribeTable":
+            lines = [body.start_point[0] + 1, body.end_point[0] + 1]
+            for arg in args.named_children:
+                if arg is not body:
+                    self._walk_ginkgo_tree(arg, test_node, inner_setup, lines)
+
+    def _ginkgo_call(self, node: Node) -> tuple[str, str | None, Node] | None:
+        """Node kind, variant and arguments of a Ginkgo container, spec or setup."""
+        if node.type != "call_expression":
+            return None
+        function = node.child_by_field_name("function")
+        if function is not None and function.type == "selector_expression":
+            # ginkgo.Describe without a dot import
+            function = function.child_by_field_name("field")
+        arguments = node.child_by_field_name("arguments")
+        if function is None or arguments is None:
+            return None
+        name = function.text.decode("utf-8")
+        if name in GINKGO_CONTAINERS | GINKGO_SPECS | GINKGO_SETUP:
+            return name, None, arguments
+        if name[:1] in GINKGO_VARIANTS and name[1:] in GINKGO_CONTAINERS | GINKGO_SPECS:
+            return name[1:], GINKGO_VARIANTS[name[0]], arguments
+        return None
 
-    def _walk_ginkgo_tree(
+    @staticmethod
+    def _ginkgo_text(arguments: Node) -> str | None:
+        """The description passed as the first argument, if it is a literal."""
+        first = arguments.named_children[0] if arguments.named_children else None
+        if first is None or first.type not in (
+            "interpreted_string_literal",
+            "raw_string_literal",
+        ):
+            return None
+        return first.text.decode("utf-8")[1:-1]
+
+    def _ginkgo_setup_ranges(self, body: Node) -> list[list[int]]:
+        """Lines of the setup blocks declared directly in a container's body."""
+        ranges = []
+        statements = list(body.named_children)
+        while statements:
+            statement = statements.pop(0)
+            if statement.type in ("block", "statement_list"):
+                statements[:0] = statement.named_children
+            elif statement.type == "expression_statement":
+                call = self._ginkgo_call(statement.named_children[0])
+                if call and call[0] in GINKGO_SETUP:
+                    ranges.append(
+                        [statement.start_point[0] + 1, statement.end_point[0] + 1]
+                    )
+        return ranges
+
+    def _walk_go_subtests(
         self,
         node: Node,
-        framework_info: TestFrameworkInfo,
-        parent_suite: str | None = None,
+        parent: TestNode,
+        body: Node,
+        setup: list[list[int]],
     ) -> None:
-        """Walk Go AST to find Ginkgo test constructs."""
-        if node.type == "call_expression":
-            function_node = node.child_by_field_name("function")
-            if function_node and function_node.type == "identifier":
-                func_name = function_node.text.decode("utf-8")
+        """
+        Emit a test case for each t.Run below a test function or subtest,
+        named like `go test` does: TestSubtract/negative_result. A name read
+        from a table (`t.Run(tt.name, ...)` in `for _, tt := range tests`)
+        gives one case per table row when the table is a literal in the
+        function; other dynamic names give one case named by the expression.
+        The statements before the call in the enclosing bodies count as setup.
+        """
+        if not self._is_subtest_run(node):
+            for child in node.named_children:
+                self._walk_go_subtests(child, parent, body, setup)
+            return
+
+        name_arg, *rest = node.child_by_field_name("arguments").named_children
+        func = next((arg for arg in rest if arg.type == "func_literal"), None)
+        run_setup = setup + self._go_statements_before(body, node)
+        cases = self._subtest_names(name_arg, body)
+        resolved = cases is not None
+        if cases is None:
+            cases = [(name_arg.text.decode("utf-8"), None)]
+        for name, case_line in cases:
+            properties: dict[str, Any] = {
+                "framework": parent.properties.get("framework", "testing"),
+                "test_type": "subtest",
+                "full_text": f"{parent.key}/{name.replace(' ', '_')}",
+                "parent_test": parent.key,
+                "name_resolved": resolved,
+            }
+            if run_setup:
+                properties["setup_lines"] = run_setup
+            if case_line:
+                properties["case_line"] = case_line
+            subtest = TestNode(
+                node_type="test_case",
+                name=name,
+                file_path=self.current_file,
+                start_line=node.start_point[0] + 1,
+                end_line=node.end_point[0] + 1,
+                properties=properties,
+            )
+            self.nodes.append(subtest)
+            inner = func.child_by_field_name("body") if func else None
+            if inner is not None:
+                self._walk_go_subtests(inner, subtest, inner, run_setup)
+
+    @staticmethod
+    def _is_subtest_run(node: Node) -> bool:
+        """A t.Run(name, func(t *testing.T) {...}) call (or b.Run)."""
+        if node.type != "call_expression":
+            return False
+        function = node.child_by_field_name("function")
+        arguments = node.child_by_field_name("arguments")
+        if function is None or function.type != "selector_expression":
+            return False
+        field_node = function.child_by_field_name("field")
+        operand = function.child_by_field_name("operand")
+        return (
+            field_node is not None
+            and field_node.text == b"Run"
+            # suite.Run(t, new(S)) starts a testify suite, not a subtest
+            and (operand is None or operand.text != b"suite")
+            and arguments is not None
+            and len(arguments.named_children) == 2
+        )
 
-                # Check for Describe/Context blocks
-                if func_name in ["Describe", "Context"]:
-                    args = node.child_by_field_name("arguments")
-                    if args and args.named_children:
-                        # Get suite name from first argument
-                        name_arg = args.named_children[0]
-                        if name_arg.type == "interpreted_string_literal":
-                            suite_name = name_arg.text.decode("utf-8").strip('"')
+    def _go_statements_before(self, body: Node, run: Node) -> list[list[int]]:
+        """Lines of the statements of a body ahead of a subtest that run no subtests."""
+        ranges = []
+        for statement in _block_statements(body):
+            if statement.end_point[0] >= run.start_point[0]:
+                break
+            if not self._contains_subtest(statement):
+                ranges.append(
+                    [statement.start_point[0] + 1, statement.end_point[0] + 1]
+                )
+        return ranges
 
-                            test_suite = TestNode(
-                                node_type="test_suite",
-                                name=suite_name,
-                                file_path=self.current_file,
-                                start_line=node.start_point[0] + 1,
-                                end_line=node.end_point[0] + 1,
-                                properties={
-                                    "framework": framework_info.framework,
-                                    "ginkgo_type": func_name.lower(),
-                                },
-                            )
-                            self.nodes.append(test_suite)
+    def _contains_subtest(self, node: Node) -> bool:
+        return self._is_subtest_run(node) or any(
+            self._contains_subtest(child) for child in node.named_children
+        )
 
-                            if parent_suite:
-                                self.relationships.append(
-                                    (
-                                        parent_suite,
-                                        "CONTAINS_SUITE",
-                                        "test_suite",
-                                        suite_name,
-                                    )
-                                )
+    def _subtest_names(
+        self, name_arg: Node, body: Node
+    ) -> list[tuple[str, int | None]] | None:
+        """Subtest names with the line of their table row, or None if dynamic."""
+        if name_arg.type in ("interpreted_string_literal", "raw_string_literal"):
+            return [(name_arg.text.decode("utf-8")[1:-1], None)]
+
+        if name_arg.type == "selector_expression":
+            # t.Run(tt.name, ...): a field of the range value
+            operand = name_arg.child_by_field_name("operand")
+            field_node = name_arg.child_by_field_name("field")
+            if operand is None or field_node is None:
+                return None
+            loop = _range_over(body, operand.text.decode("utf-8"), value=True)
+            table = loop and _table_literal(body, loop)
+            if table is None:
+                return None
+            column = field_node.text.decode("utf-8")
+            return _table_column(table, column)
+
+        if name_arg.type == "identifier":
+            # for name, tt := range cases: the keys of a map literal
+            loop = _range_over(body, name_arg.text.decode("utf-8"), value=False)
+            table = loop and _table_literal(body, loop)
+            if table is None:
+                return None
+            return _table_keys(table)
+        return None
 
-                            # Process callback for nested tests
-                            if len(args.named_children) > 1:
-                                callback = args.named_children[1]
-                                self._walk_ginkgo_tree(
-                                    callback, framework_info, suite_name
-                                )
 
-                # Check for It blocks
-                elif func_name == "It":
-                    args = node.child_by_field_name("arguments")
-                    if args and args.named_children:
-                        # Get test name from first argument
-                        name_arg = args.named_children[0]
-                        if name_arg.type == "interpreted_string_literal":
-                            test_name = name_arg.text.decode("utf-8").strip('"')
+def _unwrap(node: Node) -> Node:
+    """The expression inside a literal_element (newer tree-sitter-go grammars)."""
+    while node.type == "literal_element" and node.named_children:
+        node = node.named_children[0]
+    return node
 
-                            test_case = TestNode(
-                                node_type="test_case",
-                                name=test_name,
-                                file_path=self.current_file,
-                                start_line=node.start_point[0] + 1,
-                                end_line=node.end_point[0] + 1,
-                                properties={
-                                    "framework": framework_info.framework,
-                                },
-                            )
-                            self.nodes.append(test_case)
 
-                            if parent_suite:
-                                self.relationships.append(
-                                    (
-                                        parent_suite,
-                                        "CONTAINS_TEST",
-                                        "test_case",
-                                        test_name,
-                                    )
-                                )
+def _string_value(node: Node) -> str | None:
+    node = _unwrap(node)
+    if node.type in ("interpreted_string_literal", "raw_string_literal"):
+        return node.text.decode("utf-8")[1:-1]
+    return None
 
-        # Recurse to children
-        for child in node.named_children:
-            self._walk_ginkgo_tree(child, framework_info, parent_suite)
+
+def _block_statements(body: Node) -> list[Node]:
+    statements = []
+    for child in body.named_children:
+        if child.type == "statement_list":
+            statements.extend(child.named_children)
+        else:
+            statements.append(child)
+    return statements
+
+
+def _descendants(node: Node) -> Iterator[Node]:
+    for child in node.named_children:
+        yield child
+        yield from _descendants(child)
+
+
+def _go_type_specs(root: Node) -> Iterator[tuple[str, Node]]:
+    for declaration in root.named_children:
+        if declaration.type != "type_declaration":
+            continue
+        for spec in declaration.named_children:
+            name = spec.child_by_field_name("name")
+            if spec.type == "type_spec" and name is not None:
+                yield name.text.decode("utf-8"), spec
+
+
+def _embeds_suite(spec: Node) -> bool:
+    """Whether a struct type embeds testify's suite.Suite."""
+    struct = spec.child_by_field_name("type")
+    if struct is None or struct.type != "struct_type":
+        return False
+    return any(
+        field_node.type == "field_declaration"
+        and field_node.child_by_field_name("name") is None
+        and field_node.text.decode("utf-8").lstrip("*").strip() == "suite.Suite"
+        for field_node in _descendants(struct)
+    )
+
+
+def _receiver_type(method: Node) -> str | None:
+    """The receiver's type name of a method: S for (s *S) and (s S)."""
+    receiver = method.child_by_field_name("receiver")
+    if receiver is None or not receiver.named_children:
+        return None
+    type_node = receiver.named_children[0].child_by_field_name("type")
+    if type_node is None:
+        return None
+    return type_node.text.decode("utf-8").lstrip("*").strip()
+
+
+def _suite_runs(function: Node) -> Iterator[str]:
+    """Suite types a test function runs with suite.Run(t, new(S))."""
+    body = function.child_by_field_name("body")
+    if body is None:
+        return
+    for node in _descendants(body):
+        if node.type != "call_expression":
+            continue
+        callee = node.child_by_field_name("function")
+        arguments = node.child_by_field_name("arguments")
+        if (
+            callee is None
+            or callee.text != b"suite.Run"
+            or arguments is None
+            or len(arguments.named_children) != 2
+        ):
+            continue
+        argument = arguments.named_children[1].text.decode("utf-8")
+        match = TESTIFY_SUITE_ARG.match(argument)
+        if match:
+            yield match.group(1) or match.group(2)
+
+
+def _range_over(body: Node, variable: str, value: bool) -> Node | None:
+    """
+    The range clause binding a variable: as the value (`_, tt := range`) or,
+    with value False, as the key of a map range (`name, tt := range`).
+    """
+    for node in _descendants(body):
+        if node.type != "range_clause":
+            continue
+        left = node.child_by_field_name("left")
+        if left is None:
+            continue
+        names = [name.text.decode("utf-8") for name in left.named_children]
+        if value and names[1:2] == [variable]:
+            return node
+        if not value and names[:1] == [variable] and len(names) == 2:
+            return node
+    return None
+
+
+def _table_literal(body: Node, loop: Node) -> Node | None:
+    """The composite literal a range clause iterates over, inline or assigned."""
+    ranged = loop.child_by_field_name("right")
+    if ranged is None or ranged.type == "composite_literal":
+        return ranged
+    if ranged.type != "identifier":
+        return None
+    table = ranged.text.decode("utf-8")
+    for node in _descendants(body):
+        if node.start_point[0] > loop.start_point[0]:
+            break
+        if node.type == "short_var_declaration":
+            left = node.child_by_field_name("left")
+            names = [name.text.decode("utf-8") for name in left.named_children]
+            right = node.child_by_field_name("right")
+        elif node.type == "var_spec":
+            names = [
+                name.text.decode("utf-8")
+                for name in node.children_by_field_name("name")
+            ]
+            right = node.child_by_field_name("value")
+        else:
+            continue
+        values = right.named_children if right is not None else []
+        if table in names and names.index(table) < len(values):
+            value = values[names.index(table)]
+            return value if value.type == "composite_literal" else None
+    return None
+
+
+def _struct_fields(literal: Node) -> list[str]:
+    """Field names, in order, of a []struct{...} or map[K]struct{...} literal."""
+    literal_type = literal.child_by_field_name("type")
+    if literal_type is None:
+        return []
+    struct = next(
+        (node for node in _descendants(literal_type) if node.type == "struct_type"),
+        None,
+    )
+    if struct is None or not struct.named_children:
+        return []
+    return [
+        name.text.decode("utf-8")
+        for declaration in struct.named_children[0].named_children
+        if declaration.type == "field_declaration"
+        for name in declaration.children_by_field_name("name")
+    ]
+
+
+def _rows(literal: Node) -> list[Node]:
+    body = literal.child_by_field_name("body")
+    if body is None:
+        return []
+    return [row for row in body.named_children if row.type != "comment"]
+
+
+def _table_column(literal: Node, column: str) -> list[tuple[str, int | None]] | None:
+    fields = _struct_fields(literal)
+    names = []
+    for row in _rows(literal):
+        row = _unwrap(row)
+        if row.type == "keyed_element":
+            # Map tables: the struct literal is the value
+            row = _unwrap(row.named_children[-1])
+        if row.type != "literal_value":
+            return None
+        value = None
+        elements = [item for item in row.named_children if item.type != "comment"]
+        for position, element in enumerate(elements):
+            element = _unwrap(element)
+            if element.type == "keyed_element":
+                key, *_, item = element.named_children
+                if _unwrap(key).text.decode("utf-8") == column:
+                    value = _string_value(item)
+                    break
+            elif position < len(fields) and fields[position] == column:
+                value = _string_value(element)
+                break
+        if value is None:
+            return None
+        names.append((value, row.start_point[0] + 1))
+    return names or None
+
+
+def _table_keys(literal: Node) -> list[tuple[str, int | None]] | None:
+    names = []
+    for row in _rows(literal):
+        row = _unwrap(row)
+        key = None
+        if row.type == "keyed_element":
+            key = _string_value(row.named_children[0])
+        if key is None:
+            return None
+        names.append((key, row.start_point[0] + 1))
+    return names or None
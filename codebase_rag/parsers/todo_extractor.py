@@ -0,0 +1,62 @@
+"""Extraction of TODO/FIXME/HACK markers from source comments."""
+
+import re
+from dataclasses import dataclass
+
+from tree_sitter import Node
+
+TODO_KINDS = ("TODO", "FIXME", "HACK", "XXX")
+
+# "TODO: text", "FIXME(alice): text", "HACK - text"
+TODO_PATTERN = re.compile(
+    r"\b(" + "|".join(TODO_KINDS) + r")\b(?:\(([^)]*)\))?[:\s-]*(.*)"
+)
+
+# Leading/trailing comment syntax stripped from each line of a comment
+COMMENT_DECORATION = re.compile(r"^\s*(?:#+|//+|/\*+|\*+|--|;+)?\s*|\s*\*+/\s*$")
+
+
+@dataclass
+class TodoComment:
+    """A single TODO-style marker found in a comment."""
+
+    kind: str  # TODO, FIXME, HACK or XXX
+    text: str
+    line_number: int
+    tag: str = ""  # Parenthesized owner or ticket, e.g. TODO(alice)
+
+
+class TodoExtractor:
+    """Finds TODO-style markers in the comment nodes of a syntax tree."""
+
+    def extract(self, root_node: Node) -> list[TodoComment]:
+        """Return TODO markers in source order."""
+        todos = []
+        stack = [root_node]
+        while stack:
+            node = stack.pop()
+            # comment, line_comment, block_comment, ...
+            if node.type.endswith("comment"):
+                todos.extend(self._extract_from_comment(node))
+                continue
+            stack.extend(node.children)
+        return sorted(todos, key=lambda t: t.line_number)
+
+    def _extract_from_comment(self, node: Node) -> list[TodoComment]:
+        if node.text is None:
+            return []
+        todos = []
+        lines = node.text.decode("utf-8", errors="replace").split("\n")
+        for offset, line in enumerate(lines):
+            match = TODO_PATTERN.search(COMMENT_DECORATION.sub("", line))
+            if not match:
+                continue
+            todos.append(
+                TodoComment(
+                    kind=match.group(1),
+                    text=match.group(3).strip(),
+                    line_number=node.start_point[0] + offset + 1,
+                    tag=(match.group(2) or "").strip(),
+                )
+            )
+        return todos
@@ -0,0 +1,618 @@
+"""GitHub Actions workflows and Makefiles, read for the commands they run.
+
+A workflow is read as its jobs and their steps: the action a step uses, or
+the shell script it runs. A Makefile is read as its targets, with their
+prerequisites and the commands of their recipes. What ties either to the
+code is what those commands invoke: Makefile targets, scripts of the
+repository and the Go packages that go and its linters are run on.
+"""
+
+import re
+import shlex
+from collections.abc import Callable
+from dataclasses import dataclass, field, replace
+from pathlib import PurePosixPath
+from typing import Any
+
+import yaml
+
+WORKFLOW_FILE = re.compile(r"^\.github/workflows/[^/]+\.ya?ml$")
+# In the order make looks for them
+MAKEFILE_NAMES = ("GNUmakefile", "makefile", "Makefile")
+
+ASSIGNMENT = re.compile(r"^[A-Za-z_]\w*=")
+# Words before a command that run it: sudo make, time go test, ...
+PREFIXES = {"sudo", "time", "env", "exec", "nohup", "command"}
+# Shell keywords a command may follow: if make check; then ...
+KEYWORDS = {"if", "then", "else", "elif", "do", "while", "until", "!", "{", "("}
+SEPARATORS = {";", "&&", "||", "|", "&", ";;"}
+SHELLS = {"bash", "sh", "zsh", "source", "."}
+MAKE_COMMANDS = {"make", "gmake", "$(MAKE)", "${MAKE}"}
+GO_COMMANDS = {"build", "test", "vet", "run", "install", "generate", "list", "fmt"}
+GO_TOOLS = {"golangci-lint", "staticcheck", "govulncheck"}
+# Flags of go and its linters that take the next word as their value
+VALUE_FLAGS = {
+    "-o",
+    "-run",
+    "-skip",
+    "-tags",
+    "-timeout",
+    "-bench",
+    "-benchtime",
+    "-count",
+    "-cpu",
+    "-p",
+    "-parallel",
+    "-coverprofile",
+    "-covermode",
+    "-coverpkg",
+    "-ldflags",
+    "-gcflags",
+    "-mod",
+    "-modfile",
+    "-exec",
+    "-c",
+    "--config",
+    "--timeout",
+    "--out-format",
+    "-E",
+    "--enable",
+    "-D",
+    "--disable",
+}
+MAKE_VALUE_FLAGS = {"-C", "--directory", "-f", "--file", "--makefile", "-o", "-W", "-I"}
+
+MAKE_ASSIGNMENT = re.compile(
+    r"^(?:(?:export|override)\s+)*([A-Za-z_.][\w.-]*)\s*"
+    r"(:::=|::?=|\?=|\+=|!=|=)\s*(.*)$"
+)
+MAKE_RULE = re.compile(r"^([^:=#\t][^:=#]*?)\s*::?(?!=)\s*(.*)$")
+MAKE_DIRECTIVES = (
+    "ifeq",
+    "ifneq",
+    "ifdef",
+    "ifndef",
+    "else",
+    "endif",
+    "include",
+    "-include",
+    "sinclude",
+    "export",
+    "unexport",
+    "vpath",
+)
+# $(NAME), or a substitution reference $(NAME:.c=.o)
+MAKE_VARIABLE = re.compile(r"\$[({]([A-Za-z_.][\w.-]*)(?::([^=)}]*)=([^)}]*))?[)}]")
+# $@, $<, $^, ... of a recipe, also written $(@) or ${@}
+AUTOMATIC = re.compile(r"(?<!\$)\$(?:([@<^+?])|[({]([@<^+?])[)}])")
+# Variables make defines itself
+MAKE_DEFAULTS = {"MAKE": "make", "CC": "cc", "CXX": "g++", "AR": "ar", "AS": "as"}
+# Rules make has built in, for prerequisites no rule of a Makefile builds
+BUILTIN_RULES = [
+    ("%.o", ["%.c"]),
+    ("%.o", ["%.cc"]),
+    ("%.o", ["%.cpp"]),
+    ("%.o", ["%.S"]),
+    ("%", ["%.c"]),
+]
+COMPILERS = {"cc", "gcc", "clang", "c++", "g++", "clang++", "as", "javac", "rustc"}
+SOURCE_SUFFIXES = {".c", ".cc", ".cpp", ".cxx", ".S", ".s", ".java", ".rs"}
+
+
+@dataclass
+class Invocation:
+    """Something of the repository a shell command runs."""
+
+    kind: str  # "make", "script" or "go"
+    # Make target ("" for the default goal), script path or package pattern
+    target: str
+    line_number: int  # Within the script, from 1
+    command: str  # As run, e.g. "make", "bash" or "go test"
+    directory: str = "."  # Where make runs, from make -C
+    makefile: str | None = None  # From make -f
+
+
+@dataclass
+class WorkflowStep:
+    name: str
+    line_number: int
+    uses: str | None = None  # An action, e.g. actions/checkout@v4
+    run: str | None = None
+    run_line_number: int = 0  # Of the first line of run's script
+    working_directory: str | None = None
+    named: bool = True  # False when the name is the first line of run
+
+
+@dataclass
+class WorkflowJob:
+    id: str
+    name: str
+    line_number: int
+    runs_on: str = ""
+    needs: list[str] = field(default_factory=list)
+    uses: str | None = None  # A reusable workflow the job calls
+    working_directory: str | None = None  # Default of its run steps
+    steps: list[WorkflowStep] = field(default_factory=list)
+
+
+@dataclass
+class Workflow:
+    name: str
+    triggers: list[str]
+    jobs: list[WorkflowJob] = field(default_factory=list)
+
+
+@dataclass
+class MakeTarget:
+    name: str
+    line_number: int
+    prerequisites: list[str] = field(default_factory=list)
+    # (line, command) of the recipe, with the Makefile's variables expanded
+    commands: list[tuple[int, str]] = field(default_factory=list)
+    is_phony: bool = False
+
+    @property
+    def outputs(self) -> list[str]:
+        """
+        Files the target builds: itself unless it is phony, and what its
+        recipe writes with -o, as go build and compilers are told to.
+        """
+        outputs = [] if self.is_phony else [self.name]
+        for _, command in self.commands:
+            words = command.split()
+            outputs += [
+                value
+                for flag, value in zip(words, words[1:])
+                if flag == "-o" and value not in outputs
+            ]
+        return outputs
+
+
+@dataclass
+class Makefile:
+    targets: dict[str, MakeTarget] = field(default_factory=dict)
+    default_goal: str | None = None
+    # (target pattern, prerequisite patterns) of rules like %.o: %.c
+    pattern_rules: list[tuple[str, list[str]]] = field(default_factory=list)
+
+    def sources(
+        self, target: MakeTarget, is_file: Callable[[str], bool]
+    ) -> list[tuple[str, str]]:
+        """
+        (source file, what it is built into) for the files a target is built
+        from: prerequisites that are files, with "" for what they are built
+        into, the files of prerequisites no rule names that pattern rules or
+        make's own rules build from (main.c for main.o), and the sources its
+        recipe gives compilers.
+        """
+        sources: list[tuple[str, str]] = []
+        for prerequisite in target.prerequisites:
+            if is_file(prerequisite):
+                sources.append((prerequisite, ""))
+            elif prerequisite not in self.targets:
+                sources += [
+                    (source, prerequisite)
+                    for source in self._implicit_sources(prerequisite, is_file)
+                ]
+        for _, command in target.commands:
+            words = command.split()
+            if not words or PurePosixPath(words[0]).name not in COMPILERS:
+                continue
+            sources += [
+                (word, "")
+                for word in words[1:]
+                if PurePosixPath(word).suffix in SOURCE_SUFFIXES and is_file(word)
+            ]
+        return list(dict.fromkeys(sources))
+
+    def _implicit_sources(
+        self, name: str, is_file: Callable[[str], bool]
+    ) -> list[str]:
+        for pattern, prerequisites in self.pattern_rules + BUILTIN_RULES:
+            prefix, _, suffix = pattern.partition("%")
+            if not (name.startswith(prefix) and name.endswith(suffix)):
+                continue
+            stem = name[len(prefix) : len(name) - len(suffix)]
+            if not stem:
+                continue
+            files = [p.replace("%", stem, 1) for p in prerequisites]
+            # The first rule whose prerequisites all exist is the one used
+            if files and all(is_file(f) for f in files):
+                return files
+        return []
+
+
+class WorkflowSyntaxError(ValueError):
+    pass
+
+
+def is_workflow_file(relative_path: str) -> bool:
+    """A workflow under .github/workflows of the repository."""
+    return bool(WORKFLOW_FILE.match(relative_path))
+
+
+def is_makefile(name: str) -> bool:
+    return name in MAKEFILE_NAMES or name.endswith(".mk")
+
+
+def parse_workflow(content: str) -> Workflow:
+    """Read the jobs and steps of a GitHub Actions workflow."""
+    try:
+        data = yaml.safe_load(content)
+    except yaml.YAMLError as e:
+        raise WorkflowSyntaxError(str(e)) from e
+    if not isinstance(data, dict):
+        raise WorkflowSyntaxError("a workflow is a mapping")
+    # YAML 1.1 reads the on key as true
+    triggers = data.get("on", data.get(True))
+    workflow = Workflow(name=str(data.get("name") or ""), triggers=_names(triggers))
+    jobs = data.get("jobs")
+    if not isinstance(jobs, dict):
+        return workflow
+    default_directory = _working_directory(data)
+    lines = _job_lines(content.split("\n"))
+    for job_id, definition in jobs.items():
+        definition = definition if isinstance(definition, dict) else {}
+        job_line, step_lines = lines.get(str(job_id), (0, []))
+        runs_on = definition.get("runs-on") or ""
+        if isinstance(runs_on, list):
+            runs_on = ", ".join(map(str, runs_on))
+        job = WorkflowJob(
+            id=str(job_id),
+            name=str(definition.get("name") or job_id),
+            line_number=job_line,
+            runs_on=str(runs_on),
+            needs=_names(definition.get("needs")),
+            uses=definition.get("uses"),
+            working_directory=_working_directory(definition) or default_directory,
+        )
+        for index, step in enumerate(definition.get("steps") or []):
+            if not isinstance(step, dict):
+                continue
+            step_line, run_line = (
+                step_lines[index] if index < len(step_lines) else (0, 0)
+            )
+            run = step.get("run")
+            name = step.get("name") or step.get("uses") or ""
+            if not name and run:
+                name = str(run).strip().split("\n")[0]
+            job.steps.append(
+                WorkflowStep(
+                    name=str(name),
+                    line_number=step_line,
+                    uses=step.get("uses"),
+                    run=str(run) if run is not None else None,
+                    run_line_number=run_line,
+                    working_directory=step.get("working-directory"),
+                    named=bool(step.get("name") or step.get("uses")),
+                )
+            )
+        workflow.jobs.append(job)
+    return workflow
+
+
+def parse_makefile(content: str) -> Makefile:
+    """Read the targets of a Makefile, with its variables expanded in them."""
+    makefile = Makefile()
+    variables: dict[str, str] = dict(MAKE_DEFAULTS)
+    phony: list[str] = []
+    current: list[MakeTarget] = []
+    in_define = False
+    for line_number, line in _logical_lines(content):
+        stripped = line.strip()
+        if in_define:
+            in_define = stripped != "endef"
+            continue
+        if line.startswith("\t"):
+            if current and stripped and not stripped.startswith("#"):
+                for target in current:
+                    target.commands.append((line_number, stripped.lstrip("@-+ ")))
+            continue
+        if not stripped or stripped.startswith("#"):
+            continue
+        if stripped.startswith("define "):
+            in_define = True
+            continue
+        if assignment := MAKE_ASSIGNMENT.match(stripped):
+            name, operator, value = assignment.groups()
+            if operator == "+=" and name in variables:
+                variables[name] += f" {value}"
+            elif operator != "?=" or name not in variables:
+                variables[name] = value
+            continue
+        if stripped.split()[0] in MAKE_DIRECTIVES:
+            continue
+        rule = MAKE_RULE.match(stripped)
+        if not rule:
+            current = []
+            continue
+        names, rest = rule.groups()
+        prerequisites, _, recipe = rest.partition(";")
+        current = []
+        if names == ".PHONY":
+            phony += prerequisites.split()
+            continue
+        for name in names.split():
+            if "%" in name and ":" not in prerequisites:
+                makefile.pattern_rules.append(
+                    (name, prerequisites.replace("|", " ").split())
+                )
+                continue
+            if name.startswith(".") or "%" in name or "$" in name:
+                continue  # Special targets, static pattern rules, computed names
+            target = makefile.targets.setdefault(name, MakeTarget(name, line_number))
+            target.prerequisites += prerequisites.replace("|", " ").split()
+            if recipe.strip():
+                target.commands.append((line_number, recipe.strip()))
+            current.append(target)
+            if makefile.default_goal is None:
+                makefile.default_goal = name
+    if goal := variables.get(".DEFAULT_GOAL", "").strip():
+        makefile.default_goal = goal
+    phony_names = {word for name in phony for word in _expand(name, variables).split()}
+    makefile.pattern_rules = [
+        (_expand(pattern, variables), _expand(" ".join(patterns), variables).split())
+        for pattern, patterns in makefile.pattern_rules
+    ]
+    for target in makefile.targets.values():
+        target.is_phony = target.name in phony_names
+        target.prerequisites = [
+            word
+            for prerequisite in target.prerequisites
+            for word in _expand(prerequisite, variables).split()
+        ]
+        target.commands = [
+            (line, _expand(command, variables, target))
+            for line, command in target.commands
+        ]
+    return makefile
+
+
+def invocations(script: str) -> list[Invocation]:
+    """The Makefile targets, scripts and Go packages a shell script runs."""
+    found = []
+    for line_number, words in _commands(script):
+        found += _invocations(words, line_number)
+    return found
+
+
+def _invocations(words: list[str], line_number: int) -> list[Invocation]:
+    while words and (
+        words[0] in PREFIXES or words[0] in KEYWORDS or ASSIGNMENT.match(words[0])
+    ):
+        words = words[1:]
+    if not words:
+        return []
+    program = PurePosixPath(words[0]).name
+    if words[0] in MAKE_COMMANDS or program in MAKE_COMMANDS:
+        return _make(words[1:], line_number)
+    if program in SHELLS and len(words) > 1:
+        if words[1] == "-c" and len(words) > 2:
+            return [replace(i, line_number=line_number) for i in invocations(words[2])]
+        arguments = [w for w in words[1:] if not w.startswith("-")]
+        if arguments and _is_path(arguments[0]):
+            return [Invocation("script", arguments[0], line_number, program)]
+        return []
+    if program == "go" and len(words) > 1 and words[1] in GO_COMMANDS:
+        return _go(f"go {words[1]}", words[2:], line_number, ["."])
+    if program in GO_TOOLS:
+        if program == "golangci-lint":
+            if len(words) < 2 or words[1] != "run":
+                return []
+            return _go("golangci-lint run", words[2:], line_number, ["./..."])
+        return _go(program, words[1:], line_number, [])
+    if "/" in words[0] and _is_path(words[0]):
+        return [Invocation("script", words[0], line_number, words[0])]
+    return []
+
+
+def _make(arguments: list[str], line_number: int) -> list[Invocation]:
+    directory, makefile, targets = ".", None, []
+    index = 0
+    while index < len(arguments):
+        word = arguments[index]
+        value = None
+        if word in MAKE_VALUE_FLAGS:
+            index += 1
+            value = arguments[index] if index < len(arguments) else None
+        elif word == "-j" and index + 1 < len(arguments):
+            index += arguments[index + 1].isdigit()
+        elif word.startswith("--directory=") or word.startswith("--file="):
+            word, _, value = word.partition("=")
+        elif word[:2] in ("-C", "-f") and len(word) > 2:
+            word, value = word[:2], word[2:]
+        elif not word.startswith("-") and "=" not in word:
+            targets.append(word)
+        if value is not None and word in ("-C", "--directory"):
+            directory = value
+        elif value is not None and word in ("-f", "--file", "--makefile"):
+            makefile = value
+        index += 1
+    return [
+        Invocation("make", target, line_number, "make", directory, makefile)
+        for target in targets or [""]
+    ]
+
+
+def _go(
+    command: str, arguments: list[str], line_number: int, default: list[str]
+) -> list[Invocation]:
+    patterns = []
+    others = False
+    skip = False
+    for word in arguments:
+        if skip:
+            skip = False
+        elif word == "--":
+            break
+        elif word in VALUE_FLAGS:
+            skip = True
+        elif word.startswith("-"):
+            continue
+        elif word == "." or word.startswith(("./", "../")) or word.endswith(".go"):
+            patterns.append(word)
+            if command == "go run":
+                break  # What follows are arguments of the program
+        else:
+            others = True  # Import paths, or arguments of the program
+            if command == "go run":
+                break
+    if not patterns and not others:
+        patterns = default
+    return [Invocation("go", pattern, line_number, command) for pattern in patterns]
+
+
+def _commands(script: str) -> list[tuple[int, list[str]]]:
+    """(line, words) of the simple commands of a script."""
+    commands = []
+    pending = ""
+    start = 0
+    for number, line in enumerate(script.split("\n"), start=1):
+        if not pending:
+            start = number
+        if line.rstrip().endswith("\\"):
+            pending += line.rstrip()[:-1] + " "
+            continue
+        text = pending + line
+        pending = ""
+        lexer = shlex.shlex(text, posix=True, punctuation_chars=";&|")
+        lexer.whitespace_split = True
+        words: list[str] = []
+        try:
+            for token in lexer:
+                if token in SEPARATORS:
+                    commands.append((start, words))
+                    words = []
+                else:
+                    words.append(token)
+        except ValueError:
+            continue  # Unbalanced quotes, as a heredoc's text may have
+        commands.append((start, words))
+    return [(line, words) for line, words in commands if words]
+
+
+def _is_path(word: str) -> bool:
+    """A relative path to a file of the repository, not a URL or variable."""
+    return not (
+        word.startswith(("/", "~", "-")) or "://" in word or "$" in word or "=" in word
+    )
+
+
+def _expand(
+    text: str, variables: dict[str, str], target: MakeTarget | None = None
+) -> str:
+    """Text with the variables of a Makefile, and a recipe's automatic ones."""
+    for _ in range(5):  # Variables whose values use variables
+        expanded = MAKE_VARIABLE.sub(lambda m: _variable(m, variables), text)
+        if expanded == text:
+            break
+        text = expanded
+    if target is not None:
+        prerequisites = " ".join(dict.fromkeys(target.prerequisites))
+        automatic = {
+            "@": target.name,
+            "<": target.prerequisites[0] if target.prerequisites else "",
+            "^": prerequisites,
+            "+": " ".join(target.prerequisites),
+            "?": prerequisites,
+        }
+        text = AUTOMATIC.sub(lambda m: automatic[m.group(1) or m.group(2)], text)
+    return text.replace("$$", "$")
+
+
+def _variable(match: re.Match, variables: dict[str, str]) -> str:
+    if match.group(1) not in variables:
+        return match.group(0)
+    value = variables[match.group(1)]
+    if match.group(2) is None:
+        return value
+    # A substitution reference: $(SRCS:.c=.o), or $(SRCS:%.c=build/%.o)
+    old, new = match.group(2), match.group(3)
+    if "%" not in old:
+        old, new = f"%{old}", f"%{new}"
+    prefix, _, suffix = old.partition("%")
+    words = []
+    for word in value.split():
+        if word.startswith(prefix) and word.endswith(suffix):
+            stem = word[len(prefix) : len(word) - len(suffix)]
+            word = new.replace("%", stem, 1)
+        words.append(word)
+    return " ".join(words)
+
+
+def _logical_lines(content: str) -> list[tuple[int, str]]:
+    """(line, text) with backslash continuation lines joined."""
+    lines = []
+    pending = ""
+    start = 0
+    for number, line in enumerate(content.split("\n"), start=1):
+        if not pending:
+            start = number
+        if line.endswith("\\"):
+            pending += line[:-1] + " "
+            continue
+        lines.append((start, pending + line))
+        pending = ""
+    return lines
+
+
+def _names(value: Any) -> list[str]:
+    """Names of a workflow's triggers or a job's needs: one, a list or a map."""
+    if isinstance(value, str):
+        return [value]
+    if isinstance(value, dict | list):
+        return [str(name) for name in value]
+    return []
+
+
+def _working_directory(definition: dict) -> str | None:
+    defaults = definition.get("defaults")
+    run = defaults.get("run") if isinstance(defaults, dict) else None
+    return run.get("working-directory") if isinstance(run, dict) else None
+
+
+def _job_lines(lines: list[str]) -> dict[str, tuple[int, list[tuple[int, int]]]]:
+    """
+    Line of each job's key under jobs:, with (line of the step, first line
+    of its run script) for each of its steps.
+    """
+    jobs: dict[str, tuple[int, list[tuple[int, int]]]] = {}
+    in_jobs = False
+    job_indent = steps_indent = item_indent = None
+    steps: list[tuple[int, int]] = []
+    key = re.compile(r"""^["']?([\w.-]+)["']?\s*:""")
+    run = re.compile(r"^(?:-\s+)?run\s*:\s*(.*)$")
+    for number, line in enumerate(lines, start=1):
+        stripped = line.lstrip()
+        if not stripped or stripped.startswith("#"):
+            continue
+        depth = len(line) - len(stripped)
+        if depth == 0:
+            in_jobs = re.match(r"jobs\s*:", stripped) is not None
+            continue
+        if not in_jobs:
+            continue
+        job_indent = depth if job_indent is None else job_indent
+        if depth == job_indent and (match := key.match(stripped)):
+            steps = []
+            jobs[match.group(1)] = (number, steps)
+            steps_indent = item_indent = None
+            continue
+        if depth <= job_indent:
+            continue
+        if steps_indent is not None and depth <= steps_indent and not (
+            depth == steps_indent and stripped.startswith("-")
+        ):
+            steps_indent = item_indent = None  # Past the steps of the job
+        if re.match(r"steps\s*:", stripped):
+            steps_indent = depth
+            continue
+        if steps_indent is None:
+            continue
+        if stripped.startswith("-") and item_indent in (None, depth):
+            item_indent = depth
+            steps.append((number, 0))
+        if steps and (match := run.match(stripped)) and not steps[-1][1]:
+            block = match.group(1).strip()[:1] in ("|", ">", "")
+            steps[-1] = (steps[-1][0], number + 1 if block else number)
+    return jobs
@@ -0,0 +1,191 @@
+"""Private ingestion: the graph keeps structure and signatures, not the code's text.
+
+Names, qualified names, paths, kinds, line spans, metrics and relationships
+are stored as usual, so navigation and impact analysis keep working. Every
+other text (docstrings, comments, commands and scripts, log and commit
+messages, assertion texts, literal values) is replaced by a hash, which still
+compares equal when the text is the same, and string literals inside
+decorators and signatures are hashed in place. Set PRIVACY_HASH_KEY to make
+the hashes keyed (HMAC), so short values cannot be recovered by hashing
+guesses.
+"""
+
+import hashlib
+import hmac
+import re
+from typing import Any
+
+# Texts kept as they are: what things are called, where they are and what
+# kind they are. Text under any other property is replaced by a hash, so
+# properties added later are private unless listed here.
+STRUCTURAL_PROPERTIES = frozenset(
+    {
+        # Names and locations
+        "qualified_name",
+        "name",
+        "path",
+        "file_path",
+        "module",
+        "package",
+        "project",
+        "repo",
+        "directory",
+        "extension",
+        "language",
+        "outputs",
+        # Keys of nodes from outside the source
+        "id",
+        "key",
+        "ref",
+        "sha",
+        "short_sha",
+        "hash",
+        "body_hash",
+        "commit_sha",
+        "last_commit_sha",
+        "issue_id",
+        "run_id",
+        "result_id",
+        "taint_id",
+        "vulnerability_id",
+        "cwe_id",
+        "purl",
+        "conversation_id",
+        "question_id",
+        "answer_id",
+        # Who changed what, and when
+        "email",
+        "author",
+        "author_email",
+        "last_modified_by",
+        "timestamp",
+        "date",
+        "created_at",
+        "started_at",
+        "finished_at",
+        "asked_at",
+        "first_seen",
+        "last_seen",
+        "last_modified_at",
+        "updated_at",
+        # Kinds, categories and versions
+        "kind",
+        "type",
+        "label",
+        "level",
+        "severity",
+        "status",
+        "scope",
+        "category",
+        "confidence",
+        "framework",
+        "library",
+        "ecosystem",
+        "format",
+        "tracker",
+        "license",
+        "lifecycle",
+        "source_type",
+        "sink_type",
+        "source_kind",
+        "sink_kind",
+        "flow_type",
+        "node_type",
+        "change_type",
+        "import_type",
+        "export_type",
+        "inheritance_type",
+        "override_type",
+        "entrypoint_kind",
+        "reachability",
+        "tags",
+        "triggers",
+        "goos",
+        "goarch",
+        "platforms",
+        "runs_on",
+        "uses",
+        "version",
+        "version_spec",
+        "fixed_version",
+        "go_version",
+        "api_version",
+        # API surface, named by the qualified names of endpoints anyway
+        "method",
+        "route",
+        "handler",
+        # Other definitions named in a relationship
+        "caller",
+        "callee",
+        "function",
+        "symbol",
+        "target",
+        "parent",
+        "child",
+        "via",
+    }
+)
+# Literal values, hashed whatever their type
+VALUE_PROPERTIES = frozenset({"value", "initial_value"})
+# Code kept for its shape, with the string literals in it hashed
+LITERAL_PROPERTIES = frozenset(
+    {"decorators", "parameters", "return_type", "signature"}
+)
+
+HASH_PREFIX = "sha256:"
+STRING_LITERAL = re.compile(r"""(?P<quote>["'`])(?:\\.|(?!(?P=quote)).)*(?P=quote)""")
+
+
+class Redactor:
+    """Rewrites node and relationship properties before they are stored."""
+
+    def __init__(self, key: str | None = None) -> None:
+        self._key = key.encode() if key else None
+
+    @property
+    def fingerprint(self) -> str:
+        """Tells redactors apart by their key, without giving the key away."""
+        if not self._key:
+            return "sha256"
+        digest = hmac.new(self._key, b"fingerprint", hashlib.sha256)
+        return "hmac:" + digest.hexdigest()[:16]
+
+    def hash(self, value: Any) -> str:
+        data = str(value).encode("utf-8", "replace")
+        digest = (
+            hmac.new(self._key, data, hashlib.sha256)
+            if self._key
+            else hashlib.sha256(data)
+        )
+        return HASH_PREFIX + digest.hexdigest()[:16]
+
+    def properties(self, properties: dict[str, Any]) -> dict[str, Any]:
+        return {key: self._redact(key, value) for key, value in properties.items()}
+
+    def node_ref(self, node: tuple) -> tuple:
+        """A (label, key, value) reference matching the redacted node."""
+        label, key, value = node
+        return (label, key, self._redact(key, value))
+
+    def _redact(self, key: str, value: Any) -> Any:
+        # Flags, and numbers such as line spans and metrics, are not text
+        if value is None or isinstance(value, bool):
+            return value
+        if key in VALUE_PROPERTIES:
+            return self.hash(value)
+        if key in LITERAL_PROPERTIES:
+            return self._hash_literals(value)
+        if key in STRUCTURAL_PROPERTIES or isinstance(value, int | float):
+            return value
+        if isinstance(value, list):
+            return [self._redact(key, item) for item in value]
+        return self.hash(value)
+
+    def _hash_literals(self, value: Any) -> Any:
+        if isinstance(value, list):
+            return [self._hash_literals(item) for item in value]
+        if isinstance(value, str):
+            return STRING_LITERAL.sub(
+                lambda m: f'"{self.hash(m.group(0)[1:-1])}"', value
+            )
+        return value
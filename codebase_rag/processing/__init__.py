@@ -1,17 +0,0 @@
-"""Processing utilities for scalable code ingestion."""
-
-from .parallel_processor import (
-    FileProcessor,
-    FileTask,
-    ParallelProcessor,
-    ProcessingResult,
-    ThreadSafeIngestor,
-)
-
-__all__ = [
-    "FileProcessor",
-    "FileTask",
-    "ParallelProcessor",
-    "ProcessingResult",
-    "ThreadSafeIngestor",
-]
@@ -1,10 +1,15 @@
 """Query caching module for improved performance (REQ-SCL-3)."""
 
+import copy
 import hashlib
 import json
+import re
 import time
+import uuid
 from collections import OrderedDict
+from collections.abc import Callable
 from dataclasses import dataclass
+from datetime import UTC, datetime
 from typing import Any
 
 from loguru import logger
@@ -271,4 +276,69 @@ def cached_query(ttl: float = 3600):
         wrapper.cache = cache
         return wrapper
         
-    return decorator
\ No newline at end of file
+    return decorator
+
+
+# A token stored in the graph and replaced by every ingestion, so readers in
+# other processes see that cached results are stale
+GRAPH_VERSION_QUERY = "MATCH (v:GraphVersion {name: 'graph'}) RETURN v.token AS token"
+SET_GRAPH_VERSION = (
+    "MERGE (v:GraphVersion {name: 'graph'}) "
+    "SET v.token = $token, v.updated_at = $updated_at"
+)
+# Clauses that write, or procedures that may; such queries are never cached
+WRITE_CLAUSES = re.compile(
+    r"\b(CREATE|MERGE|DELETE|SET|REMOVE|DROP|CALL|LOAD\s+CSV)\b", re.IGNORECASE
+)
+
+
+class GraphQueryCache:
+    """
+    Results of read-only queries, keyed by query text, parameters and graph
+    version. Reading the version costs a lookup of one node, much less than
+    the traversals the agent repeats within a conversation; once an ingestion
+    changes it, entries of older versions are dropped.
+    """
+
+    def __init__(self, max_size: int = 256):
+        self.cache = QueryCache(max_size=max_size, ttl=float("inf"))
+        self.version: str | None = None
+
+    def fetch_all(
+        self,
+        execute: Callable[[str, dict[str, Any] | None], list],
+        query: str,
+        params: dict[str, Any] | None = None,
+    ) -> list:
+        """Rows of query from the cache, else from execute and then cached."""
+        if WRITE_CLAUSES.search(query):
+            self.clear()
+            return execute(query, params)
+        keyed = {"params": params or {}, "graph_version": self._version(execute)}
+        try:
+            cached = self.cache.get(query, keyed)
+        except TypeError:
+            return execute(query, params)  # Parameters that are not JSON
+        if cached is not None:
+            return copy.deepcopy(cached)
+        rows = execute(query, params)
+        self.cache.put(query, copy.deepcopy(rows), keyed)
+        return rows
+
+    def clear(self) -> None:
+        """Drop every result, for writes made without a new graph version."""
+        if self.cache.get_stats()["size"]:
+            self.cache.invalidate()
+
+    def _version(self, execute: Callable[[str, dict[str, Any] | None], list]) -> str:
+        rows = execute(GRAPH_VERSION_QUERY, None)
+        version = rows[0]["token"] if rows else ""
+        if version != self.version:
+            if self.version is not None:
+                self.cache.invalidate()
+            self.version = version
+        return version
+
+
+def graph_version_params() -> dict[str, str]:
+    return {"token": uuid.uuid4().hex, "updated_at": datetime.now(UTC).isoformat()}
@@ -12,12 +12,23 @@ The database contains comprehensive information about a codebase with the follow
 - Project: {name: string}
 - Package: {qualified_name: string, name: string, path: string}
 - Folder: {path: string, name: string}
-- File: {path: string, name: string, extension: string}
-- Module: {qualified_name: string, name: string, path: string, git_creation_date: string, git_last_modified: string, git_commit_count: int}
-- Class: {qualified_name: string, name: string, decorators: list[string], base_classes: list[string], is_abstract: bool}
-- Function: {qualified_name: string, name: string, decorators: list[string], start_line: int, end_line: int}
-- Method: {qualified_name: string, name: string, decorators: list[string], is_override: bool, calls_super: bool}
+- File: {path: string, name: string, extension: string, churn: int, hotspot_score: float}
+- Module: {qualified_name: string, name: string, path: string, git_creation_date: string, git_last_modified: string, git_commit_count: int, generated: bool}  (generated: true on modules of generated code, protobuf stubs, mocks and files with a `Code generated ... DO NOT EDIT.` header, and on the functions, methods and classes they define; leave them out with `WHERE NOT coalesce(n.generated, false)` when asked about handwritten code)
+- Class: {qualified_name: string, name: string, decorators: list[string], base_classes: list[string], is_abstract: bool, docstring: string, field_count: int, smells: list[string], is_dead_code: bool}
+- Function: {qualified_name: string, name: string, decorators: list[string], docstring: string, start_line: int, end_line: int, cyclomatic_complexity: int, parameter_count: int, max_nesting_depth: int, lines_of_code: int, churn: int, hotspot_score: float, smells: list[string], entrypoint_kind: string, call_depth_max: int, call_depth_avg: float, call_tree_size: int, calls_panic: bool, has_recover: bool, may_panic: bool, is_dead_code: bool, last_modified_by: string, last_modified_at: string, last_commit_sha: string, author_count: int, coverage_percent: float, covered_statements: int, total_statements: int, body_hash: string, body_minhash: list[int], body_tokens: int}  (body_* fingerprint the body with names and literals normalized, absent for short bodies; coverage from Go cover profiles loaded with `load-coverage`)
+- Method: {qualified_name: string, name: string, decorators: list[string], docstring: string, is_override: bool, calls_super: bool, cyclomatic_complexity: int, parameter_count: int, max_nesting_depth: int, lines_of_code: int, churn: int, hotspot_score: float, smells: list[string], entrypoint_kind: string, call_depth_max: int, call_depth_avg: float, call_tree_size: int, calls_panic: bool, has_recover: bool, may_panic: bool, is_dead_code: bool, last_modified_by: string, last_modified_at: string, last_commit_sha: string, author_count: int, body_hash: string, body_minhash: list[int], body_tokens: int}
+- Interface: {qualified_name: string, name: string, start_line: int, end_line: int, method_count: int, is_external: bool, is_dead_code: bool}  (Go interface; external ones are standard library interfaces such as "io.Writer" or "error", qualified as Go code refers to them)
 - ExternalPackage: {name: string, version_spec: string}
+- BuildConstraint: {expression: string, goos: list[string], goarch: list[string], tags: list[string], platforms: list[string]}  (Go build constraint of a file from `//go:build` and its _GOOS_GOARCH suffix, e.g. "linux && !cgo"; platforms: the common GOOS/GOARCH pairs it builds for, e.g. "windows/amd64")
+- GoModule: {path: string, is_local: bool, manifest: string, go_version: string}  (Go module, e.g. "golang.org/x/crypto"; local ones are declared by a go.mod in the repository)
+- ModuleVersion: {qualified_name: string, path: string, version: string, checksum: string}  (qualified_name e.g. "golang.org/x/crypto@v0.21.0"; checksum from go.sum)
+- Endpoint: {qualified_name: string, method: string, route: string, framework: string, handler: string, path: string, line_number: int}
+- TypeParameter: {qualified_name: string, name: string, constraint: string, position: int}  (type parameter of a generic Go function or type, e.g. "shop.maps.Map.K"; constraint as written, e.g. "comparable" or "~int | ~float64")
+- PackageInit: {qualified_name: string, package: string, directory: string, init_count: int, order: int, blank_imports: list[string]}  (the init() functions of a Go package, qualified_name e.g. "shop.db:init"; order: position in the repository's package initialization order, -1 in an import cycle)
+- Directive: {qualified_name: string, kind: string, line_number: int, command: string, patterns: list[string], variable: string}  (a Go `//go:generate` (kind generate, with its command) or `//go:embed` line (kind embed, with its patterns and the variable they are embedded into), qualified_name e.g. "pill.pill:go:embed:12")
+- RpcMethod: {qualified_name: string, name: string, service: string, streaming: bool}  (RPC of a gRPC service declared in generated Go code, qualified by its full method name, e.g. "/users.v1.UserService/GetUser")
+- PanicSite: {qualified_name: string, line_number: int, value: string}  (a `panic(...)` call in a Go function, qualified_name e.g. "calc.calc.Sqrt.panic:12"; value: the argument as written)
+- Channel: {qualified_name: string, name: string, scope: string, element_type: string, direction: string, buffered: bool, line_number: int}  (Go channel; scope: package, field, local or parameter; direction: both, send or receive)
 
 **C Language Nodes:**
 - Struct: {qualified_name: string, name: string, size: int}
@@ -27,15 +38,49 @@ The database contains comprehensive information about a codebase with the follow
 
 **Analysis Nodes:**
 - Variable: {qualified_name: string, name: string, type: string, scope: string}
-- Vulnerability: {id: string, type: string, severity: string, cwe_id: string, description: string, recommendation: string}
-- TestCase: {qualified_name: string, name: string, test_type: string}
-- TestSuite: {qualified_name: string, name: string, framework: string}
+- GlobalVariable: {qualified_name: string, name: string, type: string, is_static: bool, is_extern: bool, is_const: bool}  (C globals and Go package-level vars)
+- Vulnerability: {id: string, type: string, severity: string, cwe_id: string, description: string, recommendation: string}  (type go_vulnerability: a Go vulnerability database entry from govulncheck, id e.g. "GO-2023-2102", with aliases (CVE and GHSA ids), url and reachability: called, imported or required; severity high, medium or low accordingly)
+- TestCase: {qualified_name: string, name: string, test_type: string, framework: string, ginkgo_type: string, full_text: string, focused: bool, pending: bool, run_count: int, fail_count: int, pass_rate: float, flakiness_score: float, is_flaky: bool, last_status: string, status_history: string}
+- TestFunction: {qualified_name: string, name: string, framework: string, kind: string (test, benchmark or example), run_count: int, fail_count: int, pass_rate: float, flakiness_score: float, is_flaky: bool, last_status: string, status_history: string}
+- TestRun: {run_id: string, timestamp: string, source: string, total: int, failures: int, skipped: int}
+- TestResult: {result_id: string, classname: string, name: string, status: string, duration: float, message: string, timestamp: string}
+- TestSuite: {qualified_name: string, name: string, framework: string, ginkgo_type: string, full_text: string, lifecycle: list}  (Ginkgo Describe/Context/When/DescribeTable, nested via CONTAINS_SUITE; testify suite structs, with their Setup/TearDown methods in lifecycle)
 - Assertion: {qualified_name: string, type: string, message: string}
+- Todo: {qualified_name: string, kind: string, text: string, tag: string, path: string, line_number: int, author: string, author_email: string, commit_sha: string, created_at: string}
+- LogStatement: {qualified_name: string, library: string, level: string, message: string, call: string, path: string, line_number: int}
+- UncheckedError: {qualified_name: string, call: string, kind: string, category: string, likelihood: int, path: string, line_number: int}  (Go call whose error result is discarded; kind: blank, ignored, deferred or goroutine)
+- Chunk: {qualified_name: string, parent: string, index: int, chunk_count: int, start_line: int, end_line: int}  (a stretch of a function, method or file too long to embed whole, split before a statement and overlapping the chunk before it; qualified_name e.g. "shop.orders.checkout:chunk2")
+- Documentation: {qualified_name: string, kind: string, name: string, text: string, path: string, start_line: int, end_line: int}  (author-written prose: a Go doc comment, kind package, function, method or type, qualified_name e.g. "shop.cart.cart.Add:doc" or "shop.cart.doc:doc" for the package doc; or a README section, kind readme, qualified_name its path and first line, e.g. "cart/README.md:12", name its heading; embedded by `embed` like symbols)
+- ApiSymbol: {qualified_name: string, symbol: string, name: string, kind: string, signature: string, path: string, language: string, members: list[string], type_signature: string}  (an exported declaration of a non-test file as `api-diff` compares it; symbol is "<package dir>.<Name>" for Go, "<module path>.<Name>" otherwise, qualified_name is "<project>:<symbol>"; kind function, method, class, struct, interface, type, const or var; members: exported struct fields or interface methods; type_signature: Go types without parameter names, e.g. "func(int) error")
 
 **Version Control Nodes:**
 - Repository: {name: string, path: string, total_commits: int, last_commit_date: string}
 - Commit: {sha: string, message: string, date: string, author: string}
+- Author: {email: string, name: string}  (commit author, loaded by `ingest-history`)
+- Crash: {issue_id: string, short_id: string, title: string, culprit: string, level: string, status: string, project: string, count: int, user_count: int, first_seen: string, last_seen: string, url: string}  (Sentry issue imported by `ingest-crashes`; count is the number of events)
+- Issue: {key: string, tracker: string, number: int, project: string, title: string, state: string, url: string, labels: list[string], is_pull_request: bool, issue_type: string, assignee: string, closed_at: string, hydrated_at: string}  (key: "#123", "owner/repo#123" or Jira "PROJ-456"; title and later only once fetched from the tracker)
 - Contributor: {id: string, name: string, email: string, total_commits: int}
+- Team: {name: string}  (CODEOWNERS team or group, e.g. "@org/payments")
+- User: {name: string, email: string}  (CODEOWNERS user handle or email)
+- Conversation: {conversation_id: string, repo: string, user: string, started_at: string}  (a chat session of `start`, resumable with `start --conversation`)
+- Question: {question_id: string, conversation_id: string, index: int, text: string, asked_at: string}  (question_id e.g. "3f9c2a7b1d04:2", the third question of its conversation)
+- Answer: {answer_id: string, text: string}  (answer_id is the question_id of the question answered)
+
+**Service Catalog Nodes:**
+- Component: {ref: string, name: string, title: string, description: string, type: string, lifecycle: string, tags: list[string], path: string}  (Backstage entity from catalog-info.yaml; ref e.g. "component:default/payments"; path is the descriptor, empty for entities declared in other repositories)
+- System: {ref: string, name: string, title: string, description: string, type: string, lifecycle: string, tags: list[string], path: string}
+- API: {ref: string, name: string, title: string, description: string, type: string, lifecycle: string, tags: list[string], path: string}  (type e.g. "openapi", "grpc")
+- Layer: {name: string, rank: int, packages: list[string]}  (architectural layer declared in the [[layers]] of .cgr.toml and written by `analyze layering`; rank 0 is the outermost, and a layer may only depend on higher ranks or the layers in its may_use)
+
+**Database Nodes:**
+- Table: {qualified_name: string, name: string, schema: string, kind: string, path: string, line_number: int, primary_key: list[string]}  (table or view (kind) as the repository's SQL migrations leave it, qualified_name e.g. "users" or "billing.invoices", without the default schema; tables only queried by code have just qualified_name and name)
+- Column: {qualified_name: string, name: string, data_type: string, nullable: bool, primary_key: bool, default: string, path: string, line_number: int}  (qualified_name e.g. "users.email"; path and line_number of the migration adding it)
+
+**CI Nodes:**
+- Workflow: {qualified_name: string, name: string, path: string, triggers: list[string]}  (GitHub Actions workflow; qualified_name is its path, e.g. ".github/workflows/ci.yml")
+- Job: {qualified_name: string, name: string, path: string, line_number: int, runs_on: string, uses: string}  (qualified_name e.g. ".github/workflows/ci.yml:lint"; uses names the reusable workflow a job calls)
+- Step: {qualified_name: string, name: string, path: string, line_number: int, uses: string, run: string}  (qualified_name e.g. ".github/workflows/ci.yml:lint:1", the second step of the job; uses is an action such as "actions/checkout@v4", run its shell script)
+- MakeTarget: {qualified_name: string, name: string, path: string, line_number: int, commands: list[string], is_default: bool, is_phony: bool, outputs: list[string]}  (qualified_name e.g. "Makefile:lint"; commands with the Makefile's variables expanded; outputs are the files it builds, itself unless phony and what its recipe writes with -o)
 
 **Configuration Nodes:**
 - ConfigFile: {qualified_name: string, path: string, format: string, setting_count: int, environment_list: string}
@@ -46,25 +91,97 @@ The database contains comprehensive information about a codebase with the follow
 - CONTAINS_* (hierarchical containment)
 - DEFINES (module defines classes/functions)
 - DEFINES_METHOD (class defines methods)
-- CALLS (function/method calls)
+- CALLS (function/method calls; a Go `x.M()` call on an interface value points to the Interface declaring M; layer_violation as on IMPORTS; synthetic: true with via for a Go function called through a value, e.g. via "sort.Slice" from the function passing a comparator, or via the parameter name from a function calling the function passed to it)
+- REFERENCES (Go Function -> Function it passes, stores or returns as a value without calling it, method values included; props: line_number, use: argument, assignment, field or return)
 - DEPENDS_ON_EXTERNAL (external dependencies)
+- DEFINES_ENDPOINT (module registers an HTTP endpoint)
+- HANDLED_BY (endpoint is served by a function/method)
+- DEFINES_VARIABLE (module defines a global/package-level variable)
+- SPAWNS (Go function starts another in a goroutine with `go f()`)
+- WRITES (Go function assigns a package-level variable; props: in_goroutine, guarded)
+- DEFINES_CHANNEL (module declares a package or struct field channel, function a local or parameter one)
+- SENDS_TO / RECEIVES_FROM (Go function sends on or receives from a channel, including `range ch`; props: line_number, in_goroutine, via: the callee's parameter when the channel was passed in)
+- PASSED_AS (channel is passed to a function as one of its channel parameters)
+- HAS_BUILD_CONSTRAINT (Go Module file -> BuildConstraint; files without one build everywhere)
+- VARIANT_OF (Go function -> same-name function of its package in a file built for other platforms)
+- DECLARES_MODULE (go.mod File -> local GoModule); INCLUDES_MODULE (go.work File -> GoModule of the workspace)
+- DEPENDS_ON (GoModule -> ModuleVersion it requires; props: indirect); HAS_VERSION (GoModule -> ModuleVersion)
+- REPLACED_BY (GoModule -> ModuleVersion or local GoModule from a replace directive; props: version, empty for all versions, manifest)
+- IMPORTS_MODULE (Go source Module -> GoModule its imports come from; props: packages, blank: those imported with `_` for their side effects)
+- USES_MODULE (Go function refers to a package of the GoModule, or calls a name its file dot-imports from it; props: packages)
+- HAS_DIRECTIVE (Go Module -> Directive)
+- GENERATES (go:generate Directive -> File of its directory whose `// Code generated ... DO NOT EDIT.` header names the command's program)
+- EMBEDS (go:embed Directive -> File embedded into the binary)
+- INVOKES_RPC (Go function -> RpcMethod it calls through a generated gRPC client; props: line_number)
+- HANDLES_RPC (Go method of a type implementing a generated gRPC server interface -> RpcMethod it serves; methods promoted from the embedded Unimplemented server are not handlers)
+- CALLS_RPC (Go function -> the method handling an RPC it invokes, joined on the RpcMethod so services ingested separately connect; props: rpc, line_number)
+- CALLS_NATIVE (Go function -> C Function it calls through cgo as C.name, from the file's `import "C"` preamble, qualified e.g. "shop.mathx.add.C.add", or from a C file; props: line_number)
+- PANICS (Go function -> PanicSite of a panic call in it)
+- DEFERS (Go function -> Function/Method called by one of its defer statements; props: line_number)
+- RECOVERS (Go function with a deferred recover -> its own PanicSites and the functions it calls, whose panics it absorbs; props: line_number of the defer)
+- HAS_INIT (Package/Folder -> PackageInit of the Go package in it)
+- RUNS (PackageInit -> init Function of one file; props: order, file, count of init() in that file)
+- INIT_BEFORE (PackageInit -> PackageInit of a package that imports it, directly or through packages without init())
+- INITIALIZES (Go init function -> package-level GlobalVariable it assigns)
+- HAS_TYPE_PARAMETER (generic Go function or type declares a type parameter)
+- CONSTRAINED_BY (type parameter is constrained by a named interface: a repository one, `comparable`, `any` or another module's such as "cmp.Ordered")
+- INSTANTIATES (Go function uses a generic function or type; props: type_arguments, e.g. "string, Order", empty when inferred from a call; inferred; line_number)
 
 **Enhanced Relationships:**
-- IMPORTS (module imports from another)
+- IMPORTS (module imports from another, or Module -> ExternalPackage for a third-party Python or JavaScript import, named by its top-level module or npm package; props: symbol, line_number of the import statement, layer_violation: the broken rule, e.g. "services -> handlers", when `analyze layering` found the import crossing layers the wrong way)
+- IN_LAYER (Module -> the Layer whose packages contain it)
 - EXPORTS (module exports symbols)
 - REQUIRES (module requires another)
 - CIRCULAR_DEPENDENCY (circular import detected)
-- FLOWS_TO (data flow between variables)
+- CLONE_OF (Function/Method -> an earlier function with a near-identical body, from `analyze clones`; props: similarity 0-1, exact when the normalized bodies are equal)
+- FLOWS_TO (data flow between variables; from `analyze taint`, also between functions on an unsanitized path from a taint source to a sink; props: taint_id of the path's Vulnerability, source_kind, sink_kind)
 - INHERITS_FROM (class inheritance)
-- IMPLEMENTS (interface implementation)
+- IMPLEMENTS (interface implementation; for Go, resolved from method sets, props: pointer_receiver when only *T implements it)
 - OVERRIDES (method overrides parent)
 - TESTS (test case tests code)
+- EXERCISES (Go benchmark function runs the function it measures)
+- DOCUMENTS (Go example function illustrates a function, by the Example naming convention; Documentation -> the Function, Class or Interface its doc comment is on, or the Package/Folder/Project a package doc or README section describes)
+- HAS_DOCUMENTATION (Module -> Documentation of its doc comments; README File -> Documentation of its sections)
+- EXPOSES (Module -> ApiSymbol of each exported declaration in it)
+- COVERED_BY (code is covered by a test)
+- COVERS (Go test function ran statements of a function in its `go test -coverprofile` profile, loaded with `load-coverage --per-test`; props: covered_statements, coverage_percent of the function's statements)
 - ASSERTS (assertion in test)
+- HAS_TODO (function/method/module contains a TODO comment)
+- LOGS (function/method/module contains a logging or print call)
+- HAS_UNCHECKED_ERROR (function/method/module discards an error at a call site)
+- HAS_CHUNK (function/method/module -> a Chunk of its body, in order of index)
+- HAS_RESULT (test run produced a result)
+- RESULT_OF (result belongs to a test case/function)
 - HAS_VULNERABILITY (code has security issue)
+- HAS_KNOWN_VULNERABILITY (ModuleVersion -> Vulnerability govulncheck found in it; props: package, fixed_version)
+- CALLS_VULNERABLE (function whose call path reaches a vulnerable symbol, per govulncheck; props: symbol, line_number of the call, entry: the function the path enters the repository through, trace: the symbols from the vulnerable one outwards)
 - TAINT_FLOW (tainted data flow path)
-- AUTHORED_BY (commit authored by contributor)
+- AUTHORED_BY (commit or TODO authored by contributor; commits loaded by `ingest-history` point to their Author)
 - MODIFIES (commit modifies file)
+- HAS_QUESTION (Conversation -> Question asked in it); FOLLOWS (Question -> the question asked before it); ANSWERED_BY (Question -> Answer)
+- REFERENCES (Answer -> Function/Method/Class/Module the answer cited; past investigations of a function are the questions whose answers reference it)
+- MODIFIED (Commit -> File it changed, or -> Function/Method whose current lines git blame attributes to it, from `ingest-history`; props: date for files, lines for functions)
+- REFERENCES_ISSUE (commit message or code comment mentions an issue; props: closes for commits, path and line_number for comments)
+- CHANGED_FOR (function/method has lines blamed on a commit referencing the issue; props: commit_sha)
+- IMPLEMENTS_TICKET (code changed for or mentioning a Jira ticket, rebuilt by `link-issues`; props: via ["commit", "comment"], commits)
+- CHILD_OF (Jira sub-task or epic member -> parent Issue)
 - CONTRIBUTES_TO (contributor to project)
+- OWNED_BY (File/Package -> Team/User owning it in CODEOWNERS or an OWNERS file; props: pattern, section, source (the CODEOWNERS or OWNERS file), line_number. OWNERS owners apply to their directory and below, patterns like "/payments/**". Filter code by owner with e.g. (:File {path: $path})-[:OWNED_BY]->(owner:Team|User))
+- OWNS (Backstage owner -> Component/System/API; the Team/User is named by entity ref, e.g. "group:default/payments"; props: source)
+- PART_OF (Component/API -> System)
+- PROVIDES_API / CONSUMES_API (Component -> API)
+- DEPENDS_ON (config file -> ExternalPackage; Component -> Component from the catalog's dependsOn)
+- IMPLEMENTED_IN (Component -> Package, Folder or Project holding its catalog-info.yaml)
+- DEFINED_IN (API -> File of its spec given with $text/$yaml/$json)
+- CRASHED_IN (Crash -> function/method in the stack of its latest event; props: depth from the crash site, is_top, line)
+- OBSERVED_CALL (function/method called another in an ingested OpenTelemetry trace; props: count, error_count, avg_ms, p50_ms, p95_ms, max_ms, total_ms, services, last_seen; percentiles cover the latest `ingest-traces` run)
+- READS_FROM / WRITES_TO (function/method/module whose SQL query reads or writes a Table, or .sql File; view Table -> the tables it selects from; props: line_number)
+- HAS_COLUMN (Table -> Column); ALTERS (migration File -> Table it changes after another created it; props: line_number)
+- REFERENCES (Table -> Table, and Column -> Column, of a foreign key; props: columns)
+- HAS_JOB (Workflow -> Job); HAS_STEP (Job -> Step; props: order); NEEDS (Job -> the jobs it waits for); CALLS_WORKFLOW (Job -> reusable Workflow of the repository)
+- DEPENDS_ON (MakeTarget -> MakeTarget of its prerequisites)
+- BUILDS_FROM (MakeTarget -> source File it is built from: a prerequisite, a source of an object prerequisite built by a pattern rule or make's own rules, or a source its recipe compiles; props: via, the object built from it, e.g. "main.o")
+- RUNS_TARGET / RUNS_SCRIPT / RUNS_PACKAGE (Step or MakeTarget -> MakeTarget, script File, or the Package/Folder/Project of Go packages its commands run `go test`, `go build`, `golangci-lint run`, ... on; props: command, line_number, pattern (RUNS_PACKAGE, e.g. "./internal/..." for the packages under internal))
 - HAS_CONFIG (project has config file)
 - DEFINES_SETTING (config defines setting)
 - REFERENCES_MODULE (config references code)
@@ -186,6 +303,42 @@ WITH t, collect(DISTINCT a.type) AS assertion_types
 WHERE size(assertion_types) > 3
 RETURN t.qualified_name AS test, assertion_types
 ```
+
+4. Find tests that fail intermittently in CI:
+```cypher
+// Flaky tests from ingested JUnit results and the code they exercise
+MATCH (t:TestCase|TestFunction)
+WHERE t.is_flaky = true
+OPTIONAL MATCH (t)-[:TESTS]->(code)
+RETURN t.qualified_name AS test, t.status_history AS history, collect(code.qualified_name) AS exercises
+ORDER BY t.flakiness_score DESC
+```
+
+5. Find the BDD specs that cover a method:
+```cypher
+// Ginkgo It/Entry specs exercising Calculator.Divide, directly or in BeforeEach
+MATCH (t:TestCase {framework: 'ginkgo'})-[:TESTS]->(m:Method)
+WHERE m.qualified_name ENDS WITH 'Calculator.Divide'
+RETURN t.full_text AS spec, t.pending AS pending
+```
+
+6. Find Go benchmarks and examples for a function:
+```cypher
+// Benchmarks EXERCISE and examples DOCUMENT the function they are named after
+MATCH (t:TestFunction)-[r:EXERCISES|DOCUMENTS]->(f:Function {name: 'Add'})
+RETURN t.name AS test, t.kind AS kind, type(r) AS relationship, f.qualified_name AS target
+```
+
+7. Find the most complex untested functions:
+```cypher
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f:Function|Method)
+WHERE NOT (f)<-[:TESTS]-() AND NOT (f)-[:COVERED_BY]->()
+RETURN f.qualified_name AS function, m.path AS path,
+       f.cyclomatic_complexity AS complexity, f.lines_of_code AS lines,
+       f.parameter_count AS parameters, f.max_nesting_depth AS nesting
+ORDER BY complexity DESC, lines DESC
+LIMIT 20
+```
 """
 
 GIT_QUERIES = """
@@ -219,6 +372,300 @@ RETURN m.path AS file, m.git_last_modified AS last_modified
 ORDER BY m.git_last_modified DESC
 LIMIT 20
 ```
+
+4. Find hotspots (high churn x high complexity):
+```cypher
+// hotspot_score is populated by `analyze hotspots` and normalized to 0.0 - 1.0
+// churn counts the commits that changed the function's own lines
+MATCH (f:Function|Method)
+WHERE f.hotspot_score IS NOT NULL
+RETURN f.qualified_name AS function, f.churn AS churn,
+       f.cyclomatic_complexity AS complexity, f.hotspot_score AS hotspot_score
+ORDER BY f.hotspot_score DESC
+LIMIT 20
+```
+
+5. Find the oldest TODOs in a package:
+```cypher
+// created_at comes from git blame of the TODO line
+MATCH (owner)-[:HAS_TODO]->(t:Todo)
+WHERE t.path STARTS WITH 'payments/' AND t.created_at <> ''
+RETURN t.kind AS kind, t.text AS text, t.path AS path, t.line_number AS line,
+       owner.qualified_name AS owner, t.author AS author, t.created_at AS since
+ORDER BY t.created_at ASC
+LIMIT 20
+```
+
+6. Find who should review changes to a function:
+```cypher
+// OWNED_BY edges come from CODEOWNERS; the module's File carries the ownership
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f:Function|Method)
+WHERE toLower(f.name) CONTAINS 'retry'
+MATCH (:File {path: m.path})-[:OWNED_BY]->(owner:Team|User)
+RETURN f.qualified_name AS function, collect(DISTINCT owner.name) AS reviewers
+```
+
+7. Find code smells in a package:
+```cypher
+// smells is populated by `analyze smells`, e.g. ["god_class"] or ["deep_nesting"]
+MATCH (n)
+WHERE (n:Class OR n:Function OR n:Method) AND size(n.smells) > 0
+  AND n.qualified_name STARTS WITH $package_qn
+RETURN labels(n)[0] AS kind, n.qualified_name AS name, n.smells AS smells
+```
+
+8. Find the deepest entrypoints:
+```cypher
+// call_depth_* is populated by `analyze call-depth`
+MATCH (f:Function|Method)
+WHERE f.entrypoint_kind IS NOT NULL
+RETURN f.qualified_name AS entrypoint, f.entrypoint_kind AS kind,
+       f.call_depth_max AS max_depth, f.call_tree_size AS reachable
+ORDER BY f.call_depth_max DESC
+LIMIT 20
+```
+
+9. Find exported Go functions that may panic:
+```cypher
+// may_panic is populated by `analyze panics`; calls_panic marks the panic site
+MATCH (f:Function|Method {may_panic: true})
+OPTIONAL MATCH path = (f)-[:CALLS*1..5]->(site {calls_panic: true})
+RETURN f.qualified_name AS function, f.calls_panic AS panics_directly,
+       [n IN nodes(path) | n.qualified_name] AS chain
+LIMIT 20
+```
+
+10. Find package variables written from goroutines without locking:
+```cypher
+MATCH (spawner)-[:SPAWNS]->(g)-[:CALLS*0..5]->(writer)-[w:WRITES]->(v:GlobalVariable)
+WHERE w.guarded = false
+RETURN v.qualified_name AS variable, collect(DISTINCT writer.qualified_name) AS writers,
+       collect(DISTINCT spawner.qualified_name) AS spawned_from
+```
+
+11. Find functions that drop errors from I/O, network or database calls:
+```cypher
+MATCH (f)-[:HAS_UNCHECKED_ERROR]->(u:UncheckedError)
+WHERE u.likelihood >= 3
+RETURN f.qualified_name AS function, collect(u.call) AS calls, count(u) AS unchecked
+ORDER BY unchecked DESC
+```
+
+12. Find the code changed for an issue:
+```cypher
+// CHANGED_FOR is populated by `link-issues`; comments mentioning it count too
+MATCH (i:Issue {key: '#123'})
+OPTIONAL MATCH (changed)-[:CHANGED_FOR]->(i)
+OPTIONAL MATCH (mention)-[:REFERENCES_ISSUE]->(i)
+WHERE NOT mention:Commit
+RETURN i.title AS title, collect(DISTINCT changed.qualified_name) AS changed,
+       collect(DISTINCT mention.qualified_name) AS mentioned_in
+```
+
+13. Find the code that implemented a Jira ticket, including its sub-tasks:
+```cypher
+MATCH (ticket:Issue {key: 'PROJ-1234'})
+OPTIONAL MATCH (sub:Issue)-[:CHILD_OF*1..2]->(ticket)
+WITH [ticket] + collect(sub) AS tickets
+UNWIND tickets AS t
+MATCH (code)-[r:IMPLEMENTS_TICKET]->(t)
+RETURN t.key AS ticket, t.title AS title, code.qualified_name AS code, r.via AS via
+```
+
+14. Find the functions of a catalog system's components and who owns them:
+```cypher
+MATCH (c:Component)-[:PART_OF]->(:System {name: 'checkout'})
+MATCH (c)-[:IMPLEMENTED_IN]->(root)
+OPTIONAL MATCH (owner)-[:OWNS]->(c)
+MATCH (root)-[:CONTAINS_PACKAGE|CONTAINS_FOLDER|CONTAINS_MODULE*1..]->(m:Module)
+MATCH (m)-[:DEFINES]->(f:Function)
+RETURN c.name AS component, owner.name AS owner, count(f) AS functions
+```
+
+15. Compare runtime calls from traces with the static call graph:
+```cypher
+// Observed calls missing from CALLS are dynamic dispatch, reflection or RPC
+MATCH (a)-[o:OBSERVED_CALL]->(b)
+WHERE NOT (a)-[:CALLS]->(b)
+RETURN a.qualified_name AS caller, b.qualified_name AS callee,
+       o.count AS calls, o.p95_ms AS p95_ms
+ORDER BY o.count DESC
+```
+
+16. Find high-traffic crashes in code touched by a change:
+```cypher
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(f)
+WHERE m.path IN ['shop/cart.py']
+MATCH (c:Crash)-[r:CRASHED_IN]->(f)
+WHERE c.count >= 100
+RETURN f.qualified_name AS function, c.short_id AS crash, c.title AS title,
+       c.count AS events, r.is_top AS crash_site
+ORDER BY c.count DESC
+```
+
+17. Find which goroutines talk to each other through a channel:
+```cypher
+MATCH (sender)-[s:SENDS_TO]->(ch:Channel)<-[r:RECEIVES_FROM]-(receiver)
+RETURN ch.qualified_name AS channel, ch.buffered AS buffered,
+       collect(DISTINCT sender.qualified_name) AS senders,
+       collect(DISTINCT receiver.qualified_name) AS receivers
+```
+
+18. Find the functions that touch anything from a Go module:
+```cypher
+MATCH (f)-[u:USES_MODULE]->(g:GoModule {path: 'golang.org/x/crypto'})
+OPTIONAL MATCH (g)-[:HAS_VERSION]->(v:ModuleVersion)<-[:DEPENDS_ON]-(:GoModule {is_local: true})
+RETURN f.qualified_name AS function, u.packages AS packages, collect(DISTINCT v.version) AS versions
+```
+
+19. Find the Go files and functions built for a platform:
+```cypher
+MATCH (m:Module)-[:DEFINES]->(f:Function)
+WHERE m.path ENDS WITH '.go'
+OPTIONAL MATCH (m)-[:HAS_BUILD_CONSTRAINT]->(b:BuildConstraint)
+WITH m, f, b
+WHERE b IS NULL OR 'windows/amd64' IN b.platforms
+RETURN m.path AS file, b.expression AS constraint, collect(f.name) AS functions
+```
+
+20. Find all concrete uses of a generic type or function:
+```cypher
+MATCH (user)-[i:INSTANTIATES]->(g {name: 'Map'})-[:HAS_TYPE_PARAMETER]->(p:TypeParameter)
+WITH user, i, g, collect(p.name + ' ' + p.constraint) AS parameters
+RETURN g.qualified_name AS generic, parameters, user.qualified_name AS used_by,
+       i.type_arguments AS type_arguments, i.inferred AS inferred
+```
+
+21. Find what runs at startup before a Go package's init() and what it sets up:
+```cypher
+MATCH (p:PackageInit {package: 'example.com/shop/api'})
+OPTIONAL MATCH (before:PackageInit)-[:INIT_BEFORE*]->(p)
+OPTIONAL MATCH (p)-[:RUNS]->(f:Function)-[:INITIALIZES]->(v:GlobalVariable)
+RETURN p.order AS position, collect(DISTINCT before.package) AS initialized_first,
+       collect(DISTINCT v.name) AS variables_set
+```
+
+22. Find exported Go functions that can panic and the callers that do not recover:
+```cypher
+MATCH (f:Function|Method)-[:PANICS]->(p:PanicSite)
+WHERE f.name =~ '[A-Z].*'
+OPTIONAL MATCH (caller)-[:CALLS]->(f)
+WHERE NOT (caller)-[:RECOVERS]->(f)
+RETURN f.qualified_name AS function, collect(DISTINCT p.value) AS panics,
+       collect(DISTINCT caller.qualified_name) AS unprotected_callers
+```
+
+23. Find the Go code affected by a change to a C function:
+```cypher
+MATCH (c:Function {name: 'hash_update'})<-[:CALLS*0..3]-(native:Function)<-[n:CALLS_NATIVE]-(go)
+OPTIONAL MATCH (caller)-[:CALLS*1..3]->(go)
+RETURN go.qualified_name AS go_function, n.line_number AS line,
+       collect(DISTINCT caller.qualified_name) AS go_callers
+```
+
+24. Find the generated Go files and the assets embedded in binaries:
+```cypher
+MATCH (m:Module)-[:HAS_DIRECTIVE]->(d:Directive)-[r:GENERATES|EMBEDS]->(f:File)
+RETURN m.path AS declared_in, d.kind AS kind, d.command AS command,
+       d.variable AS variable, collect(f.path) AS files
+```
+
+25. Find the least covered Go functions and the tests that reach them:
+```cypher
+MATCH (f:Function)
+WHERE f.coverage_percent IS NOT NULL AND f.coverage_percent < 50
+OPTIONAL MATCH (t:TestFunction)-[:COVERS]->(f)
+RETURN f.qualified_name AS function, f.coverage_percent AS coverage,
+       f.total_statements AS statements, collect(t.name) AS tests
+ORDER BY coverage, statements DESC
+```
+
+26. Check whether a CVE is reachable and from where:
+```cypher
+MATCH (v:Vulnerability)
+WHERE v.id = 'GO-2023-2102' OR 'CVE-2023-39325' IN v.aliases
+OPTIONAL MATCH (mv:ModuleVersion)-[k:HAS_KNOWN_VULNERABILITY]->(v)
+OPTIONAL MATCH (f)-[c:CALLS_VULNERABLE]->(v)
+RETURN v.id AS vulnerability, v.reachability AS reachability,
+       collect(DISTINCT mv.qualified_name) AS module_versions,
+       collect(DISTINCT k.fixed_version) AS fixed_in,
+       collect(DISTINCT {caller: f.qualified_name, symbol: c.symbol, entry: c.entry}) AS calls
+```
+
+27. Find dead code that can be deleted together:
+```cypher
+// is_dead_code is populated by `analyze dead-code`
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(n {is_dead_code: true})
+RETURN m.path AS file, labels(n)[0] AS kind, collect(n.name) AS dead_symbols
+ORDER BY file
+```
+
+28. Find the import statements that make two packages depend on each other:
+```cypher
+// `analyze cycles` finds longer package cycles
+MATCH (a:Module)-[r1:IMPORTS]->(b:Module)-[r2:IMPORTS]->(c:Module)
+WHERE a.path STARTS WITH 'orders/' AND c.path STARTS WITH 'orders/'
+  AND b.path STARTS WITH 'billing/'
+RETURN a.path + ':' + toString(r1.line_number) AS import_site,
+       b.path + ':' + toString(r2.line_number) AS import_back
+```
+
+29. Find who last touched a function:
+```cypher
+// Set from git blame by `ingest-history`
+MATCH (f:Function {name: 'apply_discount'})
+RETURN f.qualified_name AS function, f.last_modified_by AS author,
+       f.last_modified_at AS date, f.last_commit_sha AS commit
+```
+
+30. Find the functions that churn the most alongside a function:
+```cypher
+MATCH (f:Function {name: 'apply_discount'})<-[:MODIFIED]-(c:Commit)-[:MODIFIED]->(g)
+WHERE (g:Function OR g:Method) AND g <> f
+RETURN g.qualified_name AS changed_with, count(DISTINCT c) AS shared_commits
+ORDER BY shared_commits DESC
+LIMIT 10
+```
+
+31. Trace a request across gRPC services:
+```cypher
+MATCH path = (:Function {name: 'Checkout'})-[:CALLS|CALLS_RPC*1..6]->(f)
+WHERE any(r IN relationships(path) WHERE type(r) = 'CALLS_RPC')
+RETURN [n IN nodes(path) | n.qualified_name] AS chain
+LIMIT 10
+```
+
+32. Show unvalidated paths from request input to database queries:
+```cypher
+MATCH (m:Module)-[:HAS_VULNERABILITY]->(v:Vulnerability {type: 'user_input_to_sql'})
+RETURN v.description AS flow, v.code_snippet AS through, m.path AS path,
+       v.line_number AS line_number
+ORDER BY m.path, v.line_number
+```
+
+33. Find imports and calls that break the declared layering:
+```cypher
+MATCH (a)-[r:IMPORTS|CALLS]->(b)
+WHERE r.layer_violation IS NOT NULL
+RETURN r.layer_violation AS rule, type(r) AS kind, a.qualified_name AS source,
+       b.qualified_name AS target, r.line_number AS line_number
+ORDER BY rule, source
+```
+
+34. Find where a function's logic was copy-pasted:
+```cypher
+MATCH (f {name: 'apply_discount'})-[r:CLONE_OF]-(copy)
+WHERE f:Function OR f:Method
+RETURN copy.qualified_name AS copy, r.similarity AS similarity, r.exact AS exact
+ORDER BY similarity DESC
+```
+
+35. Find what a package is supposed to do, in its authors' words:
+```cypher
+MATCH (d:Documentation)-[:DOCUMENTS]->(p {path: 'internal/cart'})
+RETURN d.kind AS kind, d.name AS name, d.path AS path, d.text AS text
+ORDER BY d.kind, d.path, d.start_line
+```
 """
 
 CONFIG_QUERIES = """
@@ -418,6 +865,24 @@ NATURAL_LANGUAGE_EXAMPLES = """
 
 15. "Show me the data flow for the 'password' variable"
     -> Traces FLOWS_TO relationships from password Variable nodes
+
+16. "Who should review changes to the retry logic?"
+    -> Follows OWNED_BY edges from the files defining retry functions to Team/User nodes
+
+17. "Where is 'payment failed' logged?"
+    -> Matches LogStatement.message and follows LOGS back to the enclosing function
+
+18. "What can we safely delete?"
+    -> Returns nodes tagged is_dead_code by `analyze dead-code`, grouped by file
+
+19. "Which functions change together with apply_discount?"
+    -> Counts the Commit nodes with MODIFIED edges to both functions
+
+20. "Show unvalidated paths from request input to database queries"
+    -> Lists user_input_to_sql Vulnerability nodes from `analyze taint` and follows their FLOWS_TO edges
+
+21. "Where is this logic copy-pasted?"
+    -> Follows CLONE_OF edges from `analyze clones` in both directions from the function, by similarity
 """
 
 # ======================================================================================
@@ -0,0 +1,226 @@
+"""The prompt of the chat session: history, completion and slash commands.
+
+Questions are kept in REPL_HISTORY_PATH across sessions, where Up and
+Ctrl+R find them again. Tab completes symbol names from the index that
+ingestion writes for shell completion, so no query runs per key press, and
+the names of slash commands. A line starting with a slash command is run
+rather than asked: /cypher shows the rows of a query as a table, without
+the round trip through the agent, /query runs a query saved in the config
+file and /save saves one there, and /explore opens the graph explorer,
+whose picked symbol the next question is asked about.
+"""
+
+import json
+import re
+import shlex
+from collections.abc import Iterator
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any
+
+from prompt_toolkit import PromptSession
+from prompt_toolkit.completion import CompleteEvent, Completer, Completion
+from prompt_toolkit.document import Document
+from prompt_toolkit.history import FileHistory, History, InMemoryHistory
+from rich.console import Console
+from rich.table import Table
+from rich.text import Text
+
+from .completion import complete_symbol
+from .config import active_config_file, settings
+from .cypher_templates import TemplateRunner
+from .explorer import GraphExplorer, context_note, run_explorer
+from .saved_queries import SavedQuery, parse_arguments, save_query, saved_queries
+from .server.query import is_read_only, jsonable
+from .workspace import CONFIG_FILE_NAME
+
+SLASH_COMMANDS = {
+    "/cypher": "Run a read-only Cypher query and show its rows",
+    "/query": "Run a saved query: /query NAME --param value; alone, list them",
+    "/save": "Save a read-only query under a name: /save NAME MATCH ...",
+    "/explore": "Browse the graph from a symbol; c adds it to the next question",
+    "/help": "List the slash commands",
+    "/exit": "End the session",
+}
+# Rows of a /cypher query shown, and characters of each value
+MAX_SHOWN_ROWS = 100
+MAX_CELL_CHARS = 120
+# What Tab completes: a qualified name, or the start of one
+SYMBOL_PREFIX = re.compile(r"[\w.]+$")
+
+
+@dataclass
+class SlashCommand:
+    name: str
+    argument: str  # The rest of the input, e.g. a Cypher query
+
+
+def parse_slash_command(text: str) -> SlashCommand | None:
+    """
+    The slash command a line of input runs, None for a question. A line
+    starting with an absolute path, such as an image to ask about, is a
+    question: only a single word after the slash is taken for a command.
+    """
+    words = text.strip().split(maxsplit=1)
+    if not words or not re.fullmatch(r"/[A-Za-z][\w-]*", words[0]):
+        return None
+    return SlashCommand(words[0].lower(), words[1] if len(words) > 1 else "")
+
+
+class SessionCompleter(Completer):
+    """Completes slash commands at the start of the input, else symbol names."""
+
+    def get_completions(
+        self, document: Document, complete_event: CompleteEvent
+    ) -> Iterator[Completion]:
+        text = document.text_before_cursor
+        if text.startswith("/") and not any(c.isspace() for c in text):
+            for name, description in SLASH_COMMANDS.items():
+                if name.startswith(text.lower()):
+                    yield Completion(
+                        name, start_position=-len(text), display_meta=description
+                    )
+            return
+        match = SYMBOL_PREFIX.search(text)
+        if not match:
+            return
+        for name, label in complete_symbol(match.group(0)):
+            yield Completion(
+                name, start_position=-len(match.group(0)), display_meta=label
+            )
+
+
+def create_session() -> PromptSession:
+    """A prompt remembering its questions, completing on Tab only."""
+    return PromptSession(
+        history=_history(),
+        completer=SessionCompleter(),
+        complete_while_typing=False,
+    )
+
+
+def _history() -> History:
+    if not settings.REPL_HISTORY_PATH:
+        return InMemoryHistory()
+    path = Path(settings.REPL_HISTORY_PATH).expanduser()
+    try:
+        path.parent.mkdir(parents=True, exist_ok=True)
+    except OSError:
+        return InMemoryHistory()
+    return FileHistory(str(path))
+
+
+def run_slash_command(
+    command: SlashCommand,
+    ingestor: Any,
+    console: Console,
+    project_root: Path | None = None,
+) -> str | None:
+    """
+    Run a slash command other than /exit, which ends the chat loop. Returns
+    the context a symbol picked in /explore adds to the next question.
+    """
+    if command.name == "/help":
+        table = Table(show_header=False, box=None)
+        for name, description in SLASH_COMMANDS.items():
+            table.add_row(f"[cyan]{name}[/cyan]", description)
+        console.print(table)
+    elif command.name == "/cypher":
+        if not command.argument:
+            console.print("[yellow]Usage: /cypher MATCH (n:Function) RETURN n[/yellow]")
+        elif not is_read_only(command.argument):
+            console.print("[bold red]Only read-only queries can be run.[/bold red]")
+        else:
+            try:
+                rows = ingestor.fetch_all(command.argument)
+            except Exception as e:
+                console.print(f"[bold red]Query failed: {e}[/bold red]")
+                return None
+            console.print(rows_table(rows))
+    elif command.name == "/query":
+        run_saved_query(command.argument, ingestor, console)
+    elif command.name == "/save":
+        name, _, query = command.argument.partition(" ")
+        if not query.strip():
+            console.print("[yellow]Usage: /save NAME MATCH (n) RETURN n[/yellow]")
+            return None
+        config_file = active_config_file() or Path.cwd() / CONFIG_FILE_NAME
+        try:
+            save_query(config_file, SavedQuery(name, query.strip()))
+        except ValueError as e:
+            console.print(f"[bold red]{e}.[/bold red]")
+            return None
+        console.print(
+            f"[green]Saved {name} to {config_file}; /query {name} runs it.[/green]"
+        )
+    elif command.name == "/explore":
+        explorer = GraphExplorer(ingestor, project_root or Path.cwd())
+        node = run_explorer(explorer, command.argument or None, pick=True)
+        if node is not None:
+            console.print(
+                f"[dim]The next question is about {node.qualified_name}.[/dim]"
+            )
+            return context_note(node)
+    else:
+        console.print(
+            f"[yellow]Unknown command {command.name}; /help lists them.[/yellow]"
+        )
+    return None
+
+
+def run_saved_query(argument: str, ingestor: Any, console: Console) -> None:
+    """/query: the saved queries when no name is given, else the named one's rows."""
+    try:
+        queries = saved_queries()
+        words = shlex.split(argument)
+        if not words:
+            table = Table(show_header=False, box=None)
+            for saved in queries.values():
+                table.add_row(
+                    f"[cyan]{saved.name}[/cyan]",
+                    " ".join(f"--{p}" for p in saved.parameters),
+                    saved.description,
+                )
+            console.print(table if queries else "[yellow]No saved queries.[/yellow]")
+            return
+        saved = queries.get(words[0])
+        if saved is None:
+            raise ValueError(f"No query is saved as '{words[0]}'")
+        result = saved.run(TemplateRunner(ingestor), parse_arguments(words[1:]))
+    except ValueError as e:  # Includes a broken config file and bad quoting
+        console.print(f"[bold red]{e}.[/bold red]")
+        return
+    except Exception as e:
+        console.print(f"[bold red]Query failed: {e}[/bold red]")
+        return
+    for symbol in result.unresolved:
+        console.print(f"[yellow]No symbol in the graph matches {symbol}.[/yellow]")
+    console.print(rows_table(result.results))
+
+
+def rows_table(rows: list[dict[str, Any]]) -> Table:
+    """Query rows as a table, one column per key, nodes shown as their data."""
+    columns = list(dict.fromkeys(key for row in rows for key in row))
+    caption = f"{len(rows)} row{'' if len(rows) == 1 else 's'}"
+    if len(rows) > MAX_SHOWN_ROWS:
+        caption = f"First {MAX_SHOWN_ROWS} of {len(rows)} rows"
+    table = Table(caption=caption)
+    for column in columns:
+        table.add_column(column, overflow="fold")
+    for row in rows[:MAX_SHOWN_ROWS]:
+        table.add_row(*(Text(cell_text(row.get(column))) for column in columns))
+    return table
+
+
+def cell_text(value: Any) -> str:
+    """A value of a row as text: strings as they are, the rest as JSON."""
+    value = jsonable(value)
+    if value is None:
+        text = ""
+    elif isinstance(value, str):
+        text = value
+    else:
+        text = json.dumps(value, ensure_ascii=False, separators=(", ", ": "))
+    if len(text) > MAX_CELL_CHARS:
+        text = text[: MAX_CELL_CHARS - 3] + "..."
+    return text
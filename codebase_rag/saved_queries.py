@@ -0,0 +1,171 @@
+"""Named Cypher queries saved in the config file.
+
+A team's recurring questions, written once as [queries.NAME] tables, run as
+`query run NAME --param value`, as /query at the chat prompt, and by the
+agent through a tool of its own, instead of being pasted around. Each
+$parameter of a query becomes an option; those listed under `symbols` are
+matched to qualified names the way the built-in templates match them, so
+`--symbol Calculator.Divide` finds shop.calc.Calculator.Divide. Saved
+queries only read: a query that writes is refused when the file is loaded.
+"""
+
+import json
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+from .config import active_config_file, read_config_file
+from .cypher_templates import TemplateResult, TemplateRunner
+from .server.query import is_read_only
+
+QUERY_NAME = re.compile(r"[A-Za-z][\w-]*")
+_STRING = re.compile(r"'(?:[^'\\]|\\.)*'|\"(?:[^\"\\]|\\.)*\"")
+_PARAMETER = re.compile(r"\$(\w+)")
+
+
+@dataclass
+class SavedQuery:
+    name: str
+    query: str
+    description: str = ""
+    # Parameters holding symbols, resolved to qualified names before running
+    symbols: list[str] = field(default_factory=list)
+    defaults: dict[str, Any] = field(default_factory=dict)
+
+    @property
+    def parameters(self) -> list[str]:
+        """The query's $parameters, in the order they first appear."""
+        code = _STRING.sub("''", self.query)
+        return list(dict.fromkeys(_PARAMETER.findall(code)))
+
+    def run(self, runner: TemplateRunner, arguments: dict[str, Any]) -> TemplateResult:
+        """Run the query with its defaults overridden by the arguments given."""
+        parameters = self.parameters
+        unknown = sorted(set(arguments) - set(parameters))
+        if unknown:
+            raise ValueError(
+                f"{self.name} has no parameter {', '.join(unknown)}; "
+                f"it takes {', '.join(parameters) or 'none'}"
+            )
+        values = {**self.defaults, **arguments}
+        missing = [p for p in parameters if p not in values]
+        if missing:
+            raise ValueError(
+                f"{self.name} needs "
+                + ", ".join(f"--{p.replace('_', '-')}" for p in missing)
+            )
+        unresolved = []
+        for parameter in self.symbols:
+            symbol = str(values[parameter])
+            qualified_name = runner.resolve(symbol)
+            if qualified_name is None:
+                unresolved.append(symbol)
+            values[parameter] = qualified_name or symbol
+        results = runner.ingestor.fetch_all(self.query, values)
+        return TemplateResult(self.name, self.query, values, results, unresolved)
+
+
+def load_saved_queries(document: dict[str, Any]) -> dict[str, SavedQuery]:
+    """The queries in a config file's [queries.NAME] tables."""
+    section = document.get("queries") or {}
+    if not isinstance(section, dict):
+        raise ValueError("queries must map names to [queries.NAME] tables")
+    queries: dict[str, SavedQuery] = {}
+    for name, entry in section.items():
+        if not QUERY_NAME.fullmatch(name):
+            raise ValueError(f"Query name '{name}' must be a word, hyphens allowed")
+        if isinstance(entry, str):
+            entry = {"query": entry}
+        if not isinstance(entry, dict) or not isinstance(entry.get("query"), str):
+            raise ValueError(f"Query '{name}' needs a query")
+        if not is_read_only(entry["query"]):
+            raise ValueError(f"Query '{name}' writes to the graph; only read")
+        symbols = entry.get("symbols") or []
+        if isinstance(symbols, str):
+            symbols = [symbols]
+        defaults = entry.get("defaults") or {}
+        if not isinstance(defaults, dict):
+            raise ValueError(f"queries.{name}.defaults must be a table")
+        saved = SavedQuery(
+            name,
+            entry["query"].strip(),
+            str(entry.get("description") or ""),
+            list(symbols),
+            dict(defaults),
+        )
+        for parameter in [*saved.symbols, *saved.defaults]:
+            if parameter not in saved.parameters:
+                raise ValueError(f"Query '{name}' has no parameter ${parameter}")
+        queries[name] = saved
+    return queries
+
+
+def saved_queries(config_file: Path | None = None) -> dict[str, SavedQuery]:
+    """The saved queries of a config file, by default the active one."""
+    config_file = config_file or active_config_file()
+    if config_file is None or not config_file.is_file():
+        return {}
+    return load_saved_queries(read_config_file(config_file))
+
+
+def parse_arguments(words: list[str]) -> dict[str, Any]:
+    """
+    `--name value` and `--name=value` pairs as parameters: hyphens in names
+    become underscores, and whole numbers and true or false are converted.
+    """
+    arguments: dict[str, Any] = {}
+    remaining = list(words)
+    while remaining:
+        word = remaining.pop(0)
+        if not word.startswith("--") or len(word) == 2:
+            raise ValueError(f"Expected --parameter value, got '{word}'")
+        name, equals, value = word[2:].partition("=")
+        if not equals:
+            if not remaining:
+                raise ValueError(f"--{name} needs a value")
+            value = remaining.pop(0)
+        arguments[name.replace("-", "_")] = _value(value)
+    return arguments
+
+
+def saved_query_entry(
+    name: str, query: str, description: str = "", symbols: list[str] | None = None
+) -> str:
+    """The [queries.NAME] table `query save` and /save append to a TOML file."""
+    lines = [f"\n[queries.{name}]"]
+    if description:
+        lines.append(f"description = {json.dumps(description)}")
+    if "'''" in query:
+        lines.append(f"query = {json.dumps(query.strip())}")
+    else:
+        lines.append(f"query = '''\n{query.strip()}\n'''")
+    if symbols:
+        lines.append(f"symbols = {json.dumps(symbols)}")
+    return "\n".join(lines) + "\n"
+
+
+def save_query(config_file: Path, saved: SavedQuery) -> None:
+    """Append a query to a TOML config file, refusing names already taken."""
+    if config_file.suffix != ".toml":
+        raise ValueError(f"Add the query to the queries of {config_file} by hand")
+    if not QUERY_NAME.fullmatch(saved.name):
+        raise ValueError(f"Query name '{saved.name}' must be a word, hyphens allowed")
+    if not is_read_only(saved.query):
+        raise ValueError("Only queries that read can be saved")
+    if saved.name in saved_queries(config_file):
+        raise ValueError(f"A query named '{saved.name}' is already saved")
+    with config_file.open("a", encoding="utf-8") as f:
+        f.write(
+            saved_query_entry(
+                saved.name, saved.query, saved.description, saved.symbols
+            )
+        )
+
+
+def _value(text: str) -> Any:
+    if re.fullmatch(r"-?\d+", text):
+        return int(text)
+    if text.lower() in ("true", "false"):
+        return text.lower() == "true"
+    return text
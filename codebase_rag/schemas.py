@@ -41,6 +41,12 @@ class CodeSnippet(BaseModel):
     line_start: int
     line_end: int
     docstring: str | None = None
+    # CODEOWNERS and OWNERS users and teams owning the file
+    owners: list[str] = Field(default_factory=list)
+    # Callers, callees, types and tests within the context expansion policy
+    related: list[dict[str, Any]] = Field(default_factory=list)
+    # Source lines and related code left out to fit the model's token budget
+    omitted: list[str] = Field(default_factory=list)
     found: bool = True
     error_message: str | None = None
 
@@ -0,0 +1,381 @@
+"""Search for code by what it does, from embeddings and the call graph.
+
+`embed` stores a vector per function, method and class, computed from its
+name, docstring and source, on the node itself. Bodies too long to embed
+whole also get a vector per chunk; a chunk matching a question is reported
+as the symbol or module it belongs to, with the lines that matched. Doc
+comments and README sections, the Documentation nodes, get a vector of their
+own text, so a question about what a package is for can match what its
+authors wrote about it.
+
+A search embeds the question, ranks the symbols by cosine similarity, then
+pulls in the callers and callees of the best matches: the function that
+retries is often not the one whose words match, but the one calling it.
+Related symbols rank below the matches that brought them in unless their own
+similarity is higher. Generated code ranks below handwritten code.
+
+The provider, model and dimension of the vectors are kept on an
+EmbeddingIndex node. Embedding or searching with another embedder fails
+with an error saying how the index was built, instead of comparing vectors
+that share no space; `embed --rebuild` replaces the index.
+"""
+
+import hashlib
+import math
+from dataclasses import asdict, dataclass
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+
+from .chunking import MAX_CHUNK_LINES
+from .services.embeddings import Embedder
+from .services.rerankers import Reranker, rerank
+
+DOCUMENTS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(n)
+WHERE n:Function OR n:Method OR n:Class
+RETURN DISTINCT n.qualified_name AS qualified_name, labels(n)[0] AS label,
+       n.name AS name, n.docstring AS docstring, m.path AS path,
+       n.start_line AS start_line, n.end_line AS end_line,
+       n.embedding_hash AS embedding_hash, null AS parent
+UNION ALL
+MATCH (p)-[:HAS_CHUNK]->(c:Chunk)
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(p)
+RETURN DISTINCT c.qualified_name AS qualified_name, labels(p)[0] AS label,
+       p.name AS name, null AS docstring, coalesce(m.path, p.path) AS path,
+       c.start_line AS start_line, c.end_line AS end_line,
+       c.embedding_hash AS embedding_hash, p.qualified_name AS parent
+UNION ALL
+MATCH (d:Documentation)
+RETURN d.qualified_name AS qualified_name, 'Documentation' AS label,
+       d.name AS name, d.text AS docstring, d.path AS path,
+       null AS start_line, null AS end_line,
+       d.embedding_hash AS embedding_hash, null AS parent
+"""
+
+STORE_EMBEDDINGS = """
+UNWIND $rows AS row
+MATCH (n {qualified_name: row.qualified_name})
+WHERE n:Function OR n:Method OR n:Class OR n:Chunk OR n:Documentation
+SET n.embedding = row.embedding, n.embedding_model = row.model,
+    n.embedding_hash = row.hash
+"""
+
+VECTORS_QUERY = """
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(n)
+WHERE (n:Function OR n:Method OR n:Class) AND n.embedding_model = $model
+RETURN DISTINCT n.qualified_name AS qualified_name, labels(n)[0] AS label,
+       m.path AS path, n.start_line AS start_line, n.end_line AS end_line,
+       n.embedding AS embedding, null AS parent, null AS chunk_start,
+       null AS chunk_end, coalesce(n.generated, false) AS generated
+UNION ALL
+MATCH (p)-[:HAS_CHUNK]->(c:Chunk)
+WHERE c.embedding_model = $model
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(p)
+RETURN DISTINCT c.qualified_name AS qualified_name, labels(p)[0] AS label,
+       coalesce(m.path, p.path) AS path, p.start_line AS start_line,
+       p.end_line AS end_line, c.embedding AS embedding,
+       p.qualified_name AS parent, c.start_line AS chunk_start,
+       c.end_line AS chunk_end, coalesce(p.generated, false) AS generated
+UNION ALL
+MATCH (d:Documentation)
+WHERE d.embedding_model = $model
+RETURN d.qualified_name AS qualified_name, 'Documentation' AS label,
+       d.path AS path, d.start_line AS start_line, d.end_line AS end_line,
+       d.embedding AS embedding, null AS parent, null AS chunk_start,
+       null AS chunk_end, false AS generated
+"""
+
+INDEX_QUERY = """
+MATCH (i:EmbeddingIndex {name: 'code'})
+RETURN i.provider AS provider, i.model AS model, i.dimension AS dimension,
+       i.updated_at AS updated_at
+"""
+
+STORE_INDEX = """
+MERGE (i:EmbeddingIndex {name: 'code'})
+SET i.provider = $provider, i.model = $model, i.dimension = $dimension,
+    i.updated_at = $updated_at
+"""
+
+NEIGHBOURS_QUERY = """
+UNWIND $qualified_names AS qn
+MATCH (n {qualified_name: qn})-[r:CALLS]-(other)
+WHERE (n:Function OR n:Method) AND (other:Function OR other:Method)
+MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(other)
+RETURN DISTINCT qn AS hit, other.qualified_name AS qualified_name,
+       labels(other)[0] AS label, m.path AS path, other.start_line AS start_line,
+       other.end_line AS end_line,
+       CASE WHEN startNode(r) = n THEN 'callee' ELSE 'caller' END AS relation
+"""
+
+# Source lines embedded with a symbol; a class's first methods say enough,
+# and longer functions are embedded chunk by chunk as well
+MAX_SOURCE_LINES = MAX_CHUNK_LINES
+BATCH_SIZE = 64
+# Best matches whose callers and callees are added to the results
+EXPANDED_MATCHES = 5
+# Share of a match's score given to the symbols calling or called by it
+NEIGHBOUR_WEIGHT = 0.6
+# Share of its similarity kept by generated code, so handwritten code
+# matching as well ranks first (see generated_code.py)
+GENERATED_WEIGHT = 0.8
+
+
+class EmbeddingMismatchError(ValueError):
+    """Vectors of an embedder other than the one the index was built with."""
+
+
+@dataclass
+class SearchHit:
+    qualified_name: str
+    label: str
+    path: str | None
+    start_line: int | None
+    score: float
+    relation: str = "match"  # match, caller or callee
+    via: str | None = None  # The match a caller or callee was found through
+    end_line: int | None = None
+    rerank_score: float | None = None
+    # The lines of the chunk that matched, when the match was part of the body
+    chunk_start: int | None = None
+    chunk_end: int | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+def document_text(row: dict[str, Any], source: str = "") -> str:
+    """What is embedded for a symbol: its kind, name, docstring and source."""
+    parts = [f"{row['label']} {row.get('parent') or row['qualified_name']}"]
+    if row.get("docstring"):
+        parts.append(row["docstring"])
+    if source:
+        parts.append(source)
+    return "\n".join(parts)
+
+
+def cosine(first: list[float], second: list[float]) -> float:
+    dot = sum(x * y for x, y in zip(first, second))
+    norm = math.sqrt(sum(x * x for x in first) * sum(y * y for y in second))
+    return dot / norm if norm else 0.0
+
+
+class SemanticIndex:
+    """Embeddings of a graph's symbols and hybrid search over them."""
+
+    def __init__(
+        self,
+        ingestor: Any,
+        embedder: Embedder,
+        repo_path: Path | None = None,
+        reranker: Reranker | None = None,
+        rerank_top_k: int = 5,
+    ):
+        self.ingestor = ingestor
+        self.embedder = embedder
+        self.repo_path = repo_path
+        # Scores the results' source against the question, keeping the best
+        self.reranker = reranker
+        self.rerank_top_k = rerank_top_k
+        # Loaded on the first search
+        self._vectors: list[dict[str, Any]] | None = None
+
+    def info(self) -> dict[str, Any] | None:
+        """The provider, model and dimension the index was built with."""
+        rows = self.ingestor.fetch_all(INDEX_QUERY)
+        return rows[0] if rows else None
+
+    def build(self, rebuild: bool = False) -> dict[str, int]:
+        """
+        Embed every symbol whose text changed since it was last embedded, and
+        store the vectors on the nodes. An index built with another embedder
+        is only replaced, with every symbol embedded again, when rebuilding.
+        """
+        info = None if rebuild else self.info()
+        if info:
+            self._check(info)
+        pending = []
+        unchanged = 0
+        files: dict[str, list[str]] = {}
+        for row in self.ingestor.fetch_all(DOCUMENTS_QUERY):
+            text = document_text(row, self._source(row, files))
+            key = f"{self.embedder.name}\n{text}"
+            digest = hashlib.sha1(key.encode()).hexdigest()
+            if digest == row.get("embedding_hash") and not rebuild:
+                unchanged += 1
+                continue
+            pending.append((row["qualified_name"], text, digest))
+
+        for start in range(0, len(pending), BATCH_SIZE):
+            batch = pending[start : start + BATCH_SIZE]
+            vectors = self.embedder.embed([text for _, text, _ in batch])
+            if info and start == 0:
+                # API models report their dimension with the first vectors
+                self._check(info)
+            self.ingestor.execute_write(
+                STORE_EMBEDDINGS,
+                {
+                    "rows": [
+                        {
+                            "qualified_name": qn,
+                            "embedding": vector,
+                            "model": self.embedder.name,
+                            "hash": digest,
+                        }
+                        for (qn, _, digest), vector in zip(batch, vectors)
+                    ]
+                },
+            )
+            logger.info(f"Embedded {start + len(batch)}/{len(pending)} symbols")
+        if pending or not info:
+            self.ingestor.execute_write(
+                STORE_INDEX,
+                {
+                    "provider": self.embedder.provider,
+                    "model": self.embedder.name,
+                    "dimension": self.embedder.dimension
+                    or (info or {}).get("dimension"),
+                    "updated_at": datetime.now(UTC).isoformat(),
+                },
+            )
+        self._vectors = None
+        return {"embedded": len(pending), "unchanged": unchanged}
+
+    def _check(self, info: dict[str, Any]) -> None:
+        """Fail unless the embedder makes vectors like those of the index."""
+        built = (info.get("provider"), info.get("model"))
+        dimension = info.get("dimension")
+        if built != (self.embedder.provider, self.embedder.name) or (
+            dimension
+            and self.embedder.dimension
+            and dimension != self.embedder.dimension
+        ):
+            raise EmbeddingMismatchError(
+                f"The semantic index was built with {built[0]} model {built[1]} "
+                f"({dimension or 'unknown'} dimensions), not {self.embedder.provider} "
+                f"model {self.embedder.name} "
+                f"({self.embedder.dimension or 'unknown'} dimensions); switch back "
+                "or run `embed --rebuild`"
+            )
+
+    def search(
+        self, question: str, limit: int = 10, expand: bool = True
+    ) -> list[SearchHit]:
+        """
+        The symbols most similar to a question; when expanding, the callers and
+        callees of the best of them compete for the same places. With a
+        reranker, these are candidates of which the rerank_top_k most relevant
+        are returned.
+        """
+        if self._vectors is None:
+            self._vectors = self.ingestor.fetch_all(
+                VECTORS_QUERY, {"model": self.embedder.name}
+            )
+        if not self._vectors:
+            # No vectors of this embedder: none at all, or another's
+            info = self.info()
+            if info:
+                self._check(info)
+            return []
+        [query] = self.embedder.embed([question])
+        dimensions = {len(row["embedding"]) for row in self._vectors}
+        if dimensions != {len(query)}:
+            raise EmbeddingMismatchError(
+                f"The question has {len(query)} dimensions but the stored "
+                f"{self.embedder.name} vectors have "
+                f"{', '.join(map(str, sorted(dimensions)))}; run `embed --rebuild`"
+            )
+        # A symbol scores as its best matching vector, its own or a chunk's
+        best: dict[str, tuple[float, dict[str, Any]]] = {}
+        for row in self._vectors:
+            qn = row.get("parent") or row["qualified_name"]
+            score = cosine(query, row["embedding"])
+            if row.get("generated"):
+                score *= GENERATED_WEIGHT
+            if qn not in best or score > best[qn][0]:
+                best[qn] = (score, row)
+        scores = {qn: score for qn, (score, _) in best.items()}
+        ranked = sorted(best.items(), key=lambda item: -item[1][0])
+        hits = {
+            qn: SearchHit(
+                qn,
+                row["label"],
+                row["path"],
+                row["start_line"],
+                round(score, 4),
+                end_line=row.get("end_line"),
+                chunk_start=row.get("chunk_start"),
+                chunk_end=row.get("chunk_end"),
+            )
+            for qn, (score, row) in ranked[:limit]
+            if score > 0
+        }
+        if not expand or not hits:
+            return self._rerank(question, list(hits.values()))
+
+        best = list(hits)[:EXPANDED_MATCHES]
+        related: dict[str, SearchHit] = {}
+        for row in self.ingestor.fetch_all(
+            NEIGHBOURS_QUERY, {"qualified_names": best}
+        ):
+            qn = row["qualified_name"]
+            if qn in hits:
+                continue
+            score = max(
+                NEIGHBOUR_WEIGHT * hits[row["hit"]].score, scores.get(qn, 0.0)
+            )
+            if qn not in related or score > related[qn].score:
+                related[qn] = SearchHit(
+                    qn,
+                    row["label"],
+                    row["path"],
+                    row["start_line"],
+                    round(score, 4),
+                    row["relation"],
+                    row["hit"],
+                    row.get("end_line"),
+                )
+        combined = sorted(
+            [*hits.values(), *related.values()],
+            key=lambda h: (-h.score, h.relation != "match", h.qualified_name),
+        )
+        return self._rerank(question, combined[:limit])
+
+    def _rerank(self, question: str, hits: list[SearchHit]) -> list[SearchHit]:
+        if self.reranker is None:
+            return hits
+        files: dict[str, list[str]] = {}
+
+        def snippet(hit: SearchHit) -> str:
+            row = hit.to_dict()
+            if hit.chunk_start:
+                row.update(start_line=hit.chunk_start, end_line=hit.chunk_end)
+            return document_text(row, self._source(row, files))
+
+        reranked = rerank(
+            self.reranker, question, hits, snippet, self.rerank_top_k
+        )
+        for hit, score in reranked:
+            hit.rerank_score = None if score is None else round(score, 4)
+        return [hit for hit, _ in reranked]
+
+    def _source(self, row: dict[str, Any], files: dict[str, list[str]]) -> str:
+        path = row.get("path")
+        if not self.repo_path or not path or not row.get("start_line"):
+            return ""
+        if path not in files:
+            try:
+                files[path] = (
+                    (self.repo_path / path)
+                    .read_text(encoding="utf-8", errors="replace")
+                    .splitlines()
+                )
+            except OSError:
+                files[path] = []
+        lines = files[path]
+        start = row["start_line"] - 1
+        end = min(row.get("end_line") or start + 1, start + MAX_SOURCE_LINES)
+        return "\n".join(lines[start:end])
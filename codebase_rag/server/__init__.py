@@ -0,0 +1,7 @@
+"""HTTP server mode: webhook receivers that keep a shared graph current, an
+endpoint for editor extensions to query it, and Slack and Discord bots.
+"""
+
+from .app import GraphServer, Request, Response
+
+__all__ = ["GraphServer", "Request", "Response"]
@@ -0,0 +1,135 @@
+"""REST endpoints for web UIs and bots built on a running server.
+
+Every endpoint takes an API token (`Authorization: Bearer <token>`) with
+access to the served project:
+
+    GET  /api/symbols?q=cart.total&limit=10   read-only: fuzzy symbol lookup
+    POST /api/ask {"question": "..."}         analyst: answer with citations
+                                              (tokens scoped to all repos)
+    POST /api/ingest                          admin: fetch and apply new commits
+
+Raw Cypher is POST /query (see query.py), registered alongside.
+"""
+
+from collections.abc import Callable
+from typing import Any
+from urllib.parse import parse_qs, urlsplit
+
+from ..citations import CitationResolver
+from ..mcp.tools import SYMBOL_DETAILS_QUERY
+from ..services.graph_service import MemgraphIngestor
+from ..symbol_search import SYMBOLS_QUERY, SymbolIndex
+from .app import GraphServer, Request, Response
+from .auth import Role, TokenRegistry, check_access
+from .query import create_query_routes
+
+DEFAULT_SYMBOL_LIMIT = 10
+MAX_SYMBOL_LIMIT = 100
+
+
+class RestApi:
+    """Handlers of the /api endpoints for one served project."""
+
+    def __init__(
+        self,
+        ingestor: MemgraphIngestor,
+        registry: TokenRegistry,
+        project: str,
+        ingest: Callable[[], dict[str, Any]],
+        answer: Callable[[str], str] | None = None,
+    ):
+        self.ingestor = ingestor
+        self.registry = registry
+        self.project = project
+        self.ingest_changes = ingest
+        self.answer = answer  # None without a language model configured
+
+    def symbols(self, request: Request) -> Response:
+        token = check_access(self.registry, request, Role.READ_ONLY, self.project)
+        if isinstance(token, Response):
+            return token
+        query = parse_qs(urlsplit(request.path).query)
+        name = (query.get("q") or [""])[0].strip()
+        if not name:
+            return Response(400, {"error": "expected a 'q' query parameter"})
+        try:
+            limit = int((query.get("limit") or [DEFAULT_SYMBOL_LIMIT])[0])
+        except ValueError:
+            return Response(400, {"error": "'limit' must be an integer"})
+        limit = max(1, min(limit, MAX_SYMBOL_LIMIT))
+
+        prefix = f"{self.project}."
+        index = SymbolIndex(
+            [
+                (row["qualified_name"], row["label"])
+                for row in self.ingestor.fetch_all(SYMBOLS_QUERY)
+                if row["qualified_name"].startswith(prefix)
+            ]
+        )
+        matches = index.search(name, limit=limit)
+        details = {
+            row["qualified_name"]: row
+            for row in self.ingestor.fetch_all(
+                SYMBOL_DETAILS_QUERY,
+                {"qualified_names": [m.qualified_name for m in matches]},
+            )
+        }
+        return Response(
+            200,
+            {
+                "symbols": [
+                    {
+                        **details.get(m.qualified_name, {}),
+                        "qualified_name": m.qualified_name,
+                        "label": m.label,
+                        "score": round(m.score, 3),
+                    }
+                    for m in matches
+                ]
+            },
+        )
+
+    def ask(self, request: Request) -> Response:
+        token = check_access(self.registry, request, Role.ANALYST, self.project)
+        if isinstance(token, Response):
+            return token
+        # The agent's queries and the citations reach every project in the graph
+        if not token.all_repos:
+            return Response(
+                403, {"error": "questions need a token scoped to all repos"}
+            )
+        if self.answer is None:
+            return Response(503, {"error": "no language model is configured"})
+        try:
+            payload = request.json()
+        except ValueError:
+            return Response(400, {"error": "invalid JSON"})
+        question = payload.get("question") if isinstance(payload, dict) else None
+        if not isinstance(question, str) or not question.strip():
+            return Response(400, {"error": "expected a 'question' string"})
+        text = self.answer(question.strip())
+        cited = CitationResolver(self.ingestor, self.project).cite(text)
+        return Response(200, cited.to_dict())
+
+    def ingest(self, request: Request) -> Response:
+        token = check_access(self.registry, request, Role.ADMIN, self.project)
+        if isinstance(token, Response):
+            return token
+        return Response(200, self.ingest_changes())
+
+
+def create_api_routes(
+    server: GraphServer,
+    ingestor: MemgraphIngestor,
+    registry: TokenRegistry,
+    project: str,
+    ingest: Callable[[], dict[str, Any]],
+    answer: Callable[[str], str] | None = None,
+) -> RestApi:
+    """Register the /api endpoints and POST /query on a GraphServer."""
+    api = RestApi(ingestor, registry, project, ingest, answer)
+    server.route("GET", "/api/symbols", api.symbols)
+    server.route("POST", "/api/ask", api.ask)
+    server.route("POST", "/api/ingest", api.ingest)
+    create_query_routes(server, ingestor, registry)
+    return api
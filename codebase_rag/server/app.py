@@ -0,0 +1,117 @@
+"""A small routing layer over the standard library HTTP server."""
+
+import json
+import traceback
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from typing import Any
+
+from loguru import logger
+
+from .repositories import redact_credentials
+
+# Upper bound on accepted request bodies; push payloads are well below this
+MAX_BODY_BYTES = 25 * 1024 * 1024
+
+
+@dataclass
+class Request:
+    """An incoming HTTP request with lower-cased header names."""
+
+    method: str
+    path: str
+    headers: dict[str, str] = field(default_factory=dict)
+    body: bytes = b""
+
+    def header(self, name: str, default: str = "") -> str:
+        return self.headers.get(name.lower(), default)
+
+    def json(self) -> Any:
+        return json.loads(self.body or b"{}")
+
+
+@dataclass
+class Response:
+    """A JSON response."""
+
+    status: int
+    body: dict[str, Any] = field(default_factory=dict)
+
+
+Handler = Callable[[Request], Response]
+
+
+class BadRequestError(Exception):
+    """Raised by a handler for a request it refuses; answered with a 400."""
+
+
+class GraphServer:
+    """Dispatches requests to handlers registered per method and path."""
+
+    def __init__(self) -> None:
+        self.routes: dict[tuple[str, str], Handler] = {}
+
+    def route(self, method: str, path: str, handler: Handler) -> None:
+        self.routes[(method.upper(), path.rstrip("/") or "/")] = handler
+
+    def handle(self, request: Request) -> Response:
+        path = request.path.split("?", 1)[0].rstrip("/") or "/"
+        handler = self.routes.get((request.method.upper(), path))
+        if handler is None:
+            if any(route_path == path for _, route_path in self.routes):
+                return Response(405, {"error": "method not allowed"})
+            return Response(404, {"error": "not found"})
+        try:
+            return handler(request)
+        except BadRequestError as e:
+            return Response(400, {"error": str(e)})
+        except Exception as e:
+            # Failed git commands name their remote, which may carry a token;
+            # the response says nothing the caller could learn it from
+            details = redact_credentials("".join(traceback.format_exception(e)))
+            logger.error(f"Handler for {request.method} {path} failed\n{details}")
+            return Response(500, {"error": "internal server error"})
+
+    def serve(self, host: str, port: int) -> None:
+        """Serve until interrupted."""
+        httpd = ThreadingHTTPServer((host, port), self._request_handler())
+        logger.info(f"Listening on http://{host}:{port}")
+        try:
+            httpd.serve_forever()
+        except KeyboardInterrupt:
+            logger.info("Shutting down server")
+        finally:
+            httpd.server_close()
+
+    def _request_handler(self) -> type[BaseHTTPRequestHandler]:
+        server = self
+
+        class RequestHandler(BaseHTTPRequestHandler):
+            def _dispatch(self) -> None:
+                length = int(self.headers.get("Content-Length") or 0)
+                if length > MAX_BODY_BYTES:
+                    self._send(Response(413, {"error": "payload too large"}))
+                    return
+                request = Request(
+                    method=self.command,
+                    path=self.path,
+                    headers={k.lower(): v for k, v in self.headers.items()},
+                    body=self.rfile.read(length) if length else b"",
+                )
+                self._send(server.handle(request))
+
+            def _send(self, response: Response) -> None:
+                payload = json.dumps(response.body).encode("utf-8")
+                self.send_response(response.status)
+                self.send_header("Content-Type", "application/json")
+                self.send_header("Content-Length", str(len(payload)))
+                self.end_headers()
+                self.wfile.write(payload)
+
+            def log_message(self, format: str, *args: Any) -> None:
+                logger.debug(f"{self.address_string()} - {format % args}")
+
+            do_GET = do_POST = _dispatch
+
+        return RequestHandler
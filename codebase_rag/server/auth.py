@@ -0,0 +1,138 @@
+"""API tokens with roles and repository scopes for the server endpoints.
+
+Tokens live in a TOML file (API_TOKENS_FILE) holding only their SHA-256
+hashes, so the file can be shared with operators without handing out access:
+
+    [tokens.ci-bot]
+    sha256 = "9f86d081..."
+    role = "read-only"
+    repos = ["shop", "billing"]
+
+Roles are ordered: read-only navigates the graph, analyst may also ask the
+language model and run read-only Cypher, admin may run any Cypher. A token
+reaches only the graph projects listed in repos, or all of them with "*".
+"""
+
+import hashlib
+import secrets
+import tomllib
+from dataclasses import dataclass
+from enum import IntEnum
+from pathlib import Path
+from typing import Any
+
+import toml
+
+from .app import Request, Response
+
+ALL_REPOS = "*"
+
+
+class Role(IntEnum):
+    READ_ONLY = 1
+    ANALYST = 2
+    ADMIN = 3
+
+    @classmethod
+    def parse(cls, name: str) -> "Role":
+        try:
+            return cls[name.strip().upper().replace("-", "_")]
+        except KeyError:
+            raise ValueError(
+                f"Unknown role '{name}', expected one of {', '.join(ROLE_NAMES)}"
+            ) from None
+
+    @property
+    def label(self) -> str:
+        return self.name.lower().replace("_", "-")
+
+
+ROLE_NAMES = [role.label for role in Role]
+
+
+@dataclass(frozen=True)
+class ApiToken:
+    """Who a token belongs to and what it may do."""
+
+    name: str
+    role: Role
+    repos: tuple[str, ...] = (ALL_REPOS,)
+
+    def allows(self, role: Role) -> bool:
+        return self.role >= role
+
+    def allows_repo(self, project: str) -> bool:
+        return ALL_REPOS in self.repos or project in self.repos
+
+    @property
+    def all_repos(self) -> bool:
+        return ALL_REPOS in self.repos
+
+
+def hash_token(secret: str) -> str:
+    return hashlib.sha256(secret.encode()).hexdigest()
+
+
+class TokenRegistry:
+    """Looks up the token presented as `Authorization: Bearer <token>`."""
+
+    def __init__(self, tokens: dict[str, ApiToken] | None = None):
+        # By SHA-256 hash of the secret
+        self.tokens = tokens or {}
+
+    @classmethod
+    def from_file(cls, path: Path) -> "TokenRegistry":
+        if not path.is_file():
+            return cls()
+        document = tomllib.loads(path.read_text(encoding="utf-8"))
+        tokens = {}
+        for name, entry in (document.get("tokens") or {}).items():
+            try:
+                tokens[entry["sha256"]] = ApiToken(
+                    name,
+                    Role.parse(entry.get("role", "read-only")),
+                    tuple(entry.get("repos") or [ALL_REPOS]),
+                )
+            except (KeyError, TypeError, ValueError) as e:
+                raise ValueError(f"{path}: token '{name}' is invalid: {e}") from e
+        return cls(tokens)
+
+    def authenticate(self, request: Request) -> ApiToken | None:
+        header = request.header("Authorization")
+        if not header.startswith("Bearer "):
+            return None
+        return self.tokens.get(hash_token(header.removeprefix("Bearer ").strip()))
+
+
+def add_token(path: Path, name: str, role: Role, repos: list[str]) -> str:
+    """Create a token in the tokens file, replacing one of the same name."""
+    document: dict[str, Any] = (
+        tomllib.loads(path.read_text(encoding="utf-8")) if path.is_file() else {}
+    )
+    secret = secrets.token_urlsafe(32)
+    document.setdefault("tokens", {})[name] = {
+        "sha256": hash_token(secret),
+        "role": role.label,
+        "repos": repos or [ALL_REPOS],
+    }
+    path.parent.mkdir(parents=True, exist_ok=True)
+    path.write_text(toml.dumps(document), encoding="utf-8")
+    path.chmod(0o600)
+    return secret
+
+
+def check_access(
+    registry: TokenRegistry,
+    request: Request,
+    role: Role,
+    project: str | None = None,
+) -> ApiToken | Response:
+    """The caller's token, or the response refusing the request."""
+    token = registry.authenticate(request)
+    if token is None:
+        return Response(401, {"error": "missing or unknown token"})
+    if not token.allows(role):
+        return Response(403, {"error": f"requires the {role.label} role"})
+    if project is not None and not token.allows_repo(project):
+        return Response(403, {"error": f"no access to {project}"})
+    return token
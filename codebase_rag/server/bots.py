@@ -0,0 +1,383 @@
+"""Slack and Discord bots answering code questions from the graph.
+
+Each chat channel is scoped to one ingested project by a bot configuration
+file, so a team's channel only gets answers about its own repository:
+
+    projects:
+      shop:
+        path: /srv/repos/shop
+        source_url: https://github.com/acme/shop/blob/main
+    channels:
+      C024BE91L: shop            # Slack channel id
+      "1120336614450098276": shop  # Discord channel id
+    default_project: shop        # Optional, for channels not listed
+
+Answers come from the RAG agent and cite the definitions they mention, linked
+to the hosted source when the project has a source_url. Both platforms expect
+a reply to their HTTP request within seconds, so questions are acknowledged
+at once and answered in the background: in the message's thread on Slack,
+as the deferred reply to the /ask command on Discord.
+"""
+
+import hashlib
+import hmac
+import json
+import re
+import threading
+import time
+import urllib.request
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any
+
+import yaml
+from loguru import logger
+
+from ..citations import Citation, CitationResolver
+from ..services.graph_service import MemgraphIngestor
+from ..utils.ed25519 import verify
+from .app import GraphServer, Request, Response
+
+SLACK_MENTION = re.compile(r"<@[A-Z0-9]+>")
+
+MAX_CITATIONS = 5
+# Slack rejects requests whose timestamp is older than this, against replays
+SLACK_MAX_AGE_SECONDS = 300
+DISCORD_MESSAGE_LIMIT = 2000
+DISCORD_ASK_COMMAND = "ask"
+
+NOT_SCOPED_REPLY = (
+    "This channel is not linked to a repository, so I can't answer questions here."
+)
+
+
+@dataclass
+class BotProject:
+    """An ingested project a channel can ask about."""
+
+    name: str  # First segment of the project's qualified names
+    path: Path  # Checkout, for reading source code
+    source_url: str = ""  # Prefix of file links, e.g. .../blob/main
+
+    def link(self, citation: Citation) -> str | None:
+        """A link to the cited code in the hosted source, if there is one."""
+        if not self.source_url:
+            return None
+        return citation.url(self.path, self.source_url)
+
+
+@dataclass
+class BotAnswer:
+    text: str
+    project: BotProject
+    citations: list[Citation] = field(default_factory=list)
+
+
+Answerer = Callable[[str, BotProject], str]
+Background = Callable[[Callable[[], None]], None]
+
+
+class ChannelScopes:
+    """Which project each chat channel asks about."""
+
+    def __init__(
+        self,
+        projects: dict[str, BotProject],
+        channels: dict[str, str],
+        default_project: str | None = None,
+    ):
+        for project in [*channels.values(), default_project]:
+            if project is not None and project not in projects:
+                raise ValueError(f"Channel scoped to unknown project '{project}'")
+        self.projects = projects
+        self.channels = channels
+        self.default_project = default_project
+
+    @classmethod
+    def load(cls, path: Path) -> "ChannelScopes":
+        """Read a bot configuration file (see the module docstring)."""
+        with open(path, encoding="utf-8") as f:
+            data = yaml.safe_load(f) or {}
+        projects = {
+            name: BotProject(
+                name,
+                Path(options["path"]).expanduser(),
+                options.get("source_url", ""),
+            )
+            for name, options in (data.get("projects") or {}).items()
+        }
+        # YAML reads unquoted numeric Discord ids as integers
+        channels = {
+            str(channel): project
+            for channel, project in (data.get("channels") or {}).items()
+        }
+        return cls(projects, channels, data.get("default_project"))
+
+    def project_for(self, channel: str) -> BotProject | None:
+        name = self.channels.get(channel, self.default_project)
+        return self.projects.get(name) if name else None
+
+
+class CodeQuestionBot:
+    """Answers a channel's question about its project, with citations."""
+
+    def __init__(
+        self, ingestor: MemgraphIngestor, scopes: ChannelScopes, answerer: Answerer
+    ):
+        self.ingestor = ingestor
+        self.scopes = scopes
+        self.answerer = answerer
+
+    def answer(self, channel: str, question: str) -> BotAnswer | None:
+        """None when the channel is not scoped to a project."""
+        project = self.scopes.project_for(channel)
+        if project is None:
+            return None
+        text = self.answerer(question, project)
+        return BotAnswer(text, project, self._citations(text, project))
+
+    def _citations(self, text: str, project: BotProject) -> list[Citation]:
+        """The project's code the answer cites, once each, in order of mention."""
+        cited = CitationResolver(self.ingestor, project.name).cite(text)
+        citations: list[Citation] = []
+        seen: set[str | None] = set()
+        for citation in cited.citations:
+            if citation.start_line is None or citation.qualified_name in seen:
+                continue
+            seen.add(citation.qualified_name)
+            citations.append(citation)
+        return citations[:MAX_CITATIONS]
+
+
+def scoped_question(question: str, project: BotProject) -> str:
+    """The prompt that keeps the agent to the channel's project."""
+    return (
+        f"Answer about the `{project.name}` project only: the qualified names "
+        f"of its code start with '{project.name}.'. Wrap every function, method "
+        f"and class you mention in backticks, using its qualified name.\n\n"
+        f"{question}"
+    )
+
+
+def in_background(task: Callable[[], None]) -> None:
+    threading.Thread(target=task, daemon=True).start()
+
+
+class SlackBot:
+    """Handles Slack Events API requests: mentions and direct messages."""
+
+    def __init__(
+        self,
+        bot: CodeQuestionBot,
+        signing_secret: str | None,
+        token: str,
+        api_url: str = "https://slack.com/api",
+        background: Background = in_background,
+    ):
+        self.bot = bot
+        self.signing_secret = signing_secret
+        self.token = token
+        self.api_url = api_url.rstrip("/")
+        self.background = background
+
+    def __call__(self, request: Request) -> Response:
+        if self.signing_secret and not verify_slack_signature(
+            self.signing_secret,
+            request.body,
+            request.header("X-Slack-Request-Timestamp"),
+            request.header("X-Slack-Signature"),
+        ):
+            return Response(401, {"error": "invalid signature"})
+
+        payload = request.json()
+        if payload.get("type") == "url_verification":
+            return Response(200, {"challenge": payload.get("challenge", "")})
+        if request.header("X-Slack-Retry-Num"):
+            # Slack retries slow acknowledgements; the first delivery is answered
+            return Response(200, {"status": "ignored", "reason": "retry"})
+
+        event = payload.get("event") or {}
+        is_question = event.get("type") == "app_mention" or (
+            event.get("type") == "message" and event.get("channel_type") == "im"
+        )
+        if not is_question or event.get("bot_id") or event.get("subtype"):
+            return Response(200, {"status": "ignored"})
+
+        question = SLACK_MENTION.sub("", event.get("text", "")).strip()
+        channel = event["channel"]
+        thread = event.get("thread_ts") or event["ts"]
+        self.background(lambda: self._reply(channel, thread, question))
+        return Response(200, {"status": "accepted"})
+
+    def _reply(self, channel: str, thread: str, question: str) -> None:
+        try:
+            answer = self.bot.answer(channel, question)
+            text = format_slack_answer(answer) if answer else NOT_SCOPED_REPLY
+        except Exception as e:
+            logger.exception(f"Answering Slack question in {channel} failed")
+            text = f"Sorry, I couldn't answer that: {e}"
+        _send_json(
+            "POST",
+            f"{self.api_url}/chat.postMessage",
+            {"channel": channel, "thread_ts": thread, "text": text},
+            {"Authorization": f"Bearer {self.token}"},
+        )
+
+
+class DiscordBot:
+    """Handles Discord interactions: the /ask slash command."""
+
+    def __init__(
+        self,
+        bot: CodeQuestionBot,
+        public_key: str,
+        api_url: str = "https://discord.com/api/v10",
+        background: Background = in_background,
+    ):
+        self.bot = bot
+        self.public_key = public_key
+        self.api_url = api_url.rstrip("/")
+        self.background = background
+
+    def __call__(self, request: Request) -> Response:
+        # Discord requires verification, and probes it with bad signatures
+        if not verify_discord_signature(
+            self.public_key,
+            request.body,
+            request.header("X-Signature-Timestamp"),
+            request.header("X-Signature-Ed25519"),
+        ):
+            return Response(401, {"error": "invalid signature"})
+
+        interaction = request.json()
+        if interaction.get("type") == 1:  # PING
+            return Response(200, {"type": 1})
+        data = interaction.get("data") or {}
+        if interaction.get("type") != 2 or data.get("name") != DISCORD_ASK_COMMAND:
+            return Response(
+                200, {"type": 4, "data": {"content": "Unsupported command."}}
+            )
+
+        options = {o["name"]: o.get("value") for o in data.get("options") or []}
+        question = str(options.get("question") or "").strip()
+        channel = str(interaction.get("channel_id", ""))
+        followup = (
+            f"{self.api_url}/webhooks/{interaction['application_id']}/"
+            f"{interaction['token']}/messages/@original"
+        )
+        self.background(lambda: self._reply(channel, question, followup))
+        # DEFERRED_CHANNEL_MESSAGE_WITH_SOURCE: "thinking..." until edited
+        return Response(200, {"type": 5})
+
+    def _reply(self, channel: str, question: str, followup: str) -> None:
+        try:
+            answer = self.bot.answer(channel, question)
+            text = format_discord_answer(answer) if answer else NOT_SCOPED_REPLY
+        except Exception as e:
+            logger.exception(f"Answering Discord question in {channel} failed")
+            text = f"Sorry, I couldn't answer that: {e}"
+        _send_json("PATCH", followup, {"content": text[:DISCORD_MESSAGE_LIMIT]})
+
+
+def verify_slack_signature(
+    secret: str, body: bytes, timestamp: str, signature: str
+) -> bool:
+    if not timestamp.isdigit():
+        return False
+    if abs(time.time() - int(timestamp)) > SLACK_MAX_AGE_SECONDS:
+        return False
+    base = b"v0:" + timestamp.encode() + b":" + body
+    expected = "v0=" + hmac.new(secret.encode(), base, hashlib.sha256).hexdigest()
+    return hmac.compare_digest(expected, signature)
+
+
+def verify_discord_signature(
+    public_key: str, body: bytes, timestamp: str, signature: str
+) -> bool:
+    try:
+        key, signed = bytes.fromhex(public_key), bytes.fromhex(signature)
+    except ValueError:
+        return False
+    return verify(key, timestamp.encode() + body, signed)
+
+
+def format_slack_answer(answer: BotAnswer) -> str:
+    """Slack mrkdwn: the answer, then its sources as links."""
+    if not answer.citations:
+        return answer.text
+    lines = [answer.text, "", "*Sources*"]
+    for citation in answer.citations:
+        url = answer.project.link(citation)
+        name = citation.qualified_name
+        if url:
+            name = f"<{url}|{name}>"
+        lines.append(f"• {name} ({citation.location})")
+    return "\n".join(lines)
+
+
+def format_discord_answer(answer: BotAnswer) -> str:
+    """Discord markdown; sources come first when the answer must be cut."""
+    if not answer.citations:
+        return answer.text
+    sources = ["**Sources**"]
+    for citation in answer.citations:
+        url = answer.project.link(citation)
+        # Angle brackets stop Discord from embedding a preview of each link
+        name = (
+            f"[`{citation.qualified_name}`](<{url}>)"
+            if url
+            else f"`{citation.qualified_name}`"
+        )
+        sources.append(f"- {name} ({citation.location})")
+    footer = "\n".join(sources)
+    room = DISCORD_MESSAGE_LIMIT - len(footer) - 3
+    text = answer.text
+    if len(text) > room:
+        text = text[: room - 1] + "…"
+    return f"{text}\n\n{footer}"
+
+
+def create_bot_routes(
+    server: GraphServer,
+    bot: CodeQuestionBot,
+    slack_token: str | None = None,
+    slack_signing_secret: str | None = None,
+    discord_public_key: str | None = None,
+) -> list[str]:
+    """Register the receivers of the configured platforms; returns their paths."""
+    paths = []
+    if slack_token:
+        server.route(
+            "POST", "/slack/events", SlackBot(bot, slack_signing_secret, slack_token)
+        )
+        paths.append("/slack/events")
+    if discord_public_key:
+        server.route(
+            "POST", "/discord/interactions", DiscordBot(bot, discord_public_key)
+        )
+        paths.append("/discord/interactions")
+    server.route("GET", "/healthz", lambda _: Response(200, {"status": "ok"}))
+    return paths
+
+
+def _send_json(
+    method: str,
+    url: str,
+    payload: dict[str, Any],
+    headers: dict[str, str] | None = None,
+) -> None:
+    request = urllib.request.Request(
+        url,
+        data=json.dumps(payload).encode("utf-8"),
+        method=method,
+        headers={
+            **(headers or {}),
+            "Content-Type": "application/json; charset=utf-8",
+        },
+    )
+    with urllib.request.urlopen(request, timeout=30) as response:
+        body = json.loads(response.read().decode("utf-8") or "{}")
+    # Slack answers 200 with {"ok": false, "error": ...} on failures
+    if body.get("ok") is False:
+        logger.error(f"{url} rejected the reply: {body.get('error')}")
@@ -0,0 +1,312 @@
+"""JSON-RPC endpoint for editor extensions (VS Code, Neovim) to a running server.
+
+Extensions POST JSON-RPC 2.0 requests to /rpc. The server's graph may have
+been ingested from other checkouts than the editor's, so every request names
+the editor's workspace folders: a folder maps to the graph project of the
+same directory name unless it names its project explicitly, and locations
+returned by the graph are translated back to paths in those folders.
+"""
+
+import hmac
+from collections.abc import Callable
+from dataclasses import dataclass
+from pathlib import PurePath
+from typing import Any
+
+from loguru import logger
+
+from ..lsp.protocol import (
+    INTERNAL_ERROR,
+    INVALID_PARAMS,
+    INVALID_REQUEST,
+    METHOD_NOT_FOUND,
+    PARSE_ERROR,
+    JsonRpcError,
+    error_response,
+)
+from ..lsp.server import EXPLAIN_QUERY, explain_symbol
+from ..services.graph_service import MemgraphIngestor
+from .app import GraphServer, Request, Response
+from .auth import ApiToken, Role, TokenRegistry
+
+# A node and the module that defines it (modules define themselves)
+NODE_LOCATION_QUERY = """
+MATCH (s {qualified_name: $qualified_name})
+OPTIONAL MATCH (m:Module)-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+RETURN s.qualified_name AS qualified_name, labels(s)[0] AS label,
+       coalesce(m.qualified_name, s.qualified_name) AS module,
+       coalesce(m.path, s.path) AS path,
+       s.start_line AS start_line, s.end_line AS end_line
+LIMIT 1
+"""
+
+# Definitions overlapping a range of lines, outermost first
+SELECTION_SYMBOLS_QUERY = """
+MATCH (m:Module {path: $path})-[:DEFINES|DEFINES_METHOD*1..2]->(s)
+WHERE (s:Function OR s:Method OR s:Class)
+  AND (m.qualified_name = $project OR m.qualified_name STARTS WITH $project + '.')
+  AND s.start_line <= $end_line AND s.end_line >= $start_line
+RETURN s.qualified_name AS qualified_name, s.name AS name,
+       labels(s)[0] AS label, m.qualified_name AS module, m.path AS path,
+       s.start_line AS start_line, s.end_line AS end_line
+ORDER BY s.start_line
+LIMIT 10
+"""
+
+DEFAULT_QUESTION = "Explain what this code does and how the rest of the code uses it."
+
+# JSON-RPC error for requests an API token may not make (server-defined range)
+FORBIDDEN = -32001
+
+# The least role allowed to call each method; asking uses the language model
+METHOD_ROLES = {
+    "graph/jumpToNode": Role.READ_ONLY,
+    "graph/insertCitation": Role.READ_ONLY,
+    "graph/askAboutSelection": Role.ANALYST,
+}
+# Methods answered by the language model, whose queries reach every project
+ANSWERING_METHODS = {"graph/askAboutSelection"}
+
+Answerer = Callable[[str], str]
+
+
+@dataclass
+class WorkspaceFolder:
+    """A folder open in the editor and the graph project it holds."""
+
+    path: PurePath
+    project: str
+
+
+class WorkspaceMap:
+    """Translates between editor file paths and graph projects and paths."""
+
+    def __init__(self, folders: list[WorkspaceFolder]):
+        # Longest paths first, so nested folders win over their parents
+        self.folders = sorted(folders, key=lambda f: len(f.path.parts), reverse=True)
+
+    @classmethod
+    def from_params(cls, params: dict[str, Any]) -> "WorkspaceMap":
+        folders = []
+        for folder in params.get("workspaceFolders") or []:
+            if isinstance(folder, str):
+                folder = {"path": folder}
+            path = PurePath(folder["path"])
+            folders.append(WorkspaceFolder(path, folder.get("project") or path.name))
+        return cls(folders)
+
+    def to_graph(self, file: str) -> tuple[str, str] | None:
+        """The project and repository-relative path of an editor file."""
+        path = PurePath(file)
+        for folder in self.folders:
+            if path.is_relative_to(folder.path):
+                return folder.project, path.relative_to(folder.path).as_posix()
+        return None
+
+    def to_editor(self, module: str, path: str | None) -> str | None:
+        """The editor path of a graph path, if its project is open."""
+        if not path:
+            return None
+        project = module.split(".", 1)[0]
+        for folder in self.folders:
+            if folder.project == project:
+                return str(folder.path / path)
+        return None
+
+
+class EditorRpc:
+    """Handles JSON-RPC requests from editor extensions."""
+
+    def __init__(
+        self,
+        ingestor: MemgraphIngestor,
+        answerer: Answerer | None = None,
+        token: str | None = None,
+        registry: TokenRegistry | None = None,
+    ):
+        self.ingestor = ingestor
+        self.answerer = answerer
+        # API tokens take over from the single shared token when configured
+        self.token = token
+        self.registry = registry
+        self.methods: dict[str, Callable[[dict[str, Any]], Any]] = {
+            "graph/askAboutSelection": self.ask_about_selection,
+            "graph/insertCitation": self.insert_citation,
+            "graph/jumpToNode": self.jump_to_node,
+        }
+
+    def __call__(self, request: Request) -> Response:
+        api_token = None
+        if self.registry:
+            api_token = self.registry.authenticate(request)
+            if api_token is None:
+                return Response(401, {"error": "missing or unknown token"})
+        elif self.token:
+            supplied = request.header("Authorization").removeprefix("Bearer ")
+            if not hmac.compare_digest(supplied, self.token):
+                return Response(401, {"error": "invalid token"})
+        try:
+            message = request.json()
+        except ValueError:
+            error = JsonRpcError(PARSE_ERROR, "Invalid JSON")
+            return Response(200, error_response(None, error))
+        return Response(200, self.handle(message, api_token))
+
+    def handle(self, message: Any, api_token: ApiToken | None = None) -> dict[str, Any]:
+        request_id = message.get("id") if isinstance(message, dict) else None
+        try:
+            if not isinstance(message, dict) or "method" not in message:
+                raise JsonRpcError(INVALID_REQUEST, "Expected a JSON-RPC request")
+            method = self.methods.get(message["method"])
+            if method is None:
+                raise JsonRpcError(
+                    METHOD_NOT_FOUND, f"Unsupported method {message['method']}"
+                )
+            params = message.get("params") or {}
+            if api_token:
+                _authorize(
+                    api_token, message["method"], params, self.answerer is not None
+                )
+            result = method(params)
+        except JsonRpcError as e:
+            return error_response(request_id, e)
+        except KeyError as e:
+            error = JsonRpcError(INVALID_PARAMS, f"Missing parameter {e}")
+            return error_response(request_id, error)
+        except Exception as e:
+            logger.exception(f"Editor request {message.get('method')} failed")
+            return error_response(request_id, JsonRpcError(INTERNAL_ERROR, str(e)))
+        return {"jsonrpc": "2.0", "id": request_id, "result": result}
+
+    def jump_to_node(self, params: dict[str, Any]) -> dict[str, Any] | None:
+        """Where a node, given by qualified name, is defined in the workspace."""
+        rows = self.ingestor.fetch_all(
+            NODE_LOCATION_QUERY, {"qualified_name": params["qualifiedName"]}
+        )
+        if not rows:
+            return None
+        return _location(rows[0], WorkspaceMap.from_params(params))
+
+    def insert_citation(self, params: dict[str, Any]) -> dict[str, Any] | None:
+        """
+        A reference to the innermost definition at a line (0-based, as editors
+        count), formatted as markdown (default) or plain text.
+        """
+        symbols = self._symbols(params, params["line"], params["line"])
+        if not symbols:
+            return None
+        # Innermost: the latest-starting definition enclosing the line
+        symbol = max(symbols, key=lambda s: s["start_line"])
+        path, start, end = symbol["path"], symbol["start_line"], symbol["end_line"]
+        if params.get("style") == "plain":
+            text = f"{symbol['qualified_name']} ({path}:{start}-{end})"
+        else:
+            text = f"[`{symbol['qualified_name']}`]({path}#L{start}-L{end})"
+        return {**_location(symbol, WorkspaceMap.from_params(params)), "text": text}
+
+    def ask_about_selection(self, params: dict[str, Any]) -> dict[str, Any]:
+        """
+        Answer a question about a selection (0-based lines) with the graph
+        context of the definitions it overlaps. Without a configured language
+        model only the context is returned.
+        """
+        workspace = WorkspaceMap.from_params(params)
+        symbols = self._symbols(params, params["startLine"], params["endLine"])
+        explanations = []
+        for symbol in symbols:
+            rows = self.ingestor.fetch_all(
+                EXPLAIN_QUERY, {"qualified_name": symbol["qualified_name"]}
+            )
+            if rows:
+                explanations.append(explain_symbol(symbol["qualified_name"], rows[0]))
+        context = "\n\n".join(explanations)
+
+        answer = None
+        if self.answerer is not None:
+            answer = self.answerer(_selection_prompt(params, symbols, context))
+        return {
+            "answer": answer,
+            "context": context,
+            "symbols": [_location(symbol, workspace) for symbol in symbols],
+        }
+
+    def _symbols(
+        self, params: dict[str, Any], start_line: int, end_line: int
+    ) -> list[dict[str, Any]]:
+        located = WorkspaceMap.from_params(params).to_graph(params["file"])
+        if located is None:
+            raise JsonRpcError(
+                INVALID_PARAMS, f"{params['file']} is not in a workspace folder"
+            )
+        project, path = located
+        return self.ingestor.fetch_all(  # type: ignore[no-any-return]
+            SELECTION_SYMBOLS_QUERY,
+            {
+                "project": project,
+                "path": path,
+                "start_line": start_line + 1,
+                "end_line": end_line + 1,
+            },
+        )
+
+
+def create_editor_routes(
+    server: GraphServer,
+    ingestor: MemgraphIngestor,
+    answerer: Answerer | None = None,
+    token: str | None = None,
+    registry: TokenRegistry | None = None,
+) -> EditorRpc:
+    """Register the editor JSON-RPC endpoint on a GraphServer."""
+    rpc = EditorRpc(ingestor, answerer, token, registry)
+    server.route("POST", "/rpc", rpc)
+    return rpc
+
+
+def _authorize(
+    api_token: ApiToken, method: str, params: dict[str, Any], answers: bool
+) -> None:
+    """
+    Refuse methods above the token's role and projects outside its scope;
+    only tokens scoped to all repos may have the language model answer.
+    """
+    role = METHOD_ROLES[method]
+    if not api_token.allows(role):
+        raise JsonRpcError(FORBIDDEN, f"{method} requires the {role.label} role")
+    if answers and method in ANSWERING_METHODS and not api_token.all_repos:
+        raise JsonRpcError(FORBIDDEN, f"{method} needs a token scoped to all repos")
+    if "qualifiedName" in params:
+        project = str(params["qualifiedName"]).split(".", 1)[0]
+    else:
+        located = WorkspaceMap.from_params(params).to_graph(params["file"])
+        project = located[0] if located else None
+    if project is not None and not api_token.allows_repo(project):
+        raise JsonRpcError(FORBIDDEN, f"No access to {project}")
+
+
+def _location(row: dict[str, Any], workspace: WorkspaceMap) -> dict[str, Any]:
+    """A graph node's location; lines are 0-based, as editors count them."""
+    start, end = row.get("start_line"), row.get("end_line")
+    return {
+        "qualifiedName": row["qualified_name"],
+        "label": row["label"],
+        "path": row.get("path"),
+        "file": workspace.to_editor(row["module"], row.get("path")),
+        "startLine": start - 1 if start else 0,
+        "endLine": end - 1 if end else 0,
+    }
+
+
+def _selection_prompt(
+    params: dict[str, Any], symbols: list[dict[str, Any]], context: str
+) -> str:
+    lines = f"{params['startLine'] + 1}-{params['endLine'] + 1}"
+    parts = [
+        f"I selected lines {lines} of {params['file']}:",
+        f"```\n{params.get('text', '')}\n```",
+    ]
+    if symbols:
+        parts.append(f"What the code graph knows about it:\n\n{context}")
+    parts.append(params.get("question") or DEFAULT_QUESTION)
+    return "\n\n".join(parts)
+
@@ -0,0 +1,106 @@
+"""Cypher over HTTP for tokens allowed to use it.
+
+A Cypher query can reach every project in the graph, so only tokens scoped
+to all repositories may send one: analysts read-only queries, admins any.
+"""
+
+import re
+from typing import Any
+
+from ..services.graph_service import MemgraphIngestor
+from .app import GraphServer, Request, Response
+from .auth import Role, TokenRegistry, check_access
+
+MAX_ROWS = 1000
+
+# Clauses that change the graph; CALL is included since procedures may write
+WRITE_CLAUSE = re.compile(
+    r"\b(CREATE|MERGE|DELETE|DETACH|SET|REMOVE|DROP|CALL|LOAD\s+CSV|FOREACH)\b",
+    re.IGNORECASE,
+)
+
+
+def is_read_only(cypher: str) -> bool:
+    """
+    Whether a query contains no writing clause. Deliberately strict: a
+    clause keyword inside a string literal counts too.
+    """
+    return WRITE_CLAUSE.search(cypher) is None
+
+
+class CypherEndpoint:
+    """POST {"cypher": "...", "params": {...}} and get {"rows": [...]} back."""
+
+    def __init__(self, ingestor: MemgraphIngestor, registry: TokenRegistry):
+        self.ingestor = ingestor
+        self.registry = registry
+
+    def __call__(self, request: Request) -> Response:
+        token = check_access(self.registry, request, Role.ANALYST)
+        if isinstance(token, Response):
+            return token
+        if not token.all_repos:
+            return Response(
+                403, {"error": "Cypher queries need a token scoped to all repos"}
+            )
+        try:
+            payload = request.json()
+        except ValueError:
+            return Response(400, {"error": "invalid JSON"})
+        cypher = payload.get("cypher") if isinstance(payload, dict) else None
+        if not isinstance(cypher, str) or not cypher.strip():
+            return Response(400, {"error": "expected a 'cypher' string"})
+        writes = not is_read_only(cypher)
+        if writes and not token.allows(Role.ADMIN):
+            return Response(403, {"error": "writing queries require the admin role"})
+
+        params = payload.get("params") or {}
+        if writes:
+            # Writes return no rows; the new version makes cached reads stale
+            self.ingestor.execute_write(cypher, params)
+            self.ingestor.mark_graph_changed()
+            rows = []
+        else:
+            rows = self.ingestor.fetch_all(cypher, params)
+        return Response(
+            200,
+            {
+                "rows": [
+                    {key: jsonable(value) for key, value in row.items()}
+                    for row in rows[:MAX_ROWS]
+                ],
+                "truncated": len(rows) > MAX_ROWS,
+            },
+        )
+
+
+def jsonable(value: Any) -> Any:
+    """Nodes, relationships and paths as plain data; other values unchanged."""
+    if isinstance(value, list):
+        return [jsonable(item) for item in value]
+    if isinstance(value, dict):
+        return {key: jsonable(item) for key, item in value.items()}
+    if hasattr(value, "properties"):
+        data = {"properties": jsonable(dict(value.properties))}
+        if hasattr(value, "labels"):
+            data["labels"] = sorted(value.labels)
+        if hasattr(value, "type"):
+            data["type"] = value.type
+        return data
+    if hasattr(value, "nodes") and hasattr(value, "relationships"):
+        return {
+            "nodes": jsonable(list(value.nodes)),
+            "relationships": jsonable(list(value.relationships)),
+        }
+    if value is None or isinstance(value, str | int | float | bool):
+        return value
+    return str(value)
+
+
+def create_query_routes(
+    server: GraphServer, ingestor: MemgraphIngestor, registry: TokenRegistry
+) -> CypherEndpoint:
+    """Register POST /query on a GraphServer."""
+    endpoint = CypherEndpoint(ingestor, registry)
+    server.route("POST", "/query", endpoint)
+    return endpoint
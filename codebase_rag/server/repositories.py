@@ -0,0 +1,154 @@
+"""Local mirrors of hosted repositories, kept in sync with pushed commits."""
+
+import base64
+import os
+import re
+import subprocess
+from pathlib import Path
+from urllib.parse import urlsplit
+
+from loguru import logger
+
+from ..version_control.changes import ChangedPaths, parse_name_status
+
+# Commit id used by push events for a branch that did not exist before
+NULL_SHA = "0" * 40
+COMMIT_ID = re.compile(r"[0-9a-f]{40}")
+CREDENTIALS_IN_URL = re.compile(r"(\w+://)[^/@\s]+@")
+
+# Username paired with a personal or app access token for HTTPS Basic auth.
+# GitLab deploy tokens, Bitbucket app passwords and Bitbucket Server tokens
+# use the account's own username instead.
+TOKEN_USERNAMES = {
+    "github": "x-access-token",
+    "gitlab": "oauth2",
+    "bitbucket": "x-token-auth",
+}
+
+
+def credential_env(
+    clone_url: str, token: str | None, username: str = TOKEN_USERNAMES["github"]
+) -> dict[str, str]:
+    """Environment giving git an access token for the host of a clone URL.
+
+    The token is sent as an http.<host>.extraHeader set through GIT_CONFIG_*
+    variables, so it is neither in git's arguments, which failures report,
+    nor in the clone's .git/config. Works for any host, so self-hosted GitLab
+    instances need no extra setup.
+    """
+    if not token or not clone_url.startswith("https://"):
+        return {}
+    parts = urlsplit(clone_url)
+    origin = f"https://{parts.hostname}" + (f":{parts.port}" if parts.port else "")
+    basic = base64.b64encode(f"{username}:{token}".encode()).decode()
+    return {
+        "GIT_CONFIG_COUNT": "1",
+        "GIT_CONFIG_KEY_0": f"http.{origin}/.extraHeader",
+        "GIT_CONFIG_VALUE_0": f"Authorization: Basic {basic}",
+    }
+
+
+def redact_credentials(text: str) -> str:
+    """Text with the credentials of any URL in it masked, for logs."""
+    return CREDENTIALS_IN_URL.sub(r"\1***@", text)
+
+
+def is_commit_id(value: str) -> bool:
+    """Whether a value is a full SHA-1 commit id, as push payloads carry."""
+    return COMMIT_ID.fullmatch(value) is not None
+
+
+class RepositoryMirror:
+    """A working copy that follows one branch of a remote repository."""
+
+    def __init__(
+        self,
+        repo_path: Path,
+        clone_url: str = "",
+        token: str | None = None,
+        username: str = TOKEN_USERNAMES["github"],
+    ):
+        self.repo_path = repo_path
+        self.clone_url = clone_url
+        self.token = token
+        self.username = username
+
+    def ensure_clone(self) -> None:
+        """Clone the remote if the working copy does not exist yet."""
+        if (self.repo_path / ".git").exists():
+            if self.clone_url:
+                # Mirrors cloned by earlier versions kept the token in the URL
+                self._git("remote", "set-url", "origin", self.clone_url)
+            return
+        if not self.clone_url:
+            raise ValueError(f"{self.repo_path} is not a Git repository")
+        logger.info(f"Cloning {self.clone_url} into {self.repo_path}")
+        self.repo_path.parent.mkdir(parents=True, exist_ok=True)
+        subprocess.run(
+            ["git", "clone", "--", self.clone_url, str(self.repo_path)],
+            capture_output=True,
+            check=True,
+            env=self._env(),
+        )
+
+    def fetch(self, ref: str) -> str:
+        """Fetch a ref from the remote and return the commit it points to."""
+        # Refs come from webhook payloads: never let one pass for an option
+        self._git("fetch", "--quiet", "--end-of-options", "origin", ref)
+        return self._git("rev-parse", "FETCH_HEAD").strip()
+
+    def checkout(self, ref: str, sha: str) -> None:
+        """Fetch a pushed ref and move the working copy to its commit."""
+        self.fetch(ref)
+        self.detach(sha)
+
+    def detach(self, sha: str) -> None:
+        """Move the working copy to a commit already fetched."""
+        # checkout --detach takes no --end-of-options, so only commit ids pass
+        if not is_commit_id(sha):
+            raise ValueError(f"Not a commit id: {sha!r}")
+        self._git("checkout", "--quiet", "--force", "--detach", sha)
+
+    def head(self) -> str:
+        """The commit the working copy is at."""
+        return self._git("rev-parse", "HEAD").strip()
+
+    def default_branch(self) -> str:
+        """The remote's default branch, as recorded when the mirror was cloned."""
+        ref = self._git("symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+        return ref.strip().removeprefix("origin/")
+
+    def changed_paths(self, before: str, after: str) -> ChangedPaths:
+        """Paths changed between two commits, with renames split into both sides."""
+        changes = ChangedPaths()
+        if before == NULL_SHA:
+            # A new branch: everything in it is new to the graph
+            output = self._git(
+                "ls-tree", "-r", "--name-only", "--end-of-options", after
+            )
+            changes.changed = [line for line in output.splitlines() if line]
+            return changes
+
+        return parse_name_status(
+            self._git("diff", "--name-status", "-M", "--end-of-options", before, after)
+        )
+
+    def diff(self, base: str, head: str) -> str:
+        """Unified diff of what head changes on top of its merge base with base."""
+        return self._git("diff", "--no-color", "--end-of-options", f"{base}...{head}")
+
+    def _env(self) -> dict[str, str]:
+        return {
+            **os.environ,
+            **credential_env(self.clone_url, self.token, self.username),
+        }
+
+    def _git(self, *args: str) -> str:
+        result = subprocess.run(
+            ["git", "-C", str(self.repo_path), *args],
+            capture_output=True,
+            text=True,
+            check=True,
+            env=self._env(),
+        )
+        return result.stdout
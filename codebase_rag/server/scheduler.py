@@ -0,0 +1,315 @@
+"""Cron-scheduled jobs for the server mode, with a history of their runs.
+
+Jobs are configured per repository, in the [schedule] section of the
+repository's own .cgr.toml (or .cgr.yaml):
+
+    [schedule.hourly]
+    cron = "15 * * * *"
+    tasks = ["ingest"]
+
+    [schedule.nightly]
+    cron = "0 2 * * 1-5"
+    tasks = ["ingest", "hotspots", "smells"]
+
+Cron expressions have the usual five fields (minute, hour, day of month,
+month, day of week with 0 or 7 for Sunday) and support *, lists, ranges
+and steps; they are evaluated in the server's local time. A job's tasks run
+in order and the job stops at the first task that fails.
+"""
+
+import json
+import threading
+from collections import deque
+from collections.abc import Callable
+from dataclasses import asdict, dataclass, field
+from datetime import datetime, timedelta
+from pathlib import Path
+from typing import Any
+from urllib.parse import parse_qs, urlsplit
+
+from loguru import logger
+
+from .app import GraphServer, Request, Response
+from .auth import Role, TokenRegistry, check_access
+
+# Name, lowest and highest value of each cron field
+CRON_FIELDS = (
+    ("minute", 0, 59),
+    ("hour", 0, 23),
+    ("day of month", 1, 31),
+    ("month", 1, 12),
+    ("day of week", 0, 7),
+)
+# Runs kept in memory and returned by the API
+HISTORY_SIZE = 500
+# How far ahead next_after looks before deciding an expression never fires
+MAX_LOOKAHEAD = timedelta(days=366 * 4)
+
+Task = Callable[[], dict[str, Any]]
+
+
+def _parse_field(text: str, name: str, low: int, high: int) -> frozenset[int]:
+    values: set[int] = set()
+    for part in text.split(","):
+        spec, _, step_text = part.partition("/")
+        try:
+            step = int(step_text) if step_text else 1
+            if spec == "*":
+                start, end = low, high
+            elif "-" in spec:
+                start, end = (int(bound) for bound in spec.split("-", 1))
+            else:
+                start = int(spec)
+                end = high if step_text else start
+        except ValueError:
+            raise ValueError(f"invalid {name} '{part}'") from None
+        if step < 1 or not low <= start <= end <= high:
+            raise ValueError(f"{name} '{part}' is outside {low}-{high}")
+        values.update(range(start, end + 1, step))
+    return frozenset(values)
+
+
+@dataclass(frozen=True)
+class CronSchedule:
+    expression: str
+    minutes: frozenset[int]
+    hours: frozenset[int]
+    days: frozenset[int]
+    months: frozenset[int]
+    weekdays: frozenset[int]  # 0 is Sunday
+
+    @classmethod
+    def parse(cls, expression: str) -> "CronSchedule":
+        fields = expression.split()
+        if len(fields) != len(CRON_FIELDS):
+            raise ValueError(
+                f"cron expression '{expression}' needs 5 fields, found {len(fields)}"
+            )
+        minutes, hours, days, months, weekdays = (
+            _parse_field(text, *spec)
+            for text, spec in zip(fields, CRON_FIELDS, strict=True)
+        )
+        return cls(
+            expression,
+            minutes,
+            hours,
+            days,
+            months,
+            frozenset(day % 7 for day in weekdays),
+        )
+
+    def matches_day(self, moment: datetime) -> bool:
+        if moment.month not in self.months:
+            return False
+        day = moment.day in self.days
+        weekday = (moment.weekday() + 1) % 7 in self.weekdays
+        # As in cron, a day matches either field when both are restricted
+        if len(self.days) < 31 and len(self.weekdays) < 7:
+            return day or weekday
+        return day and weekday
+
+    def matches(self, moment: datetime) -> bool:
+        return (
+            moment.minute in self.minutes
+            and moment.hour in self.hours
+            and self.matches_day(moment)
+        )
+
+    def next_after(self, moment: datetime) -> datetime:
+        """The first matching minute strictly after a moment."""
+        candidate = moment.replace(second=0, microsecond=0) + timedelta(minutes=1)
+        limit = candidate + MAX_LOOKAHEAD
+        while candidate < limit:
+            if not self.matches_day(candidate):
+                candidate = candidate.replace(hour=0, minute=0) + timedelta(days=1)
+            elif candidate.hour not in self.hours:
+                candidate = candidate.replace(minute=0) + timedelta(hours=1)
+            elif candidate.minute not in self.minutes:
+                candidate += timedelta(minutes=1)
+            else:
+                return candidate
+        raise ValueError(f"cron expression '{self.expression}' never fires")
+
+
+@dataclass
+class ScheduledJob:
+    name: str
+    schedule: CronSchedule
+    tasks: list[str]
+
+
+def load_jobs(document: dict[str, Any], known_tasks: list[str]) -> list[ScheduledJob]:
+    """The jobs in a config file's [schedule] section."""
+    section = document.get("schedule") or {}
+    if not isinstance(section, dict):
+        raise ValueError("The schedule section must be a mapping of jobs")
+    jobs = []
+    for name, entry in section.items():
+        if not isinstance(entry, dict) or not isinstance(entry.get("cron"), str):
+            raise ValueError(f"Scheduled job '{name}' needs a cron expression")
+        tasks = entry.get("tasks") or []
+        if isinstance(tasks, str):
+            tasks = [tasks]
+        unknown = [task for task in tasks if task not in known_tasks]
+        if not tasks or unknown:
+            raise ValueError(
+                f"Scheduled job '{name}' has unknown or no tasks "
+                f"({', '.join(unknown) or 'none'}); expected some of "
+                f"{', '.join(known_tasks)}"
+            )
+        try:
+            schedule = CronSchedule.parse(entry["cron"])
+            schedule.next_after(datetime.now())
+        except ValueError as e:
+            raise ValueError(f"Scheduled job '{name}': {e}") from None
+        jobs.append(ScheduledJob(name, schedule, list(tasks)))
+    return jobs
+
+
+@dataclass
+class JobRun:
+    job: str
+    started_at: str
+    finished_at: str = ""
+    status: str = "running"  # then "succeeded" or "failed"
+    # Summary returned by each task that ran, by task name
+    results: dict[str, Any] = field(default_factory=dict)
+    error: str | None = None
+
+
+class RunHistory:
+    """Recent job runs, appended to a JSON lines file if one is given."""
+
+    def __init__(self, path: Path | None = None, size: int = HISTORY_SIZE):
+        self.path = path
+        self.runs: deque[JobRun] = deque(maxlen=size)
+        self._lock = threading.Lock()
+        if path and path.is_file():
+            for line in path.read_text(encoding="utf-8").splitlines()[-size:]:
+                try:
+                    self.runs.append(JobRun(**json.loads(line)))
+                except (TypeError, ValueError):
+                    logger.warning(f"Skipping unreadable run in {path}")
+
+    def add(self, run: JobRun) -> None:
+        with self._lock:
+            self.runs.append(run)
+            if self.path:
+                self.path.parent.mkdir(parents=True, exist_ok=True)
+                with self.path.open("a", encoding="utf-8") as f:
+                    f.write(json.dumps(asdict(run)) + "\n")
+
+    def recent(self, job: str | None = None, limit: int = 50) -> list[JobRun]:
+        """The latest runs first, optionally of one job only."""
+        with self._lock:
+            runs = [run for run in self.runs if job is None or run.job == job]
+        return runs[::-1][:limit]
+
+
+class Scheduler:
+    """Runs due jobs once a minute on a background thread, one job at a time."""
+
+    def __init__(
+        self,
+        jobs: list[ScheduledJob],
+        tasks: dict[str, Task],
+        history: RunHistory | None = None,
+    ):
+        self.jobs = jobs
+        self.tasks = tasks
+        self.history = history or RunHistory()
+        self._stop = threading.Event()
+        self._thread: threading.Thread | None = None
+
+    def run_job(self, job: ScheduledJob) -> JobRun:
+        run = JobRun(job.name, datetime.now().isoformat(timespec="seconds"))
+        with logger.contextualize(job=job.name):
+            for task in job.tasks:
+                try:
+                    run.results[task] = self.tasks[task]()
+                except Exception as e:
+                    logger.exception(f"Scheduled task {task} of {job.name} failed")
+                    run.status, run.error = "failed", f"{task}: {e}"
+                    break
+            else:
+                run.status = "succeeded"
+        run.finished_at = datetime.now().isoformat(timespec="seconds")
+        logger.info(f"Scheduled job {job.name} {run.status}")
+        self.history.add(run)
+        return run
+
+    def tick(self, moment: datetime) -> list[JobRun]:
+        """Run the jobs due at a minute."""
+        return [self.run_job(job) for job in self.jobs if job.schedule.matches(moment)]
+
+    def start(self) -> None:
+        self._thread = threading.Thread(
+            target=self._loop, name="scheduler", daemon=True
+        )
+        self._thread.start()
+
+    def stop(self) -> None:
+        self._stop.set()
+
+    def _loop(self) -> None:
+        minute = datetime.now().replace(second=0, microsecond=0)
+        while True:
+            minute += timedelta(minutes=1)
+            if self._stop.wait(max(0.0, (minute - datetime.now()).total_seconds())):
+                return
+            self.tick(minute)
+            current = datetime.now().replace(second=0, microsecond=0)
+            if current > minute:
+                # Jobs due while others were still running are not caught up
+                logger.warning(f"Scheduled jobs ran past {current:%H:%M}")
+                minute = current
+
+    def describe(self) -> list[dict[str, Any]]:
+        now = datetime.now()
+        return [
+            {
+                "name": job.name,
+                "cron": job.schedule.expression,
+                "tasks": job.tasks,
+                "next_run": job.schedule.next_after(now).isoformat(),
+            }
+            for job in self.jobs
+        ]
+
+
+def create_schedule_routes(
+    server: GraphServer,
+    scheduler: Scheduler,
+    registry: TokenRegistry | None = None,
+    project: str | None = None,
+) -> None:
+    """
+    Register GET /schedule (jobs and their next run) and GET /schedule/runs
+    (?job=NAME&limit=N). With API tokens, callers need access to the project.
+    """
+
+    def guarded(handler: Callable[[Request], Response]) -> Callable:
+        def route(request: Request) -> Response:
+            if registry is not None:
+                token = check_access(registry, request, Role.READ_ONLY, project)
+                if isinstance(token, Response):
+                    return token
+            return handler(request)
+
+        return route
+
+    def jobs(_: Request) -> Response:
+        return Response(200, {"jobs": scheduler.describe()})
+
+    def runs(request: Request) -> Response:
+        query = parse_qs(urlsplit(request.path).query)
+        try:
+            limit = int(query.get("limit", ["50"])[0])
+        except ValueError:
+            return Response(400, {"error": "limit must be a number"})
+        job = query.get("job", [None])[0]
+        recent = scheduler.history.recent(job, limit)
+        return Response(200, {"runs": [asdict(run) for run in recent]})
+
+    server.route("GET", "/schedule", guarded(jobs))
+    server.route("GET", "/schedule/runs", guarded(runs))
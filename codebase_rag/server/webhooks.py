@@ -0,0 +1,430 @@
+"""Webhook receivers that run diff-scoped ingestion for pushed commits."""
+
+import hashlib
+import hmac
+import subprocess
+import threading
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from typing import Any, Protocol, TypeVar
+
+from loguru import logger
+
+from ..analysis.impact import ImpactReport, impact_comment
+from ..analysis.review import FileDiff, parse_unified_diff
+from .app import BadRequestError, GraphServer, Request, Response
+from .repositories import NULL_SHA, ChangedPaths, RepositoryMirror, is_commit_id
+
+T = TypeVar("T")
+
+
+class IncrementalUpdater(Protocol):
+    def update_files(self, changed: list[str], removed: list[str]) -> None: ...
+
+
+class ImpactSource(Protocol):
+    def analyze(self, diffs: list[FileDiff]) -> ImpactReport: ...
+
+
+@dataclass
+class PushEvent:
+    """A provider-neutral push notification."""
+
+    provider: str  # "github", "gitlab", "bitbucket" or "poll" for scheduled syncs
+    repository: str  # owner/name, group/subgroup/name or PROJECT/slug
+    clone_url: str
+    ref: str  # refs/heads/main
+    before: str
+    after: str
+    default_branch: str = ""
+    # File lists reported by the payload, used when the diff cannot be computed
+    changed: list[str] = field(default_factory=list)
+    removed: list[str] = field(default_factory=list)
+
+    @property
+    def branch(self) -> str:
+        return self.ref.removeprefix("refs/heads/")
+
+    @property
+    def deletes_branch(self) -> bool:
+        return set(self.after) == {"0"}
+
+
+@dataclass
+class PullRequestEvent:
+    """A GitHub pull request that was opened or pushed to."""
+
+    repository: str  # owner/name
+    number: int
+    action: str  # opened, synchronize, closed, ...
+    base_ref: str  # The branch it merges into, e.g. main
+    base_sha: str
+    head_sha: str
+    default_branch: str = ""
+    draft: bool = False
+
+
+def verify_github_signature(secret: str, body: bytes, signature: str) -> bool:
+    """Check the X-Hub-Signature-256 header against the shared secret."""
+    expected = "sha256=" + hmac.new(secret.encode(), body, hashlib.sha256).hexdigest()
+    return hmac.compare_digest(expected, signature)
+
+
+def verify_gitlab_token(secret: str, token: str) -> bool:
+    """Check the X-Gitlab-Token header against the configured secret token."""
+    return hmac.compare_digest(secret.encode(), token.encode())
+
+
+def _commit_files(commits: list[dict[str, Any]]) -> tuple[list[str], list[str]]:
+    changed: set[str] = set()
+    removed: set[str] = set()
+    for commit in commits:
+        changed.update(commit.get("added") or [])
+        changed.update(commit.get("modified") or [])
+        removed.update(commit.get("removed") or [])
+    return sorted(changed - removed), sorted(removed)
+
+
+def parse_github_push(payload: dict[str, Any]) -> PushEvent:
+    """Build a PushEvent from a GitHub push payload."""
+    repository = payload.get("repository") or {}
+    changed, removed = _commit_files(payload.get("commits") or [])
+    return PushEvent(
+        provider="github",
+        repository=repository.get("full_name", ""),
+        clone_url=repository.get("clone_url", ""),
+        ref=payload.get("ref", ""),
+        before=payload.get("before", ""),
+        after=payload.get("after", ""),
+        default_branch=repository.get("default_branch", ""),
+        changed=changed,
+        removed=removed,
+    )
+
+
+def parse_github_pull_request(payload: dict[str, Any]) -> PullRequestEvent:
+    """Build a PullRequestEvent from a GitHub pull_request payload."""
+    repository = payload.get("repository") or {}
+    pull = payload.get("pull_request") or {}
+    base, head = pull.get("base") or {}, pull.get("head") or {}
+    return PullRequestEvent(
+        repository=repository.get("full_name", ""),
+        number=payload.get("number") or pull.get("number", 0),
+        action=payload.get("action", ""),
+        base_ref=base.get("ref", ""),
+        base_sha=base.get("sha", ""),
+        head_sha=head.get("sha", ""),
+        default_branch=repository.get("default_branch", ""),
+        draft=bool(pull.get("draft")),
+    )
+
+
+def parse_gitlab_push(payload: dict[str, Any]) -> PushEvent:
+    """Build a PushEvent from a GitLab push hook payload.
+
+    GitLab lists at most 20 commits per payload, so the file lists are only
+    complete for small pushes; the mirror's diff is preferred over them.
+    """
+    project = payload.get("project") or {}
+    changed, removed = _commit_files(payload.get("commits") or [])
+    return PushEvent(
+        provider="gitlab",
+        repository=project.get("path_with_namespace", ""),
+        clone_url=project.get("git_http_url", ""),
+        ref=payload.get("ref", ""),
+        before=payload.get("before", ""),
+        after=payload.get("after", ""),
+        default_branch=project.get("default_branch", ""),
+        changed=changed,
+        removed=removed,
+    )
+
+
+def parse_bitbucket_push(payload: dict[str, Any]) -> list[PushEvent]:
+    """Build PushEvents from a Bitbucket Cloud or Server push payload.
+
+    One push can update several refs, so each change becomes its own event.
+    Neither variant lists changed files or the default branch.
+    """
+    repository = payload.get("repository") or {}
+    if "push" in payload:
+        # Bitbucket Cloud: repo:push
+        clone_url = ((repository.get("links") or {}).get("html") or {}).get("href")
+        events = []
+        for change in (payload.get("push") or {}).get("changes") or []:
+            old, new = change.get("old") or {}, change.get("new") or {}
+            ref = new or old
+            prefix = "refs/tags/" if ref.get("type") == "tag" else "refs/heads/"
+            events.append(
+                PushEvent(
+                    provider="bitbucket",
+                    repository=repository.get("full_name", ""),
+                    clone_url=f"{clone_url}.git" if clone_url else "",
+                    ref=prefix + ref.get("name", ""),
+                    before=(old.get("target") or {}).get("hash", NULL_SHA),
+                    after=(new.get("target") or {}).get("hash", NULL_SHA),
+                )
+            )
+        return events
+
+    # Bitbucket Server / Data Center: repo:refs_changed
+    project = (repository.get("project") or {}).get("key", "")
+    clone_links = (repository.get("links") or {}).get("clone") or []
+    clone_url = next(
+        (link["href"] for link in clone_links if link.get("name") == "http"), ""
+    )
+    return [
+        PushEvent(
+            provider="bitbucket",
+            repository=f"{project}/{repository.get('slug', '')}",
+            clone_url=clone_url,
+            ref=(change.get("ref") or {}).get("id", change.get("refId", "")),
+            before=change.get("fromHash", NULL_SHA),
+            after=change.get("toHash", NULL_SHA),
+        )
+        for change in payload.get("changes") or []
+    ]
+
+
+class PushSynchronizer:
+    """Moves a mirror to each pushed commit and re-ingests only what changed."""
+
+    def __init__(
+        self,
+        mirror: RepositoryMirror,
+        updater: IncrementalUpdater,
+        branch: str | None = None,
+    ):
+        self.mirror = mirror
+        self.updater = updater
+        self.branch = branch  # None follows the repository's default branch
+        self._lock = threading.Lock()
+
+    def apply(self, event: PushEvent) -> dict[str, Any]:
+        # Refs and commit ids reach git commands, so only the forms pushes
+        # of branches have are accepted
+        if not event.ref.startswith("refs/heads/"):
+            return {"status": "ignored", "reason": f"not a branch: {event.ref}"}
+        if not (is_commit_id(event.before) and is_commit_id(event.after)):
+            raise BadRequestError("before and after must be 40-character commit ids")
+        tracked = self.branch or event.default_branch
+        if event.deletes_branch or (tracked and event.branch != tracked):
+            return {"status": "ignored", "reason": f"not tracking {event.ref}"}
+
+        # Pushes are applied one at a time so the checkout matches what is parsed
+        with self._lock:
+            self.mirror.checkout(event.ref, event.after)
+            try:
+                changes = self.mirror.changed_paths(event.before, event.after)
+            except subprocess.CalledProcessError:
+                # e.g. a force push whose old head was never fetched
+                logger.warning(
+                    f"Could not diff {event.before[:8]}..{event.after[:8]}; "
+                    "using the files listed in the payload"
+                )
+                changes = ChangedPaths(event.changed, event.removed)
+            self.updater.update_files(changes.changed, changes.removed)
+
+        logger.info(
+            f"Applied {event.provider} push {event.after[:8]} to {event.ref}: "
+            f"{len(changes.changed)} changed, {len(changes.removed)} removed"
+        )
+        return {
+            "status": "updated",
+            "commit": event.after,
+            "changed": len(changes.changed),
+            "removed": len(changes.removed),
+        }
+
+    def poll(self) -> dict[str, Any]:
+        """Fetch the followed branch and apply what was pushed since the last sync."""
+        branch = self.branch or self.mirror.default_branch()
+        ref = f"refs/heads/{branch}"
+        after = self.mirror.fetch(ref)
+        before = self.mirror.head()
+        if after == before:
+            return {"status": "unchanged", "commit": after}
+        event = PushEvent("poll", "", "", ref, before, after, default_branch=branch)
+        return self.apply(event)
+
+    def inspect_pull_request(
+        self, event: PullRequestEvent, inspect: Callable[[str], T]
+    ) -> T:
+        """
+        Ingest a pull request's head, hand its diff to inspect, then take the
+        graph back to the followed branch. Only the files the two heads differ
+        in are parsed, each way; queries in between see the pull request.
+        """
+        with self._lock:
+            followed = self.mirror.head()
+            # The base commit, for the merge base the diff is taken from
+            self.mirror.fetch(f"refs/heads/{event.base_ref}")
+            self.mirror.checkout(f"refs/pull/{event.number}/head", event.head_sha)
+            try:
+                ahead = self.mirror.changed_paths(followed, event.head_sha)
+                self.updater.update_files(ahead.changed, ahead.removed)
+                return inspect(self.mirror.diff(event.base_sha, event.head_sha))
+            finally:
+                self.mirror.detach(followed)
+                back = self.mirror.changed_paths(event.head_sha, followed)
+                self.updater.update_files(back.changed, back.removed)
+
+
+class PullRequestImpact:
+    """Comments on pull requests with the tests that reach their changes."""
+
+    ACTIONS = {"opened", "reopened", "synchronize", "ready_for_review"}
+
+    def __init__(
+        self,
+        synchronizer: PushSynchronizer,
+        analyzer: ImpactSource,
+        comment: Callable[[PullRequestEvent, str], None],
+    ):
+        self.synchronizer = synchronizer
+        self.analyzer = analyzer
+        self.comment = comment
+
+    def apply(self, event: PullRequestEvent) -> dict[str, Any]:
+        if event.action not in self.ACTIONS or event.draft:
+            return {"status": "ignored", "reason": f"action {event.action}"}
+        # The graph follows one branch; pull requests into others would need
+        # the whole difference between the branches parsed
+        followed = self.synchronizer.branch or event.default_branch
+        if followed and event.base_ref != followed:
+            return {"status": "ignored", "reason": f"not tracking {event.base_ref}"}
+
+        if not (is_commit_id(event.base_sha) and is_commit_id(event.head_sha)):
+            raise BadRequestError("base and head must be 40-character commit ids")
+        report = self.synchronizer.inspect_pull_request(
+            event, lambda diff: self.analyzer.analyze(parse_unified_diff(diff))
+        )
+        self.comment(event, impact_comment(report))
+        logger.info(
+            f"Commented on {event.repository}#{event.number}: "
+            f"{len(report.tests)} tests reach {len(report.changed)} changed functions"
+        )
+        return {
+            "status": "commented",
+            "pull_request": event.number,
+            "tests": len(report.tests),
+        }
+
+
+class GitHubWebhook:
+    """Handles POSTs from a GitHub repository or organization webhook."""
+
+    def __init__(
+        self,
+        synchronizer: PushSynchronizer,
+        secret: str | None = None,
+        pull_requests: PullRequestImpact | None = None,
+    ):
+        self.synchronizer = synchronizer
+        self.secret = secret
+        self.pull_requests = pull_requests
+
+    def __call__(self, request: Request) -> Response:
+        if self.secret and not verify_github_signature(
+            self.secret, request.body, request.header("X-Hub-Signature-256")
+        ):
+            return Response(401, {"error": "invalid signature"})
+
+        event = request.header("X-GitHub-Event")
+        if event == "ping":
+            return Response(200, {"status": "pong"})
+        if event == "pull_request" and self.pull_requests:
+            pull_request = parse_github_pull_request(request.json())
+            return Response(200, self.pull_requests.apply(pull_request))
+        if event != "push":
+            return Response(202, {"status": "ignored", "reason": f"event {event}"})
+        return Response(200, self.synchronizer.apply(parse_github_push(request.json())))
+
+
+class GitLabWebhook:
+    """Handles POSTs from a GitLab project or group webhook."""
+
+    def __init__(self, synchronizer: PushSynchronizer, secret: str | None = None):
+        self.synchronizer = synchronizer
+        self.secret = secret
+
+    def __call__(self, request: Request) -> Response:
+        if self.secret and not verify_gitlab_token(
+            self.secret, request.header("X-Gitlab-Token")
+        ):
+            return Response(401, {"error": "invalid token"})
+
+        event = request.header("X-Gitlab-Event")
+        if event != "Push Hook":
+            return Response(202, {"status": "ignored", "reason": f"event {event}"})
+        return Response(200, self.synchronizer.apply(parse_gitlab_push(request.json())))
+
+
+class BitbucketWebhook:
+    """Handles POSTs from Bitbucket Cloud and Bitbucket Server webhooks."""
+
+    PUSH_EVENTS = {"repo:push", "repo:refs_changed"}
+
+    def __init__(self, synchronizer: PushSynchronizer, secret: str | None = None):
+        self.synchronizer = synchronizer
+        self.secret = secret
+
+    def __call__(self, request: Request) -> Response:
+        # Both variants sign the body like GitHub, under X-Hub-Signature
+        if self.secret and not verify_github_signature(
+            self.secret, request.body, request.header("X-Hub-Signature")
+        ):
+            return Response(401, {"error": "invalid signature"})
+
+        event = request.header("X-Event-Key")
+        if event == "diagnostics:ping":
+            return Response(200, {"status": "pong"})
+        if event not in self.PUSH_EVENTS:
+            return Response(202, {"status": "ignored", "reason": f"event {event}"})
+        results = [
+            self.synchronizer.apply(push)
+            for push in parse_bitbucket_push(request.json())
+        ]
+        return Response(200, {"status": "processed", "refs": results})
+
+
+def create_webhook_routes(
+    server: GraphServer,
+    mirror: RepositoryMirror,
+    updater: IncrementalUpdater,
+    github_secret: str | None = None,
+    branch: str | None = None,
+    gitlab_secret: str | None = None,
+    bitbucket_secret: str | None = None,
+    impact: ImpactSource | None = None,
+    comment: Callable[[PullRequestEvent, str], None] | None = None,
+    insecure: bool = False,
+) -> PushSynchronizer:
+    """
+    Register the webhook and health routes on a GraphServer. Only providers
+    with a secret get a webhook route, unless `insecure` accepts unverified
+    payloads from all of them. Given an impact analyzer and a way to comment,
+    GitHub pull requests are commented on too.
+    """
+    synchronizer = PushSynchronizer(mirror, updater, branch)
+    pull_requests = None
+    if impact and comment:
+        pull_requests = PullRequestImpact(synchronizer, impact, comment)
+    if github_secret or insecure:
+        server.route(
+            "POST",
+            "/webhooks/github",
+            GitHubWebhook(synchronizer, github_secret, pull_requests),
+        )
+    if gitlab_secret or insecure:
+        server.route(
+            "POST", "/webhooks/gitlab", GitLabWebhook(synchronizer, gitlab_secret)
+        )
+    if bitbucket_secret or insecure:
+        server.route(
+            "POST",
+            "/webhooks/bitbucket",
+            BitbucketWebhook(synchronizer, bitbucket_secret),
+        )
+    server.route("GET", "/healthz", lambda _: Response(200, {"status": "ok"}))
+    return synchronizer
@@ -0,0 +1,244 @@
+"""Dry-run ingestion: parse a repository and count what would be written.
+
+Besides totals per label and relationship type, each node is traced to the
+file it came from, by its own path or else by the module its qualified name
+lies in, so the counts can be broken down per language and per file: a file
+that yields no functions where some were expected shows up directly.
+"""
+
+import json
+from collections import Counter
+from dataclasses import dataclass, field
+from typing import Any
+
+from ..utils.visibility import language_for_path
+from .graph_service import MemgraphIngestor
+
+# Memgraph's documented per-object overhead; property values come on top
+NODE_OVERHEAD_BYTES = 212
+RELATIONSHIP_OVERHEAD_BYTES = 162
+
+
+@dataclass
+class DryRunReport:
+    """What an ingestion would write, without a database."""
+
+    node_counts: dict[str, int] = field(default_factory=dict)
+    relationship_counts: dict[str, int] = field(default_factory=dict)
+    # Relationships whose endpoints were never ingested; MATCH would drop them
+    unresolved_relationships: dict[str, int] = field(default_factory=dict)
+    skipped_files: dict[str, str] = field(default_factory=dict)
+    property_bytes: int = 0
+    # {language: {label: count}}; nodes of no language's file under "other"
+    language_counts: dict[str, dict[str, int]] = field(default_factory=dict)
+    language_relationships: dict[str, int] = field(default_factory=dict)
+    # {path: {label: count}} for the files parsed, File and Module included
+    file_counts: dict[str, dict[str, int]] = field(default_factory=dict)
+    # Up to the sample size of nodes per label and relationships per type
+    node_samples: dict[str, list[dict[str, Any]]] = field(default_factory=dict)
+    relationship_samples: dict[str, list[tuple]] = field(default_factory=dict)
+
+    @property
+    def total_nodes(self) -> int:
+        return sum(self.node_counts.values())
+
+    @property
+    def total_relationships(self) -> int:
+        return sum(self.relationship_counts.values())
+
+    @property
+    def estimated_bytes(self) -> int:
+        """A rough lower bound on storage memory, before indexes."""
+        return (
+            self.total_nodes * NODE_OVERHEAD_BYTES
+            + self.total_relationships * RELATIONSHIP_OVERHEAD_BYTES
+            + self.property_bytes
+        )
+
+    def skip_reasons(self) -> dict[str, int]:
+        """How many paths were skipped for each reason, parse errors together."""
+        reasons = Counter(
+            "parse error" if r.startswith("parse error") else r
+            for r in self.skipped_files.values()
+        )
+        return dict(reasons.most_common())
+
+    def to_dict(self) -> dict[str, Any]:
+        return {
+            "nodes": self.node_counts,
+            "relationships": self.relationship_counts,
+            "unresolved_relationships": self.unresolved_relationships,
+            "total_nodes": self.total_nodes,
+            "total_relationships": self.total_relationships,
+            "skipped_files": self.skipped_files,
+            "estimated_bytes": self.estimated_bytes,
+            "languages": self.language_counts,
+            "language_relationships": self.language_relationships,
+            "files": self.file_counts,
+        }
+
+
+class DryRunIngestor(MemgraphIngestor):
+    """
+    An ingestor that never connects: flushed batches are counted the way
+    MERGE would store them, so nodes sharing a key and repeated relationships
+    are counted once. Reads return nothing and writes are dropped.
+    """
+
+    def __init__(self, batch_size: int | None = None, sample_size: int = 0):
+        # Sinks would export the graph, which a dry run must not do either
+        super().__init__(host="", port=0, batch_size=batch_size, sinks=[])
+        # {label: {key value: property bytes}}, the last write winning as in MERGE
+        self.nodes: dict[str, dict[Any, int]] = {}
+        # {(from node, type, to node): property bytes}
+        self.relationships: dict[tuple, int] = {}
+        # The path property of nodes having one, by (label, key value)
+        self.paths: dict[tuple[str, Any], str] = {}
+        self.sample_size = sample_size
+        self.node_samples: dict[str, dict[Any, dict[str, Any]]] = {}
+        self.relationship_samples: dict[str, dict[tuple, dict[str, Any]]] = {}
+
+    def __enter__(self) -> "DryRunIngestor":
+        return self
+
+    def __exit__(
+        self, exc_type: type | None, exc_val: Exception | None, exc_tb: Any
+    ) -> None:
+        self.flush_all()
+
+    def _execute_query(self, query: str, params: dict[str, Any] | None = None) -> list:
+        return []
+
+    def _execute_batch(self, query: str, params_list: list[dict[str, Any]]) -> None:
+        pass
+
+    def clean_database(self) -> None:
+        pass
+
+    def ensure_constraints(self) -> None:
+        pass
+
+    def flush_nodes(self) -> None:
+        for label, properties in self.node_buffer:
+            if not properties:
+                continue
+            key = _hashable(next(iter(properties.values())))
+            self.nodes.setdefault(label, {})[key] = _size(properties)
+            if isinstance(properties.get("path"), str):
+                self.paths[(label, key)] = properties["path"]
+            samples = self.node_samples.setdefault(label, {})
+            if key in samples or len(samples) < self.sample_size:
+                samples[key] = properties
+        self.node_buffer.clear()
+
+    def flush_relationships(self) -> None:
+        for from_node, rel_type, to_node, properties in self.relationship_buffer:
+            identity = (_node_ref(from_node), rel_type, _node_ref(to_node))
+            self.relationships[identity] = _size(properties or {})
+            samples = self.relationship_samples.setdefault(rel_type, {})
+            if identity in samples or len(samples) < self.sample_size:
+                samples[identity] = properties or {}
+        self.relationship_buffer.clear()
+
+    def report(self, skipped_files: dict[str, str] | None = None) -> DryRunReport:
+        """Counts per label and type for everything flushed so far."""
+        self.flush_all()
+        report = DryRunReport(skipped_files=dict(sorted((skipped_files or {}).items())))
+        for label, nodes in sorted(self.nodes.items()):
+            report.node_counts[label] = len(nodes)
+            report.property_bytes += sum(nodes.values())
+
+        relationship_counts: Counter[str] = Counter()
+        unresolved: Counter[str] = Counter()
+        for (from_node, rel_type, to_node), size in self.relationships.items():
+            if self._exists(from_node) and self._exists(to_node):
+                relationship_counts[rel_type] += 1
+                report.property_bytes += size
+            else:
+                unresolved[rel_type] += 1
+        report.relationship_counts = dict(sorted(relationship_counts.items()))
+        report.unresolved_relationships = dict(sorted(unresolved.items()))
+        self._add_breakdowns(report)
+        report.node_samples = {
+            label: list(samples.values())
+            for label, samples in sorted(self.node_samples.items())
+            if samples
+        }
+        report.relationship_samples = {
+            rel_type: [(*identity, props) for identity, props in samples.items()]
+            for rel_type, samples in sorted(self.relationship_samples.items())
+            if samples
+        }
+        return report
+
+    def _add_breakdowns(self, report: DryRunReport) -> None:
+        """Counts per language and per file, through the file of each node."""
+        module_paths = {
+            key: path
+            for (label, key), path in self.paths.items()
+            if label == "Module" and isinstance(key, str)
+        }
+        files: dict[tuple[str, Any], str | None] = {}
+        languages: dict[str, Counter[str]] = {}
+        per_file: dict[str, Counter[str]] = {}
+        for label, nodes in self.nodes.items():
+            for key in nodes:
+                path = self._file_of(label, key, module_paths)
+                files[(label, key)] = path
+                language = (path and language_for_path(path)) or "other"
+                languages.setdefault(language, Counter())[label] += 1
+                if path and language != "other":
+                    per_file.setdefault(path, Counter())[label] += 1
+        relationships: Counter[str] = Counter()
+        for from_node, _, to_node in self.relationships:
+            if self._exists(from_node) and self._exists(to_node):
+                path = files.get((from_node[0], from_node[2]))
+                relationships[(path and language_for_path(path)) or "other"] += 1
+
+        def by_size(counts: dict[str, Counter[str]]) -> dict[str, dict[str, int]]:
+            return {
+                name: dict(counter.most_common())
+                for name, counter in sorted(
+                    counts.items(), key=lambda item: -sum(item[1].values())
+                )
+            }
+
+        report.language_counts = by_size(languages)
+        report.language_relationships = dict(relationships.most_common())
+        report.file_counts = {
+            path: dict(counts.most_common())
+            for path, counts in sorted(per_file.items())
+        }
+
+    def _file_of(
+        self, label: str, key: Any, module_paths: dict[str, str]
+    ) -> str | None:
+        """A node's own path, or that of the module its qualified name is in."""
+        if (label, key) in self.paths:
+            return self.paths[(label, key)]
+        if not isinstance(key, str):
+            return None
+        parts = key.split(".")
+        for end in range(len(parts) - 1, 0, -1):
+            path = module_paths.get(".".join(parts[:end]))
+            if path is not None:
+                return path
+        return None
+
+    def _exists(self, node: tuple) -> bool:
+        label, _, value = node
+        return value in self.nodes.get(label, {})
+
+
+def _node_ref(node: tuple) -> tuple:
+    label, key, value = node
+    return (label, key, _hashable(value))
+
+
+def _hashable(value: Any) -> Any:
+    return tuple(value) if isinstance(value, list) else value
+
+
+def _size(properties: dict[str, Any]) -> int:
+    # Encoded length approximates Memgraph's property store closely enough
+    return len(json.dumps(properties, default=str).encode("utf-8"))
@@ -0,0 +1,216 @@
+"""Text embeddings for semantic code search.
+
+The hashing embedder needs no model or network: it hashes the words of
+identifiers, comments and docstrings into a fixed-size vector, so code and
+questions sharing vocabulary ("retry", "backoff", "debounce") end up close.
+An embeddings API (OpenAI, Voyage, or an OpenAI-compatible local server such
+as Ollama) or a sentence-transformers model run in-process also catches
+synonyms and paraphrases.
+
+Vectors of different models, or of one model at different sizes, do not
+compare, so each embedder names its provider, model and dimension; the
+semantic index records them and refuses to mix them.
+"""
+
+import hashlib
+import math
+import re
+from collections.abc import Callable
+from typing import Protocol
+
+from loguru import logger
+
+from ..config import settings
+from ..symbol_search import split_identifier
+
+TOKEN = re.compile(r"[A-Za-z][A-Za-z0-9_]*")
+# Words too common in code or questions to say what a function does
+STOPWORDS = set(
+    """
+    a an and are as at be by const def do does else err error fn for from
+    func function get how if in is it lambda let nil none not of on or
+    return self set that the this to var we what where which with
+    """.split()
+)
+
+
+class Embedder(Protocol):
+    # Stored with each vector; vectors from different embedders do not compare
+    name: str
+    provider: str
+    # Length of the vectors; API models report it with their first vectors
+    dimension: int | None
+
+    def embed(self, texts: list[str]) -> list[list[float]]: ...
+
+
+def stem(word: str) -> str:
+    """A crude suffix strip, so retries, retrying and retried meet retry."""
+    for suffix, replacement in (("ies", "y"), ("ied", "y"), ("ing", ""), ("ed", "")):
+        if word.endswith(suffix) and len(word) - len(suffix) >= 3:
+            return word[: -len(suffix)] + replacement
+    if word.endswith("s") and not word.endswith("ss") and len(word) > 3:
+        return word[:-1]
+    return word
+
+
+def text_words(text: str) -> list[str]:
+    return [
+        stem(word)
+        for token in TOKEN.findall(text)
+        for word in split_identifier(token)
+        if word not in STOPWORDS and len(word) > 1
+    ]
+
+
+class HashingEmbedder:
+    """Feature-hashed, log-scaled counts of stemmed words, of unit length."""
+
+    provider = "hashing"
+
+    def __init__(self, dimension: int = 512):
+        self.dimension: int | None = dimension
+        self.name = f"hashing-{dimension}"
+
+    def embed(self, texts: list[str]) -> list[list[float]]:
+        return [self._embed(text) for text in texts]
+
+    def _embed(self, text: str) -> list[float]:
+        counts: dict[str, int] = {}
+        for word in text_words(text):
+            counts[word] = counts.get(word, 0) + 1
+        vector = [0.0] * self.dimension
+        for word, count in counts.items():
+            digest = hashlib.blake2b(word.encode(), digest_size=8).digest()
+            index = int.from_bytes(digest[:4]) % self.dimension
+            sign = 1.0 if digest[4] & 1 else -1.0
+            vector[index] += sign * (1.0 + math.log(count))
+        norm = math.sqrt(sum(x * x for x in vector))
+        return [x / norm for x in vector] if norm else vector
+
+
+class OpenAIEmbedder:
+    """Embeddings from an OpenAI-compatible /embeddings endpoint."""
+
+    def __init__(
+        self,
+        model: str,
+        api_key: str | None,
+        base_url: str | None = None,
+        batch_size: int = 64,
+        provider: str = "openai",
+    ):
+        from openai import OpenAI
+
+        self.name = model
+        self.provider = provider
+        self.dimension: int | None = None
+        self.batch_size = batch_size
+        self.client = OpenAI(api_key=api_key, base_url=base_url)
+
+    def embed(self, texts: list[str]) -> list[list[float]]:
+        vectors: list[list[float]] = []
+        for start in range(0, len(texts), self.batch_size):
+            batch = texts[start : start + self.batch_size]
+            response = self.client.embeddings.create(model=self.name, input=batch)
+            data = sorted(response.data, key=lambda d: d.index)
+            vectors += [d.embedding for d in data]
+            logger.debug(f"Embedded {start + len(batch)}/{len(texts)} texts")
+        if vectors:
+            self.dimension = len(vectors[0])
+        return vectors
+
+
+class VoyageEmbedder:
+    """Embeddings from Voyage AI, whose voyage-code models are trained on code."""
+
+    provider = "voyage"
+
+    def __init__(self, model: str, api_key: str | None, batch_size: int = 128):
+        import voyageai
+
+        self.name = model
+        self.dimension: int | None = None
+        self.batch_size = batch_size
+        self.client = voyageai.Client(api_key=api_key)
+
+    def embed(self, texts: list[str]) -> list[list[float]]:
+        vectors: list[list[float]] = []
+        for start in range(0, len(texts), self.batch_size):
+            batch = texts[start : start + self.batch_size]
+            vectors += self.client.embed(batch, model=self.name).embeddings
+            logger.debug(f"Embedded {start + len(batch)}/{len(texts)} texts")
+        if vectors:
+            self.dimension = len(vectors[0])
+        return vectors
+
+
+class SentenceTransformerEmbedder:
+    """Embeddings from a sentence-transformers model run in this process."""
+
+    provider = "sentence-transformers"
+
+    def __init__(self, model: str, batch_size: int = 32):
+        from sentence_transformers import SentenceTransformer
+
+        self.name = model
+        self.batch_size = batch_size
+        self.model = SentenceTransformer(model)
+        self.dimension: int | None = self.model.get_sentence_embedding_dimension()
+
+    def embed(self, texts: list[str]) -> list[list[float]]:
+        vectors = self.model.encode(
+            texts, batch_size=self.batch_size, normalize_embeddings=True
+        )
+        return [[float(x) for x in vector] for vector in vectors]
+
+
+# Models used when EMBEDDING_MODEL_ID is not set
+DEFAULT_EMBEDDING_MODELS = {
+    "openai": "text-embedding-3-small",
+    "voyage": "voyage-code-3",
+    "sentence-transformers": "all-MiniLM-L6-v2",
+    "local": "nomic-embed-text",
+}
+# Providers sending the embedded code to a third party
+CLOUD_EMBEDDING_PROVIDERS = {"openai", "voyage"}
+
+
+def _openai(model: str) -> Embedder:
+    if not settings.OPENAI_API_KEY:
+        raise ValueError("EMBEDDING_PROVIDER=openai needs OPENAI_API_KEY")
+    return OpenAIEmbedder(model, settings.OPENAI_API_KEY)
+
+
+def _voyage(model: str) -> Embedder:
+    if not settings.VOYAGE_API_KEY:
+        raise ValueError("EMBEDDING_PROVIDER=voyage needs VOYAGE_API_KEY")
+    return VoyageEmbedder(model, settings.VOYAGE_API_KEY)
+
+
+def _local(model: str) -> Embedder:
+    return OpenAIEmbedder(
+        model,
+        settings.LOCAL_MODEL_API_KEY,
+        str(settings.LOCAL_MODEL_ENDPOINT),
+        provider="local",
+    )
+
+
+EMBEDDERS: dict[str, Callable[[str], Embedder]] = {
+    "openai": _openai,
+    "voyage": _voyage,
+    "sentence-transformers": SentenceTransformerEmbedder,
+    "local": _local,
+}
+
+
+def create_embedder() -> Embedder:
+    """The embedder chosen by EMBEDDING_PROVIDER and EMBEDDING_MODEL_ID."""
+    provider = settings.EMBEDDING_PROVIDER
+    if provider not in EMBEDDERS:
+        return HashingEmbedder()
+    if settings.LOCAL_ONLY and provider in CLOUD_EMBEDDING_PROVIDERS:
+        raise ValueError(f"EMBEDDING_PROVIDER={provider} is refused with LOCAL_ONLY")
+    model = settings.EMBEDDING_MODEL_ID or DEFAULT_EMBEDDING_MODELS[provider]
+    return EMBEDDERS[provider](model)
@@ -0,0 +1,284 @@
+"""The graph databases the ingestor can write to, chosen by GRAPH_BACKEND.
+
+The ingestor speaks openCypher through a DB-API style connection: `cursor()`,
+then `execute(query, params)`, `description` and `fetchall()`. mgclient's
+connection is one already; the Neo4j driver's sessions and embedded
+FalkorDB graphs are wrapped into one. What else differs is the backend's
+business: how constraints and indexes are declared, how to empty a large
+graph, and which errors are conflicts worth retrying.
+
+The embedded backend keeps the graph in a file, with no server to run. It
+is FalkorDB Lite rather than Kuzu or SQLite: like Memgraph it takes
+openCypher without a schema, where Kuzu needs a table declared per label
+and per pair of labels a relationship joins, and SQLite no Cypher at all.
+"""
+
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Protocol
+
+import mgclient
+
+from ..config import settings
+
+# Memgraph aborts one of two transactions writing the same nodes at once and
+# asks for a retry; these phrases mark such errors among the driver's
+TRANSIENT_ERRORS = ("conflicting transactions", "serialization error")
+# Neo4j reports lock contention as deadlocks, retryable the same way
+NEO4J_TRANSIENT_ERRORS = ("deadlock", "lockclient", "transienterror")
+
+
+def is_transient(error: Exception) -> bool:
+    """Whether a Memgraph write failed on a conflict that retrying resolves."""
+    message = str(error).lower()
+    return any(phrase in message for phrase in TRANSIENT_ERRORS)
+
+
+class GraphBackend(Protocol):
+    """A graph database: how to connect to it and the Cypher it differs in."""
+
+    name: str
+
+    def connect(self) -> Any: ...
+
+    def constraint_query(self, label: str, prop: str) -> str: ...
+
+    def index_query(self, label: str, prop: str) -> str: ...
+
+    def drop_index_query(self, label: str, prop: str) -> str: ...
+
+    def clear_query(self) -> str: ...
+
+    def is_transient(self, error: Exception) -> bool: ...
+
+
+class MemgraphBackend:
+    name = "Memgraph"
+
+    def __init__(self, host: str, port: int):
+        self.host = host
+        self.port = port
+
+    def __str__(self) -> str:
+        return f"Memgraph at {self.host}:{self.port}"
+
+    def connect(self) -> mgclient.Connection:
+        conn = mgclient.connect(host=self.host, port=self.port)
+        conn.autocommit = True
+        return conn
+
+    def constraint_query(self, label: str, prop: str) -> str:
+        return f"CREATE CONSTRAINT ON (n:{label}) ASSERT n.{prop} IS UNIQUE;"
+
+    def index_query(self, label: str, prop: str) -> str:
+        return f"CREATE INDEX ON :{label}({prop})"
+
+    def drop_index_query(self, label: str, prop: str) -> str:
+        return f"DROP INDEX ON :{label}({prop})"
+
+    def clear_query(self) -> str:
+        return "MATCH (n) DETACH DELETE n;"
+
+    def is_transient(self, error: Exception) -> bool:
+        return is_transient(error)
+
+
+class Neo4jBackend:
+    """
+    Neo4j 5 over Bolt. Indexes are named after their label and property, as
+    Neo4j drops them by name, and statements that already hold are skipped
+    with IF NOT EXISTS instead of failing.
+    """
+
+    name = "Neo4j"
+
+    def __init__(
+        self,
+        uri: str,
+        username: str,
+        password: str | None,
+        database: str | None = None,
+    ):
+        self.uri = uri
+        self.username = username
+        self.password = password
+        self.database = database
+
+    def __str__(self) -> str:
+        return f"Neo4j at {self.uri}"
+
+    def connect(self) -> "Neo4jConnection":
+        try:
+            import neo4j  # noqa: PLC0415
+        except ImportError as e:
+            raise ImportError(
+                "GRAPH_BACKEND=neo4j needs the Neo4j driver: "
+                "pip install 'graph-code[neo4j]'"
+            ) from e
+        auth = (self.username, self.password) if self.password else None
+        driver = neo4j.GraphDatabase.driver(self.uri, auth=auth)
+        driver.verify_connectivity()
+        return Neo4jConnection(driver, self.database)
+
+    def constraint_query(self, label: str, prop: str) -> str:
+        return (
+            f"CREATE CONSTRAINT {label}_{prop}_unique IF NOT EXISTS "
+            f"FOR (n:{label}) REQUIRE n.{prop} IS UNIQUE"
+        )
+
+    def index_query(self, label: str, prop: str) -> str:
+        return (
+            f"CREATE INDEX {label}_{prop} IF NOT EXISTS "
+            f"FOR (n:{label}) ON (n.{prop})"
+        )
+
+    def drop_index_query(self, label: str, prop: str) -> str:
+        return f"DROP INDEX {label}_{prop} IF EXISTS"
+
+    def clear_query(self) -> str:
+        # One transaction deleting a large graph runs Neo4j out of memory
+        return (
+            "MATCH (n) CALL { WITH n DETACH DELETE n } "
+            "IN TRANSACTIONS OF 10000 ROWS"
+        )
+
+    def is_transient(self, error: Exception) -> bool:
+        message = f"{type(error).__name__} {error}".lower()
+        return any(phrase in message for phrase in NEO4J_TRANSIENT_ERRORS)
+
+
+@dataclass
+class Column:
+    name: str
+
+
+class Neo4jCursor:
+    """Runs each statement in its own auto-commit transaction."""
+
+    def __init__(self, session: Any):
+        self.session = session
+        self.description: list[Column] | None = None
+        self._rows: list[tuple] = []
+
+    def execute(self, query: str, params: dict[str, Any] | None = None) -> None:
+        # Memgraph accepts a trailing semicolon, Neo4j does not
+        result = self.session.run(query.strip().rstrip(";"), params or {})
+        records = list(result)
+        keys = result.keys()
+        self.description = [Column(key) for key in keys] if keys else None
+        self._rows = [tuple(record.values()) for record in records]
+
+    def fetchall(self) -> list[tuple]:
+        return self._rows
+
+    def close(self) -> None:
+        self._rows = []
+
+
+class Neo4jConnection:
+    """A Neo4j driver and session behind the connection interface."""
+
+    def __init__(self, driver: Any, database: str | None = None):
+        self.driver = driver
+        self.session = driver.session(database=database)
+
+    def cursor(self) -> Neo4jCursor:
+        return Neo4jCursor(self.session)
+
+    def close(self) -> None:
+        self.session.close()
+        self.driver.close()
+
+
+class EmbeddedBackend:
+    """
+    FalkorDB Lite, which starts the database along with this process and
+    saves it to one file. Writes are serialized, so none conflict; uniqueness
+    constraints are Redis commands rather than Cypher, so the merge keys
+    are indexed instead.
+    """
+
+    name = "embedded FalkorDB"
+
+    def __init__(self, path: str, graph: str = "code"):
+        self.path = Path(path).expanduser()
+        self.graph = graph
+
+    def __str__(self) -> str:
+        return f"embedded FalkorDB at {self.path}"
+
+    def connect(self) -> "EmbeddedConnection":
+        try:
+            from redislite.falkordb_client import FalkorDB  # noqa: PLC0415
+        except ImportError as e:
+            raise ImportError(
+                "GRAPH_BACKEND=embedded needs FalkorDB Lite: "
+                "pip install 'graph-code[embedded]'"
+            ) from e
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        db = FalkorDB(str(self.path))
+        return EmbeddedConnection(db, db.select_graph(self.graph))
+
+    def constraint_query(self, label: str, prop: str) -> str:
+        return self.index_query(label, prop)
+
+    def index_query(self, label: str, prop: str) -> str:
+        return f"CREATE INDEX FOR (n:{label}) ON (n.{prop})"
+
+    def drop_index_query(self, label: str, prop: str) -> str:
+        return f"DROP INDEX FOR (n:{label}) ON (n.{prop})"
+
+    def clear_query(self) -> str:
+        return "MATCH (n) DETACH DELETE n"
+
+    def is_transient(self, error: Exception) -> bool:
+        return False
+
+
+class EmbeddedCursor:
+    def __init__(self, graph: Any):
+        self.graph = graph
+        self.description: list[Column] | None = None
+        self._rows: list[list] = []
+
+    def execute(self, query: str, params: dict[str, Any] | None = None) -> None:
+        result = self.graph.query(query.strip().rstrip(";"), params or None)
+        # Headers are [column type, name] pairs
+        header = result.header or []
+        self.description = [Column(column[1]) for column in header] or None
+        self._rows = result.result_set or []
+
+    def fetchall(self) -> list[list]:
+        return self._rows
+
+    def close(self) -> None:
+        self._rows = []
+
+
+class EmbeddedConnection:
+    """An embedded FalkorDB graph behind the connection interface."""
+
+    def __init__(self, db: Any, graph: Any):
+        self.db = db
+        self.graph = graph
+
+    def cursor(self) -> EmbeddedCursor:
+        return EmbeddedCursor(self.graph)
+
+    def close(self) -> None:
+        # Written to the file now rather than whenever Redis next snapshots
+        self.db.connection.save()
+
+
+def create_backend(host: str, port: int) -> GraphBackend:
+    """The backend named by GRAPH_BACKEND; host and port are Memgraph's."""
+    if settings.GRAPH_BACKEND == "embedded":
+        return EmbeddedBackend(settings.EMBEDDED_GRAPH_PATH)
+    if settings.GRAPH_BACKEND == "neo4j":
+        return Neo4jBackend(
+            settings.NEO4J_URI,
+            settings.NEO4J_USERNAME,
+            settings.NEO4J_PASSWORD,
+            settings.NEO4J_DATABASE,
+        )
+    return MemgraphBackend(host, port)
@@ -1,27 +1,76 @@
+import time
 from collections import defaultdict
+from collections.abc import Iterator
+from contextlib import contextmanager
 from datetime import UTC, datetime
 from typing import Any
 
-import mgclient
 from loguru import logger
 
+from ..config import settings
+from ..privacy import Redactor
+from ..query_cache import SET_GRAPH_VERSION, GraphQueryCache, graph_version_params
+from .graph_backends import GraphBackend, create_backend
+from .graph_sinks import GraphSink, SinkDispatcher, load_sinks
+
+# Seconds before the first retry of a conflicting batch, doubled for each next
+RETRY_BACKOFF_SECONDS = 0.2
+
+
+def _node_pattern(name: str, label: str, key: str, value: str) -> str:
+    # Edges to a node of unknown label, e.g. an imported symbol, match any
+    label_part = f":{label}" if label else ""
+    return f"({name}{label_part} {{{key}: {value}}})"
+
 
 class MemgraphIngestor:
-    """Handles all communication and query execution with the Memgraph database."""
+    """
+    Handles all communication and query execution with the graph database:
+    Memgraph unless GRAPH_BACKEND or an explicit backend says otherwise.
+    """
 
-    def __init__(self, host: str, port: int, batch_size: int = 1000):
-        self._host = host
-        self._port = port
-        self.batch_size = batch_size
-        self.conn: mgclient.Connection | None = None
+    def __init__(
+        self,
+        host: str,
+        port: int,
+        batch_size: int | None = None,
+        sinks: list[GraphSink] | None = None,
+        private: bool | None = None,
+        write_retries: int | None = None,
+        query_cache: GraphQueryCache | None = None,
+        backend: GraphBackend | None = None,
+    ):
+        self.backend = backend or create_backend(host, port)
+        # Nodes or relationships buffered before they are written in batches
+        self.batch_size = batch_size or settings.GRAPH_BATCH_SIZE
+        if write_retries is None:
+            write_retries = settings.GRAPH_WRITE_RETRIES
+        self.write_retries = write_retries
+        self.conn: Any = None
         self.node_buffer: list[tuple[str, dict[str, Any]]] = []
         self.relationship_buffer: list[tuple[tuple, str, tuple, dict | None]] = []
+        # Sinks named in GRAPH_SINKS unless given explicitly
+        self.sinks = SinkDispatcher(
+            load_sinks(settings.GRAPH_SINKS) if sinks is None else sinks
+        )
+        # Private ingestion hashes code text before it reaches the database or sinks
+        if private is None:
+            private = settings.PRIVATE_INGESTION
+        self.redactor = Redactor(settings.PRIVACY_HASH_KEY) if private else None
+        # Read-only query results, kept until an ingestion changes the graph
+        self.query_cache = query_cache
+        # Batches given up on, so a checkpoint never counts their writes stored
+        self.failed_batches = 0
+        # Set while re-reading what a resumed ingestion stored already
+        self._skipping_writes = False
+        # Properties added to the nodes of a module while it is ingested:
+        # (module qualified name, properties)
+        self._module_tags: tuple[str, dict[str, Any]] | None = None
 
     def __enter__(self) -> "MemgraphIngestor":
-        logger.info(f"Connecting to Memgraph at {self._host}:{self._port}...")
-        self.conn = mgclient.connect(host=self._host, port=self._port)
-        self.conn.autocommit = True
-        logger.info("Successfully connected to Memgraph.")
+        logger.info(f"Connecting to {self.backend}...")
+        self.conn = self.backend.connect()
+        logger.info(f"Successfully connected to {self.backend.name}.")
         return self
 
     def __exit__(
@@ -33,13 +82,14 @@ class MemgraphIngestor:
                 exc_info=True,
             )
         self.flush_all()
+        self.sinks.finish()
         if self.conn:
             self.conn.close()
-            logger.info("\nDisconnected from Memgraph.")
+            logger.info(f"\nDisconnected from {self.backend.name}.")
 
     def _execute_query(self, query: str, params: dict[str, Any] | None = None) -> list:
         if not self.conn:
-            raise ConnectionError("Not connected to Memgraph.")
+            raise ConnectionError(f"Not connected to {self.backend.name}.")
         params = params or {}
         cursor = None
         try:
@@ -65,21 +115,37 @@ class MemgraphIngestor:
     def _execute_batch(self, query: str, params_list: list[dict[str, Any]]) -> None:
         if not self.conn or not params_list:
             return
-        cursor = None
-        try:
-            cursor = self.conn.cursor()
-            batch_query = f"UNWIND $batch AS row\n{query}"
-            cursor.execute(batch_query, {"batch": params_list})
-        except Exception as e:
-            if "already exists" not in str(e).lower():
-                logger.error(f"!!! Batch Cypher Error: {e}")
-        finally:
-            if cursor:
-                cursor.close()
+        if self.query_cache:
+            # Writes of this process that leave the version alone, such as
+            # conversation memory, still change what reads return
+            self.query_cache.clear()
+        batch_query = f"UNWIND $batch AS row\n{query}"
+        for attempt in range(self.write_retries + 1):
+            cursor = None
+            try:
+                cursor = self.conn.cursor()
+                cursor.execute(batch_query, {"batch": params_list})
+                return
+            except Exception as e:
+                if self.backend.is_transient(e) and attempt < self.write_retries:
+                    delay = RETRY_BACKOFF_SECONDS * 2**attempt
+                    logger.warning(
+                        f"Batch of {len(params_list)} conflicted with another "
+                        f"write, retrying in {delay:.1f}s: {e}"
+                    )
+                    time.sleep(delay)
+                    continue
+                if "already exists" not in str(e).lower():
+                    logger.error(f"!!! Batch Cypher Error: {e}")
+                    self.failed_batches += 1
+                return
+            finally:
+                if cursor:
+                    cursor.close()
 
     def clean_database(self) -> None:
         logger.info("--- Cleaning database... ---")
-        self._execute_query("MATCH (n) DETACH DELETE n;")
+        self._execute_query(self.backend.clear_query())
         logger.info("--- Database cleaned. ---")
 
     def ensure_constraints(self) -> None:
@@ -97,15 +163,24 @@ class MemgraphIngestor:
         }
         for label, prop in constraints.items():
             try:
-                self._execute_query(
-                    f"CREATE CONSTRAINT ON (n:{label}) ASSERT n.{prop} IS UNIQUE;"
-                )
+                self._execute_query(self.backend.constraint_query(label, prop))
             except Exception:
                 pass
         logger.info("Constraints checked/created.")
 
     def ensure_node_batch(self, label: str, properties: dict[str, Any]) -> None:
-        self.node_buffer.append((label, properties))
+        if self._module_tags:
+            module_qn, tags = self._module_tags
+            qualified_name = str(properties.get("qualified_name", ""))
+            if qualified_name == module_qn or qualified_name.startswith(
+                f"{module_qn}."
+            ):
+                properties = {**properties, **tags}
+        if self.redactor:
+            properties = self.redactor.properties(properties)
+        if not self._skipping_writes:
+            self.node_buffer.append((label, properties))
+        self.sinks.node(label, properties)
         if len(self.node_buffer) >= self.batch_size:
             self.flush_nodes()
 
@@ -116,68 +191,135 @@ class MemgraphIngestor:
         to_node: tuple,
         properties: dict[str, Any] | None = None,
     ) -> None:
-        self.relationship_buffer.append((from_node, rel_type, to_node, properties))
+        if self.redactor:
+            from_node = self.redactor.node_ref(from_node)
+            to_node = self.redactor.node_ref(to_node)
+            if properties:
+                properties = self.redactor.properties(properties)
+        if not self._skipping_writes:
+            self.relationship_buffer.append((from_node, rel_type, to_node, properties))
+        self.sinks.relationship(from_node, rel_type, to_node, properties)
         if len(self.relationship_buffer) >= self.batch_size:
             self.flush_relationships()
 
+    @contextmanager
+    def skipping_writes(self) -> Iterator[None]:
+        """
+        Keep nodes and relationships out of the database meanwhile, as they
+        are there already. Sinks still see them, the parse cache among them.
+        """
+        self._skipping_writes = True
+        try:
+            yield
+        finally:
+            self._skipping_writes = False
+
+    @contextmanager
+    def discarding_writes(self) -> Iterator[None]:
+        """
+        Drop nodes and relationships meanwhile, for work done again only for
+        the state it leaves: neither the database nor the sinks see them.
+        """
+        sinks, self.sinks = self.sinks, SinkDispatcher([])
+        skipping, self._skipping_writes = self._skipping_writes, True
+        try:
+            yield
+        finally:
+            self.sinks, self._skipping_writes = sinks, skipping
+
+    @contextmanager
+    def tagging_module(self, module_qn: str, tags: dict[str, Any]) -> Iterator[None]:
+        """
+        Add properties to the nodes written meanwhile that belong to a module:
+        the module and what is qualified below it, not the packages around it.
+        """
+        self._module_tags = (module_qn, tags)
+        try:
+            yield
+        finally:
+            self._module_tags = None
+
     def flush_nodes(self) -> None:
         if not self.node_buffer:
             return
+        for query, rows in self.node_batches():
+            self._execute_batch(query, rows)
+        logger.info(f"Flushed {len(self.node_buffer)} nodes.")
+        self.node_buffer.clear()
+
+    def node_batches(self) -> list[tuple[str, list[dict[str, Any]]]]:
+        """A MERGE per label and property set, with the buffered nodes as rows.
 
-        nodes_by_label = defaultdict(list)
+        Nodes are grouped by their properties too, so a row never sets a
+        property it lacks to null; the first property is the merge key.
+        """
+        nodes_by_shape: dict[tuple[str, tuple[str, ...]], list] = defaultdict(list)
         for label, props in self.node_buffer:
-            nodes_by_label[label].append(props)
-        for label, props_list in nodes_by_label.items():
-            if not props_list:
-                continue
-            id_key = next(iter(props_list[0]))
-            prop_keys = list(props_list[0].keys())
-            set_clause = ", ".join([f"n.{key} = row.{key}" for key in prop_keys])
+            if props:
+                nodes_by_shape[(label, tuple(props))].append(props)
+        batches = []
+        for (label, prop_keys), rows in nodes_by_shape.items():
+            id_key = prop_keys[0]
+            set_clause = ", ".join(f"n.{key} = row.{key}" for key in prop_keys)
             query = (
                 f"MERGE (n:{label} {{{id_key}: row.{id_key}}}) "
                 f"ON CREATE SET {set_clause} ON MATCH SET {set_clause}"
             )
-            self._execute_batch(query, props_list)
-        logger.info(f"Flushed {len(self.node_buffer)} nodes.")
-        self.node_buffer.clear()
+            batches.append((query, rows))
+        return batches
 
     def flush_relationships(self) -> None:
         if not self.relationship_buffer:
             return
+        for query, rows in self.relationship_batches():
+            self._execute_batch(query, rows)
+        logger.info(f"Flushed {len(self.relationship_buffer)} relationships.")
+        self.relationship_buffer.clear()
 
+    def relationship_batches(self) -> list[tuple[str, list[dict[str, Any]]]]:
+        """A MATCH and MERGE per pattern of labels, keys and type, with rows."""
         rels_by_pattern = defaultdict(list)
         for from_node, rel_type, to_node, props in self.relationship_buffer:
             pattern = (from_node[0], from_node[1], rel_type, to_node[0], to_node[1])
             rels_by_pattern[pattern].append(
                 {"from_val": from_node[2], "to_val": to_node[2], "props": props or {}}
             )
-        for pattern, params_list in rels_by_pattern.items():
+        batches = []
+        for pattern, rows in rels_by_pattern.items():
             from_label, from_key, rel_type, to_label, to_key = pattern
             query = (
-                f"MATCH (a:{from_label} {{{from_key}: row.from_val}}), "
-                f"(b:{to_label} {{{to_key}: row.to_val}})\n"
+                f"MATCH {_node_pattern('a', from_label, from_key, 'row.from_val')}, "
+                f"{_node_pattern('b', to_label, to_key, 'row.to_val')}\n"
                 f"MERGE (a)-[r:{rel_type}]->(b)"
             )
-            if any(p["props"] for p in params_list):
+            if any(row["props"] for row in rows):
                 query += "\nSET r += row.props"
-            self._execute_batch(query, params_list)
-        logger.info(f"Flushed {len(self.relationship_buffer)} relationships.")
-        self.relationship_buffer.clear()
+            batches.append((query, rows))
+        return batches
 
     def flush_all(self) -> None:
         logger.info("--- Flushing all pending writes to database... ---")
         self.flush_nodes()
         self.flush_relationships()
+        self.sinks.flush()
         logger.info("--- Flushing complete. ---")
 
     def fetch_all(self, query: str, params: dict[str, Any] | None = None) -> list:
         """Executes a query and fetches all results."""
         logger.debug(f"Executing fetch query: {query} with params: {params}")
+        if self.query_cache:
+            return self.query_cache.fetch_all(self._execute_query, query, params)
         return self._execute_query(query, params)
 
+    def mark_graph_changed(self) -> None:
+        """Give the graph a new version, so cached query results go stale."""
+        self._execute_query(SET_GRAPH_VERSION, graph_version_params())
+
     def execute_write(self, query: str, params: dict[str, Any] | None = None) -> None:
         """Executes a write query without returning results."""
         logger.debug(f"Executing write query: {query} with params: {params}")
+        if self.query_cache:
+            self.query_cache.clear()
         self._execute_query(query, params)
 
     def export_graph_to_dict(self) -> dict[str, Any]:
@@ -0,0 +1,202 @@
+"""Graph sinks: plugins that receive the nodes and relationships being ingested.
+
+Sinks see every node and relationship the ingestor buffers, alongside the
+writes to Memgraph, so the same extraction can feed a warehouse, a search
+index or an audit log. They are enabled by name in GRAPH_SINKS: built-in
+sinks, sinks registered under the "code_graph_rag.sinks" entry point group,
+or "module:attribute" import paths.
+"""
+
+import importlib
+import json
+from datetime import UTC, datetime
+from importlib.metadata import entry_points
+from pathlib import Path
+from typing import Any, TextIO
+
+from loguru import logger
+
+from ..config import settings
+
+ENTRY_POINT_GROUP = "code_graph_rag.sinks"
+
+# (label, key property, key value), as passed to ensure_relationship_batch
+NodeRef = tuple[str, str, Any]
+
+
+class SinkError(ValueError):
+    """A sink name that does not resolve to a sink."""
+
+
+class GraphSink:
+    """
+    Base class for sinks; every hook is optional.
+
+    start() runs before the first node or relationship reaches the sink,
+    flush() after the ingestor writes its buffers to the database, and
+    finish() once when the ingestor closes, if the sink was started.
+    """
+
+    name = "sink"
+
+    def start(self) -> None:
+        pass
+
+    def on_node(self, label: str, properties: dict[str, Any]) -> None:
+        pass
+
+    def on_relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        pass
+
+    def flush(self) -> None:
+        pass
+
+    def finish(self) -> None:
+        pass
+
+
+class JsonLinesSink(GraphSink):
+    """Appends every node and relationship to a JSON Lines file."""
+
+    name = "jsonl"
+
+    def __init__(self, path: str | None = None):
+        self.path = Path(path or settings.GRAPH_SINK_JSONL_PATH)
+        self._file: TextIO | None = None
+
+    def start(self) -> None:
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        self._file = self.path.open("a", encoding="utf-8")
+        self._write({"event": "start"})
+
+    def on_node(self, label: str, properties: dict[str, Any]) -> None:
+        self._write({"event": "node", "label": label, "properties": properties})
+
+    def on_relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        self._write(
+            {
+                "event": "relationship",
+                "type": rel_type,
+                "from": list(from_node),
+                "to": list(to_node),
+                "properties": properties or {},
+            }
+        )
+
+    def flush(self) -> None:
+        if self._file:
+            self._file.flush()
+
+    def finish(self) -> None:
+        self._write({"event": "finish"})
+        if self._file:
+            self._file.close()
+            self._file = None
+
+    def _write(self, record: dict[str, Any]) -> None:
+        if self._file is None:
+            return
+        record["at"] = datetime.now(UTC).isoformat()
+        # Properties can hold dates or sets from analyzers; keep them readable
+        self._file.write(json.dumps(record, default=str) + "\n")
+
+
+BUILTIN_SINKS: dict[str, type[GraphSink]] = {"jsonl": JsonLinesSink}
+
+
+def create_sink(spec: str) -> GraphSink:
+    """A sink from a built-in name, an entry point name or "module:attribute"."""
+    if spec in BUILTIN_SINKS:
+        return BUILTIN_SINKS[spec]()
+    if ":" in spec:
+        module_name, _, attribute = spec.partition(":")
+        factory = getattr(importlib.import_module(module_name), attribute)
+    else:
+        matches = list(entry_points(group=ENTRY_POINT_GROUP, name=spec))
+        if not matches:
+            raise SinkError(f"Unknown graph sink '{spec}'")
+        factory = matches[0].load()
+    sink = factory() if callable(factory) else factory
+    if not isinstance(sink, GraphSink):
+        raise SinkError(f"'{spec}' did not produce a GraphSink")
+    return sink
+
+
+def load_sinks(specs: str) -> list[GraphSink]:
+    """Sinks for a comma-separated list of names; broken ones are logged."""
+    sinks = []
+    for spec in (s.strip() for s in specs.split(",")):
+        if not spec:
+            continue
+        try:
+            sinks.append(create_sink(spec))
+        except Exception as e:
+            logger.warning(f"Graph sink '{spec}' not enabled: {e}")
+    return sinks
+
+
+class SinkDispatcher:
+    """Fans ingestor events out to sinks; a sink that raises is dropped."""
+
+    def __init__(self, sinks: list[GraphSink]):
+        self.sinks = list(sinks)
+        self._started = False
+
+    def add(self, sink: GraphSink) -> None:
+        if self._started:
+            sink.start()
+        self.sinks.append(sink)
+
+    def remove(self, sink: GraphSink) -> None:
+        if sink in self.sinks:
+            self.sinks.remove(sink)
+
+    def node(self, label: str, properties: dict[str, Any]) -> None:
+        self._dispatch("on_node", label, properties)
+
+    def relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        self._dispatch("on_relationship", from_node, rel_type, to_node, properties)
+
+    def flush(self) -> None:
+        if self._started:
+            self._call_all("flush")
+
+    def finish(self) -> None:
+        if self._started:
+            self._call_all("finish")
+            self._started = False
+
+    def _dispatch(self, hook: str, *args: Any) -> None:
+        if not self.sinks:
+            return
+        if not self._started:
+            # Started lazily so query-only commands never touch the sinks
+            self._started = True
+            self._call_all("start")
+        self._call_all(hook, *args)
+
+    def _call_all(self, hook: str, *args: Any) -> None:
+        for sink in list(self.sinks):
+            try:
+                getattr(sink, hook)(*args)
+            except Exception as e:
+                logger.error(f"Graph sink '{sink.name}' failed in {hook}: {e}")
+                self.sinks.remove(sink)
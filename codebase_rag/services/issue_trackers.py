@@ -0,0 +1,121 @@
+"""Fetch issue metadata from issue trackers to hydrate Issue nodes."""
+
+import base64
+import json
+import urllib.error
+import urllib.request
+from typing import Any
+
+from loguru import logger
+
+
+class IssueTracker:
+    """Base class for tracker APIs: looks up one issue by its graph key."""
+
+    tracker = ""  # The IssueReference.tracker value this API serves
+
+    def __init__(self, token: str | None, api_url: str):
+        self.token = token
+        self.api_url = api_url.rstrip("/")
+
+    def fetch(self, issue: dict[str, Any]) -> dict[str, Any] | None:
+        """
+        Properties for an Issue node given its key, number and project, or
+        None if the tracker does not know the issue.
+        """
+        raise NotImplementedError
+
+    def _headers(self) -> dict[str, str]:
+        raise NotImplementedError
+
+    def _get(self, path: str) -> Any:
+        request = urllib.request.Request(
+            f"{self.api_url}{path}",
+            headers={**self._headers(), "Accept": "application/json"},
+        )
+        try:
+            with urllib.request.urlopen(request, timeout=30) as response:
+                return json.loads(response.read().decode("utf-8"))
+        except urllib.error.HTTPError as e:
+            if e.code == 404:
+                return None
+            raise
+
+
+class GitHubIssueTracker(IssueTracker):
+    """Issues and pull requests of GitHub repositories."""
+
+    tracker = "github"
+
+    def __init__(
+        self,
+        repository: str,  # owner/name, for "#123" references
+        token: str | None,
+        api_url: str = "https://api.github.com",
+    ):
+        super().__init__(token, api_url)
+        self.repository = repository
+
+    def fetch(self, issue: dict[str, Any]) -> dict[str, Any] | None:
+        # Cross-repository references ("owner/repo#12") carry their repository
+        repository = issue.get("project") or self.repository
+        data = self._get(f"/repos/{repository}/issues/{issue['number']}")
+        if data is None:
+            logger.debug(f"GitHub issue {issue['key']} not found in {repository}")
+            return None
+        return {
+            "title": data.get("title") or "",
+            "state": data.get("state") or "",
+            "url": data.get("html_url") or "",
+            "labels": [label["name"] for label in data.get("labels") or []],
+            "is_pull_request": "pull_request" in data,
+            "closed_at": data.get("closed_at") or "",
+        }
+
+    def _headers(self) -> dict[str, str]:
+        headers = {"X-GitHub-Api-Version": "2022-11-28"}
+        if self.token:
+            headers["Authorization"] = f"Bearer {self.token}"
+        return headers
+
+
+class JiraIssueTracker(IssueTracker):
+    """Issues of a Jira Cloud site or Jira Server/Data Center instance."""
+
+    tracker = "jira"
+
+    def __init__(self, base_url: str, token: str | None, email: str | None = None):
+        super().__init__(token, f"{base_url.rstrip('/')}/rest/api/2")
+        self.base_url = base_url.rstrip("/")
+        self.email = email
+
+    def fetch(self, issue: dict[str, Any]) -> dict[str, Any] | None:
+        data = self._get(
+            f"/issue/{issue['key']}"
+            "?fields=summary,status,issuetype,assignee,labels,parent,resolutiondate"
+        )
+        if data is None:
+            logger.debug(f"Jira issue {issue['key']} not found")
+            return None
+        fields = data.get("fields") or {}
+        return {
+            "title": fields.get("summary") or "",
+            "state": (fields.get("status") or {}).get("name", ""),
+            "url": f"{self.base_url}/browse/{issue['key']}",
+            "labels": fields.get("labels") or [],
+            "issue_type": (fields.get("issuetype") or {}).get("name", ""),
+            "assignee": (fields.get("assignee") or {}).get("displayName", ""),
+            "closed_at": fields.get("resolutiondate") or "",
+            # Sub-tasks and issues in an epic name it as their parent
+            "parent": (fields.get("parent") or {}).get("key", ""),
+        }
+
+    def _headers(self) -> dict[str, str]:
+        if not self.token:
+            return {}
+        if self.email:
+            # Jira Cloud: API token with the account's email
+            credentials = base64.b64encode(f"{self.email}:{self.token}".encode())
+            return {"Authorization": f"Basic {credentials.decode()}"}
+        # Jira Server/Data Center: personal access token
+        return {"Authorization": f"Bearer {self.token}"}
@@ -1,4 +1,4 @@
-from typing import cast
+from typing import Any, cast
 
 from loguru import logger
 from pydantic_ai import Agent, Tool
@@ -13,7 +13,12 @@ from pydantic_ai.providers.google_gla import GoogleGLAProvider
 from pydantic_ai.providers.google_vertex import GoogleVertexProvider, VertexAiRegion
 from pydantic_ai.providers.openai import OpenAIProvider
 
-from ..config import detect_provider_from_model, settings
+from ..config import (
+    detect_provider_from_model,
+    local_endpoint,
+    settings,
+    split_model_id,
+)
 from ..prompts import (
     CYPHER_SYSTEM_PROMPT,
     LOCAL_CYPHER_SYSTEM_PROMPT,
@@ -37,65 +42,82 @@ def _clean_cypher_response(response_text: str) -> str:
     return query
 
 
+def create_model(
+    model_id: str, role: str = "orchestrator"
+) -> tuple[Any, GeminiModelSettings | None]:
+    """
+    A pydantic-ai model for a model ID, from the provider it belongs to. Local
+    models are reached at the OpenAI-compatible endpoint configured for the
+    role they play.
+    """
+    model_settings = None
+    provider_name, model_name = split_model_id(model_id)
+    if settings.LOCAL_ONLY and provider_name != "local":
+        raise ValueError(
+            f"LOCAL_ONLY is set, refusing {provider_name} model {model_id}"
+        )
+    if provider_name == "gemini":
+        if settings.GEMINI_PROVIDER == "vertex":
+            provider = GoogleVertexProvider(
+                project_id=settings.GCP_PROJECT_ID,
+                region=cast(VertexAiRegion, settings.GCP_REGION),
+                service_account_file=settings.GCP_SERVICE_ACCOUNT_FILE,
+            )
+        else:
+            provider = GoogleGLAProvider(api_key=settings.GEMINI_API_KEY)  # type: ignore
+
+        if settings.GEMINI_THINKING_BUDGET is not None:
+            model_settings = GeminiModelSettings(
+                gemini_thinking_config={
+                    "thinking_budget": int(settings.GEMINI_THINKING_BUDGET)
+                }
+            )
+        return GeminiModel(model_name, provider=provider), model_settings
+    if provider_name == "openai":
+        return (
+            OpenAIResponsesModel(
+                model_name,
+                provider=OpenAIProvider(
+                    api_key=settings.OPENAI_API_KEY,
+                ),
+            ),
+            None,
+        )
+    if provider_name == "anthropic":
+        return (
+            AnthropicModel(
+                model_name,
+                provider=AnthropicProvider(
+                    api_key=settings.ANTHROPIC_API_KEY,
+                ),
+            ),
+            None,
+        )
+    # local: Ollama, vLLM, llama.cpp and other OpenAI-compatible servers
+    return (
+        OpenAIModel(  # type: ignore
+            model_name,
+            provider=OpenAIProvider(
+                api_key=settings.LOCAL_MODEL_API_KEY,
+                base_url=local_endpoint(settings, role),
+            ),
+        ),
+        None,
+    )
+
+
 class CypherGenerator:
     """Generates Cypher queries from natural language."""
 
     def __init__(self) -> None:
         try:
-            model_settings = None
-
             # Get active cypher model and detect its provider
             cypher_model_id = settings.active_cypher_model
-            cypher_provider = detect_provider_from_model(cypher_model_id)
-
-            # Configure model based on detected provider
-            if cypher_provider == "gemini":
-                if settings.GEMINI_PROVIDER == "vertex":
-                    provider = GoogleVertexProvider(
-                        project_id=settings.GCP_PROJECT_ID,
-                        region=cast(VertexAiRegion, settings.GCP_REGION),
-                        service_account_file=settings.GCP_SERVICE_ACCOUNT_FILE,
-                    )
-                else:
-                    provider = GoogleGLAProvider(api_key=settings.GEMINI_API_KEY)  # type: ignore
-
-                if settings.GEMINI_THINKING_BUDGET is not None:
-                    model_settings = GeminiModelSettings(
-                        gemini_thinking_config={
-                            "thinking_budget": int(settings.GEMINI_THINKING_BUDGET)
-                        }
-                    )
-
-                llm = GeminiModel(
-                    cypher_model_id,
-                    provider=provider,
-                )
-                system_prompt = CYPHER_SYSTEM_PROMPT
-            elif cypher_provider == "openai":
-                llm = OpenAIResponsesModel(
-                    cypher_model_id,
-                    provider=OpenAIProvider(
-                        api_key=settings.OPENAI_API_KEY,
-                    ),
-                )
-                system_prompt = CYPHER_SYSTEM_PROMPT
-            elif cypher_provider == "anthropic":
-                llm = AnthropicModel(
-                    cypher_model_id,
-                    provider=AnthropicProvider(
-                        api_key=settings.ANTHROPIC_API_KEY,
-                    ),
-                )
-                system_prompt = CYPHER_SYSTEM_PROMPT
-            else:  # local
-                llm = OpenAIModel(  # type: ignore
-                    cypher_model_id,
-                    provider=OpenAIProvider(
-                        api_key=settings.LOCAL_MODEL_API_KEY,
-                        base_url=str(settings.LOCAL_MODEL_ENDPOINT),
-                    ),
-                )
+            llm, model_settings = create_model(cypher_model_id, "cypher")
+            if detect_provider_from_model(cypher_model_id) == "local":
                 system_prompt = LOCAL_CYPHER_SYSTEM_PROMPT
+            else:
+                system_prompt = CYPHER_SYSTEM_PROMPT
             self.agent = Agent(
                 model=llm,
                 system_prompt=system_prompt,
@@ -132,56 +154,7 @@ class CypherGenerator:
 def create_rag_orchestrator(tools: list[Tool]) -> Agent:
     """Factory function to create the main RAG orchestrator agent."""
     try:
-        model_settings = None
-
-        # Get active orchestrator model and detect its provider
-        orchestrator_model_id = settings.active_orchestrator_model
-        orchestrator_provider = detect_provider_from_model(orchestrator_model_id)
-
-        if orchestrator_provider == "gemini":
-            if settings.GEMINI_PROVIDER == "vertex":
-                provider = GoogleVertexProvider(
-                    project_id=settings.GCP_PROJECT_ID,
-                    region=cast(VertexAiRegion, settings.GCP_REGION),
-                    service_account_file=settings.GCP_SERVICE_ACCOUNT_FILE,
-                )
-            else:
-                provider = GoogleGLAProvider(api_key=settings.GEMINI_API_KEY)  # type: ignore
-
-            if settings.GEMINI_THINKING_BUDGET is not None:
-                model_settings = GeminiModelSettings(
-                    gemini_thinking_config={
-                        "thinking_budget": int(settings.GEMINI_THINKING_BUDGET)
-                    }
-                )
-
-            llm = GeminiModel(
-                orchestrator_model_id,
-                provider=provider,
-            )
-        elif orchestrator_provider == "openai":
-            llm = OpenAIResponsesModel(
-                orchestrator_model_id,
-                provider=OpenAIProvider(
-                    api_key=settings.OPENAI_API_KEY,
-                ),
-            )
-        elif orchestrator_provider == "anthropic":
-            llm = AnthropicModel(
-                orchestrator_model_id,
-                provider=AnthropicProvider(
-                    api_key=settings.ANTHROPIC_API_KEY,
-                ),
-            )
-        else:  # local provider
-            llm = OpenAIModel(  # type: ignore
-                orchestrator_model_id,
-                provider=OpenAIProvider(
-                    api_key=settings.LOCAL_MODEL_API_KEY,
-                    base_url=str(settings.LOCAL_MODEL_ENDPOINT),
-                ),
-            )
-
+        llm, model_settings = create_model(settings.active_orchestrator_model)
         return Agent(
             model=llm,
             system_prompt=RAG_ORCHESTRATOR_SYSTEM_PROMPT,
@@ -0,0 +1,111 @@
+"""Reranking of retrieved snippets against the question they answer.
+
+Retrieval casts a wide net: embeddings and the call graph bring in every
+function sharing words with the question, and a large package shares many.
+A reranker reads the question and each snippet together, which an embedding
+of either alone cannot, and only the best of them go into the prompt. A
+cross-encoder (sentence-transformers) runs locally; a language model is
+slower but needs nothing installed.
+"""
+
+from collections.abc import Callable
+from typing import Protocol, TypeVar
+
+from loguru import logger
+
+from ..config import settings
+
+DEFAULT_CROSS_ENCODER = "cross-encoder/ms-marco-MiniLM-L-6-v2"
+# Characters of each snippet shown to a language model reranker
+LLM_SNIPPET_CHARS = 1500
+
+RERANK_SYSTEM_PROMPT = """You judge how well code snippets answer a question
+about a codebase. Rate each snippet from 0 (irrelevant) to 10 (exactly what
+the question asks about). Reply with the ratings only, as a JSON list of
+integers in the order of the snippets, one per snippet."""
+
+T = TypeVar("T")
+
+
+class Reranker(Protocol):
+    name: str
+
+    def score(self, question: str, texts: list[str]) -> list[float]: ...
+
+
+class CrossEncoderReranker:
+    """Relevance from a cross-encoder scoring each (question, snippet) pair."""
+
+    def __init__(self, model: str = DEFAULT_CROSS_ENCODER):
+        from sentence_transformers import CrossEncoder
+
+        self.name = model
+        self.model = CrossEncoder(model)
+
+    def score(self, question: str, texts: list[str]) -> list[float]:
+        scores = self.model.predict([(question, text) for text in texts])
+        return [float(score) for score in scores]
+
+
+class LLMReranker:
+    """Relevance ratings from a language model, for all snippets in one call."""
+
+    def __init__(self, model_id: str):
+        from pydantic_ai import Agent
+
+        from .llm import create_model
+
+        # A local model is served next to the Cypher model it defaults to
+        llm, model_settings = create_model(model_id, "cypher")
+        self.name = model_id
+        self.agent = Agent(
+            model=llm,
+            system_prompt=RERANK_SYSTEM_PROMPT,
+            output_type=list[int],
+            model_settings=model_settings,
+        )
+
+    def score(self, question: str, texts: list[str]) -> list[float]:
+        snippets = "\n\n".join(
+            f"Snippet {number}:\n{text[:LLM_SNIPPET_CHARS]}"
+            for number, text in enumerate(texts, 1)
+        )
+        result = self.agent.run_sync(f"Question: {question}\n\n{snippets}")
+        ratings = list(result.output)
+        if len(ratings) != len(texts):
+            raise ValueError(f"Got {len(ratings)} ratings for {len(texts)} snippets")
+        return [float(rating) for rating in ratings]
+
+
+def rerank(
+    reranker: Reranker,
+    question: str,
+    candidates: list[T],
+    text: Callable[[T], str],
+    top_k: int,
+) -> list[tuple[T, float | None]]:
+    """
+    The top_k candidates most relevant to the question, best first, with
+    their scores. Candidates keep their retrieval order when the reranker
+    fails, scored as None.
+    """
+    if not candidates:
+        return []
+    try:
+        scores = reranker.score(question, [text(c) for c in candidates])
+    except Exception as e:
+        logger.warning(f"Reranking with {reranker.name} failed: {e}")
+        return [(candidate, None) for candidate in candidates[:top_k]]
+    order = sorted(range(len(candidates)), key=lambda i: -scores[i])
+    return [(candidates[i], scores[i]) for i in order[:top_k]]
+
+
+def create_reranker() -> Reranker | None:
+    """The reranker chosen by RERANKER, or None when reranking is off."""
+    if settings.RERANKER == "cross-encoder":
+        return CrossEncoderReranker(
+            settings.RERANKER_MODEL_ID or DEFAULT_CROSS_ENCODER
+        )
+    if settings.RERANKER == "llm":
+        return LLMReranker(settings.RERANKER_MODEL_ID or settings.active_cypher_model)
+    return None
@@ -0,0 +1,175 @@
+"""Post review comments to GitHub pull requests and GitLab merge requests."""
+
+import json
+import urllib.error
+import urllib.request
+from typing import Any
+from urllib.parse import quote
+
+from loguru import logger
+
+from ..analysis.review import ReviewComment, ReviewReport
+
+
+class ReviewPublisher:
+    """Base class for hosting APIs: fetches a change's diff and posts a review."""
+
+    def __init__(self, token: str | None, api_url: str):
+        self.token = token
+        self.api_url = api_url.rstrip("/")
+
+    def fetch_diff(self) -> str:
+        raise NotImplementedError
+
+    def publish(self, report: ReviewReport) -> int:
+        """Post the report; returns the number of inline comments posted."""
+        raise NotImplementedError
+
+    def _headers(self) -> dict[str, str]:
+        raise NotImplementedError
+
+    def _request(
+        self,
+        method: str,
+        path: str,
+        payload: dict[str, Any] | None = None,
+        accept: str = "application/json",
+    ) -> Any:
+        data = json.dumps(payload).encode("utf-8") if payload is not None else None
+        request = urllib.request.Request(
+            f"{self.api_url}{path}",
+            data=data,
+            method=method,
+            headers={
+                **self._headers(),
+                "Accept": accept,
+                "Content-Type": "application/json",
+            },
+        )
+        with urllib.request.urlopen(request, timeout=30) as response:
+            body = response.read().decode("utf-8")
+        if accept != "application/json":
+            return body
+        return json.loads(body) if body else None
+
+
+class GitHubReviewPublisher(ReviewPublisher):
+    """Posts a single COMMENT review with inline comments on a pull request."""
+
+    def __init__(
+        self,
+        repository: str,  # owner/name
+        pull_number: int,
+        token: str | None,
+        api_url: str = "https://api.github.com",
+    ):
+        super().__init__(token, api_url)
+        self.pulls_path = f"/repos/{repository}/pulls/{pull_number}"
+        self.issue_path = f"/repos/{repository}/issues/{pull_number}"
+        self.comments_path = f"/repos/{repository}/issues/comments"
+
+    def fetch_diff(self) -> str:
+        return str(
+            self._request("GET", self.pulls_path, accept="application/vnd.github.diff")
+        )
+
+    def publish(self, report: ReviewReport) -> int:
+        head_sha = self._request("GET", self.pulls_path)["head"]["sha"]
+        self._request(
+            "POST",
+            f"{self.pulls_path}/reviews",
+            {
+                "commit_id": head_sha,
+                "event": "COMMENT",
+                "body": report.summary(),
+                "comments": [
+                    {
+                        "path": c.path,
+                        "line": c.line,
+                        "side": "RIGHT",
+                        "body": format_comment(c),
+                    }
+                    for c in report.comments
+                ],
+            },
+        )
+        return len(report.comments)
+
+    def upsert_comment(self, body: str, marker: str) -> None:
+        """Post a conversation comment, or edit the one starting with marker."""
+        comments = self._request("GET", f"{self.issue_path}/comments?per_page=100")
+        existing = next(
+            (c for c in comments or [] if c.get("body", "").startswith(marker)), None
+        )
+        if existing:
+            path = f"{self.comments_path}/{existing['id']}"
+            self._request("PATCH", path, {"body": body})
+        else:
+            self._request("POST", f"{self.issue_path}/comments", {"body": body})
+
+    def _headers(self) -> dict[str, str]:
+        headers = {"X-GitHub-Api-Version": "2022-11-28"}
+        if self.token:
+            headers["Authorization"] = f"Bearer {self.token}"
+        return headers
+
+
+class GitLabReviewPublisher(ReviewPublisher):
+    """Posts a summary note and one diff discussion per comment on a merge request."""
+
+    def __init__(
+        self,
+        project: str,  # group/name or numeric id
+        merge_request_iid: int,
+        token: str | None,
+        api_url: str = "https://gitlab.com/api/v4",
+    ):
+        super().__init__(token, api_url)
+        self.mr_path = (
+            f"/projects/{quote(project, safe='')}/merge_requests/{merge_request_iid}"
+        )
+
+    def fetch_diff(self) -> str:
+        changes = self._request("GET", f"{self.mr_path}/changes")["changes"]
+        parts = []
+        for change in changes:
+            old = "/dev/null" if change.get("new_file") else f"a/{change['old_path']}"
+            new = (
+                "/dev/null" if change.get("deleted_file") else f"b/{change['new_path']}"
+            )
+            parts.append(f"--- {old}\n+++ {new}\n{change.get('diff', '')}")
+        return "\n".join(parts)
+
+    def publish(self, report: ReviewReport) -> int:
+        diff_refs = self._request("GET", self.mr_path)["diff_refs"]
+        self._request("POST", f"{self.mr_path}/notes", {"body": report.summary()})
+        posted = 0
+        for comment in report.comments:
+            position = {
+                "position_type": "text",
+                "base_sha": diff_refs["base_sha"],
+                "start_sha": diff_refs["start_sha"],
+                "head_sha": diff_refs["head_sha"],
+                "new_path": comment.path,
+                "new_line": comment.line,
+            }
+            try:
+                self._request(
+                    "POST",
+                    f"{self.mr_path}/discussions",
+                    {"body": format_comment(comment), "position": position},
+                )
+                posted += 1
+            except urllib.error.HTTPError as e:
+                # GitLab rejects positions outside the diff instead of moving them
+                logger.warning(
+                    f"Could not comment on {comment.path}:{comment.line}: {e}"
+                )
+        return posted
+
+    def _headers(self) -> dict[str, str]:
+        return {"PRIVATE-TOKEN": self.token} if self.token else {}
+
+
+def format_comment(comment: ReviewComment) -> str:
+    return f"**{comment.severity}**: {comment.body}"
@@ -0,0 +1,200 @@
+"""Ingesting a monorepo in shards built apart and merged into one graph.
+
+A shard is the part of the graph parsed from one top-level directory,
+written without a database to a JSON Lines file, so shards can be built in
+parallel on different machines with a checkout each. Nodes of a shard carry
+its name in a `shard` property: merging a shard again first deletes the
+subgraph it left last time, and queries can keep to one shard.
+
+Relationships are written by matching the nodes at both ends, so merging
+loads the nodes of every shard before any relationship, which lets imports
+and other edges cross shards. Calls a shard could not resolve among its own
+functions are kept by name and resolved once every shard is loaded.
+"""
+
+import json
+from collections import defaultdict
+from collections.abc import Iterator
+from dataclasses import dataclass
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import Any, TextIO
+
+from .graph_updater import GraphUpdater
+from .services.graph_service import MemgraphIngestor
+from .services.graph_sinks import GraphSink, NodeRef
+
+SHARD_FORMAT = 1
+
+# Written by every shard, since each walks the whole directory tree, and
+# owned by none of them
+SHARED_LABELS = frozenset({"Project", "Package", "Folder"})
+
+
+class ShardWriter(GraphSink):
+    """Writes the nodes and relationships being ingested to a shard file."""
+
+    name = "shard"
+
+    def __init__(self, path: Path, shard: str, project: str):
+        self.path = path
+        self.shard = shard
+        self.project = project
+        self.nodes = 0
+        self.relationships = 0
+        self._file: TextIO | None = None
+
+    def start(self) -> None:
+        if self._file is not None:
+            return
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        self._file = self.path.open("w", encoding="utf-8")
+        self._write(
+            {
+                "format": SHARD_FORMAT,
+                "shard": self.shard,
+                "project": self.project,
+                "built_at": datetime.now(UTC).isoformat(),
+            }
+        )
+
+    def on_node(self, label: str, properties: dict[str, Any]) -> None:
+        if label not in SHARED_LABELS:
+            properties = {**properties, "shard": self.shard}
+        self._write({"node": label, "properties": properties})
+        self.nodes += 1
+
+    def on_relationship(
+        self,
+        from_node: NodeRef,
+        rel_type: str,
+        to_node: NodeRef,
+        properties: dict[str, Any] | None,
+    ) -> None:
+        self._write(
+            {
+                "relationship": rel_type,
+                "from": list(from_node),
+                "to": list(to_node),
+                "properties": properties or {},
+            }
+        )
+        self.relationships += 1
+
+    def write_calls(self, calls: list[tuple[str, str, str, str]]) -> None:
+        for call in calls:
+            self._write({"call": list(call)})
+
+    def finish(self) -> None:
+        if self._file:
+            self._file.close()
+            self._file = None
+
+    def _write(self, record: dict[str, Any]) -> None:
+        if self._file is None:
+            return
+        # Properties can hold dates or sets from analyzers; keep them readable
+        self._file.write(json.dumps(record, default=str) + "\n")
+
+
+def write_shard(updater: GraphUpdater, shard: str, output: Path) -> ShardWriter:
+    """
+    Run updater, whose folder filter selects the shard's directory, into a
+    shard file; the writer returned has the counts written.
+    """
+    writer = ShardWriter(output, shard, updater.project_name)
+    updater.unresolved_calls = []
+    updater.ingestor.sinks.add(writer)
+    try:
+        writer.start()
+        updater.run()
+        writer.write_calls(updater.unresolved_calls)
+    finally:
+        updater.ingestor.sinks.remove(writer)
+        writer.finish()
+    return writer
+
+
+@dataclass
+class MergeResult:
+    """What merging shard files loaded, and how many kept calls resolved."""
+
+    shards: list[str]
+    nodes: int = 0
+    relationships: int = 0
+    calls_linked: int = 0
+    calls_unresolved: int = 0
+
+
+def read_header(path: Path) -> dict[str, Any]:
+    with path.open(encoding="utf-8") as f:
+        header = json.loads(f.readline() or "{}")
+    if header.get("format") != SHARD_FORMAT or "shard" not in header:
+        raise ValueError(f"{path} is not a shard file of format {SHARD_FORMAT}")
+    return header
+
+
+def merge_shards(
+    ingestor: MemgraphIngestor, updater: GraphUpdater, paths: list[Path]
+) -> MergeResult:
+    """
+    Load shard files into the graph, replacing what earlier merges of the
+    same shards wrote. Edges from shards not merged now into these ones are
+    deleted with the old subgraphs, so every shard is best merged together.
+    """
+    headers = [read_header(path) for path in paths]
+    names = [header["shard"] for header in headers]
+    repeated = sorted({name for name in names if names.count(name) > 1})
+    if repeated:
+        raise ValueError(f"Shards given more than once: {', '.join(repeated)}")
+    for path, header in zip(paths, headers):
+        if header["project"] != updater.project_name:
+            raise ValueError(
+                f"{path} was built for project {header['project']}, "
+                f"not {updater.project_name}"
+            )
+
+    result = MergeResult(shards=names)
+    for name in names:
+        ingestor.execute_write(
+            "MATCH (n {shard: $shard}) DETACH DELETE n", {"shard": name}
+        )
+    for path in paths:
+        for record in _records(path, "node"):
+            ingestor.ensure_node_batch(record["node"], record["properties"])
+            result.nodes += 1
+    # Every node is in place before relationships match their ends
+    ingestor.flush_all()
+    for path in paths:
+        for record in _records(path, "relationship"):
+            ingestor.ensure_relationship_batch(
+                tuple(record["from"]),
+                record["relationship"],
+                tuple(record["to"]),
+                record["properties"] or None,
+            )
+            result.relationships += 1
+    ingestor.flush_all()
+
+    updater.load_function_registry()
+    calls: dict[str, list[tuple[str, str, str]]] = defaultdict(list)
+    for path in paths:
+        for record in _records(path, "call"):
+            module_qn, caller_type, caller_qn, call_name = record["call"]
+            calls[module_qn].append((caller_type, caller_qn, call_name))
+    for module_qn, module_calls in calls.items():
+        linked = updater.link_calls(module_qn, module_calls)
+        result.calls_linked += linked
+        result.calls_unresolved += len(module_calls) - linked
+    ingestor.flush_all()
+    ingestor.mark_graph_changed()
+    return result
+
+
+def _records(path: Path, kind: str) -> Iterator[dict[str, Any]]:
+    with path.open(encoding="utf-8") as f:
+        next(f)  # The header
+        for line in f:
+            record = json.loads(line)
+            if kind in record:
+                yield record
@@ -0,0 +1,248 @@
+"""Saving the whole graph to an archive and restoring it elsewhere.
+
+A snapshot is a gzipped tar of a manifest and two JSON Lines files, one of
+nodes and one of relationships, holding every label and property. Vectors
+stored by `embed` and the EmbeddingIndex node describing them are part of
+the graph, so a restored snapshot answers semantic searches without
+embedding again. The parse cache is not included: it lives on disk and
+only spares parsing, not the graph.
+
+Relationships refer to nodes by their id in the graph the snapshot was
+taken from. Restoring gives each node that id under a temporary label and
+property, indexed, so relationships can match both ends; both are removed
+once everything is loaded.
+"""
+
+import io
+import json
+import tarfile
+import tempfile
+from collections import defaultdict
+from collections.abc import Callable, Iterator
+from dataclasses import dataclass
+from datetime import UTC, datetime
+from pathlib import Path
+from typing import IO, Any
+
+from .semantic_search import INDEX_QUERY
+from .services.graph_service import MemgraphIngestor
+
+SNAPSHOT_FORMAT = 1
+MANIFEST = "manifest.json"
+NODES_FILE = "nodes.jsonl"
+RELATIONSHIPS_FILE = "relationships.jsonl"
+PAGE_SIZE = 5000
+
+RESTORE_LABEL = "SnapshotRestore"
+RESTORE_KEY = "snapshot_id"
+
+NODES_PAGE_QUERY = """
+MATCH (n)
+WHERE id(n) > $after
+RETURN id(n) AS id, labels(n) AS labels, properties(n) AS properties
+ORDER BY id
+LIMIT $limit
+"""
+
+RELATIONSHIPS_PAGE_QUERY = """
+MATCH (a)-[r]->(b)
+WHERE id(r) > $after
+RETURN id(r) AS id, id(a) AS from_id, id(b) AS to_id, type(r) AS type,
+       properties(r) AS properties
+ORDER BY id
+LIMIT $limit
+"""
+
+PROJECTS_QUERY = "MATCH (p:Project) RETURN p.name AS name ORDER BY name"
+COUNT_QUERY = "MATCH (n) RETURN count(n) AS count"
+
+
+@dataclass
+class SnapshotResult:
+    """What a snapshot archive holds, or what restoring one loaded."""
+
+    path: Path
+    projects: list[str]
+    nodes: int = 0
+    relationships: int = 0
+    embedding_model: str | None = None
+
+
+def save_snapshot(ingestor: MemgraphIngestor, output: Path) -> SnapshotResult:
+    """Write every node and relationship of the graph to a snapshot archive."""
+    index = ingestor.fetch_all(INDEX_QUERY)
+    result = SnapshotResult(
+        path=output,
+        projects=[row["name"] for row in ingestor.fetch_all(PROJECTS_QUERY)],
+        embedding_model=index[0]["model"] if index else None,
+    )
+    output.parent.mkdir(parents=True, exist_ok=True)
+    # Spooled to disk, as a monorepo's graph with vectors can be gigabytes
+    with (
+        tarfile.open(output, "w:gz") as archive,
+        tempfile.TemporaryFile() as nodes,
+        tempfile.TemporaryFile() as relationships,
+    ):
+        for row in _pages(ingestor, NODES_PAGE_QUERY):
+            _write_line(nodes, row)
+            result.nodes += 1
+        for row in _pages(ingestor, RELATIONSHIPS_PAGE_QUERY):
+            del row["id"]
+            _write_line(relationships, row)
+            result.relationships += 1
+        manifest = {
+            "format": SNAPSHOT_FORMAT,
+            "created_at": datetime.now(UTC).isoformat(),
+            "projects": result.projects,
+            "nodes": result.nodes,
+            "relationships": result.relationships,
+            "embedding_index": index[0] if index else None,
+        }
+        data = json.dumps(manifest, indent=2, default=str).encode("utf-8")
+        _add(archive, MANIFEST, io.BytesIO(data))
+        _add(archive, NODES_FILE, nodes)
+        _add(archive, RELATIONSHIPS_FILE, relationships)
+    return result
+
+
+def read_manifest(path: Path) -> dict[str, Any]:
+    with tarfile.open(path, "r:gz") as archive:
+        manifest = json.load(_member(archive, MANIFEST))
+    if manifest.get("format") != SNAPSHOT_FORMAT:
+        raise ValueError(f"{path} is not a snapshot of format {SNAPSHOT_FORMAT}")
+    return manifest
+
+
+def snapshot_rows(path: Path, name: str) -> Iterator[dict[str, Any]]:
+    """The rows of NODES_FILE or RELATIONSHIPS_FILE of a snapshot archive."""
+    read_manifest(path)
+    with tarfile.open(path, "r:gz") as archive:
+        yield from _lines(_member(archive, name))
+
+
+def restore_snapshot(
+    ingestor: MemgraphIngestor, path: Path, replace: bool = False
+) -> SnapshotResult:
+    """
+    Load a snapshot archive into the graph. The graph must be empty unless
+    replace is set, which deletes what it holds first.
+    """
+    manifest = read_manifest(path)
+    if ingestor.fetch_all(COUNT_QUERY)[0]["count"]:
+        if not replace:
+            raise ValueError(
+                "The graph is not empty; restore with --replace to clear it"
+            )
+        ingestor.clean_database()
+    ingestor.ensure_constraints()
+
+    index = manifest.get("embedding_index") or {}
+    result = SnapshotResult(
+        path=path, projects=manifest["projects"], embedding_model=index.get("model")
+    )
+    backend = ingestor.backend
+    ingestor.execute_write(backend.index_query(RESTORE_LABEL, RESTORE_KEY))
+    with tarfile.open(path, "r:gz") as archive:
+        result.nodes = _load(
+            ingestor,
+            _lines(_member(archive, NODES_FILE)),
+            lambda row: tuple(row["labels"]),
+            _create_nodes_query,
+        )
+        result.relationships = _load(
+            ingestor,
+            _lines(_member(archive, RELATIONSHIPS_FILE)),
+            lambda row: row["type"],
+            _create_relationships_query,
+        )
+    ingestor.execute_write(
+        f"MATCH (n:{RESTORE_LABEL}) REMOVE n:{RESTORE_LABEL}, n.{RESTORE_KEY}"
+    )
+    ingestor.execute_write(backend.drop_index_query(RESTORE_LABEL, RESTORE_KEY))
+    ingestor.mark_graph_changed()
+    return result
+
+
+def _load(
+    ingestor: MemgraphIngestor,
+    rows: Iterator[dict[str, Any]],
+    group_of: Callable[[dict[str, Any]], Any],
+    query_for: Callable[[Any], str],
+) -> int:
+    """Write rows in batches of one label set or relationship type each."""
+    groups: dict[Any, list[dict[str, Any]]] = defaultdict(list)
+    loaded = 0
+    for row in rows:
+        key = group_of(row)
+        groups[key].append(row)
+        loaded += 1
+        if len(groups[key]) >= ingestor.batch_size:
+            ingestor.execute_write(query_for(key), {"rows": groups.pop(key)})
+    for key, group in groups.items():
+        if group:
+            ingestor.execute_write(query_for(key), {"rows": group})
+    return loaded
+
+
+def _create_nodes_query(labels: tuple[str, ...]) -> str:
+    label_list = "".join(f":{_escape(label)}" for label in labels)
+    return (
+        "UNWIND $rows AS row\n"
+        f"CREATE (n:{RESTORE_LABEL}{label_list})\n"
+        f"SET n += row.properties, n.{RESTORE_KEY} = row.id"
+    )
+
+
+def _create_relationships_query(rel_type: str) -> str:
+    return (
+        "UNWIND $rows AS row\n"
+        f"MATCH (a:{RESTORE_LABEL} {{{RESTORE_KEY}: row.from_id}}), "
+        f"(b:{RESTORE_LABEL} {{{RESTORE_KEY}: row.to_id}})\n"
+        f"CREATE (a)-[r:{_escape(rel_type)}]->(b)\n"
+        "SET r += row.properties"
+    )
+
+
+def _escape(name: str) -> str:
+    return "`" + name.replace("`", "``") + "`"
+
+
+def _pages(ingestor: MemgraphIngestor, query: str) -> Iterator[dict[str, Any]]:
+    """Every row of query, fetched a page at a time in order of id."""
+    after = -1
+    while True:
+        rows = ingestor.fetch_all(query, {"after": after, "limit": PAGE_SIZE})
+        if rows:
+            after = rows[-1]["id"]
+        yield from rows
+        if len(rows) < PAGE_SIZE:
+            return
+
+
+def _write_line(f: IO[bytes], row: dict[str, Any]) -> None:
+    # Temporal properties come back as datetimes; they are kept as text
+    f.write(json.dumps(row, default=str).encode("utf-8") + b"\n")
+
+
+def _add(archive: tarfile.TarFile, name: str, f: IO[bytes]) -> None:
+    info = tarfile.TarInfo(name)
+    info.size = f.seek(0, io.SEEK_END)
+    info.mtime = int(datetime.now(UTC).timestamp())
+    f.seek(0)
+    archive.addfile(info, f)
+
+
+def _member(archive: tarfile.TarFile, name: str) -> IO[bytes]:
+    try:
+        f = archive.extractfile(name)
+    except KeyError:
+        f = None
+    if f is None:
+        raise ValueError(f"{archive.name} has no {name}")
+    return f
+
+
+def _lines(f: IO[bytes]) -> Iterator[dict[str, Any]]:
+    for line in f:
+        if line.strip():
+            yield json.loads(line)
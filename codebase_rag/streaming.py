@@ -0,0 +1,168 @@
+"""Answers rendered as the agent writes them, with its tool calls as they run.
+
+Without streaming a question can sit behind a spinner for a minute while the
+agent generates Cypher, reads files and writes a long answer. Streaming runs
+the agent node by node: each tool call is printed when it is made, with the
+arguments it was given, and its result summarised when it returns (the
+Cypher a graph query ran, the number of rows or matches), and answer text
+is rendered as Markdown as its tokens arrive.
+"""
+
+import json
+from typing import Any, Protocol
+
+from pydantic_ai import Agent
+from rich.console import Console
+from rich.live import Live
+from rich.markdown import Markdown
+from rich.markup import escape
+from rich.panel import Panel
+
+# Longest argument value or result summary shown for a tool call
+MAX_SHOWN_CHARS = 100
+
+
+class StreamPrinter(Protocol):
+    def text_start(self, text: str) -> None: ...
+
+    def text_delta(self, delta: str) -> None: ...
+
+    def tool_call(self, name: str, args: dict[str, Any]) -> None: ...
+
+    def tool_result(self, name: str, content: Any) -> None: ...
+
+    def done(self) -> None: ...
+
+
+def _shorten(text: str) -> str:
+    text = " ".join(text.split())
+    if len(text) <= MAX_SHOWN_CHARS:
+        return text
+    return text[: MAX_SHOWN_CHARS - 3] + "..."
+
+
+def tool_arguments(args: Any) -> dict[str, Any]:
+    """A tool call's arguments, which models send as a dict or a JSON string."""
+    if isinstance(args, dict):
+        return args
+    if isinstance(args, str) and args:
+        try:
+            parsed = json.loads(args)
+        except json.JSONDecodeError:
+            return {"args": args}
+        return parsed if isinstance(parsed, dict) else {"args": parsed}
+    return {}
+
+
+def describe_call(name: str, args: dict[str, Any]) -> str:
+    """A tool call as it would be written in Python, long values shortened."""
+    shown = ", ".join(f"{key}={_shorten(repr(value))}" for key, value in args.items())
+    return f"{name}({shown})"
+
+
+def summarize_result(content: Any) -> str:
+    """What a tool returned, in a line: the query run, or how much came back."""
+    if hasattr(content, "model_dump"):
+        content = content.model_dump()
+    if isinstance(content, dict):
+        if content.get("error"):
+            return f"error: {_shorten(str(content['error']))}"
+        if "query_used" in content:
+            rows = len(content.get("results") or [])
+            return f"{_shorten(content['query_used'])} -> {rows} row(s)"
+        if content.get("qualified_name"):
+            return str(content["qualified_name"])
+    if isinstance(content, list):
+        return f"{len(content)} result(s)"
+    text = str(content)
+    return _shorten(text.splitlines()[0]) if text.strip() else "(empty)"
+
+
+def handle_event(event: Any, printer: StreamPrinter) -> None:
+    """Pass one event of an agent run on to the printer."""
+    kind = getattr(event, "event_kind", None)
+    if kind == "part_start" and event.part.part_kind == "text":
+        printer.text_start(event.part.content)
+    elif kind == "part_delta" and event.delta.part_delta_kind == "text":
+        printer.text_delta(event.delta.content_delta)
+    elif kind == "function_tool_call":
+        printer.tool_call(event.part.tool_name, tool_arguments(event.part.args))
+    elif kind == "function_tool_result":
+        # Not retry prompts, sent back when the arguments did not validate
+        if event.result.part_kind == "tool-return":
+            printer.tool_result(event.result.tool_name, event.result.content)
+
+
+async def stream_run(
+    agent: Any,
+    question: str,
+    message_history: list[Any],
+    printer: StreamPrinter,
+) -> Any:
+    """Run the agent on a question, printing as it goes; the run's result."""
+    try:
+        async with agent.iter(question, message_history=message_history) as run:
+            async for node in run:
+                # Only model requests and tool calls have events to show
+                if not (
+                    Agent.is_model_request_node(node)
+                    or Agent.is_call_tools_node(node)
+                ):
+                    continue
+                async with node.stream(run.ctx) as events:
+                    async for event in events:
+                        handle_event(event, printer)
+    finally:
+        printer.done()
+    return run.result
+
+
+class ConsoleStream:
+    """Prints a streamed run: tool calls as dim lines, text as live Markdown."""
+
+    def __init__(self, console: Console):
+        self.console = console
+        self.text = ""
+        self._live: Live | None = None
+
+    def _panel(self) -> Panel:
+        return Panel(
+            Markdown(self.text),
+            title="[bold green]Answer[/bold green]",
+            border_style="green",
+        )
+
+    def text_start(self, text: str) -> None:
+        # Each model response starts a panel of its own
+        self._stop()
+        self.text = text
+        self._live = Live(
+            self._panel(),
+            console=self.console,
+            refresh_per_second=8,
+            vertical_overflow="visible",
+        )
+        self._live.start()
+
+    def text_delta(self, delta: str) -> None:
+        if self._live is None:
+            self.text_start(delta)
+            return
+        self.text += delta
+        self._live.update(self._panel())
+
+    def tool_call(self, name: str, args: dict[str, Any]) -> None:
+        self._stop()
+        self.console.print(f"[dim]> {escape(describe_call(name, args))}[/dim]")
+
+    def tool_result(self, name: str, content: Any) -> None:
+        summary = escape(summarize_result(content))
+        self.console.print(f"[dim]  {name}: {summary}[/dim]", highlight=False)
+
+    def done(self) -> None:
+        self._stop()
+
+    def _stop(self) -> None:
+        if self._live is not None:
+            self._live.stop()
+            self._live = None
@@ -0,0 +1,135 @@
+"""Fuzzy lookup of functions, methods and classes by the names people type.
+
+Names are compared by their words rather than their spelling conventions, so
+getUserName, get_user_name and GetUserName are the same name, case is
+ignored, and small typos are forgiven: "calcualtor.Divde" finds
+shop.Calculator.divide. A dotted query matches the trailing parts of a
+qualified name, one part against each.
+"""
+
+import re
+from dataclasses import dataclass
+from difflib import SequenceMatcher, get_close_matches
+from typing import Any
+
+# Below this similarity a query part does not match a name part
+MIN_PART_SIMILARITY = 0.75
+# Close spellings considered per query, before scoring whole qualified names
+MAX_CLOSE_NAMES = 50
+
+SYMBOLS_QUERY = """
+MATCH (n) WHERE (n:Function OR n:Method OR n:Class) AND n.qualified_name IS NOT NULL
+RETURN n.qualified_name AS qualified_name, labels(n)[0] AS label
+"""
+
+WORD = re.compile(r"[A-Z]+(?=[A-Z][a-z]|\d|\b|_)|[A-Z]?[a-z]+|[A-Z]+|\d+")
+# Identifiers worth linking in a question: dotted, snake_case, camelCase
+# or quoted in backticks; plain English words are left alone
+IDENTIFIER = re.compile(
+    r"`([^`]+)`"
+    r"|\b([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)+)\b"
+    r"|\b([A-Za-z]\w*_\w+)\b"
+    r"|\b([a-z]+[A-Z]\w*|[A-Z][a-z0-9]+[A-Z]\w*)\b"
+)
+
+
+def split_identifier(name: str) -> list[str]:
+    """Lower-cased words of an identifier: parseHTTPRequest -> parse, http, request."""
+    return [word.lower() for word in WORD.findall(name)]
+
+
+def normalize(name: str) -> str:
+    return "".join(split_identifier(name))
+
+
+@dataclass
+class SymbolMatch:
+    qualified_name: str
+    label: str
+    score: float  # 1.0 for an exact match of every part
+
+
+class SymbolIndex:
+    """Qualified names grouped by the normalized form of their last part."""
+
+    def __init__(self, symbols: list[tuple[str, str]]):
+        self.by_name: dict[str, list[tuple[str, str]]] = {}
+        for qualified_name, label in symbols:
+            last = normalize(qualified_name.rsplit(".", 1)[-1])
+            self.by_name.setdefault(last, []).append((qualified_name, label))
+
+    @classmethod
+    def from_graph(cls, ingestor: Any) -> "SymbolIndex":
+        rows = ingestor.fetch_all(SYMBOLS_QUERY)
+        return cls([(row["qualified_name"], row["label"]) for row in rows])
+
+    def search(self, query: str, limit: int = 10) -> list[SymbolMatch]:
+        """Best matches first; ties go to the shorter qualified name."""
+        parts = [normalize(part) for part in query.strip().split(".")]
+        parts = [part for part in parts if part]
+        if not parts:
+            return []
+        last = parts[-1]
+        names = {name for name in self.by_name if name.startswith(last)}
+        names.update(
+            get_close_matches(
+                last, self.by_name, n=MAX_CLOSE_NAMES, cutoff=MIN_PART_SIMILARITY
+            )
+        )
+
+        matches = []
+        for name in names:
+            for qualified_name, label in self.by_name[name]:
+                score = _score(parts, qualified_name)
+                if score is not None:
+                    matches.append(SymbolMatch(qualified_name, label, score))
+        matches.sort(key=lambda m: (-m.score, len(m.qualified_name)))
+        return matches[:limit]
+
+
+def _score(parts: list[str], qualified_name: str) -> float | None:
+    names = [normalize(part) for part in qualified_name.split(".")]
+    if len(names) < len(parts):
+        return None
+    scores = []
+    for part, name in zip(parts, names[-len(parts) :], strict=True):
+        if part == name:
+            scores.append(1.0)
+        elif name.startswith(part):
+            # A prefix is a good match, better the more of the name it covers
+            scores.append(0.8 + 0.2 * len(part) / len(name))
+        else:
+            similarity = SequenceMatcher(None, part, name).ratio()
+            if similarity < MIN_PART_SIMILARITY:
+                return None
+            scores.append(similarity * 0.95)
+    return round(sum(scores) / len(scores), 3)
+
+
+def link_entities(
+    question: str, index: SymbolIndex, min_score: float = 0.8
+) -> dict[str, SymbolMatch]:
+    """The best graph symbol for each identifier in a natural language question."""
+    links = {}
+    for match in IDENTIFIER.finditer(question):
+        mention = next(group for group in match.groups() if group)
+        if mention in links:
+            continue
+        found = index.search(mention, limit=1)
+        if found and found[0].score >= min_score:
+            links[mention] = found[0]
+    return links
+
+
+def annotate_question(question: str, links: dict[str, SymbolMatch]) -> str:
+    """The question with the linked qualified names appended for the query model."""
+    if not links:
+        return question
+    lines = [
+        f"- {mention} is {match.label} {match.qualified_name}"
+        for mention, match in links.items()
+    ]
+    return (
+        f"{question}\n\nSymbols in the graph the question refers to "
+        "(use these exact qualified names):\n" + "\n".join(lines)
+    )
@@ -0,0 +1,43 @@
+package calculator
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "github.com/stretchr/testify/suite"
+)
+
+type CalculatorSuite struct {
+    suite.Suite
+    calc *Calculator
+}
+
+func (s *CalculatorSuite) SetupTest() {
+    s.calc = NewCalculator()
+}
+
+func (s *CalculatorSuite) TearDownTest() {
+    s.calc = nil
+}
+
+func (s *CalculatorSuite) TestAdd() {
+    s.Equal(5, s.calc.Add(2, 3))
+}
+
+func (s *CalculatorSuite) TestDivide() {
+    s.Run("by zero", func() {
+        _, err := s.calc.Divide(1, 0)
+        s.Require().Error(err)
+    })
+}
+
+func TestCalculatorSuite(t *testing.T) {
+    suite.Run(t, new(CalculatorSuite))
+}
+
+func TestMultiply(t *testing.T) {
+    calc := NewCalculator()
+    require.NotNil(t, calc)
+    assert.Equal(t, 6, calc.Multiply(2, 3))
+}
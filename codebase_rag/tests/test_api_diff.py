@@ -0,0 +1,232 @@
+"""Tests for public API extraction and diffs between revisions."""
+
+import subprocess
+import tempfile
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.api_surface import (
+    ApiSymbol,
+    api_snapshot,
+    diff_api,
+    extract_api,
+)
+from codebase_rag.graph_diff import graph_at_revision
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+GO_SOURCE = b"""package store
+
+// MaxItems bounds a cart.
+const MaxItems = 10
+
+const internalLimit = 5
+
+type Item struct {
+    ID    string
+    Price int
+    note  string
+}
+
+type Repository interface {
+    Get(id string) (*Item, error)
+    Put(item *Item) error
+}
+
+type cache struct{}
+
+func New(dsn string) (*Store, error) { return nil, nil }
+
+func (s *Store) Save(item Item) error { return nil }
+
+func (c *cache) Get() {}
+
+func helper() {}
+"""
+
+PY_SOURCE = b"""class Client:
+    def send(self, payload: dict) -> bool:
+        return True
+
+    def _retry(self):
+        pass
+
+def connect(url: str, timeout: int = 5) -> Client:
+    def inner():
+        pass
+    return Client()
+
+def _private():
+    pass
+"""
+
+
+@pytest.fixture(scope="module")
+def parsers():
+    return load_parsers()
+
+
+def _extract(parsers, source: bytes, rel_path: str, language: str) -> dict:
+    root = parsers[0][language].parse(source).root_node
+    return {s.qualified_name: s for s in extract_api(root, rel_path, language)}
+
+
+class TestApiSurfaceExtraction:
+    """Test extraction of exported symbols and signatures."""
+
+    def test_go_symbols(self, parsers):
+        symbols = _extract(parsers, GO_SOURCE, "pkg/store/store.go", "go")
+
+        assert set(symbols) == {
+            "pkg/store.MaxItems",
+            "pkg/store.Item",
+            "pkg/store.Repository",
+            "pkg/store.New",
+            "pkg/store.Store.Save",
+        }
+        assert symbols["pkg/store.New"].signature == "func New(dsn string) (*Store, error)"
+        assert symbols["pkg/store.Store.Save"].kind == "method"
+        assert symbols["pkg/store.Item"].members == ["ID string", "Price int"]
+        assert symbols["pkg/store.Repository"].kind == "interface"
+        assert symbols["pkg/store.Repository"].members == [
+            "Get(id string) (*Item, error)",
+            "Put(item *Item) error",
+        ]
+
+    def test_python_symbols(self, parsers):
+        symbols = _extract(parsers, PY_SOURCE, "shop/client.py", "python")
+
+        assert set(symbols) == {
+            "shop/client.Client",
+            "shop/client.Client.send",
+            "shop/client.connect",
+        }
+        assert (
+            symbols["shop/client.connect"].signature
+            == "def connect(url: str, timeout: int = 5) -> Client"
+        )
+
+
+class TestApiDiff:
+    """Test comparison of API snapshots."""
+
+    def _symbol(self, qn: str, signature: str, members=None) -> ApiSymbol:
+        return ApiSymbol(
+            qualified_name=qn,
+            name=qn.split(".")[-1],
+            kind="interface" if members is not None else "function",
+            signature=signature,
+            path="pkg/a.go",
+            language="go",
+            members=members or [],
+        )
+
+    def test_added_removed_changed(self):
+        old = {
+            "pkg.Keep": self._symbol("pkg.Keep", "func Keep()"),
+            "pkg.Gone": self._symbol("pkg.Gone", "func Gone()"),
+            "pkg.Sig": self._symbol("pkg.Sig", "func Sig(a int)"),
+            "pkg.Iface": self._symbol("pkg.Iface", "type Iface interface", ["A()", "B()"]),
+        }
+        new = {
+            "pkg.Keep": self._symbol("pkg.Keep", "func Keep()"),
+            "pkg.Sig": self._symbol("pkg.Sig", "func Sig(a int, b int)"),
+            "pkg.Iface": self._symbol("pkg.Iface", "type Iface interface", ["A()", "C()"]),
+            "pkg.Fresh": self._symbol("pkg.Fresh", "func Fresh()"),
+        }
+
+        diff = diff_api(old, new, base="v1", head="v2")
+
+        assert [c.qualified_name for c in diff.added] == ["pkg.Fresh"]
+        assert [c.qualified_name for c in diff.removed] == ["pkg.Gone"]
+        assert [c.qualified_name for c in diff.changed] == ["pkg.Iface", "pkg.Sig"]
+        iface = diff.changed[0]
+        assert (iface.removed_members, iface.added_members) == (["B()"], ["C()"])
+        assert diff.to_dict()["summary"] == {
+            "added": 1,
+            "removed": 1,
+            "changed": 2,
+            "breaking": 0,
+        }
+
+    def test_identical_snapshots(self):
+        snapshot = {"pkg.Keep": self._symbol("pkg.Keep", "func Keep()")}
+        assert diff_api(snapshot, dict(snapshot)).is_empty
+
+
+class TestApiSymbolIngestion:
+    """Test exported symbols stored as ApiSymbol nodes and read back."""
+
+    def test_nodes_round_trip(self, tmp_path):
+        ingestor = MagicMock()
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+        symbol = ApiSymbol(
+            qualified_name="pkg/store.Item",
+            name="Item",
+            kind="struct",
+            signature="type Item struct",
+            path="pkg/store/store.go",
+            language="go",
+            members=["ID string"],
+        )
+
+        with patch("codebase_rag.graph_updater.extract_api", return_value=[symbol]):
+            updater._ingest_api_surface(
+                MagicMock(), "shop.pkg.store.store", "pkg/store/store.go", "go"
+            )
+            # Test files are not part of the public API
+            updater._ingest_api_surface(
+                MagicMock(), "shop.pkg.store.store_test", "pkg/store/a_test.go", "go"
+            )
+
+        [node] = [c.args[1] for c in ingestor.ensure_node_batch.call_args_list]
+        assert node["qualified_name"] == f"{tmp_path.name}:pkg/store.Item"
+        ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Module", "qualified_name", "shop.pkg.store.store"),
+            "EXPOSES",
+            ("ApiSymbol", "qualified_name", node["qualified_name"]),
+        )
+        assert api_snapshot([node]) == {"pkg/store.Item": symbol}
+
+
+class TestSnapshotAtRevision:
+    """Test API snapshots read from the graphs of Git revisions."""
+
+    @pytest.fixture
+    def git_repo(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            repo_path = Path(temp_dir)
+            subprocess.run(["git", "init"], cwd=repo_path, check=True)
+            subprocess.run(["git", "config", "user.name", "Test User"], cwd=repo_path, check=True)
+            subprocess.run(["git", "config", "user.email", "test@example.com"], cwd=repo_path, check=True)
+
+            (repo_path / "api.go").write_text("package api\n\nfunc Get(id int) {}\n")
+            (repo_path / "api_test.go").write_text("package api\n\nfunc TestGet() {}\n")
+            subprocess.run(["git", "add", "."], cwd=repo_path, check=True)
+            subprocess.run(["git", "commit", "-m", "v1"], cwd=repo_path, check=True)
+            subprocess.run(["git", "tag", "v1"], cwd=repo_path, check=True)
+
+            (repo_path / "api.go").write_text("package api\n\nfunc Get(id string) {}\n")
+            subprocess.run(["git", "commit", "-am", "v2"], cwd=repo_path, check=True)
+            yield repo_path
+
+    def _snapshot(self, git_repo, revision, parsers):
+        record = graph_at_revision(git_repo, revision, *parsers)
+        return api_snapshot(record.labelled("ApiSymbol"))
+
+    def test_diff_between_commits(self, git_repo, parsers):
+        old = self._snapshot(git_repo, "v1", parsers)
+        new = self._snapshot(git_repo, "HEAD", parsers)
+
+        # Test files are not part of the public API
+        assert set(old) == {"Get"}
+        diff = diff_api(old, new)
+        [change] = diff.changed
+        assert change.old_signature == "func Get(id int)"
+        assert change.new_signature == "func Get(id string)"
+
+    def test_unknown_revision(self, git_repo, parsers):
+        with pytest.raises(subprocess.CalledProcessError):
+            self._snapshot(git_repo, "does-not-exist", parsers)
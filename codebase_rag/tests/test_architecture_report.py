@@ -0,0 +1,181 @@
+"""Tests for the architecture report."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag import architecture_report
+from codebase_rag.analysis.hotspots import FUNCTION_METRICS_QUERY
+from codebase_rag.analysis.import_cycles import IMPORT_EDGES_QUERY
+from codebase_rag.analysis.test_gaps import UNTESTED_FUNCTIONS_QUERY
+from codebase_rag.architecture_report import (
+    FILES_QUERY,
+    OWNERSHIP_QUERY,
+    ArchitectureReport,
+    PackageEdge,
+    build_report,
+    dependency_svg,
+    package_dependencies,
+    summarize_owners,
+)
+from codebase_rag.version_control.git_analyzer import DiffHunk
+
+
+def _import(source: str, target: str) -> dict[str, object]:
+    """An IMPORT_EDGES_QUERY row; module names are their paths with dots."""
+    return {
+        "source": source.removesuffix(".py").replace("/", "."),
+        "source_path": source,
+        "target": target.removesuffix(".py").replace("/", "."),
+        "target_path": target,
+        "line_number": 1,
+        "symbol": None,
+    }
+
+
+IMPORTS = [
+    _import("app/api/routes.py", "app/core/models.py"),
+    _import("app/api/views.py", "app/core/models.py"),
+    _import("app/core/models.py", "app/util/text.py"),
+    _import("app/util/text.py", "app/core/models.py"),
+    _import("app/api/routes.py", "app/api/views.py"),
+]
+
+# Five commits changing the second line of routes.py
+HISTORY = [{"app/api/routes.py": [DiffHunk(2, 1, 2, 1)]}] * 5
+
+
+def _function(qualified_name: str, path: str, complexity: int) -> dict[str, object]:
+    return {
+        "qualified_name": qualified_name,
+        "label": "Function",
+        "path": path,
+        "complexity": complexity,
+        "start_line": 1,
+        "end_line": 3,
+    }
+
+
+def make_ingestor() -> MagicMock:
+    results = {
+        IMPORT_EDGES_QUERY: IMPORTS,
+        FUNCTION_METRICS_QUERY: [
+            _function("app.api.routes.index", "app/api/routes.py", 3),
+            _function("app.core.models.save", "app/core/models.py", 9),
+        ],
+        UNTESTED_FUNCTIONS_QUERY: [
+            {
+                **_function("app.core.models.save", "app/core/models.py", 9),
+                "name": "save",
+            }
+        ],
+        FILES_QUERY: [{"path": "app/api/routes.py"}, {"path": "app/core/models.py"}],
+        OWNERSHIP_QUERY: [
+            {"owner": "@org/api", "kind": "Team", "path": "app/api/routes.py"},
+        ],
+    }
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params=None: results.get(query, [])
+    return ingestor
+
+
+class TestBuildReport:
+    """Test gathering the report from the graph."""
+
+    def test_package_dependencies(self):
+        edges = package_dependencies(IMPORTS)
+
+        assert edges == [
+            PackageEdge("app/api", "app/core", 2),
+            PackageEdge("app/core", "app/util", 1),
+            PackageEdge("app/util", "app/core", 1),
+        ]
+        # One level up, everything is the one package app
+        assert package_dependencies(IMPORTS, depth=1) == []
+
+    def test_report(self):
+        report = build_report(make_ingestor(), "shop", HISTORY)
+
+        assert report.project == "shop"
+        assert report.cycles == [["app/core", "app/util"]]
+        assert [h.qualified_name for h in report.hotspots][0] == "app.api.routes.index"
+        assert report.test_gaps[0].package == "app/core"
+        assert report.test_gaps[0].examples == ["app.core.models.save"]
+        assert [(o.owner, o.files) for o in report.owners] == [("@org/api", 1)]
+        assert (report.unowned_files, report.total_files) == (1, 2)
+
+    def test_without_history_hotspots_are_the_most_complex(self):
+        report = build_report(make_ingestor(), "shop", [], limit=1)
+
+        assert not report.has_churn
+        assert [h.qualified_name for h in report.hotspots] == ["app.core.models.save"]
+        assert "ranked by complexity" in report.to_markdown()
+
+    def test_owners(self):
+        rows = [
+            {"owner": "@org/api", "kind": "Team", "path": "app/api/a.py"},
+            {"owner": "@org/api", "kind": "Team", "path": "app/api/v2/b.py"},
+            {"owner": "alice", "kind": "User", "path": "docs/c.md"},
+        ]
+
+        owners = summarize_owners(rows)
+
+        assert [(o.owner, o.kind, o.files, o.directories) for o in owners] == [
+            ("@org/api", "Team", 2, ["app/api"]),
+            ("alice", "User", 1, ["docs"]),
+        ]
+
+
+class TestRendering:
+    """Test the Markdown and HTML renderings."""
+
+    def test_markdown(self):
+        markdown = build_report(make_ingestor(), "shop", []).to_markdown()
+
+        assert markdown.startswith("# Architecture report: shop\n")
+        assert "```mermaid\ngraph LR\n" in markdown
+        assert '    p0["app/api"]' in markdown
+        assert "    p0 -->|2| p1" in markdown
+        assert "- app/core -> app/util -> app/core" in markdown
+        assert "| `app.core.models.save` | app/core/models.py | 0 | 9 |" in markdown
+        assert "1 of 2 files have no owner." in markdown
+
+    def test_empty_graph(self):
+        markdown = ArchitectureReport("shop", "now").to_markdown()
+
+        assert "No imports between packages in the graph." in markdown
+        assert "No CODEOWNERS, OWNERS or catalog owners in the graph." in markdown
+
+    def test_html_is_standalone_and_escaped(self):
+        report = build_report(make_ingestor(), "<shop>", [])
+
+        page = report.to_html()
+
+        assert page.startswith("<!DOCTYPE html>")
+        assert "<title>Architecture report: &lt;shop&gt;</title>" in page
+        assert "<svg" in page and "<script" not in page
+        assert "<td>@org/api</td>" in page
+
+    def test_diagram_columns(self):
+        svg = dependency_svg(
+            [
+                PackageEdge("api", "core", 2),
+                PackageEdge("core", "util", 1),
+                PackageEdge("util", "core", 1),
+            ]
+        )
+
+        # api in the first column, core and util, importing each other, in the next
+        assert '<rect x="10" y="10"' in svg
+        assert '<rect x="290" y="10"' in svg
+        assert '<rect x="290" y="52"' in svg
+        assert svg.count("<line ") == 3
+
+    def test_diagram_keeps_the_busiest_packages(self, monkeypatch):
+        monkeypatch.setattr(architecture_report, "MAX_DIAGRAM_PACKAGES", 2)
+        report = ArchitectureReport(
+            "shop",
+            "now",
+            dependencies=[PackageEdge("a", "b", 5), PackageEdge("c", "d", 1)],
+        )
+
+        assert report.diagram_edges() == [PackageEdge("a", "b", 5)]
+        assert "2 dependencies in all" in report.to_markdown()
@@ -0,0 +1,125 @@
+"""Tests for Backstage catalog parsing and ingestion."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.backstage_parser import (
+    BackstageCatalogParser,
+    parse_entity_ref,
+)
+
+CATALOG = """apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: payments
+  title: Payments
+  tags: [go]
+spec:
+  type: service
+  lifecycle: production
+  owner: team-payments
+  system: checkout
+  providesApis: [payments-api]
+  consumesApis: [billing/ledger-api]
+  dependsOn: [resource:default/payments-db, component:fraud]
+---
+apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: payments-api
+spec:
+  type: openapi
+  owner: user:alice
+  system: checkout
+  definition:
+    $text: ../api/openapi.yaml
+---
+apiVersion: backstage.io/v1alpha1
+kind: Group
+metadata:
+  name: team-payments
+"""
+
+
+class TestBackstageCatalogParser:
+    """Test entity and reference parsing."""
+
+    def test_entity_refs(self):
+        assert parse_entity_ref("Team-A", "group").ref == "group:default/team-a"
+        assert parse_entity_ref("user:ops/bob", "group").ref == "user:ops/bob"
+        assert parse_entity_ref("ops/svc", "component", "x").ref == (
+            "component:ops/svc"
+        )
+
+    def test_parses_components_and_apis(self):
+        entities = BackstageCatalogParser().parse(CATALOG, "payments/catalog-info.yaml")
+
+        component, api = entities
+        assert (component.label, component.ref.ref) == (
+            "Component",
+            "component:default/payments",
+        )
+        assert component.owner.ref == "group:default/team-payments"
+        assert component.system.ref == "system:default/checkout"
+        assert [a.ref for a in component.consumes_apis] == ["api:billing/ledger-api"]
+        assert component.directory == "payments"
+        assert api.label == "API"
+        assert api.owner.ref == "user:default/alice"
+        assert api.definition_path == "api/openapi.yaml"
+
+    def test_invalid_yaml(self):
+        assert BackstageCatalogParser().parse("kind: [", "catalog-info.yaml") == []
+
+
+class TestBackstageIngestion:
+    """Test catalog nodes and edges created during ingestion."""
+
+    def test_links_catalog_to_code(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "payments").mkdir()
+        (temp_repo / "payments" / "catalog-info.yaml").write_text(CATALOG)
+
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.structural_elements = {
+            Path(): None,
+            Path("payments"): f"{temp_repo.name}.payments",
+        }
+        updater._ingest_backstage_catalog()
+
+        edges = {
+            (call.args[0], call.args[1], call.args[2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+        }
+        payments = ("Component", "ref", "component:default/payments")
+        assert (
+            ("Team", "name", "group:default/team-payments"),
+            "OWNS",
+            payments,
+        ) in edges
+        assert (payments, "PART_OF", ("System", "ref", "system:default/checkout")) in (
+            edges
+        )
+        assert (
+            payments,
+            "IMPLEMENTED_IN",
+            ("Package", "qualified_name", f"{temp_repo.name}.payments"),
+        ) in edges
+        assert (
+            ("API", "ref", "api:default/payments-api"),
+            "DEFINED_IN",
+            ("File", "path", "api/openapi.yaml"),
+        ) in edges
+        # Resources are not modelled
+        assert not [edge for edge in edges if "resource:" in edge[2][2]]
+        nodes = [call.args for call in mock_ingestor.ensure_node_batch.call_args_list]
+        assert {label for label, _ in nodes} == {
+            "Component",
+            "System",
+            "API",
+            "Team",
+            "User",
+        }
+        node_keys = {
+            tuple(properties) for label, properties in nodes if label == "Component"
+        }
+        assert len(node_keys) == 1  # Declared and referenced nodes batch together
@@ -0,0 +1,211 @@
+"""Tests for the Slack and Discord code question bots."""
+
+import hashlib
+import hmac
+import json
+import time
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.citations import NAMES_QUERY
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.bots import (
+    NOT_SCOPED_REPLY,
+    BotProject,
+    ChannelScopes,
+    CodeQuestionBot,
+    DiscordBot,
+    SlackBot,
+    create_bot_routes,
+    format_discord_answer,
+)
+from codebase_rag.utils.ed25519 import verify
+
+SHOP = BotProject("shop", Path("/srv/shop"), "https://github.com/acme/shop/blob/main")
+TOTAL = {
+    "name": "Cart.total",
+    "qualified_name": "shop.cart.Cart.total",
+    "label": "Method",
+    "node_id": 7,
+    "path": "shop/cart.py",
+    "start_line": 12,
+    "end_line": 20,
+}
+
+# RFC 8032, section 7.1, test 2
+RFC_PUBLIC_KEY = "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c"
+RFC_SIGNATURE = (
+    "92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da"
+    "085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00"
+)
+
+
+def _bot(answer_text: str = "It sums `Cart.total` and `unknown`.") -> CodeQuestionBot:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params: (
+        [TOTAL] if query == NAMES_QUERY else []
+    )
+    scopes = ChannelScopes({"shop": SHOP}, {"C1": "shop"})
+    return CodeQuestionBot(ingestor, scopes, lambda question, project: answer_text)
+
+
+def _slack_request(payload: dict, secret: str = "shh", **headers) -> Request:
+    body = json.dumps(payload).encode()
+    timestamp = str(int(time.time()))
+    base = b"v0:" + timestamp.encode() + b":" + body
+    signature = "v0=" + hmac.new(secret.encode(), base, hashlib.sha256).hexdigest()
+    return Request(
+        "POST",
+        "/slack/events",
+        {
+            "x-slack-request-timestamp": timestamp,
+            "x-slack-signature": signature,
+            **headers,
+        },
+        body,
+    )
+
+
+def _urlopen(sent: list):
+    def urlopen(request, timeout):
+        sent.append((request.get_method(), request.full_url, json.loads(request.data)))
+        response = MagicMock()
+        response.__enter__.return_value.read.return_value = b'{"ok": true}'
+        return response
+
+    return urlopen
+
+
+class TestCodeQuestionBot:
+    """Test channel scoping and citations."""
+
+    def test_answer_cites_known_definitions(self):
+        answer = _bot().answer("C1", "What does the cart do?")
+
+        assert answer is not None
+        assert [c.qualified_name for c in answer.citations] == ["shop.cart.Cart.total"]
+        assert SHOP.link(answer.citations[0]) == (
+            "https://github.com/acme/shop/blob/main/shop/cart.py#L12-L20"
+        )
+
+    def test_citations_are_limited_to_the_channels_project(self):
+        bot = _bot()
+
+        bot.answer("C1", "What does the cart do?")
+
+        query, params = bot.ingestor.fetch_all.call_args.args
+        assert query == NAMES_QUERY
+        assert params == {"names": ["Cart.total", "unknown"], "project": "shop"}
+
+    def test_unscoped_channel(self):
+        assert _bot().answer("C2", "What does the cart do?") is None
+
+    def test_scopes_reject_unknown_projects(self):
+        with pytest.raises(ValueError, match="billing"):
+            ChannelScopes({"shop": SHOP}, {"C1": "billing"})
+
+    def test_discord_answer_keeps_sources_when_cut(self):
+        answer = _bot("x" * 3000 + " `Cart.total`").answer("C1", "?")
+
+        text = format_discord_answer(answer)
+
+        assert len(text) <= 2000
+        assert text.endswith("(shop/cart.py:12-20)")
+
+
+class TestSlackBot:
+    """Test the Slack Events API receiver."""
+
+    def test_url_verification(self):
+        slack = SlackBot(_bot(), "shh", "xoxb-1")
+        response = slack(_slack_request({"type": "url_verification", "challenge": "c"}))
+        assert response.body == {"challenge": "c"}
+
+    def test_rejects_bad_signature(self):
+        slack = SlackBot(_bot(), "shh", "xoxb-1")
+        request = _slack_request({"type": "url_verification"}, secret="wrong")
+        assert slack(request).status == 401
+
+    def test_answers_mention_in_thread(self):
+        slack = SlackBot(_bot(), "shh", "xoxb-1", background=lambda task: task())
+        event = {
+            "type": "app_mention",
+            "text": "<@U123> what does the cart do?",
+            "channel": "C1",
+            "ts": "1700000000.000100",
+        }
+        sent: list = []
+
+        with patch("urllib.request.urlopen", _urlopen(sent)):
+            response = slack(_slack_request({"type": "event_callback", "event": event}))
+
+        assert response.body["status"] == "accepted"
+        [(method, url, payload)] = sent
+        assert url == "https://slack.com/api/chat.postMessage"
+        assert payload["thread_ts"] == "1700000000.000100"
+        assert "*Sources*" in payload["text"]
+        assert "<https://github.com/acme/shop/blob/main/shop/cart.py#L12-L20|" in (
+            payload["text"]
+        )
+
+    def test_unscoped_channel_and_retries(self):
+        slack = SlackBot(_bot(), "shh", "xoxb-1", background=lambda task: task())
+        event = {"type": "app_mention", "text": "hi", "channel": "C9", "ts": "1.2"}
+        payload = {"type": "event_callback", "event": event}
+        sent: list = []
+
+        with patch("urllib.request.urlopen", _urlopen(sent)):
+            slack(_slack_request(payload))
+            retry = slack(_slack_request(payload, **{"x-slack-retry-num": "1"}))
+
+        assert retry.body["reason"] == "retry"
+        assert [p["text"] for _, _, p in sent] == [NOT_SCOPED_REPLY]
+
+
+class TestDiscordBot:
+    """Test the Discord interactions receiver."""
+
+    def test_signature_verification(self):
+        public_key = bytes.fromhex(RFC_PUBLIC_KEY)
+        signature = bytes.fromhex(RFC_SIGNATURE)
+        assert verify(public_key, b"\x72", signature)
+        assert not verify(public_key, b"\x73", signature)
+        server = GraphServer()
+        create_bot_routes(server, _bot(), discord_public_key=RFC_PUBLIC_KEY)
+        request = Request(
+            "POST",
+            "/discord/interactions",
+            {"x-signature-ed25519": "00" * 64, "x-signature-timestamp": "1"},
+            b'{"type": 1}',
+        )
+        assert server.handle(request).status == 401
+
+    def test_ping_and_ask(self):
+        discord = DiscordBot(_bot(), RFC_PUBLIC_KEY, background=lambda task: task())
+        interaction = {
+            "type": 2,
+            "application_id": "42",
+            "token": "tok",
+            "channel_id": "C1",
+            "data": {
+                "name": "ask",
+                "options": [{"name": "question", "value": "What is a cart?"}],
+            },
+        }
+        sent: list = []
+
+        with (
+            patch("codebase_rag.server.bots.verify", return_value=True),
+            patch("urllib.request.urlopen", _urlopen(sent)),
+        ):
+            ping = discord(Request("POST", "/", {}, b'{"type": 1}'))
+            ask = discord(Request("POST", "/", {}, json.dumps(interaction).encode()))
+
+        assert ping.body == {"type": 1}
+        assert ask.body == {"type": 5}
+        [(method, url, payload)] = sent
+        assert method == "PATCH"
+        assert url.endswith("/webhooks/42/tok/messages/@original")
+        assert "**Sources**" in payload["content"]
@@ -0,0 +1,174 @@
+"""Tests for classifying API changes as breaking or compatible."""
+
+import pytest
+
+from codebase_rag.analysis.api_surface import ApiSymbol, diff_api, extract_api
+from codebase_rag.analysis.breaking_changes import classify_diff
+from codebase_rag.parser_loader import load_parsers
+
+
+def _symbol(
+    qn: str,
+    kind: str = "function",
+    signature: str = "",
+    type_signature: str = "",
+    members=None,
+    language: str = "go",
+) -> ApiSymbol:
+    return ApiSymbol(
+        qualified_name=qn,
+        name=qn.split(".")[-1],
+        kind=kind,
+        signature=signature or f"{kind} {qn}",
+        path="pkg/a.go",
+        language=language,
+        members=members or [],
+        type_signature=type_signature,
+    )
+
+
+def _classify(old: list[ApiSymbol], new: list[ApiSymbol]) -> dict[str, tuple]:
+    before = {s.qualified_name: s for s in old}
+    after = {s.qualified_name: s for s in new}
+    diff = classify_diff(diff_api(before, after), before, after)
+    changes = [*diff.added, *diff.removed, *diff.changed]
+    return {c.qualified_name: (c.breaking, c.reason) for c in changes}
+
+
+class TestGoClassification:
+    """Test Go compatibility rules."""
+
+    def test_added_and_removed(self):
+        result = _classify([_symbol("pkg.Gone")], [_symbol("pkg.Fresh")])
+        assert result["pkg.Gone"] == (True, "function removed")
+        assert result["pkg.Fresh"] == (False, "function added")
+
+    def test_parameter_rename_is_compatible(self):
+        result = _classify(
+            [_symbol("pkg.Get", signature="func Get(id int)", type_signature="func(int)")],
+            [_symbol("pkg.Get", signature="func Get(key int)", type_signature="func(int)")],
+        )
+        assert result["pkg.Get"] == (False, "only parameter names changed")
+
+    def test_parameter_type_change_is_breaking(self):
+        result = _classify(
+            [_symbol("pkg.Get", signature="func Get(id int)", type_signature="func(int)")],
+            [_symbol("pkg.Get", signature="func Get(id string)", type_signature="func(string)")],
+        )
+        breaking, reason = result["pkg.Get"]
+        assert breaking
+        assert "func(int)" in reason and "func(string)" in reason
+
+    def test_interface_changes(self):
+        old = [
+            _symbol("pkg.Narrowed", "interface", members=["A()", "B()"]),
+            _symbol("pkg.Widened", "interface", members=["A()"]),
+        ]
+        new = [
+            _symbol("pkg.Narrowed", "interface", members=["A()"]),
+            _symbol("pkg.Widened", "interface", members=["A()", "B()"]),
+        ]
+        result = _classify(old, new)
+        assert result["pkg.Narrowed"] == (True, "interface narrowed: methods removed")
+        # Existing implementations no longer satisfy the interface
+        assert result["pkg.Widened"] == (True, "methods added to interface")
+
+    def test_struct_fields(self):
+        old = [
+            _symbol("pkg.Grown", "struct", members=["ID string"]),
+            _symbol("pkg.Retyped", "struct", members=["ID string"]),
+        ]
+        new = [
+            _symbol("pkg.Grown", "struct", members=["ID string", "Name string"]),
+            _symbol("pkg.Retyped", "struct", members=["ID int"]),
+        ]
+        result = _classify(old, new)
+        assert result["pkg.Grown"] == (False, "exported fields added")
+        assert result["pkg.Retyped"][0] is True
+
+    def test_kind_change(self):
+        result = _classify(
+            [_symbol("pkg.ID", "struct")], [_symbol("pkg.ID", "type", "type ID string")]
+        )
+        assert result["pkg.ID"] == (True, "changed from struct to type")
+
+    def test_var_initializer_change_is_compatible(self):
+        result = _classify(
+            [_symbol("pkg.Default", "var", "var Default = 1")],
+            [_symbol("pkg.Default", "var", "var Default = 2")],
+        )
+        assert result["pkg.Default"] == (False, "variable initializer changed")
+
+    def test_non_go_change_is_breaking(self):
+        result = _classify(
+            [_symbol("shop.send", signature="def send(a)", language="python")],
+            [_symbol("shop.send", signature="def send(b)", language="python")],
+        )
+        assert result["shop.send"] == (True, "signature changed")
+
+    def test_summary_counts_breaking(self):
+        old = {"pkg.Gone": _symbol("pkg.Gone")}
+        new = {"pkg.Fresh": _symbol("pkg.Fresh")}
+        diff = classify_diff(diff_api(old, new), old, new)
+        assert [c.qualified_name for c in diff.breaking_changes] == ["pkg.Gone"]
+        assert diff.to_dict()["summary"]["breaking"] == 1
+
+
+class TestGoTypeSignature:
+    """Test type signatures extracted for Go functions and methods."""
+
+    @pytest.fixture(scope="class")
+    def parser(self):
+        parsers, _ = load_parsers()
+        return parsers["go"]
+
+    def test_parameter_names_dropped(self, parser):
+        source = (
+            b"package store\n"
+            b"\n"
+            b"func Copy(dst, src string, opts ...Option) (n int, err error) { return }\n"
+            b"\n"
+            b"func (s *Store) Save(item Item) error { return nil }\n"
+            b"\n"
+            b"var Timeout time.Duration = 5\n"
+        )
+        symbols = {
+            s.qualified_name: s
+            for s in extract_api(parser.parse(source).root_node, "store/store.go", "go")
+        }
+        assert symbols["store.Copy"].type_signature == (
+            "func(string, string, ...Option) (int, error)"
+        )
+        assert symbols["store.Store.Save"].type_signature == "(*Store) func(Item) error"
+        assert symbols["store.Timeout"].type_signature == "time.Duration"
+
+
+class TestVersionBump:
+    """Test the semantic version a classified diff calls for."""
+
+    def _diff(self, old: list[ApiSymbol], new: list[ApiSymbol], base: str):
+        before = {s.qualified_name: s for s in old}
+        after = {s.qualified_name: s for s in new}
+        return classify_diff(diff_api(before, after, base=base), before, after)
+
+    def test_bumps(self):
+        keep = _symbol("pkg.Keep")
+        renamed = _symbol("pkg.Keep", signature="func Keep(b int)")
+
+        major = self._diff([keep, _symbol("pkg.Gone")], [keep], "v1.4.2")
+        minor = self._diff([keep], [keep, _symbol("pkg.Fresh")], "v1.4.2")
+        patch = self._diff([keep], [renamed], "v1.4.2")
+
+        assert (major.bump, major.next_version) == ("major", "v2.0.0")
+        assert (minor.bump, minor.next_version) == ("minor", "v1.5.0")
+        assert (patch.bump, patch.next_version) == ("patch", "v1.4.3")
+        assert major.to_dict()["next_version"] == "v2.0.0"
+
+    @pytest.mark.parametrize(
+        "base, expected",
+        [("v0.9.1", "v0.10.0"), ("api/v1.2.0", "api/v2.0.0"), ("main", None)],
+    )
+    def test_next_version(self, base, expected):
+        diff = self._diff([_symbol("pkg.Gone")], [], base)
+
+        assert diff.next_version == expected
@@ -0,0 +1,112 @@
+"""Tests for entrypoint detection and call-tree depth metrics."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.call_depth import (
+    CallDepthAnalyzer,
+    classify_entrypoint,
+    compute_call_depths,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+
+class TestEntrypointClassification:
+    """Test recognition of entrypoint kinds from graph rows."""
+
+    @pytest.mark.parametrize(
+        "row, kind",
+        [
+            ({"name": "list_users", "endpoint_count": 1}, "http"),
+            ({"name": "sync", "decorators": ["app.command"]}, "cli"),
+            ({"name": "sync", "decorators": ["click.option", "cli.group"]}, "cli"),
+            ({"name": "nightly", "decorators": ["celery.shared_task"]}, "cron"),
+            ({"name": "main", "label": "Function"}, "main"),
+            ({"name": "main", "label": "Method"}, None),
+            ({"name": "helper", "decorators": ["functools.cache"]}, None),
+        ],
+    )
+    def test_kinds(self, row, kind):
+        assert classify_entrypoint(row, []) == kind
+
+    def test_custom_patterns(self):
+        row = {"qualified_name": "shop.jobs.reindex", "name": "reindex"}
+        assert classify_entrypoint(row, ["shop.jobs.*"]) == "custom"
+        assert classify_entrypoint(row, ["shop.api.*"]) is None
+
+
+class TestCallDepths:
+    """Test depth and size computation over call edges."""
+
+    def test_chain_and_fan_out(self):
+        edges = [
+            ("main", "load"),
+            ("main", "run"),
+            ("run", "step"),
+            ("step", "write"),
+        ]
+        [depth] = compute_call_depths([("main", "Function", "main")], edges)
+        assert depth.max_depth == 3
+        assert depth.tree_size == 4
+        # load and run at 1, step at 2, write at 3
+        assert depth.avg_depth == pytest.approx(7 / 4)
+
+    def test_recursion_is_collapsed(self):
+        edges = [
+            ("handler", "parse"),
+            ("parse", "parse_expr"),
+            ("parse_expr", "parse"),
+            ("parse_expr", "emit"),
+            ("emit", "emit"),
+        ]
+        [depth] = compute_call_depths([("handler", "Function", "http")], edges)
+        # handler -> {parse, parse_expr} -> emit
+        assert depth.max_depth == 2
+        assert depth.tree_size == 3
+
+    def test_entrypoint_without_calls(self):
+        [depth] = compute_call_depths([("main", "Function", "main")], [])
+        assert (depth.max_depth, depth.avg_depth, depth.tree_size) == (0, 0.0, 0)
+
+    def test_sorted_deepest_first(self):
+        edges = [("a", "b"), ("b", "c"), ("x", "y")]
+        depths = compute_call_depths(
+            [("x", "Function", "main"), ("a", "Function", "cli")], edges
+        )
+        assert [d.qualified_name for d in depths] == ["a", "x"]
+
+    def test_store_depths(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [{"qualified_name": "p.main", "label": "Function", "name": "main", "decorators": [], "endpoint_count": 0}],
+            [{"caller": "p.main", "callee": "p.run"}],
+        ]
+        analyzer = CallDepthAnalyzer(ingestor)
+        depths = analyzer.analyze()
+        analyzer.store_depths(depths)
+
+        ingestor.ensure_node_batch.assert_called_once_with(
+            "Function",
+            {
+                "qualified_name": "p.main",
+                "entrypoint_kind": "main",
+                "call_depth_max": 1,
+                "call_depth_avg": 1.0,
+                "call_tree_size": 1,
+            },
+        )
+
+
+class TestDecoratorExtraction:
+    """Test decorators recorded on ingested functions."""
+
+    def test_python_decorators(self, temp_repo: Path, mock_ingestor: MagicMock):
+        parsers, queries = load_parsers()
+        updater = GraphUpdater(mock_ingestor, temp_repo, parsers, queries)
+        source = b"@app.command('sync')\n@retry(times=3)\ndef sync():\n    pass\n"
+        tree = parsers["python"].parse(source)
+        [func] = queries["python"]["functions"].captures(tree.root_node)["function"]
+        assert updater._get_decorators(func) == ["app.command", "retry"]
@@ -0,0 +1,102 @@
+"""Tests for cgo preambles and calls from Go into C."""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.cgo import (
+    NativeCall,
+    cgo_preamble,
+    is_cgo_builtin,
+    native_calls,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+SOURCE = b"""package mathx
+
+// #include <stdlib.h>
+/*
+static int add(int a, int b) { return a + b; }
+*/
+import "C"
+
+import "unsafe"
+
+func Add(a, b int) int {
+    s := C.CString("sum")
+    defer C.free(unsafe.Pointer(s))
+    return int(C.add(C.int(a), C.int(b)))
+}
+"""
+
+
+class TestPreamble:
+    """Test reading the preamble and the calls into C."""
+
+    def test_preamble_and_calls(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        root = parsers["go"].parse(SOURCE).root_node
+        [function] = [
+            node for node in root.named_children if node.type == "function_declaration"
+        ]
+
+        preamble = cgo_preamble(root)
+
+        assert preamble.start_line == 3
+        # Each line of C stays on its line of the Go file
+        assert preamble.source.splitlines()[2] == (
+            "static int add(int a, int b) { return a + b; }"
+        )
+        assert native_calls(function) == [NativeCall("free", 13), NativeCall("add", 14)]
+
+    def test_no_preamble(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        source = b'package mathx\n\n// Not a preamble\n\nimport "C"\n'
+
+        assert cgo_preamble(parsers["go"].parse(source).root_node) is None
+
+    @pytest.mark.parametrize(
+        "name, builtin",
+        [("int", True), ("CString", True), ("struct_point", True), ("add", False)],
+    )
+    def test_builtins(self, name, builtin):
+        assert is_cgo_builtin(name) == builtin
+
+
+class TestNativeCalls:
+    """Test CALLS_NATIVE edges to preamble and C file functions."""
+
+    def test_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.cgo_functions["shop.mathx.add"] = {"add": "shop.mathx.add.C.add"}
+        updater.c_functions["hash"].update({"shop.mathx.hash.hash", "vendor.hash.hash"})
+        updater.c_functions["sqrt"].add("vendor.libm.sqrt")
+        calls = [
+            NativeCall("add", 12),
+            NativeCall("hash", 13),
+            NativeCall("sqrt", 14),
+            NativeCall("missing", 15),
+        ]
+
+        with patch("codebase_rag.graph_updater.native_calls", return_value=calls):
+            updater._ingest_cgo_calls(
+                MagicMock(), "shop.mathx.add.Add", "Function", "shop.mathx.add"
+            )
+
+        targets = [
+            (c.args[2][2], c.args[3]["line_number"])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "CALLS_NATIVE"
+        ]
+        assert targets == [
+            ("shop.mathx.add.C.add", 12),
+            # The C file in the package's directory wins
+            ("shop.mathx.hash.hash", 13),
+            ("vendor.libm.sqrt", 14),
+        ]
@@ -0,0 +1,141 @@
+"""Tests for changelog generation between two revisions."""
+
+import subprocess
+import tempfile
+from pathlib import Path
+
+import pytest
+
+from codebase_rag.analysis.api_surface import ApiChange, ApiDiff
+from codebase_rag.analysis.changelog import (
+    RawCommit,
+    build_changelog,
+    classify_commit,
+    commits_between,
+)
+
+
+def _commit(subject: str, body: str = "", parents: int = 1) -> RawCommit:
+    return RawCommit(
+        sha=f"{abs(hash(subject)):040x}"[:40],
+        author="Ada",
+        subject=subject,
+        body=body,
+        parents=[f"p{i}" for i in range(parents)],
+    )
+
+
+class TestClassifyCommit:
+    """Test sections, scopes, issues and breaking markers of commits."""
+
+    def test_conventional_commits(self):
+        feature = classify_commit(_commit("feat(cart): add shipping costs (#12)"))
+        assert feature.section == "features"
+        assert feature.scope == "cart"
+        assert feature.description == "add shipping costs (#12)"
+        assert feature.issues == ["#12"]
+
+        assert classify_commit(_commit("fix: handle empty carts")).section == "fixes"
+        assert classify_commit(_commit("perf: cache prices")).section == "other"
+        assert classify_commit(_commit("chore: bump deps")) is None
+
+    def test_breaking_markers(self):
+        bang = classify_commit(_commit("feat(api)!: drop v1 routes"))
+        assert bang.breaking == "drop v1 routes"
+
+        footer = classify_commit(
+            _commit(
+                "refactor: rename settings",
+                "Settings moved.\n\nBREAKING CHANGE: CART_TTL is now CART_TIMEOUT",
+            )
+        )
+        assert footer.breaking == "CART_TTL is now CART_TIMEOUT"
+
+    def test_free_form_subjects_and_merges(self):
+        assert classify_commit(_commit("Fixed rounding in totals")).section == "fixes"
+        assert classify_commit(_commit("Add PAY-42 refunds")).issues == ["PAY-42"]
+        assert classify_commit(_commit("Tidy up")).section == "other"
+        assert classify_commit(_commit("Merge branch 'x'", parents=2)) is None
+
+
+class TestBuildChangelog:
+    """Test grouping commits with the classified API diff."""
+
+    def test_sections_and_markdown(self):
+        diff = ApiDiff(
+            base="v1.0.0",
+            head="v1.1.0",
+            added=[ApiChange("cart.Shipping", "function", "added", "cart.go")],
+            changed=[
+                ApiChange(
+                    "cart.Total",
+                    "function",
+                    "changed",
+                    "cart.go",
+                    old_signature="func Total(items []Item) int",
+                    new_signature="func Total(items []Item) (int, error)",
+                    breaking=True,
+                    reason="type changed",
+                )
+            ],
+        )
+        commits = [
+            _commit("feat(cart): add shipping costs"),
+            _commit("fix: handle empty carts (closes #7)"),
+            _commit("ci: cache modules"),
+        ]
+
+        changelog = build_changelog(commits, diff)
+        markdown = changelog.to_markdown()
+
+        assert [e.description for e in changelog.features] == ["add shipping costs"]
+        assert [c.qualified_name for c in changelog.breaking_api] == ["cart.Total"]
+        assert "### Breaking changes" in markdown
+        assert "- `cart.Total` (function): type changed" in markdown
+        assert "  - after: `func Total(items []Item) (int, error)`" in markdown
+        assert "- New API: `cart.Shipping`" in markdown
+        assert "- handle empty carts (closes #7) (#7)" in markdown
+        assert "cache modules" not in markdown
+        assert changelog.to_dict()["breaking"]["api"][0]["reason"] == "type changed"
+
+    def test_empty_range(self):
+        changelog = build_changelog([], ApiDiff(base="v1", head="v2"))
+
+        assert changelog.to_markdown() == (
+            "## v2\n\nChanges since v1.\n\nNo notable changes.\n"
+        )
+
+
+class TestCommitsBetween:
+    """Test reading commits between two tags from Git."""
+
+    @pytest.fixture
+    def git_repo(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            repo_path = Path(temp_dir)
+
+            def git(*args):
+                subprocess.run(["git", *args], cwd=repo_path, check=True)
+
+            git("init")
+            git("config", "user.name", "Test User")
+            git("config", "user.email", "test@example.com")
+            (repo_path / "cart.py").write_text("def total():\n    return 0\n")
+            git("add", ".")
+            git("commit", "-m", "Initial import")
+            git("tag", "v1")
+            (repo_path / "cart.py").write_text("def total():\n    return 1\n")
+            git("commit", "-am", "fix: count items", "-m", "Refs #3")
+            yield repo_path
+
+    def test_messages_and_bodies(self, git_repo):
+        [commit] = commits_between(git_repo, "v1", "HEAD")
+
+        assert commit.subject == "fix: count items"
+        assert commit.body == "Refs #3"
+        assert commit.author == "Test User"
+        assert len(commit.parents) == 1
+
+    def test_unknown_revision(self, git_repo):
+        with pytest.raises(subprocess.CalledProcessError):
+            commits_between(git_repo, "does-not-exist", "HEAD")
@@ -0,0 +1,71 @@
+"""Tests for splitting long functions into chunks at statement boundaries."""
+
+from types import SimpleNamespace
+
+from codebase_rag.chunking import chunk_spans, node_chunks, statement_starts
+
+
+def _node(start: int, end: int, children=(), body=None) -> SimpleNamespace:
+    """A syntax node spanning 1-based lines start to end."""
+    return SimpleNamespace(
+        start_point=(start - 1, 0),
+        end_point=(end - 1, 0),
+        named_children=list(children),
+        child_by_field_name=lambda name: body if name == "body" else None,
+    )
+
+
+class TestChunkSpans:
+    """Test cutting line ranges before statements, with overlap."""
+
+    def test_short_body_is_not_chunked(self):
+        assert chunk_spans(10, 89, {20, 40}) == []
+
+    def test_cuts_before_the_furthest_statement(self):
+        assert chunk_spans(1, 200, {30, 61, 75, 150}, max_lines=80, overlap=8) == [
+            (1, 74),
+            (67, 146),
+            (139, 200),
+        ]
+
+    def test_cut_without_statements(self):
+        assert chunk_spans(1, 100, set(), max_lines=40, overlap=5) == [
+            (1, 40),
+            (36, 75),
+            (71, 100),
+        ]
+
+    def test_statement_inside_the_overlap_is_passed_over(self):
+        # Cutting at 5 would start the next chunk before this one
+        assert chunk_spans(1, 30, {5}, max_lines=20, overlap=4) == [
+            (1, 20),
+            (17, 30),
+        ]
+
+
+class TestNodeChunks:
+    """Test chunking a function from its syntax tree."""
+
+    def test_long_loop_is_split_inside(self):
+        loop_body = _node(12, 150, [_node(12, 60), _node(61, 120), _node(121, 150)])
+        loop = _node(11, 150, [loop_body])
+        body = _node(2, 160, [_node(2, 10), loop, _node(151, 160)])
+        function = _node(1, 160, body=body)
+
+        assert statement_starts(function) == {2, 11, 12, 61, 121, 151}
+
+        chunks = node_chunks(function, "shop.orders.checkout")
+
+        assert [(c["start_line"], c["end_line"]) for c in chunks] == [
+            (1, 60),
+            (53, 120),
+            (113, 160),
+        ]
+        assert chunks[1] == {
+            "qualified_name": "shop.orders.checkout:chunk1",
+            "parent": "shop.orders.checkout",
+            "index": 1,
+            "chunk_count": 3,
+            "start_line": 53,
+            "end_line": 120,
+        }
@@ -0,0 +1,99 @@
+"""Tests for resolving the citations of agent answers against the graph."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.citations import (
+    NAMES_QUERY,
+    PATHS_QUERY,
+    Citation,
+    CitationResolver,
+    claims,
+)
+
+ANSWER = """Totals are computed by `Cart.total`, which calls `price()`.
+Discounts are applied in shop/discounts.py:40-52 before `Cart.total` runs.
+
+```python
+cart.total()
+```
+`Ledger.post` records the payment."""
+
+DEFINITIONS = [
+    {
+        "name": "Cart.total",
+        "qualified_name": "shop.cart.Cart.total",
+        "label": "Method",
+        "node_id": 17,
+        "path": "shop/cart.py",
+        "start_line": 12,
+        "end_line": 20,
+    },
+    {
+        "name": "price",
+        "qualified_name": "shop.pricing.price",
+        "label": "Function",
+        "node_id": 31,
+        "path": "shop/pricing.py",
+        "start_line": 3,
+        "end_line": 9,
+    },
+]
+MODULES = [
+    {"path": "shop/discounts.py", "qualified_name": "shop.discounts", "node_id": 5}
+]
+
+
+def _resolver() -> CitationResolver:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params: (
+        DEFINITIONS if query == NAMES_QUERY else MODULES if query == PATHS_QUERY else []
+    )
+    return CitationResolver(ingestor)
+
+
+class TestCitations:
+    """Test tying each claim to the definitions and locations it names."""
+
+    def test_claims_skip_code_blocks(self):
+        assert claims(ANSWER) == [
+            "Totals are computed by `Cart.total`, which calls `price()`.",
+            "Discounts are applied in shop/discounts.py:40-52 before "
+            "`Cart.total` runs.",
+            "`Ledger.post` records the payment.",
+        ]
+
+    def test_cite(self):
+        answer = _resolver().cite(ANSWER)
+
+        assert [
+            (c.reference, c.qualified_name, c.location, c.node_id)
+            for c in answer.citations
+        ] == [
+            ("Cart.total", "shop.cart.Cart.total", "shop/cart.py:12-20", 17),
+            ("price", "shop.pricing.price", "shop/pricing.py:3-9", 31),
+            ("shop/discounts.py:40-52", "shop.discounts", "shop/discounts.py:40-52", 5),
+            ("Cart.total", "shop.cart.Cart.total", "shop/cart.py:12-20", 17),
+            ("Ledger.post", None, "", None),
+        ]
+        assert answer.citations[2].claim.startswith("Discounts are applied")
+        assert not answer.citations[4].resolved
+        assert answer.to_dict()["citations"][0]["location"] == "shop/cart.py:12-20"
+
+    def test_no_references(self):
+        ingestor = MagicMock()
+
+        answer = CitationResolver(ingestor).cite("The cart has no tests.")
+
+        assert answer.citations == []
+        ingestor.fetch_all.assert_not_called()
+
+    def test_urls(self, tmp_path):
+        citation = Citation("", "Cart.total", path="shop/cart.py", start_line=12)
+
+        assert (
+            citation.url(tmp_path, "https://github.com/acme/shop/blob/main/")
+            == "https://github.com/acme/shop/blob/main/shop/cart.py#L12"
+        )
+        assert citation.url(tmp_path) == (tmp_path / "shop/cart.py").as_uri()
+        assert Citation("", "Ledger.post").url(Path(".")) is None
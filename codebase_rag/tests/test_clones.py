@@ -0,0 +1,139 @@
+"""Tests for finding copy-pasted functions from body fingerprints."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.clones import (
+    CLEAR_CLONES,
+    FINGERPRINTS_QUERY,
+    SIGNATURE_SIZE,
+    CloneAnalyzer,
+    body_fingerprint,
+    minhash,
+)
+from codebase_rag.parser_loader import load_parsers
+
+BASE = [f"tok{i}" for i in range(60)]
+
+
+def _row(qn: str, path: str, start: int, tokens: list[str], **extra) -> dict:
+    return {
+        "qualified_name": qn,
+        "label": "Function",
+        "path": path,
+        "start_line": start,
+        "end_line": start + 11,
+        "lines_of_code": 12,
+        "body_hash": "h-" + " ".join(tokens),
+        "body_minhash": minhash(tokens),
+        **extra,
+    }
+
+
+ROWS = [
+    _row("app.billing.total", "billing/total.go", 10, BASE),
+    _row("app.orders.total", "orders/sum.go", 4, BASE),
+    # The copy in reports had one token changed
+    _row("app.reports.total", "reports/sum.go", 30, BASE[:30] + ["x"] + BASE[31:]),
+    _row("app.users.find", "users/find.go", 3, [f"other{i}" for i in range(60)]),
+]
+
+
+def _analyzer(rows: list[dict]) -> tuple[CloneAnalyzer, MagicMock]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {FINGERPRINTS_QUERY: rows}[
+        query
+    ]
+    return CloneAnalyzer(ingestor), ingestor
+
+
+class TestCloneAnalyzer:
+    """Test grouping functions by fingerprint."""
+
+    def test_groups(self):
+        analyzer, _ = _analyzer(ROWS)
+
+        [group] = analyzer.analyze(threshold=0.7)
+
+        assert [m.qualified_name for m in group.members] == [
+            "app.billing.total",
+            "app.orders.total",
+            "app.reports.total",
+        ]
+        assert group.duplicated_lines == 24
+        exact = [p for p in group.pairs if p.exact]
+        assert [(p.source, p.target, p.similarity) for p in exact] == [
+            ("app.orders.total", "app.billing.total", 1.0)
+        ]
+
+    def test_threshold(self):
+        analyzer, _ = _analyzer(ROWS)
+
+        [group] = analyzer.analyze(threshold=1.0)
+
+        assert len(group.members) == 2
+
+    def test_short_and_nested_functions(self):
+        closure = _row("app.billing.total.func1", "billing/total.go", 12, BASE)
+        closure["end_line"] = 15
+        short = _row("app.misc.total", "misc/total.go", 1, BASE, lines_of_code=3)
+        analyzer, _ = _analyzer([ROWS[0], closure, short])
+
+        # A closure repeating its parent is not a copy of it
+        assert analyzer.analyze(min_lines=5) == []
+
+    def test_store(self):
+        analyzer, ingestor = _analyzer(ROWS)
+
+        stored = analyzer.store_pairs(analyzer.analyze(threshold=1.0))
+
+        ingestor.execute_write.assert_called_once_with(CLEAR_CLONES)
+        ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Function", "qualified_name", "app.orders.total"),
+            "CLONE_OF",
+            ("Function", "qualified_name", "app.billing.total"),
+            {"similarity": 1.0, "exact": True},
+        )
+        assert stored == 1
+
+
+GO_SOURCE = b"""package app
+
+func Total(items []Item) int {
+    // Sum the prices
+    sum := 0
+    for _, item := range items {
+        if item.Price > 0 {
+            sum += item.Price * item.Quantity
+        }
+    }
+    return sum
+}
+
+func Amount(lines []Line) int {
+    acc := 10
+    for _, l := range lines {
+        if l.Cost > 0 {
+            acc += l.Cost * l.Count
+        }
+    }
+    return acc
+}
+"""
+
+
+class TestBodyFingerprint:
+    """Test fingerprinting function bodies with Tree-sitter."""
+
+    def test_renamed_copy(self):
+        parsers, _ = load_parsers()
+        root = parsers["go"].parse(GO_SOURCE).root_node
+        total, amount = [
+            node for node in root.children if node.type == "function_declaration"
+        ]
+
+        first, second = body_fingerprint(total), body_fingerprint(amount)
+
+        # Names, literals and comments differ; the structure does not
+        assert first["body_hash"] == second["body_hash"]
+        assert len(first["body_minhash"]) == SIGNATURE_SIZE
+        assert body_fingerprint(total.child_by_field_name("name")) == {}
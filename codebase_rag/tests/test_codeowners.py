@@ -0,0 +1,261 @@
+"""Tests for CODEOWNERS parsing and OWNED_BY relationships."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.codeowners_parser import (
+    CodeOwnersParser,
+    OwnersTree,
+    parse_owners_file,
+)
+
+GITHUB_CODEOWNERS = """# Default owners
+*                 @org/everyone
+*.go              @gophers dev@example.com
+/build/logs/      @doctocat
+docs/*            @org/docs
+**/retry/**       @org/reliability
+/vendor/
+docs/My\\ Guide.md @writer  # trailing comment @ignored
+"""
+
+GITLAB_CODEOWNERS = """* @org/everyone
+
+[Frontend][2] @org/frontend
+web/
+web/legacy/ @alice
+
+^[Docs]
+*.md @org/docs
+"""
+
+
+def _owners(parser: CodeOwnersParser, path: str, is_dir: bool = False) -> list[str]:
+    return [owner.name for owner in parser.owners_for(path, is_dir)]
+
+
+class TestCodeOwnersParser:
+    """Test pattern matching and owner resolution."""
+
+    @pytest.fixture
+    def github(self) -> CodeOwnersParser:
+        return CodeOwnersParser.parse(GITHUB_CODEOWNERS)
+
+    def test_last_match_wins(self, github):
+        assert _owners(github, "README.md") == ["@org/everyone"]
+        assert _owners(github, "pkg/api/client.go") == ["@gophers", "dev@example.com"]
+        assert _owners(github, "internal/retry/backoff.go") == ["@org/reliability"]
+
+    def test_directory_patterns(self, github):
+        assert _owners(github, "build/logs/2024/app.log") == ["@doctocat"]
+        # "docs/*" owns direct children only
+        assert _owners(github, "docs/index.md") == ["@org/docs"]
+        assert _owners(github, "docs/api/index.md") == ["@org/everyone"]
+
+    def test_rule_without_owners_clears_ownership(self, github):
+        assert _owners(github, "vendor/lib/lib.go") == []
+
+    def test_escaped_spaces_and_comments(self, github):
+        assert _owners(github, "docs/My Guide.md") == ["@writer"]
+
+    def test_owner_kinds(self, github):
+        owners = {o.name: o for o in github.owners_for("main.go")}
+        assert owners["@gophers"].kind == "User"
+        assert (owners["dev@example.com"].kind, owners["dev@example.com"].email) == (
+            "User",
+            "dev@example.com",
+        )
+        assert github.owners_for("README.md")[0].kind == "Team"
+
+    def test_directory_ownership(self, github):
+        assert _owners(github, "internal/retry", is_dir=True) == ["@org/reliability"]
+        assert _owners(github, "docs", is_dir=True) == ["@org/docs"]
+
+    def test_gitlab_sections_combine(self):
+        gitlab = CodeOwnersParser.parse(GITLAB_CODEOWNERS)
+        assert _owners(gitlab, "web/app.js") == ["@org/everyone", "@org/frontend"]
+        assert _owners(gitlab, "web/legacy/old.js") == ["@org/everyone", "@alice"]
+        assert _owners(gitlab, "web/README.md") == [
+            "@org/everyone",
+            "@org/frontend",
+            "@org/docs",
+        ]
+        assert {rule.section for rule in gitlab.rules_for("web/README.md")} == {
+            "",
+            "Frontend",
+            "Docs",
+        }
+
+    def test_find_prefers_github_directory(self, temp_repo):
+        (temp_repo / "CODEOWNERS").write_text("* @root\n")
+        assert CodeOwnersParser.find(temp_repo) == temp_repo / "CODEOWNERS"
+        (temp_repo / ".github").mkdir()
+        (temp_repo / ".github" / "CODEOWNERS").write_text("* @github\n")
+        assert CodeOwnersParser.find(temp_repo) == temp_repo / ".github" / "CODEOWNERS"
+
+
+CHROMIUM_OWNERS = """set noparent
+# Payments reviewers
+pay@example.com
+per-file *.gn,BUILD=build@example.com
+file://build/OWNERS
+"""
+
+KUBERNETES_OWNERS = """approvers:
+  - alice
+  - payments-maintainers
+reviewers:
+  - bob
+"""
+
+
+def _tree_owners(tree: OwnersTree, path: str, is_dir: bool = False) -> list[str]:
+    return [
+        owner.name for _, rule in tree.rules_for(path, is_dir) for owner in rule.owners
+    ]
+
+
+class TestOwnersFiles:
+    """Test Chromium and Kubernetes OWNERS files."""
+
+    @pytest.fixture
+    def tree(self) -> OwnersTree:
+        return OwnersTree(
+            [
+                parse_owners_file("root@example.com\n", "OWNERS"),
+                parse_owners_file(CHROMIUM_OWNERS, "payments/OWNERS"),
+                parse_owners_file(
+                    KUBERNETES_OWNERS, "payments/api/OWNERS", {"payments-maintainers"}
+                ),
+                parse_owners_file("docs@example.com\n", "docs/OWNERS"),
+            ]
+        )
+
+    def test_inherited_from_parent_directories(self, tree):
+        assert _tree_owners(tree, "main.go") == ["root@example.com"]
+        assert _tree_owners(tree, "docs/guide/intro.md") == [
+            "docs@example.com",
+            "root@example.com",
+        ]
+
+    def test_noparent_and_per_file(self, tree):
+        assert _tree_owners(tree, "payments/charge.go") == ["pay@example.com"]
+        assert _tree_owners(tree, "payments/BUILD") == [
+            "pay@example.com",
+            "build@example.com",
+        ]
+        # per-file rules cover their own directory only
+        assert _tree_owners(tree, "payments/api/BUILD") == [
+            "@alice",
+            "payments-maintainers",
+            "pay@example.com",
+        ]
+
+    def test_kubernetes_approvers(self, tree):
+        rules = tree.rules_for("payments/api", is_dir=True)
+        owners = [(owner.name, owner.kind) for _, r in rules for owner in r.owners]
+        assert owners[:2] == [("@alice", "User"), ("payments-maintainers", "Team")]
+        # Reviewers do not own the code
+        assert "@bob" not in _tree_owners(tree, "payments/api/server.go")
+        assert [r.line_number for _, r in rules[:2]] == [2, 3]
+        assert rules[0][0].path == "payments/api/OWNERS"
+
+    def test_find(self, temp_repo):
+        (temp_repo / "OWNERS").write_text("root@example.com\n")
+        (temp_repo / "OWNERS_ALIASES").write_text("aliases:\n  sig-x:\n    - carol\n")
+        (temp_repo / "lib").mkdir()
+        (temp_repo / "lib" / "OWNERS").write_text("approvers:\n- sig-x\n")
+
+        tree = OwnersTree.find(temp_repo, set())
+
+        assert sorted(tree.files) == ["", "lib"]
+        assert tree.files["lib"].rules[0].owners[0].kind == "Team"
+
+
+class TestCodeOwnersIngestion:
+    """Test OWNED_BY relationships created during ingestion."""
+
+    def test_files_and_packages_are_owned(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / ".github").mkdir()
+        (temp_repo / ".github" / "CODEOWNERS").write_text(
+            "* @org/everyone\n/payments/ @alice\n"
+        )
+        (temp_repo / "payments").mkdir()
+        (temp_repo / "payments" / "retry.go").write_text("package payments\n")
+        (temp_repo / "main.go").write_text("package main\n")
+
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.structural_elements = {
+            Path(): None,
+            Path("payments"): f"{temp_repo.name}.payments",
+        }
+        updater._ingest_code_owners()
+
+        owned_by = {
+            (call.args[0], call.args[2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == "OWNED_BY"
+        }
+        assert (
+            ("File", "path", str(Path("payments") / "retry.go")),
+            ("User", "name", "@alice"),
+        ) in owned_by
+        assert (
+            ("File", "path", "main.go"),
+            ("Team", "name", "@org/everyone"),
+        ) in owned_by
+        assert (
+            ("Package", "qualified_name", f"{temp_repo.name}.payments"),
+            ("User", "name", "@alice"),
+        ) in owned_by
+        # The last matching rule wins, so everyone does not own payments/
+        assert (
+            ("File", "path", str(Path("payments") / "retry.go")),
+            ("Team", "name", "@org/everyone"),
+        ) not in owned_by
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "User", {"name": "@alice", "email": ""}
+        )
+
+    def test_owners_files(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "payments").mkdir()
+        (temp_repo / "payments" / "OWNERS").write_text("pay@example.com\n")
+        (temp_repo / "payments" / "retry.go").write_text("package payments\n")
+
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.structural_elements = {
+            Path(): None,
+            Path("payments"): f"{temp_repo.name}.payments",
+        }
+        updater._ingest_code_owners()
+
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("File", "path", str(Path("payments") / "retry.go")),
+            "OWNED_BY",
+            ("User", "name", "pay@example.com"),
+            {
+                "pattern": "/payments/**",
+                "section": "",
+                "source": "payments/OWNERS",
+                "line_number": 1,
+            },
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Package", "qualified_name", f"{temp_repo.name}.payments"),
+            "OWNED_BY",
+            ("User", "name", "pay@example.com"),
+            {
+                "pattern": "/payments/**",
+                "section": "",
+                "source": "payments/OWNERS",
+                "line_number": 1,
+            },
+        )
+
+    def test_no_codeowners_file(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "main.go").write_text("package main\n")
+        GraphUpdater(mock_ingestor, temp_repo, {}, {})._ingest_code_owners()
+        mock_ingestor.ensure_relationship_batch.assert_not_called()
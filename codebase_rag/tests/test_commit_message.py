@@ -0,0 +1,156 @@
+"""Tests for graph-aware commit message proposals."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.commit_message import (
+    CommitMessageGenerator,
+    classify_file,
+    propose_message,
+)
+from codebase_rag.analysis.review import (
+    SYMBOL_CONTEXT_QUERY,
+    SYMBOLS_IN_PATHS_QUERY,
+    ChangedSymbol,
+    FileDiff,
+)
+
+NEW_FUNCTION_DIFF = """\
+diff --git a/shop/cart.py b/shop/cart.py
+index 1111111..2222222 100644
+--- a/shop/cart.py
++++ b/shop/cart.py
+@@ -20,0 +21,3 @@ def total(items):
++def shipping(items):
++    return 5 if len(items) < 3 else 0
++
+"""
+
+
+def _symbol(**overrides) -> ChangedSymbol:
+    fields = {
+        "qualified_name": "shop.cart.total",
+        "label": "Function",
+        "path": "shop/cart.py",
+        "start_line": 9,
+        "end_line": 13,
+        "first_changed_line": 11,
+    }
+    return ChangedSymbol(**{**fields, **overrides})
+
+
+class TestClassifyFile:
+    """Test file kinds inferred from paths."""
+
+    def test_kinds(self):
+        assert classify_file("shop/cart.py") == "code"
+        assert classify_file("tests/test_cart.py") == "test"
+        assert classify_file("web/cart.spec.ts") == "test"
+        assert classify_file("cart/cart_test.go") == "test"
+        assert classify_file("README.md") == "docs"
+        assert classify_file(".github/workflows/ci.yml") == "ci"
+        assert classify_file("pyproject.toml") == "build"
+
+
+class TestProposeMessage:
+    """Test commit types, scopes and bodies derived from graph context."""
+
+    def test_modified_function(self):
+        diffs = [FileDiff(path="shop/cart.py", old_path="shop/cart.py")]
+        diffs[0].added_lines = {11}
+        symbol = _symbol(
+            callers=["shop.api.checkout"],
+            endpoints=["POST /checkout"],
+            owners=["@shop-team"],
+        )
+
+        message = propose_message(diffs, [symbol])
+
+        assert message.header == "fix(shop): update total"
+        assert message.body == [
+            "Changes total in 1 file(s) under shop.",
+            "Affects endpoints POST /checkout.",
+            "Reached from 1 caller(s), e.g. shop.api.checkout.",
+            "No tests cover total.",
+            "Owners: @shop-team.",
+        ]
+
+    def test_crash_fix_and_removal(self):
+        diffs = [FileDiff(path="shop/cart.py", old_path="shop/cart.py")]
+        diffs[0].removed_at = {11}
+        crashing = _symbol(crashes=[{"short_id": "SHOP-1", "count": 3}])
+
+        assert propose_message(diffs, [crashing]).header == (
+            "fix(shop): fix crashes in total"
+        )
+        assert propose_message(diffs, [_symbol()]).header == (
+            "refactor(shop): remove code from total"
+        )
+
+    def test_tests_only(self):
+        diffs = [
+            FileDiff(path="tests/test_cart.py", old_path="tests/test_cart.py"),
+            FileDiff(path="tests/test_api.py", old_path="tests/test_api.py"),
+        ]
+
+        message = propose_message(diffs, [])
+
+        assert message.header == "test(tests): update test_cart.py and test_api.py"
+
+    def test_long_subject_is_summarised(self):
+        diffs = [FileDiff(path="shop/cart.py", old_path="shop/cart.py")]
+        diffs[0].added_lines = set(range(1, 200))
+        symbols = [
+            _symbol(
+                qualified_name=f"shop.cart.function_with_a_long_name_{i}",
+                start_line=i * 10 + 5,
+            )
+            for i in range(6)
+        ]
+
+        message = propose_message(diffs, symbols)
+
+        assert message.header.startswith("feat(shop): add function_with_a_long_name_0")
+        assert message.header.endswith("more")
+        assert len(message.header) <= 72
+
+
+class TestCommitMessageGenerator:
+    """Test proposals for a diff against the graph."""
+
+    def test_new_function_is_a_feature(self):
+        ingestor = MagicMock()
+
+        def fetch_all(query, params=None):
+            if query == SYMBOLS_IN_PATHS_QUERY:
+                return [
+                    {
+                        "qualified_name": "shop.cart.shipping",
+                        "label": "Function",
+                        "path": "shop/cart.py",
+                        "start_line": 21,
+                        "end_line": 22,
+                        "complexity": 2,
+                    }
+                ]
+            if query == SYMBOL_CONTEXT_QUERY:
+                return [
+                    {
+                        "qualified_name": "shop.cart.shipping",
+                        "callers": [],
+                        "tests": ["tests.test_cart.test_shipping"],
+                        "endpoints": [],
+                        "crashes": [],
+                    }
+                ]
+            return []
+
+        ingestor.fetch_all.side_effect = fetch_all
+
+        message = CommitMessageGenerator(ingestor).generate(NEW_FUNCTION_DIFF)
+
+        assert message.render() == (
+            "feat(shop): add shipping\n\n"
+            "Changes shipping in 1 file(s) under shop.\n\n"
+            "Covered by tests.test_cart.test_shipping.\n"
+        )
+        assert message.to_dict()["type"] == "feat"
@@ -0,0 +1,87 @@
+"""Tests for shell completion of symbols, profiles and fixed choices."""
+
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.completion import (
+    MAX_COMPLETIONS,
+    choices,
+    complete_language,
+    complete_profile,
+    complete_symbol,
+    write_symbol_index,
+)
+from codebase_rag.config import settings
+
+
+@pytest.fixture
+def index_path(tmp_path, monkeypatch):
+    path = tmp_path / "cache" / "symbols.tsv"
+    monkeypatch.setattr(settings, "COMPLETION_INDEX_PATH", str(path))
+    return path
+
+
+class TestSymbolIndex:
+    """Test writing the index from the graph and completing from it."""
+
+    def test_write_and_complete(self, index_path):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"name": "shop.cart.total", "label": "Function"},
+            {"name": "shop.cart.Cart", "label": "Class"},
+            {"name": "shop.cart.Cart.add", "label": "Method"},
+            {"name": "shop.tax.total_tax", "label": "Function"},
+            {"name": None, "label": "Function"},
+        ]
+
+        assert write_symbol_index(ingestor) == 4
+        assert index_path.read_text().splitlines()[0] == "shop.cart.Cart\tClass"
+
+        # By qualified-name prefix
+        assert complete_symbol("shop.cart.C") == [
+            ("shop.cart.Cart", "Class"),
+            ("shop.cart.Cart.add", "Method"),
+        ]
+        # By the last part of the name
+        assert complete_symbol("total") == [
+            ("shop.cart.total", "Function"),
+            ("shop.tax.total_tax", "Function"),
+        ]
+
+    def test_missing_index_completes_nothing(self, index_path):
+        assert complete_symbol("shop") == []
+
+    def test_completions_are_capped(self, index_path):
+        index_path.parent.mkdir(parents=True)
+        index_path.write_text(
+            "".join(f"pkg.f{i}\tFunction\n" for i in range(MAX_COMPLETIONS + 10))
+        )
+
+        assert len(complete_symbol("pkg.")) == MAX_COMPLETIONS
+
+
+class TestOtherCompletions:
+    """Test profile, language and fixed-choice completion."""
+
+    def test_profiles_from_config_file(self, tmp_path):
+        config_file = tmp_path / ".cgr.toml"
+        config_file.write_text(
+            "[profiles.work]\nmemgraph_host = 'db'\n\n"
+            "[profiles.weekend]\nmemgraph_host = 'localhost'\n\n"
+            "[profiles.ci]\nmemgraph_host = 'ci'\n"
+        )
+
+        with patch(
+            "codebase_rag.completion.active_config_file", return_value=config_file
+        ):
+            assert complete_profile("w") == ["work", "weekend"]
+
+    def test_no_config_file(self):
+        with patch("codebase_rag.completion.active_config_file", return_value=None):
+            assert complete_profile("") == []
+
+    def test_languages_and_choices(self):
+        assert "python" in complete_language("py")
+        assert complete_language("zzz") == []
+        assert choices("markdown", "json")("j") == ["json"]
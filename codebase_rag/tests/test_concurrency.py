@@ -0,0 +1,232 @@
+"""Tests for goroutine shared-state hazard heuristics and Go channels."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.concurrency import (
+    analyze_go_function,
+    collect_function_channels,
+    collect_package_channels,
+    collect_package_variables,
+    find_shared_state_hazards,
+    receiver_of,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+
+def _write(writer, variable="shop.cache", in_goroutine=False, guarded=False):
+    return {
+        "writer": writer,
+        "variable": variable,
+        "path": "shop/cache.go",
+        "in_goroutine": in_goroutine,
+        "guarded": guarded,
+    }
+
+
+class TestSharedStateHazards:
+    """Test hazard detection over SPAWNS, CALLS and WRITES edges."""
+
+    def test_write_reachable_from_spawned_function(self):
+        spawns = [("shop.Start", "shop.worker")]
+        calls = [("shop.worker", "shop.refresh")]
+        writes = [_write("shop.refresh"), _write("shop.Reset")]
+
+        [hazard] = find_shared_state_hazards(spawns, calls, writes)
+        assert hazard.variable == "shop.cache"
+        assert hazard.goroutine_writers == ["shop.refresh"]
+        assert hazard.other_writers == ["shop.Reset"]
+        assert hazard.spawned_from == ["shop.Start"]
+
+    def test_write_inside_goroutine_literal(self):
+        [hazard] = find_shared_state_hazards(
+            [], [], [_write("shop.Start", in_goroutine=True)]
+        )
+        assert hazard.goroutine_writers == ["shop.Start"]
+        assert hazard.spawned_from == ["shop.Start"]
+
+    def test_guarded_and_non_goroutine_writes_are_ignored(self):
+        spawns = [("shop.Start", "shop.worker")]
+        writes = [_write("shop.worker", guarded=True), _write("shop.Reset")]
+        assert find_shared_state_hazards(spawns, [], writes) == []
+
+
+class TestGoConcurrencyExtraction:
+    """Test extraction of package variables, goroutines and writes from Go."""
+
+    SOURCE = b"""package shop
+
+var (
+    cache = map[string]int{}
+    hits, misses int
+)
+var mu sync.Mutex
+
+func Start() {
+    go worker()
+    go func() {
+        misses++
+    }()
+    hits = 0
+}
+
+func worker() {
+    cache["k"] = 1
+}
+
+func Reset() {
+    mu.Lock()
+    defer mu.Unlock()
+    cache = map[string]int{}
+}
+
+func shadow() {
+    hits := 1
+    hits++
+}
+"""
+
+    @pytest.fixture
+    def go_functions(self):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        tree = parsers["go"].parse(self.SOURCE)
+        functions = queries["go"]["functions"].captures(tree.root_node)["function"]
+        return tree.root_node, {
+            f.child_by_field_name("name").text.decode(): f for f in functions
+        }
+
+    def test_package_variables(self, go_functions):
+        root, _ = go_functions
+        assert [name for name, _ in collect_package_variables(root)] == [
+            "cache",
+            "hits",
+            "misses",
+            "mu",
+        ]
+
+    def test_function_facts(self, go_functions):
+        _, functions = go_functions
+        package_vars = {"cache", "hits", "misses", "mu"}
+
+        start = analyze_go_function(functions["Start"], package_vars)
+        assert start.spawned == ["worker"]
+        assert start.writes == {"misses": True, "hits": False}
+        assert not start.guarded
+
+        worker = analyze_go_function(functions["worker"], package_vars)
+        assert worker.writes == {"cache": False}
+
+        reset = analyze_go_function(functions["Reset"], package_vars)
+        assert reset.guarded
+
+        assert analyze_go_function(functions["shadow"], package_vars).writes == {}
+
+
+class TestGoChannelExtraction:
+    """Test extraction of channels and the sends and receives on them."""
+
+    SOURCE = b"""package shop
+
+var events = make(chan Event, 16)
+
+type Pool struct {
+    jobs    chan Job
+    results chan<- Result
+}
+
+func (p *Pool) Run(done <-chan struct{}, out chan Result) {
+    errs := make(chan error)
+    go func() {
+        for job := range p.jobs {
+            out <- process(job)
+        }
+    }()
+    drain(errs)
+    <-done
+    events <- Event{}
+}
+
+func drain(errs chan error) {
+    for err := range errs {
+        log(err)
+    }
+}
+"""
+
+    @pytest.fixture
+    def go_source(self):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        tree = parsers["go"].parse(self.SOURCE)
+        functions = queries["go"]["functions"].captures(tree.root_node)["function"]
+        return tree.root_node, {
+            f.child_by_field_name("name").text.decode(): f for f in functions
+        }
+
+    def test_declared_channels(self, go_source):
+        root, functions = go_source
+
+        package = {c.name: c for c in collect_package_channels(root)}
+        assert (package["events"].scope, package["events"].buffered) == (
+            "package",
+            True,
+        )
+        assert package["jobs"].owner == "Pool"
+        assert package["results"].direction == "send"
+
+        run = {c.name: c for c in collect_function_channels(functions["Run"])}
+        assert run["done"].direction == "receive"
+        assert (run["out"].parameter_index, run["out"].element_type) == (1, "Result")
+        assert (run["errs"].scope, run["errs"].buffered) == ("local", False)
+        assert receiver_of(functions["Run"]) == ("p", "Pool")
+
+    def test_operations(self, go_source):
+        _, functions = go_source
+
+        facts = analyze_go_function(functions["Run"], {"events"})
+
+        assert [(s.channel, s.in_goroutine) for s in facts.sends] == [
+            ("out", True),
+            ("events", False),
+        ]
+        assert [(r.channel, r.in_goroutine, r.ranged) for r in facts.receives] == [
+            ("p.jobs", True, True),
+            ("done", False, False),
+        ]
+        assert ("drain", 0, "errs") in facts.arguments
+
+
+class TestChannelArguments:
+    """Test following channels passed into other functions."""
+
+    def test_uses_through_parameter(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.function_registry["shop.pool.drain"] = "Function"
+        updater.go_parameter_channels["shop.pool.drain"][0] = "shop.pool.drain.errs"
+        updater.go_channel_uses["shop.pool.drain.errs"].append(
+            ("Function", "shop.pool.drain", "RECEIVES_FROM", 24, False)
+        )
+        updater.pending_channel_arguments.append(
+            ("shop.pool", "drain", 0, "shop.pool.Pool.Run.errs")
+        )
+
+        updater._link_channel_arguments()
+
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Channel", "qualified_name", "shop.pool.Pool.Run.errs"),
+            "PASSED_AS",
+            ("Channel", "qualified_name", "shop.pool.drain.errs"),
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Function", "qualified_name", "shop.pool.drain"),
+            "RECEIVES_FROM",
+            ("Channel", "qualified_name", "shop.pool.Pool.Run.errs"),
+            {"line_number": 24, "in_goroutine": False, "via": "errs"},
+        )
+        assert updater.pending_channel_arguments == []
@@ -0,0 +1,213 @@
+"""Tests for the config file with named profiles."""
+
+import os
+from pathlib import Path
+from unittest.mock import patch
+
+import pytest
+
+from codebase_rag import config
+from codebase_rag.config import (
+    AppConfig,
+    ConfigFileError,
+    find_config_file,
+    load_settings,
+    profile_values,
+    read_config_file,
+    resolve_profile,
+    validate_config_file,
+)
+
+CONFIG_TOML = """\
+default_profile = "local"
+
+[settings]
+memgraph_port = 7687
+target_repo_path = "."
+
+[profiles.local]
+memgraph_host = "localhost"
+
+[profiles.shared]
+memgraph_host = "graph.internal"
+memgraph_port = 17687
+
+[profiles.shop]
+extends = "shared"
+target_repo_path = "~/src/shop"
+orchestrator_model = "llama3"
+cypher_model = "llama3"
+"""
+
+CONFIG_YAML = """\
+settings:
+  memgraph_host: yaml-host
+profiles:
+  ci:
+    memgraph_port: 7688
+"""
+
+
+@pytest.fixture
+def config_file(tmp_path):
+    path = tmp_path / ".cgr.toml"
+    path.write_text(CONFIG_TOML)
+    return path
+
+
+@pytest.fixture
+def clean_env():
+    names = ("CGR_CONFIG", "CGR_PROFILE", "MEMGRAPH_HOST", "MEMGRAPH_PORT")
+    with patch.dict(os.environ):
+        for name in names:
+            os.environ.pop(name, None)
+        yield
+
+
+class TestConfigFile:
+    """Test finding, reading and resolving config file profiles."""
+
+    def test_find_in_parent_directory(self, tmp_path, config_file, clean_env):
+        nested = tmp_path / "src" / "pkg"
+        nested.mkdir(parents=True)
+
+        assert find_config_file(nested) == config_file
+        os.environ["CGR_CONFIG"] = "/etc/cgr.toml"
+        assert find_config_file(nested) == Path("/etc/cgr.toml")
+
+    def test_profiles_extend_and_override(self, config_file):
+        document = read_config_file(config_file)
+
+        assert resolve_profile(document, None) == {
+            "MEMGRAPH_PORT": 7687,
+            "TARGET_REPO_PATH": ".",
+        }
+        assert resolve_profile(document, "shop") == {
+            "MEMGRAPH_PORT": 17687,
+            "TARGET_REPO_PATH": "~/src/shop",
+            "MEMGRAPH_HOST": "graph.internal",
+            "ORCHESTRATOR_MODEL": "llama3",
+            "CYPHER_MODEL": "llama3",
+        }
+        with pytest.raises(ConfigFileError):
+            resolve_profile(document, "missing")
+
+    def test_circular_extends(self):
+        document = {"profiles": {"a": {"extends": "b"}, "b": {"extends": "a"}}}
+
+        with pytest.raises(ConfigFileError):
+            resolve_profile(document, "a")
+
+    def test_yaml_file(self, tmp_path, clean_env):
+        path = tmp_path / ".cgr.yaml"
+        path.write_text(CONFIG_YAML)
+
+        assert profile_values(path, "ci") == {
+            "MEMGRAPH_HOST": "yaml-host",
+            "MEMGRAPH_PORT": 7688,
+        }
+
+    def test_default_and_environment_profile(self, config_file, clean_env):
+        assert profile_values(config_file)["MEMGRAPH_HOST"] == "localhost"
+        os.environ["CGR_PROFILE"] = "shared"
+        assert profile_values(config_file)["MEMGRAPH_HOST"] == "graph.internal"
+
+    def test_broken_file_is_ignored_unless_strict(self, tmp_path, clean_env):
+        path = tmp_path / ".cgr.toml"
+        path.write_text("[settings\n")
+
+        assert profile_values(path) == {}
+        with pytest.raises(ConfigFileError):
+            profile_values(path, strict=True)
+
+
+class TestLoadSettings:
+    """Test settings built from a profile with environment overrides."""
+
+    @pytest.fixture(autouse=True)
+    def restore_settings(self):
+        selection = dict(config._config_selection)
+        values = {
+            name: getattr(config.settings, name) for name in AppConfig.model_fields
+        }
+        yield
+        config._config_selection.update(selection)
+        for name, value in values.items():
+            setattr(config.settings, name, value)
+
+    def test_profile_values_are_applied(self, config_file, clean_env):
+        settings = load_settings(config_file, "shop")
+
+        assert settings is config.settings
+        assert settings.MEMGRAPH_HOST == "graph.internal"
+        assert settings.MEMGRAPH_PORT == 17687
+        assert settings.active_orchestrator_model == "llama3"
+
+    def test_environment_overrides_profile(self, config_file, clean_env):
+        os.environ["MEMGRAPH_HOST"] = "from-env"
+
+        settings = load_settings(config_file, "shop")
+
+        assert settings.MEMGRAPH_HOST == "from-env"
+        assert settings.MEMGRAPH_PORT == 17687
+
+    def test_unknown_profile(self, config_file, clean_env):
+        with pytest.raises(ConfigFileError):
+            load_settings(config_file, "missing")
+
+    def test_lists_are_comma_separated(self, tmp_path, clean_env):
+        path = tmp_path / ".cgr.toml"
+        path.write_text(
+            '[settings]\nexclude_paths = ["**/generated/**", "*_pb2.py"]\n'
+            'disabled_analyses = ["security"]\n'
+        )
+
+        settings = load_settings(path)
+
+        assert settings.EXCLUDE_PATHS == "**/generated/**,*_pb2.py"
+        assert settings.DISABLED_ANALYSES == "security"
+
+
+class TestValidateConfigFile:
+    """Test problems reported by `config validate`."""
+
+    def test_valid_file(self, config_file, clean_env):
+        assert validate_config_file(config_file) == []
+
+    def test_missing_api_key_for_chosen_model(self, tmp_path, clean_env):
+        path = tmp_path / ".cgr.toml"
+        path.write_text(
+            '[profiles.cloud]\norchestrator_model = "gpt-4o"\ncypher_model = "gpt-4o"\n'
+        )
+
+        with patch.dict(os.environ, {"OPENAI_API_KEY": ""}):
+            [problem] = validate_config_file(path)
+
+        assert problem.startswith("profile 'cloud': Configuration Error")
+        assert "OPENAI_API_KEY" in problem
+
+    def test_problems(self, tmp_path, clean_env):
+        path = tmp_path / ".cgr.toml"
+        path.write_text(
+            'default_profile = "prod"\n'
+            "[servers]\n"
+            "[settings]\n"
+            'memgraph_hots = "typo"\n'
+            "[profiles.dev]\n"
+            'memgraph_port = "not a port"\n'
+            "[profiles.loop]\n"
+            'extends = "loop"\n'
+            "[profiles.fast]\n"
+            'disabled_analyses = ["security", "lint"]\n'
+        )
+
+        problems = validate_config_file(path)
+
+        assert "Unknown section 'servers'" in problems
+        assert "settings: unknown setting 'memgraph_hots'" in problems
+        assert "default_profile 'prod' is not a profile" in problems
+        assert any(p.startswith("profile 'dev': MEMGRAPH_PORT") for p in problems)
+        assert "profile 'loop': Profile 'loop' has circular extends" in problems
+        assert (
+            "profile 'fast': unknown analysis 'lint' in disabled_analyses" in problems
+        )
@@ -0,0 +1,221 @@
+"""Tests for Dockerfile and Compose parsing and the Image and Service nodes."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.container_parser import (
+    ContainerSyntaxError,
+    context_path,
+    is_compose_file,
+    is_dockerfile,
+    parse_compose,
+    parse_dockerfile,
+)
+
+API_DOCKERFILE = """# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine AS build
+WORKDIR /src
+COPY go.mod go.sum ./
+COPY cmd/ ./cmd/
+COPY internal ./internal
+RUN go build \\
+    -o /out/api ./cmd/api
+
+FROM build AS test
+RUN go test ./...
+
+FROM gcr.io/distroless/static:nonroot
+COPY --from=build /out/api /api
+COPY ["config/api.yaml", "/etc/api.yaml"]
+EXPOSE 8080 9090/tcp
+ENTRYPOINT ["/api"]
+"""
+
+COMPOSE = """services:
+  api:
+    build:
+      context: ..
+      dockerfile: deploy/Dockerfile
+    ports:
+      - "8080:8080"
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+  worker:
+    build: ../worker
+    depends_on: [db]
+  db:
+    image: postgres:16
+  cache:
+    image: redis
+"""
+
+
+class TestContainerParser:
+    """Test reading Dockerfile stages and Compose services."""
+
+    def test_file_names(self):
+        assert all(
+            is_dockerfile(name)
+            for name in ("Dockerfile", "Dockerfile.dev", "api.Dockerfile")
+        )
+        assert is_dockerfile("Containerfile")
+        assert not is_dockerfile("dockerfile_test.go")
+        assert all(
+            is_compose_file(name)
+            for name in ("compose.yaml", "docker-compose.yml", "compose.prod.yaml")
+        )
+        assert not is_compose_file("composer.json")
+
+    def test_stages(self):
+        dockerfile = parse_dockerfile(API_DOCKERFILE)
+
+        assert [(s.name, s.base, s.line_number) for s in dockerfile.stages] == [
+            ("build", "golang:1.22-alpine", 4),
+            ("test", "build", 12),
+            ("2", "gcr.io/distroless/static:nonroot", 15),
+        ]
+        assert dockerfile.stage(None) is dockerfile.stages[2]
+        assert dockerfile.stage("0") is dockerfile.stage("build")
+        assert dockerfile.stages[2].exposed_ports == ["8080", "9090/tcp"]
+
+    def test_copies(self):
+        build, _, final = parse_dockerfile(API_DOCKERFILE).stages
+
+        assert [(c.sources, c.destination, c.line_number) for c in build.copies] == [
+            (["go.mod", "go.sum"], "./", 6),
+            (["cmd/"], "./cmd/", 7),
+            (["internal"], "./internal", 8),
+        ]
+        assert [(c.sources, c.from_stage) for c in final.copies] == [
+            (["/out/api"], "build"),
+            (["config/api.yaml"], None),
+        ]
+
+    def test_from_without_image(self):
+        with pytest.raises(ContainerSyntaxError):
+            parse_dockerfile("FROM --platform=linux/amd64\n")
+
+    def test_compose(self):
+        api, worker, db, cache = parse_compose(COMPOSE)
+
+        assert (api.name, api.line_number) == ("api", 2)
+        assert (api.build_context, api.dockerfile) == ("..", "deploy/Dockerfile")
+        assert api.depends_on == ["db", "cache"]
+        assert api.ports == ["8080:8080"]
+        assert (worker.build_context, worker.dockerfile) == ("../worker", "Dockerfile")
+        assert worker.depends_on == ["db"]
+        assert (db.image, db.build_context) == ("postgres:16", None)
+        assert cache.line_number == 18
+
+    def test_context_path(self):
+        assert context_path("deploy", "..") == ""
+        assert context_path("deploy", "../cmd/api") == "cmd/api"
+        assert context_path("", "./internal") == "internal"
+        assert context_path("deploy", "../..") is None
+        assert context_path("", "https://github.com/org/repo.git") is None
+
+
+class TestContainerIngestion:
+    """Test the nodes and edges created from Dockerfiles and Compose files."""
+
+    def _ingest(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        for directory in ("cmd/api", "internal", "config", "deploy", "worker"):
+            (temp_repo / directory).mkdir(parents=True)
+        (temp_repo / "go.mod").write_text("module example.com/shop\n")
+        (temp_repo / "config" / "api.yaml").write_text("port: 8080\n")
+        (temp_repo / "deploy" / "Dockerfile").write_text(API_DOCKERFILE)
+        (temp_repo / "deploy" / "compose.yaml").write_text(COMPOSE)
+        (temp_repo / "worker" / "Dockerfile").write_text(
+            "FROM python:3.12\nCOPY . /app\n"
+        )
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater._identify_structure()
+        updater._parse_dockerfile(temp_repo / "deploy" / "Dockerfile")
+        updater._parse_dockerfile(temp_repo / "worker" / "Dockerfile")
+        updater._parse_compose_file(temp_repo / "deploy" / "compose.yaml")
+        updater._link_containers()
+        return updater
+
+    def _edges(self, mock_ingestor: MagicMock, rel_type: str) -> set[tuple]:
+        return {
+            (call.args[0][2], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == rel_type
+        }
+
+    def test_images(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Image",
+            {
+                "qualified_name": "deploy/Dockerfile:2",
+                "name": "2",
+                "path": "deploy/Dockerfile",
+                "line_number": 15,
+                "exposed_ports": ["8080", "9090/tcp"],
+                "is_final": True,
+                "is_external": False,
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Image",
+            {
+                "qualified_name": "gcr.io/distroless/static:nonroot",
+                "name": "gcr.io/distroless/static",
+                "tag": "nonroot",
+                "is_external": True,
+            },
+        )
+        assert self._edges(mock_ingestor, "BUILDS_FROM") == {
+            ("deploy/Dockerfile:build", "golang:1.22-alpine"),
+            ("deploy/Dockerfile:test", "deploy/Dockerfile:build"),
+            ("deploy/Dockerfile:2", "gcr.io/distroless/static:nonroot"),
+            ("worker/Dockerfile:0", "python:3.12"),
+        }
+        assert self._edges(mock_ingestor, "COPIES_FROM") == {
+            ("deploy/Dockerfile:2", "deploy/Dockerfile:build")
+        }
+
+    def test_services(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        assert self._edges(mock_ingestor, "DEPENDS_ON") == {
+            ("deploy/compose.yaml:api", "deploy/compose.yaml:db"),
+            ("deploy/compose.yaml:api", "deploy/compose.yaml:cache"),
+            ("deploy/compose.yaml:worker", "deploy/compose.yaml:db"),
+        }
+        assert self._edges(mock_ingestor, "RUNS_IMAGE") == {
+            ("deploy/compose.yaml:db", "postgres:16"),
+            ("deploy/compose.yaml:cache", "redis"),
+        }
+        assert self._edges(mock_ingestor, "BUILDS") == {
+            ("deploy/compose.yaml:api", "deploy/Dockerfile:2"),
+            ("deploy/compose.yaml:worker", "worker/Dockerfile:0"),
+        }
+
+    def test_copied_sources(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        # Found in the repository root, the context Compose builds it with
+        assert self._edges(mock_ingestor, "COPIES") == {
+            ("deploy/Dockerfile:build", "go.mod"),
+            ("deploy/Dockerfile:build", "cmd"),
+            ("deploy/Dockerfile:build", "internal"),
+            ("deploy/Dockerfile:2", "config/api.yaml"),
+            ("worker/Dockerfile:0", "worker"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Image", "qualified_name", "deploy/Dockerfile:build"),
+            "COPIES",
+            ("Folder", "path", "cmd"),
+            {"destination": "./cmd/", "line_number": 7},
+        )
@@ -0,0 +1,110 @@
+"""Tests for expanding retrieved code to its callers, callees, types and tests."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.context_expansion import (
+    ContextExpander,
+    ExpansionPolicy,
+    callers_query,
+    tests_query,
+)
+
+SOURCE = """def checkout(cart):
+    total = price(cart)
+    return charge(total)
+
+
+def price(cart):
+    return sum(item.cost for item in cart)
+"""
+
+
+def _row(qn: str, hops: int, start: int | None = None, end: int | None = None):
+    return {
+        "qualified_name": qn,
+        "label": "Function",
+        "hops": hops,
+        "path": "shop/orders.py",
+        "start_line": start,
+        "end_line": end,
+    }
+
+
+class TestExpansionPolicy:
+    """Test reading hop limits per kind of edge."""
+
+    def test_parse(self):
+        policy = ExpansionPolicy.parse("callers=2, tests=1", limit=3)
+
+        assert policy == ExpansionPolicy(
+            callers=2, callees=0, types=0, tests=1, limit=3
+        )
+        assert policy.enabled
+        assert not ExpansionPolicy.parse("").enabled
+
+    @pytest.mark.parametrize(
+        "spec, message",
+        [
+            ("callers=1,siblings=1", "Unknown context expansion 'siblings'"),
+            ("callers=many", "Expected callers=<hops>"),
+            ("callees=9", "between 0 and 4"),
+        ],
+    )
+    def test_invalid(self, spec, message):
+        with pytest.raises(ValueError, match=message):
+            ExpansionPolicy.parse(spec)
+
+    def test_hops_in_queries(self):
+        assert "[:CALLS*1..2]" in callers_query(2)
+        # A test of the symbol itself follows no calls
+        assert "[:CALLS*0..0]" in tests_query(1)
+
+
+class TestContextExpander:
+    """Test collecting related symbols with their source."""
+
+    def test_expand(self, tmp_path):
+        (tmp_path / "shop").mkdir()
+        (tmp_path / "shop" / "orders.py").write_text(SOURCE)
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [_row("shop.orders.checkout", 1, 1, 3)],
+            [_row("shop.orders.test_price", 1)],
+        ]
+        policy = ExpansionPolicy(callers=2, callees=0, types=0, tests=1, limit=4)
+
+        related = ContextExpander(ingestor, policy, tmp_path).expand(
+            "shop.orders.price"
+        )
+
+        assert [(r.qualified_name, r.relation, r.hops) for r in related] == [
+            ("shop.orders.checkout", "caller", 1),
+            ("shop.orders.test_price", "test", 1),
+        ]
+        assert related[0].source.splitlines()[1] == "    total = price(cart)"
+        assert related[1].source == ""
+        queries = [call.args[0] for call in ingestor.fetch_all.call_args_list]
+        assert queries == [callers_query(2), tests_query(1)]
+        assert ingestor.fetch_all.call_args.args[1] == {
+            "qualified_name": "shop.orders.price",
+            "limit": 4,
+        }
+
+    def test_failed_kind_is_skipped(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            RuntimeError("query timed out"),
+            [_row("shop.orders.charge", 1)],
+            [],
+            [],
+        ]
+
+        related = ContextExpander(ingestor, ExpansionPolicy()).expand(
+            "shop.orders.checkout"
+        )
+
+        assert [(r.qualified_name, r.relation) for r in related] == [
+            ("shop.orders.charge", "callee")
+        ]
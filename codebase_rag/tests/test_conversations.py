@@ -0,0 +1,137 @@
+"""Tests for keeping chat sessions in the graph."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.citations import Citation, CitedAnswer
+from codebase_rag.conversations import (
+    RECENT_SYMBOLS_QUERY,
+    TRANSCRIPT_QUERY,
+    ConversationStore,
+    Exchange,
+    investigations,
+)
+
+
+def _answer(text: str, *cited: tuple[str, str]) -> CitedAnswer:
+    return CitedAnswer(
+        text,
+        [
+            Citation(text, qn.rsplit(".", 1)[-1], qn, label, "calc/calc.go", 3)
+            for label, qn in cited
+        ]
+        + [Citation(text, "Ledger.post")],
+    )
+
+
+class TestConversationStore:
+    """Test recording exchanges and the code they referenced."""
+
+    def test_record(self):
+        ingestor = MagicMock()
+        store = ConversationStore(ingestor, "c1", repo="calc", user="dana")
+
+        store.record(
+            "What divides?",
+            _answer("`calc.calc.Div` divides.", ("Function", "calc.calc.Div")),
+        )
+        store.record("Why does it panic?", _answer("On a zero divisor."))
+
+        nodes = [call.args for call in ingestor.ensure_node_batch.call_args_list]
+        assert [label for label, _ in nodes] == [
+            "Conversation",
+            "Question",
+            "Answer",
+            "Question",
+            "Answer",
+        ]
+        assert nodes[0][1]["user"] == "dana"
+        assert nodes[3][1]["question_id"] == "c1:1"
+        assert nodes[3][1]["text"] == "Why does it panic?"
+        edges = [
+            (call.args[0][2], call.args[1], call.args[2][2])
+            for call in ingestor.ensure_relationship_batch.call_args_list
+        ]
+        assert edges == [
+            ("c1", "HAS_QUESTION", "c1:0"),
+            ("c1:0", "ANSWERED_BY", "c1:0"),
+            ("c1:0", "REFERENCES", "calc.calc.Div"),
+            ("c1", "HAS_QUESTION", "c1:1"),
+            ("c1:1", "FOLLOWS", "c1:0"),
+            ("c1:1", "ANSWERED_BY", "c1:1"),
+        ]
+        assert ingestor.flush_all.call_count == 2
+        assert store.turns == 2
+
+    def test_pronouns_get_the_latest_code(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [{"qualified_name": "calc.calc.Div"}]
+        store = ConversationStore(ingestor, "c1")
+        store.turns = 1
+
+        question = store.with_context("Why does it panic?")
+
+        assert question == (
+            "Why does it panic?\n\n(Code discussed most recently: `calc.calc.Div`)"
+        )
+        assert ingestor.fetch_all.call_args.args == (
+            RECENT_SYMBOLS_QUERY,
+            {"conversation_id": "c1", "limit": 5},
+        )
+        assert store.with_context("What calls calc.Sqrt?") == "What calls calc.Sqrt?"
+
+    def test_first_question_is_sent_as_asked(self):
+        ingestor = MagicMock()
+
+        assert ConversationStore(ingestor).with_context("What is it?") == "What is it?"
+        ingestor.fetch_all.assert_not_called()
+
+    def test_resume(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"index": 0, "question": "Divides?", "answer": "Div.", "asked_at": "t0"},
+            {"index": 1, "question": "Why?", "answer": None, "asked_at": "t1"},
+        ]
+        store = ConversationStore(ingestor, "c1")
+
+        exchanges = store.resume()
+
+        assert exchanges[1] == Exchange(1, "Why?", None, "t1", "c1")
+        assert store.turns == 2
+        assert ingestor.fetch_all.call_args.args == (
+            TRANSCRIPT_QUERY,
+            {"conversation_id": "c1"},
+        )
+
+    def test_resume_unknown(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+
+        with pytest.raises(ValueError, match="No conversation 'c9'"):
+            ConversationStore(ingestor, "c9").resume()
+
+
+class TestInvestigations:
+    """Test finding the questions asked about a function."""
+
+    def test_investigations(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {
+                "index": 0,
+                "question": "What divides?",
+                "answer": "Div.",
+                "asked_at": "t0",
+                "conversation_id": "c1",
+                "user": "dana",
+            }
+        ]
+
+        [exchange] = investigations(ingestor, "calc.calc.Div")
+
+        assert (exchange.conversation_id, exchange.user) == ("c1", "dana")
+        assert ingestor.fetch_all.call_args.args[1] == {
+            "qualified_name": "calc.calc.Div",
+            "limit": 20,
+        }
@@ -0,0 +1,146 @@
+"""Tests for Sentry crash report ingestion."""
+
+import json
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.crashes import CrashMapper, load_export_file
+
+ISSUES = [
+    {
+        "id": "4711",
+        "shortId": "SHOP-7",
+        "title": "ZeroDivisionError: division by zero",
+        "culprit": "shop.cart in total",
+        "level": "error",
+        "status": "unresolved",
+        "count": "1520",
+        "userCount": 311,
+        "permalink": "https://acme.sentry.io/issues/4711/",
+        "project": {"slug": "shop"},
+        "latestEvent": {
+            "entries": [
+                {
+                    "type": "exception",
+                    "data": {
+                        "values": [
+                            {
+                                "type": "ZeroDivisionError",
+                                "stacktrace": {
+                                    "frames": [
+                                        {
+                                            "filename": "django/core/handlers.py",
+                                            "function": "inner",
+                                            "lineNo": 55,
+                                            "inApp": False,
+                                        },
+                                        {
+                                            "filename": "shop/api.py",
+                                            "function": "get_cart",
+                                            "lineNo": 4,
+                                            "inApp": True,
+                                        },
+                                        {
+                                            "module": "shop.cart",
+                                            "function": "Cart.total",
+                                            "inApp": True,
+                                        },
+                                    ]
+                                },
+                            }
+                        ]
+                    },
+                }
+            ]
+        },
+    },
+    {"id": "4712", "shortId": "SHOP-8", "title": "No event", "count": "3"},
+]
+
+FUNCTIONS = [
+    {
+        "qualified_name": "shop.shop.cart.Cart.total",
+        "name": "total",
+        "label": "Method",
+        "path": "shop/cart.py",
+        "start_line": 2,
+        "end_line": 3,
+    },
+    {
+        "qualified_name": "shop.shop.api.get_cart",
+        "name": "get_cart",
+        "label": "Function",
+        "path": "shop/api.py",
+        "start_line": 1,
+        "end_line": 4,
+    },
+]
+
+
+class TestSentryExport:
+    """Test reading Sentry issue and event exports."""
+
+    def test_issue_list(self, temp_repo: Path):
+        export = temp_repo / "issues.json"
+        export.write_text(json.dumps(ISSUES))
+
+        crash, without_event = load_export_file(export)
+
+        assert (crash.short_id, crash.count, crash.user_count) == ("SHOP-7", 1520, 311)
+        assert crash.project == "shop"
+        # Library frames are dropped once Sentry marks some frames in-app
+        assert [(f.function, f.file, f.line) for f in crash.frames] == [
+            ("get_cart", "shop/api.py", 4),
+            ("shop.cart.Cart.total", "", 0),
+        ]
+        assert without_event.frames == []
+
+    def test_single_event(self, temp_repo: Path):
+        event = {
+            "groupID": "4711",
+            "title": "KeyError",
+            "exception": {
+                "values": [
+                    {"stacktrace": {"frames": [{"filename": "a.py", "lineno": 3}]}}
+                ]
+            },
+        }
+        export = temp_repo / "event.json"
+        export.write_text(json.dumps(event))
+
+        [crash] = load_export_file(export)
+
+        assert (crash.issue_id, crash.count) == ("4711", 1)
+        assert [(f.file, f.line) for f in crash.frames] == [("a.py", 3)]
+
+
+class TestCrashMapper:
+    """Test Crash nodes and CRASHED_IN edges."""
+
+    def test_links_frames_to_functions(self, temp_repo: Path):
+        export = temp_repo / "issues.json"
+        export.write_text(json.dumps(ISSUES[:1]))
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = FUNCTIONS
+
+        stats = CrashMapper(ingestor).ingest(load_export_file(export))
+
+        assert stats == {"crashes": 1, "frames": 2, "resolved_frames": 2}
+        node = ingestor.ensure_node_batch.call_args.args
+        assert node[0] == "Crash"
+        assert (node[1]["issue_id"], node[1]["count"]) == ("4711", 1520)
+        edges = [c.args for c in ingestor.ensure_relationship_batch.call_args_list]
+        assert edges == [
+            (
+                ("Crash", "issue_id", "4711"),
+                "CRASHED_IN",
+                ("Method", "qualified_name", "shop.shop.cart.Cart.total"),
+                {"depth": 0, "is_top": True, "line": 0},
+            ),
+            (
+                ("Crash", "issue_id", "4711"),
+                "CRASHED_IN",
+                ("Function", "qualified_name", "shop.shop.api.get_cart"),
+                {"depth": 1, "is_top": False, "line": 4},
+            ),
+        ]
@@ -0,0 +1,157 @@
+"""Tests for the Cypher template library and checks on generated queries."""
+
+import asyncio
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.cypher_templates import (
+    TEMPLATES,
+    TemplateRunner,
+    graph_schema,
+    match_question,
+    schema_problems,
+)
+from codebase_rag.symbol_search import SYMBOLS_QUERY
+from codebase_rag.tools.codebase_query import create_query_tool
+
+SYMBOLS = [
+    {"qualified_name": "shop.Calculator", "label": "Class"},
+    {"qualified_name": "shop.Calculator.divide", "label": "Method"},
+    {"qualified_name": "shop.checkout.pay", "label": "Function"},
+]
+CALLERS = [{"caller": "shop.checkout.pay", "kind": "Function", "path": "shop.py"}]
+
+
+def _ingestor(results: list[dict] | None = None) -> MagicMock:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params=None: (
+        SYMBOLS if query == SYMBOLS_QUERY else results or []
+    )
+    return ingestor
+
+
+class TestSchemaProblems:
+    """Test what is rejected before a query reaches the database."""
+
+    def test_templates_match_the_schema(self):
+        for template in TEMPLATES.values():
+            assert schema_problems(template.query) == [], template.name
+
+    def test_schema_lists(self):
+        schema = graph_schema()
+
+        assert {"Function", "Method", "TestFunction", "Module"} <= schema.labels
+        assert schema.knows_relationship("CALLS")
+        assert schema.knows_relationship("CONTAINS_FILE")
+        assert not schema.knows_relationship("INVOKES")
+
+    def test_valid_query(self):
+        query = (
+            "MATCH (f:Function)-[c:CALLS]->(g) WHERE g:Method AND "
+            "f.name = 'x:Widget' RETURN f, c.line_number"
+        )
+
+        assert schema_problems(query) == []
+
+    def test_problems(self):
+        query = (
+            "MATCH (f:Funtion)-[:INVOKES|CALLS]->(g) WHERE g:Widget "
+            "SET g.seen = true RETURN f"
+        )
+
+        assert schema_problems(query) == [
+            "SET writes to the graph; only read",
+            "Unknown node label :Funtion",
+            "Unknown node label :Widget",
+            "Unknown relationship type :INVOKES",
+        ]
+
+
+class TestMatchQuestion:
+    """Test which questions a template answers when generation fails."""
+
+    @pytest.mark.parametrize(
+        "question, expected",
+        [
+            ("Who calls `divide`?", ("find_callers", ["divide"])),
+            ("Which functions call Cart.total", ("find_callers", ["Cart.total"])),
+            ("what does checkout.pay call?", ("find_callees", ["checkout.pay"])),
+            ("List implementations of Storage.", ("find_implementations", ["Storage"])),
+            ("Which tests cover divide", ("tests_for_function", ["divide"])),
+            (
+                "Show the path from pay to divide",
+                ("path_between_symbols", ["pay", "divide"]),
+            ),
+            ("Summarize the billing module", None),
+        ],
+    )
+    def test_questions(self, question, expected):
+        assert match_question(question) == expected
+
+
+class TestTemplateRunner:
+    """Test running templates with symbols resolved from the graph."""
+
+    def test_resolves_misspelled_symbols(self):
+        ingestor = _ingestor(CALLERS)
+
+        result = TemplateRunner(ingestor).run("find_callers", ["Calculator.divde"])
+
+        assert result.parameters["symbol"] == "shop.Calculator.divide"
+        assert result.results == CALLERS
+        assert result.unresolved == []
+
+    def test_unresolved_symbols_are_passed_as_given(self):
+        result = TemplateRunner(_ingestor()).run(
+            "path_between_symbols", ["pay", "inventory.reserve"]
+        )
+
+        assert result.parameters["target"] == "inventory.reserve"
+        assert result.unresolved == ["inventory.reserve"]
+
+    def test_bad_arguments(self):
+        runner = TemplateRunner(_ingestor())
+
+        with pytest.raises(ValueError, match="Unknown template"):
+            runner.run("find_everything", ["pay"])
+        with pytest.raises(ValueError, match="takes 2 symbol"):
+            runner.run("path_between_symbols", ["pay"])
+
+
+class TestQueryTool:
+    """Test retrying rejected queries and falling back to a template."""
+
+    def _ask(self, ingestor, generated: list[str], question: str):
+        cypher_gen = MagicMock()
+        prompts = []
+
+        async def generate(prompt):
+            prompts.append(prompt)
+            return generated[len(prompts) - 1]
+
+        cypher_gen.generate = generate
+        tool = create_query_tool(ingestor, cypher_gen, MagicMock())
+        return asyncio.run(tool.function(question)), prompts
+
+    def test_retries_with_the_problems(self):
+        ingestor = _ingestor(CALLERS)
+        bad = "MATCH (f:Funtion) RETURN f"
+        good = "MATCH (f:Function) RETURN f.qualified_name AS caller"
+
+        data, prompts = self._ask(ingestor, [bad, good], "List functions")
+
+        assert data.query_used == good
+        assert len(prompts) == 2
+        assert "Unknown node label :Funtion" in prompts[1]
+
+    def test_falls_back_to_a_template(self):
+        ingestor = _ingestor(CALLERS)
+        bad = "MATCH (f)-[:INVOKES]->(g) RETURN f"
+
+        data, prompts = self._ask(ingestor, [bad] * 3, "Who calls divide?")
+
+        assert len(prompts) == 3
+        assert data.query_used == TEMPLATES["find_callers"].query
+        assert data.results == CALLERS
+        assert "find_callers template" in data.summary
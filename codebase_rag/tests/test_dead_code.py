@@ -0,0 +1,115 @@
+"""Tests for dead code detection."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.dead_code import (
+    DeadCodeAnalyzer,
+    DeadSymbol,
+    find_dead_code,
+    is_excluded,
+)
+
+
+def _symbol(qualified_name: str, path: str, label: str = "Function", **extra):
+    return {
+        "qualified_name": qualified_name,
+        "label": label,
+        "name": qualified_name.rsplit(".", 1)[-1],
+        "path": path,
+        "line_number": 1,
+        **extra,
+    }
+
+
+def _use(source: str, target: str, kind: str = "CALLS", label: str = "Function"):
+    return {"source": source, "source_label": label, "target": target, "type": kind}
+
+
+SYMBOLS = [
+    _symbol("shop.main.main", "main.go"),
+    _symbol("shop.main.serve", "main.go"),
+    _symbol("shop.main.unused", "main.go"),
+    _symbol("shop.main.helper", "main.go"),
+    _symbol("shop.main.init", "main.go"),
+    _symbol("shop.cart.Total", "cart/cart.go"),
+    _symbol("shop.cart.round", "cart/cart.go"),
+    _symbol("shop.cart.tested", "cart/cart.go"),
+    _symbol("shop.cart.cart_test.TestRound", "cart/cart_test.go"),
+    _symbol("shop.cart.legacyCart", "cart/cart.go", label="Class"),
+    _symbol("shop.plugins.handler", "plugins/handler.go"),
+]
+USES = [
+    _use("shop.main.main", "shop.main.serve"),
+    # Only called by dead code
+    _use("shop.main.unused", "shop.main.helper"),
+    _use("shop.cart.Total", "shop.cart.round"),
+    _use("shop.cart.TestTested", "shop.cart.tested", "TESTS", "TestFunction"),
+]
+
+
+class TestDeadCode:
+    """Test walking from the roots to find unreachable symbols."""
+
+    def test_unreachable_symbols(self):
+        dead = find_dead_code(SYMBOLS, USES)
+
+        assert [d.qualified_name for d in dead] == [
+            "shop.cart.legacyCart",
+            "shop.main.helper",
+            "shop.main.unused",
+            "shop.plugins.handler",
+        ]
+        [helper] = [d for d in dead if d.name == "helper"]
+        assert helper.dead_users == ["shop.main.unused"]
+        assert helper.package == "."
+
+    def test_without_exported_roots(self):
+        dead = find_dead_code(SYMBOLS, USES, exported_roots=False)
+
+        # Total and round are only reachable as exported API
+        assert {"shop.cart.Total", "shop.cart.round"} <= {
+            d.qualified_name for d in dead
+        }
+
+    def test_excluded_packages_and_entrypoints(self):
+        dead = find_dead_code(
+            SYMBOLS, USES, exclude=["plugins"], entrypoints=["shop.main.unused"]
+        )
+
+        assert [d.qualified_name for d in dead] == ["shop.cart.legacyCart"]
+
+    def test_backward_edges(self):
+        symbols = [
+            _symbol("app.Base.run", "app.py", label="Method"),
+            _symbol("app.Child.run", "app.py", label="Method"),
+            _symbol("app._Worker", "app.py", label="Class"),
+            _symbol("app._Worker.start", "app.py", label="Method"),
+            _symbol("app.main", "app.py"),
+            _symbol("app._go", "app.py"),
+        ]
+        uses = [
+            _use("app.main", "app._go"),
+            _use("app._go", "app._Worker.start"),
+            # A live method makes its class and its overrides live
+            _use("app._Worker", "app._Worker.start", "DEFINES_METHOD", "Class"),
+            _use("app.Child.run", "app.Base.run", "OVERRIDES", "Method"),
+        ]
+
+        assert find_dead_code(symbols, uses) == []
+
+    def test_is_excluded(self):
+        assert is_excluded("plugins/handler.go", ["plugins/"])
+        assert is_excluded("internal/rpc/gen.pb.go", ["*.pb.go"])
+        assert not is_excluded("pluginsx/handler.go", ["plugins"])
+
+    def test_store_results(self):
+        ingestor = MagicMock()
+        dead = [DeadSymbol("shop.main.unused", "unused", "Function", "main.go", ".")]
+
+        DeadCodeAnalyzer(ingestor).store_results(dead)
+
+        ingestor.execute_write.assert_called_once()
+        ingestor.ensure_node_batch.assert_called_once_with(
+            "Function", {"qualified_name": "shop.main.unused", "is_dead_code": True}
+        )
+        ingestor.flush_all.assert_called_once()
@@ -236,4 +236,31 @@ class TestDependencyAnalyzer:
         assert requires_rels[0]["properties"]["symbol"] == "*"
         
         imports_rels = [r for r in relationships if r["rel_type"] == "IMPORTS"]
-        assert len(imports_rels) == 0  # No specific imports for wildcard
\ No newline at end of file
+        assert len(imports_rels) == 0  # No specific imports for wildcard
+    def test_javascript_imports(self):
+        """Test ES imports, re-exports, require() and import() calls."""
+        from codebase_rag.parser_loader import load_parsers
+
+        parsers, queries = load_parsers()
+        if "javascript" not in parsers:
+            pytest.skip("JavaScript parser not available")
+        analyzer = DependencyAnalyzer(
+            parsers["javascript"], queries["javascript"], "javascript"
+        )
+        source = (
+            "import React from 'react';\n"
+            "import './styles.css';\n"
+            "export { map } from \"lodash/fp\";\n"
+            "const z = require('@scope/pkg/sub');\n"
+            "const lazy = () => import('./lazy');\n"
+        )
+
+        _, imports = analyzer.analyze_file("app.js", source, "shop.app")
+
+        assert [(i.source_module, i.import_type, i.line_number) for i in imports] == [
+            ("react", "namespace", 1),
+            ("./styles.css", "side_effect", 2),
+            ("lodash/fp", "namespace", 3),
+            ("@scope/pkg/sub", "namespace", 4),
+            ("./lazy", "namespace", 5),
+        ]
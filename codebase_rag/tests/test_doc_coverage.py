@@ -0,0 +1,100 @@
+"""Tests for doc comment extraction and the undocumented public API report."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.doc_coverage import (
+    DocCoverageAnalyzer,
+    build_doc_coverage_report,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+
+class TestDocCommentExtraction:
+    """Test docstrings taken from leading comments in non-Python languages."""
+
+    @pytest.fixture
+    def updater(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        parsers, queries = load_parsers()
+        return GraphUpdater(mock_ingestor, temp_repo, parsers, queries)
+
+    def _functions(self, updater: GraphUpdater, source: str, language: str):
+        tree = updater.parsers[language].parse(source.encode("utf-8"))
+        captures = updater.queries[language]["functions"].captures(tree.root_node)
+        return captures["function"]
+
+    def test_go_line_comments(self, updater):
+        source = (
+            "package pay\n"
+            "\n"
+            "// Charge bills the card.\n"
+            "// It retries once.\n"
+            "func Charge() {}\n"
+            "\n"
+            "// unrelated note\n"
+            "\n"
+            "func Refund() {}\n"
+        )
+        charge, refund = self._functions(updater, source, "go")
+        assert updater._get_docstring(charge, "go") == (
+            "Charge bills the card.\nIt retries once."
+        )
+        # A blank line separates the comment from the declaration
+        assert updater._get_docstring(refund, "go") is None
+
+    def test_jsdoc_on_exported_function(self, updater):
+        source = (
+            "/**\n"
+            " * Charges a card.\n"
+            " */\n"
+            "export function charge() {}\n"
+        )
+        [charge] = self._functions(updater, source, "javascript")
+        assert updater._get_docstring(charge, "javascript") == "Charges a card."
+
+    def test_python_ignores_comments(self, updater):
+        source = "# Not a docstring\ndef f():\n    pass\n"
+        [func] = self._functions(updater, source, "python")
+        assert updater._get_docstring(func, "python") is None
+
+
+class TestDocCoverageReport:
+    """Test filtering and ranking of undocumented symbols."""
+
+    @pytest.fixture
+    def rows(self):
+        return [
+            {"qualified_name": "p.pay.Charge", "name": "Charge", "label": "Function", "path": "pay/pay.go", "docstring": None, "fan_in": 3},
+            {"qualified_name": "p.pay.Refund", "name": "Refund", "label": "Function", "path": "pay/pay.go", "docstring": "Refund money.", "fan_in": 9},
+            {"qualified_name": "p.pay.helper", "name": "helper", "label": "Function", "path": "pay/pay.go", "docstring": None, "fan_in": 20},
+            {"qualified_name": "p.api.Client", "name": "Client", "label": "Class", "path": "api/client.py", "docstring": "  ", "fan_in": 12},
+            {"qualified_name": "p.api.Client._send", "name": "_send", "label": "Method", "path": "api/client.py", "docstring": None, "fan_in": 4},
+            {"qualified_name": "p.tests.test_api.make", "name": "make", "label": "Function", "path": "tests/test_api.py", "docstring": None, "fan_in": 7},
+            {"qualified_name": "p.api.ping", "name": "ping", "label": "Function", "path": "api/client.py", "docstring": None, "fan_in": None},
+        ]
+
+    def test_ranked_by_fan_in(self, rows):
+        report = build_doc_coverage_report(rows)
+
+        assert [s.qualified_name for s in report.undocumented] == [
+            "p.api.Client",
+            "p.pay.Charge",
+            "p.api.ping",
+        ]
+        assert report.total_exported == 4
+        assert report.documented == 1
+        assert report.coverage_percent == pytest.approx(25.0)
+
+    def test_min_fan_in(self, rows):
+        report = build_doc_coverage_report(rows, min_fan_in=5)
+        assert [s.name for s in report.undocumented] == ["Client"]
+        # Coverage still counts every exported symbol
+        assert report.total_exported == 4
+
+    def test_empty_graph(self):
+        report = DocCoverageAnalyzer(MagicMock(fetch_all=MagicMock(return_value=[]))).analyze()
+        assert report.coverage_percent == 100.0
+        assert report.to_dict()["undocumented"] == []
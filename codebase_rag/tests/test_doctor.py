@@ -0,0 +1,252 @@
+"""Tests for the doctor environment checks."""
+
+from types import SimpleNamespace
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.doctor import (
+    FAIL,
+    MIN_FREE_DISK_BYTES,
+    OK,
+    REQUIRED_INDEXES,
+    WARN,
+    check_database,
+    check_grammars,
+    check_providers,
+    check_resources,
+    checks_to_dict,
+)
+from codebase_rag.language_config import LANGUAGE_CONFIGS
+from codebase_rag.services.graph_backends import EmbeddedBackend, Neo4jBackend
+
+
+def fake_ingestor(version="2.18.1", vertices=120, indexes=REQUIRED_INDEXES):
+    ingestor = MagicMock()
+    results = {
+        "SHOW VERSION": [{"version": version}],
+        "SHOW STORAGE INFO": [
+            {"storage info": "vertex_count", "value": vertices},
+            {"storage info": "edge_count", "value": 300},
+        ],
+        "SHOW INDEX INFO": [
+            {"index type": "label+property", "label": label, "property": prop}
+            for label, prop in indexes
+        ],
+    }
+    ingestor.fetch_all.side_effect = lambda query: results[query]
+    return ingestor
+
+
+def models(orchestrator, cypher, **settings):
+    defaults = {
+        "GEMINI_PROVIDER": "gla",
+        "GEMINI_API_KEY": None,
+        "GCP_PROJECT_ID": None,
+        "OPENAI_API_KEY": None,
+        "ANTHROPIC_API_KEY": None,
+        "LOCAL_MODEL_ENDPOINT": "http://localhost:11434/v1",
+    }
+    return SimpleNamespace(
+        active_orchestrator_model=orchestrator,
+        active_cypher_model=cypher,
+        **{**defaults, **settings},
+    )
+
+
+class TestDatabaseChecks:
+    """Test connectivity, version, contents and index checks."""
+
+    def test_healthy_database(self):
+        ingestor = fake_ingestor()
+
+        checks = check_database(ingestor, "localhost", 7687)
+
+        assert [c.status for c in checks] == [OK, OK, OK]
+        assert "2.18.1" in checks[0].detail
+        ingestor.__exit__.assert_called_once()
+
+    def test_connection_failure_explains_how_to_start_memgraph(self):
+        ingestor = MagicMock()
+        ingestor.__enter__.side_effect = ConnectionError("Connection refused")
+
+        checks = check_database(ingestor, "localhost", 7687)
+
+        assert len(checks) == 1
+        assert checks[0].status == FAIL
+        assert "Connection refused" in checks[0].detail
+        assert "docker compose up" in checks[0].fix
+
+    def test_old_version_fails(self):
+        checks = check_database(fake_ingestor(version="1.6.0"), "db", 7687)
+
+        assert checks[0].status == FAIL
+        assert "Upgrade" in checks[0].fix
+
+    def test_empty_graph_and_missing_indexes_warn(self):
+        checks = check_database(
+            fake_ingestor(vertices=0, indexes=REQUIRED_INDEXES[:1]), "db", 7687
+        )
+
+        contents, indexes = checks[1], checks[2]
+        assert contents.status == WARN
+        assert "--update-graph" in contents.fix
+        assert indexes.status == WARN
+        assert ":Function(qualified_name)" in indexes.detail
+        assert ":File(path)" not in indexes.detail
+
+    def test_neo4j_is_checked_with_its_own_statements(self):
+        ingestor = MagicMock()
+        ingestor.backend = Neo4jBackend("bolt://graph:7687", "neo4j", "secret")
+
+        def fetch_all(query):
+            if query.startswith("CALL dbms.components()"):
+                return [{"version": "5.20.0"}]
+            if query.startswith("SHOW INDEXES"):
+                return [
+                    {"labels": [label], "properties": [prop]}
+                    for label, prop in REQUIRED_INDEXES
+                ] + [{"labels": None, "properties": None}]
+            return [{"count": 42}]
+
+        ingestor.fetch_all.side_effect = fetch_all
+
+        checks = check_database(ingestor, "localhost", 7687)
+
+        assert [c.status for c in checks] == [OK, OK, OK]
+        assert checks[0].detail == "Neo4j at bolt://graph:7687, version 5.20.0"
+        assert checks[1].detail == "42 nodes, 42 relationships"
+
+        ingestor.__enter__.side_effect = ConnectionError("Connection refused")
+        (failed,) = check_database(ingestor, "localhost", 7687)
+        assert failed.name == "Neo4j connection"
+        assert "NEO4J_URI" in failed.fix
+
+    def test_embedded_graph_has_no_version_to_check(self):
+        ingestor = MagicMock()
+        ingestor.backend = EmbeddedBackend("/data/graph.db")
+        ingestor.fetch_all.side_effect = lambda query: (
+            [{"labels": ["File"], "properties": ["path", "name"]}]
+            if query.startswith("CALL db.indexes()")
+            else [{"count": 0}]
+        )
+
+        connection, contents, indexes = check_database(ingestor, "localhost", 7687)
+
+        assert connection.detail == "embedded FalkorDB at /data/graph.db"
+        assert contents.status == WARN
+        assert ":File(path)" not in indexes.detail
+        assert ":Module(qualified_name)" in indexes.detail
+
+
+class TestGrammarChecks:
+    """Test installed, missing and broken grammars."""
+
+    def test_missing_and_broken_grammars(self):
+        names = list(LANGUAGE_CONFIGS)
+        loaders = {name: (lambda: "grammar") for name in names}
+        loaders[names[0]] = None
+
+        def broken():
+            raise OSError("incompatible language version 15")
+
+        loaders[names[1]] = broken
+
+        with patch("codebase_rag.doctor.Language"):
+            checks = check_grammars(loaders)
+
+        by_name = {c.name: c for c in checks}
+        assert by_name["Grammars"].status == OK
+        assert by_name["Missing grammars"].status == WARN
+        assert f"tree-sitter-{names[0]}" in by_name["Missing grammars"].fix
+        assert by_name["Broken grammars"].status == FAIL
+        assert "incompatible language version" in by_name["Broken grammars"].detail
+
+    def test_no_grammars_fails(self):
+        checks = check_grammars({})
+
+        assert checks[0].status == FAIL
+        assert "uv sync" in checks[0].fix
+
+
+class TestProviderChecks:
+    """Test credentials per configured model and local endpoint probing."""
+
+    def test_missing_api_key(self):
+        config = models("gpt-4o", "claude-3-5-sonnet-latest", OPENAI_API_KEY="sk")
+
+        orchestrator, cypher = check_providers(config)
+
+        assert orchestrator.status == OK
+        assert cypher.status == FAIL
+        assert "ANTHROPIC_API_KEY" in cypher.detail
+        assert "ANTHROPIC_API_KEY" in cypher.fix
+
+    def test_gemini_vertex_needs_project(self):
+        config = models("gemini-2.5-pro", "gemini-2.5-flash", GEMINI_PROVIDER="vertex")
+
+        checks = check_providers(config)
+
+        assert all(c.status == FAIL for c in checks)
+        assert "GCP_PROJECT_ID" in checks[0].detail
+
+    def test_unreachable_local_endpoint(self):
+        def refuse(url):
+            assert url == "http://localhost:11434/v1/models"
+            raise OSError("Connection refused")
+
+        checks = check_providers(models("llama3", "llama3"), probe=refuse)
+
+        assert [c.status for c in checks] == [FAIL, FAIL]
+        assert "ollama serve" in checks[0].fix
+
+    def test_each_role_probes_its_own_endpoint(self):
+        probed = []
+        config = models(
+            "ollama:qwen2.5-coder:32b",
+            "ollama:qwen2.5-coder:7b",
+            LOCAL_ORCHESTRATOR_ENDPOINT="http://gpu-box:8000/v1",
+        )
+
+        checks = check_providers(config, probe=probed.append)
+
+        assert probed == [
+            "http://gpu-box:8000/v1/models",
+            "http://localhost:11434/v1/models",
+        ]
+        assert [c.status for c in checks] == [OK, OK]
+
+    def test_local_only_refuses_cloud_models(self):
+        config = models(
+            "local:gpt-oss:20b", "gpt-4o-mini", OPENAI_API_KEY="sk", LOCAL_ONLY=True
+        )
+
+        orchestrator, cypher = check_providers(config, probe=lambda url: None)
+
+        assert orchestrator.status == OK
+        assert cypher.status == FAIL
+        assert "LOCAL_ONLY" in cypher.detail
+
+
+class TestResourceChecks:
+    """Test disk and memory headroom thresholds."""
+
+    def test_low_disk_and_memory(self, tmp_path):
+        with (
+            patch("codebase_rag.doctor.shutil.disk_usage") as disk_usage,
+            patch("codebase_rag.doctor.psutil.virtual_memory") as memory,
+        ):
+            disk_usage.return_value = SimpleNamespace(free=MIN_FREE_DISK_BYTES // 4)
+            memory.return_value = SimpleNamespace(available=512 * 1024**2)
+            disk, ram = check_resources(tmp_path)
+
+        assert disk.status == WARN
+        assert "0.5 GiB free" in disk.detail
+        assert ram.status == WARN
+        assert "--workers" in ram.fix
+
+    def test_report_is_unhealthy_only_with_failures(self):
+        ingestor = fake_ingestor(vertices=0)
+        warnings = check_database(ingestor, "db", 7687)
+
+        assert checks_to_dict(warnings)["healthy"] is True
+        failing = check_database(fake_ingestor(version="1.0"), "db", 7687)
+        assert checks_to_dict(failing)["healthy"] is False
@@ -0,0 +1,210 @@
+"""Tests for Go doc comments and README sections as Documentation nodes."""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.go_docs import GoDoc, collect_go_docs
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.parsers.markdown_parser import is_readme, parse_markdown_sections
+
+SOURCE = b"""// Package cart keeps the items a customer is about to order
+// until checkout turns them into an order.
+package cart
+
+// Add puts an item in the cart, merging it with one of the same SKU.
+//
+//go:noinline
+func Add(c *Cart, item Item) {}
+
+func undocumented() {}
+
+// Total is the price of the cart's items,
+/* discounts applied. */
+func (c *Cart) Total() int { return 0 }
+
+// The cart's items are kept in memory.
+
+type Cart struct{}
+
+type (
+    // Item is one product line.
+    Item struct{}
+    // Pricer prices items.
+    Pricer interface{ Price(Item) int }
+)
+"""
+
+README = """[![CI](https://example.com/badge.svg)](https://example.com)
+
+Carts for the shop.
+
+# Cart
+
+## Usage
+
+```sh
+# not a heading
+go get example.com/shop/cart
+```
+
+## Empty
+
+### Design
+Items are merged by SKU.
+"""
+
+
+class TestGoDocs:
+    """Test reading doc comments from Go source."""
+
+    @pytest.fixture
+    def docs(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        return collect_go_docs(parsers["go"].parse(SOURCE).root_node)
+
+    def test_declarations(self, docs):
+        assert [(d.kind, d.name, d.is_interface) for d in docs] == [
+            ("package", "cart", False),
+            ("function", "Add", False),
+            ("method", "Total", False),
+            ("type", "Item", False),
+            ("type", "Pricer", True),
+        ]
+
+    def test_text(self, docs):
+        package, add, total = docs[:3]
+
+        assert package.text.startswith("Package cart keeps the items")
+        assert (package.start_line, package.end_line) == (1, 2)
+        # The directive is not part of the text
+        assert add.text == (
+            "Add puts an item in the cart, merging it with one of the same SKU."
+        )
+        assert total.text == (
+            "Total is the price of the cart's items,\ndiscounts applied."
+        )
+
+
+class TestGoDocIngestion:
+    """Test doc comments becoming Documentation nodes of what they describe."""
+
+    def test_documents(self, tmp_path):
+        updater = GraphUpdater(MagicMock(), tmp_path, {}, {})
+        updater.function_registry["shop.cart.cart.Add"] = "Function"
+        docs = [
+            GoDoc("package", "cart", "Package cart keeps items.", 1, 1),
+            GoDoc("function", "Add", "Add puts an item in the cart.", 3, 3),
+            GoDoc("type", "Pricer", "Pricer prices items.", 6, 6, True),
+            # Not ingested as a function
+            GoDoc("function", "missing", "Gone.", 9, 9),
+        ]
+
+        with patch("codebase_rag.graph_updater.collect_go_docs", return_value=docs):
+            updater._ingest_go_documentation(
+                MagicMock(), "shop.cart.cart", Path("cart/cart.go")
+            )
+
+        documents = {
+            c.args[0][2]: c.args[2]
+            for c in updater.ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "DOCUMENTS"
+        }
+        assert documents == {
+            "shop.cart.cart:doc": ("Folder", "path", "cart"),
+            "shop.cart.cart.Add:doc": (
+                "Function",
+                "qualified_name",
+                "shop.cart.cart.Add",
+            ),
+            "shop.cart.cart.Pricer:doc": (
+                "Interface",
+                "qualified_name",
+                "shop.cart.cart.Pricer",
+            ),
+        }
+
+
+class TestMarkdownSections:
+    """Test splitting READMEs at their headings."""
+
+    def test_is_readme(self):
+        assert is_readme("README.md")
+        assert is_readme("readme")
+        assert is_readme("Readme.markdown")
+        assert not is_readme("README.md.orig")
+        assert not is_readme("readme_test.go")
+
+    def test_sections(self):
+        sections = parse_markdown_sections(README, "README.md")
+
+        assert [(s.title, s.level, s.start_line, s.end_line) for s in sections] == [
+            ("README.md", 0, 1, 4),
+            ("Usage", 2, 7, 13),
+            ("Design", 3, 16, 17),
+        ]
+        # A comment in a code block does not start a section
+        assert "# not a heading" in sections[1].text
+        assert sections[2].text == "### Design\nItems are merged by SKU."
+
+
+class TestReadmeIngestion:
+    """Test README sections becoming Documentation nodes."""
+
+    def test_sections_document_their_directory(self, tmp_path):
+        (tmp_path / "cart").mkdir()
+        (tmp_path / "cart" / "README.md").write_text(README)
+        ingestor = MagicMock()
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+
+        updater._parse_readme(tmp_path / "cart" / "README.md")
+
+        nodes = [c.args[1] for c in ingestor.ensure_node_batch.call_args_list]
+        assert [(n["qualified_name"], n["kind"], n["name"]) for n in nodes] == [
+            ("cart/README.md:1", "readme", "README.md"),
+            ("cart/README.md:7", "readme", "Usage"),
+            ("cart/README.md:16", "readme", "Design"),
+        ]
+        edges = {
+            (c.args[0][0], c.args[1], c.args[2][0])
+            for c in ingestor.ensure_relationship_batch.call_args_list
+        }
+        assert edges == {
+            ("File", "HAS_DOCUMENTATION", "Documentation"),
+            ("Documentation", "DOCUMENTS", "Folder"),
+        }
+        documented = {
+            c.args[2]
+            for c in ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "DOCUMENTS"
+        }
+        assert documented == {updater._module_parent(Path("cart/README.md"))}
+
+    def test_changed_readme_is_parsed_again(self, tmp_path):
+        (tmp_path / "README.md").write_text("# Shop\n\nSells things.\n")
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+
+        updater.update_files(["README.md"], [])
+
+        ingestor.execute_write.assert_any_call(
+            "MATCH (:File {path: $path})-[:HAS_DOCUMENTATION]->(d) DETACH DELETE d",
+            {"path": "README.md"},
+        )
+        ingestor.ensure_node_batch.assert_any_call(
+            "Documentation",
+            {
+                "qualified_name": "README.md:1",
+                "kind": "readme",
+                "name": "Shop",
+                "text": "# Shop\n\nSells things.",
+                "path": "README.md",
+                "start_line": 1,
+                "end_line": 3,
+            },
+        )
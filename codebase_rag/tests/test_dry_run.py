@@ -0,0 +1,186 @@
+"""Tests for dry-run ingestion counts, skipped files and size estimates."""
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.services.dry_run import (
+    NODE_OVERHEAD_BYTES,
+    RELATIONSHIP_OVERHEAD_BYTES,
+    DryRunIngestor,
+    DryRunReport,
+)
+
+
+def _module(path: str) -> dict[str, str]:
+    qualified_name = path.rsplit(".", 1)[0].replace("/", ".")
+    return {"qualified_name": qualified_name, "name": path.split("/")[-1], "path": path}
+
+
+class TestDryRunIngestor:
+    """Test that flushed batches are counted as MERGE would store them."""
+
+    def test_counts_merged_nodes_and_relationships(self):
+        ingestor = DryRunIngestor(batch_size=2)
+        ingestor.ensure_node_batch("Module", {"qualified_name": "shop.cart"})
+        ingestor.ensure_node_batch("Function", {"qualified_name": "shop.cart.total"})
+        # Same key again: MERGE updates the existing node
+        ingestor.ensure_node_batch(
+            "Function", {"qualified_name": "shop.cart.total", "start_line": 3}
+        )
+        for _ in range(2):
+            ingestor.ensure_relationship_batch(
+                ("Module", "qualified_name", "shop.cart"),
+                "DEFINES",
+                ("Function", "qualified_name", "shop.cart.total"),
+            )
+
+        report = ingestor.report()
+
+        assert report.node_counts == {"Function": 1, "Module": 1}
+        assert report.relationship_counts == {"DEFINES": 1}
+        assert report.unresolved_relationships == {}
+
+    def test_relationships_to_unknown_nodes_are_unresolved(self):
+        ingestor = DryRunIngestor()
+        ingestor.ensure_node_batch("Function", {"qualified_name": "shop.cart.total"})
+        ingestor.ensure_relationship_batch(
+            ("Function", "qualified_name", "shop.cart.total"),
+            "CALLS",
+            ("Function", "qualified_name", "vendor.lib.round"),
+        )
+
+        report = ingestor.report()
+
+        assert report.relationship_counts == {}
+        assert report.unresolved_relationships == {"CALLS": 1}
+
+    def test_reads_and_writes_touch_nothing(self):
+        with DryRunIngestor() as ingestor:
+            ingestor.clean_database()
+            ingestor.ensure_constraints()
+            ingestor.execute_write("MATCH (n) DETACH DELETE n")
+            assert ingestor.fetch_all("MATCH (n) RETURN n") == []
+            assert ingestor.conn is None
+            assert ingestor.sinks.sinks == []
+
+    def test_counts_per_language_and_file(self):
+        ingestor = DryRunIngestor()
+        for label, properties in [
+            ("File", {"path": "shop/cart.py", "name": "cart.py"}),
+            ("File", {"path": "web/app.ts", "name": "app.ts"}),
+            ("File", {"path": "web/empty.ts", "name": "empty.ts"}),
+            ("Folder", {"path": "shop", "name": "shop"}),
+            ("Module", _module("shop/cart.py")),
+            ("Module", _module("web/app.ts")),
+            ("Class", {"qualified_name": "shop.cart.Cart"}),
+            ("Method", {"qualified_name": "shop.cart.Cart.total"}),
+            ("Function", {"qualified_name": "web.app.render"}),
+            ("ExternalPackage", {"name": "requests"}),
+        ]:
+            ingestor.ensure_node_batch(label, properties)
+        ingestor.ensure_relationship_batch(
+            ("Class", "qualified_name", "shop.cart.Cart"),
+            "DEFINES_METHOD",
+            ("Method", "qualified_name", "shop.cart.Cart.total"),
+        )
+
+        report = ingestor.report()
+
+        assert report.language_counts == {
+            "python": {"File": 1, "Module": 1, "Class": 1, "Method": 1},
+            "typescript": {"File": 2, "Module": 1, "Function": 1},
+            "other": {"Folder": 1, "ExternalPackage": 1},
+        }
+        assert report.language_relationships == {"python": 1}
+        assert report.file_counts["shop/cart.py"] == {
+            "File": 1,
+            "Module": 1,
+            "Class": 1,
+            "Method": 1,
+        }
+        # Parsed, but nothing found in it
+        assert report.file_counts["web/empty.ts"] == {"File": 1}
+
+    def test_samples(self):
+        ingestor = DryRunIngestor(sample_size=1)
+        for name in ("total", "tax", "total"):
+            ingestor.ensure_node_batch(
+                "Function", {"qualified_name": f"shop.cart.{name}", "name": name}
+            )
+        ingestor.ensure_relationship_batch(
+            ("Function", "qualified_name", "shop.cart.total"),
+            "CALLS",
+            ("Function", "qualified_name", "shop.cart.tax"),
+            {"line_number": 4},
+        )
+
+        report = ingestor.report()
+
+        assert report.node_samples == {
+            "Function": [{"qualified_name": "shop.cart.total", "name": "total"}]
+        }
+        assert report.relationship_samples == {
+            "CALLS": [
+                (
+                    ("Function", "qualified_name", "shop.cart.total"),
+                    "CALLS",
+                    ("Function", "qualified_name", "shop.cart.tax"),
+                    {"line_number": 4},
+                )
+            ]
+        }
+        assert DryRunIngestor().report().node_samples == {}
+
+
+class TestDryRunReport:
+    """Test totals, skip reasons and the size estimate."""
+
+    def test_estimated_bytes(self):
+        report = DryRunReport(
+            node_counts={"File": 2, "Module": 1},
+            relationship_counts={"CONTAINS_FILE": 2},
+            property_bytes=100,
+        )
+
+        assert report.estimated_bytes == (
+            3 * NODE_OVERHEAD_BYTES + 2 * RELATIONSHIP_OVERHEAD_BYTES + 100
+        )
+        assert report.to_dict()["total_nodes"] == 3
+
+    def test_skip_reasons_group_parse_errors(self):
+        report = DryRunReport(
+            skipped_files={
+                "a.py": "parse error: invalid utf-8",
+                "b.py": "parse error: recursion depth",
+                "logo.png": "no parser for .png files",
+            }
+        )
+
+        assert report.skip_reasons() == {
+            "parse error": 2,
+            "no parser for .png files": 1,
+        }
+
+
+class TestGraphUpdaterDryRun:
+    """Test a dry run over a small repository with no grammars loaded."""
+
+    def test_skipped_files_and_counts(self, tmp_path):
+        (tmp_path / "app.py").write_text("def main():\n    pass\n")
+        (tmp_path / "logo.png").write_bytes(b"\x89PNG")
+        (tmp_path / "LICENSE").write_text("MIT")
+        (tmp_path / "node_modules").mkdir()
+        (tmp_path / "node_modules" / "left-pad.js").write_text("")
+
+        ingestor = DryRunIngestor()
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+        updater.run()
+        report = ingestor.report(updater.skipped_files)
+
+        assert report.skipped_files == {
+            "LICENSE": "no parser for files without an extension",
+            "app.py": "no python grammar installed",
+            "logo.png": "no parser for .png files",
+            "node_modules/": "ignored directory",
+        }
+        assert report.node_counts["File"] == 3
+        assert report.node_counts["Project"] == 1
+        assert report.relationship_counts["CONTAINS_FILE"] == 3
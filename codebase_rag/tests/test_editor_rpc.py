@@ -0,0 +1,177 @@
+"""Tests for the editor extension JSON-RPC endpoint."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.lsp.protocol import INVALID_PARAMS, METHOD_NOT_FOUND
+from codebase_rag.lsp.server import EXPLAIN_QUERY
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.editor import (
+    NODE_LOCATION_QUERY,
+    SELECTION_SYMBOLS_QUERY,
+    WorkspaceMap,
+    create_editor_routes,
+)
+
+WORKSPACE = [
+    "/home/dev/src/shop",
+    {"path": "/home/dev/src/shop/vendor/pay", "project": "payments"},
+]
+
+TOTAL = {
+    "qualified_name": "shop.cart.Cart.total",
+    "name": "total",
+    "label": "Method",
+    "module": "shop.cart",
+    "path": "cart.py",
+    "start_line": 12,
+    "end_line": 20,
+}
+CART = {
+    **TOTAL,
+    "qualified_name": "shop.cart.Cart",
+    "name": "Cart",
+    "label": "Class",
+    "start_line": 5,
+    "end_line": 40,
+}
+
+
+def _call(server: GraphServer, method: str, params: dict, token: str = "s3cret"):
+    body = {"jsonrpc": "2.0", "id": 7, "method": method, "params": params}
+    return server.handle(
+        Request(
+            "POST",
+            "/rpc",
+            {"authorization": f"Bearer {token}"},
+            json.dumps(body).encode(),
+        )
+    )
+
+
+def _server(responses: dict[str, list], answerer=None) -> GraphServer:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params: responses.get(query, [])
+    server = GraphServer()
+    create_editor_routes(server, ingestor, answerer, token="s3cret")
+    return server
+
+
+class TestWorkspaceMap:
+    """Test translation between editor paths and graph projects."""
+
+    def test_nested_folders_and_explicit_projects(self):
+        workspace = WorkspaceMap.from_params({"workspaceFolders": WORKSPACE})
+
+        assert workspace.to_graph("/home/dev/src/shop/cart.py") == ("shop", "cart.py")
+        assert workspace.to_graph("/home/dev/src/shop/vendor/pay/api.go") == (
+            "payments",
+            "api.go",
+        )
+        assert workspace.to_graph("/tmp/scratch.py") is None
+        assert (
+            workspace.to_editor("payments.api", "api.go")
+            == "/home/dev/src/shop/vendor/pay/api.go"
+        )
+        assert workspace.to_editor("billing.core", "core.py") is None
+
+
+class TestEditorRpc:
+    """Test the JSON-RPC methods served at /rpc."""
+
+    def test_rejects_wrong_token(self):
+        response = _call(_server({}), "graph/jumpToNode", {}, token="nope")
+        assert response.status == 401
+
+    def test_jump_to_node(self):
+        server = _server({NODE_LOCATION_QUERY: [TOTAL]})
+
+        response = _call(
+            server,
+            "graph/jumpToNode",
+            {"qualifiedName": "shop.cart.Cart.total", "workspaceFolders": WORKSPACE},
+        )
+
+        assert response.status == 200
+        assert response.body["id"] == 7
+        assert response.body["result"] == {
+            "qualifiedName": "shop.cart.Cart.total",
+            "label": "Method",
+            "path": "cart.py",
+            "file": "/home/dev/src/shop/cart.py",
+            "startLine": 11,
+            "endLine": 19,
+        }
+
+    def test_insert_citation_uses_innermost_definition(self):
+        server = _server({SELECTION_SYMBOLS_QUERY: [CART, TOTAL]})
+        params = {
+            "file": "/home/dev/src/shop/cart.py",
+            "line": 14,
+            "workspaceFolders": WORKSPACE,
+        }
+
+        markdown = _call(server, "graph/insertCitation", params).body["result"]
+        plain = _call(server, "graph/insertCitation", {**params, "style": "plain"})
+
+        assert markdown["text"] == "[`shop.cart.Cart.total`](cart.py#L12-L20)"
+        assert plain.body["result"]["text"] == "shop.cart.Cart.total (cart.py:12-20)"
+
+    def test_ask_about_selection(self):
+        prompts = []
+
+        def answerer(prompt: str) -> str:
+            prompts.append(prompt)
+            return "It sums the cart."
+
+        explanation = {
+            "label": "Method",
+            "docstring": "Sum line items.",
+            "complexity": 2,
+            "callers": ["shop.api.checkout"],
+            "callees": [],
+            "tests": [],
+            "endpoints": [],
+        }
+        server = _server(
+            {SELECTION_SYMBOLS_QUERY: [TOTAL], EXPLAIN_QUERY: [explanation]},
+            answerer,
+        )
+
+        response = _call(
+            server,
+            "graph/askAboutSelection",
+            {
+                "file": "/home/dev/src/shop/cart.py",
+                "startLine": 12,
+                "endLine": 14,
+                "text": "return sum(items)",
+                "question": "Is this tested?",
+                "workspaceFolders": WORKSPACE,
+            },
+        )
+
+        result = response.body["result"]
+        assert result["answer"] == "It sums the cart."
+        assert [s["qualifiedName"] for s in result["symbols"]] == [
+            "shop.cart.Cart.total"
+        ]
+        [prompt] = prompts
+        assert "lines 13-15 of /home/dev/src/shop/cart.py" in prompt
+        assert "- Called by: `shop.api.checkout`" in prompt
+        assert prompt.endswith("Is this tested?")
+
+    def test_errors(self):
+        server = _server({})
+
+        unknown = _call(server, "graph/rename", {})
+        missing = _call(server, "graph/jumpToNode", {})
+        outside = _call(
+            server,
+            "graph/insertCitation",
+            {"file": "/tmp/x.py", "line": 1, "workspaceFolders": WORKSPACE},
+        )
+
+        assert unknown.body["error"]["code"] == METHOD_NOT_FOUND
+        assert missing.body["error"]["code"] == INVALID_PARAMS
+        assert "not in a workspace folder" in outside.body["error"]["message"]
@@ -0,0 +1,141 @@
+"""Tests for scoring retrieval against golden questions."""
+
+import asyncio
+from types import SimpleNamespace
+
+import pytest
+
+from codebase_rag.evaluation import (
+    GoldenQuestion,
+    Retrieval,
+    evaluate,
+    load_golden_set,
+    retrieved_nodes,
+    score_answer,
+    score_retrieval,
+)
+
+GOLDEN = """
+questions:
+  - id: retries
+    question: Where do we retry failed calls?
+    expected_nodes: [billing.jobs.wait_and_repeat, billing.jobs.send_invoice]
+    expected_answer: [wait_and_repeat, backoff]
+  - question: What does the checkout total include?
+    expected_answer: tax
+"""
+
+
+class TestGoldenSet:
+    """Test reading golden sets."""
+
+    def test_load(self, tmp_path):
+        path = tmp_path / "golden.yaml"
+        path.write_text(GOLDEN)
+
+        retries, total = load_golden_set(path)
+
+        assert retries.id == "retries"
+        assert retries.expected_nodes[0] == "billing.jobs.wait_and_repeat"
+        assert (total.id, total.expected_nodes, total.expected_answer) == (
+            "2",
+            [],
+            ["tax"],
+        )
+
+    def test_question_expecting_nothing(self, tmp_path):
+        path = tmp_path / "golden.yaml"
+        path.write_text("questions:\n  - question: Why?\n")
+
+        with pytest.raises(ValueError, match="expects neither nodes nor an answer"):
+            load_golden_set(path)
+
+
+class TestScoring:
+    """Test retrieval and answer metrics."""
+
+    def test_score_retrieval(self):
+        expected = ["a.first", "a.second"]
+
+        precision, recall, reciprocal_rank = score_retrieval(
+            expected, ["a.other", "a.second", "a.third", "a.first"], k=2
+        )
+
+        assert (precision, recall, reciprocal_rank) == (0.5, 1.0, 0.5)
+        assert score_retrieval(expected, [], k=5) == (0.0, 0.0, 0.0)
+
+    def test_score_answer(self):
+        assert score_answer("Retries back off in `Wait`.", ["wait", "back off"]) == (
+            True,
+            [],
+        )
+        assert score_answer("It sleeps.", ["backoff"]) == (False, ["backoff"])
+
+    def test_nodes_from_tool_returns(self):
+        def tool_return(content):
+            return SimpleNamespace(part_kind="tool-return", content=content)
+
+        messages = [
+            SimpleNamespace(parts=[SimpleNamespace(part_kind="user-prompt")]),
+            SimpleNamespace(
+                parts=[
+                    tool_return(
+                        [
+                            {"qualified_name": "billing.jobs.settle:chunk2"},
+                            {"omitted": ["caller billing.run (15 tokens)"]},
+                        ]
+                    ),
+                    tool_return(
+                        {
+                            "qualified_name": "billing.jobs.settle",
+                            "related": [{"qualified_name": "billing.jobs.charge"}],
+                        }
+                    ),
+                ]
+            ),
+        ]
+
+        assert retrieved_nodes(messages) == [
+            "billing.jobs.settle",
+            "billing.jobs.charge",
+        ]
+
+
+class TestEvaluate:
+    """Test running golden questions through a pipeline."""
+
+    def test_report(self):
+        questions = [
+            GoldenQuestion("retries", "Retries?", ["jobs.wait"], ["backoff"]),
+            GoldenQuestion("tax", "Tax?", [], ["tax"]),
+            GoldenQuestion("broken", "Broken?", ["jobs.parse"]),
+        ]
+        answers = {
+            "Retries?": Retrieval(["jobs.send", "jobs.wait"], "It uses backoff."),
+            "Tax?": Retrieval([], "No idea."),
+        }
+
+        async def retrieve(question: str) -> Retrieval:
+            if question not in answers:
+                raise RuntimeError("model unavailable")
+            return answers[question]
+
+        report = asyncio.run(evaluate(questions, retrieve, k=5))
+
+        retries, tax, broken = report.results
+        assert (retries.recall, retries.reciprocal_rank, retries.answer_correct) == (
+            1.0,
+            0.5,
+            True,
+        )
+        assert (tax.precision, tax.answer_correct) == (None, False)
+        assert broken.error == "model unavailable"
+        assert report.summary() == {
+            "questions": 3,
+            "k": 5,
+            "precision@5": 0.25,
+            "recall": 0.5,
+            "mrr": 0.25,
+            "answer_accuracy": 0.5,
+            "errors": 1,
+        }
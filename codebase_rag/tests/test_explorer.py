@@ -0,0 +1,165 @@
+"""Tests for the graph explorer's queries, navigation and source preview."""
+
+from pathlib import Path
+from typing import Any
+
+import pytest
+
+from codebase_rag.context_expansion import callees_query, callers_query, tests_query
+from codebase_rag.explorer import (
+    IMPLEMENTATIONS_QUERY,
+    MATCHES,
+    NODE_QUERY,
+    ExplorerNode,
+    ExplorerState,
+    GraphExplorer,
+    context_note,
+)
+from codebase_rag.symbol_search import SYMBOLS_QUERY
+
+SOURCE = """def checkout(cart):
+    total = price(cart)
+    return charge(total)
+
+
+def price(cart):
+    return sum(item.cost for item in cart)
+"""
+
+
+def _row(qn: str, start: int | None = None, end: int | None = None) -> dict:
+    return {
+        "qualified_name": qn,
+        "label": "Function",
+        "hops": 1,
+        "path": "shop/orders.py" if start else None,
+        "start_line": start,
+        "end_line": end,
+    }
+
+
+NODES = {
+    "shop.orders.checkout": _row("shop.orders.checkout", 1, 3),
+    "shop.orders.price": _row("shop.orders.price", 6, 7),
+    "shop.pay.charge": _row("shop.pay.charge"),
+    "tests.test_orders.test_checkout": _row("tests.test_orders.test_checkout"),
+}
+RELATED = {
+    callers_query(1): {"shop.orders.price": ["shop.orders.checkout"]},
+    callees_query(1): {
+        "shop.orders.checkout": ["shop.orders.price", "shop.pay.charge"]
+    },
+    IMPLEMENTATIONS_QUERY: {},
+    tests_query(1): {"shop.orders.checkout": ["tests.test_orders.test_checkout"]},
+}
+
+
+class FakeIngestor:
+    """Answers the explorer's queries from the small graph above."""
+
+    def __init__(self) -> None:
+        self.queries: list[str] = []
+
+    def fetch_all(self, query: str, params: dict[str, Any] | None = None) -> list:
+        self.queries.append(query)
+        if query == SYMBOLS_QUERY:
+            return [{"qualified_name": qn, "label": "Function"} for qn in NODES]
+        assert params is not None
+        name = params["qualified_name"]
+        if query == NODE_QUERY:
+            return [NODES[name]] if name in NODES else []
+        if query == IMPLEMENTATIONS_QUERY:
+            raise RuntimeError("Unknown relationship type")
+        return [NODES[qn] for qn in RELATED[query].get(name, [])]
+
+
+@pytest.fixture
+def explorer(tmp_path: Path) -> GraphExplorer:
+    (tmp_path / "shop").mkdir()
+    (tmp_path / "shop" / "orders.py").write_text(SOURCE)
+    return GraphExplorer(FakeIngestor(), tmp_path)
+
+
+class TestGraphExplorer:
+    """Test the symbols listed around a symbol and the source shown for it."""
+
+    def test_related_by_relation(self, explorer):
+        entries = explorer.related("shop.orders.checkout")
+
+        assert [(e.relation, e.node.qualified_name) for e in entries] == [
+            ("Callees", "shop.orders.price"),
+            ("Callees", "shop.pay.charge"),
+            ("Tests", "tests.test_orders.test_checkout"),
+        ]
+
+    def test_preview(self, explorer):
+        assert explorer.preview(ExplorerNode("shop.orders.price", "Function")) == (
+            "6  def price(cart):\n7      return sum(item.cost for item in cart)"
+        )
+        assert explorer.preview(ExplorerNode("shop.pay.charge", "Function")) == (
+            "No source of shop.pay.charge in the graph."
+        )
+
+    def test_nodes_are_looked_up_once(self, explorer):
+        explorer.related("shop.orders.checkout")
+        explorer.node("shop.orders.price")
+        explorer.node("shop.orders.price")
+
+        assert explorer.ingestor.queries.count(NODE_QUERY) == 0
+
+    def test_context_note(self):
+        assert context_note(
+            ExplorerNode("shop.orders.price", "Function", "shop/orders.py", 6, 7)
+        ) == "(Context: Function shop.orders.price at shop/orders.py:6-7.)"
+        assert context_note(ExplorerNode("shop.pay.charge", "Method")) == (
+            "(Context: Method shop.pay.charge.)"
+        )
+
+
+class TestExplorerState:
+    """Test moving through the graph with the explorer's keys."""
+
+    def test_single_match_is_opened(self, explorer):
+        state = ExplorerState(explorer)
+        state.search("price")
+
+        assert state.current.qualified_name == "shop.orders.price"
+        assert state.selected_node.qualified_name == "shop.orders.checkout"
+
+    def test_many_matches_are_listed(self, explorer):
+        state = ExplorerState(explorer)
+        state.search("checkout")
+
+        assert state.current is None
+        assert [(e.relation, e.node.qualified_name) for e in state.entries] == [
+            (MATCHES, "shop.orders.checkout"),
+            (MATCHES, "tests.test_orders.test_checkout"),
+        ]
+        state.search("nothing")
+        assert state.message == "No symbols match 'nothing'."
+
+    def test_move_enter_and_back(self, explorer):
+        state = ExplorerState(explorer)
+        state.open("shop.orders.checkout")
+
+        state.move(5)
+        assert state.selected_node.qualified_name == "tests.test_orders.test_checkout"
+        state.move(-1)
+        state.enter()
+        assert state.current.qualified_name == "shop.pay.charge"
+        assert state.entries == [] and state.selected_node == state.current
+        assert state.message == "Nothing is related to it."
+
+        state.back()
+        assert state.current.qualified_name == "shop.orders.checkout"
+        state.back()
+        assert state.current.qualified_name == "shop.orders.checkout"
+
+    def test_lines(self, explorer):
+        state = ExplorerState(explorer)
+        state.open("shop.orders.price")
+        text = "".join(fragment[1] for fragment in state.lines())
+
+        assert text.startswith("Function shop.orders.price\n")
+        assert "\nCallers\n  shop.orders.checkout  Function\n" in text
+        assert ("reverse", "  shop.orders.checkout") in state.lines()
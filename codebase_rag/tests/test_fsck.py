@@ -0,0 +1,79 @@
+"""Tests for the graph consistency checks and repairs."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.fsck import (
+    DANGLING_EDGES_QUERY,
+    DELETE_DEFINITIONS_QUERY,
+    DELETE_MODULE_QUERY,
+    MISSING_PROVENANCE_QUERY,
+    STALE_MODULES_QUERY,
+    check_graph,
+    findings_to_dict,
+    repair,
+)
+
+STALE = [{"name": "shop.old", "path": "shop/old.py"}]
+
+
+def fake_ingestor(stale=STALE):
+    def fetch_all(query, params=None):
+        if query == STALE_MODULES_QUERY:
+            return stale
+        if query == DANGLING_EDGES_QUERY:
+            return [
+                {"source": "shop.cart.total", "type": "CALLS", "target": "shop.old.f"}
+            ] * len(stale)
+        if query == MISSING_PROVENANCE_QUERY:
+            return [{"label": "Function", "name": "lost.f"}]
+        if "n:Function" in query:
+            return [{"key": "shop.cart.total", "copies": 2}]
+        return []
+
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = fetch_all
+    return ingestor
+
+
+class TestFsck:
+    """Test what the checks report and what repair deletes."""
+
+    def test_findings(self):
+        findings = check_graph(fake_ingestor())
+
+        assert [(f.name, f.count) for f in findings] == [
+            ("Duplicate keys", 1),
+            ("Modules without a file", 1),
+            ("Edges into deleted files", 1),
+            ("Definitions without a source", 1),
+        ]
+        assert findings[0].samples == ["Function shop.cart.total (2 nodes)"]
+        assert findings[1].samples == ["shop.old (shop/old.py)"]
+        assert findings[2].samples == ["shop.cart.total -[CALLS]-> shop.old.f"]
+        assert [f.repairable for f in findings] == [False, True, True, False]
+
+    def test_samples_are_limited(self):
+        stale = [{"name": f"m{i}", "path": f"m{i}.py"} for i in range(5)]
+
+        finding = check_graph(fake_ingestor(stale), sample_size=2)[1]
+
+        assert finding.count == 5
+        assert finding.samples == ["m0 (m0.py)", "m1 (m1.py)"]
+
+    def test_repair_deletes_definitions_before_module(self):
+        ingestor = fake_ingestor()
+
+        assert repair(ingestor) == 1
+        assert [c.args for c in ingestor.execute_write.call_args_list] == [
+            (DELETE_DEFINITIONS_QUERY, {"name": "shop.old"}),
+            (DELETE_MODULE_QUERY, {"name": "shop.old"}),
+        ]
+
+    def test_consistent_graph(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+
+        result = findings_to_dict(check_graph(ingestor))
+
+        assert result["consistent"] is True
+        assert len(result["findings"]) == 4
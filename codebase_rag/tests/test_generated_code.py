@@ -0,0 +1,130 @@
+"""Tests for recognising generated code and the modes for ingesting it."""
+
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.generated_code import (
+    DEFAULT_GENERATED_PATHS,
+    has_generated_header,
+    is_generated,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.services.dry_run import DryRunIngestor
+
+
+@pytest.fixture
+def stubs(tmp_path):
+    path = tmp_path / "api" / "users_grpc.go"
+    path.parent.mkdir()
+    path.write_text(
+        "// Code generated by protoc-gen-go-grpc. DO NOT EDIT.\n"
+        "// versions:\n//  protoc v4.25.1\n\npackage api\n"
+    )
+    return tmp_path, path
+
+
+def updater_for(repo, mode: str) -> GraphUpdater:
+    return GraphUpdater(
+        MagicMock(),
+        repo,
+        {"go": MagicMock()},
+        {"go": {"config": MagicMock()}},
+        generated_code_mode=mode,
+    )
+
+
+class TestDetection:
+    """Test telling generated files by their header or path."""
+
+    def test_headers(self):
+        assert has_generated_header(
+            b"//go:build linux\n\n// Code generated by stringer -type=Pill; "
+            b"DO NOT EDIT.\n\npackage painkiller\n"
+        )
+        assert has_generated_header(b"# @generated by protoc-gen-python\nimport x\n")
+        assert has_generated_header(b"/**\n * @generated SignedSource<<abc>>\n */\n")
+
+    def test_hand_written(self):
+        # Only a comment before the code counts
+        assert not has_generated_header(
+            b'package docs\n\n// Code generated by hand. DO NOT EDIT.\nvar x = 1\n'
+        )
+        assert not has_generated_header(b"# Do not edit the defaults below\n")
+
+    def test_paths(self, tmp_path):
+        (tmp_path / "cart.go").write_text("package cart\n")
+        patterns = [*DEFAULT_GENERATED_PATHS, "internal/gen/"]
+
+        assert is_generated(tmp_path / "cart.go", "api/users.pb.go", patterns)
+        assert is_generated(tmp_path / "cart.go", "store/mocks/store.go", patterns)
+        assert is_generated(tmp_path / "cart.go", "internal/gen/enum.go", patterns)
+        assert not is_generated(tmp_path / "cart.go", "cart/cart.go", patterns)
+
+
+class TestGeneratedCodeModes:
+    """Test the modes for generated files."""
+
+    def test_skip(self, stubs):
+        repo, path = stubs
+        updater = updater_for(repo, "skip")
+
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(path, "go")
+
+        parse.assert_not_called()
+        assert updater.skipped_files["api/users_grpc.go"] == "generated code"
+
+    def test_declarations_are_tagged(self, stubs):
+        repo, path = stubs
+        updater = updater_for(repo, "declarations")
+
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(path, "go")
+
+        assert parse.call_args.kwargs == {"declarations_only": True}
+        updater.ingestor.tagging_module.assert_called_once_with(
+            f"{repo.name}.api.users_grpc", {"generated": True}
+        )
+
+    def test_full(self, stubs):
+        repo, path = stubs
+        hand_written = repo / "api" / "server.go"
+        hand_written.write_text("package api\n")
+        updater = updater_for(repo, "full")
+
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(path, "go")
+            updater.parse_and_ingest_file(hand_written, "go")
+
+        assert [c.kwargs for c in parse.call_args_list] == [{}, {}]
+        assert updater.ingestor.tagging_module.call_count == 1
+
+
+class TestTagging:
+    """Test that a module's tags reach its own nodes only."""
+
+    def test_module_nodes_are_tagged(self):
+        ingestor = DryRunIngestor(sample_size=10)
+        with ingestor.tagging_module("shop.api.users_pb", {"generated": True}):
+            ingestor.ensure_node_batch("Package", {"qualified_name": "shop.api"})
+            ingestor.ensure_node_batch(
+                "Module", {"qualified_name": "shop.api.users_pb"}
+            )
+            ingestor.ensure_node_batch(
+                "Function", {"qualified_name": "shop.api.users_pb.NewClient"}
+            )
+            ingestor.ensure_node_batch(
+                "Function", {"qualified_name": "shop.api.users_pbx.Other"}
+            )
+        ingestor.ensure_node_batch(
+            "Function", {"qualified_name": "shop.api.users_pb.Later"}
+        )
+
+        tagged = {
+            props["qualified_name"]
+            for _, props in ingestor.node_buffer
+            if props.get("generated")
+        }
+
+        assert tagged == {"shop.api.users_pb", "shop.api.users_pb.NewClient"}
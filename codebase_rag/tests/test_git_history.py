@@ -0,0 +1,140 @@
+"""Tests for loading commit history onto files and functions."""
+
+from datetime import datetime
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.git_history import GitHistoryLoader
+from codebase_rag.analysis.review import SYMBOLS_IN_PATHS_QUERY
+from codebase_rag.version_control.git_analyzer import BlameInfo, CommitInfo
+
+ANN = ("Ann", "ann@example.com")
+BOB = ("Bob", "bob@example.com")
+
+
+def _commit(sha: str, author: tuple[str, str], day: int, files: list[str]):
+    return CommitInfo(
+        sha=sha,
+        author=author[0],
+        author_email=author[1],
+        committer=author[0],
+        committer_email=author[1],
+        message=f"Change {sha}",
+        date=datetime(2024, 5, day),
+        files_changed=files,
+        additions=0,
+        deletions=0,
+        parent_shas=[],
+    )
+
+
+def _blame(line: int, sha: str, author: tuple[str, str], day: int) -> BlameInfo:
+    return BlameInfo(line, sha, *author, datetime(2024, 5, day), "", line)
+
+
+SYMBOLS = [
+    {
+        "qualified_name": "shop.cart.total",
+        "label": "Function",
+        "path": "shop/cart.py",
+        "start_line": 1,
+        "end_line": 4,
+    },
+    {
+        "qualified_name": "shop.cart.Cart.add",
+        "label": "Method",
+        "path": "shop/cart.py",
+        "start_line": 6,
+        "end_line": 7,
+    },
+]
+
+
+def _loader() -> tuple[GitHistoryLoader, MagicMock, MagicMock]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {
+        SYMBOLS_IN_PATHS_QUERY: SYMBOLS
+    }[query]
+    git_analyzer = MagicMock(repo_path="/src/shop")
+    git_analyzer.get_blame_info.return_value = [
+        # An older commit outside the loaded window
+        _blame(1, "old", ANN, 1),
+        _blame(2, "c1", ANN, 2),
+        _blame(3, "c2", BOB, 3),
+        _blame(4, "c2", BOB, 3),
+        _blame(6, "c1", ANN, 2),
+        _blame(7, "c1", ANN, 2),
+    ]
+    return GitHistoryLoader(ingestor, git_analyzer), ingestor, git_analyzer
+
+
+class TestGitHistoryLoader:
+    """Test Commit, Author and MODIFIED edges from history and blame."""
+
+    def test_commits_and_authors(self):
+        loader, ingestor, git_analyzer = _loader()
+        commits = [
+            _commit("c2", BOB, 3, ["shop/cart.py"]),
+            _commit("c1", ANN, 2, ["shop/cart.py", "README.md"]),
+        ]
+
+        stats = loader.load(commits)
+
+        ingestor.ensure_node_batch.assert_any_call(
+            "Author", {"email": "bob@example.com", "name": "Bob"}
+        )
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Commit", "sha", "c2"),
+            "AUTHORED_BY",
+            ("Author", "email", "bob@example.com"),
+            {"date": "2024-05-03T00:00:00"},
+        )
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Commit", "sha", "c1"),
+            "MODIFIED",
+            ("File", "path", "README.md"),
+            {"date": "2024-05-02T00:00:00"},
+        )
+        git_analyzer.get_blame_info.assert_called_once_with("/src/shop/shop/cart.py")
+        assert stats == {
+            "commits": 2,
+            "authors": 2,
+            "file_links": 3,
+            "function_links": 3,
+            "functions": 2,
+        }
+
+    def test_blamed_functions(self):
+        loader, ingestor, _ = _loader()
+
+        loader.load([_commit("c1", ANN, 2, ["shop/cart.py"])])
+
+        function_edges = [
+            (c.args[0][2], c.args[2][2], c.args[3])
+            for c in ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "MODIFIED" and c.args[2][0] != "File"
+        ]
+        # Only loaded commits get edges
+        assert function_edges == [
+            ("c1", "shop.cart.total", {"lines": 1}),
+            ("c1", "shop.cart.Cart.add", {"lines": 2}),
+        ]
+        ingestor.ensure_node_batch.assert_any_call(
+            "Function",
+            {
+                "qualified_name": "shop.cart.total",
+                "last_modified_by": "Bob",
+                "last_modified_email": "bob@example.com",
+                "last_modified_at": "2024-05-03T00:00:00",
+                "last_commit_sha": "c2",
+                "author_count": 2,
+            },
+        )
+
+    def test_clears_earlier_edges(self):
+        loader, ingestor, git_analyzer = _loader()
+
+        loader.load([])
+
+        ingestor.execute_write.assert_called_once()
+        git_analyzer.get_blame_info.assert_not_called()
+        ingestor.flush_all.assert_called_once()
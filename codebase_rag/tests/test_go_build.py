@@ -0,0 +1,142 @@
+"""Tests for Go build constraints and build-configuration aware ingestion."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_build import (
+    BuildConfig,
+    build_constraint,
+    constraint_terms,
+    file_name_constraint,
+    header_constraint,
+    platforms,
+)
+from codebase_rag.graph_updater import GraphUpdater
+
+
+class TestConstraints:
+    """Test reading constraints from file names and build lines."""
+
+    @pytest.mark.parametrize(
+        "file_name, expected",
+        [
+            ("file_linux.go", "linux"),
+            ("file_windows_amd64.go", "windows && amd64"),
+            ("file_arm64_test.go", "arm64"),
+            # Everything before the first underscore is the file's own name
+            ("linux_amd64.go", "amd64"),
+            ("linux.go", ""),
+            ("file_other.go", ""),
+        ],
+    )
+    def test_file_names(self, file_name, expected):
+        assert file_name_constraint(file_name) == expected
+
+    def test_build_lines(self):
+        go_build = "// Copyright\n\n//go:build linux || darwin\n\npackage fs\n"
+        plus_build = "// +build linux,cgo darwin\n// +build !nocgo\n\npackage fs\n"
+        after_package = "package fs\n\n//go:build linux\n"
+
+        assert header_constraint(go_build) == "linux || darwin"
+        assert header_constraint(plus_build) == (
+            "(linux && cgo || darwin) && !nocgo"
+        )
+        assert header_constraint(after_package) == ""
+        assert build_constraint("fs_amd64.go", go_build) == (
+            "amd64 && (linux || darwin)"
+        )
+
+    def test_terms_and_platforms(self):
+        assert constraint_terms("(linux || darwin) && !cgo && go1.21") == (
+            ["darwin", "linux"],
+            [],
+            ["cgo", "go1.21"],
+        )
+        assert platforms("unix && !arm64") == [
+            "linux/amd64",
+            "linux/386",
+            "linux/arm",
+            "darwin/amd64",
+            "freebsd/amd64",
+        ]
+
+
+class TestBuildConfig:
+    """Test evaluating constraints for a build configuration."""
+
+    def test_satisfies(self):
+        linux = BuildConfig("linux", "amd64")
+        tagged = BuildConfig("windows", "arm64", frozenset({"integration"}))
+
+        assert linux.satisfies("linux && !windows")
+        assert linux.satisfies("unix && go1.21")
+        assert not linux.satisfies("integration")
+        assert tagged.satisfies("integration && (windows || plan9)")
+        assert not tagged.satisfies("unix")
+        assert BuildConfig("android", "arm64").satisfies("linux")
+        assert str(tagged) == "windows/arm64 tags=integration"
+
+    @pytest.mark.parametrize("expression", ["linux &&", "(linux", "linux darwin"])
+    def test_invalid(self, expression):
+        with pytest.raises(ValueError):
+            BuildConfig().satisfies(expression)
+
+
+class TestIngestion:
+    """Test skipping excluded files and linking platform variants."""
+
+    def test_excluded_file_is_skipped(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "open_windows.go").write_text("package fs\n")
+        (temp_repo / "open_linux.go").write_text("//go:build !nofs\n\npackage fs\n")
+        updater = GraphUpdater(
+            mock_ingestor, temp_repo, {}, {}, build_config=BuildConfig()
+        )
+
+        excluded = updater._go_build_constraint(
+            temp_repo / "open_windows.go", b"package fs\n"
+        )
+
+        assert excluded is None
+        assert updater.skipped_files == {"open_windows.go": "not built for linux/amd64"}
+        assert (
+            updater._go_build_constraint(
+                temp_repo / "open_linux.go", b"//go:build !nofs\n\npackage fs\n"
+            )
+            == "linux && !nofs"
+        )
+
+    def test_variants(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater._ingest_build_constraint("shop.fs.open_linux", "linux")
+        updater._ingest_build_constraint("shop.fs.open_windows", "windows")
+        updater.function_registry.update(
+            {
+                "shop.fs.open_linux.open": "Function",
+                "shop.fs.open_windows.open": "Function",
+                "shop.fs.open_windows.longPath": "Function",
+                "shop.fs.fs.open": "Function",
+            }
+        )
+
+        updater._link_build_variants()
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "BuildConstraint",
+            {
+                "expression": "windows",
+                "goos": ["windows"],
+                "goarch": [],
+                "tags": [],
+                "platforms": ["windows/amd64", "windows/arm64", "windows/386"],
+            },
+        )
+        variant_edges = [
+            (c.args[0][2], c.args[2][2])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "VARIANT_OF"
+        ]
+        assert variant_edges == [
+            ("shop.fs.open_linux.open", "shop.fs.open_windows.open")
+        ]
@@ -0,0 +1,146 @@
+"""Tests for loading Go cover profiles into the graph."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.code_locator import FUNCTIONS_QUERY
+from codebase_rag.analysis.go_coverage import (
+    GO_TEST_FUNCTIONS_QUERY,
+    CoverageBlock,
+    CoverageLoader,
+    collect_profiles,
+    parse_cover_profile,
+)
+
+FUNCTIONS = [
+    {
+        "qualified_name": "shop.calc.calc.Add",
+        "name": "Add",
+        "label": "Function",
+        "path": "calc/calc.go",
+        "start_line": 3,
+        "end_line": 8,
+    },
+    {
+        "qualified_name": "shop.calc.calc.Div",
+        "name": "Div",
+        "label": "Function",
+        "path": "calc/calc.go",
+        "start_line": 10,
+        "end_line": 15,
+    },
+]
+TESTS = [
+    {"qualified_name": "shop.calc.calc_test.TestAdd", "name": "TestAdd"},
+    {"qualified_name": "shop.money.money_test.TestAdd", "name": "TestAdd"},
+]
+
+ADD_PROFILE = """mode: set
+example.com/shop/calc/calc.go:3.26,4.12 1 1
+example.com/shop/calc/calc.go:4.12,6.3 1 0
+example.com/shop/calc/calc.go:7.2,7.14 1 1
+example.com/shop/calc/calc.go:10.26,11.12 2 0
+example.com/shop/other/other.go:1.1,2.2 1 1
+"""
+DIV_PROFILE = """mode: set
+example.com/shop/calc/calc.go:3.26,4.12 1 0
+example.com/shop/calc/calc.go:4.12,6.3 1 1
+example.com/shop/calc/calc.go:7.2,7.14 1 0
+example.com/shop/calc/calc.go:10.26,11.12 2 1
+"""
+
+
+def _loader() -> tuple[CoverageLoader, MagicMock]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {
+        FUNCTIONS_QUERY: FUNCTIONS,
+        GO_TEST_FUNCTIONS_QUERY: TESTS,
+    }[query]
+    return CoverageLoader(ingestor), ingestor
+
+
+class TestProfileParsing:
+    """Test reading cover profiles."""
+
+    def test_parse(self):
+        profile = parse_cover_profile(
+            "mode: atomic\n"
+            "example.com/shop/calc/calc.go:3.26,4.12 1 0\n"
+            "example.com/shop/calc/calc.go:3.26,4.12 1 5\n"
+            "not a block\n"
+        )
+
+        assert profile.mode == "atomic"
+        # Listed once per test binary with -coverpkg; the highest count wins
+        assert profile.blocks == [
+            CoverageBlock("example.com/shop/calc/calc.go", 3, 26, 4, 12, 1, 5)
+        ]
+
+    def test_collect_profiles(self, temp_repo: Path):
+        (temp_repo / "cover").mkdir()
+        (temp_repo / "cover" / "TestAdd.out").write_text("mode: set\n")
+        (temp_repo / "cover" / "notes.txt").write_text("")
+
+        assert collect_profiles([temp_repo]) == [temp_repo / "cover" / "TestAdd.out"]
+
+
+class TestCoverageLoader:
+    """Test coverage properties on functions and COVERS edges from tests."""
+
+    def test_function_coverage(self):
+        loader, ingestor = _loader()
+
+        stats = loader.load(
+            [parse_cover_profile(ADD_PROFILE), parse_cover_profile(DIV_PROFILE)]
+        )
+
+        # Blocks covered by either profile count once
+        ingestor.ensure_node_batch.assert_any_call(
+            "Function",
+            {
+                "qualified_name": "shop.calc.calc.Add",
+                "coverage_percent": 100.0,
+                "covered_statements": 3,
+                "total_statements": 3,
+            },
+        )
+        ingestor.ensure_node_batch.assert_any_call(
+            "Function",
+            {
+                "qualified_name": "shop.calc.calc.Div",
+                "coverage_percent": 100.0,
+                "covered_statements": 2,
+                "total_statements": 2,
+            },
+        )
+        assert stats["functions"] == 2
+        assert stats["unmatched_blocks"] == 1
+        assert stats["covers"] == 0
+        ingestor.ensure_relationship_batch.assert_not_called()
+
+    def test_covers_edges_of_single_test_profile(self):
+        loader, ingestor = _loader()
+
+        stats = loader.load([parse_cover_profile(ADD_PROFILE, test="TestAdd")])
+
+        # TestAdd exists in two packages; the one in calc covers calc.go
+        ingestor.execute_write.assert_called_once()
+        assert ingestor.execute_write.call_args.args[1] == {
+            "qualified_name": "shop.calc.calc_test.TestAdd"
+        }
+        ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("TestFunction", "qualified_name", "shop.calc.calc_test.TestAdd"),
+            "COVERS",
+            ("Function", "qualified_name", "shop.calc.calc.Add"),
+            {"covered_statements": 2, "coverage_percent": 66.7},
+        )
+        assert stats["covers"] == 1
+
+    def test_unknown_test(self):
+        loader, ingestor = _loader()
+
+        stats = loader.load([parse_cover_profile(ADD_PROFILE, test="TestMissing")])
+
+        assert stats["unmatched_tests"] == 1
+        ingestor.execute_write.assert_not_called()
+        ingestor.ensure_relationship_batch.assert_not_called()
@@ -0,0 +1,125 @@
+"""Tests for //go:generate and //go:embed directives."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_directives import (
+    GoDirective,
+    collect_directives,
+    embedded_files,
+    generated_by,
+    generator_program,
+)
+from codebase_rag.graph_updater import GraphUpdater
+
+SOURCE = """package pill
+
+import "embed"
+
+//go:generate stringer -type=Pill
+//go:generate go run ./gen -out tables.go
+
+type Pill int
+
+// Templates of the web UI.
+//
+//go:embed templates/*.html "static files" `all:assets`
+var content embed.FS
+"""
+
+
+class TestDirectives:
+    """Test reading directives and generated-code headers."""
+
+    def test_collect(self):
+        assert collect_directives(SOURCE) == [
+            GoDirective("generate", 5, command="stringer -type=Pill"),
+            GoDirective("generate", 6, command="go run ./gen -out tables.go"),
+            GoDirective(
+                "embed",
+                12,
+                patterns=["templates/*.html", "static files", "all:assets"],
+                variable="content",
+            ),
+        ]
+
+    @pytest.mark.parametrize(
+        "command, program",
+        [
+            ("stringer -type=Pill", "stringer"),
+            ("go run ./gen -out tables.go", "gen"),
+            ("go run gen.go", "gen"),
+            ("go run golang.org/x/tools/cmd/stringer@v0.20.0 -type=X", "stringer"),
+            ("go tool mockgen -source=repo.go", "mockgen"),
+        ],
+    )
+    def test_generator_program(self, command, program):
+        assert generator_program(command) == program
+
+    def test_generated_by(self):
+        generated = (
+            "// Code generated by stringer -type=Pill; DO NOT EDIT.\n\npackage pill\n"
+        )
+        late = "package pill\n\n// Code generated by hand; DO NOT EDIT.\n"
+
+        assert generated_by(generated) == "by stringer -type=Pill;"
+        assert generated_by(late) is None
+        assert generated_by(SOURCE) is None
+
+    def test_embedded_files(self, temp_repo: Path):
+        for name in ["templates/index.html", "templates/x.txt", "assets/.keep"]:
+            (temp_repo / name).parent.mkdir(parents=True, exist_ok=True)
+            (temp_repo / name).write_text("")
+        (temp_repo / "static").mkdir()
+        (temp_repo / "static" / "app.js").write_text("")
+        (temp_repo / "static" / "_draft.js").write_text("")
+
+        found = embedded_files(temp_repo, ["templates/*.html", "static", "all:assets"])
+
+        assert [path.relative_to(temp_repo).as_posix() for path in found] == [
+            "assets/.keep",
+            "static/app.js",
+            "templates/index.html",
+        ]
+
+
+class TestDirectiveGraph:
+    """Test the Directive nodes and their edges to files."""
+
+    def test_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "pill.go").write_text(SOURCE)
+        (temp_repo / "pill_string.go").write_text(
+            '// Code generated by "stringer -type=Pill"; DO NOT EDIT.\n\n'
+            "package pill\n"
+        )
+        (temp_repo / "handwritten.go").write_text("package pill\n")
+        (temp_repo / "templates").mkdir()
+        (temp_repo / "templates" / "index.html").write_text("")
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+
+        updater._ingest_go_directives(
+            temp_repo / "pill.go", SOURCE.encode(), "pill.pill"
+        )
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Directive",
+            {
+                "qualified_name": "pill.pill:go:embed:12",
+                "kind": "embed",
+                "line_number": 12,
+                "command": "",
+                "patterns": ["templates/*.html", "static files", "all:assets"],
+                "variable": "content",
+            },
+        )
+        edges = {
+            (c.args[0][2], c.args[1], c.args[2][2])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] != "HAS_DIRECTIVE"
+        }
+        assert edges == {
+            ("pill.pill:go:generate:5", "GENERATES", "pill_string.go"),
+            ("pill.pill:go:embed:12", "EMBEDS", "templates/index.html"),
+        }
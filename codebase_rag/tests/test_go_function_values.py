@@ -0,0 +1,191 @@
+"""Tests for Go functions passed, stored and returned as values."""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.function_values import (
+    FunctionValue,
+    GoFunctionValues,
+    collect_function_values,
+)
+from codebase_rag.analysis.go_init import GoPackageFacts
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+SOURCE = b"""package orders
+
+func byTotal(a, b int) bool { return a < b }
+
+func Sorted(items []Order, less func(a, b Order) bool) []Order {
+    sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+    return items
+}
+
+func Routes(s *Server) Handler {
+    mux.Handle("/orders", auth.Wrap(s.list))
+    check := validate
+    check()
+    h := Handler{OnError: logError}
+    byTotal := 3
+    use(byTotal)
+    return h.serve
+}
+"""
+
+
+class TestCollection:
+    """Test finding function values in Go source."""
+
+    @pytest.fixture
+    def functions(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        root = parsers["go"].parse(SOURCE).root_node
+        return {
+            c.child_by_field_name("name").text.decode(): c
+            for c in root.named_children
+            if c.type == "function_declaration"
+        }
+
+    def test_called_parameters(self, functions):
+        facts = collect_function_values(functions["Sorted"])
+
+        assert facts.called_parameters == {1: "less"}
+
+    def test_values(self, functions):
+        facts = collect_function_values(functions["Routes"])
+        values = {(v.name, v.use, v.callee, v.position, v.target) for v in facts.values}
+
+        assert ("s.list", "argument", "auth.Wrap", 0, "") in values
+        assert ("validate", "assignment", "", 0, "check") in values
+        assert ("logError", "field", "", 0, "OnError") in values
+        assert ("h.serve", "return", "", 0, "") in values
+        assert "check" in facts.called_names
+        # The local shadows the package's byTotal
+        assert "byTotal" in facts.local_names
+
+
+class TestLinking:
+    """Test REFERENCES and synthetic CALLS edges to function values."""
+
+    @pytest.fixture
+    def updater(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        for qn in (
+            "shop.orders.sort.byTotal",
+            "shop.orders.sort.Sorted",
+            "shop.orders.errors.logError",
+            "shop.auth.auth.Wrap",
+        ):
+            updater.function_registry[qn] = "Function"
+            updater.simple_name_lookup[qn.rsplit(".", 1)[1]].add(qn)
+        updater.go_file_imports["shop.orders.routes"] = {
+            "sort": "sort",
+            "auth": "shop/auth",
+        }
+        # Outside any Go module, a package's import path is its directory
+        updater.go_packages["shop.auth"] = GoPackageFacts("shop/auth")
+        return updater
+
+    def ingest(self, updater: GraphUpdater, facts: GoFunctionValues) -> None:
+        with patch(
+            "codebase_rag.graph_updater.collect_function_values", return_value=facts
+        ):
+            updater._ingest_go_function_values(
+                MagicMock(),
+                "shop.orders.routes.Routes",
+                "Function",
+                "shop.orders.routes",
+                {},
+            )
+
+    def test_values_passed_to_the_standard_library(
+        self, updater: GraphUpdater, mock_ingestor: MagicMock
+    ):
+        self.ingest(
+            updater,
+            GoFunctionValues(
+                values=[
+                    FunctionValue("byTotal", 4, "argument", "sort.Slice", 1),
+                    FunctionValue("logError", 5, "field", target="OnError"),
+                    FunctionValue("Unknown", 6, "return"),
+                ]
+            ),
+        )
+
+        routes = ("Function", "qualified_name", "shop.orders.routes.Routes")
+        by_total = ("Function", "qualified_name", "shop.orders.sort.byTotal")
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert edges == [
+            (routes, "REFERENCES", by_total, {"line_number": 4, "use": "argument"}),
+            (routes, "CALLS", by_total, {"synthetic": True, "via": "sort.Slice"}),
+            (
+                routes,
+                "REFERENCES",
+                ("Function", "qualified_name", "shop.orders.errors.logError"),
+                {"line_number": 5, "use": "field"},
+            ),
+        ]
+
+    def test_shadowed_names_are_not_functions(
+        self, updater: GraphUpdater, mock_ingestor: MagicMock
+    ):
+        self.ingest(
+            updater,
+            GoFunctionValues(
+                values=[FunctionValue("byTotal", 4, "argument", "use", 0)],
+                local_names={"byTotal"},
+            ),
+        )
+
+        mock_ingestor.ensure_relationship_batch.assert_not_called()
+
+    def test_values_called_through_a_local(
+        self, updater: GraphUpdater, mock_ingestor: MagicMock
+    ):
+        self.ingest(
+            updater,
+            GoFunctionValues(
+                values=[FunctionValue("byTotal", 4, "assignment", target="check")],
+                called_names={"check"},
+                local_names={"check"},
+            ),
+        )
+
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert edges[-1][1:] == (
+            "CALLS",
+            ("Function", "qualified_name", "shop.orders.sort.byTotal"),
+            {"synthetic": True, "via": "check"},
+        )
+
+    def test_values_passed_to_functions_calling_their_parameter(
+        self, updater: GraphUpdater, mock_ingestor: MagicMock
+    ):
+        updater.go_called_parameters["shop.auth.auth.Wrap"] = {0: "next"}
+        self.ingest(
+            updater,
+            GoFunctionValues(
+                values=[
+                    FunctionValue("logError", 4, "argument", "auth.Wrap", 0),
+                    FunctionValue("byTotal", 5, "argument", "auth.Wrap", 1),
+                ]
+            ),
+        )
+        mock_ingestor.reset_mock()
+
+        updater._link_callback_arguments()
+
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert edges == [
+            (
+                ("Function", "qualified_name", "shop.auth.auth.Wrap"),
+                "CALLS",
+                ("Function", "qualified_name", "shop.orders.errors.logError"),
+                {"synthetic": True, "via": "next"},
+            )
+        ]
+        assert updater.pending_callback_arguments == []
@@ -0,0 +1,146 @@
+"""Tests for Go type parameters and generic instantiations."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_generics import (
+    GoInstantiation,
+    GoTypeParameter,
+    collect_generic_definitions,
+    collect_instantiations,
+)
+from codebase_rag.analysis.go_interfaces import GoType
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+SOURCE = b"""package collections
+
+type Number interface {
+    ~int | ~float64
+}
+
+type Stack[T any] struct {
+    items []T
+}
+
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+func Map[K comparable, V, R any](m map[K]V, f func(V) R) map[K]R {
+    return nil
+}
+
+func Sum[N Number](values ...N) N { return 0 }
+
+func Totals(orders map[string]Order) map[string]float64 {
+    stack := Stack[Order]{}
+    ids := Map[string, Order, string](orders, id)
+    return Map(orders, total)
+}
+"""
+
+
+class TestCollection:
+    """Test reading type parameters and instantiations from Go source."""
+
+    @pytest.fixture
+    def root(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        return parsers["go"].parse(SOURCE).root_node
+
+    def test_definitions(self, root):
+        definitions = {d.name: d for d in collect_generic_definitions(root)}
+
+        # Number has a type set but no type parameters: not generic
+        assert set(definitions) == {"Stack", "Map", "Sum"}
+        assert not definitions["Stack"].is_function
+        assert definitions["Map"].parameters == [
+            GoTypeParameter("K", "comparable", 0),
+            GoTypeParameter("V", "any", 1),
+            GoTypeParameter("R", "any", 2),
+        ]
+        assert definitions["Sum"].parameters[0].constraint_name == "Number"
+        assert GoTypeParameter("T", "~int | ~string", 0).constraint_name is None
+
+    def test_instantiations(self, root):
+        functions = {
+            c.child_by_field_name("name").text.decode(): c
+            for c in root.named_children
+            if c.type in ("function_declaration", "method_declaration")
+        }
+
+        totals = [
+            (i.name, i.type_arguments)
+            for i in collect_instantiations(functions["Totals"])
+        ]
+
+        assert ("Stack", ["Order"]) in totals
+        assert ("Map", ["string", "Order", "string"]) in totals
+        assert ("Map", []) in totals
+        # A method's receiver names the type's own parameter
+        assert ("Stack", ["T"]) not in [
+            (i.name, i.type_arguments)
+            for i in collect_instantiations(functions["Push"])
+        ]
+
+
+class TestLinking:
+    """Test CONSTRAINED_BY and INSTANTIATES edges across packages."""
+
+    def test_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_method_sets.add_file(
+            "shop.collections",
+            "shop.collections.numbers",
+            [GoType("Number", True, 1, 3)],
+            [],
+        )
+        updater.go_generic_definitions["shop.collections"] = {
+            "Map": ("Function", "shop.collections.maps.Map"),
+            "Sum": ("Function", "shop.collections.numbers.Sum"),
+        }
+        updater.pending_type_constraints = [
+            ("shop.collections.numbers.Sum.N", "shop.collections", "Number"),
+            ("shop.collections.maps.Map.K", "shop.collections", "comparable"),
+            ("shop.collections.maps.Map.V", "shop.collections", "Unknown"),
+        ]
+        updater.pending_instantiations = [
+            (
+                "Function",
+                "shop.orders.orders.Totals",
+                "shop.orders",
+                GoInstantiation("collections.Map", ["string", "Order"], 7),
+            ),
+            (
+                "Function",
+                "shop.orders.orders.Totals",
+                "shop.orders",
+                GoInstantiation("total", [], 8),
+            ),
+        ]
+
+        updater._link_go_generics()
+
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert edges == [
+            (
+                ("TypeParameter", "qualified_name", "shop.collections.numbers.Sum.N"),
+                "CONSTRAINED_BY",
+                ("Interface", "qualified_name", "shop.collections.numbers.Number"),
+            ),
+            (
+                ("TypeParameter", "qualified_name", "shop.collections.maps.Map.K"),
+                "CONSTRAINED_BY",
+                ("Interface", "qualified_name", "comparable"),
+            ),
+            (
+                ("Function", "qualified_name", "shop.orders.orders.Totals"),
+                "INSTANTIATES",
+                ("Function", "qualified_name", "shop.collections.maps.Map"),
+                {"type_arguments": "string, Order", "inferred": False, "line_number": 7},
+            ),
+        ]
+        assert updater.pending_instantiations == []
@@ -0,0 +1,189 @@
+"""Tests for linking calls through generated gRPC clients to their handlers."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.go_grpc import GrpcService, collect_grpc_services
+from codebase_rag.analysis.go_interfaces import GoMethod, GoType, MethodSets
+from codebase_rag.graph_updater import GraphUpdater
+
+GENERATED = """package pb
+
+func (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error) {
+	out := new(GetUserResponse)
+	err := c.cc.Invoke(ctx, UserService_GetUser_FullMethodName, in, out, opts...)
+	return out, err
+}
+
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "users.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _UserService_GetUser_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUsers",
+			Handler:       _UserService_WatchUsers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "users/v1/users.proto",
+}
+"""
+
+SERVICE = GrpcService(
+    "users.v1.UserService", "UserServiceServer", ["GetUser"], ["WatchUsers"]
+)
+GET_USER = "(Context,*GetUserRequest)*GetUserResponse,error"
+WATCH_USERS = "(*WatchUsersRequest,UserService_WatchUsersServer)error"
+
+
+def _method_sets() -> MethodSets:
+    method_sets = MethodSets()
+    method_sets.add_file(
+        "shop.pb",
+        "shop.pb.users_grpc_pb",
+        [
+            GoType(
+                "UserServiceServer",
+                True,
+                1,
+                4,
+                methods={"GetUser": GET_USER, "WatchUsers": WATCH_USERS},
+            ),
+            GoType("UnimplementedUserServiceServer", False, 5, 5),
+        ],
+        [
+            GoMethod("UnimplementedUserServiceServer", "GetUser", GET_USER, False),
+            GoMethod(
+                "UnimplementedUserServiceServer", "WatchUsers", WATCH_USERS, False
+            ),
+        ],
+    )
+    # Implements GetUser and leaves WatchUsers to the embedded server
+    method_sets.add_file(
+        "shop.users",
+        "shop.users.server",
+        [
+            GoType(
+                "server",
+                False,
+                1,
+                3,
+                embedded=[("pb.UnimplementedUserServiceServer", False)],
+            )
+        ],
+        [GoMethod("server", "GetUser", GET_USER, True)],
+    )
+    return method_sets
+
+
+class TestGrpcServices:
+    """Test reading services from generated code."""
+
+    def test_collect(self):
+        [service] = collect_grpc_services(GENERATED)
+
+        assert service == SERVICE
+        assert service.client == "UserServiceClient"
+        assert service.rpc_name("GetUser") == "/users.v1.UserService/GetUser"
+        assert service.has_rpc("WatchUsers") and not service.has_rpc("Invoke")
+
+    def test_no_service(self):
+        assert collect_grpc_services("package pb\n\ntype Empty struct{}\n") == []
+
+
+class TestGrpcIngestion:
+    """Test RpcMethod nodes and the edges from callers and handlers."""
+
+    def test_rpc_methods(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+
+        updater._ingest_grpc_services([SERVICE], "shop.pb.users_grpc_pb")
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "RpcMethod",
+            {
+                "qualified_name": "/users.v1.UserService/WatchUsers",
+                "name": "WatchUsers",
+                "service": "users.v1.UserService",
+                "streaming": True,
+            },
+        )
+        assert updater.grpc_clients == {
+            "shop.pb.users_grpc_pb.UserServiceClient": SERVICE
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("ProtoService", "qualified_name", "users.v1.UserService"),
+            "HAS_RPC",
+            ("RpcMethod", "qualified_name", "/users.v1.UserService/GetUser"),
+        )
+        declared = {
+            (call.args[0][2], call.args[3]["method"])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == "DECLARES_RPC"
+        }
+        assert declared == {
+            (f"shop.pb.users_grpc_pb.UserService{side}", method)
+            for side in ("Server", "Client")
+            for method in ("GetUser", "WatchUsers")
+        }
+
+    def test_client_call(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        call_node = MagicMock(start_point=(41, 8))
+        selector = call_node.child_by_field_name.return_value
+        selector.child_by_field_name.return_value.text = b"GetUser"
+
+        updater._ingest_rpc_call(
+            call_node, "shop.web.handlers.Profile", "Function", SERVICE
+        )
+
+        mock_ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Function", "qualified_name", "shop.web.handlers.Profile"),
+            "INVOKES_RPC",
+            ("RpcMethod", "qualified_name", "/users.v1.UserService/GetUser"),
+            {"line_number": 42},
+        )
+
+    def test_handlers(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_method_sets = _method_sets()
+        updater.grpc_servers = {"shop.pb.users_grpc_pb.UserServiceServer": SERVICE}
+
+        updater._link_go_implementations()
+
+        handles = [
+            (call.args[0][2], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == "HANDLES_RPC"
+        ]
+        # Not the Unimplemented server, nor the method promoted from it
+        assert handles == [
+            ("shop.users.server.GetUser", "/users.v1.UserService/GetUser")
+        ]
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Class", "qualified_name", "shop.users.server.server"),
+            "IMPLEMENTS_SERVICE",
+            ("ProtoService", "qualified_name", "users.v1.UserService"),
+        )
+
+    def test_generated_module_names(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        project = updater.project_name
+
+        assert (
+            updater._module_qualified_name(Path("pb/users_grpc.pb.go"))
+            == f"{project}.pb.users_grpc_pb"
+        )
+        assert (
+            updater._module_qualified_name(Path("web/app.test.ts"))
+            == f"{project}.web.app.test"
+        )
+        assert updater._module_qualified_name(Path("shop/__init__.py")) == (
+            f"{project}.shop"
+        )
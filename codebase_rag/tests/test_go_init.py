@@ -0,0 +1,129 @@
+"""Tests for Go init() functions and package initialization order."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_init import (
+    GoPackageFacts,
+    count_init_functions,
+    initialization_order,
+    nearest_initializers,
+)
+from codebase_rag.analysis.go_modules import GoModFile
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+IMPORTS = {
+    "example.com/shop": {"example.com/shop/db", "example.com/shop/api"},
+    "example.com/shop/api": {"example.com/shop/log", "example.com/shop/db"},
+    "example.com/shop/db": {"example.com/shop/log"},
+    "example.com/shop/log": set(),
+}
+
+
+class TestOrder:
+    """Test the order packages are initialized in."""
+
+    def test_dependencies_first_then_import_path(self):
+        assert initialization_order(IMPORTS) == [
+            "example.com/shop/log",
+            "example.com/shop/db",
+            "example.com/shop/api",
+            "example.com/shop",
+        ]
+
+    def test_cycle_is_left_out(self):
+        imports = {"a": {"b"}, "b": {"a"}, "c": {"fmt"}}
+
+        assert initialization_order(imports) == ["c"]
+
+    def test_nearest_initializers(self):
+        initializers = {"example.com/shop", "example.com/shop/log"}
+
+        # api has no init(), the root waits for log through it and db
+        assert nearest_initializers("example.com/shop", IMPORTS, initializers) == {
+            "example.com/shop/log"
+        }
+        assert nearest_initializers("example.com/shop/log", IMPORTS, initializers) == (
+            set()
+        )
+
+
+class TestInitFunctions:
+    """Test counting init() declarations."""
+
+    def test_count(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        source = b"""package db
+
+func init() { register() }
+
+func init() {}
+
+func initialize() {}
+
+func (d *DB) init() {}
+"""
+
+        root = parsers["go"].parse(source).root_node
+
+        assert count_init_functions(root) == 2
+
+
+class TestInitGraph:
+    """Test the PackageInit nodes and edges linked after parsing."""
+
+    def test_link(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_mod_files[Path()] = GoModFile(module="example.com/shop")
+        updater.go_packages = {
+            "shop": GoPackageFacts(
+                ".",
+                imports={"example.com/shop/api", "fmt"},
+                init_files=[("main.go", "shop.main", 1)],
+            ),
+            "shop.api": GoPackageFacts("api", imports={"example.com/shop/db"}),
+            "shop.db": GoPackageFacts(
+                "db",
+                blank_imports={"github.com/lib/pq"},
+                init_files=[
+                    ("schema.go", "shop.db.schema", 1),
+                    ("db.go", "shop.db.db", 2),
+                ],
+            ),
+        }
+
+        updater._link_go_initialization()
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "PackageInit",
+            {
+                "qualified_name": "shop.db:init",
+                "package": "example.com/shop/db",
+                "directory": "db",
+                "init_count": 3,
+                "order": 0,
+                "blank_imports": ["github.com/lib/pq"],
+            },
+        )
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert (
+            ("PackageInit", "qualified_name", "shop.db:init"),
+            "RUNS",
+            ("Function", "qualified_name", "shop.db.db.init"),
+            {"order": 0, "file": "db.go", "count": 2},
+        ) in edges
+        assert (
+            ("PackageInit", "qualified_name", "shop.db:init"),
+            "INIT_BEFORE",
+            ("PackageInit", "qualified_name", "shop:init"),
+        ) in edges
+        assert (
+            ("Project", "name", temp_repo.name),
+            "HAS_INIT",
+            ("PackageInit", "qualified_name", "shop:init"),
+        ) in edges
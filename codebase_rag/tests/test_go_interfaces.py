@@ -0,0 +1,306 @@
+"""Tests for implicit Go interface satisfaction."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_interfaces import (
+    GoMethod,
+    GoType,
+    MethodSets,
+    collect_go_methods,
+    collect_go_types,
+    variable_types,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+STORE = b"""package store
+
+type Repository interface {
+    Get(id string) (*Order, error)
+    Save(o *Order) error
+}
+
+type Closer interface {
+    Close() error
+}
+
+type ReadRepository interface {
+    Repository
+    Closer
+}
+"""
+
+MEMORY = b"""package memory
+
+type Store struct {
+    orders map[string]*store.Order
+}
+
+func (s *Store) Get(id string) (*store.Order, error) { return s.orders[id], nil }
+
+func (s *Store) Save(o *store.Order) error { return nil }
+
+func (s Store) Close() error { return nil }
+
+type Logged struct {
+    *Store
+    out io.Writer
+}
+
+type Buffer []byte
+
+func (b *Buffer) Write(p []byte) (n int, err error) { return len(p), nil }
+
+func (b Buffer) String() string { return string(b) }
+
+func (l *Logged) Reload(repo store.ReadRepository) {
+    l.Close()
+    l.Store.Save(nil)
+    var b Buffer
+    b.Write(nil)
+    w := &Logged{}
+    w.Get("id")
+    repo.Close()
+}
+"""
+
+
+def _implementations(method_sets: MethodSets) -> set[tuple[str, str, bool]]:
+    return {
+        (i.type_qn.rsplit(".", 1)[1], i.interface_qn, i.pointer_receiver)
+        for i in method_sets.implementations()
+    }
+
+
+class TestCollection:
+    """Test reading types and methods from Go source."""
+
+    @pytest.fixture
+    def go_parser(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        return parsers["go"]
+
+    def test_types_and_methods(self, go_parser):
+        types = {t.name: t for t in collect_go_types(go_parser.parse(STORE).root_node)}
+        root = go_parser.parse(MEMORY).root_node
+        methods = {m.name: m for m in collect_go_methods(root)}
+
+        assert types["Repository"].methods == {
+            "Get": "(string)*Order,error",
+            "Save": "(*Order)error",
+        }
+        assert types["ReadRepository"].embedded == [
+            ("Repository", False),
+            ("Closer", False),
+        ]
+        logged = {t.name: t for t in collect_go_types(root)}["Logged"]
+        assert (logged.embedded, logged.field_count) == ([("Store", True)], 2)
+        # Qualifiers and parameter names do not matter
+        assert methods["Get"].signature == "(string)*Order,error"
+        assert methods["Write"].signature == "([]byte)int,error"
+        assert methods["Write"].pointer_receiver
+        assert not methods["Close"].pointer_receiver
+
+    def test_across_packages(self, go_parser):
+        method_sets = MethodSets()
+        for package_qn, source in [("shop.store", STORE), ("shop.memory", MEMORY)]:
+            root = go_parser.parse(source).root_node
+            method_sets.add_file(
+                package_qn,
+                f"{package_qn}.{package_qn.rsplit('.', 1)[1]}",
+                collect_go_types(root),
+                collect_go_methods(root),
+            )
+
+        implementations = _implementations(method_sets)
+
+        assert ("Store", "shop.store.store.Repository", True) in implementations
+        assert ("Logged", "shop.store.store.ReadRepository", False) in implementations
+        assert ("Buffer", "io.Writer", True) in implementations
+        assert ("Buffer", "fmt.Stringer", False) in implementations
+
+    def test_method_calls(self, go_parser):
+        method_sets = MethodSets()
+        for package_qn, source in [("shop.store", STORE), ("shop.memory", MEMORY)]:
+            root = go_parser.parse(source).root_node
+            method_sets.add_file(
+                package_qn,
+                f"{package_qn}.{package_qn.rsplit('.', 1)[1]}",
+                collect_go_types(root),
+                collect_go_methods(root),
+            )
+        root = go_parser.parse(MEMORY).root_node
+        [reload] = [
+            node
+            for node in root.named_children
+            if node.type == "method_declaration"
+            and node.child_by_field_name("name").text == b"Reload"
+        ]
+        variables = variable_types(reload)
+        calls = [
+            node
+            for node in reload.child_by_field_name("body").named_children
+            if node.type == "expression_statement"
+        ]
+
+        assert variables == {
+            "l": "Logged",
+            "repo": "store.ReadRepository",
+            "b": "Buffer",
+            "w": "Logged",
+        }
+        assert [
+            method_sets.resolve_call("shop.memory", call.named_children[0], variables)
+            for call in calls
+        ] == [
+            ("Function", "shop.memory.memory.Close"),
+            ("Function", "shop.memory.memory.Save"),
+            ("Function", "shop.memory.memory.Write"),
+            ("Function", "shop.memory.memory.Get"),
+            ("Interface", "shop.store.store.Closer"),
+        ]
+
+
+class TestMethodSets:
+    """Test method set resolution, embedding and receivers."""
+
+    def setup_method(self):
+        self.method_sets = MethodSets()
+        self.method_sets.add_file(
+            "shop.store",
+            "shop.store.store",
+            [
+                GoType(
+                    "Repository",
+                    True,
+                    1,
+                    4,
+                    methods={"Get": "(string)*Order,error", "Save": "(*Order)error"},
+                ),
+                GoType("Closer", True, 5, 7, methods={"Close": "()error"}),
+                GoType(
+                    "ReadRepository",
+                    True,
+                    8,
+                    11,
+                    embedded=[("Repository", False), ("Closer", False)],
+                ),
+                GoType("Any", True, 12, 12),
+            ],
+            [],
+        )
+        self.method_sets.add_file(
+            "shop.memory",
+            "shop.memory.memory",
+            [
+                GoType("Store", False, 1, 3),
+                GoType("Logged", False, 4, 7, embedded=[("Store", True)]),
+                GoType("Wrapped", False, 8, 10, embedded=[("store.Closer", False)]),
+                GoType("Buffer", False, 11, 11),
+            ],
+            [
+                GoMethod("Store", "Get", "(string)*Order,error", True),
+                GoMethod("Store", "Save", "(*Order)error", True),
+                GoMethod("Store", "Close", "()error", False),
+                GoMethod("Buffer", "Write", "([]byte)int,error", True),
+                GoMethod("Buffer", "String", "()string", False),
+                # Same name, other signature: not a Repository
+                GoMethod("Wrapped", "Get", "(int)*Order,error", False),
+            ],
+        )
+
+    def test_implementations(self):
+        assert _implementations(self.method_sets) == {
+            # Pointer receivers: only *Store has Get and Save
+            ("Store", "shop.store.store.Repository", True),
+            ("Store", "shop.store.store.ReadRepository", True),
+            ("Store", "shop.store.store.Closer", False),
+            ("Store", "io.Closer", False),
+            # Embedding *Store promotes all its methods to Logged values
+            ("Logged", "shop.store.store.Repository", False),
+            ("Logged", "shop.store.store.ReadRepository", False),
+            ("Logged", "shop.store.store.Closer", False),
+            ("Logged", "io.Closer", False),
+            # An embedded interface from another package brings its methods
+            ("Wrapped", "shop.store.store.Closer", False),
+            ("Wrapped", "io.Closer", False),
+            ("Buffer", "io.Writer", True),
+            ("Buffer", "fmt.Stringer", False),
+        }
+
+    def test_promoted_methods(self):
+        method_sets = self.method_sets
+
+        # Through embedded *Store, and through embedded interfaces
+        assert method_sets.method_owner("shop.memory", "Logged", "Save") == (
+            "Function",
+            "shop.memory.memory.Save",
+        )
+        assert method_sets.method_owner("shop.memory", "Wrapped", "Close") == (
+            "Interface",
+            "shop.store.store.Closer",
+        )
+        assert method_sets.method_owner("shop.store", "ReadRepository", "Get") == (
+            "Interface",
+            "shop.store.store.Repository",
+        )
+        # A type's own method shadows promoted ones
+        assert method_sets.method_owner("shop.memory", "Wrapped", "Get") == (
+            "Function",
+            "shop.memory.memory.Get",
+        )
+        assert method_sets.method_owner("shop.memory", "Logged", "Write") is None
+
+    def test_ambiguous_selector(self):
+        method_sets = MethodSets()
+        for module_qn, name in [("shop.a", "A"), ("shop.b", "B")]:
+            method_sets.add_file(
+                "shop",
+                module_qn,
+                [GoType(name, False, 1, 1)],
+                [GoMethod(name, "Close", "()", False)],
+            )
+        method_sets.add_file(
+            "shop",
+            "shop.both",
+            [
+                GoType(
+                    "Both",
+                    False,
+                    1,
+                    4,
+                    embedded=[("A", False), ("B", False)],
+                    fields={"A": "A", "B": "B", "name": "string"},
+                )
+            ],
+            [],
+        )
+
+        # Close is promoted from A and B at the same depth, Go rejects b.Close()
+        assert method_sets.method_owner("shop", "Both", "Close") is None
+        assert method_sets.field_type("shop", "Both", "B") == ("shop", "B")
+
+    def test_ingested_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_method_sets = self.method_sets
+
+        updater._link_go_implementations()
+
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Class", "qualified_name", "shop.memory.memory.Buffer"),
+            "IMPLEMENTS",
+            ("Interface", "qualified_name", "io.Writer"),
+            {"pointer_receiver": True},
+        )
+        external = {
+            call.args[1]["qualified_name"]
+            for call in mock_ingestor.ensure_node_batch.call_args_list
+            if call.args[0] == "Interface"
+        }
+        # Only the standard library interfaces something implements
+        assert external == {"io.Closer", "io.Writer", "fmt.Stringer"}
@@ -0,0 +1,250 @@
+"""Tests for Go module resolution from go.mod, go.work and go.sum."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_init import GoPackageFacts
+from codebase_rag.analysis.go_modules import (
+    GoReplacement,
+    GoRequirement,
+    collect_go_imports,
+    import_specs,
+    is_standard_library,
+    module_for_import,
+    parse_go_mod_file,
+    parse_go_sum,
+    parse_go_work,
+    referenced_packages,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+GO_MOD = """module example.com/shop // the storefront
+
+go 1.22
+
+require golang.org/x/crypto v0.21.0
+
+require (
+    github.com/go-chi/chi/v5 v5.0.12
+    gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+    example.com/shop/billing => ./billing
+    gopkg.in/yaml.v3 v3.0.1 => gopkg.in/yaml.v3 v3.0.2
+)
+"""
+
+GO_SUM = """golang.org/x/crypto v0.21.0 h1:X31++rzVUdKhX5sWmSOFZxx8UW/ldWx55cbf08iNAMA=
+golang.org/x/crypto v0.21.0/go.mod h1:0BP7YvVV9gBbVKyeTG0Gyn+gZm94bibOW5BjDEYAOMs=
+"""
+
+GO_WORK = """go 1.22
+
+use (
+    .
+    ./billing
+)
+"""
+
+SOURCE = b"""package auth
+
+import (
+    "crypto/rand"
+    "golang.org/x/crypto/bcrypt"
+    router "github.com/go-chi/chi/v5"
+    _ "github.com/lib/pq"
+)
+
+func Hash(password string) ([]byte, error) {
+    return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+func Routes(r router.Router) {}
+"""
+
+
+class TestManifests:
+    """Test parsing go.mod, go.work and go.sum."""
+
+    def test_go_mod(self):
+        go_mod = parse_go_mod_file(GO_MOD)
+
+        assert (go_mod.module, go_mod.go_version) == ("example.com/shop", "1.22")
+        assert go_mod.requires == [
+            GoRequirement("golang.org/x/crypto", "v0.21.0"),
+            GoRequirement("github.com/go-chi/chi/v5", "v5.0.12"),
+            GoRequirement("gopkg.in/yaml.v3", "v3.0.1", indirect=True),
+        ]
+        assert go_mod.replaces == [
+            GoReplacement("example.com/shop/billing", "", "./billing", ""),
+            GoReplacement("gopkg.in/yaml.v3", "v3.0.1", "gopkg.in/yaml.v3", "v3.0.2"),
+        ]
+        assert go_mod.replaces[0].is_local
+        assert not go_mod.replaces[1].is_local
+
+    def test_go_work_and_go_sum(self):
+        go_work = parse_go_work(GO_WORK)
+
+        assert go_work.uses == [".", "./billing"]
+        assert parse_go_sum(GO_SUM) == {
+            ("golang.org/x/crypto", "v0.21.0"): (
+                "h1:X31++rzVUdKhX5sWmSOFZxx8UW/ldWx55cbf08iNAMA="
+            )
+        }
+
+    def test_module_for_import(self):
+        modules = ["golang.org/x/crypto", "github.com/go-chi/chi/v5", "example.com"]
+
+        assert module_for_import("golang.org/x/crypto/bcrypt", modules) == (
+            "golang.org/x/crypto"
+        )
+        assert module_for_import("github.com/go-chi/chi/v5", modules) == (
+            "github.com/go-chi/chi/v5"
+        )
+        # Only whole path elements match
+        assert module_for_import("golang.org/x/cryptography", modules) is None
+        assert is_standard_library("crypto/rand")
+        assert not is_standard_library("example.com/shop")
+
+
+class TestImports:
+    """Test which imported packages a Go file and its functions use."""
+
+    @pytest.fixture
+    def root(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        return parsers["go"].parse(SOURCE).root_node
+
+    def test_imports_and_references(self, root):
+        imports = collect_go_imports(root)
+        functions = {
+            f.child_by_field_name("name").text.decode(): f
+            for f in root.named_children
+            if f.type == "function_declaration"
+        }
+
+        assert imports == {
+            "rand": "crypto/rand",
+            "bcrypt": "golang.org/x/crypto/bcrypt",
+            "router": "github.com/go-chi/chi/v5",
+        }
+        assert referenced_packages(functions["Hash"], set(imports)) == {"bcrypt"}
+        assert referenced_packages(functions["Routes"], set(imports)) == {"router"}
+        assert ("_", "github.com/lib/pq") in import_specs(root)
+
+
+class TestModuleGraph:
+    """Test the module, version and replacement nodes ingested."""
+
+    def test_workspace(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "billing").mkdir()
+        (temp_repo / "go.mod").write_text(GO_MOD)
+        (temp_repo / "go.sum").write_text(GO_SUM)
+        (temp_repo / "go.work").write_text(GO_WORK)
+        (temp_repo / "billing" / "go.mod").write_text(
+            "module example.com/shop/billing\n\ngo 1.22\n"
+        )
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+
+        updater._ingest_go_modules()
+
+        nodes = {
+            (label, props.get("qualified_name") or props["path"]): props
+            for label, props in (
+                call.args for call in mock_ingestor.ensure_node_batch.call_args_list
+            )
+        }
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        assert nodes[("GoModule", "example.com/shop")]["is_local"]
+        assert nodes[("GoModule", "example.com/shop/billing")]["manifest"] == (
+            "billing/go.mod"
+        )
+        assert nodes[("ModuleVersion", "golang.org/x/crypto@v0.21.0")][
+            "checksum"
+        ].startswith("h1:")
+        assert (
+            ("GoModule", "path", "example.com/shop"),
+            "DEPENDS_ON",
+            ("ModuleVersion", "qualified_name", "gopkg.in/yaml.v3@v3.0.1"),
+            {"indirect": True},
+        ) in edges
+        assert (
+            ("GoModule", "path", "example.com/shop/billing"),
+            "REPLACED_BY",
+            ("GoModule", "path", "example.com/shop/billing"),
+            {"version": "", "manifest": "go.mod"},
+        ) in edges
+        assert (
+            ("GoModule", "path", "gopkg.in/yaml.v3"),
+            "REPLACED_BY",
+            ("ModuleVersion", "qualified_name", "gopkg.in/yaml.v3@v3.0.2"),
+            {"version": "v3.0.1", "manifest": "go.mod"},
+        ) in edges
+        assert (
+            ("File", "path", "go.work"),
+            "INCLUDES_MODULE",
+            ("GoModule", "path", "example.com/shop/billing"),
+        ) in edges
+
+
+class TestDotImports:
+    """Test resolving calls to names a Go file dot-imports."""
+
+    @staticmethod
+    def _updater(temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_mod_files[Path()] = parse_go_mod_file(GO_MOD)
+        updater.go_packages = {
+            "shop.api": GoPackageFacts("api"),
+            "shop.testutil": GoPackageFacts("testutil"),
+        }
+        for qn in [
+            "shop.api.routes.Routes",
+            "shop.testutil.fixtures.NewOrder",
+            "shop.billing.orders.NewOrder",
+        ]:
+            updater.function_registry[qn] = "Function"
+            updater.simple_name_lookup[qn.rsplit(".", 1)[1]].add(qn)
+        updater.go_dot_imports["shop.api.routes_test"] = [
+            ("example.com/shop/testutil", ""),
+            ("github.com/go-chi/chi/v5", "github.com/go-chi/chi/v5"),
+        ]
+        return updater
+
+    def test_resolution(self, temp_repo: Path, mock_ingestor: MagicMock):
+        resolve = self._updater(temp_repo, mock_ingestor)._resolve_go_dot_import
+
+        assert resolve("Routes", "shop.api.routes_test") == (
+            "Function",
+            "shop.api.routes.Routes",
+        )
+        # Not the NewOrder of another package that happens to share the name
+        assert resolve("NewOrder", "shop.api.routes_test") == (
+            "Function",
+            "shop.testutil.fixtures.NewOrder",
+        )
+        assert resolve("NewRouter", "shop.api.routes_test") is None
+
+    def test_external_uses(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = self._updater(temp_repo, mock_ingestor)
+
+        updater._ingest_go_module_uses(
+            MagicMock(),
+            "shop.api.routes_test.TestRoutes",
+            "Function",
+            "shop.api.routes_test",
+            calls_dot_imports=True,
+        )
+
+        mock_ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Function", "qualified_name", "shop.api.routes_test.TestRoutes"),
+            "USES_MODULE",
+            ("GoModule", "path", "github.com/go-chi/chi/v5"),
+            {"packages": ["github.com/go-chi/chi/v5"]},
+        )
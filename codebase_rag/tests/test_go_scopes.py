@@ -0,0 +1,148 @@
+"""Tests for lexical scopes in Go call resolution."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.go_scopes import GoScopes
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+SOURCE = b"""package orders
+
+func Checkout(cart *Cart, items []Item) {
+    total := 0
+    apply := func(total int, d Discount) {
+        d.Apply(total)
+    }
+    for _, item := range items {
+        s := &Shipping{}
+        s.Quote(item)
+    }
+    switch v := cart.Payment.(type) {
+    case *Card:
+        v.Charge(total)
+    default:
+        v.Reset()
+    }
+    apply(total, nil)
+}
+"""
+
+
+def calls(root, name: str) -> list:
+    """Call expressions whose function reads as name, in source order."""
+    found, stack = [], [root]
+    while stack:
+        node = stack.pop()
+        function = node.child_by_field_name("function")
+        if node.type == "call_expression" and function.text.decode() == name:
+            found.append(node)
+        stack.extend(reversed(node.named_children))
+    return found
+
+
+class TestScopes:
+    """Test which declaration a name refers to at a point of a Go function."""
+
+    @pytest.fixture
+    def checkout(self):
+        parsers, _ = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        root = parsers["go"].parse(SOURCE).root_node
+        return root.named_children[1]
+
+    def test_closure_parameters_shadow_outer_locals(self, checkout):
+        scopes = GoScopes(checkout)
+        [inner] = calls(checkout, "d.Apply")
+        [outer] = calls(checkout, "apply")
+
+        inside = scopes.lookup(inner, "total")
+        outside = scopes.lookup(outer, "total")
+
+        assert inside.type_name == "int"
+        assert outside.type_name == ""
+        assert inside.scope_start > outside.scope_start
+        assert scopes.variables_at(inner)["d"] == "Discount"
+        # The closure's parameter is not visible after it
+        assert "d" not in scopes.variables_at(outer)
+
+    def test_block_locals_end_with_their_block(self, checkout):
+        scopes = GoScopes(checkout)
+        [quote] = calls(checkout, "s.Quote")
+        [apply] = calls(checkout, "apply")
+
+        assert scopes.variables_at(quote)["s"] == "Shipping"
+        assert scopes.lookup(apply, "s") is None
+        assert scopes.lookup(apply, "item") is None
+
+    def test_type_switch_binding_per_clause(self, checkout):
+        scopes = GoScopes(checkout)
+        [charge] = calls(checkout, "v.Charge")
+        [reset] = calls(checkout, "v.Reset")
+
+        assert scopes.variables_at(charge)["v"] == "Card"
+        assert scopes.lookup(reset, "v").type_name == ""
+
+
+class TestResolution:
+    """Test CALLS edges of shadowed names and aliased imports."""
+
+    def test_shadowed_and_aliased_calls(
+        self, temp_repo: Path, mock_ingestor: MagicMock
+    ):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        (temp_repo / "go.mod").write_text("module example.com/shop\n\ngo 1.22\n")
+        (temp_repo / "telemetry").mkdir()
+        (temp_repo / "telemetry" / "counter.go").write_text(
+            "package metrics\n\nfunc Inc(name string) {}\n"
+        )
+        (temp_repo / "orders").mkdir()
+        (temp_repo / "orders" / "orders.go").write_text(
+            """package orders
+
+import m "example.com/shop/telemetry"
+
+type Meter struct{}
+
+func (Meter) Inc(name string) {}
+
+func format(id string) string { return id }
+
+func Place(id string) {
+    go func() {
+        m := &Meter{}
+        m.Inc(id)
+    }()
+    m.Inc("orders")
+    format := func(s string) string { return s }
+    format(id)
+}
+
+func Label(id string) string {
+    return format(id)
+}
+"""
+        )
+
+        GraphUpdater(mock_ingestor, temp_repo, parsers, queries).run()
+
+        project = temp_repo.name
+        edges = {
+            (c.args[0][2], c.args[2][2])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "CALLS"
+        }
+        place = f"{project}.orders.orders.Place"
+        assert (place, f"{project}.orders.orders.Inc") in edges
+        assert (place, f"{project}.telemetry.counter.Inc") in edges
+        # The local func variable, not the package's format
+        assert (place, f"{project}.orders.orders.format") not in edges
+        assert (
+            f"{project}.orders.orders.Label",
+            f"{project}.orders.orders.format",
+        ) in edges
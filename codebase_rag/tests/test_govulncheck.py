@@ -0,0 +1,200 @@
+"""Tests for mapping govulncheck findings onto the graph."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.code_locator import FUNCTIONS_QUERY
+from codebase_rag.analysis.govulncheck import (
+    CALLED,
+    IMPORTED,
+    REQUIRED,
+    GovulncheckLoader,
+    VulnFrame,
+    parse_govulncheck_json,
+)
+
+FUNCTIONS = [
+    {
+        "qualified_name": "shop.server.server.Serve",
+        "name": "Serve",
+        "label": "Function",
+        "path": "server/server.go",
+        "start_line": 10,
+        "end_line": 30,
+    },
+    {
+        "qualified_name": "shop.main.main",
+        "name": "main",
+        "label": "Function",
+        "path": "main.go",
+        "start_line": 5,
+        "end_line": 9,
+    },
+]
+
+OSV = {
+    "id": "GO-2023-2102",
+    "aliases": ["CVE-2023-39325", "GHSA-4374-p667-p6c8"],
+    "summary": "HTTP/2 rapid reset can cause excessive work in net/http",
+    "database_specific": {"url": "https://pkg.go.dev/vuln/GO-2023-2102"},
+}
+NET = {"module": "golang.org/x/net", "version": "v0.7.0"}
+CALL_TRACE = [
+    {
+        **NET,
+        "package": "golang.org/x/net/http2",
+        "function": "ServeConn",
+        "receiver": "*Server",
+    },
+    {
+        "module": "example.com/shop",
+        "package": "example.com/shop/server",
+        "function": "Serve",
+        "position": {"filename": "/src/shop/server/server.go", "line": 21},
+    },
+    {
+        "module": "example.com/shop",
+        "package": "example.com/shop",
+        "function": "main",
+        "position": {"filename": "/src/shop/main.go", "line": 7},
+    },
+]
+
+
+def _messages(*findings) -> str:
+    messages = [{"config": {"protocol_version": "v1.0.0"}}, {"osv": OSV}]
+    messages += [{"finding": finding} for finding in findings]
+    # govulncheck indents each message
+    return "\n".join(json.dumps(message, indent=2) for message in messages)
+
+
+def _loader() -> tuple[GovulncheckLoader, MagicMock]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {
+        FUNCTIONS_QUERY: FUNCTIONS
+    }[query]
+    return GovulncheckLoader(ingestor), ingestor
+
+
+class TestGovulncheckParsing:
+    """Test reading the JSON message stream."""
+
+    def test_parse(self):
+        report = parse_govulncheck_json(
+            _messages(
+                {"osv": "GO-2023-2102", "fixed_version": "v0.17.0", "trace": [NET]},
+                {"osv": "GO-2023-2102", "trace": [CALL_TRACE[0]]},
+            )
+        )
+
+        assert list(report.osvs) == ["GO-2023-2102"]
+        assert [finding.reachability for finding in report.findings] == [
+            REQUIRED,
+            CALLED,
+        ]
+        assert report.findings[0].fixed_version == "v0.17.0"
+        assert (
+            report.findings[1].trace[0].symbol
+            == "golang.org/x/net/http2.Server.ServeConn"
+        )
+
+    def test_symbols(self):
+        package = VulnFrame("golang.org/x/net", package="golang.org/x/net/http2")
+
+        assert package.symbol == "golang.org/x/net/http2"
+        assert VulnFrame("stdlib").symbol == "stdlib"
+
+
+class TestGovulncheckLoader:
+    """Test Vulnerability nodes and the edges to module versions and callers."""
+
+    def test_called_vulnerability(self):
+        loader, ingestor = _loader()
+        report = parse_govulncheck_json(
+            _messages(
+                {"osv": "GO-2023-2102", "fixed_version": "v0.17.0", "trace": [NET]},
+                {
+                    "osv": "GO-2023-2102",
+                    "fixed_version": "v0.17.0",
+                    "trace": [{**NET, "package": "golang.org/x/net/http2"}],
+                },
+                {
+                    "osv": "GO-2023-2102",
+                    "fixed_version": "v0.17.0",
+                    "trace": CALL_TRACE,
+                },
+            )
+        )
+
+        stats = loader.load(report)
+
+        ingestor.ensure_node_batch.assert_any_call(
+            "Vulnerability",
+            {
+                "id": "GO-2023-2102",
+                "type": "go_vulnerability",
+                "severity": "high",
+                "description": OSV["summary"],
+                "recommendation": "Upgrade golang.org/x/net to v0.17.0",
+                "aliases": ["CVE-2023-39325", "GHSA-4374-p667-p6c8"],
+                "reachability": CALLED,
+                "url": "https://pkg.go.dev/vuln/GO-2023-2102",
+            },
+        )
+        ingestor.ensure_node_batch.assert_any_call(
+            "ModuleVersion",
+            {
+                "qualified_name": "golang.org/x/net@v0.7.0",
+                "path": "golang.org/x/net",
+                "version": "v0.7.0",
+            },
+        )
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Function", "qualified_name", "shop.server.server.Serve"),
+            "CALLS_VULNERABLE",
+            ("Vulnerability", "id", "GO-2023-2102"),
+            {
+                "symbol": "golang.org/x/net/http2.Server.ServeConn",
+                "line_number": 21,
+                "entry": "shop.main.main",
+                "trace": [
+                    "golang.org/x/net/http2.Server.ServeConn",
+                    "example.com/shop/server.Serve",
+                    "example.com/shop.main",
+                ],
+            },
+        )
+        assert stats == {
+            "vulnerabilities": 1,
+            "called": 1,
+            "module_versions": 1,
+            "calls": 1,
+            "unresolved_traces": 0,
+        }
+
+    def test_imported_only(self):
+        loader, ingestor = _loader()
+        report = parse_govulncheck_json(
+            _messages(
+                {
+                    "osv": "GO-2023-2102",
+                    "trace": [{**NET, "package": "golang.org/x/net/http2"}],
+                }
+            )
+        )
+
+        stats = loader.load(report)
+
+        [vulnerability] = [
+            c.args[1]
+            for c in ingestor.ensure_node_batch.call_args_list
+            if c.args[0] == "Vulnerability"
+        ]
+        assert (vulnerability["reachability"], vulnerability["severity"]) == (
+            IMPORTED,
+            "medium",
+        )
+        assert [
+            c.args[1] for c in ingestor.ensure_relationship_batch.call_args_list
+        ] == ["HAS_KNOWN_VULNERABILITY"]
+        assert stats["calls"] == 0
@@ -0,0 +1,156 @@
+"""Tests for choosing a graph backend and talking to Neo4j through it."""
+
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.config import settings
+from codebase_rag.services.graph_backends import (
+    EmbeddedBackend,
+    EmbeddedConnection,
+    MemgraphBackend,
+    Neo4jBackend,
+    Neo4jConnection,
+    create_backend,
+)
+from codebase_rag.services.graph_service import MemgraphIngestor
+
+
+class FakeRecord:
+    def __init__(self, row: dict):
+        self.row = row
+
+    def values(self) -> list:
+        return list(self.row.values())
+
+
+class FakeResult:
+    """What a Neo4j session returns for one statement."""
+
+    def __init__(self, keys: list[str], rows: list[dict]):
+        self._keys = keys
+        self._records = [FakeRecord(row) for row in rows]
+
+    def __iter__(self):
+        return iter(self._records)
+
+    def keys(self) -> list[str]:
+        return self._keys
+
+
+def neo4j_ingestor(session: MagicMock, **options) -> MemgraphIngestor:
+    backend = Neo4jBackend("bolt://graph:7687", "neo4j", "secret")
+    writer = MemgraphIngestor("", 0, sinks=[], backend=backend, **options)
+    driver = MagicMock()
+    driver.session.return_value = session
+    writer.conn = Neo4jConnection(driver)
+    return writer
+
+
+class TestBackendChoice:
+    """Test which backend GRAPH_BACKEND selects."""
+
+    def test_memgraph_by_default(self, monkeypatch):
+        monkeypatch.setattr(settings, "GRAPH_BACKEND", "memgraph")
+
+        backend = MemgraphIngestor("db", 7688, sinks=[]).backend
+
+        assert isinstance(backend, MemgraphBackend)
+        assert str(backend) == "Memgraph at db:7688"
+
+    def test_neo4j_from_settings(self, monkeypatch):
+        monkeypatch.setattr(settings, "GRAPH_BACKEND", "neo4j")
+        monkeypatch.setattr(settings, "NEO4J_URI", "neo4j+s://graph.example.com")
+        monkeypatch.setattr(settings, "NEO4J_DATABASE", "code")
+
+        backend = create_backend("localhost", 7687)
+
+        assert isinstance(backend, Neo4jBackend)
+        assert backend.uri == "neo4j+s://graph.example.com"
+        assert backend.database == "code"
+
+    def test_embedded_from_settings(self, monkeypatch, tmp_path):
+        monkeypatch.setattr(settings, "GRAPH_BACKEND", "embedded")
+        monkeypatch.setattr(settings, "EMBEDDED_GRAPH_PATH", str(tmp_path / "g.db"))
+
+        backend = create_backend("localhost", 7687)
+
+        assert isinstance(backend, EmbeddedBackend)
+        assert backend.path == tmp_path / "g.db"
+
+
+class TestNeo4j:
+    """Test reads, writes and DDL against a Neo4j session."""
+
+    def test_rows_become_dicts(self):
+        session = MagicMock()
+        session.run.return_value = FakeResult(
+            ["name", "line"], [{"name": "total", "line": 3}]
+        )
+        writer = neo4j_ingestor(session)
+
+        rows = writer.fetch_all("MATCH (f:Function) RETURN f.name AS name;")
+
+        assert rows == [{"name": "total", "line": 3}]
+        # Neo4j rejects the trailing semicolon Memgraph accepts
+        assert session.run.call_args.args[0].endswith("AS name")
+
+    def test_constraints_and_clearing_use_neo4j_syntax(self):
+        session = MagicMock()
+        session.run.return_value = FakeResult([], [])
+        writer = neo4j_ingestor(session)
+
+        writer.ensure_constraints()
+        writer.clean_database()
+
+        queries = [c.args[0] for c in session.run.call_args_list]
+        assert (
+            "CREATE CONSTRAINT Function_qualified_name_unique IF NOT EXISTS "
+            "FOR (n:Function) REQUIRE n.qualified_name IS UNIQUE"
+        ) in queries
+        assert "IN TRANSACTIONS" in queries[-1]
+
+    def test_deadlocks_are_retried(self):
+        class TransientError(Exception):
+            pass
+
+        session = MagicMock()
+        session.run.side_effect = [
+            TransientError("ForsetiClient can't acquire ExclusiveLock"),
+            FakeResult([], []),
+        ]
+        writer = neo4j_ingestor(session, write_retries=1)
+
+        with patch("codebase_rag.services.graph_service.time.sleep") as sleep:
+            writer._execute_batch("MERGE (n:File {path: row.path})", [{"path": "a"}])
+
+        assert session.run.call_count == 2
+        sleep.assert_called_once()
+
+
+class TestEmbedded:
+    """Test the embedded FalkorDB graph behind the connection interface."""
+
+    def test_rows_and_ddl(self):
+        graph = MagicMock()
+        graph.query.return_value = MagicMock(
+            header=[[1, "name"], [1, "line"]], result_set=[["total", 3]]
+        )
+        db = MagicMock()
+        writer = MemgraphIngestor(
+            "", 0, sinks=[], backend=EmbeddedBackend("/tmp/graph.db")
+        )
+        writer.conn = EmbeddedConnection(db, graph)
+
+        rows = writer.fetch_all("MATCH (f:Function) RETURN f.name AS name, 3 AS line")
+        writer.ensure_constraints()
+
+        assert rows == [{"name": "total", "line": 3}]
+        queries = [c.args[0] for c in graph.query.call_args_list]
+        assert "CREATE INDEX FOR (n:Function) ON (n.qualified_name)" in queries
+
+        writer.conn.close()
+        db.connection.save.assert_called_once()
+
+    def test_writes_never_conflict(self):
+        backend = EmbeddedBackend("/tmp/graph.db")
+
+        assert not backend.is_transient(RuntimeError("conflicting transactions"))
@@ -0,0 +1,278 @@
+"""Tests for comparing the graphs of two revisions or snapshots."""
+
+import subprocess
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag import graph_diff
+from codebase_rag.graph_diff import (
+    DependencyChange,
+    EdgeChange,
+    GraphRecord,
+    SymbolChange,
+    diff_graphs,
+    graph_at_revision,
+    graph_from_snapshot,
+)
+from codebase_rag.snapshots import (
+    NODES_PAGE_QUERY,
+    PROJECTS_QUERY,
+    RELATIONSHIPS_PAGE_QUERY,
+    save_snapshot,
+)
+
+
+def _function(qn: str, **properties) -> dict:
+    return {"qualified_name": qn, "name": qn.rsplit(".", 1)[-1], **properties}
+
+
+def _record(nodes: list[tuple[str, dict]], edges=()) -> GraphRecord:
+    record = GraphRecord()
+    for label, properties in nodes:
+        record.add_node(label, properties)
+    for source, rel_type, target in edges:
+        record.add_relationship(source, rel_type, target)
+    return record
+
+
+OLD = _record(
+    [
+        ("Module", {"qualified_name": "shop.cart"}),
+        ("Function", _function("shop.cart.price", parameter_count=1, body_hash="a")),
+        ("Function", _function("shop.cart.total", start_line=5, decorators=[])),
+        ("Function", _function("shop.cart.legacy")),
+        ("ExternalPackage", {"name": "requests", "version_spec": "==2.0"}),
+        ("ExternalPackage", {"name": "six", "version_spec": ""}),
+        ("GoModule", {"path": "shop", "is_local": True}),
+        (
+            "ModuleVersion",
+            {
+                "qualified_name": "golang.org/x/text@v0.3.0",
+                "path": "golang.org/x/text",
+                "version": "v0.3.0",
+            },
+        ),
+        ("Interface", {"qualified_name": "io.Writer", "is_external": True}),
+    ],
+    [
+        (("Function", "shop.cart.total"), "CALLS", ("Function", "shop.cart.price")),
+        (("Function", "shop.cart.legacy"), "CALLS", ("Function", "shop.cart.price")),
+        (
+            ("GoModule", "shop"),
+            "DEPENDS_ON",
+            ("ModuleVersion", "golang.org/x/text@v0.3.0"),
+        ),
+    ],
+)
+NEW = _record(
+    [
+        ("Module", {"qualified_name": "shop.cart"}),
+        ("Module", {"qualified_name": "shop.tax"}),
+        ("Function", _function("shop.cart.price", parameter_count=2, body_hash="b")),
+        # Moved down the file, otherwise the same
+        ("Function", _function("shop.cart.total", start_line=9, decorators=())),
+        ("Function", _function("shop.tax.apply")),
+        ("ExternalPackage", {"name": "requests", "version_spec": "==2.1"}),
+        ("ExternalPackage", {"name": "rich"}),
+        ("GoModule", {"path": "shop", "is_local": True}),
+        (
+            "ModuleVersion",
+            {
+                "qualified_name": "golang.org/x/text@v0.14.0",
+                "path": "golang.org/x/text",
+                "version": "v0.14.0",
+            },
+        ),
+    ],
+    [
+        (("Function", "shop.cart.total"), "CALLS", ("Function", "shop.cart.price")),
+        (("Function", "shop.cart.total"), "CALLS", ("Function", "shop.tax.apply")),
+        (("Module", "shop.cart"), "IMPORTS", ("Module", "shop.tax")),
+        # Never ingested, so not in the graph either
+        (("Function", "shop.tax.apply"), "CALLS", ("Function", "builtins.round")),
+        (
+            ("GoModule", "shop"),
+            "DEPENDS_ON",
+            ("ModuleVersion", "golang.org/x/text@v0.14.0"),
+        ),
+    ],
+)
+
+
+class TestDiffGraphs:
+    """Test what is reported between two recorded graphs."""
+
+    def test_symbols(self):
+        diff = diff_graphs(OLD, NEW, "v1", "v2")
+
+        assert diff.symbols == [
+            SymbolChange("shop.tax.apply", "Function", "added"),
+            SymbolChange("shop.cart.legacy", "Function", "removed"),
+            SymbolChange(
+                "shop.cart.price",
+                "Function",
+                "changed",
+                ["parameter_count 1 -> 2", "body changed"],
+            ),
+        ]
+
+    def test_dependencies(self):
+        diff = diff_graphs(OLD, NEW, "v1", "v2")
+
+        assert diff.dependencies == [
+            DependencyChange("golang.org/x/text", "changed", "v0.3.0", "v0.14.0"),
+            DependencyChange("requests", "changed", "==2.0", "==2.1"),
+            DependencyChange("rich", "added"),
+            DependencyChange("six", "removed"),
+        ]
+
+    def test_imported_packages_are_not_dependencies(self):
+        new = _record(
+            [
+                ("Module", {"qualified_name": "shop.cart"}),
+                ("ExternalPackage", {"name": "requests"}),
+                ("ExternalPackage", {"name": "yaml"}),
+            ],
+            [
+                (
+                    ("Project", "shop"),
+                    "DEPENDS_ON_EXTERNAL",
+                    ("ExternalPackage", "requests"),
+                ),
+                (("Module", "shop.cart"), "IMPORTS", ("ExternalPackage", "requests")),
+                (("Module", "shop.cart"), "IMPORTS", ("ExternalPackage", "yaml")),
+            ],
+        )
+
+        diff = diff_graphs(_record([]), new, "v1", "v2")
+
+        assert diff.dependencies == [DependencyChange("requests", "added")]
+
+    def test_edges(self):
+        diff = diff_graphs(OLD, NEW, "v1", "v2")
+
+        assert diff.edges == [
+            EdgeChange("CALLS", "shop.cart.total", "shop.tax.apply", "added"),
+            EdgeChange("CALLS", "shop.cart.legacy", "shop.cart.price", "removed"),
+            EdgeChange("IMPORTS", "shop.cart", "shop.tax", "added"),
+        ]
+        assert diff.counts()["CALLS edge added"] == 1
+
+    def test_same_graph(self):
+        diff = diff_graphs(OLD, OLD, "v1", "v1")
+
+        assert diff.is_empty
+        assert diff.to_markdown().endswith("No architectural changes.\n")
+
+    def test_markdown(self):
+        text = diff_graphs(OLD, NEW, "v1", "v2").to_markdown()
+
+        assert text.startswith("## Architecture changes v1..v2\n")
+        assert "- `shop.cart.price` (Function): parameter_count 1 -> 2; body" in text
+        assert "- `requests` ==2.0 -> ==2.1\n- added `rich`\n" in text
+        assert "### Imports\n\n- + `shop.cart` -> `shop.tax`\n" in text
+        assert "- - `shop.cart.legacy` -> `shop.cart.price`" in text
+
+
+class TestSources:
+    """Test recording graphs from snapshots and Git revisions."""
+
+    def test_snapshot(self, tmp_path):
+        nodes = [
+            {"id": 1, "labels": ["Project"], "properties": {"name": "shop"}},
+            {
+                "id": 2,
+                "labels": ["Function"],
+                "properties": _function("shop.cart.total", decorators=["cache"]),
+            },
+            {
+                "id": 3,
+                "labels": ["Function"],
+                "properties": _function("shop.cart.price"),
+            },
+        ]
+        relationships = [
+            {"id": 1, "from_id": 2, "to_id": 3, "type": "CALLS", "properties": {}},
+            {"id": 2, "from_id": 1, "to_id": 2, "type": "CONTAINS", "properties": {}},
+        ]
+
+        def source_graph(query, params=None):
+            if query == PROJECTS_QUERY:
+                return [{"name": "shop"}]
+            if query in (NODES_PAGE_QUERY, RELATIONSHIPS_PAGE_QUERY):
+                rows = nodes if query == NODES_PAGE_QUERY else relationships
+                return [dict(r) for r in rows if r["id"] > params["after"]]
+            return []
+
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = source_graph
+        save_snapshot(ingestor, tmp_path / "shop.tar.gz")
+
+        record = graph_from_snapshot(tmp_path / "shop.tar.gz")
+
+        assert set(record.nodes) == {
+            ("Function", "shop.cart.total"),
+            ("Function", "shop.cart.price"),
+        }
+        assert record.edges("CALLS") == {
+            (("Function", "shop.cart.total"), ("Function", "shop.cart.price"))
+        }
+        # Lists come back from JSON, tuples from ingestion
+        total = record.nodes[("Function", "shop.cart.total")]
+        assert total["decorators"] == ["cache"]
+        assert not diff_graphs(record, _with_tuples(record), "a", "b").symbols
+
+    def test_revision(self, tmp_path, monkeypatch):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+
+        def git(*args):
+            subprocess.run(["git", *args], cwd=repo, check=True, capture_output=True)
+
+        git("init")
+        git("config", "user.name", "Test User")
+        git("config", "user.email", "test@example.com")
+        (repo / "cart.py").write_text("def total():\n    return 0\n")
+        git("add", ".")
+        git("commit", "-m", "Initial import")
+        ingested = []
+
+        class Updater:
+            def __init__(self, ingestor, tree, parsers, queries):
+                self.ingestor, self.tree = ingestor, tree
+
+            def run(self):
+                ingested.append((self.tree.name, (self.tree / "cart.py").read_text()))
+                self.ingestor.ensure_node_batch(
+                    "Function", _function("shop.cart.total")
+                )
+                self.ingestor.ensure_relationship_batch(
+                    ("Function", "qualified_name", "shop.cart.total"),
+                    "CALLS",
+                    ("Function", "qualified_name", "shop.cart.price"),
+                )
+
+        monkeypatch.setattr(graph_diff, "GraphUpdater", Updater)
+
+        record = graph_at_revision(repo, "HEAD", {}, {})
+
+        # Named like the repository, so qualified names start the same
+        assert ingested == [("shop", "def total():\n    return 0\n")]
+        assert list(record.nodes) == [("Function", "shop.cart.total")]
+        assert record.edges("CALLS") == set()
+        with pytest.raises(subprocess.CalledProcessError):
+            graph_at_revision(repo, "does-not-exist", {}, {})
+
+
+def _with_tuples(record: GraphRecord) -> GraphRecord:
+    copy = GraphRecord(relationships=set(record.relationships))
+    for (label, _), properties in record.nodes.items():
+        copy.add_node(
+            label,
+            {
+                k: tuple(v) if isinstance(v, list) else v
+                for k, v in properties.items()
+            },
+        )
+    return copy
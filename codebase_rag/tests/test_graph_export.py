@@ -0,0 +1,152 @@
+"""Tests for exporting the graph, or a slice of it, in several formats."""
+
+import io
+import json
+from pathlib import Path
+from unittest.mock import MagicMock
+from xml.etree import ElementTree
+
+import pytest
+
+from codebase_rag.graph_export import (
+    RELATIONSHIPS_QUERY,
+    GraphFilter,
+    format_for,
+    select_graph,
+    write_dot,
+    write_graphml,
+    write_jsonl,
+)
+
+GRAPH = {
+    "nodes": [
+        {
+            "node_id": 1,
+            "labels": ["Function"],
+            "properties": {"name": "total", "qualified_name": "shop.cart.total"},
+        },
+        {
+            "node_id": 2,
+            "labels": ["Class"],
+            "properties": {
+                "name": 'Cart<"T">',
+                "qualified_name": "shop.cart.Cart",
+                "decorators": ["dataclass"],
+            },
+        },
+    ],
+    "relationships": [
+        {"from_id": 2, "to_id": 1, "type": "DEFINES_METHOD", "properties": {}}
+    ],
+    "metadata": {"total_nodes": 2, "total_relationships": 1},
+}
+
+
+class FakeNode:
+    def __init__(self, node_id: int):
+        self.id = node_id
+        self.labels = {"Function"}
+
+
+class FakePath:
+    def __init__(self, *node_ids: int):
+        self.nodes = [FakeNode(i) for i in node_ids]
+
+
+class TestSelectGraph:
+    """Test which nodes and relationships a slice keeps."""
+
+    def test_no_filter_exports_everything(self):
+        ingestor = MagicMock()
+
+        assert select_graph(ingestor, GraphFilter()) is (
+            ingestor.export_graph_to_dict.return_value
+        )
+        ingestor.fetch_all.assert_not_called()
+
+    def test_filters_intersect(self):
+        ingestor = MagicMock()
+        query_rows = [{"p": FakePath(1, 2)}, {"n": FakeNode(3), "name": "x"}]
+
+        def fetch_all(query, params=None):
+            if query.startswith("MATCH p"):
+                return query_rows
+            if query == RELATIONSHIPS_QUERY:
+                return GRAPH["relationships"]
+            return GRAPH["nodes"]
+
+        ingestor.fetch_all.side_effect = fetch_all
+        graph_filter = GraphFilter(
+            ["shop.cart"], ["Function", "Class"], "MATCH p = ()-->() RETURN p"
+        )
+
+        graph = select_graph(ingestor, graph_filter)
+
+        nodes_query, params = ingestor.fetch_all.call_args_list[1].args
+        assert "STARTS WITH p + '.'" in nodes_query
+        assert " AND " in nodes_query
+        assert params == {
+            "packages": ["shop.cart"],
+            "labels": ["Function", "Class"],
+            "query_ids": [1, 2, 3],
+        }
+        assert ingestor.fetch_all.call_args_list[2].args == (
+            RELATIONSHIPS_QUERY,
+            {"ids": [1, 2]},
+        )
+        assert graph["metadata"]["total_relationships"] == 1
+
+    def test_query_must_be_read_only(self):
+        ingestor = MagicMock()
+
+        with pytest.raises(ValueError):
+            select_graph(ingestor, GraphFilter(query="MATCH (n) DETACH DELETE n"))
+        ingestor.fetch_all.assert_not_called()
+
+
+class TestWriters:
+    """Test each output format."""
+
+    def test_format_from_flag_or_suffix(self):
+        assert format_for(Path("graph.GraphML")) == "graphml"
+        assert format_for(Path("graph.gv")) == "dot"
+        assert format_for(Path("graph.out")) == "json"
+        assert format_for(Path("graph.dot"), "jsonl") == "jsonl"
+        with pytest.raises(ValueError):
+            format_for(Path("graph.json"), "csv")
+
+    def test_graphml_is_well_formed(self):
+        out = io.StringIO()
+
+        write_graphml(GRAPH, out)
+
+        ns = {"g": "http://graphml.graphdrawing.org/xmlns"}
+        root = ElementTree.fromstring(out.getvalue())
+        keys = {k.get("id"): k.get("attr.name") for k in root.findall("g:key", ns)}
+        assert keys["n_decorators"] == "decorators"
+        cart = root.find("g:graph/g:node[@id='n2']", ns)
+        data = {d.get("key"): d.text for d in cart.findall("g:data", ns)}
+        assert data["n_label"] == 'Cart<"T">'
+        assert data["n_labels"] == "Class"
+        assert data["n_decorators"] == '["dataclass"]'
+        edge = root.find("g:graph/g:edge", ns)
+        assert (edge.get("source"), edge.get("target")) == ("n2", "n1")
+
+    def test_dot_escapes_labels(self):
+        out = io.StringIO()
+
+        write_dot(GRAPH, out)
+
+        dot = out.getvalue()
+        assert dot.startswith("digraph code_graph {")
+        assert 'n2 [label="Cart<\\"T\\">\\nClass"];' in dot
+        assert 'n2 -> n1 [label="DEFINES_METHOD"];' in dot
+
+    def test_jsonl_has_one_record_per_line(self):
+        out = io.StringIO()
+
+        write_jsonl(GRAPH, out)
+
+        records = [json.loads(line) for line in out.getvalue().splitlines()]
+        assert [r["kind"] for r in records] == ["node", "node", "relationship"]
+        assert records[2]["type"] == "DEFINES_METHOD"
@@ -0,0 +1,164 @@
+"""Tests for graph sinks receiving ingested nodes and relationships."""
+
+import json
+import sys
+import types
+
+from codebase_rag.services.graph_service import MemgraphIngestor
+from codebase_rag.services.graph_sinks import (
+    GraphSink,
+    JsonLinesSink,
+    SinkDispatcher,
+    create_sink,
+    load_sinks,
+)
+
+
+class RecordingSink(GraphSink):
+    name = "recording"
+
+    def __init__(self):
+        self.events = []
+
+    def start(self):
+        self.events.append("start")
+
+    def on_node(self, label, properties):
+        self.events.append(("node", label, properties["qualified_name"]))
+
+    def on_relationship(self, from_node, rel_type, to_node, properties):
+        self.events.append(("relationship", rel_type))
+
+    def flush(self):
+        self.events.append("flush")
+
+    def finish(self):
+        self.events.append("finish")
+
+
+class FailingSink(GraphSink):
+    name = "failing"
+
+    def on_node(self, label, properties):
+        raise RuntimeError("warehouse unavailable")
+
+
+class TestSinkDispatcher:
+    """Test lifecycle hooks and isolation of failing sinks."""
+
+    def test_lifecycle(self):
+        sink = RecordingSink()
+        dispatcher = SinkDispatcher([sink])
+
+        dispatcher.flush()  # Not started yet: no events
+        dispatcher.node("Function", {"qualified_name": "shop.cart.total"})
+        dispatcher.node("Function", {"qualified_name": "shop.cart.tax"})
+        dispatcher.flush()
+        dispatcher.finish()
+        dispatcher.finish()
+
+        assert sink.events == [
+            "start",
+            ("node", "Function", "shop.cart.total"),
+            ("node", "Function", "shop.cart.tax"),
+            "flush",
+            "finish",
+        ]
+
+    def test_failing_sink_is_dropped(self):
+        sink = RecordingSink()
+        dispatcher = SinkDispatcher([FailingSink(), sink])
+
+        dispatcher.node("Function", {"qualified_name": "a"})
+        dispatcher.node("Function", {"qualified_name": "b"})
+
+        assert dispatcher.sinks == [sink]
+        assert sink.events[1:] == [
+            ("node", "Function", "a"),
+            ("node", "Function", "b"),
+        ]
+
+    def test_sink_added_after_start_is_started(self):
+        first, late = RecordingSink(), RecordingSink()
+        dispatcher = SinkDispatcher([first])
+        dispatcher.node("Function", {"qualified_name": "a"})
+
+        dispatcher.add(late)
+        dispatcher.node("Function", {"qualified_name": "b"})
+        dispatcher.remove(late)
+        dispatcher.node("Function", {"qualified_name": "c"})
+
+        assert late.events == ["start", ("node", "Function", "b")]
+        assert len(first.events) == 4
+
+
+class TestIngestorSinks:
+    """Test that the ingestor streams its writes to sinks."""
+
+    def test_ingestor_events(self):
+        sink = RecordingSink()
+        ingestor = MemgraphIngestor("localhost", 7687, sinks=[sink])
+
+        ingestor.ensure_node_batch("Function", {"qualified_name": "shop.cart.total"})
+        ingestor.ensure_relationship_batch(
+            ("Module", "qualified_name", "shop.cart"),
+            "DEFINES",
+            ("Function", "qualified_name", "shop.cart.total"),
+        )
+        ingestor.__exit__(None, None, None)
+
+        assert sink.events == [
+            "start",
+            ("node", "Function", "shop.cart.total"),
+            ("relationship", "DEFINES"),
+            "flush",
+            "finish",
+        ]
+
+
+class TestJsonLinesSink:
+    """Test the built-in JSON Lines sink."""
+
+    def test_writes_events(self, tmp_path):
+        path = tmp_path / "out" / "graph.jsonl"
+        dispatcher = SinkDispatcher([JsonLinesSink(str(path))])
+
+        dispatcher.node("File", {"path": "shop/cart.py"})
+        dispatcher.relationship(
+            ("Module", "qualified_name", "shop.cart"),
+            "IMPORTS",
+            ("Module", "qualified_name", "shop.tax"),
+            None,
+        )
+        dispatcher.finish()
+
+        records = [json.loads(line) for line in path.read_text().splitlines()]
+        assert [r["event"] for r in records] == [
+            "start",
+            "node",
+            "relationship",
+            "finish",
+        ]
+        assert records[1]["properties"] == {"path": "shop/cart.py"}
+        assert records[2]["to"] == ["Module", "qualified_name", "shop.tax"]
+
+
+class TestCreateSink:
+    """Test resolving sink names."""
+
+    def test_builtin_and_import_path(self):
+        module = types.ModuleType("custom_sinks")
+        module.RecordingSink = RecordingSink
+        module.not_a_sink = lambda: object()
+        sys.modules["custom_sinks"] = module
+        try:
+            assert isinstance(create_sink("jsonl"), JsonLinesSink)
+            assert isinstance(create_sink("custom_sinks:RecordingSink"), RecordingSink)
+            # Unknown and invalid sinks are skipped with a warning
+            sinks = load_sinks(
+                "custom_sinks:RecordingSink, missing, custom_sinks:not_a_sink"
+            )
+        finally:
+            del sys.modules["custom_sinks"]
+
+        assert [type(s) for s in sinks] == [RecordingSink]
@@ -0,0 +1,96 @@
+"""Tests for batched UNWIND writes and retries of conflicting batches."""
+
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.services.graph_service import MemgraphIngestor
+
+
+def ingestor(**options) -> MemgraphIngestor:
+    return MemgraphIngestor("localhost", 7687, sinks=[], **options)
+
+
+class TestBatches:
+    """Test how buffered nodes and relationships are grouped into queries."""
+
+    def test_nodes_grouped_by_label_and_properties(self):
+        writer = ingestor(batch_size=100)
+        writer.ensure_node_batch("Function", {"qualified_name": "a.f", "name": "f"})
+        writer.ensure_node_batch("Function", {"qualified_name": "a.g", "name": "g"})
+        writer.ensure_node_batch(
+            "Function", {"qualified_name": "a.h", "name": "h", "docstring": "H."}
+        )
+        writer.ensure_node_batch("File", {"path": "a.py"})
+
+        batches = writer.node_batches()
+
+        assert [len(rows) for _, rows in batches] == [2, 1, 1]
+        plain, documented, file = (query for query, _ in batches)
+        assert "docstring" not in plain
+        assert "n.docstring = row.docstring" in documented
+        assert file.startswith("MERGE (n:File {path: row.path})")
+
+    def test_relationship_to_node_of_unknown_label(self):
+        writer = ingestor(batch_size=100)
+        for target in ("shop.tax.rate", "shop.tax.round"):
+            writer.ensure_relationship_batch(
+                ("Module", "qualified_name", "shop.cart"),
+                "REQUIRES",
+                ("", "qualified_name", target),
+            )
+
+        ((query, rows),) = writer.relationship_batches()
+
+        assert query.startswith(
+            "MATCH (a:Module {qualified_name: row.from_val}), "
+            "(b {qualified_name: row.to_val})"
+        )
+        assert "SET r" not in query
+        assert [row["to_val"] for row in rows] == ["shop.tax.rate", "shop.tax.round"]
+
+    def test_flushed_at_batch_size(self):
+        writer = ingestor(batch_size=2)
+        writer._execute_batch = MagicMock()
+
+        writer.ensure_node_batch("File", {"path": "a.py"})
+        writer._execute_batch.assert_not_called()
+        writer.ensure_node_batch("File", {"path": "b.py"})
+
+        writer._execute_batch.assert_called_once()
+        assert writer.node_buffer == []
+
+
+class TestRetries:
+    """Test retrying batches that conflict with another transaction."""
+
+    def test_conflicts_are_retried(self):
+        writer = ingestor(write_retries=2)
+        writer.conn = MagicMock()
+        cursor = writer.conn.cursor.return_value
+        cursor.execute.side_effect = [
+            RuntimeError("Cannot resolve conflicting transactions."),
+            None,
+        ]
+
+        with patch("codebase_rag.services.graph_service.time.sleep") as sleep:
+            writer._execute_batch("MERGE (n:File {path: row.path})", [{"path": "a"}])
+
+        assert cursor.execute.call_count == 2
+        sleep.assert_called_once()
+        query, params = cursor.execute.call_args.args
+        assert query.startswith("UNWIND $batch AS row\n")
+        assert params == {"batch": [{"path": "a"}]}
+
+    def test_other_errors_and_exhausted_retries_give_up(self):
+        writer = ingestor(write_retries=1)
+        writer.conn = MagicMock()
+        cursor = writer.conn.cursor.return_value
+        cursor.execute.side_effect = RuntimeError("conflicting transactions")
+
+        with patch("codebase_rag.services.graph_service.time.sleep"):
+            writer._execute_batch("MERGE (n:File {path: row.path})", [{"path": "a"}])
+        assert cursor.execute.call_count == 2
+
+        cursor.execute.reset_mock()
+        cursor.execute.side_effect = RuntimeError("Invalid input 'MERG'")
+        writer._execute_batch("MERG (n)", [{"path": "a"}])
+        assert cursor.execute.call_count == 1
@@ -0,0 +1,219 @@
+"""Tests for churn/complexity hotspot analysis."""
+
+import subprocess
+import tempfile
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.complexity import calculate_cyclomatic_complexity
+from codebase_rag.analysis.hotspots import (
+    HotspotAnalyzer,
+    function_churn,
+    score_file_hotspots,
+    score_function_hotspots,
+)
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.version_control.git_analyzer import DiffHunk, GitAnalyzer
+
+
+def _first_function(source: str, language: str = "python"):
+    parsers, queries = load_parsers()
+    tree = parsers[language].parse(source.encode("utf-8"))
+    captures = queries[language]["functions"].captures(tree.root_node)
+    return captures["function"][0]
+
+
+class TestCyclomaticComplexity:
+    """Test cyclomatic complexity calculation."""
+
+    def test_straight_line_function(self):
+        func = _first_function("def f(x):\n    return x + 1\n")
+        assert calculate_cyclomatic_complexity(func, "python") == 1
+
+    def test_branches_and_loops(self):
+        source = (
+            "def f(items):\n"
+            "    total = 0\n"
+            "    for item in items:\n"
+            "        if item > 0 and item < 10:\n"
+            "            total += item\n"
+            "        elif item == 0:\n"
+            "            continue\n"
+            "    while total > 100:\n"
+            "        total -= 1\n"
+            "    return total\n"
+        )
+        func = _first_function(source)
+        # for + if + and + elif + while
+        assert calculate_cyclomatic_complexity(func, "python") == 6
+
+    def test_nested_functions_are_excluded(self):
+        source = (
+            "def outer(x):\n"
+            "    def inner(y):\n"
+            "        if y:\n"
+            "            return 1\n"
+            "        return 0\n"
+            "    return inner(x)\n"
+        )
+        func = _first_function(source)
+        assert calculate_cyclomatic_complexity(func, "python") == 1
+
+    def test_unknown_language_defaults_to_one(self):
+        func = _first_function("def f(x):\n    if x:\n        return 1\n")
+        assert calculate_cyclomatic_complexity(func, "cobol") == 1
+
+
+class TestHotspotScoring:
+    """Test hotspot scoring from graph rows and churn."""
+
+    @pytest.fixture
+    def rows(self):
+        return [
+            {"qualified_name": "proj.a.f", "label": "Function", "path": "a.py", "complexity": 10, "start_line": 1, "end_line": 4},
+            {"qualified_name": "proj.a.g", "label": "Function", "path": "a.py", "complexity": 2, "start_line": 7, "end_line": 8},
+            {"qualified_name": "proj.b.C.m", "label": "Method", "path": "b.py", "complexity": 5, "start_line": 2, "end_line": 5},
+            {"qualified_name": "proj.c.h", "label": "Function", "path": "c.py", "complexity": None, "start_line": 1, "end_line": 2},
+        ]
+
+    def test_function_ranking(self, rows):
+        churn = {"proj.a.f": 3, "proj.a.g": 3, "proj.b.C.m": 8}
+        hotspots = score_function_hotspots(rows, churn)
+
+        assert [h.qualified_name for h in hotspots] == [
+            "proj.b.C.m",
+            "proj.a.f",
+            "proj.a.g",
+            "proj.c.h",
+        ]
+        assert hotspots[0].score == 40
+        assert hotspots[0].normalized_score == 1.0
+        assert hotspots[1].normalized_score == pytest.approx(0.75)
+        # Missing complexity falls back to 1, missing churn to 0
+        assert hotspots[-1].complexity == 1
+        assert hotspots[-1].score == 0
+
+    def test_file_ranking(self, rows):
+        churn = {"a.py": 3, "b.py": 2, "c.py": 1}
+        hotspots = score_file_hotspots(rows, churn)
+
+        assert [h.path for h in hotspots] == ["a.py", "b.py", "c.py"]
+        assert hotspots[0].complexity == 12
+        assert hotspots[0].score == 36
+        assert all(h.label == "File" for h in hotspots)
+
+    def test_empty_inputs(self):
+        assert score_function_hotspots([], {"a.py": 1}) == []
+        assert score_file_hotspots([], {}) == []
+
+    def test_analyzer_stores_scores(self, rows):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = rows
+        analyzer = HotspotAnalyzer(ingestor)
+
+        history = [{"b.py": [DiffHunk(3, 1, 3, 1)]}] * 8
+        hotspots = analyzer.rank_functions(history, limit=2)
+        assert len(hotspots) == 2
+
+        analyzer.store_scores(hotspots)
+        ingestor.ensure_node_batch.assert_any_call(
+            "Method",
+            {"qualified_name": "proj.b.C.m", "churn": 8, "hotspot_score": 1.0},
+        )
+        ingestor.flush_all.assert_called_once()
+
+    def test_analyzer_stores_file_scores_by_path(self, rows):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = rows
+        analyzer = HotspotAnalyzer(ingestor)
+
+        analyzer.store_scores(analyzer.rank_files({"a.py": 1}, limit=1))
+        ingestor.ensure_node_batch.assert_called_once_with(
+            "File", {"path": "a.py", "churn": 1, "hotspot_score": 1.0}
+        )
+
+
+class TestFunctionChurn:
+    """Test counting the commits changing each function's lines."""
+
+    @pytest.fixture
+    def rows(self):
+        # f is lines 1-4 and g lines 7-8 of a.py at HEAD
+        return [
+            {"qualified_name": "f", "path": "a.py", "start_line": 1, "end_line": 4},
+            {"qualified_name": "g", "path": "a.py", "start_line": 7, "end_line": 8},
+        ]
+
+    def test_only_changed_functions_count(self, rows):
+        history = [{"a.py": [DiffHunk(8, 1, 8, 1)]}, {"b.py": [DiffHunk(1, 1, 1, 1)]}]
+
+        assert function_churn(rows, history) == {"g": 1}
+
+    def test_removed_lines_count_for_the_function_around_them(self, rows):
+        history = [{"a.py": [DiffHunk(3, 2, 2, 0)]}]
+
+        assert function_churn(rows, history) == {"f": 1}
+
+    def test_spans_follow_lines_added_above(self, rows):
+        history = [
+            # Two lines added above g, then older changes to what were f and g
+            {"a.py": [DiffHunk(4, 0, 5, 2)]},
+            {"a.py": [DiffHunk(5, 1, 5, 1)]},
+            {"a.py": [DiffHunk(2, 1, 2, 1)]},
+        ]
+
+        assert function_churn(rows, history) == {"f": 1, "g": 1}
+
+    def test_history_stops_at_the_commit_adding_a_function(self, rows):
+        history = [
+            {"a.py": [DiffHunk(5, 0, 6, 3)]},
+            # Lines 6-7 before g was added
+            {"a.py": [DiffHunk(6, 2, 6, 2)]},
+        ]
+
+        assert function_churn(rows, history) == {"g": 1}
+
+
+class TestFileChurn:
+    """Test Git churn collection."""
+
+    @pytest.fixture
+    def git_repo(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            repo_path = Path(temp_dir)
+            subprocess.run(["git", "init"], cwd=repo_path, check=True)
+            subprocess.run(["git", "config", "user.name", "Test User"], cwd=repo_path, check=True)
+            subprocess.run(["git", "config", "user.email", "test@example.com"], cwd=repo_path, check=True)
+
+            for i in range(3):
+                (repo_path / "hot.py").write_text(f"x = {i}\n")
+                if i == 0:
+                    (repo_path / "cold.py").write_text("y = 1\n")
+                subprocess.run(["git", "add", "."], cwd=repo_path, check=True)
+                subprocess.run(["git", "commit", "-m", f"commit {i}"], cwd=repo_path, check=True)
+
+            yield repo_path
+
+    def test_get_file_churn(self, git_repo):
+        churn = GitAnalyzer(git_repo).get_file_churn()
+        assert churn == {"hot.py": 3, "cold.py": 1}
+
+    def test_get_file_churn_max_commits(self, git_repo):
+        churn = GitAnalyzer(git_repo).get_file_churn(max_commits=1)
+        assert churn == {"hot.py": 1}
+
+    def test_get_diff_hunks(self, git_repo):
+        history = GitAnalyzer(git_repo).get_diff_hunks()
+
+        assert history == [
+            {"hot.py": [DiffHunk(1, 1, 1, 1)]},
+            {"hot.py": [DiffHunk(1, 1, 1, 1)]},
+            {"cold.py": [DiffHunk(0, 0, 1, 1)], "hot.py": [DiffHunk(0, 0, 1, 1)]},
+        ]
+
+    def test_non_git_directory(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            assert GitAnalyzer(Path(temp_dir)).get_file_churn() == {}
+            assert GitAnalyzer(Path(temp_dir)).get_diff_hunks() == []
@@ -0,0 +1,170 @@
+"""Tests for selecting the tests a change can affect."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.impact import (
+    CALLERS_QUERY,
+    TESTS_IN_PATHS_QUERY,
+    TESTS_OF_QUERY,
+    IMPACT_COMMENT_MARKER,
+    ImpactAnalyzer,
+    go_test_runs,
+    impact_comment,
+)
+from codebase_rag.analysis.review import SYMBOLS_IN_PATHS_QUERY, FileDiff
+
+
+def _symbol(qn: str, path: str, start: int, end: int) -> dict:
+    return {
+        "qualified_name": qn,
+        "label": "Function",
+        "path": path,
+        "start_line": start,
+        "end_line": end,
+        "complexity": 1,
+    }
+
+
+def _test(qn: str, path: str, start: int, end: int, label="TestFunction") -> dict:
+    return {
+        "qualified_name": qn,
+        "label": label,
+        "name": qn.rsplit(".", 1)[-1],
+        "path": path,
+        "start_line": start,
+        "end_line": end,
+    }
+
+
+SYMBOLS = [
+    _symbol("shop.pricing.discount", "pricing/discount.go", 10, 20),
+    _symbol("shop.pricing.round", "pricing/discount.go", 22, 25),
+    _symbol("shop.tax.rate", "tax/rate.go", 3, 9),
+]
+# discount <- Total <- Checkout; rate is called by nothing
+CALLERS = {
+    "shop.pricing.discount": ["shop.cart.Total"],
+    "shop.cart.Total": ["shop.api.Checkout"],
+}
+DISCOUNT_TEST = ("shop.pricing.TestDiscount", "pricing/discount_test.go")
+TESTS = {
+    "shop.pricing.discount": [DISCOUNT_TEST],
+    "shop.api.Checkout": [("shop.api.TestCheckout", "api/checkout_test.go")],
+    "shop.cart.Total": [DISCOUNT_TEST],
+}
+TEST_FILES = [
+    _test("shop.cart.TestTotal", "cart/cart_test.go", 5, 15),
+    _test("shop.cart.TestEmpty", "cart/cart_test.go", 17, 20),
+]
+
+
+def _fetch_all(query: str, params: dict) -> list[dict]:
+    if query == SYMBOLS_IN_PATHS_QUERY:
+        return [s for s in SYMBOLS if s["path"] in params["paths"]]
+    if query == TESTS_IN_PATHS_QUERY:
+        return [t for t in TEST_FILES if t["path"] in params["paths"]]
+    if query == CALLERS_QUERY:
+        return [
+            {"callee": qn, "caller": caller}
+            for qn in params["qualified_names"]
+            for caller in CALLERS.get(qn, [])
+        ]
+    assert query == TESTS_OF_QUERY
+    return [
+        {
+            "target": qn,
+            "qualified_name": test,
+            "label": "TestFunction",
+            "name": test.rsplit(".", 1)[-1],
+            "path": path,
+        }
+        for qn in params["qualified_names"]
+        for test, path in TESTS.get(qn, [])
+    ]
+
+
+def _analyzer() -> ImpactAnalyzer:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = _fetch_all
+    return ImpactAnalyzer(ingestor)
+
+
+class TestImpactAnalyzer:
+    """Test walking from changed lines to the tests that reach them."""
+
+    def test_callers_and_tests(self):
+        report = _analyzer().analyze(
+            [FileDiff("pricing/discount.go", added_lines={12})]
+        )
+
+        assert [s.qualified_name for s in report.changed] == ["shop.pricing.discount"]
+        assert [(t.qualified_name, t.via, t.distance) for t in report.tests] == [
+            ("shop.api.TestCheckout", "shop.api.Checkout", 2),
+            # Linked directly and through Total; the direct link is kept
+            ("shop.pricing.TestDiscount", "shop.pricing.discount", 0),
+        ]
+        assert report.packages == ["api", "pricing"]
+        assert report.untested == []
+
+    def test_untested_change(self):
+        report = _analyzer().analyze([FileDiff("tax/rate.go", removed_at={4})])
+
+        assert report.tests == []
+        assert report.untested == ["shop.tax.rate"]
+
+    def test_module_level_change(self):
+        # An import line changes every function of the file
+        report = _analyzer().analyze([FileDiff("pricing/discount.go", added_lines={3})])
+
+        assert [s.qualified_name for s in report.changed] == [
+            "shop.pricing.discount",
+            "shop.pricing.round",
+        ]
+        assert report.untested == ["shop.pricing.round"]
+
+    def test_edited_tests(self):
+        analyzer = _analyzer()
+
+        inside = analyzer.analyze([FileDiff("cart/cart_test.go", added_lines={18})])
+        # A helper between the tests may be used by any of them
+        outside = analyzer.analyze([FileDiff("cart/cart_test.go", added_lines={2})])
+
+        assert [t.qualified_name for t in inside.tests] == ["shop.cart.TestEmpty"]
+        assert [t.qualified_name for t in outside.tests] == [
+            "shop.cart.TestEmpty",
+            "shop.cart.TestTotal",
+        ]
+        assert inside.changed == []
+
+    def test_go_test_runs(self):
+        report = _analyzer().analyze(
+            [FileDiff("pricing/discount.go", added_lines={12})]
+        )
+
+        assert go_test_runs(report) == [
+            "./api -run '^(TestCheckout)$'",
+            "./pricing -run '^(TestDiscount)$'",
+        ]
+
+    def test_deleted_file(self):
+        report = _analyzer().analyze([FileDiff("old.go", is_deleted=True)])
+
+        assert report.changed == [] and report.tests == []
+
+    def test_impact_comment(self):
+        report = _analyzer().analyze(
+            [
+                FileDiff("pricing/discount.go", added_lines={12}),
+                FileDiff("tax/rate.go", removed_at={4}),
+            ]
+        )
+
+        comment = impact_comment(report, limit=1)
+
+        lines = comment.splitlines()
+        assert lines[0] == IMPACT_COMMENT_MARKER
+        assert lines[1].startswith("**2 tests cover your diff**, across 2 package(s)")
+        assert "| `shop.api.TestCheckout` | api/checkout_test.go |" in comment
+        assert "TestDiscount" not in comment
+        assert "…and 1 more." in comment
+        assert lines[-1] == "No test reaches: `shop.tax.rate`"
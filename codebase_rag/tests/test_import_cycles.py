@@ -0,0 +1,78 @@
+"""Tests for package-level import cycle detection."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.import_cycles import (
+    IMPORT_EDGES_QUERY,
+    ImportCycleAnalyzer,
+    find_package_cycles,
+)
+
+
+def _import(source_path: str, target_path: str, line_number: int) -> dict:
+    def module(path: str) -> str:
+        return "app." + path.removesuffix(".py").replace("/", ".")
+
+    return {
+        "source": module(source_path),
+        "source_path": source_path,
+        "target": module(target_path),
+        "target_path": target_path,
+        "line_number": line_number,
+        "symbol": None,
+    }
+
+
+EDGES = [
+    _import("orders/service.py", "billing/invoice.py", 3),
+    _import("orders/models.py", "billing/invoice.py", 5),
+    _import("billing/invoice.py", "orders/models.py", 12),
+    # Within one package
+    _import("orders/service.py", "orders/models.py", 4),
+    # Into the cycle but not part of it
+    _import("api/views.py", "orders/service.py", 1),
+    _import("shipping/rates.py", "shipping/zones.py", 2),
+]
+
+
+class TestImportCycles:
+    """Test grouping imports into packages and finding their cycles."""
+
+    def test_package_cycle(self):
+        [cycle] = find_package_cycles(EDGES)
+
+        assert cycle.packages == ["billing", "orders"]
+        # The single import is the cheapest to break, so it comes first
+        assert [(d.source, d.target) for d in cycle.dependencies] == [
+            ("billing", "orders"),
+            ("orders", "billing"),
+        ]
+        assert [site.location for site in cycle.dependencies[1].imports] == [
+            "orders/models.py:5",
+            "orders/service.py:3",
+        ]
+        assert cycle.dependencies[0].imports[0].target_module == "app.orders.models"
+
+    def test_no_cycles(self):
+        assert find_package_cycles(EDGES[3:]) == []
+
+    def test_largest_cycle_first(self):
+        edges = EDGES + [
+            _import("a/x.py", "b/y.py", 1),
+            _import("b/y.py", "c/z.py", 1),
+            _import("c/z.py", "a/x.py", 1),
+        ]
+
+        cycles = find_package_cycles(edges)
+
+        assert [c.packages for c in cycles] == [["a", "b", "c"], ["billing", "orders"]]
+
+    def test_analyzer(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = lambda query, *args: {
+            IMPORT_EDGES_QUERY: EDGES
+        }[query]
+
+        [cycle] = ImportCycleAnalyzer(ingestor).analyze()
+
+        assert cycle.to_dict()["packages"] == ["billing", "orders"]
@@ -0,0 +1,121 @@
+"""Tests for updating the graph from the files changed in Git."""
+
+import subprocess
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.graph_updater import (
+    DEPENDENT_FILES_QUERY,
+    PROTO_DEPENDENT_FILES_QUERY,
+    GraphUpdater,
+)
+from codebase_rag.version_control.changes import changed_paths, parse_name_status
+
+
+@pytest.fixture
+def repo(tmp_path: Path):
+    def git(*args: str) -> str:
+        return subprocess.run(
+            ["git", "-C", str(tmp_path), *args],
+            capture_output=True,
+            text=True,
+            check=True,
+        ).stdout.strip()
+
+    git("init", "--quiet")
+    git("config", "user.email", "dev@example.com")
+    git("config", "user.name", "Dev")
+    (tmp_path / "cart.py").write_text("def total():\n    return 0\n")
+    (tmp_path / "legacy.py").write_text("def old():\n    pass\n")
+    (tmp_path / "tax.py").write_text("RATE = 0.2\n")
+    git("add", ".")
+    git("commit", "--quiet", "-m", "initial")
+    return tmp_path, git
+
+
+class TestChangedPaths:
+    """Test the files to re-ingest between commits or in the working tree."""
+
+    def test_name_status(self):
+        changes = parse_name_status("M\tcart.py\nR087\told.py\tnew.py\nD\ttax.py\n")
+
+        assert changes.changed == ["cart.py", "new.py"]
+        assert changes.removed == ["old.py", "tax.py"]
+
+    def test_working_tree(self, repo):
+        path, git = repo
+        (path / "cart.py").write_text("def total():\n    return 1\n")
+        (path / "billing.py").write_text("def charge():\n    pass\n")
+        git("rm", "--quiet", "legacy.py")
+
+        changes = changed_paths(path, "HEAD")
+
+        assert sorted(changes.changed) == ["billing.py", "cart.py"]
+        assert changes.removed == ["legacy.py"]
+
+    def test_between_commits(self, repo):
+        path, git = repo
+        before = git("rev-parse", "HEAD")
+        git("mv", "legacy.py", "billing.py")
+        git("commit", "--quiet", "-m", "rename")
+
+        changes = changed_paths(path, before, "HEAD")
+
+        assert (changes.changed, changes.removed) == (["billing.py"], ["legacy.py"])
+        with pytest.raises(ValueError, match="is not checked out"):
+            changed_paths(path, "HEAD", before)
+
+
+class TestUpdateFiles:
+    """Test re-ingesting changed files and re-linking their dependents."""
+
+    def test_dependents_are_parsed_again_without_deleting(self, repo):
+        path, _ = repo
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [{"path": "tax.py"}]
+        updater = GraphUpdater(ingestor, path, {"python": MagicMock()}, {})
+
+        with patch.object(GraphUpdater, "parse_and_ingest_file") as parse:
+            updater.update_files(["cart.py"], ["legacy.py"])
+
+        query, params = ingestor.fetch_all.call_args.args
+        assert query == DEPENDENT_FILES_QUERY
+        assert params == {"paths": ["cart.py", "legacy.py"]}
+        deleted = [c.args[1]["path"] for c in ingestor.execute_write.call_args_list]
+        assert "tax.py" not in deleted
+        assert [c.args[0] for c in parse.call_args_list] == [
+            path / "cart.py",
+            path / "tax.py",
+        ]
+
+    def test_proto_files(self, repo):
+        path, _ = repo
+        (path / "money.proto").write_text(
+            "package shop;\nmessage Money { int64 units = 1; }\n"
+        )
+        (path / "orders.proto").write_text(
+            "package shop;\nmessage Order { Money total = 1; Item item = 2; }\n"
+        )
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = lambda query, params=None: {
+            DEPENDENT_FILES_QUERY: [],
+            PROTO_DEPENDENT_FILES_QUERY: [{"path": "orders.proto"}],
+        }.get(query, [{"qualified_name": "shop.Item"}])
+        updater = GraphUpdater(ingestor, path, {}, {})
+
+        updater.update_files(["money.proto"], [])
+
+        ingestor.execute_write.assert_any_call(
+            "MATCH (m:ProtoMessage) WHERE m.path IN $paths DETACH DELETE m",
+            {"paths": ["money.proto"]},
+        )
+        # The dependent file is read again, and linked to the message of the
+        # changed file and to the one only in the graph
+        references = [
+            (c.args[0][2], c.args[2][2])
+            for c in ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "REFERENCES"
+        ]
+        assert references == [("shop.Order", "shop.Money"), ("shop.Order", "shop.Item")]
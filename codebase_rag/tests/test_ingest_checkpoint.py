@@ -0,0 +1,126 @@
+"""Tests for checkpointing a full ingestion and resuming it."""
+
+from pathlib import Path
+from unittest.mock import patch
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.ingest_checkpoint import IngestCheckpoint
+from codebase_rag.parse_cache import CachedFile, ExtractionRecorder
+from codebase_rag.services.dry_run import DryRunIngestor
+
+
+def checkpoint_for(repo: Path, interval: int = 1) -> IngestCheckpoint:
+    return IngestCheckpoint(repo / ".checkpoints", repo, interval)
+
+
+class TestIngestCheckpoint:
+    """Test recording, restoring and invalidating stored files."""
+
+    def test_saved_files_are_restored_when_resuming(self, tmp_path):
+        (tmp_path / "cart.py").write_text("def total(): pass\n")
+        checkpoint = checkpoint_for(tmp_path, interval=10)
+        checkpoint.start("options")
+        checkpoint.mark("files", "cart.py")
+
+        # Pending until their writes are flushed
+        assert not checkpoint.due
+        assert checkpoint_for(tmp_path).start("options", resume=True) == 0
+
+        checkpoint.save()
+        resumed = checkpoint_for(tmp_path)
+
+        assert resumed.start("options", resume=True) == 1
+        assert resumed.is_stored("files", "cart.py")
+        assert not resumed.is_stored("calls", "cart.py")
+
+    def test_changed_file_is_not_stored(self, tmp_path):
+        (tmp_path / "cart.py").write_text("def total(): pass\n")
+        checkpoint = checkpoint_for(tmp_path)
+        checkpoint.start("options")
+        checkpoint.mark("files", "cart.py")
+        checkpoint.save()
+        (tmp_path / "cart.py").write_text("def total(items): pass\n")
+
+        resumed = checkpoint_for(tmp_path)
+        resumed.start("options", resume=True)
+
+        assert not resumed.is_stored("files", "cart.py")
+
+    def test_other_options_or_a_fresh_run_start_from_zero(self, tmp_path):
+        (tmp_path / "cart.py").write_text("")
+        checkpoint = checkpoint_for(tmp_path)
+        checkpoint.start("options")
+        checkpoint.mark("files", "cart.py")
+        checkpoint.save()
+
+        assert checkpoint_for(tmp_path).start("other options", resume=True) == 0
+        assert checkpoint.path.exists()
+
+        checkpoint_for(tmp_path).start("options")
+
+        assert not checkpoint.path.exists()
+
+    def test_discarded_files_are_not_stored(self, tmp_path):
+        (tmp_path / "cart.py").write_text("")
+        checkpoint = checkpoint_for(tmp_path)
+        checkpoint.start("options")
+        checkpoint.mark("files", "cart.py")
+        checkpoint.discard()
+        checkpoint.save()
+
+        assert not checkpoint.is_stored("files", "cart.py")
+
+
+class TestSkippingWrites:
+    """Test that skipped writes reach the sinks but not the database."""
+
+    def test_sinks_still_see_skipped_writes(self):
+        ingestor = DryRunIngestor()
+        entry = CachedFile("key")
+        ingestor.sinks.add(ExtractionRecorder(entry))
+        with ingestor.skipping_writes():
+            ingestor.ensure_node_batch("Function", {"qualified_name": "cart.total"})
+        ingestor.ensure_node_batch("Function", {"qualified_name": "cart.tax"})
+
+        report = ingestor.report()
+
+        assert report.node_counts == {"Function": 1}
+        assert len(entry.nodes) == 2
+
+
+class TestResumedIngestion:
+    """Test an interrupted run resumed without writing its stored files again."""
+
+    def test_resume_writes_only_the_remaining_files(self, tmp_path):
+        repo = tmp_path / "repo"
+        repo.mkdir()
+        for name in ("a.txt", "b.txt", "c.txt"):
+            (repo / name).write_text(name)
+        checkpoint = IngestCheckpoint(tmp_path / "checkpoints", repo, 1)
+
+        updater = GraphUpdater(DryRunIngestor(), repo, {}, {}, checkpoint=checkpoint)
+        process_file = updater._process_file
+        processed: list[str] = []
+
+        def interrupted(filepath, parent, parsed=None):
+            if len(processed) == 2:
+                raise KeyboardInterrupt
+            process_file(filepath, parent, parsed)
+            processed.append(filepath.name)
+
+        with (
+            patch.object(updater, "_process_file", interrupted),
+            pytest.raises(KeyboardInterrupt),
+        ):
+            updater.run()
+        assert checkpoint.path.exists()
+
+        ingestor = DryRunIngestor()
+        GraphUpdater(ingestor, repo, {}, {}, checkpoint=checkpoint, resume=True).run()
+        report = ingestor.report()
+
+        remaining = {"a.txt", "b.txt", "c.txt"} - set(processed)
+        assert report.node_counts["File"] == len(remaining) == 1
+        assert not checkpoint.path.exists()
@@ -0,0 +1,115 @@
+"""Tests for the ingestion report and the IngestionRun summary node."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.config import settings
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.ingestion_report import (
+    MAX_REPORTED_WARNINGS,
+    CountingSink,
+    IngestionReport,
+    store_run_summary,
+    write_report,
+)
+from codebase_rag.services.dry_run import DryRunIngestor
+
+STARTED_AT = "2026-10-01T12:00:00+00:00"
+
+
+def counted_sink():
+    sink = CountingSink()
+    sink.on_node("Module", {"qualified_name": "shop.cart"})
+    sink.on_node("Function", {"qualified_name": "shop.cart.total"})
+    sink.on_node("Function", {"qualified_name": "shop.cart.total", "end_line": 9})
+    for _ in range(2):
+        sink.on_relationship(
+            ("Module", "qualified_name", "shop.cart"),
+            "DEFINES",
+            ("Function", "qualified_name", "shop.cart.total"),
+            None,
+        )
+    return sink
+
+
+class TestIngestionReport:
+    """Test counting, classification of skipped files and the JSON file."""
+
+    def test_counting_sink_counts_each_key_once(self):
+        sink = counted_sink()
+
+        assert sink.node_counts() == {"Function": 1, "Module": 1}
+        assert sink.relationship_counts() == {"DEFINES": 1}
+
+    def test_finish_separates_parse_errors(self):
+        report = IngestionReport("3f2a9c", "shop", "/src/shop", STARTED_AT)
+        with report.stage("files"):
+            pass
+
+        report.finish(
+            counted_sink(),
+            {
+                "broken.py": "parse error: invalid syntax",
+                "logo.png": "no parser for .png files",
+            },
+            ["w"] * (MAX_REPORTED_WARNINGS + 5),
+        )
+
+        assert list(report.stages) == ["files"]
+        assert report.parse_errors == {"broken.py": "parse error: invalid syntax"}
+        assert report.skipped_files == {"logo.png": "no parser for .png files"}
+        assert report.warning_count == MAX_REPORTED_WARNINGS + 5
+        assert len(report.warnings) == MAX_REPORTED_WARNINGS
+        assert report.duration_seconds > 0
+
+    def test_write_report_to_default_directory(self, tmp_path, monkeypatch):
+        monkeypatch.setattr(settings, "INGESTION_REPORT_DIR", str(tmp_path))
+        report = IngestionReport("3f2a9c", "shop", "/src/shop", STARTED_AT)
+        report.node_counts = {"Function": 4}
+
+        path = write_report(report)
+
+        assert path == tmp_path / "shop-20261001T120000-3f2a9c.json"
+        data = json.loads(path.read_text())
+        assert data["run_id"] == "3f2a9c"
+        assert data["total_nodes"] == 4
+
+    def test_summary_node(self):
+        ingestor = MagicMock()
+        report = IngestionReport("3f2a9c", "shop", "/src/shop")
+        report.parse_errors = {"broken.py": "parse error: invalid syntax"}
+
+        store_run_summary(ingestor, report)
+
+        label, properties = ingestor.ensure_node_batch.call_args.args
+        assert label == "IngestionRun"
+        assert next(iter(properties)) == "run_id"
+        assert properties["parse_errors"] == 1
+        ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Project", "name", "shop"),
+            "HAS_INGESTION_RUN",
+            ("IngestionRun", "run_id", "3f2a9c"),
+        )
+        ingestor.flush_all.assert_called_once()
+
+
+class TestGraphUpdaterReport:
+    """Test the report a run leaves on the updater."""
+
+    def test_run_produces_report(self, tmp_path):
+        (tmp_path / "app.py").write_text("def main():\n    pass\n")
+        (tmp_path / "notes.txt").write_text("todo")
+
+        ingestor = DryRunIngestor()
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+        updater.run()
+
+        report = updater.report
+        assert report.run_id == updater.run_id
+        assert report.project == tmp_path.name
+        assert {"structure", "files", "calls", "flush"} <= set(report.stages)
+        assert report.node_counts["File"] == 2
+        assert report.relationship_counts["CONTAINS_FILE"] == 2
+        assert "app.py" in report.skipped_files
+        # The counter is detached once the run ends
+        assert ingestor.sinks.sinks == []
@@ -0,0 +1,267 @@
+"""Tests for issue reference extraction and linking issues to commits and code."""
+
+import json
+from datetime import datetime
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.analysis.issues import (
+    HYDRATE_ISSUE_QUERY,
+    ISSUE_QUERY,
+    LINK_PARENT_ISSUE_QUERY,
+    SYNC_TICKET_EDGES_QUERY,
+    TICKET_CODE_QUERY,
+    UNHYDRATED_ISSUES_QUERY,
+    IssueLinker,
+)
+from codebase_rag.analysis.review import SYMBOLS_IN_PATHS_QUERY
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.parsers.issue_references import (
+    IssueReferenceExtractor,
+    extract_issue_references,
+)
+from codebase_rag.services.issue_trackers import GitHubIssueTracker, JiraIssueTracker
+from codebase_rag.version_control.git_analyzer import BlameInfo, CommitInfo
+
+
+def _commit(sha: str, message: str, files: list[str]) -> CommitInfo:
+    return CommitInfo(
+        sha=sha,
+        author="Ann",
+        author_email="ann@example.com",
+        committer="Ann",
+        committer_email="ann@example.com",
+        message=message,
+        date=datetime(2024, 5, 1),
+        files_changed=files,
+        additions=0,
+        deletions=0,
+        parent_shas=[],
+    )
+
+
+def _blame(line: int, sha: str) -> BlameInfo:
+    date = datetime(2024, 5, 1)
+    return BlameInfo(line, sha, "Ann", "ann@example.com", date, "", line)
+
+
+def _keys(text: str, **kwargs) -> list[tuple[str, bool]]:
+    return [(r.key, r.closes) for r in extract_issue_references(text, **kwargs)]
+
+
+class TestIssueReferences:
+    """Test recognizing issue references in free text."""
+
+    def test_github_references(self):
+        text = "Fixes #12, see GH-40 and acme/billing#7 (not a#1 or &#38;)"
+        assert _keys(text) == [("#12", True), ("#40", False), ("acme/billing#7", False)]
+
+    def test_jira_references(self):
+        text = "PAY-101: resolve UTF-8 handling. Closes PAY-102; mentions SHA-256"
+        assert _keys(text) == [("PAY-101", False), ("PAY-102", True)]
+        assert _keys("OPS-3 and PAY-4", jira_projects={"PAY"}) == [("PAY-4", False)]
+
+    def test_repeated_reference_keeps_closing(self):
+        assert _keys("Refs #5. Fixed #5") == [("#5", True)]
+
+    def test_source_comments(self):
+        source = (
+            "# Workaround for PAY-88\n"
+            "def pay(amount):\n"
+            "    label = '#1 not a comment'\n"
+            "    return amount  # see #301\n"
+        )
+        parsers, _ = load_parsers()
+        tree = parsers["python"].parse(source.encode("utf-8"))
+        references = IssueReferenceExtractor().extract(tree.root_node)
+        assert [(r.key, r.line_number) for r in references] == [
+            ("PAY-88", 1),
+            ("#301", 4),
+        ]
+
+
+class TestIssueLinker:
+    """Test linking referencing commits and their blamed functions."""
+
+    def test_links_commits_and_blamed_functions(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {
+                "qualified_name": "shop.cart.total",
+                "label": "Function",
+                "path": "shop/cart.py",
+                "start_line": 10,
+                "end_line": 20,
+            },
+            {
+                "qualified_name": "shop.cart.empty",
+                "label": "Function",
+                "path": "shop/cart.py",
+                "start_line": 30,
+                "end_line": 35,
+            },
+        ]
+        git_analyzer = MagicMock(repo_path="/src/shop")
+        git_analyzer.get_blame_info.return_value = [
+            _blame(12, "aaa"),
+            _blame(31, "bbb"),
+        ]
+        commits = [
+            _commit("aaa", "Round totals\n\nFixes #12", ["shop/cart.py"]),
+            _commit("bbb", "Tidy up", ["shop/cart.py"]),
+        ]
+
+        stats = IssueLinker(ingestor, git_analyzer).link_commits(commits)
+
+        assert stats == {"commits": 1, "issues": 1, "function_links": 1}
+        ingestor.fetch_all.assert_called_once_with(
+            SYMBOLS_IN_PATHS_QUERY, {"paths": ["shop/cart.py"]}
+        )
+        git_analyzer.get_blame_info.assert_called_once_with("/src/shop/shop/cart.py")
+        relationships = [
+            c.args for c in ingestor.ensure_relationship_batch.call_args_list
+        ]
+        assert relationships[0] == (
+            ("Commit", "sha", "aaa"),
+            "REFERENCES_ISSUE",
+            ("Issue", "key", "#12"),
+            {"closes": True},
+        )
+        assert relationships[1:] == [
+            (
+                ("Function", "qualified_name", "shop.cart.total"),
+                "CHANGED_FOR",
+                ("Issue", "key", "#12"),
+                {"commit_sha": "aaa"},
+            )
+        ]
+        ingestor.flush_all.assert_called_once()
+
+    def test_hydrate_github_issues(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"key": "#12", "tracker": "github", "number": 12, "project": ""},
+            {
+                "key": "acme/billing#7",
+                "tracker": "github",
+                "number": 7,
+                "project": "acme/billing",
+            },
+        ]
+        urls = []
+
+        def urlopen(request, timeout):
+            urls.append(request.full_url)
+            body = {
+                "title": "Totals are off by a cent",
+                "state": "closed",
+                "html_url": "https://github.com/acme/shop/issues/12",
+                "labels": [{"name": "bug"}],
+            }
+            response = MagicMock()
+            response.__enter__.return_value.read.return_value = json.dumps(
+                body
+            ).encode()
+            return response
+
+        tracker = GitHubIssueTracker("acme/shop", "ghp_token")
+        with patch("urllib.request.urlopen", urlopen):
+            hydrated = IssueLinker(ingestor, MagicMock()).hydrate_issues([tracker])
+
+        assert hydrated == 2
+        assert urls == [
+            "https://api.github.com/repos/acme/shop/issues/12",
+            "https://api.github.com/repos/acme/billing/issues/7",
+        ]
+        ingestor.fetch_all.assert_called_once_with(
+            UNHYDRATED_ISSUES_QUERY, {"trackers": ["github"], "refresh": False}
+        )
+        query, params = ingestor.execute_write.call_args_list[0].args
+        assert query == HYDRATE_ISSUE_QUERY
+        assert params["properties"]["labels"] == ["bug"]
+        assert params["properties"]["is_pull_request"] is False
+
+    def test_hydrate_jira_tickets(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"key": "PAY-12", "tracker": "jira", "number": 12, "project": "PAY"},
+        ]
+        requests = []
+
+        def urlopen(request, timeout):
+            requests.append(request)
+            body = {
+                "fields": {
+                    "summary": "Round totals",
+                    "status": {"name": "Done"},
+                    "issuetype": {"name": "Sub-task"},
+                    "assignee": {"displayName": "Ann"},
+                    "labels": ["billing"],
+                    "parent": {"key": "PAY-3"},
+                }
+            }
+            response = MagicMock()
+            response.__enter__.return_value.read.return_value = json.dumps(
+                body
+            ).encode()
+            return response
+
+        tracker = JiraIssueTracker(
+            "https://acme.atlassian.net/", "secret", "ann@example.com"
+        )
+        with patch("urllib.request.urlopen", urlopen):
+            hydrated = IssueLinker(ingestor, None).hydrate_issues(
+                [tracker], refresh=True
+            )
+
+        assert hydrated == 1
+        [request] = requests
+        assert request.full_url.startswith(
+            "https://acme.atlassian.net/rest/api/2/issue/PAY-12?fields="
+        )
+        # base64 of "ann@example.com:secret"
+        assert request.get_header("Authorization") == (
+            "Basic YW5uQGV4YW1wbGUuY29tOnNlY3JldA=="
+        )
+        ingestor.fetch_all.assert_called_once_with(
+            UNHYDRATED_ISSUES_QUERY, {"trackers": ["jira"], "refresh": True}
+        )
+        (hydrate, hydrate_params), (link, link_params) = [
+            c.args for c in ingestor.execute_write.call_args_list
+        ]
+        assert hydrate == HYDRATE_ISSUE_QUERY
+        assert hydrate_params["properties"]["url"] == (
+            "https://acme.atlassian.net/browse/PAY-12"
+        )
+        assert hydrate_params["properties"]["issue_type"] == "Sub-task"
+        assert "parent" not in hydrate_params["properties"]
+        assert link == LINK_PARENT_ISSUE_QUERY
+        assert link_params == {
+            "key": "PAY-12",
+            "parent": "PAY-3",
+            "project": "PAY",
+            "number": 3,
+        }
+
+    def test_ticket_edges_and_lookup(self):
+        ingestor = MagicMock()
+        code = [{"qualified_name": "shop.cart.total", "ticket": "PAY-12"}]
+        ingestor.fetch_all.side_effect = [
+            [{"linked": 4}],
+            [{"key": "PAY-3", "title": "Billing"}],
+            code,
+            [],
+        ]
+        linker = IssueLinker(ingestor, None)
+
+        assert linker.sync_ticket_edges() == 4
+        assert linker.find_ticket_code("PAY-3") == (
+            {"key": "PAY-3", "title": "Billing"},
+            code,
+        )
+        assert linker.find_ticket_code("PAY-404") == (None, [])
+        assert [c.args[0] for c in ingestor.fetch_all.call_args_list] == [
+            SYNC_TICKET_EDGES_QUERY,
+            ISSUE_QUERY,
+            TICKET_CODE_QUERY,
+            ISSUE_QUERY,
+        ]
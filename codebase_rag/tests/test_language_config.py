@@ -0,0 +1,57 @@
+"""Tests for disabling languages and overriding file extensions."""
+
+import pytest
+
+from codebase_rag.language_config import (
+    DISABLED_LANGUAGES,
+    EXTENSION_OVERRIDES,
+    configure_languages,
+    get_language_config,
+    language_extensions,
+    parse_extension_map,
+)
+
+
+@pytest.fixture(autouse=True)
+def reset_overrides():
+    yield
+    configure_languages("", "")
+
+
+class TestLanguageOverrides:
+    """Test DISABLED_LANGUAGES and LANGUAGE_EXTENSIONS."""
+
+    def test_parse_extension_map(self):
+        assert parse_extension_map(" .pyi=python, gotmpl=, .H=CPP ,") == {
+            ".pyi": "python",
+            ".gotmpl": "",
+            ".H": "cpp",
+        }
+        with pytest.raises(ValueError):
+            parse_extension_map(".pyi")
+
+    def test_extensions_are_remapped(self):
+        assert configure_languages("", ".pyi=python,.h=cpp,.js=") == []
+
+        assert get_language_config(".pyi").name == "python"
+        assert get_language_config(".h").name == "cpp"
+        assert get_language_config(".js") is None
+        assert ".h" not in language_extensions("c")
+        assert language_extensions("cpp")[-1] == ".h"
+
+    def test_disabled_language(self):
+        configure_languages("Java", ".pyi=python")
+
+        assert get_language_config(".java") is None
+        assert get_language_config(".java", include_disabled=True).name == "java"
+        assert get_language_config(".py").name == "python"
+
+    def test_unknown_names_are_reported_and_ignored(self):
+        problems = configure_languages("cobol", ".cbl=cobol,.pyi=python")
+
+        assert problems == [
+            "Cannot disable unknown language 'cobol'",
+            "Cannot map .cbl to unknown language 'cobol'",
+        ]
+        assert DISABLED_LANGUAGES == set()
+        assert EXTENSION_OVERRIDES == {".pyi": "python"}
@@ -0,0 +1,136 @@
+"""Tests for language plugins."""
+
+from unittest.mock import patch
+
+import pytest
+
+from codebase_rag.language_config import (
+    LANGUAGE_CONFIGS,
+    LanguageConfig,
+    get_language_config,
+)
+from codebase_rag.language_plugins import (
+    PLUGIN_API_VERSION,
+    REGISTERED_PLUGINS,
+    LanguagePlugin,
+    PluginError,
+    discover_plugins,
+    load_language_plugins,
+    load_manifest_plugin,
+    register_language_plugin,
+)
+
+PYTHON_PLUGIN = """\
+from codebase_rag.language_config import LanguageConfig
+from codebase_rag.language_plugins import LanguagePlugin
+
+PLUGIN = LanguagePlugin(
+    config=LanguageConfig(
+        name="verilog",
+        file_extensions=[".v", ".sv"],
+        function_node_types=["function_declaration", "task_declaration"],
+        class_node_types=["module_declaration"],
+        module_node_types=["source_file"],
+    ),
+    language=lambda: None,
+)
+"""
+
+MANIFEST = """\
+api_version = 1
+name = "cobol"
+file_extensions = [".cbl", ".cob"]
+grammar = "libtree-sitter-cobol.so"
+function_node_types = ["paragraph_header"]
+module_node_types = ["start"]
+call_node_types = ["perform_statement_call_proc"]
+
+[queries]
+assignments = "(move_statement) @assignment"
+"""
+
+
+def _plugin(name: str = "cobol", extensions=None, **overrides) -> LanguagePlugin:
+    config = LanguageConfig(
+        name=name,
+        file_extensions=extensions or [".cbl"],
+        function_node_types=["paragraph_header"],
+        class_node_types=[],
+        module_node_types=["start"],
+    )
+    return LanguagePlugin(config=config, language=lambda: None, **overrides)
+
+
+@pytest.fixture(autouse=True)
+def restore_registry():
+    configs = dict(LANGUAGE_CONFIGS)
+    yield
+    LANGUAGE_CONFIGS.clear()
+    LANGUAGE_CONFIGS.update(configs)
+    REGISTERED_PLUGINS.clear()
+
+
+class TestRegisterLanguagePlugin:
+    """Test adding plugin languages to the registry."""
+
+    def test_register(self):
+        register_language_plugin(_plugin(extensions=[".cbl", ".py"]))
+
+        config = get_language_config(".cbl")
+        assert config is not None and config.name == "cobol"
+        # Extensions of other languages stay with them
+        assert config.file_extensions == [".cbl"]
+        assert get_language_config(".py").name == "python"
+
+    def test_rejects_builtin_and_foreign_api_version(self):
+        with pytest.raises(PluginError):
+            register_language_plugin(_plugin(name="python", extensions=[".py3"]))
+        with pytest.raises(PluginError):
+            register_language_plugin(_plugin(api_version=PLUGIN_API_VERSION + 1))
+        with pytest.raises(PluginError):
+            register_language_plugin(_plugin(extensions=[".go"]))
+
+
+class TestDiscoverPlugins:
+    """Test plugins found in directories."""
+
+    def test_python_and_manifest_plugins(self, tmp_path):
+        (tmp_path / "verilog.py").write_text(PYTHON_PLUGIN)
+        (tmp_path / "cobol").mkdir()
+        (tmp_path / "cobol" / "plugin.toml").write_text(MANIFEST)
+        (tmp_path / "notes.txt").write_text("not a plugin")
+
+        with patch("codebase_rag.language_plugins.entry_points", return_value=[]):
+            plugins = discover_plugins([tmp_path])
+
+        assert [p.config.name for p in plugins] == ["cobol", "verilog"]
+        cobol = plugins[0]
+        assert cobol.config.call_node_types == ["perform_statement_call_proc"]
+        assert cobol.queries == {"assignments": "(move_statement) @assignment"}
+        assert cobol.source.endswith("plugin.toml")
+
+    def test_broken_plugins_are_skipped(self, tmp_path):
+        (tmp_path / "broken.py").write_text("raise RuntimeError('boom')\n")
+        (tmp_path / "empty.py").write_text("X = 1\n")
+
+        with patch("codebase_rag.language_plugins.entry_points", return_value=[]):
+            assert discover_plugins([tmp_path]) == []
+
+    def test_manifest_requires_grammar(self, tmp_path):
+        manifest = tmp_path / "plugin.toml"
+        manifest.write_text('name = "cobol"\nfile_extensions = [".cbl"]\n')
+
+        with pytest.raises(PluginError):
+            load_manifest_plugin(manifest)
+
+    def test_load_registers_once(self, tmp_path):
+        (tmp_path / "verilog.py").write_text(PYTHON_PLUGIN)
+
+        with (
+            patch("codebase_rag.language_plugins.entry_points", return_value=[]),
+            patch("codebase_rag.language_plugins.USER_PLUGIN_DIR", tmp_path / "none"),
+        ):
+            assert load_language_plugins(str(tmp_path)) == ["verilog"]
+            assert load_language_plugins(str(tmp_path)) == []
+
+        assert get_language_config(".sv").name == "verilog"
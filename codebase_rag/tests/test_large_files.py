@@ -0,0 +1,98 @@
+"""Tests for ingesting source files too large to parse like the rest."""
+
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag import large_files
+from codebase_rag.config import settings
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.large_files import summarize_file
+
+
+@pytest.fixture
+def bundle(tmp_path, monkeypatch):
+    monkeypatch.setattr(settings, "LARGE_FILE_BYTES", 100)
+    monkeypatch.setattr(settings, "MAX_PARSE_BYTES", 1000)
+    path = tmp_path / "dist" / "bundle.js"
+    path.parent.mkdir()
+    path.write_text("// @generated by webpack\n" + "var a = 1;\n" * 20)
+    return tmp_path, path
+
+
+def updater_for(repo, mode: str) -> GraphUpdater:
+    return GraphUpdater(
+        MagicMock(),
+        repo,
+        {"javascript": MagicMock()},
+        {"javascript": {"config": MagicMock()}},
+        large_file_mode=mode,
+    )
+
+
+class TestSummarizeFile:
+    """Test reading a file's size, lines and generated marker in chunks."""
+
+    def test_summary(self, tmp_path):
+        path = tmp_path / "users.pb.go"
+        path.write_bytes(
+            b"// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb\nvar x"
+        )
+
+        with patch.object(large_files, "READ_CHUNK_BYTES", 8):
+            summary = summarize_file(path)
+
+        assert (summary.size_bytes, summary.line_count) == (65, 3)
+        assert summary.generated
+
+    def test_hand_written(self, tmp_path):
+        path = tmp_path / "cart.py"
+        path.write_text("def total():\n    return 0\n")
+
+        assert not summarize_file(path).generated
+        assert summarize_file(path).line_count == 2
+
+
+class TestLargeFileModes:
+    """Test the modes for files above LARGE_FILE_BYTES."""
+
+    def test_summarize(self, bundle):
+        repo, path = bundle
+        updater = updater_for(repo, "summarize")
+
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(path, "javascript")
+
+        parse.assert_not_called()
+        label, props = updater.ingestor.ensure_node_batch.call_args.args
+        assert label == "Module"
+        assert props["line_count"] == 21
+        assert props["generated"] and props["summarized"]
+        assert updater.ingestor.ensure_relationship_batch.call_args.args[0] == (
+            "Folder",
+            "path",
+            "dist",
+        )
+        assert updater.skipped_files["dist/bundle.js"].endswith("summarized")
+
+    def test_skip(self, bundle):
+        repo, path = bundle
+        updater = updater_for(repo, "skip")
+
+        updater.parse_and_ingest_file(path, "javascript")
+
+        updater.ingestor.ensure_node_batch.assert_not_called()
+        assert updater.skipped_files["dist/bundle.js"] == "larger than 100 bytes"
+
+    def test_declarations_only_up_to_the_parse_limit(self, bundle, monkeypatch):
+        repo, path = bundle
+        updater = updater_for(repo, "declarations")
+
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(path, "javascript")
+            assert parse.call_args.kwargs == {"declarations_only": True}
+
+            monkeypatch.setattr(settings, "MAX_PARSE_BYTES", 200)
+            updater.parse_and_ingest_file(path, "javascript")
+            assert parse.call_count == 1
+        assert updater.ingestor.ensure_node_batch.call_args.args[1]["summarized"]
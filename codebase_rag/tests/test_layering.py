@@ -0,0 +1,143 @@
+"""Tests for checking imports and calls against declared layers."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.import_cycles import IMPORT_EDGES_QUERY
+from codebase_rag.analysis.layering import (
+    CALL_EDGES_QUERY,
+    FLAG_CALLS,
+    FLAG_IMPORTS,
+    MODULES_QUERY,
+    LayeringAnalyzer,
+    load_layers,
+)
+
+CONFIG = {
+    "layers": [
+        {"name": "handlers", "packages": ["internal/handlers", "cmd/**"]},
+        {"name": "services", "packages": ["internal/services"]},
+        {"name": "repos", "packages": "internal/store"},
+    ]
+}
+
+
+def _edge(source: str, target: str, line_number: int) -> dict:
+    def name(path: str) -> str:
+        return "shop." + path.removesuffix(".go").replace("/", ".")
+
+    return {
+        "source": name(source),
+        "source_path": source,
+        "target": name(target),
+        "target_path": target,
+        "line_number": line_number,
+    }
+
+
+IMPORTS = [
+    _edge("internal/handlers/user.go", "internal/services/user.go", 5),
+    # Services reaching back up into handlers
+    _edge("internal/services/user.go", "internal/handlers/dto.go", 7),
+    _edge("internal/store/user.go", "pkg/log/log.go", 3),
+]
+CALLS = [
+    _edge("cmd/shop/main.go", "internal/store/user.go", 20),
+    _edge("internal/store/user.go", "internal/services/user.go", 31),
+]
+
+
+def _ingestor() -> MagicMock:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {
+        IMPORT_EDGES_QUERY: IMPORTS,
+        CALL_EDGES_QUERY: CALLS,
+        MODULES_QUERY: [
+            {"qualified_name": "shop.cmd.shop.main", "path": "cmd/shop/main.go"},
+            {"qualified_name": "shop.pkg.log.log", "path": "pkg/log/log.go"},
+        ],
+    }[query]
+    return ingestor
+
+
+class TestLoadLayers:
+    """Test reading layers from a config file."""
+
+    def test_layers(self):
+        handlers, services, repos = load_layers(CONFIG)
+
+        assert handlers.contains("internal/handlers/user.go")
+        assert handlers.contains("cmd/shop/main.go")
+        assert not handlers.contains("internal/handlersx/user.go")
+        assert handlers.may_depend_on(repos) and not repos.may_depend_on(services)
+
+    def test_may_use(self):
+        layers = load_layers(
+            {
+                "layers": [
+                    {"name": "handlers", "packages": ["web"], "may_use": "services"},
+                    {"name": "services", "packages": ["core"]},
+                    {"name": "repos", "packages": ["store"]},
+                ]
+            }
+        )
+
+        assert not layers[0].may_depend_on(layers[2])
+
+    @pytest.mark.parametrize(
+        "layers",
+        [
+            {"name": "web"},
+            [{"packages": ["web"]}],
+            [{"name": "web", "packages": ["web"], "may_use": ["db"]}],
+            [{"name": "web", "packages": ["a"]}, {"name": "web", "packages": ["b"]}],
+        ],
+    )
+    def test_invalid(self, layers):
+        with pytest.raises(ValueError):
+            load_layers({"layers": layers})
+
+
+class TestLayeringAnalyzer:
+    """Test finding and storing violations."""
+
+    def test_violations(self):
+        analyzer = LayeringAnalyzer(_ingestor(), load_layers(CONFIG))
+
+        violations = analyzer.analyze()
+
+        # Skipping a layer is allowed; modules outside every layer are not checked
+        assert [(v.rule, v.kind, v.location) for v in violations] == [
+            ("repos -> services", "call", "internal/store/user.go:31"),
+            ("services -> handlers", "import", "internal/services/user.go:7"),
+        ]
+
+    def test_store(self):
+        ingestor = _ingestor()
+        analyzer = LayeringAnalyzer(ingestor, load_layers(CONFIG))
+
+        stats = analyzer.store(analyzer.analyze())
+
+        ingestor.ensure_node_batch.assert_any_call(
+            "Layer", {"name": "repos", "rank": 2, "packages": ["internal/store"]}
+        )
+        ingestor.ensure_relationship_batch.assert_called_once_with(
+            ("Module", "qualified_name", "shop.cmd.shop.main"),
+            "IN_LAYER",
+            ("Layer", "name", "handlers"),
+        )
+        ingestor.execute_write.assert_any_call(
+            FLAG_IMPORTS,
+            {
+                "edges": [
+                    {
+                        "source": "shop.internal.services.user",
+                        "target": "shop.internal.handlers.dto",
+                        "rule": "services -> handlers",
+                    }
+                ]
+            },
+        )
+        assert FLAG_CALLS in [c.args[0] for c in ingestor.execute_write.call_args_list]
+        assert stats == {"layers": 3, "modules": 1}
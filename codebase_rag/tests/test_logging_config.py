@@ -0,0 +1,85 @@
+"""Tests for leveled text and JSON log output with run and file context."""
+
+import io
+import json
+import sys
+
+import pytest
+from loguru import logger
+
+from codebase_rag.logging_config import configure_logging
+
+
+@pytest.fixture(autouse=True)
+def restore_default_handler():
+    yield
+    logger.remove()
+    logger.configure(patcher=None)
+    logger.add(sys.stderr)
+
+
+class TestJsonLogging:
+    """Test JSON lines with levels, context and exceptions."""
+
+    def test_records_carry_run_and_file_context(self):
+        stream = io.StringIO()
+        configure_logging("INFO", "json", stream=stream)
+
+        logger.debug("not shown at INFO")
+        with logger.contextualize(run_id="3f2a9c", file="shop/cart.py"):
+            logger.warning("unresolved import")
+
+        lines = stream.getvalue().splitlines()
+        assert len(lines) == 1
+        record = json.loads(lines[0])
+        assert record["level"] == "WARNING"
+        assert record["message"] == "unresolved import"
+        assert record["run_id"] == "3f2a9c"
+        assert record["file"] == "shop/cart.py"
+        assert record["function"] == "test_records_carry_run_and_file_context"
+        assert "context" not in record
+
+    def test_exceptions_are_fields(self):
+        stream = io.StringIO()
+        configure_logging("ERROR", "json", stream=stream)
+
+        try:
+            raise UnicodeDecodeError("utf-8", b"\xff", 0, 1, "invalid start byte")
+        except UnicodeDecodeError:
+            logger.exception("parse failed")
+
+        record = json.loads(stream.getvalue())
+        assert record["exception"]["type"] == "UnicodeDecodeError"
+        assert "invalid start byte" in record["exception"]["value"]
+
+    def test_log_file_receives_the_same_records(self, tmp_path):
+        log_file = tmp_path / "ingest.log"
+        configure_logging("INFO", "json", str(log_file), stream=io.StringIO())
+
+        logger.bind(file="main.go").error("parse error")
+        logger.remove()  # Closes the file
+
+        record = json.loads(log_file.read_text().strip())
+        assert record["file"] == "main.go"
+
+
+class TestTextLogging:
+    """Test the human-readable format and option validation."""
+
+    def test_context_prefix(self):
+        stream = io.StringIO()
+        configure_logging("debug", "text", stream=stream)
+
+        logger.info("no context")
+        with logger.contextualize(run_id="3f2a9c", file="shop/cart.py"):
+            logger.debug("parsed")
+
+        first, second = stream.getvalue().splitlines()
+        assert first.endswith("| INFO     | no context")
+        assert second.endswith("| DEBUG    | [3f2a9c shop/cart.py] parsed")
+
+    def test_unknown_format_and_level(self):
+        with pytest.raises(ValueError, match="Unknown log format"):
+            configure_logging("INFO", "xml")
+        with pytest.raises(ValueError):
+            configure_logging("LOUD", "text", stream=io.StringIO())
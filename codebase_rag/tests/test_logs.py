@@ -0,0 +1,136 @@
+"""Tests for logging call extraction and LogStatement ingestion."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.logs import LogAnalyzer
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.parsers.log_extractor import LogExtractor
+
+
+def _extract(source: str, language: str):
+    parsers, _ = load_parsers()
+    if language not in parsers:
+        pytest.skip(f"{language} parser not available")
+    tree = parsers[language].parse(source.encode("utf-8"))
+    return LogExtractor(language).extract(tree.root_node)
+
+
+class TestLogExtractor:
+    """Test recognition of logging calls, levels and message templates."""
+
+    def test_go_libraries(self):
+        source = (
+            "package shop\n"
+            "func Pay(ctx context.Context) {\n"
+            '    slog.ErrorContext(ctx, "payment failed", "id", id)\n'
+            '    logger.Infow("charged", "amount", amount)\n'
+            '    logrus.WithField("id", id).Warnf("retrying %s", id)\n'
+            '    log.Printf("legacy %d", n)\n'
+            '    fmt.Println("done")\n'
+            '    fmt.Errorf("not a log: %w", err)\n'
+            '    http.Error(w, "not a log", 500)\n'
+            "}\n"
+        )
+        statements = _extract(source, "go")
+        assert [(s.library, s.level, s.message) for s in statements] == [
+            ("slog", "error", "payment failed"),
+            ("zap", "info", "charged"),
+            ("logrus", "warn", "retrying %s"),
+            ("log", "info", "legacy %d"),
+            ("print", "info", "done"),
+        ]
+        assert statements[0].line_number == 3
+        assert statements[2].call == "logrus.WithField.Warnf"
+
+    def test_python_logging_and_print(self):
+        source = (
+            "import logging\n"
+            "logger = logging.getLogger(__name__)\n"
+            "def pay(order):\n"
+            "    logger.warning('payment failed for %s', order.id)\n"
+            "    self.log.exception(f'refund {order.id} failed')\n"
+            "    logging.debug('trace')\n"
+            "    print('done')\n"
+            "    logger.setLevel(logging.INFO)\n"
+        )
+        statements = _extract(source, "python")
+        assert [(s.library, s.level, s.message) for s in statements] == [
+            ("logger", "warn", "payment failed for %s"),
+            ("logger", "error", "refund {order.id} failed"),
+            ("logging", "debug", "trace"),
+            ("print", "info", "done"),
+        ]
+
+    def test_javascript_console_and_logger(self):
+        source = (
+            "function pay(id) {\n"
+            "  console.error(`payment ${id} failed`);\n"
+            "  this.logger.info('charged', { id });\n"
+            "  Math.log(2);\n"
+            "}\n"
+        )
+        statements = _extract(source, "javascript")
+        assert [(s.library, s.level, s.message) for s in statements] == [
+            ("console", "error", "payment ${id} failed"),
+            ("logger", "info", "charged"),
+        ]
+
+
+class TestLogIngestion:
+    """Test LogStatement nodes and LOGS relationships created during ingestion."""
+
+    def test_log_statements_linked_to_enclosing_function(
+        self, temp_repo: Path, mock_ingestor: MagicMock
+    ):
+        project = temp_repo / "shop"
+        project.mkdir()
+        (project / "billing.py").write_text(
+            "logger.info('loading billing')\n"
+            "def charge():\n"
+            "    logger.error('payment failed')\n"
+        )
+        parsers, queries = load_parsers()
+        GraphUpdater(mock_ingestor, project, parsers, queries).run()
+
+        logs = {
+            c.args[1]["qualified_name"]: c.args[1]
+            for c in mock_ingestor.ensure_node_batch.call_args_list
+            if c.args[0] == "LogStatement"
+        }
+        assert logs["shop.billing:3:4"]["message"] == "payment failed"
+        assert logs["shop.billing:3:4"]["level"] == "error"
+
+        edges = {
+            (c.args[0], c.args[2][2])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "LOGS"
+        }
+        assert edges == {
+            (("Module", "qualified_name", "shop.billing"), "shop.billing:1:0"),
+            (("Function", "qualified_name", "shop.billing.charge"), "shop.billing:3:4"),
+        }
+
+
+class TestLogAnalyzer:
+    """Test log search query parameters."""
+
+    def test_find_log_statements_normalizes_filters(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+
+        LogAnalyzer(ingestor).find_log_statements(
+            "payment failed", ["ERROR"], path_prefix="billing", limit=5
+        )
+
+        _, params = ingestor.fetch_all.call_args.args
+        assert params == {
+            "text": "payment failed",
+            "levels": ["error"],
+            "library": "",
+            "path_prefix": "billing/",
+            "limit": 5,
+        }
@@ -0,0 +1,236 @@
+"""Tests for the graph-backed language server."""
+
+import io
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.lsp.protocol import (
+    METHOD_NOT_FOUND,
+    SERVER_NOT_INITIALIZED,
+    read_message,
+    write_message,
+)
+from codebase_rag.lsp.server import (
+    ENCLOSING_SYMBOLS_QUERY,
+    EXPLAIN_QUERY,
+    IMPLEMENTATIONS_QUERY,
+    NAMED_SYMBOLS_QUERY,
+    REFERENCES_QUERY,
+    GraphLanguageServer,
+    explain_symbol,
+)
+
+CART = """\
+from shop.pricing import apply_tax
+
+
+def total(items):
+    subtotal = sum(i.price for i in items)
+    return apply_tax(subtotal)
+"""
+
+PRICING = """\
+RATE = 0.2
+
+
+def apply_tax(amount):
+    return amount * (1 + RATE)
+"""
+
+
+def _symbol(name: str, module: str, path: str, start: int, end: int, **extra):
+    return {
+        "qualified_name": f"{module}.{name}",
+        "name": name,
+        "label": "Function",
+        "module": module,
+        "path": path,
+        "start_line": start,
+        "end_line": end,
+        **extra,
+    }
+
+
+TOTAL = _symbol("total", "shop.shop.cart", "shop/cart.py", 4, 6)
+APPLY_TAX = _symbol("apply_tax", "shop.shop.pricing", "shop/pricing.py", 4, 5)
+
+
+def _server(repo: Path, responses: dict[str, list]) -> GraphLanguageServer:
+    (repo / "shop").mkdir()
+    (repo / "shop" / "cart.py").write_text(CART)
+    (repo / "shop" / "pricing.py").write_text(PRICING)
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params: responses.get(query, [])
+    # Module qualified names start with the project, "shop" here
+    server = GraphLanguageServer(ingestor, {"shop": repo})
+    server.handle({"jsonrpc": "2.0", "id": 0, "method": "initialize"})
+    return server
+
+
+def _request(server: GraphLanguageServer, method: str, path: Path, line: int, col: int):
+    response = server.handle(
+        {
+            "jsonrpc": "2.0",
+            "id": 1,
+            "method": method,
+            "params": {
+                "textDocument": {"uri": path.as_uri()},
+                "position": {"line": line, "character": col},
+                "context": {"includeDeclaration": False},
+            },
+        }
+    )
+    return response["result"]
+
+
+class TestProtocol:
+    """Test message framing and the server lifecycle."""
+
+    def test_framing_round_trip(self):
+        stream = io.BytesIO()
+        write_message(stream, {"jsonrpc": "2.0", "id": 1, "method": "shutdown"})
+        stream.seek(0)
+        assert read_message(stream) == {"jsonrpc": "2.0", "id": 1, "method": "shutdown"}
+        assert read_message(stream) is None
+
+    def test_lifecycle(self):
+        server = GraphLanguageServer(MagicMock(), {})
+        early = server.handle({"jsonrpc": "2.0", "id": 1, "method": "shutdown"})
+        assert early["error"]["code"] == SERVER_NOT_INITIALIZED
+
+        root = Path("/srv/checkouts/shop")
+        messages = [
+            {
+                "jsonrpc": "2.0",
+                "id": 1,
+                "method": "initialize",
+                "params": {"rootUri": root.as_uri()},
+            },
+            {"jsonrpc": "2.0", "method": "initialized", "params": {}},
+            {"jsonrpc": "2.0", "id": 2, "method": "workspace/symbol", "params": {}},
+            {"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 2}},
+            {"jsonrpc": "2.0", "id": 3, "method": "shutdown"},
+            {"jsonrpc": "2.0", "method": "exit"},
+        ]
+        reader = io.BytesIO()
+        for message in messages:
+            write_message(reader, message)
+        reader.seek(0)
+        writer = io.BytesIO()
+
+        assert server.serve(reader, writer) == 0
+        assert server.projects == {"shop": root.resolve()}
+
+        writer.seek(0)
+        responses = [read_message(writer) for _ in range(3)]
+        assert [r["id"] for r in responses] == [1, 2, 3]
+        assert responses[1]["error"]["code"] == METHOD_NOT_FOUND
+        assert responses[2]["result"] is None
+        assert read_message(writer) is None
+
+
+class TestNavigation:
+    """Test navigation requests answered from graph rows."""
+
+    def test_definition_prefers_called_symbol(self, temp_repo: Path):
+        other = _symbol("apply_tax", "shop.legacy", "legacy.py", 1, 2)
+        server = _server(
+            temp_repo,
+            {
+                ENCLOSING_SYMBOLS_QUERY: [TOTAL],
+                NAMED_SYMBOLS_QUERY: [
+                    {**other, "called": False},
+                    {**APPLY_TAX, "called": True},
+                ],
+            },
+        )
+        cart = temp_repo / "shop" / "cart.py"
+
+        [location] = _request(server, "textDocument/definition", cart, 5, 14)
+
+        assert location["uri"] == (temp_repo / "shop" / "pricing.py").resolve().as_uri()
+        assert location["range"]["start"] == {"line": 3, "character": 4}
+        params = server.ingestor.fetch_all.call_args_list[1].args[1]
+        assert params == {"name": "apply_tax", "caller": "shop.shop.cart.total"}
+
+    def test_references_point_at_call_sites(self, temp_repo: Path):
+        server = _server(
+            temp_repo,
+            {
+                ENCLOSING_SYMBOLS_QUERY: [APPLY_TAX],
+                REFERENCES_QUERY: [TOTAL],
+            },
+        )
+        pricing = temp_repo / "shop" / "pricing.py"
+
+        # Cursor on the definition's own name
+        [location] = _request(server, "textDocument/references", pricing, 3, 6)
+
+        assert location["uri"].endswith("/shop/cart.py")
+        assert location["range"]["start"] == {"line": 5, "character": 11}
+        assert location["range"]["end"] == {"line": 5, "character": 20}
+
+    def test_implementations_and_open_documents(self, temp_repo: Path):
+        subclass = _symbol("apply_tax", "shop.shop.eu", "shop/eu.py", 1, 2)
+        server = _server(
+            temp_repo,
+            {
+                ENCLOSING_SYMBOLS_QUERY: [APPLY_TAX],
+                IMPLEMENTATIONS_QUERY: [subclass],
+            },
+        )
+        eu = temp_repo / "shop" / "eu.py"
+        # Unsaved buffer content is used over the file on disk
+        server.handle(
+            {
+                "jsonrpc": "2.0",
+                "method": "textDocument/didOpen",
+                "params": {
+                    "textDocument": {
+                        "uri": eu.resolve().as_uri(),
+                        "text": "def  apply_tax(amount):\n    return amount\n",
+                    }
+                },
+            }
+        )
+        pricing = temp_repo / "shop" / "pricing.py"
+
+        [location] = _request(server, "textDocument/implementation", pricing, 3, 6)
+
+        assert location["range"]["start"] == {"line": 0, "character": 5}
+
+    def test_hover_explains_symbol(self, temp_repo: Path):
+        server = _server(
+            temp_repo,
+            {
+                ENCLOSING_SYMBOLS_QUERY: [APPLY_TAX],
+                EXPLAIN_QUERY: [
+                    {
+                        "label": "Function",
+                        "docstring": "Add VAT to an amount.",
+                        "complexity": 1,
+                        "callers": ["shop.shop.cart.total"],
+                        "callees": [],
+                        "tests": [],
+                        "endpoints": [],
+                    }
+                ],
+            },
+        )
+        pricing = temp_repo / "shop" / "pricing.py"
+
+        hover = _request(server, "textDocument/hover", pricing, 3, 6)
+
+        value = hover["contents"]["value"]
+        assert value.startswith("**Function** `shop.shop.pricing.apply_tax`")
+        assert "Add VAT to an amount." in value
+        assert "- Called by: `shop.shop.cart.total`" in value
+        assert "- Tests: none" in value
+
+    def test_explain_truncates_long_lists(self):
+        text = explain_symbol(
+            "shop.Cart",
+            {"label": "Class", "callers": [f"c{i}" for i in range(8)]},
+        )
+        assert "and 3 more" in text
+        assert "Tests" not in text
@@ -0,0 +1,218 @@
+"""Tests for resolving calls through a language server's call hierarchy."""
+
+import sys
+import textwrap
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.lsp_resolution import (
+    DEFINITIONS_QUERY,
+    EXISTING_CALLS_QUERY,
+    CallResolver,
+    LanguageServerClient,
+    LanguageServerError,
+)
+
+HANDLER_GO = """\
+package api
+
+func Handle(s Store) {
+\tsave(s)
+\ts.Put("k")
+}
+
+func save(s Store) {}
+"""
+
+STORE_GO = """\
+package api
+
+type Store interface{ Put(k string) }
+
+type memStore struct{}
+
+func (m *memStore) Put(k string) {}
+"""
+
+DEFINITIONS = [
+    {
+        "qualified_name": "shop.api.handler.Handle",
+        "label": "Function",
+        "name": "Handle",
+        "path": "api/handler.go",
+        "start_line": 3,
+    },
+    {
+        "qualified_name": "shop.api.handler.save",
+        "label": "Function",
+        "name": "save",
+        "path": "api/handler.go",
+        "start_line": 8,
+    },
+    {
+        "qualified_name": "shop.api.store.memStore.Put",
+        "label": "Method",
+        "name": "Put",
+        "path": "api/store.go",
+        "start_line": 7,
+    },
+]
+
+
+class FakeGopls:
+    """Answers for Handle, which calls save and the Store interface's Put."""
+
+    command = "gopls serve"
+
+    def __init__(self, repo: Path):
+        self.handler = (repo / "api/handler.go").as_uri()
+        self.store = (repo / "api/store.go").as_uri()
+        self.opened: list[str] = []
+
+    def notify(self, method, params):
+        if method == "textDocument/didOpen":
+            self.opened.append(params["textDocument"]["uri"])
+
+    def request(self, method, params):
+        if method == "textDocument/prepareCallHierarchy":
+            return [{"name": "caller", "position": params["position"]}]
+        if method == "callHierarchy/outgoingCalls":
+            if params["item"]["position"] != {"line": 2, "character": 5}:
+                return []
+            return [
+                {"to": {"uri": self.handler, "selectionRange": _at(7, 5)}},
+                # Store.Put is declared in the interface, not a graph node
+                {"to": {"uri": self.store, "selectionRange": _at(2, 28)}},
+            ]
+        if method == "textDocument/implementation":
+            assert params["position"] == {"line": 2, "character": 28}
+            return [{"targetUri": self.store, "targetSelectionRange": _at(6, 19)}]
+        raise AssertionError(method)
+
+
+def _at(line: int, character: int) -> dict:
+    position = {"line": line, "character": character}
+    return {"start": position, "end": position}
+
+
+def _repo(tmp_path: Path) -> Path:
+    (tmp_path / "api").mkdir()
+    (tmp_path / "api/handler.go").write_text(HANDLER_GO)
+    (tmp_path / "api/store.go").write_text(STORE_GO)
+    return tmp_path
+
+
+def _graph(existing: list[dict]):
+    def fetch_all(query, params=None):
+        if query == DEFINITIONS_QUERY:
+            return DEFINITIONS
+        if query == EXISTING_CALLS_QUERY:
+            return existing
+        return []
+
+    return fetch_all
+
+
+class TestCallResolver:
+    """Test the edges added from the server's answers."""
+
+    def test_static_and_dynamic_calls_are_added(self, tmp_path):
+        repo = _repo(tmp_path)
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = _graph([])
+        client = FakeGopls(repo)
+
+        stats = CallResolver(ingestor, client, repo, "go").resolve()
+
+        assert stats == {"functions": 3, "calls": 1, "dynamic_calls": 1, "failed": 0}
+        edges = [c.args for c in ingestor.ensure_relationship_batch.call_args_list]
+        assert edges == [
+            (
+                ("Function", "qualified_name", "shop.api.handler.Handle"),
+                "CALLS",
+                ("Function", "qualified_name", "shop.api.handler.save"),
+                {"resolved_by": "lsp", "server": "gopls"},
+            ),
+            (
+                ("Function", "qualified_name", "shop.api.handler.Handle"),
+                "CALLS",
+                ("Method", "qualified_name", "shop.api.store.memStore.Put"),
+                {"resolved_by": "lsp", "server": "gopls", "dynamic": True},
+            ),
+        ]
+        assert client.opened == [client.handler, client.store]
+
+    def test_edges_already_in_the_graph_are_kept_as_they_are(self, tmp_path):
+        repo = _repo(tmp_path)
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = _graph(
+            [{"caller": "shop.api.handler.Handle", "callee": "shop.api.handler.save"}]
+        )
+
+        stats = CallResolver(ingestor, FakeGopls(repo), repo, "go").resolve()
+
+        assert (stats["calls"], stats["dynamic_calls"]) == (0, 1)
+
+
+class TestLanguageServerClient:
+    """Test the stdio client against a minimal server."""
+
+    SERVER = textwrap.dedent(
+        """
+        import json, sys
+
+        def read():
+            length = 0
+            while (line := sys.stdin.buffer.readline().strip()):
+                name, _, value = line.partition(b":")
+                if name.lower() == b"content-length":
+                    length = int(value)
+            return json.loads(sys.stdin.buffer.read(length)) if length else None
+
+        def write(message):
+            body = json.dumps(message).encode()
+            sys.stdout.buffer.write(b"Content-Length: %d\\r\\n\\r\\n" % len(body))
+            sys.stdout.buffer.write(body)
+            sys.stdout.buffer.flush()
+
+        while (message := read()) is not None:
+            method = message.get("method")
+            if method == "exit":
+                break
+            if "id" not in message:
+                continue
+            if method == "textDocument/hover":
+                write({"jsonrpc": "2.0", "id": 99,
+                       "method": "workspace/configuration",
+                       "params": {"items": [{}, {}]}})
+                reply = read()
+                write({"jsonrpc": "2.0", "id": message["id"],
+                       "result": reply["result"]})
+            elif method == "textDocument/definition":
+                write({"jsonrpc": "2.0", "id": message["id"],
+                       "error": {"code": -32603, "message": "no definition"}})
+            else:
+                write({"jsonrpc": "2.0", "id": message["id"],
+                       "result": {"method": method}})
+        """
+    )
+
+    def test_requests_and_server_requests(self, tmp_path):
+        script = tmp_path / "server.py"
+        script.write_text(self.SERVER)
+
+        with LanguageServerClient(f"{sys.executable} {script}", tmp_path) as client:
+            assert client.request("callHierarchy/outgoingCalls", {}) == {
+                "method": "callHierarchy/outgoingCalls"
+            }
+            # The server asks for configuration before answering
+            assert client.request("textDocument/hover", {}) == [None, None]
+            with pytest.raises(LanguageServerError):
+                client.request("textDocument/definition", {})
+
+    def test_missing_server(self, tmp_path):
+        with pytest.raises(LanguageServerError):
+            with LanguageServerClient("no-such-language-server", tmp_path):
+                pass
@@ -0,0 +1,100 @@
+"""Tests for the graph queries behind the MCP server's code graph tools."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.impact import CALLERS_QUERY, TESTS_OF_QUERY
+from codebase_rag.mcp.tools import SYMBOL_DETAILS_QUERY, GraphTools
+from codebase_rag.symbol_search import SYMBOLS_QUERY
+
+# shop.cart.total is called by checkout, itself called by the API handler
+CALLERS = {
+    "shop.cart.total": ["shop.cart.checkout"],
+    "shop.cart.checkout": ["shop.api.handle", "shop.cart.total"],
+}
+
+CHECKOUT_TEST = {
+    "qualified_name": "tests.test_cart.test_checkout",
+    "label": "TestFunction",
+    "path": "tests/test_cart.py",
+}
+
+
+def _graph(query, params=None):
+    if query == SYMBOLS_QUERY:
+        return [
+            {"qualified_name": "shop.cart.total", "label": "Function"},
+            {"qualified_name": "shop.tax.total_tax", "label": "Function"},
+        ]
+    if query == SYMBOL_DETAILS_QUERY:
+        return [
+            {"qualified_name": qn, "path": f"{qn.split('.')[1]}.py", "start_line": 1}
+            for qn in params["qualified_names"]
+        ]
+    if query == CALLERS_QUERY:
+        return [
+            {"callee": qn, "caller": caller}
+            for qn in params["qualified_names"]
+            for caller in CALLERS.get(qn, [])
+        ]
+    if query == TESTS_OF_QUERY:
+        # Linked to checkout and to total itself; the closer link wins
+        return [
+            {"target": "shop.cart.checkout", **CHECKOUT_TEST},
+            {"target": "shop.cart.total", **CHECKOUT_TEST},
+        ]
+    return [{"n": 1}]
+
+
+def _tools() -> GraphTools:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = _graph
+    return GraphTools(ingestor)
+
+
+class TestGraphTools:
+    """Test the answers of each tool from the graph."""
+
+    def test_find_symbol(self):
+        result = _tools().find_symbol("Cart.Total")
+
+        assert result["matches"] == [
+            {
+                "qualified_name": "shop.cart.total",
+                "label": "Function",
+                "score": 1.0,
+                "path": "cart.py",
+                "start_line": 1,
+            }
+        ]
+
+    def test_get_callers_by_depth(self):
+        tools = _tools()
+
+        direct = tools.get_callers("shop.cart.total")
+        both = tools.get_callers("shop.cart.total", depth=2)
+
+        assert [c["qualified_name"] for c in direct["callers"]] == [
+            "shop.cart.checkout"
+        ]
+        assert [(c["qualified_name"], c["depth"]) for c in both["callers"]] == [
+            ("shop.cart.checkout", 1),
+            ("shop.api.handle", 2),
+        ]
+
+    def test_get_tests_for_keeps_the_closest_link(self):
+        result = _tools().get_tests_for("shop.cart.total")
+
+        assert result["tests"] == [
+            {**CHECKOUT_TEST, "via": "shop.cart.total", "depth": 0}
+        ]
+
+    def test_run_cypher_refuses_writes(self):
+        tools = _tools()
+
+        rows = tools.run_cypher("MATCH (n) RETURN 1 AS n")
+        assert rows == {"rows": [{"n": 1}], "truncated": False}
+
+        with pytest.raises(ValueError, match="read-only"):
+            tools.run_cypher("MATCH (f:Function) SET f.checked = true")
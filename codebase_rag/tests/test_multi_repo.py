@@ -0,0 +1,218 @@
+"""Tests for ingesting several repositories into one graph."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag import multi_repo
+from codebase_rag.analysis.go_init import GoPackageFacts
+from codebase_rag.analysis.go_modules import WorkspaceModules, parse_go_mod_file
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.multi_repo import (
+    LINK_REPOSITORIES_QUERY,
+    TAG_REPOSITORY_QUERY,
+    ingest_workspace,
+    ingestion_order,
+    load_repositories,
+    repository_dependencies,
+    repository_entry,
+)
+
+
+def make_workspace(root: Path) -> Path:
+    """An API repository requiring a client library, and an unrelated tool."""
+    for name, go_mod in (
+        ("api", "module example.com/api\n\nrequire example.com/client v1.2.0\n"),
+        ("client", "module example.com/client\n\nrequire golang.org/x/net v0.1.0\n"),
+        ("tools", ""),
+    ):
+        (root / name).mkdir()
+        if go_mod:
+            (root / name / "go.mod").write_text(go_mod)
+    return root
+
+
+class TestRepositories:
+    """Test reading the workspace from a config file and ordering it."""
+
+    def test_load(self, tmp_path):
+        root = make_workspace(tmp_path)
+        document = {"repositories": [{"path": "api"}, {"path": "./client"}]}
+
+        api, client = load_repositories(document, root)
+
+        assert (api.name, api.path) == ("api", (root / "api").resolve())
+        assert api.modules == ["example.com/api"]
+        assert api.requires == ["example.com/client"]
+        assert client.modules == ["example.com/client"]
+
+    @pytest.mark.parametrize(
+        ("entries", "problem"),
+        [
+            ({"path": "api"}, "must be a list"),
+            ([{"name": "api"}], "Repository 1 needs a path"),
+            ([{"path": "missing"}], "is not a directory"),
+            ([{"path": "api"}, {"path": "./api/"}], "'api' is used more than once"),
+        ],
+    )
+    def test_problems(self, tmp_path, entries, problem):
+        root = make_workspace(tmp_path)
+
+        with pytest.raises(ValueError, match=problem):
+            load_repositories({"repositories": entries}, root)
+
+    def test_dependencies_and_order(self, tmp_path):
+        root = make_workspace(tmp_path)
+        repositories = load_repositories(
+            {"repositories": [{"path": "api"}, {"path": "tools"}, {"path": "client"}]},
+            root,
+        )
+
+        assert repository_dependencies(repositories) == {
+            "api": {"client": ["example.com/client"]}
+        }
+        # The client library before the API requiring it
+        assert [r.name for r in ingestion_order(repositories)] == [
+            "tools",
+            "client",
+            "api",
+        ]
+
+    def test_entry(self, tmp_path):
+        root = make_workspace(tmp_path)
+
+        assert repository_entry(root / "client", root / "api") == (
+            '\n[[repositories]]\npath = "../client"\n'
+        )
+
+
+class TestIngestWorkspace:
+    """Test ingesting the repositories in turn and linking their projects."""
+
+    def test_ingest(self, tmp_path, monkeypatch):
+        root = make_workspace(tmp_path)
+        repositories = load_repositories(
+            {"repositories": [{"path": "api"}, {"path": "client"}]}, root
+        )
+        runs = []
+
+        class Updater:
+            def __init__(self, ingestor, repo_path, parsers, queries, **options):
+                self.repo_path = repo_path
+                self.workspace = options["workspace"]
+                self.skip_tests = options["skip_tests"]
+
+            def run(self):
+                runs.append(
+                    (
+                        self.repo_path.name,
+                        dict(self.workspace.functions),
+                        self.skip_tests,
+                    )
+                )
+
+            def go_exports(self):
+                if self.repo_path.name != "client":
+                    return {}
+                return {"example.com/client/v2": {"New": "client.v2.client.New"}}
+
+        monkeypatch.setattr(multi_repo, "GraphUpdater", Updater)
+        ingestor = MagicMock()
+
+        updaters = ingest_workspace(ingestor, repositories, {}, {}, skip_tests=True)
+
+        assert [u.repo_path.name for u in updaters] == ["client", "api"]
+        # The API is ingested knowing the client's exported functions
+        assert runs == [
+            ("client", {}, True),
+            ("api", {"example.com/client/v2": {"New": "client.v2.client.New"}}, True),
+        ]
+        assert updaters[1].workspace.repository("example.com/client/v2") == "client"
+        writes = [c.args for c in ingestor.execute_write.call_args_list]
+        assert writes == [
+            (TAG_REPOSITORY_QUERY, {"repo": "client", "prefix": "client."}),
+            (TAG_REPOSITORY_QUERY, {"repo": "api", "prefix": "api."}),
+            (
+                LINK_REPOSITORIES_QUERY,
+                {
+                    "source": "api",
+                    "target": "client",
+                    "modules": ["example.com/client"],
+                },
+            ),
+        ]
+        ingestor.mark_graph_changed.assert_called_once()
+
+
+def _selector_call(package: str, name: str) -> MagicMock:
+    """A Go `package.name()` call expression."""
+
+    def node(type_: str, text: str) -> MagicMock:
+        return MagicMock(type=type_, text=text.encode())
+
+    operand, field = node("identifier", package), node("field_identifier", name)
+    function = MagicMock(type="selector_expression")
+    function.child_by_field_name.side_effect = {
+        "operand": operand,
+        "field": field,
+    }.get
+    call = MagicMock()
+    call.child_by_field_name.side_effect = {"function": function}.get
+    return call
+
+
+class TestGoResolution:
+    """Test resolving Go calls and modules into other repositories."""
+
+    @staticmethod
+    def _updater(temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        workspace = WorkspaceModules(
+            repositories={"example.com/client": "client"},
+            functions={"example.com/client/v2": {"New": "client.v2.client.New"}},
+        )
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {}, workspace=workspace)
+        updater.go_imports["api.server.main"] = {
+            "clientv2": ("example.com/client/v2", "example.com/client"),
+        }
+        return updater
+
+    def test_calls(self, temp_repo: Path, mock_ingestor: MagicMock):
+        resolve = self._updater(temp_repo, mock_ingestor)._resolve_workspace_call
+
+        assert resolve(_selector_call("clientv2", "New"), "api.server.main") == (
+            "Function",
+            "client.v2.client.New",
+        )
+        assert resolve(_selector_call("clientv2", "Dial"), "api.server.main") is None
+        assert resolve(_selector_call("http", "Get"), "api.server.main") is None
+
+    def test_modules_of_other_repositories_are_kept(
+        self, temp_repo: Path, mock_ingestor: MagicMock
+    ):
+        updater = self._updater(temp_repo, mock_ingestor)
+
+        updater._ensure_go_module_version("example.com/client", "v1.2.0")
+
+        labels = [c.args[0] for c in mock_ingestor.ensure_node_batch.call_args_list]
+        # Its GoModule node is the client repository's, not an external one
+        assert labels == ["ModuleVersion"]
+
+    def test_exports(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.go_mod_files[Path()] = parse_go_mod_file("module example.com/client\n")
+        updater.go_packages = {
+            "client.v2": GoPackageFacts("v2"),
+            "client.v2.client_test": GoPackageFacts("v2"),
+        }
+        for qn, label in [
+            ("client.v2.client.New", "Function"),
+            ("client.v2.client.dial", "Function"),
+            ("client.v2.client.Client.Close", "Method"),
+            ("client.v2.client_test.TestNew", "Function"),
+        ]:
+            updater.function_registry[qn] = label
+
+        assert updater.go_exports() == {
+            "example.com/client/v2": {"New": "client.v2.client.New"}
+        }
@@ -0,0 +1,188 @@
+"""Tests for OpenAPI spec parsing and linking operations to their handlers."""
+
+import json
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.openapi_parser import normalize_route, parse_openapi
+
+ORDERS_SPEC = """openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.4.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+    post:
+      operationId: createOrder
+      summary: Place an order
+  /orders/{id}:
+    parameters:
+      - name: id
+        in: path
+    get:
+      operationId: getOrder
+    delete:
+      operationId: cancelOrder
+      deprecated: true
+  /health:
+    get:
+      operationId: healthCheck
+"""
+
+ROUTES_GO = """package api
+
+func Routes(r chi.Router, g *gin.Engine) {
+	r.Get("/v1/orders", ListOrders)
+	r.Post("/v1/orders", h.CreateOrder)
+	v1 := g.Group("/v1")
+	v1.GET("/orders/:id", GetOrder)
+	http.HandleFunc("/health", Health)
+}
+"""
+
+
+class TestOpenApiParser:
+    """Test reading operations from OpenAPI and Swagger specs."""
+
+    def test_openapi(self):
+        spec = parse_openapi(ORDERS_SPEC, ".yaml")
+
+        assert spec is not None
+        assert (spec.title, spec.version, spec.spec_version) == (
+            "Orders",
+            "1.4.0",
+            "3.0.3",
+        )
+        assert spec.base_path == "/v1"
+        assert [
+            (o.method, o.path, o.operation_id, o.line_number) for o in spec.operations
+        ] == [
+            ("GET", "/orders", "listOrders", 9),
+            ("POST", "/orders", "createOrder", 12),
+            ("GET", "/orders/{id}", "getOrder", 19),
+            ("DELETE", "/orders/{id}", "cancelOrder", 21),
+            ("GET", "/health", "healthCheck", 25),
+        ]
+        assert spec.operations[0].tags == ["orders"]
+        assert spec.operations[1].summary == "Place an order"
+        assert spec.operations[3].deprecated
+        assert spec.route(spec.operations[2]) == "/v1/orders/{id}"
+
+    def test_swagger_json(self):
+        content = json.dumps(
+            {
+                "swagger": "2.0",
+                "info": {"title": "Pets", "version": "1"},
+                "basePath": "/api/",
+                "paths": {"/pets": {"get": {"operationId": "listPets"}}},
+            }
+        )
+
+        spec = parse_openapi(content, ".json")
+
+        assert spec is not None
+        assert spec.spec_version == "2.0"
+        assert spec.base_path == "/api"
+        assert [(o.method, spec.route(o)) for o in spec.operations] == [
+            ("GET", "/api/pets")
+        ]
+
+    def test_not_a_spec(self):
+        assert parse_openapi("name: build\non: [push]\n", ".yml") is None
+        assert parse_openapi('{"name": "web", "version": "1.0.0"}', ".json") is None
+        assert parse_openapi('{"openapi": "3.0.0",', ".json") is None
+
+    def test_normalize_route(self):
+        assert normalize_route("/orders/{id}/") == "/orders/{}"
+        assert normalize_route("/orders/:id/lines/:line") == "/orders/{}/lines/{}"
+        assert normalize_route("/orders/{id:[0-9]+}") == "/orders/{}"
+        assert normalize_route("/files/{path...}") == "/files/{}"
+        assert normalize_route("/static/*filepath") == "/static/{}"
+        assert normalize_route("/") == "/"
+
+
+class TestApiOperationLinking:
+    """Test the Endpoint nodes of a spec and their links to code."""
+
+    def _updater(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        for name in ("ListOrders", "CreateOrder", "GetOrder", "Health"):
+            qn = f"shop.api.routes.{name}"
+            updater.function_registry[qn] = "Function"
+            updater.simple_name_lookup[name].add(qn)
+        updater.function_registry["shop.api.orders.cancel_order"] = "Function"
+        updater.simple_name_lookup["cancel_order"].add("shop.api.orders.cancel_order")
+        updater._ingest_http_endpoints(
+            "api/routes.go", ROUTES_GO, "shop.api.routes", "go"
+        )
+        updater._link_http_endpoints()
+
+        (temp_repo / "openapi.yaml").write_text(ORDERS_SPEC)
+        updater._parse_openapi_file(temp_repo / "openapi.yaml")
+        updater._link_api_operations()
+        return updater
+
+    def test_endpoint_nodes(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._updater(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Endpoint",
+            {
+                "qualified_name": "openapi.yaml:POST /v1/orders",
+                "method": "POST",
+                "route": "/v1/orders",
+                "framework": "openapi",
+                "handler": "createOrder",
+                "path": "openapi.yaml",
+                "line_number": 12,
+                "summary": "Place an order",
+                "tags": [],
+                "deprecated": False,
+                "api_title": "Orders",
+                "api_version": "1.4.0",
+            },
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("File", "path", "openapi.yaml"),
+            "DEFINES_ENDPOINT",
+            ("Endpoint", "qualified_name", "openapi.yaml:GET /v1/orders/{id}"),
+        )
+
+    def test_handlers(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._updater(temp_repo, mock_ingestor)
+
+        handled = {
+            (call.args[0][2], call.args[2][2], call.args[3]["matched_by"])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == "HANDLED_BY" and call.args[0][2].startswith("openapi")
+        }
+        assert handled == {
+            # The full route
+            ("openapi.yaml:GET /v1/orders", "shop.api.routes.ListOrders", "route"),
+            ("openapi.yaml:POST /v1/orders", "shop.api.routes.CreateOrder", "route"),
+            # The route of a router group, without its prefix
+            (
+                "openapi.yaml:GET /v1/orders/{id}",
+                "shop.api.routes.GetOrder",
+                "route",
+            ),
+            # The route without the base path, registered for any method
+            ("openapi.yaml:GET /v1/health", "shop.api.routes.Health", "route"),
+            # No route, but a function named by the operationId
+            (
+                "openapi.yaml:DELETE /v1/orders/{id}",
+                "shop.api.orders.cancel_order",
+                "operation_id",
+            ),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Endpoint", "qualified_name", "openapi.yaml:GET /v1/orders/{id}"),
+            "SPECIFIES",
+            ("Endpoint", "qualified_name", "shop.api.routes:GET /orders/:id"),
+        )
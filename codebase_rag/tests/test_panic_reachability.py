@@ -0,0 +1,214 @@
+"""Tests for panic reachability from exported Go functions."""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.panic_reachability import (
+    DeferredCall,
+    PanicCall,
+    PanicReachabilityAnalyzer,
+    collect_panic_flow,
+    detect_panic_and_recover,
+    find_panic_paths,
+)
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+
+
+def _row(qualified_name, label="Function", panics=False, recovers=False):
+    return {
+        "qualified_name": qualified_name,
+        "label": label,
+        "name": qualified_name.split(".")[-1],
+        "path": "shop/order.go",
+        "calls_panic": panics,
+        "has_recover": recovers,
+    }
+
+
+class TestFindPanicPaths:
+    """Test the search for unrecovered panics over call edges."""
+
+    def test_direct_and_transitive(self):
+        rows = [
+            _row("shop.Checkout"),
+            _row("shop.validate"),
+            _row("shop.mustParse", panics=True),
+            _row("shop.MustLoad", panics=True),
+        ]
+        edges = [
+            ("shop.Checkout", "shop.validate"),
+            ("shop.validate", "shop.mustParse"),
+        ]
+        paths = find_panic_paths(rows, edges)
+        assert [(p.qualified_name, p.chain) for p in paths] == [
+            ("shop.MustLoad", ["shop.MustLoad"]),
+            ("shop.Checkout", ["shop.Checkout", "shop.validate", "shop.mustParse"]),
+        ]
+        assert paths[0].panics_directly
+        assert not paths[1].panics_directly
+
+    def test_recover_stops_propagation(self):
+        rows = [
+            _row("shop.Serve"),
+            _row("shop.safely", recovers=True),
+            _row("shop.handle", panics=True),
+            _row("shop.Guarded", panics=True, recovers=True),
+        ]
+        edges = [("shop.Serve", "shop.safely"), ("shop.safely", "shop.handle")]
+        assert find_panic_paths(rows, edges) == []
+
+    def test_unexported_excluded_by_default(self):
+        rows = [_row("shop.helper", panics=True)]
+        assert find_panic_paths(rows, []) == []
+        [path] = find_panic_paths(rows, [], include_unexported=True)
+        assert path.qualified_name == "shop.helper"
+
+    def test_method_on_unexported_receiver(self):
+        rows = [
+            _row("shop.cart.Add", label="Method", panics=True),
+            _row("shop.Cart.Add", label="Method", panics=True),
+        ]
+        paths = find_panic_paths(rows, [])
+        assert [p.qualified_name for p in paths] == ["shop.Cart.Add"]
+
+    def test_store_results(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [_row("shop.MustLoad", panics=True)],
+            [],
+        ]
+        analyzer = PanicReachabilityAnalyzer(ingestor)
+        analyzer.store_results(analyzer.analyze())
+
+        ingestor.execute_write.assert_called_once()
+        ingestor.ensure_node_batch.assert_called_once_with(
+            "Function", {"qualified_name": "shop.MustLoad", "may_panic": True}
+        )
+        ingestor.flush_all.assert_called_once()
+
+
+class TestPanicDetection:
+    """Test detection of panic calls and deferred recovers in Go source."""
+
+    def test_detect_panic_and_recover(self):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        source = b"""package shop
+
+func MustLoad() {
+    panic("missing config")
+}
+
+func Serve() {
+    defer func() {
+        if r := recover(); r != nil {
+            log(r)
+        }
+    }()
+    handle()
+}
+
+func notDeferred() {
+    recover()
+}
+"""
+        tree = parsers["go"].parse(source)
+        functions = queries["go"]["functions"].captures(tree.root_node)["function"]
+        flags = {
+            f.child_by_field_name("name").text.decode(): detect_panic_and_recover(f)
+            for f in functions
+        }
+        assert flags == {
+            "MustLoad": (True, False),
+            "Serve": (False, True),
+            "notDeferred": (False, False),
+        }
+
+    def test_collect_panic_flow(self):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        source = b"""package calc
+
+func (c *Calculator) Sqrt(x float64) float64 {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    defer func() {
+        if r := recover(); r != nil {
+            c.errors++
+        }
+    }()
+    if x < 0 {
+        panic("negative input")
+    }
+    return math.Sqrt(x)
+}
+"""
+        tree = parsers["go"].parse(source)
+        [method] = queries["go"]["functions"].captures(tree.root_node)["function"]
+
+        assert collect_panic_flow(method) == (
+            [PanicCall(12, '"negative input"')],
+            [DeferredCall(5, "c.mu.Unlock", False), DeferredCall(6, "", True)],
+        )
+
+
+class TestPanicFlowEdges:
+    """Test the PANICS, DEFERS and RECOVERS edges of a Go function."""
+
+    def test_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater.function_registry["calc.calc.closeLog"] = "Function"
+        updater.simple_name_lookup["closeLog"].add("calc.calc.closeLog")
+        flow = (
+            [PanicCall(12, '"negative input"')],
+            [DeferredCall(5, "closeLog", False), DeferredCall(6, "", True)],
+        )
+
+        with patch(
+            "codebase_rag.graph_updater.collect_panic_flow", return_value=flow
+        ):
+            updater._ingest_go_panic_flow(
+                MagicMock(),
+                "calc.calc.Sqrt",
+                "Function",
+                "calc.calc",
+                {("Function", "calc.calc.validate")},
+            )
+
+        mock_ingestor.ensure_node_batch.assert_called_once_with(
+            "PanicSite",
+            {
+                "qualified_name": "calc.calc.Sqrt.panic:12",
+                "line_number": 12,
+                "value": '"negative input"',
+            },
+        )
+        edges = [c.args for c in mock_ingestor.ensure_relationship_batch.call_args_list]
+        sqrt = ("Function", "qualified_name", "calc.calc.Sqrt")
+        site = ("PanicSite", "qualified_name", "calc.calc.Sqrt.panic:12")
+        assert edges == [
+            (sqrt, "PANICS", site),
+            (
+                sqrt,
+                "DEFERS",
+                ("Function", "qualified_name", "calc.calc.closeLog"),
+                {"line_number": 5},
+            ),
+            (
+                sqrt,
+                "RECOVERS",
+                site,
+                {"line_number": 6},
+            ),
+            (
+                sqrt,
+                "RECOVERS",
+                ("Function", "qualified_name", "calc.calc.validate"),
+                {"line_number": 6},
+            ),
+        ]
@@ -0,0 +1,251 @@
+"""Tests for replaying the extraction of unchanged files from the parse cache."""
+
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from codebase_rag.analysis.go_modules import parse_go_mod_file
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parse_cache import CachedFile, ParseCache
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.privacy import HASH_PREFIX, Redactor
+from codebase_rag.services.dry_run import DryRunIngestor
+
+
+def updater_for(
+    repo: Path, cache_dir: Path, redactor: Redactor | None = None
+) -> GraphUpdater:
+    ingestor = DryRunIngestor(batch_size=1000)
+    ingestor.redactor = redactor
+    return GraphUpdater(
+        ingestor,
+        repo,
+        {"python": MagicMock()},
+        {"python": {"config": MagicMock()}},
+        parse_cache=ParseCache(cache_dir, repo),
+    )
+
+
+GO_SOURCE = """package cart
+
+import "example.com/money"
+
+type Cart struct{ items []int }
+
+func (c *Cart) Total() money.Amount {
+	return money.Sum(c.items)
+}
+"""
+
+
+def fake_parse(updater: GraphUpdater):
+    """Stands in for parsing cart.py: one function calling round_price()."""
+
+    def parse(file_path, language, parsed=None):
+        qn = f"{updater.project_name}.cart.total"
+        properties = {"qualified_name": qn, "name": "total"}
+        if updater.ingestor.redactor:
+            properties["docstring"] = "Sum of the cart."
+        updater.ingestor.ensure_node_batch("Function", properties)
+        updater.ingestor.ensure_relationship_batch(
+            ("Module", "qualified_name", f"{updater.project_name}.cart"),
+            "DEFINES",
+            ("Function", "qualified_name", qn),
+        )
+        updater.function_registry[qn] = "Function"
+        updater.function_spans[f"{updater.project_name}.cart"].append(
+            (1, 2, "Function", qn)
+        )
+        updater.ast_cache[file_path] = (MagicMock(), language)
+
+    return parse
+
+
+def fake_calls(updater: GraphUpdater):
+    def process(root_node, module_qn, language):
+        updater.recorded_calls.append(
+            ("Function", f"{module_qn}.total", "round_price")
+        )
+
+    return process
+
+
+def ingest(updater: GraphUpdater, file_path: Path) -> None:
+    with (
+        patch.object(updater, "_parse_and_ingest_file", fake_parse(updater)),
+        patch.object(updater, "_process_calls_in_functions", fake_calls(updater)),
+        patch.object(updater, "_process_calls_in_classes"),
+    ):
+        updater.parse_and_ingest_file(file_path, "python")
+        updater._process_function_calls()
+
+
+class TestParseCache:
+    """Test storing, loading and invalidating cached extractions."""
+
+    def test_entries_match_their_key(self, tmp_path):
+        cache = ParseCache(tmp_path / "cache", tmp_path)
+        key = ParseCache.key(b"def total(): pass\n", "cart.py", "python")
+        cache.store("cart.py", CachedFile(key, nodes=[("File", {"path": "cart.py"})]))
+
+        assert cache.load("cart.py", key).nodes == [("File", {"path": "cart.py"})]
+        assert cache.load("cart.py", ParseCache.key(b"", "cart.py", "python")) is None
+        assert cache.load("tax.py", key) is None
+        assert cache.hits == 1
+
+    def test_unreadable_entry_is_a_miss(self, tmp_path):
+        cache = ParseCache(tmp_path / "cache", tmp_path)
+        cache._entry_path("cart.py").parent.mkdir(parents=True)
+        cache._entry_path("cart.py").write_bytes(b"not a pickle")
+
+        assert cache.load("cart.py", "key") is None
+
+
+class TestReplay:
+    """Test ingesting unchanged files from the cache instead of parsing them."""
+
+    def test_unchanged_file_is_replayed(self, tmp_path):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.py").write_text("def total():\n    return round_price(1)\n")
+        ingest(updater_for(repo, tmp_path / "cache"), repo / "cart.py")
+
+        updater = updater_for(repo, tmp_path / "cache")
+        updater.function_registry["shop.pricing.round_price"] = "Function"
+        updater.simple_name_lookup["round_price"].add("shop.pricing.round_price")
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(repo / "cart.py", "python")
+            updater._process_function_calls()
+
+        parse.assert_not_called()
+        assert updater.ingestor.node_buffer == [
+            ("Function", {"qualified_name": "shop.cart.total", "name": "total"})
+        ]
+        assert updater.function_registry["shop.cart.total"] == "Function"
+        assert updater.function_spans["shop.cart"] == [
+            (1, 2, "Function", "shop.cart.total")
+        ]
+        assert (
+            ("Function", "qualified_name", "shop.cart.total"),
+            "CALLS",
+            ("Function", "qualified_name", "shop.pricing.round_price"),
+            None,
+        ) in updater.ingestor.relationship_buffer
+
+    def test_changed_and_uncached_files_are_parsed(self, tmp_path):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.py").write_text("def total():\n    return 0\n")
+        (repo / "cart_test.py").write_text("def test_total():\n    pass\n")
+        ingest(updater_for(repo, tmp_path / "cache"), repo / "cart.py")
+        ingest(updater_for(repo, tmp_path / "cache"), repo / "cart_test.py")
+        (repo / "cart.py").write_text("def total():\n    return 1\n")
+
+        updater = updater_for(repo, tmp_path / "cache")
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(repo / "cart.py", "python")
+            updater.parse_and_ingest_file(repo / "cart_test.py", "python")
+
+        assert parse.call_count == 2
+        assert updater.parse_cache.hits == 0
+
+    def test_private_runs_are_parsed(self, tmp_path):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.py").write_text("def total():\n    return 0\n")
+        ingest(updater_for(repo, tmp_path / "cache", Redactor()), repo / "cart.py")
+
+        updater = updater_for(repo, tmp_path / "cache", Redactor())
+        ingest(updater, repo / "cart.py")
+
+        assert updater.parse_cache.hits == 0
+        docstring = updater.ingestor.node_buffer[0][1]["docstring"]
+        # Hashed once, as a replay would hash the hash again
+        assert docstring == Redactor().hash("Sum of the cart.")
+        assert docstring.startswith(HASH_PREFIX)
+
+        # Nor does a private run leave entries for a plain one to replay
+        updater = updater_for(repo, tmp_path / "cache")
+        with patch.object(updater, "_parse_and_ingest_file") as parse:
+            updater.parse_and_ingest_file(repo / "cart.py", "python")
+        parse.assert_called_once()
+
+    def test_git_history_is_read_again(self, tmp_path):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.py").write_text("def total():\n    return 0\n")
+        updater = updater_for(repo, tmp_path / "cache")
+        updater.git_analyzer = MagicMock()
+        with patch.object(updater, "_analyze_git_info") as history:
+            ingest(updater, repo / "cart.py")
+        history.assert_called_once_with(repo / "cart.py", "shop.cart")
+
+        updater = updater_for(repo, tmp_path / "cache")
+        updater.git_analyzer = MagicMock()
+        with (
+            patch.object(updater, "_parse_and_ingest_file") as parse,
+            patch.object(updater, "_analyze_git_info") as history,
+        ):
+            updater.parse_and_ingest_file(repo / "cart.py", "python")
+
+        # Replayed, with the commits made since read from Git
+        parse.assert_not_called()
+        history.assert_called_once_with(repo / "cart.py", "shop.cart")
+
+    def test_go_keys_follow_go_mod(self, tmp_path):
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.go").write_text(GO_SOURCE)
+        updater = GraphUpdater(
+            DryRunIngestor(),
+            repo,
+            {"go": MagicMock()},
+            {"go": {"config": MagicMock()}},
+            parse_cache=ParseCache(tmp_path / "cache", repo),
+        )
+        updater.go_mod_files[Path()] = parse_go_mod_file("module example.com/shop\n")
+        key, _ = updater._lookup_cached(repo / "cart.go", "go")
+
+        updater.go_mod_files[Path()] = parse_go_mod_file(
+            "module example.com/shop\n\nrequire example.com/money v1.2.0\n"
+        )
+
+        assert key is not None
+        assert updater._lookup_cached(repo / "cart.go", "go")[0] != key
+
+    def test_go_file_is_replayed_and_parsed_for_its_calls(self, tmp_path):
+        parsers, queries = load_parsers()
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+        repo = tmp_path / "shop"
+        repo.mkdir()
+        (repo / "cart.go").write_text(GO_SOURCE)
+
+        def go_updater() -> GraphUpdater:
+            updater = GraphUpdater(
+                DryRunIngestor(batch_size=1000),
+                repo,
+                parsers,
+                queries,
+                parse_cache=ParseCache(tmp_path / "cache", repo),
+            )
+            updater.go_mod_files[Path()] = parse_go_mod_file(
+                "module example.com/shop\n\nrequire example.com/money v1.2.0\n"
+            )
+            return updater
+
+        parsed = go_updater()
+        parsed.parse_and_ingest_file(repo / "cart.go", "go")
+        nodes = list(parsed.ingestor.node_buffer)
+        parsed._process_function_calls()
+
+        replayed = go_updater()
+        replayed.parse_and_ingest_file(repo / "cart.go", "go")
+
+        assert replayed.parse_cache.hits == 1
+        # Written once, by the replay
+        assert replayed.ingestor.node_buffer == nodes
+        assert replayed.function_spans == parsed.function_spans
+        assert replayed.go_imports == parsed.go_imports
+        assert repo / "cart.go" in replayed.ast_cache
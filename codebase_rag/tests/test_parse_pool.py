@@ -0,0 +1,123 @@
+"""Tests for parsing files on worker threads and ingestion progress."""
+
+import threading
+import time
+from unittest.mock import patch
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parse_pool import ParsePool
+from codebase_rag.services.dry_run import DryRunIngestor
+
+
+class FakeParser:
+    created: list[int] = []
+
+    def __init__(self, language):
+        self.language = language
+        FakeParser.created.append(threading.get_ident())
+
+    def parse(self, source):
+        # The first file parses slowest, yet must still come back first
+        if source.startswith(b"slow"):
+            time.sleep(0.2)
+        return (self.language, source)
+
+
+class RecordingProgress:
+    def __init__(self):
+        self.total = None
+        self.advanced = 0
+        self.finished = False
+
+    def start(self, total):
+        self.total = total
+
+    def advance(self):
+        self.advanced += 1
+
+    def finish(self):
+        self.finished = True
+
+
+class TestParsePool:
+    """Test the order of parsed files and per-thread parsers."""
+
+    def test_results_in_walk_order(self, tmp_path):
+        names = ["a.py", "b.py", "notes.txt", "c.py"]
+        (tmp_path / "a.py").write_text("slow = 1")
+        for name in names[1:]:
+            (tmp_path / name).write_text(name)
+        files = [
+            (tmp_path / name, None if name.endswith(".txt") else "python")
+            for name in names
+        ]
+
+        with (
+            patch("codebase_rag.parse_pool.Parser", FakeParser),
+            ParsePool({"python": FakeParser("grammar")}, workers=3) as pool,
+        ):
+            FakeParser.created = []
+            futures = list(pool.parse_in_order(files))
+            results = [f.result() if f else None for f in futures]
+
+        assert [r.source if r else None for r in results] == [
+            b"slow = 1",
+            b"b.py",
+            None,
+            b"c.py",
+        ]
+        assert results[0].tree == ("grammar", b"slow = 1")
+        # One parser per thread and language, never shared between threads
+        assert len(FakeParser.created) == len(set(FakeParser.created))
+
+    def test_read_errors_raise_from_the_result(self, tmp_path):
+        with (
+            patch("codebase_rag.parse_pool.Parser", FakeParser),
+            ParsePool({"python": FakeParser("grammar")}, workers=2) as pool,
+        ):
+            (future,) = pool.parse_in_order([(tmp_path / "gone.py", "python")])
+            with pytest.raises(FileNotFoundError):
+                future.result()
+
+    def test_default_workers(self):
+        with patch("codebase_rag.parse_pool.os.cpu_count", return_value=10):
+            pool = ParsePool({})
+
+        assert pool.workers == 8
+
+
+class TestFilePass:
+    """Test the file pass of an ingestion run, in parallel or not."""
+
+    def test_progress_and_filters(self, tmp_path):
+        (tmp_path / "src").mkdir()
+        (tmp_path / "src" / "app.py").write_text("")
+        (tmp_path / "src" / "test_app.py").write_text("")
+        (tmp_path / "src" / "index.js").write_text("")
+        (tmp_path / "src" / "style.css").write_text("")
+        (tmp_path / "docs").mkdir()
+        (tmp_path / "docs" / "guide.py").write_text("")
+        progress = RecordingProgress()
+
+        updater = GraphUpdater(
+            DryRunIngestor(),
+            tmp_path,
+            {},
+            {},
+            parallel=True,
+            num_workers=2,
+            folder_filter="src",
+            file_pattern="*.py, *.js",
+            skip_tests=True,
+            progress=progress,
+        )
+        updater.run()
+
+        assert (progress.total, progress.advanced, progress.finished) == (2, 2, True)
+        skipped = updater.skipped_files
+        assert skipped["docs/guide.py"] == "outside --folder-filter"
+        assert skipped["src/test_app.py"] == "test file (--skip-tests)"
+        assert skipped["src/style.css"] == "does not match --file-pattern"
+        assert skipped["src/app.py"] == "no python grammar installed"
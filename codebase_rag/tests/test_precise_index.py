@@ -0,0 +1,173 @@
+"""Tests for importing SCIP and LSIF indexes."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.precise_index import (
+    DEFINITIONS_IN_PATHS_QUERY,
+    PRUNE_CALLS_QUERY,
+    Occurrence,
+    PreciseIndex,
+    PreciseIndexImporter,
+    parse_lsif,
+    parse_scip,
+)
+from codebase_rag.utils.protobuf_wire import encode_field, encode_packed
+
+AREA = "scip-go gomod example.com/geo v1 `example.com/geo`/Shape#Area()."
+SQUARE_AREA = "scip-go gomod example.com/geo v1 `example.com/geo`/Square#Area()."
+TOTAL = "scip-go gomod example.com/geo v1 `example.com/geo`/Total()."
+
+
+def _occurrence(symbol: str, line: int, roles: int = 0) -> bytes:
+    return (
+        encode_packed(1, (line, 5, 9))
+        + encode_field(2, symbol)
+        + encode_field(3, roles)
+    )
+
+
+def _scip_index() -> bytes:
+    relationship = encode_field(1, AREA) + encode_field(3, 1)  # is_implementation
+    document = b"".join(
+        [
+            encode_field(1, "geo/shapes.go"),
+            encode_field(2, _occurrence(SQUARE_AREA, 9, roles=1)),
+            encode_field(2, _occurrence(TOTAL, 13, roles=1)),
+            encode_field(2, _occurrence(SQUARE_AREA, 15)),
+            encode_field(2, _occurrence("local 3", 14)),
+            encode_field(
+                3, encode_field(1, SQUARE_AREA) + encode_field(4, relationship)
+            ),
+        ]
+    )
+    metadata = encode_field(2, encode_field(1, "scip-go"))
+    return encode_field(1, metadata) + encode_field(2, document)
+
+
+def _vertex(vertex_id: int, label: str, **properties) -> dict:
+    return {"id": vertex_id, "type": "vertex", "label": label, **properties}
+
+
+def _edge(edge_id: int, label: str, out_vertex: int, **properties) -> dict:
+    edge = {"id": edge_id, "type": "edge", "label": label, "outV": out_vertex}
+    return {**edge, **properties}
+
+
+def _graph_rows() -> list[dict]:
+    rows = [
+        ("geo.shapes.Shape", "Class", 3, 5),
+        ("geo.shapes.Shape.Area", "Method", 4, 4),
+        ("geo.shapes.Square", "Class", 7, 11),
+        ("geo.shapes.Square.Area", "Method", 10, 10),
+        ("geo.shapes.Total", "Function", 14, 18),
+    ]
+    return [
+        {
+            "qualified_name": qn,
+            "label": label,
+            "path": "geo/shapes.go",
+            "start_line": start,
+            "end_line": end,
+        }
+        for qn, label, start, end in rows
+    ]
+
+
+class TestIndexParsing:
+    """Test decoding of SCIP and LSIF indexes."""
+
+    def test_scip(self):
+        index = parse_scip(_scip_index())
+
+        assert index.tool == "scip-go"
+        assert index.occurrences == [
+            Occurrence(SQUARE_AREA, "geo/shapes.go", 10, True),
+            Occurrence(TOTAL, "geo/shapes.go", 14, True),
+            Occurrence(SQUARE_AREA, "geo/shapes.go", 16, False),
+        ]
+        assert index.implementations == [(SQUARE_AREA, AREA)]
+
+    def test_lsif(self):
+        root = "file:///src/geo"
+        elements = [
+            _vertex(1, "metaData", projectRoot=root, toolInfo={"name": "lsif-go"}),
+            _vertex(2, "document", uri=f"{root}/geo/shapes.go"),
+            _vertex(3, "resultSet"),
+            _vertex(4, "range", start={"line": 9, "character": 5}),
+            _vertex(5, "range", start={"line": 15, "character": 9}),
+            _edge(6, "next", 4, inV=3),
+            _edge(7, "next", 5, inV=3),
+            _vertex(8, "definitionResult"),
+            _edge(9, "textDocument/definition", 3, inV=8),
+            _edge(10, "item", 8, inVs=[4], document=2),
+            _edge(11, "contains", 2, inVs=[4, 5]),
+        ]
+        lines = [json.dumps(element) for element in elements]
+
+        index = parse_lsif(lines)
+
+        assert index.tool == "lsif-go"
+        assert index.occurrences == [
+            Occurrence("lsif:3", "geo/shapes.go", 10, True),
+            Occurrence("lsif:3", "geo/shapes.go", 16, False),
+        ]
+
+
+class TestPreciseIndexImporter:
+    """Test linking index symbols to graph nodes."""
+
+    def test_records_calls_and_implementations(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = _graph_rows()
+        index = parse_scip(_scip_index())
+        # The implemented interface method lives in the same file
+        index.occurrences.append(Occurrence(AREA, "geo/shapes.go", 4, True))
+
+        stats = PreciseIndexImporter(ingestor).import_index(index)
+
+        assert stats["calls"] == 1
+        assert stats["implementations"] == 1
+        calls = [c.args for c in ingestor.ensure_relationship_batch.call_args_list]
+        assert calls[0] == (
+            ("Function", "qualified_name", "geo.shapes.Total"),
+            "CALLS",
+            ("Method", "qualified_name", "geo.shapes.Square.Area"),
+            {"precise": True, "indexer": "scip-go"},
+        )
+        assert calls[1][1:3] == (
+            "OVERRIDES",
+            ("Method", "qualified_name", "geo.shapes.Shape.Area"),
+        )
+        ingestor.flush_all.assert_called_once()
+
+    def test_prefix_and_prune(self):
+        ingestor = MagicMock()
+        rows = [{**row, "path": "services/geo/shapes.go"} for row in _graph_rows()]
+        responses = {
+            DEFINITIONS_IN_PATHS_QUERY: rows,
+            PRUNE_CALLS_QUERY: [{"pruned": 2}],
+        }
+        ingestor.fetch_all.side_effect = lambda query, params: responses[query]
+        index = PreciseIndex(
+            "scip-go",
+            [
+                Occurrence(TOTAL, "geo/shapes.go", 14, True),
+                Occurrence(SQUARE_AREA, "geo/shapes.go", 10, True),
+                Occurrence(SQUARE_AREA, "geo/shapes.go", 16),
+            ],
+        )
+
+        stats = PreciseIndexImporter(ingestor).import_index(
+            index, path_prefix="services/", prune=True
+        )
+
+        assert stats["pruned_calls"] == 2
+        # The caller's own index is left untouched
+        assert index.occurrences[0].path == "geo/shapes.go"
+        params = ingestor.fetch_all.call_args_list[-1].args[1]
+        assert params["paths"] == ["services/geo/shapes.go"]
+        confirmed = {row["caller"]: row["confirmed"] for row in params["callers"]}
+        assert confirmed["geo.shapes.Total"] == ["geo.shapes.Square.Area"]
+        assert confirmed["geo.shapes.Shape.Area"] == []
+        assert "geo.shapes.Shape" not in confirmed
@@ -0,0 +1,135 @@
+"""Tests for private ingestion."""
+
+from pathlib import Path
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.privacy import HASH_PREFIX, Redactor
+from codebase_rag.services.graph_service import MemgraphIngestor
+from codebase_rag.services.graph_sinks import GraphSink
+
+WORKFLOW = """name: CI
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: go test -race ./internal/...
+      - name: Lint
+        run: make lint
+"""
+
+MAKEFILE = """.PHONY: lint
+lint:
+\tgolangci-lint run --timeout 5m ./internal/...
+\tbash scripts/lint.sh --fix
+"""
+
+
+class RecordingSink(GraphSink):
+    """Keeps everything the ingestor hands to sinks."""
+
+    def __init__(self):
+        self.received: list = []
+
+    def on_node(self, label, properties):
+        self.received.append((label, properties))
+
+    def on_relationship(self, from_node, rel_type, to_node, properties):
+        self.received.append((from_node, rel_type, to_node, properties))
+
+
+class TestRedactor:
+    """Test which properties are hashed and how."""
+
+    def test_structure_is_kept_and_text_hashed(self):
+        redactor = Redactor()
+        properties = redactor.properties(
+            {
+                "qualified_name": "shop.cart.total",
+                "start_line": 3,
+                "cyclomatic_complexity": 4,
+                "kind": "method",
+                "docstring": "Sum the cart, see INTERNAL-42.",
+                "commands": ["deploy --token $PROD_TOKEN"],
+                "summary": "Refund an order",
+                "decorators": ["route('/admin/refund')", "cached"],
+                "is_exported": True,
+                "value": None,
+            }
+        )
+
+        assert properties["qualified_name"] == "shop.cart.total"
+        assert properties["start_line"] == 3
+        assert properties["cyclomatic_complexity"] == 4
+        assert properties["kind"] == "method"
+        assert properties["is_exported"] is True
+        assert properties["value"] is None
+        assert properties["docstring"].startswith(HASH_PREFIX)
+        # Properties not known to be structural are hashed too
+        assert properties["commands"][0].startswith(HASH_PREFIX)
+        assert properties["summary"].startswith(HASH_PREFIX)
+        assert "admin" not in properties["decorators"][0]
+        assert properties["decorators"][0].startswith('route("sha256:')
+        assert properties["decorators"][1] == "cached"
+
+    def test_hashes_are_stable_and_keyed(self):
+        assert Redactor().hash("secret") == Redactor().hash("secret")
+        assert Redactor("k1").hash("secret") != Redactor().hash("secret")
+        assert Redactor("k1").hash("secret") != Redactor("k2").hash("secret")
+
+    def test_fingerprints_tell_keys_apart(self):
+        assert Redactor().fingerprint == Redactor().fingerprint
+        assert Redactor("k1").fingerprint != Redactor().fingerprint
+        assert Redactor("k1").fingerprint != Redactor("k2").fingerprint
+        assert "k1" not in Redactor("k1").fingerprint
+
+    def test_references_match_redacted_keys(self):
+        redactor = Redactor()
+        node = redactor.properties({"text": "assert total == 3"})
+
+        assert redactor.node_ref(("Assertion", "text", "assert total == 3")) == (
+            "Assertion",
+            "text",
+            node["text"],
+        )
+        assert redactor.node_ref(("Function", "qualified_name", "a.b")) == (
+            "Function",
+            "qualified_name",
+            "a.b",
+        )
+
+    def test_private_ingestor(self):
+        ingestor = MemgraphIngestor("localhost", 7687, sinks=[], private=True)
+        ingestor.ensure_node_batch(
+            "Function", {"qualified_name": "a", "docstring": "d"}
+        )
+        ingestor.ensure_relationship_batch(
+            ("TestCase", "qualified_name", "t"), "ASSERTS", ("Assertion", "text", "x")
+        )
+
+        assert ingestor.node_buffer[0][1]["docstring"].startswith(HASH_PREFIX)
+        assert ingestor.relationship_buffer[0][2][2].startswith(HASH_PREFIX)
+        assert MemgraphIngestor("localhost", 7687, sinks=[]).redactor is None
+
+    def test_no_commands_reach_sinks(self, tmp_path: Path):
+        workflows = tmp_path / ".github" / "workflows"
+        workflows.mkdir(parents=True)
+        (workflows / "ci.yml").write_text(WORKFLOW)
+        (tmp_path / "internal").mkdir()
+        (tmp_path / "Makefile").write_text(MAKEFILE)
+        sink = RecordingSink()
+        ingestor = MemgraphIngestor(
+            "localhost", 7687, batch_size=1000, sinks=[sink], private=True
+        )
+        updater = GraphUpdater(ingestor, tmp_path, {}, {})
+        updater._identify_structure()
+        updater._parse_workflow_file(workflows / "ci.yml")
+        updater._parse_makefile(tmp_path / "Makefile")
+        updater._link_workflows()
+        updater._link_makefiles()
+
+        received = repr(sink.received)
+        assert "Makefile:lint" in received
+        assert ".github/workflows/ci.yml:test:1" in received
+        for text in ("go test", "make lint", "golangci-lint", "--fix", "--timeout"):
+            assert text not in received
@@ -0,0 +1,263 @@
+"""Tests for .proto parsing and the ProtoService, RpcMethod and ProtoMessage nodes."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.proto_parser import (
+    ProtoSyntaxError,
+    parse_proto,
+    resolve_type,
+)
+
+USERS_PROTO = """syntax = "proto3";
+
+// Users of the shop
+package users.v1;
+
+import "google/protobuf/timestamp.proto";
+import public "users/v1/common.proto";
+
+option go_package = "example.com/shop/gen/users/v1;usersv1";
+option (custom.settings) = { retries: 3 };
+
+service UserService {
+  option deprecated = true;
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc WatchUsers(GetUserRequest) returns (stream User) {
+    option (google.api.http) = { get: "/v1/users/{id}" };
+  }
+  rpc Import(stream User.Address) returns (.users.v1.User) {}
+}
+
+message GetUserRequest {
+  string id = 1 [json_name = "userId"];
+}
+
+/* A user,
+   with their addresses */
+message User {
+  message Address {
+    string city = 1;
+    Country country = 2;
+  }
+  enum Role { ROLE_UNSPECIFIED = 0; ADMIN = 1; }
+
+  string id = 1;
+  repeated Address addresses = 2;
+  map<string, Address> by_label = 3;
+  oneof contact {
+    string email = 4;
+    Phone phone = 5;
+  }
+  Role role = 6;
+  google.protobuf.Timestamp created_at = 7;
+  reserved 8, 9 to 11;
+  reserved "legacy";
+}
+
+message Country { string code = 1; }
+message Phone { string number = 1; }
+"""
+
+
+class TestProtoParser:
+    """Test reading the declarations of .proto files."""
+
+    def test_file_options(self):
+        proto = parse_proto(USERS_PROTO)
+
+        assert proto.package == "users.v1"
+        assert proto.imports == [
+            "google/protobuf/timestamp.proto",
+            "users/v1/common.proto",
+        ]
+        assert proto.go_package == "example.com/shop/gen/users/v1"
+        assert proto.full_name("User") == "users.v1.User"
+
+    def test_services(self):
+        [service] = parse_proto(USERS_PROTO).services
+
+        assert service.name == "UserService"
+        assert service.line_number == 12
+        assert [
+            (r.name, r.request, r.response, r.client_streaming, r.server_streaming)
+            for r in service.rpcs
+        ] == [
+            ("GetUser", "GetUserRequest", "User", False, False),
+            ("WatchUsers", "GetUserRequest", "User", False, True),
+            ("Import", "User.Address", ".users.v1.User", True, False),
+        ]
+        assert service.rpcs[0].line_number == 14
+
+    def test_messages(self):
+        proto = parse_proto(USERS_PROTO)
+        messages = {m.name: m for m in proto.messages}
+
+        assert list(messages) == [
+            "GetUserRequest",
+            "User",
+            "User.Address",
+            "Country",
+            "Phone",
+        ]
+        assert messages["User"].line_number == 27
+        assert [
+            (f.name, f.number, f.repeated, f.type_name)
+            for f in messages["User"].fields
+        ] == [
+            ("id", 1, False, None),
+            ("addresses", 2, True, "Address"),
+            ("by_label", 3, False, "Address"),
+            ("email", 4, False, None),
+            ("phone", 5, False, "Phone"),
+            ("role", 6, False, "Role"),
+            ("created_at", 7, False, "google.protobuf.Timestamp"),
+        ]
+        assert messages["User"].fields[2].type == "map<string, Address>"
+        assert proto.enums == ["User.Role"]
+
+    def test_proto2_groups_and_extensions(self):
+        proto = parse_proto(
+            """syntax = "proto2";
+            message Search {
+              extensions 100 to 199;
+              optional int32 limit = 1 [default = 10];
+              repeated group Result = 2 { required string url = 3; }
+            }
+            extend Search { optional string tag = 100; }
+            """
+        )
+
+        [search] = proto.messages
+        assert [(f.name, f.repeated) for f in search.fields] == [
+            ("limit", False),
+            ("result", True),
+        ]
+
+    def test_syntax_error(self):
+        with pytest.raises(ProtoSyntaxError, match="not closed"):
+            parse_proto("message User {\n  string id = 1;\n")
+
+    def test_resolve_type(self):
+        known = {
+            "users.v1.User",
+            "users.v1.User.Address",
+            "users.v1.Address",
+            "billing.Invoice",
+        }
+
+        # The innermost scope declaring the name wins
+        assert resolve_type("Address", "users.v1.User", known) == (
+            "users.v1.User.Address"
+        )
+        assert resolve_type("Address", "users.v1", known) == "users.v1.Address"
+        assert resolve_type("User.Address", "users.v1", known) == (
+            "users.v1.User.Address"
+        )
+        assert resolve_type("billing.Invoice", "users.v1.User", known) == (
+            "billing.Invoice"
+        )
+        assert resolve_type(".users.v1.User", "billing", known) == "users.v1.User"
+        assert resolve_type("Unknown", "users.v1", known) is None
+
+
+class TestProtoIngestion:
+    """Test the nodes and edges created from .proto files."""
+
+    def _ingest(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        (temp_repo / "users.proto").write_text(USERS_PROTO)
+        (temp_repo / "common.proto").write_text(
+            "package users.v1;\nmessage Address { string city = 1; }\n"
+        )
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater._parse_proto_file(temp_repo / "users.proto")
+        updater._parse_proto_file(temp_repo / "common.proto")
+        updater._link_proto_types()
+        return updater
+
+    def test_nodes(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "ProtoService",
+            {
+                "qualified_name": "users.v1.UserService",
+                "name": "UserService",
+                "package": "users.v1",
+                "path": "users.proto",
+                "start_line": 12,
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "RpcMethod",
+            {
+                "qualified_name": "/users.v1.UserService/WatchUsers",
+                "name": "WatchUsers",
+                "service": "users.v1.UserService",
+                "streaming": True,
+                "client_streaming": False,
+                "server_streaming": True,
+                "request_type": "GetUserRequest",
+                "response_type": "User",
+                "path": "users.proto",
+                "start_line": 15,
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "ProtoMessage",
+            {
+                "qualified_name": "users.v1.User.Address",
+                "name": "Address",
+                "package": "users.v1",
+                "path": "users.proto",
+                "start_line": 28,
+                "field_count": 2,
+            },
+        )
+
+    def test_edges(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        edges = {
+            (call.args[0][2], call.args[1], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+        }
+        assert {
+            ("users.proto", "DEFINES", "users.v1.UserService"),
+            ("users.proto", "DEFINES", "users.v1.User"),
+            ("common.proto", "DEFINES", "users.v1.Address"),
+            ("users.v1.UserService", "HAS_RPC", "/users.v1.UserService/GetUser"),
+            ("/users.v1.UserService/GetUser", "ACCEPTS", "users.v1.GetUserRequest"),
+            ("/users.v1.UserService/GetUser", "RETURNS", "users.v1.User"),
+            ("/users.v1.UserService/Import", "ACCEPTS", "users.v1.User.Address"),
+            ("/users.v1.UserService/Import", "RETURNS", "users.v1.User"),
+            # The nested Address, not the package's
+            ("users.v1.User", "REFERENCES", "users.v1.User.Address"),
+            ("users.v1.User", "REFERENCES", "users.v1.Phone"),
+            ("users.v1.User.Address", "REFERENCES", "users.v1.Country"),
+        } <= edges
+        # Neither the enum nor the well-known type outside the repository
+        assert not {
+            target
+            for _, rel_type, target in edges
+            if rel_type == "REFERENCES"
+            and target in ("users.v1.User.Role", "google.protobuf.Timestamp")
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("ProtoMessage", "qualified_name", "users.v1.User"),
+            "REFERENCES",
+            ("ProtoMessage", "qualified_name", "users.v1.User.Address"),
+            {"field": "addresses", "repeated": True},
+        )
+
+    def test_invalid_file(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "broken.proto").write_text("service Users {\n")
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+
+        updater._parse_proto_file(temp_repo / "broken.proto")
+
+        assert updater.skipped_files["broken.proto"].startswith("invalid protobuf")
+        mock_ingestor.ensure_node_batch.assert_not_called()
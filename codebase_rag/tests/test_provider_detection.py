@@ -2,7 +2,7 @@
 
 import pytest
 
-from codebase_rag.config import detect_provider_from_model
+from codebase_rag.config import detect_provider_from_model, split_model_id
 
 
 class TestProviderDetection:
@@ -41,6 +41,24 @@ class TestProviderDetection:
         assert detect_provider_from_model("custom-model") == "local"
         assert detect_provider_from_model("") == "local"  # Empty defaults to local
 
+    def test_provider_prefix(self):
+        """Test that a provider prefix wins over the model name."""
+        assert split_model_id("ollama:qwen2.5-coder:7b") == (
+            "local",
+            "qwen2.5-coder:7b",
+        )
+        assert split_model_id("openai-compatible:gpt-oss-20b") == (
+            "local",
+            "gpt-oss-20b",
+        )
+        assert split_model_id("anthropic:claude-sonnet-4") == (
+            "anthropic",
+            "claude-sonnet-4",
+        )
+        # Ollama tags are not providers
+        assert split_model_id("llama3:8b") == ("local", "llama3:8b")
+        assert detect_provider_from_model("local:gpt-oss:20b") == "local"
+
     def test_case_sensitivity(self):
         """Test that detection is case-sensitive."""
         # Model names should be exact
@@ -108,3 +126,40 @@ class TestProviderConfiguration:
         config.ANTHROPIC_API_KEY = "test-key"
         config.GEMINI_API_KEY = "test-key"
         config.validate_for_usage()  # Should not raise
+
+    def test_default_models_of_the_provider(self):
+        """Test that MODEL_PROVIDER picks the models used when none is given."""
+        from codebase_rag.config import AppConfig
+
+        config = AppConfig()
+        config.ORCHESTRATOR_MODEL = None
+        config.CYPHER_MODEL = None
+        config.MODEL_PROVIDER = "local"
+        config.LOCAL_ORCHESTRATOR_MODEL_ID = "gpt-oss:20b"
+        config.LOCAL_CYPHER_MODEL_ID = "qwen2.5-coder:7b"
+
+        assert config.active_orchestrator_model == "local:gpt-oss:20b"
+        assert config.active_cypher_model == "local:qwen2.5-coder:7b"
+        config.validate_for_usage()  # Local models need no API key
+
+    def test_local_only(self):
+        """Test that LOCAL_ONLY refuses anything sending code to the cloud."""
+        from codebase_rag.config import AppConfig
+
+        config = AppConfig()
+        config._active_orchestrator_model = "ollama:llama3.1"
+        config._active_cypher_model = "gpt-4o-mini"
+        config.OPENAI_API_KEY = "test-key"
+        config.LOCAL_ONLY = True
+
+        with pytest.raises(ValueError, match="LOCAL_ONLY is set but openai"):
+            config.validate_for_usage()
+
+        config._active_cypher_model = "ollama:llama3.1"
+        config.EMBEDDING_PROVIDER = "openai"
+
+        with pytest.raises(ValueError, match="EMBEDDING_PROVIDER is openai"):
+            config.validate_for_usage()
+
+        config.EMBEDDING_PROVIDER = "local"
+        config.validate_for_usage()
@@ -1,16 +1,24 @@
 """Tests for query caching functionality (REQ-SCL-3)."""
 
+import json
 import time
 from unittest.mock import MagicMock
 
 import pytest
 
 from codebase_rag.query_cache import (
+    GRAPH_VERSION_QUERY,
+    SET_GRAPH_VERSION,
     CacheEntry,
     CachedQueryExecutor,
+    GraphQueryCache,
     QueryCache,
     cached_query,
 )
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.auth import ApiToken, Role, TokenRegistry, hash_token
+from codebase_rag.server.query import create_query_routes
+from codebase_rag.services.graph_service import MemgraphIngestor
 
 
 class TestCacheEntry:
@@ -264,4 +272,119 @@ class TestCachedQueryDecorator:
         # Check cache stats
         stats = execute_query.cache.get_stats()
         assert stats["hits"] == 1
-        assert stats["misses"] == 2
\ No newline at end of file
+        assert stats["misses"] == 2
+
+
+class FakeGraph:
+    """Answers queries with the current version token and a call count."""
+
+    def __init__(self):
+        self.version = "v1"
+        self.reads = 0
+
+    def __call__(self, query, params=None):
+        if query == GRAPH_VERSION_QUERY:
+            return [{"token": self.version}]
+        self.reads += 1
+        return [{"name": "total", "read": self.reads}]
+
+
+class SettingGraph:
+    """One Setting node whose value writes change, and the graph version."""
+
+    def __init__(self):
+        self.version = "v1"
+        self.value = "old"
+
+    def __call__(self, query, params=None):
+        if query == GRAPH_VERSION_QUERY:
+            return [{"token": self.version}]
+        if query == SET_GRAPH_VERSION:
+            self.version = params["token"]
+        elif " SET " in query:
+            self.value = params["value"]
+        else:
+            return [{"value": self.value}]
+        return []
+
+
+class TestGraphQueryCache:
+    """Test caching read-only graph queries until the graph version changes."""
+
+    def test_repeated_reads_hit_until_the_version_changes(self):
+        graph = FakeGraph()
+        cache = GraphQueryCache(max_size=8)
+        query = "MATCH (f:Function {name: $name}) RETURN f.name AS name"
+
+        first = cache.fetch_all(graph, query, {"name": "total"})
+        first[0]["name"] = "changed by the caller"
+
+        assert cache.fetch_all(graph, query, {"name": "total"})[0] == {
+            "name": "total",
+            "read": 1,
+        }
+        assert cache.fetch_all(graph, query, {"name": "tax"})[0]["read"] == 2
+        graph.version = "v2"
+        assert cache.fetch_all(graph, query, {"name": "total"})[0]["read"] == 3
+        assert cache.cache.get_stats()["size"] == 1
+
+    def test_writes_and_unserializable_params_are_not_cached(self):
+        graph = FakeGraph()
+        cache = GraphQueryCache()
+
+        for _ in range(2):
+            cache.fetch_all(graph, "MATCH (n:Temp) DELETE n RETURN count(n)")
+            cache.fetch_all(graph, "MATCH (n) WHERE n.id = $id RETURN n", {"id": {1}})
+
+        assert graph.reads == 4
+
+    def test_ingestor_reads_through_the_cache(self):
+        graph = FakeGraph()
+        ingestor = MemgraphIngestor("", 0, sinks=[], query_cache=GraphQueryCache())
+        ingestor._execute_query = MagicMock(side_effect=graph)
+
+        ingestor.fetch_all("MATCH (m:Module) RETURN m.name AS name")
+        ingestor.fetch_all("MATCH (m:Module) RETURN m.name AS name")
+        assert graph.reads == 1
+
+        ingestor.execute_write("MATCH (q:Question) DETACH DELETE q")
+        ingestor.fetch_all("MATCH (m:Module) RETURN m.name AS name")
+        assert graph.reads == 3
+
+        ingestor.mark_graph_changed()
+        query, params = ingestor._execute_query.call_args.args
+        assert query == SET_GRAPH_VERSION
+        assert set(params) == {"token", "updated_at"}
+
+    def test_writes_are_read_back(self):
+        graph = SettingGraph()
+        cache = GraphQueryCache()
+        read = "MATCH (s:Setting) RETURN s.value AS value"
+
+        assert cache.fetch_all(graph, read) == [{"value": "old"}]
+        cache.fetch_all(graph, "MATCH (s:Setting) SET s.value = $value", {"value": 1})
+
+        assert cache.fetch_all(graph, read) == [{"value": 1}]
+
+    def test_writes_through_the_query_endpoint_are_read_back(self):
+        graph = SettingGraph()
+        ingestor = MemgraphIngestor("", 0, sinks=[], query_cache=GraphQueryCache())
+        ingestor._execute_query = MagicMock(side_effect=graph)
+        server = GraphServer()
+        create_query_routes(
+            server,
+            ingestor,
+            TokenRegistry({hash_token("admin"): ApiToken("admin", Role.ADMIN)}),
+        )
+
+        def query(cypher: str, params: dict | None = None):
+            body = json.dumps({"cypher": cypher, "params": params}).encode()
+            headers = {"authorization": "Bearer admin"}
+            return server.handle(Request("POST", "/query", headers, body))
+
+        read = "MATCH (s:Setting) RETURN s.value AS value"
+        assert query(read).body["rows"] == [{"value": "old"}]
+        write = query("MATCH (s:Setting) SET s.value = $value", {"value": "new"})
+        assert write.status == 200
+
+        assert query(read).body["rows"] == [{"value": "new"}]
@@ -0,0 +1,194 @@
+"""Tests for the chat prompt's slash commands, completion and history."""
+
+from io import StringIO
+from unittest.mock import MagicMock
+
+import pytest
+from prompt_toolkit.completion import CompleteEvent
+from prompt_toolkit.document import Document
+from prompt_toolkit.history import FileHistory, InMemoryHistory
+from rich.console import Console
+
+from codebase_rag import repl, saved_queries
+from codebase_rag.config import settings
+from codebase_rag.explorer import ExplorerNode
+from codebase_rag.repl import (
+    MAX_CELL_CHARS,
+    MAX_SHOWN_ROWS,
+    SessionCompleter,
+    SlashCommand,
+    _history,
+    cell_text,
+    parse_slash_command,
+    rows_table,
+    run_slash_command,
+)
+
+
+@pytest.fixture
+def index_path(tmp_path, monkeypatch):
+    path = tmp_path / "symbols.tsv"
+    path.write_text(
+        "shop.cart.Cart\tClass\nshop.cart.total\tFunction\nshop.tax.rate\tFunction\n"
+    )
+    monkeypatch.setattr(settings, "COMPLETION_INDEX_PATH", str(path))
+    return path
+
+
+def _console() -> tuple[Console, StringIO]:
+    output = StringIO()
+    return Console(file=output, width=120, color_system=None), output
+
+
+class TestSlashCommands:
+    """Test telling commands from questions and running them."""
+
+    def test_parse(self):
+        assert parse_slash_command("/cypher MATCH (n)\nRETURN n") == SlashCommand(
+            "/cypher", "MATCH (n)\nRETURN n"
+        )
+        assert parse_slash_command("  /HELP ") == SlashCommand("/help", "")
+        assert parse_slash_command("What calls shop.cart.total?") is None
+        # An image to ask about, not a command
+        assert parse_slash_command("/home/me/trace.png why this error?") is None
+
+    def test_cypher(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"name": "total", "calls": 3},
+            {"name": "Cart", "calls": None},
+        ]
+        console, output = _console()
+
+        run_slash_command(
+            SlashCommand("/cypher", "MATCH (f) RETURN f.name AS name"), ingestor, console
+        )
+
+        ingestor.fetch_all.assert_called_once_with("MATCH (f) RETURN f.name AS name")
+        text = output.getvalue()
+        assert "name" in text and "calls" in text and "total" in text
+        assert "2 rows" in text
+
+    def test_cypher_refuses_writes(self):
+        ingestor = MagicMock()
+        console, output = _console()
+
+        run_slash_command(
+            SlashCommand("/cypher", "MATCH (f) DETACH DELETE f"), ingestor, console
+        )
+
+        ingestor.fetch_all.assert_not_called()
+        assert "read-only" in output.getvalue()
+
+    def test_failed_query_and_unknown_command(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = RuntimeError("Invalid input 'MATC'")
+        console, output = _console()
+
+        run_slash_command(SlashCommand("/cypher", "MATC (n)"), ingestor, console)
+        run_slash_command(SlashCommand("/graph", ""), ingestor, console)
+
+        assert "Query failed: Invalid input 'MATC'" in output.getvalue()
+        assert "Unknown command /graph" in output.getvalue()
+
+    def test_explore_picks_context(self, monkeypatch, tmp_path):
+        opened = []
+
+        def run_explorer(explorer, start, pick):
+            opened.append((explorer.repo_path, start, pick))
+            return ExplorerNode("shop.cart.total", "Function", "shop/cart.py", 4, 9)
+
+        monkeypatch.setattr(repl, "run_explorer", run_explorer)
+        console, output = _console()
+
+        note = run_slash_command(
+            SlashCommand("/explore", "total"), MagicMock(), console, tmp_path
+        )
+
+        assert opened == [(tmp_path, "total", True)]
+        assert note == "(Context: Function shop.cart.total at shop/cart.py:4-9.)"
+        assert "The next question is about shop.cart.total" in output.getvalue()
+
+        monkeypatch.setattr(repl, "run_explorer", lambda *args, **kwargs: None)
+        command = SlashCommand("/explore", "")
+        assert run_slash_command(command, MagicMock(), console) is None
+
+    def test_saved_queries(self, monkeypatch, tmp_path):
+        config_file = tmp_path / ".cgr.toml"
+        config_file.write_text("[settings]\n")
+        monkeypatch.setattr(repl, "active_config_file", lambda: config_file)
+        monkeypatch.setattr(saved_queries, "active_config_file", lambda: config_file)
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [{"route": "/cart"}]
+        console, output = _console()
+
+        for command in [
+            SlashCommand("/save", "routes MATCH (e:Endpoint) RETURN e.route AS route"),
+            SlashCommand("/save", "wipe MATCH (n) DELETE n"),
+            SlashCommand("/query", ""),
+            SlashCommand("/query", "routes"),
+            SlashCommand("/query", "routes --verb GET"),
+        ]:
+            run_slash_command(command, ingestor, console)
+
+        text = output.getvalue()
+        assert "Saved routes" in text
+        assert "Only queries that read can be saved" in text
+        assert "/cart" in text and "1 row" in text
+        assert "routes has no parameter verb" in text
+        ingestor.fetch_all.assert_called_once_with(
+            "MATCH (e:Endpoint) RETURN e.route AS route", {}
+        )
+
+
+class TestRowsTable:
+    """Test rendering query rows."""
+
+    def test_columns_and_cells(self):
+        table = rows_table([{"a": 1}, {"b": [1, 2], "a": "x"}])
+
+        assert [column.header for column in table.columns] == ["a", "b"]
+        assert cell_text({"k": "v"}) == '{"k": "v"}'
+        assert cell_text(None) == ""
+        assert len(cell_text("x" * 500)) == MAX_CELL_CHARS
+
+    def test_long_results_are_cut(self):
+        table = rows_table([{"n": i} for i in range(MAX_SHOWN_ROWS + 5)])
+
+        assert table.row_count == MAX_SHOWN_ROWS
+        assert table.caption == f"First {MAX_SHOWN_ROWS} of {MAX_SHOWN_ROWS + 5} rows"
+
+
+class TestCompletion:
+    """Test what Tab offers at the prompt."""
+
+    def _complete(self, text: str) -> list[tuple[str, int]]:
+        return [
+            (completion.text, completion.start_position)
+            for completion in SessionCompleter().get_completions(
+                Document(text), CompleteEvent(completion_requested=True)
+            )
+        ]
+
+    def test_symbols(self, index_path):
+        assert self._complete("Who calls tot") == [("shop.cart.total", -3)]
+        assert self._complete("explain shop.cart.") == [
+            ("shop.cart.Cart", -10),
+            ("shop.cart.total", -10),
+        ]
+
+    def test_commands(self, index_path):
+        assert self._complete("/cy") == [("/cypher", -3)]
+        assert self._complete("/cypher MATCH (n) WHERE n.name = shop.tax.r") == [
+            ("shop.tax.rate", -10)
+        ]
+
+    def test_history(self, tmp_path, monkeypatch):
+        monkeypatch.setattr(settings, "REPL_HISTORY_PATH", "")
+        assert isinstance(_history(), InMemoryHistory)
+
+        monkeypatch.setattr(
+            settings, "REPL_HISTORY_PATH", str(tmp_path / "cgr" / "history")
+        )
+        assert isinstance(_history(), FileHistory)
+        assert (tmp_path / "cgr").is_dir()
@@ -0,0 +1,228 @@
+"""Tests for the pull request review assistant."""
+
+import json
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.analysis.review import (
+    OWNERS_QUERY,
+    SYMBOL_CONTEXT_QUERY,
+    SYMBOLS_IN_PATHS_QUERY,
+    ChangedSymbol,
+    ReviewAssistant,
+    find_changed_symbols,
+    generate_comments,
+    parse_unified_diff,
+)
+from codebase_rag.services.review_publishers import GitHubReviewPublisher
+
+DIFF = """\
+diff --git a/shop/cart.py b/shop/cart.py
+index 1111111..2222222 100644
+--- a/shop/cart.py
++++ b/shop/cart.py
+@@ -10,3 +10,4 @@ def total(items):
+     subtotal = sum(i.price for i in items)
+-    return subtotal
++    tax = subtotal * RATE
++    return subtotal + tax
+
+@@ -30,3 +31,2 @@ def discount(code):
+     rules = load_rules()
+---- legacy rule
+     return rules.get(code)
+diff --git a/shop/legacy.py b/shop/legacy.py
+deleted file mode 100644
+--- a/shop/legacy.py
++++ /dev/null
+@@ -1,2 +0,0 @@
+-def old():
+-    pass
+"""
+
+
+def _symbol(**overrides) -> ChangedSymbol:
+    fields = {
+        "qualified_name": "shop.cart.total",
+        "label": "Function",
+        "path": "shop/cart.py",
+        "start_line": 9,
+        "end_line": 13,
+        "first_changed_line": 11,
+    }
+    return ChangedSymbol(**{**fields, **overrides})
+
+
+class TestDiffParsing:
+    """Test unified diff parsing into changed line numbers."""
+
+    def test_added_and_removed_lines(self):
+        cart, legacy = parse_unified_diff(DIFF)
+
+        assert cart.path == "shop/cart.py"
+        assert cart.added_lines == {11, 12}
+        # The removed line starting with "---" is content, not a file header
+        assert cart.removed_at == {11, 32}
+        assert legacy.path == "shop/legacy.py"
+        assert legacy.is_deleted
+
+    def test_changed_symbols(self):
+        rows = [
+            {
+                "qualified_name": "shop.cart.total",
+                "label": "Function",
+                "path": "shop/cart.py",
+                "start_line": 9,
+                "end_line": 13,
+            },
+            {
+                "qualified_name": "shop.cart.untouched",
+                "label": "Function",
+                "path": "shop/cart.py",
+                "start_line": 15,
+                "end_line": 28,
+            },
+            {
+                "qualified_name": "shop.cart.discount",
+                "label": "Function",
+                "path": "shop/cart.py",
+                "start_line": 30,
+                "end_line": 33,
+            },
+        ]
+
+        symbols = find_changed_symbols(parse_unified_diff(DIFF), rows)
+
+        assert [(s.qualified_name, s.first_changed_line) for s in symbols] == [
+            ("shop.cart.total", 11),
+            ("shop.cart.discount", 32),
+        ]
+
+
+class TestReviewComments:
+    """Test comments generated from graph context."""
+
+    def test_untested_symbol_with_callers(self):
+        symbol = _symbol(
+            complexity=12,
+            callers=[f"shop.api.handler{i}" for i in range(6)],
+            endpoints=["POST /checkout"],
+        )
+
+        comments = generate_comments([symbol])
+
+        assert [c.severity for c in comments] == ["warning", "info", "info", "info"]
+        assert "6 caller(s) and 1 endpoint(s)" in comments[0].body
+        assert "and 3 more" in comments[1].body
+        assert all((c.path, c.line) == ("shop/cart.py", 11) for c in comments)
+
+    def test_tested_symbol_is_quiet(self):
+        symbol = _symbol(
+            callers=["shop.api.checkout"], tests=["tests.test_cart.test_total"]
+        )
+        assert generate_comments([symbol]) == []
+
+    def test_crashing_symbol(self):
+        crashes = [
+            {"short_id": "SHOP-7", "title": "ZeroDivisionError", "count": 90},
+            {"short_id": "SHOP-9", "title": "KeyError", "count": 40},
+        ]
+        symbol = _symbol(tests=["tests.test_cart.test_total"], crashes=crashes)
+
+        [comment] = generate_comments([symbol])
+
+        assert comment.severity == "warning"
+        assert "2 crash report(s) with 130 events" in comment.body
+        assert "`SHOP-7`, `SHOP-9`" in comment.body
+
+    def test_assistant_combines_graph_context(self):
+        ingestor = MagicMock()
+        responses = {
+            OWNERS_QUERY: [{"path": "shop/cart.py", "owners": ["@acme/payments"]}],
+            SYMBOLS_IN_PATHS_QUERY: [
+                {
+                    "qualified_name": "shop.cart.total",
+                    "label": "Function",
+                    "path": "shop/cart.py",
+                    "start_line": 9,
+                    "end_line": 13,
+                    "complexity": 2,
+                }
+            ],
+            SYMBOL_CONTEXT_QUERY: [
+                {
+                    "qualified_name": "shop.cart.total",
+                    "callers": ["shop.api.checkout"],
+                    "tests": [],
+                    "endpoints": [],
+                }
+            ],
+        }
+        ingestor.fetch_all.side_effect = lambda query, params: responses[query]
+
+        report = ReviewAssistant(ingestor).review(parse_unified_diff(DIFF))
+
+        [symbol] = report.symbols
+        assert symbol.owners == ["@acme/payments"]
+        assert report.reviewers == ["@acme/payments"]
+        assert [c.severity for c in report.comments] == ["warning"]
+        assert "1 without tests" in report.summary()
+        paths = ingestor.fetch_all.call_args_list[0].args[1]["paths"]
+        assert paths == ["shop/cart.py"]
+
+
+class TestGitHubPublisher:
+    """Test the GitHub review payload."""
+
+    def test_publish(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+        report = ReviewAssistant(ingestor).review(parse_unified_diff(DIFF))
+        report.comments = generate_comments([_symbol(callers=["shop.api.checkout"])])
+        requests = []
+
+        def urlopen(request, timeout):
+            requests.append(request)
+            response = MagicMock()
+            body = {"head": {"sha": "abc123"}} if request.method == "GET" else {}
+            response.__enter__.return_value.read.return_value = json.dumps(
+                body
+            ).encode()
+            return response
+
+        publisher = GitHubReviewPublisher("acme/shop", 7, "ghp_token")
+        with patch("urllib.request.urlopen", urlopen):
+            assert publisher.publish(report) == 1
+
+        review = requests[1]
+        assert review.full_url.endswith("/repos/acme/shop/pulls/7/reviews")
+        assert review.get_header("Authorization") == "Bearer ghp_token"
+        payload = json.loads(review.data)
+        assert payload["commit_id"] == "abc123"
+        assert payload["comments"][0]["line"] == 11
+        assert payload["comments"][0]["body"].startswith("**warning**")
+
+    def test_upsert_comment(self):
+        comments = [{"id": 5, "body": "LGTM"}]
+        requests = []
+
+        def urlopen(request, timeout):
+            requests.append(request)
+            response = MagicMock()
+            body = comments if request.method == "GET" else {}
+            response.__enter__.return_value.read.return_value = json.dumps(
+                body
+            ).encode()
+            return response
+
+        publisher = GitHubReviewPublisher("acme/shop", 7, "ghp_token")
+        with patch("urllib.request.urlopen", urlopen):
+            publisher.upsert_comment("<!-- m -->\nfirst", "<!-- m -->")
+            comments.append({"id": 9, "body": "<!-- m -->\nfirst"})
+            publisher.upsert_comment("<!-- m -->\nsecond", "<!-- m -->")
+
+        posted, edited = requests[1], requests[3]
+        assert posted.method == "POST"
+        assert posted.full_url.endswith("/repos/acme/shop/issues/7/comments")
+        assert edited.method == "PATCH"
+        assert edited.full_url.endswith("/repos/acme/shop/issues/comments/9")
+        assert json.loads(edited.data) == {"body": "<!-- m -->\nsecond"}
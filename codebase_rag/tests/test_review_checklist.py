@@ -0,0 +1,128 @@
+"""Tests for reviewer checklist generation."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.review import ChangedSymbol, ReviewReport
+from codebase_rag.analysis.review_checklist import (
+    MODULE_CYCLES_QUERY,
+    SYMBOL_FACTS_QUERY,
+    ReviewChecklistBuilder,
+    build_checklist,
+)
+
+
+def _symbol(**overrides) -> ChangedSymbol:
+    fields = {
+        "qualified_name": "shop.cart.total",
+        "label": "Function",
+        "path": "shop/cart.py",
+        "start_line": 9,
+        "end_line": 13,
+        "first_changed_line": 11,
+        "tests": ["tests.test_cart.test_total"],
+    }
+    return ChangedSymbol(**{**fields, **overrides})
+
+
+def _report(symbols, reviewers=None) -> ReviewReport:
+    return ReviewReport(
+        files=sorted({s.path for s in symbols}),
+        symbols=symbols,
+        comments=[],
+        reviewers=reviewers or [],
+    )
+
+
+class TestBuildChecklist:
+    """Test checklist items derived from review context and graph facts."""
+
+    def test_items_by_category(self):
+        report = _report(
+            [
+                _symbol(
+                    qualified_name="shop.api.checkout",
+                    path="shop/api.py",
+                    tests=[],
+                    endpoints=["POST /checkout"],
+                ),
+                _symbol(),
+            ],
+            reviewers=["@shop-team"],
+        )
+        facts = {
+            "shop.cart.total": {
+                "hotspot_score": 0.8,
+                "churn": 42,
+                "flaky_tests": ["tests.test_cart.test_total"],
+            }
+        }
+        cycles = {"shop/cart.py": ["shop.pricing"]}
+
+        checklist = build_checklist(report, facts, cycles)
+
+        assert [item.category for item in checklist.items] == [
+            "API compatibility",
+            "Tests",
+            "Tests",
+            "Hotspots",
+            "Architecture",
+            "Ownership",
+        ]
+        untested = checklist.items[1]
+        assert untested.priority == "high"
+        assert untested.refs == ["shop.api.checkout"]
+        assert "0.80" in checklist.items[3].text
+        assert checklist.items[4].refs == ["shop.pricing"]
+
+    def test_quiet_change(self):
+        checklist = build_checklist(_report([_symbol()]), {}, {})
+
+        assert checklist.items == []
+        assert "nothing specific" in checklist.to_markdown()
+
+    def test_markdown_and_json(self):
+        report = _report(
+            [
+                _symbol(
+                    crashes=[
+                        {"short_id": "SHOP-1", "title": "KeyError", "count": 250},
+                    ]
+                )
+            ]
+        )
+        checklist = build_checklist(report, {}, {})
+
+        markdown = checklist.to_markdown()
+        assert "### Crashes" in markdown
+        assert "- [ ] **(high)** `total` appears in crash reports `SHOP-1`" in markdown
+        data = checklist.to_dict()
+        assert data["files"] == ["shop/cart.py"]
+        assert data["items"][0]["refs"] == ["SHOP-1"]
+
+
+class TestReviewChecklistBuilder:
+    """Test the graph queries behind the checklist."""
+
+    def test_build_queries_graph(self):
+        ingestor = MagicMock()
+
+        def fetch_all(query, params=None):
+            if query == SYMBOL_FACTS_QUERY:
+                assert params == {"qualified_names": ["shop.cart.total"]}
+                return [
+                    {
+                        "qualified_name": "shop.cart.total",
+                        "hotspot_score": 0.9,
+                        "churn": 12,
+                        "flaky_tests": [],
+                    }
+                ]
+            assert query == MODULE_CYCLES_QUERY
+            assert params == {"paths": ["shop/cart.py"]}
+            return [{"path": "shop/cart.py", "cycle_with": []}]
+
+        ingestor.fetch_all.side_effect = fetch_all
+
+        checklist = ReviewChecklistBuilder(ingestor).build(_report([_symbol()]))
+
+        assert [item.category for item in checklist.items] == ["Hotspots"]
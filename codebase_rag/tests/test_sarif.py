@@ -0,0 +1,221 @@
+"""Tests for SARIF output of analysis findings."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.dead_code import DeadSymbol
+from codebase_rag.analysis.import_cycles import (
+    ImportSite,
+    PackageCycle,
+    PackageDependency,
+)
+from codebase_rag.analysis.layering import LayerViolation
+from codebase_rag.analysis.sarif import (
+    build_sarif_log,
+    dead_code_findings,
+    import_cycle_findings,
+    layering_findings,
+    smell_findings,
+    taint_findings,
+    unchecked_error_findings,
+    vulnerability_findings,
+)
+from codebase_rag.analysis.smells import LONG_FUNCTION, CodeSmell
+from codebase_rag.analysis.taint import TaintPath, TaintSource
+from codebase_rag.analysis.vulnerabilities import VulnerabilityAnalyzer
+
+SECRET_ROW = {
+    "id": "shop.settings:hardcoded_secret:12",
+    "type": "hardcoded_secret",
+    "severity": "high",
+    "description": "Hardcoded password or secret detected",
+    "cwe_id": "CWE-798",
+    "recommendation": "Use environment variables or a secrets manager",
+    "confidence": 0.8,
+    "code_snippet": 'API_KEY = "abc123"',
+    "path": "shop/settings.py",
+    "line_number": 12,
+}
+
+
+class TestSarifLog:
+    """Test the structure of generated SARIF logs."""
+
+    def test_smells(self):
+        smells = [
+            CodeSmell(
+                qualified_name=f"shop.cart.{name}",
+                label="Function",
+                path="shop/cart.py",
+                smell=LONG_FUNCTION,
+                metric="line_count",
+                value=90,
+                threshold=60,
+                start_line=line,
+            )
+            for name, line in (("total", 10), ("checkout", 120))
+        ]
+
+        log = build_sarif_log(smell_findings(smells))
+
+        assert log["version"] == "2.1.0"
+        [run] = log["runs"]
+        [rule] = run["tool"]["driver"]["rules"]
+        assert rule["id"] == "smells/long-function"
+        assert rule["defaultConfiguration"] == {"level": "warning"}
+        first, second = run["results"]
+        assert first["ruleIndex"] == second["ruleIndex"] == 0
+        location = first["locations"][0]["physicalLocation"]
+        assert location["artifactLocation"]["uri"] == "shop/cart.py"
+        assert location["region"] == {"startLine": 10}
+        assert "line_count 90 (limit 60)" in first["message"]["text"]
+        assert (
+            first["partialFingerprints"]["graphCodeFinding/v1"]
+            != second["partialFingerprints"]["graphCodeFinding/v1"]
+        )
+
+    def test_vulnerabilities_and_unchecked_errors(self):
+        unchecked = {
+            "call": "os.Remove",
+            "kind": "blank",
+            "category": "io",
+            "likelihood": 3,
+            "path": "store/files.go",
+            "line_number": 40,
+            "owner": "store.Cleanup",
+        }
+        findings = vulnerability_findings([SECRET_ROW])
+        unlikely = {**unchecked, "likelihood": 1}
+        findings += unchecked_error_findings([unchecked, unlikely])
+
+        [run] = build_sarif_log(findings)["runs"]
+
+        secret_rule, unchecked_rule = run["tool"]["driver"]["rules"]
+        assert secret_rule["name"] == "HardcodedSecret"
+        assert secret_rule["properties"]["tags"] == ["security", "external/cwe/cwe-798"]
+        assert [r["level"] for r in run["results"]] == ["error", "warning", "note"]
+        assert run["results"][0]["message"]["text"] == (
+            "Hardcoded password or secret detected. "
+            "Use environment variables or a secrets manager"
+        )
+        assert unchecked_rule["id"] == "go/unchecked-error"
+
+    def test_fingerprint_ignores_line_shifts(self):
+        [before] = build_sarif_log(vulnerability_findings([SECRET_ROW]))["runs"]
+        moved = {**SECRET_ROW, "line_number": 30, "id": "shop.settings:x:30"}
+        [after] = build_sarif_log(vulnerability_findings([moved]))["runs"]
+        assert (
+            before["results"][0]["partialFingerprints"]
+            == after["results"][0]["partialFingerprints"]
+        )
+
+    def test_dead_code_and_layering(self):
+        dead = DeadSymbol(
+            "shop.cart.legacyTotal",
+            "legacyTotal",
+            "Function",
+            "cart/cart.go",
+            "cart",
+            line_number=40,
+            dead_users=["shop.cart.oldCheckout"],
+        )
+        violation = LayerViolation(
+            "call",
+            "domain",
+            "api",
+            "shop.cart.Total",
+            "shop.api.Respond",
+            "cart/cart.go",
+            12,
+        )
+
+        [run] = build_sarif_log(
+            dead_code_findings([dead]) + layering_findings([violation])
+        )["runs"]
+
+        dead_result, layering_result = run["results"]
+        assert dead_result["level"] == "note"
+        assert dead_result["message"]["text"].endswith(
+            "Only dead code uses it: shop.cart.oldCheckout."
+        )
+        assert layering_result["ruleId"] == "architecture/layering"
+        assert layering_result["message"]["text"] == (
+            "Call of shop.api.Respond from shop.cart.Total: layer 'domain' may "
+            "not use layer 'api'."
+        )
+        assert layering_result["properties"] == {"rule": "domain -> api"}
+
+    def test_import_cycle_points_at_the_weakest_dependency(self):
+        cycle = PackageCycle(
+            ["api", "cart"],
+            [
+                PackageDependency(
+                    "cart",
+                    "api",
+                    [
+                        ImportSite("cart/cart.go", 3, "shop/api"),
+                        ImportSite("cart/view.go", 5, "shop/api"),
+                    ],
+                ),
+                PackageDependency(
+                    "api", "cart", [ImportSite("api/a.go", 4, "shop/cart")] * 3
+                ),
+            ],
+        )
+
+        [run] = build_sarif_log(import_cycle_findings([cycle]))["runs"]
+
+        [result] = run["results"]
+        location = result["locations"][0]["physicalLocation"]
+        assert location["artifactLocation"]["uri"] == "cart/cart.go"
+        assert "cart -> api takes the fewest imports to break (2)" in (
+            result["message"]["text"]
+        )
+        [related] = result["relatedLocations"]
+        assert related["physicalLocation"]["region"] == {"startLine": 5}
+
+    def test_taint_path_is_a_code_flow(self):
+        path = TaintPath(
+            source=TaintSource("user_input", "r.FormValue", "shop.api.Search", 10),
+            sink_kind="sql",
+            sink_call="db.Query",
+            sink_function="shop.store.Find",
+            sink_path="store/find.go",
+            sink_line=22,
+            source_path="api/search.go",
+            functions=["shop.api.Search", "shop.store.Find"],
+        )
+
+        [run] = build_sarif_log(taint_findings([path]))["runs"]
+
+        [rule] = run["tool"]["driver"]["rules"]
+        assert rule["id"] == "taint/user_input_to_sql"
+        assert rule["properties"]["tags"] == ["security", "external/cwe/cwe-89"]
+        [result] = run["results"]
+        assert result["level"] == "error"
+        steps = result["codeFlows"][0]["threadFlows"][0]["locations"]
+        assert [
+            step["location"]["physicalLocation"]["artifactLocation"]["uri"]
+            for step in steps
+        ] == ["api/search.go", "store/find.go"]
+        assert steps[1]["location"]["message"]["text"] == "db.Query receives it"
+
+
+class TestVulnerabilityAnalyzer:
+    """Test severity filtering and ordering of vulnerability queries."""
+
+    def test_filters_and_orders_by_severity(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {**SECRET_ROW, "severity": "medium"},
+            {**SECRET_ROW, "severity": "critical"},
+        ]
+
+        rows = VulnerabilityAnalyzer(ingestor).find_vulnerabilities(
+            "medium", ["hardcoded_secret"], "shop", limit=1
+        )
+
+        assert [r["severity"] for r in rows] == ["critical"]
+        params = ingestor.fetch_all.call_args.args[1]
+        assert params["severities"] == ["critical", "high", "medium"]
+        assert params["types"] == ["hardcoded_secret"]
+        assert params["path_prefix"] == "shop/"
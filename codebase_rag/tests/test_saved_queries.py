@@ -0,0 +1,161 @@
+"""Tests for named queries saved in the config file."""
+
+import asyncio
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.config import read_config_file
+from codebase_rag.cypher_templates import TemplateRunner
+from codebase_rag.saved_queries import (
+    SavedQuery,
+    load_saved_queries,
+    parse_arguments,
+    save_query,
+    saved_queries,
+)
+from codebase_rag.symbol_search import SYMBOLS_QUERY
+from codebase_rag.tools.codebase_query import create_saved_query_tool
+
+SYMBOLS = [
+    {"qualified_name": "shop.Calculator", "label": "Class"},
+    {"qualified_name": "shop.Calculator.Divide", "label": "Method"},
+]
+TESTS = [{"test": "shop.test_calc.test_divide"}]
+TESTS_FOR = """
+MATCH (t)-[:TESTS]->(f {qualified_name: $symbol})
+WHERE t.name <> '$not_a_parameter'
+RETURN t.qualified_name AS test
+LIMIT $limit
+"""
+
+
+def _ingestor() -> MagicMock:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params=None: (
+        SYMBOLS if query == SYMBOLS_QUERY else TESTS
+    )
+    return ingestor
+
+
+def _tests_for() -> SavedQuery:
+    return SavedQuery(
+        "tests-for", TESTS_FOR.strip(), "Tests of a function", ["symbol"], {"limit": 50}
+    )
+
+
+class TestLoading:
+    """Test reading [queries.NAME] tables."""
+
+    def test_load(self):
+        document = {
+            "queries": {
+                "tests-for": {
+                    "description": "Tests of a function",
+                    "query": TESTS_FOR,
+                    "symbols": "symbol",
+                    "defaults": {"limit": 50},
+                },
+                "endpoints": "MATCH (e:Endpoint) RETURN e.route AS route",
+            }
+        }
+
+        queries = load_saved_queries(document)
+
+        assert queries["tests-for"] == _tests_for()
+        assert queries["tests-for"].parameters == ["symbol", "limit"]
+        assert queries["endpoints"].parameters == []
+
+    @pytest.mark.parametrize(
+        ("section", "problem"),
+        [
+            (["MATCH (n) RETURN n"], "must map names"),
+            ({"tests for": "MATCH (n) RETURN n"}, "must be a word"),
+            ({"empty": {"description": "nothing"}}, "needs a query"),
+            ({"wipe": "MATCH (n) DETACH DELETE n"}, "writes to the graph"),
+            (
+                {"q": {"query": "MATCH (n) RETURN n", "symbols": ["symbol"]}},
+                "has no parameter \\$symbol",
+            ),
+        ],
+    )
+    def test_problems(self, section, problem):
+        with pytest.raises(ValueError, match=problem):
+            load_saved_queries({"queries": section})
+
+
+class TestRunning:
+    """Test running a saved query with its arguments."""
+
+    def test_run(self):
+        ingestor = _ingestor()
+        arguments = {"symbol": "Calculator.Divde"}
+
+        result = _tests_for().run(TemplateRunner(ingestor), arguments)
+
+        assert result.results == TESTS
+        assert result.parameters == {"symbol": "shop.Calculator.Divide", "limit": 50}
+        assert result.unresolved == []
+        ingestor.fetch_all.assert_called_with(TESTS_FOR.strip(), result.parameters)
+
+    def test_bad_arguments(self):
+        runner = TemplateRunner(_ingestor())
+
+        with pytest.raises(ValueError, match="needs --symbol"):
+            _tests_for().run(runner, {})
+        with pytest.raises(ValueError, match="has no parameter depth"):
+            _tests_for().run(runner, {"symbol": "Divide", "depth": 2})
+
+    def test_arguments(self):
+        assert parse_arguments(
+            ["--symbol", "Calculator.Divide", "--max-depth=3", "--all", "true"]
+        ) == {"symbol": "Calculator.Divide", "max_depth": 3, "all": True}
+        with pytest.raises(ValueError, match="--symbol needs a value"):
+            parse_arguments(["--symbol"])
+        with pytest.raises(ValueError, match="Expected --parameter value"):
+            parse_arguments(["Calculator.Divide"])
+
+
+class TestSaving:
+    """Test appending queries to the config file."""
+
+    def test_save_and_load_back(self, tmp_path):
+        config_file = tmp_path / ".cgr.toml"
+        config_file.write_text("[settings]\nmemgraph_port = 7687\n")
+
+        save_query(config_file, _tests_for())
+        save_query(config_file, SavedQuery("quoted", "MATCH (n) RETURN \"'''\" AS q"))
+
+        queries = saved_queries(config_file)
+        assert queries["tests-for"].query == TESTS_FOR.strip()
+        assert queries["tests-for"].symbols == ["symbol"]
+        assert queries["quoted"].query == "MATCH (n) RETURN \"'''\" AS q"
+        assert read_config_file(config_file)["settings"] == {"memgraph_port": 7687}
+
+    def test_refused(self, tmp_path):
+        config_file = tmp_path / ".cgr.toml"
+        save_query(config_file, _tests_for())
+
+        with pytest.raises(ValueError, match="already saved"):
+            save_query(config_file, _tests_for())
+        with pytest.raises(ValueError, match="Only queries that read"):
+            save_query(config_file, SavedQuery("wipe", "MATCH (n) DELETE n"))
+        with pytest.raises(ValueError, match="by hand"):
+            save_query(tmp_path / "cgr.yaml", _tests_for())
+
+
+class TestSavedQueryTool:
+    """Test the agent's tool for saved queries."""
+
+    def test_runs_by_name(self):
+        tool = create_saved_query_tool(
+            TemplateRunner(_ingestor()), {"tests-for": _tests_for()}
+        )
+
+        data = asyncio.run(tool.function("tests-for", {"symbol": "Divide"}))
+        missing = asyncio.run(tool.function("callers", {}))
+
+        assert data.results == TESTS
+        assert "tests-for saved query" in data.summary
+        assert "tests-for (symbol, limit): Tests of a function" in tool.description
+        assert "No query is saved as 'callers'" in missing.summary
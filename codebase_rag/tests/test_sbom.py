@@ -0,0 +1,172 @@
+"""Tests for SBOM generation from manifests and lockfiles."""
+
+import base64
+import hashlib
+import json
+from pathlib import Path
+
+from codebase_rag.analysis.sbom import (
+    SbomBuilder,
+    parse_package_lock,
+    parse_python_lock,
+    to_cyclonedx,
+    to_spdx,
+)
+
+GO_MOD = """\
+module example.com/shop
+
+go 1.22
+
+require (
+\tgithub.com/go-chi/chi/v5 v5.0.12
+\tgolang.org/x/sys v0.20.0 // indirect
+)
+"""
+
+UV_LOCK = """\
+version = 1
+
+[[package]]
+name = "Requests"
+version = "2.32.3"
+sdist = { url = "https://example.com/requests.tar.gz", hash = "sha256:aaaa" }
+wheels = [{ url = "https://example.com/requests.whl", hash = "sha256:bbbb" }]
+"""
+
+LEFT_PAD_TARBALL = b"left-pad tarball"
+
+
+def _write_repo(root: Path) -> None:
+    (root / "go.mod").write_text(GO_MOD)
+    web = root / "web"
+    web.mkdir()
+    (web / "package.json").write_text(
+        json.dumps(
+            {
+                "name": "shop-web",
+                "dependencies": {"left-pad": "^1.3.0"},
+                "devDependencies": {"jest": "^29.0.0"},
+            }
+        )
+    )
+    integrity = "sha512-" + base64.b64encode(
+        hashlib.sha512(LEFT_PAD_TARBALL).digest()
+    ).decode()
+    (web / "package-lock.json").write_text(
+        json.dumps(
+            {
+                "lockfileVersion": 3,
+                "packages": {
+                    "": {"name": "shop-web"},
+                    "node_modules/left-pad": {
+                        "version": "1.3.0",
+                        "license": "WTFPL",
+                        "integrity": integrity,
+                    },
+                    "node_modules/jest": {"version": "29.7.0", "dev": True},
+                    "node_modules/jest/node_modules/left-pad": {"version": "1.1.0"},
+                },
+            }
+        )
+    )
+    tools = root / "tools"
+    tools.mkdir()
+    (tools / "pyproject.toml").write_text(
+        '[project]\nname = "shop-tools"\ndependencies = ["requests>=2.31", "click"]\n'
+    )
+    (tools / "uv.lock").write_text(UV_LOCK)
+
+
+class TestLockfiles:
+    """Test lockfile parsing."""
+
+    def test_package_lock_ignores_nested_copies(self):
+        locked = parse_package_lock(
+            json.dumps(
+                {
+                    "packages": {
+                        "node_modules/a": {"version": "2.0.0", "license": "MIT"},
+                        "node_modules/b/node_modules/a": {"version": "1.0.0"},
+                    }
+                }
+            )
+        )
+        assert locked["a"]["version"] == "2.0.0"
+        assert locked["a"]["license"] == "MIT"
+
+    def test_python_lock_prefers_sdist_hash(self):
+        locked = parse_python_lock(UV_LOCK)
+        assert locked["requests"] == {
+            "version": "2.32.3",
+            "hashes": {"SHA-256": "aaaa"},
+        }
+
+
+class TestSbomBuilder:
+    """Test component collection and document rendering."""
+
+    def test_collect_repository(self, temp_repo: Path):
+        _write_repo(temp_repo)
+
+        components = SbomBuilder(temp_repo).collect()
+
+        by_name = {c.name: c for c in components}
+        assert sorted(by_name) == [
+            "click",
+            "github.com/go-chi/chi/v5",
+            "golang.org/x/sys",
+            "left-pad",
+            "requests",
+        ]
+        assert by_name["golang.org/x/sys"].purl == "pkg:golang/golang.org/x/sys@v0.20.0"
+        left_pad = by_name["left-pad"]
+        assert (left_pad.version, left_pad.license) == ("1.3.0", "WTFPL")
+        assert left_pad.hashes == {
+            "SHA-512": hashlib.sha512(LEFT_PAD_TARBALL).hexdigest()
+        }
+        assert by_name["requests"].purl == "pkg:pypi/requests@2.32.3"
+        # Unlocked and unpinned: the constraint is kept, the purl has no version
+        assert by_name["click"].purl == "pkg:pypi/click"
+
+    def test_scoped_to_manifest_with_dev(self, temp_repo: Path):
+        _write_repo(temp_repo)
+
+        components = SbomBuilder(temp_repo, include_dev=True).collect(
+            "web/package.json"
+        )
+
+        assert [(c.name, c.version, c.dev) for c in components] == [
+            ("jest", "29.7.0", True),
+            ("left-pad", "1.3.0", False),
+        ]
+
+    def test_documents(self, temp_repo: Path):
+        _write_repo(temp_repo)
+        components = SbomBuilder(temp_repo, include_dev=True).collect(
+            "web/package.json"
+        )
+
+        bom = to_cyclonedx(components, "shop/web")
+        assert bom["bomFormat"] == "CycloneDX"
+        jest, left_pad = bom["components"]
+        assert jest["scope"] == "optional"
+        assert left_pad["purl"] == "pkg:npm/left-pad@1.3.0"
+        assert left_pad["licenses"] == [{"expression": "WTFPL"}]
+        assert left_pad["hashes"][0]["alg"] == "SHA-512"
+
+        spdx = to_spdx(components, "shop/web")
+        assert spdx["spdxVersion"] == "SPDX-2.3"
+        root, jest_pkg, left_pad_pkg = spdx["packages"]
+        assert left_pad_pkg["licenseDeclared"] == "WTFPL"
+        assert jest_pkg["licenseDeclared"] == "NOASSERTION"
+        assert left_pad_pkg["checksums"][0]["algorithm"] == "SHA512"
+        relationships = {
+            (r["spdxElementId"], r["relationshipType"], r["relatedSpdxElement"])
+            for r in spdx["relationships"]
+        }
+        assert relationships == {
+            ("SPDXRef-DOCUMENT", "DESCRIBES", root["SPDXID"]),
+            (jest_pkg["SPDXID"], "DEV_DEPENDENCY_OF", root["SPDXID"]),
+            (root["SPDXID"], "DEPENDS_ON", left_pad_pkg["SPDXID"]),
+        }
@@ -0,0 +1,167 @@
+"""Tests for cron-scheduled server jobs and their run history."""
+
+from datetime import datetime
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.auth import ApiToken, Role, TokenRegistry, hash_token
+from codebase_rag.server.scheduler import (
+    CronSchedule,
+    RunHistory,
+    Scheduler,
+    create_schedule_routes,
+    load_jobs,
+)
+
+TASKS = ["ingest", "hotspots", "smells"]
+
+
+class TestCronSchedule:
+    """Test parsing and evaluating cron expressions."""
+
+    def test_fields(self):
+        schedule = CronSchedule.parse("*/15 9-17 * * 1-5")
+
+        assert schedule.minutes == {0, 15, 30, 45}
+        assert schedule.hours == set(range(9, 18))
+        # Wednesday 2026-10-14, 09:30
+        assert schedule.matches(datetime(2026, 10, 14, 9, 30))
+        assert not schedule.matches(datetime(2026, 10, 14, 9, 31))
+        assert not schedule.matches(datetime(2026, 10, 18, 9, 30))  # Sunday
+
+    def test_next_after(self):
+        nightly = CronSchedule.parse("0 2 * * *")
+        sundays = CronSchedule.parse("30 4 * * 7")
+
+        assert nightly.next_after(datetime(2026, 10, 14, 2, 0)) == datetime(
+            2026, 10, 15, 2, 0
+        )
+        assert sundays.next_after(datetime(2026, 10, 14, 12, 0)) == datetime(
+            2026, 10, 18, 4, 30
+        )
+
+    def test_day_of_month_or_weekday(self):
+        # Both restricted: the 1st of the month or any Monday, as in cron
+        schedule = CronSchedule.parse("0 0 1 * 1")
+
+        assert schedule.matches(datetime(2026, 10, 1))  # Thursday
+        assert schedule.matches(datetime(2026, 10, 12))  # Monday
+        assert not schedule.matches(datetime(2026, 10, 13))
+
+    @pytest.mark.parametrize(
+        "expression", ["* * * *", "60 * * * *", "*/0 * * * *", "a * * * *"]
+    )
+    def test_invalid(self, expression):
+        with pytest.raises(ValueError):
+            CronSchedule.parse(expression)
+
+    def test_never_fires(self):
+        with pytest.raises(ValueError, match="never fires"):
+            CronSchedule.parse("0 0 31 2 *").next_after(datetime(2026, 1, 1))
+
+
+class TestLoadJobs:
+    """Test reading jobs from a repository config file."""
+
+    def test_jobs(self):
+        document = {
+            "settings": {"memgraph_port": 7687},
+            "schedule": {
+                "hourly": {"cron": "15 * * * *", "tasks": "ingest"},
+                "nightly": {"cron": "0 2 * * *", "tasks": ["ingest", "smells"]},
+            },
+        }
+
+        jobs = load_jobs(document, TASKS)
+
+        assert [(job.name, job.tasks) for job in jobs] == [
+            ("hourly", ["ingest"]),
+            ("nightly", ["ingest", "smells"]),
+        ]
+        assert load_jobs({"settings": {}}, TASKS) == []
+
+    @pytest.mark.parametrize(
+        "entry, message",
+        [
+            ({"tasks": ["ingest"]}, "needs a cron expression"),
+            ({"cron": "0 2 * * *", "tasks": ["embeddings"]}, "embeddings"),
+            ({"cron": "0 2 * * *"}, "no tasks"),
+            ({"cron": "0 25 * * *", "tasks": ["ingest"]}, "hour '25'"),
+        ],
+    )
+    def test_invalid_jobs(self, entry, message):
+        with pytest.raises(ValueError, match=message):
+            load_jobs({"schedule": {"nightly": entry}}, TASKS)
+
+
+class TestScheduler:
+    """Test running due jobs and recording their runs."""
+
+    def test_tick_runs_due_jobs_in_order(self, tmp_path):
+        calls = []
+        tasks = {
+            "ingest": lambda: calls.append("ingest") or {"status": "unchanged"},
+            "hotspots": lambda: calls.append("hotspots") or {"functions": 3},
+            "smells": MagicMock(side_effect=RuntimeError("graph unavailable")),
+        }
+        document = {
+            "schedule": {
+                "hourly": {"cron": "0 * * * *", "tasks": ["ingest", "hotspots"]},
+                "nightly": {"cron": "0 2 * * *", "tasks": ["smells", "ingest"]},
+            }
+        }
+        history = RunHistory(tmp_path / "runs.jsonl")
+        scheduler = Scheduler(load_jobs(document, TASKS), tasks, history)
+
+        assert scheduler.tick(datetime(2026, 10, 14, 2, 30)) == []
+        hourly, nightly = scheduler.tick(datetime(2026, 10, 14, 2, 0))
+
+        assert calls == ["ingest", "hotspots"]
+        assert hourly.status == "succeeded"
+        assert hourly.results["hotspots"] == {"functions": 3}
+        assert nightly.status == "failed"
+        assert nightly.error == "smells: graph unavailable"
+        assert nightly.results == {}
+
+        reloaded = RunHistory(tmp_path / "runs.jsonl")
+        assert [run.job for run in reloaded.recent()] == ["nightly", "hourly"]
+        assert [run.status for run in reloaded.recent("hourly")] == ["succeeded"]
+
+
+class TestScheduleRoutes:
+    """Test the schedule and run history API."""
+
+    def setup_method(self):
+        document = {"schedule": {"nightly": {"cron": "0 2 * * *", "tasks": "ingest"}}}
+        self.scheduler = Scheduler(
+            load_jobs(document, TASKS), {"ingest": lambda: {"status": "unchanged"}}
+        )
+        self.scheduler.tick(datetime(2026, 10, 14, 2, 0))
+        registry = TokenRegistry(
+            {
+                hash_token("shop"): ApiToken("shop", Role.READ_ONLY, ("shop",)),
+                hash_token("other"): ApiToken("other", Role.ADMIN, ("billing",)),
+            }
+        )
+        self.server = GraphServer()
+        create_schedule_routes(self.server, self.scheduler, registry, "shop")
+
+    def _get(self, path: str, token: str | None = "shop"):
+        headers = {"authorization": f"Bearer {token}"} if token else {}
+        return self.server.handle(Request("GET", path, headers))
+
+    def test_jobs_and_runs(self):
+        jobs = self._get("/schedule").body["jobs"]
+        runs = self._get("/schedule/runs?job=nightly&limit=5").body["runs"]
+
+        assert jobs[0]["name"] == "nightly"
+        assert datetime.fromisoformat(jobs[0]["next_run"]).hour == 2
+        assert [run["status"] for run in runs] == ["succeeded"]
+        assert self._get("/schedule/runs?job=hourly").body["runs"] == []
+        assert self._get("/schedule/runs?limit=x").status == 400
+
+    def test_requires_access_to_the_repository(self):
+        assert self._get("/schedule", token=None).status == 401
+        assert self._get("/schedule/runs", token="other").status == 403
@@ -0,0 +1,150 @@
+"""Tests for exporting the graph as a SCIP index."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.precise_index import Occurrence, parse_scip
+from codebase_rag.analysis.scip_export import (
+    CALLS_QUERY,
+    DEFINITIONS_QUERY,
+    ENDPOINTS_QUERY,
+    IMPLEMENTATIONS_QUERY,
+    TESTS_QUERY,
+    ScipExporter,
+)
+from codebase_rag.utils.protobuf_wire import fields, int_field, string_field
+
+CART_SOURCE = """\
+class Cart:
+    def total(self):
+        return price(self.items)
+
+
+def price(items):
+    return sum(items)
+"""
+
+API_SOURCE = """\
+@app.post("/checkout")
+def checkout():
+    return Cart().total()
+"""
+
+SYMBOL = "graph-code . shop . "
+
+
+def _definition(qn: str, label: str, path: str, start: int, end: int) -> dict:
+    module = qn.rsplit(".", 2 if label == "Method" else 1)[0]
+    return {
+        "module": module,
+        "path": path,
+        "qualified_name": qn,
+        "name": qn.rsplit(".", 1)[1],
+        "label": label,
+        "start_line": start,
+        "end_line": end,
+        "docstring": None,
+    }
+
+
+def _export(repo: Path) -> tuple[bytes, dict[str, int]]:
+    responses = {
+        DEFINITIONS_QUERY: [
+            _definition("shop.cart.Cart", "Class", "cart.py", 1, 3),
+            _definition("shop.cart.Cart.total", "Method", "cart.py", 2, 3),
+            _definition("shop.cart.price", "Function", "cart.py", 6, 7),
+            _definition("shop.api.checkout", "Function", "api.py", 2, 3),
+            _definition("shop.tests.test_cart.test_total", "Function", "t.py", 1, 2),
+        ],
+        CALLS_QUERY: [
+            {"source": "shop.cart.Cart.total", "target": "shop.cart.price"},
+            {"source": "shop.api.checkout", "target": "shop.cart.Cart.total"},
+            {"source": "shop.api.checkout", "target": "stdlib.json.dumps"},
+        ],
+        IMPLEMENTATIONS_QUERY: [],
+        TESTS_QUERY: [
+            {
+                "source": "shop.tests.test_cart.test_total",
+                "target": "shop.cart.Cart.total",
+            }
+        ],
+        ENDPOINTS_QUERY: [
+            {
+                "qualified_name": "shop.api:POST /checkout",
+                "method": "POST",
+                "route": "/checkout",
+                "framework": "fastapi",
+                "line_number": 1,
+                "module": "shop.api",
+                "path": "api.py",
+                "handlers": ["shop.api.checkout"],
+            }
+        ],
+    }
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, params=None: responses[query]
+    return ScipExporter(ingestor, repo, project="shop").export()
+
+
+def _symbol_infos(index: bytes) -> dict[str, bytes]:
+    infos = {}
+    for number, document in fields(index):
+        if number != 2:
+            continue
+        for doc_number, value in fields(document):
+            if doc_number == 3:
+                infos[string_field(value, 1)] = value
+    return infos
+
+
+def _relationships(info: bytes) -> list[tuple[str, int, int]]:
+    return [
+        (string_field(value, 1), int_field(value, 2), int_field(value, 3))
+        for number, value in fields(info)
+        if number == 4
+    ]
+
+
+class TestScipExport:
+    """Test the encoded index against the SCIP reader used for imports."""
+
+    def test_definitions_and_call_references(self, temp_repo: Path):
+        (temp_repo / "cart.py").write_text(CART_SOURCE)
+        (temp_repo / "api.py").write_text(API_SOURCE)
+
+        index, stats = _export(temp_repo)
+        parsed = parse_scip(index)
+
+        assert parsed.tool == "graph-code"
+        total = f"{SYMBOL}cart/Cart#total()."
+        price = f"{SYMBOL}cart/price()."
+        assert Occurrence(total, "cart.py", 2, True) in parsed.occurrences
+        assert Occurrence(price, "cart.py", 3, False) in parsed.occurrences
+        assert Occurrence(total, "api.py", 3, False) in parsed.occurrences
+        # The call out of the project has no symbol to reference
+        assert stats["references"] == 2
+        assert stats["documents"] == 3
+
+    def test_tests_and_endpoints_become_relationships(self, temp_repo: Path):
+        (temp_repo / "api.py").write_text(API_SOURCE)
+
+        index, stats = _export(temp_repo)
+        infos = _symbol_infos(index)
+
+        endpoint = f"{SYMBOL}api/`POST /checkout`."
+        test = infos[f"{SYMBOL}tests/test_cart/test_total()."]
+        handler = infos[f"{SYMBOL}api/checkout()."]
+        assert _relationships(test) == [(f"{SYMBOL}cart/Cart#total().", 1, 0)]
+        assert _relationships(handler) == [(endpoint, 0, 1)]
+        assert "fastapi" in string_field(infos[endpoint], 3)
+        assert Occurrence(endpoint, "api.py", 1, True) in parse_scip(index).occurrences
+        assert stats["tests"] == 1
+        assert stats["endpoints"] == 1
+
+    def test_missing_sources_keep_definitions(self, temp_repo: Path):
+        index, stats = _export(temp_repo)
+
+        definitions = [o for o in parse_scip(index).occurrences if o.is_definition]
+        assert len(definitions) == 6
+        # Call positions need the caller's source
+        assert "references" not in stats
@@ -0,0 +1,332 @@
+"""Tests for semantic code search over embeddings and the call graph."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.semantic_search import (
+    DOCUMENTS_QUERY,
+    INDEX_QUERY,
+    NEIGHBOURS_QUERY,
+    STORE_EMBEDDINGS,
+    STORE_INDEX,
+    VECTORS_QUERY,
+    EmbeddingMismatchError,
+    SemanticIndex,
+    cosine,
+    document_text,
+)
+from codebase_rag.services.embeddings import HashingEmbedder, stem, text_words
+from codebase_rag.services.rerankers import rerank
+
+SOURCE = '''def wait_and_repeat(call, attempts):
+    """Retry a failing call, waiting longer after each attempt."""
+    for attempt in range(attempts):
+        time.sleep(2 ** attempt)
+
+
+def send_invoice(invoice):
+    wait_and_repeat(lambda: mailer.send(invoice), 5)
+
+
+def parse_amount(text):
+    return Decimal(text.replace(",", ""))
+'''
+
+
+def _row(qn: str, start: int, end: int, docstring: str | None = None) -> dict:
+    return {
+        "qualified_name": qn,
+        "label": "Function",
+        "name": qn.rsplit(".", 1)[-1],
+        "docstring": docstring,
+        "path": "billing/jobs.py",
+        "start_line": start,
+        "end_line": end,
+        "embedding_hash": None,
+    }
+
+
+DOCUMENTS = [
+    _row("billing.jobs.wait_and_repeat", 1, 4, "Retry a failing call."),
+    _row("billing.jobs.send_invoice", 7, 8),
+    _row("billing.jobs.parse_amount", 11, 12),
+]
+
+
+class FakeGraph:
+    """Keeps the embeddings written, and answers the reads from them."""
+
+    def __init__(self):
+        self.stored: dict[str, dict] = {}
+        self.index: dict | None = None
+
+    def fetch_all(self, query: str, params: dict | None = None) -> list[dict]:
+        if query == INDEX_QUERY:
+            return [self.index] if self.index else []
+        if query == DOCUMENTS_QUERY:
+            return [
+                {**row, "embedding_hash": self._stored(row).get("hash")}
+                for row in DOCUMENTS
+            ]
+        if query == VECTORS_QUERY:
+            return [
+                {**row, "embedding": self._stored(row)["embedding"]}
+                for row in DOCUMENTS
+                if self._stored(row).get("model") == params["model"]
+            ]
+        assert query == NEIGHBOURS_QUERY
+        calls = [("billing.jobs.send_invoice", "billing.jobs.wait_and_repeat")]
+        rows = []
+        for qn in params["qualified_names"]:
+            for caller, callee in calls:
+                if qn in (caller, callee):
+                    other = callee if qn == caller else caller
+                    rows.append(
+                        {
+                            "hit": qn,
+                            "qualified_name": other,
+                            "label": "Function",
+                            "path": "billing/jobs.py",
+                            "start_line": 7,
+                            "end_line": 8,
+                            "relation": "callee" if qn == caller else "caller",
+                        }
+                    )
+        return rows
+
+    def _stored(self, row: dict) -> dict:
+        return self.stored.get(row["qualified_name"], {})
+
+    def execute_write(self, query: str, params: dict) -> None:
+        if query == STORE_INDEX:
+            self.index = params
+            return
+        assert query == STORE_EMBEDDINGS
+        for row in params["rows"]:
+            self.stored[row["qualified_name"]] = row
+
+
+class KeywordReranker:
+    """Scores a snippet by how often it mentions a word."""
+
+    name = "keyword"
+
+    def __init__(self, word: str):
+        self.word = word
+        self.texts: list[str] = []
+
+    def score(self, question: str, texts: list[str]) -> list[float]:
+        self.texts = texts
+        return [float(text.count(self.word)) for text in texts]
+
+
+class FailingReranker:
+    name = "failing"
+
+    def score(self, question: str, texts: list[str]) -> list[float]:
+        raise RuntimeError("model unavailable")
+
+
+def _index(tmp_path, reranker=None) -> tuple[SemanticIndex, FakeGraph]:
+    (tmp_path / "billing").mkdir()
+    (tmp_path / "billing" / "jobs.py").write_text(SOURCE)
+    graph = FakeGraph()
+    index = SemanticIndex(graph, HashingEmbedder(), tmp_path, reranker, 1)
+    return index, graph
+
+
+class TestHashingEmbedder:
+    """Test embedding without a model."""
+
+    def test_words(self):
+        assert stem("retries") == stem("retry") == "retry"
+        assert text_words("def retryWithBackoff(self):") == ["retry", "backoff"]
+
+    def test_similar_vocabulary_is_closer(self):
+        embedder = HashingEmbedder()
+        question, retry, parse = embedder.embed(
+            ["where do we retry failed calls", "retries a call", "parse an amount"]
+        )
+
+        assert cosine(question, retry) > cosine(question, parse)
+        assert abs(cosine(retry, retry) - 1.0) < 1e-9
+
+
+class TestSemanticIndex:
+    """Test storing embeddings and searching them with graph expansion."""
+
+    def test_document_text(self):
+        text = document_text(DOCUMENTS[0], "for attempt in range(attempts):")
+
+        assert text.splitlines() == [
+            "Function billing.jobs.wait_and_repeat",
+            "Retry a failing call.",
+            "for attempt in range(attempts):",
+        ]
+
+    def test_build_skips_unchanged(self, tmp_path):
+        index, graph = _index(tmp_path)
+
+        first = index.build()
+        second = index.build()
+
+        assert first == {"embedded": 3, "unchanged": 0}
+        assert second == {"embedded": 0, "unchanged": 3}
+        assert graph.stored["billing.jobs.parse_amount"]["model"] == "hashing-512"
+
+    def test_search_expands_to_callers(self, tmp_path):
+        index, _ = _index(tmp_path)
+        index.build()
+
+        # The caller shares no words with the question
+        hits = index.search("where do we retry failing calls", limit=2)
+
+        assert [(h.qualified_name, h.relation, h.via) for h in hits] == [
+            ("billing.jobs.wait_and_repeat", "match", None),
+            ("billing.jobs.send_invoice", "caller", "billing.jobs.wait_and_repeat"),
+        ]
+
+    def test_chunk_match_reports_its_function(self):
+        embedder = HashingEmbedder()
+        head, chunk, other = embedder.embed(
+            ["load the open orders", "retry failing calls", "parse an amount"]
+        )
+        function = _row("billing.jobs.settle", 1, 240)
+        graph = MagicMock()
+        graph.fetch_all.return_value = [
+            {**function, "embedding": head},
+            {
+                **function,
+                "qualified_name": "billing.jobs.settle:chunk2",
+                "embedding": chunk,
+                "parent": "billing.jobs.settle",
+                "chunk_start": 153,
+                "chunk_end": 232,
+            },
+            {**_row("billing.jobs.parse_amount", 11, 12), "embedding": other},
+        ]
+
+        [hit] = SemanticIndex(graph, embedder).search(
+            "where do we retry failing calls", limit=1, expand=False
+        )
+
+        assert (hit.qualified_name, hit.start_line, hit.end_line) == (
+            "billing.jobs.settle",
+            1,
+            240,
+        )
+        assert (hit.chunk_start, hit.chunk_end) == (153, 232)
+
+    def test_generated_code_ranks_below_handwritten(self):
+        embedder = HashingEmbedder()
+        [vector] = embedder.embed(["retry failing calls"])
+        graph = MagicMock()
+        graph.fetch_all.return_value = [
+            {
+                **_row("billing.pb.retry_pb.Retry", 1, 9),
+                "embedding": vector,
+                "generated": True,
+            },
+            {**_row("billing.jobs.retry", 1, 9), "embedding": vector},
+        ]
+
+        hits = SemanticIndex(graph, embedder).search(
+            "retry failing calls", expand=False
+        )
+
+        assert [hit.qualified_name for hit in hits] == [
+            "billing.jobs.retry",
+            "billing.pb.retry_pb.Retry",
+        ]
+        assert hits[1].score < hits[0].score
+
+    def test_documentation_matches(self):
+        embedder = HashingEmbedder()
+        doc, other = embedder.embed(
+            ["Package cart keeps the items a customer is about to order", "parse"]
+        )
+        graph = MagicMock()
+        graph.fetch_all.return_value = [
+            {
+                "qualified_name": "shop.cart.doc:doc",
+                "label": "Documentation",
+                "path": "cart/doc.go",
+                "start_line": 1,
+                "end_line": 2,
+                "embedding": doc,
+            },
+            {**_row("billing.jobs.parse_amount", 11, 12), "embedding": other},
+        ]
+
+        [hit] = SemanticIndex(graph, embedder).search(
+            "what are carts for", limit=1, expand=False
+        )
+
+        assert (hit.qualified_name, hit.label, hit.path) == (
+            "shop.cart.doc:doc",
+            "Documentation",
+            "cart/doc.go",
+        )
+
+    def test_index_records_the_embedder(self, tmp_path):
+        index, graph = _index(tmp_path)
+
+        index.build()
+
+        assert (graph.index["provider"], graph.index["model"]) == (
+            "hashing",
+            "hashing-512",
+        )
+        assert graph.index["dimension"] == 512
+
+    def test_other_embedder_fails_loudly(self, tmp_path):
+        index, graph = _index(tmp_path)
+        index.build()
+        other = SemanticIndex(graph, HashingEmbedder(256), tmp_path)
+
+        with pytest.raises(EmbeddingMismatchError, match="hashing-512"):
+            other.build()
+        with pytest.raises(EmbeddingMismatchError, match="embed --rebuild"):
+            other.search("retries")
+
+        assert other.build(rebuild=True) == {"embedded": 3, "unchanged": 0}
+        assert graph.index["dimension"] == 256
+        assert other.search("where do we retry failing calls", expand=False)
+
+    def test_vectors_of_another_size_fail_loudly(self):
+        graph = MagicMock()
+        graph.fetch_all.return_value = [
+            {**DOCUMENTS[0], "embedding": [1.0, 0.0, 0.0]}
+        ]
+
+        with pytest.raises(EmbeddingMismatchError, match="512 dimensions"):
+            SemanticIndex(graph, HashingEmbedder()).search("retries")
+
+    def test_search_without_embeddings(self):
+        graph = MagicMock()
+        graph.fetch_all.return_value = []
+
+        assert SemanticIndex(graph, HashingEmbedder()).search("retries") == []
+
+
+class TestReranking:
+    """Test reranking retrieved symbols by their source."""
+
+    def test_keeps_the_most_relevant(self, tmp_path):
+        reranker = KeywordReranker("mailer")
+        index, _ = _index(tmp_path, reranker)
+        index.build()
+
+        hits = index.search("where do we retry failing calls", limit=2)
+
+        assert [(h.qualified_name, h.rerank_score) for h in hits] == [
+            ("billing.jobs.send_invoice", 1.0)
+        ]
+        # The reranker reads the source, not only the name
+        assert any("mailer.send(invoice)" in text for text in reranker.texts)
+
+    def test_failure_keeps_retrieval_order(self):
+        ranked = rerank(FailingReranker(), "retries", ["a", "b", "c"], str, 2)
+
+        assert ranked == [("a", None), ("b", None)]
@@ -0,0 +1,133 @@
+"""Tests for the REST endpoints of the server mode."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.citations import NAMES_QUERY
+from codebase_rag.mcp.tools import SYMBOL_DETAILS_QUERY
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.api import create_api_routes
+from codebase_rag.server.auth import ApiToken, Role, TokenRegistry, hash_token
+from codebase_rag.symbol_search import SYMBOLS_QUERY
+
+REGISTRY = TokenRegistry(
+    {
+        hash_token("viewer"): ApiToken("viewer", Role.READ_ONLY, ("shop",)),
+        hash_token("outsider"): ApiToken("outsider", Role.ADMIN, ("billing",)),
+        hash_token("analyst"): ApiToken("analyst", Role.ANALYST),
+        hash_token("scoped"): ApiToken("scoped", Role.ANALYST, ("shop",)),
+        hash_token("admin"): ApiToken("admin", Role.ADMIN),
+    }
+)
+
+
+def _graph(query, params=None):
+    if query == SYMBOLS_QUERY:
+        return [
+            {"qualified_name": "shop.cart.total", "label": "Function"},
+            {"qualified_name": "billing.invoice.total", "label": "Function"},
+        ]
+    if query == SYMBOL_DETAILS_QUERY:
+        return [
+            {"qualified_name": qn, "path": "cart.py", "start_line": 3}
+            for qn in params["qualified_names"]
+        ]
+    if query == NAMES_QUERY:
+        return [
+            {
+                "name": "shop.cart.total",
+                "qualified_name": "shop.cart.total",
+                "label": "Function",
+                "node_id": 7,
+                "path": "cart.py",
+                "start_line": 3,
+                "end_line": 5,
+            }
+        ]
+    return []
+
+
+def _request(server: GraphServer, method: str, path: str, token: str | None, body=None):
+    headers = {"authorization": f"Bearer {token}"} if token else {}
+    payload = json.dumps(body).encode() if body is not None else b""
+    return server.handle(Request(method, path, headers, payload))
+
+
+class TestRestApi:
+    """Test the /api endpoints and who may call them."""
+
+    def setup_method(self):
+        self.ingestor = MagicMock()
+        self.ingestor.fetch_all.side_effect = _graph
+        self.ingest = MagicMock(return_value={"status": "unchanged"})
+        self.answer = MagicMock(return_value="It is summed in `shop.cart.total`.")
+        self.server = GraphServer()
+        create_api_routes(
+            self.server, self.ingestor, REGISTRY, "shop", self.ingest, self.answer
+        )
+
+    def test_symbols_of_the_served_project(self):
+        response = _request(self.server, "GET", "/api/symbols?q=total", "viewer")
+
+        assert response.status == 200
+        assert response.body["symbols"] == [
+            {
+                "qualified_name": "shop.cart.total",
+                "label": "Function",
+                "score": 1.0,
+                "path": "cart.py",
+                "start_line": 3,
+            }
+        ]
+        assert _request(self.server, "GET", "/api/symbols", "viewer").status == 400
+        path = "/api/symbols?q=total&limit=many"
+        assert _request(self.server, "GET", path, "viewer").status == 400
+        assert _request(self.server, "GET", "/api/symbols?q=total", None).status == 401
+        outsider = _request(self.server, "GET", "/api/symbols?q=total", "outsider")
+        assert outsider.status == 403
+
+    def test_ask_returns_a_cited_answer(self):
+        question = {"question": " Where is the cart total computed? "}
+
+        viewer = _request(self.server, "POST", "/api/ask", "viewer", question)
+        assert viewer.status == 403
+        response = _request(self.server, "POST", "/api/ask", "analyst", question)
+
+        assert response.status == 200
+        self.answer.assert_called_once_with("Where is the cart total computed?")
+        assert response.body["answer"] == "It is summed in `shop.cart.total`."
+        assert response.body["citations"][0]["path"] == "cart.py"
+        empty = _request(self.server, "POST", "/api/ask", "analyst", {"question": ""})
+        assert empty.status == 400
+
+    def test_ask_needs_a_token_for_all_repos(self):
+        question = {"question": "What does billing.invoice.total add up?"}
+
+        response = _request(self.server, "POST", "/api/ask", "scoped", question)
+
+        assert response.status == 403
+        assert "citations" not in response.body
+        self.answer.assert_not_called()
+        self.ingestor.fetch_all.assert_not_called()
+
+    def test_ask_without_a_model(self):
+        server = GraphServer()
+        create_api_routes(server, self.ingestor, REGISTRY, "shop", self.ingest)
+
+        response = _request(server, "POST", "/api/ask", "admin", {"question": "Why?"})
+
+        assert response.status == 503
+
+    def test_ingest_needs_the_admin_role(self):
+        assert _request(self.server, "POST", "/api/ingest", "analyst").status == 403
+        self.ingest.assert_not_called()
+
+        response = _request(self.server, "POST", "/api/ingest", "admin")
+
+        assert response.status == 200
+        assert response.body == {"status": "unchanged"}
+
+    def test_cypher_is_served_too(self):
+        body = {"cypher": "MATCH (f:Function) RETURN f.name AS name"}
+
+        assert _request(self.server, "POST", "/query", "analyst", body).status == 200
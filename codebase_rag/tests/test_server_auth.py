@@ -0,0 +1,152 @@
+"""Tests for API tokens, roles and scopes on the server endpoints."""
+
+import json
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.server import GraphServer, Request
+from codebase_rag.server.auth import (
+    ApiToken,
+    Role,
+    TokenRegistry,
+    add_token,
+    hash_token,
+)
+from codebase_rag.server.editor import FORBIDDEN, create_editor_routes
+from codebase_rag.server.query import create_query_routes, is_read_only
+
+REGISTRY = TokenRegistry(
+    {
+        hash_token("viewer"): ApiToken("viewer", Role.READ_ONLY, ("shop",)),
+        hash_token("analyst"): ApiToken("analyst", Role.ANALYST),
+        hash_token("scoped"): ApiToken("scoped", Role.ANALYST, ("shop",)),
+        hash_token("admin"): ApiToken("admin", Role.ADMIN),
+    }
+)
+
+
+def _post(server: GraphServer, path: str, body: dict, token: str | None):
+    headers = {"authorization": f"Bearer {token}"} if token else {}
+    return server.handle(Request("POST", path, headers, json.dumps(body).encode()))
+
+
+def _rpc(server: GraphServer, method: str, params: dict, token: str):
+    body = {"jsonrpc": "2.0", "id": 1, "method": method, "params": params}
+    return _post(server, "/rpc", body, token).body
+
+
+class TestTokens:
+    """Test roles, the tokens file and authentication."""
+
+    def test_roles(self):
+        assert Role.parse("read-only") is Role.READ_ONLY
+        assert Role.parse(" Admin ") is Role.ADMIN
+        assert Role.ANALYST.label == "analyst"
+        with pytest.raises(ValueError):
+            Role.parse("owner")
+
+    def test_add_token_round_trip(self, tmp_path):
+        path = tmp_path / "tokens.toml"
+        secret = add_token(path, "ci", Role.ANALYST, ["shop"])
+        add_token(path, "ops", Role.ADMIN, [])
+
+        assert secret not in path.read_text()
+        registry = TokenRegistry.from_file(path)
+        request = Request("GET", "/", {"authorization": f"Bearer {secret}"})
+        assert registry.authenticate(request) == ApiToken(
+            "ci", Role.ANALYST, ("shop",)
+        )
+        assert registry.authenticate(Request("GET", "/")) is None
+        assert len(registry.tokens) == 2
+
+    def test_invalid_role_in_file(self, tmp_path):
+        path = tmp_path / "tokens.toml"
+        path.write_text('[tokens.x]\nsha256 = "ab"\nrole = "owner"\n')
+
+        with pytest.raises(ValueError, match="token 'x'"):
+            TokenRegistry.from_file(path)
+
+
+class TestQueryEndpoint:
+    """Test who may run which Cypher on /query."""
+
+    def setup_method(self):
+        self.ingestor = MagicMock()
+        self.ingestor.fetch_all.return_value = [{"name": "total"}]
+        self.server = GraphServer()
+        create_query_routes(self.server, self.ingestor, REGISTRY)
+
+    def test_read_only_detection(self):
+        assert is_read_only("MATCH (f:Function) RETURN f.name LIMIT 5")
+        assert not is_read_only("MATCH (f) detach delete f")
+        assert not is_read_only("CALL mg.procedures() YIELD name RETURN name")
+
+    def test_access(self):
+        read = {"cypher": "MATCH (f:Function) RETURN f.name AS name"}
+        write = {"cypher": "MATCH (f:Function) SET f.reviewed = true"}
+
+        assert _post(self.server, "/query", read, None).status == 401
+        assert _post(self.server, "/query", read, "viewer").status == 403
+        assert _post(self.server, "/query", read, "scoped").status == 403
+        assert _post(self.server, "/query", write, "analyst").status == 403
+        response = _post(self.server, "/query", read, "analyst")
+        assert response.status == 200
+        assert response.body == {"rows": [{"name": "total"}], "truncated": False}
+        assert _post(self.server, "/query", write, "admin").status == 200
+
+
+class TestEditorScopes:
+    """Test roles and project scopes on the editor endpoint."""
+
+    def setup_method(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+        self.server = GraphServer()
+        create_editor_routes(self.server, ingestor, registry=REGISTRY)
+
+    def test_unknown_token(self):
+        response = _post(self.server, "/rpc", {}, "s3cret")
+        assert response.status == 401
+
+    def test_project_scope(self):
+        allowed = _rpc(
+            self.server, "graph/jumpToNode", {"qualifiedName": "shop.cart"}, "viewer"
+        )
+        refused = _rpc(
+            self.server, "graph/jumpToNode", {"qualifiedName": "billing.x"}, "viewer"
+        )
+
+        assert allowed["result"] is None
+        assert refused["error"]["code"] == FORBIDDEN
+
+    def test_role_per_method(self):
+        params = {
+            "file": "/src/shop/cart.py",
+            "startLine": 0,
+            "endLine": 3,
+            "workspaceFolders": ["/src/shop"],
+        }
+
+        refused = _rpc(self.server, "graph/askAboutSelection", params, "viewer")
+        allowed = _rpc(self.server, "graph/askAboutSelection", params, "scoped")
+
+        assert refused["error"]["code"] == FORBIDDEN
+        assert allowed["result"]["symbols"] == []
+
+    def test_answers_need_a_token_for_all_repos(self):
+        answerer = MagicMock(return_value="It sums the invoices.")
+        server = GraphServer()
+        create_editor_routes(server, MagicMock(), answerer, registry=REGISTRY)
+        params = {
+            "file": "/src/shop/cart.py",
+            "startLine": 0,
+            "endLine": 3,
+            "workspaceFolders": ["/src/shop"],
+            "question": "How does billing.invoice.total use this?",
+        }
+
+        refused = _rpc(server, "graph/askAboutSelection", params, "scoped")
+
+        assert refused["error"]["code"] == FORBIDDEN
+        answerer.assert_not_called()
@@ -0,0 +1,114 @@
+"""Tests for building monorepo shards apart and merging them into one graph."""
+
+import json
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.services.dry_run import DryRunIngestor
+from codebase_rag.shards import merge_shards, write_shard
+
+
+class FakeRun:
+    """Stands in for ingesting one shard of the shop monorepo."""
+
+    def __init__(self, updater, shard: str, callee: str):
+        self.updater = updater
+        self.shard = shard
+        self.callee = callee
+
+    def __call__(self):
+        ingestor = self.updater.ingestor
+        module_qn = f"shop.{self.shard}.api"
+        ingestor.ensure_node_batch("Project", {"name": "shop"})
+        ingestor.ensure_node_batch(
+            "Module", {"qualified_name": module_qn, "path": f"{self.shard}/api.py"}
+        )
+        ingestor.ensure_node_batch(
+            "Function", {"qualified_name": f"{module_qn}.handle", "name": "handle"}
+        )
+        ingestor.ensure_relationship_batch(
+            ("Module", "qualified_name", module_qn),
+            "IMPORTS",
+            ("Module", "qualified_name", "shop.common.money"),
+        )
+        self.updater.unresolved_calls.append(
+            (module_qn, "Function", f"{module_qn}.handle", self.callee)
+        )
+
+
+def build(tmp_path: Path, shard: str, callee: str) -> Path:
+    repo = tmp_path / "shop"
+    (repo / shard).mkdir(parents=True, exist_ok=True)
+    updater = GraphUpdater(DryRunIngestor(), repo, {}, {}, folder_filter=shard)
+    updater.run = FakeRun(updater, shard, callee)
+    output = tmp_path / f"{shard}.shard.jsonl"
+    write_shard(updater, shard, output)
+    return output
+
+
+def merge_updater(tmp_path: Path) -> tuple[MagicMock, GraphUpdater]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.return_value = [
+        {"qualified_name": "shop.billing.api.handle", "label": "Function"},
+        {"qualified_name": "shop.common.money.round_price", "label": "Function"},
+    ]
+    return ingestor, GraphUpdater(ingestor, tmp_path / "shop", {}, {})
+
+
+class TestShards:
+    """Test shard files and the merge pass stitching them together."""
+
+    def test_shard_file(self, tmp_path):
+        path = build(tmp_path, "billing", "round_price")
+
+        header, *records = (json.loads(line) for line in path.read_text().splitlines())
+
+        assert (header["shard"], header["project"]) == ("billing", "shop")
+        nodes = {r["node"]: r["properties"] for r in records if "node" in r}
+        assert "shard" not in nodes["Project"]
+        assert nodes["Function"]["shard"] == "billing"
+        assert records[-1] == {
+            "call": [
+                "shop.billing.api",
+                "Function",
+                "shop.billing.api.handle",
+                "round_price",
+            ]
+        }
+
+    def test_merge_loads_nodes_first_and_links_calls(self, tmp_path):
+        shards = [
+            build(tmp_path, "billing", "round_price"),
+            build(tmp_path, "catalog", "missing"),
+        ]
+        ingestor, updater = merge_updater(tmp_path)
+
+        result = merge_shards(ingestor, updater, shards)
+
+        assert result.shards == ["billing", "catalog"]
+        assert (result.calls_linked, result.calls_unresolved) == (1, 1)
+        deletes = [c.args[1]["shard"] for c in ingestor.execute_write.call_args_list]
+        assert deletes == ["billing", "catalog"]
+        names = [c[0] for c in ingestor.mock_calls]
+        last_node = max(i for i, n in enumerate(names) if n == "ensure_node_batch")
+        first_edge = names.index("ensure_relationship_batch")
+        assert last_node < names.index("flush_all") < first_edge
+        assert ingestor.ensure_relationship_batch.call_args_list[-1].args == (
+            ("Function", "qualified_name", "shop.billing.api.handle"),
+            "CALLS",
+            ("Function", "qualified_name", "shop.common.money.round_price"),
+        )
+
+    def test_merge_refuses_other_projects_and_repeats(self, tmp_path):
+        path = build(tmp_path, "billing", "round_price")
+        ingestor, _ = merge_updater(tmp_path)
+
+        other_project = GraphUpdater(ingestor, tmp_path / "warehouse", {}, {})
+        with pytest.raises(ValueError, match="built for project shop"):
+            merge_shards(ingestor, other_project, [path])
+        with pytest.raises(ValueError, match="more than once: billing"):
+            merge_shards(ingestor, merge_updater(tmp_path)[1], [path, path])
+        ingestor.execute_write.assert_not_called()
@@ -0,0 +1,181 @@
+"""Tests for size metrics and code-smell detection."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.code_metrics import (
+    calculate_max_nesting_depth,
+    count_class_fields,
+    count_lines_of_code,
+    count_parameters,
+)
+from codebase_rag.analysis.smells import (
+    DEEP_NESTING,
+    GOD_CLASS,
+    LONG_FUNCTION,
+    LONG_PARAMETER_LIST,
+    SmellAnalyzer,
+    SmellThresholds,
+    detect_class_smells,
+    detect_function_smells,
+)
+from codebase_rag.parser_loader import load_parsers
+
+
+@pytest.fixture(scope="module")
+def parsers():
+    parsers, queries = load_parsers()
+    return parsers, queries
+
+
+def _first(parsers, source: str, language: str, capture: str):
+    parser_map, queries = parsers
+    tree = parser_map[language].parse(source.encode("utf-8"))
+    query = queries[language]["classes" if capture == "class" else "functions"]
+    return query.captures(tree.root_node)[capture][0]
+
+
+class TestCodeMetrics:
+    """Test parameter counts, nesting depth and field counts from the AST."""
+
+    def test_python_function(self, parsers):
+        source = (
+            "def pay(self, amount, *, currency='EUR', **extra):\n"
+            "    for item in amount:\n"
+            "        if item:\n"
+            "            while True:\n"
+            "                pass\n"
+            "        elif currency:\n"
+            "            pass\n"
+        )
+        func = _first(parsers, source, "python", "function")
+        assert count_parameters(func) == 3
+        assert calculate_max_nesting_depth(func, "python") == 3
+
+    def test_go_else_if_chain_is_one_level(self, parsers):
+        source = (
+            "package p\n"
+            "func Route(a, b int, name string) {\n"
+            "    if a > 0 {\n"
+            "    } else if b > 0 {\n"
+            "    } else if name != \"\" {\n"
+            "        for {}\n"
+            "    }\n"
+            "}\n"
+        )
+        func = _first(parsers, source, "go", "function")
+        assert count_parameters(func) == 3
+        assert calculate_max_nesting_depth(func, "go") == 2
+
+    def test_lines_of_code(self, parsers):
+        source = (
+            "def pay(amount):\n"
+            "    # Charge the card\n"
+            "\n"
+            '    note = """\n'
+            "    paid\n"
+            '    """\n'
+            "    return amount  # done\n"
+        )
+        func = _first(parsers, source, "python", "function")
+        # Blank and comment-only lines do not count; a string's lines do
+        assert count_lines_of_code(func) == 5
+
+    def test_nested_functions_are_skipped(self, parsers):
+        source = (
+            "def outer():\n"
+            "    def inner():\n"
+            "        if True:\n"
+            "            if True:\n"
+            "                pass\n"
+            "    return inner\n"
+        )
+        func = _first(parsers, source, "python", "function")
+        assert calculate_max_nesting_depth(func, "python") == 0
+
+    def test_python_class_fields(self, parsers):
+        source = (
+            "class Cart:\n"
+            "    currency = 'EUR'\n"
+            "    limit: int = 10\n"
+            "    def __init__(self):\n"
+            "        self.items = []\n"
+            "        self.total = 0\n"
+            "    def add(self, item):\n"
+            "        self.items.append(item)\n"
+            "        self.total += 1\n"
+        )
+        cls = _first(parsers, source, "python", "class")
+        assert count_class_fields(cls, "python") == 4
+
+    def test_java_class_fields(self, parsers):
+        source = (
+            "class Cart {\n"
+            "    private int x, y;\n"
+            "    private String name;\n"
+            "    void add() {}\n"
+            "}\n"
+        )
+        cls = _first(parsers, source, "java", "class")
+        assert count_class_fields(cls, "java") == 3
+
+
+class TestSmellDetection:
+    """Test threshold checks over graph rows."""
+
+    def test_function_smells(self):
+        rows = [
+            {"qualified_name": "p.big", "label": "Function", "path": "p.py", "start_line": 1, "end_line": 100, "parameter_count": 7, "max_nesting_depth": 5},
+            {"qualified_name": "p.C.ok", "label": "Method", "path": "p.py", "start_line": 1, "end_line": 10, "parameter_count": 2, "max_nesting_depth": 1},
+            {"qualified_name": "p.legacy", "label": "Function", "path": "p.py", "start_line": None, "end_line": None, "parameter_count": None, "max_nesting_depth": None},
+        ]
+        smells = detect_function_smells(rows, SmellThresholds())
+        assert [(s.qualified_name, s.smell, s.value) for s in smells] == [
+            ("p.big", LONG_FUNCTION, 100),
+            ("p.big", LONG_PARAMETER_LIST, 7),
+            ("p.big", DEEP_NESTING, 5),
+        ]
+
+    def test_class_smells(self):
+        rows = [
+            {"qualified_name": "p.God", "path": "p.py", "start_line": 1, "method_count": 30, "field_count": 16},
+            {"qualified_name": "p.Small", "path": "p.py", "start_line": 40, "method_count": 3, "field_count": None},
+        ]
+        smells = detect_class_smells(rows, SmellThresholds())
+        assert [(s.smell, s.metric) for s in smells] == [
+            (GOD_CLASS, "method_count"),
+            (GOD_CLASS, "field_count"),
+        ]
+
+    def test_custom_thresholds(self):
+        rows = [{"qualified_name": "p.f", "label": "Function", "path": "p.py", "start_line": 1, "end_line": 3, "parameter_count": 2, "max_nesting_depth": 0}]
+        smells = detect_function_smells(rows, SmellThresholds(max_parameters=1))
+        assert [s.smell for s in smells] == [LONG_PARAMETER_LIST]
+
+
+class TestSmellAnalyzer:
+    """Test ranking and tagging through the ingestor."""
+
+    def test_detect_and_store(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [{"qualified_name": "p.God", "path": "p.py", "start_line": 1, "method_count": 21, "field_count": 0}],
+            [{"qualified_name": "p.f", "label": "Function", "path": "p.py", "start_line": 1, "end_line": 5, "parameter_count": 15, "max_nesting_depth": 9}],
+        ]
+        analyzer = SmellAnalyzer(ingestor)
+        smells = analyzer.detect()
+
+        # Ranked by how far each value exceeds its threshold
+        assert [s.smell for s in smells] == [LONG_PARAMETER_LIST, DEEP_NESTING, GOD_CLASS]
+
+        analyzer.store_smells(smells)
+        ingestor.ensure_node_batch.assert_any_call(
+            "Function",
+            {"qualified_name": "p.f", "smells": [DEEP_NESTING, LONG_PARAMETER_LIST]},
+        )
+        ingestor.ensure_node_batch.assert_any_call(
+            "Class", {"qualified_name": "p.God", "smells": [GOD_CLASS]}
+        )
+        ingestor.execute_write.assert_called_once()
+        ingestor.flush_all.assert_called_once()
@@ -0,0 +1,138 @@
+"""Tests for saving the graph to a snapshot archive and restoring it."""
+
+import tarfile
+from datetime import UTC, datetime
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag import snapshots
+from codebase_rag.semantic_search import INDEX_QUERY
+from codebase_rag.services.graph_backends import MemgraphBackend
+from codebase_rag.snapshots import (
+    COUNT_QUERY,
+    NODES_PAGE_QUERY,
+    PROJECTS_QUERY,
+    RELATIONSHIPS_PAGE_QUERY,
+    read_manifest,
+    restore_snapshot,
+    save_snapshot,
+)
+
+NODES = [
+    {"id": 0, "labels": ["Project"], "properties": {"name": "shop"}},
+    {
+        "id": 4,
+        "labels": ["Function"],
+        "properties": {"qualified_name": "shop.cart.total", "embedding": [0.5, 1.0]},
+    },
+    {
+        "id": 9,
+        "labels": ["Function"],
+        "properties": {"qualified_name": "shop.cart.checkout"},
+    },
+    {
+        "id": 12,
+        "labels": ["EmbeddingIndex"],
+        "properties": {"name": "code", "model": "hashing-512"},
+    },
+]
+
+RELATIONSHIPS = [
+    {"id": 3, "from_id": 9, "to_id": 4, "type": "CALLS", "properties": {}},
+    {"id": 7, "from_id": 0, "to_id": 9, "type": "CONTAINS", "properties": {"x": 1}},
+]
+
+
+def source_graph(query, params=None):
+    if query == INDEX_QUERY:
+        updated_at = datetime(2026, 10, 1, tzinfo=UTC)
+        return [
+            {"provider": "hashing", "model": "hashing-512", "updated_at": updated_at}
+        ]
+    if query == PROJECTS_QUERY:
+        return [{"name": "shop"}]
+    rows = {NODES_PAGE_QUERY: NODES, RELATIONSHIPS_PAGE_QUERY: RELATIONSHIPS}[query]
+    after = [row for row in rows if row["id"] > params["after"]]
+    return [dict(row) for row in after[: params["limit"]]]
+
+
+def save(tmp_path: Path) -> Path:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = source_graph
+    save_snapshot(ingestor, tmp_path / "out" / "shop.tar.gz")
+    return tmp_path / "out" / "shop.tar.gz"
+
+
+def target_graph(count: int) -> MagicMock:
+    ingestor = MagicMock()
+    ingestor.backend = MemgraphBackend("localhost", 7687)
+    ingestor.batch_size = 2
+    ingestor.fetch_all.side_effect = lambda query, params=None: (
+        [{"count": count}] if query == COUNT_QUERY else []
+    )
+    return ingestor
+
+
+class TestSnapshots:
+    """Test the round trip of a graph through an archive."""
+
+    def test_save_pages_through_the_graph(self, tmp_path, monkeypatch):
+        monkeypatch.setattr(snapshots, "PAGE_SIZE", 2)
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = source_graph
+
+        result = save_snapshot(ingestor, tmp_path / "shop.tar.gz")
+
+        assert (result.nodes, result.relationships) == (4, 2)
+        assert result.embedding_model == "hashing-512"
+        pages = [
+            c.args[1]["after"]
+            for c in ingestor.fetch_all.call_args_list
+            if c.args[0] == NODES_PAGE_QUERY
+        ]
+        assert pages == [-1, 4, 12]
+        manifest = read_manifest(tmp_path / "shop.tar.gz")
+        assert manifest["projects"] == ["shop"]
+        assert manifest["embedding_index"]["updated_at"].startswith("2026-10-01")
+
+    def test_restore_recreates_nodes_and_relationships(self, tmp_path):
+        archive = save(tmp_path)
+        ingestor = target_graph(0)
+
+        result = restore_snapshot(ingestor, archive)
+
+        assert (result.nodes, result.relationships) == (4, 2)
+        writes = [c.args for c in ingestor.execute_write.call_args_list]
+        functions = [w for w in writes if ":`Function`" in w[0]]
+        assert len(functions) == 1
+        assert [row["id"] for row in functions[0][1]["rows"]] == [4, 9]
+        calls = next(w for w in writes if "[r:`CALLS`]" in w[0])
+        assert calls[1]["rows"] == [
+            {"from_id": 9, "to_id": 4, "type": "CALLS", "properties": {}}
+        ]
+        # The restore label and its index are gone once relationships are in
+        assert writes[-2][0].startswith("MATCH (n:SnapshotRestore) REMOVE")
+        assert writes[-1][0] == "DROP INDEX ON :SnapshotRestore(snapshot_id)"
+        ingestor.clean_database.assert_not_called()
+        ingestor.mark_graph_changed.assert_called_once()
+
+    def test_restore_into_a_graph_that_is_not_empty(self, tmp_path):
+        archive = save(tmp_path)
+        ingestor = target_graph(3)
+
+        with pytest.raises(ValueError):
+            restore_snapshot(ingestor, archive)
+        ingestor.execute_write.assert_not_called()
+
+        restore_snapshot(ingestor, archive, replace=True)
+        ingestor.clean_database.assert_called_once()
+
+    def test_restore_refuses_other_archives(self, tmp_path):
+        other = tmp_path / "other.tar.gz"
+        with tarfile.open(other, "w:gz") as archive:
+            archive.add(save(tmp_path), arcname="nested.tar.gz")
+
+        with pytest.raises(ValueError):
+            restore_snapshot(target_graph(0), other)
@@ -40,6 +40,9 @@ class MockIngestor:
     def flush_all(self):
         pass
 
+    def mark_graph_changed(self):
+        pass
+
 
 class TestSprint3Integration:
     """Test the complete Sprint 3 implementation."""
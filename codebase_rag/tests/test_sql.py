@@ -0,0 +1,317 @@
+"""Tests for SQL migration parsing and linking code to the tables it queries."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.sql_detector import SqlQueryDetector
+from codebase_rag.parsers.sql_parser import (
+    SqlSchema,
+    is_migration,
+    is_query,
+    migration_order,
+    normalize_name,
+    query_tables,
+    split_statements,
+)
+
+CREATE_MIGRATION = """-- +goose Up
+CREATE TABLE IF NOT EXISTS public.users (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE, -- login; unique
+    name text,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "Orders" (
+    id bigint,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    total numeric(10, 2) DEFAULT 0,
+    CONSTRAINT orders_pk PRIMARY KEY (id)
+);
+
+/* Keeps updated_at current; its body has semicolons */
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- +goose Down
+DROP TABLE "Orders";
+DROP TABLE users;
+"""
+
+ALTER_MIGRATION = """ALTER TABLE users ADD COLUMN phone text, DROP COLUMN name;
+ALTER TABLE users RENAME COLUMN email TO email_address;
+ALTER TABLE "Orders" ALTER COLUMN total TYPE numeric(12, 2);
+ALTER TABLE "Orders" RENAME TO purchases;
+CREATE VIEW big_spenders AS
+    SELECT u.id FROM users u JOIN purchases p ON p.user_id = u.id;
+INSERT INTO purchases (id, user_id) SELECT 1, id FROM users;
+"""
+
+
+def _schema() -> SqlSchema:
+    schema = SqlSchema()
+    for path, content in (
+        ("db/migrations/1_create.sql", CREATE_MIGRATION),
+        ("db/migrations/2_alter.sql", ALTER_MIGRATION),
+    ):
+        for statement in split_statements(content):
+            schema.apply(statement, path)
+    return schema
+
+
+class TestSqlParser:
+    """Test applying migrations to a schema and reading the tables of queries."""
+
+    def test_statements(self):
+        statements = split_statements(CREATE_MIGRATION)
+
+        # Without the down section, and with the function in one piece
+        assert [(s.text.split()[2], s.line_number) for s in statements] == [
+            ("IF", 2),
+            ('"Orders"', 9),
+            ("touch()", 17),
+        ]
+        assert "-- login" not in statements[0].text
+
+    def test_dbmate_sections(self):
+        statements = split_statements(
+            "-- migrate:up\nCREATE TABLE a (id int);\n"
+            "-- migrate:down\nDROP TABLE a;\n"
+        )
+
+        assert [s.text for s in statements] == ["CREATE TABLE a (id int)"]
+
+    def test_tables(self):
+        schema = _schema()
+
+        assert list(schema.tables) == ["users", "purchases", "big_spenders"]
+        users = schema.tables["users"]
+        assert (users.path, users.line_number) == ("db/migrations/1_create.sql", 2)
+        assert users.alterations == [
+            ("db/migrations/2_alter.sql", 1),
+            ("db/migrations/2_alter.sql", 2),
+        ]
+        assert schema.tables["big_spenders"].kind == "view"
+        assert schema.tables["big_spenders"].reads == ["users", "purchases"]
+
+    def test_columns(self):
+        schema = _schema()
+
+        users = schema.tables["users"]
+        assert [
+            (c.name, c.data_type, c.nullable, c.line_number)
+            for c in users.columns.values()
+        ] == [
+            ("id", "BIGSERIAL", False, 3),
+            ("email_address", "VARCHAR(255)", False, 4),
+            ("created_at", "TIMESTAMPTZ", False, 6),
+            ("phone", "text", True, 1),
+        ]
+        assert users.primary_key == ["id"]
+        assert users.columns["created_at"].default == "now()"
+        purchases = schema.tables["purchases"]
+        assert purchases.primary_key == ["id"]
+        assert purchases.columns["total"].data_type == "numeric(12, 2)"
+        (key,) = purchases.foreign_keys
+        assert (key.columns, key.table, key.referenced_columns) == (
+            ["user_id"],
+            "users",
+            ["id"],
+        )
+
+    def test_rename_updates_foreign_keys(self):
+        schema = SqlSchema()
+        for statement in split_statements(
+            "CREATE TABLE a (id int PRIMARY KEY);\n"
+            "CREATE TABLE b (a_id int, FOREIGN KEY (a_id) REFERENCES a);\n"
+            "RENAME TABLE a TO accounts;\n"
+            "ALTER TABLE b DROP COLUMN IF EXISTS missing;\n"
+        ):
+            schema.apply(statement, "schema.sql")
+
+        (key,) = schema.tables["b"].foreign_keys
+        assert (key.table, key.referenced_columns) == ("accounts", [])
+
+    def test_query_tables(self):
+        assert query_tables(
+            "SELECT o.id FROM orders o, customers AS c WHERE o.c = c.id"
+        ) == (["orders", "customers"], [])
+        assert query_tables(
+            "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent "
+            "JOIN users USING (id)"
+        ) == (["orders", "users"], [])
+        assert query_tables("DELETE FROM sessions WHERE expires < now()") == (
+            [],
+            ["sessions"],
+        )
+        assert query_tables(
+            "UPDATE users u SET note = 'FROM x' FROM teams t WHERE t.id = u.team_id"
+        ) == (["teams"], ["users"])
+        assert query_tables(
+            "SELECT EXTRACT(YEAR FROM created_at) FROM public.orders"
+        ) == (["orders"], [])
+
+    def test_is_query(self):
+        assert is_query("select id from users")
+        assert is_query("INSERT INTO audit (x) VALUES ($1)")
+        assert is_query("WITH t AS (SELECT 1) SELECT * FROM t")
+        assert not is_query("Select a file from the list")
+        assert not is_query("CREATE TABLE a (id int)")
+
+    def test_migration_files(self):
+        assert is_migration("db/migrations/20240101_init.up.sql")
+        assert not is_migration("db/migrations/20240101_init.down.sql")
+        assert not is_migration("db/migration/U2__drop_orders.sql")
+        assert sorted(["m/10_b.sql", "m/9_a.sql"], key=migration_order) == [
+            "m/9_a.sql",
+            "m/10_b.sql",
+        ]
+        assert normalize_name('"Public"."Users"') == "Users"
+        assert normalize_name("Billing.Invoices") == "billing.invoices"
+
+
+class TestSqlQueryDetector:
+    """Test finding SQL queries in the string literals of code."""
+
+    def test_go(self):
+        content = """package repo
+
+func (r *Repo) Get(id int) {
+	row := r.db.QueryRow(`SELECT id, email
+		FROM users WHERE id = $1`, id)
+	_, err := r.db.Exec("UPDATE users SET seen = now() " +
+		"WHERE id = $1", id)
+	log.Print("Select a file from the list")
+}
+"""
+        queries = SqlQueryDetector().detect(content)
+
+        assert [(q.line_number, q.reads, q.writes) for q in queries] == [
+            (4, ["users"], []),
+            (6, [], ["users"]),
+        ]
+
+    def test_adjacent_python_literals(self):
+        content = (
+            "cur.execute(\n"
+            '    "SELECT o.id FROM orders o "\n'
+            '    "JOIN customers c ON c.id = o.customer_id"\n'
+            ")\n"
+        )
+
+        (query,) = SqlQueryDetector().detect(content)
+
+        assert (query.line_number, query.reads) == (2, ["orders", "customers"])
+
+
+class TestSqlIngestion:
+    """Test the Table and Column nodes and the code linked to them."""
+
+    REPO_PY = '''def find_user(cur, user_id):
+    cur.execute("SELECT * FROM users WHERE id = %s", (user_id,))
+
+
+def archive(cur):
+    cur.execute("""
+        INSERT INTO purchases_archive SELECT * FROM purchases
+    """)
+'''
+
+    def _ingest(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        migrations = temp_repo / "db" / "migrations"
+        migrations.mkdir(parents=True)
+        (migrations / "2_alter.sql").write_text(ALTER_MIGRATION)
+        (migrations / "1_create.sql").write_text(CREATE_MIGRATION)
+        (migrations / "1_create.down.sql").write_text("DROP TABLE users;\n")
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        for name in ("2_alter.sql", "1_create.sql", "1_create.down.sql"):
+            updater._parse_sql_file(migrations / name)
+        updater._ingest_sql_schema()
+        return updater
+
+    def _edges(self, mock_ingestor: MagicMock, rel_type: str) -> set[tuple]:
+        return {
+            (call.args[0][2], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == rel_type
+        }
+
+    def test_tables(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Table",
+            {
+                "qualified_name": "users",
+                "name": "users",
+                "schema": "",
+                "kind": "table",
+                "path": "db/migrations/1_create.sql",
+                "line_number": 2,
+                "primary_key": ["id"],
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Column",
+            {
+                "qualified_name": "users.phone",
+                "name": "phone",
+                "data_type": "text",
+                "nullable": True,
+                "primary_key": False,
+                "default": "",
+                "path": "db/migrations/2_alter.sql",
+                "line_number": 1,
+            },
+        )
+        assert self._edges(mock_ingestor, "DEFINES") == {
+            ("db/migrations/1_create.sql", "users"),
+            ("db/migrations/1_create.sql", "purchases"),
+            ("db/migrations/2_alter.sql", "big_spenders"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("File", "path", "db/migrations/2_alter.sql"),
+            "ALTERS",
+            ("Table", "qualified_name", "purchases"),
+            {"line_number": 4},
+        )
+
+    def test_foreign_keys(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        assert self._edges(mock_ingestor, "REFERENCES") == {
+            ("purchases", "users"),
+            ("purchases.user_id", "users.id"),
+        }
+
+    def test_table_uses(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = self._ingest(temp_repo, mock_ingestor)
+        updater.function_spans["shop.repo"] += [
+            (1, 2, "Function", "shop.repo.find_user"),
+            (5, 8, "Function", "shop.repo.archive"),
+        ]
+
+        updater._ingest_sql_queries(self.REPO_PY, "shop.repo")
+
+        assert self._edges(mock_ingestor, "READS_FROM") == {
+            ("big_spenders", "users"),
+            ("big_spenders", "purchases"),
+            ("db/migrations/2_alter.sql", "users"),
+            ("shop.repo.find_user", "users"),
+            ("shop.repo.archive", "purchases"),
+        }
+        assert self._edges(mock_ingestor, "WRITES_TO") == {
+            ("db/migrations/2_alter.sql", "purchases"),
+            ("shop.repo.archive", "purchases_archive"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Function", "qualified_name", "shop.repo.find_user"),
+            "READS_FROM",
+            ("Table", "qualified_name", "users"),
+            {"line_number": 2},
+        )
@@ -0,0 +1,151 @@
+"""Tests for parsing stack traces and resolving their frames to graph nodes."""
+
+from datetime import datetime
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.stack_traces import (
+    GO,
+    JVM,
+    PYTHON,
+    StackTraceResolver,
+    parse_stack_trace,
+)
+from codebase_rag.version_control.git_analyzer import BlameInfo
+
+PYTHON_TRACE = """Traceback (most recent call last):
+  File "/srv/app/shop/api.py", line 4, in get_cart
+    return cart.total()
+  File "/srv/app/shop/cart.py", line 3, in total
+    return sum(self.items) / len(self.items)
+ZeroDivisionError: division by zero
+"""
+
+GO_TRACE = """panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x48f1d4]
+
+goroutine 1 [running]:
+github.com/acme/shop/cart.(*Cart).Total(0x0)
+\t/build/shop/cart/cart.go:12 +0x14
+main.main()
+\t/build/shop/main.go:9 +0x25
+
+goroutine 6 [chan receive]:
+main.worker()
+\t/build/shop/main.go:20 +0x31
+exit status 2
+"""
+
+JVM_TRACE = """Exception in thread "main" java.lang.IllegalStateException: empty cart
+\tat com.acme.shop.Cart.total(Cart.java:42)
+\tat com.acme.shop.Api$Handler.get(Api.java:10)
+\tat java.base/java.lang.Thread.run(Thread.java:833)
+Caused by: java.lang.ArithmeticException: / by zero
+\tat com.acme.shop.Cart.average(Cart.java:50)
+\t... 3 more
+"""
+
+FUNCTIONS = [
+    {
+        "qualified_name": "shop.shop.cart.Cart.total",
+        "name": "total",
+        "label": "Method",
+        "path": "shop/cart.py",
+        "start_line": 2,
+        "end_line": 3,
+    },
+    {
+        "qualified_name": "shop.shop.api.get_cart",
+        "name": "get_cart",
+        "label": "Function",
+        "path": "shop/api.py",
+        "start_line": 1,
+        "end_line": 4,
+    },
+]
+
+
+class TestParseStackTrace:
+    """Test frame extraction, outermost call first."""
+
+    def test_python(self):
+        kind, message, frames = parse_stack_trace(PYTHON_TRACE)
+        assert (kind, message) == (PYTHON, "ZeroDivisionError: division by zero")
+        assert [(f.function, f.line) for f in frames] == [("get_cart", 4), ("total", 3)]
+
+    def test_go_panicking_goroutine_only(self):
+        kind, message, frames = parse_stack_trace(GO_TRACE)
+        assert kind == GO
+        assert message.startswith("panic: runtime error")
+        assert [(f.function, f.file, f.line) for f in frames] == [
+            ("main.main", "/build/shop/main.go", 9),
+            ("github.com/acme/shop/cart.(*Cart).Total", "/build/shop/cart/cart.go", 12),
+        ]
+
+    def test_jvm_outer_exception(self):
+        kind, message, frames = parse_stack_trace(JVM_TRACE)
+        assert kind == JVM
+        assert message.endswith("IllegalStateException: empty cart")
+        assert [(f.function, f.file, f.line) for f in frames] == [
+            ("java.lang.Thread.run", "java/lang/Thread.java", 833),
+            ("com.acme.shop.Api.get", "com/acme/shop/Api.java", 10),
+            ("com.acme.shop.Cart.total", "com/acme/shop/Cart.java", 42),
+        ]
+
+    def test_rejects_other_text(self):
+        with pytest.raises(ValueError):
+            parse_stack_trace("just a log line")
+
+
+class TestStackTraceResolver:
+    """Test resolving frames with snippets and authors."""
+
+    def test_resolves_frames(self, temp_repo: Path):
+        (temp_repo / "shop").mkdir()
+        (temp_repo / "shop" / "cart.py").write_text(
+            "class Cart:\n    def total(self):\n        return sum(self.items)\n"
+        )
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = FUNCTIONS
+        git_analyzer = MagicMock()
+        git_analyzer.get_blame_info.return_value = [
+            BlameInfo(2, "a" * 40, "Ann", "", datetime(2024, 1, 5), "", 2),
+            BlameInfo(3, "b" * 40, "Bob", "", datetime(2024, 3, 1), "", 3),
+            BlameInfo(1, "c" * 40, "Cy", "", datetime(2024, 6, 1), "", 1),
+        ]
+
+        trace = StackTraceResolver(
+            ingestor, temp_repo, git_analyzer, context_lines=1
+        ).resolve(PYTHON_TRACE)
+
+        api, cart = trace.frames
+        assert api.qualified_name == "shop.shop.api.get_cart"
+        assert api.snippet == []  # Not checked out
+        assert (cart.label, cart.path) == ("Method", "shop/cart.py")
+        assert cart.snippet == [
+            (2, "    def total(self):"),
+            (3, "        return sum(self.items)"),
+        ]
+        # Cy only touched the class line, outside the method
+        assert [a["author"] for a in cart.authors] == ["Bob", "Ann"]
+        assert cart.authors[0]["commit"] == "bbbbbbbb"
+        git_analyzer.get_blame_info.assert_any_call(str(temp_repo / "shop/cart.py"))
+
+    def test_name_fallback_requires_matching_file(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = FUNCTIONS
+        resolver = StackTraceResolver(ingestor, Path("/nonexistent"))
+
+        trace = resolver.resolve(
+            'Traceback (most recent call last):\n'
+            '  File "/srv/app/shop/cart.py", line 99, in total\n'
+            '  File "/srv/app/other.py", line 1, in get_cart\n'
+            "KeyError: 'x'\n"
+        )
+
+        assert [f.qualified_name for f in trace.frames] == [
+            "shop.shop.cart.Cart.total",
+            "",
+        ]
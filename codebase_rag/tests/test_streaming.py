@@ -0,0 +1,110 @@
+"""Tests for streaming the agent's tool calls and answer."""
+
+from types import SimpleNamespace
+
+from codebase_rag.streaming import (
+    MAX_SHOWN_CHARS,
+    describe_call,
+    handle_event,
+    summarize_result,
+    tool_arguments,
+)
+
+
+class RecordingPrinter:
+    def __init__(self):
+        self.calls: list[tuple] = []
+
+    def text_start(self, text):
+        self.calls.append(("start", text))
+
+    def text_delta(self, delta):
+        self.calls.append(("delta", delta))
+
+    def tool_call(self, name, args):
+        self.calls.append(("call", name, args))
+
+    def tool_result(self, name, content):
+        self.calls.append(("result", name, content))
+
+    def done(self):
+        self.calls.append(("done",))
+
+
+def _event(kind, **fields):
+    return SimpleNamespace(event_kind=kind, **fields)
+
+
+class TestHandleEvent:
+    """Test passing agent run events on to the printer."""
+
+    def test_text_and_tool_calls(self):
+        printer = RecordingPrinter()
+        events = [
+            _event(
+                "function_tool_call",
+                part=SimpleNamespace(
+                    tool_name="read_file_content", args='{"file_path": "calc.go"}'
+                ),
+            ),
+            _event(
+                "function_tool_result",
+                result=SimpleNamespace(
+                    part_kind="tool-return", tool_name="read_file_content", content="x"
+                ),
+            ),
+            _event(
+                "function_tool_result",
+                result=SimpleNamespace(part_kind="retry-prompt"),
+            ),
+            _event("part_start", part=SimpleNamespace(part_kind="text", content="Div")),
+            _event(
+                "part_delta",
+                delta=SimpleNamespace(part_delta_kind="text", content_delta="ides."),
+            ),
+            _event("final_result"),
+        ]
+
+        for event in events:
+            handle_event(event, printer)
+
+        assert printer.calls == [
+            ("call", "read_file_content", {"file_path": "calc.go"}),
+            ("result", "read_file_content", "x"),
+            ("start", "Div"),
+            ("delta", "ides."),
+        ]
+
+
+class TestDescriptions:
+    """Test the one-line descriptions of tool calls and results."""
+
+    def test_tool_arguments(self):
+        assert tool_arguments({"limit": 5}) == {"limit": 5}
+        assert tool_arguments('{"question": "who calls Div?"}') == {
+            "question": "who calls Div?"
+        }
+        assert tool_arguments("not json") == {"args": "not json"}
+        assert tool_arguments(None) == {}
+
+    def test_describe_call(self):
+        line = describe_call("semantic_code_search", {"question": "x" * 200})
+
+        assert line.startswith("semantic_code_search(question='xxx")
+        assert line.endswith("...)")
+        assert len(line) == len("semantic_code_search(question=)") + MAX_SHOWN_CHARS
+
+    def test_summarize_result(self):
+        graph_data = {
+            "query_used": "MATCH (f:Function)\nRETURN f.name",
+            "results": [{"f.name": "Div"}, {"f.name": "Sqrt"}],
+            "summary": "2 rows",
+        }
+
+        assert summarize_result(graph_data) == (
+            "MATCH (f:Function) RETURN f.name -> 2 row(s)"
+        )
+        assert summarize_result([{"error": "No embeddings"}]) == "1 result(s)"
+        assert summarize_result({"error": "File not found"}) == "error: File not found"
+        assert summarize_result({"qualified_name": "calc.Div"}) == "calc.Div"
+        assert summarize_result("package calc\n\nfunc Div") == "package calc"
@@ -0,0 +1,100 @@
+"""Tests for fuzzy symbol search and entity linking in questions."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.symbol_search import (
+    SymbolIndex,
+    annotate_question,
+    link_entities,
+    normalize,
+    split_identifier,
+)
+
+SYMBOLS = [
+    ("shop.Calculator", "Class"),
+    ("shop.Calculator.divide", "Method"),
+    ("shop.calc.divide_all", "Function"),
+    ("shop.Cart.total", "Method"),
+    ("users.get_user_name", "Function"),
+    ("users.parse_http_request", "Function"),
+]
+
+
+def names(matches):
+    return [match.qualified_name for match in matches]
+
+
+class TestSymbolSearch:
+    """Test matching across case, naming conventions and typos."""
+
+    def setup_method(self):
+        self.index = SymbolIndex(SYMBOLS)
+
+    def test_identifier_words(self):
+        assert split_identifier("parseHTTPRequest") == ["parse", "http", "request"]
+        assert split_identifier("__init__") == ["init"]
+        assert normalize("GetUserName") == normalize("get_user_name")
+
+    def test_conventions_and_case(self):
+        assert names(self.index.search("getUserName")) == ["users.get_user_name"]
+        assert names(self.index.search("ParseHTTPRequest")) == [
+            "users.parse_http_request"
+        ]
+        assert self.index.search("CART.TOTAL")[0].score == 1.0
+
+    def test_typos(self):
+        matches = self.index.search("calcualtor.Divde")
+
+        assert names(matches) == ["shop.Calculator.divide"]
+        assert matches[0].label == "Method"
+
+    def test_ranking(self):
+        # Exact before prefix, averaged over the dotted parts
+        assert names(self.index.search("divide")) == [
+            "shop.Calculator.divide",
+            "shop.calc.divide_all",
+        ]
+        assert names(self.index.search("calc.divide")) == [
+            "shop.calc.divide_all",
+            "shop.Calculator.divide",
+        ]
+        assert names(self.index.search("divide", limit=1)) == [
+            "shop.Calculator.divide"
+        ]
+        assert self.index.search("inventory") == []
+        assert self.index.search(" . ") == []
+
+    def test_from_graph(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"qualified_name": "shop.Cart.total", "label": "Method"}
+        ]
+
+        assert names(SymbolIndex.from_graph(ingestor).search("total")) == [
+            "shop.Cart.total"
+        ]
+
+
+class TestEntityLinking:
+    """Test which mentions in a question are linked and how they are passed on."""
+
+    def test_links_identifiers_only(self):
+        index = SymbolIndex(SYMBOLS)
+        question = (
+            "Which functions call calcualtor.Divde, and is `total` tested? "
+            "Also where is getUserName used?"
+        )
+
+        links = link_entities(question, index)
+
+        assert {m: link.qualified_name for m, link in links.items()} == {
+            "calcualtor.Divde": "shop.Calculator.divide",
+            "total": "shop.Cart.total",
+            "getUserName": "users.get_user_name",
+        }
+        annotated = annotate_question(question, links)
+        assert annotated.startswith(question)
+        assert "- getUserName is Function users.get_user_name" in annotated
+
+    def test_no_links_leaves_question_unchanged(self):
+        assert annotate_question("What calls what?", {}) == "What calls what?"
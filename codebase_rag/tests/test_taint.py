@@ -0,0 +1,241 @@
+"""Tests for taint tracking from configured sources to sinks."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.taint import (
+    CallSite,
+    FunctionFlow,
+    Step,
+    TaintAnalyzer,
+    TaintEngine,
+    TaintRules,
+    TaintSource,
+    Value,
+)
+from codebase_rag.parser_loader import load_parsers
+
+HANDLER = "shop.web.handlers.GetUser"
+FIND = "shop.store.Store.Find"
+
+
+def _call(callee: str, line: int, *args: Value, receiver: Value | None = None):
+    return Value(line, calls=[CallSite(callee, line, list(args), receiver)])
+
+
+def _var(name: str) -> Value:
+    return Value(0, [name])
+
+
+def _handler(*steps: Step) -> FunctionFlow:
+    query = _call("r.URL.Query", 10)
+    return FunctionFlow(
+        HANDLER,
+        "Function",
+        "web/handlers.go",
+        ["w", "r"],
+        [
+            # id := r.URL.Query().Get("id")
+            Step(["id"], [_call("r.URL.Query().Get", 10, Value(), receiver=query)]),
+            *steps,
+        ],
+    )
+
+
+# func (s *Store) Find(id string) { q := "SELECT ..." + id; s.db.Query(q) }
+STORE_FIND = FunctionFlow(
+    FIND,
+    "Method",
+    "store/store.go",
+    ["id"],
+    [
+        Step(["q"], [_var("id")]),
+        Step([], [_call("s.db.Query", 20, _var("q"), receiver=_var("s.db"))]),
+    ],
+    receiver="s",
+)
+
+
+class TestTaintEngine:
+    """Test propagation through assignments, arguments and return values."""
+
+    def test_through_call_argument(self):
+        handler = _handler(Step([], [_call("h.store.Find", 11, _var("id"))]))
+
+        [path] = TaintEngine(TaintRules(), [handler, STORE_FIND], [(HANDLER, FIND)]).run()
+
+        assert path.source == TaintSource("user_input", "r.URL.Query", HANDLER, 10)
+        assert (path.sink_kind, path.sink_call, path.sink_line) == (
+            "sql",
+            "s.db.Query",
+            20,
+        )
+        assert path.functions == [HANDLER, FIND]
+        assert path.to_dict()["sink_location"] == "store/store.go:20"
+        assert path.vuln_type == "user_input_to_sql"
+
+    def test_sanitizer_stops_taint(self):
+        handler = _handler(
+            Step(["n", "err"], [_call("strconv.Atoi", 11, _var("id"))]),
+            Step([], [_call("h.store.Find", 12, _var("n"))]),
+        )
+
+        engine = TaintEngine(TaintRules(), [handler, STORE_FIND], [(HANDLER, FIND)])
+
+        assert engine.run() == []
+
+    def test_untainted_argument(self):
+        handler = _handler(Step([], [_call("h.store.Find", 11, Value(11))]))
+
+        engine = TaintEngine(TaintRules(), [handler, STORE_FIND], [(HANDLER, FIND)])
+
+        assert engine.run() == []
+
+    def test_through_return_value(self):
+        # func dsn() string { return os.Getenv("DSN") }
+        dsn = FunctionFlow(
+            "tool.config.dsn",
+            "Function",
+            "config/config.go",
+            [],
+            [Step([], [_call("os.Getenv", 3, Value(3))], returns=True)],
+        )
+        # exec.Command("psql", dsn()).Run()
+        main = FunctionFlow(
+            "tool.main.main",
+            "Function",
+            "main.go",
+            [],
+            [Step([], [_call("exec.Command", 8, Value(8), _call("dsn", 8))])],
+        )
+
+        [path] = TaintEngine(
+            TaintRules(), [dsn, main], [("tool.main.main", "tool.config.dsn")]
+        ).run()
+
+        assert path.vuln_type == "env_to_exec"
+        assert path.functions == ["tool.config.dsn", "tool.main.main"]
+
+    def test_member_reads_and_fields(self):
+        # self.name = request.args["name"]; cursor.execute(f"... {self.name}")
+        view = FunctionFlow(
+            "app.views.Search.get",
+            "Method",
+            "app/views.py",
+            [],
+            [
+                Step(["self.name"], [Value(4, reads=["request.args"])]),
+                Step([], [_call("cursor.execute", 5, _var("self.name"))]),
+            ],
+            receiver="self",
+        )
+
+        [path] = TaintEngine(TaintRules(), [view], []).run()
+
+        assert path.source.text == "request.args"
+        assert path.sink_line == 5
+
+    def test_configured_rules(self):
+        rules = TaintRules.from_config(
+            {
+                "taint": {
+                    "defaults": False,
+                    "sources": {"secret": ["vault.Read"]},
+                    "sinks": {"log": "log.Printf"},
+                }
+            }
+        )
+        flow = FunctionFlow(
+            "svc.main.run",
+            "Function",
+            "main.go",
+            [],
+            [
+                Step(["token"], [_call("vault.Read", 2)]),
+                Step([], [_call("log.Printf", 3, Value(3), _var("token"))]),
+                Step([], [_call("db.Exec", 4, _var("token"))]),
+            ],
+        )
+
+        [path] = TaintEngine(rules, [flow], []).run()
+
+        assert (path.source.kind, path.sink_kind) == ("secret", "log")
+        assert rules.sanitizers == []
+
+    @pytest.mark.parametrize(
+        "section",
+        [["os.Getenv"], {"sources": ["os.Getenv"]}, {"sinks": {"sql": [1]}}],
+    )
+    def test_invalid_config(self, section):
+        with pytest.raises(ValueError):
+            TaintRules.from_config({"taint": section})
+
+
+class TestTaintStorage:
+    """Test the Vulnerability nodes and FLOWS_TO edges written for paths."""
+
+    def test_store_paths(self):
+        handler = _handler(Step([], [_call("h.store.Find", 11, _var("id"))]))
+        paths = TaintEngine(
+            TaintRules(), [handler, STORE_FIND], [(HANDLER, FIND)]
+        ).run()
+        ingestor = MagicMock()
+        analyzer = TaintAnalyzer(ingestor, {}, TaintRules())
+        analyzer.labels = {HANDLER: "Function", FIND: "Method"}
+
+        analyzer.store_paths(paths)
+
+        vulnerability = ingestor.ensure_node_batch.call_args.args[1]
+        assert vulnerability["type"] == "user_input_to_sql"
+        assert vulnerability["cwe_id"] == "CWE-89"
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Module", "path", "store/store.go"),
+            "HAS_VULNERABILITY",
+            ("Vulnerability", "id", vulnerability["id"]),
+            {"file_path": "store/store.go"},
+        )
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Function", "qualified_name", HANDLER),
+            "FLOWS_TO",
+            ("Method", "qualified_name", FIND),
+            {
+                "taint_id": vulnerability["id"],
+                "source_kind": "user_input",
+                "sink_kind": "sql",
+            },
+        )
+        ingestor.flush_all.assert_called_once()
+
+
+GO_SOURCE = b"""package web
+
+func GetUser(w http.ResponseWriter, r *http.Request) {
+    id := r.URL.Query().Get("id")
+    query := fmt.Sprintf("SELECT * FROM users WHERE id = '%s'", id)
+    rows, err := db.Query(query)
+    page, _ := strconv.Atoi(r.FormValue("page"))
+    db.Exec("UPDATE visits SET page = ?", page)
+}
+"""
+
+
+class TestFlowExtraction:
+    """Test reading Go functions into flows with Tree-sitter."""
+
+    def test_go_handler(self):
+        parsers, _ = load_parsers()
+        locator = MagicMock()
+        locator.at_line.return_value = {
+            "qualified_name": "app.web.GetUser",
+            "name": "GetUser",
+            "label": "Function",
+        }
+        analyzer = TaintAnalyzer(MagicMock(), parsers, TaintRules())
+
+        [flow] = analyzer.extract_flows(GO_SOURCE, "web/user.go", "go", locator)
+        [path] = TaintEngine(TaintRules(), [flow], []).run()
+
+        assert flow.params == ["w", "r"]
+        assert path.source.text == "r.URL.Query"
+        assert (path.sink_call, path.sink_line) == ("db.Query", 6)
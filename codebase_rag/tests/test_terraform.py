@@ -0,0 +1,367 @@
+"""Tests for Terraform parsing and tracing its resources to the code using them."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.env_detector import EnvReadDetector
+from codebase_rag.parsers.hcl_parser import (
+    HclSyntaxError,
+    object_entries,
+    parse_hcl,
+    traversals,
+)
+from codebase_rag.parsers.terraform_parser import parse_terraform, provides_env
+
+MAIN_TF = """terraform {
+  required_version = ">= 1.5"
+}
+
+variable "env" {
+  description = "Deployment stage"
+  default     = "prod"
+}
+
+locals {
+  prefix = "${var.env}-shop"
+}
+
+resource "aws_sqs_queue" "orders" {
+  name = "${local.prefix}-orders"
+}
+
+resource "aws_s3_bucket" "uploads" {
+  bucket = "acme-uploads" # Globally unique
+}
+
+resource "aws_lambda_function" "worker" {
+  function_name = "${local.prefix}-worker"
+  environment {
+    variables = {
+      QUEUE_URL = aws_sqs_queue.orders.url
+      /* Where uploads go */
+      BUCKET = aws_s3_bucket.uploads[0].id
+      STAGE  = var.env
+    }
+  }
+}
+
+resource "aws_ecs_task_definition" "api" {
+  family = "api"
+  container_definitions = jsonencode([{
+    name        = "api"
+    environment = [{ name = "DB_HOST", value = module.db.address }]
+  }])
+}
+
+module "db" {
+  source = "./modules/db"
+  name   = local.prefix
+  count  = 1
+}
+
+output "queue_url" {
+  value = aws_sqs_queue.orders.url
+}
+"""
+
+DB_MODULE_TF = """variable "name" {}
+
+resource "aws_db_instance" "main" {
+  identifier = var.name
+}
+
+output "address" {
+  value = aws_db_instance.main.address
+}
+"""
+
+
+class TestHclParser:
+    """Test scanning HCL into blocks, attributes and expressions."""
+
+    def test_blocks_and_attributes(self):
+        body = parse_hcl(MAIN_TF)
+
+        variable = body.blocks[1]
+        assert (variable.type, variable.labels, variable.line_number) == (
+            "variable",
+            ["env"],
+            5,
+        )
+        assert variable.attributes["default"].literal == "prod"
+        bucket = body.blocks[4].attributes["bucket"]
+        assert (bucket.expression, bucket.line_number) == ('"acme-uploads"', 19)
+        worker = body.blocks[5]
+        assert [block.type for block in worker.walk()] == [
+            "resource",
+            "environment",
+        ]
+        assert worker.attributes["function_name"].literal is None
+
+    def test_traversals(self):
+        assert traversals('"${var.env}-${local.prefix}.svc"') == [
+            "var.env",
+            "local.prefix",
+        ]
+        assert traversals("aws_instance.web[count.index].id") == [
+            "aws_instance.web.id"
+        ]
+        assert traversals('concat(["a.b"], [aws_subnet.a.id])') == ["aws_subnet.a.id"]
+
+    def test_object_entries(self):
+        entries = object_entries('{\n  A = var.a\n  "B" = "b", C = {x = 1}\n}')
+
+        assert entries == [("A", "var.a", 1), ("B", '"b"', 2), ("C", "{x = 1}", 2)]
+        assert object_entries("var.settings") == []
+
+    def test_heredoc(self):
+        body = parse_hcl('policy = <<EOF\n{\n  "a": "${var.b}"\nEOF\nname = "x"\n')
+
+        assert body.attributes["policy"].traversals == ["var.b"]
+        assert body.attributes["name"].line_number == 5
+
+    def test_unclosed_block(self):
+        with pytest.raises(HclSyntaxError):
+            parse_hcl('resource "a_b" "c" {\n  name = "d"\n')
+
+
+class TestTerraformParser:
+    """Test reading the blocks of a Terraform module and what they refer to."""
+
+    def test_blocks(self):
+        blocks = parse_terraform(MAIN_TF)
+
+        assert [(b.kind, b.address, b.line_number) for b in blocks] == [
+            ("variable", "var.env", 5),
+            ("local", "local.prefix", 11),
+            ("resource", "aws_sqs_queue.orders", 14),
+            ("resource", "aws_s3_bucket.uploads", 18),
+            ("resource", "aws_lambda_function.worker", 22),
+            ("resource", "aws_ecs_task_definition.api", 34),
+            ("module", "module.db", 42),
+            ("output", "output.queue_url", 48),
+        ]
+        assert blocks[1].references == ["var.env"]
+        assert blocks[4].references == [
+            "local.prefix",
+            "aws_sqs_queue.orders",
+            "aws_s3_bucket.uploads",
+            "var.env",
+        ]
+        assert blocks[6].source == "./modules/db"
+
+    def test_environment(self):
+        blocks = parse_terraform(MAIN_TF)
+
+        worker, api = blocks[4], blocks[5]
+        assert [(e.name, e.line_number, e.references) for e in worker.env] == [
+            ("QUEUE_URL", 26, ["aws_sqs_queue.orders"]),
+            ("BUCKET", 28, ["aws_s3_bucket.uploads"]),
+            ("STAGE", 29, ["var.env"]),
+        ]
+        assert [(e.name, e.line_number, e.references) for e in api.env] == [
+            ("DB_HOST", 38, ["module.db"])
+        ]
+
+    def test_kubernetes_env(self):
+        (deployment,) = parse_terraform(
+            'resource "kubernetes_deployment" "api" {\n'
+            "  container {\n"
+            "    env {\n"
+            '      name  = "CACHE_HOST"\n'
+            "      value = google_redis_instance.cache.host\n"
+            "    }\n"
+            "  }\n"
+            "}\n"
+        )
+
+        assert [(e.name, e.line_number, e.references) for e in deployment.env] == [
+            ("CACHE_HOST", 4, ["google_redis_instance.cache"])
+        ]
+
+    def test_provides_env(self):
+        blocks = {block.address: block for block in parse_terraform(MAIN_TF)}
+        queue = blocks["aws_sqs_queue.orders"]
+        bucket = blocks["aws_s3_bucket.uploads"]
+
+        assert provides_env(queue, "ORDERS_QUEUE_URL")
+        assert provides_env(queue, "ORDERS_SQS_QUEUE_NAME")
+        # By the name the bucket is created with
+        assert provides_env(bucket, "ACME_UPLOADS_BUCKET")
+        # The kind of resource, or which one, is not named
+        assert not provides_env(queue, "ORDERS_URL")
+        assert not provides_env(queue, "QUEUE_URL")
+        assert not provides_env(queue, "PAYMENTS_QUEUE_URL")
+        assert not provides_env(blocks["var.env"], "ENV")
+
+
+class TestEnvReadDetector:
+    """Test finding the environment variables code reads."""
+
+    def test_languages(self):
+        detector = EnvReadDetector()
+
+        go = 'url := os.Getenv("QUEUE_URL")\nv, ok := os.LookupEnv("STAGE")\n'
+        assert [(r.name, r.line_number) for r in detector.detect(go, "go")] == [
+            ("QUEUE_URL", 1),
+            ("STAGE", 2),
+        ]
+        python = 'a = os.environ["A"]\nb = os.environ.get("B")\nc = os.getenv("C")\n'
+        assert [r.name for r in detector.detect(python, "python")] == ["A", "B", "C"]
+        js = "const a = process.env.A, b = process.env['B'];\n"
+        assert [r.name for r in detector.detect(js, "typescript")] == ["A", "B"]
+        assert [r.name for r in detector.detect('System.getenv("A")', "java")] == [
+            "A"
+        ]
+
+    def test_names_from_variables(self):
+        assert EnvReadDetector().detect("os.Getenv(key)", "go") == []
+
+
+class TestTerraformIngestion:
+    """Test the Terraform nodes and their links to each other and to code."""
+
+    WORKER_GO = """package worker
+
+import "os"
+
+func Run() {
+	queue := os.Getenv("ORDERS_QUEUE_URL")
+	_ = os.Getenv("QUEUE_URL")
+}
+"""
+
+    def _ingest(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        (temp_repo / "infra" / "modules" / "db").mkdir(parents=True)
+        (temp_repo / "infra" / "main.tf").write_text(MAIN_TF)
+        (temp_repo / "infra" / "modules" / "db" / "main.tf").write_text(DB_MODULE_TF)
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater._identify_structure()
+        updater._parse_terraform_file(temp_repo / "infra" / "main.tf")
+        updater._parse_terraform_file(
+            temp_repo / "infra" / "modules" / "db" / "main.tf"
+        )
+        updater._link_terraform()
+        return updater
+
+    def _edges(self, mock_ingestor: MagicMock, rel_type: str) -> set[tuple]:
+        return {
+            (call.args[0][2], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == rel_type
+        }
+
+    def test_nodes(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "TerraformResource",
+            {
+                "qualified_name": "infra:aws_sqs_queue.orders",
+                "name": "orders",
+                "address": "aws_sqs_queue.orders",
+                "path": "infra/main.tf",
+                "line_number": 14,
+                "type": "aws_sqs_queue",
+                "provider": "aws",
+                "is_data": False,
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "TerraformVariable",
+            {
+                "qualified_name": "infra:var.env",
+                "name": "env",
+                "address": "var.env",
+                "path": "infra/main.tf",
+                "line_number": 5,
+                "kind": "input",
+                "description": "Deployment stage",
+            },
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("File", "path", "infra/main.tf"),
+            "DEFINES",
+            ("TerraformModule", "qualified_name", "infra:module.db"),
+        )
+
+    def test_references(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        references = self._edges(mock_ingestor, "REFERENCES")
+        assert ("infra:local.prefix", "infra:var.env") in references
+        assert ("infra:output.queue_url", "infra:aws_sqs_queue.orders") in references
+        assert (
+            "infra/modules/db:aws_db_instance.main",
+            "infra/modules/db:var.name",
+        ) in references
+        assert self._edges(mock_ingestor, "SOURCED_FROM") == {
+            ("infra:module.db", "infra/modules/db")
+        }
+        # count is a meta-argument, not an input variable
+        assert self._edges(mock_ingestor, "SETS") == {
+            ("infra:module.db", "infra/modules/db:var.name")
+        }
+
+    def test_environment(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        assert self._edges(mock_ingestor, "SETS_ENV") == {
+            ("infra:aws_lambda_function.worker", "QUEUE_URL"),
+            ("infra:aws_lambda_function.worker", "BUCKET"),
+            ("infra:aws_lambda_function.worker", "STAGE"),
+            ("infra:aws_ecs_task_definition.api", "DB_HOST"),
+        }
+        assert self._edges(mock_ingestor, "PROVIDES_ENV") == {
+            ("infra:aws_sqs_queue.orders", "QUEUE_URL"),
+            ("infra:aws_s3_bucket.uploads", "BUCKET"),
+            ("infra:module.db", "DB_HOST"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("TerraformResource", "qualified_name", "infra:aws_sqs_queue.orders"),
+            "PROVIDES_ENV",
+            ("EnvVar", "name", "QUEUE_URL"),
+            {
+                "matched_by": "reference",
+                "set_by": "aws_lambda_function.worker",
+                "line_number": 26,
+            },
+        )
+
+    def test_code_reading_env(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = self._ingest(temp_repo, mock_ingestor)
+        updater.function_spans["shop.worker"].append(
+            (5, 8, "Function", "shop.worker.Run")
+        )
+        updater._ingest_env_reads(self.WORKER_GO, "shop.worker", "go")
+        mock_ingestor.fetch_all.return_value = [
+            {"name": "ORDERS_QUEUE_URL"},
+            {"name": "QUEUE_URL"},
+        ]
+
+        updater._link_env_providers()
+
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Function", "qualified_name", "shop.worker.Run"),
+            "READS_ENV",
+            ("EnvVar", "name", "ORDERS_QUEUE_URL"),
+            {"line_number": 6},
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("TerraformResource", "qualified_name", "infra:aws_sqs_queue.orders"),
+            "PROVIDES_ENV",
+            ("EnvVar", "name", "ORDERS_QUEUE_URL"),
+            {"matched_by": "name"},
+        )
+
+    def test_invalid_file(self, temp_repo: Path, mock_ingestor: MagicMock):
+        (temp_repo / "broken.tf").write_text('resource "a_b" "c" {\n')
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+
+        updater._parse_terraform_file(temp_repo / "broken.tf")
+
+        assert updater.skipped_files["broken.tf"].startswith("invalid Terraform")
+        assert not updater.terraform_modules
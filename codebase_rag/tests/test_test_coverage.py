@@ -1,6 +1,7 @@
 """Tests for test coverage analysis and test-code linking."""
 
 from codebase_rag.analysis.test_coverage import TestCodeAnalyzer, TestCodeLink
+from codebase_rag.parsers.test_parser import TestNode
 
 
 class TestTestCodeAnalyzer:
@@ -143,6 +144,51 @@ def test_subtract():
         assert test_sub_rel[0][1] == "TESTS"
         assert test_sub_rel[0][3] == "subtract"
 
+    def test_ginkgo_specs_link_through_setup_blocks(self):
+        """Ginkgo specs are keyed by full text and include their BeforeEach code."""
+        analyzer = TestCodeAnalyzer()
+        spec = TestNode(
+            node_type="test_case",
+            name="returns an error",
+            file_path="calculator_test.go",
+            start_line=7,
+            end_line=10,
+            properties={
+                "full_text": "Calculator Divide returns an error",
+                "setup_lines": [[3, 5]],
+            },
+        )
+        code_nodes = [
+            type(
+                "obj",
+                (object,),
+                {"name": name, "node_type": node_type, "qualified_name": name},
+            )()
+            for name, node_type in [("NewCalculator", "function"), ("Divide", "method")]
+        ]
+        test_content = """var _ = Describe("Calculator", func() {
+    var calc *Calculator
+    BeforeEach(func() {
+        calc = NewCalculator()
+    })
+    Describe("Divide", func() {
+        It("returns an error", func() {
+            _, err := calc.Divide(1, 0)
+            Expect(err).To(HaveOccurred())
+        })
+    })
+})
+"""
+
+        relationships = analyzer.analyze_test_code_relationships(
+            [spec], code_nodes, test_content, "go"
+        )
+
+        assert {(r[0], r[3]) for r in relationships} == {
+            ("Calculator Divide returns an error", "Divide"),
+            ("Calculator Divide returns an error", "NewCalculator"),
+        }
+
     def test_calculate_coverage_metrics(self):
         """Test coverage metric calculation."""
         analyzer = TestCodeAnalyzer()
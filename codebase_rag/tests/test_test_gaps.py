@@ -0,0 +1,168 @@
+"""Tests for HTTP endpoint detection and the test gap report."""
+
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.test_gaps import (
+    TestGapAnalyzer,
+    collect_test_gaps,
+    group_by_package,
+)
+from codebase_rag.parsers.endpoint_detector import EndpointDetector
+from codebase_rag.utils.visibility import is_exported, language_for_path
+
+
+class TestEndpointDetector:
+    """Test HTTP endpoint detection across frameworks."""
+
+    def setup_method(self):
+        self.detector = EndpointDetector()
+
+    def test_fastapi_decorators(self):
+        source = (
+            '@router.get("/users/{user_id}")\n'
+            "@requires_auth\n"
+            "async def get_user(user_id: int):\n"
+            "    return user_id\n"
+        )
+        [endpoint] = self.detector.detect(source, "python")
+        assert (endpoint.method, endpoint.route) == ("GET", "/users/{user_id}")
+        assert endpoint.handler == "get_user"
+        assert endpoint.line_number == 1
+
+    def test_flask_route_methods(self):
+        source = (
+            '@app.route("/items", methods=["GET", "POST"])\n'
+            "def items():\n"
+            "    pass\n"
+            '@app.route("/health")\n'
+            "def health():\n"
+            "    pass\n"
+        )
+        endpoints = self.detector.detect(source, "python")
+        assert [(e.method, e.route, e.handler) for e in endpoints] == [
+            ("GET", "/items", "items"),
+            ("POST", "/items", "items"),
+            ("GET", "/health", "health"),
+        ]
+
+    def test_django_path(self):
+        [endpoint] = self.detector.detect(
+            'urlpatterns = [path("users/", views.user_list)]', "python"
+        )
+        assert (endpoint.method, endpoint.handler) == ("ANY", "user_list")
+
+    def test_express_routes_ignore_client_calls(self):
+        source = (
+            "router.post('/login', rateLimit(5), handleLogin);\n"
+            "app.get('/inline', (req, res) => res.send('ok'));\n"
+            "const value = cache.get('/not-a-route', fallback);\n"
+            "axios.get('/api/users', config);\n"
+        )
+        endpoints = self.detector.detect(source, "javascript")
+        assert [(e.method, e.route, e.handler) for e in endpoints] == [
+            ("POST", "/login", "handleLogin"),
+            ("GET", "/inline", None),
+        ]
+
+    def test_go_handlers(self):
+        source = (
+            'mux.HandleFunc("GET /users/{id}", getUser)\n'
+            'r.HandleFunc("/orders", h.CreateOrder).Methods("POST")\n'
+            'r.GET("/ping", ping)\n'
+        )
+        endpoints = self.detector.detect(source, "go")
+        assert [(e.method, e.route, e.handler) for e in endpoints] == [
+            ("GET", "/users/{id}", "getUser"),
+            ("POST", "/orders", "CreateOrder"),
+            ("GET", "/ping", "ping"),
+        ]
+
+    def test_spring_mappings_use_class_prefix(self):
+        source = (
+            '@RequestMapping("/api")\n'
+            "public class UserController {\n"
+            '    @GetMapping("/users")\n'
+            "    public List<User> listUsers() {\n"
+            "    }\n"
+            '    @RequestMapping(value = "/users", method = RequestMethod.DELETE)\n'
+            "    public void clear() {\n"
+            "    }\n"
+            "}\n"
+        )
+        endpoints = self.detector.detect(source, "java")
+        assert [(e.method, e.route, e.handler) for e in endpoints] == [
+            ("GET", "/api/users", "listUsers"),
+            ("DELETE", "/api/users", "clear"),
+        ]
+
+    def test_unsupported_language(self):
+        assert self.detector.detect('@app.get("/x")', "rust") == []
+
+
+class TestVisibility:
+    """Test public API detection conventions."""
+
+    def test_language_conventions(self):
+        assert is_exported("Handler", "go")
+        assert not is_exported("handler", "go")
+        assert is_exported("parse", "c")
+        assert not is_exported("parse", "c", is_static=True)
+        assert is_exported("load", "python")
+        assert not is_exported("_load", "python")
+        assert not is_exported("", "python")
+
+    def test_language_for_path(self):
+        assert language_for_path("pkg/server.go") == "go"
+        assert language_for_path("README.md") is None
+
+
+class TestTestGaps:
+    """Test filtering, sorting and grouping of untested symbols."""
+
+    def test_collect_filters_private_and_test_code(self):
+        rows = [
+            {"qualified_name": "p.api.Serve", "name": "Serve", "label": "Function", "path": "api/server.go", "complexity": 3},
+            {"qualified_name": "p.api.serve", "name": "serve", "label": "Function", "path": "api/server.go", "complexity": 9},
+            {"qualified_name": "p.svc._helper", "name": "_helper", "label": "Function", "path": "svc/core.py", "complexity": 4},
+            {"qualified_name": "p.svc.run", "name": "run", "label": "Function", "path": "svc/core.py", "complexity": 7},
+            {"qualified_name": "p.tests.test_core.build", "name": "build", "label": "Function", "path": "tests/test_core.py", "complexity": 5},
+            {"qualified_name": "p.lib.parse", "name": "parse", "label": "Function", "path": "lib/parse.c", "complexity": 2, "is_static": True},
+        ]
+        gaps = collect_test_gaps(rows)
+        assert [g.qualified_name for g in gaps] == ["p.svc.run", "p.api.Serve"]
+        assert gaps[0].package == "svc"
+
+    def test_endpoints_are_always_reported(self):
+        rows = [
+            {"qualified_name": "p.app:GET /users", "name": "GET /users", "label": "Endpoint", "path": "app.py", "complexity": None, "line_number": 4},
+        ]
+        [gap] = collect_test_gaps(rows)
+        assert gap.label == "Endpoint"
+        assert gap.complexity == 1
+        assert gap.package == "."
+
+    def test_group_by_package_orders_by_complexity(self):
+        rows = [
+            {"qualified_name": "p.a.low", "name": "low", "label": "Function", "path": "a/x.py", "complexity": 1},
+            {"qualified_name": "p.b.high", "name": "high", "label": "Function", "path": "b/y.py", "complexity": 8},
+            {"qualified_name": "p.a.mid", "name": "mid", "label": "Method", "path": "a/x.py", "complexity": 5},
+        ]
+        grouped = group_by_package(collect_test_gaps(rows))
+        assert list(grouped) == ["b", "a"]
+        assert [g.name for g in grouped["a"]] == ["mid", "low"]
+
+    def test_analyzer_queries_functions_and_endpoints(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [{"qualified_name": "p.a.f", "name": "f", "label": "Function", "path": "a.py", "complexity": 2}],
+            [{"qualified_name": "p.a:POST /x", "name": "POST /x", "label": "Endpoint", "path": "a.py", "complexity": 6}],
+        ]
+        gaps = TestGapAnalyzer(ingestor).find_gaps()
+        assert [g.label for g in gaps] == ["Endpoint", "Function"]
+        assert ingestor.fetch_all.call_count == 2
+
+    def test_analyzer_without_endpoints(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+        assert TestGapAnalyzer(ingestor).find_gaps_by_package(include_endpoints=False) == {}
+        ingestor.fetch_all.assert_called_once()
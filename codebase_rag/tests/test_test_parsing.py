@@ -1,11 +1,13 @@
 from pathlib import Path
+from unittest.mock import MagicMock
 
 import pytest
 
+from codebase_rag.graph_updater import GraphUpdater
 from codebase_rag.parser_loader import load_parsers
 from codebase_rag.parsers.bdd_parser import BDDParser
 from codebase_rag.parsers.test_detector import TestDetector
-from codebase_rag.parsers.test_parser import TestParser
+from codebase_rag.parsers.test_parser import TestParser, go_test_target
 
 
 class TestTestParsing:
@@ -69,6 +71,16 @@ describe('Example', () => {
         assert framework is not None
         assert framework.framework == "jest"
 
+        # Testify detection, ahead of the plain testing package it also imports
+        testify_code = (
+            Path(__file__).parent / "fixtures" / "calculator_testify_test.go"
+        ).read_text()
+        framework = detector.detect_framework(testify_code, "go", "calc_test.go")
+        assert framework is not None
+        assert framework.framework == "testify"
+        assertions = detector.extract_assertions(testify_code, framework)
+        assert [line for line, _ in assertions] == [25, 31, 41, 42]
+
     def test_python_test_parsing(self, parsers_and_queries):
         """Test parsing Python test files."""
         parsers, queries = parsers_and_queries
@@ -283,6 +295,27 @@ describe('Example', () => {
         assert "TestDivide" in test_names
         assert "BenchmarkAdd" in test_names
 
+        # t.Run subtests, one per table row when the names come from the table
+        subtests = {
+            n.key: n for n in nodes if n.properties.get("test_type") == "subtest"
+        }
+        assert sorted(subtests) == [
+            "TestDivide/divide_by_zero",
+            "TestDivide/normal_division",
+            "TestSubtract/negative_result",
+            "TestSubtract/positive_numbers",
+            "TestSubtract/with_zero",
+        ]
+        negative = subtests["TestSubtract/negative_result"]
+        assert negative.name == "negative result"
+        assert negative.properties["case_line"] == 28
+        assert negative.properties["parent_test"] == "TestSubtract"
+        # Setup is what runs before the subtest, not the other subtests
+        assert [20, 20] in negative.properties["setup_lines"]
+        assert subtests["TestDivide/divide_by_zero"].properties["setup_lines"] == [
+            [42, 42]
+        ]
+
     def test_ginkgo_test_parsing(self, parsers_and_queries):
         """Test parsing Go Ginkgo BDD-style test files."""
         parsers, queries = parsers_and_queries
@@ -321,3 +354,103 @@ describe('Example', () => {
         test_names = [n.name for n in nodes if n.node_type == "test_case"]
         assert "should add positive numbers correctly" in test_names
         assert "should return error on divide by zero" in test_names
+
+        # Specs are nested under their containers and keyed by Ginkgo full text
+        by_key = {n.key: n for n in nodes if n.properties.get("framework") == "ginkgo"}
+        spec = by_key[
+            "Calculator Advanced operations Division "
+            "should return error on divide by zero"
+        ]
+        context = by_key["Calculator Advanced operations Division"]
+        assert spec.properties["parent_suite"] == context.key
+        assert context.properties["parent_suite"] == "Calculator Advanced operations"
+        assert "parent_suite" not in by_key["Calculator"].properties
+        # The top-level BeforeEach runs before every spec
+        assert spec.properties["setup_lines"] == [[17, 19]]
+
+    def test_testify_test_parsing(self, parsers_and_queries):
+        """Test parsing testify suites and assertions."""
+        parsers, queries = parsers_and_queries
+        if "go" not in parsers:
+            pytest.skip("Go parser not available")
+
+        test_parser = TestParser(parsers["go"], queries["go"], "go")
+        test_file = Path(__file__).parent / "fixtures" / "calculator_testify_test.go"
+        content = test_file.read_text()
+
+        nodes, relationships = test_parser.parse_test_file(str(test_file), content)
+
+        by_key = {n.key: n for n in nodes}
+        suite = by_key["CalculatorSuite"]
+        assert suite.node_type == "test_suite"
+        assert suite.properties["lifecycle"] == ["SetupTest", "TearDownTest"]
+        # Suite methods are cases named as go test reports them, not functions
+        add = by_key["TestCalculatorSuite/TestAdd"]
+        assert add.node_type == "test_case"
+        assert add.properties["parent_suite"] == "CalculatorSuite"
+        assert add.properties["setup_lines"] == [[16, 18]]
+        by_zero = by_key["TestCalculatorSuite/TestDivide/by_zero"]
+        assert by_zero.properties["parent_test"] == "TestCalculatorSuite/TestDivide"
+        assert sorted(
+            n.name for n in nodes if n.node_type == "test_function"
+        ) == ["TestCalculatorSuite", "TestMultiply"]
+
+        asserts = {(r[0], r[3]) for r in relationships if r[1] == "ASSERTS"}
+        assert asserts == {
+            ("TestCalculatorSuite/TestAdd", "s.Equal(5, s.calc.Add(2, 3))"),
+            ("TestCalculatorSuite/TestDivide/by_zero", "s.Require().Error(err)"),
+            ("TestMultiply", "require.NotNil(t, calc)"),
+            ("TestMultiply", "assert.Equal(t, 6, calc.Multiply(2, 3))"),
+        }
+
+
+class TestGoExampleLinks:
+    """Test linking Go examples and benchmarks to the code they are about."""
+
+    def test_target_names(self):
+        assert go_test_target("ExampleCalculator_Add") == ["Calculator", "Add"]
+        assert go_test_target("ExampleAdd_second") == ["Add"]
+        assert go_test_target("BenchmarkAdd") == ["Add"]
+        assert go_test_target("Example_suffix") == []
+        assert go_test_target("Example") == []
+        assert go_test_target("TestAdd") == []
+
+    def test_links(self, temp_repo: Path, mock_ingestor: MagicMock):
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        for qn in [
+            "shop.calc.calculator.Add",
+            "shop.calc.calculator.NewCalculator",
+            "shop.calc.calculator_test.BenchmarkAdd",
+            "shop.other.util.Add",
+        ]:
+            updater.function_registry[qn] = "Function"
+        test_module = "shop.calc.calculator_test"
+        updater.pending_go_targets = [
+            (f"{test_module}.ExampleCalculator_Add", test_module, "example", []),
+            (f"{test_module}.BenchmarkAdd", test_module, "benchmark", []),
+            # No function named Hot, so the first call in the body decides
+            (f"{test_module}.BenchmarkHot", test_module, "benchmark", ["Println"]),
+            (
+                f"{test_module}.ExampleCalculator",
+                test_module,
+                "example",
+                ["NewCalculator", "Add"],
+            ),
+        ]
+
+        updater._link_go_test_targets()
+
+        links = {
+            (call.args[0][2].rsplit(".", 1)[1], call.args[1], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+        }
+        assert links == {
+            ("ExampleCalculator_Add", "DOCUMENTS", "shop.calc.calculator.Add"),
+            ("BenchmarkAdd", "EXERCISES", "shop.calc.calculator.Add"),
+            (
+                "ExampleCalculator",
+                "DOCUMENTS",
+                "shop.calc.calculator.NewCalculator",
+            ),
+        }
+        assert updater.pending_go_targets == []
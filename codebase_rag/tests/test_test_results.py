@@ -0,0 +1,213 @@
+"""Tests for JUnit XML ingestion and flaky test detection."""
+
+import tempfile
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.test_results import (
+    ERROR,
+    FAILED,
+    PASSED,
+    RETRIED,
+    SKIPPED,
+    TestResult,
+    TestResultAnalyzer,
+    TestRun,
+    collect_reports,
+    match_test_node,
+    parse_junit_xml,
+    summarize_history,
+)
+
+PYTEST_REPORT = """<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" timestamp="2024-05-01T10:00:00" tests="4">
+    <testcase classname="tests.test_cart" name="test_add_item" time="0.01"/>
+    <testcase classname="tests.test_cart" name="test_checkout[visa]" time="0.20">
+      <failure message="AssertionError: timeout">Traceback...</failure>
+    </testcase>
+    <testcase classname="tests.test_cart" name="test_refund" time="0.00">
+      <skipped message="not implemented"/>
+    </testcase>
+    <testcase classname="tests.test_cart" name="test_broken" time="0.00">
+      <error message="fixture failed"/>
+    </testcase>
+  </testsuite>
+</testsuites>
+"""
+
+SUREFIRE_REPORT = """<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="com.shop.CartTest" tests="1">
+  <testcase classname="com.shop.CartTest" name="testTotal" time="1.5">
+    <flakyFailure message="expected 3 but was 2"/>
+  </testcase>
+</testsuite>
+"""
+
+
+class TestJUnitParsing:
+    """Test JUnit XML report parsing."""
+
+    @pytest.fixture
+    def report_dir(self):
+        with tempfile.TemporaryDirectory() as temp_dir:
+            path = Path(temp_dir)
+            (path / "pytest.xml").write_text(PYTEST_REPORT)
+            (path / "nested").mkdir()
+            (path / "nested" / "surefire.xml").write_text(SUREFIRE_REPORT)
+            (path / "notes.txt").write_text("not a report")
+            yield path
+
+    def test_parse_statuses(self, report_dir):
+        run = parse_junit_xml(report_dir / "pytest.xml")
+
+        assert run.timestamp == "2024-05-01T10:00:00"
+        assert [(r.name, r.status) for r in run.results] == [
+            ("test_add_item", PASSED),
+            ("test_checkout[visa]", FAILED),
+            ("test_refund", SKIPPED),
+            ("test_broken", ERROR),
+        ]
+        assert run.results[1].message == "AssertionError: timeout"
+        assert run.results[1].duration == pytest.approx(0.2)
+        assert run.count(FAILED) == 1
+
+    def test_run_id_is_stable(self, report_dir):
+        first = parse_junit_xml(report_dir / "pytest.xml")
+        second = parse_junit_xml(report_dir / "pytest.xml")
+        assert first.run_id == second.run_id
+
+    def test_surefire_retries_and_missing_timestamp(self, report_dir):
+        run = parse_junit_xml(report_dir / "nested" / "surefire.xml")
+        [result] = run.results
+        assert result.status == RETRIED
+        assert result.classname == "com.shop.CartTest"
+        # Falls back to the file modification time
+        assert run.timestamp.startswith("20")
+
+    def test_collect_reports(self, report_dir):
+        reports = collect_reports([report_dir])
+        assert [r.name for r in reports] == ["surefire.xml", "pytest.xml"]
+
+
+class TestFlakyDetection:
+    """Test pass/fail history summaries."""
+
+    def test_intermittent_failures_are_flaky(self):
+        history = summarize_history(
+            "p.t.test_x", "TestFunction", [PASSED, FAILED, PASSED, PASSED, FAILED]
+        )
+        assert history.is_flaky
+        assert history.run_count == 5
+        assert history.fail_count == 2
+        assert history.pass_rate == pytest.approx(0.6)
+        assert history.flakiness_score == pytest.approx(0.75)
+        assert history.last_status == FAILED
+
+    def test_regression_is_not_flaky(self):
+        history = summarize_history(
+            "p.t.test_x", "TestFunction", [PASSED, PASSED, FAILED, FAILED]
+        )
+        assert not history.is_flaky
+        assert history.flakiness_score == pytest.approx(1 / 3)
+
+    def test_retried_pass_is_flaky(self):
+        history = summarize_history(
+            "p.t.test_x", "TestCase", [PASSED, RETRIED, PASSED]
+        )
+        assert history.is_flaky
+        assert history.fail_count == 0
+
+    def test_skips_and_min_runs(self):
+        statuses = [PASSED, SKIPPED, FAILED, SKIPPED, PASSED, FAILED]
+        assert summarize_history("t", "TestCase", statuses).is_flaky
+        assert not summarize_history("t", "TestCase", statuses, min_runs=5).is_flaky
+
+
+class TestResultMatching:
+    """Test matching JUnit results to graph test nodes."""
+
+    @pytest.fixture
+    def graph_test_nodes(self):
+        return {
+            "test_checkout": [("TestFunction", "shop.tests.test_cart.test_checkout")],
+            "testTotal": [
+                ("TestCase", "shop.src.test.java.com.shop.CartTest.testTotal"),
+                ("TestCase", "shop.src.test.java.com.shop.OrderTest.testTotal"),
+            ],
+            "TestParse": [("TestFunction", "shop.parser.parser_test.TestParse")],
+        }
+
+    def test_parametrized_name(self, graph_test_nodes):
+        result = TestResult("tests.test_cart", "test_checkout[visa]", FAILED)
+        assert match_test_node(result, graph_test_nodes) == (
+            "TestFunction",
+            "shop.tests.test_cart.test_checkout",
+        )
+
+    def test_classname_disambiguates(self, graph_test_nodes):
+        result = TestResult("com.shop.OrderTest", "testTotal", PASSED)
+        assert match_test_node(result, graph_test_nodes) == (
+            "TestCase",
+            "shop.src.test.java.com.shop.OrderTest.testTotal",
+        )
+
+    def test_go_subtest(self, graph_test_nodes):
+        result = TestResult("shop/parser", "TestParse/empty_input", PASSED)
+        assert match_test_node(result, graph_test_nodes)[1].endswith("TestParse")
+
+    def test_unknown_test(self, graph_test_nodes):
+        assert match_test_node(TestResult("x", "test_missing", PASSED), graph_test_nodes) is None
+
+
+class TestResultAnalyzerIngestion:
+    """Test writing results and history to the graph."""
+
+    def test_ingest_runs(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.side_effect = [
+            [{"qualified_name": "p.tests.test_a.test_one", "name": "test_one", "label": "TestFunction"}],
+            [
+                {"qualified_name": "p.tests.test_a.test_one", "label": "TestFunction", "status": status}
+                for status in (PASSED, FAILED, PASSED, FAILED)
+            ],
+        ]
+        run = TestRun(
+            run_id="abc",
+            timestamp="2024-05-01T10:00:00",
+            source="report.xml",
+            results=[
+                TestResult("tests.test_a", "test_one", FAILED),
+                TestResult("tests.test_a", "test_other", PASSED),
+            ],
+        )
+
+        stats = TestResultAnalyzer(ingestor).ingest_runs([run])
+
+        assert stats == {
+            "runs": 1,
+            "results": 2,
+            "matched_tests": 1,
+            "unmatched_results": 1,
+            "flaky_tests": 1,
+        }
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("TestResult", "result_id", "abc:tests.test_a.test_one"),
+            "RESULT_OF",
+            ("TestFunction", "qualified_name", "p.tests.test_a.test_one"),
+        )
+        ingestor.ensure_node_batch.assert_any_call(
+            "TestFunction",
+            {
+                "qualified_name": "p.tests.test_a.test_one",
+                "run_count": 4,
+                "fail_count": 2,
+                "pass_rate": 0.5,
+                "flakiness_score": 1.0,
+                "is_flaky": True,
+                "last_status": FAILED,
+                "status_history": "PFPF",
+            },
+        )
@@ -0,0 +1,118 @@
+"""Tests for TODO/FIXME extraction and Todo node ingestion."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.todos import TodoAnalyzer
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.parsers.todo_extractor import TodoExtractor
+
+
+def _extract(source: str, language: str):
+    parsers, _ = load_parsers()
+    tree = parsers[language].parse(source.encode("utf-8"))
+    return TodoExtractor().extract(tree.root_node)
+
+
+class TestTodoExtractor:
+    """Test TODO marker extraction from comment nodes."""
+
+    def test_python_comments(self):
+        source = (
+            "# TODO: remove legacy path\n"
+            "def pay(amount):\n"
+            "    # FIXME(alice): rounding is wrong\n"
+            "    todo_list = 'TODO: not a comment'\n"
+            "    return amount  # HACK - skip validation\n"
+        )
+        todos = _extract(source, "python")
+        assert [(t.kind, t.text, t.line_number, t.tag) for t in todos] == [
+            ("TODO", "remove legacy path", 1, ""),
+            ("FIXME", "rounding is wrong", 3, "alice"),
+            ("HACK", "skip validation", 5, ""),
+        ]
+
+    def test_javascript_block_comment(self):
+        source = (
+            "/**\n"
+            " * Charges a card.\n"
+            " * TODO: support refunds */\n"
+            "function charge() {}\n"
+        )
+        [todo] = _extract(source, "javascript")
+        assert (todo.kind, todo.text, todo.line_number) == (
+            "TODO",
+            "support refunds",
+            3,
+        )
+
+    def test_lowercase_is_ignored(self):
+        assert _extract("# todo: later\n# a todo list\n", "python") == []
+
+
+class TestTodoIngestion:
+    """Test Todo nodes and HAS_TODO relationships created during ingestion."""
+
+    @pytest.fixture
+    def project(self, temp_repo: Path) -> Path:
+        project_path = temp_repo / "shop"
+        (project_path / "payments").mkdir(parents=True)
+        (project_path / "payments" / "charge.py").write_text(
+            "# XXX: module-level note\n"
+            "class Gateway:\n"
+            "    def charge(self):\n"
+            "        # TODO: retry on timeout\n"
+            "        pass\n"
+        )
+        return project_path
+
+    def test_todos_linked_to_enclosing_method(
+        self, project: Path, mock_ingestor: MagicMock
+    ):
+        parsers, queries = load_parsers()
+        GraphUpdater(mock_ingestor, project, parsers, queries).run()
+
+        todo_nodes = [
+            c.args[1]
+            for c in mock_ingestor.ensure_node_batch.call_args_list
+            if c.args[0] == "Todo"
+        ]
+        assert {(t["kind"], t["line_number"]) for t in todo_nodes} == {
+            ("XXX", 1),
+            ("TODO", 4),
+        }
+        assert all(t["path"] == str(Path("payments") / "charge.py") for t in todo_nodes)
+
+        has_todo = {
+            (c.args[0], c.args[2][2])
+            for c in mock_ingestor.ensure_relationship_batch.call_args_list
+            if c.args[1] == "HAS_TODO"
+        }
+        assert has_todo == {
+            (("Module", "qualified_name", "shop.payments.charge"), "shop.payments.charge:1"),
+            (
+                ("Method", "qualified_name", "shop.payments.charge.Gateway.charge"),
+                "shop.payments.charge:4",
+            ),
+        }
+
+
+class TestTodoAnalyzer:
+    """Test TODO query parameters."""
+
+    def test_find_todos_normalizes_filters(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+
+        TodoAnalyzer(ingestor).find_todos("payments", ["fixme"], limit=5)
+
+        _, params = ingestor.fetch_all.call_args.args
+        assert params == {
+            "path_prefix": "payments/",
+            "kinds": ["FIXME"],
+            "author": "",
+            "limit": 5,
+        }
@@ -0,0 +1,112 @@
+"""Tests for fitting retrieved code into a model's token budget."""
+
+from codebase_rag.schemas import CodeSnippet
+from codebase_rag.token_budget import (
+    DEFAULT_CONTEXT_WINDOW,
+    DIRECT,
+    DOCS,
+    NEIGHBOUR,
+    BudgetItem,
+    TokenBudget,
+    context_window,
+)
+from codebase_rag.tools.code_retrieval import fit_snippet
+
+SOURCE = """def settle(orders):
+    total = 0
+    for order in orders:
+        total += order.amount
+        log(order)
+    charge(total)
+    return total
+"""
+
+
+class WordCounter:
+    """A token per word, so budgets are easy to reason about."""
+
+    name = "words"
+
+    def count(self, text: str) -> int:
+        return len(text.split())
+
+
+class TestContextWindow:
+    """Test looking up context windows by model id."""
+
+    def test_longest_prefix_wins(self):
+        assert context_window("gpt-4o-mini") == 128_000
+        assert context_window("gpt-4-0613") == 8_192
+        assert context_window("llama3.1:8b") == 131_072
+        assert context_window("my-finetune") == DEFAULT_CONTEXT_WINDOW
+
+    def test_budget_is_a_share_of_the_window(self):
+        assert TokenBudget.for_model("llama3", share=0.5).limit == 4_096
+        assert TokenBudget.for_model("llama3", 32_000, 0.25).limit == 8_000
+
+
+class TestTokenBudget:
+    """Test keeping retrieved items by priority."""
+
+    def test_items_kept_by_priority(self):
+        budget = TokenBudget(10, WordCounter())
+        items = [
+            BudgetItem("docs", "one two three", DOCS),
+            BudgetItem("caller a", "one two three four five six", NEIGHBOUR),
+            BudgetItem("hit", "one two three four", DIRECT),
+            BudgetItem("caller b", "one two", NEIGHBOUR),
+        ]
+
+        kept, omitted = budget.fit(items)
+
+        # Kept in their original order; the docs no longer fit
+        assert [item.label for item in kept] == ["caller a", "hit"]
+        assert omitted == ["caller b (2 tokens)", "docs (3 tokens)"]
+
+    def test_long_hit_is_cut_before_a_statement(self):
+        budget = TokenBudget(15, WordCounter())
+        hit = BudgetItem("billing.settle", SOURCE, DIRECT, first_line=10)
+
+        [kept], omitted = budget.fit([hit])
+
+        assert kept.text.splitlines() == [
+            "def settle(orders):",
+            "    total = 0",
+            "... lines 12-16 left out to fit the context budget",
+        ]
+        assert omitted == ["lines 12-16 of billing.settle"]
+
+
+class TestFitSnippet:
+    """Test fitting a retrieved snippet and its related code."""
+
+    def test_related_code_is_left_out_whole(self):
+        snippet = CodeSnippet(
+            qualified_name="billing.settle",
+            source_code=SOURCE,
+            file_path="billing.py",
+            line_start=10,
+            line_end=16,
+            docstring="Charge the orders.",
+            related=[
+                {
+                    "qualified_name": "billing.run",
+                    "relation": "caller",
+                    "hops": 2,
+                    "source": "def run():\n    settle(load())\n" * 5,
+                },
+                {
+                    "qualified_name": "billing.charge",
+                    "relation": "callee",
+                    "hops": 1,
+                    "source": "def charge(total): ...",
+                },
+            ],
+        )
+
+        fitted = fit_snippet(snippet, TokenBudget(30, WordCounter()))
+
+        assert fitted.source_code == SOURCE
+        assert [r["qualified_name"] for r in fitted.related] == ["billing.charge"]
+        assert fitted.docstring == "Charge the orders."
+        assert fitted.omitted == ["caller billing.run (15 tokens)"]
@@ -0,0 +1,168 @@
+"""Tests for mapping OpenTelemetry traces to observed calls."""
+
+import json
+from unittest.mock import MagicMock
+
+from codebase_rag.analysis.code_locator import FUNCTIONS_QUERY
+from codebase_rag.analysis.traces import (
+    ENDPOINT_HANDLERS_QUERY,
+    TraceMapper,
+    parse_otlp_json,
+    percentile,
+)
+
+FUNCTIONS = [
+    {
+        "qualified_name": "shop.cart.Cart.total",
+        "name": "total",
+        "label": "Method",
+        "path": "shop/cart.py",
+        "start_line": 10,
+        "end_line": 30,
+    },
+    {
+        "qualified_name": "shop.cart.Cart.total.round_cents",
+        "name": "round_cents",
+        "label": "Function",
+        "path": "shop/cart.py",
+        "start_line": 12,
+        "end_line": 15,
+    },
+    {
+        "qualified_name": "shop.tax.rate",
+        "name": "rate",
+        "label": "Function",
+        "path": "shop/tax.py",
+        "start_line": 1,
+        "end_line": 5,
+    },
+    {
+        "qualified_name": "shop.api.get_cart",
+        "name": "get_cart",
+        "label": "Function",
+        "path": "shop/api.py",
+        "start_line": 1,
+        "end_line": 9,
+    },
+]
+HANDLERS = [
+    {
+        "method": "GET",
+        "route": "/carts/{cart_id}",
+        "qualified_name": "shop.api.get_cart",
+        "label": "Function",
+    }
+]
+
+
+def _attribute(key: str, value) -> dict:
+    if isinstance(value, int):
+        return {"key": key, "value": {"intValue": str(value)}}
+    return {"key": key, "value": {"stringValue": value}}
+
+
+def _span(span_id: str, parent: str, name: str, ms: int, error=False, **attrs):
+    return {
+        "traceId": "t1",
+        "spanId": span_id,
+        "parentSpanId": parent,
+        "name": name,
+        "startTimeUnixNano": "1700000000000000000",
+        "endTimeUnixNano": str(1700000000000000000 + ms * 1_000_000),
+        "status": {"code": 2 if error else 1},
+        "attributes": [_attribute(k.replace("_", "."), v) for k, v in attrs.items()],
+    }
+
+
+def _export(*spans) -> str:
+    return json.dumps(
+        {
+            "resourceSpans": [
+                {
+                    "resource": {"attributes": [_attribute("service.name", "shop")]},
+                    "scopeSpans": [{"spans": list(spans)}],
+                }
+            ]
+        }
+    )
+
+
+def _mapper() -> tuple[TraceMapper, MagicMock]:
+    ingestor = MagicMock()
+    ingestor.fetch_all.side_effect = lambda query, *args: {
+        FUNCTIONS_QUERY: FUNCTIONS,
+        ENDPOINT_HANDLERS_QUERY: HANDLERS,
+    }[query]
+    return TraceMapper(ingestor), ingestor
+
+
+class TestTraceParsing:
+    """Test reading OTLP/JSON exports."""
+
+    def test_parse_export_and_json_lines(self):
+        export = _export(_span("a", "", "GET /carts/{cart_id}", 40, http_route="/x"))
+        [span] = parse_otlp_json(export)
+        assert (span.service, span.name, span.duration_ms) == (
+            "shop",
+            "GET /carts/{cart_id}",
+            40.0,
+        )
+        assert span.attributes == {"http.route": "/x"}
+        assert len(parse_otlp_json(f"{export}\n{export}\n")) == 2
+
+    def test_percentile(self):
+        assert percentile([1.0, 2.0, 3.0, 4.0], 50) == 2.0
+        assert percentile([1.0, 2.0, 3.0, 4.0], 95) == 4.0
+        assert percentile([], 95) == 0.0
+
+
+class TestTraceMapper:
+    """Test resolving spans and recording OBSERVED_CALL edges."""
+
+    def test_resolve_strategies(self):
+        mapper, _ = _mapper()
+        in_file = {"code_filepath": "/app/shop/cart.py", "code_lineno": 13}
+        [by_line, by_name, by_route, by_span_name, unknown] = parse_otlp_json(
+            _export(
+                _span("1", "", "x", 1, **in_file),
+                _span("2", "", "x", 1, code_function="total", code_namespace="Cart"),
+                _span("3", "", "x", 1, http_route="/carts/:id", http_method="GET"),
+                _span("4", "", "tax.rate", 1),
+                _span("5", "", "HTTP GET", 1),
+            )
+        )
+        assert mapper.resolve(by_line) == (
+            "Function",
+            "shop.cart.Cart.total.round_cents",
+        )
+        assert mapper.resolve(by_name) == ("Method", "shop.cart.Cart.total")
+        assert mapper.resolve(by_route) == ("Function", "shop.api.get_cart")
+        assert mapper.resolve(by_span_name) == ("Function", "shop.tax.rate")
+        assert mapper.resolve(unknown) is None
+
+    def test_ingest_records_calls_through_unresolved_spans(self):
+        mapper, ingestor = _mapper()
+        spans = parse_otlp_json(
+            _export(
+                _span("root", "", "GET", 50, http_route="/carts/{id}"),
+                _span("mw", "root", "middleware", 45),
+                _span("t1", "mw", "Cart.total", 30, error=True),
+                _span("t2", "mw", "Cart.total", 10),
+                _span("r1", "t1", "rate", 2, code_function="rate"),
+            )
+        )
+
+        stats = mapper.ingest(spans)
+
+        assert stats == {"spans": 5, "resolved_spans": 4, "observed_calls": 2}
+        writes = {
+            call.args[0].split("\n")[2]: call.args[1]["edges"]
+            for call in ingestor.execute_write.call_args_list
+        }
+        [handler_calls] = writes["MATCH (a:Function {qualified_name: e.caller})"]
+        assert handler_calls["callee"] == "shop.cart.Cart.total"
+        assert (handler_calls["count"], handler_calls["error_count"]) == (2, 1)
+        assert (handler_calls["p50_ms"], handler_calls["max_ms"]) == (10.0, 30.0)
+        assert handler_calls["services"] == ["shop"]
+        [method_calls] = writes["MATCH (a:Method {qualified_name: e.caller})"]
+        assert (method_calls["callee"], method_calls["count"]) == ("shop.tax.rate", 1)
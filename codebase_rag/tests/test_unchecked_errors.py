@@ -0,0 +1,95 @@
+"""Tests for the Go unchecked-error audit."""
+
+from unittest.mock import MagicMock
+
+import pytest
+
+from codebase_rag.analysis.unchecked_errors import (
+    UncheckedErrorAnalyzer,
+    categorize_call,
+    collect_error_returning_functions,
+    find_unchecked_errors,
+)
+from codebase_rag.parser_loader import load_parsers
+
+SOURCE = b"""package shop
+
+func save(o Order) error { return nil }
+
+func total(o Order) (int, error) { return 0, nil }
+
+func log(msg string) {}
+
+func Checkout(o Order) {
+    f, _ := os.Create("receipt.txt")
+    defer f.Close()
+    _ = save(o)
+    save(o)
+    n, _ := strconv.Atoi(o.ID)
+    log("done")
+    fmt.Println(n)
+    if err := save(o); err != nil {
+        return
+    }
+    go s.db.Exec("UPDATE orders")
+}
+"""
+
+
+@pytest.fixture
+def go_root():
+    parsers, _ = load_parsers()
+    if "go" not in parsers:
+        pytest.skip("Go parser not available")
+    return parsers["go"].parse(SOURCE).root_node
+
+
+class TestUncheckedErrorDetection:
+    """Test detection of discarded errors in Go source."""
+
+    def test_error_returning_functions(self, go_root):
+        assert collect_error_returning_functions(go_root) == {"save", "total"}
+
+    def test_find_unchecked_errors(self, go_root):
+        findings = find_unchecked_errors(go_root, {"save", "total"})
+        assert [(f.call, f.kind, f.category, f.line_number) for f in findings] == [
+            ("os.Create", "blank", "io", 10),
+            ("f.Close", "deferred", "io", 11),
+            ("save", "blank", "other", 12),
+            ("save", "ignored", "other", 13),
+            ("strconv.Atoi", "blank", "parse", 14),
+            ("s.db.Exec", "goroutine", "database", 20),
+        ]
+
+
+class TestCategorizeCall:
+    """Test failure-likelihood categories of callees."""
+
+    @pytest.mark.parametrize(
+        "parts, category",
+        [
+            (["os", "Remove"], "io"),
+            (["w", "Write"], "io"),
+            (["http", "Get"], "network"),
+            (["srv", "ListenAndServe"], "network"),
+            (["tx", "Commit"], "database"),
+            (["json", "Unmarshal"], "encoding"),
+            (["time", "Parse"], "parse"),
+            (["cache", "Set"], "other"),
+        ],
+    )
+    def test_categories(self, parts, category):
+        assert categorize_call(parts) == category
+
+
+class TestUncheckedErrorAnalyzer:
+    """Test unchecked-error query parameters."""
+
+    def test_find_unchecked_errors_normalizes_filters(self):
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = []
+
+        UncheckedErrorAnalyzer(ingestor).find_unchecked_errors(3, "payments", 10)
+
+        _, params = ingestor.fetch_all.call_args.args
+        assert params == {"min_likelihood": 3, "path_prefix": "payments/", "limit": 10}
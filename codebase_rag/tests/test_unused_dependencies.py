@@ -0,0 +1,180 @@
+"""Tests for detecting declared dependencies that are never imported."""
+
+import json
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.analysis.dependencies import Import
+from codebase_rag.analysis.unused_dependencies import (
+    DeclaredDependency,
+    UnusedDependencyAnalyzer,
+    is_dependency_imported,
+    parse_go_mod,
+    parse_requirements,
+)
+from codebase_rag.graph_updater import GraphUpdater
+
+GO_MOD = """module example.com/shop
+
+go 1.22
+
+require github.com/google/uuid v1.6.0
+
+require (
+    github.com/stretchr/testify v1.9.0
+    golang.org/x/crypto v0.21.0
+    github.com/unused/lib v1.0.0
+    github.com/davecgh/go-spew v1.1.1 // indirect
+)
+"""
+
+
+class TestManifestParsing:
+    """Test parsing of dependency manifests."""
+
+    def test_go_mod_skips_indirect(self):
+        manifest = parse_go_mod(GO_MOD)
+        assert manifest.module_name == "example.com/shop"
+        assert [d.name for d in manifest.dependencies] == [
+            "github.com/google/uuid",
+            "github.com/stretchr/testify",
+            "golang.org/x/crypto",
+            "github.com/unused/lib",
+        ]
+
+    def test_requirements(self):
+        manifest = parse_requirements(
+            "# pinned\nrequests>=2.31\n-r base.txt\nPyYAML==6.0 ; python_version>'3'\n"
+            "git+https://example.com/repo.git\n"
+        )
+        assert [(d.name, d.version) for d in manifest.dependencies] == [
+            ("requests", ">=2.31"),
+            ("PyYAML", "==6.0"),
+        ]
+
+
+class TestImportMatching:
+    """Test matching imported packages against declared names."""
+
+    def test_go_modules(self):
+        imports = {"golang.org/x/crypto", "github.com/google/uuid"}
+        assert is_dependency_imported(
+            DeclaredDependency("golang.org/x/crypto"), "go", imports
+        )
+        # A shared prefix is not a subpackage
+        assert not is_dependency_imported(
+            DeclaredDependency("golang.org/x/cry"), "go", imports
+        )
+
+    def test_npm_packages(self):
+        imports = {"react", "lodash", "@scope/pkg"}
+        for name in ("react", "lodash", "@scope/pkg", "@types/react"):
+            assert is_dependency_imported(DeclaredDependency(name), "npm", imports)
+        assert not is_dependency_imported(DeclaredDependency("left-pad"), "npm", imports)
+
+    def test_python_import_names(self):
+        imports = {"yaml", "bs4"}
+        for name in ("PyYAML", "beautifulsoup4"):
+            assert is_dependency_imported(DeclaredDependency(name), "python", imports)
+        assert not is_dependency_imported(DeclaredDependency("requests"), "python", imports)
+
+
+class TestUnusedDependencyAnalyzer:
+    """Test per-manifest reports from the imports in the graph."""
+
+    def test_reports_per_manifest(self, temp_repo: Path):
+        (temp_repo / "go.mod").write_text(GO_MOD)
+        (temp_repo / "tools").mkdir()
+        (temp_repo / "tools" / "go.mod").write_text(
+            "module example.com/shop/tools\n\nrequire golang.org/x/crypto v0.21.0\n"
+        )
+        (temp_repo / "web").mkdir()
+        (temp_repo / "web" / "package.json").write_text(
+            json.dumps(
+                {
+                    "name": "web",
+                    "dependencies": {"react": "^18.0.0", "moment": "^2.0.0"},
+                    "devDependencies": {"jest": "^29.0.0"},
+                }
+            )
+        )
+        (temp_repo / "node_modules").mkdir()
+        (temp_repo / "node_modules" / "package.json").write_text("{}")
+        ingestor = MagicMock()
+        ingestor.fetch_all.return_value = [
+            {"path": "main.go", "package": "github.com/google/uuid", "go": True},
+            {
+                "path": "shop_test.go",
+                "package": "github.com/stretchr/testify",
+                "go": True,
+            },
+            # A nested module's imports do not count for the root module
+            {"path": "tools/hash.go", "package": "golang.org/x/crypto", "go": True},
+            {"path": "web/app.tsx", "package": "react", "go": False},
+            # Nor do imports of another ecosystem
+            {"path": "web/build.py", "package": "moment", "go": False},
+        ]
+
+        reports = {
+            report.manifest: report
+            for report in UnusedDependencyAnalyzer(ingestor, temp_repo).analyze()
+        }
+
+        assert ingestor.fetch_all.call_args.args[1] == {"project": temp_repo.name}
+        assert set(reports) == {"go.mod", "tools/go.mod", "web/package.json"}
+        assert [d.name for d in reports["go.mod"].unused] == [
+            "golang.org/x/crypto",
+            "github.com/unused/lib",
+        ]
+        assert reports["tools/go.mod"].unused == []
+        assert [d.name for d in reports["web/package.json"].unused] == ["moment"]
+
+        with_dev = UnusedDependencyAnalyzer(ingestor, temp_repo).analyze(
+            include_dev=True
+        )
+        web = next(r for r in with_dev if r.manifest == "web/package.json")
+        assert [d.name for d in web.unused] == ["moment", "jest"]
+
+
+class TestImportIngestion:
+    """Test third-party imports becoming IMPORTS edges to ExternalPackage nodes."""
+
+    def test_external_packages(self, tmp_path: Path):
+        (tmp_path / "shop").mkdir()
+        updater = GraphUpdater(MagicMock(), tmp_path, {}, {})
+
+        assert updater._external_package("yaml.loader", "python") == "yaml"
+        assert updater._external_package("os.path", "python") is None
+        assert updater._external_package("shop.cart", "python") is None
+        assert updater._external_package("..cart", "python") is None
+        assert updater._external_package("@scope/pkg/sub", "typescript") == (
+            "@scope/pkg"
+        )
+        for specifier in ("./cart", "@/components/cart", "fs", "node:fs"):
+            assert updater._external_package(specifier, "javascript") is None
+
+    def test_imports_edges(self, tmp_path: Path):
+        ingestor = MagicMock()
+        updater = GraphUpdater(
+            ingestor, tmp_path, {"python": MagicMock()}, {"python": {}}
+        )
+        imports = [
+            Import("safe_load", "yaml", "named", 1),
+            Import("path", "os", "namespace", 2),
+        ]
+
+        with patch("codebase_rag.graph_updater.DependencyAnalyzer") as analyzer:
+            analyzer.return_value.analyze_file.return_value = ([], imports)
+            updater._analyze_dependencies(
+                tmp_path / "cart.py", "", f"{tmp_path.name}.cart", "python"
+            )
+
+        ingestor.ensure_node_batch.assert_called_once_with(
+            "ExternalPackage", {"name": "yaml"}
+        )
+        ingestor.ensure_relationship_batch.assert_any_call(
+            ("Module", "qualified_name", f"{tmp_path.name}.cart"),
+            "IMPORTS",
+            ("ExternalPackage", "name", "yaml"),
+            {"symbol": "safe_load", "line_number": 1},
+        )
@@ -0,0 +1,90 @@
+"""Tests for debouncing file changes into incremental graph updates."""
+
+from pathlib import Path
+from types import SimpleNamespace
+from unittest.mock import MagicMock
+
+from codebase_rag.watcher import ChangeBatcher, _EventHandler, apply_changes
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 100.0
+
+    def __call__(self):
+        return self.now
+
+
+def batcher_for(repo: Path, clock: FakeClock) -> ChangeBatcher:
+    return ChangeBatcher(repo.resolve(), {".git", "node_modules"}, 0.5, clock)
+
+
+class TestChangeBatcher:
+    """Test which paths are collected and when they are released."""
+
+    def test_released_once_quiet(self, tmp_path):
+        clock = FakeClock()
+        batcher = batcher_for(tmp_path, clock)
+        (tmp_path / "cart.py").write_text("def total(): pass\n")
+
+        batcher.record(tmp_path / "cart.py")
+        batcher.record(tmp_path / "legacy.py")
+        clock.now += 0.3
+        batcher.record(tmp_path / "cart.py")
+        clock.now += 0.3
+        assert batcher.take() is None
+
+        clock.now += 0.3
+        assert batcher.take() == (["cart.py"], ["legacy.py"])
+        assert batcher.take() is None
+
+    def test_ignored_paths(self, tmp_path):
+        clock = FakeClock()
+        batcher = batcher_for(tmp_path, clock)
+
+        batcher.record(tmp_path / ".git" / "index")
+        batcher.record(tmp_path / "node_modules" / "left-pad" / "index.js")
+        batcher.record(tmp_path / ".cart.py.swp")
+        batcher.record(tmp_path.parent / "elsewhere.py")
+        clock.now += 1
+
+        assert batcher.take() is None
+
+    def test_moves_record_both_paths(self, tmp_path):
+        clock = FakeClock()
+        batcher = batcher_for(tmp_path, clock)
+        (tmp_path / "billing.py").write_text("")
+        handler = _EventHandler(batcher)
+
+        handler.on_any_event(
+            SimpleNamespace(
+                is_directory=False,
+                src_path=str(tmp_path / "legacy.py"),
+                dest_path=str(tmp_path / "billing.py"),
+            )
+        )
+        handler.on_any_event(
+            SimpleNamespace(is_directory=True, src_path=str(tmp_path / "pkg.py"))
+        )
+        clock.now += 1
+
+        assert batcher.take() == (["billing.py"], ["legacy.py"])
+
+
+class TestApplyChanges:
+    """Test passing batches to the incremental updater."""
+
+    def test_failed_update_is_retried(self, tmp_path):
+        clock = FakeClock()
+        batcher = batcher_for(tmp_path, clock)
+        updater = MagicMock()
+        updater.update_files.side_effect = [ConnectionError("refused"), None]
+        updated = []
+
+        batcher.record(tmp_path / "legacy.py")
+        clock.now += 1
+
+        assert not apply_changes(batcher, updater, lambda *b: updated.append(b))
+        assert apply_changes(batcher, updater, lambda *b: updated.append(b))
+        assert updated == [([], ["legacy.py"])]
+        assert updater.update_files.call_count == 2
@@ -0,0 +1,358 @@
+"""Tests for GitHub Actions and Makefile parsing and what their commands run."""
+
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.parsers.workflow_parser import (
+    invocations,
+    is_makefile,
+    is_workflow_file,
+    parse_makefile,
+    parse_workflow,
+)
+
+CI_WORKFLOW = """name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Lint
+        run: |
+          make lint
+          golangci-lint run --timeout 5m ./internal/...
+  test:
+    needs: lint
+    runs-on: [self-hosted, linux]
+    defaults:
+      run:
+        working-directory: services/api
+    steps:
+    - run: go test -race -coverprofile cover.out ./... && ./scripts/check.sh
+    - name: Build
+      run: CGO_ENABLED=0 go build -o bin/api ./cmd/api
+  release:
+    needs: [lint, test]
+    uses: ./.github/workflows/release.yml
+"""
+
+MAKEFILE = """GO ?= go
+PKGS := ./internal/...
+.PHONY: all lint test
+.DEFAULT_GOAL := all
+
+all: lint test
+
+lint: tools
+\t@$(GO) vet $(PKGS)
+\tbash scripts/lint.sh --fix
+
+tools:
+\t$(GO) install ./tools/...
+
+test:
+\t$(GO) test -run TestAPI \\
+\t  ./services/...
+\t$(MAKE) -C docs html
+
+%.o: %.c
+\tcc -c $<
+"""
+
+C_MAKEFILE = """SRCS = main.c util.c
+OBJS = $(SRCS:.c=.o)
+CFLAGS = -O2
+.PHONY: all clean
+
+all: bin/app
+
+bin/app: $(OBJS) include/app.h
+\t$(CC) $(CFLAGS) -o $@ $^ vendor/lib.c
+
+build/%.o: src/%.c
+\t$(CC) -c $< -o $@
+
+clean:
+\trm -f $(OBJS) bin/app
+"""
+
+
+class TestWorkflowParser:
+    """Test reading workflows and Makefiles, and the commands of scripts."""
+
+    def test_file_names(self):
+        assert is_workflow_file(".github/workflows/ci.yml")
+        assert is_workflow_file(".github/workflows/release.yaml")
+        assert not is_workflow_file("ci/.github/workflows/ci.yml")
+        assert not is_workflow_file(".github/dependabot.yml")
+        assert is_makefile("GNUmakefile")
+        assert is_makefile("build/go.mk")
+        assert not is_makefile("Makefile.am")
+
+    def test_jobs(self):
+        workflow = parse_workflow(CI_WORKFLOW)
+
+        assert (workflow.name, workflow.triggers) == ("CI", ["push", "pull_request"])
+        assert [
+            (job.id, job.line_number, job.runs_on, job.needs, job.working_directory)
+            for job in workflow.jobs
+        ] == [
+            ("lint", 7, "ubuntu-latest", [], None),
+            ("test", 15, "self-hosted, linux", ["lint"], "services/api"),
+            ("release", 25, "", ["lint", "test"], None),
+        ]
+        assert workflow.jobs[2].uses == "./.github/workflows/release.yml"
+
+    def test_steps(self):
+        lint, test, _ = parse_workflow(CI_WORKFLOW).jobs
+
+        assert [
+            (step.name, step.line_number, step.run_line_number)
+            for step in lint.steps + test.steps
+        ] == [
+            ("actions/checkout@v4", 10, 0),
+            ("Lint", 11, 13),
+            (
+                "go test -race -coverprofile cover.out ./... && ./scripts/check.sh",
+                22,
+                22,
+            ),
+            ("Build", 23, 24),
+        ]
+        assert lint.steps[0].uses == "actions/checkout@v4"
+
+    def test_invocations(self):
+        script = (
+            "if make -j 4 -f build.mk check VERBOSE=1; then sudo ./deploy.sh; fi\n"
+            "bash -c 'go vet .'\n"
+            "GOFLAGS=-mod=mod go run ./cmd/gen -- ./out\n"
+            "go test github.com/example/shop/...\n"
+            "golangci-lint run\n"
+            "echo done | tee /tmp/log\n"
+        )
+
+        assert [
+            (i.kind, i.target, i.line_number, i.command, i.makefile)
+            for i in invocations(script)
+        ] == [
+            ("make", "check", 1, "make", "build.mk"),
+            ("script", "./deploy.sh", 1, "./deploy.sh", None),
+            ("go", ".", 2, "go vet", None),
+            ("go", "./cmd/gen", 3, "go run", None),
+            ("go", "./...", 5, "golangci-lint run", None),
+        ]
+
+    def test_makefile(self):
+        makefile = parse_makefile(MAKEFILE)
+
+        assert makefile.default_goal == "all"
+        assert list(makefile.targets) == ["all", "lint", "tools", "test"]
+        lint = makefile.targets["lint"]
+        assert (lint.line_number, lint.prerequisites) == (8, ["tools"])
+        assert lint.commands == [
+            (9, "go vet ./internal/..."),
+            (10, "bash scripts/lint.sh --fix"),
+        ]
+        assert makefile.targets["test"].commands[1] == (18, "make -C docs html")
+        (make,) = invocations("make -C docs html")
+        assert (make.target, make.directory) == ("html", "docs")
+
+    def test_build_rules(self):
+        makefile = parse_makefile(C_MAKEFILE)
+
+        app = makefile.targets["bin/app"]
+        assert app.prerequisites == ["main.o", "util.o", "include/app.h"]
+        assert app.commands == [
+            (9, "cc -O2 -o bin/app main.o util.o include/app.h vendor/lib.c")
+        ]
+        assert makefile.pattern_rules == [("build/%.o", ["src/%.c"])]
+        assert [(t.name, t.is_phony, t.outputs) for t in makefile.targets.values()] == [
+            ("all", True, []),
+            ("bin/app", False, ["bin/app"]),
+            ("clean", True, []),
+        ]
+
+    def test_sources(self):
+        makefile = parse_makefile(C_MAKEFILE)
+        files = {"main.c", "util.c", "include/app.h", "vendor/lib.c"}
+
+        # The objects are built from their .c files by make's own rule
+        assert makefile.sources(makefile.targets["bin/app"], files.__contains__) == [
+            ("main.c", "main.o"),
+            ("util.c", "util.o"),
+            ("include/app.h", ""),
+            ("vendor/lib.c", ""),
+        ]
+        makefile.targets["bin/app"].prerequisites = ["build/net.o"]
+        assert makefile.sources(
+            makefile.targets["bin/app"], {"src/net.c"}.__contains__
+        ) == [("src/net.c", "build/net.o")]
+
+
+class TestWorkflowIngestion:
+    """Test the workflow and Makefile nodes and what their commands link to."""
+
+    def _ingest(self, temp_repo: Path, mock_ingestor: MagicMock) -> GraphUpdater:
+        workflows = temp_repo / ".github" / "workflows"
+        workflows.mkdir(parents=True)
+        (workflows / "ci.yml").write_text(CI_WORKFLOW)
+        (workflows / "release.yml").write_text(
+            "on: workflow_call\njobs:\n  publish:\n    runs-on: ubuntu-latest\n"
+            "    steps:\n      - run: make\n"
+        )
+        for directory in (
+            "internal/store",
+            "scripts",
+            "tools/gen",
+            "services/api/cmd/api",
+            "services/api/scripts",
+            "docs",
+        ):
+            (temp_repo / directory).mkdir(parents=True)
+        (temp_repo / "Makefile").write_text(MAKEFILE)
+        (temp_repo / "native").mkdir()
+        (temp_repo / "native" / "Makefile").write_text(C_MAKEFILE)
+        for name in ("main.c", "util.c"):
+            (temp_repo / "native" / name).write_text("int x;\n")
+        (temp_repo / "docs" / "Makefile").write_text("html:\n\tsphinx-build . _build\n")
+        (temp_repo / "scripts" / "lint.sh").write_text("#!/bin/sh\n")
+        (temp_repo / "services" / "api" / "scripts" / "check.sh").write_text("exit 0\n")
+        updater = GraphUpdater(mock_ingestor, temp_repo, {}, {})
+        updater._identify_structure()
+        updater._parse_workflow_file(workflows / "ci.yml")
+        updater._parse_workflow_file(workflows / "release.yml")
+        updater._parse_makefile(temp_repo / "Makefile")
+        updater._parse_makefile(temp_repo / "docs" / "Makefile")
+        updater._parse_makefile(temp_repo / "native" / "Makefile")
+        updater._link_workflows()
+        updater._link_makefiles()
+        return updater
+
+    def _edges(self, mock_ingestor: MagicMock, rel_type: str) -> set[tuple]:
+        return {
+            (call.args[0][2], call.args[2][2])
+            for call in mock_ingestor.ensure_relationship_batch.call_args_list
+            if call.args[1] == rel_type
+        }
+
+    def test_workflow_nodes(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Workflow",
+            {
+                "qualified_name": ".github/workflows/release.yml",
+                "name": "release",
+                "path": ".github/workflows/release.yml",
+                "triggers": ["workflow_call"],
+            },
+        )
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "Job",
+            {
+                "qualified_name": ".github/workflows/ci.yml:test",
+                "name": "test",
+                "path": ".github/workflows/ci.yml",
+                "line_number": 15,
+                "runs_on": "self-hosted, linux",
+                "uses": "",
+            },
+        )
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Job", "qualified_name", ".github/workflows/ci.yml:lint"),
+            "HAS_STEP",
+            ("Step", "qualified_name", ".github/workflows/ci.yml:lint:1"),
+            {"order": 1},
+        )
+        assert self._edges(mock_ingestor, "NEEDS") == {
+            (".github/workflows/ci.yml:test", ".github/workflows/ci.yml:lint"),
+            (".github/workflows/ci.yml:release", ".github/workflows/ci.yml:lint"),
+            (".github/workflows/ci.yml:release", ".github/workflows/ci.yml:test"),
+        }
+        assert self._edges(mock_ingestor, "CALLS_WORKFLOW") == {
+            (".github/workflows/ci.yml:release", ".github/workflows/release.yml")
+        }
+
+    def test_make_targets(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        assert self._edges(mock_ingestor, "DEPENDS_ON") == {
+            ("Makefile:all", "Makefile:lint"),
+            ("Makefile:all", "Makefile:test"),
+            ("Makefile:lint", "Makefile:tools"),
+            ("native/Makefile:all", "native/Makefile:bin/app"),
+        }
+        # make without a target runs the default goal
+        assert self._edges(mock_ingestor, "RUNS_TARGET") == {
+            (".github/workflows/ci.yml:lint:1", "Makefile:lint"),
+            (".github/workflows/release.yml:publish:0", "Makefile:all"),
+            ("Makefile:test", "docs/Makefile:html"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Step", "qualified_name", ".github/workflows/ci.yml:lint:1"),
+            "RUNS_TARGET",
+            ("MakeTarget", "qualified_name", "Makefile:lint"),
+            {"command": "make", "line_number": 13},
+        )
+
+    def test_scripts_and_packages(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        assert self._edges(mock_ingestor, "RUNS_SCRIPT") == {
+            (".github/workflows/ci.yml:test:0", "services/api/scripts/check.sh"),
+            ("Makefile:lint", "scripts/lint.sh"),
+        }
+        # Relative to the job's working directory, with -o's value left out
+        assert self._edges(mock_ingestor, "RUNS_PACKAGE") == {
+            (".github/workflows/ci.yml:lint:1", "internal"),
+            (".github/workflows/ci.yml:test:0", "services/api"),
+            (".github/workflows/ci.yml:test:1", "services/api/cmd/api"),
+            ("Makefile:lint", "internal"),
+            ("Makefile:tools", "tools"),
+            ("Makefile:test", "services"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("Step", "qualified_name", ".github/workflows/ci.yml:lint:1"),
+            "RUNS_PACKAGE",
+            ("Folder", "path", "internal"),
+            {
+                "command": "golangci-lint run",
+                "line_number": 14,
+                "pattern": "./internal/...",
+            },
+        )
+
+    def test_build_sources(self, temp_repo: Path, mock_ingestor: MagicMock):
+        self._ingest(temp_repo, mock_ingestor)
+
+        mock_ingestor.ensure_node_batch.assert_any_call(
+            "MakeTarget",
+            {
+                "qualified_name": "native/Makefile:bin/app",
+                "name": "bin/app",
+                "path": "native/Makefile",
+                "line_number": 8,
+                "commands": [
+                    "cc -O2 -o bin/app main.o util.o include/app.h vendor/lib.c"
+                ],
+                "is_default": False,
+                "is_phony": False,
+                "outputs": ["bin/app"],
+            },
+        )
+        # Relative to the Makefile, and only the files the repository has
+        assert self._edges(mock_ingestor, "BUILDS_FROM") == {
+            ("native/Makefile:bin/app", "native/main.c"),
+            ("native/Makefile:bin/app", "native/util.c"),
+        }
+        mock_ingestor.ensure_relationship_batch.assert_any_call(
+            ("MakeTarget", "qualified_name", "native/Makefile:bin/app"),
+            "BUILDS_FROM",
+            ("File", "path", "native/main.c"),
+            {"via": "main.o"},
+        )
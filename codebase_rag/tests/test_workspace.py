@@ -0,0 +1,156 @@
+"""Tests for repository setup: language detection and generated files."""
+
+import tomllib
+from unittest.mock import MagicMock, patch
+
+from codebase_rag.config import settings
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.services.dry_run import DryRunIngestor
+from codebase_rag.workspace import (
+    RAGIGNORE,
+    GitIgnore,
+    detect_languages,
+    ignore_candidates,
+    matches_glob,
+    provision_schema,
+    read_ragignore,
+    render_config,
+    render_ragignore,
+)
+
+
+def make_repo(root):
+    for relative in (
+        "app/main.py",
+        "app/util.py",
+        "web/index.ts",
+        "vendor/lib/dep.go",
+        "node_modules/pkg/index.js",
+        "web/coverage/report.js",
+        "README.md",
+    ):
+        path = root / relative
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text("")
+    return root
+
+
+class TestWorkspace:
+    """Test what init detects and writes."""
+
+    def test_detect_languages(self, tmp_path):
+        repo = make_repo(tmp_path)
+
+        assert detect_languages(repo) == {
+            "python": 2,
+            "typescript": 1,
+            "go": 1,
+            "javascript": 1,
+        }
+        (repo / RAGIGNORE).write_text("vendor/\ncoverage\n")
+        assert detect_languages(repo) == {"python": 2, "typescript": 1}
+
+    def test_ignore_candidates(self, tmp_path):
+        assert ignore_candidates(make_repo(tmp_path)) == ["vendor", "coverage"]
+
+    def test_generated_files_read_back(self, tmp_path):
+        config = render_config({"python": 2}, "db", 7688)
+        settings = tomllib.loads(config)["settings"]
+        assert settings == {
+            "target_repo_path": ".",
+            "memgraph_host": "db",
+            "memgraph_port": 7688,
+        }
+        assert "python (2)" in config
+
+        (tmp_path / RAGIGNORE).write_text(render_ragignore(["vendor", "target"]))
+        assert read_ragignore(tmp_path) == {"vendor", "target"}
+
+    def test_provision_schema(self):
+        ingestor = MagicMock()
+        with patch("codebase_rag.graph_indexing.GraphIndexManager") as manager:
+            provision_schema(ingestor)
+
+        ingestor.ensure_constraints.assert_called_once()
+        manager.assert_called_once_with(ingestor, ingestor.backend)
+        manager.return_value.create_indexes.assert_called_once()
+
+    def test_ragignore_applies_to_ingestion(self, tmp_path):
+        repo = make_repo(tmp_path)
+        (repo / RAGIGNORE).write_text("# build output\nvendor\n")
+
+        updater = GraphUpdater(DryRunIngestor(), repo, {}, {})
+
+        assert "vendor" in updater.ignore_dirs
+        assert ".git" in updater.ignore_dirs
+
+    def test_matches_glob(self):
+        assert matches_glob("app/proto/user_pb2.py", "*_pb2.py")
+        assert matches_glob("web/generated/api.ts", "**/generated/**")
+        assert matches_glob("generated/api.ts", "**/generated/**")
+        assert matches_glob("docs/guide/index.py", "docs/")
+        assert matches_glob("app/main.py", "./app/*")
+        assert not matches_glob("web/index.ts", "app/**")
+        assert not matches_glob("app/main.py", "*.ts")
+
+    def test_path_globs_apply_to_ingestion(self, tmp_path, monkeypatch):
+        repo = make_repo(tmp_path)
+        monkeypatch.setattr(settings, "INCLUDE_PATHS", "app/**, web/**")
+        monkeypatch.setattr(settings, "EXCLUDE_PATHS", "util.py")
+
+        updater = GraphUpdater(DryRunIngestor(), repo, {}, {})
+        updater.run()
+
+        skipped = updater.skipped_files
+        assert skipped["vendor/lib/dep.go"] == "not in INCLUDE_PATHS"
+        assert skipped["app/util.py"] == "excluded by EXCLUDE_PATHS"
+        assert skipped["app/main.py"] == "no python grammar installed"
+
+    def test_disabled_analyses(self, tmp_path, monkeypatch):
+        monkeypatch.setattr(settings, "DISABLED_ANALYSES", "git, cycles")
+
+        updater = GraphUpdater(DryRunIngestor(), make_repo(tmp_path), {}, {})
+        updater.run()
+
+        assert updater.git_analyzer is None
+        assert "cycles" not in updater.report.stages
+
+    def test_gitignore(self, tmp_path):
+        (tmp_path / ".gitignore").write_text(
+            "# build output\n*.log\n/dist\nout/\n!keep.log\ndocs/**/*.html\n"
+        )
+        (tmp_path / "web").mkdir()
+        (tmp_path / "web" / ".gitignore").write_text("gen/\n!important.log\n")
+        (tmp_path / ".git" / "info").mkdir(parents=True)
+        (tmp_path / ".git" / "info" / "exclude").write_text("scratch.py\n")
+        ignore = GitIgnore(tmp_path)
+
+        assert ignore.ignored("server.log")
+        assert ignore.ignored("app/debug.log")
+        assert not ignore.ignored("app/keep.log")
+        assert ignore.ignored("dist", is_dir=True)
+        assert not ignore.ignored("app/dist", is_dir=True)
+        # out/ names directories only, and their contents stay ignored
+        assert not ignore.ignored("out")
+        assert ignore.ignored("app/out/main.py")
+        assert ignore.ignored("docs/api/v1/index.html")
+        assert ignore.ignored("web/gen/api.ts")
+        assert not ignore.ignored("gen/api.ts")
+        assert not ignore.ignored("web/important.log")
+        assert ignore.ignored("app/scratch.py")
+
+    def test_gitignore_applies_to_ingestion(self, tmp_path, monkeypatch):
+        repo = make_repo(tmp_path)
+        (repo / ".gitignore").write_text("web/\napp/util.py\n")
+
+        updater = GraphUpdater(DryRunIngestor(), repo, {}, {})
+        updater.run()
+
+        assert updater.skipped_files["web/"] == "ignored by .gitignore"
+        assert updater.skipped_files["app/util.py"] == "ignored by .gitignore"
+        assert "web/index.ts" not in updater.skipped_files
+
+        monkeypatch.setattr(settings, "RESPECT_GITIGNORE", False)
+        updater = GraphUpdater(DryRunIngestor(), repo, {}, {})
+        updater.run()
+        assert "web/" not in updater.skipped_files
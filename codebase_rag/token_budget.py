@@ -0,0 +1,207 @@
+"""Fitting retrieved code into the context window of the model reading it.
+
+A tool result is one message among many in a conversation, so it may fill
+only a share of the orchestrator model's context window. Retrieved items are
+kept by priority: the code asked for first, then its callers, callees and
+other one-hop neighbours, then documentation. Each is kept whole or left out
+and listed as omitted, rather than cut off wherever a small local model's
+context happens to end. Only a direct hit longer than the whole budget is
+shortened, at a line starting a statement of its body, with a note naming
+the lines left out so the agent can ask for them.
+
+Tokens are counted with tiktoken's encoding for OpenAI models, or with the
+Hugging Face tokenizer named by TOKENIZER_MODEL_ID for local ones. Without
+either, cl100k_base stands in for other models, or failing that an estimate
+of four characters per token.
+"""
+
+import math
+from collections.abc import Callable
+from dataclasses import dataclass
+from typing import Any
+
+from loguru import logger
+
+# Context windows in tokens by model name prefix; the longest prefix wins
+CONTEXT_WINDOWS = {
+    "gpt-3.5": 16_385,
+    "gpt-4": 8_192,
+    "gpt-4-turbo": 128_000,
+    "gpt-4o": 128_000,
+    "gpt-4.1": 1_047_576,
+    "o1": 200_000,
+    "o3": 200_000,
+    "o4": 200_000,
+    "gemini-1.5": 1_048_576,
+    "gemini-2": 1_048_576,
+    "claude-": 200_000,
+    "llama2": 4_096,
+    "llama3": 8_192,
+    "llama3.1": 131_072,
+    "llama3.2": 131_072,
+    "codellama": 16_384,
+    "mistral": 32_768,
+    "qwen2.5-coder": 32_768,
+    "deepseek-coder": 16_384,
+    "phi3": 4_096,
+}
+# Models not listed, e.g. a local model served with Ollama's default context
+DEFAULT_CONTEXT_WINDOW = 8_192
+CHARS_PER_TOKEN = 4
+
+DIRECT = 0
+NEIGHBOUR = 1
+DOCS = 2
+
+
+def context_window(model_id: str) -> int:
+    """The context window of a model, by the longest known prefix of its id."""
+    prefixes = [p for p in CONTEXT_WINDOWS if model_id.startswith(p)]
+    if not prefixes:
+        return DEFAULT_CONTEXT_WINDOW
+    return CONTEXT_WINDOWS[max(prefixes, key=len)]
+
+
+class TokenCounter:
+    """Counts tokens as the model's tokenizer would, or estimates them."""
+
+    def __init__(self, model_id: str, tokenizer_id: str | None = None):
+        self.name = "estimate"
+        self._encode: Callable[[str], list[int]] | None = None
+        if tokenizer_id:
+            self._load_hugging_face(tokenizer_id)
+        if self._encode is None:
+            self._load_tiktoken(model_id)
+
+    def _load_hugging_face(self, tokenizer_id: str) -> None:
+        try:
+            from tokenizers import Tokenizer
+
+            tokenizer = Tokenizer.from_pretrained(tokenizer_id)
+        except Exception as e:
+            logger.warning(f"Tokenizer {tokenizer_id} unavailable: {e}")
+            return
+        self._encode = lambda text: tokenizer.encode(text).ids
+        self.name = tokenizer_id
+
+    def _load_tiktoken(self, model_id: str) -> None:
+        try:
+            import tiktoken
+
+            try:
+                encoding = tiktoken.encoding_for_model(model_id)
+            except KeyError:
+                encoding = tiktoken.get_encoding("cl100k_base")
+        except Exception as e:
+            logger.debug(f"Estimating tokens, tiktoken unavailable: {e}")
+            return
+        self._encode = lambda text: encoding.encode(text, disallowed_special=())
+        self.name = encoding.name
+
+    def count(self, text: str) -> int:
+        if self._encode is None:
+            return math.ceil(len(text) / CHARS_PER_TOKEN)
+        return len(self._encode(text))
+
+
+@dataclass
+class BudgetItem:
+    """A piece of retrieved context competing for the budget."""
+
+    label: str  # How the item is named when it is left out
+    text: str
+    priority: int = DIRECT  # DIRECT, NEIGHBOUR or DOCS
+    value: Any = None
+    # First line of the text in its file, for items that may be shortened
+    first_line: int | None = None
+    tokens: int = 0
+
+
+class TokenBudget:
+    """The tokens one tool result may use, and the items that fit in them."""
+
+    def __init__(self, limit: int, counter: TokenCounter):
+        self.limit = limit
+        self.counter = counter
+
+    @classmethod
+    def for_model(
+        cls,
+        model_id: str,
+        window: int | None = None,
+        share: float = 0.25,
+        tokenizer_id: str | None = None,
+    ) -> "TokenBudget":
+        limit = int((window or context_window(model_id)) * share)
+        return cls(limit, TokenCounter(model_id, tokenizer_id))
+
+    def fit(self, items: list[BudgetItem]) -> tuple[list[BudgetItem], list[str]]:
+        """
+        The items kept, in their original order, and what was left out.
+        Items are admitted by priority, then in order; a shortenable item
+        too long for what remains is shortened, any other is left out.
+        """
+        remaining = self.limit
+        kept: set[int] = set()
+        omitted = []
+        ranked = sorted(range(len(items)), key=lambda i: items[i].priority)
+        for i in ranked:
+            item = items[i]
+            item.tokens = whole = self.counter.count(item.text)
+            if item.tokens > remaining and item.first_line is not None:
+                text, note = self._shorten(item, remaining)
+                if note:
+                    item.text = text
+                    item.tokens = self.counter.count(text)
+                    omitted.append(note)
+            if item.tokens <= remaining:
+                kept.add(i)
+                remaining -= item.tokens
+            else:
+                omitted.append(f"{item.label} ({whole} tokens)")
+        if omitted:
+            logger.info(
+                f"Left out {len(omitted)} item(s) to fit {self.limit} tokens: "
+                + "; ".join(omitted)
+            )
+        return [items[i] for i in sorted(kept)], omitted
+
+    def _shorten(self, item: BudgetItem, tokens: int) -> tuple[str, str | None]:
+        """
+        The longest leading part of an item's lines within tokens that ends
+        before a statement of its body, and a note on the lines cut.
+        """
+        lines = item.text.splitlines(keepends=True)
+        first = item.first_line or 1
+        last = first + len(lines) - 1
+        # Leave room for the note itself
+        tokens -= self.counter.count(_note(first, last))
+        low, high = 0, len(lines)
+        while low < high:
+            middle = (low + high + 1) // 2
+            if self.counter.count("".join(lines[:middle])) <= tokens:
+                low = middle
+            else:
+                high = middle - 1
+        if low == 0:
+            return "", None
+        body_indent = _indent(next((ln for ln in lines[1:] if ln.strip()), ""))
+        cut = low
+        while cut > 1 and not (
+            lines[cut].strip() and _indent(lines[cut]) <= body_indent
+        ):
+            cut -= 1
+        if cut == 1:
+            cut = low
+        return (
+            "".join(lines[:cut]) + _note(first + cut, last),
+            f"lines {first + cut}-{last} of {item.label}",
+        )
+
+
+def _note(start: int, end: int) -> str:
+    return f"... lines {start}-{end} left out to fit the context budget\n"
+
+
+def _indent(line: str) -> int:
+    return len(line) - len(line.lstrip())
@@ -3,26 +3,50 @@ from pathlib import Path
 from loguru import logger
 from pydantic_ai import RunContext, Tool
 
+from ..context_expansion import ContextExpander, ExpansionPolicy
 from ..graph_updater import MemgraphIngestor
 from ..schemas import CodeSnippet
+from ..token_budget import DIRECT, DOCS, NEIGHBOUR, BudgetItem, TokenBudget
+
+OWNERS_QUERY = """
+    MATCH (:File {path: $path})-[:OWNED_BY]->(owner)
+    WHERE owner:Team OR owner:User
+    RETURN DISTINCT owner.name AS name
+"""
 
 
 class CodeRetriever:
     """Service to retrieve code snippets using the graph and filesystem."""
 
-    def __init__(self, project_root: str, ingestor: MemgraphIngestor):
+    def __init__(
+        self,
+        project_root: str,
+        ingestor: MemgraphIngestor,
+        expansion: ExpansionPolicy | None = None,
+        budget: TokenBudget | None = None,
+    ):
         self.project_root = Path(project_root).resolve()
         self.ingestor = ingestor
+        self.budget = budget
+        self.expander = (
+            ContextExpander(ingestor, expansion, self.project_root)
+            if expansion and expansion.enabled
+            else None
+        )
         logger.info(f"CodeRetriever initialized with root: {self.project_root}")
 
     async def find_code_snippet(self, qualified_name: str) -> CodeSnippet:
         """Finds a code snippet by querying the graph for its location."""
         logger.info(f"[CodeRetriever] Searching for: {qualified_name}")
 
+        # A chunk of a function or method stands for the whole of it; a chunk
+        # of a module is returned as is, a file being too long to show whole
         query = """
-            MATCH (n) WHERE n.qualified_name = $qn
+            MATCH (c) WHERE c.qualified_name = $qn
+            OPTIONAL MATCH (parent)-[:HAS_CHUNK]->(c)
+            WITH CASE WHEN parent IS NULL OR parent:Module THEN c ELSE parent END AS n
             OPTIONAL MATCH (m:Module)-[*]-(n)
-            RETURN n.name AS name, n.start_line AS start, n.end_line AS end, m.path AS path, n.docstring AS docstring
+            RETURN n.qualified_name AS qualified_name, n.name AS name, n.start_line AS start, n.end_line AS end, m.path AS path, n.docstring AS docstring
             LIMIT 1
         """
         params = {"qn": qualified_name}
@@ -42,6 +66,7 @@ class CodeRetriever:
                 )
 
             res = results[0]
+            qualified_name = res.get("qualified_name") or qualified_name
             file_path_str = res.get("path")
             start_line = res.get("start")
             end_line = res.get("end")
@@ -64,14 +89,27 @@ class CodeRetriever:
             snippet_lines = all_lines[start_line - 1 : end_line]
             source_code = "".join(snippet_lines)
 
-            return CodeSnippet(
+            snippet = CodeSnippet(
                 qualified_name=qualified_name,
                 source_code=source_code,
                 file_path=file_path_str,
                 line_start=start_line,
                 line_end=end_line,
                 docstring=res.get("docstring"),
+                owners=[
+                    row["name"]
+                    for row in self.ingestor.fetch_all(
+                        OWNERS_QUERY, {"path": file_path_str}
+                    )
+                ],
+                related=[
+                    related.to_dict()
+                    for related in (
+                        self.expander.expand(qualified_name) if self.expander else []
+                    )
+                ],
             )
+            return fit_snippet(snippet, self.budget) if self.budget else snippet
         except Exception as e:
             logger.error(f"[CodeRetriever] Error: {e}", exc_info=True)
             return CodeSnippet(
@@ -85,6 +123,39 @@ class CodeRetriever:
             )
 
 
+def fit_snippet(snippet: CodeSnippet, budget: TokenBudget) -> CodeSnippet:
+    """
+    The snippet within the token budget: its source first, then related
+    code nearest first, then its docstring, already part of the source.
+    """
+    source = BudgetItem(
+        snippet.qualified_name,
+        snippet.source_code,
+        DIRECT,
+        first_line=snippet.line_start,
+    )
+    related = [
+        BudgetItem(
+            f"{r['relation']} {r['qualified_name']}",
+            r["source"] or r["qualified_name"],
+            NEIGHBOUR,
+            r,
+        )
+        for r in sorted(snippet.related, key=lambda r: r["hops"])
+    ]
+    docstring = BudgetItem("docstring", snippet.docstring or "", DOCS)
+    kept, omitted = budget.fit([source, *related, docstring])
+    kept_ids = {id(item) for item in kept}
+    return snippet.model_copy(
+        update={
+            "source_code": source.text if id(source) in kept_ids else "",
+            "related": [r.value for r in related if id(r) in kept_ids],
+            "docstring": snippet.docstring if id(docstring) in kept_ids else None,
+            "omitted": omitted,
+        }
+    )
+
+
 def create_code_retrieval_tool(code_retriever: CodeRetriever) -> Tool:
     """Factory function to create the code snippet retrieval tool."""
 
@@ -95,5 +166,5 @@ def create_code_retrieval_tool(code_retriever: CodeRetriever) -> Tool:
 
     return Tool(
         function=get_code_snippet,
-        description="Retrieves the source code for a specific function, class, or method using its full qualified name.",
+        description="Retrieves the source code for a specific function, class, or method using its full qualified name, with the users and teams owning its file and, in related, the nearby callers, callees, types and tests with their source. omitted lists what was left out to fit the context budget.",
     )
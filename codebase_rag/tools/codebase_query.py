@@ -1,12 +1,27 @@
+import json
+from typing import Any
+
 from loguru import logger
 from pydantic_ai import Tool
 from rich.console import Console
 from rich.panel import Panel
 from rich.table import Table
 
+from ..cypher_templates import (
+    MAX_ATTEMPTS,
+    TEMPLATES,
+    TemplateResult,
+    TemplateRunner,
+    match_question,
+    retry_prompt,
+    schema_problems,
+)
 from ..graph_updater import MemgraphIngestor
+from ..saved_queries import SavedQuery
 from ..schemas import GraphData
 from ..services.llm import CypherGenerator, LLMGenerationError
+from ..symbol_search import annotate_question, link_entities
+from ..token_budget import BudgetItem, TokenBudget
 
 
 class GraphQueryError(Exception):
@@ -15,10 +30,79 @@ class GraphQueryError(Exception):
     pass
 
 
+def _print_results(console: Console, results: list[dict[str, Any]]) -> None:
+    table = Table(
+        show_header=True,
+        header_style="bold magenta",
+    )
+    headers = results[0].keys()
+    for header in headers:
+        table.add_column(header)
+
+    for row in results:
+        renderable_values = []
+        for value in row.values():
+            if value is None:
+                renderable_values.append("")
+            elif isinstance(value, bool):
+                # Check bool first since bool is a subclass of int in Python
+                renderable_values.append("✓" if value else "✗")
+            elif isinstance(value, int | float):
+                # Let Rich handle number formatting by converting to string
+                renderable_values.append(str(value))
+            else:
+                renderable_values.append(str(value))
+        table.add_row(*renderable_values)
+
+    console.print(
+        Panel(
+            table,
+            title="[bold blue]Cypher Query Results[/bold blue]",
+            expand=False,
+        )
+    )
+
+
+def _template_summary(result: TemplateResult, kind: str = "template") -> str:
+    summary = (
+        f"Retrieved {len(result.results)} item(s) with the {result.template} {kind}."
+    )
+    if result.unresolved:
+        summary += (
+            f" No symbol in the graph matches {', '.join(result.unresolved)}; "
+            "check the name with the symbol search."
+        )
+    return summary
+
+
+def _fit(data: GraphData, budget: TokenBudget | None) -> GraphData:
+    """The rows within the token budget, in order, counting those left out."""
+    if budget is None or not data.results:
+        return data
+    kept, omitted = budget.fit(
+        [
+            BudgetItem(f"row {i}", json.dumps(row, default=str), value=row)
+            for i, row in enumerate(data.results, 1)
+        ]
+    )
+    if not omitted:
+        return data
+    return data.model_copy(
+        update={
+            "results": [item.value for item in kept],
+            "summary": f"{data.summary} {len(omitted)} of {len(data.results)} "
+            "rows were left out to fit the context budget; narrow the question "
+            "to see them.",
+        }
+    )
+
+
 def create_query_tool(
     ingestor: MemgraphIngestor,
     cypher_gen: CypherGenerator,
     console: Console | None = None,
+    runner: TemplateRunner | None = None,
+    budget: TokenBudget | None = None,
 ) -> Tool:
     """
     Factory function that creates the knowledge graph query tool,
@@ -27,6 +111,35 @@ def create_query_tool(
     # Use provided console or create a default one
     if console is None:
         console = Console(width=None, force_terminal=True)
+    # Symbol names for entity linking and templates, loaded on the first question
+    runner = runner or TemplateRunner(ingestor)
+
+    def link(question: str) -> str:
+        links = link_entities(question, runner.symbols)
+        for mention, match in links.items():
+            logger.info(
+                f"[Tool:QueryGraph] Linked '{mention}' to {match.qualified_name}"
+            )
+        return annotate_question(question, links)
+
+    def fall_back(question: str, problems: list[str]) -> GraphData | None:
+        matched = match_question(question)
+        if matched is None:
+            return None
+        name, symbols = matched
+        logger.info(f"[Tool:QueryGraph] Falling back to the {name} template")
+        result = runner.run(name, symbols)
+        if result.results:
+            _print_results(console, result.results)
+        return _fit(
+            GraphData(
+                query_used=result.query,
+                results=result.results,
+                summary=f"Generated queries failed ({'; '.join(problems)}). "
+                + _template_summary(result),
+            ),
+            budget,
+        )
 
     async def query_codebase_knowledge_graph(natural_language_query: str) -> GraphData:
         """
@@ -45,46 +158,56 @@ def create_query_tool(
         """
         logger.info(f"[Tool:QueryGraph] Received NL query: '{natural_language_query}'")
         cypher_query = "N/A"
+        problems: list[str] = []
         try:
-            cypher_query = await cypher_gen.generate(natural_language_query)
-
-            results = ingestor.fetch_all(cypher_query)
-
-            if results:
-                table = Table(
-                    show_header=True,
-                    header_style="bold magenta",
-                )
-                headers = results[0].keys()
-                for header in headers:
-                    table.add_column(header)
-
-                for row in results:
-                    renderable_values = []
-                    for value in row.values():
-                        if value is None:
-                            renderable_values.append("")
-                        elif isinstance(value, bool):
-                            # Check bool first since bool is a subclass of int in Python
-                            renderable_values.append("✓" if value else "✗")
-                        elif isinstance(value, int | float):
-                            # Let Rich handle number formatting by converting to string
-                            renderable_values.append(str(value))
-                        else:
-                            renderable_values.append(str(value))
-                    table.add_row(*renderable_values)
-
-                console.print(
-                    Panel(
-                        table,
-                        title="[bold blue]Cypher Query Results[/bold blue]",
-                        expand=False,
-                    )
+            question = link(natural_language_query)
+            prompt = question
+            for attempt in range(1, MAX_ATTEMPTS + 1):
+                cypher_query = await cypher_gen.generate(prompt)
+                problems = schema_problems(cypher_query)
+                if not problems:
+                    try:
+                        results = ingestor.fetch_all(cypher_query)
+                    except Exception as e:
+                        problems = [f"the database rejected it: {e}"]
+                    else:
+                        if results:
+                            _print_results(console, results)
+                        summary = (
+                            f"Successfully retrieved {len(results)} item(s) "
+                            "from the graph."
+                        )
+                        return _fit(
+                            GraphData(
+                                query_used=cypher_query,
+                                results=results,
+                                summary=summary,
+                            ),
+                            budget,
+                        )
+                logger.warning(
+                    f"[Tool:QueryGraph] Attempt {attempt}/{MAX_ATTEMPTS} failed: "
+                    f"{'; '.join(problems)}"
                 )
+                prompt = retry_prompt(question, cypher_query, problems)
 
-            summary = f"Successfully retrieved {len(results)} item(s) from the graph."
-            return GraphData(query_used=cypher_query, results=results, summary=summary)
+            fallback = fall_back(natural_language_query, problems)
+            if fallback is not None:
+                return fallback
+            return GraphData(
+                query_used=cypher_query,
+                results=[],
+                summary="There was an error querying the database: "
+                + "; ".join(problems),
+            )
         except LLMGenerationError as e:
+            try:
+                fallback = fall_back(natural_language_query, [str(e)])
+            except Exception as template_error:
+                logger.error(f"[Tool:QueryGraph] Template failed: {template_error}")
+                fallback = None
+            if fallback is not None:
+                return fallback
             return GraphData(
                 query_used="N/A",
                 results=[],
@@ -104,3 +227,105 @@ def create_query_tool(
         function=query_codebase_knowledge_graph,
         description="Query the codebase knowledge graph using natural language questions. Ask in plain English about classes, functions, methods, dependencies, or code structure. Examples: 'Find all functions that call each other', 'What classes are in the user module', 'Show me functions with the longest call chains'.",
     )
+
+
+def create_template_tool(
+    runner: TemplateRunner, budget: TokenBudget | None = None
+) -> Tool:
+    """Factory function to create the validated query template tool."""
+
+    async def run_query_template(
+        template: str, symbol: str, target: str | None = None
+    ) -> GraphData:
+        """
+        Answers a common question with a fixed, schema-checked query. Symbols
+        may be short or misspelled names; they are matched to qualified names.
+
+        Templates:
+        - find_callers: functions and methods calling `symbol`
+        - find_callees: functions and methods `symbol` calls
+        - find_implementations: classes implementing or inheriting `symbol`
+        - tests_for_function: tests linked to `symbol` by name or coverage
+        - path_between_symbols: the shortest call chain from `symbol` to `target`
+        """
+        logger.info(f"[Tool:QueryTemplate] {template}({symbol}, {target})")
+        symbols = [symbol] if target is None else [symbol, target]
+        try:
+            result = runner.run(template, symbols)
+        except ValueError as e:
+            return GraphData(query_used="N/A", results=[], summary=str(e))
+        except Exception as e:
+            logger.error(f"[Tool:QueryTemplate] Error: {e}", exc_info=True)
+            return GraphData(
+                query_used=TEMPLATES[template].query,
+                results=[],
+                summary=f"There was an error querying the database: {e}",
+            )
+        return _fit(
+            GraphData(
+                query_used=result.query,
+                results=result.results,
+                summary=_template_summary(result),
+            ),
+            budget,
+        )
+
+    return Tool(
+        function=run_query_template,
+        description="Answer common graph questions with validated queries: find_callers, find_callees, find_implementations, tests_for_function (symbol) and path_between_symbols (symbol, target). Prefer it over free-form queries for these questions.",
+    )
+
+
+def create_saved_query_tool(
+    runner: TemplateRunner,
+    queries: dict[str, SavedQuery],
+    budget: TokenBudget | None = None,
+) -> Tool:
+    """Factory function to create the tool running queries saved in the config."""
+
+    async def run_saved_query(
+        name: str, arguments: dict[str, Any] | None = None
+    ) -> GraphData:
+        """
+        Runs a query the team saved under a name, with its parameters as
+        arguments, e.g. name="tests-for", arguments={"symbol": "Calculator.Divide"}.
+        Symbol parameters may be short or misspelled names.
+        """
+        logger.info(f"[Tool:SavedQuery] {name}({arguments})")
+        saved = queries.get(name)
+        if saved is None:
+            return GraphData(
+                query_used="N/A",
+                results=[],
+                summary=f"No query is saved as '{name}'; choose from "
+                f"{', '.join(queries)}",
+            )
+        try:
+            result = saved.run(runner, arguments or {})
+        except ValueError as e:
+            return GraphData(query_used=saved.query, results=[], summary=str(e))
+        except Exception as e:
+            logger.error(f"[Tool:SavedQuery] Error: {e}", exc_info=True)
+            return GraphData(
+                query_used=saved.query,
+                results=[],
+                summary=f"There was an error querying the database: {e}",
+            )
+        return _fit(
+            GraphData(
+                query_used=result.query,
+                results=result.results,
+                summary=_template_summary(result, "saved query"),
+            ),
+            budget,
+        )
+
+    listed = "; ".join(
+        f"{q.name} ({', '.join(q.parameters) or 'no parameters'})"
+        + (f": {q.description}" if q.description else "")
+        for q in queries.values()
+    )
+    return Tool(
+        function=run_saved_query,
+        description=f"Run a query the team saved for a recurring question: {listed}. Prefer it over free-form queries when one fits.",
+    )
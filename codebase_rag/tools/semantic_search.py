@@ -0,0 +1,57 @@
+import asyncio
+import json
+from typing import Any
+
+from loguru import logger
+from pydantic_ai import Tool
+
+from ..semantic_search import SemanticIndex
+from ..token_budget import DIRECT, NEIGHBOUR, BudgetItem, TokenBudget
+
+
+def create_semantic_search_tool(
+    index: SemanticIndex, budget: TokenBudget | None = None
+) -> Tool:
+    """Factory function to create the semantic code search tool."""
+
+    async def semantic_code_search(
+        question: str, limit: int = 10
+    ) -> list[dict[str, Any]]:
+        """
+        Finds functions, methods and classes by what they do, with the callers
+        and callees of the best matches. Use it for fuzzy questions no name
+        pins down, e.g. "where do we debounce retries?".
+        """
+        logger.info(f"[Tool:SemanticSearch] Searching for: '{question}'")
+        try:
+            # A language model reranker runs its own event loop
+            hits = await asyncio.to_thread(index.search, question, limit)
+        except Exception as e:
+            logger.error(f"[Tool:SemanticSearch] Error: {e}", exc_info=True)
+            return [{"error": str(e)}]
+        if not hits:
+            return [{"error": "No embeddings in the graph; run `embed` first."}]
+        results = [hit.to_dict() for hit in hits]
+        if budget is None:
+            return results
+        # Matches before the callers and callees found through them
+        kept, omitted = budget.fit(
+            [
+                BudgetItem(
+                    hit["qualified_name"],
+                    json.dumps(hit),
+                    DIRECT if hit["relation"] == "match" else NEIGHBOUR,
+                    hit,
+                )
+                for hit in results
+            ]
+        )
+        results = [item.value for item in kept]
+        if omitted:
+            results.append({"omitted": omitted})
+        return results
+
+    return Tool(
+        function=semantic_code_search,
+        description="Search code by meaning rather than name: returns the functions, methods and classes most similar to a description, plus callers and callees of the best matches (relation and via say how each was found). Follow up with get_code_snippet on the qualified names.",
+    )
@@ -10,6 +10,11 @@ def get_node_text(node: Node, source_code: str) -> str:
     return source_code[node.start_byte:node.end_byte]
 
 
+def node_text(node: Node) -> str:
+    """Get the text of a node parsed from bytes, or "" when it has none."""
+    return node.text.decode("utf-8", errors="replace") if node.text is not None else ""
+
+
 def find_nodes_by_type(node: Node, node_type: str) -> list[Node]:
     """Find all nodes of a specific type in the AST."""
     results = []
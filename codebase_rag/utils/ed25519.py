@@ -0,0 +1,89 @@
+"""Ed25519 signature verification (RFC 8032), for Discord interaction requests.
+
+Only verification is needed and speed does not matter at a few requests per
+second, so this follows the RFC's reference arithmetic instead of adding a
+cryptography dependency. Points are kept in extended coordinates (X, Y, Z, T).
+"""
+
+import hashlib
+
+P = 2**255 - 19
+L = 2**252 + 27742317777372353535851937790883648493
+D = -121665 * pow(121666, P - 2, P) % P
+SQRT_M1 = pow(2, (P - 1) // 4, P)
+
+Point = tuple[int, int, int, int]
+
+
+def verify(public_key: bytes, message: bytes, signature: bytes) -> bool:
+    """Whether signature is a valid signature of message by public_key."""
+    if len(public_key) != 32 or len(signature) != 64:
+        return False
+    a = _decompress(public_key)
+    r = _decompress(signature[:32])
+    s = int.from_bytes(signature[32:], "little")
+    if a is None or r is None or s >= L:
+        return False
+    digest = hashlib.sha512(signature[:32] + public_key + message).digest()
+    h = int.from_bytes(digest, "little") % L
+    return _equal(_multiply(s, BASE), _add(r, _multiply(h, a)))
+
+
+def _add(a: Point, b: Point) -> Point:
+    x1, y1, z1, t1 = a
+    x2, y2, z2, t2 = b
+    p = (y1 - x1) * (y2 - x2) % P
+    q = (y1 + x1) * (y2 + x2) % P
+    r = 2 * t1 * t2 * D % P
+    s = 2 * z1 * z2 % P
+    e, f, g, h = q - p, s - r, s + r, q + p
+    return (e * f % P, g * h % P, f * g % P, e * h % P)
+
+
+def _multiply(scalar: int, point: Point) -> Point:
+    result: Point = (0, 1, 1, 0)  # The neutral element
+    while scalar > 0:
+        if scalar & 1:
+            result = _add(result, point)
+        point = _add(point, point)
+        scalar >>= 1
+    return result
+
+
+def _equal(a: Point, b: Point) -> bool:
+    # Compare affine coordinates x/z and y/z without inverting z
+    return (a[0] * b[2] - b[0] * a[2]) % P == 0 and (
+        a[1] * b[2] - b[1] * a[2]
+    ) % P == 0
+
+
+def _recover_x(y: int, sign: int) -> int | None:
+    if y >= P:
+        return None
+    x2 = (y * y - 1) * pow(D * y * y + 1, P - 2, P) % P
+    if x2 == 0:
+        return None if sign else 0
+    x = pow(x2, (P + 3) // 8, P)
+    if (x * x - x2) % P != 0:
+        x = x * SQRT_M1 % P
+    if (x * x - x2) % P != 0:
+        return None
+    if x & 1 != sign:
+        x = P - x
+    return x
+
+
+def _decompress(encoded: bytes) -> Point | None:
+    y = int.from_bytes(encoded, "little")
+    sign = y >> 255
+    y &= (1 << 255) - 1
+    x = _recover_x(y, sign)
+    if x is None:
+        return None
+    return (x, y, 1, x * y % P)
+
+
+_BASE_Y = 4 * pow(5, P - 2, P) % P
+_BASE_X = _recover_x(_BASE_Y, 0)
+assert _BASE_X is not None
+BASE: Point = (_BASE_X, _BASE_Y, 1, _BASE_X * _BASE_Y % P)
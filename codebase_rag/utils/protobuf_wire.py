@@ -0,0 +1,86 @@
+"""Protobuf wire-format reading and writing without a protobuf runtime.
+
+Enough for formats with a small, stable schema (SCIP indexes): callers walk
+fields by number and build messages from encoded fields.
+"""
+
+from collections.abc import Iterable, Iterator
+
+
+def fields(data: bytes) -> Iterator[tuple[int, int | bytes]]:
+    """Yield (field number, value) pairs; varints as ints, others as bytes."""
+    position = 0
+    while position < len(data):
+        key, position = read_varint(data, position)
+        number, wire_type = key >> 3, key & 0x7
+        if wire_type == 0:
+            value, position = read_varint(data, position)
+            yield number, value
+        elif wire_type == 2:
+            length, position = read_varint(data, position)
+            yield number, data[position : position + length]
+            position += length
+        elif wire_type in (1, 5):  # Fixed 64- and 32-bit values
+            size = 8 if wire_type == 1 else 4
+            yield number, data[position : position + size]
+            position += size
+        else:
+            raise ValueError(f"Unsupported protobuf wire type {wire_type}")
+
+
+def read_varint(data: bytes, position: int) -> tuple[int, int]:
+    result = shift = 0
+    while True:
+        if position >= len(data):
+            raise ValueError("Truncated protobuf varint")
+        byte = data[position]
+        position += 1
+        result |= (byte & 0x7F) << shift
+        if not byte & 0x80:
+            return result, position
+        shift += 7
+
+
+def packed_varints(data: bytes) -> Iterator[int]:
+    position = 0
+    while position < len(data):
+        value, position = read_varint(data, position)
+        yield value
+
+
+def string_field(data: bytes, number: int) -> str:
+    for field_number, value in fields(data):
+        if field_number == number and isinstance(value, bytes):
+            return value.decode("utf-8")
+    return ""
+
+
+def int_field(data: bytes, number: int) -> int:
+    for field_number, value in fields(data):
+        if field_number == number and isinstance(value, int):
+            return value
+    return 0
+
+
+def encode_varint(value: int) -> bytes:
+    out = bytearray()
+    while True:
+        byte = value & 0x7F
+        value >>= 7
+        if not value:
+            out.append(byte)
+            return bytes(out)
+        out.append(byte | 0x80)
+
+
+def encode_field(number: int, value: int | str | bytes) -> bytes:
+    """Encode a varint, or a length-delimited string, bytes or message."""
+    if isinstance(value, bool | int):
+        return encode_varint(number << 3) + encode_varint(int(value))
+    if isinstance(value, str):
+        value = value.encode("utf-8")
+    return encode_varint(number << 3 | 2) + encode_varint(len(value)) + value
+
+
+def encode_packed(number: int, values: Iterable[int]) -> bytes:
+    return encode_field(number, b"".join(encode_varint(v) for v in values))
@@ -0,0 +1,27 @@
+"""Helpers for deciding whether a symbol belongs to a module's public API."""
+
+from pathlib import Path
+
+from ..language_config import get_language_config
+
+
+def language_for_path(path: str) -> str | None:
+    """Return the configured language name for a file path, if any."""
+    config = get_language_config(Path(path).suffix)
+    return config.name if config else None
+
+
+def is_exported(name: str, language: str | None, is_static: bool = False) -> bool:
+    """
+    Check whether a symbol name is exported under its language's conventions.
+
+    Go exports capitalized identifiers, C hides ``static`` functions, and the
+    remaining languages treat a leading underscore as private.
+    """
+    if not name:
+        return False
+    if language == "go":
+        return name[0].isupper()
+    if language == "c":
+        return not is_static
+    return not name.startswith("_")
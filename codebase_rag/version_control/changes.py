@@ -0,0 +1,64 @@
+"""Files changed between two commits, or in the working tree, for re-ingestion."""
+
+import subprocess
+from dataclasses import dataclass, field
+from pathlib import Path
+
+
+@dataclass
+class ChangedPaths:
+    """Repository-relative paths touched between two commits."""
+
+    changed: list[str] = field(default_factory=list)  # Added or modified
+    removed: list[str] = field(default_factory=list)
+
+
+def parse_name_status(output: str) -> ChangedPaths:
+    """Paths of `git diff --name-status -M` output, renames split into both sides."""
+    changes = ChangedPaths()
+    for line in output.splitlines():
+        status, *paths = line.split("\t")
+        if status.startswith("R"):
+            changes.removed.append(paths[0])
+            changes.changed.append(paths[1])
+        elif status.startswith("D"):
+            changes.removed.append(paths[0])
+        elif paths:
+            changes.changed.append(paths[-1])
+    return changes
+
+
+def changed_paths(repo_path: Path, base: str, head: str | None = None) -> ChangedPaths:
+    """Paths changed from base to head, or to the working tree without a head.
+
+    Files are parsed from the working tree, so a head other than the commit
+    checked out is refused: its contents are not what would be ingested.
+    The working tree includes staged, unstaged and untracked, not ignored,
+    files.
+    """
+    if head is not None:
+        if _git(repo_path, "rev-parse", f"{head}^{{commit}}") != _git(
+            repo_path, "rev-parse", "HEAD"
+        ):
+            raise ValueError(
+                f"{head} is not checked out in {repo_path}; check it out first, "
+                "since changed files are parsed from the working tree"
+            )
+        return parse_name_status(
+            _git(repo_path, "diff", "--name-status", "-M", base, head)
+        )
+
+    changes = parse_name_status(_git(repo_path, "diff", "--name-status", "-M", base))
+    untracked = _git(repo_path, "ls-files", "--others", "--exclude-standard")
+    changes.changed += [line for line in untracked.splitlines() if line]
+    return changes
+
+
+def _git(repo_path: Path, *args: str) -> str:
+    result = subprocess.run(
+        ["git", "-C", str(repo_path), *args],
+        capture_output=True,
+        text=True,
+        check=True,
+    )
+    return result.stdout.strip()
@@ -1,5 +1,6 @@
 """Git repository analysis for version control integration."""
 
+import re
 import subprocess
 from dataclasses import dataclass
 from datetime import datetime
@@ -55,6 +56,18 @@ class FileHistory:
     contributors: list[tuple[str, int]]  # List of (author, commit_count)
 
 
+@dataclass(frozen=True)
+class DiffHunk:
+    """Lines a commit changed in a file, as in the @@ header of a unified diff."""
+    old_start: int
+    old_count: int
+    new_start: int
+    new_count: int
+
+
+HUNK_HEADER = re.compile(r"^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@")
+
+
 class GitAnalyzer:
     """Analyzes Git repository history and blame information."""
 
@@ -402,3 +415,78 @@ class GitAnalyzer:
                 logger.error(f"Failed to get contributors: {e}")
 
         return sorted(contributors.items(), key=lambda x: x[1], reverse=True)
+
+    def get_file_churn(
+        self, since: str | None = None, max_commits: int | None = None
+    ) -> dict[str, int]:
+        """Count how many commits modified each file in the repository."""
+        churn: dict[str, int] = {}
+
+        try:
+            cmd = ["git", "-C", str(self.repo_path), "log", "--format=", "--name-only"]
+            if since:
+                cmd.append(f"--since={since}")
+            if max_commits:
+                cmd.append(f"--max-count={max_commits}")
+            result = subprocess.run(cmd, capture_output=True, text=True, check=True)
+
+            for line in result.stdout.split('\n'):
+                path = line.strip()
+                if path:
+                    churn[path] = churn.get(path, 0) + 1
+
+        except subprocess.CalledProcessError as e:
+            logger.error(f"Git log failed while computing churn: {e}")
+        except Exception as e:
+            logger.error(f"Failed to compute file churn: {e}")
+
+        return churn
+
+    def get_diff_hunks(
+        self, since: str | None = None, max_commits: int | None = None
+    ) -> list[dict[str, list[DiffHunk]]]:
+        """The lines each commit changed in each file, newest commit first."""
+        history: list[dict[str, list[DiffHunk]]] = []
+
+        try:
+            cmd = ["git", "-C", str(self.repo_path), "log", "--format=%x00", "-p"]
+            cmd += ["--unified=0", "--no-renames", "--no-color", "--no-ext-diff"]
+            if since:
+                cmd.append(f"--since={since}")
+            if max_commits:
+                cmd.append(f"--max-count={max_commits}")
+            result = subprocess.run(
+                cmd, capture_output=True, text=True, errors="replace", check=True
+            )
+
+            path = None
+            # Changed lines of the current hunk still to skip
+            remaining = 0
+            for line in result.stdout.split('\n'):
+                if remaining:
+                    # "\ No newline at end of file" is not one of them
+                    if not line.startswith("\\"):
+                        remaining -= 1
+                    continue
+                if line == "\x00":
+                    history.append({})
+                    path = None
+                elif line.startswith("+++ "):
+                    # Deleted files have no lines left to attribute changes to
+                    path = line[6:] if line.startswith("+++ b/") else None
+                elif path and (match := HUNK_HEADER.match(line)):
+                    old_start, old_count, new_start, new_count = (
+                        int(group) if group is not None else 1
+                        for group in match.groups()
+                    )
+                    history[-1].setdefault(path, []).append(
+                        DiffHunk(old_start, old_count, new_start, new_count)
+                    )
+                    remaining = old_count + new_count
+
+        except subprocess.CalledProcessError as e:
+            logger.error(f"Git log failed while reading diff hunks: {e}")
+        except Exception as e:
+            logger.error(f"Failed to read diff hunks: {e}")
+
+        return history
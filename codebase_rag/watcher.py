@@ -0,0 +1,131 @@
+"""Keeping the graph in step with a working copy while files are edited.
+
+Editors rarely save a file in one event: a write, a rename over the
+original, a chmod, sometimes a delete and create. So changes are collected
+until the tree has been quiet for a moment, the debounce, and then applied
+together as an incremental update. Whether a path was changed or removed is
+decided when the batch is applied, by whether the file still exists, which
+also sorts out renames and files created then deleted in one burst. A failed
+update keeps its paths for the next batch, so a database restart loses
+nothing.
+"""
+
+import threading
+import time
+from collections.abc import Callable
+from pathlib import Path
+from typing import Any
+
+from loguru import logger
+from watchdog.events import FileSystemEventHandler
+from watchdog.observers import Observer
+
+from .language_config import get_language_config
+from .server.webhooks import IncrementalUpdater
+
+DEFAULT_DEBOUNCE_SECONDS = 0.5
+
+
+class ChangeBatcher:
+    """Paths changed under a repository, released once the edits settle."""
+
+    def __init__(
+        self,
+        repo_path: Path,
+        ignore_dirs: set[str],
+        debounce: float = DEFAULT_DEBOUNCE_SECONDS,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        self.repo_path = repo_path
+        self.ignore_dirs = ignore_dirs
+        self.debounce = debounce
+        self.clock = clock
+        self._paths: set[str] = set()
+        self._last_change = 0.0
+        self._lock = threading.Lock()
+
+    def record(self, path: str | Path) -> None:
+        """Note a source file that was created, modified, moved or deleted."""
+        try:
+            relative = Path(path).resolve().relative_to(self.repo_path)
+        except ValueError:
+            return
+        if self.ignore_dirs.intersection(relative.parts[:-1]):
+            return
+        if get_language_config(relative.suffix) is None:
+            return  # Editor swap files, build output and the like
+        with self._lock:
+            self._paths.add(relative.as_posix())
+            self._last_change = self.clock()
+
+    def take(self) -> tuple[list[str], list[str]] | None:
+        """The changed and removed paths once quiet for the debounce, else None."""
+        with self._lock:
+            if not self._paths or self.clock() - self._last_change < self.debounce:
+                return None
+            paths, self._paths = sorted(self._paths), set()
+        changed = [p for p in paths if (self.repo_path / p).is_file()]
+        removed = [p for p in paths if p not in changed]
+        return changed, removed
+
+    def put_back(self, paths: list[str]) -> None:
+        with self._lock:
+            self._paths.update(paths)
+
+
+class _EventHandler(FileSystemEventHandler):
+    def __init__(self, batcher: ChangeBatcher):
+        self.batcher = batcher
+
+    def on_any_event(self, event: Any) -> None:
+        if event.is_directory:
+            return
+        self.batcher.record(event.src_path)
+        # Moves and renames: the old path is gone, the new one changed
+        if getattr(event, "dest_path", ""):
+            self.batcher.record(event.dest_path)
+
+
+def apply_changes(
+    batcher: ChangeBatcher,
+    updater: IncrementalUpdater,
+    on_update: Callable[[list[str], list[str]], None] | None = None,
+) -> bool:
+    """Apply the settled changes, if any; whether an update was made."""
+    batch = batcher.take()
+    if batch is None:
+        return False
+    changed, removed = batch
+    try:
+        updater.update_files(changed, removed)
+    except Exception as e:
+        logger.error(f"Graph update failed, retrying with the next change: {e}")
+        batcher.put_back([*changed, *removed])
+        return False
+    if on_update:
+        on_update(changed, removed)
+    return True
+
+
+def watch_repository(
+    repo_path: Path,
+    updater: IncrementalUpdater,
+    ignore_dirs: set[str],
+    stop: threading.Event,
+    debounce: float = DEFAULT_DEBOUNCE_SECONDS,
+    on_update: Callable[[list[str], list[str]], None] | None = None,
+) -> None:
+    """Update the graph as files under repo_path change, until stop is set."""
+    repo_path = repo_path.resolve()
+    batcher = ChangeBatcher(repo_path, ignore_dirs, debounce)
+    observer = Observer()
+    observer.schedule(_EventHandler(batcher), str(repo_path), recursive=True)
+    observer.start()
+    logger.info(f"Watching {repo_path} for changes")
+    try:
+        # Checked a few times per debounce so updates follow the last edit closely
+        while not stop.wait(max(debounce / 4, 0.05)):
+            apply_changes(batcher, updater, on_update)
+    finally:
+        observer.stop()
+        observer.join()
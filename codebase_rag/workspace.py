@@ -0,0 +1,234 @@
+"""Setting up a repository for ingestion: config file, .ragignore and schema.
+
+`init` looks at what is in the repository before writing anything, so the
+generated files start from the languages and build directories actually
+found there rather than from a generic template.
+"""
+
+import fnmatch
+import os
+import re
+from collections import Counter
+from dataclasses import dataclass
+from pathlib import Path, PurePosixPath
+from typing import Any
+
+from .language_config import get_language_config
+
+RAGIGNORE = ".ragignore"
+GITIGNORE = ".gitignore"
+CONFIG_FILE_NAME = ".cgr.toml"
+
+# Directories never worth walking while detecting languages
+SCAN_SKIP_DIRS = {".git", "node_modules", "venv", ".venv", "__pycache__"}
+# Generated or third-party code that is often committed next to the sources
+IGNORE_CANDIDATES = (
+    "vendor",
+    "third_party",
+    "target",
+    "coverage",
+    "out",
+    "bin",
+    "obj",
+    ".next",
+    ".nuxt",
+    ".terraform",
+    "generated",
+)
+
+
+def read_ragignore(repo_path: Path) -> set[str]:
+    """
+    Directory names listed in the repository's .ragignore, one per line.
+    Blank lines and lines starting with # are skipped; a trailing / is allowed.
+    """
+    path = repo_path / RAGIGNORE
+    if not path.is_file():
+        return set()
+    names = set()
+    for line in path.read_text(encoding="utf-8").splitlines():
+        line = line.strip()
+        if line and not line.startswith("#"):
+            names.add(line.rstrip("/"))
+    return names
+
+
+def matches_glob(relative_path: str, pattern: str) -> bool:
+    """
+    Whether a repository path matches a glob of INCLUDE_PATHS or
+    EXCLUDE_PATHS. A glob without a slash matches the file name at any
+    depth, one ending in a slash everything in the directory, * also matches
+    across slashes as in fnmatch, and a leading **/ matches no directory at
+    all too.
+    """
+    path = PurePosixPath(relative_path).as_posix()
+    pattern = pattern.removeprefix("./")
+    if pattern.endswith("/"):
+        pattern += "**"
+    if "/" not in pattern:
+        return fnmatch.fnmatchcase(PurePosixPath(path).name, pattern)
+    if fnmatch.fnmatchcase(path, pattern):
+        return True
+    return pattern.startswith("**/") and fnmatch.fnmatchcase(path, pattern[3:])
+
+
+@dataclass
+class IgnoreRule:
+    """A line of a .gitignore, matched against paths below its directory."""
+
+    regex: re.Pattern
+    negated: bool = False
+    directory_only: bool = False
+
+
+def parse_ignore_line(line: str) -> IgnoreRule | None:
+    """A .gitignore line as a rule; None for blank lines and comments."""
+    line = line.rstrip("\n").rstrip()
+    if not line or line.startswith("#"):
+        return None
+    negated = line.startswith("!")
+    if negated or line.startswith("\\"):
+        line = line[1:]
+    directory_only = line.endswith("/")
+    body = line.strip("/")
+    if not body:
+        return None
+    # A slash before the end anchors the pattern to the .gitignore's directory
+    anchored = "/" in line.rstrip("/")
+    regex = ""
+    i = 0
+    while i < len(body):
+        if body.startswith("**/", i):
+            regex += "(?:.*/)?"
+            i += 3
+        elif body.startswith("/**", i) and i + 3 == len(body):
+            regex += "/.*"
+            i += 3
+        elif body.startswith("**", i):
+            regex += ".*"
+            i += 2
+        elif body[i] == "*":
+            regex += "[^/]*"
+            i += 1
+        elif body[i] == "?":
+            regex += "[^/]"
+            i += 1
+        elif body[i] == "[" and "]" in body[i + 2 :]:
+            end = body.index("]", i + 2)
+            characters = body[i + 1 : end]
+            if characters.startswith("!"):
+                characters = "^" + characters[1:]
+            regex += f"[{characters}]"
+            i = end + 1
+        else:
+            regex += re.escape(body[i])
+            i += 1
+    if not anchored:
+        regex = "(?:.*/)?" + regex
+    return IgnoreRule(re.compile(regex, re.DOTALL), negated, directory_only)
+
+
+class GitIgnore:
+    """
+    The .gitignore files of a repository and its .git/info/exclude. Each
+    file applies below its own directory and, as in Git, the last matching
+    line wins, a ! line re-includes a path, and nothing inside an ignored
+    directory can be re-included. Files are read when first needed.
+    """
+
+    def __init__(self, repo_path: Path):
+        self.repo_path = repo_path
+        self._rules: dict[str, list[IgnoreRule]] = {}
+
+    def ignored(self, relative_path: str, is_dir: bool = False) -> bool:
+        parts = PurePosixPath(relative_path).parts
+        for depth in range(1, len(parts)):
+            if self._matches(parts[:depth], True):
+                return True
+        return bool(parts) and self._matches(parts, is_dir)
+
+    def _matches(self, parts: tuple[str, ...], is_dir: bool) -> bool:
+        ignored = False
+        for depth in range(len(parts)):
+            rest = "/".join(parts[depth:])
+            for rule in self._rules_in("/".join(parts[:depth])):
+                if rule.directory_only and not is_dir:
+                    continue
+                if rule.regex.fullmatch(rest):
+                    ignored = not rule.negated
+        return ignored
+
+    def _rules_in(self, directory: str) -> list[IgnoreRule]:
+        if directory not in self._rules:
+            paths = [self.repo_path / directory / GITIGNORE]
+            if not directory:
+                paths.insert(0, self.repo_path / ".git" / "info" / "exclude")
+            rules = []
+            for path in paths:
+                try:
+                    text = path.read_text(encoding="utf-8", errors="replace")
+                except OSError:
+                    continue
+                rules += filter(None, map(parse_ignore_line, text.splitlines()))
+            self._rules[directory] = rules
+        return self._rules[directory]
+
+
+def detect_languages(repo_path: Path) -> dict[str, int]:
+    """Parseable files per language, most common first."""
+    counts: Counter[str] = Counter()
+    skip = SCAN_SKIP_DIRS | read_ragignore(repo_path)
+    for _, dirs, files in os.walk(repo_path):
+        dirs[:] = [d for d in dirs if d not in skip]
+        for name in files:
+            lang_config = get_language_config(Path(name).suffix)
+            if lang_config:
+                counts[lang_config.name] += 1
+    return dict(counts.most_common())
+
+
+def ignore_candidates(repo_path: Path) -> list[str]:
+    """Directories from IGNORE_CANDIDATES present anywhere in the repository."""
+    found = set()
+    for _, dirs, _ in os.walk(repo_path):
+        found.update(d for d in dirs if d in IGNORE_CANDIDATES)
+        dirs[:] = [d for d in dirs if d not in SCAN_SKIP_DIRS and d not in found]
+    return [name for name in IGNORE_CANDIDATES if name in found]
+
+
+def render_config(languages: dict[str, int], host: str, port: int) -> str:
+    """A starter .cgr.toml for the repository it is written to."""
+    found = ", ".join(f"{name} ({count})" for name, count in languages.items())
+    return (
+        "# Written by `init`; run `config validate` after editing.\n"
+        "# Environment variables and .env still override these values.\n"
+        "\n"
+        "[settings]\n"
+        'target_repo_path = "."\n'
+        f'memgraph_host = "{host}"\n'
+        f"memgraph_port = {port}\n"
+        f"# Files found per language: {found or 'none'}\n"
+        '# disabled_languages = ""\n'
+        '# language_extensions = ".pyi=python"\n'
+        '# include_paths = ["src/**"]\n'
+        '# exclude_paths = ["**/generated/**", "*_pb2.py"]\n'
+        '# disabled_analyses = ["data-flow", "security"]\n'
+    )
+
+
+def render_ragignore(directories: list[str]) -> str:
+    lines = [
+        "# Directories left out of the graph, one name per line. Matches the",
+        "# name at any depth; .git, node_modules and virtualenvs are always",
+        "# skipped.",
+        *directories,
+    ]
+    return "\n".join(lines) + "\n"
+
+
+def provision_schema(ingestor: Any) -> None:
+    """Create the uniqueness constraints and lookup indexes ingestion relies on."""
+    from .graph_indexing import GraphIndexManager
+
+    ingestor.ensure_constraints()
+    GraphIndexManager(ingestor, ingestor.backend).create_indexes()
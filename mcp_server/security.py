@@ -32,6 +32,10 @@ class RateLimitConfig:
             "export_graph": 20,  # 20 per hour
             "get_code_metrics": 50,  # 50 per hour
             "analyze_git_history": 30,  # 30 per hour
+            "find_symbol": 200,  # 200 per hour
+            "get_callers": 200,  # 200 per hour
+            "get_tests_for": 200,  # 200 per hour
+            "run_cypher": 100,  # 100 per hour
         }
     )
 
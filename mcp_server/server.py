@@ -25,10 +25,17 @@ from mcp.types import EmbeddedResource, ImageContent, TextContent
 
 from codebase_rag.analysis.data_flow import DataFlowAnalyzer
 from codebase_rag.analysis.security import SecurityAnalyzer
-from codebase_rag.analysis.test_coverage import TestCoverageAnalyzer
 from codebase_rag.analysis.vcs import VCSAnalyzer
-from codebase_rag.graph_loader import export_graph_to_file, load_graph
-from codebase_rag.main import parse_and_store_codebase
+from codebase_rag.config import settings
+from codebase_rag.graph_loader import load_graph
+from codebase_rag.graph_updater import GraphUpdater
+from codebase_rag.mcp.tools import (
+    DEFAULT_CALLER_DEPTH,
+    DEFAULT_TEST_DEPTH,
+    GraphTools,
+)
+from codebase_rag.parser_loader import load_parsers
+from codebase_rag.services.graph_service import MemgraphIngestor
 
 from .security import SecureMCPServer, rate_limit, validate_inputs
 
@@ -36,6 +43,44 @@ logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger(__name__)
 
 
+def _memgraph() -> MemgraphIngestor:
+    return MemgraphIngestor(host=settings.MEMGRAPH_HOST, port=settings.MEMGRAPH_PORT)
+
+
+def parse_and_store_codebase(
+    repo_path: str,
+    clean: bool = False,
+    parallel: bool = True,
+    folder_filter: str | None = None,
+) -> None:
+    """Ingest a repository into Memgraph, as the start command does."""
+    parsers, queries = load_parsers()
+    with _memgraph() as ingestor:
+        if clean:
+            ingestor.clean_database()
+        GraphUpdater(
+            ingestor,
+            Path(repo_path),
+            parsers,
+            queries,
+            parallel=parallel,
+            folder_filter=folder_filter,
+        ).run()
+
+
+def export_graph_to_file(output_path: str) -> None:
+    """Write the whole graph in Memgraph to a JSON file."""
+    with _memgraph() as ingestor:
+        graph_data = ingestor.export_graph_to_dict()
+    Path(output_path).write_text(json.dumps(graph_data, indent=2), encoding="utf-8")
+
+
+QUALIFIED_NAME = {
+    "type": "string",
+    "description": "Qualified name of a function or method, e.g. shop.cart.total",
+}
+
+
 @dataclass
 class CodeGraphContext:
     """Context for the current codebase analysis session."""
@@ -62,10 +107,12 @@ class CodeGraphContext:
 class CodeGraphMCPServer(SecureMCPServer):
     """MCP Server for Graph-Code RAG System with security features."""
 
-    def __init__(self):
+    def __init__(self, ingestor: Any | None = None):
         super().__init__()
         self.server = Server("code-graph-rag")
         self.context = CodeGraphContext()
+        # The graph tools answer from Memgraph, without loading a repository
+        self.graph_tools = GraphTools(ingestor) if ingestor is not None else None
         self._setup_handlers()
 
     def _setup_handlers(self):
@@ -334,6 +381,75 @@ class CodeGraphMCPServer(SecureMCPServer):
                         },
                     },
                 ),
+                Tool(
+                    name="find_symbol",
+                    description="Find functions, methods and classes by name. "
+                    "Matching ignores case and naming style and forgives small "
+                    "typos; a dotted name matches the end of qualified names.",
+                    inputSchema={
+                        "type": "object",
+                        "properties": {
+                            "name": {
+                                "type": "string",
+                                "description": "e.g. Cart.total",
+                            },
+                            "limit": {"type": "integer", "default": 10},
+                        },
+                        "required": ["name"],
+                    },
+                ),
+                Tool(
+                    name="get_callers",
+                    description="List the functions and methods calling a "
+                    "function, with how many calls away each is.",
+                    inputSchema={
+                        "type": "object",
+                        "properties": {
+                            "qualified_name": QUALIFIED_NAME,
+                            "depth": {
+                                "type": "integer",
+                                "description": "Levels of callers to follow",
+                                "default": DEFAULT_CALLER_DEPTH,
+                            },
+                        },
+                        "required": ["qualified_name"],
+                    },
+                ),
+                Tool(
+                    name="get_tests_for",
+                    description="Find the tests exercising a function or method, "
+                    "directly or through its callers, closest first.",
+                    inputSchema={
+                        "type": "object",
+                        "properties": {
+                            "qualified_name": QUALIFIED_NAME,
+                            "depth": {
+                                "type": "integer",
+                                "description": "Levels of callers whose tests count",
+                                "default": DEFAULT_TEST_DEPTH,
+                            },
+                        },
+                        "required": ["qualified_name"],
+                    },
+                ),
+                Tool(
+                    name="run_cypher",
+                    description="Run a read-only Cypher query against the code "
+                    "graph. Nodes: Project, Package, Folder, File, Module, Class, "
+                    "Function, Method; edges include DEFINES, DEFINES_METHOD, "
+                    "CALLS, IMPORTS and INHERITS_FROM.",
+                    inputSchema={
+                        "type": "object",
+                        "properties": {
+                            "cypher": {"type": "string"},
+                            "params": {
+                                "type": "object",
+                                "description": "Query parameters",
+                            },
+                        },
+                        "required": ["cypher"],
+                    },
+                ),
             ]
 
         @self.server.call_tool()
@@ -362,6 +478,14 @@ class CodeGraphMCPServer(SecureMCPServer):
                     result = await self._get_code_metrics(arguments or {})
                 elif name == "analyze_git_history":
                     result = await self._analyze_git_history(arguments or {})
+                elif name == "find_symbol":
+                    result = await self._find_symbol(arguments or {})
+                elif name == "get_callers":
+                    result = await self._get_callers(arguments or {})
+                elif name == "get_tests_for":
+                    result = await self._get_tests_for(arguments or {})
+                elif name == "run_cypher":
+                    result = await self._run_cypher(arguments or {})
                 else:
                     raise ValueError(f"Unknown tool: {name}")
 
@@ -497,23 +621,26 @@ class CodeGraphMCPServer(SecureMCPServer):
         if not self.context.graph_data:
             raise ValueError("No graph loaded. Use load_repository first.")
 
-        # Get or create test coverage analyzer
-        if "test_coverage" not in self.context.active_analyzers:
-            self.context.active_analyzers["test_coverage"] = TestCoverageAnalyzer(
-                self.context.graph_data
-            )
-
-        analyzer = self.context.active_analyzers["test_coverage"]
-        untested = analyzer.find_untested_functions()
-        coverage = analyzer.calculate_coverage()
+        nodes = self.context.graph_data.get("nodes", [])
+        functions = {
+            node["node_id"]: node["properties"].get("qualified_name")
+            for node in nodes
+            if {"Function", "Method"} & set(node.get("labels", []))
+        }
+        tests = [node for node in nodes if "TestCase" in node.get("labels", [])]
+        tested = {
+            rel["to_id"]
+            for rel in self.context.graph_data.get("relationships", [])
+            if rel["type"] == "TESTS" and rel["to_id"] in functions
+        }
+        untested = [qn for node_id, qn in functions.items() if node_id not in tested]
 
         return {
-            "overall_coverage": coverage,
+            "overall_coverage": (
+                round(len(tested) / len(functions) * 100, 1) if functions else 0
+            ),
             "untested_functions": len(untested),
-            "test_statistics": {
-                "total_tests": analyzer.get_test_count(),
-                "total_assertions": analyzer.get_assertion_count(),
-            },
+            "test_statistics": {"total_tests": len(tests)},
             "untested_samples": untested[:10],  # Sample of untested functions
         }
 
@@ -653,6 +780,36 @@ class CodeGraphMCPServer(SecureMCPServer):
             ],
         }
 
+    def _graph(self) -> GraphTools:
+        if self.graph_tools is None:
+            raise ValueError("Not connected to the graph database.")
+        return self.graph_tools
+
+    @rate_limit("find_symbol")
+    async def _find_symbol(self, args: dict[str, Any]) -> dict[str, Any]:
+        """Find symbols by name in the graph."""
+        return self._graph().find_symbol(args["name"], args.get("limit", 10))
+
+    @rate_limit("get_callers")
+    async def _get_callers(self, args: dict[str, Any]) -> dict[str, Any]:
+        """List the callers of a function."""
+        return self._graph().get_callers(
+            args["qualified_name"], args.get("depth", DEFAULT_CALLER_DEPTH)
+        )
+
+    @rate_limit("get_tests_for")
+    async def _get_tests_for(self, args: dict[str, Any]) -> dict[str, Any]:
+        """Find the tests reaching a function."""
+        return self._graph().get_tests_for(
+            args["qualified_name"], args.get("depth", DEFAULT_TEST_DEPTH)
+        )
+
+    @rate_limit("run_cypher")
+    @validate_inputs(query_params={"cypher"})
+    async def _run_cypher(self, args: dict[str, Any]) -> dict[str, Any]:
+        """Run a read-only Cypher query."""
+        return self._graph().run_cypher(args["cypher"], args.get("params"))
+
     async def run(self):
         """Run the MCP server."""
         async with stdio_server() as (read_stream, write_stream):
@@ -674,8 +831,8 @@ class CodeGraphMCPServer(SecureMCPServer):
 
 async def main():
     """Main entry point."""
-    server = CodeGraphMCPServer()
-    await server.run()
+    with _memgraph() as ingestor:
+        await CodeGraphMCPServer(ingestor).run()
 
 
 if __name__ == "__main__":
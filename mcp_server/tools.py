@@ -8,7 +8,28 @@ to interact with codebases through the MCP protocol.
 from dataclasses import dataclass
 from typing import Any
 
-from codebase_rag.query_templates import QUERY_TEMPLATES
+# Cypher for the questions generate_cypher_query recognizes
+QUERY_TEMPLATES = {
+    "circular_dependencies": {
+        "cypher": "MATCH (m1:Module)-[:CIRCULAR_DEPENDENCY]->(m2:Module) "
+        "RETURN m1.qualified_name AS module1, m2.qualified_name AS module2"
+    },
+    "complex_functions": {
+        "cypher": "MATCH (f:Function|Method) WHERE f.cyclomatic_complexity > 10 "
+        "RETURN f.qualified_name AS name, f.cyclomatic_complexity AS complexity "
+        "ORDER BY complexity DESC LIMIT 25"
+    },
+    "test_coverage": {
+        "cypher": "MATCH (code:Function|Method) "
+        "WHERE NOT (code)<-[:TESTS]-(:TestCase) "
+        "RETURN code.qualified_name AS untested_code, labels(code)[0] AS type"
+    },
+    "security_vulnerabilities": {
+        "cypher": "MATCH (v:Vulnerability) WHERE v.severity IN ['HIGH', 'CRITICAL'] "
+        "RETURN v.type AS vulnerability_type, v.severity AS severity, "
+        "v.description AS description ORDER BY v.severity DESC"
+    },
+}
 
 
 @dataclass
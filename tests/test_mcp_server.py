@@ -287,6 +287,24 @@ class TestCodeGraphMCPServer:
             assert "top_contributors" in result
             assert "recent_commits" in result
 
+    async def test_graph_tools(self):
+        """Test the graph tools answering from the connected graph."""
+        ingestor = Mock()
+        ingestor.fetch_all.return_value = [{"n": 1}]
+        server = CodeGraphMCPServer(ingestor)
+
+        result = await server._run_cypher({"cypher": "MATCH (n) RETURN 1 AS n"})
+
+        assert result == {"rows": [{"n": 1}], "truncated": False}
+        ingestor.fetch_all.assert_called_once_with("MATCH (n) RETURN 1 AS n", {})
+
+    async def test_graph_tools_need_a_connection(self):
+        """Test the graph tools without a graph database."""
+        server = CodeGraphMCPServer()
+
+        with pytest.raises(ValueError, match="Not connected"):
+            await server._get_callers({"qualified_name": "shop.cart.total"})
+
 
 if __name__ == "__main__":
     pytest.main([__file__, "-v"])